@@ -0,0 +1,129 @@
+// Package artifact describes files that belong to a conversation - uploaded
+// attachments, generated charts, and export files - as typed references.
+//
+// A Ref carries an artifact's metadata, never its content. Exports, the
+// client protocol and support tooling all present artifacts as Refs, with a
+// signed, expiring download URL minted on demand by a Resolver, so nothing
+// inlines file data and no link outlives its TTL.
+package artifact
+
+import (
+	"strings"
+	"time"
+)
+
+// Kind is the kind of artifact.
+type Kind string
+
+// Artifact kinds.
+const (
+	KindAttachment Kind = "attachment" // uploaded by the user, e.g. a receipt photo
+	KindChart      Kind = "chart"      // generated by the agent
+	KindExport     Kind = "export"     // an export file, e.g. of a conversation or transactions
+)
+
+// Status says whether an artifact can be downloaded.
+type Status string
+
+// Artifact statuses, set by Resolver.Resolve.
+const (
+	// StatusAvailable artifacts have a signed download URL.
+	StatusAvailable Status = "available"
+
+	// StatusNotRetained artifacts were ephemeral: used for the conversation
+	// and then discarded, so only their metadata remains.
+	StatusNotRetained Status = "not_retained"
+
+	// StatusUnavailable artifacts were retained, but no URL can be minted,
+	// e.g. because no Resolver is configured.
+	StatusUnavailable Status = "unavailable"
+)
+
+// Ref is a reference to an artifact.
+type Ref struct {
+	// ID uniquely identifies the artifact.
+	ID string `json:"id"`
+
+	// Kind is what sort of artifact this is.
+	Kind Kind `json:"kind"`
+
+	// Name is a human-readable name, e.g. a file name or chart title.
+	Name string `json:"name,omitempty"`
+
+	// MediaType is the artifact's MIME type, e.g. image/svg+xml.
+	MediaType string `json:"media_type"`
+
+	// Size is the artifact's size in bytes.
+	Size int64 `json:"size"`
+
+	// CreatedAt is when the artifact was created or uploaded.
+	CreatedAt time.Time `json:"created_at"`
+
+	// Ephemeral artifacts are not retained after use, per the attachment
+	// policy, and can never be downloaded again.
+	Ephemeral bool `json:"ephemeral,omitempty"`
+
+	// Location is where a retained artifact is stored, relative to the
+	// Resolver's base URL. It is cleared by Resolve, so it is never shown
+	// to clients.
+	Location string `json:"location,omitempty"`
+
+	// Status is set by Resolve.
+	Status Status `json:"status,omitempty"`
+
+	// URL is a signed download link, set by Resolve for available artifacts.
+	URL string `json:"url,omitempty"`
+
+	// URLExpiresAt is when URL stops working.
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+}
+
+// Resolver mints download URLs for artifacts served by the static-file
+// handler at BaseURL, which should be wrapped with Signer.Protect.
+type Resolver struct {
+	// BaseURL is where retained artifacts are served,
+	// e.g. https://agent.example.com/artifacts/.
+	BaseURL string
+
+	// Signer signs the URLs.
+	Signer *Signer
+}
+
+// Resolve returns ref ready to present: with a status, a signed URL if the
+// artifact is available, and no storage location. A nil Resolver marks
+// retained artifacts unavailable.
+func (r *Resolver) Resolve(ref Ref) Ref {
+	location := ref.Location
+	ref.Location = ""
+	ref.URL = ""
+	ref.URLExpiresAt = nil
+
+	switch {
+	case ref.Ephemeral:
+		ref.Status = StatusNotRetained
+	case r == nil || r.Signer == nil || location == "":
+		ref.Status = StatusUnavailable
+	default:
+		url, expires, err := r.Signer.Sign(strings.TrimSuffix(r.BaseURL, "/") + "/" + strings.TrimPrefix(location, "/"))
+		if err != nil {
+			ref.Status = StatusUnavailable
+			return ref
+		}
+		ref.Status = StatusAvailable
+		ref.URL = url
+		ref.URLExpiresAt = &expires
+	}
+	return ref
+}
+
+// ResolveAll resolves each ref.
+func (r *Resolver) ResolveAll(refs []Ref) []Ref {
+	if len(refs) == 0 {
+		return nil
+	}
+	resolved := make([]Ref, len(refs))
+	for i, ref := range refs {
+		resolved[i] = r.Resolve(ref)
+	}
+	return resolved
+}
@@ -0,0 +1,176 @@
+package artifact
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func fixedSigner(now *time.Time) *Signer {
+	s := NewSigner([]byte("test-secret"), 10*time.Minute)
+	s.Now = func() time.Time { return *now }
+	return s
+}
+
+func TestSigner(t *testing.T) {
+	now := epoch
+	signer := fixedSigner(&now)
+
+	signed, expires, err := signer.Sign("https://agent.example.com/artifacts/chart-1.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expires.Equal(epoch.Add(10 * time.Minute)) {
+		t.Errorf("expires = %v, want %v", expires, epoch.Add(10*time.Minute))
+	}
+
+	tests := []struct {
+		name    string
+		url     func() string
+		at      time.Duration
+		wantErr error
+	}{
+		{name: "valid", url: func() string { return signed }},
+		{name: "valid until expiry", url: func() string { return signed }, at: 10*time.Minute - time.Second},
+		{name: "expired", url: func() string { return signed }, at: 10 * time.Minute, wantErr: ErrURLExpired},
+		{name: "other file", url: func() string { return strings.Replace(signed, "chart-1", "chart-2", 1) }, wantErr: ErrURLSignature},
+		{name: "extended expiry", url: func() string {
+			u, _ := url.Parse(signed)
+			q := u.Query()
+			q.Set("expires", "9999999999")
+			u.RawQuery = q.Encode()
+			return u.String()
+		}, wantErr: ErrURLSignature},
+		{name: "tampered signature", url: func() string {
+			u, _ := url.Parse(signed)
+			q := u.Query()
+			sig := []byte(q.Get("sig"))
+			sig[0] ^= 1
+			q.Set("sig", string(sig))
+			u.RawQuery = q.Encode()
+			return u.String()
+		}, wantErr: ErrURLSignature},
+		{name: "unsigned", url: func() string { return "https://agent.example.com/artifacts/chart-1.svg" }, wantErr: ErrURLSignature},
+		{name: "other secret", url: func() string {
+			other := NewSigner([]byte("other-secret"), 10*time.Minute)
+			other.Now = signer.Now
+			u, _, _ := other.Sign("https://agent.example.com/artifacts/chart-1.svg")
+			return u
+		}, wantErr: ErrURLSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = epoch.Add(tt.at)
+			u, err := url.Parse(tt.url())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := signer.Verify(u); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignerProtect(t *testing.T) {
+	now := epoch
+	signer := fixedSigner(&now)
+	files := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<svg/>"))
+	})
+	srv := httptest.NewServer(signer.Protect(http.StripPrefix("/artifacts/", files)))
+	defer srv.Close()
+
+	signed, _, _ := signer.Sign(srv.URL + "/artifacts/chart-1.svg")
+
+	tests := []struct {
+		name string
+		url  string
+		at   time.Duration
+		want int
+	}{
+		{name: "signed", url: signed, want: http.StatusOK},
+		{name: "expired", url: signed, at: time.Hour, want: http.StatusForbidden},
+		{name: "unsigned", url: srv.URL + "/artifacts/chart-1.svg", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = epoch.Add(tt.at)
+			resp, err := http.Get(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	now := epoch
+	resolver := &Resolver{BaseURL: "https://agent.example.com/artifacts/", Signer: fixedSigner(&now)}
+
+	tests := []struct {
+		name       string
+		resolver   *Resolver
+		ref        Ref
+		wantStatus Status
+		wantURL    string
+	}{
+		{
+			name:       "retained",
+			resolver:   resolver,
+			ref:        Ref{ID: "a-1", Kind: KindChart, Location: "chart-1.svg"},
+			wantStatus: StatusAvailable,
+			wantURL:    "https://agent.example.com/artifacts/chart-1.svg?expires=",
+		},
+		{
+			name:       "ephemeral",
+			resolver:   resolver,
+			ref:        Ref{ID: "a-2", Kind: KindAttachment, Ephemeral: true, Location: "receipt.jpg"},
+			wantStatus: StatusNotRetained,
+		},
+		{
+			name:       "no location",
+			resolver:   resolver,
+			ref:        Ref{ID: "a-3", Kind: KindAttachment},
+			wantStatus: StatusUnavailable,
+		},
+		{
+			name:       "no resolver",
+			ref:        Ref{ID: "a-4", Kind: KindChart, Location: "chart-1.svg"},
+			wantStatus: StatusUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.resolver.Resolve(tt.ref)
+			if got.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", got.Status, tt.wantStatus)
+			}
+			if got.Location != "" {
+				t.Errorf("Location = %q, want it hidden", got.Location)
+			}
+			if tt.wantURL == "" {
+				if got.URL != "" || got.URLExpiresAt != nil {
+					t.Errorf("URL = %q, want none", got.URL)
+				}
+				return
+			}
+			if !strings.HasPrefix(got.URL, tt.wantURL) || got.URLExpiresAt == nil {
+				t.Errorf("URL = %q (expires %v), want a signed %s...", got.URL, got.URLExpiresAt, tt.wantURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// FileConfig configures a FileLogger.
+type FileConfig struct {
+	// Path is the file entries are appended to, one JSON object per line.
+	Path string
+
+	// MaxSize rotates the file once it reaches this many bytes. Zero
+	// means no size limit.
+	MaxSize int64
+
+	// Daily rotates the file when the local date changes.
+	Daily bool
+
+	// Sync fsyncs the file after each entry, so entries survive a crash of
+	// the machine at the cost of a disk flush per tool call.
+	Sync bool
+
+	// BufferSize is how many entries are buffered. Defaults to
+	// DefaultBufferSize.
+	BufferSize int
+
+	// Overflow is what happens when the buffer is full. Defaults to
+	// DropOldest.
+	Overflow Overflow
+}
+
+// FileLogger appends audit entries to a JSONL file. Rotated files are
+// renamed with the time they were rotated, e.g. audit.jsonl becomes
+// audit-20260301T090000.000.jsonl, and are never deleted.
+type FileLogger struct {
+	cfg    FileConfig
+	q      *queue
+	now    func() time.Time
+	file   *os.File
+	size   int64
+	opened time.Time // when the current file was started, for Daily
+}
+
+// NewFileLogger opens, or creates, the file at cfg.Path and starts writing
+// to it. Call Close to flush and close it.
+func NewFileLogger(cfg FileConfig) (*FileLogger, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("audit file path is required")
+	}
+	l := &FileLogger{cfg: cfg, q: newQueue(cfg.BufferSize, cfg.Overflow), now: time.Now}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	go l.q.run(1, 0, l.write)
+	return l, nil
+}
+
+// Log buffers an entry to be written.
+func (l *FileLogger) Log(ctx context.Context, entry *engine.AuditEntry) error {
+	return l.q.push(ctx, entry)
+}
+
+// Dropped returns how many entries were discarded because the buffer was
+// full.
+func (l *FileLogger) Dropped() int64 {
+	return l.q.dropped.Load()
+}
+
+// Close writes the buffered entries and closes the file.
+func (l *FileLogger) Close() error {
+	l.q.close()
+	return l.file.Close()
+}
+
+func (l *FileLogger) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.cfg.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	f, err := os.OpenFile(l.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	l.file, l.size = f, info.Size()
+	l.opened = l.now()
+	if l.size > 0 {
+		// An existing file was started no later than its last write.
+		l.opened = info.ModTime()
+	}
+	return nil
+}
+
+func (l *FileLogger) write(batch []*engine.AuditEntry) {
+	for _, entry := range batch {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			log.Printf("Failed to encode audit entry %s: %v", entry.ID, err)
+			continue
+		}
+		line = append(line, '\n')
+
+		if l.due(int64(len(line))) {
+			if err := l.rotate(); err != nil {
+				log.Printf("Failed to rotate audit file: %v", err)
+			}
+		}
+		n, err := l.file.Write(line)
+		l.size += int64(n)
+		if err != nil {
+			log.Printf("Failed to write audit entry %s: %v", entry.ID, err)
+			continue
+		}
+		if l.cfg.Sync {
+			if err := l.file.Sync(); err != nil {
+				log.Printf("Failed to sync audit file: %v", err)
+			}
+		}
+	}
+}
+
+// due reports whether the file must be rotated before writing n more
+// bytes. An empty file is never rotated.
+func (l *FileLogger) due(n int64) bool {
+	if l.size == 0 {
+		return false
+	}
+	if l.cfg.MaxSize > 0 && l.size+n > l.cfg.MaxSize {
+		return true
+	}
+	if l.cfg.Daily {
+		y1, m1, d1 := l.opened.Date()
+		y2, m2, d2 := l.now().Date()
+		return y1 != y2 || m1 != m2 || d1 != d2
+	}
+	return false
+}
+
+// rotate renames the current file aside and starts a new one.
+func (l *FileLogger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	ext := filepath.Ext(l.cfg.Path)
+	rotated := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(l.cfg.Path, ext), l.now().Format("20060102T150405.000"), ext)
+	if err := os.Rename(l.cfg.Path, rotated); err != nil {
+		// Keep appending to the current file rather than losing entries.
+		log.Printf("Failed to rename audit file: %v", err)
+	}
+	return l.open()
+}
+
+// Verify FileLogger implements engine.AuditLogger.
+var _ engine.AuditLogger = (*FileLogger)(nil)
@@ -0,0 +1,194 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+func entry(i int) *engine.AuditEntry {
+	parent, failure := "parent-1", "insufficient funds"
+	e := &engine.AuditEntry{
+		ID:         fmt.Sprintf("audit-%d", i),
+		UserID:     "user-1",
+		SessionID:  "session-1",
+		RequestID:  "request-1",
+		AgentName:  "default",
+		ToolName:   "send_money",
+		ToolInput:  json.RawMessage(`{"amount":"50","recipient":"@alice"}`),
+		DurationMs: 42,
+		IsWriteOp:  true,
+		Timestamp:  1772355600,
+	}
+	if i%2 == 1 {
+		e.ParentID = &parent
+		e.Error = &failure
+		e.OriginalInput = json.RawMessage(`{"amount":"60","recipient":"@alice"}`)
+	} else {
+		e.ToolOutput = json.RawMessage(`{"status":"sent"}`)
+	}
+	return e
+}
+
+// readEntries decodes a JSONL file.
+func readEntries(t *testing.T, path string) []*engine.AuditEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []*engine.AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e engine.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, &e)
+	}
+	return entries
+}
+
+func TestFileLogger_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+	l, err := NewFileLogger(FileConfig{Path: path, Sync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*engine.AuditEntry{entry(0), entry(1)}
+	for _, e := range want {
+		if err := l.Log(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Log(context.Background(), entry(2)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Log() after Close = %v, want ErrClosed", err)
+	}
+
+	got := readEntries(t, path)
+	if !reflect.DeepEqual(got, want) {
+		g, _ := json.Marshal(got)
+		w, _ := json.Marshal(want)
+		t.Errorf("read back %s, want %s", g, w)
+	}
+}
+
+func TestFileLogger_Rotation(t *testing.T) {
+	// Odd entries are the longer ones.
+	line, _ := json.Marshal(entry(1))
+	size := int64(len(line) + 1)
+
+	tests := []struct {
+		name  string
+		cfg   FileConfig
+		clock func(i int) time.Time
+		files []int // entries per file, oldest first
+	}{
+		{
+			name:  "by size",
+			cfg:   FileConfig{MaxSize: 2 * size},
+			clock: func(i int) time.Time { return time.Date(2026, 3, 1, 9, 0, i, 0, time.Local) },
+			files: []int{2, 2, 1},
+		},
+		{
+			name:  "by date",
+			cfg:   FileConfig{Daily: true},
+			clock: func(i int) time.Time { return time.Date(2026, 3, 1+i/3, 9, 0, i, 0, time.Local) },
+			files: []int{3, 2},
+		},
+		{
+			name:  "no rotation",
+			clock: func(i int) time.Time { return time.Date(2026, 3, 1+i, 9, 0, 0, 0, time.Local) },
+			files: []int{5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.cfg.Path = filepath.Join(dir, "audit.jsonl")
+			l, err := NewFileLogger(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Entries are written in order, one at a time, so the clock
+			// can follow them.
+			l.opened = tt.clock(0)
+			for i := 0; i < 5; i++ {
+				l.now = func() time.Time { return tt.clock(i) }
+				l.write([]*engine.AuditEntry{entry(i)})
+			}
+			l.Close()
+
+			names, _ := filepath.Glob(filepath.Join(dir, "audit-*.jsonl"))
+			sort.Strings(names)
+			names = append(names, tt.cfg.Path)
+			if len(names) != len(tt.files) {
+				t.Fatalf("files = %v, want %d", names, len(tt.files))
+			}
+			n := 0
+			for i, name := range names {
+				entries := readEntries(t, name)
+				if len(entries) != tt.files[i] {
+					t.Errorf("%s has %d entries, want %d", filepath.Base(name), len(entries), tt.files[i])
+				}
+				for _, e := range entries {
+					if e.ID != fmt.Sprintf("audit-%d", n) {
+						t.Errorf("%s: entry %s out of order", filepath.Base(name), e.ID)
+					}
+					n++
+				}
+			}
+		})
+	}
+}
+
+func TestQueue_Overflow(t *testing.T) {
+	t.Run("drop oldest", func(t *testing.T) {
+		q := newQueue(2, DropOldest)
+		for i := 0; i < 5; i++ {
+			if err := q.push(context.Background(), entry(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if q.dropped.Load() != 3 {
+			t.Errorf("dropped %d, want 3", q.dropped.Load())
+		}
+		var ids []string
+		go q.run(10, 0, func(batch []*engine.AuditEntry) {
+			for _, e := range batch {
+				ids = append(ids, e.ID)
+			}
+		})
+		q.close()
+		if fmt.Sprint(ids) != "[audit-3 audit-4]" {
+			t.Errorf("kept %v, want the newest two", ids)
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		q := newQueue(1, Block)
+		q.push(context.Background(), entry(0))
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := q.push(ctx, entry(1)); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("push() to a full queue = %v, want it to wait for the context", err)
+		}
+		if q.dropped.Load() != 0 {
+			t.Errorf("dropped %d, want none", q.dropped.Load())
+		}
+	})
+}
@@ -0,0 +1,136 @@
+// Package audit provides engine.AuditLogger implementations: a JSONL file
+// with rotation, and a webhook that POSTs signed batches.
+//
+// Both buffer entries and write them on their own goroutine, so logging
+// never waits on a disk or a network. Close flushes what is buffered.
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// DefaultBufferSize is how many entries a logger buffers by default.
+const DefaultBufferSize = 1024
+
+// ErrClosed is returned when logging to a closed logger.
+var ErrClosed = errors.New("audit logger closed")
+
+// Overflow is what a logger does with an entry when its buffer is full.
+type Overflow int
+
+const (
+	// DropOldest discards the oldest buffered entry to make room, so
+	// logging never blocks the engine. Dropped entries are counted.
+	DropOldest Overflow = iota
+
+	// Block waits for room, or for the context passed to Log to end.
+	Block
+)
+
+// queue buffers entries for a writer goroutine, which hands them on in
+// batches.
+type queue struct {
+	entries  chan *engine.AuditEntry
+	overflow Overflow
+	dropped  atomic.Int64
+
+	mu     sync.RWMutex // held for writing to close entries
+	closed bool
+	done   chan struct{} // closed when the writer has flushed everything
+}
+
+func newQueue(size int, overflow Overflow) *queue {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &queue{
+		entries:  make(chan *engine.AuditEntry, size),
+		overflow: overflow,
+		done:     make(chan struct{}),
+	}
+}
+
+// push buffers an entry, dropping the oldest or waiting if the buffer is
+// full.
+func (q *queue) push(ctx context.Context, entry *engine.AuditEntry) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrClosed
+	}
+
+	if q.overflow == Block {
+		select {
+		case q.entries <- entry:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for {
+		select {
+		case q.entries <- entry:
+			return nil
+		default:
+		}
+		select {
+		case <-q.entries:
+			q.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// run hands buffered entries to flush in batches of up to size, and any
+// partial batch every interval, if set. It returns once the queue is
+// closed and drained.
+func (q *queue) run(size int, interval time.Duration, flush func([]*engine.AuditEntry)) {
+	defer close(q.done)
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var batch []*engine.AuditEntry
+	for {
+		select {
+		case entry, ok := <-q.entries:
+			if !ok {
+				if len(batch) > 0 {
+					flush(batch)
+				}
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= size {
+				flush(batch)
+				batch = nil
+			}
+		case <-tick:
+			if len(batch) > 0 {
+				flush(batch)
+				batch = nil
+			}
+		}
+	}
+}
+
+// close stops accepting entries and waits for the writer to flush the
+// rest.
+func (q *queue) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.entries)
+	}
+	q.mu.Unlock()
+	<-q.done
+}
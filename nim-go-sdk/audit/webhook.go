@@ -0,0 +1,198 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// Webhook defaults.
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 5 * time.Second
+	DefaultMaxRetries    = 3
+	DefaultRetryBackoff  = time.Second
+)
+
+// Headers sent with each webhook request.
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the
+	// timestamp, a ".", and the body. See Signature.
+	SignatureHeader = "X-Nim-Signature"
+
+	// TimestampHeader carries the Unix time the request was signed, so
+	// receivers can reject replays.
+	TimestampHeader = "X-Nim-Timestamp"
+)
+
+// WebhookConfig configures a WebhookLogger.
+type WebhookConfig struct {
+	// URL receives the batches, as POSTs of a WebhookBatch.
+	URL string
+
+	// Secret signs each request. See Signature.
+	Secret []byte
+
+	// BatchSize is the most entries sent in one request. Defaults to
+	// DefaultBatchSize.
+	BatchSize int
+
+	// FlushInterval is the longest an entry waits for its batch to fill.
+	// Defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+
+	// MaxRetries is how many times a failed request is retried, after
+	// RetryBackoff, doubling each time. Requests failing with a 4xx other
+	// than 429 are not retried. Defaults to DefaultMaxRetries; -1 never
+	// retries.
+	MaxRetries int
+
+	// RetryBackoff is the wait before the first retry. Defaults to
+	// DefaultRetryBackoff.
+	RetryBackoff time.Duration
+
+	// Client sends the requests. Defaults to a client with a 10s timeout.
+	Client *http.Client
+
+	// BufferSize is how many entries are buffered. Defaults to
+	// DefaultBufferSize.
+	BufferSize int
+
+	// Overflow is what happens when the buffer is full. Defaults to
+	// DropOldest.
+	Overflow Overflow
+}
+
+// WebhookBatch is the body of a webhook request.
+type WebhookBatch struct {
+	Entries []*engine.AuditEntry `json:"entries"`
+}
+
+// WebhookLogger sends audit entries to a URL in signed batches.
+type WebhookLogger struct {
+	cfg WebhookConfig
+	q   *queue
+	now func() time.Time
+}
+
+// NewWebhookLogger starts sending entries to cfg.URL. Call Close to send
+// the buffered entries and stop.
+func NewWebhookLogger(cfg WebhookConfig) (*WebhookLogger, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("audit webhook URL is required")
+	}
+	if len(cfg.Secret) == 0 {
+		return nil, fmt.Errorf("audit webhook secret is required")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultRetryBackoff
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	l := &WebhookLogger{cfg: cfg, q: newQueue(cfg.BufferSize, cfg.Overflow), now: time.Now}
+	go l.q.run(cfg.BatchSize, cfg.FlushInterval, l.send)
+	return l, nil
+}
+
+// Log buffers an entry to be sent.
+func (l *WebhookLogger) Log(ctx context.Context, entry *engine.AuditEntry) error {
+	return l.q.push(ctx, entry)
+}
+
+// Dropped returns how many entries were discarded because the buffer was
+// full.
+func (l *WebhookLogger) Dropped() int64 {
+	return l.q.dropped.Load()
+}
+
+// Close sends the buffered entries, retrying as usual, and stops.
+func (l *WebhookLogger) Close() error {
+	l.q.close()
+	return nil
+}
+
+// Signature returns the value of SignatureHeader for a request body signed
+// at timestamp, as sent in TimestampHeader. Receivers should compare it
+// with hmac.Equal.
+func Signature(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (l *WebhookLogger) send(batch []*engine.AuditEntry) {
+	body, err := json.Marshal(WebhookBatch{Entries: batch})
+	if err != nil {
+		log.Printf("Failed to encode audit batch: %v", err)
+		return
+	}
+
+	backoff := l.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := l.post(body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= l.cfg.MaxRetries {
+			log.Printf("Failed to send %d audit entries: %v", len(batch), err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends one signed request, reporting whether a failure is worth
+// retrying.
+func (l *WebhookLogger) post(body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, l.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(l.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Signature(l.cfg.Secret, timestamp, body))
+
+	resp, err := l.cfg.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return false, fmt.Errorf("webhook returned %s", resp.Status)
+}
+
+// Verify WebhookLogger implements engine.AuditLogger.
+var _ engine.AuditLogger = (*WebhookLogger)(nil)
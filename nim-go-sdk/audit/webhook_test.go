@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver is a webhook endpoint that verifies signatures and records the
+// batches it accepts. It fails the first failures requests with status.
+type receiver struct {
+	t        *testing.T
+	secret   []byte
+	status   int
+	failures int
+
+	mu       sync.Mutex
+	requests int
+	batches  []WebhookBatch
+}
+
+func (rc *receiver) serve() *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want := Signature(rc.secret, r.Header.Get(TimestampHeader), body)
+		if !hmac.Equal([]byte(r.Header.Get(SignatureHeader)), []byte(want)) {
+			rc.t.Errorf("signature = %q, want %q", r.Header.Get(SignatureHeader), want)
+		}
+
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		rc.requests++
+		if rc.requests <= rc.failures {
+			w.WriteHeader(rc.status)
+			return
+		}
+		var batch WebhookBatch
+		if err := json.Unmarshal(body, &batch); err != nil {
+			rc.t.Errorf("body %s: %v", body, err)
+		}
+		rc.batches = append(rc.batches, batch)
+	}))
+	rc.t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWebhookLogger_BatchesAndSigns(t *testing.T) {
+	rc := &receiver{t: t, secret: []byte("shh")}
+	srv := rc.serve()
+	l, err := NewWebhookLogger(WebhookConfig{URL: srv.URL, Secret: rc.secret, BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := l.Log(context.Background(), entry(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The last, partial batch is sent on Close.
+	l.Close()
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	var sizes []int
+	n := 0
+	for _, batch := range rc.batches {
+		sizes = append(sizes, len(batch.Entries))
+		for _, e := range batch.Entries {
+			if want := entry(n); e.ID != want.ID || string(e.ToolInput) != string(want.ToolInput) {
+				t.Errorf("entry %d = %+v, want %+v", n, e, want)
+			}
+			n++
+		}
+	}
+	if len(sizes) != 3 || sizes[0] != 2 || sizes[1] != 2 || sizes[2] != 1 {
+		t.Errorf("batch sizes = %v, want [2 2 1]", sizes)
+	}
+}
+
+func TestWebhookLogger_FlushInterval(t *testing.T) {
+	rc := &receiver{t: t, secret: []byte("shh")}
+	l, err := NewWebhookLogger(WebhookConfig{URL: rc.serve().URL, Secret: rc.secret, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Log(context.Background(), entry(0))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rc.mu.Lock()
+		sent := len(rc.batches)
+		rc.mu.Unlock()
+		if sent == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("a partial batch was not sent after the flush interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebhookLogger_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		failures     int
+		wantRequests int
+		wantBatches  int
+	}{
+		{name: "server error retried", status: http.StatusServiceUnavailable, failures: 2, wantRequests: 3, wantBatches: 1},
+		{name: "rate limit retried", status: http.StatusTooManyRequests, failures: 1, wantRequests: 2, wantBatches: 1},
+		{name: "gives up after max retries", status: http.StatusInternalServerError, failures: 10, wantRequests: 4, wantBatches: 0},
+		{name: "client error not retried", status: http.StatusBadRequest, failures: 1, wantRequests: 1, wantBatches: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &receiver{t: t, secret: []byte("shh"), status: tt.status, failures: tt.failures}
+			l, err := NewWebhookLogger(WebhookConfig{
+				URL:          rc.serve().URL,
+				Secret:       rc.secret,
+				MaxRetries:   3,
+				RetryBackoff: time.Millisecond,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Log(context.Background(), entry(0))
+			l.Close()
+
+			rc.mu.Lock()
+			defer rc.mu.Unlock()
+			if rc.requests != tt.wantRequests || len(rc.batches) != tt.wantBatches {
+				t.Errorf("%d requests, %d batches delivered, want %d and %d", rc.requests, len(rc.batches), tt.wantRequests, tt.wantBatches)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/limits"
+	"github.com/becomeliminal/nim-go-sdk/sanitize"
+	"github.com/becomeliminal/nim-go-sdk/server"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// Stores are the persistence backends selected by ServerSettings.Store.
+type Stores struct {
+	Conversations store.Conversations
+	Confirmations store.Confirmations
+}
+
+// StoreFactory builds stores for a backend from the settings.
+type StoreFactory func(s *ServerSettings) (*Stores, error)
+
+var (
+	storesMu  sync.RWMutex
+	factories = map[string]StoreFactory{
+		StoreMemory:    memoryStores,
+		StoreRistretto: ristrettoStores,
+	}
+)
+
+// RegisterStore registers the factory for a store backend.
+// The SDK provides "memory" and "ristretto"; applications register "sql" and
+// "redis" with their own implementations before calling BuildServer.
+func RegisterStore(name string, factory StoreFactory) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	factories[name] = factory
+}
+
+// BuildStores creates the stores selected by s.Store.
+func BuildStores(s *ServerSettings) (*Stores, error) {
+	storesMu.RLock()
+	factory, ok := factories[s.Store]
+	storesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no store registered for %q; call config.RegisterStore first", s.Store)
+	}
+	stores, err := factory(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s store: %w", s.Store, err)
+	}
+	return stores, nil
+}
+
+func memoryStores(s *ServerSettings) (*Stores, error) {
+	return &Stores{
+		Conversations: memoryConversations(s),
+		Confirmations: store.NewMemoryConfirmations(),
+	}, nil
+}
+
+func ristrettoStores(s *ServerSettings) (*Stores, error) {
+	confirmations, err := store.NewRistrettoConfirmations(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Stores{
+		Conversations: memoryConversations(s),
+		Confirmations: confirmations,
+	}, nil
+}
+
+// memoryConversations creates an in-memory conversation store bounded by
+// the settings' limits.
+func memoryConversations(s *ServerSettings) *store.MemoryConversations {
+	return store.NewBoundedMemoryConversations(store.MemoryLimits{
+		MaxConversations:        int(s.MaxConversations),
+		MaxMessages:             int(s.MaxMessages),
+		MaxConversationMessages: int(s.MaxConversationMessages),
+	})
+}
+
+// BuildExecutor creates the executor selected by s.Executor.
+// Returns nil for ExecutorNone.
+func BuildExecutor(s *ServerSettings) *executor.HTTPExecutor {
+	if s.Executor != ExecutorHTTP {
+		return nil
+	}
+	return executor.NewHTTPExecutor(executor.HTTPExecutorConfig{
+		BaseURL:    s.LiminalBaseURL,
+		Timeout:    s.LiminalTimeout,
+		MaxRetries: int(s.LiminalMaxRetries),
+		DryRun:     s.LiminalDryRun,
+	})
+}
+
+// ServerConfig converts validated settings into a server.Config,
+// creating the selected stores, executor, sanitizer, and limits.
+func ServerConfig(s *ServerSettings) (server.Config, error) {
+	if err := s.Validate(); err != nil {
+		return server.Config{}, err
+	}
+
+	stores, err := BuildStores(s)
+	if err != nil {
+		return server.Config{}, err
+	}
+
+	cfg := server.Config{
+		AnthropicKey:     s.AnthropicKey,
+		BaseURL:          s.AnthropicBaseURL,
+		SystemPrompt:     s.SystemPrompt,
+		Model:            s.Model,
+		AllowedModels:    s.AllowedModels,
+		MaxTokens:        s.MaxTokens,
+		LiminalExecutor:  BuildExecutor(s),
+		Conversations:    stores.Conversations,
+		Confirmations:    stores.Confirmations,
+		DisableStreaming: s.DisableStreaming,
+		TextPartSize:     int(s.TextPartSize),
+		Features:         s.Features,
+	}
+	if s.Sanitize {
+		policy := sanitize.DefaultPolicy(s.ChartBaseURL)
+		cfg.Sanitizer = &policy
+	}
+	if s.DailyTransferLimit != "" || s.SingleTransferMax != "" {
+		cfg.Limits = limits.New(limits.Config{
+			DailyLimit:        s.DailyTransferLimit,
+			SingleTransferMax: s.SingleTransferMax,
+		})
+	}
+	return cfg, nil
+}
+
+// BuildServer creates a server from settings. Optional customize functions
+// can adjust the config before the server is created, e.g. to set the
+// application's system prompt or AuthFunc. If transfer limits are configured,
+// the get_transfer_allowance tool is registered.
+func BuildServer(s *ServerSettings, customize ...func(*server.Config)) (*server.Server, error) {
+	cfg, err := ServerConfig(s)
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range customize {
+		fn(&cfg)
+	}
+
+	srv, err := server.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Limits != nil {
+		srv.AddTool(limits.AllowanceTool(cfg.Limits))
+	}
+	return srv, nil
+}
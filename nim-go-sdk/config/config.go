@@ -0,0 +1,490 @@
+// Package config loads server settings from the environment.
+//
+// All settings are read in one place, defaults are applied consistently,
+// and Validate reports every problem at once so a misconfigured container
+// fails fast at startup with a complete list of what to fix.
+//
+// Variables use the NIM_ prefix for agent settings and LIMINAL_ for the
+// Liminal API. ANTHROPIC_API_KEY and PORT keep their conventional names.
+package config
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/features"
+)
+
+// Store backends.
+const (
+	// StoreMemory keeps conversations and confirmations in process memory.
+	StoreMemory = "memory"
+
+	// StoreRistretto keeps confirmations in a Ristretto cache with TTL eviction.
+	StoreRistretto = "ristretto"
+
+	// StoreSQL and StoreRedis are provided by the consuming application;
+	// see RegisterStore.
+	StoreSQL   = "sql"
+	StoreRedis = "redis"
+)
+
+// Executor backends.
+const (
+	// ExecutorHTTP calls the Liminal API over HTTP.
+	ExecutorHTTP = "http"
+
+	// ExecutorNone runs without Liminal tools.
+	ExecutorNone = "none"
+)
+
+// Defaults applied by FromEnv.
+const (
+	DefaultPort           = "8080"
+	DefaultModel          = engine.DefaultModel
+	DefaultMaxTokens      = engine.DefaultMaxTokens
+	DefaultLiminalBaseURL = "https://api.liminal.cash"
+	DefaultLiminalTimeout = 30 * time.Second
+	DefaultLiminalRetries = 2
+)
+
+// ServerSettings are the settings needed to run an agent server.
+type ServerSettings struct {
+	// Port is the HTTP listen port (PORT).
+	Port string
+
+	// AnthropicKey is the Anthropic API key (ANTHROPIC_API_KEY). Required.
+	AnthropicKey string
+
+	// AnthropicBaseURL overrides the Anthropic API URL (NIM_ANTHROPIC_BASE_URL).
+	AnthropicBaseURL string
+
+	// Model is the Claude model (NIM_MODEL).
+	Model string
+
+	// AllowedModels are the models clients may ask for per message,
+	// comma-separated (NIM_ALLOWED_MODELS).
+	AllowedModels []string
+
+	// MaxTokens is the maximum response tokens (NIM_MAX_TOKENS).
+	MaxTokens int64
+
+	// SystemPrompt replaces the application's system prompt (NIM_SYSTEM_PROMPT).
+	SystemPrompt string
+
+	// DisableStreaming uses the non-streaming API (NIM_DISABLE_STREAMING).
+	DisableStreaming bool
+
+	// Executor selects the tool executor: "http" or "none" (NIM_EXECUTOR).
+	Executor string
+
+	// LiminalBaseURL is the Liminal API URL (LIMINAL_BASE_URL).
+	LiminalBaseURL string
+
+	// LiminalTimeout is the Liminal API request timeout (LIMINAL_TIMEOUT).
+	LiminalTimeout time.Duration
+
+	// LiminalMaxRetries is how many times failed Liminal reads are retried
+	// (LIMINAL_MAX_RETRIES). Writes are never retried.
+	LiminalMaxRetries int64
+
+	// LiminalDryRun is set if the Liminal API supports dry runs of writes,
+	// which preview tools need (LIMINAL_DRY_RUN).
+	LiminalDryRun bool
+
+	// Store selects the store backend: "memory", "ristretto", "sql" or "redis" (NIM_STORE).
+	Store string
+
+	// StoreDSN is the SQL connection string, required for "sql" (NIM_STORE_DSN).
+	StoreDSN string
+
+	// RedisURL is the Redis URL, required for "redis" (NIM_REDIS_URL).
+	RedisURL string
+
+	// MaxConversations caps the conversations kept in memory by the
+	// "memory" and "ristretto" stores, evicting the least recently used
+	// (NIM_MAX_CONVERSATIONS). Zero means no cap.
+	MaxConversations int64
+
+	// MaxMessages caps the messages kept in memory across all conversations
+	// (NIM_MAX_MESSAGES). Zero means no cap.
+	MaxMessages int64
+
+	// MaxConversationMessages caps the messages kept in memory per
+	// conversation, dropping the oldest (NIM_MAX_CONVERSATION_MESSAGES).
+	// Zero means no cap.
+	MaxConversationMessages int64
+
+	// TextPartSize splits final text longer than this many bytes into
+	// "text_part" frames (NIM_TEXT_PART_SIZE). Zero sends it whole.
+	TextPartSize int64
+
+	// Sanitize enables markdown sanitization of assistant output (NIM_SANITIZE).
+	Sanitize bool
+
+	// ChartBaseURL is the URL prefix images may be loaded from (NIM_CHART_BASE_URL).
+	// Defaults to the local /charts/ endpoint on Port.
+	ChartBaseURL string
+
+	// ExportBaseURL is the URL transaction exports are downloaded from
+	// (NIM_EXPORT_BASE_URL). Defaults to the local /exports/ endpoint on Port.
+	ExportBaseURL string
+
+	// DailyTransferLimit caps transfers per user per day (NIM_DAILY_TRANSFER_LIMIT).
+	// Empty means no limit.
+	DailyTransferLimit string
+
+	// SingleTransferMax caps any single transfer (NIM_SINGLE_TRANSFER_MAX).
+	// Empty means no cap.
+	SingleTransferMax string
+
+	// Features sets feature flags, as a comma-separated list of flags to
+	// turn on, or name=false to turn one off (NIM_FEATURES).
+	Features map[string]bool
+}
+
+// ValidationError lists every problem found in the settings.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// FromEnv loads settings from the environment, applies defaults, and validates them.
+// The settings are returned even when invalid so callers can log them.
+func FromEnv() (*ServerSettings, error) {
+	return load(os.LookupEnv)
+}
+
+// load reads settings using lookup, collecting parse errors alongside
+// validation errors.
+func load(lookup func(string) (string, bool)) (*ServerSettings, error) {
+	l := &loader{lookup: lookup}
+
+	s := &ServerSettings{
+		Port:                    l.str("PORT", DefaultPort),
+		AnthropicKey:            l.str("ANTHROPIC_API_KEY", ""),
+		AnthropicBaseURL:        l.str("NIM_ANTHROPIC_BASE_URL", ""),
+		Model:                   l.str("NIM_MODEL", DefaultModel),
+		AllowedModels:           l.list("NIM_ALLOWED_MODELS"),
+		MaxTokens:               l.int("NIM_MAX_TOKENS", DefaultMaxTokens),
+		SystemPrompt:            l.str("NIM_SYSTEM_PROMPT", ""),
+		DisableStreaming:        l.bool("NIM_DISABLE_STREAMING", false),
+		Executor:                l.str("NIM_EXECUTOR", ExecutorHTTP),
+		LiminalBaseURL:          l.str("LIMINAL_BASE_URL", DefaultLiminalBaseURL),
+		LiminalTimeout:          l.duration("LIMINAL_TIMEOUT", DefaultLiminalTimeout),
+		LiminalMaxRetries:       l.int("LIMINAL_MAX_RETRIES", DefaultLiminalRetries),
+		LiminalDryRun:           l.bool("LIMINAL_DRY_RUN", false),
+		Store:                   l.str("NIM_STORE", StoreMemory),
+		StoreDSN:                l.str("NIM_STORE_DSN", ""),
+		RedisURL:                l.str("NIM_REDIS_URL", ""),
+		MaxConversations:        l.int("NIM_MAX_CONVERSATIONS", 0),
+		MaxMessages:             l.int("NIM_MAX_MESSAGES", 0),
+		MaxConversationMessages: l.int("NIM_MAX_CONVERSATION_MESSAGES", 0),
+		TextPartSize:            l.int("NIM_TEXT_PART_SIZE", 0),
+		Sanitize:                l.bool("NIM_SANITIZE", true),
+		ChartBaseURL:            l.str("NIM_CHART_BASE_URL", ""),
+		ExportBaseURL:           l.str("NIM_EXPORT_BASE_URL", ""),
+		DailyTransferLimit:      l.str("NIM_DAILY_TRANSFER_LIMIT", ""),
+		SingleTransferMax:       l.str("NIM_SINGLE_TRANSFER_MAX", ""),
+		Features:                l.flags("NIM_FEATURES"),
+	}
+	if _, err := strconv.Atoi(s.Port); err == nil && s.ChartBaseURL == "" {
+		s.ChartBaseURL = fmt.Sprintf("http://localhost:%s/charts/", s.Port)
+	}
+	if _, err := strconv.Atoi(s.Port); err == nil && s.ExportBaseURL == "" {
+		s.ExportBaseURL = fmt.Sprintf("http://localhost:%s/exports/", s.Port)
+	}
+
+	problems := append(l.problems, s.problems()...)
+	if len(problems) > 0 {
+		return s, &ValidationError{Problems: problems}
+	}
+	return s, nil
+}
+
+// Validate checks the settings, reporting all problems at once.
+func (s *ServerSettings) Validate() error {
+	if problems := s.problems(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func (s *ServerSettings) problems() []string {
+	var p []string
+
+	if s.AnthropicKey == "" {
+		p = append(p, "ANTHROPIC_API_KEY is required")
+	}
+	if port, err := strconv.Atoi(s.Port); err != nil || port < 1 || port > 65535 {
+		p = append(p, fmt.Sprintf("PORT must be a number between 1 and 65535, got %q", s.Port))
+	}
+	if s.AnthropicBaseURL != "" && !validURL(s.AnthropicBaseURL) {
+		p = append(p, fmt.Sprintf("NIM_ANTHROPIC_BASE_URL must be an absolute URL, got %q", s.AnthropicBaseURL))
+	}
+	if s.Model == "" {
+		p = append(p, "NIM_MODEL must not be empty")
+	}
+	if s.MaxTokens <= 0 {
+		p = append(p, fmt.Sprintf("NIM_MAX_TOKENS must be positive, got %d", s.MaxTokens))
+	}
+
+	switch s.Executor {
+	case ExecutorHTTP:
+		if !validURL(s.LiminalBaseURL) {
+			p = append(p, fmt.Sprintf("LIMINAL_BASE_URL must be an absolute URL, got %q", s.LiminalBaseURL))
+		}
+		if s.LiminalTimeout <= 0 {
+			p = append(p, fmt.Sprintf("LIMINAL_TIMEOUT must be positive, got %s", s.LiminalTimeout))
+		}
+		if s.LiminalMaxRetries < 0 {
+			p = append(p, fmt.Sprintf("LIMINAL_MAX_RETRIES must not be negative, got %d", s.LiminalMaxRetries))
+		}
+	case ExecutorNone:
+	default:
+		p = append(p, fmt.Sprintf("NIM_EXECUTOR must be %q or %q, got %q", ExecutorHTTP, ExecutorNone, s.Executor))
+	}
+
+	switch s.Store {
+	case StoreMemory, StoreRistretto:
+	case StoreSQL:
+		if s.StoreDSN == "" {
+			p = append(p, "NIM_STORE_DSN is required when NIM_STORE=sql")
+		}
+	case StoreRedis:
+		if s.RedisURL == "" {
+			p = append(p, "NIM_REDIS_URL is required when NIM_STORE=redis")
+		}
+	default:
+		p = append(p, fmt.Sprintf("NIM_STORE must be one of memory, ristretto, sql, redis, got %q", s.Store))
+	}
+
+	for _, limit := range []struct {
+		key   string
+		value int64
+	}{
+		{"NIM_MAX_CONVERSATIONS", s.MaxConversations},
+		{"NIM_MAX_MESSAGES", s.MaxMessages},
+		{"NIM_MAX_CONVERSATION_MESSAGES", s.MaxConversationMessages},
+		{"NIM_TEXT_PART_SIZE", s.TextPartSize},
+	} {
+		if limit.value < 0 {
+			p = append(p, fmt.Sprintf("%s must not be negative, got %d", limit.key, limit.value))
+		}
+	}
+
+	if s.Sanitize && s.ChartBaseURL != "" && !validURL(s.ChartBaseURL) {
+		p = append(p, fmt.Sprintf("NIM_CHART_BASE_URL must be an absolute URL, got %q", s.ChartBaseURL))
+	}
+	if s.ExportBaseURL != "" && !validURL(s.ExportBaseURL) {
+		p = append(p, fmt.Sprintf("NIM_EXPORT_BASE_URL must be an absolute URL, got %q", s.ExportBaseURL))
+	}
+	if !validAmount(s.DailyTransferLimit) {
+		p = append(p, fmt.Sprintf("NIM_DAILY_TRANSFER_LIMIT must be a positive decimal, got %q", s.DailyTransferLimit))
+	}
+	if !validAmount(s.SingleTransferMax) {
+		p = append(p, fmt.Sprintf("NIM_SINGLE_TRANSFER_MAX must be a positive decimal, got %q", s.SingleTransferMax))
+	}
+	if _, err := features.New(s.Features, nil); err != nil {
+		p = append(p, fmt.Sprintf("NIM_FEATURES: %v", err))
+	}
+	return p
+}
+
+// String returns the settings with secrets redacted, safe for startup logs.
+func (s *ServerSettings) String() string {
+	fields := []struct{ name, value string }{
+		{"port", s.Port},
+		{"anthropic_key", redact(s.AnthropicKey)},
+		{"anthropic_base_url", s.AnthropicBaseURL},
+		{"model", s.Model},
+		{"allowed_models", strings.Join(s.AllowedModels, ",")},
+		{"max_tokens", strconv.FormatInt(s.MaxTokens, 10)},
+		{"system_prompt", promptSummary(s.SystemPrompt)},
+		{"disable_streaming", strconv.FormatBool(s.DisableStreaming)},
+		{"executor", s.Executor},
+		{"liminal_base_url", s.LiminalBaseURL},
+		{"liminal_timeout", s.LiminalTimeout.String()},
+		{"liminal_max_retries", strconv.FormatInt(s.LiminalMaxRetries, 10)},
+		{"liminal_dry_run", strconv.FormatBool(s.LiminalDryRun)},
+		{"store", s.Store},
+		{"store_dsn", redact(s.StoreDSN)},
+		{"redis_url", redactURL(s.RedisURL)},
+		{"max_conversations", strconv.FormatInt(s.MaxConversations, 10)},
+		{"max_messages", strconv.FormatInt(s.MaxMessages, 10)},
+		{"max_conversation_messages", strconv.FormatInt(s.MaxConversationMessages, 10)},
+		{"text_part_size", strconv.FormatInt(s.TextPartSize, 10)},
+		{"sanitize", strconv.FormatBool(s.Sanitize)},
+		{"chart_base_url", s.ChartBaseURL},
+		{"export_base_url", s.ExportBaseURL},
+		{"daily_transfer_limit", s.DailyTransferLimit},
+		{"single_transfer_max", s.SingleTransferMax},
+		{"features", featureList(s.Features)},
+	}
+
+	var b strings.Builder
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s=%q", f.name, f.value)
+	}
+	return b.String()
+}
+
+// loader reads environment variables, recording values that fail to parse.
+type loader struct {
+	lookup   func(string) (string, bool)
+	problems []string
+}
+
+func (l *loader) str(key, def string) string {
+	if v, ok := l.lookup(key); ok && strings.TrimSpace(v) != "" {
+		return strings.TrimSpace(v)
+	}
+	return def
+}
+
+func (l *loader) int(key string, def int64) int64 {
+	v := l.str(key, "")
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		l.problems = append(l.problems, fmt.Sprintf("%s must be an integer, got %q", key, v))
+		return def
+	}
+	return n
+}
+
+func (l *loader) bool(key string, def bool) bool {
+	v := l.str(key, "")
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.problems = append(l.problems, fmt.Sprintf("%s must be true or false, got %q", key, v))
+		return def
+	}
+	return b
+}
+
+func (l *loader) list(key string) []string {
+	var items []string
+	for _, item := range strings.Split(l.str(key, ""), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (l *loader) flags(key string) map[string]bool {
+	v := l.str(key, "")
+	if v == "" {
+		return nil
+	}
+	flags := make(map[string]bool)
+	for _, item := range strings.Split(v, ",") {
+		name, value, hasValue := strings.Cut(strings.TrimSpace(item), "=")
+		if name == "" {
+			continue
+		}
+		on := true
+		if hasValue {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				l.problems = append(l.problems, fmt.Sprintf("%s: %s must be true or false, got %q", key, name, value))
+				continue
+			}
+			on = b
+		}
+		flags[name] = on
+	}
+	return flags
+}
+
+// featureList formats flags as NIM_FEATURES would set them, sorted by name.
+func featureList(flags map[string]bool) string {
+	items := make([]string, 0, len(flags))
+	for name, on := range flags {
+		if on {
+			items = append(items, name)
+		} else {
+			items = append(items, name+"=false")
+		}
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+func (l *loader) duration(key string, def time.Duration) time.Duration {
+	v := l.str(key, "")
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		l.problems = append(l.problems, fmt.Sprintf("%s must be a duration such as 30s, got %q", key, v))
+		return def
+	}
+	return d
+}
+
+func validURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// validAmount reports whether s is empty or a positive decimal.
+func validAmount(s string) bool {
+	if s == "" {
+		return true
+	}
+	r, ok := new(big.Rat).SetString(s)
+	return ok && r.Sign() > 0
+}
+
+// redact hides a secret, keeping only whether it is set.
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+// redactURL hides the password in a URL, or the whole value if it doesn't parse.
+func redactURL(s string) string {
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return redact(s)
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "redacted")
+	}
+	return u.String()
+}
+
+// promptSummary describes a system prompt override without logging it.
+func promptSummary(s string) string {
+	if s == "" {
+		return ""
+	}
+	return fmt.Sprintf("(%d chars)", len(s))
+}
@@ -0,0 +1,567 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/llm"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+func TestCategorizeNote(t *testing.T) {
+	tests := []struct {
+		note string
+		want string
+	}{
+		{"Netflix monthly", Subscription},
+		{"Concert tickets", Entertainment},
+		{"Lunch with Sam", Food},
+		{"Uber to airport", Travel},
+		{"New laptop", Electronics},
+		{"Rent", Miscellaneous},
+	}
+	for _, tt := range tests {
+		t.Run(tt.note, func(t *testing.T) {
+			if got := CategorizeNote(tt.note); got != tt.want {
+				t.Errorf("CategorizeNote(%q) = %q, want %q", tt.note, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategorizer(t *testing.T) {
+	notes := []string{"Lunch", "Spotify"}
+	tests := []struct {
+		name      string
+		completer llm.Completer
+		want      []string
+	}{
+		{"no model", nil, []string{Food, Subscription}},
+		{"model", llm.CompleterFunc(func(ctx context.Context, prompt string, maxTokens int) (string, error) {
+			return "Here you go: [\"travel\", \"bogus\"]", nil
+		}), []string{Travel, Miscellaneous}},
+		{"model error", llm.CompleterFunc(func(ctx context.Context, prompt string, maxTokens int) (string, error) {
+			return "", errors.New("unavailable")
+		}), []string{Food, Subscription}},
+		{"wrong count", llm.CompleterFunc(func(ctx context.Context, prompt string, maxTokens int) (string, error) {
+			return `["travel"]`, nil
+		}), []string{Food, Subscription}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewCategorizer(tt.completer).Categorize(context.Background(), notes)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Categorize() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Categorize()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// structuredFunc is an llm.StructuredCompleter that calls itself.
+type structuredFunc func(ctx context.Context, prompt string, schema map[string]interface{}) (json.RawMessage, error)
+
+func (f structuredFunc) CompleteStructured(ctx context.Context, prompt string, schema map[string]interface{}, opts ...engine.StructuredOption) (json.RawMessage, error) {
+	return f(ctx, prompt, schema)
+}
+
+func TestStructuredCategorizer(t *testing.T) {
+	notes := []string{"Lunch", "Spotify"}
+	tests := []struct {
+		name  string
+		model llm.StructuredCompleter
+		want  []string
+	}{
+		{"no model", nil, []string{Food, Subscription}},
+		{"model", structuredFunc(func(ctx context.Context, prompt string, schema map[string]interface{}) (json.RawMessage, error) {
+			if schema["required"] == nil {
+				t.Error("schema has no required fields")
+			}
+			return json.RawMessage(`{"categories":["travel","bogus"]}`), nil
+		}), []string{Travel, Miscellaneous}},
+		{"model error", structuredFunc(func(ctx context.Context, prompt string, schema map[string]interface{}) (json.RawMessage, error) {
+			return nil, errors.New("unavailable")
+		}), []string{Food, Subscription}},
+		{"wrong count", structuredFunc(func(ctx context.Context, prompt string, schema map[string]interface{}) (json.RawMessage, error) {
+			return json.RawMessage(`{"categories":["travel"]}`), nil
+		}), []string{Food, Subscription}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewStructuredCategorizer(tt.model).Categorize(context.Background(), notes)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Categorize() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Categorize()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFlag(t *testing.T) {
+	txs := []txn.Transaction{
+		{Amount: "30", Direction: "debit", Note: "Netflix subscription"},
+		{Amount: "5", Direction: "debit", Note: "Movie"},
+		{Amount: "200", Direction: "debit", Note: "Groceries"},
+		{Amount: "500", Direction: "credit", Note: "Salary"},
+	}
+
+	f := Flag(txs, 1000)
+	if len(f.Unnecessary) != 1 || f.Unnecessary[0].Category != Subscription {
+		t.Errorf("Unnecessary = %+v, want only subscription", f.Unnecessary)
+	}
+	if len(f.Excessive) != 1 || f.Excessive[0].Category != Food {
+		t.Errorf("Excessive = %+v, want only food", f.Excessive)
+	}
+	if f.PotentialSavings() != 30+50 {
+		t.Errorf("PotentialSavings() = %v, want 80", f.PotentialSavings())
+	}
+
+	if f := Flag(txs, 0); len(f.Excessive) != 0 {
+		t.Errorf("Flag with no income flagged %+v as excessive", f.Excessive)
+	}
+
+	// Amounts are totaled exactly, and malformed ones are skipped rather
+	// than counted as zero.
+	txs = []txn.Transaction{
+		{Amount: "10.10", Direction: "debit", Note: "Netflix subscription"},
+		{Amount: "0.20", Direction: "debit", Note: "Spotify subscription"},
+		{Amount: "12abc", Direction: "debit", Note: "Hulu subscription"},
+		{Amount: "-0.10", Note: "Disney+ subscription"},
+	}
+	f = Flag(txs, 0)
+	if f.TotalUnnecessary != 10.4 || f.PotentialSavings() != 10.4 || f.Skipped != 1 {
+		t.Errorf("Flag() = %+v, want 10.40 unnecessary and 1 skipped", f)
+	}
+	if len(f.Unnecessary) != 1 || f.Unnecessary[0].Count != 3 {
+		t.Errorf("Unnecessary = %+v, want 3 subscriptions", f.Unnecessary)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	txs := []txn.Transaction{
+		{Amount: "60", Direction: "debit", CreatedAt: "2026-03-20T00:00:00Z"},
+		{Amount: "100", Direction: "credit", CreatedAt: "2026-03-21T00:00:00Z"},
+		{Amount: "1000", Direction: "debit", CreatedAt: "2026-01-01T00:00:00Z"},
+	}
+
+	s := Summarize(txs, now, 0)
+	if s.Days != 30 || s.TotalSpent != 60 || s.TotalReceived != 100 || s.SpendCount != 1 {
+		t.Errorf("Summarize() = %+v", s)
+	}
+	if s.AvgDailySpend != 2 {
+		t.Errorf("AvgDailySpend = %v, want 2", s.AvgDailySpend)
+	}
+
+	txs = []txn.Transaction{
+		{Amount: "0.10", Direction: "debit", CreatedAt: "2026-03-20T00:00:00Z"},
+		{Amount: "0.20", Direction: "debit", CreatedAt: "2026-03-21T00:00:00Z"},
+		{Amount: "92233720368547758.07", Direction: "credit", CreatedAt: "2026-03-22T00:00:00Z"},
+		{Amount: "0.01", Direction: "credit", CreatedAt: "2026-03-22T00:00:00Z"},
+		{Amount: "twelve", Direction: "debit", CreatedAt: "2026-03-23T00:00:00Z"},
+		{Amount: "", Direction: "credit", CreatedAt: "2026-03-23T00:00:00Z"},
+	}
+	s = Summarize(txs, now, 30)
+	if s.TotalSpent != 0.3 || s.SpendCount != 2 {
+		t.Errorf("Summarize() spent %v in %d transactions, want exactly 0.3 in 2", s.TotalSpent, s.SpendCount)
+	}
+	if s.TotalReceived != 92233720368547758.07 || s.ReceiveCount != 1 {
+		t.Errorf("Summarize() received %v in %d transactions, want the largest amount once", s.TotalReceived, s.ReceiveCount)
+	}
+	if s.Skipped != 3 {
+		t.Errorf("Skipped = %d, want 3 (two malformed, one overflowing)", s.Skipped)
+	}
+}
+
+func TestScoreStability(t *testing.T) {
+	flat := make([]float64, HistoryWindow)
+	for i := range flat {
+		flat[i] = 6
+	}
+	if s := ScoreStability(flat); s.Score != 100 || s.Label != "Stable 🟢" {
+		t.Errorf("flat history scored %v %q, want 100 Stable", s.Score, s.Label)
+	}
+
+	spiky := make([]float64, HistoryWindow)
+	for i := range spiky {
+		spiky[i] = 8
+		if i%2 == 1 {
+			spiky[i] = 3
+		}
+	}
+	if s := ScoreStability(spiky); s.Score >= 40 {
+		t.Errorf("spiky history scored %v, want under 40", s.Score)
+	}
+}
+
+func TestAPYHistory(t *testing.T) {
+	h := NewAPYHistory(5, 6)
+	if h.Add(6) {
+		t.Error("Add() recorded a value equal to the latest")
+	}
+	if !h.Add(7) {
+		t.Error("Add() skipped a new value")
+	}
+	for i := 0; i < HistoryWindow; i++ {
+		h.Add(float64(i))
+	}
+	if got := len(h.Values()); got != HistoryWindow {
+		t.Errorf("history has %d values, want %d", got, HistoryWindow)
+	}
+}
+
+func TestClassifyBalance(t *testing.T) {
+	strict := HealthThresholds{Low: 100, Dust: 1}
+	tests := []struct {
+		name       string
+		balance    float64
+		thresholds HealthThresholds
+		want       BalanceHealth
+	}{
+		{"healthy", 50, DefaultHealthThresholds, BalanceHealthy},
+		{"low", 1.5, DefaultHealthThresholds, BalanceLow},
+		{"tiny positive", 0.02, DefaultHealthThresholds, BalanceLow},
+		{"dust", 0.004, DefaultHealthThresholds, BalanceZero},
+		{"zero", 0, DefaultHealthThresholds, BalanceZero},
+		{"negative dust", -0.01, DefaultHealthThresholds, BalanceZero},
+		{"negative", -20, DefaultHealthThresholds, BalanceNegative},
+		{"custom low", 50, strict, BalanceLow},
+		{"custom dust", 0.9, strict, BalanceZero},
+		{"custom negative", -1.5, strict, BalanceNegative},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyBalance(tt.balance, tt.thresholds)
+			if got != tt.want {
+				t.Errorf("ClassifyBalance(%v) = %s, want %s", tt.balance, got, tt.want)
+			}
+			if wantAdvise := tt.want == BalanceHealthy || tt.want == BalanceLow; got.CanAdvise() != wantAdvise {
+				t.Errorf("%s.CanAdvise() = %v", got, got.CanAdvise())
+			}
+		})
+	}
+}
+
+// debits returns an outgoing payment to payee on each date, of the amount
+// at the same index, or the last amount.
+func debits(payee string, amounts []string, dates ...string) []txn.Transaction {
+	txs := make([]txn.Transaction, len(dates))
+	for i, date := range dates {
+		amount := amounts[min(i, len(amounts)-1)]
+		txs[i] = txn.Transaction{Amount: amount, Currency: "USD", Direction: "debit", Counterparty: payee, CreatedAt: date + "T10:00:00Z"}
+	}
+	return txs
+}
+
+func TestDetectRecurring(t *testing.T) {
+	now := time.Date(2026, 5, 20, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		txs  []txn.Transaction
+		want []RecurringPayment // only the fields checked below
+	}{
+		{
+			name: "monthly drift",
+			txs:  debits("NETFLIX.COM #8841", []string{"15.49"}, "2026-01-15", "2026-02-17", "2026-03-15", "2026-04-18", "2026-05-16"),
+			want: []RecurringPayment{{Payee: "NETFLIX.COM #8841", Cadence: CadenceMonthly, Payments: 5, AverageAmount: 15.49, LastPayment: "2026-05-16", NextCharge: "2026-06-16"}},
+		},
+		{
+			name: "month end",
+			txs:  debits("Gym", []string{"40"}, "2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30"),
+			want: []RecurringPayment{{Payee: "Gym", Cadence: CadenceMonthly, Payments: 4, AverageAmount: 40, LastPayment: "2026-04-30", NextCharge: "2026-05-30"}},
+		},
+		{
+			name: "price change",
+			txs:  debits("Spotify", []string{"10", "10", "10", "12", "12"}, "2026-01-05", "2026-02-05", "2026-03-05", "2026-04-05", "2026-05-05"),
+			want: []RecurringPayment{{Payee: "Spotify", Cadence: CadenceMonthly, Payments: 5, AverageAmount: 10.8, LastAmount: 12, PreviousAmount: 10, LastPayment: "2026-05-05", NextCharge: "2026-06-05"}},
+		},
+		{
+			name: "amount drift",
+			txs:  debits("Cloud storage", []string{"9.99", "10.19", "10.39"}, "2026-03-01", "2026-04-01", "2026-05-01"),
+			want: []RecurringPayment{{Payee: "Cloud storage", Cadence: CadenceMonthly, Payments: 3, AverageAmount: 10.19, LastAmount: 10.39, LastPayment: "2026-05-01", NextCharge: "2026-06-01"}},
+		},
+		{
+			name: "weekly",
+			txs:  debits("Veg box", []string{"25"}, "2026-04-22", "2026-04-29", "2026-05-07", "2026-05-13"),
+			want: []RecurringPayment{{Payee: "Veg box", Cadence: CadenceWeekly, Payments: 4, AverageAmount: 25, LastPayment: "2026-05-13", NextCharge: "2026-05-20"}},
+		},
+		{
+			name: "one-offs from a subscription's payee",
+			txs: append(debits("Amazon", []string{"14.99"}, "2026-02-10", "2026-03-10", "2026-04-10", "2026-05-10"),
+				debits("Amazon", []string{"120", "35.50"}, "2026-03-02", "2026-04-28")...),
+			want: []RecurringPayment{{Payee: "Amazon", Cadence: CadenceMonthly, Payments: 4, AverageAmount: 14.99, LastPayment: "2026-05-10", NextCharge: "2026-06-10"}},
+		},
+		{
+			name: "too few",
+			txs:  debits("Netflix", []string{"15.49"}, "2026-04-15", "2026-05-15"),
+		},
+		{
+			name: "irregular",
+			txs:  debits("Cafe", []string{"4.50"}, "2026-04-01", "2026-04-12", "2026-05-02", "2026-05-09"),
+		},
+		{
+			name: "credits",
+			txs: []txn.Transaction{
+				{Amount: "3000", Currency: "USD", Direction: "credit", Counterparty: "Employer", CreatedAt: "2026-03-01T10:00:00Z"},
+				{Amount: "3000", Currency: "USD", Direction: "credit", Counterparty: "Employer", CreatedAt: "2026-04-01T10:00:00Z"},
+				{Amount: "3000", Currency: "USD", Direction: "credit", Counterparty: "Employer", CreatedAt: "2026-05-01T10:00:00Z"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectRecurring(tt.txs, now).Series
+			if len(got) != len(tt.want) {
+				t.Fatalf("DetectRecurring() = %+v, want %d series", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				g := got[i]
+				if want.LastAmount == 0 {
+					want.LastAmount = want.AverageAmount
+				}
+				if g.Payee != want.Payee || g.Cadence != want.Cadence || g.Payments != want.Payments ||
+					g.AverageAmount != want.AverageAmount || g.LastAmount != want.LastAmount || g.PreviousAmount != want.PreviousAmount ||
+					g.LastPayment != want.LastPayment || g.NextCharge != want.NextCharge {
+					t.Errorf("series %d = %+v, want %+v", i, g, want)
+				}
+				if g.Lapsed || g.Reminder == nil || g.Reminder.Frequency != want.Cadence || g.Reminder.Amount != want.LastAmount {
+					t.Errorf("series %d reminder = %+v, lapsed %v, want a %s reminder", i, g.Reminder, g.Lapsed, want.Cadence)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectRecurring_Lapsed(t *testing.T) {
+	txs := append(debits("Netflix", []string{"15.49"}, "2026-01-15", "2026-02-15", "2026-03-15"),
+		debits("Spotify", []string{"10"}, "2026-03-05", "2026-04-05", "2026-05-05")...)
+	txs = append(txs, txn.Transaction{Amount: "12abc", Currency: "USD", Direction: "debit", Counterparty: "Hulu", CreatedAt: "2026-05-01T10:00:00Z"})
+
+	r := DetectRecurring(txs, time.Date(2026, 5, 20, 12, 0, 0, 0, time.UTC))
+	if len(r.Series) != 2 || r.Series[0].Payee != "Netflix" || r.Series[1].Payee != "Spotify" {
+		t.Fatalf("Series = %+v, want Netflix then Spotify", r.Series)
+	}
+	if netflix := r.Series[0]; !netflix.Lapsed || netflix.Reminder != nil {
+		t.Errorf("Netflix = %+v, want lapsed without a reminder", netflix)
+	}
+	if spotify := r.Series[1]; spotify.Reminder == nil || spotify.Reminder.StartDate != "2026-06-04" {
+		t.Errorf("Spotify reminder = %+v, want one starting the day before 2026-06-05", spotify.Reminder)
+	}
+	if r.MonthlyTotal["USD"] != 10 || r.Analyzed != 7 || r.Skipped != 1 {
+		t.Errorf("DetectRecurring() = %+v, want 10 USD a month from 7 analyzed with 1 skipped", r)
+	}
+}
+
+func TestRobustStats(t *testing.T) {
+	tests := []struct {
+		xs                 []float64
+		median, mad, pct90 float64
+	}{
+		{[]float64{5}, 5, 0, 5},
+		{[]float64{1, 2, 3, 4}, 2.5, 1, 4},
+		{[]float64{10, 1, 2, 2, 3, 3, 4, 100, 2, 3}, 3, 1, 10},
+		{[]float64{80, 90, 90, 95, 100, 110, 90}, 90, 5, 110},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.xs), func(t *testing.T) {
+			if got := median(tt.xs); got != tt.median {
+				t.Errorf("median = %v, want %v", got, tt.median)
+			}
+			if got := mad(tt.xs); got != tt.mad {
+				t.Errorf("mad = %v, want %v", got, tt.mad)
+			}
+			if got := percentile(tt.xs, 0.9); got != tt.pct90 {
+				t.Errorf("percentile(0.9) = %v, want %v", got, tt.pct90)
+			}
+		})
+	}
+}
+
+// anomalyNow is when the anomaly fixtures are analyzed.
+var anomalyNow = time.Date(2026, 5, 20, 12, 0, 0, 0, time.UTC)
+
+// spendingHistory returns a seeded history of the days before anomalyNow,
+// from firstDay days ago to lastDay: lunch every day, about $12, and a $25
+// Uber every Monday.
+func spendingHistory(seed int64, firstDay, lastDay int) []txn.Transaction {
+	rng := rand.New(rand.NewSource(seed))
+	var txs []txn.Transaction
+	for day := firstDay; day >= lastDay; day-- {
+		at := anomalyNow.AddDate(0, 0, -day).Add(-time.Hour)
+		lunch := 12 + rng.NormFloat64()*3
+		txs = append(txs, spending(fmt.Sprintf("lunch-%d", day), "Lunch", "", lunch, at))
+		if at.Weekday() == time.Monday {
+			txs = append(txs, spending(fmt.Sprintf("uber-%d", day), "", "Uber", 25, at))
+		}
+	}
+	return txs
+}
+
+// spending returns an outgoing payment of amount dollars at at.
+func spending(id, note, counterparty string, amount float64, at time.Time) txn.Transaction {
+	return txn.Transaction{
+		ID:           id,
+		Amount:       fmt.Sprintf("-%.2f", amount),
+		Currency:     "USD",
+		Direction:    "debit",
+		Note:         note,
+		Counterparty: counterparty,
+		CreatedAt:    at.Format(time.RFC3339),
+	}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	baseline := spendingHistory(42, 89, 7)
+	// extra returns the baseline with the current week's spending, plus
+	// more.
+	extra := func(more ...txn.Transaction) []txn.Transaction {
+		return append(append(spendingHistory(7, 6, 0), baseline...), more...)
+	}
+	day := func(days int) time.Time { return anomalyNow.AddDate(0, 0, -days) }
+	groceries := []txn.Transaction{
+		spending("groceries-1", "Groceries", "", 15, day(5)),
+		spending("groceries-2", "Groceries", "", 15, day(4)),
+		spending("groceries-3", "Groceries", "", 15, day(3)),
+		spending("groceries-4", "Groceries", "", 15, day(2)),
+	}
+
+	tests := []struct {
+		name       string
+		txs        []txn.Transaction
+		multiple   float64
+		categories []string
+		large      []string
+		level      string
+	}{
+		{
+			name:  "usual week",
+			txs:   extra(),
+			level: AnomalyNone,
+		},
+		{
+			name: "food spike",
+			txs: extra(
+				spending("dinner-1", "Dinner", "", 20, day(5)),
+				spending("dinner-2", "Dinner", "", 20, day(4)),
+				spending("dinner-3", "Restaurant", "", 20, day(3)),
+				spending("dinner-4", "Restaurant", "", 20, day(2)),
+				spending("dinner-5", "Dinner", "", 20, day(1)),
+			),
+			categories: []string{Food},
+			level:      AnomalyHigh,
+		},
+		{
+			name:       "new laptop",
+			txs:        extra(spending("laptop", "New laptop", "Apple", 900, day(3))),
+			categories: []string{Electronics},
+			large:      []string{"laptop"},
+			level:      AnomalyHigh,
+		},
+		{
+			name:  "large payment in a usual category",
+			txs:   extra(spending("banquet", "Lunch", "", 40, day(4))),
+			large: []string{"banquet"},
+			level: AnomalyLow,
+		},
+		{
+			name:  "below the default multiple",
+			txs:   extra(groceries...),
+			level: AnomalyNone,
+		},
+		{
+			name:       "above a lower multiple",
+			txs:        extra(groceries...),
+			multiple:   1.5,
+			categories: []string{Food},
+			level:      AnomalyModerate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := DetectAnomalies(tt.txs, anomalyNow, tt.multiple)
+			if a.Baseline != BaselineOK || a.Weeks != 11 {
+				t.Fatalf("baseline = %s over %d weeks, want ok over 11", a.Baseline, a.Weeks)
+			}
+			var categories, large []string
+			for _, c := range a.Categories {
+				categories = append(categories, c.Category)
+				if want := fmt.Sprintf("%s this week %s vs typical %s", c.Category, usd(c.ThisWeek), usd(c.Typical)); c.Description != want {
+					t.Errorf("description = %q, want %q", c.Description, want)
+				}
+			}
+			for _, l := range a.LargeTransactions {
+				large = append(large, l.ID)
+			}
+			if fmt.Sprint(categories) != fmt.Sprint(tt.categories) || fmt.Sprint(large) != fmt.Sprint(tt.large) {
+				t.Errorf("flagged categories %v and payments %v, want %v and %v", categories, large, tt.categories, tt.large)
+			}
+			if a.Level != tt.level {
+				t.Errorf("level = %s with score %v, want %s", a.Level, a.Score, tt.level)
+			}
+			if flagged := len(categories)+len(large) > 0; (a.Goal != nil) != flagged || (a.Score > 0) != flagged {
+				t.Errorf("score %v and goal %+v, want them only if something was flagged", a.Score, a.Goal)
+			}
+		})
+	}
+}
+
+func TestDetectAnomalies_Food(t *testing.T) {
+	txs := append(spendingHistory(1, 89, 7), spending("feast", "Dinner", "", 240, anomalyNow.AddDate(0, 0, -1)))
+	a := DetectAnomalies(txs, anomalyNow, 0)
+	if len(a.Categories) != 1 {
+		t.Fatalf("Categories = %+v, want food", a.Categories)
+	}
+	food := a.Categories[0]
+	// Lunch costs about $84 a week, with a median absolute deviation of
+	// a few dollars.
+	if food.ThisWeek != 240 || food.Typical < 74 || food.Typical > 94 || food.MAD <= 0 || food.MAD > 10 || food.Deviations < 10 {
+		t.Errorf("food = %+v, want $240 against about $84", food)
+	}
+	if !strings.HasPrefix(food.Description, "food this week $240.00 vs typical $") {
+		t.Errorf("Description = %q", food.Description)
+	}
+	// All of this week's spending was the feast, and all of it unusual
+	// but the typical week.
+	if want := round2((240 - food.Typical) / 240 * 100); a.Score != want || a.Level != AnomalyHigh {
+		t.Errorf("score = %v (%s), want %v", a.Score, a.Level, want)
+	}
+	if a.Goal == nil || a.Goal.Currency != "USD" || a.Goal.Amount < a.TypicalWeek || a.Goal.Amount >= a.TypicalWeek+1 {
+		t.Errorf("Goal = %+v, want the typical week %v rounded up", a.Goal, a.TypicalWeek)
+	}
+}
+
+func TestDetectAnomalies_InsufficientData(t *testing.T) {
+	// Three full weeks of history and a spike.
+	txs := append(spendingHistory(3, 28, 0), spending("laptop", "New laptop", "", 900, anomalyNow.AddDate(0, 0, -1)))
+	a := DetectAnomalies(txs, anomalyNow, 0)
+	if a.Baseline != BaselineInsufficient || a.Weeks != 3 {
+		t.Fatalf("baseline = %s over %d weeks, want insufficient_data over 3", a.Baseline, a.Weeks)
+	}
+	if len(a.Categories) != 0 || len(a.LargeTransactions) != 0 || a.Score != 0 || a.TypicalWeek != 0 || a.Goal != nil || a.Level != AnomalyNone {
+		t.Errorf("DetectAnomalies() = %+v, want no statistics", a)
+	}
+	if a.ThisWeekTotal < 900 || a.Analyzed != len(txs) {
+		t.Errorf("this week %v from %d analyzed, want the spike counted", a.ThisWeekTotal, a.Analyzed)
+	}
+
+	// An incoming transfer five weeks ago is history enough.
+	txs = append(txs, txn.Transaction{Amount: "500", Currency: "USD", Direction: "credit", CreatedAt: anomalyNow.AddDate(0, 0, -35).Format(time.RFC3339)})
+	if a := DetectAnomalies(txs, anomalyNow, 0); a.Baseline != BaselineOK || a.Weeks != 4 {
+		t.Errorf("baseline = %s over %d weeks, want ok over 4", a.Baseline, a.Weeks)
+	}
+}
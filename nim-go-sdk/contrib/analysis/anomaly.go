@@ -0,0 +1,355 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/money"
+)
+
+// AnomalyWindowDays is how many days of transactions the spending baseline
+// is built from, the current week included.
+const AnomalyWindowDays = 90
+
+// MinBaselineWeeks is how many full weeks of history, before the current
+// one, a baseline needs. With fewer, the medians mean little.
+const MinBaselineWeeks = 4
+
+// DefaultAnomalyMultiple is how many times its typical week a category's
+// spending must reach to be flagged, unless the caller says otherwise.
+const DefaultAnomalyMultiple = 2.0
+
+// LargePercentile is the fraction of the baseline's payments a single
+// payment must exceed to be flagged as unusually large.
+const LargePercentile = 0.95
+
+// MinLargeSample is how many baseline payments the percentile needs.
+// With fewer, large payments aren't flagged.
+const MinLargeSample = 20
+
+// MinAnomalyExcess is how far, in USD, a category's week must be above its
+// typical week to be flagged, so a coffee in a rarely used category isn't.
+const MinAnomalyExcess = 10.0
+
+// madScale turns a median absolute deviation into an estimate of the
+// standard deviation of normally distributed data.
+const madScale = 1.4826
+
+// Baseline statuses.
+const (
+	BaselineOK           = "ok"
+	BaselineInsufficient = "insufficient_data"
+)
+
+// Anomaly levels, from the overall score.
+const (
+	AnomalyNone     = "none"
+	AnomalyLow      = "low"      // score under 25
+	AnomalyModerate = "moderate" // score under 50
+	AnomalyHigh     = "high"
+)
+
+// CategoryAnomaly is a category the user spent unusually much on this
+// week. Amounts are in USD.
+type CategoryAnomaly struct {
+	Category string  `json:"category"`
+	ThisWeek float64 `json:"this_week"`
+	Typical  float64 `json:"typical_week"` // the median week
+	MAD      float64 `json:"mad"`          // median absolute deviation of the weeks
+
+	// Deviations is how many robust standard deviations this week is above
+	// the typical one, or 0 if the weeks don't vary.
+	Deviations float64 `json:"deviations,omitempty"`
+
+	// Description compares the week to the typical one, e.g. "food this
+	// week $240.00 vs typical $90.00".
+	Description string `json:"description"`
+}
+
+// LargeTransaction is a payment this week bigger than LargePercentile of
+// the baseline's. Amounts are in USD.
+type LargeTransaction struct {
+	ID           string  `json:"id,omitempty"`
+	Counterparty string  `json:"counterparty,omitempty"`
+	Note         string  `json:"note,omitempty"`
+	Category     string  `json:"category"`
+	Amount       float64 `json:"amount"`
+	Date         string  `json:"date"`      // YYYY-MM-DD
+	Threshold    float64 `json:"threshold"` // the baseline's percentile
+
+	// Description compares the payment to the threshold, e.g. "$800.00 at
+	// Apple on 2026-05-18, more than 95% of your payments (up to $120.00)".
+	Description string `json:"description"`
+}
+
+// GoalInput is the input for spend_weekly_goal.
+type GoalInput struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// Anomalies is the result of comparing the current week's spending to the
+// user's baseline. Amounts are in USD: each payment's USD value, or its
+// amount if it has none.
+type Anomalies struct {
+	Baseline string `json:"baseline"` // BaselineOK or BaselineInsufficient
+
+	// Weeks counts the full weeks of history the baseline is built from.
+	Weeks int `json:"baseline_weeks"`
+
+	WeekStart string `json:"week_start"` // YYYY-MM-DD, the current week's first day
+
+	ThisWeekTotal float64 `json:"this_week_total"`
+	TypicalWeek   float64 `json:"typical_week_total,omitempty"` // the median week
+
+	// Categories and LargeTransactions are the flagged items, biggest
+	// first.
+	Categories        []CategoryAnomaly  `json:"categories,omitempty"`
+	LargeTransactions []LargeTransaction `json:"large_transactions,omitempty"`
+
+	// Score is the percentage of this week's spending that was unusual:
+	// above a flagged category's typical week, or in a flagged payment.
+	Score float64 `json:"score"`
+	Level string  `json:"level"`
+
+	// Goal is spend_weekly_goal's input for a limit at the typical week,
+	// set when something was flagged.
+	Goal *GoalInput `json:"goal,omitempty"`
+
+	// Analyzed counts the outgoing transactions in the window.
+	Analyzed int `json:"analyzed_transactions"`
+
+	// Skipped counts outgoing transactions whose amounts or dates couldn't
+	// be read, and so weren't considered.
+	Skipped int `json:"skipped_transactions,omitempty"`
+}
+
+// spend is one outgoing transaction in the window.
+type spend struct {
+	tx       txn.Transaction
+	at       time.Time
+	amount   money.Amount // USD
+	category string
+	week     int // 0 for the current week, 1 for the one before, ...
+}
+
+// DetectAnomalies compares the outgoing transactions of the week before
+// now to the AnomalyWindowDays before it. A category is flagged if its
+// week reached multiple times its median week, and multiple robust
+// standard deviations above it, and MinAnomalyExcess more; a payment is
+// flagged if it's bigger than LargePercentile of the baseline's. Weeks
+// are counted back from now, and only full weeks since the oldest
+// transaction of any kind make up the baseline; with fewer than
+// MinBaselineWeeks the result is BaselineInsufficient, without statistics.
+// Categories come from CategorizeNote on each note and counterparty.
+func DetectAnomalies(txs []txn.Transaction, now time.Time, multiple float64) Anomalies {
+	if multiple <= 1 {
+		multiple = DefaultAnomalyMultiple
+	}
+	maxWeeks := AnomalyWindowDays/7 - 1
+	since := now.AddDate(0, 0, -AnomalyWindowDays)
+
+	a := Anomalies{WeekStart: now.AddDate(0, 0, -7).Format(time.DateOnly)}
+	var spends []spend
+	var oldest time.Time
+	for _, tx := range txs {
+		at := txn.CreatedAt(tx)
+		if !at.IsZero() && (oldest.IsZero() || at.Before(oldest)) {
+			oldest = at
+		}
+		if !txn.IsDebit(tx) || !at.IsZero() && (at.Before(since) || at.After(now)) {
+			continue
+		}
+		a.Analyzed++
+		amount, err := usdSpent(tx)
+		if err != nil || at.IsZero() {
+			a.Skipped++
+			continue
+		}
+		week := int(now.Sub(at) / (7 * 24 * time.Hour))
+		if week > maxWeeks {
+			continue
+		}
+		spends = append(spends, spend{
+			tx:       tx,
+			at:       at,
+			amount:   amount,
+			category: CategorizeNote(tx.Note + " " + tx.Counterparty),
+			week:     week,
+		})
+	}
+
+	// Week w covers (now-7(w+1) days, now-7w days], so it's full if the
+	// oldest transaction is no later than its start.
+	for w := 1; w <= maxWeeks && !oldest.IsZero() && !oldest.After(now.AddDate(0, 0, -7*(w+1))); w++ {
+		a.Weeks = w
+	}
+
+	weekly := make(map[string][]float64) // category to its spending in weeks 0..a.Weeks
+	totals := make([]float64, a.Weeks+1)
+	var history []float64
+	for _, s := range spends {
+		if s.week > a.Weeks {
+			continue
+		}
+		if weekly[s.category] == nil {
+			weekly[s.category] = make([]float64, a.Weeks+1)
+		}
+		f := s.amount.Float64()
+		weekly[s.category][s.week] += f
+		totals[s.week] += f
+		if s.week > 0 {
+			history = append(history, f)
+		}
+	}
+	a.ThisWeekTotal = round2(totals[0])
+	if a.Weeks < MinBaselineWeeks {
+		a.Baseline, a.Level = BaselineInsufficient, AnomalyNone
+		return a
+	}
+	a.Baseline = BaselineOK
+	a.TypicalWeek = round2(median(totals[1:]))
+
+	// excess is this week's unusual spending.
+	var excess float64
+	flagged := make(map[string]bool)
+	for category, weeks := range weekly {
+		typical, deviation := median(weeks[1:]), mad(weeks[1:])
+		this := weeks[0]
+		above := this - typical
+		if this < multiple*typical || above < multiple*madScale*deviation || above < MinAnomalyExcess {
+			continue
+		}
+		anomaly := CategoryAnomaly{
+			Category:    category,
+			ThisWeek:    round2(this),
+			Typical:     round2(typical),
+			MAD:         round2(deviation),
+			Description: fmt.Sprintf("%s this week %s vs typical %s", category, usd(this), usd(typical)),
+		}
+		if deviation > 0 {
+			anomaly.Deviations = round2(above / (madScale * deviation))
+		}
+		a.Categories = append(a.Categories, anomaly)
+		flagged[category] = true
+		excess += above
+	}
+	sort.Slice(a.Categories, func(i, j int) bool {
+		if a.Categories[i].ThisWeek != a.Categories[j].ThisWeek {
+			return a.Categories[i].ThisWeek > a.Categories[j].ThisWeek
+		}
+		return a.Categories[i].Category < a.Categories[j].Category
+	})
+
+	if len(history) >= MinLargeSample {
+		threshold := percentile(history, LargePercentile)
+		for _, s := range spends {
+			f := s.amount.Float64()
+			if s.week != 0 || f <= threshold {
+				continue
+			}
+			a.LargeTransactions = append(a.LargeTransactions, largeTransaction(s, threshold))
+			if !flagged[s.category] {
+				// Otherwise its category's excess counts it.
+				excess += f
+			}
+		}
+		sort.Slice(a.LargeTransactions, func(i, j int) bool {
+			return a.LargeTransactions[i].Amount > a.LargeTransactions[j].Amount
+		})
+	}
+
+	found := len(a.Categories)+len(a.LargeTransactions) > 0
+	if totals[0] > 0 {
+		a.Score = round2(math.Min(100, excess/totals[0]*100))
+	}
+	switch {
+	case !found:
+		a.Level = AnomalyNone
+	case a.Score < 25:
+		a.Level = AnomalyLow
+	case a.Score < 50:
+		a.Level = AnomalyModerate
+	default:
+		a.Level = AnomalyHigh
+	}
+	if found && a.TypicalWeek > 0 {
+		a.Goal = &GoalInput{Amount: math.Ceil(a.TypicalWeek), Currency: "USD"}
+	}
+	return a
+}
+
+// largeTransaction describes s, a payment above threshold.
+func largeTransaction(s spend, threshold float64) LargeTransaction {
+	date := s.at.Format(time.DateOnly)
+	desc := usd(s.amount.Float64())
+	switch {
+	case s.tx.Counterparty != "":
+		desc += " at " + s.tx.Counterparty
+	case s.tx.Note != "":
+		desc += " for " + s.tx.Note
+	}
+	return LargeTransaction{
+		ID:           s.tx.ID,
+		Counterparty: s.tx.Counterparty,
+		Note:         s.tx.Note,
+		Category:     s.category,
+		Amount:       s.amount.Float64(),
+		Date:         date,
+		Threshold:    round2(threshold),
+		Description:  fmt.Sprintf("%s on %s, more than %d%% of your payments (up to %s)", desc, date, int(LargePercentile*100), usd(threshold)),
+	}
+}
+
+// usdSpent parses tx's outgoing amount in USD: its USD value, or else its
+// amount.
+func usdSpent(tx txn.Transaction) (money.Amount, error) {
+	if tx.USDValue != "" {
+		amount, err := money.FromUSDValue(tx.USDValue)
+		return amount.Abs(), err
+	}
+	amount, err := money.ParseAmount(tx.Amount, "USD")
+	return amount.Abs(), err
+}
+
+// usd formats f dollars, e.g. "$240.00".
+func usd(f float64) string {
+	amount, _ := money.FromFloat(f, "USD")
+	return amount.String()
+}
+
+// median returns the median of xs, or 0 if there are none.
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+// mad returns the median absolute deviation of xs from their median.
+func mad(xs []float64) float64 {
+	m := median(xs)
+	deviations := make([]float64, len(xs))
+	for i, x := range xs {
+		deviations[i] = math.Abs(x - m)
+	}
+	return median(deviations)
+}
+
+// percentile returns the p quantile of xs, 0 < p < 1, by the nearest-rank
+// method.
+func percentile(xs []float64, p float64) float64 {
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
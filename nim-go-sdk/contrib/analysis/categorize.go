@@ -0,0 +1,193 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/llm"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// Spending categories.
+const (
+	Food          = "food"
+	Travel        = "travel"
+	Subscription  = "subscription"
+	Entertainment = "entertainment"
+	Electronics   = "electronics"
+	Miscellaneous = "miscellaneous"
+)
+
+// Categories lists every spending category.
+var Categories = []string{Food, Travel, Subscription, Entertainment, Electronics, Miscellaneous}
+
+// keywords maps note keywords to categories, checked in order so that
+// e.g. "netflix monthly" is a subscription rather than travel.
+var keywords = []struct {
+	category string
+	words    []string
+}{
+	{Subscription, []string{"subscription", "netflix", "spotify", "hulu", "prime", "membership", "premium", "monthly"}},
+	{Entertainment, []string{"movie", "concert", "game", "entertainment", "video"}},
+	{Food, []string{"food", "restaurant", "cafe", "coffee", "lunch", "dinner", "grocer", "meal"}},
+	{Travel, []string{"travel", "uber", "lyft", "flight", "hotel", "gas", "parking", "taxi", "bus", "train", "ticket"}},
+	{Electronics, []string{"phone", "laptop", "computer", "electronics", "gadget", "tech"}},
+}
+
+// CategorizeNote categorizes a transaction note by keyword.
+// Notes matching no keyword are Miscellaneous.
+func CategorizeNote(note string) string {
+	note = strings.ToLower(note)
+	for _, k := range keywords {
+		for _, w := range k.words {
+			if strings.Contains(note, w) {
+				return k.category
+			}
+		}
+	}
+	return Miscellaneous
+}
+
+// Breakdown counts categorized notes.
+type Breakdown struct {
+	Categories    map[string]int `json:"categories"`
+	TotalAnalyzed int            `json:"total_analyzed"`
+	Breakdown     []string       `json:"breakdown"`
+
+	// Sources counts the analyzed notes by txn.Source. Set only when some
+	// were imported from another bank.
+	Sources map[string]int `json:"sources,omitempty"`
+}
+
+// NewBreakdown counts notes by their categories; categories[i] is the
+// category of notes[i].
+func NewBreakdown(notes, categories []string) Breakdown {
+	b := Breakdown{
+		Categories:    make(map[string]int, len(Categories)),
+		TotalAnalyzed: len(notes),
+		Breakdown:     make([]string, 0, len(notes)),
+	}
+	for _, c := range Categories {
+		b.Categories[c] = 0
+	}
+	for i, note := range notes {
+		b.Categories[categories[i]]++
+		b.Breakdown = append(b.Breakdown, fmt.Sprintf("%s: %s", note, categories[i]))
+	}
+	return b
+}
+
+// Categorizer categorizes transaction notes with a model, falling back to
+// keyword matching when no model is configured or the model's answer
+// can't be used.
+type Categorizer struct {
+	completer  llm.Completer
+	structured llm.StructuredCompleter
+}
+
+// NewCategorizer creates a categorizer that asks completer for a JSON
+// array of categories. A nil completer categorizes by keyword only.
+func NewCategorizer(completer llm.Completer) *Categorizer {
+	return &Categorizer{completer: completer}
+}
+
+// NewStructuredCategorizer creates a categorizer that asks model for
+// structured output, e.g. the server (see server.Server.CompleteStructured),
+// so answers are always well-formed. A nil model categorizes by keyword only.
+func NewStructuredCategorizer(model llm.StructuredCompleter) *Categorizer {
+	return &Categorizer{structured: model}
+}
+
+// Categorize returns the category of each note.
+func (c *Categorizer) Categorize(ctx context.Context, notes []string) []string {
+	if c != nil && (c.completer != nil || c.structured != nil) && len(notes) > 0 {
+		categories, err := c.categorizeWithModel(ctx, notes)
+		if err == nil {
+			return categories
+		}
+		log.Printf("Model categorization failed, using keywords: %v", err)
+	}
+
+	categories := make([]string, len(notes))
+	for i, note := range notes {
+		categories[i] = CategorizeNote(note)
+	}
+	return categories
+}
+
+func (c *Categorizer) categorizeWithModel(ctx context.Context, notes []string) ([]string, error) {
+	var prompt strings.Builder
+	prompt.WriteString("Categorize each of the following transaction notes into exactly one of these categories: ")
+	prompt.WriteString(strings.Join(Categories, ", "))
+	instruction := "Return ONLY a JSON array of category names, one per note, in the same order."
+	if c.structured != nil {
+		instruction = "Give one category per note, in the same order."
+	}
+	fmt.Fprintf(&prompt, ".\n%s\n\nTransaction notes:\n", instruction)
+	for i, note := range notes {
+		fmt.Fprintf(&prompt, "%d. %s\n", i+1, note)
+	}
+
+	if c.structured != nil {
+		out, err := c.structured.CompleteStructured(ctx, prompt.String(), categoriesSchema, engine.WithStructuredMaxTokens(2048))
+		if err != nil {
+			return nil, err
+		}
+		var answer struct {
+			Categories json.RawMessage `json:"categories"`
+		}
+		if err := json.Unmarshal(out, &answer); err != nil {
+			return nil, fmt.Errorf("invalid categories: %w", err)
+		}
+		return parseCategories(string(answer.Categories), len(notes))
+	}
+
+	text, err := c.completer.Complete(ctx, prompt.String(), 2048)
+	if err != nil {
+		return nil, err
+	}
+	return parseCategories(text, len(notes))
+}
+
+// categoriesSchema is the structured output of categorization.
+var categoriesSchema = tools.ObjectSchema(map[string]interface{}{
+	"categories": map[string]interface{}{
+		"type":        "array",
+		"description": "The category of each note, in order",
+		"items":       map[string]interface{}{"type": "string", "enum": Categories},
+	},
+}, "categories")
+
+// parseCategories reads the model's JSON array of categories. Unknown
+// categories become Miscellaneous.
+func parseCategories(text string, want int) ([]string, error) {
+	start, end := strings.Index(text, "["), strings.LastIndex(text, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON array in response")
+	}
+	var categories []string
+	if err := json.Unmarshal([]byte(text[start:end+1]), &categories); err != nil {
+		return nil, fmt.Errorf("invalid categories: %w", err)
+	}
+	if len(categories) != want {
+		return nil, fmt.Errorf("got %d categories for %d notes", len(categories), want)
+	}
+	for i, c := range categories {
+		categories[i] = normalizeCategory(c)
+	}
+	return categories, nil
+}
+
+func normalizeCategory(c string) string {
+	c = strings.ToLower(strings.TrimSpace(c))
+	for _, known := range Categories {
+		if c == known {
+			return c
+		}
+	}
+	return Miscellaneous
+}
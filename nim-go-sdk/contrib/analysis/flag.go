@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/money"
+)
+
+// unnecessaryCategories are non-essential categories worth cutting back on.
+var unnecessaryCategories = map[string]bool{
+	Entertainment: true,
+	Subscription:  true,
+}
+
+// essentialShares caps spending in essential categories, as a share of
+// monthly income.
+var essentialShares = map[string]float64{
+	Food:        0.15,
+	Travel:      0.10,
+	Electronics: 0.05,
+}
+
+// unnecessaryMinimum is the spend below which a non-essential category
+// isn't worth flagging.
+const unnecessaryMinimum = 10
+
+// FlaggedItem is a spending category that has been flagged.
+type FlaggedItem struct {
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+	Count    int     `json:"count"`
+	Reason   string  `json:"reason"`
+}
+
+// Flagged is the result of flagging a user's spending.
+type Flagged struct {
+	// Unnecessary are non-essential categories, e.g. entertainment.
+	Unnecessary []FlaggedItem `json:"unnecessary"`
+
+	// Excessive are essential categories spent beyond their recommended share.
+	Excessive []FlaggedItem `json:"excessive"`
+
+	// TotalUnnecessary is the total spent in unnecessary categories.
+	TotalUnnecessary float64 `json:"total_unnecessary"`
+
+	// TotalExcessive is the total spent beyond the recommended shares.
+	TotalExcessive float64 `json:"total_excessive"`
+
+	// Skipped counts outgoing transactions whose amounts couldn't be
+	// parsed, and so weren't considered.
+	Skipped int `json:"skipped_transactions,omitempty"`
+}
+
+// PotentialSavings is how much the user could save per month by cutting
+// the flagged spending.
+func (f Flagged) PotentialSavings() float64 {
+	unnecessary, _ := money.FromFloat(f.TotalUnnecessary, "")
+	excessive, _ := money.FromFloat(f.TotalExcessive, "")
+	total, _ := unnecessary.Add(excessive)
+	return total.Float64()
+}
+
+// Flag categorizes outgoing transactions by note and flags unnecessary
+// categories, and essential categories over their recommended share of
+// monthlyIncome. Items are ordered by category.
+func Flag(txs []txn.Transaction, monthlyIncome float64) Flagged {
+	result := Flagged{
+		Unnecessary: []FlaggedItem{},
+		Excessive:   []FlaggedItem{},
+	}
+	spent := make(map[string]money.Amount)
+	counts := make(map[string]int)
+	for _, tx := range txs {
+		if !txn.IsDebit(tx) {
+			continue
+		}
+		category := CategorizeNote(tx.Note)
+		amount, err := unsigned(tx)
+		if err == nil {
+			amount, err = spent[category].Add(amount)
+		}
+		if err != nil {
+			result.Skipped++
+			continue
+		}
+		spent[category] = amount
+		counts[category]++
+	}
+
+	categories := make([]string, 0, len(spent))
+	for c := range spent {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+
+	var totalUnnecessary, totalExcessive money.Amount
+	for _, category := range categories {
+		amount := spent[category].Float64()
+		if unnecessaryCategories[category] && amount > unnecessaryMinimum {
+			result.Unnecessary = append(result.Unnecessary, FlaggedItem{
+				Category: category,
+				Amount:   amount,
+				Count:    counts[category],
+				Reason:   "Non-essential, consider cutting back",
+			})
+			totalUnnecessary, _ = totalUnnecessary.Add(spent[category])
+		}
+
+		threshold, err := money.FromFloat(monthlyIncome*essentialShares[category], "")
+		if err != nil || threshold.Sign() <= 0 {
+			continue
+		}
+		if excess, err := spent[category].Sub(threshold); err == nil && excess.Sign() > 0 {
+			result.Excessive = append(result.Excessive, FlaggedItem{
+				Category: category,
+				Amount:   amount,
+				Count:    counts[category],
+				Reason:   fmt.Sprintf("%.0f%% over recommended budget", excess.Float64()/threshold.Float64()*100),
+			})
+			totalExcessive, _ = totalExcessive.Add(excess)
+		}
+	}
+	result.TotalUnnecessary = totalUnnecessary.Float64()
+	result.TotalExcessive = totalExcessive.Float64()
+	return result
+}
+
+// EssentialTarget is the recommended monthly spend for an essential
+// category given monthlyIncome, or zero if the category has no target.
+func EssentialTarget(category string, monthlyIncome float64) float64 {
+	return monthlyIncome * essentialShares[category]
+}
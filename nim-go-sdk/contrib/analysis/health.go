@@ -0,0 +1,49 @@
+package analysis
+
+import "math"
+
+// BalanceHealth classifies how much money a user has to work with.
+// Advice built on a balance (savings, budgets, deposits) only makes sense
+// for positive balances, so callers consult the health before giving it.
+type BalanceHealth string
+
+// Balance health levels, from best to worst.
+const (
+	BalanceHealthy  BalanceHealth = "healthy"
+	BalanceLow      BalanceHealth = "low"
+	BalanceZero     BalanceHealth = "zero"
+	BalanceNegative BalanceHealth = "negative"
+)
+
+// HealthThresholds configures ClassifyBalance.
+type HealthThresholds struct {
+	// Low is the balance below which a positive balance is low.
+	Low float64
+
+	// Dust is the largest balance, either side of zero, that counts as zero,
+	// so rounding leftovers aren't mistaken for funds or debt.
+	Dust float64
+}
+
+// DefaultHealthThresholds treats balances under 2 as low and anything
+// within a cent of zero as zero.
+var DefaultHealthThresholds = HealthThresholds{Low: 2, Dust: 0.01}
+
+// ClassifyBalance classifies balance against t.
+func ClassifyBalance(balance float64, t HealthThresholds) BalanceHealth {
+	switch {
+	case math.Abs(balance) <= t.Dust:
+		return BalanceZero
+	case balance < 0:
+		return BalanceNegative
+	case balance < t.Low:
+		return BalanceLow
+	}
+	return BalanceHealthy
+}
+
+// CanAdvise reports whether h leaves money to base savings or budget
+// advice on.
+func (h BalanceHealth) CanAdvise() bool {
+	return h == BalanceHealthy || h == BalanceLow
+}
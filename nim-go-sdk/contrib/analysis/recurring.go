@@ -0,0 +1,304 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/money"
+)
+
+// Recurring payment cadences. They match create_calendar_reminder's
+// frequencies, so a series can be handed to it as is.
+const (
+	CadenceWeekly  = "weekly"
+	CadenceMonthly = "monthly"
+)
+
+// MinOccurrences is how many payments a series needs to count as recurring.
+const MinOccurrences = 3
+
+// DayTolerance is how many days a payment may land either side of its
+// expected date and still belong to a series.
+const DayTolerance = 3
+
+// AmountTolerance is how far, as a fraction, a payment may differ from the
+// one before it and still be the same charge, e.g. after a currency
+// conversion. Bigger differences are taken as a price change.
+const AmountTolerance = 0.05
+
+// RecurringPayment is a series of payments to the same payee at a regular
+// cadence, such as a subscription.
+type RecurringPayment struct {
+	Payee    string `json:"payee"`
+	Cadence  string `json:"cadence"` // CadenceWeekly or CadenceMonthly
+	Currency string `json:"currency"`
+	Payments int    `json:"payments"`
+
+	AverageAmount float64 `json:"average_amount"`
+	LastAmount    float64 `json:"last_amount"`
+
+	// PreviousAmount is what the payee charged before its last price
+	// change, if the price changed during the series.
+	PreviousAmount float64 `json:"previous_amount,omitempty"`
+
+	LastPayment string `json:"last_payment"` // YYYY-MM-DD
+	NextCharge  string `json:"next_charge"`  // YYYY-MM-DD, predicted
+
+	// Lapsed is set if the next charge is overdue, e.g. because the
+	// subscription was cancelled.
+	Lapsed bool `json:"lapsed,omitempty"`
+
+	// Reminder is create_calendar_reminder's input for a reminder the day
+	// before each charge. Lapsed series have none.
+	Reminder *ReminderInput `json:"reminder,omitempty"`
+}
+
+// ReminderInput is the input for create_calendar_reminder.
+type ReminderInput struct {
+	Frequency string  `json:"frequency"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+	StartDate string  `json:"start_date"` // YYYY-MM-DD
+}
+
+// Recurring is the result of looking for recurring payments.
+type Recurring struct {
+	Series []RecurringPayment `json:"series"`
+
+	// MonthlyTotal is what the active series cost a month, by currency.
+	MonthlyTotal map[string]float64 `json:"monthly_total,omitempty"`
+
+	// Analyzed counts the outgoing transactions considered.
+	Analyzed int `json:"analyzed_transactions"`
+
+	// Skipped counts outgoing transactions whose amounts or dates couldn't
+	// be read, and so weren't considered.
+	Skipped int `json:"skipped_transactions,omitempty"`
+}
+
+// payment is one outgoing transaction of a possible series.
+type payment struct {
+	payee  string
+	at     time.Time
+	amount money.Amount
+}
+
+// DetectRecurring finds the series of outgoing payments in txs that recur
+// weekly or monthly, at least MinOccurrences times, as of now. Payments
+// are grouped by payee, the counterparty or else the note, ignoring case,
+// digits and punctuation, and by currency. Incoming transactions are
+// ignored. Series are sorted by next charge, soonest first.
+func DetectRecurring(txs []txn.Transaction, now time.Time) Recurring {
+	var r Recurring
+	groups := make(map[[2]string][]payment)
+	for _, tx := range txs {
+		if !txn.IsDebit(tx) {
+			continue
+		}
+		key := normalizePayee(tx.Counterparty)
+		payee := tx.Counterparty
+		if key == "" {
+			key, payee = normalizePayee(tx.Note), tx.Note
+		}
+		if key == "" {
+			continue
+		}
+		r.Analyzed++
+		amount, err := txn.SpentMoney(tx)
+		at := txn.CreatedAt(tx)
+		if err != nil || at.IsZero() {
+			r.Skipped++
+			continue
+		}
+		k := [2]string{key, tx.Currency}
+		groups[k] = append(groups[k], payment{payee: strings.TrimSpace(payee), at: at, amount: amount})
+	}
+
+	for _, payments := range groups {
+		sort.Slice(payments, func(i, j int) bool { return payments[i].at.Before(payments[j].at) })
+		for _, series := range mergeSeries(clusterByAmount(payments)) {
+			if p, ok := recurringPayment(series, now); ok {
+				r.Series = append(r.Series, p)
+			}
+		}
+	}
+	sort.Slice(r.Series, func(i, j int) bool {
+		if r.Series[i].NextCharge != r.Series[j].NextCharge {
+			return r.Series[i].NextCharge < r.Series[j].NextCharge
+		}
+		return r.Series[i].Payee < r.Series[j].Payee
+	})
+
+	for _, p := range r.Series {
+		if p.Lapsed {
+			continue
+		}
+		if r.MonthlyTotal == nil {
+			r.MonthlyTotal = make(map[string]float64)
+		}
+		monthly := p.LastAmount
+		if p.Cadence == CadenceWeekly {
+			monthly = p.LastAmount * 52 / 12
+		}
+		r.MonthlyTotal[p.Currency] = round2(r.MonthlyTotal[p.Currency] + monthly)
+	}
+	return r
+}
+
+// normalizePayee reduces a counterparty or note to the words that name
+// the payee, e.g. "NETFLIX.COM #1234" to "netflix com".
+func normalizePayee(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// clusterByAmount splits a payee's payments, oldest first, into runs of
+// similar amounts, so one-off purchases from a payee with a subscription
+// aren't mistaken for part of it. Each payment joins the run whose latest
+// amount it's closest to within AmountTolerance, letting amounts drift.
+func clusterByAmount(payments []payment) [][]payment {
+	var clusters [][]payment
+	for _, p := range payments {
+		best, bestDiff := -1, math.Inf(1)
+		for i, c := range clusters {
+			if diff := relativeDiff(c[len(c)-1].amount, p.amount); diff <= AmountTolerance && diff < bestDiff {
+				best, bestDiff = i, diff
+			}
+		}
+		if best < 0 {
+			clusters = append(clusters, []payment{p})
+			continue
+		}
+		clusters[best] = append(clusters[best], p)
+	}
+	return clusters
+}
+
+// mergeSeries joins runs that follow on from each other at the same
+// cadence: the same charge before and after a price change.
+func mergeSeries(clusters [][]payment) [][]payment {
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i][0].at.Before(clusters[j][0].at) })
+	var merged [][]payment
+next:
+	for _, c := range clusters {
+		for i := len(merged) - 1; i >= 0; i-- {
+			prev := merged[i]
+			if !prev[len(prev)-1].at.Before(c[0].at) {
+				continue
+			}
+			joined := append(append([]payment(nil), prev...), c...)
+			if want := cadence(prev); want != "" && want == cadence(joined) {
+				merged[i] = joined
+				continue next
+			}
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+// recurringPayment describes series as of now, if it's long and regular
+// enough to be recurring.
+func recurringPayment(series []payment, now time.Time) (RecurringPayment, bool) {
+	if len(series) < MinOccurrences {
+		return RecurringPayment{}, false
+	}
+	c := cadence(series)
+	if c == "" {
+		return RecurringPayment{}, false
+	}
+
+	amounts := make([]money.Amount, len(series))
+	for i, p := range series {
+		amounts[i] = p.amount
+	}
+	total, err := money.Sum(amounts...)
+	if err != nil {
+		return RecurringPayment{}, false
+	}
+
+	last := series[len(series)-1]
+	next := nextCharge(last.at, c)
+	p := RecurringPayment{
+		Payee:         last.payee,
+		Cadence:       c,
+		Currency:      last.amount.Currency,
+		Payments:      len(series),
+		AverageAmount: round2(total.Float64() / float64(len(series))),
+		LastAmount:    last.amount.Float64(),
+		LastPayment:   last.at.Format(time.DateOnly),
+		NextCharge:    next.Format(time.DateOnly),
+		Lapsed:        now.After(next.AddDate(0, 0, DayTolerance+1)),
+	}
+	for i := len(series) - 1; i > 0; i-- {
+		if relativeDiff(series[i-1].amount, series[i].amount) > AmountTolerance {
+			p.PreviousAmount = series[i-1].amount.Float64()
+			break
+		}
+	}
+	if !p.Lapsed {
+		p.Reminder = &ReminderInput{
+			Frequency: c,
+			Amount:    p.LastAmount,
+			Currency:  p.Currency,
+			StartDate: next.AddDate(0, 0, -1).Format(time.DateOnly),
+		}
+	}
+	return p, true
+}
+
+// cadence returns the cadence every payment in series, oldest first, keeps
+// to within DayTolerance of the one before, or "" if there's none.
+func cadence(series []payment) string {
+	if len(series) < 2 {
+		return ""
+	}
+	for _, c := range []string{CadenceWeekly, CadenceMonthly} {
+		regular := true
+		for i := 1; i < len(series) && regular; i++ {
+			expected := nextCharge(series[i-1].at, c)
+			regular = math.Abs(series[i].at.Sub(expected).Hours()) <= DayTolerance*24
+		}
+		if regular {
+			return c
+		}
+	}
+	return ""
+}
+
+// nextCharge is when a payment made at t is next due at cadence c.
+// Monthly charges fall on the same day of the month, or its last day in
+// shorter months.
+func nextCharge(t time.Time, c string) time.Time {
+	if c == CadenceWeekly {
+		return t.AddDate(0, 0, 7)
+	}
+	next := t.AddDate(0, 1, 0)
+	if next.Day() != t.Day() {
+		// The month is too short, so AddDate overflowed into the next.
+		next = next.AddDate(0, 0, -next.Day())
+	}
+	return next
+}
+
+// relativeDiff is how far b is from a, as a fraction of a.
+func relativeDiff(a, b money.Amount) float64 {
+	x, y := a.Float64(), b.Float64()
+	if x == 0 {
+		if y == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return math.Abs(y-x) / math.Abs(x)
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
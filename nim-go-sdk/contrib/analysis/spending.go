@@ -0,0 +1,117 @@
+// Package analysis provides spending analysis for Liminal accounts:
+// period summaries, note categorization, flagging of unnecessary or
+// excessive spending, recurring payment and spending anomaly detection,
+// and savings vault APY stability scoring. Tools returns the agent tools
+// built on them.
+package analysis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/money"
+)
+
+// Summary is an overview of spending over a period.
+type Summary struct {
+	Days          int      `json:"period_days"`
+	TotalSpent    float64  `json:"total_spent"`
+	TotalReceived float64  `json:"total_received"`
+	SpendCount    int      `json:"spend_count"`
+	ReceiveCount  int      `json:"receive_count"`
+	AvgDailySpend float64  `json:"avg_daily_spend"`
+	Velocity      string   `json:"velocity"`
+	Insights      []string `json:"insights"`
+
+	// Skipped counts the period's transactions whose amounts couldn't be
+	// parsed, and so aren't in the totals.
+	Skipped int `json:"skipped_transactions,omitempty"`
+
+	// Sources counts the period's transactions by txn.Source. Set only
+	// when some were imported from another bank.
+	Sources map[string]int `json:"sources,omitempty"`
+}
+
+// Summarize analyzes the transactions created in the days before now.
+func Summarize(txs []txn.Transaction, now time.Time, days int) Summary {
+	if days <= 0 {
+		days = 30
+	}
+	since := now.AddDate(0, 0, -days)
+
+	s := Summary{Days: days}
+	var spent, received money.Amount
+	sources := make(map[string]int)
+	for _, tx := range txs {
+		if at := txn.CreatedAt(tx); at.IsZero() || at.Before(since) || at.After(now) {
+			continue
+		}
+		sources[txn.Source(tx)]++
+		if !txn.IsDebit(tx) && !txn.IsCredit(tx) {
+			continue
+		}
+		total, count := &received, &s.ReceiveCount
+		if txn.IsDebit(tx) {
+			total, count = &spent, &s.SpendCount
+		}
+		amount, err := unsigned(tx)
+		if err == nil {
+			amount, err = total.Add(amount)
+		}
+		if err != nil {
+			s.Skipped++
+			continue
+		}
+		*total = amount
+		*count++
+	}
+	s.TotalSpent = spent.Float64()
+	s.TotalReceived = received.Float64()
+
+	avg, _ := money.FromFloat(s.TotalSpent/float64(days), "")
+	s.AvgDailySpend = avg.Float64()
+	s.Velocity = Velocity(s.SpendCount, days)
+	if s.SpendCount == 0 && s.ReceiveCount == 0 && s.Skipped == 0 {
+		s.Insights = []string{"No transactions found in the specified period"}
+		return s
+	}
+	s.Insights = []string{
+		fmt.Sprintf("You made %d spending transactions over %d days", s.SpendCount, days),
+		fmt.Sprintf("Average daily spend: $%.2f", s.AvgDailySpend),
+		"Consider setting up savings goals to build financial cushion",
+	}
+	if s.Skipped > 0 {
+		s.Insights = append(s.Insights, fmt.Sprintf("%d transactions had amounts that couldn't be read and aren't counted", s.Skipped))
+	}
+	if n := sources[txn.SourceExternal]; n > 0 {
+		s.Sources = sources
+		s.Insights = append(s.Insights, fmt.Sprintf("%d of these transactions were imported from another bank", n))
+	}
+	return s
+}
+
+// unsigned parses tx's amount without its sign. Summaries total every
+// currency together, so the amount has no currency.
+func unsigned(tx txn.Transaction) (money.Amount, error) {
+	amount, err := money.ParseAmount(tx.Amount, "")
+	return amount.Abs(), err
+}
+
+// Velocity describes how often the user spends: "low" (under 2 transactions
+// a week), "moderate" (under 7), or "high".
+func Velocity(transactionCount, days int) string {
+	if days <= 0 {
+		return "low"
+	}
+	perWeek := float64(transactionCount) / float64(days) * 7
+
+	switch {
+	case perWeek < 2:
+		return "low"
+	case perWeek < 7:
+		return "moderate"
+	default:
+		return "high"
+	}
+}
@@ -0,0 +1,223 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// HistoryWindow is how many APY values an APYHistory keeps.
+const HistoryWindow = 30
+
+// StabilityThreshold is the APY, in percent, a vault should stay above to
+// count as giving consistently good returns.
+const StabilityThreshold = 5.5
+
+// APYHistory tracks the most recent distinct APY values of a savings vault.
+// It is safe for concurrent use.
+type APYHistory struct {
+	mu     sync.Mutex
+	values []float64
+}
+
+// NewAPYHistory creates a history seeded with values, oldest first.
+// Only the last HistoryWindow values are kept.
+func NewAPYHistory(seed ...float64) *APYHistory {
+	h := &APYHistory{values: append([]float64(nil), seed...)}
+	h.trim()
+	return h
+}
+
+// Add records a new APY value. Values equal to the latest are skipped, so
+// the history only moves when the rate changes. Reports whether it was added.
+func (h *APYHistory) Add(apy float64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if n := len(h.values); n > 0 && h.values[n-1] == apy {
+		return false
+	}
+	h.values = append(h.values, apy)
+	h.trim()
+	return true
+}
+
+// Values returns a copy of the history, oldest first.
+func (h *APYHistory) Values() []float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]float64(nil), h.values...)
+}
+
+func (h *APYHistory) trim() {
+	if len(h.values) > HistoryWindow {
+		h.values = h.values[len(h.values)-HistoryWindow:]
+	}
+}
+
+// Refresh fetches the current APY for currency's vault and adds it.
+func (h *APYHistory) Refresh(ctx context.Context, exec core.ToolExecutor, userID, requestID, currency string) error {
+	rates, err := txn.VaultRates(ctx, exec, userID, requestID)
+	if err != nil {
+		return err
+	}
+	apy, ok := rates[currency]
+	if !ok {
+		return fmt.Errorf("no %s vault in response", currency)
+	}
+	h.Add(apy)
+	return nil
+}
+
+// Poll refreshes the history from currency's vault immediately and then
+// every interval, until ctx is cancelled. Failures are logged and retried
+// on the next tick.
+func (h *APYHistory) Poll(ctx context.Context, exec core.ToolExecutor, currency string, interval time.Duration) {
+	// Background polling has no originating request, so identify it explicitly
+	const pollerID = "system-polling"
+	ctx = core.WithIdentity(ctx, core.Identity{UserID: pollerID})
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		requestID := fmt.Sprintf("poll-vault-rates-%d", time.Now().Unix())
+		if err := h.Refresh(ctx, exec, pollerID, requestID, currency); err != nil {
+			log.Printf("Failed to poll vault rates: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stability scores how dependable a vault's APY has been.
+type Stability struct {
+	// Score is the weighted stability score, 0-100.
+	Score float64 `json:"score"`
+
+	// Label describes the score: Stable, Opportunistic, Spiky, or Unreliable.
+	Label string `json:"label"`
+
+	// Volatility scores how little the APY jumps around, 0-100.
+	Volatility float64 `json:"volatility"`
+
+	// Drawdown scores how rarely the APY drops suddenly, 0-100.
+	Drawdown float64 `json:"drawdown"`
+
+	// TimeAbove is the share of the history above StabilityThreshold, 0-100.
+	TimeAbove float64 `json:"time_above"`
+
+	Current float64 `json:"current_apy"`
+	Average float64 `json:"average_apy"`
+	Min     float64 `json:"min_apy"`
+	Max     float64 `json:"max_apy"`
+}
+
+// ScoreStability scores an APY history, oldest first. Volatility weighs
+// 40%, drawdowns 35%, and time above StabilityThreshold 25%.
+func ScoreStability(history []float64) Stability {
+	volatility := volatilityScore(history)
+	drawdown := drawdownScore(history)
+	timeAbove := timeAboveScore(history, StabilityThreshold)
+
+	s := Stability{
+		Score:      (0.40*volatility + 0.35*drawdown + 0.25*timeAbove) * 100,
+		Volatility: volatility * 100,
+		Drawdown:   drawdown * 100,
+		TimeAbove:  timeAbove * 100,
+	}
+	s.Label = stabilityLabel(s.Score)
+
+	if len(history) > 0 {
+		s.Current = history[len(history)-1]
+		s.Min, s.Max = history[0], history[0]
+		for _, v := range history {
+			s.Min = math.Min(s.Min, v)
+			s.Max = math.Max(s.Max, v)
+			s.Average += v
+		}
+		s.Average /= float64(len(history))
+	}
+	return s
+}
+
+func stabilityLabel(score float64) string {
+	switch {
+	case score > 80:
+		return "Stable 🟢"
+	case score > 60:
+		return "Opportunistic 🟡"
+	case score > 40:
+		return "Spiky 🟠"
+	default:
+		return "Unreliable 🔴"
+	}
+}
+
+// standardDeviation is the population standard deviation of values.
+func standardDeviation(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
+
+// volatilityScore is 1 for a flat history, falling to 0 at a standard
+// deviation of one percentage point. Short histories score 0.5.
+func volatilityScore(history []float64) float64 {
+	if len(history) < 7 {
+		return 0.5
+	}
+	const maxVolatility = 1.0
+	return 1 - math.Min(standardDeviation(history)/maxVolatility, 1)
+}
+
+// drawdownScore is 1 with no drops of more than 0.5% between consecutive
+// values, falling to 0 at ten such drops. Short histories score 0.5.
+func drawdownScore(history []float64) float64 {
+	if len(history) < 2 {
+		return 0.5
+	}
+	const threshold, maxDrawdowns = 0.5, 10.0
+	drawdowns := 0
+	for i := 1; i < len(history); i++ {
+		if history[i-1] == 0 {
+			continue
+		}
+		if change := (history[i] - history[i-1]) / history[i-1] * 100; change < -threshold {
+			drawdowns++
+		}
+	}
+	return 1 - math.Min(float64(drawdowns)/maxDrawdowns, 1)
+}
+
+// timeAboveScore is the share of history above threshold.
+func timeAboveScore(history []float64, threshold float64) float64 {
+	if len(history) == 0 {
+		return 0
+	}
+	above := 0
+	for _, v := range history {
+		if v > threshold {
+			above++
+		}
+	}
+	return float64(above) / float64(len(history))
+}
@@ -0,0 +1,210 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// Tools returns the spending analysis tools, reading account data through
+// exec: analyze_spending, categorize_transactions,
+// detect_recurring_payments and detect_spending_anomalies.
+func Tools(exec core.ToolExecutor, categorizer *Categorizer) []core.Tool {
+	return []core.Tool{
+		SpendingTool(exec),
+		CategorizeTool(exec, categorizer),
+		RecurringTool(exec),
+		AnomalyTool(exec),
+	}
+}
+
+// fetchLimit is how many transactions analyze_spending reads.
+const fetchLimit = 100
+
+// spendingInput is the input for analyze_spending.
+type spendingInput struct {
+	Days int `json:"days" description:"Number of days to analyze (default: 30)" default:"30" minimum:"1"`
+	// IncludeExternal only has an effect when exec is wrapped with
+	// imports.Executor.
+	IncludeExternal bool `json:"include_external" description:"Also analyze transactions the user imported from other banks (default: false)"`
+}
+
+// categorizeInput is the input for categorize_transactions.
+type categorizeInput struct {
+	Limit           int  `json:"limit" description:"Number of transactions to analyze (default: 50)" default:"50" minimum:"1"`
+	IncludeExternal bool `json:"include_external" description:"Also analyze transactions the user imported from other banks (default: false)"`
+}
+
+// recurringInput is the input for detect_recurring_payments.
+type recurringInput struct {
+	Limit           int  `json:"limit" description:"Number of recent transactions to search (default: 200)" default:"200" minimum:"1"`
+	IncludeExternal bool `json:"include_external" description:"Also search transactions the user imported from other banks (default: false)"`
+}
+
+// anomalyInput is the input for detect_spending_anomalies.
+type anomalyInput struct {
+	Multiple        float64 `json:"multiple" description:"How many times its typical week a category's spending must reach to be flagged (default: 2)" default:"2" minimum:"1"`
+	IncludeExternal bool    `json:"include_external" description:"Also analyze transactions the user imported from other banks (default: false)"`
+}
+
+// anomalyFetchLimit is how many transactions detect_spending_anomalies
+// reads.
+const anomalyFetchLimit = 500
+
+// SpendingTool returns the analyze_spending tool, which summarizes the
+// user's spending over a number of days.
+func SpendingTool(exec core.ToolExecutor) core.Tool {
+	return tools.New("analyze_spending").
+		Description("Analyze the user's spending patterns over a specified time period. Returns insights about spending velocity, categories, and trends.").
+		Schema(tools.SchemaFor[spendingInput]()).
+		Handler(tools.TypedHandler(func(ctx context.Context, params *core.ToolParams, input spendingInput) (*core.ToolResult, error) {
+			now := time.Now()
+			txs, err := txn.FetchQuery(ctx, exec, params.UserID, params.RequestID, txn.Query{
+				Limit:           fetchLimit,
+				Since:           now.AddDate(0, 0, -input.Days),
+				IncludeExternal: input.IncludeExternal,
+			})
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to fetch transactions: %v", err)}, nil
+			}
+
+			s := Summarize(txs, now, input.Days)
+			env := core.NewEnvelope(s).WithFigures(
+				core.NewFigure("total_spent", s.TotalSpent, "USD"),
+				core.NewFigure("total_received", s.TotalReceived, "USD"),
+				core.NewFigure("avg_daily_spend", s.AvgDailySpend, "USD"),
+			)
+			if s.SpendCount == 0 && s.ReceiveCount == 0 {
+				env.WithStatus(core.StatusEmpty)
+			}
+			if s.Skipped > 0 {
+				env.WithWarning(fmt.Sprintf("%d transactions had amounts that couldn't be read and aren't counted", s.Skipped))
+			}
+			if len(txs) == fetchLimit {
+				env.WithWarning(fmt.Sprintf("Only the %d most recent transactions were analyzed, so older spending in the period may be missing", fetchLimit))
+			}
+			return env.Result(), nil
+		})).
+		Build()
+}
+
+// CategorizeTool returns the categorize_transactions tool, which counts the
+// user's recent spending by category.
+func CategorizeTool(exec core.ToolExecutor, categorizer *Categorizer) core.Tool {
+	return tools.New("categorize_transactions").
+		Description("Analyze transaction notes and categorize spending into: food, travel, subscription, entertainment, electronics, miscellaneous using AI-powered categorization.").
+		Schema(tools.SchemaFor[categorizeInput]()).
+		Handler(tools.TypedHandler(func(ctx context.Context, params *core.ToolParams, input categorizeInput) (*core.ToolResult, error) {
+			txs, err := txn.FetchIncluding(ctx, exec, params.UserID, params.RequestID, input.Limit, input.IncludeExternal)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to fetch transactions: %v", err)}, nil
+			}
+
+			notes := txn.Notes(txs)
+			b := NewBreakdown(notes, categorizer.Categorize(ctx, notes))
+			tagExternal(&b, txs)
+			env := core.NewEnvelope(b)
+			if b.TotalAnalyzed == 0 {
+				env.WithStatus(core.StatusEmpty)
+			}
+			return env.Result(), nil
+		})).
+		Build()
+}
+
+// RecurringTool returns the detect_recurring_payments tool, which finds the
+// user's subscriptions and other regular payments in their transactions.
+func RecurringTool(exec core.ToolExecutor) core.Tool {
+	return tools.New("detect_recurring_payments").
+		Description("Find the user's subscriptions and other recurring payments: outgoing payments to the same payee at least 3 times, weekly or monthly. Returns each series with its cadence, average and last amount, last payment date and predicted next charge. Active series carry a reminder input; offer to remind the user before the next charge by passing it to create_calendar_reminder.").
+		Schema(tools.SchemaFor[recurringInput]()).
+		Handler(tools.TypedHandler(func(ctx context.Context, params *core.ToolParams, input recurringInput) (*core.ToolResult, error) {
+			if input.Limit <= 0 {
+				input.Limit = 200
+			}
+			txs, err := txn.FetchIncluding(ctx, exec, params.UserID, params.RequestID, input.Limit, input.IncludeExternal)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to fetch transactions: %v", err)}, nil
+			}
+
+			r := DetectRecurring(txs, time.Now())
+			env := core.NewEnvelope(r)
+			if len(r.MonthlyTotal) == 1 {
+				for currency, total := range r.MonthlyTotal {
+					env.WithFigures(core.NewFigure("monthly_total", total, currency))
+				}
+			}
+			if len(r.Series) == 0 {
+				env.WithStatus(core.StatusEmpty)
+			}
+			if r.Skipped > 0 {
+				env.WithWarning(fmt.Sprintf("%d transactions had amounts or dates that couldn't be read and weren't considered", r.Skipped))
+			}
+			if len(txs) == input.Limit {
+				env.WithWarning(fmt.Sprintf("Only the %d most recent transactions were searched, so payments that recur less often may be missed", input.Limit))
+			}
+			return env.Result(), nil
+		})).
+		Build()
+}
+
+// AnomalyTool returns the detect_spending_anomalies tool, which compares
+// the user's spending this week to their usual weeks.
+func AnomalyTool(exec core.ToolExecutor) core.Tool {
+	return tools.New("detect_spending_anomalies").
+		Description("Compare the user's spending over the last 7 days to their usual week, from 90 days of transactions. Flags categories well above their typical week and unusually large single payments, each with a description comparing it to the baseline to tell the user, plus an overall anomaly score (the percentage of this week's spending that was unusual) and level. If baseline is insufficient_data, there isn't enough history to compare against, so say so instead of guessing. When something is flagged, goal is spend_weekly_goal's input for a limit at the user's typical week; offer to set it.").
+		Schema(tools.SchemaFor[anomalyInput]()).
+		Handler(tools.TypedHandler(func(ctx context.Context, params *core.ToolParams, input anomalyInput) (*core.ToolResult, error) {
+			now := time.Now()
+			txs, err := txn.FetchQuery(ctx, exec, params.UserID, params.RequestID, txn.Query{
+				Limit:           anomalyFetchLimit,
+				Since:           now.AddDate(0, 0, -AnomalyWindowDays),
+				IncludeExternal: input.IncludeExternal,
+			})
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to fetch transactions: %v", err)}, nil
+			}
+
+			a := DetectAnomalies(txs, now, input.Multiple)
+			env := core.NewEnvelope(a).WithFigures(core.NewFigure("this_week_total", a.ThisWeekTotal, "USD"))
+			if a.Baseline == BaselineInsufficient {
+				env.WithStatus(core.StatusEmpty)
+				env.WithWarning(fmt.Sprintf("Only %d full weeks of history; at least %d are needed to tell unusual spending from usual", a.Weeks, MinBaselineWeeks))
+			} else {
+				env.WithFigures(core.NewFigure("typical_week_total", a.TypicalWeek, "USD"))
+			}
+			if a.Skipped > 0 {
+				env.WithWarning(fmt.Sprintf("%d transactions had amounts or dates that couldn't be read and weren't considered", a.Skipped))
+			}
+			if len(txs) == anomalyFetchLimit {
+				env.WithWarning(fmt.Sprintf("Only the %d most recent transactions were read, so the baseline covers fewer weeks", anomalyFetchLimit))
+			}
+			return env.Result(), nil
+		})).
+		Build()
+}
+
+// tagExternal marks the breakdown lines of imported transactions and counts
+// notes by source. b must be built from txn.Notes(txs).
+func tagExternal(b *Breakdown, txs []txn.Transaction) {
+	sources := make(map[string]int)
+	i := 0
+	for _, tx := range txs {
+		if !txn.IsDebit(tx) || tx.Note == "" {
+			continue
+		}
+		source := txn.Source(tx)
+		sources[source]++
+		if source == txn.SourceExternal {
+			b.Breakdown[i] += " [external]"
+		}
+		i++
+	}
+	if sources[txn.SourceExternal] > 0 {
+		b.Sources = sources
+	}
+}
@@ -0,0 +1,246 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// DefaultAlertThresholds are the percentages of a weekly goal that alert
+// the user when their spending crosses them.
+var DefaultAlertThresholds = []float64{80, 100}
+
+// DefaultAlertTools are the write tools after which spending is checked.
+var DefaultAlertTools = []string{"send_money", "withdraw_savings"}
+
+// Alert tells a user their spending this week has crossed a threshold of
+// their weekly goal.
+type Alert struct {
+	Threshold float64  `json:"threshold"` // percentage of the goal, e.g. 80
+	Goal      Goal     `json:"goal"`
+	Progress  Progress `json:"progress"`
+}
+
+// Message describes the alert for the user, e.g. "You've used 80% of your
+// weekly budget: 82.00 of 100.00 USD spent, 18.00 left."
+func (a *Alert) Message() string {
+	spent, limit, currency := a.Progress.Spent, a.Goal.Amount, a.Goal.Currency
+	switch {
+	case spent > limit:
+		return fmt.Sprintf("You've gone over your weekly budget: %.2f of %.2f %s spent.", spent, limit, currency)
+	case a.Threshold >= 100:
+		return fmt.Sprintf("You've reached your weekly budget: %.2f of %.2f %s spent.", spent, limit, currency)
+	}
+	return fmt.Sprintf("You've used %.0f%% of your weekly budget: %.2f of %.2f %s spent, %.2f left.", a.Threshold, spent, limit, currency, a.Progress.Remaining)
+}
+
+// Figures returns the alert's goal, spending and remaining budget, for a
+// client to render.
+func (a *Alert) Figures() []core.Figure {
+	return []core.Figure{
+		core.NewFigure("goal_amount", a.Goal.Amount, a.Goal.Currency),
+		core.NewFigure("spent_so_far", a.Progress.Spent, a.Goal.Currency),
+		core.NewFigure("remaining", a.Progress.Remaining, a.Goal.Currency),
+	}
+}
+
+// AlertState records the thresholds a user has been alerted to in a week.
+type AlertState struct {
+	UserID    string    `json:"user_id"`
+	WeekStart time.Time `json:"week_start"`
+	GoalSetAt time.Time `json:"goal_set_at"` // alerts start over when the goal changes
+	Fired     []float64 `json:"fired"`
+}
+
+// AlertStore keeps each user's AlertState.
+// This is an interface - implementations (e.g., database-backed) are provided
+// by the consuming application.
+type AlertStore interface {
+	// Get returns the user's state.
+	// Returns nil, nil if they have none.
+	Get(ctx context.Context, userID string) (*AlertState, error)
+
+	// Set creates or replaces the user's state.
+	Set(ctx context.Context, state *AlertState) error
+}
+
+// Alerter alerts users as their spending crosses thresholds of their
+// weekly goal, once per threshold per week. Call Check, or AfterAction
+// from a hook run after confirmed actions (see server.Server.AddActionHook),
+// and deliver the alert it returns to the user.
+//
+// Thresholds, Tools and Now may be changed before the Alerter is used.
+type Alerter struct {
+	// Thresholds are percentages of the goal, DefaultAlertThresholds by
+	// default.
+	Thresholds []float64
+
+	// Tools are the tools AfterAction checks spending after,
+	// DefaultAlertTools by default.
+	Tools []string
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	exec   core.ToolExecutor
+	goals  Goals
+	rates  Rates
+	alerts AlertStore
+	mu     sync.Mutex // serializes checks, so an alert can't fire twice
+}
+
+// NewAlerter creates an Alerter that reads spending through exec and
+// compares it with goals, converting currencies with rates as the goal
+// tools do. alerts records the alerts sent; nil keeps them in memory.
+func NewAlerter(exec core.ToolExecutor, goals Goals, rates Rates, alerts AlertStore) *Alerter {
+	if alerts == nil {
+		alerts = NewMemoryAlerts()
+	}
+	return &Alerter{
+		Thresholds: DefaultAlertThresholds,
+		Tools:      DefaultAlertTools,
+		Now:        time.Now,
+		exec:       exec,
+		goals:      goals,
+		rates:      rates,
+		alerts:     alerts,
+	}
+}
+
+// AfterAction checks the spending of the user who confirmed action, if it
+// ran one of a.Tools, returning the alert to send them or nil.
+func (a *Alerter) AfterAction(ctx context.Context, action *core.PendingAction) (*Alert, error) {
+	if !slices.Contains(a.Tools, action.Tool) {
+		return nil, nil
+	}
+	return a.Check(ctx, action.UserID, core.RequestIDFromContext(ctx))
+}
+
+// Check recomputes the user's progress this week, as the preferences on
+// ctx place and start it, and returns an alert for the highest threshold
+// newly crossed, or nil if there is none. Every
+// threshold crossed is recorded, so one large payment crossing both 80%
+// and 100% alerts once, for 100%.
+func (a *Alerter) Check(ctx context.Context, userID, requestID string) (*Alert, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	goal, err := a.goals.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load goal: %w", err)
+	}
+	if goal == nil || goal.Amount <= 0 {
+		return nil, nil
+	}
+	p, _, err := currentProgress(ctx, a.exec, a.rates, goal, userID, requestID, a.Now(), core.PreferencesFor(ctx, nil), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate progress: %w", err)
+	}
+
+	state, err := a.alerts.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alerts: %w", err)
+	}
+	if state == nil || !state.WeekStart.Equal(p.WeekStart) || !state.GoalSetAt.Equal(goal.SetAt) {
+		state = &AlertState{UserID: userID, WeekStart: p.WeekStart, GoalSetAt: goal.SetAt}
+	}
+
+	var crossed []float64
+	for _, threshold := range a.Thresholds {
+		if p.Percentage >= threshold && !slices.Contains(state.Fired, threshold) {
+			crossed = append(crossed, threshold)
+		}
+	}
+	if len(crossed) == 0 {
+		return nil, nil
+	}
+	state.Fired = append(state.Fired, crossed...)
+	if err := a.alerts.Set(ctx, state); err != nil {
+		return nil, fmt.Errorf("failed to save alerts: %w", err)
+	}
+	return &Alert{Threshold: slices.Max(crossed), Goal: *goal, Progress: p}, nil
+}
+
+// MemoryAlerts is an in-memory implementation of AlertStore.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryAlerts struct {
+	mu     sync.RWMutex
+	states map[string]*AlertState // userID -> state
+}
+
+// NewMemoryAlerts creates an in-memory alert store.
+func NewMemoryAlerts() *MemoryAlerts {
+	return &MemoryAlerts{
+		states: make(map[string]*AlertState),
+	}
+}
+
+func (m *MemoryAlerts) Get(ctx context.Context, userID string) (*AlertState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	state, ok := m.states[userID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *state
+	copied.Fired = slices.Clone(state.Fired)
+	return &copied, nil
+}
+
+func (m *MemoryAlerts) Set(ctx context.Context, state *AlertState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *state
+	copied.Fired = slices.Clone(state.Fired)
+	m.states[state.UserID] = &copied
+	return nil
+}
+
+// KeyValueAlerts stores alert state as JSON in a store.KeyValue, one key
+// per user, so alerts aren't repeated after a restart.
+type KeyValueAlerts struct {
+	kv store.KeyValue
+}
+
+// NewKeyValueAlerts creates an alert store backed by kv.
+func NewKeyValueAlerts(kv store.KeyValue) *KeyValueAlerts {
+	return &KeyValueAlerts{kv: kv}
+}
+
+func (k *KeyValueAlerts) Get(ctx context.Context, userID string) (*AlertState, error) {
+	data, err := k.kv.Get(ctx, alertKey(userID))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var state AlertState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (k *KeyValueAlerts) Set(ctx context.Context, state *AlertState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return k.kv.Set(ctx, alertKey(state.UserID), data)
+}
+
+// alertKey is the key a user's alert state is stored under.
+func alertKey(userID string) string {
+	return "budget:alerts:" + userID
+}
+
+// Verify MemoryAlerts and KeyValueAlerts implement AlertStore.
+var (
+	_ AlertStore = (*MemoryAlerts)(nil)
+	_ AlertStore = (*KeyValueAlerts)(nil)
+)
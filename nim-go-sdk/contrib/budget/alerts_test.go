@@ -0,0 +1,103 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// spent returns one debit of amount USD at.
+func spent(amount float64, at time.Time) executor.GetTransactionsResponse {
+	return executor.GetTransactionsResponse{Transactions: []txn.Transaction{
+		{ID: "tx", Amount: fmt.Sprintf("%.2f", amount), Currency: "USD", Direction: "debit", CreatedAt: at.Format(time.RFC3339)},
+	}}
+}
+
+func TestAlerter(t *testing.T) {
+	ctx := context.Background()
+	wednesday := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	goals := NewMemoryGoals()
+	goals.Set(ctx, &Goal{UserID: "user-1", Amount: 100, Currency: "USD", SetAt: wednesday.Add(-time.Hour)})
+	exec := &txntest.Executor{Responses: map[string]interface{}{}}
+	alerter := NewAlerter(exec, goals, nil, NewKeyValueAlerts(store.NewMemoryKeyValue()))
+	now := wednesday
+	alerter.Now = func() time.Time { return now }
+
+	steps := []struct {
+		spent float64
+		week  int // weeks after the first
+		want  float64
+	}{
+		{spent: 50},
+		{spent: 85, want: 80},
+		{spent: 90},
+		{spent: 100, want: 100},
+		{spent: 120},
+		// A new week starts over, and a jump past both thresholds alerts once.
+		{spent: 150, week: 1, want: 100},
+		{spent: 160, week: 1},
+	}
+	var alerts []float64
+	for i, step := range steps {
+		now = wednesday.AddDate(0, 0, 7*step.week)
+		exec.Responses["get_transactions"] = spent(step.spent, now.Add(-time.Minute))
+		alert, err := alerter.AfterAction(ctx, &core.PendingAction{UserID: "user-1", Tool: "send_money"})
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		var got float64
+		if alert != nil {
+			got = alert.Threshold
+			alerts = append(alerts, got)
+		}
+		if got != step.want {
+			t.Errorf("step %d, %.0f spent: alert at %v, want %v", i, step.spent, got, step.want)
+		}
+	}
+	if len(alerts) != 3 {
+		t.Errorf("alerts = %v, want two in the first week and one in the second", alerts)
+	}
+}
+
+func TestAlerter_AfterAction(t *testing.T) {
+	ctx := context.Background()
+	goals := NewMemoryGoals()
+	goals.Set(ctx, &Goal{UserID: "user-1", Amount: 100, Currency: "USD", SetAt: time.Now()})
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": spent(90, time.Now()),
+	}}
+	alerter := NewAlerter(exec, goals, nil, nil)
+
+	// Other tools don't check spending.
+	if alert, err := alerter.AfterAction(ctx, &core.PendingAction{UserID: "user-1", Tool: "spend_weekly_goal"}); alert != nil || err != nil || len(exec.Requests()) != 0 {
+		t.Errorf("AfterAction(spend_weekly_goal) = %+v, %v after %d requests, want nothing checked", alert, err, len(exec.Requests()))
+	}
+	// Users without a goal aren't alerted.
+	if alert, err := alerter.AfterAction(ctx, &core.PendingAction{UserID: "user-2", Tool: "send_money"}); alert != nil || err != nil {
+		t.Errorf("AfterAction for a user without a goal = %+v, %v", alert, err)
+	}
+
+	alert, err := alerter.AfterAction(ctx, &core.PendingAction{UserID: "user-1", Tool: "withdraw_savings"})
+	if err != nil || alert == nil {
+		t.Fatalf("AfterAction(withdraw_savings) = %+v, %v, want an alert", alert, err)
+	}
+	if want := "You've used 80% of your weekly budget: 90.00 of 100.00 USD spent, 10.00 left."; alert.Message() != want {
+		t.Errorf("Message() = %q, want %q", alert.Message(), want)
+	}
+	if figures := alert.Figures(); len(figures) != 3 || figures[1].Amount != "90" {
+		t.Errorf("Figures() = %+v", figures)
+	}
+
+	// A new goal starts the week's alerts over.
+	goals.Set(ctx, &Goal{UserID: "user-1", Amount: 100, Currency: "USD", SetAt: time.Now()})
+	if alert, _ := alerter.Check(ctx, "user-1", ""); alert == nil || alert.Threshold != 80 {
+		t.Errorf("Check after a new goal = %+v, want the 80%% alert again", alert)
+	}
+}
@@ -0,0 +1,588 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+func TestWeekStart(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"monday", time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC), time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"wednesday", time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC), time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"sunday", time.Date(2026, 3, 8, 23, 0, 0, 0, time.UTC), time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WeekStart(tt.t); !got.Equal(tt.want) {
+				t.Errorf("WeekStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeeklyProgress(t *testing.T) {
+	goal := &Goal{UserID: "user-1", Amount: 70, Currency: "USDC"}
+	wednesday := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	txs := []txn.Transaction{
+		{Amount: "20", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-02T10:00:00Z"},
+		{Amount: "50", Currency: "EURC", Direction: "debit", CreatedAt: "2026-03-03T10:00:00Z"},
+		{Amount: "90", Currency: "USDC", Direction: "debit", CreatedAt: "2026-02-27T10:00:00Z"},
+	}
+
+	p := WeeklyProgress(goal, txs, nil, wednesday)
+	if p.Spent != 20 || p.Remaining != 50 || !p.OnTrack {
+		t.Errorf("WeeklyProgress() = %+v, want 20 spent, 50 remaining, on track", p)
+	}
+	if p.DaysLeft != 4 {
+		t.Errorf("DaysLeft = %d, want 4", p.DaysLeft)
+	}
+
+	txs = append(txs, txn.Transaction{Amount: "100", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-04T09:00:00Z"})
+	p = WeeklyProgress(goal, txs, nil, wednesday)
+	if p.OnTrack || p.Percentage != 100 {
+		t.Errorf("overspent WeeklyProgress() = %+v, want off track at 100%%", p)
+	}
+
+	// Cents don't drift, and unreadable amounts are reported rather than
+	// counted as zero.
+	goal = &Goal{Amount: 0.5, Currency: "USDC"}
+	txs = []txn.Transaction{
+		{Amount: "0.10", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-02T10:00:00Z"},
+		{Amount: "0.20", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-03T10:00:00Z"},
+		{Amount: "12abc", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-03T11:00:00Z"},
+	}
+	p = WeeklyProgress(goal, txs, nil, wednesday)
+	if p.Spent != 0.3 || p.Remaining != 0.2 || p.Skipped != 1 {
+		t.Errorf("WeeklyProgress() = %+v, want exactly 0.3 spent, 0.2 remaining, 1 skipped", p)
+	}
+}
+
+func TestWeeklyProgressFrom_Timezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip(err)
+	}
+	goal := &Goal{UserID: "user-1", Amount: 70, Currency: "USDC"}
+	// 20:00 UTC on Sunday 17 May is Monday morning in Tokyo.
+	now := time.Date(2026, 5, 17, 20, 0, 0, 0, time.UTC)
+	txs := []txn.Transaction{
+		{Amount: "30", Currency: "USDC", Direction: "debit", CreatedAt: "2026-05-17T10:00:00Z"}, // Sunday evening in Tokyo
+		{Amount: "5", Currency: "USDC", Direction: "debit", CreatedAt: "2026-05-17T19:00:00Z"},  // Monday 4am in Tokyo
+	}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		firstDay  time.Weekday
+		wantStart time.Time
+		wantSpent float64
+	}{
+		{"utc", now, time.Monday, time.Date(2026, 5, 11, 0, 0, 0, 0, time.UTC), 35},
+		{"tokyo", now.In(tokyo), time.Monday, time.Date(2026, 5, 18, 0, 0, 0, 0, tokyo), 5},
+		{"utc from sunday", now, time.Sunday, time.Date(2026, 5, 17, 0, 0, 0, 0, time.UTC), 35},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := WeeklyProgressFrom(goal, txs, nil, tt.now, tt.firstDay)
+			if !p.WeekStart.Equal(tt.wantStart) || p.Spent != tt.wantSpent {
+				t.Errorf("WeeklyProgressFrom() starts %v with %v spent, want %v with %v", p.WeekStart, p.Spent, tt.wantStart, tt.wantSpent)
+			}
+		})
+	}
+}
+
+// mixedSpending is a week of spending in several currencies, starting
+// from monday.
+func mixedSpending(monday time.Time) []txn.Transaction {
+	at := monday.Add(time.Hour).Format(time.RFC3339)
+	return []txn.Transaction{
+		{ID: "usd", Amount: "20", Currency: "USD", Direction: "debit", CreatedAt: at},
+		{ID: "usdc", Amount: "10", Currency: "USDC", Direction: "debit", CreatedAt: at},
+		{ID: "eurc", Amount: "50", Currency: "EURC", USDValue: "54.00", Direction: "debit", CreatedAt: at},
+		{ID: "eur", Amount: "-10", Currency: "EUR", CreatedAt: at},
+		{ID: "lil-priced", Amount: "3", Currency: "LIL", USDValue: "0.30", Direction: "debit", CreatedAt: at},
+		{ID: "lil", Amount: "5", Currency: "LIL", Direction: "debit", CreatedAt: at},
+		{ID: "salary", Amount: "100", Currency: "USD", Direction: "credit", CreatedAt: at},
+		{ID: "last-week", Amount: "90", Currency: "USD", Direction: "debit", CreatedAt: monday.Add(-time.Hour).Format(time.RFC3339)},
+	}
+}
+
+func TestWeeklyProgress_Normalized(t *testing.T) {
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		goal          *Goal
+		wantSpent     float64
+		wantBreakdown []CurrencySpend
+		wantUnconv    []string
+	}{
+		{
+			name:      "USD goal",
+			goal:      &Goal{Amount: 100, Currency: "USD", Normalized: true},
+			wantSpent: 95.10,
+			wantBreakdown: []CurrencySpend{
+				{Currency: "EUR", Spent: 10, Counted: 10.80, Transactions: 1},
+				{Currency: "EURC", Spent: 50, Counted: 54, Transactions: 1},
+				{Currency: "LIL", Spent: 8, Counted: 0.30, Transactions: 2},
+				{Currency: "USD", Spent: 20, Counted: 20, Transactions: 1},
+				{Currency: "USDC", Spent: 10, Counted: 10, Transactions: 1},
+			},
+			wantUnconv: []string{"lil"},
+		},
+		{
+			name:      "EURC goal",
+			goal:      &Goal{Amount: 100, Currency: "EURC", Normalized: true},
+			wantSpent: 88.06,
+			wantBreakdown: []CurrencySpend{
+				{Currency: "EUR", Spent: 10, Counted: 10, Transactions: 1},
+				{Currency: "EURC", Spent: 50, Counted: 50, Transactions: 1},
+				{Currency: "LIL", Spent: 8, Counted: 0.28, Transactions: 2},
+				{Currency: "USD", Spent: 20, Counted: 18.52, Transactions: 1},
+				{Currency: "USDC", Spent: 10, Counted: 9.26, Transactions: 1},
+			},
+			wantUnconv: []string{"lil"},
+		},
+		{
+			name:          "not normalized",
+			goal:          &Goal{Amount: 100, Currency: "USD"},
+			wantSpent:     20,
+			wantBreakdown: []CurrencySpend{{Currency: "USD", Spent: 20, Counted: 20, Transactions: 1}},
+		},
+		{
+			name:      "goal currency without a rate",
+			goal:      &Goal{Amount: 100, Currency: "LIL", Normalized: true},
+			wantSpent: 8,
+			wantBreakdown: []CurrencySpend{
+				{Currency: "EUR", Spent: 10, Transactions: 1},
+				{Currency: "EURC", Spent: 50, Transactions: 1},
+				{Currency: "LIL", Spent: 8, Counted: 8, Transactions: 2},
+				{Currency: "USD", Spent: 20, Transactions: 1},
+				{Currency: "USDC", Spent: 10, Transactions: 1},
+			},
+			wantUnconv: []string{"usd", "usdc", "eurc", "eur"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := WeeklyProgress(tt.goal, mixedSpending(monday), map[string]float64(DefaultRates), monday.Add(48*time.Hour))
+			if !near(p.Spent, tt.wantSpent) || !near(p.Remaining, 100-tt.wantSpent) {
+				t.Errorf("spent %v, remaining %v, want %v spent", p.Spent, p.Remaining, tt.wantSpent)
+			}
+			if len(p.Breakdown) != len(tt.wantBreakdown) {
+				t.Fatalf("breakdown = %+v, want %+v", p.Breakdown, tt.wantBreakdown)
+			}
+			for i, want := range tt.wantBreakdown {
+				got := p.Breakdown[i]
+				if got.Currency != want.Currency || !near(got.Spent, want.Spent) || !near(got.Counted, want.Counted) || got.Transactions != want.Transactions {
+					t.Errorf("breakdown[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+			var unconverted []string
+			for _, u := range p.Unconverted {
+				unconverted = append(unconverted, u.ID)
+			}
+			if strings.Join(unconverted, ",") != strings.Join(tt.wantUnconv, ",") {
+				t.Errorf("unconverted = %v, want %v", unconverted, tt.wantUnconv)
+			}
+		})
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+// eurRates prices EUR only, failing for everything else.
+type eurRates struct{}
+
+func (eurRates) USDRate(ctx context.Context, currency string) (float64, error) {
+	if currency == "EUR" {
+		return 1.10, nil
+	}
+	return 0, fmt.Errorf("no rate for %s", currency)
+}
+
+func TestProgressTool_Normalized(t *testing.T) {
+	ctx := context.Background()
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{Transactions: mixedSpending(WeekStart(time.Now()))},
+	}}
+	goals := NewMemoryGoals()
+	goals.Set(ctx, &Goal{UserID: "user-1", Amount: 100, Currency: "USD", SetAt: time.Now(), Normalized: true})
+
+	result, err := ProgressTool(exec, goals, eurRates{}).Execute(ctx, &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("progress failed: %v %+v", err, result)
+	}
+	env := result.Data.(*core.Envelope)
+	data := env.Data.(map[string]interface{})
+	// The configured EUR rate is used, and the defaults for the rest.
+	if spent := data["spent_so_far"].(float64); !near(spent, 95.30) {
+		t.Errorf("spent_so_far = %v, want 95.30", spent)
+	}
+	if data["normalized"] != true || len(data["breakdown"].([]CurrencySpend)) != 5 {
+		t.Errorf("data = %+v, want a normalized breakdown of 5 currencies", data)
+	}
+	if len(env.Warnings) != 1 || !strings.Contains(env.Warnings[0], "5.00 LIL") {
+		t.Errorf("warnings = %v, want one about the unconverted LIL", env.Warnings)
+	}
+}
+
+func TestProgressTool_IncludeExternal(t *testing.T) {
+	ctx := context.Background()
+	at := WeekStart(time.Now()).Add(time.Hour).Format(time.RFC3339)
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{Transactions: []txn.Transaction{
+			{ID: "liminal", Amount: "20", Currency: "USD", Direction: "debit", CreatedAt: at},
+			{ID: "imported", Amount: "30", Currency: "USD", Direction: "debit", Type: txn.TypeExternal, CreatedAt: at},
+		}},
+	}}
+	goals := NewMemoryGoals()
+	goals.Set(ctx, &Goal{UserID: "user-1", Amount: 100, Currency: "USD", SetAt: time.Now()})
+
+	result, err := ProgressTool(exec, goals, nil).Execute(ctx, &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"include_external":true}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("progress failed: %v %+v", err, result)
+	}
+	requests := exec.Requests()
+	if !strings.Contains(string(requests[len(requests)-1].Input), `"include_external":true`) {
+		t.Errorf("get_transactions input = %s, want include_external", requests[len(requests)-1].Input)
+	}
+	data := result.Data.(*core.Envelope).Data.(map[string]interface{})
+	if spent := data["spent_so_far"].(float64); !near(spent, 50) {
+		t.Errorf("spent_so_far = %v, want 50", spent)
+	}
+	if sources, _ := data["sources"].(map[string]int); sources[txn.SourceExternal] != 1 || sources[txn.SourceLiminal] != 1 {
+		t.Errorf("sources = %v, want one of each", data["sources"])
+	}
+	if breakdown := data["breakdown"].([]CurrencySpend); len(breakdown) != 1 || breakdown[0].Transactions != 2 || breakdown[0].External != 1 {
+		t.Errorf("breakdown = %+v, want 2 USD transactions, 1 imported", breakdown)
+	}
+}
+
+func TestPlanReminders(t *testing.T) {
+	start := time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		frequency string
+		wantLast  time.Time
+	}{
+		{Weekly, start.AddDate(0, 0, 14)},
+		{BiWeekly, start.AddDate(0, 0, 28)},
+		{Monthly, start.AddDate(0, 2, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.frequency, func(t *testing.T) {
+			reminders, err := PlanReminders(tt.frequency, 25, "USDC", start, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(reminders) != 3 || !reminders[2].At.Equal(tt.wantLast) {
+				t.Errorf("PlanReminders() = %+v, want last at %v", reminders, tt.wantLast)
+			}
+		})
+	}
+
+	if _, err := PlanReminders("daily", 25, "USDC", start, 3); err == nil {
+		t.Error("PlanReminders(daily) should fail")
+	}
+}
+
+func TestPlanRemindersInput(t *testing.T) {
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	reminders, err := planReminders(json.RawMessage(`{"frequency":"Weekly","amount":10}`), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 12 || reminders[0].Currency != "USDC" {
+		t.Errorf("got %d reminders in %s, want 12 in USDC", len(reminders), reminders[0].Currency)
+	}
+	if want := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC); !reminders[0].At.Equal(want) {
+		t.Errorf("first reminder at %v, want %v", reminders[0].At, want)
+	}
+
+	if _, err := planReminders(json.RawMessage(`{"frequency":"weekly","amount":10,"start_date":"next week"}`), now); err == nil {
+		t.Error("planReminders with a malformed start_date should fail")
+	}
+}
+
+func TestSetGoalTool(t *testing.T) {
+	ctx := context.Background()
+	goals := NewMemoryGoals()
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{},
+	}}
+	tool := SetGoalTool(exec, goals, nil)
+	if !tool.RequiresConfirmation() {
+		t.Error("spend_weekly_goal should require confirmation")
+	}
+
+	result, err := tool.Execute(ctx, &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"amount":150}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("spend_weekly_goal failed: %v %+v", err, result)
+	}
+	goal, _ := goals.Get(ctx, "user-1")
+	if goal == nil || goal.Amount != 150 || goal.Currency != "USD" || !goal.Normalized {
+		t.Errorf("saved goal = %+v, want 150 USD, normalized", goal)
+	}
+
+	result, _ = tool.Execute(ctx, &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"amount":0}`)})
+	if result.Success {
+		t.Error("spend_weekly_goal accepted a zero amount")
+	}
+}
+
+func TestCategoryBudgets(t *testing.T) {
+	var total float64
+	for _, b := range CategoryBudgets(1000) {
+		total += b.Amount
+	}
+	if total != 1000 {
+		t.Errorf("category budgets total %v, want 1000", total)
+	}
+}
+
+func TestDescribeGoals(t *testing.T) {
+	ctx := context.Background()
+	goals := NewMemoryGoals()
+	goals.Set(ctx, &Goal{UserID: "user-1", Amount: 200, Currency: "USDC", SetAt: time.Now()})
+	describe := DescribeGoals(goals)
+
+	c, err := describe(ctx, "user-1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Count != 1 || c.Oldest == nil || c.Items != nil {
+		t.Errorf("summary = %+v, want one dated goal without items", c)
+	}
+
+	c, _ = describe(ctx, "user-1", true)
+	if items, ok := c.Items.([]*Goal); !ok || items[0].Amount != 200 {
+		t.Errorf("items = %#v, want the goal", c.Items)
+	}
+
+	c, _ = describe(ctx, "user-2", true)
+	if c.Count != 0 || c.Items != nil {
+		t.Errorf("user-2 = %+v, want nothing", c)
+	}
+}
+
+func TestKeyValueGoals(t *testing.T) {
+	ctx := context.Background()
+	kv := store.NewMemoryKeyValue()
+	goals := NewKeyValueGoals(kv)
+
+	if goal, err := goals.Get(ctx, "user-1"); goal != nil || err != nil {
+		t.Fatalf("Get before Set = %+v, %v, want nil, nil", goal, err)
+	}
+	setAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	if err := goals.Set(ctx, &Goal{UserID: "user-1", Amount: 150, Currency: "USD", SetAt: setAt}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second store over the same key-value store sees the goal, as a
+	// restarted server would.
+	goal, err := NewKeyValueGoals(kv).Get(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if goal == nil || goal.Amount != 150 || !goal.SetAt.Equal(setAt) {
+		t.Errorf("goal = %+v, want 150 USD set at %v", goal, setAt)
+	}
+	if other, _ := goals.Get(ctx, "user-2"); other != nil {
+		t.Errorf("user-2 goal = %+v, want none", other)
+	}
+}
+
+func TestProgressTool_CarriedOver(t *testing.T) {
+	ctx := context.Background()
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{},
+	}}
+	tests := []struct {
+		name  string
+		setAt time.Time
+		want  bool
+	}{
+		{"this week", time.Now(), false},
+		{"last week", WeekStart(time.Now()).Add(-time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goals := NewMemoryGoals()
+			goals.Set(ctx, &Goal{UserID: "user-1", Amount: 100, Currency: "USD", SetAt: tt.setAt})
+			result, err := ProgressTool(exec, goals, nil).Execute(ctx, &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{}`)})
+			if err != nil || !result.Success {
+				t.Fatalf("progress failed: %v %+v", err, result)
+			}
+			env := result.Data.(*core.Envelope)
+			data := env.Data.(map[string]interface{})
+			if data["carried_over"] != tt.want {
+				t.Errorf("carried_over = %v, want %v", data["carried_over"], tt.want)
+			}
+			if warned := len(env.Warnings) > 0; warned != tt.want {
+				t.Errorf("warnings = %v, want a warning only for a carried-over goal", env.Warnings)
+			}
+		})
+	}
+}
+
+// fakeCalendar is a Calendar that keeps events in memory.
+type fakeCalendar struct {
+	events     map[string]Reminder // event ID -> reminder
+	created    int
+	failAfter  int // AddReminders fails after creating this many events, if non-zero
+	failDelete bool
+}
+
+func (c *fakeCalendar) AddReminders(ctx context.Context, userID string, reminders []Reminder) ([]string, error) {
+	var ids []string
+	for _, r := range reminders {
+		if c.failAfter > 0 && len(ids) == c.failAfter {
+			return ids, fmt.Errorf("calendar unavailable")
+		}
+		c.created++
+		id := fmt.Sprintf("evt-%d", c.created)
+		c.events[id] = r
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (c *fakeCalendar) DeleteEvents(ctx context.Context, userID string, eventIDs []string) error {
+	if c.failDelete {
+		return fmt.Errorf("calendar unavailable")
+	}
+	for _, id := range eventIDs {
+		delete(c.events, id)
+	}
+	return nil
+}
+
+func TestReminderTools(t *testing.T) {
+	stores := map[string]func() ReminderStore{
+		"memory":    func() ReminderStore { return NewMemoryReminders() },
+		"key-value": func() ReminderStore { return NewKeyValueReminders(store.NewMemoryKeyValue()) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			calendar := &fakeCalendar{events: map[string]Reminder{}}
+			reminders := newStore()
+			create, list, cancel := ReminderTool(calendar, reminders), ListRemindersTool(reminders), CancelReminderTool(calendar, reminders)
+			if list.RequiresConfirmation() || !cancel.RequiresConfirmation() {
+				t.Error("cancelling, and only cancelling, should require confirmation")
+			}
+
+			run := func(tool core.Tool, input string) (*core.ToolResult, map[string]interface{}) {
+				t.Helper()
+				result, err := tool.Execute(ctx, &core.ToolParams{UserID: "user-1", Input: json.RawMessage(input)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !result.Success {
+					return result, nil
+				}
+				return result, result.Data.(*core.Envelope).Data.(map[string]interface{})
+			}
+			listed := func() []map[string]interface{} {
+				t.Helper()
+				_, data := run(list, `{}`)
+				return data["series"].([]map[string]interface{})
+			}
+
+			// Series run side by side rather than replacing each other.
+			_, weekly := run(create, `{"frequency":"weekly","amount":50,"start_date":"2030-01-07","duration":4}`)
+			run(create, `{"frequency":"Monthly","amount":200,"currency":"EURC","start_date":"2030-02-01"}`)
+			if len(calendar.events) != 7 {
+				t.Fatalf("calendar has %d events, want 7", len(calendar.events))
+			}
+			series := listed()
+			if len(series) != 2 {
+				t.Fatalf("listed %d series, want 2", len(series))
+			}
+			if s := series[0]; s["series_id"] != weekly["series_id"] || s["frequency"] != Weekly || s["next_reminder"] != "January 7, 2030" || s["remaining"] != 4 {
+				t.Errorf("first series = %v, want weekly, next January 7, 2030, 4 remaining", s)
+			}
+			if s := series[1]; s["frequency"] != Monthly || s["currency"] != "EURC" || s["next_reminder"] != "February 1, 2030" || s["remaining"] != 3 {
+				t.Errorf("second series = %v, want monthly EURC, next February 1, 2030, 3 remaining", s)
+			}
+
+			// Cancelling deletes the series' events, and only those.
+			_, cancelled := run(cancel, fmt.Sprintf(`{"series_id":%q}`, weekly["series_id"]))
+			if cancelled["deleted_events"] != 4 || len(calendar.events) != 3 {
+				t.Errorf("cancel = %v with %d events left, want 4 deleted and 3 left", cancelled, len(calendar.events))
+			}
+			if series := listed(); len(series) != 1 || series[0]["frequency"] != Monthly {
+				t.Errorf("after cancel, listed %v, want the monthly series", series)
+			}
+			if result, _ := run(cancel, fmt.Sprintf(`{"series_id":%q}`, weekly["series_id"])); result.Success {
+				t.Error("cancelling twice succeeded")
+			}
+
+			// A calendar failure still cancels the series, with a warning.
+			calendar.failDelete = true
+			result, _ := run(cancel, fmt.Sprintf(`{"series_id":%q}`, series[1]["series_id"]))
+			if env := result.Data.(*core.Envelope); !result.Success || len(env.Warnings) != 1 {
+				t.Errorf("cancel with a failing calendar = %+v, want success with a warning", result)
+			}
+			if series := listed(); len(series) != 0 {
+				t.Errorf("listed %d series after cancelling both, want none", len(series))
+			}
+			calendar.failDelete = false
+
+			// Events from a failed create are cleaned up, and no series kept.
+			calendar.failAfter = 2
+			if result, _ := run(create, `{"frequency":"weekly","amount":10}`); result.Success {
+				t.Error("create succeeded with a failing calendar")
+			}
+			if len(calendar.events) != 3 || len(listed()) != 0 {
+				t.Errorf("after a failed create, %d events and %d series, want 3 and none", len(calendar.events), len(listed()))
+			}
+		})
+	}
+}
+
+func TestKeyValueReminders(t *testing.T) {
+	ctx := context.Background()
+	kv := store.NewMemoryKeyValue()
+	reminders := NewKeyValueReminders(kv)
+	at := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	if err := reminders.Add(ctx, &ReminderSeries{ID: "s-1", UserID: "user-1", Frequency: Weekly, Reminders: []Reminder{{At: at, EventID: "evt-1"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second store over the same key-value store sees the series, as a
+	// restarted server would.
+	series, err := NewKeyValueReminders(kv).List(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 || !series[0].Reminders[0].At.Equal(at) || series[0].EventIDs()[0] != "evt-1" {
+		t.Errorf("series = %+v, want s-1 with event evt-1", series)
+	}
+	if err := reminders.Delete(ctx, "user-2", "s-1"); err != ErrSeriesNotFound {
+		t.Errorf("deleting another user's series: %v, want ErrSeriesNotFound", err)
+	}
+	if err := reminders.Delete(ctx, "user-1", "s-1"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := kv.Get(ctx, remindersKey("user-1")); data != nil {
+		t.Errorf("key still holds %s after the last series was deleted", data)
+	}
+}
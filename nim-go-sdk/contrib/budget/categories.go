@@ -0,0 +1,35 @@
+package budget
+
+// CategoryBudget is the recommended spend for a budget category.
+type CategoryBudget struct {
+	Category string  `json:"category"`
+	Share    float64 `json:"share"`
+	Amount   float64 `json:"amount"`
+}
+
+// categoryShares splits income across budget categories.
+var categoryShares = []struct {
+	category string
+	share    float64
+}{
+	{"Essentials (rent, utilities, insurance)", 0.50},
+	{"Food & groceries", 0.15},
+	{"Transportation", 0.10},
+	{"Savings", 0.20},
+	{"Personal & entertainment", 0.05},
+}
+
+// CategoryBudgets splits income into recommended category budgets:
+// half for essentials, a fifth for savings, and the rest for food,
+// transportation, and personal spending.
+func CategoryBudgets(income float64) []CategoryBudget {
+	budgets := make([]CategoryBudget, len(categoryShares))
+	for i, c := range categoryShares {
+		budgets[i] = CategoryBudget{
+			Category: c.category,
+			Share:    c.share,
+			Amount:   income * c.share,
+		}
+	}
+	return budgets
+}
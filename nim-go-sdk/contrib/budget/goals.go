@@ -0,0 +1,332 @@
+// Package budget tracks weekly spending goals, alerts users as they near
+// them, recommends category budgets, and plans recurring savings
+// reminders. Tools returns the goal and reminder tools wired to the
+// application's goal store and calendar, and an Alerter checks spending
+// after payments.
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/i18n"
+	"github.com/becomeliminal/nim-go-sdk/inventory"
+	"github.com/becomeliminal/nim-go-sdk/money"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// Goal is a user's weekly spending limit.
+type Goal struct {
+	UserID   string    `json:"user_id"`
+	Amount   float64   `json:"amount"`
+	Currency string    `json:"currency"`
+	SetAt    time.Time `json:"set_at"`
+
+	// Normalized counts spending in every currency, converted into
+	// Currency. Otherwise only spending in Currency counts, as it did for
+	// goals set before conversion was supported.
+	Normalized bool `json:"normalized"`
+}
+
+// Goals stores weekly spending goals.
+// This is an interface - implementations (e.g., database-backed) are provided
+// by the consuming application.
+type Goals interface {
+	// Get returns the user's goal.
+	// Returns nil, nil if the user has none.
+	Get(ctx context.Context, userID string) (*Goal, error)
+
+	// Set creates or replaces the user's goal.
+	Set(ctx context.Context, goal *Goal) error
+}
+
+// Progress is how a week's spending compares to a goal. Amounts are in the
+// goal's currency.
+type Progress struct {
+	Spent      float64   `json:"spent"`
+	Remaining  float64   `json:"remaining"`
+	Percentage float64   `json:"percentage"`
+	OnTrack    bool      `json:"on_track"`
+	DaysLeft   int       `json:"days_left"`
+	WeekStart  time.Time `json:"week_start"`
+	WeekEnd    time.Time `json:"week_end"`
+
+	// Breakdown is the week's spending by currency.
+	Breakdown []CurrencySpend `json:"breakdown,omitempty"`
+
+	// Unconverted is spending that couldn't be converted into the goal's
+	// currency, and so isn't counted in Spent.
+	Unconverted []UnconvertedSpend `json:"unconverted,omitempty"`
+
+	// Skipped counts the week's transactions whose amounts couldn't be
+	// parsed, and so aren't counted in Spent.
+	Skipped int `json:"skipped_transactions,omitempty"`
+
+	// Sources counts the transactions counted in Spent by txn.Source. Set
+	// only when some were imported from another bank.
+	Sources map[string]int `json:"sources,omitempty"`
+}
+
+// CurrencySpend is a week's spending in one currency.
+type CurrencySpend struct {
+	Currency     string  `json:"currency"`
+	Spent        float64 `json:"spent"`   // in Currency
+	Counted      float64 `json:"counted"` // in the goal's currency
+	Transactions int     `json:"transactions"`
+	External     int     `json:"external_transactions,omitempty"` // of Transactions, imported from another bank
+}
+
+// UnconvertedSpend is a transaction that couldn't be counted towards a goal
+// in another currency.
+type UnconvertedSpend struct {
+	ID       string  `json:"id"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// WeekStart returns midnight on the Monday of t's week, in t's location.
+func WeekStart(t time.Time) time.Time {
+	return i18n.WeekStart(t, "", time.Monday)
+}
+
+// weekday numbers days from Monday (1) to Sunday (7).
+func weekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// WeeklyProgress compares spending during now's week, from Monday, against
+// the goal, as WeeklyProgressFrom does.
+func WeeklyProgress(goal *Goal, txs []txn.Transaction, usdRates map[string]float64, now time.Time) Progress {
+	return WeeklyProgressFrom(goal, txs, usdRates, now, time.Monday)
+}
+
+// WeeklyProgressFrom compares spending during now's week, starting on
+// firstDay in now's location, against the goal. If the goal is normalized,
+// spending in other currencies is converted into the goal's currency, by
+// the transaction's USD value where Liminal gives one and otherwise by
+// usdRates, each currency's value in USD; spending that can't be
+// converted is reported in Unconverted. Amounts are totaled
+// exactly in minor units, and transactions whose amounts can't be parsed
+// are counted in Skipped. The user is on track if they've spent no more
+// than a daily share of the goal for each day of the week so far.
+func WeeklyProgressFrom(goal *Goal, txs []txn.Transaction, usdRates map[string]float64, now time.Time, firstDay time.Weekday) Progress {
+	start := i18n.WeekStart(now, "", firstDay)
+	end := start.AddDate(0, 0, 7)
+
+	p := Progress{
+		DaysLeft:  int(end.Sub(now).Hours() / 24),
+		WeekStart: start,
+		WeekEnd:   end,
+	}
+	type totals struct {
+		spent, counted money.Amount
+		transactions   int
+		external       int
+	}
+	byCurrency := make(map[string]*totals)
+	sources := make(map[string]int)
+	spent := money.Amount{Currency: goal.Currency}
+	for _, tx := range txs {
+		at := txn.CreatedAt(tx)
+		if at.IsZero() || at.Before(start) || !at.Before(end) {
+			continue
+		}
+		if tx.Currency != goal.Currency && !goal.Normalized {
+			continue
+		}
+		amount, err := txn.SpentMoney(tx)
+		if err != nil {
+			p.Skipped++
+			continue
+		}
+		if amount.IsZero() {
+			continue
+		}
+
+		t, ok := byCurrency[tx.Currency]
+		if !ok {
+			t = &totals{spent: money.Amount{Currency: tx.Currency}, counted: money.Amount{Currency: goal.Currency}}
+			byCurrency[tx.Currency] = t
+		}
+		counted, ok := convert(tx, amount, goal.Currency, usdRates)
+		if !ok {
+			p.Unconverted = append(p.Unconverted, UnconvertedSpend{ID: tx.ID, Amount: amount.Float64(), Currency: tx.Currency})
+		}
+		newSpent, err1 := t.spent.Add(amount)
+		newCounted, err2 := t.counted.Add(counted)
+		newTotal, err3 := spent.Add(counted)
+		if err1 != nil || err2 != nil || err3 != nil {
+			p.Skipped++
+			continue
+		}
+		t.spent, t.counted, spent = newSpent, newCounted, newTotal
+		t.transactions++
+		source := txn.Source(tx)
+		sources[source]++
+		if source == txn.SourceExternal {
+			t.external++
+		}
+	}
+	if sources[txn.SourceExternal] > 0 {
+		p.Sources = sources
+	}
+	for currency, t := range byCurrency {
+		p.Breakdown = append(p.Breakdown, CurrencySpend{
+			Currency:     currency,
+			Spent:        t.spent.Float64(),
+			Counted:      t.counted.Float64(),
+			Transactions: t.transactions,
+			External:     t.external,
+		})
+	}
+	sort.Slice(p.Breakdown, func(i, j int) bool { return p.Breakdown[i].Currency < p.Breakdown[j].Currency })
+
+	limit, err := money.FromFloat(goal.Amount, goal.Currency)
+	if err != nil {
+		limit = money.Amount{Currency: goal.Currency}
+	}
+	p.Spent = spent.Float64()
+	if remaining, err := limit.Sub(spent); err == nil {
+		p.Remaining = remaining.Float64()
+	}
+	days := (int(now.Weekday())-int(firstDay)+7)%7 + 1 // of the week so far, today included
+	p.OnTrack = p.Spent <= goal.Amount/7*float64(days)
+	if goal.Amount > 0 {
+		p.Percentage = p.Spent / goal.Amount * 100
+	}
+	if p.Percentage > 100 {
+		p.Percentage = 100
+	}
+	return p
+}
+
+// convert converts an amount spent in tx into currency, rounded to its
+// minor unit. An unconvertible amount is zero.
+func convert(tx txn.Transaction, spent money.Amount, currency string, usdRates map[string]float64) (money.Amount, bool) {
+	zero := money.Amount{Currency: currency}
+	if tx.Currency == currency {
+		return spent, true
+	}
+	rate, ok := usdRates[currency]
+	if !ok || rate <= 0 {
+		return zero, false
+	}
+	var usd float64
+	if value, err := money.FromUSDValue(tx.USDValue); err == nil {
+		usd = value.Abs().Float64()
+	}
+	if usd == 0 {
+		txRate, ok := usdRates[tx.Currency]
+		if !ok {
+			return zero, false
+		}
+		usd = spent.Float64() * txRate
+	}
+	counted, err := money.FromFloat(usd/rate, currency)
+	if err != nil {
+		return zero, false
+	}
+	return counted, true
+}
+
+// DescribeGoals describes the user's weekly spending goal for their data
+// inventory. Register it with an inventory.Registry as "budget_goals".
+func DescribeGoals(goals Goals) inventory.Describer {
+	return func(ctx context.Context, userID string, withItems bool) (*inventory.Category, error) {
+		goal, err := goals.Get(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		c := &inventory.Category{
+			Description: "Your weekly spending goal.",
+			DeleteWith:  "spend_weekly_goal",
+		}
+		if goal != nil {
+			c.Count = 1
+			c.Track(goal.SetAt)
+			if withItems {
+				c.Items = []*Goal{goal}
+			}
+		}
+		return c, nil
+	}
+}
+
+// MemoryGoals is an in-memory implementation of Goals.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryGoals struct {
+	mu      sync.RWMutex
+	goals   map[string]*Goal        // userID -> goal
+	history map[string][]WeekResult // userID -> completed weeks
+}
+
+// NewMemoryGoals creates an in-memory goal store.
+func NewMemoryGoals() *MemoryGoals {
+	return &MemoryGoals{
+		goals:   make(map[string]*Goal),
+		history: make(map[string][]WeekResult),
+	}
+}
+
+func (m *MemoryGoals) Get(ctx context.Context, userID string) (*Goal, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.goals[userID], nil
+}
+
+func (m *MemoryGoals) Set(ctx context.Context, goal *Goal) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.goals[goal.UserID] = goal
+	return nil
+}
+
+// KeyValueGoals stores goals as JSON in a store.KeyValue, one key per
+// user, so they persist wherever the key-value store does.
+type KeyValueGoals struct {
+	kv store.KeyValue
+}
+
+// NewKeyValueGoals creates a goal store backed by kv.
+func NewKeyValueGoals(kv store.KeyValue) *KeyValueGoals {
+	return &KeyValueGoals{kv: kv}
+}
+
+func (k *KeyValueGoals) Get(ctx context.Context, userID string) (*Goal, error) {
+	data, err := k.kv.Get(ctx, goalKey(userID))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var goal Goal
+	if err := json.Unmarshal(data, &goal); err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+func (k *KeyValueGoals) Set(ctx context.Context, goal *Goal) error {
+	data, err := json.Marshal(goal)
+	if err != nil {
+		return err
+	}
+	return k.kv.Set(ctx, goalKey(goal.UserID), data)
+}
+
+// goalKey is the key a user's goal is stored under.
+func goalKey(userID string) string {
+	return "budget:goal:" + userID
+}
+
+// Verify MemoryGoals and KeyValueGoals implement Goals.
+var (
+	_ Goals = (*MemoryGoals)(nil)
+	_ Goals = (*KeyValueGoals)(nil)
+)
@@ -0,0 +1,298 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/i18n"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// MaxHistoryWeeks is how many completed weeks a user's goal history keeps.
+const MaxHistoryWeeks = 26
+
+// historyFetchLimit caps the transactions read to record completed weeks.
+const historyFetchLimit = 2000
+
+// WeekResult is how a completed week's spending compared to the goal that
+// applied during it. Amounts are in the goal's currency.
+type WeekResult struct {
+	WeekStart time.Time `json:"week_start"`
+	WeekEnd   time.Time `json:"week_end"`
+	Goal      float64   `json:"goal"`
+	Currency  string    `json:"currency"`
+	Spent     float64   `json:"spent"`
+
+	// UnderBudget is whether spending stayed within the goal.
+	UnderBudget bool `json:"under_budget"`
+
+	// Incomplete is set when not all of the week's transactions could be
+	// read, so Spent may be too low.
+	Incomplete bool `json:"incomplete,omitempty"`
+}
+
+// Adherence is how well the week kept to its goal, as a percentage: 100
+// if spending stayed within it, otherwise the goal as a share of what was
+// spent, e.g. 80 for spending 125 against a goal of 100.
+func (w WeekResult) Adherence() float64 {
+	if w.Spent <= w.Goal || w.Spent <= 0 {
+		return 100
+	}
+	return w.Goal / w.Spent * 100
+}
+
+// GoalHistory keeps users' completed weeks. Goals stores that implement it
+// have their weeks recorded as they end, and Tools offers
+// get_weekly_goal_history with them.
+type GoalHistory interface {
+	// Weeks returns the user's completed weeks, oldest first.
+	Weeks(ctx context.Context, userID string) ([]WeekResult, error)
+
+	// AddWeeks appends completed weeks to the user's history, keeping the
+	// latest MaxHistoryWeeks.
+	AddWeeks(ctx context.Context, userID string, weeks []WeekResult) error
+}
+
+// HistoryStats summarizes a run of completed weeks.
+type HistoryStats struct {
+	Weeks            int `json:"weeks"`
+	WeeksUnderBudget int `json:"weeks_under_budget"`
+
+	// CurrentStreak is how many of the latest weeks in a row were under
+	// budget; LongestStreak is the longest such run.
+	CurrentStreak int `json:"current_streak"`
+	LongestStreak int `json:"longest_streak"`
+
+	// AverageAdherence is the mean of the weeks' Adherence.
+	AverageAdherence float64 `json:"average_adherence"`
+}
+
+// Stats summarizes weeks, given oldest first.
+func Stats(weeks []WeekResult) HistoryStats {
+	s := HistoryStats{Weeks: len(weeks)}
+	var adherence float64
+	for _, w := range weeks {
+		adherence += w.Adherence()
+		if w.UnderBudget {
+			s.WeeksUnderBudget++
+			s.CurrentStreak++
+			s.LongestStreak = max(s.LongestStreak, s.CurrentStreak)
+		} else {
+			s.CurrentStreak = 0
+		}
+	}
+	if len(weeks) > 0 {
+		s.AverageAdherence = adherence / float64(len(weeks))
+	}
+	return s
+}
+
+// rollover records the weeks of goal that ended since the last one in
+// history, at most MaxHistoryWeeks of them, each measured over its own
+// dates as WeeklyProgressFrom measures the current week. Weeks before the
+// goal was set aren't recorded.
+func rollover(ctx context.Context, exec core.ToolExecutor, rates Rates, history GoalHistory, goal *Goal, userID, requestID string, now time.Time, prefs *core.UserPreferences) error {
+	if goal == nil || goal.SetAt.IsZero() {
+		return nil
+	}
+	loc := i18n.Location(prefs.Timezone)
+	firstDay := prefs.FirstWeekday()
+	current := i18n.WeekStart(now.In(loc), "", firstDay)
+
+	weeks, err := history.Weeks(ctx, userID)
+	if err != nil {
+		return err
+	}
+	from := goal.SetAt
+	if len(weeks) > 0 && weeks[len(weeks)-1].WeekEnd.After(from) {
+		from = weeks[len(weeks)-1].WeekEnd
+	}
+	start := i18n.WeekStart(from.In(loc), "", firstDay)
+	if len(weeks) > 0 && start.Before(weeks[len(weeks)-1].WeekEnd) {
+		// The week was recorded, perhaps from another first weekday.
+		start = start.AddDate(0, 0, 7)
+	}
+	if oldest := current.AddDate(0, 0, -7*MaxHistoryWeeks); start.Before(oldest) {
+		start = oldest
+	}
+	if !start.Before(current) {
+		return nil
+	}
+
+	txs, truncated, err := txn.FetchAll(ctx, exec, userID, requestID, txn.Query{
+		Limit: historyFetchLimit,
+		Since: start,
+		Until: current,
+	})
+	if err != nil {
+		return err
+	}
+	converted := usdRates(ctx, rates, goal, txs)
+	var ended []WeekResult
+	for week := start; !week.AddDate(0, 0, 7).After(current); week = week.AddDate(0, 0, 7) {
+		end := week.AddDate(0, 0, 7)
+		p := WeeklyProgressFrom(goal, txs, converted, end.Add(-time.Nanosecond), firstDay)
+		ended = append(ended, WeekResult{
+			WeekStart:   p.WeekStart,
+			WeekEnd:     p.WeekEnd,
+			Goal:        goal.Amount,
+			Currency:    goal.Currency,
+			Spent:       p.Spent,
+			UnderBudget: p.Spent <= goal.Amount,
+			Incomplete:  truncated || p.Skipped > 0 || len(p.Unconverted) > 0,
+		})
+	}
+	return history.AddWeeks(ctx, userID, ended)
+}
+
+// recordWeeks runs rollover if goals keeps history, returning a warning
+// for the result if it failed.
+func recordWeeks(ctx context.Context, exec core.ToolExecutor, rates Rates, goals Goals, goal *Goal, params *core.ToolParams, now time.Time) string {
+	history, ok := goals.(GoalHistory)
+	if !ok {
+		return ""
+	}
+	if err := rollover(ctx, exec, rates, history, goal, params.UserID, params.RequestID, now, core.PreferencesFor(ctx, params)); err != nil {
+		return fmt.Sprintf("Past weeks' results couldn't be recorded: %v", err)
+	}
+	return ""
+}
+
+// historyInput is the input for get_weekly_goal_history.
+type historyInput struct {
+	Weeks int `json:"weeks" description:"Number of most recent completed weeks to return (default: all recorded, up to 26)"`
+}
+
+// HistoryTool returns the get_weekly_goal_history tool, which reports how
+// the user's completed weeks compared to their weekly goal, with their
+// streak of weeks under budget and average adherence. goals must keep
+// history (see GoalHistory).
+func HistoryTool(exec core.ToolExecutor, goals Goals, rates Rates) core.Tool {
+	return tools.New("get_weekly_goal_history").
+		Description("Get how the user's past weeks compared to their weekly spending goal: each completed week's spending and goal, their current and longest streaks of weeks under budget, and their average adherence percentage (100 means every week was within the goal). Use this for questions like \"how have I done against my budget this month?\".").
+		Schema(tools.SchemaFor[historyInput]()).
+		Handler(tools.TypedHandler(func(ctx context.Context, params *core.ToolParams, input historyInput) (*core.ToolResult, error) {
+			history, ok := goals.(GoalHistory)
+			if !ok {
+				return &core.ToolResult{Success: false, Error: "goal history isn't kept"}, nil
+			}
+			goal, err := goals.Get(ctx, params.UserID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to load goal: %v", err)}, nil
+			}
+			warning := recordWeeks(ctx, exec, rates, goals, goal, params, time.Now())
+			weeks, err := history.Weeks(ctx, params.UserID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to load goal history: %v", err)}, nil
+			}
+			if input.Weeks > 0 && input.Weeks < len(weeks) {
+				weeks = weeks[len(weeks)-input.Weeks:]
+			}
+
+			prefs := core.PreferencesFor(ctx, params)
+			series := make([]map[string]interface{}, len(weeks))
+			for i, w := range weeks {
+				series[i] = map[string]interface{}{
+					"week_start":   i18n.FormatDate(w.WeekStart, prefs.Locale, ""),
+					"week_end":     i18n.FormatDate(w.WeekEnd.AddDate(0, 0, -1), prefs.Locale, ""),
+					"goal":         w.Goal,
+					"currency":     w.Currency,
+					"spent":        w.Spent,
+					"under_budget": w.UnderBudget,
+					"adherence":    w.Adherence(),
+				}
+				if w.Incomplete {
+					series[i]["incomplete"] = true
+				}
+			}
+			env := core.NewEnvelope(map[string]interface{}{
+				"goal_set": goal != nil,
+				"weeks":    series,
+				"stats":    Stats(weeks),
+			})
+			if len(weeks) == 0 {
+				env.WithStatus(core.StatusEmpty)
+			}
+			if warning != "" {
+				env.WithWarning(warning)
+			}
+			return env.Result(), nil
+		})).
+		Build()
+}
+
+// Weeks returns the user's completed weeks, oldest first.
+func (m *MemoryGoals) Weeks(ctx context.Context, userID string) ([]WeekResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]WeekResult(nil), m.history[userID]...), nil
+}
+
+// AddWeeks appends completed weeks to the user's history.
+func (m *MemoryGoals) AddWeeks(ctx context.Context, userID string, weeks []WeekResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history[userID] = appendWeeks(m.history[userID], weeks)
+	return nil
+}
+
+// Weeks returns the user's completed weeks, oldest first.
+func (k *KeyValueGoals) Weeks(ctx context.Context, userID string) ([]WeekResult, error) {
+	data, err := k.kv.Get(ctx, historyKey(userID))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var weeks []WeekResult
+	if err := json.Unmarshal(data, &weeks); err != nil {
+		return nil, err
+	}
+	return weeks, nil
+}
+
+// AddWeeks appends completed weeks to the user's history.
+func (k *KeyValueGoals) AddWeeks(ctx context.Context, userID string, weeks []WeekResult) error {
+	existing, err := k.Weeks(ctx, userID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(appendWeeks(existing, weeks))
+	if err != nil {
+		return err
+	}
+	return k.kv.Set(ctx, historyKey(userID), data)
+}
+
+// appendWeeks appends the weeks that end after the last of history, so a
+// week recorded twice concurrently is kept once, and keeps the latest
+// MaxHistoryWeeks.
+func appendWeeks(history, weeks []WeekResult) []WeekResult {
+	for _, w := range weeks {
+		if len(history) == 0 || !w.WeekStart.Before(history[len(history)-1].WeekEnd) {
+			history = append(history, w)
+		}
+	}
+	return capWeeks(history)
+}
+
+// capWeeks keeps the latest MaxHistoryWeeks of weeks.
+func capWeeks(weeks []WeekResult) []WeekResult {
+	if len(weeks) > MaxHistoryWeeks {
+		weeks = weeks[len(weeks)-MaxHistoryWeeks:]
+	}
+	return weeks
+}
+
+// historyKey is the key a user's goal history is stored under.
+func historyKey(userID string) string {
+	return "budget:goal_history:" + userID
+}
+
+// Verify MemoryGoals and KeyValueGoals keep history.
+var (
+	_ GoalHistory = (*MemoryGoals)(nil)
+	_ GoalHistory = (*KeyValueGoals)(nil)
+)
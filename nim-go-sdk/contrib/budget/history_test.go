@@ -0,0 +1,226 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// threeWeeks is spending against a 100 USDC goal set on Monday 2 March
+// 2026: 70 in the first week, 150 in the second and 90 in the third.
+var threeWeeks = []executor.Transaction{
+	{ID: "w1-a", Amount: "50", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-03T10:00:00Z"},
+	{ID: "w1-b", Amount: "20", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-08T23:59:00Z"}, // last minute of the week
+	{ID: "w2-a", Amount: "100", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-09T00:00:00Z"},
+	{ID: "w2-b", Amount: "50", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-12T18:00:00Z"},
+	{ID: "w2-salary", Amount: "500", Currency: "USDC", Direction: "credit", CreatedAt: "2026-03-13T09:00:00Z"},
+	{ID: "w3-a", Amount: "90", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-17T12:00:00Z"},
+	{ID: "w4-a", Amount: "40", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-24T12:00:00Z"},
+}
+
+func TestRollover(t *testing.T) {
+	ctx := context.Background()
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{Transactions: threeWeeks},
+	}}
+	goal := &Goal{UserID: "user-1", Amount: 100, Currency: "USDC", SetAt: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+	goals := NewMemoryGoals()
+
+	// The clock moves through the weeks; each step records the weeks that
+	// ended since the last, and running twice records nothing new.
+	steps := []struct {
+		now   time.Time
+		weeks int
+	}{
+		{time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC), 0},
+		{time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC), 1},
+		{time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC), 1},
+		{time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC), 3}, // two weeks at once
+	}
+	for _, step := range steps {
+		if err := rollover(ctx, exec, nil, goals, goal, "user-1", "", step.now, core.DefaultPreferences()); err != nil {
+			t.Fatalf("rollover at %v: %v", step.now, err)
+		}
+		weeks, _ := goals.Weeks(ctx, "user-1")
+		if len(weeks) != step.weeks {
+			t.Fatalf("at %v recorded %d weeks, want %d", step.now, len(weeks), step.weeks)
+		}
+	}
+
+	weeks, _ := goals.Weeks(ctx, "user-1")
+	wantSpent := []float64{70, 150, 90}
+	wantUnder := []bool{true, false, true}
+	for i, w := range weeks {
+		wantStart := time.Date(2026, 3, 2+7*i, 0, 0, 0, 0, time.UTC)
+		if !w.WeekStart.Equal(wantStart) || !w.WeekEnd.Equal(wantStart.AddDate(0, 0, 7)) {
+			t.Errorf("week %d = %v to %v, want from %v", i, w.WeekStart, w.WeekEnd, wantStart)
+		}
+		if w.Spent != wantSpent[i] || w.UnderBudget != wantUnder[i] || w.Goal != 100 || w.Incomplete {
+			t.Errorf("week %d = %+v, want spent %v, under budget %v", i, w, wantSpent[i], wantUnder[i])
+		}
+	}
+
+	stats := Stats(weeks)
+	if stats.WeeksUnderBudget != 2 || stats.CurrentStreak != 1 || stats.LongestStreak != 1 {
+		t.Errorf("stats = %+v, want 2 weeks under budget, streaks 1 and 1", stats)
+	}
+	// (100 + 100/150*100 + 100) / 3
+	if want := 88.89; math.Abs(stats.AverageAdherence-want) > 0.01 {
+		t.Errorf("average adherence = %.2f, want %.2f", stats.AverageAdherence, want)
+	}
+
+	// The fourth week keeps within the goal: the streak grows.
+	if err := rollover(ctx, exec, nil, goals, goal, "user-1", "", time.Date(2026, 3, 30, 8, 0, 0, 0, time.UTC), core.DefaultPreferences()); err != nil {
+		t.Fatal(err)
+	}
+	weeks, _ = goals.Weeks(ctx, "user-1")
+	if stats := Stats(weeks); stats.Weeks != 4 || stats.CurrentStreak != 2 || stats.LongestStreak != 2 {
+		t.Errorf("after week 4 stats = %+v, want 4 weeks, streaks 2 and 2", stats)
+	}
+}
+
+func TestRollover_SetMidWeek(t *testing.T) {
+	// A goal set on Thursday counts that whole week, as progress does.
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{Transactions: threeWeeks},
+	}}
+	goal := &Goal{UserID: "user-1", Amount: 100, Currency: "USDC", SetAt: time.Date(2026, 3, 12, 9, 0, 0, 0, time.UTC)}
+	goals := NewMemoryGoals()
+	if err := rollover(context.Background(), exec, nil, goals, goal, "user-1", "", time.Date(2026, 3, 17, 0, 0, 0, 0, time.UTC), core.DefaultPreferences()); err != nil {
+		t.Fatal(err)
+	}
+	weeks, _ := goals.Weeks(context.Background(), "user-1")
+	if len(weeks) != 1 || weeks[0].Spent != 150 || weeks[0].UnderBudget {
+		t.Errorf("weeks = %+v, want the week of 9 March, over budget", weeks)
+	}
+}
+
+func TestStats(t *testing.T) {
+	week := func(spent float64) WeekResult {
+		return WeekResult{Goal: 100, Spent: spent, UnderBudget: spent <= 100}
+	}
+	tests := []struct {
+		name      string
+		spent     []float64
+		want      HistoryStats
+		adherence float64
+	}{
+		{name: "none", want: HistoryStats{}},
+		{
+			name:      "all under",
+			spent:     []float64{10, 100, 0},
+			want:      HistoryStats{Weeks: 3, WeeksUnderBudget: 3, CurrentStreak: 3, LongestStreak: 3},
+			adherence: 100,
+		},
+		{
+			name:      "streak broken then rebuilt",
+			spent:     []float64{50, 50, 50, 200, 50},
+			want:      HistoryStats{Weeks: 5, WeeksUnderBudget: 4, CurrentStreak: 1, LongestStreak: 3},
+			adherence: 90,
+		},
+		{
+			name:      "over last",
+			spent:     []float64{50, 125},
+			want:      HistoryStats{Weeks: 2, WeeksUnderBudget: 1, CurrentStreak: 0, LongestStreak: 1},
+			adherence: 90,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var weeks []WeekResult
+			for _, spent := range tt.spent {
+				weeks = append(weeks, week(spent))
+			}
+			got := Stats(weeks)
+			if math.Abs(got.AverageAdherence-tt.adherence) > 0.001 {
+				t.Errorf("average adherence = %v, want %v", got.AverageAdherence, tt.adherence)
+			}
+			got.AverageAdherence = 0
+			if got != tt.want {
+				t.Errorf("Stats = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyValueGoals_History(t *testing.T) {
+	ctx := context.Background()
+	kv := store.NewMemoryKeyValue()
+	goals := NewKeyValueGoals(kv)
+
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	var weeks []WeekResult
+	for i := 0; i < MaxHistoryWeeks+4; i++ {
+		start := monday.AddDate(0, 0, 7*i)
+		weeks = append(weeks, WeekResult{WeekStart: start, WeekEnd: start.AddDate(0, 0, 7), Goal: 100, Spent: 50, UnderBudget: true})
+	}
+	if err := goals.AddWeeks(ctx, "user-1", weeks[:10]); err != nil {
+		t.Fatal(err)
+	}
+	// Weeks already recorded are not recorded again.
+	if err := goals.AddWeeks(ctx, "user-1", weeks[8:]); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewKeyValueGoals(kv).Weeks(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != MaxHistoryWeeks {
+		t.Fatalf("kept %d weeks, want %d", len(got), MaxHistoryWeeks)
+	}
+	if !got[0].WeekStart.Equal(weeks[4].WeekStart) || !got[len(got)-1].WeekStart.Equal(weeks[len(weeks)-1].WeekStart) {
+		t.Errorf("kept weeks from %v to %v, want the latest %d", got[0].WeekStart, got[len(got)-1].WeekStart, MaxHistoryWeeks)
+	}
+	if other, _ := goals.Weeks(ctx, "user-2"); len(other) != 0 {
+		t.Errorf("user-2 weeks = %+v, want none", other)
+	}
+}
+
+func TestHistoryTool(t *testing.T) {
+	ctx := context.Background()
+	goals := NewMemoryGoals()
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{},
+	}}
+	tool := HistoryTool(exec, goals, nil)
+	params := &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{}`)}
+
+	result, err := tool.Execute(ctx, params)
+	if err != nil || !result.Success {
+		t.Fatalf("Execute = %+v, %v", result, err)
+	}
+	if env := result.Data.(*core.Envelope); env.Status != core.StatusEmpty {
+		t.Errorf("status = %q without history, want %q", env.Status, core.StatusEmpty)
+	}
+
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	var weeks []WeekResult
+	for i, spent := range []float64{70, 150, 90} {
+		start := monday.AddDate(0, 0, 7*i)
+		weeks = append(weeks, WeekResult{WeekStart: start, WeekEnd: start.AddDate(0, 0, 7), Goal: 100, Currency: "USDC", Spent: spent, UnderBudget: spent <= 100})
+	}
+	goals.AddWeeks(ctx, "user-1", weeks)
+
+	params.Input = json.RawMessage(`{"weeks":2}`)
+	result, err = tool.Execute(ctx, params)
+	if err != nil || !result.Success {
+		t.Fatalf("Execute = %+v, %v", result, err)
+	}
+	data := result.Data.(*core.Envelope).Data.(map[string]interface{})
+	series := data["weeks"].([]map[string]interface{})
+	if len(series) != 2 || series[0]["spent"] != 150.0 || series[1]["spent"] != 90.0 {
+		t.Errorf("weeks = %v, want the last two", series)
+	}
+	if stats := data["stats"].(HistoryStats); stats.Weeks != 2 || stats.CurrentStreak != 1 {
+		t.Errorf("stats = %+v, want 2 weeks with a streak of 1", stats)
+	}
+}
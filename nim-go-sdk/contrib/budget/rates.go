@@ -0,0 +1,72 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+)
+
+// Rates gives exchange rates, so spending in one currency can count towards
+// a goal in another.
+// This is an interface - implementations (e.g., backed by an FX API) are
+// provided by the consuming application.
+type Rates interface {
+	// USDRate returns what one unit of currency is worth in USD, e.g.
+	// about 1.08 for EUR.
+	USDRate(ctx context.Context, currency string) (float64, error)
+}
+
+// StaticRates are fixed USD rates by currency code.
+type StaticRates map[string]float64
+
+// DefaultRates are approximate USD rates for the currencies Liminal wallets
+// hold. They are used when no Rates are configured, and for currencies the
+// configured Rates can't price.
+var DefaultRates = StaticRates{
+	"USD":  1,
+	"USDC": 1,
+	"EUR":  1.08,
+	"EURC": 1.08,
+}
+
+func (s StaticRates) USDRate(ctx context.Context, currency string) (float64, error) {
+	rate, ok := s[strings.ToUpper(currency)]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no exchange rate for %s", currency)
+	}
+	return rate, nil
+}
+
+// usdRates looks up the USD rate of the goal's currency and of every
+// currency spent in, falling back to DefaultRates. Currencies neither can
+// price are left out.
+func usdRates(ctx context.Context, rates Rates, goal *Goal, txs []txn.Transaction) map[string]float64 {
+	out := make(map[string]float64)
+	lookup := func(currency string) {
+		if _, ok := out[currency]; ok || currency == "" {
+			return
+		}
+		if rates != nil {
+			rate, err := rates.USDRate(ctx, currency)
+			if err == nil && rate > 0 {
+				out[currency] = rate
+				return
+			}
+			log.Printf("Failed to get the %s exchange rate: %v", currency, err)
+		}
+		if rate, err := DefaultRates.USDRate(ctx, currency); err == nil {
+			out[currency] = rate
+		}
+	}
+	lookup(goal.Currency)
+	for _, tx := range txs {
+		lookup(tx.Currency)
+	}
+	return out
+}
+
+// Verify StaticRates implements Rates.
+var _ Rates = StaticRates(nil)
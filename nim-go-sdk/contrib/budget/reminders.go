@@ -0,0 +1,267 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// Reminder frequencies.
+const (
+	Weekly   = "weekly"
+	BiWeekly = "bi-weekly"
+	Monthly  = "monthly"
+)
+
+// Reminder is a calendar reminder to make a savings deposit.
+type Reminder struct {
+	At          time.Time `json:"at"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+	Currency    string    `json:"currency"`
+
+	// EventID identifies the reminder's calendar event, once created.
+	EventID string `json:"event_id,omitempty"`
+}
+
+// Calendar adds reminders to the user's calendar.
+// This is an interface - implementations (e.g., Google Calendar) are provided
+// by the consuming application.
+type Calendar interface {
+	// AddReminders creates the reminders as calendar events and returns
+	// their event IDs, in order. On failure it returns the IDs of the
+	// events it did create.
+	AddReminders(ctx context.Context, userID string, reminders []Reminder) ([]string, error)
+
+	// DeleteEvents deletes the calendar events with the given IDs.
+	DeleteEvents(ctx context.Context, userID string, eventIDs []string) error
+}
+
+// ReminderSeries is one set of recurring reminders created together.
+type ReminderSeries struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Frequency string     `json:"frequency"`
+	Amount    float64    `json:"amount"`
+	Currency  string     `json:"currency"`
+	CreatedAt time.Time  `json:"created_at"`
+	Reminders []Reminder `json:"reminders"`
+}
+
+// Upcoming returns the series' reminders after now, soonest first.
+func (s *ReminderSeries) Upcoming(now time.Time) []Reminder {
+	for i, r := range s.Reminders {
+		if r.At.After(now) {
+			return s.Reminders[i:]
+		}
+	}
+	return nil
+}
+
+// EventIDs returns the IDs of the series' calendar events.
+func (s *ReminderSeries) EventIDs() []string {
+	var ids []string
+	for _, r := range s.Reminders {
+		if r.EventID != "" {
+			ids = append(ids, r.EventID)
+		}
+	}
+	return ids
+}
+
+// ErrSeriesNotFound is returned when a user has no reminder series with
+// the given ID.
+var ErrSeriesNotFound = errors.New("reminder series not found")
+
+// ReminderStore keeps each user's reminder series.
+// This is an interface - implementations (e.g., database-backed) are provided
+// by the consuming application.
+type ReminderStore interface {
+	// List returns the user's series, oldest first.
+	List(ctx context.Context, userID string) ([]*ReminderSeries, error)
+
+	// Add saves a new series.
+	Add(ctx context.Context, series *ReminderSeries) error
+
+	// Delete removes one of the user's series.
+	// Returns ErrSeriesNotFound if they have none with that ID.
+	Delete(ctx context.Context, userID, id string) error
+}
+
+// DefaultReminderCount is how many reminders cover about three months at
+// frequency, or zero for an unknown frequency.
+func DefaultReminderCount(frequency string) int {
+	switch frequency {
+	case Weekly:
+		return 12
+	case BiWeekly:
+		return 6
+	case Monthly:
+		return 3
+	}
+	return 0
+}
+
+// NextMonday returns 9am on the first Monday after now, in now's location.
+func NextMonday(now time.Time) time.Time {
+	days := 8 - weekday(now)
+	next := now.AddDate(0, 0, days)
+	return time.Date(next.Year(), next.Month(), next.Day(), 9, 0, 0, 0, now.Location())
+}
+
+// PlanReminders returns count reminders to deposit amount of currency at
+// frequency, the first at start.
+func PlanReminders(frequency string, amount float64, currency string, start time.Time, count int) ([]Reminder, error) {
+	var label string
+	var next func(i int) time.Time
+	switch frequency {
+	case Weekly:
+		label, next = "Weekly", func(i int) time.Time { return start.AddDate(0, 0, 7*i) }
+	case BiWeekly:
+		label, next = "Bi-Weekly", func(i int) time.Time { return start.AddDate(0, 0, 14*i) }
+	case Monthly:
+		label, next = "Monthly", func(i int) time.Time { return start.AddDate(0, i, 0) }
+	default:
+		return nil, fmt.Errorf("frequency must be %q, %q, or %q", Weekly, BiWeekly, Monthly)
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive")
+	}
+
+	reminders := make([]Reminder, count)
+	for i := range reminders {
+		reminders[i] = Reminder{
+			At:          next(i),
+			Title:       label + " Investment Reminder",
+			Description: fmt.Sprintf("Time to invest %.2f %s into your savings vault. Stay on track with your financial goals!", amount, currency),
+			Amount:      amount,
+			Currency:    currency,
+		}
+	}
+	return reminders, nil
+}
+
+// MemoryReminders is an in-memory implementation of ReminderStore.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryReminders struct {
+	mu     sync.RWMutex
+	series map[string][]*ReminderSeries // userID -> series, oldest first
+}
+
+// NewMemoryReminders creates an in-memory reminder store.
+func NewMemoryReminders() *MemoryReminders {
+	return &MemoryReminders{
+		series: make(map[string][]*ReminderSeries),
+	}
+}
+
+func (m *MemoryReminders) List(ctx context.Context, userID string) ([]*ReminderSeries, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.series[userID]), nil
+}
+
+func (m *MemoryReminders) Add(ctx context.Context, series *ReminderSeries) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.series[series.UserID] = append(m.series[series.UserID], series)
+	return nil
+}
+
+func (m *MemoryReminders) Delete(ctx context.Context, userID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	series, err := withoutSeries(m.series[userID], id)
+	if err != nil {
+		return err
+	}
+	m.series[userID] = series
+	return nil
+}
+
+// KeyValueReminders stores reminder series as JSON in a store.KeyValue,
+// one key per user, so they persist wherever the key-value store does.
+type KeyValueReminders struct {
+	kv store.KeyValue
+	mu sync.Mutex // serializes read-modify-write of a user's series
+}
+
+// NewKeyValueReminders creates a reminder store backed by kv.
+func NewKeyValueReminders(kv store.KeyValue) *KeyValueReminders {
+	return &KeyValueReminders{kv: kv}
+}
+
+func (k *KeyValueReminders) List(ctx context.Context, userID string) ([]*ReminderSeries, error) {
+	data, err := k.kv.Get(ctx, remindersKey(userID))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var series []*ReminderSeries
+	if err := json.Unmarshal(data, &series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+func (k *KeyValueReminders) Add(ctx context.Context, series *ReminderSeries) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	all, err := k.List(ctx, series.UserID)
+	if err != nil {
+		return err
+	}
+	return k.save(ctx, series.UserID, append(all, series))
+}
+
+func (k *KeyValueReminders) Delete(ctx context.Context, userID, id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	all, err := k.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	all, err = withoutSeries(all, id)
+	if err != nil {
+		return err
+	}
+	return k.save(ctx, userID, all)
+}
+
+func (k *KeyValueReminders) save(ctx context.Context, userID string, series []*ReminderSeries) error {
+	if len(series) == 0 {
+		return k.kv.Delete(ctx, remindersKey(userID))
+	}
+	data, err := json.Marshal(series)
+	if err != nil {
+		return err
+	}
+	return k.kv.Set(ctx, remindersKey(userID), data)
+}
+
+// remindersKey is the key a user's reminder series are stored under.
+func remindersKey(userID string) string {
+	return "budget:reminders:" + userID
+}
+
+// withoutSeries returns series without the one with the given ID.
+func withoutSeries(series []*ReminderSeries, id string) ([]*ReminderSeries, error) {
+	i := slices.IndexFunc(series, func(s *ReminderSeries) bool { return s.ID == id })
+	if i < 0 {
+		return nil, ErrSeriesNotFound
+	}
+	return slices.Delete(slices.Clone(series), i, i+1), nil
+}
+
+// Verify MemoryReminders and KeyValueReminders implement ReminderStore.
+var (
+	_ ReminderStore = (*MemoryReminders)(nil)
+	_ ReminderStore = (*KeyValueReminders)(nil)
+)
@@ -0,0 +1,453 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/i18n"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+	"github.com/google/uuid"
+)
+
+// Tools returns the budgeting tools, reading account data through exec:
+// spend_weekly_goal, get_weekly_spending_progress, check_weeklyspend,
+// get_weekly_goal_history if goals keeps history (see GoalHistory), and,
+// if calendar is non-nil, create_calendar_reminder,
+// list_calendar_reminders and cancel_calendar_reminder, which keep each
+// reminder series in reminders; nil keeps them in memory. Spending in
+// other currencies is converted into a goal's currency with rates; nil
+// uses DefaultRates.
+func Tools(exec core.ToolExecutor, goals Goals, rates Rates, calendar Calendar, reminders ReminderStore) []core.Tool {
+	ts := []core.Tool{
+		SetGoalTool(exec, goals, rates),
+		ProgressTool(exec, goals, rates),
+		CheckSpendTool(exec, goals, rates),
+	}
+	if _, ok := goals.(GoalHistory); ok {
+		ts = append(ts, HistoryTool(exec, goals, rates))
+	}
+	if calendar != nil {
+		if reminders == nil {
+			reminders = NewMemoryReminders()
+		}
+		ts = append(ts,
+			ReminderTool(calendar, reminders),
+			ListRemindersTool(reminders),
+			CancelReminderTool(calendar, reminders),
+		)
+	}
+	return ts
+}
+
+// SetGoalTool returns the spend_weekly_goal tool, which sets the user's
+// weekly spending limit. Setting a goal requires confirmation. Weeks the
+// old goal applied to are recorded first, if goals keeps history.
+func SetGoalTool(exec core.ToolExecutor, goals Goals, rates Rates) core.Tool {
+	return tools.New("spend_weekly_goal").
+		Description("Set or update a weekly spending goal. Extracts amount and currency from user input and tracks weekly spending progress. Spending in other currencies is converted into the goal's currency unless single_currency is set.").
+		RequiresConfirmation().
+		Schema(tools.SchemaFor[goalInput]()).
+		SummaryTemplate("Set weekly spending goal to {{.amount}} {{.currency}}").
+		Handler(tools.TypedHandler(func(ctx context.Context, params *core.ToolParams, input goalInput) (*core.ToolResult, error) {
+			if input.Action == "get" {
+				return progressResult(ctx, exec, goals, rates, params, input.IncludeExternal)
+			}
+			if input.Amount <= 0 {
+				return &core.ToolResult{Success: false, Error: "amount must be greater than 0"}, nil
+			}
+
+			previous, err := goals.Get(ctx, params.UserID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to load goal: %v", err)}, nil
+			}
+			warning := recordWeeks(ctx, exec, rates, goals, previous, params, time.Now())
+
+			goal := &Goal{
+				UserID:     params.UserID,
+				Amount:     input.Amount,
+				Currency:   currencyOrDefault(input.Currency),
+				SetAt:      time.Now(),
+				Normalized: !input.SingleCurrency,
+			}
+			if err := goals.Set(ctx, goal); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to save goal: %v", err)}, nil
+			}
+
+			result, err := progressResult(ctx, exec, goals, rates, params, input.IncludeExternal)
+			if err != nil || !result.Success {
+				return result, err
+			}
+			data := result.Data.(*core.Envelope).Data.(map[string]interface{})
+			data["status"] = "goal_set"
+			data["message"] = "Weekly spending goal set to " + i18n.FormatMoney(goal.Amount, goal.Currency, core.PreferencesFor(ctx, params).Locale)
+			if warning != "" {
+				result.Data.(*core.Envelope).WithWarning(warning)
+			}
+			return result, nil
+		})).
+		Build()
+}
+
+// goalInput is the input for spend_weekly_goal.
+type goalInput struct {
+	Amount   float64 `json:"amount" description:"The weekly spending limit amount"`
+	Currency string  `json:"currency" description:"The currency code (e.g., USD, LIL, USDC)"`
+	Action   string  `json:"action" description:"Action: 'set' to create/update goal, 'get' to check current progress (default: set)"`
+
+	SingleCurrency bool `json:"single_currency" description:"Count only spending in the goal's currency, instead of converting spending in other currencies (default: false)"`
+
+	IncludeExternal bool `json:"include_external" description:"Also count spending the user imported from other banks in the progress reported (default: false)"`
+}
+
+// progressInput is the input for the progress tools.
+type progressInput struct {
+	// IncludeExternal only has an effect when exec is wrapped with
+	// imports.Executor.
+	IncludeExternal bool `json:"include_external" description:"Also count spending the user imported from other banks (default: false)"`
+}
+
+// ProgressTool returns the get_weekly_spending_progress tool, a read-only
+// view of the user's weekly goal progress.
+func ProgressTool(exec core.ToolExecutor, goals Goals, rates Rates) core.Tool {
+	return tools.New("get_weekly_spending_progress").
+		Description("Get current weekly spending goal progress without requiring confirmation. Shows how much spent, remaining budget, and on-track status.").
+		Schema(tools.SchemaFor[progressInput]()).
+		Handler(tools.TypedHandler(func(ctx context.Context, params *core.ToolParams, input progressInput) (*core.ToolResult, error) {
+			return progressResult(ctx, exec, goals, rates, params, input.IncludeExternal)
+		})).
+		Build()
+}
+
+// CheckSpendTool returns the check_weeklyspend tool, which gives the agent
+// weekly spending context before it answers spending questions.
+func CheckSpendTool(exec core.ToolExecutor, goals Goals, rates Rates) core.Tool {
+	return tools.New("check_weeklyspend").
+		Description("Check the current weekly spending status. Returns spent amount, remaining budget, percentage used, on-track status, and days left in the week. Use this to get context before answering user questions about their spending.").
+		Schema(tools.SchemaFor[progressInput]()).
+		Handler(tools.TypedHandler(func(ctx context.Context, params *core.ToolParams, input progressInput) (*core.ToolResult, error) {
+			return progressResult(ctx, exec, goals, rates, params, input.IncludeExternal)
+		})).
+		Build()
+}
+
+// progressResult reports the user's progress against their weekly goal, in
+// their week and locale, recording the weeks that ended since it was last
+// read if goals keeps history. If external is true, spending imported from
+// other banks is counted too.
+func progressResult(ctx context.Context, exec core.ToolExecutor, goals Goals, rates Rates, params *core.ToolParams, external bool) (*core.ToolResult, error) {
+	goal, err := goals.Get(ctx, params.UserID)
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to load goal: %v", err)}, nil
+	}
+	if goal == nil {
+		return core.NewEnvelope(map[string]interface{}{
+			"goal_set": false,
+			"message":  "No weekly spending goal has been set yet",
+		}).WithStatus(core.StatusEmpty).Result(), nil
+	}
+
+	now := time.Now()
+	warning := recordWeeks(ctx, exec, rates, goals, goal, params, now)
+	prefs := core.PreferencesFor(ctx, params)
+	p, txs, err := currentProgress(ctx, exec, rates, goal, params.UserID, params.RequestID, now, prefs, external)
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to calculate progress: %v", err)}, nil
+	}
+	carriedOver := !goal.SetAt.IsZero() && goal.SetAt.Before(p.WeekStart)
+
+	env := core.NewEnvelope(map[string]interface{}{
+		"goal_set":     true,
+		"goal_amount":  goal.Amount,
+		"currency":     goal.Currency,
+		"week_start":   i18n.FormatWeekdayDate(p.WeekStart, prefs.Locale, ""),
+		"week_end":     i18n.FormatWeekdayDate(p.WeekEnd, prefs.Locale, ""),
+		"spent_so_far": p.Spent,
+		"remaining":    p.Remaining,
+		"percentage":   p.Percentage,
+		"on_track":     p.OnTrack,
+		"days_left":    p.DaysLeft,
+		"goal_set_on":  goal.SetAt.In(p.WeekStart.Location()).Format("2006-01-02"),
+		"carried_over": carriedOver,
+		"normalized":   goal.Normalized,
+		"breakdown":    p.Breakdown,
+	}).WithFigures(
+		core.NewFigure("goal_amount", goal.Amount, goal.Currency),
+		core.NewFigure("spent_so_far", p.Spent, goal.Currency),
+		core.NewFigure("remaining", p.Remaining, goal.Currency),
+	)
+	if carriedOver {
+		// Goals recur weekly, so last week's goal still applies; flag it so
+		// the user can confirm it or set a new one.
+		env.WithWarning(fmt.Sprintf("This goal was set on %s, before this week started. Ask whether it still applies or whether to set a new one", i18n.FormatWeekdayDate(goal.SetAt, prefs.Locale, prefs.Timezone)))
+	}
+	if len(p.Unconverted) > 0 {
+		env.Data.(map[string]interface{})["unconverted"] = p.Unconverted
+		env.WithWarning(fmt.Sprintf("%d transactions couldn't be converted to %s and aren't counted: %s", len(p.Unconverted), goal.Currency, unconvertedSummary(p.Unconverted)))
+	}
+	if p.Sources != nil {
+		env.Data.(map[string]interface{})["sources"] = p.Sources
+	}
+	if p.Skipped > 0 {
+		env.Data.(map[string]interface{})["skipped_transactions"] = p.Skipped
+		env.WithWarning(fmt.Sprintf("%d transactions had amounts that couldn't be read and aren't counted", p.Skipped))
+	}
+	if warning != "" {
+		env.WithWarning(warning)
+	}
+	if len(txs) == fetchLimit {
+		env.WithWarning(fmt.Sprintf("Only the %d most recent transactions were counted, so spending this week may be higher", fetchLimit))
+	}
+	return env.Result(), nil
+}
+
+// currentProgress fetches the user's spending during now's week, as prefs
+// places and starts it, and compares it against goal, returning the
+// transactions it counted from. external also asks for imported
+// transactions (see txn.FetchIncluding).
+func currentProgress(ctx context.Context, exec core.ToolExecutor, rates Rates, goal *Goal, userID, requestID string, now time.Time, prefs *core.UserPreferences, external bool) (Progress, []txn.Transaction, error) {
+	now = now.In(i18n.Location(prefs.Timezone))
+	start := i18n.WeekStart(now, "", prefs.FirstWeekday())
+	txs, err := txn.FetchQuery(ctx, exec, userID, requestID, txn.Query{
+		Limit:           fetchLimit,
+		Since:           start,
+		Until:           start.AddDate(0, 0, 7),
+		IncludeExternal: external,
+	})
+	if err != nil {
+		return Progress{}, nil, err
+	}
+	return WeeklyProgressFrom(goal, txs, usdRates(ctx, rates, goal, txs), now, prefs.FirstWeekday()), txs, nil
+}
+
+// unconvertedSummary totals unconverted spending by currency, e.g.
+// "12.00 LIL".
+func unconvertedSummary(spends []UnconvertedSpend) string {
+	totals := make(map[string]float64)
+	var currencies []string
+	for _, s := range spends {
+		if _, ok := totals[s.Currency]; !ok {
+			currencies = append(currencies, s.Currency)
+		}
+		totals[s.Currency] += s.Amount
+	}
+	parts := make([]string, len(currencies))
+	for i, c := range currencies {
+		parts[i] = fmt.Sprintf("%.2f %s", totals[c], c)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fetchLimit is how many transactions progress is calculated from.
+const fetchLimit = 100
+
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return "USD"
+	}
+	return currency
+}
+
+// reminderInput is the input for create_calendar_reminder.
+type reminderInput struct {
+	Frequency string  `json:"frequency"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+	StartDate string  `json:"start_date"`
+	Duration  int     `json:"duration"`
+}
+
+// planReminders validates the input and plans the reminders it describes.
+func planReminders(raw json.RawMessage, now time.Time) ([]Reminder, error) {
+	var in reminderInput
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+	if in.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+	if in.Currency == "" {
+		in.Currency = "USDC"
+	}
+	frequency := strings.ToLower(in.Frequency)
+	if in.Duration <= 0 {
+		in.Duration = DefaultReminderCount(frequency)
+	}
+
+	start := NextMonday(now)
+	if in.StartDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", in.StartDate, now.Location())
+		if err != nil {
+			return nil, fmt.Errorf("start_date must be in YYYY-MM-DD format")
+		}
+		start = date.Add(9 * time.Hour)
+	}
+	return PlanReminders(frequency, in.Amount, in.Currency, start, in.Duration)
+}
+
+// ReminderTool returns the create_calendar_reminder tool, which adds
+// recurring savings deposit reminders to the user's calendar and keeps the
+// series in reminders. Creating reminders requires confirmation.
+func ReminderTool(calendar Calendar, reminders ReminderStore) core.Tool {
+	return tools.New("create_calendar_reminder").
+		Description("Create calendar reminders for periodic investments (weekly, bi-weekly, or monthly). This requires user confirmation before creating events.").
+		RequiresConfirmation().
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"frequency":  tools.StringProperty("Investment frequency: 'weekly', 'bi-weekly', or 'monthly'"),
+			"amount":     tools.NumberProperty("Amount to invest per period"),
+			"currency":   tools.StringProperty("Currency code (e.g., USDC, EURC)"),
+			"start_date": tools.StringProperty("Start date for reminders (YYYY-MM-DD format, optional - defaults to next week)"),
+			"duration":   tools.IntegerProperty("Number of reminders to create (default: 12 for weekly, 6 for bi-weekly, 3 for monthly)"),
+		})).
+		SummaryTemplate("Create {{.frequency}} reminders to save {{.amount}} {{.currency}}").
+		ReturnsEnvelope().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			planned, err := planReminders(params.Input, time.Now())
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+			eventIDs, err := calendar.AddReminders(ctx, params.UserID, planned)
+			if err != nil {
+				// Don't leave half a series behind.
+				if len(eventIDs) > 0 {
+					calendar.DeleteEvents(ctx, params.UserID, eventIDs)
+				}
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to create calendar events: %v", err)}, nil
+			}
+			for i := range planned {
+				if i < len(eventIDs) {
+					planned[i].EventID = eventIDs[i]
+				}
+			}
+
+			var in reminderInput
+			json.Unmarshal(params.Input, &in)
+			first := planned[0]
+			series := &ReminderSeries{
+				ID:        uuid.New().String(),
+				UserID:    params.UserID,
+				Frequency: strings.ToLower(in.Frequency),
+				Amount:    first.Amount,
+				Currency:  first.Currency,
+				CreatedAt: time.Now(),
+				Reminders: planned,
+			}
+			if err := reminders.Add(ctx, series); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("created the calendar events but failed to save the series: %v", err)}, nil
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"message":       fmt.Sprintf("Created %d calendar reminders", len(planned)),
+					"series_id":     series.ID,
+					"next_reminder": first.At.Format("January 2, 2006"),
+					"total_events":  len(planned),
+					"events":        planned,
+				},
+			}, nil
+		}).
+		Build()
+}
+
+// ListRemindersTool returns the list_calendar_reminders tool, which lists
+// the user's reminder series with when each next reminds them.
+func ListRemindersTool(reminders ReminderStore) core.Tool {
+	return tools.New("list_calendar_reminders").
+		Description("List the user's recurring calendar reminders: each series' ID, frequency, amount, and next upcoming reminder. Use the ID to cancel a series with cancel_calendar_reminder.").
+		Schema(tools.ObjectSchema(map[string]interface{}{})).
+		ReturnsEnvelope().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			all, err := reminders.List(ctx, params.UserID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to load reminders: %v", err)}, nil
+			}
+
+			now := time.Now()
+			list := make([]map[string]interface{}, 0, len(all))
+			for _, series := range all {
+				item := map[string]interface{}{
+					"series_id":    series.ID,
+					"frequency":    series.Frequency,
+					"amount":       series.Amount,
+					"currency":     series.Currency,
+					"created_at":   series.CreatedAt.Format("January 2, 2006"),
+					"total_events": len(series.Reminders),
+					"remaining":    0,
+				}
+				if upcoming := series.Upcoming(now); len(upcoming) > 0 {
+					item["next_reminder"] = upcoming[0].At.Format("January 2, 2006")
+					item["remaining"] = len(upcoming)
+				}
+				list = append(list, item)
+			}
+
+			message := fmt.Sprintf("%d reminder series", len(list))
+			if len(list) == 0 {
+				message = "No calendar reminders set up"
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"message": message,
+					"series":  list,
+				},
+			}, nil
+		}).
+		Build()
+}
+
+// cancelReminderInput is the input for cancel_calendar_reminder.
+type cancelReminderInput struct {
+	SeriesID string `json:"series_id" description:"ID of the reminder series to cancel, from list_calendar_reminders"`
+}
+
+// CancelReminderTool returns the cancel_calendar_reminder tool, which
+// deletes a reminder series and its calendar events. It requires
+// confirmation. The series is forgotten even if some events can't be
+// deleted from the calendar; the result warns about them.
+func CancelReminderTool(calendar Calendar, reminders ReminderStore) core.Tool {
+	return tools.New("cancel_calendar_reminder").
+		Description("Cancel a recurring calendar reminder series, deleting its remaining calendar events. Get the series_id from list_calendar_reminders. This requires user confirmation.").
+		RequiresConfirmation().
+		Schema(tools.SchemaFor[cancelReminderInput]()).
+		SummaryTemplate("Cancel calendar reminder series {{.series_id}}").
+		ReturnsEnvelope().
+		Handler(tools.TypedHandler(func(ctx context.Context, params *core.ToolParams, input cancelReminderInput) (*core.ToolResult, error) {
+			all, err := reminders.List(ctx, params.UserID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to load reminders: %v", err)}, nil
+			}
+			i := slices.IndexFunc(all, func(s *ReminderSeries) bool { return s.ID == input.SeriesID })
+			if i < 0 {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("no reminder series %q; use list_calendar_reminders to find it", input.SeriesID)}, nil
+			}
+			series := all[i]
+
+			if err := reminders.Delete(ctx, params.UserID, series.ID); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to cancel reminders: %v", err)}, nil
+			}
+			data := map[string]interface{}{
+				"message":   fmt.Sprintf("Cancelled %s reminders to save %.2f %s", series.Frequency, series.Amount, series.Currency),
+				"series_id": series.ID,
+			}
+
+			eventIDs := series.EventIDs()
+			if len(eventIDs) == 0 {
+				return &core.ToolResult{Success: true, Data: data}, nil
+			}
+			if err := calendar.DeleteEvents(ctx, params.UserID, eventIDs); err != nil {
+				return core.NewEnvelope(data).
+					WithWarning(fmt.Sprintf("The reminders are cancelled, but some calendar events couldn't be deleted and may still appear: %v", err)).
+					Result(), nil
+			}
+			data["deleted_events"] = len(eventIDs)
+			return &core.ToolResult{Success: true, Data: data}, nil
+		})).
+		Build()
+}
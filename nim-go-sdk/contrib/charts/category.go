@@ -0,0 +1,175 @@
+package charts
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/analysis"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+)
+
+// Category chart styles offered by generate_chart.
+const (
+	ChartPie = "pie"
+	ChartBar = "bar"
+)
+
+// CategoryTotal is the spending in one category.
+type CategoryTotal struct {
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+	Percent  float64 `json:"percent"` // of all spending, 0-100
+}
+
+// CategoryBreakdown totals outgoing transactions by category, largest
+// first, categorizing their notes with categorizer. A nil categorizer
+// categorizes by keyword, and transactions without a note are
+// miscellaneous. Categories with no spending are left out.
+func CategoryBreakdown(ctx context.Context, txs []txn.Transaction, categorizer *analysis.Categorizer) []CategoryTotal {
+	var debits []txn.Transaction
+	for _, tx := range txs {
+		if txn.Spent(tx) > 0 {
+			debits = append(debits, tx)
+		}
+	}
+	categories := categorizer.Categorize(ctx, txn.Notes(debits))
+
+	amounts := make(map[string]float64)
+	var total float64
+	i := 0
+	for _, tx := range debits {
+		category := analysis.Miscellaneous
+		if tx.Note != "" {
+			category = categories[i]
+			i++
+		}
+		amounts[category] += txn.Spent(tx)
+		total += txn.Spent(tx)
+	}
+
+	totals := make([]CategoryTotal, 0, len(amounts))
+	for category, amount := range amounts {
+		totals = append(totals, CategoryTotal{Category: category, Amount: amount, Percent: amount / total * 100})
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Amount != totals[j].Amount {
+			return totals[i].Amount > totals[j].Amount
+		}
+		return totals[i].Category < totals[j].Category
+	})
+	return totals
+}
+
+// categoryColors are the fills of the spending categories.
+var categoryColors = map[string]string{
+	analysis.Food:          "#FF6B6B",
+	analysis.Travel:        "#4ECDC4",
+	analysis.Subscription:  "#45B7D1",
+	analysis.Entertainment: "#F7B731",
+	analysis.Electronics:   "#A55EEA",
+	analysis.Miscellaneous: "#95A5A6",
+}
+
+func categoryColor(category string) string {
+	if c, ok := categoryColors[category]; ok {
+		return c
+	}
+	return categoryColors[analysis.Miscellaneous]
+}
+
+// categoryTitle is the heading of category charts.
+const categoryTitle = "Spending by Category"
+
+// noSpending is shown in place of a category chart with nothing to chart.
+const noSpending = "No spending to show yet. Charts appear once you've made a payment."
+
+// categoryLabel describes a category's spending, e.g. "Food: $120.00 (45.2%)".
+func categoryLabel(t CategoryTotal) string {
+	return fmt.Sprintf("%s: $%.2f (%.1f%%)", upperFirst(t.Category), t.Amount, t.Percent)
+}
+
+// totalSpent sums the category totals.
+func totalSpent(totals []CategoryTotal) float64 {
+	var total float64
+	for _, t := range totals {
+		total += t.Amount
+	}
+	return total
+}
+
+// CategoryPieSVG renders category spending as a pie chart with a legend of
+// amounts and percentages.
+func CategoryPieSVG(totals []CategoryTotal) string {
+	if len(totals) == 0 {
+		return placeholder(categoryTitle, noSpending)
+	}
+
+	var svg strings.Builder
+	open(&svg, width, height)
+	heading(&svg, width, 20, categoryTitle)
+	subheading(&svg, width, 55, fmt.Sprintf("Total spent: $%.2f", totalSpent(totals)))
+
+	const cx, cy, r = 250.0, 280.0, 170.0
+	if len(totals) == 1 {
+		fmt.Fprintf(&svg, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s" stroke="white" stroke-width="2"/>`, cx, cy, r, categoryColor(totals[0].Category))
+	} else {
+		// Wedges run clockwise from twelve o'clock.
+		angle := -math.Pi / 2
+		for _, t := range totals {
+			sweep := t.Percent / 100 * 2 * math.Pi
+			x1, y1 := cx+r*math.Cos(angle), cy+r*math.Sin(angle)
+			angle += sweep
+			x2, y2 := cx+r*math.Cos(angle), cy+r*math.Sin(angle)
+			large := 0
+			if sweep > math.Pi {
+				large = 1
+			}
+			fmt.Fprintf(&svg, `<path d="M %.1f %.1f L %.1f %.1f A %.1f %.1f 0 %d 1 %.1f %.1f Z" fill="%s" stroke="white" stroke-width="2"/>`, cx, cy, x1, y1, r, r, large, x2, y2, categoryColor(t.Category))
+		}
+	}
+
+	legendX, legendY := 480, 150
+	for i, t := range totals {
+		y := legendY + i*36
+		fmt.Fprintf(&svg, `<rect x="%d" y="%d" width="18" height="18" rx="3" fill="%s"/>`, legendX, y, categoryColor(t.Category))
+		fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="15" fill="#333">%s</text>`, legendX+28, y+14, html.EscapeString(categoryLabel(t)))
+	}
+
+	svg.WriteString(`</svg>`)
+	return svg.String()
+}
+
+// CategoryBarSVG renders category spending as horizontal bars, largest at
+// the top, labeled with amounts and percentages.
+func CategoryBarSVG(totals []CategoryTotal) string {
+	if len(totals) == 0 {
+		return placeholder(categoryTitle, noSpending)
+	}
+
+	const rowHeight, barHeight, top = 56, 32, 100
+	const labelWidth, valueWidth = 140, 170
+	chartHeight := max(height, top+len(totals)*rowHeight+padding/2)
+
+	var svg strings.Builder
+	open(&svg, width, chartHeight)
+	heading(&svg, width, 20, categoryTitle)
+	subheading(&svg, width, 55, fmt.Sprintf("Total spent: $%.2f", totalSpent(totals)))
+
+	barX := padding + labelWidth
+	maxBar := float64(width - padding - barX - valueWidth)
+	largest := totals[0].Amount
+	for i, t := range totals {
+		y := top + i*rowHeight
+		barWidth := math.Max(t.Amount/largest*maxBar, 2)
+		fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="end" font-size="15" fill="#333">%s</text>`, barX-12, y+barHeight/2+5, html.EscapeString(upperFirst(t.Category)))
+		fmt.Fprintf(&svg, `<rect x="%d" y="%d" width="%.1f" height="%d" rx="4" fill="%s"/>`, barX, y, barWidth, barHeight, categoryColor(t.Category))
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%d" font-size="14" fill="#666">$%.2f (%.1f%%)</text>`, float64(barX)+barWidth+10, y+barHeight/2+5, t.Amount, t.Percent)
+	}
+
+	svg.WriteString(`</svg>`)
+	return svg.String()
+}
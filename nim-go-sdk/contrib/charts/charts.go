@@ -0,0 +1,343 @@
+// Package charts renders financial charts as standalone SVG images:
+// balance and savings trends, spending by category, lump sum versus
+// dollar-cost averaging projections, and flagged spending. Line charts can
+// also be rendered as PNG, for clients that can't display SVG; a Renderer
+// draws them in either format. Dir saves charts where the client can load
+// them, and Tools returns the generate_chart tool.
+package charts
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/analysis"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/i18n"
+	"github.com/becomeliminal/nim-go-sdk/money"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// Chart dimensions, in pixels.
+const (
+	width   = 800
+	height  = 500
+	padding = 80
+)
+
+// Series is a labeled sequence of values to plot.
+type Series struct {
+	Title  string
+	Labels []string
+	Values []float64
+
+	// Skipped counts transactions left out because their amounts couldn't
+	// be parsed.
+	Skipped int
+}
+
+// BalanceTrend reconstructs the balance after each transaction, oldest
+// first, by working back from the current balance. The first point is the
+// balance before the oldest transaction. Balances are computed exactly in
+// cents; transactions whose amounts can't be parsed are left out and
+// counted in Skipped. Points are labeled as BalanceTrendFor labels them
+// with nil preferences.
+func BalanceTrend(txs []txn.Transaction, currentBalance float64) Series {
+	return BalanceTrendFor(txs, currentBalance, nil)
+}
+
+// BalanceTrendFor is BalanceTrend with points labeled with their dates in
+// the locale and timezone of prefs, e.g. "2. März" in de-DE, or as "Mar 2"
+// in the transactions' own timezone if prefs is nil.
+func BalanceTrendFor(txs []txn.Transaction, currentBalance float64, prefs *core.UserPreferences) Series {
+	s := Series{Title: "Account Balance Trend"}
+	balance, err := money.FromFloat(currentBalance, "")
+	if err != nil {
+		txs = nil
+	}
+
+	sorted := append([]txn.Transaction(nil), txs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return txn.CreatedAt(sorted[i]).Before(txn.CreatedAt(sorted[j]))
+	})
+
+	var counted []txn.Transaction
+	var changes []money.Amount
+	for _, tx := range sorted {
+		c, err := change(tx)
+		var before money.Amount
+		if err == nil {
+			before, err = balance.Sub(c)
+		}
+		if err != nil {
+			s.Skipped++
+			continue
+		}
+		balance = before
+		counted = append(counted, tx)
+		changes = append(changes, c)
+	}
+	if len(counted) == 0 {
+		s.Labels = []string{"Today"}
+		s.Values = []float64{currentBalance}
+		return s
+	}
+
+	s.Labels = append(s.Labels, dateLabel(txn.CreatedAt(counted[0]), prefs))
+	s.Values = append(s.Values, balance.Float64())
+	for i, tx := range counted {
+		balance, _ = balance.Add(changes[i])
+		s.Labels = append(s.Labels, dateLabel(txn.CreatedAt(tx), prefs))
+		s.Values = append(s.Values, balance.Float64())
+	}
+	return s
+}
+
+// SavingsTrend totals each day's savings balance across currencies, oldest
+// first, labeling days as "Mar 2".
+func SavingsTrend(points []executor.SavingsHistoryPoint) Series {
+	return SavingsTrendFor(points, nil)
+}
+
+// SavingsTrendFor is SavingsTrend with days labeled in the locale of
+// prefs, e.g. "2. März" in de-DE. Days are calendar dates, so they aren't
+// moved into the timezone of prefs.
+func SavingsTrendFor(points []executor.SavingsHistoryPoint, prefs *core.UserPreferences) Series {
+	s := Series{Title: "Savings Balance Trend"}
+	totals := make(map[string]float64)
+	var dates []string
+	for _, p := range points {
+		if _, ok := totals[p.Date]; !ok {
+			dates = append(dates, p.Date)
+		}
+		totals[p.Date] += txn.Amount(p.Balance)
+	}
+	sort.Strings(dates)
+
+	for _, date := range dates {
+		label := date
+		if t, err := time.Parse(executor.DateLayout, date); err == nil {
+			label = i18n.FormatDate(t, locale(prefs), "")
+		}
+		s.Labels = append(s.Labels, label)
+		s.Values = append(s.Values, totals[date])
+	}
+	return s
+}
+
+// dateLabel labels a point at t with its date in the locale and timezone
+// of prefs, or in en-US and t's timezone if prefs is nil.
+func dateLabel(t time.Time, prefs *core.UserPreferences) string {
+	if prefs == nil {
+		return i18n.FormatDate(t, locale(prefs), "")
+	}
+	return i18n.FormatDate(t, prefs.Locale, prefs.Timezone)
+}
+
+// locale is the locale of prefs, or en-US if prefs is nil.
+func locale(prefs *core.UserPreferences) string {
+	if prefs == nil {
+		return "en-US"
+	}
+	return prefs.Locale
+}
+
+// change is the transaction's effect on the balance. Balances total every
+// currency together, so it has no currency.
+func change(tx txn.Transaction) (money.Amount, error) {
+	if !txn.IsCredit(tx) && !txn.IsDebit(tx) {
+		return money.Amount{}, nil
+	}
+	amount, err := money.ParseAmount(tx.Amount, "")
+	if err != nil {
+		return money.Amount{}, err
+	}
+	if txn.IsDebit(tx) {
+		return amount.Abs().Neg(), nil
+	}
+	return amount, nil
+}
+
+// LineSVG renders a series as a line chart.
+func LineSVG(s Series) string {
+	if len(s.Labels) == 0 || len(s.Values) == 0 {
+		return `<svg width="600" height="400" xmlns="http://www.w3.org/2000/svg"><text x="300" y="200" text-anchor="middle" fill="#666">No data available</text></svg>`
+	}
+
+	minValue, maxValue := s.Values[0], s.Values[0]
+	for _, v := range s.Values {
+		if v < minValue {
+			minValue = v
+		}
+		if v > maxValue {
+			maxValue = v
+		}
+	}
+
+	var svg strings.Builder
+	open(&svg, width, height)
+	heading(&svg, width, 20, s.Title)
+	grid(&svg, minValue, maxValue)
+
+	plot := newPlot(len(s.Values), minValue, maxValue)
+	if minValue < 0 && maxValue > 0 {
+		// Balances crossing zero get a zero axis so overdrawn stretches stand out
+		_, y := plot.at(0, 0)
+		fmt.Fprintf(&svg, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#999" stroke-width="1.5"/>`, padding, y, width-padding, y)
+	}
+	fmt.Fprintf(&svg, `<polyline points="%s" fill="none" stroke="#4ECDC4" stroke-width="3" stroke-linecap="round" stroke-linejoin="round"/>`, plot.points(s.Values))
+
+	// Label only some points to avoid crowding
+	labelStep := 1
+	if len(s.Labels) > 15 {
+		labelStep = len(s.Labels) / 10
+	}
+	labelY := height - padding + 20
+	for i, v := range s.Values {
+		x, y := plot.at(i, v)
+		fmt.Fprintf(&svg, `<circle cx="%.1f" cy="%.1f" r="4" fill="#4ECDC4" stroke="white" stroke-width="2"/>`, x, y)
+		if i < len(s.Labels) && (i%labelStep == 0 || i == len(s.Labels)-1) {
+			fmt.Fprintf(&svg, `<text x="%.1f" y="%d" text-anchor="middle" font-size="10" fill="#666" transform="rotate(-45 %.1f %d)">%s</text>`, x, labelY, x, labelY, html.EscapeString(s.Labels[i]))
+		}
+	}
+
+	svg.WriteString(`</svg>`)
+	return svg.String()
+}
+
+// Projection returns the month-end value of principal saved at apy percent,
+// compounded monthly, either deposited at once (lumpSum) or in equal monthly
+// chunks (dca), as tools.ProjectSavings projects them. Both have months+1
+// values, starting at month zero. A principal that doesn't divide into
+// whole cents a month has the odd cents deposited at the start.
+func Projection(principal, apy float64, months int) (lumpSum, dca []float64) {
+	total := tools.Cents(principal)
+	chunk := total / int64(months)
+	lump, err := tools.ProjectSavings(tools.SavingsPlan{Principal: total, APY: apy, Months: months})
+	if err != nil {
+		return make([]float64, months+1), make([]float64, months+1)
+	}
+	chunks, err := tools.ProjectSavings(tools.SavingsPlan{
+		Principal:           total - chunk*int64(months),
+		MonthlyContribution: chunk,
+		APY:                 apy,
+		Months:              months,
+	})
+	if err != nil {
+		return make([]float64, months+1), make([]float64, months+1)
+	}
+	return lump.Balances(), chunks.Balances()
+}
+
+// InvestmentComparisonSVG charts a year of lump sum against dollar-cost
+// averaging for principal at apy percent. A negative principal has nothing
+// to invest and is charted as zero.
+func InvestmentComparisonSVG(principal, apy float64, currency string) string {
+	const months = 12
+	principal = math.Max(principal, 0)
+	lumpSum, dca := Projection(principal, apy, months)
+	maxValue := lumpSum[months]
+	if maxValue <= 0 {
+		maxValue = 1
+	}
+
+	var svg strings.Builder
+	open(&svg, width, height)
+	heading(&svg, width, 18, fmt.Sprintf("Investment Strategy Comparison (%.2f%% APY)", apy))
+	subheading(&svg, width, 50, fmt.Sprintf("Starting Amount: %.2f %s over 12 months", principal, currency))
+	grid(&svg, 0, maxValue)
+
+	plot := newPlot(months+1, 0, maxValue)
+	for i := 0; i <= months; i += 3 {
+		x, _ := plot.at(i, 0)
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%d" text-anchor="middle" font-size="12" fill="#666">Month %d</text>`, x, height-padding+25, i)
+	}
+	fmt.Fprintf(&svg, `<polyline points="%s" fill="none" stroke="#2196F3" stroke-width="3"/>`, plot.points(lumpSum))
+	fmt.Fprintf(&svg, `<polyline points="%s" fill="none" stroke="#4CAF50" stroke-width="3" stroke-dasharray="5,5"/>`, plot.points(dca))
+
+	legendX, legendY := padding+20, padding+20
+	fmt.Fprintf(&svg, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#2196F3" stroke-width="3"/>`, legendX, legendY, legendX+40, legendY)
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="14" fill="#333">💰 Lump Sum: $%.2f</text>`, legendX+50, legendY+5, lumpSum[months])
+	fmt.Fprintf(&svg, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#4CAF50" stroke-width="3" stroke-dasharray="5,5"/>`, legendX, legendY+25, legendX+40, legendY+25)
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="14" fill="#333">📅 DCA (Chunks): $%.2f</text>`, legendX+50, legendY+30, dca[months])
+
+	if dca[months] > 0 {
+		difference := lumpSum[months] - dca[months]
+		fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="12" fill="#FF5722">Difference: $%.2f (%.1f%%)</text>`, legendX, legendY+55, difference, difference/dca[months]*100)
+	}
+
+	svg.WriteString(`</svg>`)
+	return svg.String()
+}
+
+// FlaggedSpendingSVG draws flagged spending as bubbles sized by amount:
+// red for unnecessary and orange for excessive spending.
+func FlaggedSpendingSVG(f analysis.Flagged) string {
+	const chartHeight = 600
+
+	var svg strings.Builder
+	open(&svg, width, chartHeight)
+	heading(&svg, width, 20, "Flagged Spending Analysis")
+	subheading(&svg, width, 55, "Red = Unnecessary | Orange = Excessive")
+
+	type bubble struct {
+		item         analysis.FlaggedItem
+		fill, stroke string
+	}
+	var bubbles []bubble
+	for _, item := range f.Unnecessary {
+		bubbles = append(bubbles, bubble{item, "#ff5252", "#d32f2f"})
+	}
+	for _, item := range f.Excessive {
+		bubbles = append(bubbles, bubble{item, "#ff9800", "#f57c00"})
+	}
+
+	if len(bubbles) == 0 {
+		svg.WriteString(`<text x="400" y="300" text-anchor="middle" font-size="18" fill="#4CAF50">✅ No major spending issues found!</text>`)
+		svg.WriteString(`</svg>`)
+		return svg.String()
+	}
+
+	maxAmount := 0.0
+	for _, b := range bubbles {
+		if b.item.Amount > maxAmount {
+			maxAmount = b.item.Amount
+		}
+	}
+	if maxAmount <= 0 {
+		maxAmount = 1
+	}
+
+	// Three bubbles per row
+	for i, b := range bubbles {
+		x := 150 + (i%3)*250
+		y := 120 + (i/3)*140
+		radius := 30 + b.item.Amount/maxAmount*40
+		fmt.Fprintf(&svg, `<circle cx="%d" cy="%d" r="%.1f" fill="%s" opacity="0.7" stroke="%s" stroke-width="2"/>`, x, y, radius, b.fill, b.stroke)
+		fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle" font-size="14" font-weight="bold" fill="#fff">%s</text>`, x, y-5, html.EscapeString(upperFirst(b.item.Category)))
+		fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle" font-size="16" font-weight="bold" fill="#fff">$%.0f</text>`, x, y+15, b.item.Amount)
+	}
+
+	summaryY := chartHeight - 80
+	fmt.Fprintf(&svg, `<text x="400" y="%d" text-anchor="middle" font-size="18" font-weight="bold" fill="#333">Potential Monthly Savings: $%.2f</text>`, summaryY, f.PotentialSavings())
+	fmt.Fprintf(&svg, `<text x="400" y="%d" text-anchor="middle" font-size="14" fill="#666">Unnecessary: $%.2f | Excessive: $%.2f</text>`, summaryY+25, f.TotalUnnecessary, f.TotalExcessive)
+
+	svg.WriteString(`</svg>`)
+	return svg.String()
+}
+
+// upperFirst uppercases the first letter of s.
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
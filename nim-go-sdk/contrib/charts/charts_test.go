@@ -0,0 +1,459 @@
+package charts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestBalanceTrend(t *testing.T) {
+	txs := []txn.Transaction{
+		{Amount: "30", Direction: "debit", CreatedAt: "2026-03-03T00:00:00Z"},
+		{Amount: "50", Direction: "credit", CreatedAt: "2026-03-01T00:00:00Z"},
+	}
+
+	s := BalanceTrend(txs, 100)
+	want := []float64{80, 130, 100}
+	if len(s.Values) != len(want) {
+		t.Fatalf("BalanceTrend() values = %v, want %v", s.Values, want)
+	}
+	for i := range want {
+		if s.Values[i] != want[i] {
+			t.Errorf("BalanceTrend() values = %v, want %v", s.Values, want)
+			break
+		}
+	}
+	if s.Labels[1] != "Mar 1" || s.Labels[2] != "Mar 3" {
+		t.Errorf("BalanceTrend() labels = %v, want oldest first", s.Labels)
+	}
+
+	// In Los Angeles, both transactions were the evening before.
+	prefs := &core.UserPreferences{Locale: "de-DE", Timezone: "America/Los_Angeles"}
+	if s := BalanceTrendFor(txs, 100, prefs); s.Labels[1] != "28. Feb." || s.Labels[2] != "2. März" {
+		t.Errorf("BalanceTrendFor(de-DE, Los Angeles) labels = %v, want 28. Feb. and 2. März", s.Labels)
+	}
+
+	if s := BalanceTrend(nil, 42); len(s.Values) != 1 || s.Values[0] != 42 {
+		t.Errorf("BalanceTrend(nil) = %+v, want only the current balance", s)
+	}
+
+	txs = []txn.Transaction{
+		{Amount: "0.10", Direction: "credit", CreatedAt: "2026-03-01T00:00:00Z"},
+		{Amount: "12abc", Direction: "debit", CreatedAt: "2026-03-02T00:00:00Z"},
+		{Amount: "-0.20", CreatedAt: "2026-03-03T00:00:00Z"},
+	}
+	s = BalanceTrend(txs, 0.3)
+	want = []float64{0.4, 0.5, 0.3}
+	if s.Skipped != 1 || len(s.Values) != len(want) {
+		t.Fatalf("BalanceTrend() = %+v, want values %v and 1 skipped", s, want)
+	}
+	for i := range want {
+		if s.Values[i] != want[i] {
+			t.Errorf("BalanceTrend() values = %v, want exactly %v", s.Values, want)
+			break
+		}
+	}
+}
+
+func TestProjection(t *testing.T) {
+	lumpSum, dca := Projection(1200, 0, 12)
+	if lumpSum[12] != 1200 || dca[12] != 1200 {
+		t.Errorf("at 0%% APY lump sum = %v, dca = %v, want both 1200", lumpSum[12], dca[12])
+	}
+
+	lumpSum, dca = Projection(1200, 6, 12)
+	if lumpSum[12] <= dca[12] || dca[12] <= 1200 {
+		t.Errorf("at 6%% APY lump sum = %v, dca = %v, want lump sum > dca > principal", lumpSum[12], dca[12])
+	}
+}
+
+func TestLineSVGEscapes(t *testing.T) {
+	svg := LineSVG(Series{Title: "<script>", Labels: []string{"a&b"}, Values: []float64{1}})
+	if strings.Contains(svg, "<script>") || !strings.Contains(svg, "a&amp;b") {
+		t.Errorf("LineSVG() didn't escape text: %s", svg)
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := Dir{Path: t.TempDir(), BaseURL: "http://localhost:8080/charts/"}
+
+	url, err := dir.Save("trend", "<svg/>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(url, "http://localhost:8080/charts/trend-") {
+		t.Errorf("Save() = %s", url)
+	}
+	name := filepath.Base(url)
+	if _, err := os.Stat(filepath.Join(dir.Path, name)); err != nil {
+		t.Errorf("chart file not written: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	dir.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/charts/"+name, nil))
+	if rec.Code != 200 || rec.Header().Get("Content-Type") != "image/svg+xml" || rec.Body.String() != "<svg/>" {
+		t.Errorf("Handler() = %d %q %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestBalanceTrendTool(t *testing.T) {
+	recent := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{Transactions: []executor.Transaction{
+			{Amount: "10", Direction: "debit", CreatedAt: recent},
+			{Amount: "99", Direction: "debit", CreatedAt: "2020-01-01T00:00:00Z"},
+		}},
+		"get_balance": executor.GetBalanceResponse{Balances: []executor.WalletBalance{{Currency: "USD", Amount: "50"}}},
+	}}
+	dir := Dir{Path: t.TempDir(), BaseURL: "/charts/"}
+
+	result, err := BalanceTrendTool(exec, dir).Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"days":7}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("generate_chart failed: %v %+v", err, result)
+	}
+	env := result.Data.(*core.Envelope)
+	data := env.Data.(map[string]interface{})
+	if data["total_points"] != 2 {
+		t.Errorf("total_points = %v, want 2 (old transactions excluded)", data["total_points"])
+	}
+	if url, _ := data["image_url"].(string); !strings.HasPrefix(url, "/charts/balance-trend-") {
+		t.Errorf("image_url = %v", data["image_url"])
+	}
+	if len(env.Artifacts) != 1 || env.Artifacts[0].Kind != artifact.KindChart || !strings.HasSuffix(data["image_url"].(string), env.Artifacts[0].Location) {
+		t.Errorf("Artifacts = %+v, want the chart", env.Artifacts)
+	}
+}
+
+func TestLineSVGCrossingZero(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []float64
+		wantAxis   bool
+		wantLabels []string
+	}{
+		{"crossing zero", []float64{50, -50, 25}, true, []string{"$50", "$0", "-$50"}},
+		{"all negative", []float64{-10, -30}, false, []string{"-$10", "-$30"}},
+		{"all positive", []float64{10, 30}, false, []string{"$10", "$30"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := make([]string, len(tt.values))
+			svg := LineSVG(Series{Title: "Balance", Labels: labels, Values: tt.values})
+
+			if got := strings.Contains(svg, `stroke="#999"`); got != tt.wantAxis {
+				t.Errorf("zero axis drawn = %v, want %v", got, tt.wantAxis)
+			}
+			for _, label := range tt.wantLabels {
+				if !strings.Contains(svg, ">"+label+"</text>") {
+					t.Errorf("missing axis label %s", label)
+				}
+			}
+			if strings.Contains(svg, "$-") {
+				t.Error("negative label formatted as $-")
+			}
+		})
+	}
+}
+
+func TestAxisLabel(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{12.4, "$12"},
+		{-12.6, "-$13"},
+		{-0.2, "$0"},
+		{0, "$0"},
+	}
+	for _, tt := range tests {
+		if got := axisLabel(tt.v); got != tt.want {
+			t.Errorf("axisLabel(%v) = %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestInvestmentComparisonNegativePrincipal(t *testing.T) {
+	svg := InvestmentComparisonSVG(-100, 5, "USDC")
+	if strings.Contains(svg, "-100") || strings.Contains(svg, "$-") {
+		t.Errorf("negative principal charted: %s", svg)
+	}
+}
+
+// goldenSeries is a fixed balance trend that dips below zero.
+var goldenSeries = Series{
+	Title:  "Account Balance Trend",
+	Labels: []string{"Mar 1", "Mar 2", "Mar 3", "Mar 4", "Mar 5"},
+	Values: []float64{80, 130, -20, 45.5, 100},
+}
+
+func TestRenderers_Golden(t *testing.T) {
+	tests := []struct {
+		format, file string
+		same         func(got, want []byte) bool
+	}{
+		{FormatSVG, "line.svg", bytes.Equal},
+		{FormatPNG, "line.png", samePixels},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			renderer, err := RendererFor(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := renderer.Line(goldenSeries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", tt.file)
+			if *update {
+				if err := os.WriteFile(path, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !tt.same(got, want) {
+				t.Errorf("%s differs from %s (run go test -update and inspect the diff)", tt.format, path)
+			}
+		})
+	}
+
+	if _, err := RendererFor("gif"); err == nil {
+		t.Error("RendererFor accepted an unsupported format")
+	}
+}
+
+// samePixels compares PNGs by their decoded pixels, so changes to the
+// encoder's compression don't break the golden file.
+func samePixels(got, want []byte) bool {
+	a, err := png.Decode(bytes.NewReader(got))
+	if err != nil {
+		return false
+	}
+	b, err := png.Decode(bytes.NewReader(want))
+	if err != nil || a.Bounds() != b.Bounds() {
+		return false
+	}
+	for y := a.Bounds().Min.Y; y < a.Bounds().Max.Y; y++ {
+		for x := a.Bounds().Min.X; x < a.Bounds().Max.X; x++ {
+			r1, g1, b1, a1 := a.At(x, y).RGBA()
+			r2, g2, b2, a2 := b.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestBalanceTrendTool_PNG(t *testing.T) {
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{},
+		"get_balance":      executor.GetBalanceResponse{Balances: []executor.WalletBalance{{Currency: "USD", Amount: "50"}}},
+	}}
+	dir := Dir{Path: t.TempDir(), BaseURL: "/charts/"}
+	tool := BalanceTrendTool(exec, dir)
+
+	result, err := tool.Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"format":"png"}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("generate_chart failed: %v %+v", err, result)
+	}
+	env := result.Data.(*core.Envelope)
+	data := env.Data.(map[string]interface{})
+	url, _ := data["image_url"].(string)
+	if !strings.HasSuffix(url, ".png") || data["media_type"] != "image/png" || env.Artifacts[0].MediaType != "image/png" {
+		t.Errorf("image_url = %v, media_type = %v, artifact = %+v, want a PNG", url, data["media_type"], env.Artifacts[0])
+	}
+
+	rec := httptest.NewRecorder()
+	dir.Handler().ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+	if rec.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", rec.Header().Get("Content-Type"))
+	}
+	if _, _, err := image.Decode(rec.Body); err != nil {
+		t.Errorf("served chart is not an image: %v", err)
+	}
+
+	result, _ = tool.Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"format":"jpeg"}`)})
+	if result.Success {
+		t.Error("generate_chart accepted an unsupported format")
+	}
+}
+
+func TestSavingsTrendTool(t *testing.T) {
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_savings_history": executor.GetSavingsHistoryResponse{Points: []executor.SavingsHistoryPoint{
+			{Date: "2026-03-02", Currency: "USD", Balance: "100.01"},
+			{Date: "2026-03-01", Currency: "USD", Balance: "100"},
+			{Date: "2026-03-01", Currency: "EUR", Balance: "50"},
+		}},
+	}}
+	dir := Dir{Path: t.TempDir(), BaseURL: "/charts/"}
+
+	result, err := BalanceTrendTool(exec, dir).Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"data_type":"savings_trend","days":7}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("generate_chart failed: %v %+v", err, result)
+	}
+	data := result.Data.(*core.Envelope).Data.(map[string]interface{})
+	if data["data_type"] != "savings_trend" || data["total_points"] != 2 {
+		t.Errorf("data = %v, want 2 savings_trend points (one per day)", data)
+	}
+	if url, _ := data["image_url"].(string); !strings.HasPrefix(url, "/charts/savings-trend-") {
+		t.Errorf("image_url = %v", data["image_url"])
+	}
+	if reqs := exec.Requests(); len(reqs) != 1 || string(reqs[0].Input) != `{"days":7}` {
+		t.Errorf("requests = %v, want one get_savings_history for 7 days", reqs)
+	}
+
+	s := SavingsTrend(exec.Responses["get_savings_history"].(executor.GetSavingsHistoryResponse).Points)
+	if strings.Join(s.Labels, ",") != "Mar 1,Mar 2" || s.Values[0] != 150 || s.Values[1] != 100.01 {
+		t.Errorf("SavingsTrend = %v %v, want totals per day, oldest first", s.Labels, s.Values)
+	}
+}
+
+// goldenSpending is a fixed set of transactions covering every kind of
+// debit the keyword categorizer sees, plus a credit to leave out.
+var goldenSpending = []txn.Transaction{
+	{Amount: "15.99", Direction: "debit", Note: "Netflix monthly"},
+	{Amount: "12.50", Direction: "debit", Note: "Lunch at the cafe"},
+	{Amount: "84.20", Direction: "debit", Note: "Grocer weekly shop"},
+	{Amount: "32", Direction: "debit", Note: "Uber to the airport"},
+	{Amount: "25", Direction: "debit", Note: "Phone case"},
+	{Amount: "40", Direction: "debit"},
+	{Amount: "2000", Direction: "credit", Note: "Salary"},
+}
+
+func TestCategoryBreakdown(t *testing.T) {
+	totals := CategoryBreakdown(context.Background(), goldenSpending, nil)
+	var got []string
+	for _, c := range totals {
+		got = append(got, fmt.Sprintf("%s=%.2f/%.1f", c.Category, c.Amount, c.Percent))
+	}
+	want := "food=96.70/46.1,miscellaneous=40.00/19.1,travel=32.00/15.3,electronics=25.00/11.9,subscription=15.99/7.6"
+	if strings.Join(got, ",") != want {
+		t.Errorf("CategoryBreakdown = %s, want %s", strings.Join(got, ","), want)
+	}
+
+	if totals := CategoryBreakdown(context.Background(), goldenSpending[6:], nil); len(totals) != 0 {
+		t.Errorf("CategoryBreakdown of a credit = %+v, want none", totals)
+	}
+}
+
+func TestCategorySVG_Golden(t *testing.T) {
+	totals := CategoryBreakdown(context.Background(), goldenSpending, nil)
+	tests := []struct {
+		file string
+		svg  string
+	}{
+		{"category_pie.svg", CategoryPieSVG(totals)},
+		{"category_bar.svg", CategoryBarSVG(totals)},
+		{"category_single.svg", CategoryPieSVG(totals[:1])},
+		{"category_empty.svg", CategoryPieSVG(nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.file)
+			if *update {
+				if err := os.WriteFile(path, []byte(tt.svg), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if tt.svg != string(want) {
+				t.Errorf("SVG differs from %s (run go test -update and inspect the diff)", path)
+			}
+		})
+	}
+
+	if CategoryBarSVG(nil) != CategoryPieSVG(nil) {
+		t.Error("empty bar and pie charts differ")
+	}
+}
+
+func TestCategoryBreakdownTool(t *testing.T) {
+	recent := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	var txs []executor.Transaction
+	for _, tx := range goldenSpending {
+		tx.CreatedAt = recent
+		txs = append(txs, tx)
+	}
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{Transactions: txs},
+	}}
+	dir := Dir{Path: t.TempDir(), BaseURL: "/charts/"}
+	tool := ChartTool(exec, dir, nil)
+
+	tests := []struct {
+		input     string
+		wantType  string
+		wantError string
+	}{
+		{input: `{"data_type":"category_breakdown"}`, wantType: ChartPie},
+		{input: `{"data_type":"category_breakdown","chart_type":"bar","days":7}`, wantType: ChartBar},
+		{input: `{"data_type":"category_breakdown","chart_type":"line"}`, wantError: "pie or bar"},
+		{input: `{"data_type":"category_breakdown","format":"png"}`, wantError: "only available as svg"},
+		{input: `{"data_type":"balance_trend","chart_type":"pie"}`, wantError: "line charts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := tool.Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(tt.input)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantError != "" {
+				if result.Success || !strings.Contains(result.Error, tt.wantError) {
+					t.Errorf("result = %+v, want an error mentioning %q", result, tt.wantError)
+				}
+				return
+			}
+			if !result.Success {
+				t.Fatalf("generate_chart failed: %+v", result)
+			}
+			env := result.Data.(*core.Envelope)
+			data := env.Data.(map[string]interface{})
+			if data["chart_type"] != tt.wantType || data["total_spent"] != 209.69 {
+				t.Errorf("data = %v", data)
+			}
+			if totals := data["categories"].([]CategoryTotal); len(totals) != 5 || totals[0].Category != "food" {
+				t.Errorf("categories = %+v", totals)
+			}
+			if url, _ := data["image_url"].(string); !strings.HasPrefix(url, "/charts/category-breakdown-") {
+				t.Errorf("image_url = %v", data["image_url"])
+			}
+		})
+	}
+
+	empty := ChartTool(&txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{},
+	}}, dir, nil)
+	result, err := empty.Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"data_type":"category_breakdown"}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("generate_chart failed: %v %+v", err, result)
+	}
+	if env := result.Data.(*core.Envelope); env.Status != core.StatusEmpty {
+		t.Errorf("status = %q, want %q", env.Status, core.StatusEmpty)
+	}
+}
@@ -0,0 +1,53 @@
+package charts
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Dir stores rendered charts as files in Path, served to clients under BaseURL.
+// BaseURL should also be allowlisted for images by the sanitizer.
+type Dir struct {
+	// Path is the directory charts are written to.
+	Path string
+
+	// BaseURL is the URL the directory is served at, e.g. http://localhost:8080/charts/.
+	BaseURL string
+}
+
+// Save writes svg to a new file named after prefix and returns its URL.
+func (d Dir) Save(prefix, svg string) (string, error) {
+	return d.SaveImage(prefix, SVG{}.Ext(), []byte(svg))
+}
+
+// SaveImage writes an image to a new file named after prefix with the
+// extension ext, e.g. ".png", and returns its URL.
+func (d Dir) SaveImage(prefix, ext string, data []byte) (string, error) {
+	if err := os.MkdirAll(d.Path, 0755); err != nil {
+		return "", fmt.Errorf("failed to create charts directory: %w", err)
+	}
+	name := fmt.Sprintf("%s-%d%s", prefix, time.Now().UnixNano(), ext)
+	if err := os.WriteFile(filepath.Join(d.Path, name), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save chart: %w", err)
+	}
+	return strings.TrimSuffix(d.BaseURL, "/") + "/" + name, nil
+}
+
+// Handler serves the saved charts, with a Content-Type matching their
+// extension. Mount it at BaseURL's path, e.g.
+// http.Handle("/charts/", dir.Handler()).
+func (d Dir) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := filepath.Base(r.URL.Path)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if mediaType := mime.TypeByExtension(filepath.Ext(name)); mediaType != "" {
+			w.Header().Set("Content-Type", mediaType)
+		}
+		http.ServeFile(w, r, filepath.Join(d.Path, name))
+	})
+}
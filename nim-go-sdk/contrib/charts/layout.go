@@ -0,0 +1,90 @@
+package charts
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// Layout shared by the SVG charts: the canvas, headings, value grids and
+// plot coordinates.
+
+// open writes the svg element and a white background.
+func open(svg *strings.Builder, w, h int) {
+	fmt.Fprintf(svg, `<svg width="%d" height="%d" xmlns="http://www.w3.org/2000/svg">`, w, h)
+	fmt.Fprintf(svg, `<rect width="%d" height="%d" fill="#ffffff"/>`, w, h)
+}
+
+// grid writes five horizontal grid lines labeled from maxValue down to minValue.
+func grid(svg *strings.Builder, minValue, maxValue float64) {
+	chartHeight := height - padding*2
+	for i := 0; i <= 4; i++ {
+		y := float64(padding) + float64(chartHeight*i)/4
+		value := maxValue - float64(i)/4*(maxValue-minValue)
+		fmt.Fprintf(svg, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e0e0e0" stroke-width="1"/>`, padding, y, width-padding, y)
+		fmt.Fprintf(svg, `<text x="%d" y="%.1f" text-anchor="end" font-size="12" fill="#666">%s</text>`, padding-10, y+4, axisLabel(value))
+	}
+}
+
+// axisLabel formats a dollar axis value with its sign first, e.g. -$50.
+func axisLabel(v float64) string {
+	v = math.Round(v)
+	if v < 0 {
+		return fmt.Sprintf("-$%.0f", -v)
+	}
+	return fmt.Sprintf("$%.0f", math.Abs(v)) // avoid "$-0"
+}
+
+// plot maps point indexes and values to chart coordinates.
+type plot struct {
+	n                  int
+	minValue, valRange float64
+}
+
+func newPlot(n int, minValue, maxValue float64) plot {
+	valRange := maxValue - minValue
+	if valRange == 0 {
+		valRange = 1
+	}
+	return plot{n: n, minValue: minValue, valRange: valRange}
+}
+
+func (p plot) at(i int, v float64) (x, y float64) {
+	chartWidth := float64(width - padding*2)
+	chartHeight := float64(height - padding*2)
+	if p.n > 1 {
+		x = float64(i) / float64(p.n-1) * chartWidth
+	}
+	y = chartHeight - (v-p.minValue)/p.valRange*chartHeight
+	return float64(padding) + x, float64(padding) + y
+}
+
+func (p plot) points(values []float64) string {
+	points := make([]string, len(values))
+	for i, v := range values {
+		x, y := p.at(i, v)
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+	return strings.Join(points, " ")
+}
+
+// heading writes a bold title centered across a chart w pixels wide.
+func heading(svg *strings.Builder, w, size int, text string) {
+	fmt.Fprintf(svg, `<text x="%d" y="30" text-anchor="middle" font-size="%d" font-weight="bold" fill="#333">%s</text>`, w/2, size, html.EscapeString(text))
+}
+
+// subheading writes a line of muted text centered under the heading.
+func subheading(svg *strings.Builder, w, y int, text string) {
+	fmt.Fprintf(svg, `<text x="%d" y="%d" text-anchor="middle" font-size="14" fill="#666">%s</text>`, w/2, y, html.EscapeString(text))
+}
+
+// placeholder returns a chart with a title and a message in place of data.
+func placeholder(title, message string) string {
+	var svg strings.Builder
+	open(&svg, width, height)
+	heading(&svg, width, 20, title)
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle" font-size="18" fill="#666">%s</text>`, width/2, height/2, html.EscapeString(message))
+	svg.WriteString(`</svg>`)
+	return svg.String()
+}
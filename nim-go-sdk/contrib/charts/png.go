@@ -0,0 +1,151 @@
+package charts
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+// Colors of the line chart, matching LineSVG.
+var (
+	white     = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	dark      = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	muted     = color.RGBA{0x66, 0x66, 0x66, 0xff}
+	gridColor = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	axisColor = color.RGBA{0x99, 0x99, 0x99, 0xff}
+	lineColor = color.RGBA{0x4e, 0xcd, 0xc4, 0xff}
+)
+
+// LinePNG renders a series as a line chart laid out like LineSVG. Text is
+// drawn in a small built-in bitmap font, in capitals; x-axis labels are
+// horizontal rather than slanted.
+func LinePNG(s Series) ([]byte, error) {
+	if len(s.Labels) == 0 || len(s.Values) == 0 {
+		c := newCanvas(600, 400)
+		c.text("No data available", 300, 200, 1, muted, alignCenter)
+		return c.encode()
+	}
+
+	minValue, maxValue := s.Values[0], s.Values[0]
+	for _, v := range s.Values {
+		minValue = math.Min(minValue, v)
+		maxValue = math.Max(maxValue, v)
+	}
+
+	c := newCanvas(width, height)
+	c.text(s.Title, width/2, 30, 2, dark, alignCenter)
+	chartHeight := height - padding*2
+	for i := 0; i <= 4; i++ {
+		y := float64(padding) + float64(chartHeight*i)/4
+		value := maxValue - float64(i)/4*(maxValue-minValue)
+		c.line(padding, y, width-padding, y, 1, gridColor)
+		c.text(axisLabel(value), padding-10, int(y), 1, muted, alignRight)
+	}
+
+	plot := newPlot(len(s.Values), minValue, maxValue)
+	if minValue < 0 && maxValue > 0 {
+		_, y := plot.at(0, 0)
+		c.line(padding, y, width-padding, y, 1.5, axisColor)
+	}
+	for i := 1; i < len(s.Values); i++ {
+		x0, y0 := plot.at(i-1, s.Values[i-1])
+		x1, y1 := plot.at(i, s.Values[i])
+		c.line(x0, y0, x1, y1, 3, lineColor)
+	}
+
+	labelStep := 1
+	if len(s.Labels) > 15 {
+		labelStep = len(s.Labels) / 10
+	}
+	for i, v := range s.Values {
+		x, y := plot.at(i, v)
+		c.disc(x, y, 5, white)
+		c.disc(x, y, 3, lineColor)
+		if i < len(s.Labels) && (i%labelStep == 0 || i == len(s.Labels)-1) {
+			c.text(s.Labels[i], int(x), height-padding+20, 1, muted, alignCenter)
+		}
+	}
+	return c.encode()
+}
+
+// canvas is an image the chart is drawn on.
+type canvas struct {
+	img *image.RGBA
+}
+
+func newCanvas(w, h int) *canvas {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+	return &canvas{img: img}
+}
+
+func (c *canvas) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// disc fills the pixels whose centers lie within r of (cx, cy).
+func (c *canvas) disc(cx, cy, r float64, col color.RGBA) {
+	for y := int(math.Floor(cy - r)); y <= int(math.Ceil(cy+r)); y++ {
+		for x := int(math.Floor(cx - r)); x <= int(math.Ceil(cx+r)); x++ {
+			dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+			if dx*dx+dy*dy <= r*r {
+				c.img.SetRGBA(x, y, col)
+			}
+		}
+	}
+}
+
+// line draws a line of the given width with round ends.
+func (c *canvas) line(x0, y0, x1, y1, w float64, col color.RGBA) {
+	steps := int(math.Ceil(math.Hypot(x1-x0, y1-y0) * 2))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		c.disc(x0+(x1-x0)*t, y0+(y1-y0)*t, w/2, col)
+	}
+}
+
+// Text alignments, relative to the x passed to text.
+const (
+	alignLeft = iota
+	alignCenter
+	alignRight
+)
+
+// text draws s centered vertically on y, scale pixels per font pixel.
+func (c *canvas) text(s string, x, y, scale int, col color.RGBA, align int) {
+	runes := []rune(s)
+	advance := (glyphWidth + 1) * scale
+	w := len(runes)*advance - scale
+	switch align {
+	case alignCenter:
+		x -= w / 2
+	case alignRight:
+		x -= w
+	}
+	top := y - glyphHeight*scale/2
+	for i, r := range runes {
+		for row, bits := range glyph(r) {
+			for gx := 0; gx < glyphWidth; gx++ {
+				if bits&(1<<(glyphWidth-1-gx)) == 0 {
+					continue
+				}
+				px, py := x+i*advance+gx*scale, top+row*scale
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						c.img.SetRGBA(px+dx, py+dy, col)
+					}
+				}
+			}
+		}
+	}
+}
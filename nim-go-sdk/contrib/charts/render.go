@@ -0,0 +1,55 @@
+package charts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Renderer draws charts in one image format.
+type Renderer interface {
+	// Line renders the series as a line chart.
+	Line(s Series) ([]byte, error)
+
+	// MediaType is the media type of the rendered images, e.g. image/png.
+	MediaType() string
+
+	// Ext is the file extension for the rendered images, e.g. ".png".
+	Ext() string
+}
+
+// Formats generate_chart can render, by name.
+const (
+	FormatSVG = "svg"
+	FormatPNG = "png"
+)
+
+// RendererFor returns the renderer for a format name. An empty name is SVG.
+func RendererFor(format string) (Renderer, error) {
+	switch strings.ToLower(format) {
+	case "", FormatSVG:
+		return SVG{}, nil
+	case FormatPNG:
+		return PNG{}, nil
+	}
+	return nil, fmt.Errorf("format must be %q or %q, got %q", FormatSVG, FormatPNG, format)
+}
+
+// SVG renders charts as standalone SVG images.
+type SVG struct{}
+
+func (SVG) Line(s Series) ([]byte, error) { return []byte(LineSVG(s)), nil }
+func (SVG) MediaType() string             { return "image/svg+xml" }
+func (SVG) Ext() string                   { return ".svg" }
+
+// PNG renders charts as PNG images, for clients that can't display SVG.
+type PNG struct{}
+
+func (PNG) Line(s Series) ([]byte, error) { return LinePNG(s) }
+func (PNG) MediaType() string             { return "image/png" }
+func (PNG) Ext() string                   { return ".png" }
+
+// Verify SVG and PNG implement Renderer.
+var (
+	_ Renderer = SVG{}
+	_ Renderer = PNG{}
+)
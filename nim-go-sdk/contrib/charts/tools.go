@@ -0,0 +1,243 @@
+package charts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+	"github.com/becomeliminal/nim-go-sdk/contrib/analysis"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// Tools returns the chart tools, reading account data through exec and
+// saving charts to dir: generate_chart. Spending is categorized with
+// categorizer, or by keyword if it is nil.
+func Tools(exec core.ToolExecutor, dir Dir, categorizer *analysis.Categorizer) []core.Tool {
+	return []core.Tool{
+		ChartTool(exec, dir, categorizer),
+	}
+}
+
+// Chart data types offered by generate_chart.
+const (
+	DataBalanceTrend      = "balance_trend"
+	DataSavingsTrend      = "savings_trend"
+	DataCategoryBreakdown = "category_breakdown"
+)
+
+// categoryFetchLimit is how many transactions a category breakdown reads.
+const categoryFetchLimit = 200
+
+// BalanceTrendTool returns the generate_chart tool, categorizing spending
+// by keyword.
+//
+// Deprecated: Use ChartTool.
+func BalanceTrendTool(exec core.ToolExecutor, dir Dir) core.Tool {
+	return ChartTool(exec, dir, nil)
+}
+
+// ChartTool returns the generate_chart tool, which charts the user's wallet
+// or savings balance over recent days as a line, or their recent spending
+// by category as a pie or bar chart, and returns the chart's URL. Spending
+// is categorized with categorizer, or by keyword if it is nil.
+func ChartTool(exec core.ToolExecutor, dir Dir, categorizer *analysis.Categorizer) core.Tool {
+	return tools.New("generate_chart").
+		Description("Generate a chart of the user's finances: a line chart of the wallet balance, calculated from transaction history (data_type 'balance_trend'), or of the savings balance including interest earned (data_type 'savings_trend'), or a pie or bar chart of spending by category (data_type 'category_breakdown'). Returns an image_url to display with markdown: ![Balance Trend Chart](image_url).").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"chart_type": tools.StringEnumProperty("Type of chart: 'line' for balance_trend and savings_trend; 'pie' (default) or 'bar' for category_breakdown", "line", ChartPie, ChartBar),
+			"data_type": tools.StringEnumProperty("What to visualize: 'balance_trend' for the wallet balance over time (default), 'savings_trend' for the savings balance over time, or 'category_breakdown' for spending by category",
+				DataBalanceTrend, DataSavingsTrend, DataCategoryBreakdown),
+			"days":   tools.IntegerProperty("Number of days of data to include (default: 30)"),
+			"format": tools.StringEnumProperty("Image format (default: svg). Use png if the user's app can't display SVG images. Category breakdowns are SVG only", FormatSVG, FormatPNG),
+		})).
+		NoCache().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				ChartType string `json:"chart_type"`
+				DataType  string `json:"data_type"`
+				Days      int    `json:"days"`
+				Format    string `json:"format"`
+			}
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+			}
+			if input.DataType == "" {
+				input.DataType = DataBalanceTrend
+			}
+			if input.Days <= 0 {
+				input.Days = 30
+			}
+			renderer, err := RendererFor(input.Format)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			var c chart
+			switch input.DataType {
+			case DataBalanceTrend, DataSavingsTrend:
+				c, err = trendChart(ctx, exec, params, renderer, input.DataType, input.ChartType, input.Days)
+			case DataCategoryBreakdown:
+				c, err = categoryChart(ctx, exec, params, categorizer, renderer, input.ChartType, input.Days)
+			default:
+				err = fmt.Errorf("unknown data_type %q: use %s, %s or %s", input.DataType, DataBalanceTrend, DataSavingsTrend, DataCategoryBreakdown)
+			}
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			prefix := strings.ReplaceAll(input.DataType, "_", "-")
+			url, err := dir.SaveImage(prefix, renderer.Ext(), c.image)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			file := path.Base(url)
+			data := map[string]interface{}{
+				"chart_type": c.chartType,
+				"data_type":  input.DataType,
+				"image_url":  url,
+				"media_type": renderer.MediaType(),
+				"message":    fmt.Sprintf("Generated %s chart with %s. View at: %s", strings.ToLower(c.name), c.points, url),
+			}
+			for k, v := range c.data {
+				data[k] = v
+			}
+			env := core.NewEnvelope(data).WithArtifacts(artifact.Ref{
+				ID:        strings.TrimSuffix(file, renderer.Ext()),
+				Kind:      artifact.KindChart,
+				Name:      c.name,
+				MediaType: renderer.MediaType(),
+				Size:      int64(len(c.image)),
+				CreatedAt: time.Now(),
+				Location:  file,
+			})
+			if c.empty {
+				env.WithStatus(core.StatusEmpty)
+			}
+			return env.Result(), nil
+		}).
+		Build()
+}
+
+// chart is a rendered chart and what generate_chart reports about it.
+type chart struct {
+	image     []byte
+	name      string // e.g. "Balance trend"
+	chartType string
+	points    string // what was charted, e.g. "12 data points"
+	data      map[string]interface{}
+	empty     bool
+}
+
+// trendChart renders the balance or savings trend as a line chart.
+func trendChart(ctx context.Context, exec core.ToolExecutor, params *core.ToolParams, renderer Renderer, dataType, chartType string, days int) (chart, error) {
+	if chartType != "" && chartType != "line" {
+		return chart{}, fmt.Errorf("%s charts are line charts, not %s", dataType, chartType)
+	}
+	var series Series
+	var err error
+	c := chart{chartType: "line"}
+	if dataType == DataSavingsTrend {
+		series, err = savingsTrend(ctx, exec, params, days)
+		c.name = "Savings trend"
+	} else {
+		series, err = balanceTrend(ctx, exec, params, days)
+		c.name = "Balance trend"
+	}
+	if err != nil {
+		return chart{}, err
+	}
+
+	params.ReportProgress("rendering chart", 60)
+	if c.image, err = renderer.Line(series); err != nil {
+		return chart{}, fmt.Errorf("failed to render chart: %v", err)
+	}
+	c.points = fmt.Sprintf("%d data points", len(series.Values))
+	c.data = map[string]interface{}{"total_points": len(series.Values)}
+	if series.Skipped > 0 {
+		c.data["skipped_transactions"] = series.Skipped
+	}
+	return c, nil
+}
+
+// categoryChart renders the last days days' spending by category as a pie
+// or bar chart.
+func categoryChart(ctx context.Context, exec core.ToolExecutor, params *core.ToolParams, categorizer *analysis.Categorizer, renderer Renderer, chartType string, days int) (chart, error) {
+	if chartType == "" {
+		chartType = ChartPie
+	}
+	if chartType != ChartPie && chartType != ChartBar {
+		return chart{}, fmt.Errorf("category_breakdown charts are %s or %s charts, not %s", ChartPie, ChartBar, chartType)
+	}
+	if _, ok := renderer.(SVG); !ok {
+		return chart{}, fmt.Errorf("category_breakdown charts are only available as %s", FormatSVG)
+	}
+
+	params.ReportProgress("fetching transactions", 0)
+	txs, err := txn.FetchQuery(ctx, exec, params.UserID, params.RequestID, txn.Query{
+		Limit: categoryFetchLimit,
+		Since: time.Now().AddDate(0, 0, -days),
+	})
+	if err != nil {
+		return chart{}, fmt.Errorf("failed to fetch transactions: %v", err)
+	}
+	params.ReportProgress("categorizing spending", 30)
+	totals := CategoryBreakdown(ctx, txs, categorizer)
+
+	params.ReportProgress("rendering chart", 60)
+	svg := CategoryPieSVG(totals)
+	if chartType == ChartBar {
+		svg = CategoryBarSVG(totals)
+	}
+	return chart{
+		image:     []byte(svg),
+		name:      "Spending by category",
+		chartType: chartType,
+		points:    fmt.Sprintf("%d categories", len(totals)),
+		data: map[string]interface{}{
+			"categories":  totals,
+			"total_spent": totalSpent(totals),
+		},
+		empty: len(totals) == 0,
+	}, nil
+}
+
+// balanceTrend charts the wallet balance after each of the last days days'
+// transactions.
+func balanceTrend(ctx context.Context, exec core.ToolExecutor, params *core.ToolParams, days int) (Series, error) {
+	params.ReportProgress("fetching transactions", 0)
+	txs, err := txn.Fetch(ctx, exec, params.UserID, params.RequestID, 200)
+	if err != nil {
+		return Series{}, fmt.Errorf("failed to fetch transactions: %v", err)
+	}
+	balances, err := txn.Balances(ctx, exec, params.UserID, params.RequestID)
+	if err != nil {
+		return Series{}, fmt.Errorf("failed to fetch balance: %v", err)
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	var recent []txn.Transaction
+	for _, tx := range txs {
+		if txn.CreatedAt(tx).After(since) {
+			recent = append(recent, tx)
+		}
+	}
+	return BalanceTrendFor(recent, txn.Total(balances), core.PreferencesFor(ctx, params)), nil
+}
+
+// savingsTrend charts the savings balance at the end of each of the last
+// days days.
+func savingsTrend(ctx context.Context, exec core.ToolExecutor, params *core.ToolParams, days int) (Series, error) {
+	params.ReportProgress("fetching savings history", 0)
+	points, err := txn.SavingsHistory(ctx, exec, params.UserID, params.RequestID, days)
+	if err != nil {
+		return Series{}, fmt.Errorf("failed to fetch savings history: %v", err)
+	}
+	return SavingsTrendFor(points, core.PreferencesFor(ctx, params)), nil
+}
@@ -0,0 +1,383 @@
+// Package datagen generates believable, reproducible financial histories for
+// demos, screenshots and tests: months of varied transactions, paydays,
+// subscriptions and a savings position, shaped by a Persona.
+//
+// The same Options always produce the same Dataset, byte for byte. Balances
+// reconcile exactly with the transaction history, and Generate refuses to
+// return a dataset that doesn't.
+package datagen
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// Defaults for Options.
+const (
+	DefaultMonths   = 6
+	DefaultCurrency = "USDC"
+	DefaultAPY      = "4.5"
+)
+
+// Options configures Generate.
+type Options struct {
+	// Seed selects the dataset. The same seed gives the same data.
+	Seed int64
+
+	// Persona shapes income and spending. Defaults to Professional.
+	Persona *Persona
+
+	// End is when the history ends, typically today. It is part of the
+	// output, so keep it fixed for reproducible data. Required.
+	End time.Time
+
+	// Months is how many months of history to generate.
+	// Defaults to DefaultMonths.
+	Months int
+
+	// Currency of every transaction. Defaults to DefaultCurrency.
+	Currency string
+
+	// APY is the savings vault's rate in percent. Defaults to DefaultAPY.
+	APY string
+}
+
+// Dataset is a generated financial history, in the executor's response types.
+type Dataset struct {
+	Persona string `json:"persona"`
+	Seed    int64  `json:"seed"`
+
+	// StartingBalance is the wallet balance before the first transaction.
+	StartingBalance string `json:"starting_balance"`
+
+	// Transactions are newest first, as get_transactions returns them.
+	Transactions []executor.Transaction `json:"transactions"`
+
+	Balance executor.GetBalanceResponse        `json:"balance"`
+	Savings executor.GetSavingsBalanceResponse `json:"savings"`
+	Vaults  executor.GetVaultRatesResponse     `json:"vaults"`
+}
+
+// Fixture returns the dataset as canned tool responses keyed by tool name,
+// e.g. for txntest.Executor.Responses.
+func (d *Dataset) Fixture() map[string]interface{} {
+	return map[string]interface{}{
+		"get_balance":         d.Balance,
+		"get_transactions":    executor.GetTransactionsResponse{Transactions: d.Transactions},
+		"get_savings_balance": d.Savings,
+		"get_vault_rates":     d.Vaults,
+	}
+}
+
+// FixtureJSON returns Fixture as indented JSON.
+func (d *Dataset) FixtureJSON() ([]byte, error) {
+	return json.MarshalIndent(d.Fixture(), "", "  ")
+}
+
+// entry is a transaction before it is numbered and formatted.
+type entry struct {
+	at           time.Time
+	amount       int64 // cents, negative for debits
+	kind         string
+	counterparty string
+	note         string
+}
+
+// Generate creates a dataset.
+func Generate(opts Options) (*Dataset, error) {
+	if opts.End.IsZero() {
+		return nil, fmt.Errorf("datagen: End is required")
+	}
+	persona := Professional
+	if opts.Persona != nil {
+		persona = *opts.Persona
+	}
+	months := opts.Months
+	if months <= 0 {
+		months = DefaultMonths
+	}
+	currency := opts.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	apyText := opts.APY
+	if apyText == "" {
+		apyText = DefaultAPY
+	}
+	apy, err := strconv.ParseFloat(apyText, 64)
+	if err != nil || apy < 0 {
+		return nil, fmt.Errorf("datagen: invalid APY %q", apyText)
+	}
+
+	g := &generator{
+		rng:     rand.New(rand.NewSource(opts.Seed)),
+		persona: persona,
+		end:     opts.End.UTC().Truncate(time.Second),
+	}
+	g.start = g.end.AddDate(0, -months, 0)
+
+	// Start with a month's pay in the bank so spending early in the first
+	// month never overdraws.
+	starting := persona.MonthlyIncome
+	g.income()
+	savings := g.months(apy)
+
+	sort.SliceStable(g.entries, func(i, j int) bool { return g.entries[i].at.Before(g.entries[j].at) })
+
+	balance := starting
+	for _, e := range g.entries {
+		balance += e.amount
+	}
+
+	d := &Dataset{
+		Persona:         persona.Name,
+		Seed:            opts.Seed,
+		StartingBalance: cents(starting),
+		Transactions:    make([]executor.Transaction, len(g.entries)),
+		Balance: executor.GetBalanceResponse{
+			Balances: []executor.WalletBalance{{Currency: currency, Amount: cents(balance), USDValue: cents(balance)}},
+			TotalUSD: cents(balance),
+		},
+		Savings: executor.GetSavingsBalanceResponse{
+			Positions: []executor.SavingsPosition{{
+				Currency:     currency,
+				Deposited:    cents(savings.deposited),
+				CurrentValue: cents(savings.value),
+				APY:          apyText,
+				Earnings:     cents(savings.value - savings.deposited),
+			}},
+			TotalUSD: cents(savings.value),
+		},
+		Vaults: executor.GetVaultRatesResponse{
+			Vaults: []executor.VaultRate{{Currency: currency, APY: apyText, TVL: "48213907.55"}},
+		},
+	}
+
+	// Number oldest first, so IDs read in order, but list newest first.
+	for i, e := range g.entries {
+		tx := executor.Transaction{
+			ID:           fmt.Sprintf("tx_%05d", i+1),
+			Type:         e.kind,
+			Amount:       cents(abs(e.amount)),
+			Currency:     currency,
+			USDValue:     cents(abs(e.amount)),
+			Counterparty: e.counterparty,
+			Note:         e.note,
+			Status:       "completed",
+			Direction:    "credit",
+			CreatedAt:    e.at.Format(time.RFC3339),
+			TxHash:       g.hash(),
+		}
+		if e.amount < 0 {
+			tx.Direction = "debit"
+		}
+		d.Transactions[len(g.entries)-1-i] = tx
+	}
+
+	if err := Reconcile(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Reconcile checks that the dataset's final wallet balance equals its
+// starting balance plus the signed sum of its transactions.
+func Reconcile(d *Dataset) error {
+	balance, err := parseCents(d.StartingBalance)
+	if err != nil {
+		return fmt.Errorf("datagen: starting balance: %w", err)
+	}
+	for _, tx := range d.Transactions {
+		amount, err := parseCents(tx.Amount)
+		if err != nil {
+			return fmt.Errorf("datagen: transaction %s: %w", tx.ID, err)
+		}
+		if tx.Direction == "debit" {
+			amount = -amount
+		}
+		balance += amount
+	}
+	if len(d.Balance.Balances) != 1 {
+		return fmt.Errorf("datagen: want one wallet balance, got %d", len(d.Balance.Balances))
+	}
+	final, err := parseCents(d.Balance.Balances[0].Amount)
+	if err != nil {
+		return fmt.Errorf("datagen: final balance: %w", err)
+	}
+	if final != balance {
+		return fmt.Errorf("datagen: balance %s does not reconcile with transactions, which sum to %s", cents(final), cents(balance))
+	}
+	return nil
+}
+
+type generator struct {
+	rng     *rand.Rand
+	persona Persona
+	start   time.Time
+	end     time.Time
+	entries []entry
+}
+
+// add records e if it falls within the dataset, and reports whether it did.
+func (g *generator) add(e entry) bool {
+	if e.at.Before(g.start) || !e.at.Before(g.end) {
+		return false
+	}
+	g.entries = append(g.entries, e)
+	return true
+}
+
+// firstMonth returns the first day of the dataset's first month. Monthly
+// events are generated for whole calendar months and clipped to the range.
+func (g *generator) firstMonth() time.Time {
+	return time.Date(g.start.Year(), g.start.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// income adds the persona's paychecks.
+func (g *generator) income() {
+	p := g.persona
+	credit := func(at time.Time, amount int64) {
+		g.add(entry{at: at, amount: amount, kind: "payroll", counterparty: p.Employer, note: "Salary - " + p.Employer})
+	}
+
+	if p.PayCadence == Biweekly {
+		pay := p.MonthlyIncome * 12 / 26
+		for at := g.day(g.start, 9); at.Before(g.end); at = at.AddDate(0, 0, 14) {
+			credit(at, pay)
+		}
+		return
+	}
+	for m := g.firstMonth(); m.Before(g.end); m = m.AddDate(0, 1, 0) {
+		credit(g.dayOfMonth(m, p.PayDay, 9), p.MonthlyIncome)
+	}
+}
+
+type savingsPosition struct {
+	deposited, value int64
+}
+
+// months adds each month's subscriptions, savings deposit and spending, and
+// returns the resulting savings position.
+func (g *generator) months(apy float64) savingsPosition {
+	p := g.persona
+	var s savingsPosition
+
+	for m := g.firstMonth(); m.Before(g.end); m = m.AddDate(0, 1, 0) {
+		// Interest accrues monthly on what was saved before this month.
+		s.value += int64(float64(s.value) * apy / 100 / 12)
+
+		budget := p.MonthlyIncome
+		for _, sub := range p.Subscriptions {
+			g.add(entry{at: g.dayOfMonth(m, sub.Day, 6), amount: -sub.Amount, kind: "payment", counterparty: sub.Counterparty, note: sub.Note})
+			budget -= sub.Amount
+		}
+
+		deposit := int64(float64(p.MonthlyIncome) * p.SavingsRate)
+		deposit -= deposit % 100 // whole currency units
+		saved := deposit > 0 && g.add(entry{at: g.dayOfMonth(m, 28, 18), amount: -deposit, kind: "savings_deposit", counterparty: "Liminal Savings", note: "Savings deposit"})
+		if saved {
+			s.deposited += deposit
+			s.value += deposit
+			budget -= deposit
+		}
+
+		// Spend 85-95% of what's left, so the balance grows slowly.
+		budget = budget * int64(85+g.rng.Intn(11)) / 100
+		g.spend(m, budget)
+	}
+	return s
+}
+
+// spend adds purchases in month m, split across categories by the persona's
+// spending mix.
+func (g *generator) spend(m time.Time, budget int64) {
+	categories := make([]string, 0, len(g.persona.SpendingMix))
+	for c := range g.persona.SpendingMix {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories) // map order is random; output must not be
+
+	for _, c := range categories {
+		remaining := int64(float64(budget) * g.persona.SpendingMix[c])
+		for {
+			var fits []template
+			for _, t := range templates[c] {
+				if t.min <= remaining {
+					fits = append(fits, t)
+				}
+			}
+			if len(fits) == 0 {
+				break
+			}
+			t := fits[g.rng.Intn(len(fits))]
+			high := t.max
+			if high > remaining {
+				high = remaining
+			}
+			amount := t.min + g.rng.Int63n(high-t.min+1)
+			remaining -= amount
+
+			at := time.Date(m.Year(), m.Month(), 1+g.rng.Intn(28), 7+g.rng.Intn(15), g.rng.Intn(60), 0, 0, time.UTC)
+			g.add(entry{at: at, amount: -amount, kind: "payment", counterparty: t.counterparty, note: t.notes[g.rng.Intn(len(t.notes))]})
+		}
+	}
+}
+
+// day returns hour o'clock on t's day.
+func (g *generator) day(t time.Time, hour int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), hour, 0, 0, 0, time.UTC)
+}
+
+// dayOfMonth returns hour o'clock on day of m's month.
+func (g *generator) dayOfMonth(m time.Time, day, hour int) time.Time {
+	return time.Date(m.Year(), m.Month(), day, hour, 0, 0, 0, time.UTC)
+}
+
+// hash returns a fake transaction hash.
+func (g *generator) hash() string {
+	b := make([]byte, 32)
+	g.rng.Read(b)
+	return fmt.Sprintf("0x%x", b)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// cents formats an amount in cents as a decimal string, e.g. 1234 as 12.34.
+func cents(n int64) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, n/100, n%100)
+}
+
+// parseCents parses a decimal string with at most two decimal places.
+func parseCents(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	negative := strings.HasPrefix(whole, "-")
+	n, err := strconv.ParseInt(strings.TrimPrefix(whole, "-")+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		n = -n
+	}
+	return n, nil
+}
@@ -0,0 +1,175 @@
+package datagen
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/analysis"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+)
+
+var end = time.Date(2026, 3, 17, 12, 0, 0, 0, time.UTC)
+
+func generate(t *testing.T, seed int64, p Persona) *Dataset {
+	t.Helper()
+	d, err := Generate(Options{Seed: seed, Persona: &p, End: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDeterministic(t *testing.T) {
+	for name, p := range Personas {
+		t.Run(name, func(t *testing.T) {
+			a, _ := generate(t, 42, p).FixtureJSON()
+			b, _ := generate(t, 42, p).FixtureJSON()
+			if !bytes.Equal(a, b) {
+				t.Error("same seed generated different data")
+			}
+			c, _ := generate(t, 43, p).FixtureJSON()
+			if bytes.Equal(a, c) {
+				t.Error("different seeds generated the same data")
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	for name, p := range Personas {
+		t.Run(name, func(t *testing.T) {
+			for seed := int64(1); seed <= 20; seed++ {
+				d := generate(t, seed, p)
+				if err := Reconcile(d); err != nil {
+					t.Fatalf("seed %d: %v", seed, err)
+				}
+			}
+		})
+	}
+
+	d := generate(t, 1, Student)
+	d.Transactions[3].Amount = "0.01"
+	if err := Reconcile(d); err == nil {
+		t.Error("Reconcile accepted a tampered transaction")
+	}
+}
+
+func TestDataset(t *testing.T) {
+	for name, p := range Personas {
+		t.Run(name, func(t *testing.T) {
+			d := generate(t, 7, p)
+
+			if len(d.Transactions) < 100 {
+				t.Errorf("got %d transactions, want months of activity", len(d.Transactions))
+			}
+			for i := 1; i < len(d.Transactions); i++ {
+				if d.Transactions[i-1].CreatedAt < d.Transactions[i].CreatedAt {
+					t.Fatalf("transactions not newest first at %d", i)
+				}
+			}
+			oldest := txn.CreatedAt(d.Transactions[len(d.Transactions)-1])
+			if oldest.Before(end.AddDate(0, -DefaultMonths, 0)) || !txn.CreatedAt(d.Transactions[0]).Before(end) {
+				t.Errorf("transactions span %s to %s, outside the dataset", oldest, d.Transactions[0].CreatedAt)
+			}
+
+			categories := map[string]bool{}
+			for _, tx := range d.Transactions {
+				if txn.IsDebit(tx) && tx.Type == "payment" {
+					categories[analysis.CategorizeNote(tx.Note)] = true
+				}
+			}
+			for c := range p.SpendingMix {
+				if !categories[c] {
+					t.Errorf("no %s spending", c)
+				}
+			}
+
+			// Earnings are positive but no more than simple interest on
+			// everything deposited for the whole period.
+			pos := d.Savings.Positions[0]
+			deposited, value := txn.Amount(pos.Deposited), txn.Amount(pos.CurrentValue)
+			maxEarnings := deposited * txn.Amount(pos.APY) / 100 * DefaultMonths / 12
+			if earnings := value - deposited; earnings <= 0 || earnings > maxEarnings {
+				t.Errorf("earnings %.2f on %.2f deposited, want (0, %.2f]", earnings, deposited, maxEarnings)
+			}
+		})
+	}
+}
+
+func TestFixture(t *testing.T) {
+	d := generate(t, 7, Professional)
+	exec := &txntest.Executor{Responses: d.Fixture()}
+
+	txs, err := txn.Fetch(context.Background(), exec, "user-1", "req-1", 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != len(d.Transactions) || txs[0].ID != d.Transactions[0].ID {
+		t.Errorf("fetched %d transactions, want %d", len(txs), len(d.Transactions))
+	}
+	balances, err := txn.Balances(context.Background(), exec, "user-1", "req-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances[DefaultCurrency] != txn.Amount(d.Balance.Balances[0].Amount) {
+		t.Errorf("balances = %v, want %s", balances, d.Balance.Balances[0].Amount)
+	}
+}
+
+// recurring finds payees charged the same amount at least three times,
+// roughly a month apart. Savings deposits are transfers, not payments.
+func recurring(txs []txn.Transaction) []string {
+	type key struct{ counterparty, amount string }
+	dates := map[key][]time.Time{}
+	for _, tx := range txs {
+		if txn.IsDebit(tx) && tx.Type == "payment" {
+			k := key{tx.Counterparty, tx.Amount}
+			dates[k] = append(dates[k], txn.CreatedAt(tx))
+		}
+	}
+
+	var found []string
+	for k, ds := range dates {
+		if len(ds) < 3 {
+			continue
+		}
+		sort.Slice(ds, func(i, j int) bool { return ds[i].Before(ds[j]) })
+		monthly := true
+		for i := 1; i < len(ds); i++ {
+			if gap := ds[i].Sub(ds[i-1]).Hours() / 24; gap < 28 || gap > 31 {
+				monthly = false
+			}
+		}
+		if monthly {
+			found = append(found, k.counterparty)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+func TestPlantedSubscriptions(t *testing.T) {
+	for name, p := range Personas {
+		t.Run(name, func(t *testing.T) {
+			var want []string
+			for _, sub := range p.Subscriptions {
+				want = append(want, sub.Counterparty)
+			}
+			sort.Strings(want)
+
+			got := recurring(generate(t, 7, p).Transactions)
+			if len(got) != len(want) {
+				t.Fatalf("found recurring payments %v, want %v", got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("found recurring payments %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package datagen
+
+import "github.com/becomeliminal/nim-go-sdk/contrib/analysis"
+
+// Pay cadences.
+const (
+	Monthly  = "monthly"  // paid on Persona.PayDay each month
+	Biweekly = "biweekly" // paid every 14 days from the start of the dataset
+)
+
+// Subscription is a fixed monthly payment.
+type Subscription struct {
+	Counterparty string
+	Note         string
+
+	// Amount is in cents.
+	Amount int64
+
+	// Day is the day of the month it is charged, 1-28.
+	Day int
+}
+
+// Persona describes whose financial life to generate.
+type Persona struct {
+	Name     string
+	Employer string
+
+	// MonthlyIncome is take-home pay in cents.
+	MonthlyIncome int64
+
+	// PayCadence is Monthly or Biweekly.
+	PayCadence string
+
+	// PayDay is the day of the month Monthly pay arrives, 1-28.
+	PayDay int
+
+	// SavingsRate is the share of income deposited to savings each month.
+	SavingsRate float64
+
+	// SpendingMix weights discretionary spending by analysis category.
+	SpendingMix map[string]float64
+
+	// Subscriptions are charged every month.
+	Subscriptions []Subscription
+}
+
+// Student lives on a part-time wage, mostly spent on food and going out.
+var Student = Persona{
+	Name:          "student",
+	Employer:      "Campus Bookstore",
+	MonthlyIncome: 1200_00,
+	PayCadence:    Biweekly,
+	SavingsRate:   0.05,
+	SpendingMix: map[string]float64{
+		analysis.Food:          0.45,
+		analysis.Entertainment: 0.20,
+		analysis.Travel:        0.10,
+		analysis.Electronics:   0.05,
+		analysis.Miscellaneous: 0.20,
+	},
+	Subscriptions: []Subscription{
+		{Counterparty: "Spotify", Note: "Spotify Premium Student", Amount: 5_99, Day: 3},
+		{Counterparty: "Apple", Note: "iCloud+ 50GB monthly", Amount: 99, Day: 12},
+	},
+}
+
+// Professional has a salary, saves steadily, and travels.
+var Professional = Persona{
+	Name:          "professional",
+	Employer:      "Northwind Labs",
+	MonthlyIncome: 5200_00,
+	PayCadence:    Monthly,
+	PayDay:        25,
+	SavingsRate:   0.15,
+	SpendingMix: map[string]float64{
+		analysis.Food:          0.35,
+		analysis.Travel:        0.25,
+		analysis.Entertainment: 0.15,
+		analysis.Electronics:   0.10,
+		analysis.Miscellaneous: 0.15,
+	},
+	Subscriptions: []Subscription{
+		{Counterparty: "Netflix", Note: "Netflix monthly", Amount: 15_49, Day: 7},
+		{Counterparty: "Spotify", Note: "Spotify Premium", Amount: 11_99, Day: 3},
+		{Counterparty: "PureGym", Note: "Gym membership", Amount: 29_99, Day: 1},
+	},
+}
+
+// Family has two incomes, spends mostly on groceries and household items,
+// and saves less.
+var Family = Persona{
+	Name:          "family",
+	Employer:      "Contoso Health",
+	MonthlyIncome: 7400_00,
+	PayCadence:    Biweekly,
+	SavingsRate:   0.08,
+	SpendingMix: map[string]float64{
+		analysis.Food:          0.50,
+		analysis.Travel:        0.15,
+		analysis.Entertainment: 0.10,
+		analysis.Electronics:   0.05,
+		analysis.Miscellaneous: 0.20,
+	},
+	Subscriptions: []Subscription{
+		{Counterparty: "Netflix", Note: "Netflix family plan monthly", Amount: 22_99, Day: 7},
+		{Counterparty: "Disney+", Note: "Disney+ subscription", Amount: 13_99, Day: 15},
+		{Counterparty: "Amazon", Note: "Amazon Prime membership", Amount: 14_99, Day: 20},
+	},
+}
+
+// Personas maps persona names to personas.
+var Personas = map[string]Persona{
+	Student.Name:      Student,
+	Professional.Name: Professional,
+	Family.Name:       Family,
+}
+
+// template is a kind of purchase: where, what for, and how much, in cents.
+type template struct {
+	counterparty string
+	notes        []string
+	min, max     int64
+}
+
+// templates are the purchases drawn for each spending category. Notes use
+// words analysis.CategorizeNote recognizes, so generated data categorizes
+// as intended.
+var templates = map[string][]template{
+	analysis.Food: {
+		{"Blue Bottle Coffee", []string{"Morning coffee", "Coffee with Sam", "Cafe latte"}, 3_50, 7_00},
+		{"Trader Joe's", []string{"Weekly groceries", "Grocery run"}, 25_00, 120_00},
+		{"Chipotle", []string{"Lunch", "Burrito lunch"}, 9_00, 16_00},
+		{"DoorDash", []string{"Dinner delivery", "Takeout dinner"}, 18_00, 45_00},
+		{"Olive Garden", []string{"Dinner out", "Family dinner at restaurant"}, 35_00, 90_00},
+	},
+	analysis.Travel: {
+		{"Uber", []string{"Uber to work", "Uber home"}, 8_00, 35_00},
+		{"Shell", []string{"Gas", "Gas fill-up"}, 30_00, 65_00},
+		{"BART", []string{"Train fare", "Train ticket"}, 2_50, 12_00},
+		{"Delta Air Lines", []string{"Flight to Denver", "Flight home"}, 180_00, 420_00},
+	},
+	analysis.Entertainment: {
+		{"AMC Theatres", []string{"Movie night", "Movie tickets"}, 12_00, 30_00},
+		{"Steam", []string{"New game", "Game purchase"}, 5_00, 60_00},
+		{"Ticketmaster", []string{"Concert tickets"}, 40_00, 150_00},
+	},
+	analysis.Electronics: {
+		{"Best Buy", []string{"Phone charger", "Headphones - electronics", "Laptop stand"}, 20_00, 300_00},
+		{"Apple Store", []string{"Phone case", "Laptop repair"}, 30_00, 200_00},
+	},
+	analysis.Miscellaneous: {
+		{"CVS Pharmacy", []string{"Pharmacy", "Toiletries"}, 6_00, 40_00},
+		{"Target", []string{"Household supplies", "Target run"}, 15_00, 90_00},
+		{"Home Depot", []string{"Hardware", "Paint and supplies"}, 12_00, 110_00},
+	},
+}
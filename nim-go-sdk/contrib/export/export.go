@@ -0,0 +1,319 @@
+// Package export writes the user's transaction history as files they can
+// download and open elsewhere: CSV for spreadsheets, OFX for accounting
+// software such as GnuCash.
+package export
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+)
+
+// Export formats.
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+)
+
+// Dir stores exports as files in Path, served to clients under BaseURL.
+// File names include a random token, since anyone with an export's URL can
+// download it.
+type Dir struct {
+	// Path is the directory exports are written to.
+	Path string
+
+	// BaseURL is the URL the directory is served at, e.g. http://localhost:8080/exports/.
+	BaseURL string
+}
+
+// Save writes data to a new file named after prefix with the extension ext,
+// e.g. ".csv", and returns its URL.
+func (d Dir) Save(prefix, ext string, data []byte) (string, error) {
+	if err := os.MkdirAll(d.Path, 0755); err != nil {
+		return "", fmt.Errorf("failed to create exports directory: %w", err)
+	}
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to name export: %w", err)
+	}
+	name := fmt.Sprintf("%s-%d-%s%s", prefix, time.Now().Unix(), hex.EncodeToString(token), ext)
+	if err := os.WriteFile(filepath.Join(d.Path, name), data, 0600); err != nil {
+		return "", fmt.Errorf("failed to save export: %w", err)
+	}
+	return strings.TrimSuffix(d.BaseURL, "/") + "/" + name, nil
+}
+
+// Handler serves the saved exports as downloads. Mount it at BaseURL's
+// path, e.g. http.Handle("/exports/", dir.Handler()).
+func (d Dir) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := filepath.Base(r.URL.Path)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+		w.Header().Set("Content-Type", mediaType(filepath.Ext(name)))
+		http.ServeFile(w, r, filepath.Join(d.Path, name))
+	})
+}
+
+// mediaType is the Content-Type of exports with the extension ext.
+func mediaType(ext string) string {
+	switch ext {
+	case "." + FormatCSV:
+		return "text/csv; charset=utf-8"
+	case "." + FormatOFX:
+		return "application/x-ofx"
+	}
+	return "application/octet-stream"
+}
+
+// CSVHeader is the header row of CSV exports, in column order.
+var CSVHeader = []string{"date", "id", "type", "direction", "amount", "currency", "usd_value", "counterparty", "note", "status", "tx_hash"}
+
+// WriteCSV writes txs to w as CSV, with a CSVHeader row first even if there
+// are none. Dates are RFC 3339 in UTC, and amounts are signed decimals,
+// negative for money leaving the account. It returns how many transactions
+// were skipped because their amount couldn't be parsed.
+func WriteCSV(w io.Writer, txs []txn.Transaction) (skipped int, err error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return 0, err
+	}
+	for _, tx := range txs {
+		amount, ok := SignedAmount(tx)
+		if !ok {
+			skipped++
+			continue
+		}
+		row := []string{
+			date(tx), tx.ID, tx.Type, tx.Direction, amount, tx.Currency,
+			tx.USDValue, tx.Counterparty, tx.Note, tx.Status, tx.TxHash,
+		}
+		if err := cw.Write(row); err != nil {
+			return skipped, err
+		}
+	}
+	cw.Flush()
+	return skipped, cw.Error()
+}
+
+// decimalPattern matches the plain decimal amounts exports contain.
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)$`)
+
+// SignedAmount returns tx's amount as a decimal string, negative if it is a
+// debit, as it was given rather than rounded. It reports false if the
+// amount isn't a plain decimal.
+func SignedAmount(tx txn.Transaction) (string, bool) {
+	s := strings.TrimSpace(tx.Amount)
+	if !decimalPattern.MatchString(s) {
+		return "", false
+	}
+	s = strings.TrimSuffix(strings.TrimLeft(s, "+-"), ".")
+	if strings.HasPrefix(s, ".") {
+		s = "0" + s
+	}
+	if tx.IsDebit() && strings.ContainsAny(s, "123456789") {
+		s = "-" + s
+	}
+	return s, true
+}
+
+// date formats when tx was created as RFC 3339 in UTC, or "" if unknown.
+func date(tx txn.Transaction) string {
+	at := txn.CreatedAt(tx)
+	if at.IsZero() {
+		return ""
+	}
+	return at.UTC().Format(time.RFC3339)
+}
+
+// Statement is what WriteOFX needs besides the transactions.
+type Statement struct {
+	// AccountID identifies the account to the importing software, so
+	// later exports are matched to the same account. Each currency gets
+	// its own account, with the currency appended.
+	AccountID string
+
+	// Start and End are the period the statement covers.
+	Start, End time.Time
+
+	// Balances are the wallet's balances by currency, reported as each
+	// account's ledger balance. Missing currencies are zero.
+	Balances map[string]float64
+
+	// Now is when the statement was generated. Defaults to time.Now().
+	Now time.Time
+}
+
+// ofxTimeLayout is OFX's datetime format, here always in UTC.
+const ofxTimeLayout = "20060102150405.000[0:GMT]"
+
+// WriteOFX writes txs to w as an OFX 2.1.1 bank statement, with one
+// statement per currency. Transactions are STMTTRN records with their ID
+// as FITID, so importing the same transactions twice doesn't duplicate
+// them. It returns how many transactions were skipped because their
+// amount couldn't be parsed.
+func WriteOFX(w io.Writer, txs []txn.Transaction, st Statement) (skipped int, err error) {
+	if st.Now.IsZero() {
+		st.Now = time.Now()
+	}
+	byCurrency := make(map[string][]txn.Transaction)
+	for _, tx := range txs {
+		byCurrency[strings.ToUpper(tx.Currency)] = append(byCurrency[strings.ToUpper(tx.Currency)], tx)
+	}
+	currencies := make([]string, 0, len(byCurrency))
+	for c := range byCurrency {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	if len(currencies) == 0 {
+		currencies = []string{"USD"}
+	}
+
+	o := &ofxWriter{}
+	o.raw(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>`)
+	o.raw(`<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`)
+	o.open("OFX")
+	o.open("SIGNONMSGSRSV1")
+	o.open("SONRS")
+	o.status()
+	o.elem("DTSERVER", ofxTime(st.Now))
+	o.elem("LANGUAGE", "ENG")
+	o.close("SONRS")
+	o.close("SIGNONMSGSRSV1")
+
+	o.open("BANKMSGSRSV1")
+	for _, currency := range currencies {
+		o.open("STMTTRNRS")
+		o.elem("TRNUID", "0")
+		o.status()
+		o.open("STMTRS")
+		o.elem("CURDEF", currency)
+		o.open("BANKACCTFROM")
+		o.elem("BANKID", "LIMINAL")
+		o.elem("ACCTID", st.AccountID+"-"+currency)
+		o.elem("ACCTTYPE", "CHECKING")
+		o.close("BANKACCTFROM")
+
+		o.open("BANKTRANLIST")
+		o.elem("DTSTART", ofxTime(st.Start))
+		o.elem("DTEND", ofxTime(st.End))
+		for i, tx := range byCurrency[currency] {
+			amount, ok := SignedAmount(tx)
+			if !ok {
+				skipped++
+				continue
+			}
+			posted := txn.CreatedAt(tx)
+			if posted.IsZero() {
+				posted = st.End
+			}
+			id := tx.ID
+			if id == "" {
+				id = fmt.Sprintf("%s-%d", currency, i)
+			}
+			trnType := "CREDIT"
+			if tx.IsDebit() {
+				trnType = "DEBIT"
+			}
+			o.open("STMTTRN")
+			o.elem("TRNTYPE", trnType)
+			o.elem("DTPOSTED", ofxTime(posted))
+			o.elem("TRNAMT", amount)
+			o.elem("FITID", truncate(id, 255))
+			if tx.Counterparty != "" {
+				o.elem("NAME", truncate(tx.Counterparty, 32))
+			}
+			if tx.Note != "" {
+				o.elem("MEMO", truncate(tx.Note, 255))
+			}
+			o.close("STMTTRN")
+		}
+		o.close("BANKTRANLIST")
+
+		o.open("LEDGERBAL")
+		o.elem("BALAMT", strconv.FormatFloat(st.Balances[currency], 'f', -1, 64))
+		o.elem("DTASOF", ofxTime(st.Now))
+		o.close("LEDGERBAL")
+		o.close("STMTRS")
+		o.close("STMTTRNRS")
+	}
+	o.close("BANKMSGSRSV1")
+	o.close("OFX")
+
+	_, err = io.WriteString(w, o.String())
+	return skipped, err
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format(ofxTimeLayout)
+}
+
+// truncate shortens s to at most n runes, OFX's limit for the element.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// ofxWriter builds an indented OFX document.
+type ofxWriter struct {
+	strings.Builder
+	depth int
+}
+
+func (o *ofxWriter) raw(line string) {
+	o.WriteString(strings.Repeat("  ", o.depth))
+	o.WriteString(line)
+	o.WriteByte('\n')
+}
+
+func (o *ofxWriter) open(tag string) {
+	o.raw("<" + tag + ">")
+	o.depth++
+}
+
+func (o *ofxWriter) close(tag string) {
+	o.depth--
+	o.raw("</" + tag + ">")
+}
+
+func (o *ofxWriter) elem(tag, value string) {
+	o.raw("<" + tag + ">" + escape(value) + "</" + tag + ">")
+}
+
+// status writes a successful STATUS aggregate.
+func (o *ofxWriter) status() {
+	o.open("STATUS")
+	o.elem("CODE", "0")
+	o.elem("SEVERITY", "INFO")
+	o.close("STATUS")
+}
+
+var ofxEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+// escape escapes s as XML character data, dropping control characters XML
+// can't carry.
+func escape(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+	return ofxEscaper.Replace(s)
+}
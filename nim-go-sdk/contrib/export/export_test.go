@@ -0,0 +1,288 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+var sample = []txn.Transaction{
+	{ID: "tx1", Type: "send", Direction: "debit", Amount: "12.5", Currency: "USD", Counterparty: "@bob", Note: `Dinner, drinks & "tips"`, Status: "completed", CreatedAt: "2026-03-02T18:30:00-05:00"},
+	{ID: "tx2", Type: "receive", Direction: "credit", Amount: "+.75", Currency: "USDC", Counterparty: "@alice", Note: "line one\nline two", Status: "completed", CreatedAt: "2026-03-03T09:00:00Z"},
+	{ID: "tx3", Type: "send", Direction: "debit", Amount: "n/a", Currency: "USD", CreatedAt: "2026-03-04T09:00:00Z"},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	skipped, err := WriteCSV(&buf, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 1 {
+		t.Errorf("skipped = %d, want 1", skipped)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("export isn't valid CSV: %v", err)
+	}
+	want := [][]string{
+		CSVHeader,
+		{"2026-03-02T23:30:00Z", "tx1", "send", "debit", "-12.5", "USD", "", "@bob", `Dinner, drinks & "tips"`, "completed", ""},
+		{"2026-03-03T09:00:00Z", "tx2", "receive", "credit", "0.75", "USDC", "", "@alice", "line one\nline two", "completed", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q\nwant %q", rows, want)
+	}
+
+	buf.Reset()
+	if _, err := WriteCSV(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != strings.Join(CSVHeader, ",")+"\n" {
+		t.Errorf("empty export = %q, want only the header", got)
+	}
+}
+
+func TestSignedAmount(t *testing.T) {
+	tests := []struct {
+		tx     txn.Transaction
+		want   string
+		wantOK bool
+	}{
+		{txn.Transaction{Amount: "10.00", Direction: "debit"}, "-10.00", true},
+		{txn.Transaction{Amount: "-10.00"}, "-10.00", true},
+		{txn.Transaction{Amount: "0.000001", Direction: "credit"}, "0.000001", true},
+		{txn.Transaction{Amount: "0", Direction: "debit"}, "0", true},
+		{txn.Transaction{Amount: "5.", Direction: "credit"}, "5", true},
+		{txn.Transaction{Amount: "1e3"}, "", false},
+		{txn.Transaction{Amount: "NaN"}, "", false},
+		{txn.Transaction{Amount: ""}, "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := SignedAmount(tt.tx); got != tt.want || ok != tt.wantOK {
+			t.Errorf("SignedAmount(%q) = %q, %v; want %q, %v", tt.tx.Amount, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// ofxStatement is what validateOFX reads from a statement.
+type ofxStatement struct {
+	Currency     string
+	Account      string
+	Transactions []ofxTransaction
+}
+
+type ofxTransaction struct {
+	Type, Posted, Amount, ID, Name, Memo string
+}
+
+// validateOFX checks that data is well-formed OFX 2 with the aggregates
+// importers need, and returns its statements.
+func validateOFX(t *testing.T, data []byte) []ofxStatement {
+	t.Helper()
+	if !bytes.Contains(data, []byte(`<?OFX OFXHEADER="200" VERSION="211"`)) {
+		t.Fatal("missing OFX header")
+	}
+	var doc struct {
+		XMLName xml.Name `xml:"OFX"`
+		Signon  struct {
+			Code string `xml:"SONRS>STATUS>CODE"`
+		} `xml:"SIGNONMSGSRSV1"`
+		Statements []struct {
+			TrnUID string `xml:"TRNUID"`
+			Code   string `xml:"STATUS>CODE"`
+			Stmt   struct {
+				Currency string `xml:"CURDEF"`
+				BankID   string `xml:"BANKACCTFROM>BANKID"`
+				Account  string `xml:"BANKACCTFROM>ACCTID"`
+				AcctType string `xml:"BANKACCTFROM>ACCTTYPE"`
+				Start    string `xml:"BANKTRANLIST>DTSTART"`
+				End      string `xml:"BANKTRANLIST>DTEND"`
+				Txs      []struct {
+					Type   string `xml:"TRNTYPE"`
+					Posted string `xml:"DTPOSTED"`
+					Amount string `xml:"TRNAMT"`
+					ID     string `xml:"FITID"`
+					Name   string `xml:"NAME"`
+					Memo   string `xml:"MEMO"`
+				} `xml:"BANKTRANLIST>STMTTRN"`
+				Balance string `xml:"LEDGERBAL>BALAMT"`
+				AsOf    string `xml:"LEDGERBAL>DTASOF"`
+			} `xml:"STMTRS"`
+		} `xml:"BANKMSGSRSV1>STMTTRNRS"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("OFX isn't well-formed: %v", err)
+	}
+	if doc.Signon.Code != "0" {
+		t.Errorf("signon status = %q, want 0", doc.Signon.Code)
+	}
+	var statements []ofxStatement
+	for _, rs := range doc.Statements {
+		st := rs.Stmt
+		for name, v := range map[string]string{
+			"TRNUID": rs.TrnUID, "CODE": rs.Code, "CURDEF": st.Currency, "BANKID": st.BankID, "ACCTID": st.Account,
+			"ACCTTYPE": st.AcctType, "DTSTART": st.Start, "DTEND": st.End, "BALAMT": st.Balance, "DTASOF": st.AsOf,
+		} {
+			if v == "" {
+				t.Errorf("statement is missing %s", name)
+			}
+		}
+		s := ofxStatement{Currency: st.Currency, Account: st.Account}
+		for _, tx := range st.Txs {
+			if tx.Type == "" || tx.Posted == "" || tx.Amount == "" || tx.ID == "" {
+				t.Errorf("STMTTRN %+v is missing a required element", tx)
+			}
+			s.Transactions = append(s.Transactions, ofxTransaction(tx))
+		}
+		statements = append(statements, s)
+	}
+	return statements
+}
+
+func TestWriteOFX(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	skipped, err := WriteOFX(&buf, sample, Statement{
+		AccountID: "user-1",
+		Start:     start,
+		End:       start.AddDate(0, 0, 7),
+		Balances:  map[string]float64{"USD": 100.25},
+		Now:       start.AddDate(0, 0, 7),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 1 {
+		t.Errorf("skipped = %d, want 1", skipped)
+	}
+
+	got := validateOFX(t, buf.Bytes())
+	want := []ofxStatement{
+		{Currency: "USD", Account: "user-1-USD", Transactions: []ofxTransaction{
+			{Type: "DEBIT", Posted: "20260302233000.000[0:GMT]", Amount: "-12.5", ID: "tx1", Name: "@bob", Memo: `Dinner, drinks & "tips"`},
+		}},
+		{Currency: "USDC", Account: "user-1-USDC", Transactions: []ofxTransaction{
+			{Type: "CREDIT", Posted: "20260303090000.000[0:GMT]", Amount: "0.75", ID: "tx2", Name: "@alice", Memo: "line one\nline two"},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %+v\nwant %+v", got, want)
+	}
+
+	buf.Reset()
+	if _, err := WriteOFX(&buf, nil, Statement{AccountID: "user-1", Start: start, End: start.AddDate(0, 0, 7)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := validateOFX(t, buf.Bytes()); len(got) != 1 || len(got[0].Transactions) != 0 {
+		t.Errorf("empty export = %+v, want one statement with no transactions", got)
+	}
+}
+
+func TestExportTool(t *testing.T) {
+	now := time.Now().UTC()
+	tx := func(id string) executor.Transaction {
+		return executor.Transaction{ID: id, Direction: "debit", Amount: "1", Currency: "USD", CreatedAt: now.Add(-time.Hour).Format(time.RFC3339)}
+	}
+	pages := map[string]interface{}{
+		"":   executor.GetTransactionsResponse{Transactions: []executor.Transaction{tx("a"), tx("b")}, NextCursor: "p2"},
+		"p2": executor.GetTransactionsResponse{Transactions: []executor.Transaction{tx("c")}},
+	}
+	tests := []struct {
+		name       string
+		input      string
+		maxRows    int
+		pages      map[string]interface{}
+		wantRows   int
+		wantStatus core.EnvelopeStatus
+		wantExt    string
+	}{
+		{name: "csv", input: `{"days":7}`, pages: pages, wantRows: 3, wantStatus: core.StatusOK, wantExt: ".csv"},
+		{name: "ofx", input: `{"format":"ofx"}`, pages: pages, wantRows: 3, wantStatus: core.StatusOK, wantExt: ".ofx"},
+		{name: "row cap", input: `{}`, maxRows: 2, pages: pages, wantRows: 2, wantStatus: core.StatusPartial, wantExt: ".csv"},
+		{name: "other currency", input: `{"currency":"EUR"}`, pages: pages, wantRows: 0, wantStatus: core.StatusEmpty, wantExt: ".csv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := Dir{Path: t.TempDir(), BaseURL: "http://localhost:8080/exports/"}
+			exec := &txntest.Executor{
+				Pages:     tt.pages,
+				Responses: map[string]interface{}{"get_balance": executor.GetBalanceResponse{Balances: []executor.WalletBalance{{Currency: "USD", Amount: "10"}}}},
+			}
+			tool := ExportTool(Deps{Exec: exec, Dir: dir, MaxRows: tt.maxRows})
+			result, err := tool.Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(tt.input)})
+			if err != nil || !result.Success {
+				t.Fatalf("Execute() = %+v, %v", result, err)
+			}
+			env, _ := core.EnvelopeOf(result.Data)
+			data := env.Data.(map[string]interface{})
+			if data["row_count"] != tt.wantRows || env.Status != tt.wantStatus {
+				t.Errorf("row_count = %v, status = %s; want %d, %s", data["row_count"], env.Status, tt.wantRows, tt.wantStatus)
+			}
+			if tt.wantStatus == core.StatusPartial && len(env.Warnings) == 0 {
+				t.Error("truncated export has no warning")
+			}
+
+			url := data["download_url"].(string)
+			if !strings.HasPrefix(url, dir.BaseURL+"transactions-") || filepath.Ext(url) != tt.wantExt {
+				t.Errorf("download_url = %s", url)
+			}
+			saved, err := os.ReadFile(filepath.Join(dir.Path, filepath.Base(url)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantExt == ".ofx" {
+				if got := validateOFX(t, saved); len(got) != 1 || len(got[0].Transactions) != tt.wantRows {
+					t.Errorf("OFX statements = %+v, want %d transactions", got, tt.wantRows)
+				}
+				return
+			}
+			rows, err := csv.NewReader(bytes.NewReader(saved)).ReadAll()
+			if err != nil || len(rows) != tt.wantRows+1 {
+				t.Errorf("CSV has %d rows (%v), want a header and %d", len(rows), err, tt.wantRows)
+			}
+		})
+	}
+
+	exec := &txntest.Executor{Pages: map[string]interface{}{
+		"":   executor.GetTransactionsResponse{Transactions: []executor.Transaction{tx("a")}, NextCursor: "p2"},
+		"p2": executor.GetTransactionsResponse{Transactions: []executor.Transaction{tx("b")}, NextCursor: "p2"},
+	}}
+	tool := ExportTool(Deps{Exec: exec, Dir: Dir{Path: t.TempDir()}})
+	result, _ := tool.Execute(context.Background(), &core.ToolParams{Input: json.RawMessage(`{}`)})
+	if result.Success || !strings.Contains(result.Error, "cursor") {
+		t.Errorf("cursor loop: Execute() = %+v, want an error", result)
+	}
+}
+
+func TestDirHandler(t *testing.T) {
+	dir := Dir{Path: t.TempDir(), BaseURL: "/exports/"}
+	url, err := dir.Save("transactions", ".csv", []byte("date\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	dir.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	body, _ := io.ReadAll(rec.Result().Body)
+	if string(body) != "date\n" || rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" ||
+		!strings.HasPrefix(rec.Header().Get("Content-Disposition"), "attachment") {
+		t.Errorf("served %q with headers %v", body, rec.Header())
+	}
+}
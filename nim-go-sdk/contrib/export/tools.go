@@ -0,0 +1,181 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// DefaultMaxRows caps the transactions in an export unless Deps.MaxRows
+// says otherwise.
+const DefaultMaxRows = 10000
+
+// defaultDays is how far back an export goes when no dates are given.
+const defaultDays = 90
+
+// Deps are what the export tools need.
+type Deps struct {
+	// Exec reads the user's transactions. Required.
+	Exec core.ToolExecutor
+
+	// Dir is where exports are saved and served from. Required.
+	Dir Dir
+
+	// MaxRows caps the transactions in an export; larger exports are cut
+	// off with a warning. Defaults to DefaultMaxRows.
+	MaxRows int
+}
+
+// Tools returns the export tools: export_transactions.
+func Tools(d Deps) []core.Tool {
+	return []core.Tool{ExportTool(d)}
+}
+
+// ExportTool returns the export_transactions tool, which saves the user's
+// transactions over a period as a CSV or OFX file and returns its download
+// URL and row count.
+func ExportTool(d Deps) core.Tool {
+	if d.MaxRows <= 0 {
+		d.MaxRows = DefaultMaxRows
+	}
+	return tools.New("export_transactions").
+		Description(fmt.Sprintf("Export the user's transaction history to a file they can download: CSV for spreadsheets, or OFX for accounting software such as GnuCash. Covers the last days days (default %d), or start_date to end_date. Returns a download_url to share as a markdown link, and the row_count.", defaultDays)).
+		Schema(tools.ObjectSchema(tools.DateRangeProperties(map[string]interface{}{
+			"days":     tools.IntegerProperty(fmt.Sprintf("Number of days to export, ending today (default: %d). Ignored if start_date is given", defaultDays)),
+			"format":   tools.StringEnumProperty("File format (default: csv)", FormatCSV, FormatOFX),
+			"currency": tools.StringProperty("Optional: only export transactions in this currency, e.g. USDC"),
+		}))).
+		NoCache().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				Days      int    `json:"days"`
+				StartDate string `json:"start_date"`
+				EndDate   string `json:"end_date"`
+				Format    string `json:"format"`
+				Currency  string `json:"currency"`
+			}
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+			}
+			format := strings.ToLower(input.Format)
+			if format == "" {
+				format = FormatCSV
+			}
+			if format != FormatCSV && format != FormatOFX {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("unknown format %q: use %s or %s", input.Format, FormatCSV, FormatOFX)}, nil
+			}
+			now := time.Now()
+			window, err := period(input.Days, input.StartDate, input.EndDate, now)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			params.ReportProgress("fetching transactions", 0)
+			txs, truncated, err := txn.FetchAll(ctx, d.Exec, params.UserID, params.RequestID, txn.Query{
+				Limit:    d.MaxRows,
+				Since:    window.Start,
+				Until:    window.End,
+				Currency: strings.TrimSpace(input.Currency),
+			})
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to fetch transactions: %v", err)}, nil
+			}
+
+			params.ReportProgress("writing export", 70)
+			var buf bytes.Buffer
+			var skipped int
+			if format == FormatOFX {
+				balances, err := txn.Balances(ctx, d.Exec, params.UserID, params.RequestID)
+				if err != nil {
+					return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to fetch balance: %v", err)}, nil
+				}
+				skipped, err = WriteOFX(&buf, txs, Statement{
+					AccountID: params.UserID,
+					Start:     window.Start,
+					End:       window.End,
+					Balances:  upperKeys(balances),
+					Now:       now,
+				})
+			} else {
+				skipped, err = WriteCSV(&buf, txs)
+			}
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to write export: %v", err)}, nil
+			}
+			url, err := d.Dir.Save("transactions", "."+format, buf.Bytes())
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			rows := len(txs) - skipped
+			file := path.Base(url)
+			data := map[string]interface{}{
+				"download_url": url,
+				"format":       format,
+				"row_count":    rows,
+				"start_date":   window.Start.Format(executor.DateLayout),
+				"end_date":     window.End.Add(-time.Nanosecond).Format(executor.DateLayout),
+			}
+			if skipped > 0 {
+				data["skipped_transactions"] = skipped
+			}
+			env := core.NewEnvelope(data).WithArtifacts(artifact.Ref{
+				ID:        strings.TrimSuffix(file, "."+format),
+				Kind:      artifact.KindExport,
+				Name:      file,
+				MediaType: mediaType("." + format),
+				Size:      int64(buf.Len()),
+				CreatedAt: now,
+				Location:  file,
+			})
+			if rows == 0 {
+				env.WithStatus(core.StatusEmpty)
+			}
+			if truncated {
+				env.WithWarning(fmt.Sprintf("The export stops at %d transactions, the most it can hold; export a shorter period to get the rest", d.MaxRows))
+			}
+			return env.Result(), nil
+		}).
+		Build()
+}
+
+// period is the window to export: start_date to end_date if start is
+// given, otherwise the last days days up to now.
+func period(days int, start, end string, now time.Time) (executor.DateRange, error) {
+	window, err := executor.ParseDateRange(start, end)
+	if err != nil {
+		return executor.DateRange{}, err
+	}
+	if window.End.IsZero() || window.End.After(now) {
+		window.End = now
+	}
+	if window.Start.IsZero() {
+		if days <= 0 {
+			days = defaultDays
+		}
+		window.Start = window.End.AddDate(0, 0, -days)
+	}
+	if !window.Start.Before(window.End) {
+		return executor.DateRange{}, fmt.Errorf("start_date must be before end_date and today")
+	}
+	return window, nil
+}
+
+// upperKeys returns amounts keyed by upper-case currency code.
+func upperKeys(amounts map[string]float64) map[string]float64 {
+	upper := make(map[string]float64, len(amounts))
+	for currency, amount := range amounts {
+		upper[strings.ToUpper(currency)] += amount
+	}
+	return upper
+}
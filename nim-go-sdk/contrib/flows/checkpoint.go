@@ -0,0 +1,133 @@
+package flows
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// DefaultStateTTL is how long a workflow waits for the user's reply before
+// their next message starts it afresh, unless set in Deps.StateTTL.
+const DefaultStateTTL = 30 * time.Minute
+
+// Checkpoint is a workflow's state saved between the user's messages, so a
+// workflow awaiting their reply can resume where it left off.
+type Checkpoint struct {
+	UserID         string `json:"user_id"`
+	ConversationID string `json:"conversation_id"`
+
+	// Node is the node the next message resumes at. Empty if the workflow
+	// finished.
+	Node string `json:"node,omitempty"`
+
+	Route   string                 `json:"route,omitempty"`
+	Handler string                 `json:"handler,omitempty"`
+	Result  map[string]interface{} `json:"result,omitempty"`
+	Values  map[string]interface{} `json:"values,omitempty"`
+
+	SavedAt   time.Time `json:"saved_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Checkpoint saves the state of a run in conversationID, to expire after ttl.
+// Messages aren't saved: each run starts without any.
+func (s *State) Checkpoint(conversationID string, now time.Time, ttl time.Duration) *Checkpoint {
+	return &Checkpoint{
+		UserID:         s.UserID,
+		ConversationID: conversationID,
+		Node:           s.CurrentNode,
+		Route:          s.Route,
+		Handler:        s.Handler,
+		Result:         s.Result,
+		Values:         s.Values,
+		SavedAt:        now,
+		ExpiresAt:      now.Add(ttl),
+	}
+}
+
+// Expired reports whether the checkpoint is too old to resume at now.
+func (c *Checkpoint) Expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt)
+}
+
+// State restores the checkpoint as the state for running the workflow on
+// the user's next message, input.
+func (c *Checkpoint) State(requestID, input string) *State {
+	s := NewState(c.UserID, requestID, input)
+	s.CurrentNode = c.Node
+	s.Route = c.Route
+	s.Handler = c.Handler
+	maps.Copy(s.Result, c.Result)
+	maps.Copy(s.Values, c.Values)
+	return s
+}
+
+// GraphStateStore saves workflow checkpoints by user and conversation.
+// Stores that encode checkpoints, e.g. as JSON, return numbers in Values as
+// float64, so nodes resumed from them should read values with State.Float.
+// This is an interface - implementations (e.g., database-backed) are provided
+// by the consuming application.
+type GraphStateStore interface {
+	// Load returns the conversation's checkpoint.
+	// Returns nil, nil if there is none or it has expired.
+	Load(ctx context.Context, userID, conversationID string) (*Checkpoint, error)
+
+	// Save creates or replaces the checkpoint's conversation's checkpoint.
+	Save(ctx context.Context, checkpoint *Checkpoint) error
+}
+
+// MemoryGraphStates is an in-memory implementation of GraphStateStore.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryGraphStates struct {
+	mu          sync.Mutex
+	checkpoints map[graphStateKey]*Checkpoint
+	now         func() time.Time
+}
+
+type graphStateKey struct {
+	userID, conversationID string
+}
+
+// NewMemoryGraphStates creates an in-memory checkpoint store.
+func NewMemoryGraphStates() *MemoryGraphStates {
+	return &MemoryGraphStates{
+		checkpoints: make(map[graphStateKey]*Checkpoint),
+		now:         time.Now,
+	}
+}
+
+func (m *MemoryGraphStates) Load(ctx context.Context, userID, conversationID string) (*Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := graphStateKey{userID, conversationID}
+	c, ok := m.checkpoints[key]
+	if !ok {
+		return nil, nil
+	}
+	if c.Expired(m.now()) {
+		delete(m.checkpoints, key)
+		return nil, nil
+	}
+	return c.clone(), nil
+}
+
+func (m *MemoryGraphStates) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints[graphStateKey{checkpoint.UserID, checkpoint.ConversationID}] = checkpoint.clone()
+	return nil
+}
+
+// clone copies c, so a stored checkpoint isn't changed by the runs that
+// resume from it.
+func (c *Checkpoint) clone() *Checkpoint {
+	copied := *c
+	copied.Result = maps.Clone(c.Result)
+	copied.Values = maps.Clone(c.Values)
+	return &copied
+}
+
+// Verify MemoryGraphStates implements GraphStateStore.
+var _ GraphStateStore = (*MemoryGraphStates)(nil)
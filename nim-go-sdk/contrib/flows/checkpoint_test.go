@@ -0,0 +1,157 @@
+package flows
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/budget"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+func TestGraphRunResume(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("ask", func(ctx context.Context, s *State) error {
+		s.Say("ask")
+		s.Await("answer")
+		return nil
+	})
+	g.AddNode("answer", func(ctx context.Context, s *State) error {
+		s.Say("answer " + s.Input + " resumed at " + s.ResumedAt())
+		return nil
+	})
+	g.AddNode("done", step("done"))
+	g.AddEdge("ask", "done")
+	g.AddEdge("answer", "done")
+	g.SetStart("ask")
+
+	s := NewState("user-1", "req-1", "hello")
+	if err := g.Run(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(s.Messages, ", ") != "ask, done" || s.CurrentNode != "answer" {
+		t.Fatalf("first run = %v awaiting %q, want ask, done awaiting answer", s.Messages, s.CurrentNode)
+	}
+
+	s = s.Checkpoint("conv-1", time.Now(), time.Minute).State("req-2", "yes")
+	if err := g.Run(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(s.Messages, ", ") != "answer yes resumed at answer, done" || s.CurrentNode != "" {
+		t.Errorf("resumed run = %v awaiting %q, want answer, done", s.Messages, s.CurrentNode)
+	}
+}
+
+// routeConversation sends messages to route_request in one conversation,
+// returning the last result's data.
+func routeConversation(t *testing.T, tool core.Tool, messages ...string) map[string]interface{} {
+	t.Helper()
+	var data map[string]interface{}
+	for i, msg := range messages {
+		input, _ := json.Marshal(map[string]string{"user_message": msg})
+		result, err := tool.Execute(context.Background(), &core.ToolParams{
+			UserID:    "user-1",
+			RequestID: "req-1",
+			Input:     input,
+			Context:   core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+		})
+		if err != nil || !result.Success {
+			t.Fatalf("message %d: route_request failed: %v %+v", i+1, err, result)
+		}
+		data = result.Data.(*core.Envelope).Data.(map[string]interface{})
+	}
+	return data
+}
+
+func TestRouteToolResumesSaveFlow(t *testing.T) {
+	balances := []executor.WalletBalance{{Currency: "USDC", Amount: "100"}, {Currency: "LIL", Amount: "10"}}
+	ask, chunks, weekly := "help me save", "chunks please", "weekly"
+
+	t.Run("three messages", func(t *testing.T) {
+		states := NewMemoryGraphStates()
+		d := testDeps(t, RouteFinancialHelp, balances...)
+		d.States = states
+		tool := RouteTool(d)
+		// Each call advances the workflow, so a repeated reply must not be
+		// answered from the tool cache.
+		if u, ok := tool.(core.UncachedTool); !ok || !u.NoCache() {
+			t.Fatal("route_request can be cached")
+		}
+
+		data := routeConversation(t, tool, ask)
+		if data["handler_type"] != nodeSave {
+			t.Fatalf("after asking, handler = %v, want %s", data["handler_type"], nodeSave)
+		}
+		checkpoint, _ := states.Load(context.Background(), "user-1", "conv-1")
+		if checkpoint == nil || checkpoint.Node != nodeStrategy || checkpoint.Values["wants_calendar_reminders"] != false {
+			t.Fatalf("after asking, checkpoint = %+v, want awaiting %s without reminders", checkpoint, nodeStrategy)
+		}
+
+		data = routeConversation(t, tool, chunks)
+		if data["handler_type"] != nodeReminder || !strings.Contains(data["generated_response"].(string), "which frequency") {
+			t.Fatalf("after choosing chunks, handler = %v, response:\n%v", data["handler_type"], data["generated_response"])
+		}
+
+		data = routeConversation(t, tool, weekly)
+		if data["handler_type"] != nodeReminder {
+			t.Fatalf("after picking weekly, handler = %v, want %s", data["handler_type"], nodeReminder)
+		}
+		for _, want := range []string{"frequency='weekly'", "amount=24.50", "currency='USDC'", "duration=4", "Weekly reminders: deposit 24.50 USDC each time"} {
+			if !strings.Contains(data["guidance"].(string), want) {
+				t.Errorf("guidance doesn't contain %q:\n%s", want, data["guidance"])
+			}
+		}
+		checkpoint, _ = states.Load(context.Background(), "user-1", "conv-1")
+		if checkpoint.Node != "" || checkpoint.Values["reminder_frequency"] != budget.Weekly || checkpoint.Values["wants_calendar_reminders"] != true {
+			t.Errorf("final checkpoint = %+v, want finished with weekly reminders", checkpoint)
+		}
+
+		// The flow is over, so the next message starts afresh.
+		data = routeConversation(t, tool, weekly)
+		if !strings.Contains(data["generated_response"].(string), "which frequency") {
+			t.Errorf("a message after the flow didn't start it again:\n%v", data["generated_response"])
+		}
+	})
+
+	t.Run("lump sum", func(t *testing.T) {
+		d := testDeps(t, RouteFinancialHelp, balances...)
+		d.States = NewMemoryGraphStates()
+		data := routeConversation(t, RouteTool(d), ask, "1")
+		if data["handler_type"] != nodeStrategy || !strings.Contains(data["guidance"].(string), "deposit 98.00 USDC") {
+			t.Errorf("lump sum = %v:\n%s", data["handler_type"], data["guidance"])
+		}
+	})
+
+	t.Run("new request mid-flow", func(t *testing.T) {
+		d := testDeps(t, RouteFinancialHelp, balances...)
+		d.States = NewMemoryGraphStates()
+		data := routeConversation(t, RouteTool(d), ask, chunks, "how am I doing?")
+		if data["handler_type"] != nodeSave {
+			t.Errorf("handler = %v, want a new run through %s", data["handler_type"], nodeSave)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		states := NewMemoryGraphStates()
+		d := testDeps(t, RouteFinancialHelp, balances...)
+		d.States = states
+		tool := RouteTool(d)
+		routeConversation(t, tool, ask, chunks)
+
+		states.now = func() time.Time { return time.Now().Add(DefaultStateTTL) }
+		data := routeConversation(t, tool, weekly)
+		if !strings.Contains(data["generated_response"].(string), "which frequency") {
+			t.Errorf("an expired flow resumed:\n%v", data["guidance"])
+		}
+	})
+
+	t.Run("without a store", func(t *testing.T) {
+		data := routeConversation(t, RouteTool(testDeps(t, RouteFinancialHelp, balances...)), ask, chunks, weekly)
+		if !strings.Contains(data["generated_response"].(string), "which frequency") {
+			t.Errorf("a flow resumed without a store:\n%v", data["guidance"])
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package flows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/llm"
+)
+
+// DefaultMinConfidence is how sure a classifier must be of a route before
+// the financial agent takes it over the keyword matcher.
+const DefaultMinConfidence = 0.6
+
+// Classifier picks the route for a user's request, with its confidence in
+// the choice between 0 and 1.
+type Classifier interface {
+	Classify(ctx context.Context, input string) (route string, confidence float64, err error)
+}
+
+// ClassifierFunc adapts a function to the Classifier interface.
+type ClassifierFunc func(ctx context.Context, input string) (string, float64, error)
+
+func (f ClassifierFunc) Classify(ctx context.Context, input string) (string, float64, error) {
+	return f(ctx, input)
+}
+
+// Classify picks the route for a user's request with c. Requests c can't
+// classify, routes it doesn't know and routes it is less than minConfidence
+// sure of are routed by KeywordClassifier instead, as is everything when c
+// is nil.
+func Classify(ctx context.Context, c Classifier, minConfidence float64, input string) string {
+	if c != nil {
+		route, confidence, err := c.Classify(ctx, input)
+		switch {
+		case err != nil:
+			log.Printf("Failed to classify request: %v", err)
+		case !isRoute(route):
+			log.Printf("Classifier chose unknown route %q", route)
+		case confidence >= minConfidence:
+			return route
+		}
+	}
+	route, _, _ := KeywordClassifier{}.Classify(ctx, input)
+	return route
+}
+
+func isRoute(route string) bool {
+	for _, r := range Routes {
+		if route == r {
+			return true
+		}
+	}
+	return false
+}
+
+// keywordTiers are the keywords of each route, most specific tier first.
+// The first tier with a keyword in the request decides the route; within a
+// tier the keyword mentioned first wins, so "withdraw cash from a deposit"
+// is a withdrawal.
+var keywordTiers = [][]struct {
+	route string
+	words []string
+}{
+	{
+		{RouteAPYStability, []string{"stable", "stability", "volatil", "reliab", "consistent", "fluctuat", "trust this rate", "trust the rate"}},
+		{RouteImagePayment, []string{"receipt", "split", "image", "photo", "picture"}},
+	},
+	{
+		{RouteWithdraw, []string{"withdraw", "withdrew", "take out", "take money out", "pull out", "pull from", "cash out", "from savings", "from my savings", "out of savings", "out of my savings"}},
+		{RouteDeposit, []string{"deposit", "put money in", "into savings", "into my savings", "into the vault", "into a vault"}},
+	},
+	{
+		{RouteFinancialHelp, []string{"broke", "budget", "advice", "afford", "spending", "overspend", "stats", "help me save", "save money", "saving", "invest"}},
+	},
+}
+
+// KeywordClassifier routes requests by the words in them. It needs no
+// model, so it is the fallback when a model is unavailable or unsure.
+// Confidence is 1 when a single route's keywords matched, 0.5 when several
+// routes' did and 0 when nothing matched and the request went to
+// RouteGeneral.
+type KeywordClassifier struct{}
+
+func (KeywordClassifier) Classify(ctx context.Context, input string) (string, float64, error) {
+	input = strings.ToLower(input)
+	for _, tier := range keywordTiers {
+		route, first, matched := "", -1, 0
+		for _, r := range tier {
+			at := firstIndex(input, r.words)
+			if at < 0 {
+				continue
+			}
+			matched++
+			if first < 0 || at < first {
+				route, first = r.route, at
+			}
+		}
+		switch matched {
+		case 0:
+			continue
+		case 1:
+			return route, 1, nil
+		default:
+			return route, 0.5, nil
+		}
+	}
+	return RouteGeneral, 0, nil
+}
+
+// firstIndex returns where the first of words appears in s, or -1.
+func firstIndex(s string, words []string) int {
+	first := -1
+	for _, w := range words {
+		if i := strings.Index(s, w); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	return first
+}
+
+// routePrompt asks the model to pick a route for the user's request.
+const routePrompt = `You are an intelligent request router for a financial AI assistant. Your job is to analyze user requests and determine which specialized handler should process them.
+
+Available Routes:
+1. "apy_stability" - For queries about vault rate stability, APY reliability, volatility, consistency
+   Examples: "How stable is the vault rate?", "Is the APY reliable?", "Should I trust this rate?"
+
+2. "deposit" - For depositing/saving money into savings vaults
+   Examples: "Deposit 100 USD", "Put money in savings", "Save 50 EUR"
+
+3. "withdraw" - For withdrawing money from savings
+   Examples: "Withdraw 100 USD", "Take out money", "Pull from savings"
+
+4. "image_payment" - For receipt splitting and image-based payments
+   Examples: "Split this receipt", "Process this image", "Share payment from receipt"
+
+5. "financial_help" - For financial advice, budgeting help, spending analysis
+   Examples: "I'm broke", "Help me save", "Show my stats", "Budget advice"
+
+6. "general_inquiry" - For all other banking queries (balance checks, transactions, transfers)
+   Examples: "What's my balance?", "Send money to Alice", "Show transactions"
+
+Analyze this user request and respond with ONLY a JSON object of the form
+{"route": "<one of the route names above>", "confidence": <0.0 to 1.0>}
+where confidence is how sure you are of the route.
+
+User Request: `
+
+// ModelClassifier routes requests with a language model, which handles
+// phrasings keywords miss.
+type ModelClassifier struct {
+	model llm.Completer
+}
+
+// NewModelClassifier creates a classifier that asks model for the route.
+func NewModelClassifier(model llm.Completer) *ModelClassifier {
+	return &ModelClassifier{model: model}
+}
+
+// Classify asks the model for a route. Replies that aren't a known route
+// with a confidence between 0 and 1 are errors.
+func (m *ModelClassifier) Classify(ctx context.Context, input string) (string, float64, error) {
+	text, err := m.model.Complete(ctx, routePrompt+input, 100)
+	if err != nil {
+		return "", 0, err
+	}
+	return parseRoute(text)
+}
+
+// parseRoute reads the model's JSON reply, ignoring any text around it.
+func parseRoute(text string) (string, float64, error) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return "", 0, fmt.Errorf("classifier reply is not JSON: %q", text)
+	}
+	var reply struct {
+		Route      string   `json:"route"`
+		Confidence *float64 `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &reply); err != nil {
+		return "", 0, fmt.Errorf("failed to parse classifier reply: %w", err)
+	}
+	route := strings.ToLower(strings.TrimSpace(reply.Route))
+	if !isRoute(route) {
+		return "", 0, fmt.Errorf("classifier chose unknown route %q", reply.Route)
+	}
+	if reply.Confidence == nil || *reply.Confidence < 0 || *reply.Confidence > 1 {
+		return "", 0, fmt.Errorf("classifier confidence must be between 0 and 1")
+	}
+	return route, *reply.Confidence, nil
+}
+
+// Verify the classifiers implement Classifier.
+var (
+	_ Classifier = KeywordClassifier{}
+	_ Classifier = (*ModelClassifier)(nil)
+	_ Classifier = ClassifierFunc(nil)
+)
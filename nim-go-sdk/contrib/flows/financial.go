@@ -0,0 +1,282 @@
+package flows
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/analysis"
+	"github.com/becomeliminal/nim-go-sdk/contrib/budget"
+	"github.com/becomeliminal/nim-go-sdk/contrib/charts"
+	"github.com/becomeliminal/nim-go-sdk/contrib/receipt"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Routes the financial agent's orchestrator chooses between.
+const (
+	RouteAPYStability  = "apy_stability"
+	RouteDeposit       = "deposit"
+	RouteWithdraw      = "withdraw"
+	RouteImagePayment  = "image_payment"
+	RouteFinancialHelp = "financial_help"
+	RouteGeneral       = "general_inquiry"
+)
+
+// Routes lists every route.
+var Routes = []string{RouteAPYStability, RouteDeposit, RouteWithdraw, RouteImagePayment, RouteFinancialHelp, RouteGeneral}
+
+// Nodes of the financial agent beyond the routes.
+const (
+	nodeOrchestrator = "orchestrator"
+	nodeSave         = "financial_save"
+	nodeStrategy     = "savings_strategy"
+	nodeReminder     = "investment_reminder"
+	nodeLowFunds     = "financial_help_low_funds"
+	nodeNoFunds      = "financial_help_no_funds"
+	nodeSpending     = "spending_analysis"
+	nodeBudget       = "budget_recommendations"
+	nodeRespond      = "respond"
+)
+
+// Deps are what the financial agent workflow reads from and writes to.
+type Deps struct {
+	// Exec reads the user's account data.
+	Exec core.ToolExecutor
+
+	// Classifier routes requests. Nil routes by keywords alone.
+	Classifier Classifier
+
+	// MinConfidence is how sure Classifier must be of a route; less
+	// certain requests are routed by keywords instead. Defaults to
+	// DefaultMinConfidence.
+	MinConfidence float64
+
+	// Charts stores the charts the workflow draws.
+	Charts charts.Dir
+
+	// APY is the savings vault APY history scored by RouteAPYStability.
+	APY *analysis.APYHistory
+
+	// APYCurrency is the vault currency APY tracks. Defaults to USDC.
+	APYCurrency string
+
+	// Health classifies the user's balance before any advice is given.
+	// Defaults to analysis.DefaultHealthThresholds.
+	Health analysis.HealthThresholds
+
+	// Receipts reads the receipt the user uploaded for RouteImagePayment.
+	// Nil, or no Images, leaves reading it to the agent.
+	Receipts receipt.Scanner
+
+	// Images finds the user's uploaded receipt image.
+	Images receipt.Images
+
+	// NodeTimeout bounds each step of the workflow. Zero means no limit.
+	NodeTimeout time.Duration
+
+	// States saves the workflow between messages, so the user's reply to
+	// a question it asked, such as how often to save, resumes it. Nil
+	// starts every message afresh.
+	States GraphStateStore
+
+	// StateTTL is how long a saved workflow waits for the user's reply.
+	// Defaults to DefaultStateTTL.
+	StateTTL time.Duration
+}
+
+// FinancialAgent builds the financial agent workflow. The orchestrator
+// classifies the request, one handler per route gathers the user's data and
+// prepares recommendations, and respond writes the final message.
+// Financial help branches on the user's balance: users with spare funds get
+// savings advice, optionally followed by a reminder plan, users with an
+// empty or overdrawn wallet get funding guidance, and the rest get a
+// spending analysis followed by a budget. Savings advice asks the user to
+// choose between a lump sum and chunks, and reminders how often to save;
+// the workflow awaits their answer at savings_strategy and
+// investment_reminder, and an answer that isn't one starts it again at the
+// orchestrator.
+func FinancialAgent(d Deps) *Graph {
+	if d.APY == nil {
+		d.APY = analysis.NewAPYHistory()
+	}
+	if d.APYCurrency == "" {
+		d.APYCurrency = "USDC"
+	}
+	if d.MinConfidence == 0 {
+		d.MinConfidence = DefaultMinConfidence
+	}
+	if d.Health == (analysis.HealthThresholds{}) {
+		d.Health = analysis.DefaultHealthThresholds
+	}
+	h := &handlers{Deps: d}
+
+	g := NewGraph()
+	g.AddNode(nodeOrchestrator, func(ctx context.Context, s *State) error {
+		s.Route = Classify(ctx, d.Classifier, d.MinConfidence, s.Input)
+		return nil
+	})
+	g.AddNode(RouteGeneral, h.general)
+	g.AddNode(RouteImagePayment, h.imagePayment)
+	g.AddNode(RouteFinancialHelp, h.financialHelp)
+	g.AddNode(nodeSave, h.save)
+	g.AddNode(nodeStrategy, h.strategy)
+	g.AddNode(nodeReminder, h.reminder)
+	g.AddNode(nodeLowFunds, h.lowFunds)
+	g.AddNode(nodeNoFunds, h.noFunds)
+	g.AddNode(nodeSpending, h.spending)
+	g.AddNode(nodeBudget, h.budget)
+	g.AddNode(RouteWithdraw, h.withdraw)
+	g.AddNode(RouteDeposit, h.deposit)
+	g.AddNode(RouteAPYStability, h.apyStability)
+	g.AddNode(nodeRespond, respond)
+
+	for _, r := range Routes {
+		g.AddEdge(nodeOrchestrator, r)
+	}
+	g.AddRouter(nodeOrchestrator, func(s *State) string { return s.Route })
+
+	g.AddEdge(RouteFinancialHelp, nodeSave)
+	g.AddEdge(RouteFinancialHelp, nodeLowFunds)
+	g.AddEdge(RouteFinancialHelp, nodeNoFunds)
+	g.AddRouter(RouteFinancialHelp, func(s *State) string {
+		switch s.Handler {
+		case nodeSave, nodeNoFunds:
+			return s.Handler
+		}
+		return nodeLowFunds
+	})
+
+	g.AddEdge(nodeSave, nodeReminder)
+	g.AddEdge(nodeSave, nodeRespond)
+	g.AddRouter(nodeSave, func(s *State) string {
+		if wants, _ := s.Values["wants_calendar_reminders"].(bool); wants {
+			return nodeReminder
+		}
+		return nodeRespond
+	})
+
+	g.AddEdge(nodeStrategy, nodeReminder)
+	g.AddEdge(nodeStrategy, nodeRespond)
+	g.AddEdge(nodeStrategy, nodeOrchestrator)
+	g.AddRouter(nodeStrategy, func(s *State) string {
+		switch {
+		case s.Handler == "":
+			return nodeOrchestrator
+		case s.Values["wants_calendar_reminders"] == true:
+			return nodeReminder
+		}
+		return nodeRespond
+	})
+
+	g.AddEdge(nodeReminder, nodeRespond)
+	g.AddEdge(nodeReminder, nodeOrchestrator)
+	g.AddRouter(nodeReminder, func(s *State) string {
+		if s.Handler == "" {
+			return nodeOrchestrator
+		}
+		return nodeRespond
+	})
+
+	g.AddEdge(nodeLowFunds, nodeSpending)
+	g.AddEdge(nodeSpending, nodeBudget)
+	for _, n := range []string{RouteGeneral, RouteImagePayment, nodeBudget, nodeNoFunds, RouteWithdraw, RouteDeposit, RouteAPYStability} {
+		g.AddEdge(n, nodeRespond)
+	}
+
+	if d.NodeTimeout > 0 {
+		for name := range g.nodes {
+			g.SetTimeout(name, d.NodeTimeout)
+		}
+	}
+	g.SetStart(nodeOrchestrator)
+	return g
+}
+
+// wantsReminders reports whether the user asked to save in chunks over time.
+func wantsReminders(input string) bool {
+	input = strings.ToLower(input)
+	for _, w := range []string{"chunk", "period", "week", "month", "spread", "reminder", "dca"} {
+		if strings.Contains(input, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsLumpSum reports whether the user chose to save all at once.
+func wantsLumpSum(input string) bool {
+	input = strings.ToLower(input)
+	if strings.TrimSpace(input) == "1" {
+		return true
+	}
+	for _, w := range []string{"lump", "all at once", "all now", "everything"} {
+		if strings.Contains(input, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// frequency returns the reminder frequency the user chose, or "".
+func frequency(input string) string {
+	input = strings.ToLower(input)
+	switch {
+	case strings.Contains(input, "bi-weekly"), strings.Contains(input, "biweekly"),
+		strings.Contains(input, "fortnight"), strings.Contains(input, "2 weeks"):
+		return budget.BiWeekly
+	case strings.Contains(input, "week"):
+		return budget.Weekly
+	case strings.Contains(input, "month"):
+		return budget.Monthly
+	}
+	return ""
+}
+
+// restart clears what the workflow found, so it can start again at the
+// orchestrator on a message that doesn't answer the question it asked.
+func restart(s *State) {
+	s.Route = ""
+	s.Handler = ""
+	s.Result = make(map[string]interface{})
+	s.Values = make(map[string]interface{})
+}
+
+// wantsChart reports whether a general request asks for a balance chart.
+func wantsChart(input string) bool {
+	input = strings.ToLower(input)
+	for _, w := range []string{"chart", "graph", "visualize", "plot", "trend"} {
+		if strings.Contains(input, w) {
+			return true
+		}
+	}
+	return strings.Contains(input, "balance") && (strings.Contains(input, "show") || strings.Contains(input, "see"))
+}
+
+// respond writes the final response from the handler's recommendations.
+func respond(ctx context.Context, s *State) error {
+	var response strings.Builder
+	switch s.Handler {
+	case nodeSave:
+		response.WriteString("Great news! You have sufficient funds for saving and investing. Here are my recommendations:\n")
+	case RouteGeneral:
+		response.WriteString("I can help you with that. Let me check your account...")
+	case RouteImagePayment:
+		if s.Result["status"] == "receipt_read" {
+			response.WriteString("I've read your receipt. Who are you splitting it with?\n")
+		} else {
+			response.WriteString("I can help you split this bill! Upload a receipt image and I'll extract the total amount, line items, and help you split it with friends.")
+		}
+	}
+	if recs, ok := s.Result["recommendations"].([]string); ok {
+		for _, rec := range recs {
+			response.WriteString(rec)
+			response.WriteString("\n")
+		}
+	}
+	if follow, ok := s.Values["follow_up"].(string); ok {
+		response.WriteString("\n")
+		response.WriteString(follow)
+	}
+	s.Respond(strings.TrimRight(response.String(), "\n"))
+	return nil
+}
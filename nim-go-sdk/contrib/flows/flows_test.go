@@ -0,0 +1,594 @@
+package flows
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/analysis"
+	"github.com/becomeliminal/nim-go-sdk/contrib/charts"
+	"github.com/becomeliminal/nim-go-sdk/contrib/llm"
+	"github.com/becomeliminal/nim-go-sdk/contrib/receipt"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+func step(name string) NodeFunc {
+	return func(ctx context.Context, s *State) error {
+		s.Say(name)
+		return nil
+	}
+}
+
+func TestGraphRun(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", step("a"))
+	g.AddNode("b", step("b"))
+	g.AddNode("c", step("c"))
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "c")
+	g.AddRouter("a", func(s *State) string { return s.Input })
+	g.SetStart("a")
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"b", "a b", false},
+		{"c", "a c", false},
+		{"d", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			s := NewState("user-1", "req-1", tt.input)
+			err := g.Run(context.Background(), s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && strings.Join(s.Messages, " ") != tt.want {
+				t.Errorf("ran %v, want %s", s.Messages, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphRunOnMessage(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", step("a"))
+	g.AddNode("b", func(ctx context.Context, s *State) error {
+		s.Messages = append(s.Messages, "b")
+		return nil
+	})
+	g.AddNode("c", func(ctx context.Context, s *State) error {
+		s.Respond("done")
+		return nil
+	})
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.SetStart("a")
+
+	var sent []string
+	s := NewState("user-1", "req-1", "")
+	s.OnMessage = func(text string) { sent = append(sent, text) }
+	if err := g.Run(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(sent, " "); got != "a b" {
+		t.Errorf("sent %q, want the updates but not the final response", got)
+	}
+	if s.Response() != "done" {
+		t.Errorf("Response() = %q, want done", s.Response())
+	}
+}
+
+func TestGraphRunErrors(t *testing.T) {
+	cycle := NewGraph()
+	cycle.AddNode("a", step("a"))
+	cycle.AddNode("b", step("b"))
+	cycle.AddEdge("a", "b")
+	cycle.AddEdge("b", "a")
+	cycle.SetStart("a")
+	cycle.SetMaxSteps(5)
+	s := NewState("", "", "")
+	if err := cycle.Run(context.Background(), s); err == nil || !strings.Contains(err.Error(), "maximum steps (5)") {
+		t.Errorf("Run() on an endless cycle = %v, want max steps error", err)
+	}
+	if len(s.Messages) != 5 || s.Error == nil {
+		t.Errorf("ran %v with state error %v, want 5 steps and the error recorded", s.Messages, s.Error)
+	}
+
+	missing := NewGraph()
+	missing.AddNode("a", step("a"))
+	missing.AddEdge("a", "b")
+	missing.SetStart("a")
+	if err := missing.Run(context.Background(), NewState("", "", "")); err == nil {
+		t.Error("Run() reached a missing node without error")
+	}
+
+	failing := NewGraph()
+	boom := errors.New("boom")
+	failing.AddNode("a", func(ctx context.Context, s *State) error { return boom })
+	failing.SetStart("a")
+	if err := failing.Run(context.Background(), NewState("", "", "")); !errors.Is(err, boom) {
+		t.Errorf("Run() = %v, want wrapped node error", err)
+	}
+}
+
+func TestGraphRunRetryLoop(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("fetch", func(ctx context.Context, s *State) error {
+		s.Values["attempts"] = s.Float("attempts") + 1
+		s.Say("fetch")
+		return nil
+	})
+	g.AddNode("check", step("check"))
+	g.AddNode("done", step("done"))
+	g.AddEdge("fetch", "check")
+	g.AddEdge("check", "fetch")
+	g.AddEdge("check", "done")
+	g.AddRouter("check", func(s *State) string {
+		if s.Float("attempts") < 3 {
+			return "fetch"
+		}
+		return "done"
+	})
+	g.SetStart("fetch")
+
+	s := NewState("", "", "")
+	if err := g.Run(context.Background(), s); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if got, want := strings.Join(s.Messages, " "), "fetch check fetch check fetch check done"; got != want {
+		t.Errorf("ran %s, want %s", got, want)
+	}
+	if s.Error != nil {
+		t.Errorf("state error = %v, want nil", s.Error)
+	}
+}
+
+func TestGraphRunTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		slow NodeFunc
+	}{
+		{"returns context error", func(ctx context.Context, s *State) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		{"swallows context error", func(ctx context.Context, s *State) error {
+			<-ctx.Done()
+			s.Say("gave up")
+			return nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGraph()
+			g.AddNode("start", step("start"))
+			g.AddNode("slow", tt.slow)
+			g.AddNode("after", step("after"))
+			g.AddEdge("start", "slow")
+			g.AddEdge("slow", "after")
+			g.SetTimeout("slow", 10*time.Millisecond)
+			g.SetTimeout("start", time.Hour)
+			g.SetStart("start")
+
+			s := NewState("", "", "")
+			err := g.Run(context.Background(), s)
+			var nodeErr *NodeError
+			if !errors.As(err, &nodeErr) || nodeErr.Node != "slow" || !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Run() = %v, want slow node deadline error", err)
+			}
+			if s.Error != err {
+				t.Errorf("state error = %v, want %v", s.Error, err)
+			}
+			for _, m := range s.Messages {
+				if m == "after" {
+					t.Error("workflow continued past the timed out node")
+				}
+			}
+		})
+	}
+}
+
+func TestRouteToolTimeout(t *testing.T) {
+	d := testDeps(t, RouteGeneral)
+	d.Classifier = ClassifierFunc(func(ctx context.Context, input string) (string, float64, error) {
+		<-ctx.Done()
+		return "", 0, ctx.Err()
+	})
+	d.NodeTimeout = 10 * time.Millisecond
+
+	result, err := RouteTool(d).Execute(context.Background(), &core.ToolParams{
+		UserID: "user-1",
+		Input:  json.RawMessage(`{"user_message":"hello"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || !strings.Contains(result.Error, "node orchestrator: timed out") {
+		t.Errorf("result = %+v, want orchestrator timeout", result)
+	}
+}
+
+func classifier(route string) Classifier {
+	return ClassifierFunc(func(ctx context.Context, input string) (string, float64, error) {
+		return route, 1, nil
+	})
+}
+
+func TestClassify(t *testing.T) {
+	const input = "can I withdraw cash back from a deposit?"
+	tests := []struct {
+		name       string
+		classifier Classifier
+		want       string
+	}{
+		{"no classifier", nil, RouteWithdraw},
+		{"route", classifier(RouteDeposit), RouteDeposit},
+		{"unknown route", classifier("transfer"), RouteWithdraw},
+		{"error", ClassifierFunc(func(ctx context.Context, input string) (string, float64, error) {
+			return "", 0, errors.New("unavailable")
+		}), RouteWithdraw},
+		{"unsure", ClassifierFunc(func(ctx context.Context, input string) (string, float64, error) {
+			return RouteDeposit, 0.4, nil
+		}), RouteWithdraw},
+		{"sure enough", ClassifierFunc(func(ctx context.Context, input string) (string, float64, error) {
+			return RouteDeposit, DefaultMinConfidence, nil
+		}), RouteDeposit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(context.Background(), tt.classifier, DefaultMinConfidence, input); got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeywordClassifier(t *testing.T) {
+	tests := []struct {
+		input          string
+		want           string
+		wantConfidence float64
+	}{
+		{"can I withdraw cash back from a deposit?", RouteWithdraw, 0.5},
+		{"Deposit the money I withdrew yesterday", RouteDeposit, 0.5},
+		{"Pull 50 USDC from savings", RouteWithdraw, 1},
+		{"Put money in savings, into the vault", RouteDeposit, 1},
+		{"How stable is the deposit rate?", RouteAPYStability, 1},
+		{"Split this receipt and withdraw my share", RouteImagePayment, 1},
+		{"I'm broke, should I take money out of savings?", RouteWithdraw, 1},
+		{"Help me save", RouteFinancialHelp, 1},
+		{"What's my balance?", RouteGeneral, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			route, confidence, err := KeywordClassifier{}.Classify(context.Background(), tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if route != tt.want || confidence != tt.wantConfidence {
+				t.Errorf("Classify() = %q, %v, want %q, %v", route, confidence, tt.want, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+func TestModelClassifier(t *testing.T) {
+	tests := []struct {
+		name           string
+		reply          string
+		want           string
+		wantConfidence float64
+		wantErr        string
+	}{
+		{name: "route", reply: `{"route": "withdraw", "confidence": 0.8}`, want: RouteWithdraw, wantConfidence: 0.8},
+		{name: "surrounding text", reply: "```json\n{\"route\": \" Deposit\", \"confidence\": 1}\n```", want: RouteDeposit, wantConfidence: 1},
+		{name: "bare route", reply: `"deposit"`, wantErr: "not JSON"},
+		{name: "unknown route", reply: `{"route": "transfer", "confidence": 0.9}`, wantErr: "unknown route"},
+		{name: "no confidence", reply: `{"route": "deposit"}`, wantErr: "confidence"},
+		{name: "confidence out of range", reply: `{"route": "deposit", "confidence": 90}`, wantErr: "confidence"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt string
+			c := NewModelClassifier(llm.CompleterFunc(func(ctx context.Context, p string, maxTokens int) (string, error) {
+				prompt = p
+				return tt.reply, nil
+			}))
+			route, confidence, err := c.Classify(context.Background(), "can I withdraw cash back from a deposit?")
+			if !strings.HasSuffix(prompt, "can I withdraw cash back from a deposit?") {
+				t.Errorf("prompt does not end with the request: %q", prompt)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if route != tt.want || confidence != tt.wantConfidence {
+				t.Errorf("Classify() = %q, %v, want %q, %v", route, confidence, tt.want, tt.wantConfidence)
+			}
+		})
+	}
+
+	failing := NewModelClassifier(llm.CompleterFunc(func(ctx context.Context, p string, maxTokens int) (string, error) {
+		return "", errors.New("unavailable")
+	}))
+	if _, _, err := failing.Classify(context.Background(), "hello"); err == nil {
+		t.Error("Classify() should return the model's error")
+	}
+}
+
+func testDeps(t *testing.T, route string, balances ...executor.WalletBalance) Deps {
+	return Deps{
+		Exec: &txntest.Executor{Responses: map[string]interface{}{
+			"get_balance": executor.GetBalanceResponse{Balances: balances},
+			"get_savings_balance": executor.GetSavingsBalanceResponse{Positions: []executor.SavingsPosition{
+				{Currency: "USD", CurrentValue: "900"},
+			}},
+			"get_vault_rates": executor.GetVaultRatesResponse{Vaults: []executor.VaultRate{
+				{Currency: "USDC", APY: "4"},
+				{Currency: "EURC", APY: "5"},
+			}},
+			"get_transactions": executor.GetTransactionsResponse{Transactions: []executor.Transaction{
+				{Amount: "40", Direction: "debit", Note: "Netflix subscription"},
+			}},
+		}},
+		Classifier: classifier(route),
+		Charts:     charts.Dir{Path: t.TempDir(), BaseURL: "/charts/"},
+	}
+}
+
+func TestFinancialAgent(t *testing.T) {
+	tests := []struct {
+		name        string
+		route       string
+		input       string
+		locale      string
+		balances    []executor.WalletBalance
+		wantHandler string
+		wantText    string
+	}{
+		{
+			name:        "savings advice",
+			route:       RouteFinancialHelp,
+			input:       "help me save",
+			balances:    []executor.WalletBalance{{Currency: "USDC", Amount: "100"}, {Currency: "EURC", Amount: "50"}, {Currency: "LIL", Amount: "10"}},
+			wantHandler: nodeSave,
+			wantText:    "Save in EURC vault earning 5.00% APY",
+		},
+		{
+			name:        "savings reminders",
+			route:       RouteFinancialHelp,
+			input:       "I want to save weekly",
+			balances:    []executor.WalletBalance{{Currency: "USDC", Amount: "100"}, {Currency: "LIL", Amount: "10"}},
+			wantHandler: nodeReminder,
+			wantText:    "24.50 USDC per deposit over 4 weeks",
+		},
+		{
+			name:        "low funds",
+			route:       RouteFinancialHelp,
+			input:       "I'm broke",
+			balances:    []executor.WalletBalance{{Currency: "USDC", Amount: "50"}},
+			wantHandler: nodeBudget,
+			wantText:    "subscription: $40.00",
+		},
+		{
+			name:        "unsafe withdrawal",
+			route:       RouteWithdraw,
+			input:       "withdraw 100 USD",
+			balances:    []executor.WalletBalance{{Currency: "USD", Amount: "100"}},
+			wantHandler: RouteWithdraw,
+			wantText:    "Weekly spending limit: $150.00",
+		},
+		{
+			name:        "unsafe withdrawal in german",
+			route:       RouteWithdraw,
+			input:       "withdraw 100 USD",
+			locale:      "de-DE",
+			balances:    []executor.WalletBalance{{Currency: "USD", Amount: "100"}},
+			wantHandler: RouteWithdraw,
+			wantText:    "Weekly spending limit: 150,00 $",
+		},
+		{
+			name:        "general",
+			route:       RouteGeneral,
+			input:       "show me a chart",
+			wantHandler: RouteGeneral,
+			wantText:    "Let me check your account",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewState("user-1", "req-1", tt.input)
+			s.Locale = tt.locale
+			if err := FinancialAgent(testDeps(t, tt.route, tt.balances...)).Run(context.Background(), s); err != nil {
+				t.Fatal(err)
+			}
+			if s.Route != tt.route || s.Handler != tt.wantHandler {
+				t.Errorf("route = %s, handler = %s, want %s and %s", s.Route, s.Handler, tt.route, tt.wantHandler)
+			}
+			if !strings.Contains(s.Response(), tt.wantText) {
+				t.Errorf("response doesn't contain %q:\n%s", tt.wantText, s.Response())
+			}
+		})
+	}
+}
+
+func TestWithdrawalSafety(t *testing.T) {
+	tests := []struct {
+		wallet, savings float64
+		wantUnsafe      bool
+	}{
+		{100, 900, true},
+		{300, 700, false},
+		{0, 0, false},
+		{10, 0, false},
+	}
+	for _, tt := range tests {
+		if _, unsafe := withdrawalSafety(tt.wallet, tt.savings); unsafe != tt.wantUnsafe {
+			t.Errorf("withdrawalSafety(%v, %v) unsafe = %v, want %v", tt.wallet, tt.savings, unsafe, tt.wantUnsafe)
+		}
+	}
+}
+
+func TestRouteTool(t *testing.T) {
+	d := testDeps(t, RouteAPYStability)
+	d.APY = analysis.NewAPYHistory(6, 6.1, 6)
+
+	result, err := RouteTool(d).Execute(context.Background(), &core.ToolParams{
+		UserID: "user-1",
+		Input:  json.RawMessage(`{"user_message":"is the APY stable?"}`),
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("route_request failed: %v %+v", err, result)
+	}
+	data := result.Data.(*core.Envelope).Data.(map[string]interface{})
+	if data["route"] != RouteAPYStability || data["handler_type"] != RouteAPYStability {
+		t.Errorf("routed to %v/%v", data["route"], data["handler_type"])
+	}
+	response, _ := data["generated_response"].(string)
+	if guidance, _ := data["guidance"].(string); response == "" || !strings.HasSuffix(guidance, response) {
+		t.Errorf("guidance doesn't include the generated analysis:\n%s", guidance)
+	}
+}
+
+func TestFinancialAgentWithoutFunds(t *testing.T) {
+	fixtures := []struct {
+		name       string
+		balances   []executor.WalletBalance
+		canAdvise  bool
+		canDeposit bool
+		wantHealth analysis.BalanceHealth
+	}{
+		{"negative", []executor.WalletBalance{{Currency: "USD", Amount: "-50"}, {Currency: "LIL", Amount: "10"}}, false, false, analysis.BalanceNegative},
+		{"zero", []executor.WalletBalance{{Currency: "USD", Amount: "0"}, {Currency: "EUR", Amount: "0.001"}}, false, false, analysis.BalanceZero},
+		// Overall overdrawn, but the positive currency can still be deposited
+		{"offsetting", []executor.WalletBalance{{Currency: "USDC", Amount: "30"}, {Currency: "EURC", Amount: "-40"}, {Currency: "LIL", Amount: "10"}}, false, true, analysis.BalanceNegative},
+		{"tiny positive", []executor.WalletBalance{{Currency: "USD", Amount: "0.05"}}, true, true, analysis.BalanceLow},
+	}
+	// Advice that needs money to work with.
+	moneyAdvice := []string{"Deposit 50% now", "Recommended Weekly Budget", "Potential Earnings", "Ready to Deposit"}
+
+	for _, f := range fixtures {
+		for _, route := range []string{RouteFinancialHelp, RouteDeposit, RouteWithdraw} {
+			t.Run(f.name+"/"+route, func(t *testing.T) {
+				s := NewState("user-1", "req-1", "help")
+				if err := FinancialAgent(testDeps(t, route, f.balances...)).Run(context.Background(), s); err != nil {
+					t.Fatal(err)
+				}
+				response := s.Response()
+
+				for _, bad := range []string{"$-", "€-", "-0.00"} {
+					if strings.Contains(response, bad) {
+						t.Errorf("response contains %q:\n%s", bad, response)
+					}
+				}
+				advisable := f.canAdvise || (route == RouteDeposit && f.canDeposit)
+				if !advisable && route != RouteWithdraw {
+					for _, advice := range moneyAdvice {
+						if strings.Contains(response, advice) {
+							t.Errorf("response advises %q without funds:\n%s", advice, response)
+						}
+					}
+					if !strings.Contains(response, "Ways to add funds") {
+						t.Errorf("response doesn't suggest funding options:\n%s", response)
+					}
+				}
+				if route == RouteFinancialHelp {
+					if got := s.Values["balance_health"]; got != f.wantHealth {
+						t.Errorf("balance_health = %v, want %s", got, f.wantHealth)
+					}
+					if wantNoFunds := !f.canAdvise; (s.Handler == nodeNoFunds) != wantNoFunds {
+						t.Errorf("handler = %s, want no-funds path %v", s.Handler, wantNoFunds)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestWithdrawOverdrawn(t *testing.T) {
+	d := testDeps(t, RouteWithdraw, executor.WalletBalance{Currency: "USD", Amount: "-50"})
+	s := NewState("user-1", "req-1", "withdraw")
+	if err := FinancialAgent(d).Run(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Result["is_unsafe_withdrawal"] != false || s.Result["weekly_budget_limit"] != 0.0 {
+		t.Errorf("overdrawn withdrawal result = %+v, want safe with no budget", s.Result)
+	}
+	if !strings.Contains(s.Response(), "withdraw 50.00 USD") {
+		t.Errorf("response doesn't suggest covering the overdraft:\n%s", s.Response())
+	}
+}
+
+func TestRouteToolNoFundsGuidance(t *testing.T) {
+	d := testDeps(t, RouteDeposit, executor.WalletBalance{Currency: "USD", Amount: "-5"})
+	result, err := RouteTool(d).Execute(context.Background(), &core.ToolParams{
+		UserID: "user-1",
+		Input:  json.RawMessage(`{"user_message":"deposit 10 USD"}`),
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("route_request failed: %v %+v", err, result)
+	}
+	if guidance := result.Data.(*core.Envelope).Data.(map[string]interface{})["guidance"]; guidance != noFundsGuidance {
+		t.Errorf("guidance = %v, want no-funds guidance", guidance)
+	}
+}
+
+type receiptScanner struct {
+	receipt *receipt.Receipt
+	err     error
+}
+
+func (r receiptScanner) ProcessReceipt(ctx context.Context, image []byte) (*receipt.Receipt, error) {
+	return r.receipt, r.err
+}
+
+func TestImagePayment(t *testing.T) {
+	dinner := &receipt.Receipt{Merchant: "The Crown", Currency: "GBP", Total: 33, Items: []receipt.Item{{Description: "Burger", Amount: 12}}}
+	tests := []struct {
+		name       string
+		scanner    receipt.Scanner
+		uploaded   bool
+		wantStatus string
+		wantText   string
+	}{
+		{name: "no scanner", uploaded: true, wantStatus: "ready_for_receipt", wantText: "Upload a receipt image"},
+		{name: "nothing uploaded", scanner: receiptScanner{receipt: dinner}, wantStatus: "ready_for_receipt", wantText: "Upload a receipt image"},
+		{name: "receipt read", scanner: receiptScanner{receipt: dinner}, uploaded: true, wantStatus: "receipt_read", wantText: "0. Burger 12.00"},
+		{name: "not a receipt", scanner: receiptScanner{err: receipt.ErrNotReceipt}, uploaded: true, wantStatus: "receipt_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testDeps(t, RouteImagePayment)
+			d.Receipts = tt.scanner
+			d.Images = func(ctx context.Context, userID, id string) ([]byte, bool) {
+				return []byte("image"), tt.uploaded
+			}
+			s := NewState("user-1", "req-1", "split this receipt")
+			if err := FinancialAgent(d).Run(context.Background(), s); err != nil {
+				t.Fatal(err)
+			}
+			if s.Result["status"] != tt.wantStatus {
+				t.Errorf("status = %v, want %s", s.Result["status"], tt.wantStatus)
+			}
+			if !strings.Contains(s.Response(), tt.wantText) {
+				t.Errorf("response doesn't contain %q:\n%s", tt.wantText, s.Response())
+			}
+			if guidance(s) == "" {
+				t.Error("no guidance")
+			}
+		})
+	}
+}
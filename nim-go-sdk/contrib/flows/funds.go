@@ -0,0 +1,65 @@
+package flows
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/analysis"
+	"github.com/becomeliminal/nim-go-sdk/i18n"
+)
+
+// fundingOptions suggests ways to add money to an empty or overdrawn wallet.
+var fundingOptions = []string{
+	"💳 Ways to add funds:",
+	"   • Ask a friend or family member to send you money using your Liminal display tag",
+	"   • Request repayment from anyone who owes you (share your display tag from your profile)",
+	"   • Withdraw from your savings vault if you have a savings balance",
+	"   • Top up your wallet in the Liminal app",
+}
+
+// money formats amount of currency for the user's locale, putting the sign
+// first (-$5.00 rather than $-5.00).
+func (s *State) money(amount float64, currency string) string {
+	return i18n.FormatMoney(amount, currency, s.Locale)
+}
+
+// balanceHealth classifies the user's stablecoin balance.
+func (h *handlers) balanceHealth(balance float64) analysis.BalanceHealth {
+	return analysis.ClassifyBalance(balance, h.Health)
+}
+
+// noFunds explains an empty or overdrawn wallet. Savings, deposits and
+// budgets all need money to work with, so none are proposed; instead the
+// user is pointed at ways to fund the wallet.
+func (h *handlers) noFunds(ctx context.Context, s *State) error {
+	s.Handler = nodeNoFunds
+	total := s.Float("balance_usdc") + s.Float("balance_eurc")
+	health, _ := s.Values["balance_health"].(analysis.BalanceHealth)
+
+	var recs []string
+	if health == analysis.BalanceNegative {
+		recs = append(recs,
+			fmt.Sprintf("⚠️ Your wallet is overdrawn: your balance is %s.", s.money(total, "USD")),
+			"",
+			"This means more has left your account than came in. Until it's back above zero:",
+			"   • Pause non-essential spending and any new transfers",
+			"   • Any money you receive will first bring your balance back to zero",
+		)
+	} else {
+		recs = append(recs,
+			"ℹ️ Your wallet is empty right now, so there's nothing to save or budget yet.",
+			"",
+			"Once money comes in I can help you plan a budget and start saving.",
+		)
+	}
+	recs = append(recs, "")
+	recs = append(recs, fundingOptions...)
+
+	s.Result = map[string]interface{}{
+		"status":          "no_funds",
+		"balance_health":  health,
+		"total_balance":   total,
+		"recommendations": recs,
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+// Package flows runs stateful agent workflows as graphs of nodes, and
+// defines the financial agent workflow behind the route_request tool.
+package flows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// State is the state shared by a graph's nodes as it runs.
+type State struct {
+	// UserID is the user the workflow runs for.
+	UserID string
+
+	// RequestID traces the originating request.
+	RequestID string
+
+	// Input is the user's message.
+	Input string
+
+	// Locale is the user's locale, e.g. "de-DE", which amounts are
+	// formatted for. Empty is en-US.
+	Locale string
+
+	// Route is the handler the request was routed to.
+	Route string
+
+	// Handler is the node that produced Result; it may refine Route,
+	// e.g. financial_help becomes financial_save for users with spare funds.
+	Handler string
+
+	// Messages are progress updates, ending with the final response.
+	Messages []string
+
+	// OnMessage, if set, receives each progress update as it is added, so
+	// the user sees the workflow's steps while it runs. The final response,
+	// added with Respond, isn't sent.
+	OnMessage func(text string)
+	sent      int // Messages passed to OnMessage or added by Respond
+
+	// Result is the data produced by the handler.
+	Result map[string]interface{}
+
+	// Values carries intermediate values between nodes.
+	Values map[string]interface{}
+
+	// Error is the error that stopped the workflow, if any. Errors from a
+	// node, including timeouts, are a *NodeError naming it.
+	Error error
+
+	// CurrentNode is the node the workflow resumes at on the user's next
+	// message, set with Await while it waits for their reply. Run starts
+	// there instead of at the graph's start node, and clears it.
+	CurrentNode string
+	resumed     string // the node this run resumed at
+}
+
+// NewState creates the state for running a workflow on input.
+func NewState(userID, requestID, input string) *State {
+	return &State{
+		UserID:    userID,
+		RequestID: requestID,
+		Input:     input,
+		Result:    make(map[string]interface{}),
+		Values:    make(map[string]interface{}),
+	}
+}
+
+// Float returns a float value, or zero if unset.
+func (s *State) Float(key string) float64 {
+	f, _ := s.Values[key].(float64)
+	return f
+}
+
+// Say appends a progress update for the user.
+func (s *State) Say(format string, args ...interface{}) {
+	s.Messages = append(s.Messages, fmt.Sprintf(format, args...))
+	s.flush()
+}
+
+// Respond appends the final response.
+func (s *State) Respond(text string) {
+	s.flush()
+	s.Messages = append(s.Messages, text)
+	s.sent = len(s.Messages)
+}
+
+// flush passes the messages added since the last flush to OnMessage.
+func (s *State) flush() {
+	if s.OnMessage != nil {
+		for _, msg := range s.Messages[min(s.sent, len(s.Messages)):] {
+			s.OnMessage(msg)
+		}
+	}
+	s.sent = len(s.Messages)
+}
+
+// Await pauses the workflow after this run: the user's next message resumes
+// it at node, e.g. a node that reads their choice between the options just
+// offered. The rest of this run still goes ahead.
+func (s *State) Await(node string) {
+	s.CurrentNode = node
+}
+
+// ResumedAt returns the node this run resumed at, or "" if it started from
+// the beginning.
+func (s *State) ResumedAt() string {
+	return s.resumed
+}
+
+// Response returns the last message, which is the final response once
+// the workflow has finished.
+func (s *State) Response() string {
+	if len(s.Messages) == 0 {
+		return ""
+	}
+	return s.Messages[len(s.Messages)-1]
+}
+
+// NodeFunc runs a workflow step, updating state.
+type NodeFunc func(ctx context.Context, state *State) error
+
+// Router picks the next node after a step. It must return one of the
+// node's edges.
+type Router func(state *State) string
+
+// DefaultMaxSteps is how many nodes a graph runs before giving up, unless
+// set with SetMaxSteps.
+const DefaultMaxSteps = 50
+
+// NodeError is an error from running a node.
+type NodeError struct {
+	Node string
+	Err  error
+}
+
+func (e *NodeError) Error() string { return fmt.Sprintf("node %s: %v", e.Node, e.Err) }
+func (e *NodeError) Unwrap() error { return e.Err }
+
+// Graph is a workflow of nodes joined by directed edges. After a node runs,
+// its router (if any) picks the next node, otherwise the first edge is
+// taken. The workflow ends at a node without edges. Routers may send the
+// workflow back to nodes that already ran, e.g. to retry a step; MaxSteps
+// bounds how long such loops can go on.
+type Graph struct {
+	nodes    map[string]NodeFunc
+	edges    map[string][]string
+	routers  map[string]Router
+	timeouts map[string]time.Duration
+	start    string
+	maxSteps int
+}
+
+// NewGraph creates an empty graph.
+func NewGraph() *Graph {
+	return &Graph{
+		nodes:    make(map[string]NodeFunc),
+		edges:    make(map[string][]string),
+		routers:  make(map[string]Router),
+		timeouts: make(map[string]time.Duration),
+		maxSteps: DefaultMaxSteps,
+	}
+}
+
+// AddNode adds a step to the graph.
+func (g *Graph) AddNode(name string, fn NodeFunc) {
+	g.nodes[name] = fn
+}
+
+// AddEdge allows the workflow to move from one node to another.
+func (g *Graph) AddEdge(from, to string) {
+	g.edges[from] = append(g.edges[from], to)
+}
+
+// AddRouter sets how the next node is chosen after from.
+func (g *Graph) AddRouter(from string, router Router) {
+	g.routers[from] = router
+}
+
+// SetStart sets the node the workflow starts at.
+func (g *Graph) SetStart(name string) {
+	g.start = name
+}
+
+// SetTimeout bounds how long a node may run. The node's context is
+// cancelled after timeout, and the workflow fails with a *NodeError
+// wrapping context.DeadlineExceeded even if the node returns no error.
+func (g *Graph) SetTimeout(name string, timeout time.Duration) {
+	g.timeouts[name] = timeout
+}
+
+// SetMaxSteps sets how many nodes the workflow may run, counting each
+// revisit. Zero or less restores DefaultMaxSteps.
+func (g *Graph) SetMaxSteps(n int) {
+	if n <= 0 {
+		n = DefaultMaxSteps
+	}
+	g.maxSteps = n
+}
+
+// Run executes the workflow from the start node, or from state.CurrentNode
+// if a previous run is awaiting the user's reply. It fails, recording the
+// error in state.Error, if a node fails or times out, or if the workflow
+// runs more than its maximum steps.
+func (g *Graph) Run(ctx context.Context, state *State) error {
+	start := g.start
+	state.resumed = state.CurrentNode
+	if state.CurrentNode != "" {
+		start, state.CurrentNode = state.CurrentNode, ""
+	}
+	state.Error = g.run(ctx, start, state)
+	return state.Error
+}
+
+func (g *Graph) run(ctx context.Context, start string, state *State) error {
+	for current, steps := start, 0; current != ""; steps++ {
+		if steps == g.maxSteps {
+			return fmt.Errorf("exceeded maximum steps (%d) at node: %s", g.maxSteps, current)
+		}
+		if _, ok := g.nodes[current]; !ok {
+			return fmt.Errorf("node not found: %s", current)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := g.runNode(ctx, current, state)
+		// Send any messages the node appended directly.
+		state.flush()
+		if err != nil {
+			return &NodeError{Node: current, Err: err}
+		}
+
+		next, err := g.next(current, state)
+		if err != nil {
+			return err
+		}
+		current = next
+	}
+	return nil
+}
+
+// runNode runs a node under its timeout, if it has one.
+func (g *Graph) runNode(ctx context.Context, name string, state *State) error {
+	timeout, ok := g.timeouts[name]
+	if !ok || timeout <= 0 {
+		return g.nodes[name](ctx, state)
+	}
+	nodeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := g.nodes[name](nodeCtx, state)
+	if errors.Is(nodeCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return fmt.Errorf("timed out after %s: %w", timeout, context.DeadlineExceeded)
+	}
+	return err
+}
+
+// next picks the node after current, or "" at the end of the workflow.
+func (g *Graph) next(current string, state *State) (string, error) {
+	edges := g.edges[current]
+	if len(edges) == 0 {
+		return "", nil
+	}
+	router, ok := g.routers[current]
+	if !ok {
+		return edges[0], nil
+	}
+	next := router(state)
+	for _, e := range edges {
+		if e == next {
+			return next, nil
+		}
+	}
+	return "", fmt.Errorf("node %s routed to %q, which is not one of its edges", current, next)
+}
@@ -0,0 +1,810 @@
+package flows
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/analysis"
+	"github.com/becomeliminal/nim-go-sdk/contrib/budget"
+	"github.com/becomeliminal/nim-go-sdk/contrib/charts"
+	"github.com/becomeliminal/nim-go-sdk/contrib/receipt"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// minimumBalance is kept in the wallet when recommending how much to save,
+// and is the stablecoin balance needed for savings advice.
+const minimumBalance = 2
+
+// minimumLIL is the LIL balance needed for savings advice.
+const minimumLIL = 5
+
+// unsafeLiquidity is the share of total funds held in the wallet below which
+// withdrawing from savings is considered hasty.
+const unsafeLiquidity = 0.20
+
+// handlers are the financial agent's nodes.
+type handlers struct {
+	Deps
+}
+
+// either returns the first of the currencies present in amounts. Liminal
+// reports some currencies by fiat code (USD) and others by token (USDC).
+func either(amounts map[string]float64, currencies ...string) float64 {
+	for _, c := range currencies {
+		if a, ok := amounts[c]; ok {
+			return a
+		}
+	}
+	return 0
+}
+
+func (h *handlers) general(ctx context.Context, s *State) error {
+	s.Handler = RouteGeneral
+	s.Values["chart_requested"] = wantsChart(s.Input)
+	return nil
+}
+
+// imagePayment reads the receipt the user uploaded, if any, so the agent
+// can go straight to splitting it.
+func (h *handlers) imagePayment(ctx context.Context, s *State) error {
+	s.Handler = RouteImagePayment
+	s.Result["status"] = "ready_for_receipt"
+	s.Result["message"] = "Ready to process receipt image. Please provide a receipt image to extract payment details."
+	if h.Receipts == nil || h.Images == nil {
+		return nil
+	}
+	image, ok := h.Images(ctx, s.UserID, "latest")
+	if !ok {
+		return nil
+	}
+
+	s.Say("🧾 Reading your receipt...")
+	r, err := h.Receipts.ProcessReceipt(ctx, image)
+	if err != nil {
+		log.Printf("Failed to read receipt: %v", err)
+		s.Result["status"] = "receipt_error"
+		s.Result["message"] = fmt.Sprintf("The uploaded image couldn't be read as a receipt (%v). Ask the user for a clear photo of the whole receipt.", err)
+		return nil
+	}
+
+	s.Result["status"] = "receipt_read"
+	s.Result["receipt"] = r
+	recs := []string{fmt.Sprintf("🧾 %s: %.2f %s, %d items", merchantName(r), r.Total, r.Currency, len(r.Items))}
+	for i, item := range r.Items {
+		recs = append(recs, fmt.Sprintf("  %d. %s %.2f", i, item.Description, item.Amount))
+	}
+	s.Result["recommendations"] = recs
+	return nil
+}
+
+func merchantName(r *receipt.Receipt) string {
+	if r.Merchant == "" {
+		return "Receipt"
+	}
+	return r.Merchant
+}
+
+// financialHelp checks the user's balance to choose between savings advice,
+// help with low funds, and funding guidance for empty or overdrawn wallets.
+func (h *handlers) financialHelp(ctx context.Context, s *State) error {
+	s.Say("💰 Step 1/3: Checking your account balance...")
+
+	balances, err := txn.Balances(ctx, h.Exec, s.UserID, s.RequestID)
+	if err != nil {
+		log.Printf("Failed to fetch balance: %v", err)
+		s.Handler = RouteFinancialHelp
+		return nil
+	}
+	usdc, eurc, lil := either(balances, "USDC", "USD"), either(balances, "EURC", "EUR"), balances["LIL"]
+	s.Say("✅ Balance found: %.2f USDC, %.2f EURC, %.2f LIL", usdc, eurc, lil)
+
+	s.Values["balance_usdc"] = usdc
+	s.Values["balance_eurc"] = eurc
+	health := h.balanceHealth(usdc + eurc)
+	s.Values["balance_health"] = health
+	switch {
+	case !health.CanAdvise():
+		s.Handler = nodeNoFunds
+	case (usdc > minimumBalance || eurc > minimumBalance) && lil > minimumLIL:
+		s.Handler = nodeSave
+	default:
+		s.Handler = RouteFinancialHelp
+	}
+	return nil
+}
+
+// bestVault picks the vault to recommend: the higher-rate vault among the
+// stablecoins the user holds more than the minimum balance of.
+func bestVault(usdc, eurc, usdcAPY, eurcAPY float64) (currency string, apy, available float64) {
+	switch {
+	case usdc > minimumBalance && eurc > minimumBalance && eurcAPY > usdcAPY:
+		return "EURC", eurcAPY, eurc - minimumBalance
+	case usdc <= minimumBalance && eurc > minimumBalance:
+		return "EURC", eurcAPY, eurc - minimumBalance
+	}
+	available = usdc - minimumBalance
+	if available < 0 {
+		available = 0
+	}
+	return "USDC", usdcAPY, available
+}
+
+// save recommends a savings vault and compares saving all at once with
+// saving in chunks.
+func (h *handlers) save(ctx context.Context, s *State) error {
+	s.Handler = nodeSave
+	s.Say("📊 Step 2/3: Comparing vault rates to find your best option...")
+
+	usdc, eurc := s.Float("balance_usdc"), s.Float("balance_eurc")
+	rates, err := txn.VaultRates(ctx, h.Exec, s.UserID, s.RequestID)
+	if err != nil {
+		log.Printf("Failed to fetch vault rates: %v", err)
+	}
+	usdcAPY, eurcAPY := either(rates, "USDC", "USD"), either(rates, "EURC", "EUR")
+	currency, apy, available := bestVault(usdc, eurc, usdcAPY, eurcAPY)
+	s.Say("✅ Best rate: %s vault at %.2f%% APY", currency, apy)
+
+	recs := []string{
+		fmt.Sprintf("💰 Best option: Save in %s vault earning %.2f%% APY", currency, apy),
+		fmt.Sprintf("💵 You have %.2f %s available to save", available, currency),
+	}
+	if usdc > minimumBalance && eurc > minimumBalance {
+		recs = append(recs, fmt.Sprintf("📊 Rate comparison: USDC %.2f%% vs EURC %.2f%%", usdcAPY, eurcAPY))
+	}
+
+	s.Say("📈 Step 3/3: Creating investment strategy comparison...")
+	if url, err := h.Charts.Save("investment-comparison", charts.InvestmentComparisonSVG(available, apy, currency)); err != nil {
+		log.Printf("Failed to save chart: %v", err)
+	} else {
+		recs = append(recs, "", "📊 Investment Growth Comparison:", fmt.Sprintf("![Investment Comparison](%s)", url), "")
+	}
+
+	recs = append(recs,
+		"",
+		"💡 Investment Strategy Options:",
+		"",
+		"🎯 Lump Sum (All at once):",
+		"   • Pro: Start earning interest immediately on full amount",
+		"   • Pro: Simpler - one transaction and done",
+		"   • Pro: Better if rates are expected to drop",
+		"   • Con: Higher risk if market/rates fluctuate",
+		"",
+		"📅 Dollar-Cost Averaging (Chunks over time):",
+		"   • Pro: Reduces timing risk - spreads deposits over weeks/months",
+		"   • Pro: Helps build a savings habit with regular deposits",
+		"   • Pro: Less stressful - you don't have to pick the 'perfect' time",
+		"   • Con: May earn less interest initially on uninvested funds",
+		"",
+		"🎓 Recommendation:",
+		fmt.Sprintf("   With %.2f %s available:", available, currency),
+		"   • Conservative: Deposit 50% now, split rest over 4 weeks",
+		"   • Moderate: Deposit 75% now, rest next week",
+		"   • Aggressive: Deposit all now to maximize APY immediately",
+		"",
+		"💪 Choose based on your comfort level and financial goals!",
+		"",
+		"❓ Which approach works best for you?",
+		"   1️⃣  Lump sum - Deposit all at once",
+		"   2️⃣  Chunks - Spread deposits over time (I can set up calendar reminders!)",
+		"",
+		"💡 Ready to start saving now?",
+		fmt.Sprintf("I can help you deposit into your %s savings vault (%.2f%% APY) right away!", currency, apy),
+		fmt.Sprintf("Just say \"deposit [amount] %s\" to get started.", currency),
+	)
+
+	s.Result = map[string]interface{}{
+		"status":            "sufficient_funds",
+		"best_currency":     currency,
+		"best_apy":          apy,
+		"usdc_apy":          usdcAPY,
+		"eurc_apy":          eurcAPY,
+		"available_to_save": available,
+		"recommendations":   recs,
+		"can_deposit":       true,
+	}
+	s.Values["wants_calendar_reminders"] = wantsReminders(s.Input)
+	if !wantsReminders(s.Input) {
+		s.Await(nodeStrategy)
+	}
+	return nil
+}
+
+// strategy reads the user's answer to savings advice: saving in chunks
+// goes on to reminders, and a lump sum to depositing it all now. Anything
+// else is a new request.
+func (h *handlers) strategy(ctx context.Context, s *State) error {
+	switch {
+	case wantsReminders(s.Input) || strings.TrimSpace(s.Input) == "2":
+		s.Values["wants_calendar_reminders"] = true
+	case wantsLumpSum(s.Input):
+		s.Handler = nodeStrategy
+		currency, _ := s.Result["best_currency"].(string)
+		available, _ := s.Result["available_to_save"].(float64)
+		apy, _ := s.Result["best_apy"].(float64)
+		s.Result = map[string]interface{}{
+			"status":         "lump_sum",
+			"best_currency":  currency,
+			"best_apy":       apy,
+			"amount":         available,
+			"suggested_tool": "deposit_savings",
+			"recommendations": []string{
+				fmt.Sprintf("🎯 Lump sum it is: deposit %.2f %s now to start earning %.2f%% APY on all of it.", available, currency, apy),
+				"Shall I make the deposit?",
+			},
+		}
+	default:
+		restart(s)
+	}
+	return nil
+}
+
+// reminder offers calendar reminders for users who chose to save in chunks,
+// and awaits their choice of how often. Once they've chosen, it plans the
+// reminders for that frequency.
+func (h *handlers) reminder(ctx context.Context, s *State) error {
+	resumed := s.ResumedAt() == nodeReminder
+	if resumed && frequency(s.Input) == "" {
+		restart(s)
+		return nil
+	}
+	s.Handler = nodeReminder
+	currency, _ := s.Result["best_currency"].(string)
+	available, _ := s.Result["available_to_save"].(float64)
+
+	options := []struct {
+		frequency, description, duration string
+		periods                          float64
+	}{
+		{budget.Weekly, "Invest every week", "4 weeks", 4},
+		{budget.BiWeekly, "Invest every 2 weeks", "1 month", 2},
+		{budget.Monthly, "Invest once a month", "3 months", 3},
+	}
+
+	recs := []string{
+		"💡 Would you like me to help you set up calendar reminders for your periodic investments?",
+		"",
+		"I can help you set up reminders for periodic investments:",
+		"",
+	}
+	frequencies := make(map[string]interface{}, len(options))
+	for _, o := range options {
+		amount := available / o.periods
+		recs = append(recs, fmt.Sprintf("📅 **%s**: %s", o.frequency, o.description), fmt.Sprintf("   Amount: %.2f %s per deposit over %s", amount, currency, o.duration), "")
+		frequencies[o.frequency] = map[string]interface{}{
+			"description": o.description,
+			"amount":      amount,
+			"duration":    o.duration,
+		}
+		if resumed && o.frequency == frequency(s.Input) {
+			s.Values["reminder_frequency"] = o.frequency
+			s.Result = map[string]interface{}{
+				"status":         "reminder_chosen",
+				"best_currency":  currency,
+				"frequency":      o.frequency,
+				"amount":         amount,
+				"reminders":      int(o.periods),
+				"suggested_tool": "create_calendar_reminder",
+				"recommendations": []string{
+					fmt.Sprintf("📅 %s reminders: deposit %.2f %s each time, %d deposits over %s.", strings.ToUpper(o.frequency[:1])+o.frequency[1:], amount, currency, int(o.periods), o.duration),
+					"Shall I add them to your calendar?",
+				},
+			}
+			return nil
+		}
+	}
+	recs = append(recs,
+		"Benefits:",
+		"📅 Never miss your investment schedule",
+		"💪 Build consistent savings habits",
+		"🔔 Get notified when it's time to deposit",
+		"📈 Stay on track with your financial goals",
+		"",
+		"Would you like to set up reminders? If so, which frequency works best for you?",
+		"",
+		fmt.Sprintf("💰 Would you also like to make your first deposit now? You have %.2f %s available.", available, currency),
+	)
+
+	s.Result = map[string]interface{}{
+		"status":            "reminder_offered",
+		"available_amount":  available,
+		"available_to_save": available,
+		"best_currency":     currency,
+		"frequency_options": frequencies,
+		"suggested_tool":    "create_calendar_reminder",
+		"recommendations":   recs,
+	}
+	s.Await(nodeReminder)
+	return nil
+}
+
+func (h *handlers) lowFunds(ctx context.Context, s *State) error {
+	s.Handler = nodeLowFunds
+	s.Say("🔍 Analyzing your spending patterns to find ways to save...")
+	s.Values["total_balance"] = s.Float("balance_usdc") + s.Float("balance_eurc")
+	return nil
+}
+
+// estimatedIncome roughly estimates monthly income from the user's balance,
+// or zero if the balance isn't positive.
+func estimatedIncome(s *State) float64 {
+	return math.Max(s.Float("total_balance")*2, 0)
+}
+
+// spending flags unnecessary and excessive spending.
+func (h *handlers) spending(ctx context.Context, s *State) error {
+	s.Handler = nodeSpending
+	txs, err := txn.Fetch(ctx, h.Exec, s.UserID, s.RequestID, 50)
+	if err != nil {
+		log.Printf("Failed to fetch transactions: %v", err)
+	}
+	flagged := analysis.Flag(txs, estimatedIncome(s))
+	s.Values["flagged"] = flagged
+
+	recs := []string{"📊 Your Spending Analysis:"}
+	if url, err := h.Charts.Save("flagged-spending", charts.FlaggedSpendingSVG(flagged)); err != nil {
+		log.Printf("Failed to save chart: %v", err)
+	} else {
+		recs = append(recs, fmt.Sprintf("![Flagged Spending](%s)", url))
+	}
+	recs = append(recs, "")
+	recs = appendFlagged(recs, "🚩 Unnecessary Expenses:", flagged.Unnecessary)
+	recs = appendFlagged(recs, "⚠️ Excessive Essential Expenses:", flagged.Excessive)
+
+	if savings := flagged.PotentialSavings(); savings > 0 {
+		recs = append(recs,
+			fmt.Sprintf("💡 Potential Monthly Savings: $%.2f", savings),
+			"",
+			"📌 Action Steps:",
+			"1. Cancel unused subscriptions immediately",
+			"2. Set alerts for essential expense categories",
+			"3. Consider cheaper alternatives for flagged items",
+			"4. Use weekly spending goals to track progress",
+		)
+	} else {
+		recs = append(recs, "✅ Your spending looks reasonable! Focus on increasing income.")
+	}
+	if flagged.Skipped > 0 {
+		recs = append(recs, "", fmt.Sprintf("⚠️ %d transactions had amounts that couldn't be read and weren't analyzed.", flagged.Skipped))
+	}
+
+	s.Result = map[string]interface{}{
+		"status":            "analysis_complete",
+		"recommendations":   recs,
+		"flagged_spending":  flagged,
+		"potential_savings": flagged.PotentialSavings(),
+	}
+	return nil
+}
+
+func appendFlagged(recs []string, heading string, items []analysis.FlaggedItem) []string {
+	if len(items) == 0 {
+		return recs
+	}
+	recs = append(recs, heading)
+	for _, item := range items {
+		recs = append(recs, fmt.Sprintf("   • %s: $%.2f - %s", item.Category, item.Amount, item.Reason))
+	}
+	return append(recs, "")
+}
+
+// budget explains the flagged spending and proposes a weekly budget.
+func (h *handlers) budget(ctx context.Context, s *State) error {
+	s.Handler = nodeBudget
+	flagged, _ := s.Values["flagged"].(analysis.Flagged)
+	income := estimatedIncome(s)
+	savings := flagged.PotentialSavings()
+
+	recs := append([]string(nil), s.Result["recommendations"].([]string)...)
+	recs = append(recs, "", "💭 Let me explain what I found and how we can fix this:", "")
+
+	if len(flagged.Unnecessary) > 0 {
+		recs = append(recs, "🚫 Unnecessary Expenses - Why These Matter:")
+		for _, item := range flagged.Unnecessary {
+			recs = append(recs, fmt.Sprintf("   • %s: $%.2f\n     💡 %s", item.Category, item.Amount, whyUnnecessary(item, income)))
+		}
+		recs = append(recs, "")
+	}
+	if len(flagged.Excessive) > 0 {
+		recs = append(recs, "⚠️  Excessive Essential Expenses - Why These Are Too High:")
+		for _, item := range flagged.Excessive {
+			recs = append(recs, fmt.Sprintf("   • %s: $%.2f\n     💡 %s", item.Category, item.Amount, item.Reason))
+		}
+		recs = append(recs, "")
+	}
+
+	recs = append(recs, "✨ Personalized Recommendations:")
+	for _, item := range flagged.Unnecessary {
+		recs = append(recs, "   "+cutUnnecessary(item, income))
+	}
+	for _, item := range flagged.Excessive {
+		recs = append(recs, "   "+cutExcessive(item, income))
+	}
+	recs = append(recs, "")
+
+	weeklySavings := savings / 4
+	currentWeekly := savings / 4
+	recs = append(recs,
+		"📊 Your Weekly Budget Goal:",
+		fmt.Sprintf("Current weekly spending on flagged items: $%.2f", currentWeekly),
+		fmt.Sprintf("Weekly savings goal: $%.2f", weeklySavings),
+		"",
+	)
+	var categoryBudgets []budget.CategoryBudget
+	if income > 0 {
+		categoryBudgets = budget.CategoryBudgets(income / 4)
+		recs = append(recs, "📋 Recommended Weekly Budget Breakdown:")
+		for _, b := range categoryBudgets {
+			recs = append(recs, fmt.Sprintf("   • %s: $%.2f", b.Category, b.Amount))
+		}
+	} else {
+		recs = append(recs, "📋 I couldn't estimate your income from your balance, so tell me what you earn each month and I'll break down a weekly budget.")
+	}
+	recs = append(recs,
+		"",
+		"🎯 How to Track Your Weekly Budget:",
+		"   1. Set a weekly spending limit alert on your phone",
+		"   2. Check your balance every Monday morning",
+		"   3. If you're over budget mid-week, pause non-essential spending",
+		"   4. Celebrate when you hit your weekly savings goal!",
+		"",
+		fmt.Sprintf("💰 If you follow this plan, you could save $%.2f per month ($%.2f per year)!", savings, savings*12),
+		"",
+		"💡 Once you cut these expenses, would you like to start building savings?",
+		"I can help you set up automatic deposits into high-yield savings vaults.",
+		"Just let me know when you're ready to start saving!",
+	)
+
+	s.Result = map[string]interface{}{
+		"status":                  "recommendations_ready",
+		"recommendations":         recs,
+		"flagged_spending":        flagged,
+		"weekly_savings_goal":     weeklySavings,
+		"weekly_budget_breakdown": categoryBudgets,
+		"can_start_saving":        true,
+	}
+	return nil
+}
+
+func whyUnnecessary(item analysis.FlaggedItem, income float64) string {
+	switch item.Category {
+	case analysis.Entertainment:
+		share := 0.0
+		if income > 0 {
+			share = item.Amount / income * 100
+		}
+		return fmt.Sprintf("Entertainment (%.0f%% of income) - Financial experts recommend keeping entertainment under 5-10%% of your income. This helps ensure you're prioritizing savings and essentials first.", share)
+	case analysis.Subscription:
+		return fmt.Sprintf("Subscriptions ($%.2f/month) - Many people pay for services they rarely use. Audit your subscriptions quarterly - even small recurring charges add up to hundreds per year.", item.Amount)
+	}
+	return fmt.Sprintf("%s spending - While not essential, cutting this can free up cash for emergencies or savings goals.", item.Category)
+}
+
+func cutUnnecessary(item analysis.FlaggedItem, income float64) string {
+	switch item.Category {
+	case analysis.Entertainment:
+		return fmt.Sprintf("→ Entertainment: Try free alternatives - public parks, library events, free museum days. Set a monthly entertainment budget of $%.0f", income*0.05)
+	case analysis.Subscription:
+		return "→ Subscriptions: Cancel services you haven't used in 30 days. Share family plans to split costs. Consider rotating subscriptions monthly."
+	}
+	return fmt.Sprintf("→ %s: Pause spending here for 30 days and see if you miss it. If not, cut permanently.", item.Category)
+}
+
+func cutExcessive(item analysis.FlaggedItem, income float64) string {
+	target := analysis.EssentialTarget(item.Category, income)
+	switch item.Category {
+	case analysis.Food:
+		return fmt.Sprintf("→ Food: Meal prep on Sundays, buy generic brands, use grocery apps for discounts. Target: $%.0f/month (currently $%.0f)", target, item.Amount)
+	case analysis.Travel:
+		return fmt.Sprintf("→ Travel: Use public transit, carpool apps, or bike when possible. Consider a monthly transit pass. Target: $%.0f/month (currently $%.0f)", target, item.Amount)
+	case analysis.Electronics:
+		return "→ Electronics: Buy refurbished, wait for sales, or use buy-nothing groups. Electronics are rarely urgent purchases."
+	}
+	return fmt.Sprintf("→ %s: Research cheaper alternatives or negotiate better rates with providers.", item.Category)
+}
+
+// withdrawalSafety returns the share of the user's funds held in the wallet
+// and whether withdrawing from savings would leave them too little.
+// Withdrawing to cover an overdrawn wallet is never hasty.
+func withdrawalSafety(wallet, savings float64) (liquidity float64, unsafe bool) {
+	if total := wallet + savings; total > 0 && wallet > 0 {
+		liquidity = wallet / total
+	}
+	return liquidity, wallet >= 0 && liquidity < unsafeLiquidity && savings > 0
+}
+
+// withdraw checks whether withdrawing from savings is safe, and teaches the
+// cost of doing so.
+func (h *handlers) withdraw(ctx context.Context, s *State) error {
+	s.Handler = RouteWithdraw
+	balances, err := txn.Balances(ctx, h.Exec, s.UserID, s.RequestID)
+	if err != nil {
+		return h.unavailable(s, err)
+	}
+	positions, err := txn.Savings(ctx, h.Exec, s.UserID, s.RequestID)
+	if err != nil {
+		return h.unavailable(s, err)
+	}
+
+	wallet := either(balances, "USD", "USDC") + either(balances, "EUR", "EURC")
+	savings := txn.Total(positions)
+	total := wallet + savings
+	liquidity, unsafe := withdrawalSafety(wallet, savings)
+
+	situation := []string{
+		"Your current situation:",
+		fmt.Sprintf("   • Wallet (liquid): %s (%.0f%% of total)", s.money(wallet, "USD"), liquidity*100),
+		fmt.Sprintf("   • Savings: %s", s.money(savings, "USD")),
+		fmt.Sprintf("   • Total: %s", s.money(total, "USD")),
+		"",
+	}
+
+	var recs []string
+	var weeklyLimit float64
+	switch {
+	case h.balanceHealth(wallet) == analysis.BalanceNegative:
+		recs = append([]string{"⚠️ Withdrawal Safety Check:", ""}, situation...)
+		recs = append(recs,
+			fmt.Sprintf("Your wallet is overdrawn by %s.", s.money(-wallet, "USD")),
+			"Withdrawing from savings to bring it back above zero is a sensible use of your savings.",
+		)
+		if total < 0 {
+			recs = append(recs,
+				"",
+				"Your savings won't cover the whole shortfall, so you'll also need to add funds.",
+				"",
+			)
+			recs = append(recs, fundingOptions...)
+		}
+		s.Values["follow_up"] = fmt.Sprintf("💬 Tell me the amount and currency you'd like to withdraw (e.g., \"withdraw %.2f USD\" to cover the overdraft).", math.Min(-wallet, savings))
+	case unsafe:
+		weeklyLimit = total * 0.15
+		recs = append([]string{"⚠️ Withdrawal Safety Check:", ""}, situation...)
+		recs = append(recs,
+			"🚨 This is a hasty withdrawal situation:",
+			"",
+			"Why this matters:",
+			"   1. You have very little liquid cash available (less than 20% of your total)",
+			"   2. You're pulling from your savings that's earning interest",
+			"   3. This could become a habit that prevents wealth building",
+			"",
+			"💡 What you should know:",
+			"   • Financial experts recommend keeping 3-6 months expenses liquid",
+			"   • Savings should be for emergencies or planned goals, not daily spending",
+			"   • Frequent withdrawals mean you're living above your means",
+			"",
+			fmt.Sprintf("Example: If you leave %s in savings at 5%% APY, you'd earn %s per year.", s.money(savings, "USD"), s.money(yearlyInterest(savings, 5), "USD")),
+			"By withdrawing, you're giving up this passive income.",
+			"",
+			"✅ I'll allow this withdrawal, BUT to protect your financial health I'd like to help you set a weekly spending budget.",
+			"",
+			"🎯 Recommended Weekly Budget:",
+			fmt.Sprintf("   • Weekly spending limit: %s", s.money(weeklyLimit, "USD")),
+			"   • Leaves room to rebuild your liquid funds",
+		)
+		s.Values["follow_up"] = fmt.Sprintf("💬 Tell me the amount and currency you want to withdraw, and I'll process it.\nAfter withdrawal, I can help you set up that %s weekly budget to protect your finances.", s.money(weeklyLimit, "USD"))
+	default:
+		recs = append([]string{"✅ Withdrawal Safety Check:", ""}, situation...)
+		recs = append(recs,
+			"✅ This is a safe withdrawal situation:",
+			"You have sufficient liquid funds available, so withdrawing from savings is reasonable.",
+			"",
+			"💡 Even though this is safe, here's what you should consider:",
+			"   1. Opportunity Cost: Money in savings earns compound interest",
+			"   2. Rebuilding: Plan to replenish your savings after this withdrawal",
+			"   3. Goals: Make sure this purchase aligns with your financial priorities",
+			"",
+			fmt.Sprintf("💰 Cost of Withdrawal: At 5%% APY, every %s withdrawn costs you %s/year in lost earnings.", s.money(100, "USD"), s.money(5, "USD")),
+		)
+		s.Values["follow_up"] = "💬 Just tell me the amount and currency you'd like to withdraw (e.g., \"withdraw 100 USD\")."
+	}
+
+	s.Result = map[string]interface{}{
+		"status":               "withdrawal_analyzed",
+		"recommendations":      recs,
+		"is_unsafe_withdrawal": unsafe,
+		"wallet_balance":       wallet,
+		"savings_balance":      savings,
+		"liquidity_ratio":      liquidity,
+		"weekly_budget_limit":  weeklyLimit,
+		"can_withdraw":         true,
+	}
+	return nil
+}
+
+// deposit shows available balances and what depositing them would earn.
+func (h *handlers) deposit(ctx context.Context, s *State) error {
+	s.Handler = RouteDeposit
+	balances, err := txn.Balances(ctx, h.Exec, s.UserID, s.RequestID)
+	if err != nil {
+		return h.unavailable(s, err)
+	}
+	rates, err := txn.VaultRates(ctx, h.Exec, s.UserID, s.RequestID)
+	if err != nil {
+		log.Printf("Failed to fetch vault rates: %v", err)
+	}
+	usd, eur := either(balances, "USD", "USDC"), either(balances, "EUR", "EURC")
+	usdAPY, eurAPY := either(rates, "USD", "USDC"), either(rates, "EUR", "EURC")
+
+	if health := h.balanceHealth(math.Max(usd, 0) + math.Max(eur, 0)); !health.CanAdvise() {
+		recs := []string{
+			"💰 Nothing to Deposit Yet:",
+			"",
+			"Your Balances:",
+			fmt.Sprintf("   • USD: %s", s.money(usd, "USD")),
+			fmt.Sprintf("   • EUR: %s", s.money(eur, "EUR")),
+			"",
+			"You need money in your wallet before you can move it into savings.",
+			"",
+		}
+		s.Result = map[string]interface{}{
+			"status":          "no_funds",
+			"balance_health":  health,
+			"recommendations": append(recs, fundingOptions...),
+			"usd_balance":     usd,
+			"eur_balance":     eur,
+		}
+		return nil
+	}
+
+	recs := []string{
+		"💰 Ready to Deposit into Savings:",
+		"",
+		"Your Available Balances:",
+		fmt.Sprintf("   • USD: %s (earning %.2f%% APY)", s.money(usd, "USD"), usdAPY),
+		fmt.Sprintf("   • EUR: %s (earning %.2f%% APY)", s.money(eur, "EUR"), eurAPY),
+		"",
+		"✨ Benefits of Depositing:",
+		"   • Earn passive income through compound interest",
+		"   • Your funds are secure and accessible anytime",
+		"   • Interest accrues daily and compounds automatically",
+		"   • No lock-up periods or penalties for early withdrawal",
+		"",
+	}
+	recs = appendEarnings(s, recs, "USD", usd, usdAPY)
+	recs = appendEarnings(s, recs, "EUR", eur, eurAPY)
+	recs = append(recs,
+		"💬 How to Deposit:",
+		"Just tell me the amount and currency you'd like to deposit.",
+		"Examples:",
+		"   • \"Deposit 100 USD\"",
+		"   • \"Put 50 EUR in savings\"",
+	)
+
+	s.Result = map[string]interface{}{
+		"status":          "ready_to_deposit",
+		"recommendations": recs,
+		"usd_balance":     usd,
+		"eur_balance":     eur,
+		"usd_apy":         usdAPY,
+		"eur_apy":         eurAPY,
+	}
+	return nil
+}
+
+func appendEarnings(s *State, recs []string, currency string, balance, apy float64) []string {
+	if balance <= 0 || apy <= 0 {
+		return recs
+	}
+	p, err := yearOfSavings(balance, apy)
+	if err != nil {
+		return recs
+	}
+	return append(recs,
+		fmt.Sprintf("📈 Potential Earnings (%s):", currency),
+		fmt.Sprintf("   • If you deposit %s:", s.money(balance, currency)),
+		fmt.Sprintf("     - First month: %s", s.money(float64(p.Schedule[0].Interest)/100, currency)),
+		fmt.Sprintf("     - Yearly: %s", s.money(float64(p.TotalInterest)/100, currency)),
+		"",
+	)
+}
+
+// yearOfSavings projects a year of interest on balance at apy percent,
+// compounded daily as the savings vault does.
+func yearOfSavings(balance, apy float64) (*tools.SavingsProjection, error) {
+	return tools.ProjectSavings(tools.SavingsPlan{
+		Principal:   tools.Cents(balance),
+		APY:         apy,
+		Months:      12,
+		Compounding: tools.CompoundDaily,
+	})
+}
+
+// yearlyInterest is a year of interest on balance at apy percent, as
+// yearOfSavings projects it.
+func yearlyInterest(balance, apy float64) float64 {
+	p, err := yearOfSavings(math.Max(balance, 0), apy)
+	if err != nil {
+		return 0
+	}
+	return float64(p.TotalInterest) / 100
+}
+
+// apyStability scores the savings vault's recent APY history.
+func (h *handlers) apyStability(ctx context.Context, s *State) error {
+	s.Handler = RouteAPYStability
+	if err := h.APY.Refresh(ctx, h.Exec, s.UserID, s.RequestID, h.APYCurrency); err != nil {
+		log.Printf("Failed to refresh APY history: %v", err)
+	}
+	st := analysis.ScoreStability(h.APY.Values())
+
+	recs := []string{
+		"📊 APY Stability Score Analysis",
+		"",
+		fmt.Sprintf("Overall Score: %.1f/100 - %s", st.Score, st.Label),
+		"",
+		fmt.Sprintf("📈 Current %s Vault Stats (recent history):", h.APYCurrency),
+		fmt.Sprintf("   • Current APY: %.2f%%", st.Current),
+		fmt.Sprintf("   • Average APY: %.2f%%", st.Average),
+		fmt.Sprintf("   • Range: %.2f%% - %.2f%%", st.Min, st.Max),
+		"",
+		"🔍 Detailed Metrics:",
+		fmt.Sprintf("   • Volatility Score: %.1f/100", st.Volatility),
+		"     (Higher = less jumpy, more predictable)",
+		fmt.Sprintf("   • Drawdown Score: %.1f/100", st.Drawdown),
+		"     (Higher = fewer sudden drops)",
+		fmt.Sprintf("   • Time Above %.1f%%: %.1f/100", analysis.StabilityThreshold, st.TimeAbove),
+		"     (Higher = consistently good returns)",
+		"",
+	}
+	recs = append(recs, stabilityAdvice(st.Score)...)
+
+	s.Result = map[string]interface{}{
+		"status":          "stability_analyzed",
+		"stability":       st,
+		"recommendations": recs,
+		"suggest_deposit": st.Score > 60,
+	}
+	return nil
+}
+
+func stabilityAdvice(score float64) []string {
+	switch {
+	case score > 80:
+		return []string{
+			"✅ Stable Vault - Excellent for Long-Term Savings",
+			"   • Very predictable returns with minimal volatility",
+			"   • Rare sudden drops, consistently above the threshold",
+			"",
+			"💡 This is an ideal time to commit funds for long-term growth. Consider setting up recurring deposits.",
+			"💰 Ready to deposit? I can help you move funds to savings now!",
+		}
+	case score > 60:
+		return []string{
+			"🟡 Opportunistic Vault - Good for Strategic Timing",
+			"   • Moderate volatility with occasional dips",
+			"   • APY fluctuates but generally recovers",
+			"",
+			"💡 Monitor APY trends and deposit when rates are above average. Dollar-cost averaging smooths out volatility.",
+			"💰 Conditions are favorable! I can help you deposit to savings now.",
+		}
+	case score > 40:
+		return []string{
+			"🟠 Spiky Vault - Use Caution",
+			"   • High volatility with frequent APY swings",
+			"   • Drawdowns are common and recovery can be slow",
+			"",
+			"💡 Only deposit funds you can afford to lock during dips. Consider shorter-term positions or diversifying.",
+		}
+	}
+	return []string{
+		"🔴 Unreliable Vault - High Risk",
+		"   • Extreme volatility, very unpredictable",
+		"   • Frequent sharp drops, often below the threshold",
+		"",
+		"💡 Avoid depositing significant funds until stability improves.",
+	}
+}
+
+// unavailable records that the handler couldn't read the user's account.
+func (h *handlers) unavailable(s *State, err error) error {
+	log.Printf("Failed to read account for %s: %v", s.Handler, err)
+	s.Result = map[string]interface{}{
+		"status":          "error",
+		"recommendations": []string{"Unable to check your balance. Please try again."},
+	}
+	return nil
+}
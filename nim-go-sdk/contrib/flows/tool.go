@@ -0,0 +1,157 @@
+package flows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// Tools returns the workflow tools: route_request.
+func Tools(d Deps) []core.Tool {
+	return []core.Tool{RouteTool(d)}
+}
+
+// RouteTool returns the route_request tool, which runs the financial agent
+// workflow on the user's message and tells the agent how to proceed. With
+// d.States, a workflow awaiting the user's reply in the conversation resumes
+// where it left off.
+func RouteTool(d Deps) core.Tool {
+	if d.StateTTL <= 0 {
+		d.StateTTL = DefaultStateTTL
+	}
+	return tools.New("route_request").
+		Description("Analyze user's request and route to the appropriate specialized handler. Call this FIRST for any user request to determine the best way to help them. Returns routing decision and context.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"user_message": tools.StringProperty("The user's original message/request"),
+		}, "user_message")).
+		ReturnsEnvelope().
+		NoCache().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				UserMessage string `json:"user_message"`
+			}
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+			}
+
+			var conversationID string
+			if params.Context != nil {
+				conversationID = params.Context.ConversationID
+			}
+			s := loadState(ctx, d, params, conversationID, input.UserMessage)
+			s.OnMessage = params.EmitMessage
+			s.Locale = core.PreferencesFor(ctx, params).Locale
+			if err := FinancialAgent(d).Run(ctx, s); err != nil {
+				log.Printf("Graph execution error: %v", err)
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("routing failed: %v", err)}, nil
+			}
+			if d.States != nil && conversationID != "" {
+				if err := d.States.Save(ctx, s.Checkpoint(conversationID, time.Now(), d.StateTTL)); err != nil {
+					log.Printf("Failed to save workflow state: %v", err)
+				}
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"route":              s.Route,
+					"handler_type":       s.Handler,
+					"guidance":           guidance(s),
+					"user_message":       input.UserMessage,
+					"generated_response": s.Response(),
+				},
+			}, nil
+		}).
+		Build()
+}
+
+// loadState returns the state for running the workflow on the user's
+// message: the conversation's saved workflow if it awaits their reply,
+// otherwise a new one.
+func loadState(ctx context.Context, d Deps, params *core.ToolParams, conversationID, message string) *State {
+	if d.States == nil || conversationID == "" {
+		return NewState(params.UserID, params.RequestID, message)
+	}
+	checkpoint, err := d.States.Load(ctx, params.UserID, conversationID)
+	if err != nil {
+		log.Printf("Failed to load workflow state: %v", err)
+	}
+	if checkpoint == nil || checkpoint.Node == "" || checkpoint.Expired(time.Now()) {
+		return NewState(params.UserID, params.RequestID, message)
+	}
+	return checkpoint.State(params.RequestID, message)
+}
+
+// noFundsGuidance steers the agent away from advice that needs money when
+// the user's wallet is empty or overdrawn.
+const noFundsGuidance = "User's wallet balance is zero or negative. The system has explained their balance state and listed ways to add funds - present that to the user. Do NOT propose deposits, savings plans, or budgets derived from their balance, and do NOT call deposit_savings. If they have savings, withdrawing to cover an overdraft with withdraw_savings is reasonable."
+
+// guidance tells the agent how to continue after the workflow has run.
+func guidance(s *State) string {
+	switch s.Handler {
+	case RouteImagePayment:
+		switch s.Result["status"] {
+		case "receipt_read":
+			return "User wants to split the receipt they uploaded, which the system has read (items are numbered below). Ask who they're splitting with and who paid, if they haven't said, then call split_receipt with image_id='latest', their participants' display tags, and assignments for any items that belong to specific people. If the plan has payments, confirm them with the user and make each with send_money. Mention any currency_warning.\n\n" + s.Response()
+		case "receipt_error":
+			msg, _ := s.Result["message"].(string)
+			return msg
+		}
+		return "User wants to split a payment or send money based on an image/receipt. Ask them to upload the receipt image, then call split_receipt with image_id='latest' and the people they're splitting with. If the plan has payments, confirm them with the user and make each with send_money."
+	case RouteWithdraw:
+		return "User wants to withdraw money from savings. The system has analyzed their liquidity situation and provided educational content about withdrawal safety. Now help them complete the withdrawal using the withdraw_savings tool. CRITICAL: Use currency='USD' or currency='EUR' ONLY (NOT 'USDC' or 'EURC'). Require: amount (as string) and currency ('USD' or 'EUR'). If this was an unsafe withdrawal, also offer to set up the weekly budget using spend_weekly_goal tool after completing the withdrawal."
+	case RouteDeposit:
+		if s.Result["status"] == "no_funds" {
+			return noFundsGuidance
+		}
+		return "User wants to deposit money into savings. The system has shown their available balances and potential earnings. Help them complete the deposit using the deposit_savings tool. CRITICAL: Use currency='USD' or currency='EUR' ONLY (NOT 'USDC' or 'EURC'). Require: amount (as string) and currency ('USD' or 'EUR'). This tool requires user confirmation. Encourage them about the benefits of earning passive income through compound interest."
+	case RouteAPYStability:
+		return "User wants to check APY stability score. The system has analyzed the vault's recent rate history and calculated a comprehensive stability score. IMPORTANT: Present the complete analysis that was generated, including the score, label, all metrics, and recommendations. The analysis is provided below - format it clearly for the user.\n\n" + s.Response()
+	case RouteFinancialHelp, nodeLowFunds, nodeSpending, nodeBudget:
+		return "User needs financial assistance with low funds. Use check_weeklyspend, analyze_spending, and categorize_transactions to help them improve their financial situation. Focus on budget management, reducing expenses, and building up savings."
+	case nodeNoFunds:
+		return noFundsGuidance
+	case nodeStrategy:
+		return fmt.Sprintf("User chose to save a lump sum. Offer to deposit %.2f %s now with deposit_savings, which requires their confirmation. CRITICAL: Use currency='USD' for USDC or currency='EUR' for EURC.", s.Result["amount"], s.Result["best_currency"])
+	case nodeReminder:
+		if s.Result["status"] == "reminder_chosen" {
+			return fmt.Sprintf("User chose %s savings reminders. Offer to create them with create_calendar_reminder: frequency='%s', amount=%.2f, currency='%s', duration=%d.\n\n%s",
+				s.Result["frequency"], s.Result["frequency"], s.Result["amount"], s.Result["best_currency"], s.Result["reminders"], s.Response())
+		}
+		return saveGuidance(s)
+	case nodeSave:
+		return saveGuidance(s)
+	case RouteGeneral:
+		if chart, _ := s.Values["chart_requested"].(bool); chart {
+			return "User requested a balance trend chart. You MUST call the generate_chart tool with these parameters:\n- chart_type: 'line'\n- data_type: 'balance_trend'\n- days: 30 (or ask the user for a timeframe)\n\nAfter calling generate_chart, you will receive an 'image_url' field. Display it in your response using markdown:\n\n![Balance Trend Chart](image_url_here)\n\nReplace 'image_url_here' with the actual image_url from the tool result. Explain that this shows their account balance over time based on transaction history."
+		}
+		return "Standard query. Use appropriate banking tools (get_balance, get_transactions, etc.) to help the user."
+	}
+	return "Process as a general query."
+}
+
+func saveGuidance(s *State) string {
+	currency, _ := s.Result["best_currency"].(string)
+	apy, _ := s.Result["best_apy"].(float64)
+	available, _ := s.Result["available_to_save"].(float64)
+	if currency == "" {
+		currency = "USDC"
+	}
+	return fmt.Sprintf("User has sufficient funds for saving/investing. Based on vault rate analysis:\n\n"+
+		"BEST OPTION: Save in %s vault earning %.2f%% APY\n"+
+		"AVAILABLE: %.2f %s can be moved to savings\n\n"+
+		"Provide personalized advice on:\n"+
+		"1. The benefits of their recommended vault (%s at %.2f%% APY)\n"+
+		"2. Investment strategy options:\n"+
+		"   - Lump Sum: Deposit all at once (pros: immediate full interest, simpler; cons: timing risk)\n"+
+		"   - Dollar-Cost Averaging: Deposit in chunks over time (pros: reduces timing risk, builds habit; cons: less immediate interest)\n"+
+		"3. Suggest specific amounts based on their %.2f %s balance\n"+
+		"4. Use deposit_savings tool if they want to proceed\n\n"+
+		"Be encouraging and educational about building wealth through savings!",
+		currency, apy, available, currency, currency, apy, available, currency)
+}
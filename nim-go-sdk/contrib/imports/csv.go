@@ -0,0 +1,383 @@
+package imports
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+)
+
+// Import limits.
+const (
+	MaxBytes = 2 << 20 // largest CSV accepted
+	MaxRows  = 10000   // most data rows accepted
+)
+
+// Mapping says which CSV columns hold which fields. Columns are named by
+// header. Amounts come from either Amount, signed with debits negative, or
+// separate Debit and Credit columns.
+type Mapping struct {
+	Date         string `json:"date"`
+	Description  string `json:"description"`
+	Amount       string `json:"amount,omitempty"`
+	Debit        string `json:"debit,omitempty"`
+	Credit       string `json:"credit,omitempty"`
+	Currency     string `json:"currency,omitempty"`
+	Counterparty string `json:"counterparty,omitempty"`
+
+	// DateFormat is a Go time layout, e.g. "01/02/2006".
+	DateFormat string `json:"date_format"`
+
+	// InvertAmounts flips the sign of Amount, for banks that show
+	// charges as positive.
+	InvertAmounts bool `json:"invert_amounts,omitempty"`
+
+	// DefaultCurrency is used when there is no Currency column.
+	DefaultCurrency string `json:"default_currency,omitempty"`
+}
+
+// Validate checks that the mapping names the columns it needs.
+func (m *Mapping) Validate(header []string) error {
+	has := make(map[string]bool, len(header))
+	for _, h := range header {
+		has[h] = true
+	}
+	need := []string{m.Date, m.Description}
+	switch {
+	case m.Amount != "":
+		need = append(need, m.Amount)
+	case m.Debit != "" && m.Credit != "":
+		need = append(need, m.Debit, m.Credit)
+	default:
+		return fmt.Errorf("mapping needs an amount column, or debit and credit columns")
+	}
+	for _, c := range []string{m.Currency, m.Counterparty} {
+		if c != "" {
+			need = append(need, c)
+		}
+	}
+	for _, c := range need {
+		if c == "" {
+			return fmt.Errorf("mapping needs date and description columns")
+		}
+		if !has[c] {
+			return fmt.Errorf("column %q is not in the CSV", c)
+		}
+	}
+	if m.DateFormat == "" {
+		return fmt.Errorf("mapping needs a date format")
+	}
+	return nil
+}
+
+// headerNames are the header names each field is detected from, most
+// specific first.
+var headerNames = struct {
+	date, description, amount, debit, credit, currency, counterparty []string
+}{
+	date:         []string{"transaction date", "trans date", "date", "booking date", "started date", "completed date", "posted date", "post date", "posting date", "value date"},
+	description:  []string{"description", "transaction description", "details", "narrative", "memo", "name", "merchant", "payee", "reference"},
+	amount:       []string{"amount", "transaction amount", "value"},
+	debit:        []string{"debit", "debit amount", "withdrawal", "withdrawals", "money out", "paid out"},
+	credit:       []string{"credit", "credit amount", "deposit", "deposits", "money in", "paid in"},
+	currency:     []string{"currency", "ccy"},
+	counterparty: []string{"counterparty", "payee", "merchant", "name"},
+}
+
+// dateFormats are the layouts tried when detecting dates. US month-first
+// comes before day-first, so ambiguous files are read as US.
+var dateFormats = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"01/02/2006",
+	"1/2/2006",
+	"02/01/2006",
+	"2/1/2006",
+	"01/02/06",
+	"02/01/06",
+	"2006/01/02",
+	"02-01-2006",
+	"01-02-2006",
+	"02.01.2006",
+	"Jan 2, 2006",
+	"2 Jan 2006",
+	"02 Jan 2006",
+}
+
+// Read parses a CSV payload into its header and data rows.
+func Read(data string) ([]string, [][]string, error) {
+	if len(data) > MaxBytes {
+		return nil, nil, fmt.Errorf("CSV is %d bytes; the limit is %d", len(data), MaxBytes)
+	}
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(data, "\ufeff")))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, nil, fmt.Errorf("CSV needs a header row and at least one transaction")
+	}
+	if len(records)-1 > MaxRows {
+		return nil, nil, fmt.Errorf("CSV has %d rows; the limit is %d", len(records)-1, MaxRows)
+	}
+	header := make([]string, len(records[0]))
+	for i, h := range records[0] {
+		header[i] = strings.TrimSpace(h)
+	}
+	return header, records[1:], nil
+}
+
+// Detect guesses a mapping from the header and rows.
+func Detect(header []string, rows [][]string) (*Mapping, error) {
+	find := func(names []string, exclude ...string) string {
+		for _, name := range names {
+			for _, h := range header {
+				if strings.EqualFold(h, name) && !contains(exclude, h) {
+					return h
+				}
+			}
+		}
+		return ""
+	}
+
+	m := &Mapping{}
+	m.Date = find(headerNames.date)
+	m.Description = find(headerNames.description)
+	m.Amount = find(headerNames.amount)
+	if m.Amount == "" {
+		m.Debit = find(headerNames.debit)
+		m.Credit = find(headerNames.credit)
+	}
+	m.Currency = find(headerNames.currency)
+	m.Counterparty = find(headerNames.counterparty, m.Description)
+
+	if m.Date == "" {
+		return nil, fmt.Errorf("no date column found in %s", strings.Join(header, ", "))
+	}
+	m.DateFormat = detectDateFormat(column(header, rows, m.Date))
+	if m.DateFormat == "" {
+		return nil, fmt.Errorf("dates in column %q are in an unrecognized format", m.Date)
+	}
+	if err := m.Validate(header); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// detectDateFormat returns the layout that parses the most values, earlier
+// layouts winning ties, as long as it parses more than half of them. Rows it
+// can't parse are reported by Parse.
+func detectDateFormat(values []string) string {
+	best, bestCount, total := "", 0, 0
+	for _, v := range values {
+		if v != "" {
+			total++
+		}
+	}
+	for _, layout := range dateFormats {
+		count := 0
+		for _, v := range values {
+			if v == "" {
+				continue
+			}
+			if _, err := time.Parse(layout, v); err == nil {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = layout, count
+		}
+	}
+	if bestCount*2 <= total {
+		return ""
+	}
+	return best
+}
+
+// RowError reports a row that could not be imported. Row 1 is the first row
+// after the header.
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// Parse converts rows to transactions with m. Rows that fail validation are
+// skipped and reported. Each transaction's ID is a hash of its content, so
+// importing the same file twice yields the same IDs.
+func Parse(header []string, rows [][]string, m *Mapping) ([]txn.Transaction, []RowError) {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[h] = i
+	}
+	get := func(row []string, col string) string {
+		if i, ok := index[col]; ok && col != "" && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var txs []txn.Transaction
+	var errs []RowError
+	seen := make(map[string]int)
+	for n, row := range rows {
+		if isBlank(row) {
+			continue
+		}
+		tx, err := parseRow(row, m, get)
+		if err != nil {
+			errs = append(errs, RowError{Row: n + 1, Error: err.Error()})
+			continue
+		}
+
+		// Identical rows, e.g. two coffees on one day, are distinct
+		// transactions: number them so each gets its own ID.
+		key := strings.Join([]string{tx.CreatedAt, tx.Direction, tx.Amount, tx.Currency, strings.ToLower(tx.Note)}, "|")
+		seen[key]++
+		sum := sha256.Sum256([]byte(key + "|" + strconv.Itoa(seen[key])))
+		tx.ID = "ext_" + hex.EncodeToString(sum[:8])
+		txs = append(txs, tx)
+	}
+	return txs, errs
+}
+
+func parseRow(row []string, m *Mapping, get func([]string, string) string) (txn.Transaction, error) {
+	dateText := get(row, m.Date)
+	at, err := time.Parse(m.DateFormat, dateText)
+	if err != nil {
+		return txn.Transaction{}, fmt.Errorf("date %q does not match %s", dateText, m.DateFormat)
+	}
+
+	var amount float64
+	if m.Amount != "" {
+		amount, err = parseAmount(get(row, m.Amount))
+		if err != nil {
+			return txn.Transaction{}, err
+		}
+		if m.InvertAmounts {
+			amount = -amount
+		}
+	} else {
+		debit, credit := get(row, m.Debit), get(row, m.Credit)
+		switch {
+		case debit != "":
+			amount, err = parseAmount(debit)
+			amount = -abs(amount)
+		case credit != "":
+			amount, err = parseAmount(credit)
+			amount = abs(amount)
+		default:
+			err = fmt.Errorf("no debit or credit amount")
+		}
+		if err != nil {
+			return txn.Transaction{}, err
+		}
+	}
+	if amount == 0 {
+		return txn.Transaction{}, fmt.Errorf("amount is zero")
+	}
+
+	note := get(row, m.Description)
+	if note == "" {
+		return txn.Transaction{}, fmt.Errorf("description is empty")
+	}
+	currency := strings.ToUpper(get(row, m.Currency))
+	if currency == "" {
+		currency = m.DefaultCurrency
+	}
+	if currency == "" {
+		currency = "USD"
+	}
+	counterparty := get(row, m.Counterparty)
+	if counterparty == "" {
+		counterparty = note
+	}
+
+	tx := txn.Transaction{
+		Type:         txn.TypeExternal,
+		Amount:       strconv.FormatFloat(abs(amount), 'f', 2, 64),
+		Currency:     currency,
+		Counterparty: counterparty,
+		Note:         note,
+		Status:       "completed",
+		Direction:    "credit",
+		CreatedAt:    at.UTC().Format(time.RFC3339),
+	}
+	if amount < 0 {
+		tx.Direction = "debit"
+	}
+	return tx, nil
+}
+
+// parseAmount parses amounts as banks write them: "-1,234.56", "$12.00",
+// "(45.10)", "£3".
+func parseAmount(s string) (float64, error) {
+	text := strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") {
+		negative = true
+		text = text[1 : len(text)-1]
+	}
+	text = strings.NewReplacer(",", "", "$", "", "£", "", "€", "", " ", "").Replace(text)
+	if strings.HasPrefix(text, "-") {
+		negative = !negative
+		text = text[1:]
+	}
+	text = strings.TrimPrefix(text, "+")
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || text == "" {
+		return 0, fmt.Errorf("amount %q is not a number", s)
+	}
+	if negative {
+		f = -f
+	}
+	return f, nil
+}
+
+func column(header []string, rows [][]string, name string) []string {
+	i := -1
+	for j, h := range header {
+		if h == name {
+			i = j
+		}
+	}
+	var values []string
+	for _, row := range rows {
+		if i >= 0 && i < len(row) {
+			values = append(values, strings.TrimSpace(row[i]))
+		}
+	}
+	return values
+}
+
+func isBlank(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
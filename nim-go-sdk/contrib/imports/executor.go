@@ -0,0 +1,95 @@
+package imports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// Executor wraps exec so that get_transactions calls with
+// "include_external": true also return the user's imported transactions,
+// merged newest first, limited to the requested start_date and end_date,
+// and cut to the requested limit. The flag is removed before the call
+// reaches exec. Imported transactions have Type txn.TypeExternal; see
+// txn.Source.
+func Executor(exec core.ToolExecutor, store Store) core.ToolExecutor {
+	return &mergingExecutor{ToolExecutor: exec, store: store}
+}
+
+type mergingExecutor struct {
+	core.ToolExecutor
+	store Store
+}
+
+func (e *mergingExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	if req.Tool != "get_transactions" {
+		return e.ToolExecutor.Execute(ctx, req)
+	}
+
+	var input map[string]interface{}
+	if len(req.Input) > 0 {
+		if err := json.Unmarshal(req.Input, &input); err != nil {
+			return e.ToolExecutor.Execute(ctx, req)
+		}
+	}
+	flag, ok := input["include_external"]
+	if !ok {
+		return e.ToolExecutor.Execute(ctx, req)
+	}
+	include, _ := flag.(bool)
+	delete(input, "include_external")
+
+	forwarded := *req
+	forwarded.Input, _ = json.Marshal(input)
+	resp, err := e.ToolExecutor.Execute(ctx, &forwarded)
+	if err != nil || !include || !resp.Success {
+		return resp, err
+	}
+
+	var page executor.GetTransactionsResponse
+	if err := json.Unmarshal(resp.Data, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse get_transactions response: %w", err)
+	}
+	external, err := e.store.List(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read imported transactions: %w", err)
+	}
+
+	// Imported transactions honor the same date range as Liminal's.
+	dates, err := executor.ParseDateRange(stringParam(input, "start_date"), stringParam(input, "end_date"))
+	if err != nil {
+		return &core.ExecuteResponse{Success: false, Error: err.Error()}, nil
+	}
+	page.Transactions = merge(page.Transactions, executor.FilterTransactions(external, dates))
+	if limit, ok := input["limit"].(float64); ok && limit > 0 && len(page.Transactions) > int(limit) {
+		page.Transactions = page.Transactions[:int(limit)]
+	}
+	data, err := json.Marshal(page)
+	if err != nil {
+		return nil, err
+	}
+	merged := *resp
+	merged.Data = data
+	return &merged, nil
+}
+
+func stringParam(input map[string]interface{}, key string) string {
+	s, _ := input[key].(string)
+	return s
+}
+
+// merge combines transactions from both sources, newest first.
+func merge(liminal, external []txn.Transaction) []txn.Transaction {
+	all := make([]txn.Transaction, 0, len(liminal)+len(external))
+	all = append(all, liminal...)
+	all = append(all, external...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return txn.CreatedAt(all[i]).After(txn.CreatedAt(all[j]))
+	})
+	return all
+}
@@ -0,0 +1,275 @@
+package imports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/analysis"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		csv       string
+		want      Mapping
+		wantFirst txn.Transaction
+	}{
+		{
+			name: "chase",
+			csv: "Transaction Date,Post Date,Description,Category,Type,Amount,Memo\n" +
+				"01/15/2026,01/16/2026,STARBUCKS #1234,Food & Drink,Sale,-5.75,\n" +
+				"01/31/2026,01/31/2026,Payment Thank You,,Payment,250.00,\n",
+			want:      Mapping{Date: "Transaction Date", Description: "Description", Amount: "Amount", DateFormat: "01/02/2006"},
+			wantFirst: txn.Transaction{Direction: "debit", Amount: "5.75", Currency: "USD", Note: "STARBUCKS #1234", CreatedAt: "2026-01-15T00:00:00Z"},
+		},
+		{
+			name: "bank of america",
+			csv: "Date,Description,Amount,Running Bal.\n" +
+				"02/03/2026,\"NETFLIX.COM 866-579-7172 CA\",\"-15.49\",\"1,234.51\"\n" +
+				"02/05/2026,PAYROLL DEPOSIT,\"2,500.00\",\"3,734.51\"\n",
+			want:      Mapping{Date: "Date", Description: "Description", Amount: "Amount", DateFormat: "01/02/2006"},
+			wantFirst: txn.Transaction{Direction: "debit", Amount: "15.49", Currency: "USD", Note: "NETFLIX.COM 866-579-7172 CA", CreatedAt: "2026-02-03T00:00:00Z"},
+		},
+		{
+			name: "monzo",
+			csv: "Transaction ID,Date,Time,Type,Name,Emoji,Category,Amount,Currency,Description\n" +
+				"tx_1,15/01/2026,08:12:00,Card payment,Pret A Manger,,Eating out,-4.20,GBP,PRET A MANGER LONDON\n" +
+				"tx_2,28/01/2026,09:00:00,Faster payment,Acme Ltd,,Income,1800.00,GBP,SALARY\n",
+			want:      Mapping{Date: "Date", Description: "Description", Amount: "Amount", Currency: "Currency", Counterparty: "Name", DateFormat: "02/01/2006"},
+			wantFirst: txn.Transaction{Direction: "debit", Amount: "4.20", Currency: "GBP", Counterparty: "Pret A Manger", Note: "PRET A MANGER LONDON", CreatedAt: "2026-01-15T00:00:00Z"},
+		},
+		{
+			name: "debit and credit columns",
+			csv: "Transaction Date,Posted Date,Card No.,Description,Category,Debit,Credit\n" +
+				"2026-01-20,2026-01-21,1234,UBER TRIP,Other Travel,23.10,\n" +
+				"2026-01-22,2026-01-22,1234,REFUND,Other,,23.10\n",
+			want:      Mapping{Date: "Transaction Date", Description: "Description", Debit: "Debit", Credit: "Credit", DateFormat: "2006-01-02"},
+			wantFirst: txn.Transaction{Direction: "debit", Amount: "23.10", Currency: "USD", Note: "UBER TRIP", CreatedAt: "2026-01-20T00:00:00Z"},
+		},
+		{
+			name: "money in and out",
+			csv: "Date,Description,Money Out,Money In,Balance\n" +
+				"03/02/2026,TESCO STORES,\"(12.40)\",,100.00\n" +
+				"13/02/2026,TRANSFER,,50.00,150.00\n",
+			want:      Mapping{Date: "Date", Description: "Description", Debit: "Money Out", Credit: "Money In", DateFormat: "02/01/2006"},
+			wantFirst: txn.Transaction{Direction: "debit", Amount: "12.40", Currency: "USD", Note: "TESCO STORES", CreatedAt: "2026-02-03T00:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, rows, err := Read(tt.csv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := Detect(header, rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *m != tt.want {
+				t.Errorf("Detect() = %+v, want %+v", *m, tt.want)
+			}
+
+			txs, errs := Parse(header, rows, m)
+			if len(errs) != 0 || len(txs) != 2 {
+				t.Fatalf("Parse() = %d transactions, errors %+v", len(txs), errs)
+			}
+			got := txs[0]
+			want := tt.wantFirst
+			if want.Counterparty == "" {
+				want.Counterparty = want.Note
+			}
+			if got.Direction != want.Direction || got.Amount != want.Amount || got.Currency != want.Currency ||
+				got.Note != want.Note || got.Counterparty != want.Counterparty || got.CreatedAt != want.CreatedAt {
+				t.Errorf("first transaction = %+v, want %+v", got, want)
+			}
+			if txn.Source(got) != txn.SourceExternal || !strings.HasPrefix(got.ID, "ext_") {
+				t.Errorf("transaction %+v is not marked as imported", got)
+			}
+			if !txn.IsCredit(txs[1]) {
+				t.Errorf("second transaction = %+v, want a credit", txs[1])
+			}
+		})
+	}
+}
+
+func TestParse_RowErrors(t *testing.T) {
+	csv := "Date,Description,Amount\n" +
+		"2026-01-01,Coffee,-3.50\n" +
+		"yesterday,Lunch,-12.00\n" +
+		"2026-01-02,Books,lots\n" +
+		",,\n" +
+		"2026-01-03,,-1.00\n" +
+		"2026-01-04,Refund,0\n"
+	header, rows, err := Read(csv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Mapping{Date: "Date", Description: "Description", Amount: "Amount", DateFormat: "2006-01-02"}
+	txs, errs := Parse(header, rows, m)
+	if len(txs) != 1 {
+		t.Errorf("Parse() = %d transactions, want 1", len(txs))
+	}
+	wantRows := []int{2, 3, 5, 6}
+	if len(errs) != len(wantRows) {
+		t.Fatalf("errors = %+v, want rows %v", errs, wantRows)
+	}
+	for i, row := range wantRows {
+		if errs[i].Row != row {
+			t.Errorf("errors[%d].Row = %d, want %d", i, errs[i].Row, row)
+		}
+	}
+}
+
+func TestRead_Limits(t *testing.T) {
+	if _, _, err := Read(strings.Repeat("x", MaxBytes+1)); err == nil {
+		t.Error("Read() accepted a CSV over MaxBytes")
+	}
+
+	var b strings.Builder
+	b.WriteString("Date,Description,Amount\n")
+	for i := 0; i <= MaxRows; i++ {
+		b.WriteString("2026-01-01,x,1\n")
+	}
+	if _, _, err := Read(b.String()); err == nil {
+		t.Error("Read() accepted a CSV over MaxRows")
+	}
+
+	header, _, err := Read("\ufeffDate,Description,Amount\n2026-01-01,x,1\n")
+	if err != nil || header[0] != "Date" {
+		t.Errorf("Read() header = %q, %v, want the byte order mark stripped", header, err)
+	}
+}
+
+func TestImport_Dedup(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	tool := ImportTool(store)
+
+	// Two identical coffees on one day are both kept.
+	csv := "Date,Description,Amount\n2026-01-05,Coffee,-3.50\n2026-01-05,Coffee,-3.50\n2026-01-06,Lunch,-12.00\n"
+	input, _ := json.Marshal(map[string]string{"csv": csv})
+
+	for i, want := range []float64{3, 0} {
+		result, err := tool.Execute(ctx, &core.ToolParams{UserID: "user-1", Input: input})
+		if err != nil || !result.Success {
+			t.Fatalf("import %d: %+v, %v", i+1, result, err)
+		}
+		data := result.Data.(*core.Envelope).Data.(map[string]interface{})
+		if data["imported"] != int(want) || data["already_imported"] != 3-int(want) {
+			t.Errorf("import %d = %+v, want %v imported", i+1, data, want)
+		}
+	}
+
+	txs, _ := store.List(ctx, "user-1")
+	if len(txs) != 3 || txs[0].Note != "Lunch" {
+		t.Errorf("List() = %+v, want 3 transactions newest first", txs)
+	}
+	if other, _ := store.List(ctx, "user-2"); len(other) != 0 {
+		t.Errorf("List(user-2) = %+v, want none", other)
+	}
+}
+
+func TestPreview_ProposesImport(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	csv := "Date,Description,Amount\n15/01/2026,Coffee,-3.50\n20/01/2026,Lunch,-12.00\nnot a date,Bad,-1\n"
+	input, _ := json.Marshal(map[string]string{"csv": csv})
+
+	result, err := PreviewTool(store).Execute(ctx, &core.ToolParams{UserID: "user-1", Input: input})
+	if err != nil || !result.Success {
+		t.Fatalf("preview: %+v, %v", result, err)
+	}
+	if txs, _ := store.List(ctx, "user-1"); len(txs) != 0 {
+		t.Fatal("preview stored transactions")
+	}
+	if env := result.Data.(*core.Envelope); env.Status != core.StatusPartial || len(env.Warnings) != 1 {
+		t.Errorf("preview envelope = %+v, want a warning about the skipped row", env)
+	}
+	p := result.Propose
+	if p == nil || p.Tool != "import_transactions" {
+		t.Fatalf("Propose = %+v, want import_transactions", p)
+	}
+	if want := "Import 2 transactions dated 2026-01-15 to 2026-01-20 from CSV (1 rows skipped, 0 already imported)"; p.Summary != want {
+		t.Errorf("Summary = %q, want %q", p.Summary, want)
+	}
+
+	// Approving runs the proposed input, with the detected mapping.
+	result, err = ImportTool(store).Execute(ctx, &core.ToolParams{UserID: "user-1", Input: p.Input, ConfirmationID: "conf-1"})
+	if err != nil || !result.Success {
+		t.Fatalf("import: %+v, %v", result, err)
+	}
+	txs, _ := store.List(ctx, "user-1")
+	if len(txs) != 2 || txs[1].CreatedAt != "2026-01-15T00:00:00Z" {
+		t.Errorf("stored %+v, want the two valid rows with day-first dates", txs)
+	}
+
+	// Delete removes them.
+	result, _ = DeleteTool(store).Execute(ctx, &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{}`)})
+	if result.Data.(*core.Envelope).Data.(map[string]interface{})["deleted"] != 2 {
+		t.Errorf("delete = %+v, want 2 deleted", result.Data)
+	}
+}
+
+func TestExecutor_IncludeExternal(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	day := func(ago int) string { return now.AddDate(0, 0, -ago).Format("2006-01-02") }
+
+	fake := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{Transactions: []txn.Transaction{
+			{ID: "tx_1", Type: "payment", Amount: "20", Currency: "USDC", Direction: "debit", Note: "Dinner", CreatedAt: now.AddDate(0, 0, -1).Format(time.RFC3339)},
+		}},
+	}}
+	store := NewMemoryStore()
+	csv := fmt.Sprintf("Date,Description,Amount\n%s,Groceries,-40.00\n%s,Netflix,-15.00\n", day(3), day(10))
+	input, _ := json.Marshal(map[string]string{"csv": csv})
+	if result, _ := ImportTool(store).Execute(ctx, &core.ToolParams{UserID: "user-1", Input: input}); !result.Success {
+		t.Fatalf("import: %+v", result)
+	}
+
+	exec := Executor(fake, store)
+	spending := analysis.SpendingTool(exec)
+
+	result, err := spending.Execute(ctx, &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"days":30}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("analyze_spending: %+v, %v", result, err)
+	}
+	if s := result.Data.(*core.Envelope).Data.(analysis.Summary); s.TotalSpent != 20 || s.Sources != nil {
+		t.Errorf("without include_external = %+v, want only Liminal spending", s)
+	}
+
+	result, err = spending.Execute(ctx, &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"days":30,"include_external":true}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("analyze_spending: %+v, %v", result, err)
+	}
+	env := result.Data.(*core.Envelope)
+	s := env.Data.(analysis.Summary)
+	if env.Figures[0] != (core.Figure{Name: "total_spent", Amount: "75", Currency: "USD"}) {
+		t.Errorf("Figures = %+v, want total_spent 75", env.Figures)
+	}
+	if s.TotalSpent != 75 || s.Sources[txn.SourceExternal] != 2 || s.Sources[txn.SourceLiminal] != 1 {
+		t.Errorf("with include_external = %+v, want both sources", s)
+	}
+
+	for _, req := range fake.Requests() {
+		if strings.Contains(string(req.Input), "include_external") {
+			t.Errorf("request %s reached the executor with the include_external flag", req.Input)
+		}
+	}
+
+	categorize := analysis.CategorizeTool(exec, nil)
+	result, _ = categorize.Execute(ctx, &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"include_external":true}`)})
+	b := result.Data.(*core.Envelope).Data.(analysis.Breakdown)
+	if b.TotalAnalyzed != 3 || b.Breakdown[0] != "Dinner: food" || b.Breakdown[2] != "Netflix: subscription [external]" {
+		t.Errorf("categorize_transactions = %+v, want imported notes tagged", b)
+	}
+}
@@ -0,0 +1,90 @@
+package imports
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+)
+
+// Store keeps each user's imported transactions, separate from the
+// transactions Liminal records.
+// This is an interface - implementations (e.g., database-backed) are provided
+// by the consuming application.
+type Store interface {
+	// Add saves transactions for the user, skipping any whose ID is already
+	// stored. Returns how many were added.
+	Add(ctx context.Context, userID string, txs []txn.Transaction) (int, error)
+
+	// List returns the user's imported transactions, newest first.
+	List(ctx context.Context, userID string) ([]txn.Transaction, error)
+
+	// Purge deletes all of the user's imported transactions.
+	// Returns how many were deleted.
+	Purge(ctx context.Context, userID string) (int, error)
+}
+
+// MemoryStore is an in-memory implementation of Store.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryStore struct {
+	mu  sync.RWMutex
+	txs map[string]map[string]txn.Transaction // userID -> ID -> transaction
+}
+
+// NewMemoryStore creates an in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		txs: make(map[string]map[string]txn.Transaction),
+	}
+}
+
+func (m *MemoryStore) Add(ctx context.Context, userID string, txs []txn.Transaction) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.txs[userID]
+	if !ok {
+		user = make(map[string]txn.Transaction)
+		m.txs[userID] = user
+	}
+	added := 0
+	for _, tx := range txs {
+		if _, exists := user[tx.ID]; exists {
+			continue
+		}
+		user[tx.ID] = tx
+		added++
+	}
+	return added, nil
+}
+
+func (m *MemoryStore) List(ctx context.Context, userID string) ([]txn.Transaction, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	txs := make([]txn.Transaction, 0, len(m.txs[userID]))
+	for _, tx := range m.txs[userID] {
+		txs = append(txs, tx)
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		if txs[i].CreatedAt != txs[j].CreatedAt {
+			return txs[i].CreatedAt > txs[j].CreatedAt
+		}
+		return txs[i].ID < txs[j].ID
+	})
+	return txs, nil
+}
+
+func (m *MemoryStore) Purge(ctx context.Context, userID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := len(m.txs[userID])
+	delete(m.txs, userID)
+	return n, nil
+}
+
+// Verify MemoryStore implements Store.
+var _ Store = (*MemoryStore)(nil)
@@ -0,0 +1,257 @@
+// Package imports lets users bring in transaction history from other banks
+// as CSV, so analysis has something to work with before they have months of
+// Liminal history.
+//
+// Imported transactions live in their own per-user Store, never mixed into
+// Liminal's records. Wrap the executor with Executor so analysis tools can
+// ask for them with include_external, and tell them apart with txn.Source.
+package imports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/inventory"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// sampleRows is how many parsed rows the preview shows.
+const sampleRows = 5
+
+// maxReportedErrors caps the row errors returned by a tool.
+const maxReportedErrors = 20
+
+// Tools returns the import tools: preview_transaction_import,
+// import_transactions, and delete_imported_transactions.
+func Tools(store Store) []core.Tool {
+	return []core.Tool{
+		PreviewTool(store),
+		ImportTool(store),
+		DeleteTool(store),
+	}
+}
+
+// importInput is the input of import_transactions.
+type importInput struct {
+	CSV     string   `json:"csv"`
+	Mapping *Mapping `json:"mapping,omitempty"`
+}
+
+// parse reads the CSV and converts it with the given or detected mapping.
+func (in *importInput) parse() (*Mapping, []txn.Transaction, []RowError, error) {
+	header, rows, err := Read(in.CSV)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	m := in.Mapping
+	if m == nil {
+		if m, err = Detect(header, rows); err != nil {
+			return nil, nil, nil, err
+		}
+	} else if err := m.Validate(header); err != nil {
+		return nil, nil, nil, err
+	}
+	txs, errs := Parse(header, rows, m)
+	return m, txs, errs, nil
+}
+
+// sampleRow is a parsed row shown in the preview.
+type sampleRow struct {
+	Date        string `json:"date"`
+	Direction   string `json:"direction"`
+	Amount      string `json:"amount"`
+	Currency    string `json:"currency"`
+	Description string `json:"description"`
+}
+
+// PreviewTool returns the preview_transaction_import tool. It detects how
+// a pasted CSV's columns map to transaction fields and shows sample rows,
+// then offers the import for the user to approve.
+func PreviewTool(store Store) core.Tool {
+	return tools.New("preview_transaction_import").
+		Description("Preview importing transaction history from another bank. Takes the CSV text the user pasted, detects which columns hold the date, description and amount, and shows sample rows. The import is then offered to the user for approval. If the user says the mapping is wrong, call again with a corrected mapping.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"csv": tools.StringProperty("The CSV text, including its header row"),
+			"mapping": map[string]interface{}{
+				"type":        "object",
+				"description": "Optional column mapping overriding detection: date, description, amount (or debit and credit), currency, counterparty, date_format (a Go time layout, e.g. 01/02/2006), invert_amounts, default_currency",
+			},
+		}, "csv")).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input importInput
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+			}
+			m, txs, errs, err := input.parse()
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+			if len(txs) == 0 {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("no rows could be imported: %v", truncateErrors(errs))}, nil
+			}
+
+			existing, err := store.List(ctx, params.UserID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to read imported transactions: %v", err)}, nil
+			}
+			stored := make(map[string]bool, len(existing))
+			for _, tx := range existing {
+				stored[tx.ID] = true
+			}
+			already := 0
+			first, last := txn.CreatedAt(txs[0]), txn.CreatedAt(txs[0])
+			for _, tx := range txs {
+				if stored[tx.ID] {
+					already++
+				}
+				if at := txn.CreatedAt(tx); at.Before(first) {
+					first = at
+				} else if at.After(last) {
+					last = at
+				}
+			}
+
+			samples := make([]sampleRow, 0, sampleRows)
+			for _, tx := range txs[:min(sampleRows, len(txs))] {
+				amount := tx.Amount
+				if tx.Direction == "debit" {
+					amount = "-" + amount
+				}
+				samples = append(samples, sampleRow{
+					Date:        txn.CreatedAt(tx).Format("2006-01-02"),
+					Direction:   tx.Direction,
+					Amount:      amount,
+					Currency:    tx.Currency,
+					Description: tx.Note,
+				})
+			}
+
+			summary := fmt.Sprintf("Import %d transactions dated %s to %s from CSV", len(txs)-already, first.Format("2006-01-02"), last.Format("2006-01-02"))
+			if len(errs) > 0 || already > 0 {
+				summary += fmt.Sprintf(" (%d rows skipped, %d already imported)", len(errs), already)
+			}
+
+			approved, _ := json.Marshal(importInput{CSV: input.CSV, Mapping: m})
+			result := skippedWarning(core.NewEnvelope(map[string]interface{}{
+				"mapping":          m,
+				"sample":           samples,
+				"valid_rows":       len(txs),
+				"skipped_rows":     len(errs),
+				"errors":           truncateErrors(errs),
+				"already_imported": already,
+				"message":          "Show the user the mapping and sample rows. The import has been offered for their approval.",
+			}), errs).Result()
+			result.Propose = &core.ProposedAction{
+				Tool:    "import_transactions",
+				Input:   approved,
+				Summary: summary,
+			}
+			return result, nil
+		}).
+		Build()
+}
+
+// ImportTool returns the import_transactions tool, which imports a CSV of
+// transactions from another bank. Rows already imported are skipped, so
+// importing the same file twice is harmless. Requires confirmation.
+func ImportTool(store Store) core.Tool {
+	return tools.New("import_transactions").
+		Description("Import transaction history from another bank's CSV export. Prefer preview_transaction_import, which shows the user the column mapping first. Transactions already imported are skipped.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"csv": tools.StringProperty("The CSV text, including its header row"),
+			"mapping": map[string]interface{}{
+				"type":        "object",
+				"description": "The column mapping approved in the preview. Detected if omitted.",
+			},
+		}, "csv")).
+		RequiresConfirmation().
+		SummaryTemplate("Import transactions from CSV").
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input importInput
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+			}
+			_, txs, errs, err := input.parse()
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+			if len(txs) == 0 {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("no rows could be imported: %v", truncateErrors(errs))}, nil
+			}
+
+			added, err := store.Add(ctx, params.UserID, txs)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to save imported transactions: %v", err)}, nil
+			}
+			return skippedWarning(core.NewEnvelope(map[string]interface{}{
+				"imported":         added,
+				"already_imported": len(txs) - added,
+				"skipped_rows":     len(errs),
+				"errors":           truncateErrors(errs),
+			}), errs).Result(), nil
+		}).
+		Build()
+}
+
+// DeleteTool returns the delete_imported_transactions tool, which deletes
+// all of the user's imported transactions. Requires confirmation.
+func DeleteTool(store Store) core.Tool {
+	return tools.New("delete_imported_transactions").
+		Description("Delete all transactions the user imported from other banks. Their Liminal transactions are not affected.").
+		Schema(tools.ObjectSchema(map[string]interface{}{})).
+		RequiresConfirmation().
+		SummaryTemplate("Delete all imported transactions").
+		ReturnsEnvelope().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			n, err := store.Purge(ctx, params.UserID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to delete imported transactions: %v", err)}, nil
+			}
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{"deleted": n}}, nil
+		}).
+		Build()
+}
+
+// Describe describes the user's imported transactions for their data
+// inventory. Register it with an inventory.Registry as
+// "imported_transactions".
+func Describe(store Store) inventory.Describer {
+	return func(ctx context.Context, userID string, withItems bool) (*inventory.Category, error) {
+		txs, err := store.List(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		c := &inventory.Category{
+			Description: "Transactions you imported from other banks.",
+			Count:       len(txs),
+			DeleteWith:  "delete_imported_transactions",
+		}
+		for _, tx := range txs {
+			if at := txn.CreatedAt(tx); !at.IsZero() {
+				c.Track(at)
+			}
+		}
+		if withItems {
+			c.Items = txs
+		}
+		return c, nil
+	}
+}
+
+// skippedWarning warns about rows that could not be imported.
+func skippedWarning(env *core.Envelope, errs []RowError) *core.Envelope {
+	if len(errs) > 0 {
+		env.WithWarning(fmt.Sprintf("%d rows could not be read and were skipped", len(errs)))
+	}
+	return env
+}
+
+func truncateErrors(errs []RowError) []RowError {
+	if len(errs) > maxReportedErrors {
+		return errs[:maxReportedErrors]
+	}
+	return errs
+}
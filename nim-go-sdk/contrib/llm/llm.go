@@ -0,0 +1,89 @@
+// Package llm provides the small, single-prompt completions the contrib
+// packages use for classification, such as routing a request or
+// categorizing transaction notes.
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// DefaultModel is the model used by NewAnthropic when none is given.
+const DefaultModel = engine.DefaultModel
+
+// Completer answers a single prompt with text.
+// This is an interface - NewAnthropic provides one backed by the Anthropic
+// API; tests and other providers supply their own.
+type Completer interface {
+	// Complete returns the model's text response to prompt.
+	Complete(ctx context.Context, prompt string, maxTokens int) (string, error)
+}
+
+// CompleterFunc adapts a function to a Completer.
+type CompleterFunc func(ctx context.Context, prompt string, maxTokens int) (string, error)
+
+func (f CompleterFunc) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	return f(ctx, prompt, maxTokens)
+}
+
+// StructuredCompleter answers a prompt with a JSON object matching a JSON
+// Schema. *engine.Engine and *server.Server implement it, sharing the
+// agent's Anthropic client and model.
+type StructuredCompleter interface {
+	CompleteStructured(ctx context.Context, prompt string, schema map[string]interface{}, opts ...engine.StructuredOption) (json.RawMessage, error)
+}
+
+// Anthropic is a Completer backed by the Anthropic Messages API.
+type Anthropic struct {
+	client anthropic.Client
+	model  anthropic.Model
+}
+
+// NewAnthropic creates a completer for model using the given client options,
+// e.g. option.WithAPIKey. An empty model means DefaultModel.
+func NewAnthropic(model string, opts ...option.RequestOption) *Anthropic {
+	if model == "" {
+		model = DefaultModel
+	}
+	return &Anthropic{
+		client: anthropic.NewClient(opts...),
+		model:  anthropic.Model(model),
+	}
+}
+
+func (a *Anthropic) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	resp, err := a.client.Messages.New(ctx, anthropic.MessageNewParams{
+		Model:     a.model,
+		MaxTokens: int64(maxTokens),
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("completion failed: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("empty completion")
+	}
+	return text.String(), nil
+}
+
+// Verify Anthropic implements Completer, and Engine StructuredCompleter.
+var (
+	_ Completer           = (*Anthropic)(nil)
+	_ StructuredCompleter = (*engine.Engine)(nil)
+)
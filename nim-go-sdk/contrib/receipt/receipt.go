@@ -0,0 +1,124 @@
+// Package receipt reads receipts from images and splits them between
+// people. A Scanner extracts the merchant, line items and totals; TabScanner
+// implements it with the Tabscanner OCR API. Split divides a receipt into
+// per-person shares, equally or by who had which items, and Tools returns
+// the process_receipt_image and split_receipt tools.
+//
+// Amounts are handled in cents, so shares always add up to the total.
+package receipt
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// Errors returned by scanners.
+var (
+	// ErrNotReceipt means the image was read but no receipt was found in it.
+	ErrNotReceipt = errors.New("image does not look like a receipt")
+
+	// ErrTimeout means the receipt was still being processed when the
+	// scanner gave up waiting. Trying again later may succeed.
+	ErrTimeout = errors.New("timed out waiting for the receipt to be processed")
+)
+
+// Receipt is what was read from a receipt image. Amounts are in Currency.
+type Receipt struct {
+	Merchant string `json:"merchant,omitempty"`
+	Date     string `json:"date,omitempty"`
+
+	// Currency is an ISO 4217 code such as GBP, or empty if the receipt
+	// didn't show one.
+	Currency string `json:"currency,omitempty"`
+
+	Items    []Item  `json:"items"`
+	Subtotal float64 `json:"subtotal,omitempty"`
+	Tax      float64 `json:"tax,omitempty"`
+	Tip      float64 `json:"tip,omitempty"`
+	Total    float64 `json:"total"`
+}
+
+// Item is one line of a receipt.
+type Item struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity,omitempty"`
+	Amount      float64 `json:"amount"` // the line total
+}
+
+// Scanner reads receipts from images.
+type Scanner interface {
+	// ProcessReceipt extracts a receipt from an image. It returns
+	// ErrNotReceipt if the image isn't a receipt, and ErrTimeout if
+	// processing took too long.
+	ProcessReceipt(ctx context.Context, image []byte) (*Receipt, error)
+}
+
+// Cached remembers the receipts scanner has read, by image, so an image
+// processed by one tool or workflow step isn't sent for processing again by
+// the next. Failures are not remembered.
+func Cached(scanner Scanner) Scanner {
+	return &cached{scanner: scanner, receipts: make(map[[sha256.Size]byte]*Receipt)}
+}
+
+type cached struct {
+	scanner Scanner
+
+	mu       sync.Mutex
+	receipts map[[sha256.Size]byte]*Receipt
+}
+
+func (c *cached) ProcessReceipt(ctx context.Context, image []byte) (*Receipt, error) {
+	key := sha256.Sum256(image)
+	c.mu.Lock()
+	r, ok := c.receipts[key]
+	c.mu.Unlock()
+	if ok {
+		return r, nil
+	}
+
+	r, err := c.scanner.ProcessReceipt(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.receipts[key] = r
+	c.mu.Unlock()
+	return r, nil
+}
+
+// currencySymbols maps the symbols receipts print to currency codes.
+var currencySymbols = map[string]string{
+	"$": "USD",
+	"€": "EUR",
+	"£": "GBP",
+	"¥": "JPY",
+}
+
+// normalizeCurrency turns a currency as printed on a receipt into a code.
+func normalizeCurrency(c string) string {
+	c = strings.TrimSpace(c)
+	if code, ok := currencySymbols[c]; ok {
+		return code
+	}
+	return strings.ToUpper(c)
+}
+
+// cents converts an amount to whole cents.
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// formatCents formats cents as an amount such as "12.50", as send_money
+// expects.
+func formatCents(c int64) string {
+	sign := ""
+	if c < 0 {
+		sign, c = "-", -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
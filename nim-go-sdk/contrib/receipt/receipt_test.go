@@ -0,0 +1,334 @@
+package receipt
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// fakeTabScanner serves a token for uploads, then the given results in
+// turn, repeating the last.
+func fakeTabScanner(t *testing.T, results ...string) *httptest.Server {
+	t.Helper()
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("apikey") != "test-key" {
+			t.Errorf("apikey = %q", r.Header.Get("apikey"))
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/2/process":
+			if _, _, err := r.FormFile("file"); err != nil {
+				t.Errorf("upload has no file: %v", err)
+			}
+			fmt.Fprint(w, `{"token":"tok-1","status_code":1,"success":true}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/result/tok-1":
+			i := int(atomic.AddInt32(&polls, 1)) - 1
+			if i >= len(results) {
+				i = len(results) - 1
+			}
+			fmt.Fprint(w, results[i])
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestScanner(url string, timeout time.Duration) *TabScanner {
+	return NewTabScanner(TabScannerConfig{
+		APIKey:       "test-key",
+		BaseURL:      url,
+		Timeout:      timeout,
+		FirstPoll:    time.Millisecond,
+		PollInterval: time.Millisecond,
+	})
+}
+
+const (
+	processing = `{"status":"pending","status_code":2,"success":true}`
+	done       = `{"status":"done","status_code":3,"success":true,"result":{
+		"establishment":"The Crown","date":"2026-10-01","currency":"£",
+		"total":"33.00","subTotal":30,"tax":"3.00",
+		"lineItems":[{"descClean":"Burger","qty":1,"lineTotal":"12.00"},{"desc":"Fish & chips","qty":"1","lineTotal":18}]}}`
+)
+
+func TestTabScanner(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []string
+		timeout time.Duration
+		wantErr error
+	}{
+		{name: "done after processing", results: []string{processing, processing, done}, timeout: time.Second},
+		{name: "empty result", results: []string{`{"status":"done","status_code":3,"success":true,"result":{"lineItems":[]}}`}, timeout: time.Second, wantErr: ErrNotReceipt},
+		{name: "failed", results: []string{`{"status":"failed","status_code":3,"success":false,"code":500}`}, timeout: time.Second, wantErr: ErrNotReceipt},
+		{name: "still processing", results: []string{processing}, timeout: 50 * time.Millisecond, wantErr: ErrTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fakeTabScanner(t, tt.results...)
+			r, err := newTestScanner(srv.URL, tt.timeout).ProcessReceipt(context.Background(), []byte("image"))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Merchant != "The Crown" || r.Currency != "GBP" || r.Total != 33 || r.Tax != 3 || r.Subtotal != 30 {
+				t.Errorf("receipt = %+v", r)
+			}
+			if len(r.Items) != 2 || r.Items[0].Description != "Burger" || r.Items[1].Description != "Fish & chips" || r.Items[1].Amount != 18 {
+				t.Errorf("items = %+v", r.Items)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	dinner := &Receipt{
+		Currency: "GBP",
+		Items: []Item{
+			{Description: "Burger", Amount: 12},
+			{Description: "Fish & chips", Amount: 18},
+		},
+		Tax:   3,
+		Total: 33,
+	}
+	tests := []struct {
+		name         string
+		receipt      *Receipt
+		req          SplitRequest
+		wantShares   map[string]string
+		wantPayments []Payment
+		wantOwed     []Payment
+		wantErr      string
+	}{
+		{
+			name:       "equal split with leftover cents",
+			receipt:    &Receipt{Currency: "USD", Total: 10},
+			req:        SplitRequest{Participants: []string{"alice", "@Bob"}},
+			wantShares: map[string]string{Me: "3.34", "@alice": "3.33", "@bob": "3.33"},
+			wantOwed: []Payment{
+				{From: "@alice", To: Me, Amount: "3.33", Currency: "USD"},
+				{From: "@bob", To: Me, Amount: "3.33", Currency: "USD"},
+			},
+		},
+		{
+			name:    "assigned items share tax in proportion",
+			receipt: dinner,
+			req: SplitRequest{
+				Participants: []string{"@alice"},
+				Assignments:  map[int][]string{0: {Me}, 1: {"alice"}},
+				PaidBy:       "@alice",
+			},
+			wantShares:   map[string]string{Me: "13.20", "@alice": "19.80"},
+			wantPayments: []Payment{{From: Me, To: "@alice", Amount: "13.20", Currency: "GBP"}},
+		},
+		{
+			name:       "excluding the user",
+			receipt:    dinner,
+			req:        SplitRequest{Participants: []string{"@alice", "@bob"}, ExcludeMe: true},
+			wantShares: map[string]string{"@alice": "16.50", "@bob": "16.50"},
+			wantOwed: []Payment{
+				{From: "@alice", To: Me, Amount: "16.50", Currency: "GBP"},
+				{From: "@bob", To: Me, Amount: "16.50", Currency: "GBP"},
+			},
+		},
+		{name: "no one else", receipt: dinner, req: SplitRequest{}, wantErr: "besides the user"},
+		{name: "unknown assignee", receipt: dinner, req: SplitRequest{Participants: []string{"@alice"}, Assignments: map[int][]string{0: {"@carol"}}}, wantErr: "isn't a participant"},
+		{name: "item out of range", receipt: dinner, req: SplitRequest{Participants: []string{"@alice"}, Assignments: map[int][]string{2: {"@alice"}}}, wantErr: "not on the receipt"},
+		{name: "no total", receipt: &Receipt{}, req: SplitRequest{Participants: []string{"@alice"}}, wantErr: "no total"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := Split(tt.receipt, tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			shares := make(map[string]string, len(plan.Shares))
+			for _, s := range plan.Shares {
+				shares[s.Participant] = s.Amount
+			}
+			if fmt.Sprint(shares) != fmt.Sprint(tt.wantShares) {
+				t.Errorf("shares = %v, want %v", shares, tt.wantShares)
+			}
+			if fmt.Sprint(plan.Payments) != fmt.Sprint(tt.wantPayments) {
+				t.Errorf("payments = %v, want %v", plan.Payments, tt.wantPayments)
+			}
+			if fmt.Sprint(plan.OwedToUser) != fmt.Sprint(tt.wantOwed) {
+				t.Errorf("owed to user = %v, want %v", plan.OwedToUser, tt.wantOwed)
+			}
+		})
+	}
+}
+
+// fakeScanner returns its receipt and counts calls.
+type fakeScanner struct {
+	receipt *Receipt
+	err     error
+	calls   int
+}
+
+func (f *fakeScanner) ProcessReceipt(ctx context.Context, image []byte) (*Receipt, error) {
+	f.calls++
+	return f.receipt, f.err
+}
+
+func TestCached(t *testing.T) {
+	scanner := &fakeScanner{receipt: &Receipt{Total: 10}}
+	c := Cached(scanner)
+	for _, image := range []string{"a", "a", "b"} {
+		if _, err := c.ProcessReceipt(context.Background(), []byte(image)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if scanner.calls != 2 {
+		t.Errorf("scanner called %d times, want 2", scanner.calls)
+	}
+
+	failing := &fakeScanner{err: ErrTimeout}
+	c = Cached(failing)
+	c.ProcessReceipt(context.Background(), []byte("a"))
+	c.ProcessReceipt(context.Background(), []byte("a"))
+	if failing.calls != 2 {
+		t.Errorf("failures were cached: scanner called %d times, want 2", failing.calls)
+	}
+}
+
+func TestSplitTool(t *testing.T) {
+	image := base64.StdEncoding.EncodeToString([]byte("image"))
+	tests := []struct {
+		name        string
+		scanner     *fakeScanner
+		input       string
+		wantErr     string
+		wantWarning bool
+	}{
+		{
+			name:        "receipt in another currency",
+			scanner:     &fakeScanner{receipt: &Receipt{Currency: "GBP", Total: 20}},
+			input:       `{"image_base64":"data:image/jpeg;base64,` + image + `","participants":["@alice"],"paid_by":"@alice"}`,
+			wantWarning: true,
+		},
+		{
+			name:    "uploaded image",
+			scanner: &fakeScanner{receipt: &Receipt{Currency: "USD", Total: 20}},
+			input:   `{"image_id":"latest","participants":["@alice"],"paid_by":"@alice"}`,
+		},
+		{
+			name:    "two images",
+			scanner: &fakeScanner{receipt: &Receipt{Currency: "USD", Total: 20}},
+			input:   `{"image_id":"latest","image_base64":"` + image + `","participants":["@alice"]}`,
+			wantErr: "exactly one",
+		},
+		{
+			name:    "not a receipt",
+			scanner: &fakeScanner{err: ErrNotReceipt},
+			input:   `{"image_id":"latest","participants":["@alice"]}`,
+			wantErr: "doesn't look like a receipt",
+		},
+		{
+			name:    "http image",
+			scanner: &fakeScanner{receipt: &Receipt{Currency: "USD", Total: 20}},
+			input:   `{"image_url":"http://example.com/r.jpg","participants":["@alice"]}`,
+			wantErr: "https",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := SplitTool(Deps{
+				Scanner: tt.scanner,
+				Exec: &txntest.Executor{Responses: map[string]interface{}{
+					"get_balance": executor.GetBalanceResponse{Balances: []executor.WalletBalance{{Currency: "USDC", Amount: "100"}}},
+				}},
+				Images: func(ctx context.Context, userID, id string) ([]byte, bool) {
+					return []byte("image"), userID == "user-1" && id == "latest"
+				},
+			})
+			result, err := tool.Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(tt.input)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" {
+				if result.Success || !strings.Contains(result.Error, tt.wantErr) {
+					t.Fatalf("result = %+v, want error %q", result, tt.wantErr)
+				}
+				return
+			}
+			if !result.Success {
+				t.Fatalf("split_receipt failed: %s", result.Error)
+			}
+			data := result.Data.(map[string]interface{})
+			plan := data["plan"].(*Plan)
+			if len(plan.Payments) != 1 || plan.Payments[0].Amount != "10.00" || plan.Payments[0].To != "@alice" {
+				t.Errorf("payments = %+v", plan.Payments)
+			}
+			if _, warned := data["currency_warning"]; warned != tt.wantWarning {
+				t.Errorf("currency_warning = %v, want %v", data["currency_warning"], tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestFetchRefusesInternalHosts(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "https://metadata.internal/latest", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("image"))
+	}))
+	defer srv.Close()
+	port := srv.URL[strings.LastIndex(srv.URL, ":")+1:]
+
+	tests := []struct {
+		name    string
+		deps    Deps
+		url     string
+		wantErr string
+	}{
+		{name: "loopback by default", url: srv.URL + "/r.jpg", wantErr: "can't be fetched"},
+		{name: "localhost by default", url: "https://localhost:" + port + "/r.jpg", wantErr: "can't be fetched"},
+		{name: "allowed host", deps: Deps{ImageHosts: []string{"127.0.0.1"}, Client: srv.Client()}, url: srv.URL + "/r.jpg"},
+		{name: "host not allowed", deps: Deps{ImageHosts: []string{"images.example"}, Client: srv.Client()}, url: srv.URL + "/r.jpg", wantErr: "can't be fetched"},
+		{name: "redirect to other host", deps: Deps{ImageHosts: []string{"127.0.0.1"}, Client: srv.Client()}, url: srv.URL + "/redirect", wantErr: "can't be fetched from metadata.internal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image, err := tt.deps.fetch(context.Background(), tt.url)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(image) != "image" {
+				t.Fatalf("fetch() = %q, %v", image, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package receipt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Me stands for the user in a split: as a participant, in an assignment,
+// or as the payer.
+const Me = "me"
+
+// SplitRequest describes how to split a receipt.
+type SplitRequest struct {
+	// Participants are the people sharing the bill besides the user, by
+	// display tag, e.g. "@alice".
+	Participants []string
+
+	// ExcludeMe leaves the user out of the split, e.g. when they paid for
+	// friends' meal without eating.
+	ExcludeMe bool
+
+	// Assignments say who had which items, by index into Receipt.Items.
+	// An item is split equally between the people assigned it; unassigned
+	// items are split between everyone.
+	Assignments map[int][]string
+
+	// PaidBy is who paid the bill. Empty, or Me, means the user did.
+	PaidBy string
+}
+
+// Plan is a receipt split into shares, with the payments that settle it.
+// Amounts are in Currency, formatted for send_money, e.g. "12.50".
+type Plan struct {
+	Currency string  `json:"currency,omitempty"`
+	Total    string  `json:"total"`
+	Shares   []Share `json:"shares"`
+
+	// PaidBy is who paid the bill; Me if the user did.
+	PaidBy string `json:"paid_by"`
+
+	// Payments are what the user owes the payer: send_money calls to make
+	// once the user confirms. Empty when the user paid.
+	Payments []Payment `json:"payments,omitempty"`
+
+	// OwedToUser is what everyone else owes the user, when the user paid.
+	OwedToUser []Payment `json:"owed_to_user,omitempty"`
+}
+
+// Share is one person's part of the bill.
+type Share struct {
+	Participant string   `json:"participant"`
+	Amount      string   `json:"amount"`
+	Items       []string `json:"items,omitempty"` // descriptions of the items assigned to them alone or with others
+}
+
+// Payment is money owed from one person to another.
+type Payment struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Amount   string `json:"amount"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// Split divides a receipt between the user and req.Participants. Each item
+// is shared by the people assigned it, or by everyone, and the rest of the
+// total (tax, tip, and anything the items don't account for) is shared in
+// proportion to what each person had. Shares add up to the total exactly;
+// leftover cents go to the first people listed.
+func Split(r *Receipt, req SplitRequest) (*Plan, error) {
+	people := participants(req)
+	if len(people) == 0 {
+		return nil, fmt.Errorf("at least one participant is required")
+	}
+	if len(people) == 1 && people[0] == Me {
+		return nil, fmt.Errorf("at least one participant besides the user is required")
+	}
+	index := make(map[string]int, len(people))
+	for i, p := range people {
+		index[p] = i
+	}
+
+	for item, assigned := range req.Assignments {
+		if item < 0 || item >= len(r.Items) {
+			return nil, fmt.Errorf("item %d is not on the receipt, which has %d items", item, len(r.Items))
+		}
+		for _, p := range assigned {
+			if _, ok := index[normalizeTag(p)]; !ok {
+				return nil, fmt.Errorf("item %d is assigned to %s, who isn't a participant", item, p)
+			}
+		}
+	}
+
+	// Split the items.
+	had := make([]int64, len(people))
+	items := make([][]string, len(people))
+	var itemsTotal int64
+	for i, item := range r.Items {
+		amount := cents(item.Amount)
+		itemsTotal += amount
+
+		sharers := everyone(len(people))
+		if assigned := req.Assignments[i]; len(assigned) > 0 {
+			sharers = sharers[:0]
+			for _, p := range dedupe(assigned) {
+				sharers = append(sharers, index[p])
+			}
+		}
+		for j, part := range divide(amount, weights(len(sharers))) {
+			had[sharers[j]] += part
+			if len(req.Assignments[i]) > 0 {
+				items[sharers[j]] = append(items[sharers[j]], item.Description)
+			}
+		}
+	}
+
+	// Share the rest in proportion to the items each person had.
+	total := cents(r.Total)
+	if total == 0 {
+		total = itemsTotal + cents(r.Tax) + cents(r.Tip)
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("the receipt has no total to split")
+	}
+	rest := divide(total-itemsTotal, had)
+	shares := make([]int64, len(people))
+	for i := range people {
+		shares[i] = had[i] + rest[i]
+	}
+
+	payer := normalizeTag(req.PaidBy)
+	if payer == "" {
+		payer = Me
+	}
+	plan := &Plan{Currency: r.Currency, Total: formatCents(total), PaidBy: payer}
+	for i, p := range people {
+		plan.Shares = append(plan.Shares, Share{Participant: p, Amount: formatCents(shares[i]), Items: items[i]})
+		switch {
+		case p == payer || shares[i] == 0:
+		case payer == Me:
+			plan.OwedToUser = append(plan.OwedToUser, Payment{From: p, To: Me, Amount: formatCents(shares[i]), Currency: r.Currency})
+		case p == Me:
+			plan.Payments = append(plan.Payments, Payment{From: Me, To: payer, Amount: formatCents(shares[i]), Currency: r.Currency})
+		}
+	}
+	return plan, nil
+}
+
+// participants lists everyone in the split once, the user first.
+func participants(req SplitRequest) []string {
+	var people []string
+	if !req.ExcludeMe {
+		people = append(people, Me)
+	}
+	return dedupe(append(people, req.Participants...))
+}
+
+// dedupe normalizes tags and drops repeats and blanks.
+func dedupe(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var out []string
+	for _, t := range tags {
+		t = normalizeTag(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// normalizeTag lowercases a display tag and adds its @, leaving Me alone.
+func normalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || tag == Me || strings.HasPrefix(tag, "@") {
+		return tag
+	}
+	return "@" + tag
+}
+
+func everyone(n int) []int {
+	all := make([]int, n)
+	for i := range all {
+		all[i] = i
+	}
+	return all
+}
+
+func weights(n int) []int64 {
+	w := make([]int64, n)
+	for i := range w {
+		w[i] = 1
+	}
+	return w
+}
+
+// divide splits amount in proportion to weights, equally if they are all
+// zero, with parts adding up to amount exactly: leftover cents go to the
+// parts that lost most to rounding, the earliest first.
+func divide(amount int64, weights []int64) []int64 {
+	var sum int64
+	for _, w := range weights {
+		sum += w
+	}
+	if sum == 0 {
+		weights, sum = make([]int64, len(weights)), int64(len(weights))
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+
+	parts := make([]int64, len(weights))
+	remainders := make([]int64, len(weights))
+	negative := amount < 0
+	if negative {
+		amount = -amount
+	}
+	var given int64
+	for i, w := range weights {
+		parts[i] = amount * w / sum
+		remainders[i] = amount * w % sum
+		given += parts[i]
+	}
+	for left := amount - given; left > 0; left-- {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		parts[best]++
+		remainders[best] = -1
+	}
+	if negative {
+		for i := range parts {
+			parts[i] = -parts[i]
+		}
+	}
+	return parts
+}
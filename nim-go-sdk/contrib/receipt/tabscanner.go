@@ -0,0 +1,270 @@
+package receipt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTabScannerURL is the Tabscanner API.
+const DefaultTabScannerURL = "https://api.tabscanner.com"
+
+// Tabscanner result status codes.
+const (
+	tabScannerProcessing = 2
+	tabScannerDone       = 3
+)
+
+// TabScannerConfig configures a TabScanner.
+type TabScannerConfig struct {
+	// APIKey authenticates with Tabscanner. Required.
+	APIKey string
+
+	// BaseURL is the API to call. Defaults to DefaultTabScannerURL.
+	BaseURL string
+
+	// Region hints at the receipt's country, e.g. "gb" or "us", which
+	// helps parse dates and amounts. Defaults to "gb".
+	Region string
+
+	// Timeout bounds processing a receipt, polling included. Defaults to
+	// 60s.
+	Timeout time.Duration
+
+	// FirstPoll is how long after uploading the result is first asked for;
+	// Tabscanner takes a few seconds. Defaults to 5s.
+	FirstPoll time.Duration
+
+	// PollInterval is the wait between asking for the result again.
+	// Defaults to 1s.
+	PollInterval time.Duration
+
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// TabScanner reads receipts with the Tabscanner OCR API: the image is
+// uploaded, then the result is polled for until it is ready.
+type TabScanner struct {
+	cfg TabScannerConfig
+}
+
+// NewTabScanner returns a Tabscanner client.
+func NewTabScanner(cfg TabScannerConfig) *TabScanner {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultTabScannerURL
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.Region == "" {
+		cfg.Region = "gb"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 60 * time.Second
+	}
+	if cfg.FirstPoll <= 0 {
+		cfg.FirstPoll = 5 * time.Second
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &TabScanner{cfg: cfg}
+}
+
+// tabScannerResponse is the envelope of every Tabscanner response.
+type tabScannerResponse struct {
+	Token      string          `json:"token"`
+	Status     string          `json:"status"`
+	StatusCode int             `json:"status_code"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Code       int             `json:"code"`
+	Result     *tabScannerData `json:"result"`
+}
+
+type tabScannerData struct {
+	Establishment string           `json:"establishment"`
+	Date          string           `json:"date"`
+	Currency      string           `json:"currency"`
+	Total         number           `json:"total"`
+	SubTotal      number           `json:"subTotal"`
+	Tax           number           `json:"tax"`
+	Tip           number           `json:"tip"`
+	LineItems     []tabScannerLine `json:"lineItems"`
+}
+
+type tabScannerLine struct {
+	Desc      string `json:"desc"`
+	DescClean string `json:"descClean"`
+	Qty       number `json:"qty"`
+	LineTotal number `json:"lineTotal"`
+}
+
+// number decodes an amount sent either as a JSON number or a string.
+type number float64
+
+func (n *number) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %s", data)
+	}
+	*n = number(f)
+	return nil
+}
+
+// ProcessReceipt uploads the image and waits for Tabscanner to read it.
+func (t *TabScanner) ProcessReceipt(ctx context.Context, image []byte) (*Receipt, error) {
+	if t.cfg.APIKey == "" {
+		return nil, errors.New("tabscanner API key is not configured")
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.cfg.Timeout)
+	defer cancel()
+
+	token, err := t.upload(ctx, image)
+	if err != nil {
+		return nil, t.timedOut(ctx, err)
+	}
+
+	wait := t.cfg.FirstPoll
+	for {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, t.timedOut(ctx, ctx.Err())
+		}
+		wait = t.cfg.PollInterval
+
+		resp, err := t.result(ctx, token)
+		if err != nil {
+			return nil, t.timedOut(ctx, err)
+		}
+		switch {
+		case resp.StatusCode == tabScannerProcessing:
+			continue
+		case resp.StatusCode == tabScannerDone && resp.Status == "done":
+			return toReceipt(resp.Result)
+		case resp.Status == "failed":
+			return nil, ErrNotReceipt
+		}
+		return nil, fmt.Errorf("tabscanner error (status_code=%d code=%d): %s", resp.StatusCode, resp.Code, resp.Message)
+	}
+}
+
+// timedOut reports err as ErrTimeout if it was caused by the processing
+// timeout rather than the caller.
+func (t *TabScanner) timedOut(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s", ErrTimeout, t.cfg.Timeout)
+	}
+	return err
+}
+
+// upload sends the image for processing and returns the result's token.
+func (t *TabScanner) upload(ctx context.Context, image []byte) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "receipt.jpeg")
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(image); err != nil {
+		return "", err
+	}
+	writer.WriteField("documentType", "receipt")
+	writer.WriteField("region", t.cfg.Region)
+	writer.WriteField("defaultDateParsing", "d/m")
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	resp, err := t.do(ctx, http.MethodPost, "/api/2/process", &body, writer.FormDataContentType())
+	if err != nil {
+		return "", fmt.Errorf("failed to upload receipt: %w", err)
+	}
+	if resp.Token == "" {
+		return "", fmt.Errorf("tabscanner error (status_code=%d code=%d): %s", resp.StatusCode, resp.Code, resp.Message)
+	}
+	return resp.Token, nil
+}
+
+// result asks for the result of processing token.
+func (t *TabScanner) result(ctx context.Context, token string) (*tabScannerResponse, error) {
+	resp, err := t.do(ctx, http.MethodGet, "/api/result/"+token, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt result: %w", err)
+	}
+	return resp, nil
+}
+
+func (t *TabScanner) do(ctx context.Context, method, path string, body io.Reader, contentType string) (*tabScannerResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.cfg.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("apikey", t.cfg.APIKey)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := t.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var out tabScannerResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("unexpected response (http=%d): %s", resp.StatusCode, data)
+	}
+	return &out, nil
+}
+
+// toReceipt converts a Tabscanner result, rejecting results with neither
+// a total nor any line items.
+func toReceipt(data *tabScannerData) (*Receipt, error) {
+	if data == nil || (data.Total == 0 && len(data.LineItems) == 0) {
+		return nil, ErrNotReceipt
+	}
+	r := &Receipt{
+		Merchant: data.Establishment,
+		Date:     data.Date,
+		Currency: normalizeCurrency(data.Currency),
+		Subtotal: float64(data.SubTotal),
+		Tax:      float64(data.Tax),
+		Tip:      float64(data.Tip),
+		Total:    float64(data.Total),
+	}
+	for _, line := range data.LineItems {
+		desc := line.DescClean
+		if desc == "" {
+			desc = line.Desc
+		}
+		if line.LineTotal == 0 && desc == "" {
+			continue
+		}
+		r.Items = append(r.Items, Item{Description: desc, Quantity: float64(line.Qty), Amount: float64(line.LineTotal)})
+	}
+	return r, nil
+}
+
+// Verify TabScanner implements Scanner.
+var _ Scanner = (*TabScanner)(nil)
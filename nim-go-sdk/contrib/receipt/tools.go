@@ -0,0 +1,391 @@
+package receipt
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// MaxImageSize bounds the receipt images the tools accept, in bytes.
+const MaxImageSize = 10 << 20
+
+// Images looks up an image the user uploaded. ID "latest" is their most
+// recent upload.
+type Images func(ctx context.Context, userID, id string) ([]byte, bool)
+
+// Deps are what the receipt tools need.
+type Deps struct {
+	// Scanner reads the receipts. Wrap it with Cached so the same image
+	// isn't processed twice. Required.
+	Scanner Scanner
+
+	// Exec reads the user's wallet, to warn when a receipt is in a currency
+	// they don't hold. Nil skips the check.
+	Exec core.ToolExecutor
+
+	// Images resolves image_id inputs. Nil only accepts image_url and
+	// image_base64.
+	Images Images
+
+	// ImageHosts, if set, are the only hosts image_url may point at,
+	// including after redirects. Otherwise any public HTTPS URL is fetched.
+	ImageHosts []string
+
+	// Client fetches image_url. Defaults to a client with a 30s timeout
+	// that refuses to connect to loopback, private or link-local addresses.
+	// A custom client gets no such protection, so set ImageHosts with it.
+	Client *http.Client
+}
+
+// Tools returns the receipt tools: process_receipt_image and split_receipt.
+func Tools(d Deps) []core.Tool {
+	return []core.Tool{ProcessTool(d), SplitTool(d)}
+}
+
+// imageInput is how the tools are given an image.
+type imageInput struct {
+	ImageID     string `json:"image_id"`
+	ImageURL    string `json:"image_url"`
+	ImageBase64 string `json:"image_base64"`
+}
+
+func imageProperties(properties map[string]interface{}) map[string]interface{} {
+	properties["image_id"] = tools.StringProperty("ID of an image the user uploaded; 'latest' for their most recent upload")
+	properties["image_url"] = tools.StringProperty("HTTPS URL of the receipt image")
+	properties["image_base64"] = tools.StringProperty("The receipt image, base64-encoded, optionally as a data: URL")
+	return properties
+}
+
+// ProcessTool returns the process_receipt_image tool, which reads a
+// receipt's merchant, items and totals.
+func ProcessTool(d Deps) core.Tool {
+	return tools.New("process_receipt_image").
+		Description("Read a receipt image: merchant, date, currency, numbered line items, tax, tip and total. Give exactly one of image_id, image_url or image_base64; use image_id 'latest' for the receipt the user just uploaded. To split the bill, use split_receipt.").
+		Schema(tools.ObjectSchema(imageProperties(map[string]interface{}{}))).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input imageInput
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+			}
+			r, err := d.read(ctx, params, input)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			data := map[string]interface{}{"receipt": numbered(r)}
+			if warning := d.currencyWarning(ctx, params, r); warning != "" {
+				data["currency_warning"] = warning
+			}
+			return &core.ToolResult{Success: true, Data: data}, nil
+		}).
+		Build()
+}
+
+// splitInput is the input for split_receipt.
+type splitInput struct {
+	imageInput
+	Participants []string `json:"participants"`
+	ExcludeMe    bool     `json:"exclude_me"`
+	PaidBy       string   `json:"paid_by"`
+	Assignments  []struct {
+		Item         int      `json:"item"`
+		Participants []string `json:"participants"`
+	} `json:"assignments"`
+}
+
+// SplitTool returns the split_receipt tool, which reads a receipt and
+// works out what everyone owes, as a plan the agent settles with
+// send_money.
+func SplitTool(d Deps) core.Tool {
+	return tools.New("split_receipt").
+		Description("Split a receipt between the user and other people. Shares are equal unless items are assigned to specific people; tax and tip are shared in proportion. Returns each person's share and the payments that settle the bill: if someone else paid, 'payments' lists what the user owes them, to send with send_money after the user confirms; if the user paid, 'owed_to_user' lists what others owe. Give exactly one of image_id, image_url or image_base64. Use 'me' for the user.").
+		Schema(tools.ObjectSchema(imageProperties(map[string]interface{}{
+			"participants": tools.ArrayProperty("Display tags of the people sharing the bill besides the user, e.g. @alice", tools.StringProperty("Display tag")),
+			"exclude_me":   tools.BooleanProperty("Leave the user out of the split, e.g. if they paid but didn't eat (default: false)"),
+			"paid_by":      tools.StringProperty("Display tag of who paid the bill, or 'me' (default: me)"),
+			"assignments": tools.ArrayProperty("Items that belong to specific people, by item number from process_receipt_image; other items are shared by everyone", tools.ObjectSchema(map[string]interface{}{
+				"item":         tools.IntegerProperty("Item number, counting from 0"),
+				"participants": tools.ArrayProperty("Who had the item: display tags, or 'me'", tools.StringProperty("Display tag")),
+			}, "item", "participants")),
+		}), "participants")).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input splitInput
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+			}
+			r, err := d.read(ctx, params, input.imageInput)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			req := SplitRequest{Participants: input.Participants, ExcludeMe: input.ExcludeMe, PaidBy: input.PaidBy}
+			if len(input.Assignments) > 0 {
+				req.Assignments = make(map[int][]string, len(input.Assignments))
+				for _, a := range input.Assignments {
+					req.Assignments[a.Item] = append(req.Assignments[a.Item], a.Participants...)
+				}
+			}
+			plan, err := Split(r, req)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			data := map[string]interface{}{
+				"receipt": numbered(r),
+				"plan":    plan,
+				"message": planMessage(plan),
+			}
+			if warning := d.currencyWarning(ctx, params, r); warning != "" {
+				data["currency_warning"] = warning
+			}
+			return &core.ToolResult{Success: true, Data: data}, nil
+		}).
+		Build()
+}
+
+// read loads the image and reads the receipt on it, explaining failures in
+// terms the agent can pass on.
+func (d Deps) read(ctx context.Context, params *core.ToolParams, input imageInput) (*Receipt, error) {
+	if d.Scanner == nil {
+		return nil, errors.New("receipt processing is not configured")
+	}
+	image, err := d.image(ctx, params.UserID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	params.ReportProgress("reading receipt", 0)
+	r, err := d.Scanner.ProcessReceipt(ctx, image)
+	switch {
+	case errors.Is(err, ErrNotReceipt):
+		return nil, errors.New("that image doesn't look like a receipt; ask the user for a clear photo of the whole receipt")
+	case errors.Is(err, ErrTimeout):
+		return nil, errors.New("the receipt is taking too long to read; ask the user to try again in a moment")
+	case err != nil:
+		return nil, fmt.Errorf("failed to read receipt: %v", err)
+	}
+	return r, nil
+}
+
+// image loads the image given by exactly one of input's fields.
+func (d Deps) image(ctx context.Context, userID string, input imageInput) ([]byte, error) {
+	given := 0
+	for _, s := range []string{input.ImageID, input.ImageURL, input.ImageBase64} {
+		if s != "" {
+			given++
+		}
+	}
+	if given != 1 {
+		return nil, errors.New("give exactly one of image_id, image_url or image_base64")
+	}
+
+	switch {
+	case input.ImageID != "":
+		if d.Images == nil {
+			return nil, errors.New("image uploads are not supported; give image_url or image_base64")
+		}
+		image, ok := d.Images(ctx, userID, input.ImageID)
+		if !ok {
+			if input.ImageID == "latest" {
+				return nil, errors.New("no receipt image uploaded yet; ask the user to upload one")
+			}
+			return nil, fmt.Errorf("image %s not found", input.ImageID)
+		}
+		return image, nil
+
+	case input.ImageURL != "":
+		return d.fetch(ctx, input.ImageURL)
+	}
+
+	encoded := input.ImageBase64
+	if strings.HasPrefix(encoded, "data:") {
+		if i := strings.Index(encoded, ","); i >= 0 {
+			encoded = encoded[i+1:]
+		}
+	}
+	image, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errors.New("image_base64 is not valid base64")
+	}
+	if len(image) > MaxImageSize {
+		return nil, fmt.Errorf("the image is larger than %d MB", MaxImageSize>>20)
+	}
+	return image, nil
+}
+
+// fetch downloads an image from an allowed HTTPS URL.
+func (d Deps) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.New("image_url must be an https URL")
+	}
+	if err := d.checkURL(u); err != nil {
+		return nil, err
+	}
+
+	client := d.client()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch image: %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "image/") && !strings.HasPrefix(ct, "application/octet-stream") {
+		return nil, fmt.Errorf("image_url is not an image (%s)", ct)
+	}
+	image, err := io.ReadAll(io.LimitReader(resp.Body, MaxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image: %v", err)
+	}
+	if len(image) > MaxImageSize {
+		return nil, fmt.Errorf("the image is larger than %d MB", MaxImageSize>>20)
+	}
+	return image, nil
+}
+
+// checkURL reports whether image_url, or a redirect it leads to, may be fetched.
+func (d Deps) checkURL(u *url.URL) error {
+	if u.Scheme != "https" || u.Host == "" {
+		return errors.New("image_url must be an https URL")
+	}
+	if len(d.ImageHosts) > 0 && !contains(d.ImageHosts, u.Hostname()) {
+		return fmt.Errorf("images can't be fetched from %s", u.Hostname())
+	}
+	return nil
+}
+
+// client returns the client that fetches image_url, checking every redirect
+// against the same rules as the URL the model gave.
+func (d Deps) client() *http.Client {
+	var client http.Client
+	if d.Client != nil {
+		client = *d.Client
+	} else {
+		dialer := &net.Dialer{Timeout: 10 * time.Second, Control: publicOnly}
+		client = http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 10 * time.Second},
+		}
+	}
+	next := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		if err := d.checkURL(req.URL); err != nil {
+			return err
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		return nil
+	}
+	return &client
+}
+
+// publicOnly refuses connections to addresses inside the server's own
+// network, so image_url can't be used to reach internal services.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("images can't be fetched from %s", host)
+	}
+	return nil
+}
+
+// currencyWarning explains when the receipt's currency can't be paid from
+// the user's wallet as it stands.
+func (d Deps) currencyWarning(ctx context.Context, params *core.ToolParams, r *Receipt) string {
+	if r.Currency == "" {
+		return "The receipt doesn't show a currency. Ask the user which currency to use before sending any money."
+	}
+	if d.Exec == nil {
+		return ""
+	}
+	balances, err := txn.Balances(ctx, d.Exec, params.UserID, params.RequestID)
+	if err != nil || len(balances) == 0 {
+		return ""
+	}
+	var held []string
+	for currency := range balances {
+		if currency == r.Currency || currency == r.Currency+"C" {
+			return ""
+		}
+		held = append(held, currency)
+	}
+	sort.Strings(held)
+	return fmt.Sprintf("The receipt is in %s, but the user's wallet holds %s. Amounts are in %s; ask the user which currency to pay in and agree the converted amounts before sending any money.",
+		r.Currency, strings.Join(held, ", "), r.Currency)
+}
+
+// numberedItem is an Item with its number, for assigning it in
+// split_receipt.
+type numberedItem struct {
+	Number int `json:"number"`
+	Item
+}
+
+// numbered presents a receipt with its items numbered.
+func numbered(r *Receipt) map[string]interface{} {
+	items := make([]numberedItem, len(r.Items))
+	for i, item := range r.Items {
+		items[i] = numberedItem{Number: i, Item: item}
+	}
+	return map[string]interface{}{
+		"merchant": r.Merchant,
+		"date":     r.Date,
+		"currency": r.Currency,
+		"items":    items,
+		"subtotal": r.Subtotal,
+		"tax":      r.Tax,
+		"tip":      r.Tip,
+		"total":    r.Total,
+	}
+}
+
+// planMessage summarizes a plan for the agent.
+func planMessage(p *Plan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Split %s %s between %d people.", p.Total, p.Currency, len(p.Shares))
+	for _, pay := range p.Payments {
+		fmt.Fprintf(&b, " The user owes %s %s %s: confirm with the user, then call send_money with recipient %s, amount %q, currency %q.", pay.To, pay.Amount, pay.Currency, pay.To, pay.Amount, pay.Currency)
+	}
+	for _, owed := range p.OwedToUser {
+		fmt.Fprintf(&b, " %s owes the user %s %s.", owed.From, owed.Amount, owed.Currency)
+	}
+	return b.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
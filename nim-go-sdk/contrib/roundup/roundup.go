@@ -0,0 +1,348 @@
+// Package roundup implements a "save the spare change" rule: each debit is
+// rounded up to the next increment, and the round-ups are swept into
+// savings each period, either proposed as a confirmation or, if the user
+// pre-authorized it, deposited automatically up to a per-sweep maximum.
+//
+// Calculation is decimal throughout, and each transaction is recorded when
+// it is counted toward a sweep, so overlapping periods never count it twice.
+// Tools returns the tools for managing the rule; a Sweeper runs the sweeps.
+package roundup
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/analysis"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/schedule"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// Frequencies lists the supported sweep frequencies, in schema order.
+var Frequencies = []string{schedule.CadenceWeekly, schedule.CadenceBiweekly, schedule.CadenceMonthly}
+
+// Rule is a user's round-up savings rule.
+type Rule struct {
+	UserID  string `json:"user_id"`
+	Enabled bool   `json:"enabled"`
+
+	// Increment is what each debit is rounded up to a multiple of, e.g. "1"
+	// for the next dollar.
+	Increment string `json:"increment"`
+
+	// Currency is the currency of the debits rounded up, and of the sweep.
+	Currency string `json:"currency"`
+
+	// Frequency is how often round-ups are swept: one of Frequencies.
+	Frequency string `json:"frequency"`
+
+	// MaxPerTransaction caps the round-up of a single debit. Empty for no cap.
+	MaxPerTransaction string `json:"max_per_transaction,omitempty"`
+
+	// ExcludedCategories are never rounded up. They match a debit's spending
+	// category (see Category) or its transaction type.
+	ExcludedCategories []string `json:"excluded_categories,omitempty"`
+
+	// PreAuthorized sweeps are deposited without confirmation, provided they
+	// are no more than MaxPerSweep. Larger sweeps are proposed instead.
+	PreAuthorized bool   `json:"pre_authorized"`
+	MaxPerSweep   string `json:"max_per_sweep,omitempty"`
+
+	// StartDate is when the first period starts.
+	StartDate time.Time `json:"start_date"`
+
+	// SweptThrough is the end of the last period swept, or zero.
+	SweptThrough time.Time `json:"swept_through,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the rule's settings.
+func (r *Rule) Validate() error {
+	if inc, ok := parseDecimal(r.Increment); !ok || inc.Sign() <= 0 {
+		return fmt.Errorf("increment must be a positive amount")
+	}
+	if r.Currency == "" {
+		return fmt.Errorf("currency is required")
+	}
+	if !validFrequency(r.Frequency) {
+		return fmt.Errorf("frequency must be one of %v", Frequencies)
+	}
+	if r.MaxPerTransaction != "" {
+		if max, ok := parseDecimal(r.MaxPerTransaction); !ok || max.Sign() <= 0 {
+			return fmt.Errorf("max_per_transaction must be a positive amount")
+		}
+	}
+	if r.PreAuthorized || r.MaxPerSweep != "" {
+		if max, ok := parseDecimal(r.MaxPerSweep); !ok || max.Sign() <= 0 {
+			return fmt.Errorf("max_per_sweep must be a positive amount when sweeps are pre-authorized")
+		}
+	}
+	return nil
+}
+
+func validFrequency(frequency string) bool {
+	for _, f := range Frequencies {
+		if f == frequency {
+			return true
+		}
+	}
+	return false
+}
+
+// Period is one sweep period, [Start, End).
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// maxPeriods caps how far period calculations will iterate: ten years of
+// weekly sweeps.
+const maxPeriods = 520
+
+// periods returns the rule's periods in order, stopping at the first that
+// ends after until.
+func periods(r *Rule, until time.Time) []Period {
+	sched := &store.ScheduledTransfer{Cadence: r.Frequency, StartDate: r.StartDate}
+	var ps []Period
+	for n := 0; n < maxPeriods; n++ {
+		p := Period{Start: schedule.OccurrenceAt(sched, n), End: schedule.OccurrenceAt(sched, n+1)}
+		ps = append(ps, p)
+		if p.End.After(until) {
+			break
+		}
+	}
+	return ps
+}
+
+// CurrentPeriod returns the period containing t, or the first period if t
+// is before the rule starts.
+func CurrentPeriod(r *Rule, t time.Time) Period {
+	ps := periods(r, t)
+	return ps[len(ps)-1]
+}
+
+// DuePeriods returns the periods that have ended by now and not yet been
+// swept, in order.
+func DuePeriods(r *Rule, now time.Time) []Period {
+	var due []Period
+	for _, p := range periods(r, now) {
+		if p.End.After(now) {
+			break
+		}
+		if p.End.After(r.SweptThrough) {
+			due = append(due, p)
+		}
+	}
+	return due
+}
+
+// Item is one debit's round-up.
+type Item struct {
+	TransactionID string `json:"transaction_id"`
+	Amount        string `json:"amount"`
+	RoundUp       string `json:"round_up"`
+	Capped        bool   `json:"capped,omitempty"`
+}
+
+// Result is the round-up total for a period.
+type Result struct {
+	Total string `json:"total"`
+	Items []Item `json:"items,omitempty"`
+
+	// Excluded counts debits skipped for an excluded category.
+	Excluded int `json:"excluded,omitempty"`
+
+	// AlreadyCounted counts debits skipped because an earlier sweep
+	// counted them.
+	AlreadyCounted int `json:"already_counted,omitempty"`
+}
+
+// IsZero reports whether there is nothing to sweep.
+func (r Result) IsZero() bool {
+	total, _ := parseDecimal(r.Total)
+	return total == nil || total.Sign() == 0
+}
+
+// Calculate rounds up the rule's debits created in [start, end): completed
+// debits in the rule's currency, outside its excluded categories, with IDs
+// not in counted. Exact multiples of the increment round up by nothing and
+// are left out.
+func Calculate(r *Rule, txs []txn.Transaction, counted map[string]bool, start, end time.Time) (Result, error) {
+	increment, ok := parseDecimal(r.Increment)
+	if !ok || increment.Sign() <= 0 {
+		return Result{}, fmt.Errorf("invalid increment %q", r.Increment)
+	}
+	var max *big.Rat
+	if r.MaxPerTransaction != "" {
+		if max, ok = parseDecimal(r.MaxPerTransaction); !ok {
+			return Result{}, fmt.Errorf("invalid max_per_transaction %q", r.MaxPerTransaction)
+		}
+	}
+
+	var res Result
+	places := decimals(r.Increment)
+	for _, tx := range txs {
+		at := txn.CreatedAt(tx)
+		if tx.ID == "" || !txn.IsDebit(tx) || tx.Currency != r.Currency || failed(tx) {
+			continue
+		}
+		if at.IsZero() || at.Before(start) || !at.Before(end) {
+			continue
+		}
+		if excluded(r, tx) {
+			res.Excluded++
+			continue
+		}
+		if counted[tx.ID] {
+			res.AlreadyCounted++
+			continue
+		}
+		amount, ok := parseDecimal(strings.TrimPrefix(strings.TrimSpace(tx.Amount), "-"))
+		if !ok {
+			continue
+		}
+		up := RoundUp(amount, increment)
+		if up.Sign() == 0 {
+			continue
+		}
+		item := Item{TransactionID: tx.ID, Amount: amount.FloatString(decimals(tx.Amount))}
+		if max != nil && up.Cmp(max) > 0 {
+			up, item.Capped = max, true
+		}
+		if d := decimals(tx.Amount); d > places {
+			places = d
+		}
+		item.RoundUp = up.RatString()
+		res.Items = append(res.Items, item)
+	}
+	return res.format(places), nil
+}
+
+// format sets each round-up and the total to places decimal places.
+// Items hold their round-up as an exact fraction until then.
+func (r Result) format(places int) Result {
+	if places < 2 {
+		places = 2
+	}
+	total := new(big.Rat)
+	for i, item := range r.Items {
+		up, _ := new(big.Rat).SetString(item.RoundUp)
+		total.Add(total, up)
+		r.Items[i].RoundUp = up.FloatString(places)
+	}
+	r.Total = total.FloatString(places)
+	return r
+}
+
+// only returns the result restricted to the given transactions, such as
+// those a Claim granted.
+func (r Result) only(ids []string) Result {
+	keep := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		keep[id] = true
+	}
+	places := decimals(r.Total)
+	out := Result{Excluded: r.Excluded, AlreadyCounted: r.AlreadyCounted + len(r.Items) - len(ids)}
+	for _, item := range r.Items {
+		if keep[item.TransactionID] {
+			out.Items = append(out.Items, item)
+		}
+	}
+	return out.format(places)
+}
+
+// RoundUp returns how much rounds amount up to the next multiple of
+// increment: zero if it is already a multiple.
+func RoundUp(amount, increment *big.Rat) *big.Rat {
+	// amount/increment as a fraction n/d; the remainder of n by d, scaled
+	// back by the increment, is how far past the last multiple it is.
+	q := new(big.Rat).Quo(amount, increment)
+	rem := new(big.Int).Mod(q.Num(), q.Denom())
+	if rem.Sign() == 0 {
+		return new(big.Rat)
+	}
+	past := new(big.Rat).SetFrac(rem, q.Denom())
+	past.Mul(past, increment)
+	return past.Sub(increment, past)
+}
+
+// Category returns a debit's spending category, from its note.
+func Category(tx txn.Transaction) string {
+	return analysis.CategorizeNote(tx.Note)
+}
+
+// excluded reports whether a debit's category or type is excluded.
+func excluded(r *Rule, tx txn.Transaction) bool {
+	category := Category(tx)
+	for _, c := range r.ExcludedCategories {
+		if strings.EqualFold(c, category) || strings.EqualFold(c, tx.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// failed reports whether a transaction did not go through.
+func failed(tx txn.Transaction) bool {
+	switch strings.ToLower(tx.Status) {
+	case "failed", "cancelled", "canceled", "rejected", "reversed":
+		return true
+	}
+	return false
+}
+
+// Projection estimates a year of round-ups at a recent pace, and the vault
+// interest they would earn.
+type Projection struct {
+	Yearly   string `json:"yearly"`
+	Interest string `json:"interest"`
+	Total    string `json:"total"`
+}
+
+// Project projects round-ups of recent over the last days to a year. Sweeps
+// are spread across the year, so on average they earn half a year of
+// interest at apy, a percentage.
+func Project(recent string, days int, apy string) (Projection, error) {
+	amount, ok := parseDecimal(recent)
+	if !ok || days <= 0 {
+		return Projection{}, fmt.Errorf("invalid pace %q over %d days", recent, days)
+	}
+	rate, ok := parseDecimal(apy)
+	if !ok {
+		rate = new(big.Rat)
+	}
+	yearly := new(big.Rat).Mul(amount, big.NewRat(365, int64(days)))
+	interest := new(big.Rat).Mul(yearly, rate)
+	interest.Quo(interest, big.NewRat(200, 1))
+	total := new(big.Rat).Add(yearly, interest)
+	return Projection{
+		Yearly:   yearly.FloatString(2),
+		Interest: interest.FloatString(2),
+		Total:    total.FloatString(2),
+	}, nil
+}
+
+// decimalPattern matches plain decimals. Exponents are refused: parsing
+// "1e999999999" would allocate without bound.
+var decimalPattern = regexp.MustCompile(`^[+-]?\d+(\.\d+)?$`)
+
+func parseDecimal(s string) (*big.Rat, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) > 64 || !decimalPattern.MatchString(s) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// decimals returns the number of digits after the decimal point in a decimal string.
+func decimals(amount string) int {
+	amount = strings.TrimSpace(amount)
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		return len(amount) - i - 1
+	}
+	return 0
+}
@@ -0,0 +1,376 @@
+package roundup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// monday is the start of the fixtures' first weekly period.
+var monday = time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+func debit(id, amount, at, note string) txn.Transaction {
+	return txn.Transaction{ID: id, Amount: amount, Currency: "USDC", Direction: "debit", CreatedAt: at, Note: note}
+}
+
+// fixtures are a week of debits, plus some that are never rounded up.
+var fixtures = []txn.Transaction{
+	debit("t1", "3.25", "2026-03-02T09:00:00Z", "coffee"),              // 0.75
+	debit("t2", "12.00", "2026-03-03T12:00:00Z", "lunch"),              // exact dollar: 0
+	debit("t3", "-4.10", "2026-03-04T18:00:00Z", "movie ticket snack"), // 0.90
+	debit("t4", "18.01", "2026-03-05T08:00:00Z", "uber to work"),       // travel: 0.99
+	debit("t5", "7.999999", "2026-03-06T20:00:00Z", "groceries"),       // 0.000001
+	{ID: "t6", Amount: "50.50", Currency: "USDC", Direction: "credit", CreatedAt: "2026-03-03T10:00:00Z"},
+	{ID: "t7", Amount: "2.50", Currency: "EURC", Direction: "debit", CreatedAt: "2026-03-03T10:00:00Z"},
+	{ID: "t8", Amount: "9.50", Currency: "USDC", Direction: "debit", Status: "failed", CreatedAt: "2026-03-03T10:00:00Z"},
+	debit("t9", "1.50", "2026-03-09T10:00:00Z", "next week"),
+}
+
+func weeklyRule() *Rule {
+	return &Rule{UserID: "user-1", Enabled: true, Increment: "1", Currency: "USDC", Frequency: "weekly", StartDate: monday}
+}
+
+func TestRoundUp(t *testing.T) {
+	tests := []struct {
+		amount, increment, want string
+	}{
+		{"3.25", "1", "3/4"},
+		{"12", "1", "0"},
+		{"12.00", "1.00", "0"},
+		{"0.01", "1", "99/100"},
+		{"7.999999", "1", "1/1000000"},
+		{"3.25", "0.50", "1/4"},
+		{"3.50", "0.50", "0"},
+		{"13", "5", "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.amount+"/"+tt.increment, func(t *testing.T) {
+			amount, _ := new(big.Rat).SetString(tt.amount)
+			increment, _ := new(big.Rat).SetString(tt.increment)
+			if got := RoundUp(amount, increment).RatString(); got != tt.want {
+				t.Errorf("RoundUp(%s, %s) = %s, want %s", tt.amount, tt.increment, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	week := monday.AddDate(0, 0, 7)
+	tests := []struct {
+		name     string
+		rule     func(r *Rule)
+		counted  map[string]bool
+		want     string
+		wantIDs  string
+		excluded int
+	}{
+		{
+			name:    "exact dollars round up by nothing",
+			want:    "2.640001",
+			wantIDs: "t1,t3,t4,t5",
+		},
+		{
+			name:     "excluded categories and types",
+			rule:     func(r *Rule) { r.ExcludedCategories = []string{"Travel", "entertainment"} },
+			want:     "0.750001",
+			wantIDs:  "t1,t5",
+			excluded: 2,
+		},
+		{
+			name:    "per-transaction cap",
+			rule:    func(r *Rule) { r.MaxPerTransaction = "0.80" },
+			want:    "2.350001",
+			wantIDs: "t1,t3,t4,t5",
+		},
+		{
+			name:    "larger increment",
+			rule:    func(r *Rule) { r.Increment = "5" },
+			want:    "9.640001",
+			wantIDs: "t1,t2,t3,t4,t5",
+		},
+		{
+			name:    "already counted",
+			counted: map[string]bool{"t1": true, "t4": true},
+			want:    "0.900001",
+			wantIDs: "t3,t5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := weeklyRule()
+			if tt.rule != nil {
+				tt.rule(r)
+			}
+			res, err := Calculate(r, fixtures, tt.counted, monday, week)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, item := range res.Items {
+				ids = append(ids, item.TransactionID)
+			}
+			if res.Total != tt.want || strings.Join(ids, ",") != tt.wantIDs {
+				t.Errorf("Calculate() = %s from %v, want %s from %s", res.Total, ids, tt.want, tt.wantIDs)
+			}
+			if res.Excluded != tt.excluded {
+				t.Errorf("Excluded = %d, want %d", res.Excluded, tt.excluded)
+			}
+		})
+	}
+}
+
+func TestCalculate_CappedItems(t *testing.T) {
+	r := weeklyRule()
+	r.MaxPerTransaction = "0.80"
+	res, _ := Calculate(r, fixtures, nil, monday, monday.AddDate(0, 0, 7))
+	for _, item := range res.Items {
+		if capped := item.TransactionID == "t3" || item.TransactionID == "t4"; item.Capped != capped {
+			t.Errorf("%s Capped = %v, want %v", item.TransactionID, item.Capped, capped)
+		}
+		if item.Capped && item.RoundUp != "0.800000" {
+			t.Errorf("%s RoundUp = %s, want the cap", item.TransactionID, item.RoundUp)
+		}
+	}
+}
+
+func TestOverlappingPeriodsNeverDoubleCount(t *testing.T) {
+	ctx := context.Background()
+	rules := NewMemoryRules()
+	r := weeklyRule()
+
+	count := func(start, end time.Time) Result {
+		t.Helper()
+		var ids []string
+		for _, tx := range fixtures {
+			ids = append(ids, tx.ID)
+		}
+		counted, _ := rules.Counted(ctx, r.UserID, ids)
+		res, err := Calculate(r, fixtures, counted, start, end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = ids[:0]
+		for _, item := range res.Items {
+			ids = append(ids, item.TransactionID)
+		}
+		claimed, _ := rules.Claim(ctx, r.UserID, ids)
+		return res.only(claimed)
+	}
+
+	// Monday to Thursday, then a monthly period from Wednesday after the
+	// rule changed: t3 falls in both.
+	first := count(monday, monday.AddDate(0, 0, 3))
+	if first.Total != "1.65" {
+		t.Errorf("first period = %s, want 1.65", first.Total)
+	}
+	second := count(monday.AddDate(0, 0, 2), monday.AddDate(0, 1, 0))
+	if second.Total != "1.490001" || second.AlreadyCounted != 1 {
+		t.Errorf("overlapping period = %s with %d already counted, want 1.490001 with 1", second.Total, second.AlreadyCounted)
+	}
+	if again := count(monday, monday.AddDate(0, 1, 0)); !again.IsZero() {
+		t.Errorf("recounted period = %s, want nothing", again.Total)
+	}
+
+	// A claim racing the calculation only keeps what it was granted.
+	r2 := &Rule{UserID: "user-2", Increment: "1", Currency: "USDC", Frequency: "weekly"}
+	res, _ := Calculate(r2, fixtures, nil, monday, monday.AddDate(0, 0, 7))
+	rules.Claim(ctx, "user-2", []string{"t1"})
+	claimed, _ := rules.Claim(ctx, "user-2", []string{"t1", "t3"})
+	if got := res.only(claimed); got.Total != "0.900000" || got.AlreadyCounted != 3 {
+		t.Errorf("only(claimed) = %+v, want 0.900000 with 3 already counted", got)
+	}
+}
+
+func TestDuePeriods(t *testing.T) {
+	r := weeklyRule()
+	now := monday.AddDate(0, 0, 15)
+	if due := DuePeriods(r, now); len(due) != 2 || !due[1].End.Equal(monday.AddDate(0, 0, 14)) {
+		t.Errorf("DuePeriods() = %v, want the first two weeks", due)
+	}
+	r.SweptThrough = monday.AddDate(0, 0, 7)
+	if due := DuePeriods(r, now); len(due) != 1 || !due[0].Start.Equal(r.SweptThrough) {
+		t.Errorf("DuePeriods() after a sweep = %v, want the second week", due)
+	}
+	if p := CurrentPeriod(r, now); !p.Start.Equal(monday.AddDate(0, 0, 14)) {
+		t.Errorf("CurrentPeriod() = %v, want the third week", p)
+	}
+}
+
+func TestProject(t *testing.T) {
+	p, err := Project("30.00", 30, "4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Yearly != "365.00" || p.Interest != "7.30" || p.Total != "372.30" {
+		t.Errorf("Project() = %+v, want 365.00 + 7.30 interest", p)
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    func(r *Rule)
+		wantErr bool
+	}{
+		{name: "valid"},
+		{name: "zero increment", rule: func(r *Rule) { r.Increment = "0" }, wantErr: true},
+		{name: "exponent increment", rule: func(r *Rule) { r.Increment = "1e9" }, wantErr: true},
+		{name: "daily", rule: func(r *Rule) { r.Frequency = "daily" }, wantErr: true},
+		{name: "pre-authorized without a maximum", rule: func(r *Rule) { r.PreAuthorized = true }, wantErr: true},
+		{name: "pre-authorized", rule: func(r *Rule) { r.PreAuthorized, r.MaxPerSweep = true, "25" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := weeklyRule()
+			if tt.rule != nil {
+				tt.rule(r)
+			}
+			if err := r.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// writeExecutor is a txntest.Executor that records write calls, failing
+// them with fail if set.
+type writeExecutor struct {
+	txntest.Executor
+	writes []*core.ExecuteRequest
+	fail   string
+}
+
+func (w *writeExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	w.writes = append(w.writes, req)
+	if w.fail != "" {
+		return &core.ExecuteResponse{Success: false, Error: w.fail}, nil
+	}
+	return &core.ExecuteResponse{Success: true}, nil
+}
+
+func TestSweeper(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxPerSweep string // pre-authorized if set
+		fail        string
+		noToken     bool
+		wantWrites  int
+		wantPending string // why approval is needed
+	}{
+		{name: "proposed"},
+		{name: "pre-authorized", maxPerSweep: "5", wantWrites: 1},
+		{name: "pre-authorized over the maximum", maxPerSweep: "1", wantPending: "automatic limit"},
+		{name: "pre-authorized deposit fails", maxPerSweep: "5", fail: "savings unavailable", wantWrites: 1, wantPending: "savings unavailable"},
+		{name: "pre-authorized without credentials", maxPerSweep: "5", noToken: true, wantPending: "credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			exec := &writeExecutor{Executor: txntest.Executor{Responses: map[string]interface{}{
+				"get_transactions": executor.GetTransactionsResponse{Transactions: fixtures},
+			}}, fail: tt.fail}
+			rules := NewMemoryRules()
+			confirmations := store.NewMemoryConfirmations()
+			now := monday.AddDate(0, 0, 8)
+			// The store expires confirmations by the wall clock, not Now.
+			s := NewSweeper(Config{
+				Rules:           rules,
+				Confirmations:   confirmations,
+				Executor:        exec,
+				ConfirmationTTL: time.Since(now) + time.Hour,
+				Credentials: func(ctx context.Context, userID string) (string, error) {
+					if tt.noToken {
+						return "", errors.New("no token")
+					}
+					return "token-" + userID, nil
+				},
+				Now: func() time.Time { return now },
+			})
+
+			r := weeklyRule()
+			r.PreAuthorized, r.MaxPerSweep = tt.maxPerSweep != "", tt.maxPerSweep
+			rules.Set(ctx, r)
+
+			// Offline: nothing is delivered, so the sweep waits for CatchUp.
+			if err := s.Tick(ctx); err != nil {
+				t.Fatal(err)
+			}
+			actions, err := s.CatchUp(ctx, r.UserID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(exec.writes) != tt.wantWrites {
+				t.Errorf("deposits = %d, want %d", len(exec.writes), tt.wantWrites)
+			}
+			if tt.wantWrites > 0 && string(exec.writes[0].Input) != `{"amount":"2.640001","currency":"USDC"}` {
+				t.Errorf("deposit input = %s", exec.writes[0].Input)
+			}
+
+			wantPending := tt.wantPending != "" || tt.maxPerSweep == ""
+			if (len(actions) == 1) != wantPending {
+				t.Fatalf("CatchUp() = %d actions, want pending %v", len(actions), wantPending)
+			}
+			if wantPending {
+				var input map[string]string
+				json.Unmarshal(actions[0].Input, &input)
+				if actions[0].Tool != "deposit_savings" || input["amount"] != "2.640001" {
+					t.Errorf("proposed sweep = %s %s", actions[0].Tool, actions[0].Input)
+				}
+				if !strings.Contains(actions[0].Summary, tt.wantPending) {
+					t.Errorf("summary %q doesn't explain why approval is needed", actions[0].Summary)
+				}
+			}
+
+			// The period is handled: later ticks neither propose nor deposit again.
+			s.Tick(ctx)
+			if again, _ := s.CatchUp(ctx, r.UserID); len(again) != 0 || len(exec.writes) != tt.wantWrites {
+				t.Errorf("period swept again: %d actions, %d deposits", len(again), len(exec.writes))
+			}
+			if got, _ := rules.Get(ctx, r.UserID); !got.SweptThrough.Equal(monday.AddDate(0, 0, 7)) {
+				t.Errorf("SweptThrough = %v, want the end of the first week", got.SweptThrough)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	ctx := context.Background()
+	rules := NewMemoryRules()
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{Transactions: fixtures},
+		"get_vault_rates":  executor.GetVaultRatesResponse{Vaults: []executor.VaultRate{{Currency: "USDC", APY: "4"}}},
+	}}
+	params := &core.ToolParams{UserID: "user-1"}
+	now := monday.AddDate(0, 0, 10)
+
+	result, _ := statusResult(ctx, exec, rules, params, now)
+	if env, _ := core.EnvelopeOf(result.Data); env == nil || env.Status != core.StatusEmpty {
+		t.Errorf("status without a rule = %+v, want empty", result.Data)
+	}
+
+	r := weeklyRule()
+	r.SweptThrough = monday.AddDate(0, 0, 7)
+	rules.Set(ctx, r)
+	rules.Claim(ctx, r.UserID, []string{"t1"})
+
+	result, _ = statusResult(ctx, exec, rules, params, now)
+	env, _ := core.EnvelopeOf(result.Data)
+	data := env.Data.(map[string]interface{})
+	if data["pot"] != "0.50" || data["round_ups"] != 1 {
+		t.Errorf("pot = %v from %v round-ups, want 0.50 from 1", data["pot"], data["round_ups"])
+	}
+	// 3.140001 over 30 days, counted or not, projects to 38.20 a year.
+	if data["projected_yearly"] != "38.20" || data["apy"] != "4" {
+		t.Errorf("projection = %v at %v%%, want 38.20 at 4%%", data["projected_yearly"], data["apy"])
+	}
+}
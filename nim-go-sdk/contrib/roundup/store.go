@@ -0,0 +1,107 @@
+package roundup
+
+import (
+	"context"
+	"sync"
+)
+
+// Rules stores round-up rules and the transactions counted toward sweeps.
+// This is an interface - implementations (e.g., database-backed) are provided
+// by the consuming application.
+type Rules interface {
+	// Get returns the user's rule.
+	// Returns nil, nil if the user has none.
+	Get(ctx context.Context, userID string) (*Rule, error)
+
+	// Set creates or replaces the user's rule.
+	Set(ctx context.Context, rule *Rule) error
+
+	// ListEnabled returns every enabled rule.
+	ListEnabled(ctx context.Context) ([]*Rule, error)
+
+	// Counted returns which of the transactions have been counted toward a sweep.
+	Counted(ctx context.Context, userID string, txIDs []string) (map[string]bool, error)
+
+	// Claim marks the transactions as counted, returning those that were not
+	// already. It must be atomic: of concurrent claims on a transaction,
+	// only one is granted it.
+	Claim(ctx context.Context, userID string, txIDs []string) ([]string, error)
+}
+
+// MemoryRules is an in-memory implementation of Rules.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryRules struct {
+	mu      sync.RWMutex
+	rules   map[string]Rule            // userID -> rule
+	counted map[string]map[string]bool // userID -> transaction IDs
+}
+
+// NewMemoryRules creates an in-memory rule store.
+func NewMemoryRules() *MemoryRules {
+	return &MemoryRules{
+		rules:   make(map[string]Rule),
+		counted: make(map[string]map[string]bool),
+	}
+}
+
+func (m *MemoryRules) Get(ctx context.Context, userID string) (*Rule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rule, ok := m.rules[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &rule, nil
+}
+
+func (m *MemoryRules) Set(ctx context.Context, rule *Rule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules[rule.UserID] = *rule
+	return nil
+}
+
+func (m *MemoryRules) ListEnabled(ctx context.Context) ([]*Rule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var rules []*Rule
+	for _, rule := range m.rules {
+		if rule.Enabled {
+			rule := rule
+			rules = append(rules, &rule)
+		}
+	}
+	return rules, nil
+}
+
+func (m *MemoryRules) Counted(ctx context.Context, userID string, txIDs []string) (map[string]bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	counted := make(map[string]bool)
+	for _, id := range txIDs {
+		if m.counted[userID][id] {
+			counted[id] = true
+		}
+	}
+	return counted, nil
+}
+
+func (m *MemoryRules) Claim(ctx context.Context, userID string, txIDs []string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counted[userID] == nil {
+		m.counted[userID] = make(map[string]bool)
+	}
+	var claimed []string
+	for _, id := range txIDs {
+		if !m.counted[userID][id] {
+			m.counted[userID][id] = true
+			claimed = append(claimed, id)
+		}
+	}
+	return claimed, nil
+}
+
+// Verify MemoryRules implements Rules.
+var _ Rules = (*MemoryRules)(nil)
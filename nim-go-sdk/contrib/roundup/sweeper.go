@@ -0,0 +1,291 @@
+package roundup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/schedule"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// fetchLimit is how many recent transactions a sweep or status reads.
+const fetchLimit = 500
+
+// Config configures a Sweeper.
+type Config struct {
+	// Rules stores round-up rules. Required.
+	Rules Rules
+
+	// Confirmations stores confirmations for proposed sweeps. Required.
+	// Must be the same store the server confirms actions against.
+	Confirmations store.Confirmations
+
+	// Executor reads transactions and deposits pre-authorized sweeps. Required.
+	Executor core.ToolExecutor
+
+	// Notifier proactively delivers proposed sweeps.
+	// If nil, they are only presented when the user connects (see CatchUp).
+	Notifier schedule.Notifier
+
+	// Credentials returns the token a user's sweeps are made with, since
+	// ticks run without a connection. Ticks skip users without one, whose
+	// periods are swept on CatchUp instead; the Executor's own token is
+	// never used.
+	Credentials core.CredentialFunc
+
+	// Tool is the write tool used for each sweep. Defaults to "deposit_savings".
+	Tool string
+
+	// ConfirmationTTL is how long a proposed sweep stays valid.
+	// Defaults to 24 hours.
+	ConfirmationTTL time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Sweeper sweeps each enabled rule's round-ups into savings when its
+// periods end.
+//
+// A period's debits are claimed in the rule store before its sweep is
+// proposed or deposited, so no debit is counted twice, even by overlapping
+// periods after a rule changes. A proposed sweep the user declines, or
+// that expires, is not offered again.
+type Sweeper struct {
+	cfg Config
+}
+
+// NewSweeper creates a sweeper with the given configuration.
+func NewSweeper(cfg Config) *Sweeper {
+	if cfg.Tool == "" {
+		cfg.Tool = "deposit_savings"
+	}
+	if cfg.ConfirmationTTL == 0 {
+		cfg.ConfirmationTTL = 24 * time.Hour
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Sweeper{cfg: cfg}
+}
+
+// Run calls Tick every interval until ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx); err != nil {
+			log.Printf("Round-up sweep failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick sweeps every enabled rule's ended periods. Pre-authorized sweeps are
+// deposited; the rest are delivered through the Notifier.
+func (s *Sweeper) Tick(ctx context.Context) error {
+	rules, err := s.cfg.Rules.ListEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list round-up rules: %w", err)
+	}
+
+	for _, rule := range rules {
+		userCtx, err := s.cfg.Credentials.Attach(core.WithIdentity(ctx, core.Identity{UserID: rule.UserID}), rule.UserID)
+		if err != nil {
+			log.Printf("Round-up sweep failed to get credentials for user %s: %v", rule.UserID, err)
+			continue
+		}
+		if core.CredentialFromContext(userCtx) == "" {
+			continue
+		}
+		err = s.sweep(userCtx, rule, func(action *core.PendingAction) (bool, error) {
+			if s.cfg.Notifier == nil {
+				return false, nil
+			}
+			return s.cfg.Notifier.NotifyConfirmation(userCtx, rule.UserID, action)
+		})
+		if err != nil {
+			log.Printf("Round-up sweep failed for user %s: %v", rule.UserID, err)
+		}
+	}
+	return nil
+}
+
+// CatchUp returns proposed sweeps for a user's ended periods that have not
+// yet been delivered, such as those missed while the user was offline.
+// Call it when the user connects and present every returned action.
+func (s *Sweeper) CatchUp(ctx context.Context, userID string) ([]*core.PendingAction, error) {
+	rule, err := s.cfg.Rules.Get(ctx, userID)
+	if err != nil || rule == nil || !rule.Enabled {
+		return nil, err
+	}
+	var actions []*core.PendingAction
+	err = s.sweep(ctx, rule, func(action *core.PendingAction) (bool, error) {
+		actions = append(actions, action)
+		return true, nil
+	})
+	return actions, err
+}
+
+// SweepKey returns the idempotency key for the sweep of the user's period
+// ending at end.
+func SweepKey(userID string, end time.Time) string {
+	return fmt.Sprintf("roundup:%s:%d", userID, end.Unix())
+}
+
+// sweep handles each of the rule's due periods in order, recording each
+// in the rule once it is deposited, or proposed and delivered. It stops at
+// the first period left undelivered.
+func (s *Sweeper) sweep(ctx context.Context, rule *Rule, deliver func(*core.PendingAction) (bool, error)) error {
+	now := s.cfg.Now()
+	due := DuePeriods(rule, now)
+	if len(due) == 0 {
+		return nil
+	}
+
+	txs, err := txn.Fetch(ctx, s.cfg.Executor, rule.UserID, core.RequestIDFromContext(ctx), fetchLimit)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range due {
+		done, err := s.sweepPeriod(ctx, rule, p, txs, now, deliver)
+		if err != nil || !done {
+			return err
+		}
+		rule.SweptThrough = p.End
+		if err := s.cfg.Rules.Set(ctx, rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sweepPeriod deposits or proposes one period's round-ups.
+// Returns true once the period is handled.
+func (s *Sweeper) sweepPeriod(ctx context.Context, rule *Rule, p Period, txs []txn.Transaction, now time.Time, deliver func(*core.PendingAction) (bool, error)) (bool, error) {
+	key := SweepKey(rule.UserID, p.End)
+
+	// A sweep proposed earlier but not yet delivered is delivered as is.
+	existing, err := s.cfg.Confirmations.GetByIdempotency(ctx, rule.UserID, key)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		return deliver(existing)
+	}
+
+	ids := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		ids = append(ids, tx.ID)
+	}
+	counted, err := s.cfg.Rules.Counted(ctx, rule.UserID, ids)
+	if err != nil {
+		return false, err
+	}
+	res, err := Calculate(rule, txs, counted, p.Start, p.End)
+	if err != nil || res.IsZero() {
+		return err == nil, err
+	}
+
+	ids = ids[:0]
+	for _, item := range res.Items {
+		ids = append(ids, item.TransactionID)
+	}
+	claimed, err := s.cfg.Rules.Claim(ctx, rule.UserID, ids)
+	if err != nil {
+		return false, err
+	}
+	if res = res.only(claimed); res.IsZero() {
+		return true, nil
+	}
+
+	var reason string
+	if rule.PreAuthorized {
+		total, _ := parseDecimal(res.Total)
+		max, _ := parseDecimal(rule.MaxPerSweep)
+		if max == nil || total.Cmp(max) > 0 {
+			reason = fmt.Sprintf("it is over your automatic limit of %s %s", rule.MaxPerSweep, rule.Currency)
+		} else if core.CredentialFromContext(ctx) == "" {
+			reason = "your credentials are unavailable"
+		} else if failure := s.deposit(ctx, rule, key, res); failure != "" {
+			reason = "the automatic deposit failed: " + failure
+		} else {
+			return true, nil
+		}
+	}
+
+	action := &core.PendingAction{
+		ID:             uuid.New().String(),
+		IdempotencyKey: key,
+		UserID:         rule.UserID,
+		Tool:           s.cfg.Tool,
+		Summary:        sweepSummary(rule, p, res, reason),
+		CreatedAt:      now.Unix(),
+		ExpiresAt:      now.Add(s.cfg.ConfirmationTTL).Unix(),
+	}
+	if err := action.SetInput(depositInput(rule, res)); err != nil {
+		return false, err
+	}
+	if err := s.cfg.Confirmations.Store(ctx, action); err != nil {
+		return false, err
+	}
+	return deliver(action)
+}
+
+// deposit runs a pre-authorized sweep and returns why it failed, if it did.
+// Its debits are already claimed, so a failed deposit is not retried; it is
+// proposed to the user instead.
+func (s *Sweeper) deposit(ctx context.Context, rule *Rule, key string, res Result) string {
+	ctx = core.WithRequestID(ctx, key)
+	resp, err := s.cfg.Executor.ExecuteWrite(ctx, &core.ExecuteRequest{
+		UserID:    rule.UserID,
+		Tool:      s.cfg.Tool,
+		Input:     depositInput(rule, res),
+		RequestID: key,
+	})
+	if err == nil && resp.Success && resp.RequiresConfirmation && resp.Confirmation != nil {
+		resp, err = s.cfg.Executor.Confirm(ctx, rule.UserID, resp.Confirmation.ID)
+	}
+	failure := ""
+	if err != nil {
+		failure = err.Error()
+	} else if !resp.Success {
+		failure = resp.Error
+	}
+	if failure != "" {
+		log.Printf("Round-up sweep %s failed: %s", key, failure)
+	}
+	return failure
+}
+
+// depositInput builds the write tool input for a sweep.
+func depositInput(rule *Rule, res Result) json.RawMessage {
+	input, _ := json.Marshal(map[string]string{
+		"amount":   res.Total,
+		"currency": rule.Currency,
+	})
+	return input
+}
+
+// sweepSummary describes a sweep for its confirmation prompt.
+func sweepSummary(rule *Rule, p Period, res Result, reason string) string {
+	summary := fmt.Sprintf("Save your spare change: deposit %s %s from %d round-ups (%s to %s)",
+		res.Total, rule.Currency, len(res.Items), p.Start.Format("Jan 2"), p.End.AddDate(0, 0, -1).Format("Jan 2"))
+	if reason != "" {
+		summary += " — needs your approval: " + reason
+	}
+	return summary
+}
@@ -0,0 +1,258 @@
+package roundup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// paceDays is the window round-ups are projected from.
+const paceDays = 30
+
+// Tools returns the round-up tools, reading account data through exec:
+// enable_roundups, disable_roundups, and get_roundup_status.
+// Run a Sweeper with the same rule store to sweep the round-ups.
+func Tools(exec core.ToolExecutor, rules Rules) []core.Tool {
+	return []core.Tool{
+		EnableTool(rules),
+		DisableTool(rules),
+		StatusTool(exec, rules),
+	}
+}
+
+// enableInput is the input for enable_roundups.
+type enableInput struct {
+	Increment          string   `json:"increment"`
+	Currency           string   `json:"currency"`
+	Frequency          string   `json:"frequency"`
+	MaxPerTransaction  string   `json:"max_per_transaction"`
+	ExcludedCategories []string `json:"excluded_categories"`
+	PreAuthorize       bool     `json:"pre_authorize"`
+	MaxPerSweep        string   `json:"max_per_sweep"`
+}
+
+// parseEnableInput validates the input and builds the rule it describes,
+// replacing existing. Periods start where existing left off, so changing
+// an enabled rule neither skips nor recounts debits.
+func parseEnableInput(userID string, raw json.RawMessage, existing *Rule, now time.Time) (*Rule, error) {
+	var in enableInput
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+	rule := &Rule{
+		UserID:             userID,
+		Enabled:            true,
+		Increment:          in.Increment,
+		Currency:           strings.ToUpper(in.Currency),
+		Frequency:          strings.ToLower(in.Frequency),
+		MaxPerTransaction:  in.MaxPerTransaction,
+		ExcludedCategories: in.ExcludedCategories,
+		PreAuthorized:      in.PreAuthorize,
+		MaxPerSweep:        in.MaxPerSweep,
+		StartDate:          time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()),
+		UpdatedAt:          now,
+	}
+	if rule.Increment == "" {
+		rule.Increment = "1"
+	}
+	if rule.Currency == "" {
+		rule.Currency = "USDC"
+	}
+	if rule.Frequency == "" {
+		rule.Frequency = Frequencies[0]
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	if existing != nil && existing.Enabled {
+		rule.StartDate = existing.StartDate
+		if existing.SweptThrough.After(rule.StartDate) {
+			rule.StartDate = existing.SweptThrough
+		}
+	}
+	return rule, nil
+}
+
+// EnableTool returns the enable_roundups tool, which turns on or updates the
+// user's round-up rule. Changing the rule requires confirmation.
+func EnableTool(rules Rules) core.Tool {
+	return tools.New("enable_roundups").
+		Description("Turn on or update round-up savings: each purchase is rounded up to the next increment (e.g. the next dollar) and the spare change is swept into savings each week, fortnight or month. Sweeps need the user's approval unless they pre-authorize them up to a maximum. Requires confirmation.").
+		RequiresConfirmation().
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"increment":           tools.StringProperty("Round each purchase up to a multiple of this amount (default: \"1\")"),
+			"currency":            tools.StringProperty("Currency of the purchases to round up and the savings deposit (default: USDC)"),
+			"frequency":           tools.StringEnumProperty("How often to sweep round-ups into savings (default: weekly)", Frequencies...),
+			"max_per_transaction": tools.StringProperty("Largest round-up for a single purchase (optional)"),
+			"excluded_categories": tools.ArrayProperty("Spending categories or transaction types never to round up, e.g. travel", tools.StringProperty("Category")),
+			"pre_authorize":       tools.BooleanProperty("Deposit sweeps automatically without asking each time (requires max_per_sweep)"),
+			"max_per_sweep":       tools.StringProperty("Largest sweep to deposit automatically; larger sweeps ask first"),
+		})).
+		SummaryTemplate(`Round up {{default "USDC" .currency}} purchases to the next {{default "1" .increment}} and save the change {{default "weekly" .frequency}}{{if .pre_authorize}}, automatically up to {{.max_per_sweep}} per sweep{{end}}`).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			existing, err := rules.Get(ctx, params.UserID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to load round-up rule: %v", err)}, nil
+			}
+			now := time.Now()
+			rule, err := parseEnableInput(params.UserID, params.Input, existing, now)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+			if err := rules.Set(ctx, rule); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to save round-up rule: %v", err)}, nil
+			}
+
+			next := CurrentPeriod(rule, now).End
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"status":     "roundups_enabled",
+					"message":    fmt.Sprintf("Round-ups are on: purchases round up to the next %s %s, swept %s", rule.Increment, rule.Currency, rule.Frequency),
+					"next_sweep": next.Format("Monday, Jan 2"),
+					"rule":       rule,
+				},
+			}, nil
+		}).
+		Build()
+}
+
+// DisableTool returns the disable_roundups tool, which turns off the user's
+// round-up rule. Turning it off requires confirmation.
+func DisableTool(rules Rules) core.Tool {
+	return tools.New("disable_roundups").
+		Description("Turn off round-up savings. Round-ups not yet swept are not saved. Requires confirmation.").
+		RequiresConfirmation().
+		Schema(tools.ObjectSchema(map[string]interface{}{})).
+		SummaryTemplate("Turn off round-up savings").
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			rule, err := rules.Get(ctx, params.UserID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to load round-up rule: %v", err)}, nil
+			}
+			if rule == nil || !rule.Enabled {
+				return &core.ToolResult{Success: true, Data: map[string]interface{}{
+					"status":  "roundups_disabled",
+					"message": "Round-ups were already off",
+				}}, nil
+			}
+			rule.Enabled = false
+			rule.UpdatedAt = time.Now()
+			if err := rules.Set(ctx, rule); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to save round-up rule: %v", err)}, nil
+			}
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{
+				"status":  "roundups_disabled",
+				"message": "Round-ups are off",
+			}}, nil
+		}).
+		Build()
+}
+
+// StatusTool returns the get_roundup_status tool, a read-only view of the
+// round-ups accumulated since the last sweep and projected yearly savings.
+func StatusTool(exec core.ToolExecutor, rules Rules) core.Tool {
+	return tools.New("get_roundup_status").
+		Description("Get round-up savings status: the spare change accumulated since the last sweep, when the next sweep is, and projected yearly savings at the current pace including vault interest.").
+		Schema(tools.ObjectSchema(map[string]interface{}{})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			return statusResult(ctx, exec, rules, params, time.Now())
+		}).
+		Build()
+}
+
+// statusResult reports the user's round-up pot and projection.
+func statusResult(ctx context.Context, exec core.ToolExecutor, rules Rules, params *core.ToolParams, now time.Time) (*core.ToolResult, error) {
+	rule, err := rules.Get(ctx, params.UserID)
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to load round-up rule: %v", err)}, nil
+	}
+	if rule == nil || !rule.Enabled {
+		return core.NewEnvelope(map[string]interface{}{
+			"enabled": false,
+			"message": "Round-ups are not turned on",
+		}).WithStatus(core.StatusEmpty).Result(), nil
+	}
+
+	txs, err := txn.Fetch(ctx, exec, params.UserID, params.RequestID, fetchLimit)
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to calculate round-ups: %v", err)}, nil
+	}
+	ids := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		ids = append(ids, tx.ID)
+	}
+	counted, err := rules.Counted(ctx, params.UserID, ids)
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to calculate round-ups: %v", err)}, nil
+	}
+
+	// The pot is the round-ups since the last period swept that no sweep
+	// has counted yet.
+	potStart := rule.StartDate
+	if rule.SweptThrough.After(potStart) {
+		potStart = rule.SweptThrough
+	}
+	pot, err := Calculate(rule, txs, counted, potStart, now)
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: err.Error()}, nil
+	}
+	pace, err := Calculate(rule, txs, nil, now.AddDate(0, 0, -paceDays), now)
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: err.Error()}, nil
+	}
+
+	var warnings []string
+	apy := "0"
+	rates, err := txn.VaultRates(ctx, exec, params.UserID, params.RequestID)
+	if err != nil {
+		warnings = append(warnings, "Vault rates are unavailable, so the projection leaves out interest")
+	} else if rate, ok := rates[rule.Currency]; ok {
+		apy = strconv.FormatFloat(rate, 'f', -1, 64)
+	} else {
+		warnings = append(warnings, fmt.Sprintf("There is no %s savings vault rate, so the projection leaves out interest", rule.Currency))
+	}
+	projection, err := Project(pace.Total, paceDays, apy)
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: err.Error()}, nil
+	}
+
+	data := map[string]interface{}{
+		"enabled":            true,
+		"increment":          rule.Increment,
+		"currency":           rule.Currency,
+		"frequency":          rule.Frequency,
+		"pre_authorized":     rule.PreAuthorized,
+		"pot":                pot.Total,
+		"round_ups":          len(pot.Items),
+		"since":              potStart.Format("Monday, Jan 2"),
+		"next_sweep":         CurrentPeriod(rule, now).End.Format("Monday, Jan 2"),
+		"apy":                apy,
+		"projected_yearly":   projection.Yearly,
+		"projected_interest": projection.Interest,
+		"projected_total":    projection.Total,
+	}
+	if rule.PreAuthorized {
+		data["max_per_sweep"] = rule.MaxPerSweep
+	}
+	env := core.NewEnvelope(data).WithFigures(
+		core.NewFigure("pot", txn.Amount(pot.Total), rule.Currency),
+		core.NewFigure("projected_yearly", txn.Amount(projection.Yearly), rule.Currency),
+		core.NewFigure("projected_total", txn.Amount(projection.Total), rule.Currency),
+	)
+	for _, w := range warnings {
+		env.WithWarning(w)
+	}
+	if len(txs) == fetchLimit {
+		env.WithWarning(fmt.Sprintf("Only the %d most recent transactions were counted, so round-ups may be higher", fetchLimit))
+	}
+	return env.Result(), nil
+}
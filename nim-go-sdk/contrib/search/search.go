@@ -0,0 +1,204 @@
+// Package search finds the user's transactions by counterparty, note,
+// amount and direction, and totals what it finds, so the model can answer
+// questions like "how much did I pay @bob last month?" without reading
+// every transaction and adding them up itself.
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/money"
+)
+
+// Transaction directions, as in get_transactions.
+const (
+	DirectionDebit  = "debit"  // money leaving the account
+	DirectionCredit = "credit" // money entering it
+)
+
+// Filter selects transactions. Empty fields match everything, and text is
+// compared case-insensitively.
+type Filter struct {
+	// Counterparty matches the other party: a @handle matches that user
+	// exactly, and other text matches counterparties containing it.
+	Counterparty string
+
+	// NoteContains matches transactions whose note contains it.
+	NoteContains string
+
+	// MinAmount and MaxAmount bound the transaction's amount, without its
+	// sign, in the transaction's own currency: [MinAmount, MaxAmount].
+	MinAmount string
+	MaxAmount string
+
+	// Direction is DirectionDebit or DirectionCredit.
+	Direction string
+}
+
+// Validate returns an error if f's amounts aren't decimals, its range is
+// empty, or its direction is unknown.
+func (f Filter) Validate() error {
+	var bounds []money.Amount
+	for _, s := range []string{f.MinAmount, f.MaxAmount} {
+		if s == "" {
+			continue
+		}
+		amount, err := money.ParseAmount(s, "")
+		if err != nil {
+			return fmt.Errorf("invalid amount %q: use a decimal such as 5 or 12.50", s)
+		}
+		bounds = append(bounds, amount)
+	}
+	if f.MinAmount != "" && f.MaxAmount != "" && bounds[0].Minor > bounds[1].Minor {
+		return fmt.Errorf("min_amount %s is more than max_amount %s", f.MinAmount, f.MaxAmount)
+	}
+	if f.Direction != "" && f.Direction != DirectionDebit && f.Direction != DirectionCredit {
+		return fmt.Errorf("unknown direction %q: use %s or %s", f.Direction, DirectionDebit, DirectionCredit)
+	}
+	return nil
+}
+
+// Direction returns tx's direction: DirectionDebit if it is money leaving
+// the account, otherwise DirectionCredit.
+func Direction(tx txn.Transaction) string {
+	if txn.IsDebit(tx) {
+		return DirectionDebit
+	}
+	return DirectionCredit
+}
+
+// matchText reports whether tx passes f's text and direction filters.
+func (f Filter) matchText(tx txn.Transaction) bool {
+	if f.Direction != "" && Direction(tx) != f.Direction {
+		return false
+	}
+	if f.NoteContains != "" && !containsFold(tx.Note, f.NoteContains) {
+		return false
+	}
+	if want := strings.TrimSpace(f.Counterparty); want != "" {
+		if strings.HasPrefix(want, "@") {
+			return strings.EqualFold(strings.TrimPrefix(tx.Counterparty, "@"), strings.TrimPrefix(want, "@"))
+		}
+		return containsFold(tx.Counterparty, want)
+	}
+	return true
+}
+
+// matchAmount reports whether amount, without its sign, is within f's
+// range. The bounds are read in amount's currency.
+func (f Filter) matchAmount(amount money.Amount) bool {
+	amount = amount.Abs()
+	if f.MinAmount != "" {
+		min, err := money.ParseAmount(f.MinAmount, amount.Currency)
+		if err != nil || amount.Minor < min.Minor {
+			return false
+		}
+	}
+	if f.MaxAmount != "" {
+		max, err := money.ParseAmount(f.MaxAmount, amount.Currency)
+		if err != nil || amount.Minor > max.Minor {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold reports whether substr is within s, ignoring case.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(strings.TrimSpace(substr)))
+}
+
+// Aggregate totals the matching transactions in one currency and direction.
+type Aggregate struct {
+	Currency  string
+	Direction string
+	Count     int
+
+	// Total and Average are without sign.
+	Total   money.Amount
+	Average money.Amount
+}
+
+// Result is what Search found.
+type Result struct {
+	// Matches are the matching transactions, in the order given.
+	Matches []txn.Transaction
+
+	// Aggregates total Matches by currency and direction, ordered by
+	// currency, debits first.
+	Aggregates []Aggregate
+
+	// Skipped counts transactions that passed the other filters but whose
+	// amount couldn't be read or totaled, so they aren't in Matches.
+	Skipped int
+}
+
+// Search returns the transactions among txs that pass f, with their
+// totals. Amounts are compared and added exactly.
+func Search(txs []txn.Transaction, f Filter) Result {
+	var r Result
+	type key struct{ currency, direction string }
+	totals := make(map[key]*Aggregate)
+	for _, tx := range txs {
+		if !f.matchText(tx) {
+			continue
+		}
+		amount, err := txn.Money(tx)
+		if err != nil {
+			r.Skipped++
+			continue
+		}
+		if !f.matchAmount(amount) {
+			continue
+		}
+
+		k := key{strings.ToUpper(tx.Currency), Direction(tx)}
+		agg, ok := totals[k]
+		if !ok {
+			agg = &Aggregate{Currency: k.currency, Direction: k.direction, Total: money.Amount{Currency: k.currency}}
+			totals[k] = agg
+		}
+		total, err := agg.Total.Add(amount.Abs())
+		if err != nil {
+			// Too large to total: leave it out rather than wrap.
+			r.Skipped++
+			continue
+		}
+		agg.Total = total
+		agg.Count++
+		r.Matches = append(r.Matches, tx)
+	}
+
+	for _, agg := range totals {
+		if agg.Count == 0 {
+			continue
+		}
+		agg.Average = average(agg.Total, agg.Count)
+		r.Aggregates = append(r.Aggregates, *agg)
+	}
+	sort.Slice(r.Aggregates, func(i, j int) bool {
+		a, b := r.Aggregates[i], r.Aggregates[j]
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return a.Direction == DirectionDebit && b.Direction != DirectionDebit
+	})
+	return r
+}
+
+// average is total divided by count, rounded half away from zero to the
+// currency's minor unit.
+func average(total money.Amount, count int) money.Amount {
+	if count == 0 {
+		return money.Amount{Currency: total.Currency}
+	}
+	n := int64(count)
+	minor := total.Minor / n
+	if rem := total.Minor % n; 2*rem >= n {
+		minor++
+	}
+	return money.Amount{Minor: minor, Currency: total.Currency}
+}
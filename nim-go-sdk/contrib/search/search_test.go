@@ -0,0 +1,203 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// fixture is a month of the user's transactions.
+var fixture = []executor.Transaction{
+	{ID: "coffee-1", Amount: "4.50", Currency: "USDC", Direction: "debit", Counterparty: "@beanery", Note: "Morning coffee"},
+	{ID: "coffee-2", Amount: "6.20", Currency: "USDC", Direction: "debit", Counterparty: "@beanery", Note: "COFFEE and cake"},
+	{ID: "coffee-3", Amount: "5.00", Currency: "USDC", Direction: "debit", Counterparty: "@kiosk", Note: "coffee"},
+	{ID: "bob-rent", Amount: "500.00", Currency: "USDC", Direction: "debit", Counterparty: "@bob", Note: "Rent share"},
+	{ID: "bob-dinner", Amount: "-32.10", Currency: "USDC", Counterparty: "@Bob", Note: "dinner"},
+	{ID: "bobby", Amount: "15.00", Currency: "USDC", Direction: "debit", Counterparty: "@bobby", Note: "tickets"},
+	{ID: "bob-refund", Amount: "20.00", Currency: "USDC", Direction: "credit", Counterparty: "@bob", Note: "dinner refund"},
+	{ID: "eur-coffee", Amount: "3.80", Currency: "EURC", Direction: "debit", Counterparty: "Café Central", Note: "coffee"},
+	{ID: "salary", Amount: "2500", Currency: "USDC", Direction: "credit", Counterparty: "Acme Payroll", Note: "Salary"},
+	{ID: "broken", Amount: "12abc", Currency: "USDC", Direction: "debit", Counterparty: "@bob", Note: "?"},
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name        string
+		filter      Filter
+		want        string // matching IDs
+		wantTotals  string // currency direction count total average, per aggregate
+		wantSkipped int
+	}{
+		{
+			name:        "no filter",
+			want:        "coffee-1,coffee-2,coffee-3,bob-rent,bob-dinner,bobby,bob-refund,eur-coffee,salary",
+			wantTotals:  "EURC debit 1 3.80 3.80; USDC debit 6 562.80 93.80; USDC credit 2 2520.00 1260.00",
+			wantSkipped: 1,
+		},
+		{
+			name:        "counterparty handle is exact",
+			filter:      Filter{Counterparty: "@BOB"},
+			want:        "bob-rent,bob-dinner,bob-refund",
+			wantTotals:  "USDC debit 2 532.10 266.05; USDC credit 1 20.00 20.00",
+			wantSkipped: 1,
+		},
+		{
+			name:       "counterparty text is a substring",
+			filter:     Filter{Counterparty: "café"},
+			want:       "eur-coffee",
+			wantTotals: "EURC debit 1 3.80 3.80",
+		},
+		{
+			name:       "note contains",
+			filter:     Filter{NoteContains: "Coffee"},
+			want:       "coffee-1,coffee-2,coffee-3,eur-coffee",
+			wantTotals: "EURC debit 1 3.80 3.80; USDC debit 3 15.70 5.23",
+		},
+		{
+			name:        "min amount is inclusive",
+			filter:      Filter{MinAmount: "32.1"},
+			want:        "bob-rent,bob-dinner,salary",
+			wantTotals:  "USDC debit 2 532.10 266.05; USDC credit 1 2500.00 2500.00",
+			wantSkipped: 1,
+		},
+		{
+			name:        "max amount is inclusive",
+			filter:      Filter{MaxAmount: "5"},
+			want:        "coffee-1,coffee-3,eur-coffee",
+			wantTotals:  "EURC debit 1 3.80 3.80; USDC debit 2 9.50 4.75",
+			wantSkipped: 1,
+		},
+		{
+			name:        "direction",
+			filter:      Filter{Direction: DirectionCredit},
+			want:        "bob-refund,salary",
+			wantTotals:  "USDC credit 2 2520.00 1260.00",
+			wantSkipped: 0,
+		},
+		{
+			name:       "coffee over $5",
+			filter:     Filter{NoteContains: "coffee", MinAmount: "5.01"},
+			want:       "coffee-2",
+			wantTotals: "USDC debit 1 6.20 6.20",
+		},
+		{
+			name:        "paid @bob",
+			filter:      Filter{Counterparty: "@bob", Direction: DirectionDebit},
+			want:        "bob-rent,bob-dinner",
+			wantTotals:  "USDC debit 2 532.10 266.05",
+			wantSkipped: 1,
+		},
+		{
+			name:       "amount range and note",
+			filter:     Filter{NoteContains: "dinner", MinAmount: "10", MaxAmount: "25"},
+			want:       "bob-refund",
+			wantTotals: "USDC credit 1 20.00 20.00",
+		},
+		{
+			name:   "nothing matches",
+			filter: Filter{Counterparty: "@alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			r := Search(fixture, tt.filter)
+			var ids []string
+			for _, tx := range r.Matches {
+				ids = append(ids, tx.ID)
+			}
+			var totals []string
+			for _, agg := range r.Aggregates {
+				totals = append(totals, fmt.Sprintf("%s %s %d %s %s", agg.Currency, agg.Direction, agg.Count, agg.Total.Decimal(), agg.Average.Decimal()))
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("matches = %s, want %s", got, tt.want)
+			}
+			if got := strings.Join(totals, "; "); got != tt.wantTotals {
+				t.Errorf("totals = %s, want %s", got, tt.wantTotals)
+			}
+			if r.Skipped != tt.wantSkipped {
+				t.Errorf("skipped = %d, want %d", r.Skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	tests := []struct {
+		filter  Filter
+		wantErr string
+	}{
+		{Filter{MinAmount: "5", MaxAmount: "5"}, ""},
+		{Filter{MinAmount: "five"}, `invalid amount "five"`},
+		{Filter{MinAmount: "10", MaxAmount: "5"}, "min_amount 10 is more than max_amount 5"},
+		{Filter{Direction: "sideways"}, `unknown direction "sideways"`},
+	}
+	for _, tt := range tests {
+		err := tt.filter.Validate()
+		if (err == nil) != (tt.wantErr == "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("Validate(%+v) = %v, want %q", tt.filter, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSearchTool(t *testing.T) {
+	// Three pages of the fixture, then more the search won't reach.
+	pages := map[string]interface{}{
+		"":   executor.GetTransactionsResponse{Transactions: fixture[:4], NextCursor: "p2"},
+		"p2": executor.GetTransactionsResponse{Transactions: fixture[4:8], NextCursor: "p3"},
+		"p3": executor.GetTransactionsResponse{Transactions: fixture[8:], NextCursor: "p4"},
+		"p4": executor.GetTransactionsResponse{Transactions: []executor.Transaction{
+			{ID: "old-bob", Amount: "99", Currency: "USDC", Direction: "debit", Counterparty: "@bob"},
+		}},
+	}
+
+	tests := []struct {
+		name          string
+		maxPages      int
+		input         string
+		wantCount     int
+		wantTotal     string
+		wantTruncated bool
+	}{
+		{name: "all pages", input: `{"counterparty":"@bob","direction":"debit"}`, wantCount: 3, wantTotal: "631.10"},
+		{name: "page cap", maxPages: 3, input: `{"counterparty":"@bob","direction":"debit"}`, wantCount: 2, wantTotal: "532.10", wantTruncated: true},
+		{name: "numeric amounts", input: `{"note_contains":"coffee","min_amount":5.01,"max_amount":"10"}`, wantCount: 1, wantTotal: "6.20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := SearchTool(&txntest.Executor{Pages: pages}, tt.maxPages)
+			result, err := tool.Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(tt.input)})
+			if err != nil || !result.Success {
+				t.Fatalf("Execute = %+v, %v", result, err)
+			}
+			env := result.Data.(*core.Envelope)
+			data := env.Data.(map[string]interface{})
+			if data["count"] != tt.wantCount {
+				t.Errorf("count = %v, want %d", data["count"], tt.wantCount)
+			}
+			totals := data["totals"].([]map[string]interface{})
+			if len(totals) != 1 || totals[0]["total"] != tt.wantTotal {
+				t.Errorf("totals = %v, want a total of %s", totals, tt.wantTotal)
+			}
+			if truncated := len(env.Warnings) > 0; truncated != tt.wantTruncated {
+				t.Errorf("warnings = %v, want truncated %v", env.Warnings, tt.wantTruncated)
+			}
+		})
+	}
+
+	tool := SearchTool(&txntest.Executor{Pages: pages}, 0)
+	result, _ := tool.Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"min_amount":"lots"}`)})
+	if result.Success {
+		t.Error("invalid min_amount was accepted")
+	}
+}
@@ -0,0 +1,122 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// DefaultMaxPages caps the pages of transactions a search reads, of
+// txn.PageSize each, unless SearchTool is given another cap.
+const DefaultMaxPages = 10
+
+// maxListed is how many matching transactions a search lists. The totals
+// cover all of them.
+const maxListed = 50
+
+// Tools returns the search tools, reading transactions through exec:
+// search_transactions.
+func Tools(exec core.ToolExecutor, maxPages int) []core.Tool {
+	return []core.Tool{SearchTool(exec, maxPages)}
+}
+
+// SearchTool returns the search_transactions tool, which pages through the
+// user's transactions, at most maxPages pages of them (zero means
+// DefaultMaxPages), and returns those matching the model's filters with
+// their count, total and average.
+func SearchTool(exec core.ToolExecutor, maxPages int) core.Tool {
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+	return tools.New("search_transactions").
+		Description("Search the user's transactions by counterparty, note, amount range and direction, and total the matches. Use this for questions like \"how much did I pay @bob last month?\" or \"find the coffee transactions over $5\": the returned totals give the count, total and average of the matches per currency and direction, so don't add up transactions yourself.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"counterparty":  tools.StringProperty("Optional: the other party. A @handle matches that user exactly; other text matches counterparties containing it"),
+			"note_contains": tools.StringProperty("Optional: text the transaction's note contains, e.g. coffee"),
+			"min_amount":    tools.NumberProperty("Optional: smallest amount to match, inclusive, in the transaction's currency"),
+			"max_amount":    tools.NumberProperty("Optional: largest amount to match, inclusive, in the transaction's currency"),
+			"direction":     tools.StringEnumProperty("Optional: debit for money the user sent or spent, credit for money they received (default: both)", DirectionDebit, DirectionCredit),
+			"days":          tools.IntegerProperty("Optional: only search the last days days (default: all history the search reaches)"),
+		})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				Counterparty string      `json:"counterparty"`
+				NoteContains string      `json:"note_contains"`
+				MinAmount    json.Number `json:"min_amount"`
+				MaxAmount    json.Number `json:"max_amount"`
+				Direction    string      `json:"direction"`
+				Days         int         `json:"days"`
+			}
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+			}
+			filter := Filter{
+				Counterparty: input.Counterparty,
+				NoteContains: input.NoteContains,
+				MinAmount:    input.MinAmount.String(),
+				MaxAmount:    input.MaxAmount.String(),
+				Direction:    strings.ToLower(input.Direction),
+			}
+			if err := filter.Validate(); err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+			q := txn.Query{MaxPages: maxPages}
+			if input.Days > 0 {
+				q.Since = time.Now().AddDate(0, 0, -input.Days)
+			}
+
+			params.ReportProgress("searching transactions", 0)
+			txs, truncated, err := txn.FetchAll(ctx, exec, params.UserID, params.RequestID, q)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to fetch transactions: %v", err)}, nil
+			}
+			result := Search(txs, filter)
+
+			totals := make([]map[string]interface{}, len(result.Aggregates))
+			for i, agg := range result.Aggregates {
+				totals[i] = map[string]interface{}{
+					"currency":  agg.Currency,
+					"direction": agg.Direction,
+					"count":     agg.Count,
+					"total":     agg.Total.Decimal(),
+					"average":   agg.Average.Decimal(),
+				}
+			}
+			listed := result.Matches
+			if len(listed) > maxListed {
+				listed = listed[:maxListed]
+			}
+			data := map[string]interface{}{
+				"count":        len(result.Matches),
+				"totals":       totals,
+				"transactions": listed,
+				"searched":     len(txs),
+			}
+			if !q.Since.IsZero() {
+				data["since"] = q.Since.Format(executor.DateLayout)
+			}
+			if result.Skipped > 0 {
+				data["skipped_transactions"] = result.Skipped
+			}
+
+			env := core.NewEnvelope(data)
+			if len(result.Matches) == 0 {
+				env.WithStatus(core.StatusEmpty)
+			}
+			if truncated {
+				env.WithWarning(fmt.Sprintf("Only the most recent %d transactions were searched, the most one search reads, so older matches are missing from the results and totals", len(txs)))
+			}
+			if len(listed) < len(result.Matches) {
+				env.WithWarning(fmt.Sprintf("Only the first %d of %d matches are listed; the totals cover all of them", len(listed), len(result.Matches)))
+			}
+			return env.Result(), nil
+		}).
+		Build()
+}
@@ -0,0 +1,352 @@
+// Package txn reads Liminal account data through a core.ToolExecutor:
+// transactions, wallet balances, savings positions, and vault rates.
+// The contrib feature packages share these helpers instead of each parsing
+// tool responses their own way.
+package txn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/money"
+)
+
+// Transaction is a single ledger entry, as returned by get_transactions.
+type Transaction = executor.Transaction
+
+// Transaction sources.
+const (
+	SourceLiminal  = "liminal"  // recorded by Liminal
+	SourceExternal = "external" // imported from another bank
+)
+
+// TypeExternal is the Type of transactions imported from another bank.
+const TypeExternal = "external"
+
+// Source returns where the transaction came from: SourceExternal for
+// imported transactions, otherwise SourceLiminal.
+func Source(tx Transaction) string {
+	if tx.Type == TypeExternal {
+		return SourceExternal
+	}
+	return SourceLiminal
+}
+
+// Amount parses a decimal amount string. Unparseable or empty strings are zero.
+func Amount(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// IsDebit reports whether the transaction is money leaving the account.
+func IsDebit(tx Transaction) bool {
+	return tx.IsDebit()
+}
+
+// IsCredit reports whether the transaction is money entering the account.
+func IsCredit(tx Transaction) bool {
+	return tx.Direction == "credit"
+}
+
+// Spent returns the transaction's outgoing amount as a positive number,
+// or zero for incoming transactions.
+func Spent(tx Transaction) float64 {
+	if !IsDebit(tx) {
+		return 0
+	}
+	amount := tx.AmountFloat()
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
+
+// Money parses the transaction's signed amount in its currency.
+func Money(tx Transaction) (money.Amount, error) {
+	return money.ParseAmount(tx.Amount, tx.Currency)
+}
+
+// SpentMoney is Spent as an exact amount. It fails if the transaction is
+// outgoing but its amount can't be parsed.
+func SpentMoney(tx Transaction) (money.Amount, error) {
+	if !IsDebit(tx) {
+		return money.Amount{Currency: tx.Currency}, nil
+	}
+	amount, err := Money(tx)
+	if err != nil {
+		return money.Amount{}, err
+	}
+	return amount.Abs(), nil
+}
+
+// CreatedAt returns when the transaction was created.
+// Returns the zero time if the timestamp is missing or malformed.
+func CreatedAt(tx Transaction) time.Time {
+	return tx.CreatedTime()
+}
+
+// SpentBetween totals outgoing transactions in currency created in [start, end).
+// An empty currency matches every currency.
+func SpentBetween(txs []Transaction, currency string, start, end time.Time) float64 {
+	var total float64
+	for _, tx := range txs {
+		at := CreatedAt(tx)
+		if at.IsZero() || at.Before(start) || !at.Before(end) {
+			continue
+		}
+		if currency != "" && tx.Currency != currency {
+			continue
+		}
+		total += Spent(tx)
+	}
+	return total
+}
+
+// Notes returns the non-empty notes of outgoing transactions.
+func Notes(txs []Transaction) []string {
+	var notes []string
+	for _, tx := range txs {
+		if IsDebit(tx) && tx.Note != "" {
+			notes = append(notes, tx.Note)
+		}
+	}
+	return notes
+}
+
+// Fetch returns up to limit of the user's most recent transactions.
+func Fetch(ctx context.Context, exec core.ToolExecutor, userID, requestID string, limit int) ([]Transaction, error) {
+	return FetchIncluding(ctx, exec, userID, requestID, limit, false)
+}
+
+// FetchIncluding is Fetch, also asking for transactions imported from other
+// banks if external is true. They are merged in only if exec supports it
+// (see the imports package); use Source to tell them apart.
+func FetchIncluding(ctx context.Context, exec core.ToolExecutor, userID, requestID string, limit int, external bool) ([]Transaction, error) {
+	return FetchQuery(ctx, exec, userID, requestID, Query{Limit: limit, IncludeExternal: external})
+}
+
+// Query selects transactions for FetchQuery and FetchAll.
+type Query struct {
+	// Limit is how many of the most recent matching transactions to return.
+	// FetchAll treats zero as no limit.
+	Limit int
+
+	// Since and Until bound when the transactions were created:
+	// [Since, Until). A zero bound is open.
+	Since time.Time
+	Until time.Time
+
+	// IncludeExternal also asks for imported transactions; see FetchIncluding.
+	IncludeExternal bool
+
+	// Currency, if set, keeps only transactions in that currency, compared
+	// case-insensitively. get_transactions can't filter by currency, so
+	// FetchQuery may return fewer than Limit; FetchAll keeps paging.
+	Currency string
+
+	// MaxPages caps the pages FetchAll reads, however few of them match.
+	// Zero means no cap. FetchQuery reads one page.
+	MaxPages int
+}
+
+// matches reports whether tx is in q's currency.
+func (q Query) matches(tx Transaction) bool {
+	return q.Currency == "" || strings.EqualFold(tx.Currency, q.Currency)
+}
+
+// input is the get_transactions input for q, asking for limit transactions.
+// The window is widened to whole days in UTC.
+func (q Query) input(limit int) map[string]interface{} {
+	input := map[string]interface{}{"limit": limit}
+	if q.IncludeExternal {
+		input["include_external"] = true
+	}
+	if !q.Since.IsZero() {
+		input["start_date"] = q.Since.UTC().Format(executor.DateLayout)
+	}
+	if !q.Until.IsZero() {
+		input["end_date"] = q.Until.Add(-time.Nanosecond).UTC().Format(executor.DateLayout)
+	}
+	return input
+}
+
+// FetchQuery returns up to q.Limit of the user's most recent transactions
+// matching q. The window is requested as get_transactions' start_date and
+// end_date, widened to whole days in UTC, then applied exactly.
+func FetchQuery(ctx context.Context, exec core.ToolExecutor, userID, requestID string, q Query) ([]Transaction, error) {
+	var resp executor.GetTransactionsResponse
+	if err := call(ctx, exec, userID, requestID, "get_transactions", q.input(q.Limit), &resp); err != nil {
+		return nil, err
+	}
+	txs := resp.Transactions
+	if !q.Since.IsZero() || !q.Until.IsZero() {
+		txs = executor.FilterTransactions(txs, executor.DateRange{Start: q.Since, End: q.Until})
+	}
+	if q.Currency == "" {
+		return txs, nil
+	}
+	var matching []Transaction
+	for _, tx := range txs {
+		if q.matches(tx) {
+			matching = append(matching, tx)
+		}
+	}
+	return matching, nil
+}
+
+// PageSize is how many transactions FetchAll asks get_transactions for at a
+// time.
+const PageSize = 100
+
+// ErrCursorLoop is returned by FetchAll when get_transactions hands back a
+// cursor it has already returned, which would otherwise page forever.
+var ErrCursorLoop = errors.New("get_transactions returned a cursor it had already returned")
+
+// FetchAll pages through the user's transactions matching q, following
+// get_transactions' nextCursor until it runs out, q.Limit transactions
+// have been read, or q.MaxPages pages have. truncated reports whether it
+// stopped at a cap with more left to read. A transaction returned twice is
+// only kept once.
+func FetchAll(ctx context.Context, exec core.ToolExecutor, userID, requestID string, q Query) (txs []Transaction, truncated bool, err error) {
+	window := executor.DateRange{Start: q.Since, End: q.Until}
+	seenIDs := make(map[string]bool)
+	seenCursors := make(map[string]bool)
+	cursor := ""
+	for pages := 1; ; pages++ {
+		input := q.input(PageSize)
+		if cursor != "" {
+			input["cursor"] = cursor
+		}
+		var resp executor.GetTransactionsResponse
+		if err := call(ctx, exec, userID, requestID, "get_transactions", input, &resp); err != nil {
+			return nil, false, err
+		}
+
+		page := resp.Transactions
+		if !window.IsZero() {
+			page = executor.FilterTransactions(page, window)
+		}
+		for _, tx := range page {
+			if !q.matches(tx) {
+				continue
+			}
+			if tx.ID != "" {
+				if seenIDs[tx.ID] {
+					continue
+				}
+				seenIDs[tx.ID] = true
+			}
+			if q.Limit > 0 && len(txs) == q.Limit {
+				return txs, true, nil
+			}
+			txs = append(txs, tx)
+		}
+
+		if resp.NextCursor == "" {
+			return txs, false, nil
+		}
+		if q.MaxPages > 0 && pages == q.MaxPages {
+			return txs, true, nil
+		}
+		if seenCursors[resp.NextCursor] {
+			return nil, false, ErrCursorLoop
+		}
+		seenCursors[resp.NextCursor] = true
+		cursor = resp.NextCursor
+	}
+}
+
+// Balances returns the user's wallet balances by currency.
+func Balances(ctx context.Context, exec core.ToolExecutor, userID, requestID string) (map[string]float64, error) {
+	var resp executor.GetBalanceResponse
+	if err := call(ctx, exec, userID, requestID, "get_balance", nil, &resp); err != nil {
+		return nil, err
+	}
+	balances := make(map[string]float64, len(resp.Balances))
+	for _, b := range resp.Balances {
+		balances[b.Currency] += Amount(b.Amount)
+	}
+	return balances, nil
+}
+
+// Savings returns the current value of the user's savings positions by currency.
+func Savings(ctx context.Context, exec core.ToolExecutor, userID, requestID string) (map[string]float64, error) {
+	var resp executor.GetSavingsBalanceResponse
+	if err := call(ctx, exec, userID, requestID, "get_savings_balance", nil, &resp); err != nil {
+		return nil, err
+	}
+	savings := make(map[string]float64, len(resp.Positions))
+	for _, p := range resp.Positions {
+		savings[p.Currency] += Amount(p.CurrentValue)
+	}
+	return savings, nil
+}
+
+// SavingsHistory returns the user's daily savings balances and earnings over
+// the last days days, in all currencies.
+func SavingsHistory(ctx context.Context, exec core.ToolExecutor, userID, requestID string, days int) ([]executor.SavingsHistoryPoint, error) {
+	var resp executor.GetSavingsHistoryResponse
+	if err := call(ctx, exec, userID, requestID, "get_savings_history", map[string]interface{}{"days": days}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Points, nil
+}
+
+// VaultRates returns the current savings vault APYs, in percent, by currency.
+func VaultRates(ctx context.Context, exec core.ToolExecutor, userID, requestID string) (map[string]float64, error) {
+	var resp executor.GetVaultRatesResponse
+	if err := call(ctx, exec, userID, requestID, "get_vault_rates", nil, &resp); err != nil {
+		return nil, err
+	}
+	rates := make(map[string]float64, len(resp.Vaults))
+	for _, v := range resp.Vaults {
+		rates[v.Currency] = Amount(v.APY)
+	}
+	return rates, nil
+}
+
+// Total sums amounts across currencies.
+func Total(amounts map[string]float64) float64 {
+	var total float64
+	for _, a := range amounts {
+		total += a
+	}
+	return total
+}
+
+// call executes a Liminal tool and decodes its data into out.
+func call(ctx context.Context, exec core.ToolExecutor, userID, requestID, tool string, input interface{}, out interface{}) error {
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	resp, err := exec.Execute(ctx, &core.ExecuteRequest{
+		UserID:    userID,
+		Tool:      tool,
+		Input:     raw,
+		RequestID: requestID,
+	})
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", tool, err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s failed: %s", tool, resp.Error)
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", tool, err)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package txn_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+func TestSpent(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   txn.Transaction
+		want float64
+	}{
+		{"debit", txn.Transaction{Amount: "12.50", Direction: "debit"}, 12.5},
+		{"negative amount", txn.Transaction{Amount: "-3"}, 3},
+		{"credit", txn.Transaction{Amount: "20", Direction: "credit"}, 0},
+		{"malformed", txn.Transaction{Amount: "abc", Direction: "debit"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := txn.Spent(tt.tx); got != tt.want {
+				t.Errorf("Spent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpentBetween(t *testing.T) {
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	txs := []txn.Transaction{
+		{Amount: "10", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-02T00:00:00Z"},
+		{Amount: "5", Currency: "EURC", Direction: "debit", CreatedAt: "2026-03-03T12:00:00Z"},
+		{Amount: "7", Currency: "USDC", Direction: "credit", CreatedAt: "2026-03-04T12:00:00Z"},
+		{Amount: "9", Currency: "USDC", Direction: "debit", CreatedAt: "2026-03-09T00:00:00Z"},
+		{Amount: "4", Currency: "USDC", Direction: "debit", CreatedAt: "not a time"},
+	}
+
+	if got := txn.SpentBetween(txs, "USDC", start, end); got != 10 {
+		t.Errorf("SpentBetween(USDC) = %v, want 10", got)
+	}
+	if got := txn.SpentBetween(txs, "", start, end); got != 15 {
+		t.Errorf("SpentBetween(all) = %v, want 15", got)
+	}
+}
+
+func TestExecutorHelpers(t *testing.T) {
+	ctx := context.Background()
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_balance": executor.GetBalanceResponse{Balances: []executor.WalletBalance{
+			{Currency: "USD", Amount: "100.50"},
+			{Currency: "EUR", Amount: "20"},
+		}},
+		"get_savings_balance": executor.GetSavingsBalanceResponse{Positions: []executor.SavingsPosition{
+			{Currency: "USD", Deposited: "50", CurrentValue: "51.25"},
+		}},
+		"get_vault_rates": executor.GetVaultRatesResponse{Vaults: []executor.VaultRate{
+			{Currency: "USD", APY: "4.5"},
+		}},
+	}}
+
+	balances, err := txn.Balances(ctx, exec, "user-1", "req-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances["USD"] != 100.5 || txn.Total(balances) != 120.5 {
+		t.Errorf("Balances() = %v", balances)
+	}
+
+	savings, err := txn.Savings(ctx, exec, "user-1", "req-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if savings["USD"] != 51.25 {
+		t.Errorf("Savings() = %v, want current value 51.25", savings)
+	}
+
+	rates, err := txn.VaultRates(ctx, exec, "user-1", "req-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates["USD"] != 4.5 {
+		t.Errorf("VaultRates() = %v", rates)
+	}
+
+	if _, err := txn.Fetch(ctx, exec, "user-1", "req-1", 10); err == nil {
+		t.Error("Fetch() without a get_transactions response should fail")
+	}
+
+	for _, req := range exec.Requests() {
+		if req.UserID != "user-1" || req.RequestID != "req-1" {
+			t.Errorf("request %s sent as %s/%s", req.Tool, req.UserID, req.RequestID)
+		}
+	}
+}
+
+func TestFetchQuery(t *testing.T) {
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"get_transactions": executor.GetTransactionsResponse{Transactions: []executor.Transaction{
+			{ID: "next-week", CreatedAt: "2024-03-11T00:00:00Z"},
+			{ID: "sunday", CreatedAt: "2024-03-10T23:59:59Z"},
+			{ID: "monday", CreatedAt: "2024-03-04T00:00:00Z"},
+			{ID: "last-week", CreatedAt: "2024-03-03T23:59:59Z"},
+		}},
+	}}
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	txs, err := txn.FetchQuery(context.Background(), exec, "user-1", "req-1", txn.Query{
+		Limit: 100,
+		Since: start,
+		Until: start.AddDate(0, 0, 7),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, tx := range txs {
+		ids = append(ids, tx.ID)
+	}
+	if got := strings.Join(ids, ","); got != "sunday,monday" {
+		t.Errorf("FetchQuery() = %s, want sunday,monday", got)
+	}
+
+	var input map[string]interface{}
+	if err := json.Unmarshal(exec.Requests()[0].Input, &input); err != nil {
+		t.Fatal(err)
+	}
+	if input["start_date"] != "2024-03-04" || input["end_date"] != "2024-03-10" || input["limit"] != float64(100) {
+		t.Errorf("get_transactions input = %v, want the week as whole dates", input)
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	page := func(next string, ids ...string) executor.GetTransactionsResponse {
+		resp := executor.GetTransactionsResponse{NextCursor: next}
+		for _, id := range ids {
+			resp.Transactions = append(resp.Transactions, executor.Transaction{ID: id, Currency: "USD"})
+		}
+		return resp
+	}
+	tests := []struct {
+		name          string
+		pages         map[string]interface{}
+		limit         int
+		maxPages      int
+		want          string
+		wantTruncated bool
+		wantErr       error
+	}{
+		{
+			name:  "follows cursors",
+			pages: map[string]interface{}{"": page("p2", "a", "b"), "p2": page("p3", "c"), "p3": page("", "d")},
+			want:  "a,b,c,d",
+		},
+		{
+			name:  "drops repeats",
+			pages: map[string]interface{}{"": page("p2", "a", "b"), "p2": page("", "b", "c")},
+			want:  "a,b,c",
+		},
+		{
+			name:          "row cap",
+			pages:         map[string]interface{}{"": page("p2", "a", "b"), "p2": page("", "c")},
+			limit:         2,
+			want:          "a,b",
+			wantTruncated: true,
+		},
+		{
+			name:  "exactly the row cap",
+			pages: map[string]interface{}{"": page("p2", "a"), "p2": page("", "b")},
+			limit: 2,
+			want:  "a,b",
+		},
+		{
+			name:          "page cap",
+			pages:         map[string]interface{}{"": page("p2", "a", "b"), "p2": page("p3", "c"), "p3": page("", "d")},
+			maxPages:      2,
+			want:          "a,b,c",
+			wantTruncated: true,
+		},
+		{
+			name:     "exactly the page cap",
+			pages:    map[string]interface{}{"": page("p2", "a"), "p2": page("", "b")},
+			maxPages: 2,
+			want:     "a,b",
+		},
+		{
+			name:    "cursor loop",
+			pages:   map[string]interface{}{"": page("p2", "a"), "p2": page("p3", "b"), "p3": page("p2", "c")},
+			wantErr: txn.ErrCursorLoop,
+		},
+		{
+			name:  "no transactions",
+			pages: map[string]interface{}{"": page("")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &txntest.Executor{Pages: tt.pages}
+			txs, truncated, err := txn.FetchAll(context.Background(), exec, "user-1", "req-1", txn.Query{Limit: tt.limit, MaxPages: tt.maxPages})
+			if err != tt.wantErr {
+				t.Fatalf("FetchAll() error = %v, want %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, tx := range txs {
+				ids = append(ids, tx.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.want || truncated != tt.wantTruncated {
+				t.Errorf("FetchAll() = %s, truncated %v; want %s, %v", got, truncated, tt.want, tt.wantTruncated)
+			}
+		})
+	}
+
+	exec := &txntest.Executor{Pages: map[string]interface{}{"": executor.GetTransactionsResponse{Transactions: []executor.Transaction{
+		{ID: "a", Currency: "USD"}, {ID: "b", Currency: "usdc"}, {ID: "c", Currency: "EUR"},
+	}}}}
+	txs, _, err := txn.FetchAll(context.Background(), exec, "user-1", "req-1", txn.Query{Currency: "USDC"})
+	if err != nil || len(txs) != 1 || txs[0].ID != "b" {
+		t.Errorf("FetchAll(USDC) = %v, %v; want only b", txs, err)
+	}
+}
@@ -0,0 +1,73 @@
+// Package txntest provides a fake core.ToolExecutor for testing code built on
+// the contrib packages.
+package txntest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Executor answers read tools with canned responses, keyed by tool name.
+// Responses are marshaled to JSON, so executor response types
+// (e.g. executor.GetBalanceResponse) work directly. Tools without a response
+// fail, and every request is recorded.
+type Executor struct {
+	Responses map[string]interface{}
+
+	// Pages, if set, answers get_transactions instead, with the page for
+	// the request's cursor: "" for the first page, then each page's
+	// nextCursor.
+	Pages map[string]interface{}
+
+	mu       sync.Mutex
+	requests []*core.ExecuteRequest
+}
+
+// Requests returns the requests executed so far.
+func (e *Executor) Requests() []*core.ExecuteRequest {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*core.ExecuteRequest(nil), e.requests...)
+}
+
+func (e *Executor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	e.mu.Lock()
+	e.requests = append(e.requests, req)
+	e.mu.Unlock()
+
+	resp, ok := e.Responses[req.Tool]
+	if req.Tool == "get_transactions" && e.Pages != nil {
+		var input struct {
+			Cursor string `json:"cursor"`
+		}
+		json.Unmarshal(req.Input, &input)
+		resp, ok = e.Pages[input.Cursor]
+	}
+	if !ok {
+		return &core.ExecuteResponse{Success: false, Error: fmt.Sprintf("no response for %s", req.Tool)}, nil
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	return &core.ExecuteResponse{Success: true, Data: data}, nil
+}
+
+func (e *Executor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	return e.Execute(ctx, req)
+}
+
+func (e *Executor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	return nil, fmt.Errorf("confirm not supported")
+}
+
+func (e *Executor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	return nil
+}
+
+// Verify Executor implements core.ToolExecutor.
+var _ core.ToolExecutor = (*Executor)(nil)
@@ -27,6 +27,7 @@ type Capabilities struct {
 	AvailableTools []string
 
 	// Model is the Claude model to use (e.g., "claude-sonnet-4-20250514").
+	// Empty means the engine's default model.
 	Model string
 
 	// MaxTokens is the maximum response tokens per turn.
@@ -62,9 +63,14 @@ type Output struct {
 	// Text is the agent's text response.
 	Text string
 
-	// PendingAction is set when Type is OutputConfirmationNeeded.
+	// PendingAction is set when Type is OutputConfirmationNeeded and the
+	// model asked for one write.
 	PendingAction *PendingAction
 
+	// PendingGroup is set instead of PendingAction when the model asked for
+	// several writes in one turn, to be confirmed together.
+	PendingGroup *PendingActionGroup
+
 	// ToolsUsed records all tools invoked during this run.
 	ToolsUsed []ToolExecution
 
@@ -96,7 +102,6 @@ const (
 func DefaultCapabilities() *Capabilities {
 	return &Capabilities{
 		CanRequestConfirmation: true,
-		Model:                  "claude-sonnet-4-20250514",
 		MaxTokens:              4096,
 		MaxTurns:               20,
 	}
@@ -106,7 +111,6 @@ func DefaultCapabilities() *Capabilities {
 func SubAgentCapabilities() *Capabilities {
 	return &Capabilities{
 		CanRequestConfirmation: false, // Sub-agents cannot request confirmation
-		Model:                  "claude-sonnet-4-20250514",
 		MaxTokens:              2048,
 		MaxTurns:               10,
 	}
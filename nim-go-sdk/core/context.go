@@ -0,0 +1,165 @@
+package core
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// contextKey is an unexported type for context keys defined in this package.
+// Using a private type prevents collisions with keys from other packages.
+type contextKey int
+
+const (
+	identityKey contextKey = iota
+	requestIDKey
+	turnDeadlineKey
+	credentialKey
+	preferencesKey
+	userLimitsKey
+)
+
+// Identity identifies the user and conversation a request is running on behalf of.
+// It is attached to the context passed to every tool and executor call.
+type Identity struct {
+	// UserID is the authenticated user.
+	UserID string
+
+	// SessionID identifies the agent session.
+	SessionID string
+
+	// ConversationID identifies the conversation.
+	ConversationID string
+}
+
+// WithIdentity returns a copy of ctx carrying the given identity.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey, id)
+}
+
+// IdentityFromContext returns the identity attached to ctx, if any.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey).(Identity)
+	return id, ok
+}
+
+// WithRequestID returns a copy of ctx carrying the given request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID attached to ctx, or "" if none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithCredential returns a copy of ctx carrying the bearer token the user
+// authenticated with. Executors that call APIs on the user's behalf send it
+// in preference to any token they were configured with, so concurrent users
+// sharing an executor each use their own.
+func WithCredential(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, credentialKey, token)
+}
+
+// CredentialFromContext returns the bearer token attached to ctx, or "" if none.
+func CredentialFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(credentialKey).(string)
+	return token
+}
+
+// CredentialFunc returns the bearer token to act for a user with when no
+// connection supplies one, such as for scheduled transfers and jobs that run
+// in the background.
+type CredentialFunc func(ctx context.Context, userID string) (string, error)
+
+// Attach returns ctx carrying userID's credential. A credential already on
+// ctx is kept, and a nil CredentialFunc returns ctx unchanged.
+func (f CredentialFunc) Attach(ctx context.Context, userID string) (context.Context, error) {
+	if f == nil || CredentialFromContext(ctx) != "" {
+		return ctx, nil
+	}
+	token, err := f(ctx, userID)
+	if err != nil {
+		return ctx, err
+	}
+	return WithCredential(ctx, token), nil
+}
+
+// WithPreferences returns a copy of ctx carrying the user's preferences,
+// for tools run outside an agent run, such as confirmed writes.
+func WithPreferences(ctx context.Context, prefs *UserPreferences) context.Context {
+	return context.WithValue(ctx, preferencesKey, prefs)
+}
+
+// PreferencesFromContext returns the preferences attached to ctx, if any.
+func PreferencesFromContext(ctx context.Context) (*UserPreferences, bool) {
+	prefs, ok := ctx.Value(preferencesKey).(*UserPreferences)
+	return prefs, ok && prefs != nil
+}
+
+// PreferencesFor returns the preferences of the user a tool runs for: its
+// run's, or else those attached to ctx, or else DefaultPreferences.
+// Tools must not modify them.
+func PreferencesFor(ctx context.Context, params *ToolParams) *UserPreferences {
+	if params != nil && params.Context != nil && params.Context.Preferences != nil {
+		return params.Context.Preferences
+	}
+	if prefs, ok := PreferencesFromContext(ctx); ok {
+		return prefs
+	}
+	return DefaultPreferences()
+}
+
+// WithUserLimits returns a copy of ctx carrying the user's transfer limits,
+// for tool policies to enforce (see engine.ToolPolicy).
+func WithUserLimits(ctx context.Context, limits *UserLimits) context.Context {
+	return context.WithValue(ctx, userLimitsKey, limits)
+}
+
+// UserLimitsFromContext returns the user limits attached to ctx, if any.
+func UserLimitsFromContext(ctx context.Context) (*UserLimits, bool) {
+	limits, ok := ctx.Value(userLimitsKey).(*UserLimits)
+	return limits, ok && limits != nil
+}
+
+// WithTurnDeadline returns a copy of ctx that is cancelled at deadline and
+// records the deadline so tools can read it with TurnDeadlineFromContext.
+func WithTurnDeadline(ctx context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	return context.WithValue(ctx, turnDeadlineKey, deadline), cancel
+}
+
+// TurnDeadlineFromContext returns the per-turn deadline attached to ctx, if any.
+func TurnDeadlineFromContext(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Value(turnDeadlineKey).(time.Time)
+	return deadline, ok
+}
+
+// debugContext enables runtime checks for detached contexts.
+var debugContext atomic.Bool
+
+// SetDebug enables or disables debug checks.
+// When enabled, executors log a warning if they are called with a context
+// that was not derived from an agent request (e.g. context.Background()).
+func SetDebug(enabled bool) {
+	debugContext.Store(enabled)
+}
+
+// Debug reports whether debug checks are enabled.
+func Debug() bool {
+	return debugContext.Load()
+}
+
+// CheckContext logs a warning in debug mode when ctx carries no identity,
+// which usually means a tool created its own context instead of using the
+// one it was given. op names the call being made, for the log message.
+func CheckContext(ctx context.Context, op string) {
+	if !Debug() {
+		return
+	}
+	if _, ok := IdentityFromContext(ctx); !ok {
+		log.Printf("nim: %s called with a context that carries no identity; tools should pass the ctx they receive instead of context.Background()", op)
+	}
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIdentityFromContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		want   Identity
+		wantOK bool
+	}{
+		{
+			name:   "no identity",
+			ctx:    context.Background(),
+			wantOK: false,
+		},
+		{
+			name: "identity attached",
+			ctx: WithIdentity(context.Background(), Identity{
+				UserID:         "user-1",
+				SessionID:      "sess-1",
+				ConversationID: "conv-1",
+			}),
+			want: Identity{
+				UserID:         "user-1",
+				SessionID:      "sess-1",
+				ConversationID: "conv-1",
+			},
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := IdentityFromContext(tt.ctx)
+			if ok != tt.wantOK {
+				t.Fatalf("IdentityFromContext() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("IdentityFromContext() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	if got := RequestIDFromContext(context.Background()); got != "" {
+		t.Errorf("RequestIDFromContext() = %q, want empty", got)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	if got := RequestIDFromContext(ctx); got != "req-1" {
+		t.Errorf("RequestIDFromContext() = %q, want %q", got, "req-1")
+	}
+}
+
+func TestCredentialFunc_Attach(t *testing.T) {
+	creds := CredentialFunc(func(ctx context.Context, userID string) (string, error) {
+		return "token-" + userID, nil
+	})
+
+	ctx, err := creds.Attach(context.Background(), "user-1")
+	if err != nil || CredentialFromContext(ctx) != "token-user-1" {
+		t.Errorf("Attach() credential = %q, %v, want token-user-1", CredentialFromContext(ctx), err)
+	}
+
+	// A connection's own credential wins.
+	ctx, _ = creds.Attach(WithCredential(context.Background(), "conn"), "user-1")
+	if got := CredentialFromContext(ctx); got != "conn" {
+		t.Errorf("Attach() replaced the connection's credential with %q", got)
+	}
+
+	ctx, _ = CredentialFunc(nil).Attach(context.Background(), "user-1")
+	if got := CredentialFromContext(ctx); got != "" {
+		t.Errorf("nil Attach() credential = %q, want empty", got)
+	}
+}
+
+func TestWithTurnDeadline(t *testing.T) {
+	deadline := time.Now().Add(50 * time.Millisecond)
+	ctx, cancel := WithTurnDeadline(context.Background(), deadline)
+	defer cancel()
+
+	got, ok := TurnDeadlineFromContext(ctx)
+	if !ok || !got.Equal(deadline) {
+		t.Errorf("TurnDeadlineFromContext() = %v, %v, want %v, true", got, ok, deadline)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context was not cancelled at the turn deadline")
+	}
+}
+
+func TestResolveRecipient(t *testing.T) {
+	prefs := &UserPreferences{Shortcuts: map[string]string{"mom": "user_abc123", "alice": "user_mom_alice"}}
+	tests := []struct {
+		recipient string
+		want      string
+		wantOK    bool
+	}{
+		{"mom", "user_abc123", true},
+		{"Mom ", "user_abc123", true},
+		// A nickname that is also someone's display tag: the tag wins.
+		{"@alice", "", false},
+		{"alice", "user_mom_alice", true},
+		{"@mom", "", false},
+		{"dad", "", false},
+		{"user_xyz789", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.recipient, func(t *testing.T) {
+			got, ok := prefs.ResolveRecipient(tt.recipient)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ResolveRecipient(%q) = %q, %v, want %q, %v", tt.recipient, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	var none *UserPreferences
+	if _, ok := none.ResolveRecipient("mom"); ok {
+		t.Error("nil preferences resolved a shortcut")
+	}
+	if none.ShortcutsPrompt() != "" || DefaultPreferences().ShortcutsPrompt() != "" {
+		t.Error("expected no shortcuts prompt without shortcuts")
+	}
+}
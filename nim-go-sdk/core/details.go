@@ -0,0 +1,69 @@
+package core
+
+import "encoding/json"
+
+// Details describes a pending action in machine-readable fields, so clients
+// can render a review card rather than only the Summary text. Values are
+// decoded JSON. Money movements use the canonical keys below.
+type Details map[string]interface{}
+
+// Canonical Details keys.
+const (
+	DetailAmount    = "amount"    // string, e.g. "50.00"
+	DetailCurrency  = "currency"  // e.g. "USD"
+	DetailRecipient = "recipient" // display tag or user ID of the other user
+	DetailDirection = "direction" // one of the Direction constants
+	DetailNote      = "note"
+)
+
+// Directions of money movements, as Details' DetailDirection.
+const (
+	DirectionOutgoing    = "outgoing"     // from the user to someone else
+	DirectionIncoming    = "incoming"     // from someone else to the user
+	DirectionToSavings   = "to_savings"   // from the user's wallet into savings
+	DirectionFromSavings = "from_savings" // from savings into the user's wallet
+)
+
+// DetailsFunc computes the details of a pending action from its input.
+type DetailsFunc func(input json.RawMessage) Details
+
+// DetailedTool is implemented by tools that describe their pending actions
+// in Details. Tools that don't implement it, or return nil, are described
+// by their input (see InputDetails).
+type DetailedTool interface {
+	Tool
+
+	// SummaryDetails returns the details of the action input would run.
+	SummaryDetails(input json.RawMessage) Details
+}
+
+// InputDetails is the default Details of an action: its input's fields, as
+// is. It returns nil if the input isn't a JSON object or has more than
+// MaxSummaryValues values.
+func InputDetails(input json.RawMessage) Details {
+	var details Details
+	if json.Unmarshal(input, &details) != nil || countValues(map[string]interface{}(details), MaxSummaryValues) > MaxSummaryValues {
+		return nil
+	}
+	return details
+}
+
+// SummaryDetailsOf returns the details of the action tool would run with
+// input: the tool's own, if it's a DetailedTool, or else InputDetails.
+func SummaryDetailsOf(tool Tool, input json.RawMessage) Details {
+	if detailed, ok := tool.(DetailedTool); ok {
+		if details := detailed.SummaryDetails(input); details != nil {
+			return details
+		}
+	}
+	return InputDetails(input)
+}
+
+// summaryDetails returns def's details of input, or nil if it has no
+// SummaryDetails.
+func summaryDetails(def ToolDefinition, input json.RawMessage) Details {
+	if def.SummaryDetails == nil {
+		return nil
+	}
+	return def.SummaryDetails(input)
+}
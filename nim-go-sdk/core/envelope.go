@@ -0,0 +1,144 @@
+package core
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+)
+
+// EnvelopeStatus says how complete an Envelope's data is.
+type EnvelopeStatus string
+
+// Envelope statuses.
+const (
+	// StatusOK results are complete.
+	StatusOK EnvelopeStatus = "ok"
+
+	// StatusPartial results are usable but incomplete; Warnings say why.
+	StatusPartial EnvelopeStatus = "partial"
+
+	// StatusEmpty results found nothing, e.g. no transactions in the period.
+	StatusEmpty EnvelopeStatus = "empty"
+)
+
+// Figure is a monetary amount in a tool result, in a form clients can use
+// without parsing display text.
+type Figure struct {
+	// Name identifies the figure within the result, e.g. "balance" or
+	// "total_spent".
+	Name string `json:"name"`
+
+	// Amount is a plain decimal string, e.g. "1250.5", with no currency
+	// symbol or grouping separators.
+	Amount string `json:"amount"`
+
+	// Currency is the currency code, e.g. "USD" or "USDC".
+	Currency string `json:"currency"`
+}
+
+// NewFigure creates a Figure from a float amount, rounded to six decimal
+// places so floating point noise doesn't reach clients.
+func NewFigure(name string, amount float64, currency string) Figure {
+	rounded := math.Round(amount*1e6) / 1e6
+	return Figure{Name: name, Amount: strconv.FormatFloat(rounded, 'f', -1, 64), Currency: currency}
+}
+
+// Envelope is the recommended shape of a tool result's Data. Alongside the
+// tool's own data it carries a machine-readable status, warnings, the
+// monetary figures in the result, and the artifacts it produced, so servers
+// and clients can handle every tool's results the same way.
+//
+// The model is sent an Envelope as JSON, like any other result. Tools that
+// don't return one keep working unchanged.
+type Envelope struct {
+	// Status says how complete Data is.
+	Status EnvelopeStatus `json:"status"`
+
+	// Data is the tool's own result.
+	Data interface{} `json:"data,omitempty"`
+
+	// Warnings are caveats about Data, e.g. that it was truncated.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Figures are the monetary amounts in Data.
+	Figures []Figure `json:"figures,omitempty"`
+
+	// Artifacts are the files the tool produced, e.g. a chart.
+	Artifacts []artifact.Ref `json:"artifacts,omitempty"`
+}
+
+// NewEnvelope wraps data in an Envelope with StatusOK.
+func NewEnvelope(data interface{}) *Envelope {
+	return &Envelope{Status: StatusOK, Data: data}
+}
+
+// WithStatus sets the envelope's status.
+func (e *Envelope) WithStatus(status EnvelopeStatus) *Envelope {
+	e.Status = status
+	return e
+}
+
+// WithWarning adds a warning and marks the envelope StatusPartial, unless
+// it is StatusEmpty.
+func (e *Envelope) WithWarning(warning string) *Envelope {
+	e.Warnings = append(e.Warnings, warning)
+	if e.Status == StatusOK {
+		e.Status = StatusPartial
+	}
+	return e
+}
+
+// WithFigures adds monetary figures.
+func (e *Envelope) WithFigures(figures ...Figure) *Envelope {
+	e.Figures = append(e.Figures, figures...)
+	return e
+}
+
+// WithArtifacts adds artifact references.
+func (e *Envelope) WithArtifacts(refs ...artifact.Ref) *Envelope {
+	e.Artifacts = append(e.Artifacts, refs...)
+	return e
+}
+
+// Result returns a successful ToolResult carrying the envelope.
+func (e *Envelope) Result() *ToolResult {
+	return &ToolResult{Success: true, Data: e}
+}
+
+// EnvelopeOf returns the Envelope in a tool result's Data, if it is one.
+func EnvelopeOf(data interface{}) (*Envelope, bool) {
+	switch e := data.(type) {
+	case *Envelope:
+		return e, e != nil
+	case Envelope:
+		return &e, true
+	}
+	return nil, false
+}
+
+// FigureFunc extracts the monetary figures from a tool's result data,
+// given as JSON.
+type FigureFunc func(data json.RawMessage) []Figure
+
+// Enveloped wraps a successful result's Data in an Envelope, extracting
+// figures with figures if it is non-nil. Failed results and results that
+// are already envelopes are returned unchanged.
+func Enveloped(result *ToolResult, figures FigureFunc) *ToolResult {
+	if result == nil || !result.Success {
+		return result
+	}
+	if _, ok := EnvelopeOf(result.Data); ok {
+		return result
+	}
+	env := NewEnvelope(result.Data)
+	if figures != nil && result.Data != nil {
+		if data, err := json.Marshal(result.Data); err == nil {
+			env.Figures = figures(data)
+		}
+	}
+	wrapped := *result
+	wrapped.Data = env
+	return &wrapped
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+)
+
+func TestEnvelope_JSON(t *testing.T) {
+	tests := []struct {
+		name string
+		env  *Envelope
+		want string
+	}{
+		{
+			name: "data only",
+			env:  NewEnvelope(map[string]int{"count": 3}),
+			want: `{"status":"ok","data":{"count":3}}`,
+		},
+		{
+			name: "figures and warnings",
+			env: NewEnvelope(nil).
+				WithFigures(NewFigure("total_spent", 0.1+0.2, "USD"), Figure{Name: "balance", Amount: "50.25", Currency: "USDC"}).
+				WithWarning("truncated"),
+			want: `{"status":"partial","warnings":["truncated"],"figures":[{"name":"total_spent","amount":"0.3","currency":"USD"},{"name":"balance","amount":"50.25","currency":"USDC"}]}`,
+		},
+		{
+			name: "empty stays empty with warnings",
+			env:  NewEnvelope([]string{}).WithStatus(StatusEmpty).WithWarning("no history"),
+			want: `{"status":"empty","data":[],"warnings":["no history"]}`,
+		},
+		{
+			name: "artifacts",
+			env: NewEnvelope(nil).WithArtifacts(artifact.Ref{
+				ID: "chart-1", Kind: artifact.KindChart, MediaType: "image/svg+xml", Size: 10,
+				CreatedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			}),
+			want: `{"status":"ok","artifacts":[{"id":"chart-1","kind":"chart","media_type":"image/svg+xml","size":10,"created_at":"2026-03-01T00:00:00Z"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+
+			var back Envelope
+			if err := json.Unmarshal(got, &back); err != nil {
+				t.Fatal(err)
+			}
+			if back.Status != tt.env.Status || len(back.Figures) != len(tt.env.Figures) || len(back.Warnings) != len(tt.env.Warnings) {
+				t.Errorf("round trip = %+v, want %+v", back, tt.env)
+			}
+		})
+	}
+}
+
+func TestNewFigure(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{1250, "1250"},
+		{12.5, "12.5"},
+		{0.1 + 0.2, "0.3"},
+		{-45.1, "-45.1"},
+		{0.000001, "0.000001"},
+	}
+	for _, tt := range tests {
+		if got := NewFigure("x", tt.amount, "USD").Amount; got != tt.want {
+			t.Errorf("NewFigure(%v).Amount = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestEnveloped(t *testing.T) {
+	figures := func(data json.RawMessage) []Figure {
+		var v struct {
+			Amount string `json:"amount"`
+		}
+		json.Unmarshal(data, &v)
+		return []Figure{{Name: "amount", Amount: v.Amount, Currency: "USD"}}
+	}
+
+	wrapped := Enveloped(&ToolResult{Success: true, Data: map[string]string{"amount": "5"}}, figures)
+	env, ok := EnvelopeOf(wrapped.Data)
+	if !ok || env.Status != StatusOK || len(env.Figures) != 1 || env.Figures[0].Amount != "5" {
+		t.Errorf("Enveloped() = %+v, want data wrapped with figures", wrapped.Data)
+	}
+
+	own := NewEnvelope(nil).WithWarning("mine")
+	if got := Enveloped(own.Result(), figures); got.Data != own {
+		t.Errorf("Enveloped() rewrapped an envelope: %+v", got.Data)
+	}
+
+	failed := &ToolResult{Success: false, Error: "boom"}
+	if got := Enveloped(failed, figures); got != failed {
+		t.Errorf("Enveloped() changed a failed result: %+v", got)
+	}
+}
+
+func TestBaseTool_Envelope(t *testing.T) {
+	handler := func(ctx context.Context, params *ToolParams) (*ToolResult, error) {
+		return &ToolResult{Success: true, Data: "done"}, nil
+	}
+
+	plain := NewBaseTool(ToolDefinition{ToolName: "plain"}, handler)
+	if result, _ := plain.Execute(context.Background(), &ToolParams{}); result.Data != "done" {
+		t.Errorf("plain tool Data = %+v, want it unchanged", result.Data)
+	}
+
+	enveloped := NewBaseTool(ToolDefinition{ToolName: "enveloped", Envelope: true}, handler)
+	result, _ := enveloped.Execute(context.Background(), &ToolParams{})
+	if env, ok := EnvelopeOf(result.Data); !ok || env.Data != "done" {
+		t.Errorf("enveloped tool Data = %+v, want an envelope", result.Data)
+	}
+}
@@ -40,6 +40,43 @@ func TestExecutorTool_GetSummary(t *testing.T) {
 			},
 			want: "Deposit 100 USD into savings",
 		},
+		{
+			name:            "missing field",
+			summaryTemplate: "Send {{.amount}} {{default \"USDC\" .currency}} to {{.recipient}}",
+			input: map[string]interface{}{
+				"amount": "25",
+			},
+			want: "Send 25 USDC to <no value>",
+		},
+		{
+			name:            "nested object",
+			summaryTemplate: "Pay {{.invoice.amount}} to {{.invoice.payee.name}}",
+			input: map[string]interface{}{
+				"invoice": map[string]interface{}{
+					"amount": "12.50",
+					"payee":  map[string]interface{}{"name": "Acme"},
+				},
+			},
+			want: "Pay 12.50 to Acme",
+		},
+		{
+			name:            "malformed template",
+			summaryTemplate: "Send {{.amount} to {{.recipient}}",
+			input: map[string]interface{}{
+				"amount":    "50.00",
+				"recipient": "@alice",
+			},
+			want: "test_tool with {amount: 50.00, recipient: @alice}",
+		},
+		{
+			name:            "template fails on the input",
+			summaryTemplate: "Send {{currency .amount .currency}}",
+			input: map[string]interface{}{
+				"amount":   "fifty",
+				"currency": "USDC",
+			},
+			want: "test_tool with {amount: fifty, currency: USDC}",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1,12 +1,15 @@
 package core
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"text/template"
+	"errors"
 )
 
+// ErrPreviewUnavailable is returned by executors that can't dry run a
+// write, e.g. because the gateway doesn't support it yet.
+var ErrPreviewUnavailable = errors.New("preview unavailable")
+
 // ToolExecutor executes Liminal tools (get_balance, send_money, etc.).
 // This is the key abstraction that enables different implementations:
 //   - HTTPExecutor (public SDK) → calls agent_gateway over HTTP
@@ -39,6 +42,11 @@ type ExecuteRequest struct {
 
 	// RequestID for tracing/logging.
 	RequestID string `json:"request_id,omitempty"`
+
+	// DryRun previews a write passed to ExecuteWrite: it is validated and
+	// its fee and arrival estimated, but nothing is executed and no
+	// confirmation is created.
+	DryRun bool `json:"dry_run,omitempty"`
 }
 
 // ExecuteResponse contains the result of tool execution.
@@ -67,6 +75,9 @@ type ConfirmationDetails struct {
 	// Summary is a human-readable description of the action.
 	Summary string `json:"summary"`
 
+	// Details describes the action in machine-readable fields.
+	Details Details `json:"details,omitempty"`
+
 	// ExpiresAt is when this confirmation expires (unix timestamp).
 	ExpiresAt int64 `json:"expires_at"`
 }
@@ -76,6 +87,7 @@ type ConfirmationDetails struct {
 type ExecutorTool struct {
 	definition ToolDefinition
 	executor   ToolExecutor
+	summary    toolSummary
 }
 
 // NewExecutorTool creates a tool that delegates to a ToolExecutor.
@@ -83,6 +95,7 @@ func NewExecutorTool(def ToolDefinition, executor ToolExecutor) *ExecutorTool {
 	return &ExecutorTool{
 		definition: def,
 		executor:   executor,
+		summary:    parseToolSummary(def),
 	}
 }
 
@@ -106,6 +119,21 @@ func (t *ExecutorTool) RequiresConfirmation() bool {
 	return t.definition.RequiresUserConfirmation
 }
 
+// Inverse returns the tool's undo function, or nil.
+func (t *ExecutorTool) Inverse() InverseFunc {
+	return t.definition.Inverse
+}
+
+// DiffKeys returns the tool's array key fields for differential results, or nil.
+func (t *ExecutorTool) DiffKeys() map[string]string {
+	return t.definition.DiffKeys
+}
+
+// NoCache reports whether the tool's results are never cached.
+func (t *ExecutorTool) NoCache() bool {
+	return t.definition.NoCache
+}
+
 // Execute runs the tool via the ToolExecutor.
 func (t *ExecutorTool) Execute(ctx context.Context, params *ToolParams) (*ToolResult, error) {
 	req := &ExecuteRequest{
@@ -118,7 +146,12 @@ func (t *ExecutorTool) Execute(ctx context.Context, params *ToolParams) (*ToolRe
 	var resp *ExecuteResponse
 	var err error
 
-	if t.definition.RequiresUserConfirmation && params.ConfirmationID != "" {
+	if t.definition.Previews != "" {
+		// This is a dry run of a write operation
+		req.Tool = t.definition.Previews
+		req.DryRun = true
+		resp, err = t.executor.ExecuteWrite(ctx, req)
+	} else if t.definition.RequiresUserConfirmation && params.ConfirmationID != "" {
 		// This is a confirmed write operation
 		resp, err = t.executor.Confirm(ctx, params.UserID, params.ConfirmationID)
 	} else if t.definition.RequiresUserConfirmation {
@@ -138,39 +171,35 @@ func (t *ExecutorTool) Execute(ctx context.Context, params *ToolParams) (*ToolRe
 		json.Unmarshal(resp.Data, &data)
 	}
 
-	return &ToolResult{
+	result := &ToolResult{
 		Success: resp.Success,
 		Data:    data,
 		Error:   resp.Error,
-	}, nil
+	}
+	if t.definition.Envelope {
+		result = Enveloped(result, t.definition.Figures)
+	}
+	return result, nil
 }
 
 // GetSummary returns a formatted summary using the template.
 func (t *ExecutorTool) GetSummary(input json.RawMessage) string {
-	// If no template, return empty string
-	if t.definition.SummaryTemplate == "" {
-		return ""
-	}
-
-	// Parse input JSON into a map for templating
-	var data map[string]interface{}
-	if err := json.Unmarshal(input, &data); err != nil {
-		// If parsing fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
+	return t.summary.render(t.definition, input)
+}
 
-	// Create and execute template
-	tmpl, err := template.New("summary").Parse(t.definition.SummaryTemplate)
-	if err != nil {
-		// If template parsing fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
+// LocalizedSummary returns the summary written for locale, formatting
+// currency amounts for it.
+func (t *ExecutorTool) LocalizedSummary(input json.RawMessage, locale string) string {
+	return t.summary.renderLocale(t.definition, input, locale)
+}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		// If template execution fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
+// SummaryDetails returns the details of a pending action, or nil if the
+// tool has no SummaryDetails.
+func (t *ExecutorTool) SummaryDetails(input json.RawMessage) Details {
+	return summaryDetails(t.definition, input)
+}
 
-	return buf.String()
+// Validate reports whether the tool's summary template parsed.
+func (t *ExecutorTool) Validate() error {
+	return t.summary.err
 }
\ No newline at end of file
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// ShortHashLength is how many hex characters of an input hash are shown to
+// users alongside a confirmation.
+const ShortHashLength = 12
+
+// HashInput returns the hex SHA-256 of input in canonical form: object keys
+// sorted, insignificant whitespace dropped, and numbers normalized so that
+// 1, 1.0 and 1e0 hash alike. The hash is therefore stable across stores that
+// re-encode JSON (e.g. PostgreSQL jsonb reorders keys), while any change to
+// a value changes it.
+func HashInput(input json.RawMessage) (string, error) {
+	if len(bytes.TrimSpace(input)) == 0 {
+		input = json.RawMessage("null")
+	}
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", fmt.Errorf("invalid input: %w", err)
+	}
+
+	var buf bytes.Buffer
+	writeCanonical(&buf, v)
+	hash := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// writeCanonical encodes a value decoded with UseNumber. Numbers are written
+// as exact fractions (big.Rat), which is only ever hashed, never parsed.
+func writeCanonical(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(k)
+			buf.Write(key)
+			buf.WriteByte(':')
+			writeCanonical(buf, v[k])
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonical(buf, elem)
+		}
+		buf.WriteByte(']')
+	case json.Number:
+		if r, ok := new(big.Rat).SetString(string(v)); ok {
+			buf.WriteString(r.RatString())
+		} else {
+			buf.WriteString(string(v))
+		}
+	default:
+		b, _ := json.Marshal(v)
+		buf.Write(b)
+	}
+}
+
+// ShortHash abbreviates an input hash for display.
+func ShortHash(hash string) string {
+	if len(hash) <= ShortHashLength {
+		return hash
+	}
+	return hash[:ShortHashLength]
+}
+
+// InputIntegrityError is returned when a confirmed action's input no longer
+// matches the hash taken when the user was asked to approve it.
+type InputIntegrityError struct {
+	// ActionID is the pending action that failed the check.
+	ActionID string
+
+	// Tool is the tool the action would have run.
+	Tool string
+
+	// Approved is the hash shown to the user when the action was offered.
+	Approved string
+
+	// Actual is the hash of the input that would have executed.
+	Actual string
+}
+
+func (e *InputIntegrityError) Error() string {
+	if e.Approved == "" {
+		return fmt.Sprintf("action %s (%s) has no approved input hash", e.ActionID, e.Tool)
+	}
+	return fmt.Sprintf("action %s (%s) input does not match what was approved: approved %s, got %s",
+		e.ActionID, e.Tool, ShortHash(e.Approved), ShortHash(e.Actual))
+}
+
+// SetInput replaces the action's input and recomputes InputHash, so the new
+// input is what the user approves. It is the only sanctioned way to change
+// the input of an action that has been offered for confirmation.
+func (a *PendingAction) SetInput(input json.RawMessage) error {
+	hash, err := HashInput(input)
+	if err != nil {
+		return err
+	}
+	a.Input = input
+	a.InputHash = hash
+	return nil
+}
+
+// VerifyInput checks that the action's input still matches InputHash.
+// Returns an *InputIntegrityError if it does not, or if no hash was taken.
+func (a *PendingAction) VerifyInput() error {
+	actual, err := HashInput(a.Input)
+	if err != nil || a.InputHash == "" || actual != a.InputHash {
+		return &InputIntegrityError{ActionID: a.ID, Tool: a.Tool, Approved: a.InputHash, Actual: actual}
+	}
+	return nil
+}
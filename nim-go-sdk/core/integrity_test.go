@@ -0,0 +1,151 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestHashInput(t *testing.T) {
+	base := `{"amount":"50.00","currency":"USD","recipient":"@alice","meta":{"n":1,"tags":["a","b"]}}`
+
+	tests := []struct {
+		name  string
+		input string
+		same  bool
+	}{
+		{name: "identical", input: base, same: true},
+		{name: "keys reordered", input: `{"recipient":"@alice","meta":{"tags":["a","b"],"n":1},"currency":"USD","amount":"50.00"}`, same: true},
+		{name: "whitespace", input: "{\n  \"amount\": \"50.00\", \"currency\": \"USD\",\n  \"recipient\": \"@alice\", \"meta\": {\"n\": 1, \"tags\": [\"a\", \"b\"]}\n}", same: true},
+		{name: "number written as 1.0", input: `{"amount":"50.00","currency":"USD","recipient":"@alice","meta":{"n":1.0,"tags":["a","b"]}}`, same: true},
+		{name: "number written as 1e0", input: `{"amount":"50.00","currency":"USD","recipient":"@alice","meta":{"n":1e0,"tags":["a","b"]}}`, same: true},
+		{name: "escaped key", input: `{"amo\u0075nt":"50.00","currency":"USD","recipient":"@alice","meta":{"n":1,"tags":["a","b"]}}`, same: true},
+		{name: "amount changed", input: `{"amount":"500.00","currency":"USD","recipient":"@alice","meta":{"n":1,"tags":["a","b"]}}`},
+		{name: "amount as number", input: `{"amount":50.00,"currency":"USD","recipient":"@alice","meta":{"n":1,"tags":["a","b"]}}`},
+		{name: "recipient changed", input: `{"amount":"50.00","currency":"USD","recipient":"@mallory","meta":{"n":1,"tags":["a","b"]}}`},
+		{name: "array reordered", input: `{"amount":"50.00","currency":"USD","recipient":"@alice","meta":{"n":1,"tags":["b","a"]}}`},
+		{name: "field added", input: `{"amount":"50.00","currency":"USD","recipient":"@alice","meta":{"n":1,"tags":["a","b"]},"note":""}`},
+		{name: "number changed", input: `{"amount":"50.00","currency":"USD","recipient":"@alice","meta":{"n":1.0000000000000001,"tags":["a","b"]}}`},
+	}
+
+	want, err := HashInput(json.RawMessage(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HashInput(json.RawMessage(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == want) != tt.same {
+				t.Errorf("HashInput() same = %v, want %v", got == want, tt.same)
+			}
+		})
+	}
+
+	if _, err := HashInput(json.RawMessage(`{"amount":`)); err == nil {
+		t.Error("HashInput() of invalid JSON succeeded")
+	}
+}
+
+func TestPendingActionStoreRoundTrip(t *testing.T) {
+	action := &PendingAction{ID: "a-1", Tool: "send_money"}
+	if err := action.SetInput(json.RawMessage(`{"recipient":"@alice","amount":"50","currency":"USD","count":10}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stores that keep JSON as text, and ones that re-encode it the way
+	// PostgreSQL jsonb or a Redis hash round trip might.
+	reencode := map[string]func([]byte) []byte{
+		"json text": func(b []byte) []byte { return b },
+		"reencoded input": func(b []byte) []byte {
+			var stored map[string]json.RawMessage
+			json.Unmarshal(b, &stored)
+			stored["input"] = json.RawMessage(`{ "count": 1e1, "currency": "USD", "amount": "50", "recipient": "@alice" }`)
+			out, _ := json.Marshal(stored)
+			return out
+		},
+	}
+
+	for name, store := range reencode {
+		t.Run(name, func(t *testing.T) {
+			b, err := json.Marshal(action)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var loaded PendingAction
+			if err := json.Unmarshal(store(b), &loaded); err != nil {
+				t.Fatal(err)
+			}
+			if err := loaded.VerifyInput(); err != nil {
+				t.Errorf("VerifyInput() after round trip = %v", err)
+			}
+		})
+	}
+}
+
+func TestPendingActionTampering(t *testing.T) {
+	approved := json.RawMessage(`{"recipient":"@alice","amount":"50","currency":"USD"}`)
+
+	tests := []struct {
+		name   string
+		tamper func(a *PendingAction)
+	}{
+		{name: "input replaced", tamper: func(a *PendingAction) {
+			a.Input = json.RawMessage(`{"recipient":"@mallory","amount":"50","currency":"USD"}`)
+		}},
+		{name: "input edited in place", tamper: func(a *PendingAction) { copy(a.Input[14:], "@bobby") }},
+		{name: "hash cleared", tamper: func(a *PendingAction) { a.InputHash = "" }},
+		{name: "input corrupted", tamper: func(a *PendingAction) { a.Input = json.RawMessage(`{"recipient":`) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := &PendingAction{ID: "a-1", Tool: "send_money"}
+			if err := action.SetInput(append(json.RawMessage(nil), approved...)); err != nil {
+				t.Fatal(err)
+			}
+			tt.tamper(action)
+
+			var integrityErr *InputIntegrityError
+			if err := action.VerifyInput(); !errors.As(err, &integrityErr) {
+				t.Fatalf("VerifyInput() = %v, want *InputIntegrityError", err)
+			}
+			if integrityErr.ActionID != "a-1" || integrityErr.Tool != "send_money" {
+				t.Errorf("error = %+v, want action a-1 send_money", integrityErr)
+			}
+		})
+	}
+}
+
+func TestPendingActionSetInputRehashes(t *testing.T) {
+	action := &PendingAction{ID: "a-1", Tool: "send_money"}
+	if err := action.SetInput(json.RawMessage(`{"recipient":"@alice","amount":"50","currency":"USD"}`)); err != nil {
+		t.Fatal(err)
+	}
+	before := action.InputHash
+
+	// Modifying the amount before confirmation produces a new hash, which
+	// is the one the user approves.
+	if err := action.SetInput(json.RawMessage(`{"recipient":"@alice","amount":"40","currency":"USD"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if action.InputHash == before {
+		t.Error("SetInput() kept the old hash")
+	}
+	if err := action.VerifyInput(); err != nil {
+		t.Errorf("VerifyInput() after SetInput = %v", err)
+	}
+
+	// A rejected input leaves the action unchanged.
+	if err := action.SetInput(json.RawMessage(`not json`)); err == nil {
+		t.Error("SetInput() accepted invalid JSON")
+	}
+	if err := action.VerifyInput(); err != nil {
+		t.Errorf("VerifyInput() after rejected SetInput = %v", err)
+	}
+	if got := ShortHash(action.InputHash); len(got) != ShortHashLength || got != action.InputHash[:ShortHashLength] {
+		t.Errorf("ShortHash() = %q", got)
+	}
+}
@@ -0,0 +1,458 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/i18n"
+)
+
+// Limits on rendering a summary template. A render that exceeds them gives
+// FallbackSummary instead.
+const (
+	// MaxSummaryBytes caps a rendered summary.
+	MaxSummaryBytes = 1024
+
+	// SummaryTimeout caps how long a summary template may run.
+	SummaryTimeout = 100 * time.Millisecond
+
+	// MaxSummaryValues caps the values in an input rendered by a summary
+	// template, counting every element of its arrays and objects.
+	MaxSummaryValues = 1000
+)
+
+// maxFormatWidth caps the width and precision printf may pad a value to.
+const maxFormatWidth = 64
+
+// renderTimeout is SummaryTimeout, shortened in tests.
+var renderTimeout = SummaryTimeout
+
+// errSummaryTooLong is returned when a summary exceeds MaxSummaryBytes.
+var errSummaryTooLong = errors.New("summary exceeds MaxSummaryBytes")
+
+// Summary is a parsed SummaryTemplate. Templates run over the tool's
+// model-controlled input, so they are sandboxed: input is plain JSON data
+// with no methods to call, only the functions in summaryFuncs are
+// available, and output and running time are capped.
+//
+// A running template can't be interrupted, so templates are also kept
+// from looping without bound: they may not define or call templates, and
+// may only range over input fields, one level deep.
+type Summary struct {
+	tool string
+	tmpl *template.Template
+}
+
+// ParseSummary parses a tool's summary template. Tools parse their
+// template when created; the registry rejects tools whose template is
+// invalid (see Validator).
+func ParseSummary(tool, text string) (*Summary, error) {
+	tmpl, err := template.New(tool).Funcs(summaryFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid summary template: %w", err)
+	}
+	if len(tmpl.Templates()) > 1 {
+		return nil, errors.New("invalid summary template: define and block are not available")
+	}
+	if err := checkSummaryNode(tmpl.Tree.Root, false); err != nil {
+		return nil, fmt.Errorf("invalid summary template: %w", err)
+	}
+	return &Summary{tool: tool, tmpl: tmpl}, nil
+}
+
+// checkSummaryNode rejects template constructs that could run without
+// bound. inRange is set inside a range.
+func checkSummaryNode(node parse.Node, inRange bool) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkSummaryNode(child, inRange); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return checkSummaryBranch(&n.BranchNode, inRange, inRange)
+	case *parse.WithNode:
+		return checkSummaryBranch(&n.BranchNode, inRange, inRange)
+	case *parse.RangeNode:
+		if inRange {
+			return errors.New("nested range is not available")
+		}
+		if !rangesOverInput(n.Pipe) {
+			return fmt.Errorf("range over %s is not available; range over an input field", n.Pipe)
+		}
+		return checkSummaryBranch(&n.BranchNode, true, false)
+	case *parse.TemplateNode:
+		return errors.New("template calls are not available")
+	}
+	return nil
+}
+
+func checkSummaryBranch(b *parse.BranchNode, listInRange, elseInRange bool) error {
+	if err := checkSummaryNode(b.List, listInRange); err != nil {
+		return err
+	}
+	return checkSummaryNode(b.ElseList, elseInRange)
+}
+
+// rangesOverInput reports whether a range pipeline is a field of the input,
+// such as .items or $.order.items, rather than a number, variable or
+// function result that could be arbitrarily large.
+func rangesOverInput(pipe *parse.PipeNode) bool {
+	if len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		return true
+	case *parse.VariableNode:
+		return len(arg.Ident) > 1 && arg.Ident[0] == "$"
+	}
+	return false
+}
+
+// countValues counts the values in decoded JSON, stopping past limit.
+func countValues(v interface{}, limit int) int {
+	n := 1
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for _, e := range x {
+			if n += countValues(e, limit-n); n > limit {
+				return n
+			}
+		}
+	case []interface{}:
+		for _, e := range x {
+			if n += countValues(e, limit-n); n > limit {
+				return n
+			}
+		}
+	}
+	return n
+}
+
+// Render renders the summary for a tool input. If the input is not a JSON
+// object, or the template fails or exceeds its limits, it logs why and
+// returns FallbackSummary.
+func (s *Summary) Render(input json.RawMessage) string {
+	return s.render(s.tmpl, input)
+}
+
+// RenderLocale renders the summary like Render, formatting the currency
+// function's amounts for locale, e.g. "$50.00" in en-US and "50,00 €" in
+// de-DE for EUR. An empty locale renders as Render does.
+func (s *Summary) RenderLocale(input json.RawMessage, locale string) string {
+	if locale == "" {
+		return s.Render(input)
+	}
+	tmpl, err := s.tmpl.Clone()
+	if err != nil {
+		return s.Render(input)
+	}
+	return s.render(tmpl.Funcs(template.FuncMap{"currency": localCurrency(locale)}), input)
+}
+
+func (s *Summary) render(tmpl *template.Template, input json.RawMessage) string {
+	// Decoding to generic JSON values leaves the template plain data:
+	// maps, slices, strings, numbers and booleans, with no methods.
+	var data map[string]interface{}
+	if err := json.Unmarshal(input, &data); err != nil {
+		log.Printf("nim: summary for %s: input is not a JSON object: %v", s.tool, err)
+		return genericSummary(s.tool)
+	}
+	if countValues(data, MaxSummaryValues) > MaxSummaryValues {
+		log.Printf("nim: summary for %s: input has more than %d values", s.tool, MaxSummaryValues)
+		return genericSummary(s.tool)
+	}
+
+	w := &cappedWriter{limit: MaxSummaryBytes}
+	done := make(chan error, 1)
+	go func() {
+		done <- tmpl.Execute(w, data)
+	}()
+
+	timer := time.NewTimer(renderTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			log.Printf("nim: summary for %s: %v", s.tool, err)
+			return inputSummary(s.tool, data)
+		}
+		return w.String()
+	case <-timer.C:
+		// Execution can't be interrupted; failing its writes ends it at
+		// the next output.
+		w.stop()
+		log.Printf("nim: summary for %s: template ran longer than %s", s.tool, renderTimeout)
+		return inputSummary(s.tool, data)
+	}
+}
+
+// maxFallbackValue caps the runes of each input value FallbackSummary shows.
+const maxFallbackValue = 64
+
+// FallbackSummary is the summary shown when a tool's template can't be
+// rendered: the tool's input, as "send_money with {amount: 50, recipient:
+// @alice}", with long values cut short. Input that isn't a JSON object, or
+// is too large to list, gives a generic summary instead.
+func FallbackSummary(tool string, input json.RawMessage) string {
+	var data map[string]interface{}
+	if json.Unmarshal(input, &data) != nil || countValues(data, MaxSummaryValues) > MaxSummaryValues {
+		return genericSummary(tool)
+	}
+	return inputSummary(tool, data)
+}
+
+// inputSummary lists data's fields in key order, up to MaxSummaryBytes.
+func inputSummary(tool string, data map[string]interface{}) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(tool + " with {")
+	for i, k := range keys {
+		field := truncateRunes(k, maxFallbackValue) + ": " + fallbackValue(data[k])
+		if i > 0 {
+			field = ", " + field
+		}
+		if b.Len()+len(field)+len("…}") > MaxSummaryBytes {
+			b.WriteString(", …")
+			break
+		}
+		b.WriteString(field)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// fallbackValue formats an input value for FallbackSummary: strings as they
+// are, anything else as JSON, cut to maxFallbackValue runes.
+func fallbackValue(v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		data, _ := json.Marshal(v)
+		s = string(data)
+	}
+	return truncateRunes(s, maxFallbackValue)
+}
+
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
+
+// genericSummary is the summary shown when not even a tool's input can be
+// shown.
+func genericSummary(tool string) string {
+	return fmt.Sprintf("Run %s (review the details before confirming)", tool)
+}
+
+// toolSummary is a tool's parsed SummaryTemplate, or why it didn't parse.
+type toolSummary struct {
+	summary *Summary
+	err     error
+}
+
+func parseToolSummary(def ToolDefinition) toolSummary {
+	if def.SummaryTemplate == "" {
+		return toolSummary{}
+	}
+	summary, err := ParseSummary(def.ToolName, def.SummaryTemplate)
+	return toolSummary{summary: summary, err: err}
+}
+
+// render renders the summary, or returns "" for tools without a template.
+func (s toolSummary) render(def ToolDefinition, input json.RawMessage) string {
+	switch {
+	case s.err != nil:
+		return FallbackSummary(def.ToolName, input)
+	case s.summary == nil:
+		return ""
+	}
+	return s.summary.Render(input)
+}
+
+// renderLocale renders the summary for locale, or returns "" for tools
+// without a template.
+func (s toolSummary) renderLocale(def ToolDefinition, input json.RawMessage, locale string) string {
+	if s.err != nil || s.summary == nil {
+		return s.render(def, input)
+	}
+	return s.summary.RenderLocale(input, locale)
+}
+
+// LocalizedTool is implemented by tools whose summaries can be written for
+// the user's locale, such as BaseTool formatting currency amounts.
+type LocalizedTool interface {
+	Tool
+
+	// LocalizedSummary returns GetSummary's summary of input, written for
+	// locale, e.g. "en-US".
+	LocalizedSummary(input json.RawMessage, locale string) string
+}
+
+// SummaryOf returns the summary of the action tool would run with input:
+// written for locale if it's a LocalizedTool, or else GetSummary's.
+func SummaryOf(tool Tool, input json.RawMessage, locale string) string {
+	if localized, ok := tool.(LocalizedTool); ok && locale != "" {
+		return localized.LocalizedSummary(input, locale)
+	}
+	return tool.GetSummary(input)
+}
+
+// Validator is implemented by tools that can check their definition when
+// registered, such as BaseTool rejecting an invalid SummaryTemplate.
+type Validator interface {
+	Validate() error
+}
+
+// cappedWriter buffers output up to limit bytes, failing writes beyond it
+// or once stopped.
+type cappedWriter struct {
+	buf     strings.Builder
+	limit   int
+	stopped atomic.Bool
+}
+
+func (w *cappedWriter) Write(p []byte) (int, error) {
+	if w.stopped.Load() {
+		return 0, errors.New("summary template stopped")
+	}
+	if w.buf.Len()+len(p) > w.limit {
+		return 0, errSummaryTooLong
+	}
+	return w.buf.Write(p)
+}
+
+func (w *cappedWriter) String() string { return w.buf.String() }
+
+func (w *cappedWriter) stop() { w.stopped.Store(true) }
+
+// formatWidth matches the width and precision of printf verbs.
+var formatWidth = regexp.MustCompile(`%[-+# 0]*(?:\[\d+\])?(\*|\d*)(?:\.(?:\[\d+\])?(\*|\d*))?`)
+
+// summaryFuncs are the functions available to summary templates. They
+// replace text/template's call builtin, which could invoke functions in
+// data, and its printf, which could pad output without bound.
+var summaryFuncs = template.FuncMap{
+	"call": func(...interface{}) (string, error) {
+		return "", errors.New("call is not available in summary templates")
+	},
+	"printf": safePrintf,
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+	"truncate": func(n int, s string) string {
+		return truncateRunes(s, n)
+	},
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	"amount":   formatAmount,
+	"currency": formatCurrency,
+}
+
+// safePrintf is fmt.Sprintf, refusing widths and precisions that would
+// inflate the output.
+func safePrintf(format string, args ...interface{}) (string, error) {
+	for _, m := range formatWidth.FindAllStringSubmatch(format, -1) {
+		for _, n := range m[1:] {
+			if n == "*" {
+				return "", errors.New("printf: * width is not available in summary templates")
+			}
+			if w, _ := strconv.Atoi(n); w > maxFormatWidth {
+				return "", fmt.Errorf("printf: width %d exceeds %d", w, maxFormatWidth)
+			}
+		}
+	}
+	return fmt.Sprintf(format, args...), nil
+}
+
+// decimalPattern matches plain decimals. Exponents are refused: parsing
+// "1e999999999" would allocate without bound.
+var decimalPattern = regexp.MustCompile(`^[+-]?\d+(\.\d+)?$`)
+
+// formatAmount formats a decimal amount with two decimal places, or more if
+// it has them, e.g. "50" as "50.00" and "0.125" as "0.125".
+func formatAmount(v interface{}) (string, error) {
+	var s string
+	switch x := v.(type) {
+	case string:
+		s = strings.TrimSpace(x)
+	case float64:
+		s = strconv.FormatFloat(x, 'f', -1, 64)
+	case json.Number:
+		s = x.String()
+	default:
+		return "", fmt.Errorf("amount: %T is not an amount", v)
+	}
+	if len(s) > 64 || !decimalPattern.MatchString(s) {
+		return "", fmt.Errorf("amount: %.64q is not a decimal", s)
+	}
+	r, _ := new(big.Rat).SetString(s)
+
+	places := 2
+	if _, frac, found := strings.Cut(s, "."); found && len(frac) > places {
+		places = len(frac)
+	}
+	return r.FloatString(places), nil
+}
+
+// maxLocalDigits is the most significant digits an amount localCurrency
+// formats may have; float64 can't hold more exactly.
+const maxLocalDigits = 15
+
+// localCurrency returns the currency function for summaries written for
+// locale, formatting amounts with i18n.FormatMoney. Amounts with more
+// digits than a float64 holds are formatted as formatCurrency does.
+func localCurrency(locale string) func(amount, currency interface{}) (string, error) {
+	return func(amount, currency interface{}) (string, error) {
+		plain, err := formatCurrency(amount, currency)
+		if err != nil {
+			return "", err
+		}
+		a, _ := formatAmount(amount)
+		digits := strings.TrimLeft(strings.NewReplacer("-", "", "+", "", ".", "").Replace(a), "0")
+		f, err := strconv.ParseFloat(a, 64)
+		if err != nil || len(digits) > maxLocalDigits {
+			return plain, nil
+		}
+		code, _ := currency.(string)
+		return i18n.FormatMoney(f, code, locale), nil
+	}
+}
+
+// formatCurrency formats an amount with its currency code, e.g. "50.00 USDC".
+func formatCurrency(amount, currency interface{}) (string, error) {
+	a, err := formatAmount(amount)
+	if err != nil {
+		return "", err
+	}
+	code, _ := currency.(string)
+	if len(code) > 16 {
+		return "", errors.New("currency: code too long")
+	}
+	return strings.TrimSpace(a + " " + strings.ToUpper(code)), nil
+}
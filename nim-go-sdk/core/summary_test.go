@@ -0,0 +1,260 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{name: "fields", template: "Send {{.amount}} {{.currency}} to {{.recipient}}"},
+		{name: "helpers", template: "Send {{currency .amount .currency}} to {{truncate 20 .recipient}}"},
+		{name: "unclosed action", template: "Send {{.amount} to {{.recipient}}", wantErr: true},
+		{name: "unknown function", template: "{{exec .cmd}}", wantErr: true},
+		{name: "unclosed range", template: "{{range .items}}", wantErr: true},
+		{name: "range over a field", template: "{{range .items}}{{.name}}{{else}}{{range .other}}{{end}}{{end}}"},
+		{name: "range over a root field", template: "{{range $i, $e := $.items}}{{$i}}{{end}}"},
+		{name: "range over a number", template: "{{range 1000000000}}{{end}}", wantErr: true},
+		{name: "range over a variable", template: "{{$n := 1000000000}}{{range $n}}{{end}}", wantErr: true},
+		{name: "range over a function", template: "{{range len .items}}{{end}}", wantErr: true},
+		{name: "nested range", template: "{{range .a}}{{if .}}{{range $.a}}{{end}}{{end}}{{end}}", wantErr: true},
+		{name: "define", template: `{{define "a"}}{{template "a"}}{{end}}`, wantErr: true},
+		{name: "template call", template: `{{template "send_money"}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSummary("tool", tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSummary(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSummary_Render(t *testing.T) {
+	huge := strings.Repeat("A", 1<<20)
+
+	nested := `{"v":"leaf"}`
+	for i := 0; i < 5000; i++ {
+		nested = `{"v":` + nested + `}`
+	}
+
+	manyItems, _ := json.Marshal(map[string]interface{}{"items": make([]int, 500), "pad": "0123456789"})
+
+	tests := []struct {
+		name     string
+		template string
+		input    string
+		want     string // "" for the fallback summary
+	}{
+		{
+			name:     "plain fields",
+			template: "Send {{.amount}} {{.currency}} to {{.recipient}}",
+			input:    `{"amount":"50","currency":"USDC","recipient":"@alice"}`,
+			want:     "Send 50 USDC to @alice",
+		},
+		{
+			name:     "currency helpers",
+			template: "Send {{currency .amount .currency}} ({{amount .fee}} fee)",
+			input:    `{"amount":"50","currency":"usdc","fee":0.125}`,
+			want:     "Send 50.00 USDC (0.125 fee)",
+		},
+		{
+			name:     "template syntax in values is data",
+			template: "Send to {{.recipient}}",
+			input:    `{"recipient":"{{.secret}} {{call .f}} {{printf \"%999999d\" 1}}"}`,
+			want:     `Send to {{.secret}} {{call .f}} {{printf "%999999d" 1}}`,
+		},
+		{
+			name:     "huge value",
+			template: "Send to {{.recipient}}",
+			input:    `{"recipient":"` + huge + `"}`,
+		},
+		{
+			name:     "huge value truncated by the template",
+			template: "Send to {{truncate 5 .recipient}}",
+			input:    `{"recipient":"` + huge + `"}`,
+			want:     "Send to AAAAA…",
+		},
+		{
+			name:     "output built up from many small writes",
+			template: "{{range .items}}{{$.pad}}{{end}}",
+			input:    string(manyItems),
+		},
+		{
+			name:     "deeply nested input",
+			template: "{{.v.v.v.v}}",
+			input:    nested,
+		},
+		{
+			name:     "printf padding",
+			template: `{{printf "%999999999d" 1}}`,
+			input:    `{}`,
+		},
+		{
+			name:     "printf star width",
+			template: `{{printf "%*d" .n 1}}`,
+			input:    `{"n":100000000}`,
+		},
+		{
+			name:     "printf within limits",
+			template: `{{printf "%-6s|%.2f" .name .n}}`,
+			input:    `{"name":"bob","n":1.5}`,
+			want:     "bob   |1.50",
+		},
+		{
+			name:     "call",
+			template: "{{call .f}}",
+			input:    `{"f":"x"}`,
+		},
+		{
+			name:     "exponent amount",
+			template: "{{amount .amount}}",
+			input:    `{"amount":"1e999999999"}`,
+		},
+		{
+			name:     "too many values",
+			template: "Send {{.amount}}",
+			input:    `{"amount":"1","pad":[` + strings.Repeat("0,", MaxSummaryValues) + `0]}`,
+		},
+		{
+			name:     "not an object",
+			template: "Send {{.amount}}",
+			input:    `["amount"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSummary("send_money", tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := s.Render(json.RawMessage(tt.input))
+			want := tt.want
+			if want == "" {
+				want = FallbackSummary("send_money", json.RawMessage(tt.input))
+			}
+			if got != want {
+				t.Errorf("Render() = %.100q, want %.100q", got, want)
+			}
+			if len(got) > MaxSummaryBytes {
+				t.Errorf("Render() returned %d bytes, over MaxSummaryBytes", len(got))
+			}
+		})
+	}
+}
+
+func TestSummary_RenderTimeout(t *testing.T) {
+	renderTimeout = time.Millisecond
+	defer func() { renderTimeout = SummaryTimeout }()
+
+	// Upper-casing 100KB for each of 999 items takes far longer than that.
+	s, err := ParseSummary("send_money", "{{range .a}}{{$x := upper $.s}}{{end}}done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, _ := json.Marshal(map[string]interface{}{"a": make([]int, MaxSummaryValues-2), "s": strings.Repeat("x", 100<<10)})
+
+	start := time.Now()
+	got := s.Render(input)
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Render() took %s, want about %s", elapsed, renderTimeout)
+	}
+	if got != FallbackSummary("send_money", input) {
+		t.Errorf("Render() = %q, want the fallback summary", got)
+	}
+}
+
+func TestFallbackSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "fields in key order",
+			input: `{"recipient":"@alice","amount":"50","currency":"USDC"}`,
+			want:  "send_money with {amount: 50, currency: USDC, recipient: @alice}",
+		},
+		{
+			name:  "nested objects and other values as JSON",
+			input: `{"to":{"name":"Alice","tag":"@alice"},"ids":[1,2],"memo":null,"urgent":true,"fee":0.5}`,
+			want:  `send_money with {fee: 0.5, ids: [1,2], memo: null, to: {"name":"Alice","tag":"@alice"}, urgent: true}`,
+		},
+		{
+			name:  "long values cut",
+			input: `{"note":"` + strings.Repeat("é", 100) + `"}`,
+			want:  "send_money with {note: " + strings.Repeat("é", maxFallbackValue) + "…}",
+		},
+		{
+			name:  "no fields",
+			input: `{}`,
+			want:  "send_money with {}",
+		},
+		{
+			name:  "not an object",
+			input: `"send it all"`,
+			want:  "Run send_money (review the details before confirming)",
+		},
+		{
+			name:  "too many values",
+			input: `{"pad":[` + strings.Repeat("0,", MaxSummaryValues) + `0]}`,
+			want:  "Run send_money (review the details before confirming)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FallbackSummary("send_money", json.RawMessage(tt.input)); got != tt.want {
+				t.Errorf("FallbackSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Many fields are listed up to MaxSummaryBytes.
+	fields := make(map[string]string, 200)
+	for i := 0; i < 200; i++ {
+		fields[fmt.Sprintf("field_%03d", i)] = strings.Repeat("x", 40)
+	}
+	input, _ := json.Marshal(fields)
+	got := FallbackSummary("send_money", input)
+	if len(got) > MaxSummaryBytes || !strings.HasPrefix(got, "send_money with {field_000: ") || !strings.HasSuffix(got, ", …}") {
+		t.Errorf("FallbackSummary() = %.100q… (%d bytes), want the first fields within MaxSummaryBytes", got, len(got))
+	}
+}
+
+func TestSummary_Fuzz(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	s, err := ParseSummary("send_money", "Send {{currency .amount .currency}} to {{.recipient}}{{with .note}}: {{truncate 40 .}}{{end}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := []interface{}{
+		"", "50", "-0.01", "1e9", "NaN", "{{.amount}}", "<script>", "\x00\xff", strings.Repeat("9", 500),
+		1.5, -1, 1e308, true, nil, []interface{}{"a", 1}, map[string]interface{}{"a": map[string]interface{}{"b": "c"}},
+		strings.Repeat("x", MaxSummaryBytes),
+	}
+	for _, amount := range values {
+		for _, recipient := range values {
+			for _, note := range values {
+				input, _ := json.Marshal(map[string]interface{}{"amount": amount, "currency": "USDC", "recipient": recipient, "note": note})
+				if got := s.Render(input); len(got) > MaxSummaryBytes {
+					t.Fatalf("Render(%.100s) returned %d bytes", input, len(got))
+				}
+			}
+		}
+	}
+}
@@ -1,10 +1,8 @@
 package core
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"text/template"
 )
 
 // Tool is the interface for all tools available to agents.
@@ -41,6 +39,56 @@ type ToolParams struct {
 
 	// RequestID for tracing/logging.
 	RequestID string
+
+	// Context is the agent context of the run calling the tool, so tools
+	// that start agents of their own, such as sub-agent delegation, can
+	// inherit it. Nil outside a run, e.g. for confirmed writes. Tools must
+	// not modify it.
+	Context *Context
+
+	// Progress receives progress reports from long-running tools. It is nil
+	// when nobody is listening; call ReportProgress instead of using it directly.
+	Progress ProgressFunc
+
+	// Message receives messages for the user from long-running tools. It is
+	// nil when nobody is listening; call EmitMessage instead of using it
+	// directly.
+	Message MessageFunc
+}
+
+// ProgressFunc receives a tool's progress: the stage it has reached, such as
+// "fetching transactions", and roughly how far through it is, from 0 to 100.
+type ProgressFunc func(stage string, percent float64)
+
+// ReportProgress reports the tool's progress to the client, if anyone is
+// listening. Percent is clamped to 0-100. It may be called from any
+// goroutine until the handler returns; later calls are dropped.
+func (p *ToolParams) ReportProgress(stage string, percent float64) {
+	if p == nil || p.Progress == nil {
+		return
+	}
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	p.Progress(stage, percent)
+}
+
+// MessageFunc receives a message a tool wants the user to see while it is
+// still running, such as "Step 1/3: Checking your account balance...".
+type MessageFunc func(text string)
+
+// EmitMessage shows the user a message while the tool is still running, if
+// anyone is listening, e.g. each step of a multi-step workflow. Messages
+// are not sent to the model; put anything it needs in the result. Like
+// ReportProgress, it may be called from any goroutine until the handler
+// returns, and empty messages are dropped.
+func (p *ToolParams) EmitMessage(text string) {
+	if p == nil || p.Message == nil || text == "" {
+		return
+	}
+	p.Message(text)
 }
 
 // ToolResult contains the result of a tool execution.
@@ -56,6 +104,84 @@ type ToolResult struct {
 
 	// Metadata contains additional info (e.g., transaction hash).
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Propose asks the engine to offer a write action for confirmation after
+	// this tool returns. The action goes through the same checks as if the
+	// model had called the tool directly, and is never executed without
+	// the user's approval.
+	Propose *ProposedAction `json:"-"`
+}
+
+// ProposedAction is a write action a tool asks the engine to offer for confirmation.
+type ProposedAction struct {
+	// Tool is the name of the write tool to run once confirmed.
+	Tool string
+
+	// Input is the tool parameters as JSON.
+	Input json.RawMessage
+
+	// Summary replaces the tool's own summary in the confirmation prompt.
+	// If empty, the tool's summary is used.
+	Summary string
+}
+
+// InverseFunc computes the action that undoes an executed write, given the
+// input it ran with and its result. Returns feasible=false if the action
+// cannot be undone.
+type InverseFunc func(input json.RawMessage, result *ToolResult) (inverseTool string, inverseInput json.RawMessage, feasible bool)
+
+// Irreversible is an InverseFunc for actions that can never be undone,
+// such as sending money to another user.
+func Irreversible(json.RawMessage, *ToolResult) (string, json.RawMessage, bool) {
+	return "", nil, false
+}
+
+// ReversibleTool is implemented by tools that declare how to undo themselves.
+// Tools that don't implement it, or return nil, cannot be undone.
+type ReversibleTool interface {
+	Tool
+
+	// Inverse returns the function that computes this tool's undo, or nil.
+	Inverse() InverseFunc
+}
+
+// DifferentialTool is implemented by read tools whose repeated results can be
+// sent to the model as the changes since the previous call, rather than in
+// full. Tools that don't implement it, or return nil, are always sent in full.
+type DifferentialTool interface {
+	Tool
+
+	// DiffKeys returns the field that identifies the elements of each array
+	// in the result, by the array's path (see jsondiff.Keys), or nil.
+	DiffKeys() map[string]string
+}
+
+// FullResultTool is implemented by tools whose results must reach the model
+// in full, however large, rather than being truncated to the engine's cap.
+type FullResultTool interface {
+	Tool
+
+	// FullResults reports whether the tool's results are exempt from truncation.
+	FullResults() bool
+}
+
+// UncheckedInputTool is implemented by tools that validate their own input,
+// so the engine doesn't check it against their schema before executing them.
+type UncheckedInputTool interface {
+	Tool
+
+	// SkipsValidation reports whether the engine leaves the input unchecked.
+	SkipsValidation() bool
+}
+
+// UncachedTool is implemented by read tools whose results must not be
+// reused, e.g. because they change state without asking for confirmation.
+// The engine's tool cache (see engine.WithRunCache) always runs them.
+type UncachedTool interface {
+	Tool
+
+	// NoCache reports whether the tool's results are never cached.
+	NoCache() bool
 }
 
 // ToolDefinition contains static tool metadata.
@@ -72,14 +198,53 @@ type ToolDefinition struct {
 	// SummaryTemplate is a Go template for generating summaries.
 	SummaryTemplate string
 
+	// SummaryDetails computes the machine-readable details of the tool's
+	// pending actions. Nil means they are described by their input.
+	SummaryDetails DetailsFunc
+
 	// InputSchema is the JSON Schema for parameters.
 	InputSchema map[string]interface{}
+
+	// Inverse computes how to undo an executed write. Nil means the tool
+	// declares no undo; use Irreversible to state that explicitly.
+	Inverse InverseFunc
+
+	// DiffKeys opts a read tool into differential results: the key field of
+	// each array in the result, by the array's path. Nil means results are
+	// always sent in full; an empty map diffs without keyed arrays. For
+	// enveloped tools, paths are relative to the envelope's data.
+	DiffKeys map[string]string
+
+	// FullResults sends the tool's results to the model in full, exempt
+	// from the engine's result size cap.
+	FullResults bool
+
+	// SkipValidation leaves the tool's input unchecked against InputSchema
+	// before it executes; the handler validates it instead.
+	SkipValidation bool
+
+	// NoCache stops the engine's tool cache reusing the tool's results.
+	// Set it on read tools that change state or must always be fresh.
+	NoCache bool
+
+	// Previews names the write tool an executor tool previews, e.g.
+	// send_money for preview_send_money. The tool runs it as a dry run,
+	// which needs no confirmation and changes nothing.
+	Previews string
+
+	// Envelope wraps the tool's successful results in an Envelope.
+	Envelope bool
+
+	// Figures extracts the monetary figures from the tool's results into
+	// the envelope. Only used if Envelope is set.
+	Figures FigureFunc
 }
 
 // BaseTool provides common tool functionality.
 type BaseTool struct {
 	definition ToolDefinition
 	handler    ToolHandler
+	summary    toolSummary
 }
 
 // ToolHandler is a function that executes a tool.
@@ -90,6 +255,7 @@ func NewBaseTool(def ToolDefinition, handler ToolHandler) *BaseTool {
 	return &BaseTool{
 		definition: def,
 		handler:    handler,
+		summary:    parseToolSummary(def),
 	}
 }
 
@@ -113,42 +279,63 @@ func (t *BaseTool) RequiresConfirmation() bool {
 	return t.definition.RequiresUserConfirmation
 }
 
+// Inverse returns the tool's undo function, or nil.
+func (t *BaseTool) Inverse() InverseFunc {
+	return t.definition.Inverse
+}
+
+// DiffKeys returns the tool's array key fields for differential results, or nil.
+func (t *BaseTool) DiffKeys() map[string]string {
+	return t.definition.DiffKeys
+}
+
+// FullResults reports whether the tool's results are exempt from truncation.
+func (t *BaseTool) FullResults() bool {
+	return t.definition.FullResults
+}
+
+// SkipsValidation reports whether the tool's input is left unchecked.
+func (t *BaseTool) SkipsValidation() bool {
+	return t.definition.SkipValidation
+}
+
+// NoCache reports whether the tool's results are never cached.
+func (t *BaseTool) NoCache() bool {
+	return t.definition.NoCache
+}
+
 // Execute runs the tool handler.
 func (t *BaseTool) Execute(ctx context.Context, params *ToolParams) (*ToolResult, error) {
 	if t.handler == nil {
 		return &ToolResult{Success: false, Error: "no handler configured"}, nil
 	}
-	return t.handler(ctx, params)
+	result, err := t.handler(ctx, params)
+	if err != nil || !t.definition.Envelope {
+		return result, err
+	}
+	return Enveloped(result, t.definition.Figures), nil
 }
 
 // GetSummary returns a formatted summary using the template.
 func (t *BaseTool) GetSummary(input json.RawMessage) string {
-	// If no template, return empty string
-	if t.definition.SummaryTemplate == "" {
-		return ""
-	}
-
-	// Parse input JSON into a map for templating
-	var data map[string]interface{}
-	if err := json.Unmarshal(input, &data); err != nil {
-		// If parsing fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
+	return t.summary.render(t.definition, input)
+}
 
-	// Create and execute template
-	tmpl, err := template.New("summary").Parse(t.definition.SummaryTemplate)
-	if err != nil {
-		// If template parsing fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
+// LocalizedSummary returns the summary written for locale, formatting
+// currency amounts for it.
+func (t *BaseTool) LocalizedSummary(input json.RawMessage, locale string) string {
+	return t.summary.renderLocale(t.definition, input, locale)
+}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		// If template execution fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
+// SummaryDetails returns the details of a pending action, or nil if the
+// tool has no SummaryDetails.
+func (t *BaseTool) SummaryDetails(input json.RawMessage) Details {
+	return summaryDetails(t.definition, input)
+}
 
-	return buf.String()
+// Validate reports whether the tool's summary template parsed.
+func (t *BaseTool) Validate() error {
+	return t.summary.err
 }
 
 // Definition returns the underlying ToolDefinition.
@@ -83,9 +83,9 @@ func TestBaseTool_GetSummary_InvalidJSON(t *testing.T) {
 		SummaryTemplate: "Send {{.amount}} to {{.recipient}}",
 	}, nil)
 
-	// Invalid JSON should return template as-is
+	// Invalid JSON should return the generic summary
 	got := tool.GetSummary(json.RawMessage(`invalid json`))
-	want := "Send {{.amount}} to {{.recipient}}"
+	want := FallbackSummary("test_tool", json.RawMessage(`invalid json`))
 	if got != want {
 		t.Errorf("GetSummary() with invalid JSON = %q, want %q", got, want)
 	}
@@ -103,10 +103,15 @@ func TestBaseTool_GetSummary_InvalidTemplate(t *testing.T) {
 	}
 	inputBytes, _ := json.Marshal(input)
 
-	// Invalid template should return template as-is
+	// Invalid template should list the input instead
 	got := tool.GetSummary(inputBytes)
-	want := "Send {{.amount} to {{.recipient}}"
+	want := "test_tool with {amount: 50.00, recipient: @alice}"
 	if got != want {
 		t.Errorf("GetSummary() with invalid template = %q, want %q", got, want)
 	}
+
+	// and is reported when the tool is registered
+	if err := tool.Validate(); err == nil {
+		t.Error("Validate() with invalid template = nil, want an error")
+	}
 }
\ No newline at end of file
@@ -3,6 +3,9 @@ package core
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -42,6 +45,9 @@ type ContentBlock struct {
 
 	// ToolResult contains tool execution result (for ToolResultBlock type).
 	ToolResult *ToolResultContent `json:"tool_result,omitempty"`
+
+	// Image contains an image the user sent (for ImageBlock type).
+	Image *ImageContent `json:"image,omitempty"`
 }
 
 // ContentBlockType indicates the type of content block.
@@ -56,6 +62,9 @@ const (
 
 	// ToolResultBlockType contains the result of a tool execution.
 	ToolResultBlockType ContentBlockType = "tool_result"
+
+	// ImageBlockType contains an image, e.g. a photo of a receipt.
+	ImageBlockType ContentBlockType = "image"
 )
 
 // ToolUseContent contains details about a tool invocation.
@@ -82,6 +91,18 @@ type ToolResultContent struct {
 	IsError bool `json:"is_error,omitempty"`
 }
 
+// ImageContent is an image, given either inline or by URL.
+type ImageContent struct {
+	// MediaType is the image's MIME type, e.g. image/png.
+	MediaType string `json:"media_type,omitempty"`
+
+	// Data is the base64-encoded image, for inline images.
+	Data string `json:"data,omitempty"`
+
+	// URL is where the image can be fetched, for images given by URL.
+	URL string `json:"url,omitempty"`
+}
+
 // NewUserMessage creates a user text message.
 func NewUserMessage(text string) Message {
 	return Message{Role: RoleUser, Content: text}
@@ -114,6 +135,16 @@ func NewTextBlock(text string) ContentBlock {
 	return ContentBlock{Type: TextBlockType, Text: text}
 }
 
+// NewImageBlock creates an image content block from base64-encoded data.
+func NewImageBlock(mediaType, data string) ContentBlock {
+	return ContentBlock{Type: ImageBlockType, Image: &ImageContent{MediaType: mediaType, Data: data}}
+}
+
+// NewImageURLBlock creates an image content block for an image at url.
+func NewImageURLBlock(url string) ContentBlock {
+	return ContentBlock{Type: ImageBlockType, Image: &ImageContent{URL: url}}
+}
+
 // NewToolUseBlock creates a tool_use content block.
 func NewToolUseBlock(id, name string, input json.RawMessage) ContentBlock {
 	return ContentBlock{
@@ -169,6 +200,11 @@ type Context struct {
 	// AuditParentID links sub-agent audit entries to their parent.
 	AuditParentID *string
 
+	// Credential is the user's bearer token, attached to the context of
+	// every tool and executor call (see WithCredential). It is never
+	// serialized.
+	Credential string `json:"-"`
+
 	// Preferences contains user's configuration and defaults.
 	Preferences *UserPreferences
 
@@ -212,6 +248,10 @@ type UserPreferences struct {
 	// Timezone is the user's timezone (e.g., "America/New_York").
 	Timezone string `json:"timezone"`
 
+	// WeekStartDay is the day the user's weeks start on, e.g. "sunday".
+	// Empty is Monday. See FirstWeekday.
+	WeekStartDay string `json:"week_start_day,omitempty"`
+
 	// Shortcuts maps user-defined nicknames to user IDs.
 	// For example: {"mom": "user_abc123", "landlord": "user_xyz789"}
 	Shortcuts map[string]string `json:"shortcuts,omitempty"`
@@ -228,6 +268,72 @@ func DefaultPreferences() *UserPreferences {
 	}
 }
 
+// FirstWeekday returns the day the user's weeks start on: WeekStartDay,
+// or Monday if it's empty or not a day's name.
+func (p *UserPreferences) FirstWeekday() time.Weekday {
+	if p != nil {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.EqualFold(p.WeekStartDay, d.String()) {
+				return d
+			}
+		}
+	}
+	return time.Monday
+}
+
+// Clone returns a copy of the preferences that shares no state with p.
+func (p *UserPreferences) Clone() *UserPreferences {
+	if p == nil {
+		return nil
+	}
+	clone := *p
+	if p.Shortcuts != nil {
+		clone.Shortcuts = make(map[string]string, len(p.Shortcuts))
+		for nickname, userID := range p.Shortcuts {
+			clone.Shortcuts[nickname] = userID
+		}
+	}
+	return &clone
+}
+
+// ShortcutKey normalizes a nickname for Shortcuts: trimmed and lowercased,
+// so "Mom" and "mom " are the same shortcut.
+func ShortcutKey(nickname string) string {
+	return strings.ToLower(strings.TrimSpace(nickname))
+}
+
+// ResolveRecipient returns the user ID a recipient nickname is a shortcut
+// for, and whether it is one. Display tags such as "@mom" are always taken
+// literally, so a nickname can never redirect a payment meant for the user
+// with that tag.
+func (p *UserPreferences) ResolveRecipient(recipient string) (string, bool) {
+	if p == nil || strings.HasPrefix(strings.TrimSpace(recipient), "@") {
+		return "", false
+	}
+	userID, ok := p.Shortcuts[ShortcutKey(recipient)]
+	return userID, ok && userID != ""
+}
+
+// ShortcutsPrompt describes the user's shortcuts for the system prompt, so
+// the agent knows who "mom" is, or returns "" if they have none.
+func (p *UserPreferences) ShortcutsPrompt() string {
+	if p == nil || len(p.Shortcuts) == 0 {
+		return ""
+	}
+	nicknames := make([]string, 0, len(p.Shortcuts))
+	for nickname := range p.Shortcuts {
+		nicknames = append(nicknames, nickname)
+	}
+	sort.Strings(nicknames)
+
+	var b strings.Builder
+	b.WriteString("The user has saved these recipient shortcuts (nickname: user ID). Pass the nickname as send_money's recipient and it is resolved to the user; a display tag like @name is always taken literally.")
+	for _, nickname := range nicknames {
+		fmt.Fprintf(&b, "\n- %s: %s", nickname, p.Shortcuts[nickname])
+	}
+	return b.String()
+}
+
 // UserLimits contains user-specific financial limits.
 type UserLimits struct {
 	// DailyTransferLimit is the maximum amount the user can transfer per day.
@@ -260,7 +366,8 @@ type ExecutionLimits struct {
 	// Timeout is the maximum execution time.
 	Timeout time.Duration
 
-	// MaxToolCalls is the maximum total tool calls per execution.
+	// MaxToolCalls is the maximum total tool calls per execution, across
+	// all turns. Zero uses the default.
 	MaxToolCalls int
 
 	// CanConfirm indicates whether this execution can request user confirmation.
@@ -301,6 +408,7 @@ func (c *Context) ForSubAgent(requestID string) *Context {
 		ConversationID: c.ConversationID,
 		RequestID:      requestID,
 		AuditParentID:  &parentID,
+		Credential:     c.Credential,
 		Preferences:    c.Preferences,
 		UserLimits:     c.UserLimits,
 		Limits:         SubAgentLimits(),
@@ -341,6 +449,16 @@ func (t TokenUsage) TotalTokens() int {
 	return t.InputTokens + t.OutputTokens
 }
 
+// Add returns the sum of t and u, field by field.
+func (t TokenUsage) Add(u TokenUsage) TokenUsage {
+	return TokenUsage{
+		InputTokens:              t.InputTokens + u.InputTokens,
+		OutputTokens:             t.OutputTokens + u.OutputTokens,
+		CacheCreationInputTokens: t.CacheCreationInputTokens + u.CacheCreationInputTokens,
+		CacheReadInputTokens:     t.CacheReadInputTokens + u.CacheReadInputTokens,
+	}
+}
+
 // PendingAction represents an action awaiting user confirmation.
 type PendingAction struct {
 	// ID is the unique identifier for this pending action.
@@ -353,6 +471,9 @@ type PendingAction struct {
 	// SessionID identifies which session created this confirmation.
 	SessionID string `json:"session_id"`
 
+	// ConversationID is the conversation the action was offered in, if any.
+	ConversationID string `json:"conversation_id,omitempty"`
+
 	// UserID is the user who initiated the action.
 	UserID string `json:"user_id"`
 
@@ -362,12 +483,33 @@ type PendingAction struct {
 	// Input is the tool parameters as JSON.
 	Input json.RawMessage `json:"input"`
 
+	// InputHash is the SHA-256 of the canonicalized Input, taken when the
+	// action is created (see HashInput). The input is verified against it
+	// immediately before execution, so what runs is exactly what the user
+	// approved. Use SetInput to change Input.
+	InputHash string `json:"input_hash"`
+
+	// OriginalInput is the input first offered for confirmation, set when
+	// the user edited it before confirming.
+	OriginalInput json.RawMessage `json:"original_input,omitempty"`
+
 	// Summary is a human-readable description of the action.
 	Summary string `json:"summary"`
 
+	// Locale is the locale Summary is written for, e.g. "de-DE", so it is
+	// rewritten for the same one when the action is edited.
+	Locale string `json:"locale,omitempty"`
+
+	// Details describes the action in machine-readable fields, e.g. its
+	// amount and recipient, for clients to render. See DetailedTool.
+	Details Details `json:"details,omitempty"`
+
 	// BlockID is Claude's tool_use block ID for session reconstruction.
 	BlockID string `json:"block_id"`
 
+	// GroupID is the PendingActionGroup the action was offered in, if any.
+	GroupID string `json:"group_id,omitempty"`
+
 	// CreatedAt is when the action was created (unix timestamp).
 	CreatedAt int64 `json:"created_at"`
 
@@ -375,6 +517,69 @@ type PendingAction struct {
 	ExpiresAt int64 `json:"expires_at"`
 }
 
+// PendingActionGroup is several write actions the model asked for in one
+// turn, e.g. the transfers splitting a bill, offered for confirmation
+// together. The user confirms all of them, some of them, or none.
+type PendingActionGroup struct {
+	// ID is the unique identifier for this group.
+	ID string `json:"id"`
+
+	// SessionID identifies which session created this group.
+	SessionID string `json:"session_id"`
+
+	// ConversationID is the conversation the group was offered in, if any.
+	ConversationID string `json:"conversation_id,omitempty"`
+
+	// UserID is the user who initiated the actions.
+	UserID string `json:"user_id"`
+
+	// Actions are the group's actions, in the order the model asked for
+	// them, which is the order they are executed in.
+	Actions []*PendingAction `json:"actions"`
+
+	// CreatedAt is when the group was created (unix timestamp).
+	CreatedAt int64 `json:"created_at"`
+
+	// ExpiresAt is when the first of its actions expires (unix timestamp).
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// NewPendingActionGroup groups actions under id, setting their GroupID.
+func NewPendingActionGroup(id string, actions []*PendingAction) *PendingActionGroup {
+	group := &PendingActionGroup{ID: id, Actions: actions, CreatedAt: time.Now().Unix()}
+	for i, action := range actions {
+		action.GroupID = id
+		if i == 0 {
+			group.SessionID = action.SessionID
+			group.ConversationID = action.ConversationID
+			group.UserID = action.UserID
+		}
+		if group.ExpiresAt == 0 || action.ExpiresAt < group.ExpiresAt {
+			group.ExpiresAt = action.ExpiresAt
+		}
+	}
+	return group
+}
+
+// Action returns the group's action with the given ID, or nil.
+func (g *PendingActionGroup) Action(id string) *PendingAction {
+	for _, action := range g.Actions {
+		if action.ID == id {
+			return action
+		}
+	}
+	return nil
+}
+
+// Summary describes the group's actions on one line.
+func (g *PendingActionGroup) Summary() string {
+	summaries := make([]string, len(g.Actions))
+	for i, action := range g.Actions {
+		summaries[i] = action.Summary
+	}
+	return strings.Join(summaries, "; ")
+}
+
 // ToolExecution records a single tool invocation.
 type ToolExecution struct {
 	// Tool is the name of the tool.
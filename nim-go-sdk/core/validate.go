@@ -0,0 +1,204 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidateInput checks tool input against the subset of JSON Schema the
+// SDK's schema helpers produce: "type", "properties", "required", "enum",
+// "minimum" and array "items". Keywords it doesn't know are ignored, so it only
+// rejects input that is wrong, not everything a full validator would. Fields
+// the schema doesn't describe are allowed.
+//
+// Errors name the field and what was expected, e.g. "missing required field
+// 'amount' (expected string)", so a model can correct its call.
+func ValidateInput(schema map[string]interface{}, input json.RawMessage) error {
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("input is not valid JSON: %w", err)
+	}
+	return validateValue(schema, value, "")
+}
+
+func validateValue(schema map[string]interface{}, value interface{}, path string) error {
+	if t, ok := schema["type"].(string); ok && !hasType(value, t) {
+		return fmt.Errorf("%s must be %s, got %s", fieldName(path), withArticle(t), jsonType(value))
+	}
+	if enum := stringList(schema["enum"]); len(enum) > 0 {
+		s, _ := value.(string)
+		found := false
+		for _, e := range enum {
+			if s == e {
+				found = true
+				break
+			}
+		}
+		if !found {
+			got, _ := json.Marshal(value)
+			return fmt.Errorf("%s must be one of %s, got %s", fieldName(path), strings.Join(enum, ", "), got)
+		}
+	}
+	if min, ok := number(schema["minimum"]); ok {
+		if n, isNumber := value.(json.Number); isNumber {
+			if f, err := n.Float64(); err == nil && f < min {
+				return fmt.Errorf("%s must be at least %v, got %s", fieldName(path), min, n)
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range stringList(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				if property, ok := properties[name].(map[string]interface{}); ok {
+					if t, ok := property["type"].(string); ok {
+						return fmt.Errorf("missing required field '%s' (expected %s)", join(path, name), t)
+					}
+				}
+				return fmt.Errorf("missing required field '%s'", join(path, name))
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := properties[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if err := validateValue(property, v[name], join(path, name)); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		items, ok := schema["items"].(map[string]interface{})
+		if !ok {
+			break
+		}
+		for i, item := range v {
+			if err := validateValue(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// join appends a property name to a field path.
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// fieldName names a field path in errors; the empty path is the input itself.
+func fieldName(path string) string {
+	if path == "" {
+		return "input"
+	}
+	return "field '" + path + "'"
+}
+
+// jsonType names the JSON type of a value decoded with UseNumber.
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// hasType reports whether a value decoded with UseNumber is of a JSON
+// Schema type. Unknown types match anything.
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func withArticle(t string) string {
+	switch t {
+	case "object", "array", "integer":
+		return "an " + t
+	}
+	return "a " + t
+}
+
+// number reads a number from a schema, whether it was built in Go or
+// decoded from JSON.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// stringList reads a list of strings from a schema, whether it was built
+// in Go ([]string) or decoded from JSON ([]interface{}).
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		strs := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	}
+	return nil
+}
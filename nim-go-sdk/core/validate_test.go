@@ -0,0 +1,60 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateInput(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"recipient": map[string]interface{}{"type": "string"},
+			"amount":    map[string]interface{}{"type": "string"},
+			"currency":  map[string]interface{}{"type": "string", "enum": []string{"USD", "EUR"}},
+			"count":     map[string]interface{}{"type": "integer", "minimum": 1},
+			"urgent":    map[string]interface{}{"type": "boolean"},
+			"tags":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		"required": []string{"recipient", "amount", "currency"},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "valid", input: `{"recipient":"@alice","amount":"45.00","currency":"USD"}`},
+		{name: "all fields", input: `{"recipient":"@alice","amount":"45","currency":"EUR","count":2,"urgent":true,"tags":["rent"],"extra":1}`},
+		{name: "not JSON", input: `{"recipient":`, wantErr: "not valid JSON"},
+		{name: "not an object", input: `["@alice"]`, wantErr: "input must be an object, got array"},
+		{name: "missing required", input: `{"recipient":"@alice","currency":"USD"}`, wantErr: "missing required field 'amount' (expected string)"},
+		{name: "wrong type", input: `{"recipient":"@alice","amount":45,"currency":"USD"}`, wantErr: "field 'amount' must be a string, got number"},
+		{name: "not in enum", input: `{"recipient":"@alice","amount":"45","currency":"GBP"}`, wantErr: `field 'currency' must be one of USD, EUR, got "GBP"`},
+		{name: "fractional integer", input: `{"recipient":"@alice","amount":"45","currency":"USD","count":1.5}`, wantErr: "field 'count' must be an integer, got number"},
+		{name: "below minimum", input: `{"recipient":"@alice","amount":"45","currency":"USD","count":0}`, wantErr: "field 'count' must be at least 1, got 0"},
+		{name: "wrong item type", input: `{"recipient":"@alice","amount":"45","currency":"USD","tags":["rent",1]}`, wantErr: "field 'tags[1]' must be a string, got number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateInput(schema, json.RawMessage(tt.input))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateInput() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateInput() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Schemas decoded from JSON hold []interface{} rather than []string.
+	var decoded map[string]interface{}
+	json.Unmarshal([]byte(`{"type":"object","required":["amount"]}`), &decoded)
+	if err := ValidateInput(decoded, json.RawMessage(`{}`)); err == nil {
+		t.Error("ValidateInput() with a decoded schema accepted a missing required field")
+	}
+}
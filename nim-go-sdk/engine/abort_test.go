@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func TestRun_Aborted(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		cause    error
+		wantType OutputType
+	}{
+		{name: "aborted", cause: ErrAborted, wantType: OutputAborted},
+		{name: "cancelled", wantType: OutputError},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			seen := make(chan error, 1)
+			registry := NewToolRegistry()
+			registry.Register(tools.New("slow_report").
+				Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					close(started)
+					select {
+					case <-ctx.Done():
+						seen <- context.Cause(ctx)
+						return nil, ctx.Err()
+					case <-time.After(10 * time.Second):
+						return &core.ToolResult{Success: true, Data: "done"}, nil
+					}
+				}).
+				Build())
+			eng := NewEngine(scriptedClient(t, toolUses(1, "slow_report")), registry)
+
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+			type result struct {
+				out *Output
+				err error
+			}
+			done := make(chan result, 1)
+			go func() {
+				out, err := eng.Run(ctx, &Input{UserMessage: "report please", Context: core.NewContext("user-1", "sess-1", "conv-1", "req-1")})
+				done <- result{out, err}
+			}()
+
+			<-started
+			cancel(tt.cause)
+			var r result
+			select {
+			case r = <-done:
+			case <-time.After(time.Second):
+				t.Fatal("Run() didn't return within a second of the cancel")
+			}
+
+			if r.err != nil || r.out.Type != tt.wantType {
+				t.Fatalf("Run() = %+v, %v, want type %v", r.out, r.err, tt.wantType)
+			}
+			if cause := <-seen; tt.cause != nil && !errors.Is(cause, tt.cause) {
+				t.Errorf("tool saw cause %v, want %v", cause, tt.cause)
+			}
+			if tt.wantType != OutputAborted {
+				return
+			}
+			if len(r.out.ToolsUsed) != 1 || r.out.ToolsUsed[0].Tool != "slow_report" || r.out.TokensUsed.TotalTokens() == 0 {
+				t.Errorf("aborted run = %+v, want the tool call and tokens used so far", r.out)
+			}
+		})
+	}
+}
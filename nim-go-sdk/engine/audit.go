@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 )
 
-// AuditLogger logs tool executions for compliance and debugging.
-// This is an interface - implementations (e.g., PostgreSQL-backed) are provided
-// by the consuming application.
+// AuditLogger logs tool executions for compliance and debugging. The audit
+// package provides file and webhook implementations; applications can
+// provide their own, e.g. PostgreSQL-backed.
 type AuditLogger interface {
 	// Log records an audit entry for a tool execution.
 	Log(ctx context.Context, entry *AuditEntry) error
@@ -40,6 +40,10 @@ type AuditEntry struct {
 	// ToolInput contains the tool parameters as JSON.
 	ToolInput json.RawMessage `json:"tool_input"`
 
+	// OriginalInput is the input the user was first asked to confirm, when
+	// they edited it before confirming. ToolInput is what executed.
+	OriginalInput json.RawMessage `json:"original_input,omitempty"`
+
 	// ToolOutput contains the tool result as JSON.
 	ToolOutput json.RawMessage `json:"tool_output,omitempty"`
 
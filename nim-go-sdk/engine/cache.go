@@ -0,0 +1,31 @@
+package engine
+
+import "github.com/anthropics/anthropic-sdk-go"
+
+// WithPromptCaching marks the system prompt and tool definitions as a
+// cacheable prefix, so turns after the first read them from Anthropic's
+// prompt cache at a fraction of the input price. Cache reads and writes are
+// reported in Output.TokensUsed. Runs can opt out with
+// Input.DisablePromptCaching.
+func WithPromptCaching() Option {
+	return func(e *Engine) {
+		e.promptCaching = true
+	}
+}
+
+// cachePrompt adds ephemeral cache breakpoints after the last tool
+// definition and the system prompt. The tools are copied, not modified.
+func cachePrompt(params *anthropic.MessageNewParams) {
+	if n := len(params.Tools); n > 0 {
+		tools := append([]anthropic.ToolUnionParam(nil), params.Tools...)
+		if tool := tools[n-1].OfTool; tool != nil {
+			cached := *tool
+			cached.CacheControl = anthropic.NewCacheControlEphemeralParam()
+			tools[n-1] = anthropic.ToolUnionParam{OfTool: &cached}
+		}
+		params.Tools = tools
+	}
+	if n := len(params.System); n > 0 {
+		params.System[n-1].CacheControl = anthropic.NewCacheControlEphemeralParam()
+	}
+}
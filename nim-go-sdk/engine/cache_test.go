@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// cachedResponse reports a cache write and a cache read.
+const cachedResponse = `{
+	"id": "msg_1",
+	"type": "message",
+	"role": "assistant",
+	"model": "test-model",
+	"content": [{"type": "text", "text": "done"}],
+	"stop_reason": "end_turn",
+	"usage": {"input_tokens": 10, "output_tokens": 5, "cache_creation_input_tokens": 1200, "cache_read_input_tokens": 3400}
+}`
+
+func TestRun_PromptCaching(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		disable   bool
+		wantCache bool
+	}{
+		{name: "enabled", opts: []Option{WithPromptCaching()}, wantCache: true},
+		{name: "disabled for the run", opts: []Option{WithPromptCaching()}, disable: true},
+		{name: "not enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request struct {
+				System []map[string]interface{} `json:"system"`
+				Tools  []map[string]interface{} `json:"tools"`
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				json.Unmarshal(body, &request)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(cachedResponse))
+			}))
+			defer srv.Close()
+
+			client := anthropic.NewClient(
+				option.WithAPIKey("test"),
+				option.WithBaseURL(srv.URL),
+				option.WithMaxRetries(0),
+			)
+			registry := NewToolRegistry()
+			for _, name := range []string{"get_balance", "send_money"} {
+				registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: name}, nil))
+			}
+			eng := NewEngine(&client, registry, tt.opts...)
+
+			out, err := eng.Run(context.Background(), &Input{
+				UserMessage:          "hi",
+				Context:              &core.Context{UserID: "user-1"},
+				DisablePromptCaching: tt.disable,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(request.System) != 1 || len(request.Tools) != 2 {
+				t.Fatalf("request system = %v, tools = %v", request.System, request.Tools)
+			}
+			_, systemCached := request.System[0]["cache_control"]
+			_, toolsCached := request.Tools[1]["cache_control"]
+			if systemCached != tt.wantCache || toolsCached != tt.wantCache {
+				t.Errorf("system cached = %v, tools cached = %v, want %v", systemCached, toolsCached, tt.wantCache)
+			}
+			if _, ok := request.Tools[0]["cache_control"]; ok {
+				t.Error("cache breakpoint on a tool before the last")
+			}
+
+			// Usage is reported whatever the setting.
+			if out.TokensUsed.CacheCreationInputTokens != 1200 || out.TokensUsed.CacheReadInputTokens != 3400 {
+				t.Errorf("TokensUsed = %+v, want the cache tokens", out.TokensUsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// DefaultKeepTurns is how many recent turns compaction keeps verbatim
+// unless CompactionConfig says otherwise.
+const DefaultKeepTurns = 4
+
+// Compaction summary limits.
+const (
+	excerptLength    = 200  // longest excerpt of one block in a deterministic summary
+	maxSummaryLength = 4000 // longest deterministic summary; the oldest lines go first
+	modelExcerpt     = 2000 // longest excerpt of one block sent to the summary model
+)
+
+// compactedNotice opens a compacted history. The API requires the first
+// message to be the user's, so the summary is the reply to it.
+const compactedNotice = "[Earlier messages in this conversation were summarized to fit the context window.]"
+
+const summaryPrompt = `Summarize this conversation between a user and a banking assistant so the assistant can continue it. Keep every fact it may need: names, amounts, currencies, account and transaction details, decisions, and anything left unresolved. Write plain prose without preamble.`
+
+// TokenEstimator estimates the input tokens of a request.
+type TokenEstimator func(params *anthropic.MessageNewParams) int
+
+// EstimateTokens is the default TokenEstimator: about four characters of
+// the request's JSON per token. It errs high for English text.
+func EstimateTokens(params *anthropic.MessageNewParams) int {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return 0
+	}
+	return len(data) / 4
+}
+
+// CompactionConfig configures history compaction.
+type CompactionConfig struct {
+	// Threshold compacts the history before any model call whose estimated
+	// input tokens exceed it. Required.
+	Threshold int
+
+	// KeepTurns is how many of the most recent turns are kept verbatim. A
+	// turn starts with a message from the user and runs until the next,
+	// so tool calls and their results are never separated. Defaults to
+	// DefaultKeepTurns.
+	KeepTurns int
+
+	// SummaryModel, if set, summarizes the older messages with a call to
+	// this model, e.g. a Haiku model. Otherwise, or if that call fails,
+	// they are summarized with truncated excerpts, at no cost.
+	SummaryModel string
+
+	// Estimator estimates input tokens. Defaults to EstimateTokens.
+	Estimator TokenEstimator
+}
+
+// WithCompaction replaces the oldest messages of long conversations with a
+// summary before they outgrow the model's context window. Runs that
+// compacted report Output.Compacted.
+func WithCompaction(cfg CompactionConfig) Option {
+	if cfg.KeepTurns <= 0 {
+		cfg.KeepTurns = DefaultKeepTurns
+	}
+	if cfg.Estimator == nil {
+		cfg.Estimator = EstimateTokens
+	}
+	return func(e *Engine) {
+		e.compaction = &cfg
+	}
+}
+
+// compact summarizes the session's older turns if params are over the
+// threshold, reporting whether it did and the tokens the summary cost.
+func (e *Engine) compact(ctx context.Context, session *Session, params *anthropic.MessageNewParams) (bool, core.TokenUsage) {
+	cfg := e.compaction
+	if cfg.Estimator(params) <= cfg.Threshold {
+		return false, core.TokenUsage{}
+	}
+
+	messages := session.Messages()
+	start := 0
+	var lines []string
+	if session.compacted {
+		// Fold the previous summary into the new one.
+		start = 2
+		lines = strings.Split(session.summary, "\n")
+	}
+	cut := compactionPoint(messages, start, cfg.KeepTurns)
+	if cut <= start {
+		// Only the turns being kept are left; there is nothing to summarize.
+		return false, core.TokenUsage{}
+	}
+
+	var summary string
+	var usage core.TokenUsage
+	if cfg.SummaryModel != "" {
+		var err error
+		summary, usage, err = e.summarize(ctx, cfg.SummaryModel, append(lines, transcript(messages[start:cut], modelExcerpt)...))
+		if err != nil {
+			log.Printf("Failed to summarize conversation %s, truncating instead: %v", session.ConversationID, err)
+		}
+	}
+	if summary == "" {
+		summary = truncatedSummary(append(lines, transcript(messages[start:cut], excerptLength)...))
+	}
+
+	compacted := []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock(compactedNotice)),
+		anthropic.NewAssistantMessage(anthropic.NewTextBlock("Summary of the conversation so far:\n" + summary)),
+	}
+	session.messages = append(compacted, messages[cut:]...)
+	session.compacted, session.summary = true, summary
+	return true, usage
+}
+
+// compactionPoint returns the index of the message starting the earliest of
+// the last keep turns, counting from start. Turns start at user messages
+// that are not tool results, so cutting there never separates a tool_use
+// from its tool_result.
+func compactionPoint(messages []anthropic.MessageParam, start, keep int) int {
+	var turns []int
+	for i := start; i < len(messages); i++ {
+		if messages[i].Role == anthropic.MessageParamRoleUser && !hasToolResult(messages[i]) {
+			turns = append(turns, i)
+		}
+	}
+	if len(turns) <= keep {
+		return start
+	}
+	return turns[len(turns)-keep]
+}
+
+func hasToolResult(msg anthropic.MessageParam) bool {
+	for _, block := range msg.Content {
+		if block.OfToolResult != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// summarize asks model for a summary of a transcript.
+func (e *Engine) summarize(ctx context.Context, model string, lines []string) (string, core.TokenUsage, error) {
+	resp, err := e.newMessage(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(model),
+		MaxTokens: 1024,
+		System:    []anthropic.TextBlockParam{{Text: summaryPrompt}},
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock(strings.Join(lines, "\n"))),
+		},
+	})
+	if err != nil {
+		return "", core.TokenUsage{}, err
+	}
+
+	usage := core.TokenUsage{
+		InputTokens:  int(resp.Usage.InputTokens),
+		OutputTokens: int(resp.Usage.OutputTokens),
+	}
+	var summary strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			summary.WriteString(block.Text)
+		}
+	}
+	return strings.TrimSpace(summary.String()), usage, nil
+}
+
+// truncatedSummary summarizes a transcript without a model, keeping the
+// most recent lines if there are too many.
+func truncatedSummary(lines []string) string {
+	length := 0
+	first := len(lines)
+	for first > 0 && length+len(lines[first-1])+1 <= maxSummaryLength {
+		first--
+		length += len(lines[first]) + 1
+	}
+	if first > 0 {
+		lines = append([]string{fmt.Sprintf("(%d earlier lines omitted)", first)}, lines[first:]...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// transcript renders messages as one line per block, each cut to at most
+// limit characters.
+func transcript(messages []anthropic.MessageParam, limit int) []string {
+	var lines []string
+	tools := make(map[string]string) // tool_use ID -> tool name
+	for _, msg := range messages {
+		speaker := "User"
+		if msg.Role == anthropic.MessageParamRoleAssistant {
+			speaker = "Assistant"
+		}
+		for _, block := range msg.Content {
+			switch {
+			case block.OfText != nil:
+				lines = append(lines, speaker+": "+excerpt(block.OfText.Text, limit))
+			case block.OfImage != nil:
+				lines = append(lines, speaker+": [image]")
+			case block.OfToolUse != nil:
+				tools[block.OfToolUse.ID] = block.OfToolUse.Name
+				input, _ := json.Marshal(block.OfToolUse.Input)
+				lines = append(lines, fmt.Sprintf("Assistant called %s: %s", block.OfToolUse.Name, excerpt(string(input), limit)))
+			case block.OfToolResult != nil:
+				var text strings.Builder
+				for _, content := range block.OfToolResult.Content {
+					if content.OfText != nil {
+						text.WriteString(content.OfText.Text)
+					}
+				}
+				lines = append(lines, fmt.Sprintf("Result of %s: %s", tools[block.OfToolResult.ToolUseID], excerpt(text.String(), limit)))
+			}
+		}
+	}
+	return lines
+}
+
+// excerpt flattens s to one line of at most limit characters.
+func excerpt(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > limit {
+		return string(r[:limit]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// countMessages is a fake TokenEstimator: one token per message.
+func countMessages(params *anthropic.MessageNewParams) int {
+	return len(params.Messages)
+}
+
+// toolHistory returns n turns, each a question answered after a call to
+// get_balance, so 4n messages.
+func toolHistory(n int) []core.Message {
+	var history []core.Message
+	for i := 1; i <= n; i++ {
+		id := fmt.Sprintf("toolu_%d", i)
+		history = append(history,
+			core.Message{Role: core.RoleUser, Content: fmt.Sprintf("question %d", i)},
+			core.Message{Role: core.RoleAssistant, ContentBlocks: []core.ContentBlock{
+				{Type: core.ToolUseBlockType, ToolUse: &core.ToolUseContent{ID: id, Name: "get_balance", Input: json.RawMessage(`{}`)}},
+			}},
+			core.Message{Role: core.RoleUser, ContentBlocks: []core.ContentBlock{
+				{Type: core.ToolResultBlockType, ToolResult: &core.ToolResultContent{ToolUseID: id, Content: fmt.Sprintf("balance %d", i)}}},
+			},
+			core.Message{Role: core.RoleAssistant, Content: fmt.Sprintf("answer %d", i)},
+		)
+	}
+	return history
+}
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		name          string
+		turns         int
+		threshold     int
+		keepTurns     int
+		wantCompacted bool
+		wantMessages  int
+	}{
+		{name: "under the threshold", turns: 5, threshold: 20, keepTurns: 2, wantCompacted: false, wantMessages: 20},
+		{name: "keeps the last turns", turns: 5, threshold: 10, keepTurns: 2, wantCompacted: true, wantMessages: 2 + 8},
+		{name: "nothing older to summarize", turns: 2, threshold: 1, keepTurns: 2, wantCompacted: false, wantMessages: 8},
+		{name: "defaults to DefaultKeepTurns", turns: 6, threshold: 10, wantCompacted: true, wantMessages: 2 + 4*DefaultKeepTurns},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEngine(nil, NewToolRegistry(), WithCompaction(CompactionConfig{
+				Threshold: tt.threshold,
+				KeepTurns: tt.keepTurns,
+				Estimator: countMessages,
+			}))
+			session := NewSession("user-1", "conv-1")
+			session.RestoreHistory(toolHistory(tt.turns))
+			params := anthropic.MessageNewParams{Messages: session.Messages()}
+
+			compacted, usage := e.compact(context.Background(), session, &params)
+			if compacted != tt.wantCompacted {
+				t.Fatalf("compacted = %v, want %v", compacted, tt.wantCompacted)
+			}
+			if usage != (core.TokenUsage{}) {
+				t.Errorf("usage = %+v, want none for a truncated summary", usage)
+			}
+			messages := session.Messages()
+			if len(messages) != tt.wantMessages {
+				t.Fatalf("got %d messages, want %d", len(messages), tt.wantMessages)
+			}
+			checkPairing(t, messages)
+			if !compacted {
+				return
+			}
+
+			summary := messages[1].Content[0].OfText.Text
+			if !strings.Contains(summary, "User: question 1") || !strings.Contains(summary, "Result of get_balance: balance 1") {
+				t.Errorf("summary missing the oldest turn:\n%s", summary)
+			}
+			kept := messages[2].Content[0].OfText.Text
+			if first := tt.turns - (len(messages)-2)/4 + 1; kept != fmt.Sprintf("question %d", first) {
+				t.Errorf("first kept message = %q, want question %d", kept, first)
+			}
+			if strings.Contains(summary, kept) {
+				t.Errorf("summary includes the kept turns:\n%s", summary)
+			}
+		})
+	}
+}
+
+func TestCompact_FoldsPreviousSummary(t *testing.T) {
+	e := NewEngine(nil, NewToolRegistry(), WithCompaction(CompactionConfig{Threshold: 6, KeepTurns: 1, Estimator: countMessages}))
+	session := NewSession("user-1", "conv-1")
+	session.RestoreHistory(toolHistory(3))
+
+	params := anthropic.MessageNewParams{Messages: session.Messages()}
+	if compacted, _ := e.compact(context.Background(), session, &params); !compacted {
+		t.Fatal("first compaction did not happen")
+	}
+	session.AddUserMessage("question 4")
+	session.AddAssistantMessage("answer 4")
+
+	params = anthropic.MessageNewParams{Messages: session.Messages()}
+	if compacted, _ := e.compact(context.Background(), session, &params); !compacted {
+		t.Fatal("second compaction did not happen")
+	}
+	messages := session.Messages()
+	if len(messages) != 4 {
+		t.Fatalf("got %d messages, want 4", len(messages))
+	}
+	summary := messages[1].Content[0].OfText.Text
+	for _, want := range []string{"question 1", "question 2", "question 3"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+	if strings.Count(summary, "Summary of the conversation") > 1 {
+		t.Errorf("summary nests the previous one:\n%s", summary)
+	}
+}
+
+// checkPairing fails unless messages open with the user, alternate roles,
+// and answer every tool_use in the next message.
+func checkPairing(t *testing.T, messages []anthropic.MessageParam) {
+	t.Helper()
+	if messages[0].Role != anthropic.MessageParamRoleUser {
+		t.Fatalf("first message is from %s", messages[0].Role)
+	}
+	for i, msg := range messages {
+		if i > 0 && msg.Role == messages[i-1].Role {
+			t.Fatalf("messages %d and %d are both from %s", i-1, i, msg.Role)
+		}
+		for _, block := range msg.Content {
+			if block.OfToolResult == nil {
+				continue
+			}
+			found := false
+			for _, prev := range messages[i-1].Content {
+				if prev.OfToolUse != nil && prev.OfToolUse.ID == block.OfToolResult.ToolUseID {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("message %d answers %s, which the message before did not call", i, block.OfToolResult.ToolUseID)
+			}
+		}
+	}
+}
+
+func TestRun_Compaction(t *testing.T) {
+	tests := []struct {
+		name          string
+		summaryModel  string
+		threshold     int
+		wantCompacted bool
+		wantRequests  int
+		wantTokens    int
+		wantSummary   string
+	}{
+		{name: "under the threshold", threshold: 100, wantCompacted: false, wantRequests: 1, wantTokens: 2},
+		{name: "truncated summary", threshold: 10, wantCompacted: true, wantRequests: 1, wantTokens: 2, wantSummary: `User: question 1`},
+		{name: "model summary", summaryModel: "claude-haiku", threshold: 10, wantCompacted: true, wantRequests: 2, wantTokens: 4, wantSummary: `Summary of the conversation so far:\nAll good.`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, rs := newModerationEngine(t, "All good.", WithCompaction(CompactionConfig{
+				Threshold:    tt.threshold,
+				KeepTurns:    2,
+				SummaryModel: tt.summaryModel,
+				Estimator:    countMessages,
+			}))
+			input := moderationInput("question 6")
+			input.History = toolHistory(5)
+
+			out, err := e.Run(context.Background(), input)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if out.Type != OutputComplete || out.Compacted != tt.wantCompacted {
+				t.Fatalf("got type %v, compacted %v; want complete, compacted %v", out.Type, out.Compacted, tt.wantCompacted)
+			}
+			if got := out.TokensUsed.TotalTokens(); got != tt.wantTokens {
+				t.Errorf("tokens = %d, want %d", got, tt.wantTokens)
+			}
+
+			bodies := rs.bodies()
+			if len(bodies) != tt.wantRequests {
+				t.Fatalf("got %d requests, want %d", len(bodies), tt.wantRequests)
+			}
+			last := bodies[len(bodies)-1]
+			if tt.summaryModel != "" && !strings.Contains(bodies[0], `"model":"claude-haiku"`) {
+				t.Errorf("summary request did not use the summary model: %s", bodies[0])
+			}
+			if !strings.Contains(last, tt.wantSummary) {
+				t.Errorf("request missing %q: %s", tt.wantSummary, last)
+			}
+			if tt.wantCompacted && strings.Contains(last, "toolu_1") {
+				t.Errorf("request still has the oldest turn: %s", last)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// ReplyIntent is what a plain-text reply means for a pending action.
+type ReplyIntent string
+
+// Reply intents.
+const (
+	ReplyConfirm ReplyIntent = "confirm"
+	ReplyCancel  ReplyIntent = "cancel"
+	ReplyNeither ReplyIntent = "neither"
+)
+
+// ConfirmationPhrases are the exact replies, after normalization, that
+// confirm or cancel a pending action in one language.
+type ConfirmationPhrases struct {
+	Affirmative []string
+	Negative    []string
+}
+
+// DefaultConfirmationPhrases are the confirmation phrases for each supported
+// language, keyed by language code. Unknown locales use "en".
+var DefaultConfirmationPhrases = map[string]ConfirmationPhrases{
+	"en": {
+		Affirmative: []string{
+			"yes", "y", "yeah", "yep", "yup", "sure", "ok", "okay", "confirm", "confirmed",
+			"do it", "yes do it", "go ahead", "yes go ahead", "yes please", "please do",
+			"go for it", "send it", "approve", "approved", "sounds good", "yes confirm", "👍",
+		},
+		Negative: []string{
+			"no", "n", "nope", "cancel", "cancel it", "cancel that", "actually cancel that",
+			"no cancel", "no cancel it", "don't", "dont", "do not", "don't do it", "dont do it",
+			"stop", "abort", "never mind", "nevermind", "no thanks", "no thank you", "👎",
+		},
+	},
+	"es": {
+		Affirmative: []string{"sí", "si", "vale", "claro", "confirmar", "confirmo", "hazlo", "adelante", "de acuerdo", "sí hazlo", "si hazlo"},
+		Negative:    []string{"no", "cancelar", "cancela", "cancélalo", "cancelalo", "no gracias", "olvídalo", "olvidalo", "mejor no"},
+	},
+	"fr": {
+		Affirmative: []string{"oui", "ok", "d'accord", "vas-y", "vas y", "confirmer", "je confirme", "oui vas-y"},
+		Negative:    []string{"non", "annuler", "annule", "annule ça", "non merci", "laisse tomber"},
+	},
+	"de": {
+		Affirmative: []string{"ja", "jawohl", "ok", "okay", "bestätigen", "mach es", "mach das", "ja bitte", "los"},
+		Negative:    []string{"nein", "abbrechen", "stopp", "nein danke", "lass es", "vergiss es"},
+	},
+}
+
+// hedged matches replies that qualify or change the action, e.g. "sure, but
+// make it 40": they never confirm or cancel it as offered.
+var hedged = regexp.MustCompile(`\d|\b(but|except|instead|change|make it|only|unless|if|wait|pero|mais|aber)\b`)
+
+// normalizeReply lowercases a reply and removes punctuation, keeping
+// apostrophes and hyphens inside words, so "Yes, do it!" matches "yes do it".
+func normalizeReply(reply string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(reply) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '-' || r > unicode.MaxLatin1 && unicode.IsSymbol(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// language returns the language of a locale such as "en-US".
+func language(locale string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	return lang
+}
+
+// MatchConfirmationReply classifies a reply by exact phrase for the locale's
+// language. ok is false if it is not one of the phrases; hedged replies, and
+// replies with numbers, are always ReplyNeither.
+func MatchConfirmationReply(reply, locale string, phrases map[string]ConfirmationPhrases) (intent ReplyIntent, ok bool) {
+	if phrases == nil {
+		phrases = DefaultConfirmationPhrases
+	}
+	p, found := phrases[language(locale)]
+	if !found {
+		p = phrases["en"]
+	}
+
+	text := normalizeReply(reply)
+	for _, phrase := range p.Affirmative {
+		if text == normalizeReply(phrase) {
+			return ReplyConfirm, true
+		}
+	}
+	for _, phrase := range p.Negative {
+		if text == normalizeReply(phrase) {
+			return ReplyCancel, true
+		}
+	}
+	if text == "" || hedged.MatchString(text) {
+		return ReplyNeither, true
+	}
+	return "", false
+}
+
+// replyClassifierPrompt is the system prompt for classifying replies the
+// phrase lists don't cover.
+const replyClassifierPrompt = `The user was asked to approve an action and replied in plain text instead of pressing a button.
+Classify the reply with the classify_reply tool:
+- confirm: the user clearly approves the action exactly as described.
+- cancel: the user clearly rejects or cancels the action.
+- neither: anything else, including questions, changes to the action, conditions, or replies you are unsure about.
+When in doubt, choose neither.`
+
+// ClassifyConfirmationReply classifies a plain-text reply to a pending
+// action: by exact phrase first (see MatchConfirmationReply), then with a
+// model call constrained to the three intents. Any failure is ReplyNeither,
+// so a reply is never taken as approval unless it clearly is one.
+func (e *Engine) ClassifyConfirmationReply(ctx context.Context, action *core.PendingAction, reply, locale string, phrases map[string]ConfirmationPhrases) (ReplyIntent, error) {
+	if intent, ok := MatchConfirmationReply(reply, locale, phrases); ok {
+		return intent, nil
+	}
+
+	params := anthropic.MessageNewParams{
+		Model:     anthropic.ModelClaude3_5HaikuLatest,
+		MaxTokens: 100,
+		System:    []anthropic.TextBlockParam{{Text: replyClassifierPrompt}},
+		Messages: []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(
+			fmt.Sprintf("Action awaiting approval: %s\n\nUser's reply: %s", action.Summary, reply),
+		))},
+		Tools: []anthropic.ToolUnionParam{{
+			OfTool: &anthropic.ToolParam{
+				Name:        "classify_reply",
+				Description: anthropic.String("Record what the user's reply means for the action."),
+				InputSchema: anthropic.ToolInputSchemaParam{
+					Properties: map[string]interface{}{
+						"intent": map[string]interface{}{
+							"type": "string",
+							"enum": []string{string(ReplyConfirm), string(ReplyCancel), string(ReplyNeither)},
+						},
+					},
+					Required: []string{"intent"},
+				},
+			},
+		}},
+		ToolChoice: anthropic.ToolChoiceParamOfTool("classify_reply"),
+	}
+
+	resp, err := e.newMessage(ctx, params)
+	if err != nil {
+		return ReplyNeither, fmt.Errorf("failed to classify reply: %w", err)
+	}
+	for _, block := range resp.Content {
+		if block.Type != "tool_use" {
+			continue
+		}
+		var out struct {
+			Intent ReplyIntent `json:"intent"`
+		}
+		if err := json.Unmarshal(block.Input, &out); err != nil {
+			return ReplyNeither, fmt.Errorf("failed to parse reply classification: %w", err)
+		}
+		switch out.Intent {
+		case ReplyConfirm, ReplyCancel:
+			return out.Intent, nil
+		}
+		return ReplyNeither, nil
+	}
+	return ReplyNeither, nil
+}
@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestMatchConfirmationReply(t *testing.T) {
+	tests := []struct {
+		name   string
+		reply  string
+		locale string
+		want   ReplyIntent
+		ok     bool
+	}{
+		{name: "yes", reply: "yes", locale: "en-US", want: ReplyConfirm, ok: true},
+		{name: "punctuation and case", reply: "Yes, do it!", locale: "en-US", want: ReplyConfirm, ok: true},
+		{name: "go ahead", reply: "  go   ahead ", locale: "en-GB", want: ReplyConfirm, ok: true},
+		{name: "no", reply: "No.", locale: "en-US", want: ReplyCancel, ok: true},
+		{name: "cancel that", reply: "actually, cancel that", locale: "en-US", want: ReplyCancel, ok: true},
+		{name: "don't", reply: "Don't!", locale: "en-US", want: ReplyCancel, ok: true},
+		{name: "amended amount", reply: "sure, but make it 40", locale: "en-US", want: ReplyNeither, ok: true},
+		{name: "number", reply: "yes 50", locale: "en-US", want: ReplyNeither, ok: true},
+		{name: "change", reply: "change the recipient", locale: "en-US", want: ReplyNeither, ok: true},
+		{name: "condition", reply: "yes if it's free", locale: "en-US", want: ReplyNeither, ok: true},
+		{name: "empty", reply: " ?! ", locale: "en-US", want: ReplyNeither, ok: true},
+		{name: "unmatched", reply: "what's my balance?", locale: "en-US", ok: false},
+		{name: "spanish yes", reply: "Sí, hazlo", locale: "es-ES", want: ReplyConfirm, ok: true},
+		{name: "spanish no", reply: "cancélalo", locale: "es-MX", want: ReplyCancel, ok: true},
+		{name: "spanish hedge", reply: "sí, pero menos", locale: "es-ES", want: ReplyNeither, ok: true},
+		{name: "french", reply: "Oui, vas-y", locale: "fr-FR", want: ReplyConfirm, ok: true},
+		{name: "german", reply: "Nein, danke", locale: "de_DE", want: ReplyCancel, ok: true},
+		{name: "phrase from another locale", reply: "oui", locale: "en-US", ok: false},
+		{name: "unknown locale uses english", reply: "yes please", locale: "ja-JP", want: ReplyConfirm, ok: true},
+		{name: "no locale uses english", reply: "nope", locale: "", want: ReplyCancel, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := MatchConfirmationReply(tt.reply, tt.locale, nil)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("MatchConfirmationReply(%q, %q) = %q, %v, want %q, %v", tt.reply, tt.locale, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMatchConfirmationReply_CustomPhrases(t *testing.T) {
+	phrases := map[string]ConfirmationPhrases{
+		"en": {Affirmative: []string{"ship it"}, Negative: []string{"scrap it"}},
+	}
+	if got, ok := MatchConfirmationReply("Ship it!", "en-US", phrases); got != ReplyConfirm || !ok {
+		t.Errorf("custom affirmative = %q, %v", got, ok)
+	}
+	if _, ok := MatchConfirmationReply("yes", "en-US", phrases); ok {
+		t.Error("default phrase matched with custom phrases")
+	}
+}
+
+// classifyModel is a mock Claude API that answers classify_reply with intent,
+// or fails if intent is empty.
+func classifyModel(t *testing.T, intent string, calls *int32) *anthropic.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if intent == "" {
+			http.Error(w, `{"type":"error","error":{"type":"api_error","message":"boom"}}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":[{"type":"tool_use","id":"toolu_1","name":"classify_reply","input":{"intent":%q}}],"stop_reason":"tool_use","usage":{"input_tokens":1,"output_tokens":1}}`, intent)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := anthropic.NewClient(
+		option.WithAPIKey("test"),
+		option.WithBaseURL(srv.URL),
+		option.WithMaxRetries(0),
+	)
+	return &client
+}
+
+func TestClassifyConfirmationReply(t *testing.T) {
+	action := &core.PendingAction{ID: "act_1", Tool: "send_money", Summary: "Send 50 USDC to @alice"}
+
+	tests := []struct {
+		name      string
+		reply     string
+		model     string
+		want      ReplyIntent
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "phrase skips model", reply: "yes", model: "cancel", want: ReplyConfirm, wantCalls: 0},
+		{name: "hedge skips model", reply: "sure, but make it 40", model: "confirm", want: ReplyNeither, wantCalls: 0},
+		{name: "model confirms", reply: "that all looks right to me", model: "confirm", want: ReplyConfirm, wantCalls: 1},
+		{name: "model cancels", reply: "hmm I changed my mind", model: "cancel", want: ReplyCancel, wantCalls: 1},
+		{name: "model neither", reply: "who is alice again?", model: "neither", want: ReplyNeither, wantCalls: 1},
+		{name: "unexpected intent", reply: "that all looks right to me", model: "maybe", want: ReplyNeither, wantCalls: 1},
+		{name: "model failure", reply: "that all looks right to me", model: "", want: ReplyNeither, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			eng := NewEngine(classifyModel(t, tt.model, &calls), NewToolRegistry())
+			got, err := eng.ClassifyConfirmationReply(context.Background(), action, tt.reply, "en-US", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClassifyConfirmationReply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ClassifyConfirmationReply(%q) = %q, want %q", tt.reply, got, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("model calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
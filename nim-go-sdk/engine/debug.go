@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/features"
+)
+
+// DebugKind identifies what a DebugEvent describes.
+type DebugKind string
+
+// Debug event kinds.
+const (
+	// DebugSystemPrompt carries the system prompt sections sent to the model.
+	DebugSystemPrompt DebugKind = "system_prompt"
+
+	// DebugTools lists the tools advertised to the model.
+	DebugTools DebugKind = "tools"
+
+	// DebugToolCall carries a tool call's input and result, redacted.
+	DebugToolCall DebugKind = "tool_call"
+
+	// DebugTurn reports one model call's timing, stop reason and tokens.
+	DebugTurn DebugKind = "turn"
+
+	// DebugGuardrails reports the guardrails decision for the run.
+	DebugGuardrails DebugKind = "guardrails"
+
+	// DebugModeration reports a moderation decision, including allows.
+	DebugModeration DebugKind = "moderation"
+
+	// DebugFeatures carries the feature flags evaluated for the run.
+	DebugFeatures DebugKind = "features"
+
+	// DebugCompaction reports that older messages were summarized before a
+	// model call, and the tokens the summary cost.
+	DebugCompaction DebugKind = "compaction"
+)
+
+// DebugEvent describes what the model received and decided during a run,
+// for developer tooling such as the server's dev mode. Events are
+// supplementary: they are never part of the conversation.
+type DebugEvent struct {
+	Kind DebugKind `json:"kind"`
+
+	// Turn is the model call the event belongs to, counting from 1.
+	// Zero for events that apply to the whole run.
+	Turn int `json:"turn,omitempty"`
+
+	System []string `json:"system,omitempty"` // system_prompt
+	Tools  []string `json:"tools,omitempty"`  // tools
+
+	// tool_call: Input and Result are passed through RedactDebug.
+	// Confirmation is set when the call is awaiting the user's confirmation.
+	Tool         string          `json:"tool,omitempty"`
+	Input        json.RawMessage `json:"input,omitempty"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	Confirmation bool            `json:"confirmation,omitempty"`
+
+	// DurationMs is how long the tool call or model call took.
+	DurationMs int64 `json:"durationMs,omitempty"`
+
+	StopReason string           `json:"stopReason,omitempty"` // turn
+	Tokens     *core.TokenUsage `json:"tokens,omitempty"`     // turn, compaction
+
+	// guardrails, moderation: Decision is "allow", "deny" or "rewrite".
+	Decision string          `json:"decision,omitempty"`
+	Stage    ModerationStage `json:"stage,omitempty"`
+	Rule     string          `json:"rule,omitempty"`
+	Reason   string          `json:"reason,omitempty"`
+
+	Features features.Flags `json:"features,omitempty"` // features
+}
+
+// debugger sends debug events to an Input's DebugCallback, if set.
+type debugger func(DebugEvent)
+
+func (d debugger) emit(ev DebugEvent) {
+	if d != nil {
+		d(ev)
+	}
+}
+
+// moderation reports a moderation check. A nil event means the content was
+// allowed unchanged.
+func (d debugger) moderation(stage ModerationStage, event *ModerationEvent) {
+	if d == nil {
+		return
+	}
+	if event == nil {
+		event = &ModerationEvent{Stage: stage, Verdict: ModerationAllow}
+	}
+	d(DebugEvent{
+		Kind:     DebugModeration,
+		Decision: string(event.Verdict),
+		Stage:    event.Stage,
+		Rule:     event.Rule,
+		Reason:   event.Reason,
+	})
+}
+
+// guardrails reports a guardrails check.
+func (d debugger) guardrails(result *GuardrailResult) {
+	if d == nil {
+		return
+	}
+	decision := "allow"
+	if !result.Allowed {
+		decision = "deny"
+	}
+	d(DebugEvent{Kind: DebugGuardrails, Decision: decision, Rule: result.CircuitState, Reason: result.Warning})
+}
+
+// toolCall reports a tool call, redacting its input and result.
+func (d debugger) toolCall(turn int, input json.RawMessage, execution core.ToolExecution, confirmation bool) {
+	if d == nil {
+		return
+	}
+	var result json.RawMessage
+	if execution.Result != nil {
+		result, _ = json.Marshal(execution.Result)
+	}
+	d(DebugEvent{
+		Kind:         DebugToolCall,
+		Turn:         turn,
+		Tool:         execution.Tool,
+		Input:        RedactDebug(input),
+		Result:       RedactDebug(result),
+		Error:        execution.Error,
+		Confirmation: confirmation,
+		DurationMs:   execution.DurationMs,
+	})
+}
+
+// toolNames returns the names of the tools advertised to the model, sorted.
+func toolNames(tools []anthropic.ToolUnionParam) []string {
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		if name := t.GetName(); name != nil {
+			names = append(names, *name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Redacted replaces sensitive values in debug payloads.
+const Redacted = "[redacted]"
+
+// sensitiveKeys match object keys whose values RedactDebug hides, after
+// lower-casing and removing "_" and "-".
+var sensitiveKeys = regexp.MustCompile(`password|passwd|secret|token|jwt|apikey|authorization|cookie|privatekey|mnemonic|seedphrase|accountnumber|cardnumber|routingnumber|iban|^(pin|otp|cvv|cvc|ssn)$`)
+
+// longNumber matches account and card numbers in string values, keeping
+// the last four digits, as the mask_account_number moderation rule does.
+var longNumber = regexp.MustCompile(`\b\d{5,13}(\d{4})\b`)
+
+// RedactDebug hides secrets in a JSON payload before it is shown in debug
+// events: values under keys such as "password", "token" or
+// "account_number" are replaced with Redacted, and long numbers in strings
+// are masked to their last four digits. Input that is not JSON is
+// replaced entirely.
+func RedactDebug(data json.RawMessage) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		out, _ := json.Marshal(Redacted)
+		return out
+	}
+	out, _ := json.Marshal(redactValue(v))
+	return out
+}
+
+func redactValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, e := range x {
+			if sensitiveKeys.MatchString(strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(k))) {
+				x[k] = Redacted
+			} else {
+				x[k] = redactValue(e)
+			}
+		}
+	case []interface{}:
+		for i, e := range x {
+			x[i] = redactValue(e)
+		}
+	case string:
+		return longNumber.ReplaceAllString(x, "••••$1")
+	}
+	return v
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRedactDebug(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "sensitive keys",
+			input: `{"amount":"50","password":"hunter2","api_key":"k","Access-Token":"t","pin":"1234"}`,
+			want:  `{"Access-Token":"[redacted]","amount":"50","api_key":"[redacted]","password":"[redacted]","pin":"[redacted]"}`,
+		},
+		{
+			name:  "nested",
+			input: `{"recipient":{"name":"alice","account_number":"12345678"},"items":[{"jwt":"x"}]}`,
+			want:  `{"items":[{"jwt":"[redacted]"}],"recipient":{"account_number":"[redacted]","name":"alice"}}`,
+		},
+		{
+			name:  "long numbers in strings",
+			input: `{"note":"card 4111111111111111, amount 1250.00"}`,
+			want:  `{"note":"card ••••1111, amount 1250.00"}`,
+		},
+		{
+			name:  "keys that only resemble sensitive ones",
+			input: `{"shipping":"2 days","pinned":true}`,
+			want:  `{"pinned":true,"shipping":"2 days"}`,
+		},
+		{
+			name:  "not JSON",
+			input: `password=hunter2`,
+			want:  `"[redacted]"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(RedactDebug(json.RawMessage(tt.input))); got != tt.want {
+				t.Errorf("RedactDebug(%s) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
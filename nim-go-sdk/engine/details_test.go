@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// goalToolUse calls fund_goal with an input to describe.
+const goalToolUse = `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":[{"type":"tool_use","id":"toolu_1","name":"fund_goal","input":{"goal":"holiday","amount":"25","currency":"USD"}}],"stop_reason":"tool_use","usage":{"input_tokens":1,"output_tokens":1}}`
+
+func TestPendingAction_Details(t *testing.T) {
+	// goalDetails describes a transfer into a savings goal.
+	goalDetails := func(input json.RawMessage) core.Details {
+		var in struct{ Goal, Amount, Currency string }
+		json.Unmarshal(input, &in)
+		return core.Details{
+			core.DetailAmount:    in.Amount,
+			core.DetailCurrency:  in.Currency,
+			core.DetailDirection: core.DirectionToSavings,
+			"goal":               in.Goal,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		details    core.DetailsFunc
+		want       core.Details
+		wantEdited core.Details
+	}{
+		{
+			name:       "builder details",
+			details:    goalDetails,
+			want:       core.Details{"amount": "25", "currency": "USD", "direction": "to_savings", "goal": "holiday"},
+			wantEdited: core.Details{"amount": "40", "currency": "USD", "direction": "to_savings", "goal": "car"},
+		},
+		{
+			name:       "input by default",
+			want:       core.Details{"amount": "25", "currency": "USD", "goal": "holiday"},
+			wantEdited: core.Details{"amount": "40", "currency": "USD", "goal": "car"},
+		},
+		{
+			name:       "nil falls back to the input",
+			details:    func(json.RawMessage) core.Details { return nil },
+			want:       core.Details{"amount": "25", "currency": "USD", "goal": "holiday"},
+			wantEdited: core.Details{"amount": "40", "currency": "USD", "goal": "car"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewToolRegistry()
+			registry.Register(tools.New("fund_goal").
+				RequiresConfirmation().
+				SummaryTemplate("Move {{.amount}} {{.currency}} into {{.goal}}").
+				SummaryDetails(tt.details).
+				Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					return &core.ToolResult{Success: true}, nil
+				}).
+				Build())
+			eng := NewEngine(scriptedClient(t, goalToolUse), registry)
+
+			out, err := eng.Run(context.Background(), &Input{UserMessage: "fund my holiday", Context: core.NewContext("user-1", "sess-1", "conv-1", "req-1")})
+			if err != nil || out.Type != OutputConfirmationNeeded {
+				t.Fatalf("Run() = %v, %v; want a confirmation", out.Type, err)
+			}
+			pending := out.PendingAction
+			if pending.Summary != "Move 25 USD into holiday" || !reflect.DeepEqual(pending.Details, tt.want) {
+				t.Errorf("pending action = %q, %v, want details %v", pending.Summary, pending.Details, tt.want)
+			}
+
+			if err := eng.EditAction(pending, json.RawMessage(`{"goal":"car","amount":"40","currency":"USD"}`)); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pending.Details, tt.wantEdited) {
+				t.Errorf("edited details = %v, want %v", pending.Details, tt.wantEdited)
+			}
+		})
+	}
+}
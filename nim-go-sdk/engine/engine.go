@@ -3,20 +3,64 @@ package engine
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/features"
+	"github.com/becomeliminal/nim-go-sdk/sanitize"
+	"github.com/becomeliminal/nim-go-sdk/store"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Engine is the agent runner that executes tools and manages Claude API interactions.
 type Engine struct {
 	client     *anthropic.Client
 	registry   *ToolRegistry
-	guardrails Guardrails  // Optional: rate limiting and circuit breaker
-	audit      AuditLogger // Optional: audit logging
+	guardrails Guardrails             // Optional: rate limiting and circuit breaker
+	audit      AuditLogger            // Optional: audit logging
+	sanitizer  *sanitize.Policy       // Optional: outbound markdown review
+	limits     TransferLimits         // Optional: per-user transfer limits
+	policy     ToolPolicy             // Optional: per-call tool policy
+	actions    ActionLog              // Optional: last executed action per conversation, for undo
+	completed  store.CompletedActions // Optional: executed actions by idempotency key
+	reads      ReadCache              // Optional: latest differential read results per conversation
+	toolCache  ToolCache              // Optional: read-only tool results across runs
+
+	moderator      Moderator // Optional: per-turn input and output moderation
+	moderationMode ModerationMode
+
+	promptCaching   bool                // Optional: cache the system prompt and tools
+	toolParallelism int                 // Concurrent read-only tool calls per response; 0 means the default
+	compaction      *CompactionConfig   // Optional: summarize long histories
+	toolMiddleware  []ToolMiddleware    // Optional: wraps every tool execution, outermost first
+	actionInput     ActionInputFunc     // Optional: rewrites write actions' input before confirmation
+	confirmationTTL time.Duration       // How long actions await confirmation; 0 means the default
+	completedWindow time.Duration       // How long executed actions are remembered; 0 means the default
+	runCache        bool                // Memoize read-only tool results within each run
+	toolCacheTTL    time.Duration       // How long toolCache keeps results
+	invalidations   map[string][]string // Read tool prefixes each write makes stale; nil means the defaults
+
+	model     string // Model for runs that don't name one; "" means DefaultModel
+	maxTokens int64  // Response cap for runs that don't set one; 0 means DefaultMaxTokens
+
+	maxToolResult int // Tool result bytes sent to the model; 0 means the default, negative no cap
+
+	recorder *recorder    // Optional: writes every model call, for Replay
+	metrics  Metrics      // Optional: operational measurements
+	tracer   trace.Tracer // Optional: OpenTelemetry spans per run, turn and tool call
+}
+
+// TransferLimits authorizes write actions against per-user limits before a
+// confirmation is offered. limits.Limits is the SDK implementation.
+// This is an interface - implementations are provided by the consuming application.
+type TransferLimits interface {
+	// Authorize returns an error if the action would exceed the user's limits.
+	// The error message is shown to the model, so it should explain the limit.
+	Authorize(ctx context.Context, action *core.PendingAction) error
 }
 
 // Option configures the engine.
@@ -36,6 +80,56 @@ func WithAudit(a AuditLogger) Option {
 	}
 }
 
+// WithSanitizer reviews assistant text with the given policy before it is
+// streamed or returned. Changes are reported in Output.Sanitization.
+func WithSanitizer(p sanitize.Policy) Option {
+	return func(e *Engine) {
+		e.sanitizer = &p
+	}
+}
+
+// WithTransferLimits enforces transfer limits before write actions are offered
+// for confirmation. Actions over the limit are returned to the model as tool errors.
+func WithTransferLimits(l TransferLimits) Option {
+	return func(e *Engine) {
+		e.limits = l
+	}
+}
+
+// DefaultConfirmationTTL is how long a write action awaits confirmation
+// unless WithConfirmationTTL says otherwise.
+const DefaultConfirmationTTL = 10 * time.Minute
+
+// WithConfirmationTTL sets how long write actions await the user's
+// confirmation before they expire.
+func WithConfirmationTTL(d time.Duration) Option {
+	return func(e *Engine) {
+		e.confirmationTTL = d
+	}
+}
+
+// Defaults for runs that don't set a model or response cap, unless
+// WithDefaultModel or WithDefaultMaxTokens say otherwise.
+const (
+	DefaultModel     = "claude-sonnet-4-20250514"
+	DefaultMaxTokens = 4096
+)
+
+// WithDefaultModel sets the model for runs whose Input doesn't name one.
+func WithDefaultModel(model string) Option {
+	return func(e *Engine) {
+		e.model = model
+	}
+}
+
+// WithDefaultMaxTokens sets the response cap for runs whose Input doesn't
+// set one.
+func WithDefaultMaxTokens(n int64) Option {
+	return func(e *Engine) {
+		e.maxTokens = n
+	}
+}
+
 // NewEngine creates a new engine with the given Anthropic client and registry.
 func NewEngine(client *anthropic.Client, registry *ToolRegistry, opts ...Option) *Engine {
 	e := &Engine{
@@ -48,6 +142,14 @@ func NewEngine(client *anthropic.Client, registry *ToolRegistry, opts ...Option)
 	return e
 }
 
+// Model returns the model runs use unless their Input names one.
+func (e *Engine) Model() string {
+	if e.model == "" {
+		return DefaultModel
+	}
+	return e.model
+}
+
 // Registry returns the engine's tool registry.
 func (e *Engine) Registry() *ToolRegistry {
 	return e.registry
@@ -58,6 +160,11 @@ type Input struct {
 	// UserMessage is the user's message to process.
 	UserMessage string
 
+	// UserContent is content sent with UserMessage, such as images of a
+	// receipt (see core.NewImageBlock). It is added to the user's message
+	// ahead of the text, and isn't moderated.
+	UserContent []core.ContentBlock
+
 	// Context contains user identity, preferences, and execution limits.
 	Context *core.Context
 
@@ -67,10 +174,12 @@ type Input struct {
 	// SystemPrompt is the system prompt to use.
 	SystemPrompt string
 
-	// Model is the Claude model to use.
+	// Model is the Claude model to use. Defaults to the engine's model
+	// (see WithDefaultModel).
 	Model string
 
-	// MaxTokens is the maximum response tokens.
+	// MaxTokens is the maximum response tokens. Defaults to the engine's
+	// (see WithDefaultMaxTokens).
 	MaxTokens int64
 
 	// AgentName identifies the agent for audit logging.
@@ -81,8 +190,29 @@ type Input struct {
 	// If empty, all registered tools are available.
 	AvailableTools []string
 
+	// DisablePromptCaching turns off WithPromptCaching for this run, e.g.
+	// for a one-off prompt that won't be reused.
+	DisablePromptCaching bool
+
 	// StreamCallback is an optional callback for streaming responses.
 	StreamCallback func(chunk string, done bool)
+
+	// ProgressCallback is an optional callback receiving progress reported
+	// by tools while they run (see core.ToolParams.ReportProgress). Calls
+	// are serialized and end before the tool's result is processed.
+	ProgressCallback func(tool, stage string, percent float64)
+
+	// ToolMessageCallback is an optional callback receiving the messages
+	// tools show the user while they run (see core.ToolParams.EmitMessage),
+	// serialized like ProgressCallback.
+	ToolMessageCallback func(tool, text string)
+
+	// DebugCallback is an optional callback receiving what the model was
+	// sent and what was decided during the run: the system prompt, the
+	// tools advertised, each tool call (redacted), model call timings and
+	// tokens, and guardrails and moderation decisions. For developer
+	// tooling only; see DebugEvent.
+	DebugCallback func(DebugEvent)
 }
 
 // Output represents the output from an agent run.
@@ -93,9 +223,14 @@ type Output struct {
 	// Text is the agent's text response.
 	Text string
 
-	// PendingAction is set when Type is OutputConfirmationNeeded.
+	// PendingAction is set when Type is OutputConfirmationNeeded and the
+	// model asked for one write.
 	PendingAction *core.PendingAction
 
+	// PendingGroup is set instead of PendingAction when the model asked for
+	// several writes in one turn, to be confirmed together.
+	PendingGroup *core.PendingActionGroup
+
 	// ToolsUsed records all tools invoked during this run.
 	ToolsUsed []core.ToolExecution
 
@@ -105,6 +240,26 @@ type Output struct {
 	// TokensUsed tracks Claude API token consumption for this run.
 	TokensUsed core.TokenUsage
 
+	// Model is the model that served the run.
+	Model string
+
+	// Sanitization records changes made to Text by the sanitizer, if configured.
+	// Non-empty reports may indicate a prompt-injection attempt.
+	Sanitization sanitize.Report
+
+	// UserMessage is the user's message as added to the conversation, after
+	// input moderation. Empty if there was none or moderation refused it;
+	// callers keeping their own history should record this, not the original.
+	UserMessage string
+
+	// Moderation records moderation decisions that blocked or changed content,
+	// if a moderator is configured.
+	Moderation []ModerationEvent
+
+	// Compacted reports that older messages were replaced with a summary to
+	// fit the context window. See WithCompaction.
+	Compacted bool
+
 	// Error is set when Type is OutputError.
 	Error error
 }
@@ -121,35 +276,138 @@ const (
 
 	// OutputError indicates an error occurred.
 	OutputError
+
+	// OutputAborted indicates the run's context was cancelled with
+	// ErrAborted. ToolsUsed and TokensUsed cover the work done before then.
+	OutputAborted
 )
 
+// InputRefused reports whether moderation refused the user's message. The
+// refusal is in Text, and nothing was sent to the model.
+func (o *Output) InputRefused() bool {
+	for _, ev := range o.Moderation {
+		if ev.Stage == ModerationInput && ev.Verdict == ModerationDeny {
+			return true
+		}
+	}
+	return false
+}
+
 // Run executes the agent loop until completion or confirmation is needed.
-func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
-	// Check guardrails if configured
-	if e.guardrails != nil && input.Context != nil {
-		result, err := e.guardrails.Check(ctx, input.Context.UserID)
-		if err != nil {
-			return &Output{
-				Type:  OutputError,
-				Error: fmt.Errorf("guardrails check failed: %w", err),
-			}, nil
+// If a moderator is configured, the user's message is checked first: a
+// refused message ends the run with the refusal as Text.
+func (e *Engine) Run(ctx context.Context, input *Input) (out *Output, err error) {
+	if e.tracer != nil {
+		var span trace.Span
+		ctx, span = e.startRun(ctx, input)
+		defer func() { endRun(ctx, span, out, err) }()
+	}
+
+	if flags := features.FromContext(ctx); flags != nil {
+		debugger(input.DebugCallback).emit(DebugEvent{Kind: DebugFeatures, Features: flags})
+	}
+
+	if e.moderator == nil || input.UserMessage == "" {
+		out, err := e.run(ctx, input)
+		if out != nil {
+			out.UserMessage = input.UserMessage
 		}
-		if !result.Allowed {
-			return &Output{
-				Type:  OutputError,
-				Error: fmt.Errorf("request blocked by guardrails: %s", result.Warning),
-			}, nil
+		return out, err
+	}
+
+	var userID, sessionID string
+	if input.Context != nil {
+		userID, sessionID = input.Context.UserID, input.Context.SessionID
+	}
+	message, event, err := e.moderate(ctx, ModerationInput, userID, sessionID, input.UserMessage)
+	if err != nil {
+		return &Output{
+			Type:  OutputError,
+			Error: fmt.Errorf("moderation check failed: %w", err),
+		}, nil
+	}
+	debugger(input.DebugCallback).moderation(ModerationInput, event)
+	if event != nil && event.Verdict == ModerationDeny {
+		if input.StreamCallback != nil {
+			input.StreamCallback(message, false)
+			input.StreamCallback("", true)
+		}
+		return &Output{
+			Type:       OutputComplete,
+			Text:       message,
+			Moderation: []ModerationEvent{*event},
+		}, nil
+	}
+
+	moderated := *input
+	moderated.UserMessage = message
+	out, err = e.run(ctx, &moderated)
+	if out != nil {
+		out.UserMessage = message
+		if event != nil {
+			out.Moderation = append([]ModerationEvent{*event}, out.Moderation...)
 		}
 	}
+	return out, err
+}
+
+// run is Run after input moderation. It checks guardrails, if configured,
+// and reports the run's outcome and token usage to them.
+func (e *Engine) run(ctx context.Context, input *Input) (*Output, error) {
+	debug := debugger(input.DebugCallback)
+	if e.guardrails == nil || input.Context == nil {
+		return e.loop(ctx, input, debug)
+	}
+
+	userID := input.Context.UserID
+	result, err := e.guardrails.Check(ctx, userID)
+	if err != nil {
+		return &Output{
+			Type:  OutputError,
+			Error: fmt.Errorf("guardrails check failed: %w", err),
+		}, nil
+	}
+	debug.guardrails(result)
+	if !result.Allowed {
+		return &Output{
+			Type:  OutputError,
+			Error: &GuardrailsBlockedError{Warning: result.Warning},
+		}, nil
+	}
 
+	out, err := e.loop(ctx, input, debug)
+	if recorder, ok := e.guardrails.(UsageRecorder); ok && out != nil {
+		recorder.RecordUsage(ctx, userID, out.TokensUsed)
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		// The caller went away; that says nothing about the engine.
+	case err != nil || out == nil || out.Type == OutputError:
+		e.guardrails.RecordFailure(ctx, userID)
+	default:
+		e.guardrails.RecordSuccess(ctx, userID)
+	}
+	return out, err
+}
+
+// loop runs the agent loop.
+func (e *Engine) loop(ctx context.Context, input *Input, debug debugger) (out *Output, err error) {
 	// Apply defaults
 	model := input.Model
 	if model == "" {
-		model = "claude-sonnet-4-20250514"
+		model = e.Model()
 	}
+	defer func() {
+		if out != nil {
+			out.Model = model
+		}
+	}()
 	maxTokens := input.MaxTokens
 	if maxTokens == 0 {
-		maxTokens = 4096
+		maxTokens = e.maxTokens
+	}
+	if maxTokens == 0 {
+		maxTokens = DefaultMaxTokens
 	}
 	systemPrompt := input.SystemPrompt
 	if systemPrompt == "" {
@@ -158,17 +416,26 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 
 	// Get limits from context
 	maxTurns := 20
+	maxToolCalls := core.DefaultLimits().MaxToolCalls
 	canConfirm := true
 	if input.Context != nil && input.Context.Limits != nil {
 		maxTurns = input.Context.Limits.MaxTurns
+		if input.Context.Limits.MaxToolCalls > 0 {
+			maxToolCalls = input.Context.Limits.MaxToolCalls
+		}
 		canConfirm = input.Context.Limits.CanConfirm
 		if input.Context.Limits.Timeout > 0 {
 			var cancel context.CancelFunc
-			ctx, cancel = context.WithTimeout(ctx, input.Context.Limits.Timeout)
+			ctx, cancel = core.WithTurnDeadline(ctx, time.Now().Add(input.Context.Limits.Timeout))
 			defer cancel()
 		}
 	}
 
+	// Attach identity so tools and executors can read it from ctx.
+	// The same ctx is passed unchanged to every tool call below.
+	ctx = withRequestValues(ctx, input.Context)
+	ctx = e.withRunCache(ctx)
+
 	// Create session
 	userID := ""
 	conversationID := ""
@@ -177,15 +444,37 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 		conversationID = input.Context.ConversationID
 	}
 	session := NewSession(userID, conversationID)
+	session.agentCtx = input.Context
+
+	// Report the run to metrics on every outcome
+	if e.metrics != nil {
+		defer func() {
+			var tokens core.TokenUsage
+			if out != nil {
+				tokens = out.TokensUsed
+			}
+			e.metrics.ObserveRun(tokens, session.TurnCount, runOutcome(ctx, out, err))
+		}()
+	}
 
 	// Track cumulative token usage
 	var totalTokens core.TokenUsage
 
+	// Report compaction on every outcome
+	var compacted bool
+	defer func() {
+		if out != nil {
+			out.Compacted = compacted
+		}
+	}()
+
 	// Restore history
 	session.RestoreHistory(input.History)
 
 	// Add user message
-	if input.UserMessage != "" {
+	if len(input.UserContent) > 0 {
+		session.AddUserContent(input.UserContent, input.UserMessage)
+	} else if input.UserMessage != "" {
 		session.AddUserMessage(input.UserMessage)
 	}
 
@@ -196,6 +485,8 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 	} else {
 		apiTools = e.registry.ToAPITools()
 	}
+	debug.emit(DebugEvent{Kind: DebugSystemPrompt, System: []string{systemPrompt}})
+	debug.emit(DebugEvent{Kind: DebugTools, Tools: toolNames(apiTools)})
 
 	// Get agent name for audit logging
 	agentName := input.AgentName
@@ -203,18 +494,64 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 		agentName = "default"
 	}
 
-	// Get parent ID for audit chain
+	// Review streamed text before it reaches the client
+	streamCallback := e.sanitizeStream(input.StreamCallback)
+
+	// Hold streamed text until it has been moderated, if required. The
+	// moderation_best_effort flag streams it live for this run instead.
+	modelCallback := streamCallback
+	buffered := e.moderator != nil && e.moderationMode == ModerationBuffered && streamCallback != nil &&
+		!features.Enabled(ctx, features.ModerationBestEffort)
+	if buffered {
+		modelCallback = func(string, bool) {}
+	}
+	var moderation []ModerationEvent
+	var toolsUsed []core.ToolExecution
+
+	// An aborted run ends with what it did so far, however it was cut short
+	defer func() {
+		if errors.Is(context.Cause(ctx), ErrAborted) && (out == nil || out.Type == OutputError) {
+			out = &Output{
+				Type:       OutputAborted,
+				ToolsUsed:  toolsUsed,
+				TokensUsed: totalTokens,
+				Moderation: moderation,
+			}
+			err = nil
+		}
+	}()
+
+	// Get the audit chain: entries carry the run's request ID, which
+	// sub-agents it delegates to record as their parent
+	auditRequestID := session.ID
 	var auditParentID *string
-	if input.Context != nil && input.Context.AuditParentID != nil {
+	if input.Context != nil {
+		if input.Context.RequestID != "" {
+			auditRequestID = input.Context.RequestID
+		}
 		auditParentID = input.Context.AuditParentID
 	}
 
+	// Each turn's span, if tracing, is a child of the run's
+	runCtx := ctx
+	var turnSpan trace.Span
+	defer func() {
+		var turnErr error
+		if out != nil && out.Type == OutputError {
+			turnErr = out.Error
+		}
+		endTurn(turnSpan, turnErr)
+	}()
+
 	for {
+		endTurn(turnSpan, nil)
+		turnSpan = nil
+
 		// Check context cancellation
 		if ctx.Err() != nil {
 			return &Output{
 				Type:       OutputError,
-				Error:      fmt.Errorf("timed out: %w", ctx.Err()),
+				Error:      fmt.Errorf("%w: %w", ErrTimeout, ctx.Err()),
 				TokensUsed: totalTokens,
 			}, nil
 		}
@@ -223,12 +560,13 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 		if session.TurnCount >= maxTurns {
 			return &Output{
 				Type:       OutputError,
-				Error:      fmt.Errorf("exceeded maximum turns (%d)", maxTurns),
+				Error:      &TurnLimitError{Max: maxTurns},
 				TokensUsed: totalTokens,
 			}, nil
 		}
 
 		session.IncrementTurnCount()
+		ctx, turnSpan = e.startTurn(runCtx, session.TurnCount, model)
 
 		// Build the message request
 		params := anthropic.MessageNewParams{
@@ -243,36 +581,73 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 		if len(apiTools) > 0 {
 			params.Tools = apiTools
 		}
+		if e.promptCaching && !input.DisablePromptCaching {
+			cachePrompt(&params)
+		}
+
+		// Summarize older turns if the request would be too long
+		if e.compaction != nil {
+			if ok, usage := e.compact(ctx, session, &params); ok {
+				compacted = true
+				params.Messages = session.Messages()
+				totalTokens.InputTokens += usage.InputTokens
+				totalTokens.OutputTokens += usage.OutputTokens
+				debug.emit(DebugEvent{Kind: DebugCompaction, Turn: session.TurnCount, Tokens: &usage})
+			}
+		}
 
 		// Call Claude API
 		var resp *anthropic.Message
 		var err error
+		callStart := time.Now()
 
-		if input.StreamCallback != nil {
-			resp, err = e.createMessageStreaming(ctx, params, input.StreamCallback)
+		if streamCallback != nil {
+			resp, err = e.createMessageStreaming(ctx, params, modelCallback)
 		} else {
-			resp, err = e.client.Messages.New(ctx, params)
+			resp, err = e.newMessage(ctx, params)
 		}
 
 		if err != nil {
+			apiErr := newAPIError(err)
 			return &Output{
 				Type:       OutputError,
-				Error:      fmt.Errorf("claude API error: %w", err),
+				Error:      apiErr,
 				TokensUsed: totalTokens,
-			}, err
+			}, apiErr
 		}
 
 		// Accumulate token usage
+		turnResponse(turnSpan, resp)
 		totalTokens.InputTokens += int(resp.Usage.InputTokens)
 		totalTokens.OutputTokens += int(resp.Usage.OutputTokens)
+		totalTokens.CacheCreationInputTokens += int(resp.Usage.CacheCreationInputTokens)
+		totalTokens.CacheReadInputTokens += int(resp.Usage.CacheReadInputTokens)
+		debug.emit(DebugEvent{
+			Kind:       DebugTurn,
+			Turn:       session.TurnCount,
+			DurationMs: time.Since(callStart).Milliseconds(),
+			StopReason: string(resp.StopReason),
+			Tokens: &core.TokenUsage{
+				InputTokens:              int(resp.Usage.InputTokens),
+				OutputTokens:             int(resp.Usage.OutputTokens),
+				CacheCreationInputTokens: int(resp.Usage.CacheCreationInputTokens),
+				CacheReadInputTokens:     int(resp.Usage.CacheReadInputTokens),
+			},
+		})
 
 		// Process response blocks
 		var toolResults []anthropic.ContentBlockParamUnion
 		var textResponse string
-		var toolsUsed []core.ToolExecution
-		var confirmationNeeded *core.PendingAction
+		// Every write the model asked for this turn is offered together
+		var confirmations []*core.PendingAction
+
+		// The policy sees every call before any of them runs
+		decisions := e.policyDecisions(ctx, session, resp.Content)
+
+		// Read-only tools the model asked for together run concurrently
+		prefetched := e.prefetchTools(ctx, session, resp.Content, maxToolCalls-len(toolsUsed), input, decisions)
 
-		for _, block := range resp.Content {
+		for i, block := range resp.Content {
 			switch block.Type {
 			case "text":
 				textResponse += block.Text
@@ -285,14 +660,55 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 				if !ok {
 					toolResults = append(toolResults, anthropic.NewToolResultBlock(
 						block.ID,
-						fmt.Sprintf("unknown tool: %s", toolName),
+						(&UnknownToolError{Name: toolName}).Error(),
+						true,
+					))
+					continue
+				}
+
+				// The policy may require confirmation of any tool
+				decision := decisions[i]
+				requiresConfirmation := tool.RequiresConfirmation() || decision.Verdict == PolicyRequireConfirmation
+
+				// Once a write awaits confirmation, reads the model asked
+				// for alongside it wait for the next turn
+				if len(confirmations) > 0 && !requiresConfirmation {
+					continue
+				}
+
+				inputBytes, _ := json.Marshal(toolInput)
+				if err := checkInput(tool, inputBytes); err != nil {
+					debug.toolCall(session.TurnCount, inputBytes, core.ToolExecution{Tool: toolName, Input: toolInput, Error: err.Error()}, false)
+					toolResults = append(toolResults, anthropic.NewToolResultBlock(
+						block.ID,
+						err.Error(),
+						true,
+					))
+					continue
+				}
+
+				if decision.Verdict == PolicyDeny {
+					err := e.deny(ctx, AuditEntry{
+						UserID:    session.UserID,
+						SessionID: session.ID,
+						RequestID: auditRequestID,
+						ParentID:  auditParentID,
+						AgentName: agentName,
+						ToolName:  toolName,
+						ToolInput: inputBytes,
+						IsWriteOp: tool.RequiresConfirmation(),
+					}, decision.Reason)
+					debug.toolCall(session.TurnCount, inputBytes, core.ToolExecution{Tool: toolName, Input: toolInput, Error: err.Error()}, false)
+					toolResults = append(toolResults, anthropic.NewToolResultBlock(
+						block.ID,
+						err.Error(),
 						true,
 					))
 					continue
 				}
 
 				// Check if write operation requiring confirmation
-				if tool.RequiresConfirmation() {
+				if requiresConfirmation {
 					if !canConfirm {
 						toolResults = append(toolResults, anthropic.NewToolResultBlock(
 							block.ID,
@@ -302,33 +718,40 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 						continue
 					}
 
-					inputBytes, _ := json.Marshal(toolInput)
-					confirmationNeeded = &core.PendingAction{
-						ID:             uuid.New().String(),
-						IdempotencyKey: GenerateIdempotencyKey(session.UserID, toolName, inputBytes),
-						SessionID:      session.ID,
-						UserID:         session.UserID,
-						Tool:           toolName,
-						Input:          inputBytes,
-						Summary:        tool.GetSummary(inputBytes),
-						BlockID:        block.ID,
-						CreatedAt:      time.Now().Unix(),
-						ExpiresAt:      time.Now().Add(10 * time.Minute).Unix(),
+					pending, err := e.pendingAction(ctx, session, tool, inputBytes, "", block.ID)
+					if err != nil {
+						toolResults = append(toolResults, anthropic.NewToolResultBlock(
+							block.ID,
+							err.Error(),
+							true,
+						))
+						continue
 					}
-					break
-				}
 
-				// Execute read-only tool
-				startTime := time.Now()
-				inputBytes, _ := json.Marshal(toolInput)
+					confirmations = append(confirmations, pending)
+					debug.toolCall(session.TurnCount, inputBytes, core.ToolExecution{Tool: toolName}, true)
+					continue
+				}
 
-				result, err := tool.Execute(ctx, &core.ToolParams{
-					UserID:    session.UserID,
-					Input:     inputBytes,
-					RequestID: session.ID,
-				})
+				// Check tool call limit, which counts every call in the run,
+				// not just this turn's
+				if len(toolsUsed) >= maxToolCalls {
+					return &Output{
+						Type:       OutputError,
+						Error:      &ToolCallLimitError{Max: maxToolCalls},
+						ToolsUsed:  toolsUsed,
+						TokensUsed: totalTokens,
+						Moderation: moderation,
+					}, nil
+				}
 
-				durationMs := time.Since(startTime).Milliseconds()
+				// Execute read-only tool, unless it already ran
+				call, ok := prefetched[i]
+				if !ok {
+					call = e.callTool(ctx, session, tool, inputBytes, input)
+				}
+				result, err := call.result, call.err
+				startTime, durationMs := call.start, call.durationMs
 				execution := core.ToolExecution{
 					Tool:       toolName,
 					Input:      toolInput,
@@ -353,7 +776,7 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 						ID:         uuid.New().String(),
 						UserID:     session.UserID,
 						SessionID:  session.ID,
-						RequestID:  session.ID,
+						RequestID:  auditRequestID,
 						ParentID:   auditParentID,
 						AgentName:  agentName,
 						ToolName:   toolName,
@@ -380,23 +803,73 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 						result.Error,
 						true,
 					))
+				} else if result != nil && result.Propose != nil {
+					// The tool proposed a write action: offer it for confirmation
+					// as if the model had called it. The confirmed result answers
+					// this tool_use block.
+					execution.Result = result.Data
+					var pending *core.PendingAction
+					var err error
+					if d := e.evaluate(ctx, session.UserID, result.Propose.Tool, result.Propose.Input); d.Verdict == PolicyDeny {
+						err = e.deny(ctx, AuditEntry{
+							UserID:    session.UserID,
+							SessionID: session.ID,
+							RequestID: auditRequestID,
+							ParentID:  auditParentID,
+							AgentName: agentName,
+							ToolName:  result.Propose.Tool,
+							ToolInput: result.Propose.Input,
+							IsWriteOp: true,
+						}, d.Reason)
+					} else {
+						pending, err = e.proposedAction(ctx, session, result.Propose, canConfirm, block.ID)
+					}
+					if err != nil {
+						execution.Error = err.Error()
+						toolResults = append(toolResults, anthropic.NewToolResultBlock(
+							block.ID,
+							err.Error(),
+							true,
+						))
+					} else {
+						confirmations = append(confirmations, pending)
+					}
 				} else {
 					if result != nil {
 						execution.Result = result.Data
 					}
 					resultBytes, _ := json.Marshal(result.Data)
+					_, enveloped := core.EnvelopeOf(result.Data)
 					toolResults = append(toolResults, anthropic.NewToolResultBlock(
 						block.ID,
-						string(resultBytes),
+						e.fitToolResult(tool, e.toolResultContent(ctx, tool, inputBytes, resultBytes, enveloped)),
 						false,
 					))
 				}
 
 				toolsUsed = append(toolsUsed, execution)
+				debug.toolCall(session.TurnCount, inputBytes, execution, len(confirmations) > 0)
 			}
+		}
 
-			if confirmationNeeded != nil {
-				break
+		// Moderate this turn's text before the user sees it
+		if e.moderator != nil && textResponse != "" {
+			text, event, err := e.moderate(ctx, ModerationOutput, session.UserID, session.ID, textResponse)
+			if err != nil {
+				return &Output{
+					Type:       OutputError,
+					Error:      fmt.Errorf("moderation check failed: %w", err),
+					TokensUsed: totalTokens,
+					Moderation: moderation,
+				}, nil
+			}
+			if event != nil {
+				moderation = append(moderation, *event)
+			}
+			debug.moderation(ModerationOutput, event)
+			textResponse = text
+			if buffered {
+				streamCallback(textResponse, false)
 			}
 		}
 
@@ -404,37 +877,46 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 		responseBlocks := responseToBlocks(resp)
 
 		// If confirmation needed, return for user approval
-		if confirmationNeeded != nil {
+		if len(confirmations) > 0 {
 			session.AddAssistantResponse(resp)
-
+			text, report := e.sanitizeText(textResponse)
+
+			var pending *core.PendingAction
+			var group *core.PendingActionGroup
+			if len(confirmations) == 1 {
+				pending = confirmations[0]
+			} else {
+				group = core.NewPendingActionGroup(uuid.New().String(), confirmations)
+			}
 			return &Output{
 				Type:           OutputConfirmationNeeded,
-				Text:           textResponse,
-				PendingAction:  confirmationNeeded,
+				Text:           text,
+				PendingAction:  pending,
+				PendingGroup:   group,
 				ToolsUsed:      toolsUsed,
 				ResponseBlocks: responseBlocks,
 				TokensUsed:     totalTokens,
+				Sanitization:   report,
+				Moderation:     moderation,
 			}, nil
 		}
 
 		// If no tool calls, we're done
 		if len(toolResults) == 0 {
-			session.AddAssistantMessage(textResponse)
-
-			if input.StreamCallback != nil {
-				input.StreamCallback("", true)
-			}
+			text, report := e.sanitizeText(textResponse)
+			session.AddAssistantMessage(text)
 
-			// Record success with guardrails
-			if e.guardrails != nil && input.Context != nil {
-				e.guardrails.RecordSuccess(ctx, input.Context.UserID)
+			if streamCallback != nil {
+				streamCallback("", true)
 			}
 
 			return &Output{
-				Type:       OutputComplete,
-				Text:       textResponse,
-				ToolsUsed:  toolsUsed,
-				TokensUsed: totalTokens,
+				Type:         OutputComplete,
+				Text:         text,
+				ToolsUsed:    toolsUsed,
+				TokensUsed:   totalTokens,
+				Sanitization: report,
+				Moderation:   moderation,
 			}, nil
 		}
 
@@ -448,19 +930,269 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 func (e *Engine) ExecuteTool(ctx context.Context, userID, toolName string, input json.RawMessage, confirmationID string) (*core.ToolResult, error) {
 	tool, ok := e.registry.Get(toolName)
 	if !ok {
-		return nil, fmt.Errorf("unknown tool: %s", toolName)
+		return nil, &UnknownToolError{Name: toolName}
 	}
 
-	return tool.Execute(ctx, &core.ToolParams{
+	if _, ok := core.IdentityFromContext(ctx); !ok {
+		ctx = core.WithIdentity(ctx, core.Identity{UserID: userID})
+	}
+	if core.RequestIDFromContext(ctx) == "" {
+		ctx = core.WithRequestID(ctx, confirmationID)
+	}
+
+	result, err := e.executeTool(ctx, tool, &core.ToolParams{
 		UserID:         userID,
 		Input:          input,
 		ConfirmationID: confirmationID,
 		RequestID:      confirmationID,
 	})
+	if err == nil {
+		e.recordAction(ctx, userID, tool, input, result)
+	}
+	return result, err
+}
+
+// ExecuteAction executes a confirmed pending action. The action's input is
+// first checked against the hash taken when it was offered; if they differ,
+// nothing is executed, the failure is audited, and an
+// *core.InputIntegrityError is returned. With WithCompletedActions, an
+// action whose idempotency key already executed is not executed again, and
+// a *DuplicateActionError is returned.
+func (e *Engine) ExecuteAction(ctx context.Context, action *core.PendingAction) (*core.ToolResult, error) {
+	if previous := e.completedResult(ctx, action); previous != nil {
+		e.observeConfirmation(action.Tool, ConfirmationRefused)
+		return nil, &DuplicateActionError{ActionID: action.ID, Result: previous}
+	}
+	result, err := e.executeAction(ctx, action)
+	if err == nil {
+		e.recordCompleted(ctx, action, result)
+	}
+	return result, err
+}
+
+func (e *Engine) executeAction(ctx context.Context, action *core.PendingAction) (*core.ToolResult, error) {
+	if err := action.VerifyInput(); err != nil {
+		e.observeConfirmation(action.Tool, ConfirmationRefused)
+		if e.audit != nil {
+			errMsg := err.Error()
+			e.audit.Log(ctx, &AuditEntry{
+				ID:        uuid.New().String(),
+				UserID:    action.UserID,
+				SessionID: action.SessionID,
+				RequestID: action.ID,
+				ToolName:  action.Tool,
+				ToolInput: action.Input,
+				Error:     &errMsg,
+				IsWriteOp: true,
+				Timestamp: time.Now().Unix(),
+			})
+		}
+		return nil, err
+	}
+	// Limits may have changed, or the user edited the input, since it was offered
+	if d := e.evaluate(ctx, action.UserID, action.Tool, action.Input); d.Verdict == PolicyDeny {
+		e.observeConfirmation(action.Tool, ConfirmationRefused)
+		return nil, e.deny(ctx, AuditEntry{
+			UserID:    action.UserID,
+			SessionID: action.SessionID,
+			RequestID: action.ID,
+			ToolName:  action.Tool,
+			ToolInput: action.Input,
+			IsWriteOp: true,
+		}, d.Reason)
+	}
+	e.observeConfirmation(action.Tool, ConfirmationConfirmed)
+	if action.OriginalInput == nil || e.audit == nil {
+		return e.ExecuteTool(ctx, action.UserID, action.Tool, action.Input, action.ID)
+	}
+
+	start := time.Now()
+	result, err := e.ExecuteTool(ctx, action.UserID, action.Tool, action.Input, action.ID)
+	entry := &AuditEntry{
+		ID:            uuid.New().String(),
+		UserID:        action.UserID,
+		SessionID:     action.SessionID,
+		RequestID:     action.ID,
+		ToolName:      action.Tool,
+		ToolInput:     action.Input,
+		OriginalInput: action.OriginalInput,
+		DurationMs:    time.Since(start).Milliseconds(),
+		IsWriteOp:     true,
+		Timestamp:     start.Unix(),
+	}
+	switch {
+	case err != nil:
+		errMsg := err.Error()
+		entry.Error = &errMsg
+	case !result.Success:
+		entry.Error = &result.Error
+	default:
+		entry.ToolOutput, _ = json.Marshal(result.Data)
+	}
+	e.audit.Log(ctx, entry)
+	return result, err
+}
+
+// EditAction replaces a pending action's input with the user's edits,
+// e.g. a different amount, before they confirm it. The edited input must
+// satisfy the tool's schema; the summary and details are regenerated from
+// it, and the input first offered is kept in OriginalInput for the audit
+// log.
+func (e *Engine) EditAction(action *core.PendingAction, input json.RawMessage) error {
+	tool, ok := e.registry.Get(action.Tool)
+	if !ok {
+		return &UnknownToolError{Name: action.Tool}
+	}
+	if err := core.ValidateInput(tool.Schema(), input); err != nil {
+		return err
+	}
+	original := action.OriginalInput
+	if original == nil {
+		original = action.Input
+	}
+	if err := action.SetInput(input); err != nil {
+		return err
+	}
+	action.OriginalInput = original
+	action.Summary = core.SummaryOf(tool, input, action.Locale)
+	action.Details = core.SummaryDetailsOf(tool, input)
+	return nil
+}
+
+// pendingAction creates the confirmation for a write tool call, checking it
+// against transfer limits. summary overrides the tool's own summary if set;
+// the tool's is written for the locale of the preferences on ctx.
+func (e *Engine) pendingAction(ctx context.Context, session *Session, tool core.Tool, input json.RawMessage, summary, blockID string) (*core.PendingAction, error) {
+	if e.actionInput != nil {
+		input = e.actionInput(ctx, session.UserID, tool.Name(), input)
+	}
+	var locale string
+	if prefs, ok := core.PreferencesFromContext(ctx); ok {
+		locale = prefs.Locale
+	}
+	if summary == "" {
+		summary = core.SummaryOf(tool, input, locale)
+	}
+	ttl := e.confirmationTTL
+	if ttl <= 0 {
+		ttl = DefaultConfirmationTTL
+	}
+	pending := &core.PendingAction{
+		ID:             uuid.New().String(),
+		IdempotencyKey: GenerateIdempotencyKey(session.UserID, tool.Name(), input),
+		SessionID:      session.ID,
+		ConversationID: session.ConversationID,
+		UserID:         session.UserID,
+		Tool:           tool.Name(),
+		Summary:        summary,
+		Locale:         locale,
+		Details:        core.SummaryDetailsOf(tool, input),
+		BlockID:        blockID,
+		CreatedAt:      time.Now().Unix(),
+		ExpiresAt:      time.Now().Add(ttl).Unix(),
+	}
+	if err := pending.SetInput(input); err != nil {
+		return nil, err
+	}
+
+	if e.limits != nil {
+		if err := e.limits.Authorize(ctx, pending); err != nil {
+			return nil, err
+		}
+	}
+	e.observeConfirmation(pending.Tool, ConfirmationRequested)
+	return pending, nil
+}
+
+// proposedAction creates the confirmation for a write action proposed by a tool.
+// Proposed actions always require confirmation, even if the tool would not.
+func (e *Engine) proposedAction(ctx context.Context, session *Session, proposal *core.ProposedAction, canConfirm bool, blockID string) (*core.PendingAction, error) {
+	if !canConfirm {
+		return nil, fmt.Errorf("error: this operation requires user confirmation")
+	}
+	tool, ok := e.registry.Get(proposal.Tool)
+	if !ok {
+		return nil, &UnknownToolError{Name: proposal.Tool}
+	}
+	return e.pendingAction(ctx, session, tool, proposal.Input, proposal.Summary, blockID)
+}
+
+// withRequestValues attaches the identity, request ID, credential,
+// preferences and user limits from the agent context to ctx, unless the
+// caller has already set them.
+func withRequestValues(ctx context.Context, agentCtx *core.Context) context.Context {
+	if agentCtx == nil {
+		return ctx
+	}
+	if _, ok := core.IdentityFromContext(ctx); !ok {
+		ctx = core.WithIdentity(ctx, core.Identity{
+			UserID:         agentCtx.UserID,
+			SessionID:      agentCtx.SessionID,
+			ConversationID: agentCtx.ConversationID,
+		})
+	}
+	if core.RequestIDFromContext(ctx) == "" && agentCtx.RequestID != "" {
+		ctx = core.WithRequestID(ctx, agentCtx.RequestID)
+	}
+	if core.CredentialFromContext(ctx) == "" && agentCtx.Credential != "" {
+		ctx = core.WithCredential(ctx, agentCtx.Credential)
+	}
+	if _, ok := core.PreferencesFromContext(ctx); !ok && agentCtx.Preferences != nil {
+		ctx = core.WithPreferences(ctx, agentCtx.Preferences)
+	}
+	if _, ok := core.UserLimitsFromContext(ctx); !ok && agentCtx.UserLimits != nil {
+		ctx = core.WithUserLimits(ctx, agentCtx.UserLimits)
+	}
+	return ctx
+}
+
+// sanitizeText applies the sanitizer policy, if configured, to assistant text.
+func (e *Engine) sanitizeText(text string) (string, sanitize.Report) {
+	if e.sanitizer == nil {
+		return text, sanitize.Report{}
+	}
+	return sanitize.Markdown(text, *e.sanitizer)
+}
+
+// sanitizeStream wraps a stream callback so chunks are sanitized line by line.
+// Returns the callback unchanged if no sanitizer is configured.
+func (e *Engine) sanitizeStream(callback func(string, bool)) func(string, bool) {
+	if e.sanitizer == nil || callback == nil {
+		return callback
+	}
+
+	stream := sanitize.NewStream(*e.sanitizer)
+	return func(chunk string, done bool) {
+		if done {
+			if rest := stream.Flush(); rest != "" {
+				callback(rest, false)
+			}
+			callback("", true)
+			return
+		}
+		if out := stream.Write(chunk); out != "" {
+			callback(out, false)
+		}
+	}
+}
+
+// newMessage calls the Messages API, recording the call if the engine has a
+// recorder and reporting it to metrics. Every non-streaming model call goes
+// through here.
+func (e *Engine) newMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	start := time.Now()
+	resp, err := e.client.Messages.New(ctx, params)
+	e.observeModelCall(string(params.Model), start, err)
+	if err == nil && e.recorder != nil {
+		e.recorder.record(params, resp)
+	}
+	return resp, err
 }
 
 // createMessageStreaming handles streaming API calls.
-func (e *Engine) createMessageStreaming(ctx context.Context, params anthropic.MessageNewParams, callback func(string, bool)) (*anthropic.Message, error) {
+func (e *Engine) createMessageStreaming(ctx context.Context, params anthropic.MessageNewParams, callback func(string, bool)) (resp *anthropic.Message, err error) {
+	start := time.Now()
+	defer func() { e.observeModelCall(string(params.Model), start, err) }()
 	stream := e.client.Messages.NewStreaming(ctx, params)
 	defer stream.Close()
 
@@ -491,6 +1223,9 @@ func (e *Engine) createMessageStreaming(ctx context.Context, params anthropic.Me
 		return nil, err
 	}
 
+	if e.recorder != nil {
+		e.recorder.record(params, &message)
+	}
 	return &message, nil
 }
 
@@ -551,6 +1286,7 @@ func (e *Engine) RunAgent(ctx context.Context, agent core.Agent, input *core.Inp
 		Type:           core.OutputType(output.Type),
 		Text:           output.Text,
 		PendingAction:  output.PendingAction,
+		PendingGroup:   output.PendingGroup,
 		ToolsUsed:      output.ToolsUsed,
 		ResponseBlocks: output.ResponseBlocks,
 		TokensUsed:     output.TokensUsed,
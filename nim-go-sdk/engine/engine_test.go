@@ -0,0 +1,381 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// toolUseResponse asks the engine to call the named tool.
+const toolUseResponse = `{
+	"id": "msg_1",
+	"type": "message",
+	"role": "assistant",
+	"model": "test-model",
+	"content": [{"type": "tool_use", "id": "toolu_1", "name": "%s", "input": {}}],
+	"stop_reason": "tool_use",
+	"usage": {"input_tokens": 1, "output_tokens": 1}
+}`
+
+// textResponse ends the agent loop.
+const textResponse = `{
+	"id": "msg_2",
+	"type": "message",
+	"role": "assistant",
+	"model": "test-model",
+	"content": [{"type": "text", "text": "done"}],
+	"stop_reason": "end_turn",
+	"usage": {"input_tokens": 1, "output_tokens": 1}
+}`
+
+// newTestEngine returns an engine backed by a mock Claude API that requests
+// toolName on the first call and finishes on the second.
+func newTestEngine(t *testing.T, toolName string, tool core.Tool, opts ...Option) *Engine {
+	t.Helper()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) == 1 {
+			fmt.Fprintf(w, toolUseResponse, toolName)
+			return
+		}
+		w.Write([]byte(textResponse))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := anthropic.NewClient(
+		option.WithAPIKey("test"),
+		option.WithBaseURL(srv.URL),
+		option.WithMaxRetries(0),
+	)
+
+	registry := NewToolRegistry()
+	registry.Register(tool)
+	return NewEngine(&client, registry, opts...)
+}
+
+func TestRun_CancelAbortsSlowTool(t *testing.T) {
+	started := make(chan struct{})
+	var toolErr error
+
+	slow := core.NewBaseTool(core.ToolDefinition{ToolName: "slow"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			close(started)
+			select {
+			case <-ctx.Done():
+				toolErr = ctx.Err()
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return &core.ToolResult{Success: true}, nil
+			}
+		})
+
+	eng := newTestEngine(t, "slow", slow)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	done := make(chan *Output, 1)
+	go func() {
+		out, _ := eng.Run(ctx, &Input{
+			UserMessage: "hi",
+			Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+		})
+		done <- out
+	}()
+
+	select {
+	case out := <-done:
+		if out.Type != OutputError {
+			t.Errorf("Run() type = %v, want OutputError", out.Type)
+		}
+		if !errors.Is(toolErr, context.Canceled) {
+			t.Errorf("tool saw ctx error %v, want context.Canceled", toolErr)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run() did not return after the context was cancelled")
+	}
+}
+
+func TestRun_IdentityVisibleInHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		ctx         func() context.Context
+		wantUserID  string
+		wantConvID  string
+		wantRequest string
+	}{
+		{
+			name:        "derived from agent context",
+			ctx:         context.Background,
+			wantUserID:  "user-1",
+			wantConvID:  "conv-1",
+			wantRequest: "req-1",
+		},
+		{
+			name: "caller values are kept",
+			ctx: func() context.Context {
+				ctx := core.WithIdentity(context.Background(), core.Identity{UserID: "caller", ConversationID: "conv-caller"})
+				return core.WithRequestID(ctx, "req-caller")
+			},
+			wantUserID:  "caller",
+			wantConvID:  "conv-caller",
+			wantRequest: "req-caller",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotID core.Identity
+			var gotOK bool
+			var gotRequest string
+
+			whoami := core.NewBaseTool(core.ToolDefinition{ToolName: "whoami"},
+				func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					gotID, gotOK = core.IdentityFromContext(ctx)
+					gotRequest = core.RequestIDFromContext(ctx)
+					return &core.ToolResult{Success: true}, nil
+				})
+
+			eng := newTestEngine(t, "whoami", whoami)
+
+			out, err := eng.Run(tt.ctx(), &Input{
+				UserMessage: "who am I?",
+				Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+			})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if out.Type != OutputComplete {
+				t.Fatalf("Run() type = %v, want OutputComplete (error: %v)", out.Type, out.Error)
+			}
+
+			if !gotOK {
+				t.Fatal("IdentityFromContext() found no identity in handler")
+			}
+			if gotID.UserID != tt.wantUserID || gotID.ConversationID != tt.wantConvID {
+				t.Errorf("identity = %+v, want user %q conversation %q", gotID, tt.wantUserID, tt.wantConvID)
+			}
+			if gotRequest != tt.wantRequest {
+				t.Errorf("RequestIDFromContext() = %q, want %q", gotRequest, tt.wantRequest)
+			}
+		})
+	}
+}
+
+// toolUseBlocks is a response calling the named tool n times in one turn.
+func toolUseBlocks(turn int, tool string, n int) string {
+	blocks := make([]string, n)
+	for i := range blocks {
+		blocks[i] = fmt.Sprintf(`{"type":"tool_use","id":"toolu_%d_%d","name":%q,"input":{}}`, turn, i, tool)
+	}
+	return fmt.Sprintf(`{"id":"msg_%d","type":"message","role":"assistant","model":"test-model","content":[%s],"stop_reason":"tool_use","usage":{"input_tokens":1,"output_tokens":1}}`,
+		turn, strings.Join(blocks, ","))
+}
+
+func TestRun_MaxToolCalls(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxToolCalls int
+		wantType     OutputType
+		wantCalls    int
+		wantTokens   int
+	}{
+		// The model calls the tool twice in turn 1 and three times in turn 2.
+		{name: "hit mid-turn", maxToolCalls: 4, wantType: OutputError, wantCalls: 4, wantTokens: 4},
+		{name: "hit at the start of a turn", maxToolCalls: 2, wantType: OutputError, wantCalls: 2, wantTokens: 4},
+		{name: "exactly enough", maxToolCalls: 5, wantType: OutputComplete, wantCalls: 5, wantTokens: 6},
+		{name: "zero uses the default", maxToolCalls: 0, wantType: OutputComplete, wantCalls: 5, wantTokens: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var turns atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch turns.Add(1) {
+				case 1:
+					w.Write([]byte(toolUseBlocks(1, "lookup", 2)))
+				case 2:
+					w.Write([]byte(toolUseBlocks(2, "lookup", 3)))
+				default:
+					w.Write([]byte(textResponse))
+				}
+			}))
+			defer srv.Close()
+			client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+
+			var executed atomic.Int32
+			registry := NewToolRegistry()
+			registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: "lookup"},
+				func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					executed.Add(1)
+					return &core.ToolResult{Success: true, Data: "ok"}, nil
+				}))
+			eng := NewEngine(&client, registry)
+
+			agentCtx := core.NewContext("user-1", "sess-1", "conv-1", "req-1")
+			agentCtx.Limits = core.DefaultLimits()
+			agentCtx.Limits.MaxToolCalls = tt.maxToolCalls
+			out, err := eng.Run(context.Background(), &Input{UserMessage: "hi", Context: agentCtx})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out.Type != tt.wantType {
+				t.Fatalf("Run() type = %v (error %v), want %v", out.Type, out.Error, tt.wantType)
+			}
+			if tt.wantType == OutputError && (out.Error == nil || !strings.Contains(out.Error.Error(), fmt.Sprintf("maximum tool calls (%d)", tt.maxToolCalls))) {
+				t.Errorf("error = %v, want the tool call limit", out.Error)
+			}
+			if int(executed.Load()) != tt.wantCalls || len(out.ToolsUsed) != tt.wantCalls {
+				t.Errorf("executed %d tools with %d in ToolsUsed, want %d", executed.Load(), len(out.ToolsUsed), tt.wantCalls)
+			}
+			if got := out.TokensUsed.InputTokens + out.TokensUsed.OutputTokens; got != tt.wantTokens {
+				t.Errorf("TokensUsed = %+v, want %d in total", out.TokensUsed, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestRun_ToolProgress(t *testing.T) {
+	type report struct {
+		tool, stage string
+		percent     float64
+	}
+	var late core.ProgressFunc
+	chart := core.NewBaseTool(core.ToolDefinition{ToolName: "chart"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			params.ReportProgress("fetching transactions", 0)
+			params.ReportProgress("rendering chart", 140)
+			late = params.Progress
+			return &core.ToolResult{Success: true}, nil
+		})
+
+	var reports []report
+	eng := newTestEngine(t, "chart", chart)
+	_, err := eng.Run(context.Background(), &Input{
+		UserMessage: "chart my balance",
+		Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+		ProgressCallback: func(tool, stage string, percent float64) {
+			reports = append(reports, report{tool, stage, percent})
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	late("too late", 100)
+
+	want := []report{{"chart", "fetching transactions", 0}, {"chart", "rendering chart", 100}}
+	if fmt.Sprint(reports) != fmt.Sprint(want) {
+		t.Errorf("progress = %v, want %v (clamped, and nothing after the tool returned)", reports, want)
+	}
+
+	// Without a callback, reporting is a no-op.
+	eng = newTestEngine(t, "chart", chart)
+	if _, err := eng.Run(context.Background(), &Input{UserMessage: "chart my balance", Context: core.NewContext("user-1", "sess-1", "conv-1", "req-1")}); err != nil {
+		t.Fatal(err)
+	}
+	if late != nil {
+		t.Error("Progress should be nil when the run has no ProgressCallback")
+	}
+}
+
+func TestRun_ToolMessages(t *testing.T) {
+	var late core.MessageFunc
+	chart := core.NewBaseTool(core.ToolDefinition{ToolName: "chart"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			params.EmitMessage("Step 1/2: Fetching transactions...")
+			params.EmitMessage("")
+			params.EmitMessage("Step 2/2: Drawing the chart...")
+			late = params.Message
+			return &core.ToolResult{Success: true}, nil
+		})
+
+	var messages []string
+	eng := newTestEngine(t, "chart", chart)
+	_, err := eng.Run(context.Background(), &Input{
+		UserMessage: "chart my balance",
+		Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+		ToolMessageCallback: func(tool, text string) {
+			messages = append(messages, tool+": "+text)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	late("too late")
+
+	want := []string{"chart: Step 1/2: Fetching transactions...", "chart: Step 2/2: Drawing the chart..."}
+	if fmt.Sprint(messages) != fmt.Sprint(want) {
+		t.Errorf("messages = %q, want %q (no empty messages, and nothing after the tool returned)", messages, want)
+	}
+
+	// Without a callback, emitting is a no-op.
+	eng = newTestEngine(t, "chart", chart)
+	if _, err := eng.Run(context.Background(), &Input{UserMessage: "chart my balance", Context: core.NewContext("user-1", "sess-1", "conv-1", "req-1")}); err != nil {
+		t.Fatal(err)
+	}
+	if late != nil {
+		t.Error("Message should be nil when the run has no ToolMessageCallback")
+	}
+}
+
+func TestRun_Model(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []Option
+		input         string
+		want          string
+		wantMaxTokens int64
+	}{
+		{name: "default", want: DefaultModel, wantMaxTokens: DefaultMaxTokens},
+		{name: "engine default", opts: []Option{WithDefaultModel("claude-opus-test"), WithDefaultMaxTokens(1000)}, want: "claude-opus-test", wantMaxTokens: 1000},
+		{name: "input override", opts: []Option{WithDefaultModel("claude-opus-test")}, input: "claude-haiku-test", want: "claude-haiku-test", wantMaxTokens: DefaultMaxTokens},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req struct {
+				Model     string `json:"model"`
+				MaxTokens int64  `json:"max_tokens"`
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&req)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(textResponse))
+			}))
+			defer srv.Close()
+			client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+			e := NewEngine(&client, NewToolRegistry(), tt.opts...)
+
+			out, err := e.Run(context.Background(), &Input{
+				UserMessage: "hi",
+				Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+				Model:       tt.input,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.Model != tt.want || out.Model != tt.want {
+				t.Errorf("requested %q, output reports %q, want %q", req.Model, out.Model, tt.want)
+			}
+			if req.MaxTokens != tt.wantMaxTokens {
+				t.Errorf("max_tokens = %d, want %d", req.MaxTokens, tt.wantMaxTokens)
+			}
+		})
+	}
+}
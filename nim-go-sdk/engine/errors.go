@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// ErrTimeout is wrapped by the error of a run whose context ended before it
+// completed, along with the context's own error.
+var ErrTimeout = errors.New("timed out")
+
+// ErrAborted is the cause to cancel a run's context with, using
+// context.WithCancelCause, when the user stops it: the run then ends
+// promptly with OutputAborted rather than an error.
+var ErrAborted = errors.New("aborted")
+
+// GuardrailsBlockedError is the error of a run that guardrails refused,
+// e.g. because the user is over their rate limit or their circuit is open.
+type GuardrailsBlockedError struct {
+	Warning string // the guardrails' explanation for the user
+}
+
+func (e *GuardrailsBlockedError) Error() string {
+	return "request blocked by guardrails: " + e.Warning
+}
+
+// TurnLimitError is the error of a run that used all its turns without
+// finishing.
+type TurnLimitError struct {
+	Max int
+}
+
+func (e *TurnLimitError) Error() string {
+	return fmt.Sprintf("exceeded maximum turns (%d)", e.Max)
+}
+
+// ToolCallLimitError is the error of a run that asked for more tool calls
+// than its limits allow.
+type ToolCallLimitError struct {
+	Max int
+}
+
+func (e *ToolCallLimitError) Error() string {
+	return fmt.Sprintf("exceeded maximum tool calls (%d)", e.Max)
+}
+
+// APIError is the error of a run whose call to the Claude API failed.
+// Status is the response's HTTP status, or 0 if there was no response,
+// and RetryAfter is how long the API asked callers to wait, if it said.
+type APIError struct {
+	Status     int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	return "claude API error: " + e.Err.Error()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// RateLimited reports whether the API refused the call for exceeding a
+// rate limit.
+func (e *APIError) RateLimited() bool {
+	return e.Status == http.StatusTooManyRequests
+}
+
+// Overloaded reports whether the API was too busy to take the call.
+func (e *APIError) Overloaded() bool {
+	return e.Status == 529 || e.Status == http.StatusServiceUnavailable
+}
+
+// newAPIError wraps an error from the Claude API client, reading the
+// status and any Retry-After from the response.
+func newAPIError(err error) *APIError {
+	apiErr := &APIError{Err: err}
+	var respErr *anthropic.Error
+	if errors.As(err, &respErr) {
+		apiErr.Status = respErr.StatusCode
+		if respErr.Response != nil {
+			apiErr.RetryAfter = retryAfter(respErr.Response.Header)
+		}
+	}
+	return apiErr
+}
+
+// retryAfter reads how long a response asks to wait before retrying, from
+// retry-after-ms or Retry-After in seconds or as a date.
+func retryAfter(h http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	value := h.Get("Retry-After")
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// UnknownToolError is returned for a tool that isn't registered.
+type UnknownToolError struct {
+	Name string
+}
+
+func (e *UnknownToolError) Error() string {
+	return "unknown tool: " + e.Name
+}
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// blockingGuardrails refuses every request.
+type blockingGuardrails struct{}
+
+func (blockingGuardrails) Check(ctx context.Context, userID string) (*GuardrailResult, error) {
+	return &GuardrailResult{Allowed: false, Warning: "slow down"}, nil
+}
+func (blockingGuardrails) RecordSuccess(ctx context.Context, userID string) {}
+func (blockingGuardrails) RecordFailure(ctx context.Context, userID string) {}
+
+func TestRun_TypedErrors(t *testing.T) {
+	noop := core.NewBaseTool(core.ToolDefinition{ToolName: "noop"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			return &core.ToolResult{Success: true}, nil
+		})
+	limited := func(limits core.ExecutionLimits) *Input {
+		limits.CanConfirm = true
+		return &Input{UserMessage: "hi", Context: &core.Context{UserID: "user-1", Limits: &limits}}
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		engine *Engine
+		ctx    context.Context
+		input  *Input
+		check  func(t *testing.T, err error)
+	}{
+		{
+			name:   "guardrails",
+			engine: newTestEngine(t, "noop", noop, WithGuardrails(blockingGuardrails{})),
+			input:  &Input{UserMessage: "hi", Context: &core.Context{UserID: "user-1"}},
+			check: func(t *testing.T, err error) {
+				var blocked *GuardrailsBlockedError
+				if !errors.As(err, &blocked) || blocked.Warning != "slow down" {
+					t.Errorf("error = %v, want a GuardrailsBlockedError with the warning", err)
+				}
+			},
+		},
+		{
+			name:   "turn limit",
+			engine: newTestEngine(t, "noop", noop),
+			input:  limited(core.ExecutionLimits{MaxTurns: 1}),
+			check: func(t *testing.T, err error) {
+				var turns *TurnLimitError
+				if !errors.As(err, &turns) || turns.Max != 1 {
+					t.Errorf("error = %v, want a TurnLimitError for 1 turn", err)
+				}
+			},
+		},
+		{
+			name:   "timeout",
+			engine: newTestEngine(t, "noop", noop),
+			ctx:    cancelled,
+			input:  &Input{UserMessage: "hi"},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.Canceled) {
+					t.Errorf("error = %v, want ErrTimeout wrapping the context's error", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			out, err := tt.engine.Run(ctx, tt.input)
+			if err != nil || out.Type != OutputError {
+				t.Fatalf("Run() = %+v, %v, want an error output", out, err)
+			}
+			tt.check(t, out.Error)
+		})
+	}
+}
+
+func TestRun_ToolCallLimitError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":[`+
+			`{"type":"tool_use","id":"toolu_1","name":"noop","input":{}},`+
+			`{"type":"tool_use","id":"toolu_2","name":"noop","input":{}}],`+
+			`"stop_reason":"tool_use","usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer srv.Close()
+	client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+	registry := NewToolRegistry()
+	registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: "noop"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			return &core.ToolResult{Success: true}, nil
+		}))
+	eng := NewEngine(&client, registry)
+
+	out, _ := eng.Run(context.Background(), &Input{
+		UserMessage: "hi",
+		Context:     &core.Context{UserID: "user-1", Limits: &core.ExecutionLimits{MaxTurns: 5, MaxToolCalls: 1, CanConfirm: true}},
+	})
+	var calls *ToolCallLimitError
+	if out == nil || !errors.As(out.Error, &calls) || calls.Max != 1 {
+		t.Errorf("Run() = %+v, want a ToolCallLimitError for 1 call", out)
+	}
+}
+
+func TestRun_APIError(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		header, value  string
+		wantRetryAfter time.Duration
+		rateLimited    bool
+		overloaded     bool
+	}{
+		{name: "rate limited", status: http.StatusTooManyRequests, header: "Retry-After", value: "7", wantRetryAfter: 7 * time.Second, rateLimited: true},
+		{name: "rate limited in ms", status: http.StatusTooManyRequests, header: "Retry-After-Ms", value: "1500", wantRetryAfter: 1500 * time.Millisecond, rateLimited: true},
+		{name: "overloaded", status: 529, overloaded: true},
+		{name: "bad request", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if tt.header != "" {
+					w.Header().Set(tt.header, tt.value)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)
+			}))
+			defer srv.Close()
+			client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+			eng := NewEngine(&client, NewToolRegistry())
+
+			out, err := eng.Run(context.Background(), &Input{UserMessage: "hi"})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || out == nil || !errors.As(out.Error, &apiErr) {
+				t.Fatalf("Run() = %+v, %v, want an APIError", out, err)
+			}
+			if apiErr.Status != tt.status || apiErr.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("APIError = status %d, retry after %s, want %d, %s", apiErr.Status, apiErr.RetryAfter, tt.status, tt.wantRetryAfter)
+			}
+			if apiErr.RateLimited() != tt.rateLimited || apiErr.Overloaded() != tt.overloaded {
+				t.Errorf("RateLimited() = %v, Overloaded() = %v, want %v, %v", apiErr.RateLimited(), apiErr.Overloaded(), tt.rateLimited, tt.overloaded)
+			}
+			// The client's own error is still reachable.
+			var sdkErr *anthropic.Error
+			if !errors.As(err, &sdkErr) {
+				t.Errorf("error %v doesn't wrap the client's error", err)
+			}
+		})
+	}
+}
+
+func TestUnknownToolError(t *testing.T) {
+	eng := NewEngine(nil, NewToolRegistry())
+	_, err := eng.ExecuteTool(context.Background(), "user-1", "missing", nil, "")
+	var unknown *UnknownToolError
+	if !errors.As(err, &unknown) || unknown.Name != "missing" {
+		t.Errorf("ExecuteTool(missing) error = %v, want an UnknownToolError", err)
+	}
+	err = eng.EditAction(&core.PendingAction{Tool: "missing"}, nil)
+	if !errors.As(err, &unknown) {
+		t.Errorf("EditAction(missing) error = %v, want an UnknownToolError", err)
+	}
+}
@@ -2,11 +2,17 @@ package engine
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
 )
 
 // Guardrails provides rate limiting and circuit breaker functionality.
-// This is an interface - implementations (e.g., Redis-backed) are provided
-// by the consuming application.
+// NewBasicGuardrails provides an implementation; applications can provide
+// their own.
 type Guardrails interface {
 	// Check verifies whether the user is allowed to proceed.
 	// Returns a result indicating if the request is allowed and any warnings.
@@ -21,6 +27,12 @@ type Guardrails interface {
 	RecordFailure(ctx context.Context, userID string)
 }
 
+// UsageRecorder is implemented by guardrails that track token usage. The
+// engine reports the tokens each run used, however it ended.
+type UsageRecorder interface {
+	RecordUsage(ctx context.Context, userID string, usage core.TokenUsage)
+}
+
 // GuardrailResult contains the result of a guardrail check.
 type GuardrailResult struct {
 	// Allowed indicates whether the request should proceed.
@@ -59,3 +71,286 @@ func (n *NoOpGuardrails) RecordSuccess(ctx context.Context, userID string) {}
 
 // RecordFailure is a no-op.
 func (n *NoOpGuardrails) RecordFailure(ctx context.Context, userID string) {}
+
+// Circuit breaker states, as reported in GuardrailResult.CircuitState.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// DefaultCircuitCooldown is how long a circuit stays open by default.
+const DefaultCircuitCooldown = time.Minute
+
+// BasicGuardrailsConfig configures NewBasicGuardrails. Each limit is off
+// when zero.
+type BasicGuardrailsConfig struct {
+	// RequestsPerMinute is the rate a user's requests are allowed at, as a
+	// token bucket refilling continuously.
+	RequestsPerMinute int
+
+	// Burst is how many requests a user can make at once. Defaults to
+	// RequestsPerMinute.
+	Burst int
+
+	// DailyTokenBudget is how many Claude tokens a user may use a day,
+	// cache tokens included. The run that crosses it completes; later
+	// ones are refused until midnight.
+	DailyTokenBudget int
+
+	// Location is the time zone whose midnight resets the budget.
+	// Defaults to UTC.
+	Location *time.Location
+
+	// FailureThreshold is how many consecutive failed runs open a user's
+	// circuit, refusing their requests for Cooldown. Then one request is
+	// let through: the circuit closes if it succeeds and opens again if
+	// it fails.
+	FailureThreshold int
+
+	// Cooldown is how long a circuit stays open. Defaults to
+	// DefaultCircuitCooldown.
+	Cooldown time.Duration
+
+	// Store keeps each user's state. Defaults to an in-memory store.
+	Store GuardrailStore
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// GuardrailState is a user's state in BasicGuardrails.
+type GuardrailState struct {
+	// Token bucket
+	Tokens     float64   `json:"tokens"`
+	LastRefill time.Time `json:"last_refill"`
+
+	// Daily budget
+	Day        string `json:"day"` // YYYY-MM-DD in the budget's time zone
+	TokensUsed int    `json:"tokens_used"`
+
+	// Circuit breaker
+	Failures int       `json:"failures"` // consecutive
+	Circuit  string    `json:"circuit"`
+	OpenedAt time.Time `json:"opened_at"` // when it opened, or began probing
+	Probing  bool      `json:"probing"`   // a half-open request is in flight
+}
+
+// GuardrailStore keeps BasicGuardrails state, so it can be shared by
+// several server instances, e.g. in Redis.
+type GuardrailStore interface {
+	// Update calls fn with the user's state, the zero value if they have
+	// none, and saves the changes fn makes. Updates of one user's state
+	// must not interleave.
+	Update(ctx context.Context, userID string, fn func(state *GuardrailState)) error
+}
+
+// MemoryGuardrailStore keeps guardrail state in memory.
+type MemoryGuardrailStore struct {
+	mu     sync.Mutex
+	states map[string]*GuardrailState
+}
+
+// NewMemoryGuardrailStore creates an empty in-memory guardrail store.
+func NewMemoryGuardrailStore() *MemoryGuardrailStore {
+	return &MemoryGuardrailStore{states: make(map[string]*GuardrailState)}
+}
+
+// Update calls fn with the user's state.
+func (m *MemoryGuardrailStore) Update(ctx context.Context, userID string, fn func(state *GuardrailState)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.states[userID]
+	if !ok {
+		state = &GuardrailState{}
+		m.states[userID] = state
+	}
+	fn(state)
+	return nil
+}
+
+// BasicGuardrails limits each user's request rate and daily Claude token
+// usage, and stops serving users whose runs keep failing.
+type BasicGuardrails struct {
+	cfg BasicGuardrailsConfig
+}
+
+// NewBasicGuardrails creates guardrails enforcing cfg.
+func NewBasicGuardrails(cfg BasicGuardrailsConfig) *BasicGuardrails {
+	if cfg.Burst <= 0 {
+		cfg.Burst = cfg.RequestsPerMinute
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultCircuitCooldown
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryGuardrailStore()
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &BasicGuardrails{cfg: cfg}
+}
+
+// Check allows a request if the user's circuit isn't open, they have
+// budget left, and their rate allows it. A request is only counted against
+// the rate if it is allowed.
+func (g *BasicGuardrails) Check(ctx context.Context, userID string) (*GuardrailResult, error) {
+	now := g.cfg.Now()
+	var result *GuardrailResult
+	err := g.cfg.Store.Update(ctx, userID, func(state *GuardrailState) {
+		result = g.check(state, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (g *BasicGuardrails) check(state *GuardrailState, now time.Time) *GuardrailResult {
+	result := &GuardrailResult{Allowed: true, CircuitState: CircuitClosed, RemainingRequests: -1}
+
+	// Circuit breaker
+	probe := false
+	switch state.Circuit {
+	case CircuitOpen:
+		if reopen := state.OpenedAt.Add(g.cfg.Cooldown); now.Before(reopen) {
+			return deny(result, CircuitOpen, reopen, now, "Something has gone wrong with your last few requests. Please try again %s.")
+		}
+		probe = true
+	case CircuitHalfOpen:
+		// A probe that never reported back is given up on after a cooldown.
+		if retry := state.OpenedAt.Add(g.cfg.Cooldown); state.Probing && now.Before(retry) {
+			return deny(result, CircuitHalfOpen, retry, now, "Something has gone wrong with your last few requests. Please try again %s.")
+		}
+		probe = true
+	}
+
+	// Daily budget
+	if g.cfg.DailyTokenBudget > 0 {
+		g.rollDay(state, now)
+		if state.TokensUsed >= g.cfg.DailyTokenBudget {
+			return deny(result, result.CircuitState, g.nextMidnight(now), now, "You've reached today's usage limit. It resets %s.")
+		}
+		if state.TokensUsed*10 >= g.cfg.DailyTokenBudget*8 {
+			result.Warning = fmt.Sprintf("You've used %d%% of today's usage limit.", state.TokensUsed*100/g.cfg.DailyTokenBudget)
+		}
+	}
+
+	// Token bucket
+	if g.cfg.RequestsPerMinute > 0 {
+		g.refill(state, now)
+		if state.Tokens < 1 {
+			wait := time.Duration((1 - state.Tokens) / float64(g.cfg.RequestsPerMinute) * float64(time.Minute))
+			return deny(result, result.CircuitState, now.Add(wait), now, "You're sending messages too quickly. Please try again %s.")
+		}
+		state.Tokens--
+		result.RemainingRequests = int(state.Tokens)
+	}
+
+	if probe {
+		state.Circuit, state.Probing, state.OpenedAt = CircuitHalfOpen, true, now
+		result.CircuitState = CircuitHalfOpen
+	}
+	return result
+}
+
+// deny refuses a request until retry, explaining when to come back in
+// message's %s.
+func deny(result *GuardrailResult, circuit string, retry, now time.Time, message string) *GuardrailResult {
+	result.Allowed = false
+	result.CircuitState = circuit
+	result.RetryAfter = retry.Unix()
+	result.Warning = fmt.Sprintf(message, humanizeWait(retry.Sub(now)))
+	return result
+}
+
+// humanizeWait describes a wait, e.g. "in 12 seconds".
+func humanizeWait(d time.Duration) string {
+	switch {
+	case d <= time.Second:
+		return "in a second"
+	case d < time.Minute:
+		return fmt.Sprintf("in %d seconds", int((d+time.Second-1)/time.Second))
+	case d < 2*time.Minute:
+		return "in a minute"
+	case d < time.Hour:
+		return fmt.Sprintf("in %d minutes", int((d+time.Minute-1)/time.Minute))
+	case d < 2*time.Hour:
+		return "in an hour"
+	}
+	return fmt.Sprintf("in %d hours", int((d+time.Hour-1)/time.Hour))
+}
+
+// refill adds the tokens earned since the last refill, up to Burst.
+func (g *BasicGuardrails) refill(state *GuardrailState, now time.Time) {
+	if state.LastRefill.IsZero() {
+		state.Tokens, state.LastRefill = float64(g.cfg.Burst), now
+		return
+	}
+	earned := now.Sub(state.LastRefill).Minutes() * float64(g.cfg.RequestsPerMinute)
+	state.Tokens = min(state.Tokens+earned, float64(g.cfg.Burst))
+	state.LastRefill = now
+}
+
+// rollDay starts a new budget at midnight.
+func (g *BasicGuardrails) rollDay(state *GuardrailState, now time.Time) {
+	if day := now.In(g.cfg.Location).Format("2006-01-02"); state.Day != day {
+		state.Day, state.TokensUsed = day, 0
+	}
+}
+
+func (g *BasicGuardrails) nextMidnight(now time.Time) time.Time {
+	y, m, d := now.In(g.cfg.Location).Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, g.cfg.Location)
+}
+
+// RecordSuccess closes the user's circuit.
+func (g *BasicGuardrails) RecordSuccess(ctx context.Context, userID string) {
+	g.update(ctx, userID, func(state *GuardrailState) {
+		state.Failures, state.Circuit, state.Probing = 0, CircuitClosed, false
+	})
+}
+
+// RecordFailure counts a failed run, opening the user's circuit after
+// FailureThreshold in a row, or at once if it was half-open.
+func (g *BasicGuardrails) RecordFailure(ctx context.Context, userID string) {
+	if g.cfg.FailureThreshold <= 0 {
+		return
+	}
+	now := g.cfg.Now()
+	g.update(ctx, userID, func(state *GuardrailState) {
+		state.Failures++
+		if state.Circuit == CircuitHalfOpen || state.Failures >= g.cfg.FailureThreshold {
+			state.Circuit, state.OpenedAt, state.Probing = CircuitOpen, now, false
+		}
+	})
+}
+
+// RecordUsage counts a run's tokens against the user's daily budget.
+func (g *BasicGuardrails) RecordUsage(ctx context.Context, userID string, usage core.TokenUsage) {
+	if g.cfg.DailyTokenBudget <= 0 {
+		return
+	}
+	now := g.cfg.Now()
+	tokens := usage.TotalTokens() + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	g.update(ctx, userID, func(state *GuardrailState) {
+		g.rollDay(state, now)
+		state.TokensUsed += tokens
+	})
+}
+
+func (g *BasicGuardrails) update(ctx context.Context, userID string, fn func(state *GuardrailState)) {
+	if err := g.cfg.Store.Update(ctx, userID, fn); err != nil {
+		log.Printf("Failed to update guardrails for user %s: %v", userID, err)
+	}
+}
+
+// Verify BasicGuardrails implements Guardrails and UsageRecorder.
+var (
+	_ Guardrails    = (*BasicGuardrails)(nil)
+	_ UsageRecorder = (*BasicGuardrails)(nil)
+)
@@ -0,0 +1,182 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// clock is a settable time source.
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func check(t *testing.T, g *BasicGuardrails) *GuardrailResult {
+	t.Helper()
+	result, err := g.Check(context.Background(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestBasicGuardrails_RateLimit(t *testing.T) {
+	c := &clock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	g := NewBasicGuardrails(BasicGuardrailsConfig{RequestsPerMinute: 6, Burst: 3, Now: c.Now})
+
+	for i := 2; i >= 0; i-- {
+		if result := check(t, g); !result.Allowed || result.RemainingRequests != i {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", 3-i, result, i)
+		}
+	}
+	result := check(t, g)
+	if result.Allowed || !strings.Contains(result.Warning, "too quickly") || !strings.Contains(result.Warning, "in 10 seconds") {
+		t.Errorf("exhausted = %+v, want refused for 10 seconds", result)
+	}
+	if want := c.now.Add(10 * time.Second).Unix(); result.RetryAfter != want {
+		t.Errorf("RetryAfter = %d, want %d", result.RetryAfter, want)
+	}
+
+	// Other users have buckets of their own.
+	if other, _ := g.Check(context.Background(), "user-2"); !other.Allowed {
+		t.Error("another user was refused")
+	}
+
+	// Refused requests don't count: one token is back after 10s.
+	c.Advance(10 * time.Second)
+	if result := check(t, g); !result.Allowed || result.RemainingRequests != 0 {
+		t.Errorf("after 10s = %+v, want one request allowed", result)
+	}
+	if result := check(t, g); result.Allowed {
+		t.Error("second request after 10s allowed")
+	}
+
+	// The bucket refills up to the burst.
+	c.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		check(t, g)
+	}
+	if result := check(t, g); result.Allowed {
+		t.Error("bucket refilled beyond its burst")
+	}
+}
+
+func TestBasicGuardrails_DailyBudget(t *testing.T) {
+	// Budgets reset at midnight in the configured zone, here UTC-5.
+	zone := time.FixedZone("EST", -5*60*60)
+	c := &clock{now: time.Date(2026, 3, 1, 23, 0, 0, 0, zone)}
+	g := NewBasicGuardrails(BasicGuardrailsConfig{DailyTokenBudget: 1000, Location: zone, Now: c.Now})
+	ctx := context.Background()
+
+	g.RecordUsage(ctx, "user-1", core.TokenUsage{InputTokens: 500, OutputTokens: 200, CacheReadInputTokens: 100})
+	if result := check(t, g); !result.Allowed || !strings.Contains(result.Warning, "80%") {
+		t.Errorf("at 800 tokens = %+v, want allowed with a warning", result)
+	}
+
+	g.RecordUsage(ctx, "user-1", core.TokenUsage{InputTokens: 150, OutputTokens: 50})
+	result := check(t, g)
+	if result.Allowed || !strings.Contains(result.Warning, "usage limit") || !strings.Contains(result.Warning, "in an hour") {
+		t.Errorf("at 1000 tokens = %+v, want refused until midnight", result)
+	}
+	if want := time.Date(2026, 3, 2, 0, 0, 0, 0, zone).Unix(); result.RetryAfter != want {
+		t.Errorf("RetryAfter = %v, want local midnight", time.Unix(result.RetryAfter, 0).In(zone))
+	}
+
+	// 04:30 UTC is past midnight UTC but not in the budget's zone.
+	c.now = time.Date(2026, 3, 2, 4, 30, 0, 0, time.UTC)
+	if result := check(t, g); result.Allowed {
+		t.Error("budget reset at midnight UTC")
+	}
+	c.now = time.Date(2026, 3, 2, 5, 0, 0, 0, time.UTC)
+	if result := check(t, g); !result.Allowed || result.Warning != "" {
+		t.Errorf("after local midnight = %+v, want a fresh budget", result)
+	}
+}
+
+func TestBasicGuardrails_CircuitBreaker(t *testing.T) {
+	c := &clock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	g := NewBasicGuardrails(BasicGuardrailsConfig{FailureThreshold: 3, Cooldown: time.Minute, Now: c.Now})
+	ctx := context.Background()
+
+	steps := []struct {
+		name        string
+		do          func()
+		wantAllowed bool
+		wantState   string
+	}{
+		{name: "starts closed", wantAllowed: true, wantState: CircuitClosed},
+		{name: "success resets failures", do: func() {
+			g.RecordFailure(ctx, "user-1")
+			g.RecordFailure(ctx, "user-1")
+			g.RecordSuccess(ctx, "user-1")
+			g.RecordFailure(ctx, "user-1")
+			g.RecordFailure(ctx, "user-1")
+		}, wantAllowed: true, wantState: CircuitClosed},
+		{name: "opens after threshold", do: func() { g.RecordFailure(ctx, "user-1") }, wantAllowed: false, wantState: CircuitOpen},
+		{name: "stays open during cooldown", do: func() { c.Advance(59 * time.Second) }, wantAllowed: false, wantState: CircuitOpen},
+		{name: "half-opens after cooldown", do: func() { c.Advance(time.Second) }, wantAllowed: true, wantState: CircuitHalfOpen},
+		{name: "one probe at a time", wantAllowed: false, wantState: CircuitHalfOpen},
+		{name: "failed probe reopens", do: func() { g.RecordFailure(ctx, "user-1") }, wantAllowed: false, wantState: CircuitOpen},
+		{name: "half-opens again", do: func() { c.Advance(time.Minute) }, wantAllowed: true, wantState: CircuitHalfOpen},
+		{name: "successful probe closes", do: func() { g.RecordSuccess(ctx, "user-1") }, wantAllowed: true, wantState: CircuitClosed},
+		{name: "one failure doesn't reopen", do: func() { g.RecordFailure(ctx, "user-1") }, wantAllowed: true, wantState: CircuitClosed},
+	}
+	for _, step := range steps {
+		if step.do != nil {
+			step.do()
+		}
+		result := check(t, g)
+		if result.Allowed != step.wantAllowed || result.CircuitState != step.wantState {
+			t.Fatalf("%s: %+v, want allowed %v in state %s", step.name, result, step.wantAllowed, step.wantState)
+		}
+		if !result.Allowed && result.Warning == "" {
+			t.Errorf("%s: refused without a warning", step.name)
+		}
+	}
+}
+
+func TestRun_GuardrailsRecordOutcome(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"type":"error","error":{"type":"api_error","message":"down"}}`, http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+	client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+	g := NewBasicGuardrails(BasicGuardrailsConfig{FailureThreshold: 2})
+	eng := NewEngine(&client, NewToolRegistry(), WithGuardrails(g))
+
+	run := func() *Output {
+		out, _ := eng.Run(context.Background(), moderationInput("hi"))
+		return out
+	}
+	run()
+	run()
+	out := run()
+	if out.Type != OutputError || !strings.Contains(out.Error.Error(), "blocked by guardrails") {
+		t.Errorf("third run = %+v, want blocked by the open circuit", out)
+	}
+}
+
+func TestRun_GuardrailsRecordUsage(t *testing.T) {
+	eng, _ := newModerationEngine(t, "Hello!")
+	g := NewBasicGuardrails(BasicGuardrailsConfig{DailyTokenBudget: 4})
+	eng.guardrails = g
+
+	for i := 0; i < 2; i++ {
+		if out, err := eng.Run(context.Background(), moderationInput("hi")); err != nil || out.Type != OutputComplete {
+			t.Fatalf("run %d = %+v, %v", i, out, err)
+		}
+	}
+	// Each run used 2 tokens, so the budget is spent.
+	out, _ := eng.Run(context.Background(), moderationInput("hi"))
+	if out.Type != OutputError || !strings.Contains(out.Error.Error(), "usage limit") {
+		t.Errorf("third run = %+v, want refused for the budget", out)
+	}
+}
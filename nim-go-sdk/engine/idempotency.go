@@ -1,11 +1,16 @@
 package engine
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log"
 	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
 )
 
 // IdempotencyBucketDuration is the time window for idempotency key generation.
@@ -50,3 +55,59 @@ func GenerateIdempotencyKeyWithTime(userID, tool string, input json.RawMessage,
 	hash := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(hash[:])
 }
+
+// DefaultCompletedActionWindow is how long an executed action's idempotency
+// key is remembered unless WithCompletedActions says otherwise.
+const DefaultCompletedActionWindow = 10 * time.Minute
+
+// WithCompletedActions records the idempotency keys of confirmed write
+// actions that succeed in completed, for window (DefaultCompletedActionWindow
+// if zero). ExecuteAction refuses an action whose key has already executed,
+// so confirming two copies of the same request pays once.
+func WithCompletedActions(completed store.CompletedActions, window time.Duration) Option {
+	return func(e *Engine) {
+		e.completed = completed
+		e.completedWindow = window
+	}
+}
+
+// DuplicateActionError is returned by ExecuteAction for an action that
+// already executed under the same idempotency key. Nothing was executed;
+// Result is the earlier execution's result.
+type DuplicateActionError struct {
+	ActionID string
+	Result   *core.ToolResult
+}
+
+func (e *DuplicateActionError) Error() string {
+	return fmt.Sprintf("action %s was not executed: an identical action already completed", e.ActionID)
+}
+
+// completedResult returns the result of an earlier execution of action, or
+// nil if it hasn't executed. Lookup failures are logged and treated as not
+// executed, so a store outage doesn't block every write.
+func (e *Engine) completedResult(ctx context.Context, action *core.PendingAction) *core.ToolResult {
+	if e.completed == nil || action.IdempotencyKey == "" {
+		return nil
+	}
+	result, err := e.completed.Completed(ctx, action.UserID, action.IdempotencyKey)
+	if err != nil {
+		log.Printf("Failed to check completed actions for %s: %v", action.ID, err)
+		return nil
+	}
+	return result
+}
+
+// recordCompleted remembers that action executed successfully.
+func (e *Engine) recordCompleted(ctx context.Context, action *core.PendingAction, result *core.ToolResult) {
+	if e.completed == nil || action.IdempotencyKey == "" || result == nil || !result.Success {
+		return
+	}
+	window := e.completedWindow
+	if window <= 0 {
+		window = DefaultCompletedActionWindow
+	}
+	if err := e.completed.Complete(ctx, action.UserID, action.IdempotencyKey, result, window); err != nil {
+		log.Printf("Failed to record completed action %s: %v", action.ID, err)
+	}
+}
@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func TestExecuteAction_InputIntegrity(t *testing.T) {
+	tests := []struct {
+		name string
+		// between runs after the action is stored and before it is confirmed.
+		between   func(t *testing.T, stored *core.PendingAction)
+		wantInput string
+		wantErr   bool
+	}{
+		{
+			name:      "untouched",
+			between:   func(*testing.T, *core.PendingAction) {},
+			wantInput: `{}`,
+		},
+		{
+			name: "tampered in store",
+			between: func(t *testing.T, stored *core.PendingAction) {
+				stored.Input = json.RawMessage(`{"recipient":"@mallory","amount":"500","currency":"USD"}`)
+			},
+			wantErr: true,
+		},
+		{
+			name: "modified and re-approved",
+			between: func(t *testing.T, stored *core.PendingAction) {
+				before := stored.InputHash
+				if err := stored.SetInput(json.RawMessage(`{"recipient":"@alice","amount":"40","currency":"USD"}`)); err != nil {
+					t.Fatal(err)
+				}
+				if stored.InputHash == before {
+					t.Error("modified input kept the approved hash")
+				}
+			},
+			wantInput: `{"recipient":"@alice","amount":"40","currency":"USD"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executed []string
+			send := tools.New("send_money").
+				RequiresConfirmation().
+				Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					executed = append(executed, string(params.Input))
+					return &core.ToolResult{Success: true}, nil
+				}).
+				Build()
+			audit := NewMemoryAuditLogger()
+			eng := newTestEngine(t, "send_money", send, WithAudit(audit))
+
+			out, err := eng.Run(context.Background(), &Input{
+				UserMessage: "send money",
+				Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+			})
+			if err != nil || out.Type != OutputConfirmationNeeded {
+				t.Fatalf("Run() = %v, %v; want a confirmation", out.Type, err)
+			}
+			pending := out.PendingAction
+			if want, _ := core.HashInput(pending.Input); pending.InputHash != want {
+				t.Fatalf("InputHash = %q, want %q", pending.InputHash, want)
+			}
+
+			confirmations := store.NewMemoryConfirmations()
+			if err := confirmations.Store(context.Background(), pending); err != nil {
+				t.Fatal(err)
+			}
+			stored, _ := confirmations.Get(context.Background(), "user-1", pending.ID)
+			tt.between(t, stored)
+			action, err := confirmations.Confirm(context.Background(), "user-1", pending.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = eng.ExecuteAction(context.Background(), action)
+
+			var integrityErr *core.InputIntegrityError
+			if got := errors.As(err, &integrityErr); got != tt.wantErr {
+				t.Fatalf("ExecuteAction() error = %v, want integrity error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(executed) != 0 {
+					t.Errorf("tool executed %v after failing the integrity check", executed)
+				}
+				entries := audit.Entries()
+				last := entries[len(entries)-1]
+				if last.ToolName != "send_money" || last.RequestID != pending.ID || last.Error == nil || !last.IsWriteOp {
+					t.Errorf("audit entry = %+v, want the refused send_money", last)
+				}
+				return
+			}
+			if len(executed) != 1 || executed[0] != tt.wantInput {
+				t.Errorf("executed %v, want [%s]", executed, tt.wantInput)
+			}
+		})
+	}
+}
+
+func TestEditAction(t *testing.T) {
+	var executed []string
+	send := core.NewBaseTool(core.ToolDefinition{
+		ToolName:                 "send_money",
+		RequiresUserConfirmation: true,
+		SummaryTemplate:          "Send {{.amount}} {{.currency}} to {{.recipient}}",
+		InputSchema: tools.ObjectSchema(map[string]interface{}{
+			"recipient": tools.StringProperty("Recipient"),
+			"amount":    tools.StringProperty("Amount"),
+			"currency":  tools.StringProperty("Currency"),
+		}, "recipient", "amount", "currency"),
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		executed = append(executed, string(params.Input))
+		return &core.ToolResult{Success: true, Data: map[string]string{"status": "sent"}}, nil
+	})
+	const original = `{"recipient":"@alice","amount":"50","currency":"USD"}`
+
+	tests := []struct {
+		name    string
+		edits   string
+		wantErr string
+	}{
+		{name: "amount changed", edits: `{"recipient":"@alice","amount":"45","currency":"USD"}`},
+		{name: "missing field", edits: `{"recipient":"@alice","currency":"USD"}`, wantErr: "missing required field 'amount' (expected string)"},
+		{name: "wrong type", edits: `{"recipient":"@alice","amount":45,"currency":"USD"}`, wantErr: "field 'amount' must be a string, got number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executed = nil
+			audit := NewMemoryAuditLogger()
+			eng := newTestEngine(t, "send_money", send, WithAudit(audit))
+			action := &core.PendingAction{ID: "action-1", UserID: "user-1", Tool: "send_money", Summary: "Send 50 USD to @alice"}
+			if err := action.SetInput(json.RawMessage(original)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := eng.EditAction(action, json.RawMessage(tt.edits))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("EditAction() = %v, want %q", err, tt.wantErr)
+				}
+				if string(action.Input) != original || action.OriginalInput != nil || action.VerifyInput() != nil {
+					t.Errorf("rejected edits changed the action: %+v", action)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if action.Summary != "Send 45 USD to @alice" {
+				t.Errorf("Summary = %q, want regenerated", action.Summary)
+			}
+
+			if _, err := eng.ExecuteAction(context.Background(), action); err != nil {
+				t.Fatal(err)
+			}
+			if len(executed) != 1 || executed[0] != tt.edits {
+				t.Errorf("executed %v, want [%s]", executed, tt.edits)
+			}
+			entries := audit.Entries()
+			if len(entries) != 1 {
+				t.Fatalf("audit entries = %d, want 1", len(entries))
+			}
+			if e := entries[0]; string(e.OriginalInput) != original || string(e.ToolInput) != tt.edits || e.Error != nil || string(e.ToolOutput) != `{"status":"sent"}` {
+				t.Errorf("audit entry = %+v, want original and edited input", e)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Metrics receives operational measurements from the engine: tool calls,
+// model calls, runs and confirmations. The metrics package provides a
+// Prometheus implementation.
+//
+// Observations carry no user or conversation IDs, so implementations can
+// use every argument as a label without unbounded cardinality. Methods are
+// called from concurrent runs and must be safe for concurrent use.
+type Metrics interface {
+	// ObserveToolExecution records a tool call, confirmed writes included.
+	// err is the error the tool returned, or the error of an unsuccessful
+	// result.
+	ObserveToolExecution(tool string, duration time.Duration, err error)
+
+	// ObserveModelCall records a Claude API call.
+	ObserveModelCall(model string, duration time.Duration, err error)
+
+	// ObserveRun records a finished run: its token usage, how many model
+	// turns it took, and how it ended.
+	ObserveRun(tokens core.TokenUsage, turns int, outcome RunOutcome)
+
+	// ObserveConfirmation records a write action being offered for
+	// confirmation, and how it was resolved.
+	ObserveConfirmation(tool string, outcome ConfirmationOutcome)
+}
+
+// RunOutcome is how a run ended.
+type RunOutcome string
+
+// Run outcomes.
+const (
+	RunComplete           RunOutcome = "complete"
+	RunConfirmationNeeded RunOutcome = "confirmation_needed"
+	RunError              RunOutcome = "error"
+
+	// RunCanceled is a run whose caller went away, e.g. a closed connection.
+	RunCanceled RunOutcome = "canceled"
+)
+
+// ConfirmationOutcome is a step in an action's confirmation.
+type ConfirmationOutcome string
+
+// Confirmation outcomes. Every action is requested, then at most one of
+// the others. The engine reports requested, confirmed and refused; the
+// server, which owns pending actions, reports cancelled and expired.
+const (
+	ConfirmationRequested ConfirmationOutcome = "requested"
+	ConfirmationConfirmed ConfirmationOutcome = "confirmed"
+	ConfirmationCancelled ConfirmationOutcome = "cancelled"
+	ConfirmationExpired   ConfirmationOutcome = "expired"
+
+	// ConfirmationRefused is a confirmed action that wasn't executed
+	// because its input no longer matched what was approved, or because
+	// an identical action had already executed.
+	ConfirmationRefused ConfirmationOutcome = "refused"
+)
+
+// WithMetrics reports tool calls, model calls, runs and confirmations to m.
+func WithMetrics(m Metrics) Option {
+	return func(e *Engine) {
+		e.metrics = m
+	}
+}
+
+// observeTool reports a tool call to the engine's metrics, if any.
+func (e *Engine) observeTool(tool string, start time.Time, result *core.ToolResult, err error) {
+	if e.metrics == nil {
+		return
+	}
+	if err == nil && result != nil && !result.Success {
+		err = errors.New(result.Error)
+	}
+	e.metrics.ObserveToolExecution(tool, time.Since(start), err)
+}
+
+// observeModelCall reports a Claude API call to the engine's metrics, if any.
+func (e *Engine) observeModelCall(model string, start time.Time, err error) {
+	if e.metrics != nil {
+		e.metrics.ObserveModelCall(model, time.Since(start), err)
+	}
+}
+
+// observeConfirmation reports a confirmation step to the engine's metrics,
+// if any.
+func (e *Engine) observeConfirmation(tool string, outcome ConfirmationOutcome) {
+	if e.metrics != nil {
+		e.metrics.ObserveConfirmation(tool, outcome)
+	}
+}
+
+// runOutcome classifies a finished run.
+func runOutcome(ctx context.Context, out *Output, err error) RunOutcome {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return RunCanceled
+	case err != nil || out == nil || out.Type == OutputError:
+		return RunError
+	case out.Type == OutputConfirmationNeeded:
+		return RunConfirmationNeeded
+	}
+	return RunComplete
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// ToolExecFunc executes a tool call. It is core.Tool's Execute, given the
+// tool's name.
+type ToolExecFunc func(ctx context.Context, tool string, params *core.ToolParams) (*core.ToolResult, error)
+
+// ToolMiddleware wraps tool execution, e.g. to time calls or add request
+// metadata to ctx. It may call next with a different ctx or params, or not
+// call it at all and return its own result instead.
+type ToolMiddleware func(next ToolExecFunc) ToolExecFunc
+
+// WithToolMiddleware wraps every tool execution, read-only calls made
+// during a run and confirmed writes executed with ExecuteTool alike.
+// Middlewares run in the order given, across calls to WithToolMiddleware:
+// the first is outermost.
+func WithToolMiddleware(mw ...ToolMiddleware) Option {
+	return func(e *Engine) {
+		e.toolMiddleware = append(e.toolMiddleware, mw...)
+	}
+}
+
+// ActionInputFunc rewrites a write tool's input before the user is asked to
+// confirm it, e.g. to resolve a nickname to the account it stands for.
+// It returns input unchanged if there's nothing to rewrite.
+type ActionInputFunc func(ctx context.Context, userID, tool string, input json.RawMessage) json.RawMessage
+
+// WithActionInput rewrites the input of each write action as its
+// confirmation is created, so the summary, details and frozen input the
+// user confirms all show what will execute.
+func WithActionInput(f ActionInputFunc) Option {
+	return func(e *Engine) {
+		e.actionInput = f
+	}
+}
+
+// executeTool runs tool through the middleware chain, reporting the call
+// to metrics and tracing it. Reads answered from the tool cache skip the chain.
+func (e *Engine) executeTool(ctx context.Context, tool core.Tool, params *core.ToolParams) (*core.ToolResult, error) {
+	exec := func(ctx context.Context, _ string, params *core.ToolParams) (*core.ToolResult, error) {
+		return tool.Execute(ctx, params)
+	}
+	for i := len(e.toolMiddleware) - 1; i >= 0; i-- {
+		exec = e.toolMiddleware[i](exec)
+	}
+	ctx, span := e.startTool(ctx, tool.Name())
+	start := time.Now()
+	result, err := e.cachedTool(ctx, tool, params, exec)
+	e.observeTool(tool.Name(), start, result, err)
+	endTool(span, start, result, err)
+	return result, err
+}
+
+// maxLoggedInput is how much of a tool's input LogToolCalls logs.
+const maxLoggedInput = 200
+
+// LogToolCalls is a ToolMiddleware that logs each tool call with its
+// duration, outcome, and input, redacted with RedactDebug and truncated.
+func LogToolCalls(next ToolExecFunc) ToolExecFunc {
+	return func(ctx context.Context, tool string, params *core.ToolParams) (*core.ToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, tool, params)
+		duration := time.Since(start).Round(time.Millisecond)
+
+		input := string(RedactDebug(params.Input))
+		if len(input) > maxLoggedInput {
+			input = input[:maxLoggedInput] + "..."
+		}
+		switch {
+		case err != nil:
+			log.Printf("[TOOL] %s for user %s failed after %s: %v (input %s)", tool, params.UserID, duration, err, input)
+		case result != nil && !result.Success:
+			log.Printf("[TOOL] %s for user %s returned an error after %s: %s (input %s)", tool, params.UserID, duration, result.Error, input)
+		default:
+			log.Printf("[TOOL] %s for user %s took %s (input %s)", tool, params.UserID, duration, input)
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// tracer records the steps of tool executions.
+type tracer struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (tr *tracer) add(step string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.steps = append(tr.steps, step)
+}
+
+// middleware records name before and after each call it wraps.
+func (tr *tracer) middleware(name string) ToolMiddleware {
+	return func(next ToolExecFunc) ToolExecFunc {
+		return func(ctx context.Context, tool string, params *core.ToolParams) (*core.ToolResult, error) {
+			tr.add(fmt.Sprintf("%s>%s", name, tool))
+			result, err := next(ctx, tool, params)
+			tr.add("<" + name)
+			return result, err
+		}
+	}
+}
+
+func (tr *tracer) tool(name string, requiresConfirmation bool) core.Tool {
+	return core.NewBaseTool(core.ToolDefinition{ToolName: name, RequiresUserConfirmation: requiresConfirmation},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			tr.add(name)
+			return &core.ToolResult{Success: true, Data: "ok"}, nil
+		})
+}
+
+func TestToolMiddleware_Order(t *testing.T) {
+	want := "a>get_balance b>get_balance c>get_balance get_balance <c <b <a"
+
+	t.Run("run", func(t *testing.T) {
+		tr := &tracer{}
+		eng := newTestEngine(t, "get_balance", tr.tool("get_balance", false),
+			WithToolMiddleware(tr.middleware("a"), tr.middleware("b")),
+			WithToolMiddleware(tr.middleware("c")))
+		out, err := eng.Run(context.Background(), &Input{
+			UserMessage: "balance?",
+			Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+		})
+		if err != nil || out.Type != OutputComplete {
+			t.Fatalf("Run() = %+v, %v", out, err)
+		}
+		if got := strings.Join(tr.steps, " "); got != want {
+			t.Errorf("steps = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("confirmed write", func(t *testing.T) {
+		tr := &tracer{}
+		registry := NewToolRegistry()
+		registry.Register(tr.tool("get_balance", true))
+		eng := NewEngine(nil, registry,
+			WithToolMiddleware(tr.middleware("a"), tr.middleware("b")),
+			WithToolMiddleware(tr.middleware("c")))
+		if _, err := eng.ExecuteTool(context.Background(), "user-1", "get_balance", json.RawMessage(`{}`), "conf-1"); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(tr.steps, " "); got != want {
+			t.Errorf("steps = %s, want %s", got, want)
+		}
+	})
+}
+
+func TestToolMiddleware_ShortCircuit(t *testing.T) {
+	deny := func(next ToolExecFunc) ToolExecFunc {
+		return func(ctx context.Context, tool string, params *core.ToolParams) (*core.ToolResult, error) {
+			return &core.ToolResult{Success: false, Error: "blocked by policy"}, nil
+		}
+	}
+
+	tr := &tracer{}
+	eng := newTestEngine(t, "get_balance", tr.tool("get_balance", false), WithToolMiddleware(deny))
+	out, err := eng.Run(context.Background(), &Input{
+		UserMessage: "balance?",
+		Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+	})
+	if err != nil || out.Type != OutputComplete {
+		t.Fatalf("Run() = %+v, %v", out, err)
+	}
+	if len(tr.steps) != 0 {
+		t.Errorf("tool ran: %v", tr.steps)
+	}
+	if len(out.ToolsUsed) != 1 || out.ToolsUsed[0].Error != "blocked by policy" {
+		t.Errorf("tools used = %+v, want the middleware's error", out.ToolsUsed)
+	}
+}
+
+func TestLogToolCalls(t *testing.T) {
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(prev) })
+
+	exec := LogToolCalls(func(ctx context.Context, tool string, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true}, nil
+	})
+	input := fmt.Sprintf(`{"note":%q}`, strings.Repeat("x", 500))
+	if _, err := exec(context.Background(), "get_balance", &core.ToolParams{UserID: "user-1", Input: json.RawMessage(input)}); err != nil {
+		t.Fatal(err)
+	}
+
+	line := buf.String()
+	if !strings.Contains(line, "[TOOL] get_balance for user user-1 took") {
+		t.Errorf("log = %q", line)
+	}
+	if strings.Contains(line, strings.Repeat("x", maxLoggedInput)) || !strings.Contains(line, "...") {
+		t.Errorf("input not truncated: %q", line)
+	}
+}
@@ -0,0 +1,251 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModerationStage is the point in a turn where content is checked.
+type ModerationStage string
+
+// Moderation stages.
+const (
+	// ModerationInput checks the user's message before it is added to the
+	// conversation.
+	ModerationInput ModerationStage = "input"
+
+	// ModerationOutput checks assistant text before it is streamed or returned.
+	ModerationOutput ModerationStage = "output"
+)
+
+// ModerationVerdict is what to do with checked content.
+type ModerationVerdict string
+
+// Moderation verdicts.
+const (
+	ModerationAllow   ModerationVerdict = "allow"
+	ModerationDeny    ModerationVerdict = "deny"
+	ModerationRewrite ModerationVerdict = "rewrite"
+)
+
+// ModerationDecision is a moderator's verdict on one piece of content.
+type ModerationDecision struct {
+	Verdict ModerationVerdict
+
+	// Text replaces the content when Verdict is ModerationRewrite.
+	Text string
+
+	// Reason explains the decision. For denials it is shown to the user in
+	// place of the content, so it should say what they can do instead.
+	Reason string
+
+	// Rule names the rule that made the decision, for audit.
+	Rule string
+}
+
+// Moderator checks each turn's content, blocking or rewriting messages the
+// application must not process and replies it must not give.
+// This is an interface - implementations (e.g., a policy service client) are
+// provided by the consuming application. RuleModerator is a regex-based
+// reference implementation.
+type Moderator interface {
+	// CheckInput checks a user's message before the model sees it.
+	// A nil decision allows it.
+	CheckInput(ctx context.Context, userID, message string) (*ModerationDecision, error)
+
+	// CheckOutput checks assistant text before the user sees it.
+	// A nil decision allows it.
+	CheckOutput(ctx context.Context, userID, text string) (*ModerationDecision, error)
+}
+
+// ModerationMode controls how output moderation interacts with streaming.
+type ModerationMode int
+
+const (
+	// ModerationBuffered holds each turn's streamed text until the turn is
+	// complete and checked, then streams the checked text in one chunk.
+	// Nothing unchecked reaches the client, at the cost of live streaming.
+	ModerationBuffered ModerationMode = iota
+
+	// ModerationBestEffort streams text live and checks it afterwards.
+	// Output.Text carries the checked text, so a client that replaces the
+	// streamed text with the final message shows the moderated version,
+	// but unchecked text was briefly visible.
+	ModerationBestEffort
+)
+
+// ModerationEvent records a moderation decision that blocked or changed
+// content during a run.
+type ModerationEvent struct {
+	Stage   ModerationStage   `json:"stage"`
+	Verdict ModerationVerdict `json:"verdict"`
+	Rule    string            `json:"rule,omitempty"`
+	Reason  string            `json:"reason,omitempty"`
+}
+
+// WithModerator checks each user message and each turn's assistant text
+// with m. mode controls whether streamed text is held until checked.
+// Decisions that block or change content are reported in Output.Moderation,
+// audited, and counted in the nim_moderation_decisions expvar.
+func WithModerator(m Moderator, mode ModerationMode) Option {
+	return func(e *Engine) {
+		e.moderator = m
+		e.moderationMode = mode
+	}
+}
+
+// moderationMetrics counts decisions by stage and verdict, e.g. "input.deny",
+// published at /debug/vars.
+var moderationMetrics = expvar.NewMap("nim_moderation_decisions")
+
+// moderate checks text at stage and returns the text to use in its place.
+// The event is nil if the text was allowed unchanged.
+func (e *Engine) moderate(ctx context.Context, stage ModerationStage, userID, sessionID, text string) (string, *ModerationEvent, error) {
+	var d *ModerationDecision
+	var err error
+	if stage == ModerationInput {
+		d, err = e.moderator.CheckInput(ctx, userID, text)
+	} else {
+		d, err = e.moderator.CheckOutput(ctx, userID, text)
+	}
+	if err != nil || d == nil || d.Verdict == ModerationAllow || d.Verdict == "" {
+		return text, nil, err
+	}
+
+	event := &ModerationEvent{Stage: stage, Verdict: d.Verdict, Rule: d.Rule, Reason: d.Reason}
+	moderationMetrics.Add(string(stage)+"."+string(d.Verdict), 1)
+	if e.audit != nil {
+		details, _ := json.Marshal(event)
+		reason := d.Reason
+		e.audit.Log(ctx, &AuditEntry{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			SessionID: sessionID,
+			RequestID: sessionID,
+			ToolName:  "moderation." + string(stage),
+			ToolInput: details,
+			Error:     &reason,
+			Timestamp: time.Now().Unix(),
+		})
+	}
+
+	if d.Verdict == ModerationRewrite {
+		return d.Text, event, nil
+	}
+	return d.Reason, event, nil
+}
+
+// ModerationRule is a RuleModerator rule: content matching Pattern at Stage
+// is denied or rewritten.
+type ModerationRule struct {
+	// Name identifies the rule in decisions and audit entries.
+	Name string
+
+	Stage   ModerationStage
+	Pattern *regexp.Regexp
+
+	// Verdict is ModerationDeny or ModerationRewrite.
+	Verdict ModerationVerdict
+
+	// Replace is the replacement for each match when rewriting. It may refer
+	// to submatches, as in regexp.Regexp.ReplaceAllString.
+	Replace string
+
+	// Reason explains the decision.
+	Reason string
+}
+
+// RuleModerator moderates content with regular expressions. The first
+// matching deny rule wins; otherwise every matching rewrite rule is applied
+// in order.
+type RuleModerator struct {
+	Rules []ModerationRule
+}
+
+// NewRuleModerator creates a moderator with the given rules.
+// See DefaultModerationRules for a starting point.
+func NewRuleModerator(rules ...ModerationRule) *RuleModerator {
+	return &RuleModerator{Rules: rules}
+}
+
+// CheckInput applies the input rules.
+func (m *RuleModerator) CheckInput(ctx context.Context, userID, message string) (*ModerationDecision, error) {
+	return m.check(ModerationInput, message), nil
+}
+
+// CheckOutput applies the output rules.
+func (m *RuleModerator) CheckOutput(ctx context.Context, userID, text string) (*ModerationDecision, error) {
+	return m.check(ModerationOutput, text), nil
+}
+
+func (m *RuleModerator) check(stage ModerationStage, text string) *ModerationDecision {
+	for _, r := range m.Rules {
+		if r.Stage == stage && r.Verdict == ModerationDeny && r.Pattern.MatchString(text) {
+			return &ModerationDecision{Verdict: ModerationDeny, Reason: r.Reason, Rule: r.Name}
+		}
+	}
+
+	var applied *ModerationDecision
+	for _, r := range m.Rules {
+		if r.Stage != stage || r.Verdict != ModerationRewrite || !r.Pattern.MatchString(text) {
+			continue
+		}
+		text = r.Pattern.ReplaceAllString(text, r.Replace)
+		if applied == nil {
+			applied = &ModerationDecision{Verdict: ModerationRewrite, Reason: r.Reason, Rule: r.Name}
+		} else {
+			applied.Rule += "," + r.Name
+		}
+	}
+	if applied != nil {
+		applied.Text = text
+	}
+	return applied
+}
+
+// DefaultModerationRules returns rules for a consumer money app:
+//   - account_number: refuse messages containing a full bank account or card
+//     number, which users should never need to share; @tags identify people.
+//   - tax_advice: refuse replies that tell the user what to do about taxes.
+//   - mask_account_number: mask all but the last four digits of long numbers
+//     in replies.
+//
+// Amounts ("$1,250.00", "1250.5") and account tags ("@alice") don't match.
+func DefaultModerationRules() []ModerationRule {
+	return []ModerationRule{
+		{
+			Name:    "account_number",
+			Stage:   ModerationInput,
+			Pattern: accountNumber,
+			Verdict: ModerationDeny,
+			Reason:  "For your security, please don't share full account or card numbers here. To send money, use the recipient's @tag.",
+		},
+		{
+			Name:    "tax_advice",
+			Stage:   ModerationOutput,
+			Pattern: regexp.MustCompile(`(?i)\b(you should|you can|I recommend|I'd recommend|I suggest|consider)\b[^.!?\n]{0,80}\b(deduct\w*|write[- ]off|tax (return|filing|bracket|credit|loophole)s?|the IRS|HMRC)\b`),
+			Verdict: ModerationDeny,
+			Reason:  "I can't give tax advice. A qualified tax professional can help with questions like this.",
+		},
+		{
+			Name:    "mask_account_number",
+			Stage:   ModerationOutput,
+			Pattern: regexp.MustCompile(`\b\d{5,13}(\d{4})\b`),
+			Verdict: ModerationRewrite,
+			Replace: "••••$1",
+			Reason:  "account number masked",
+		},
+	}
+}
+
+// accountNumber matches 9-17 consecutive digits, card numbers in groups of
+// four, and IBANs.
+var accountNumber = regexp.MustCompile(`\b\d{9,17}\b|\b\d{4}(?:[ -]\d{4}){2,3}\b|\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){3,7}\b`)
+
+// Verify RuleModerator implements Moderator.
+var _ Moderator = (*RuleModerator)(nil)
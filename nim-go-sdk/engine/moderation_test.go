@@ -0,0 +1,266 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// replyServer is a mock Claude API that answers every request with reply,
+// streamed in two chunks if the request asks for streaming.
+type replyServer struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *replyServer) bodies() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func newModerationEngine(t *testing.T, reply string, opts ...Option) (*Engine, *replyServer) {
+	t.Helper()
+	rs := &replyServer{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rs.mu.Lock()
+		rs.requests = append(rs.requests, string(body))
+		rs.mu.Unlock()
+
+		if !strings.Contains(string(body), `"stream":true`) {
+			w.Header().Set("Content-Type", "application/json")
+			text, _ := json.Marshal(reply)
+			fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":[{"type":"text","text":%s}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`, text)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		event := func(name, data string) { fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data) }
+		event("message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":[],"stop_reason":null,"usage":{"input_tokens":1,"output_tokens":0}}}`)
+		event("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`)
+		half := len(reply) / 2
+		for _, chunk := range []string{reply[:half], reply[half:]} {
+			text, _ := json.Marshal(chunk)
+			event("content_block_delta", fmt.Sprintf(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":%s}}`, text))
+		}
+		event("content_block_stop", `{"type":"content_block_stop","index":0}`)
+		event("message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":1}}`)
+		event("message_stop", `{"type":"message_stop"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := anthropic.NewClient(
+		option.WithAPIKey("test"),
+		option.WithBaseURL(srv.URL),
+		option.WithMaxRetries(0),
+	)
+	return NewEngine(&client, NewToolRegistry(), opts...), rs
+}
+
+func moderationInput(message string) *Input {
+	return &Input{
+		UserMessage: message,
+		Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+	}
+}
+
+func TestModeration_Input(t *testing.T) {
+	rules := append(DefaultModerationRules(), ModerationRule{
+		Name:    "nickname",
+		Stage:   ModerationInput,
+		Pattern: regexp.MustCompile(`\bmy landlord\b`),
+		Verdict: ModerationRewrite,
+		Replace: "@landlord",
+		Reason:  "resolved nickname",
+	})
+
+	t.Run("deny", func(t *testing.T) {
+		audit := NewMemoryAuditLogger()
+		eng, rs := newModerationEngine(t, "sent", WithModerator(NewRuleModerator(rules...), ModerationBuffered), WithAudit(audit))
+
+		out, err := eng.Run(context.Background(), moderationInput("send $50 to account 12345678901"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Type != OutputComplete || !out.InputRefused() || !strings.Contains(out.Text, "@tag") {
+			t.Errorf("Run() = %+v, want a refusal", out)
+		}
+		if out.UserMessage != "" {
+			t.Errorf("UserMessage = %q, want none for a refused message", out.UserMessage)
+		}
+		if len(rs.bodies()) != 0 {
+			t.Error("refused message was sent to the model")
+		}
+		entries := audit.Entries()
+		if len(entries) != 1 || entries[0].ToolName != "moderation.input" || strings.Contains(string(entries[0].ToolInput), "12345678901") {
+			t.Errorf("audit = %+v, want one moderation entry without the content", entries)
+		}
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		eng, rs := newModerationEngine(t, "sent", WithModerator(NewRuleModerator(rules...), ModerationBuffered))
+
+		out, err := eng.Run(context.Background(), moderationInput("send $50 to my landlord"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.UserMessage != "send $50 to @landlord" || out.InputRefused() {
+			t.Errorf("UserMessage = %q, want it rewritten", out.UserMessage)
+		}
+		if bodies := rs.bodies(); len(bodies) != 1 || !strings.Contains(bodies[0], "@landlord") || strings.Contains(bodies[0], "my landlord") {
+			t.Errorf("model saw %v, want the rewritten message", bodies)
+		}
+		if len(out.Moderation) != 1 || out.Moderation[0].Rule != "nickname" {
+			t.Errorf("Moderation = %+v", out.Moderation)
+		}
+	})
+}
+
+func TestModeration_Output(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    string
+		wantText string
+		verdict  ModerationVerdict
+	}{
+		{
+			name:     "allow",
+			reply:    "You spent $1,250.00 on groceries this month.",
+			wantText: "You spent $1,250.00 on groceries this month.",
+		},
+		{
+			name:     "deny",
+			reply:    "You should deduct your home office on your tax return.",
+			wantText: "I can't give tax advice. A qualified tax professional can help with questions like this.",
+			verdict:  ModerationDeny,
+		},
+		{
+			name:     "rewrite",
+			reply:    "Your linked account 123456789012 is verified.",
+			wantText: "Your linked account ••••9012 is verified.",
+			verdict:  ModerationRewrite,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng, _ := newModerationEngine(t, tt.reply, WithModerator(NewRuleModerator(DefaultModerationRules()...), ModerationBuffered))
+
+			out, err := eng.Run(context.Background(), moderationInput("how am I doing?"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", out.Text, tt.wantText)
+			}
+			if tt.verdict == "" {
+				if len(out.Moderation) != 0 {
+					t.Errorf("Moderation = %+v, want none", out.Moderation)
+				}
+				return
+			}
+			if len(out.Moderation) != 1 || out.Moderation[0].Stage != ModerationOutput || out.Moderation[0].Verdict != tt.verdict {
+				t.Errorf("Moderation = %+v, want one output %s", out.Moderation, tt.verdict)
+			}
+		})
+	}
+}
+
+func TestModeration_Streaming(t *testing.T) {
+	const reply = "You should deduct your home office on your tax return."
+
+	tests := []struct {
+		name       string
+		mode       ModerationMode
+		wantStream string
+	}{
+		{"buffered", ModerationBuffered, "I can't give tax advice. A qualified tax professional can help with questions like this."},
+		{"best effort", ModerationBestEffort, reply},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng, _ := newModerationEngine(t, reply, WithModerator(NewRuleModerator(DefaultModerationRules()...), tt.mode))
+
+			var streamed strings.Builder
+			var chunks int
+			input := moderationInput("any tips?")
+			input.StreamCallback = func(chunk string, done bool) {
+				if !done && chunk != "" {
+					streamed.WriteString(chunk)
+					chunks++
+				}
+			}
+
+			out, err := eng.Run(context.Background(), input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if streamed.String() != tt.wantStream {
+				t.Errorf("streamed %q, want %q", streamed.String(), tt.wantStream)
+			}
+			if tt.mode == ModerationBuffered && chunks != 1 {
+				t.Errorf("streamed %d chunks, want the checked text in one", chunks)
+			}
+			if !strings.HasPrefix(out.Text, "I can't give tax advice") {
+				t.Errorf("Text = %q, want the refusal", out.Text)
+			}
+		})
+	}
+}
+
+func TestDefaultModerationRules(t *testing.T) {
+	m := NewRuleModerator(DefaultModerationRules()...)
+	ctx := context.Background()
+
+	allowedInput := []string{
+		"Send $1,250.00 to @alice",
+		"pay @bob_smith 45.50 USDC for dinner",
+		"I got paid 2500 on 2026-03-25",
+		"how much did I spend between 01/03/2026 and 31/03/2026?",
+		"my balance should be 12345.67",
+		"save 100000 by next year",
+		"call me on 555-123-4567",
+		"what happened with tx 0x3f9a1c22b7d4e5f60718293a4b5c6d7e?",
+	}
+	for _, msg := range allowedInput {
+		if d, _ := m.CheckInput(ctx, "user-1", msg); d != nil {
+			t.Errorf("CheckInput(%q) = %+v, want allowed", msg, d)
+		}
+	}
+
+	deniedInput := []string{
+		"my account number is 12345678901",
+		"card 4111 1111 1111 1111",
+		"send it to GB29 NWBK 6016 1331 9268 19",
+	}
+	for _, msg := range deniedInput {
+		if d, _ := m.CheckInput(ctx, "user-1", msg); d == nil || d.Verdict != ModerationDeny {
+			t.Errorf("CheckInput(%q) = %+v, want denied", msg, d)
+		}
+	}
+
+	allowedOutput := []string{
+		"You've spent $1,250.00 this month, 12% less than February.",
+		"Taxes are a big expense for many people. Your largest payment was $2,400.",
+		"You should consider saving 10% of each paycheck.",
+		"Sent 45.50 USDC to @bob_smith.",
+	}
+	for _, text := range allowedOutput {
+		if d, _ := m.CheckOutput(ctx, "user-1", text); d != nil {
+			t.Errorf("CheckOutput(%q) = %+v, want allowed", text, d)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// DefaultToolParallelism is how many read-only tool calls from one
+// response run at once unless WithToolParallelism says otherwise.
+const DefaultToolParallelism = 4
+
+// WithToolParallelism sets how many read-only tool calls from a single
+// model response run concurrently, e.g. get_balance and get_vault_rates
+// together. Results are still returned to the model in the order it asked
+// for them. n <= 1 runs every call serially.
+func WithToolParallelism(n int) Option {
+	return func(e *Engine) {
+		e.toolParallelism = n
+	}
+}
+
+// toolCall is the outcome of executing a tool.
+type toolCall struct {
+	result     *core.ToolResult
+	err        error
+	start      time.Time
+	durationMs int64
+}
+
+// callTool executes a tool for the session, timing it, passing it the
+// progress and message callbacks of run, the run's input.
+func (e *Engine) callTool(ctx context.Context, session *Session, tool core.Tool, input json.RawMessage, run *Input) *toolCall {
+	start := time.Now()
+	progress, message, stopListening := toolListeners(run, tool.Name())
+	result, err := e.executeTool(ctx, tool, &core.ToolParams{
+		UserID:    session.UserID,
+		Input:     input,
+		RequestID: session.ID,
+		Context:   session.agentCtx,
+		Progress:  progress,
+		Message:   message,
+	})
+	stopListening()
+	return &toolCall{
+		result:     result,
+		err:        err,
+		start:      start,
+		durationMs: time.Since(start).Milliseconds(),
+	}
+}
+
+// prefetchTools concurrently executes the read-only tool calls in a model
+// response that would run serially anyway, keyed by block index. Calls are
+// only taken up to the first tool requiring confirmation, since the turn
+// stops there, and up to budget, the tool calls the run has left. Calls
+// whose input fails validation, or that the policy denied (see decisions),
+// are skipped; a call the policy requires confirmation of stops the turn
+// like a write. Nothing is prefetched unless there are at least two calls
+// to overlap.
+func (e *Engine) prefetchTools(ctx context.Context, session *Session, blocks []anthropic.ContentBlockUnion, budget int, run *Input, decisions map[int]PolicyDecision) map[int]*toolCall {
+	parallelism := e.toolParallelism
+	if parallelism == 0 {
+		parallelism = DefaultToolParallelism
+	}
+	if parallelism <= 1 {
+		return nil
+	}
+
+	var eligible []int
+	for i, block := range blocks {
+		if block.Type != "tool_use" {
+			continue
+		}
+		tool, ok := e.registry.Get(block.Name)
+		if !ok {
+			continue
+		}
+		verdict := decisions[i].Verdict
+		if tool.RequiresConfirmation() || verdict == PolicyRequireConfirmation || len(eligible) >= budget {
+			break
+		}
+		if verdict == PolicyDeny {
+			continue
+		}
+		if input, _ := json.Marshal(block.Input); checkInput(tool, input) != nil {
+			// Never executed; Run returns the error instead.
+			continue
+		}
+		eligible = append(eligible, i)
+	}
+	if len(eligible) < 2 {
+		return nil
+	}
+
+	calls := make([]*toolCall, len(eligible))
+	var g errgroup.Group
+	g.SetLimit(parallelism)
+	for n, i := range eligible {
+		block := blocks[i]
+		tool, _ := e.registry.Get(block.Name)
+		input, _ := json.Marshal(block.Input)
+		g.Go(func() error {
+			// Tool failures are results for the model, not group errors.
+			calls[n] = e.callTool(ctx, session, tool, input, run)
+			return nil
+		})
+	}
+	g.Wait()
+
+	prefetched := make(map[int]*toolCall, len(eligible))
+	for n, i := range eligible {
+		prefetched[i] = calls[n]
+	}
+	return prefetched
+}
@@ -0,0 +1,226 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// threeToolsResponse asks for three read-only tools in one turn.
+const threeToolsResponse = `{
+	"id": "msg_1",
+	"type": "message",
+	"role": "assistant",
+	"model": "test-model",
+	"content": [
+		{"type": "text", "text": "Checking."},
+		{"type": "tool_use", "id": "toolu_1", "name": "get_balance", "input": {}},
+		{"type": "tool_use", "id": "toolu_2", "name": "get_vault_rates", "input": {}},
+		{"type": "tool_use", "id": "toolu_3", "name": "get_savings_balance", "input": {}}
+	],
+	"stop_reason": "tool_use",
+	"usage": {"input_tokens": 1, "output_tokens": 1}
+}`
+
+func TestRun_ParallelTools(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	tests := []struct {
+		name       string
+		opts       []Option
+		concurrent bool
+	}{
+		{name: "default parallelism", concurrent: true},
+		{name: "serial", opts: []Option{WithToolParallelism(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			var followUp struct {
+				Messages []struct {
+					Content []struct {
+						Type      string `json:"type"`
+						ToolUseID string `json:"tool_use_id"`
+						Content   []struct {
+							Text string `json:"text"`
+						} `json:"content"`
+					} `json:"content"`
+				} `json:"messages"`
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if calls.Add(1) == 1 {
+					w.Write([]byte(threeToolsResponse))
+					return
+				}
+				body, _ := io.ReadAll(r.Body)
+				json.Unmarshal(body, &followUp)
+				w.Write([]byte(textResponse))
+			}))
+			defer srv.Close()
+			client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+
+			registry := NewToolRegistry()
+			for _, name := range []string{"get_balance", "get_vault_rates", "get_savings_balance"} {
+				name := name
+				registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: name},
+					func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+						time.Sleep(delay)
+						return &core.ToolResult{Success: true, Data: "result of " + name}, nil
+					}))
+			}
+			audit := NewMemoryAuditLogger()
+			eng := NewEngine(&client, registry, append(tt.opts, WithAudit(audit))...)
+
+			start := time.Now()
+			out, err := eng.Run(context.Background(), &Input{UserMessage: "can I deposit?", Context: &core.Context{UserID: "user-1"}})
+			elapsed := time.Since(start)
+			if err != nil || out.Type != OutputComplete {
+				t.Fatalf("Run() = %+v, %v", out, err)
+			}
+			if tt.concurrent && elapsed >= 2*delay {
+				t.Errorf("three tools took %v, want them to overlap", elapsed)
+			}
+			if !tt.concurrent && elapsed < 3*delay {
+				t.Errorf("three tools took %v, want them one after another", elapsed)
+			}
+
+			// Results answer the blocks in the order the model asked.
+			results := followUp.Messages[len(followUp.Messages)-1].Content
+			want := []struct{ id, text string }{
+				{"toolu_1", `"result of get_balance"`},
+				{"toolu_2", `"result of get_vault_rates"`},
+				{"toolu_3", `"result of get_savings_balance"`},
+			}
+			if len(results) != len(want) {
+				t.Fatalf("tool results = %+v, want 3", results)
+			}
+			for i, w := range want {
+				if results[i].ToolUseID != w.id || len(results[i].Content) != 1 || results[i].Content[0].Text != w.text {
+					t.Errorf("tool result %d = %+v, want %s for %s", i, results[i], w.text, w.id)
+				}
+			}
+
+			// Each tool keeps its own timing.
+			if len(out.ToolsUsed) != 3 || len(audit.Entries()) != 3 {
+				t.Fatalf("ToolsUsed = %+v, audit = %d entries, want 3 each", out.ToolsUsed, len(audit.Entries()))
+			}
+			for i, used := range out.ToolsUsed {
+				entry := audit.Entries()[i]
+				if used.Tool != entry.ToolName || used.DurationMs < delay.Milliseconds() || entry.DurationMs != used.DurationMs {
+					t.Errorf("tool %d: used %s in %dms, audited %s in %dms, want at least %v each", i, used.Tool, used.DurationMs, entry.ToolName, entry.DurationMs, delay)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_ParallelToolsStopAtConfirmation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "msg_1", "type": "message", "role": "assistant", "model": "test-model",
+			"content": [
+				{"type": "tool_use", "id": "toolu_1", "name": "get_balance", "input": {}},
+				{"type": "tool_use", "id": "toolu_2", "name": "get_vault_rates", "input": {}},
+				{"type": "tool_use", "id": "toolu_3", "name": "send_money", "input": {"amount": "5"}},
+				{"type": "tool_use", "id": "toolu_4", "name": "get_savings_balance", "input": {}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 1, "output_tokens": 1}
+		}`))
+	}))
+	defer srv.Close()
+	client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+
+	var executed sync.Map
+	registry := NewToolRegistry()
+	for _, name := range []string{"get_balance", "get_vault_rates", "get_savings_balance"} {
+		name := name
+		registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: name},
+			func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+				executed.Store(name, true)
+				return &core.ToolResult{Success: true}, nil
+			}))
+	}
+	registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: "send_money", RequiresUserConfirmation: true}, nil))
+
+	eng := NewEngine(&client, registry)
+	out, err := eng.Run(context.Background(), &Input{UserMessage: "save 5", Context: &core.Context{UserID: "user-1"}})
+	if err != nil || out.Type != OutputConfirmationNeeded {
+		t.Fatalf("Run() = %+v, %v, want a confirmation", out, err)
+	}
+	for _, name := range []string{"get_balance", "get_vault_rates"} {
+		if _, ok := executed.Load(name); !ok {
+			t.Errorf("%s before the write did not run", name)
+		}
+	}
+	if _, ok := executed.Load("get_savings_balance"); ok {
+		t.Error("get_savings_balance after the write ran")
+	}
+}
+
+func TestRun_GroupsConfirmations(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "msg_1", "type": "message", "role": "assistant", "model": "test-model",
+			"content": [
+				{"type": "text", "text": "Splitting it three ways."},
+				{"type": "tool_use", "id": "toolu_1", "name": "send_money", "input": {"recipient": "@alice", "amount": "10"}},
+				{"type": "tool_use", "id": "toolu_2", "name": "get_balance", "input": {}},
+				{"type": "tool_use", "id": "toolu_3", "name": "send_money", "input": {"recipient": "@bob", "amount": "10"}},
+				{"type": "tool_use", "id": "toolu_4", "name": "send_money", "input": {"recipient": "@carol", "amount": "10"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 1, "output_tokens": 1}
+		}`))
+	}))
+	defer srv.Close()
+	client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+
+	var balanceRead atomic.Bool
+	registry := NewToolRegistry()
+	registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: "get_balance"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			balanceRead.Store(true)
+			return &core.ToolResult{Success: true}, nil
+		}))
+	registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: "send_money", RequiresUserConfirmation: true}, nil))
+
+	eng := NewEngine(&client, registry)
+	out, err := eng.Run(context.Background(), &Input{UserMessage: "split 30 with alice, bob and carol", Context: &core.Context{UserID: "user-1"}})
+	if err != nil || out.Type != OutputConfirmationNeeded {
+		t.Fatalf("Run() = %+v, %v, want a confirmation", out, err)
+	}
+	if out.PendingAction != nil || out.PendingGroup == nil {
+		t.Fatalf("PendingAction = %v, PendingGroup = %v, want a group", out.PendingAction, out.PendingGroup)
+	}
+	group := out.PendingGroup
+	var blocks []string
+	for _, action := range group.Actions {
+		blocks = append(blocks, action.BlockID)
+		if action.GroupID != group.ID {
+			t.Errorf("action %s has GroupID %q, want %q", action.BlockID, action.GroupID, group.ID)
+		}
+	}
+	if want := []string{"toolu_1", "toolu_3", "toolu_4"}; !slices.Equal(blocks, want) {
+		t.Errorf("grouped blocks %v, want %v", blocks, want)
+	}
+	if group.UserID != "user-1" || group.ExpiresAt != group.Actions[0].ExpiresAt {
+		t.Errorf("group = %+v", group)
+	}
+	if balanceRead.Load() {
+		t.Error("get_balance after the first write ran")
+	}
+}
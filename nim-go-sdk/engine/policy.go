@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/google/uuid"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/limits"
+)
+
+// ToolPolicy decides whether a tool call may go ahead, whatever the model
+// asked for. It is consulted before each tool the model calls is executed
+// or offered for confirmation, before each action a tool proposes is
+// offered, and again before a confirmed action executes. NewBasicPolicy
+// provides an implementation; applications can provide their own.
+type ToolPolicy interface {
+	// Evaluate decides on a call of toolName with input for the user. The
+	// user's limits, if the run has them, are on ctx (see
+	// core.UserLimitsFromContext).
+	Evaluate(ctx context.Context, userID, toolName string, input json.RawMessage) PolicyDecision
+}
+
+// PolicyVerdict is what to do with a tool call.
+type PolicyVerdict string
+
+// Policy verdicts.
+const (
+	PolicyAllow PolicyVerdict = "allow"
+	PolicyDeny  PolicyVerdict = "deny"
+
+	// PolicyRequireConfirmation offers the call for the user's
+	// confirmation, even if the tool would run without it.
+	PolicyRequireConfirmation PolicyVerdict = "require_confirmation"
+)
+
+// PolicyDecision is a policy's verdict on one tool call. The zero value
+// allows it.
+type PolicyDecision struct {
+	Verdict PolicyVerdict
+
+	// Reason explains the decision. For denials it is given to the model,
+	// so it should say why, for the model to explain to the user.
+	Reason string
+}
+
+// WithToolPolicy checks every tool call against p. Denied calls are not
+// executed: the model is given a *PolicyDeniedError with the reason, and
+// the denial is audited.
+func WithToolPolicy(p ToolPolicy) Option {
+	return func(e *Engine) {
+		e.policy = p
+	}
+}
+
+// PolicyDeniedError is the error answering a tool call the policy denied.
+// Nothing was executed or offered.
+type PolicyDeniedError struct {
+	Tool   string
+	Reason string
+}
+
+func (e *PolicyDeniedError) Error() string {
+	return fmt.Sprintf("%s was not allowed: %s", e.Tool, e.Reason)
+}
+
+// BasicPolicyConfig configures NewBasicPolicy.
+type BasicPolicyConfig struct {
+	// DeniedTools are never run, whatever their input.
+	DeniedTools []string
+
+	// TransferTools are the tools that move money out of the user's
+	// account, with "amount" and "currency" inputs. Transfers over the
+	// SingleTransferMax of the run's core.UserLimits, or over what is left
+	// of its DailyTransferLimit, are denied. Defaults to ["send_money"].
+	TransferTools []string
+}
+
+// BasicPolicy is a ToolPolicy with a tool denylist and transfer caps from
+// the run's core.UserLimits. Runs without user limits aren't capped.
+// Transfers still awaiting confirmation don't count against the daily
+// limit; limits.Limits with CountReserved covers that.
+type BasicPolicy struct {
+	cfg BasicPolicyConfig
+}
+
+// NewBasicPolicy creates a BasicPolicy with the given configuration.
+func NewBasicPolicy(cfg BasicPolicyConfig) *BasicPolicy {
+	if len(cfg.TransferTools) == 0 {
+		cfg.TransferTools = []string{"send_money"}
+	}
+	return &BasicPolicy{cfg: cfg}
+}
+
+// Evaluate denies calls of denied tools and transfers over the user's
+// limits, and allows everything else.
+func (p *BasicPolicy) Evaluate(ctx context.Context, userID, toolName string, input json.RawMessage) PolicyDecision {
+	if slices.Contains(p.cfg.DeniedTools, toolName) {
+		return PolicyDecision{Verdict: PolicyDeny, Reason: fmt.Sprintf("%s is disabled", toolName)}
+	}
+	if !slices.Contains(p.cfg.TransferTools, toolName) {
+		return PolicyDecision{Verdict: PolicyAllow}
+	}
+	userLimits, ok := core.UserLimitsFromContext(ctx)
+	if !ok {
+		return PolicyDecision{Verdict: PolicyAllow}
+	}
+	var transfer struct {
+		Amount   json.Number `json:"amount"`
+		Currency string      `json:"currency"`
+	}
+	if err := json.Unmarshal(input, &transfer); err != nil || transfer.Amount == "" {
+		return PolicyDecision{Verdict: PolicyDeny, Reason: "the transfer amount couldn't be read, so it can't be checked against the user's limits"}
+	}
+	check := limits.UserLimitsFunc(func(context.Context, string) (*core.UserLimits, error) {
+		return userLimits, nil
+	})
+	if err := check.CheckTransfer(ctx, userID, transfer.Amount.String(), transfer.Currency); err != nil {
+		return PolicyDecision{Verdict: PolicyDeny, Reason: err.Error()}
+	}
+	return PolicyDecision{Verdict: PolicyAllow}
+}
+
+// evaluate returns the policy's decision on a call of tool with input, or
+// PolicyAllow if there is no policy.
+func (e *Engine) evaluate(ctx context.Context, userID, tool string, input json.RawMessage) PolicyDecision {
+	if e.policy == nil {
+		return PolicyDecision{Verdict: PolicyAllow}
+	}
+	return e.policy.Evaluate(ctx, userID, tool, input)
+}
+
+// policyDecisions evaluates the tool calls in a model response that the
+// policy doesn't allow as they are, keyed by block index, before any of
+// them run. Calls of unknown tools aren't evaluated.
+func (e *Engine) policyDecisions(ctx context.Context, session *Session, blocks []anthropic.ContentBlockUnion) map[int]PolicyDecision {
+	if e.policy == nil {
+		return nil
+	}
+	decisions := make(map[int]PolicyDecision)
+	for i, block := range blocks {
+		if block.Type != "tool_use" {
+			continue
+		}
+		if _, ok := e.registry.Get(block.Name); !ok {
+			continue
+		}
+		input, _ := json.Marshal(block.Input)
+		if d := e.evaluate(ctx, session.UserID, block.Name, input); d.Verdict == PolicyDeny || d.Verdict == PolicyRequireConfirmation {
+			decisions[i] = d
+		}
+	}
+	return decisions
+}
+
+// deny audits a tool call the policy denied, described by entry, and
+// returns the error to answer it with.
+func (e *Engine) deny(ctx context.Context, entry AuditEntry, reason string) error {
+	err := &PolicyDeniedError{Tool: entry.ToolName, Reason: reason}
+	if e.audit != nil {
+		errMsg := err.Error()
+		entry.ID = uuid.New().String()
+		entry.Error = &errMsg
+		entry.Timestamp = time.Now().Unix()
+		e.audit.Log(ctx, &entry)
+	}
+	return err
+}
@@ -0,0 +1,228 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// policyFunc adapts a func to ToolPolicy.
+type policyFunc func(toolName string) PolicyDecision
+
+func (f policyFunc) Evaluate(ctx context.Context, userID, toolName string, input json.RawMessage) PolicyDecision {
+	return f(toolName)
+}
+
+// policyModel returns a mock Claude API that asks for tool with input, then
+// finishes, and a func returning the tool_result the engine answered with.
+func policyModel(t *testing.T, tool, input string) (*anthropic.Client, func() (text string, isError bool)) {
+	t.Helper()
+	var calls atomic.Int32
+	var followUp struct {
+		Messages []struct {
+			Content []struct {
+				Type    string `json:"type"`
+				IsError bool   `json:"is_error"`
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) == 1 {
+			fmt.Fprintf(w, toolInputResponse, tool, input)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &followUp)
+		w.Write([]byte(textResponse))
+	}))
+	t.Cleanup(srv.Close)
+	client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+	return &client, func() (string, bool) {
+		if len(followUp.Messages) == 0 {
+			return "", false
+		}
+		last := followUp.Messages[len(followUp.Messages)-1].Content
+		if len(last) != 1 || last[0].Type != "tool_result" || len(last[0].Content) != 1 {
+			t.Fatalf("last message = %+v, want one tool_result", last)
+		}
+		return last[0].Content[0].Text, last[0].IsError
+	}
+}
+
+func TestRun_BasicPolicy(t *testing.T) {
+	limits := &core.UserLimits{DailyTransferLimit: "1000", DailyTransferUsed: "800", SingleTransferMax: "500"}
+
+	tests := []struct {
+		name       string
+		tool       string
+		input      string
+		limits     *core.UserLimits
+		denied     []string
+		wantDenial string // empty if the call goes ahead
+	}{
+		{
+			name:   "within limits",
+			tool:   "send_money",
+			input:  `{"recipient":"@alice","amount":"150","currency":"USDC"}`,
+			limits: limits,
+		},
+		{
+			name:       "over the single transfer cap",
+			tool:       "send_money",
+			input:      `{"recipient":"@alice","amount":"50000","currency":"USDC"}`,
+			limits:     limits,
+			wantDenial: "send_money was not allowed: 50000 USDC exceeds the single transfer maximum of 500",
+		},
+		{
+			name:       "over the remaining daily allowance",
+			tool:       "send_money",
+			input:      `{"recipient":"@alice","amount":"250","currency":"USDC"}`,
+			limits:     limits,
+			wantDenial: "send_money was not allowed: 250 USDC exceeds your remaining daily allowance of 200.00",
+		},
+		{
+			name:       "numeric amount",
+			tool:       "send_money",
+			input:      `{"recipient":"@alice","amount":250,"currency":"USDC"}`,
+			limits:     limits,
+			wantDenial: "send_money was not allowed: 250 USDC exceeds your remaining daily allowance of 200.00",
+		},
+		{
+			name:  "no user limits",
+			tool:  "send_money",
+			input: `{"recipient":"@alice","amount":"50000","currency":"USDC"}`,
+		},
+		{
+			name:       "denylisted write",
+			tool:       "send_money",
+			input:      `{"recipient":"@alice","amount":"1","currency":"USDC"}`,
+			denied:     []string{"send_money"},
+			wantDenial: "send_money was not allowed: send_money is disabled",
+		},
+		{
+			name:       "denylisted read",
+			tool:       "get_balance",
+			input:      `{}`,
+			denied:     []string{"get_balance"},
+			wantDenial: "get_balance was not allowed: get_balance is disabled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, toolResult := policyModel(t, tt.tool, tt.input)
+			var balanceRead atomic.Bool
+			registry := NewToolRegistry()
+			registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: "get_balance"},
+				func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					balanceRead.Store(true)
+					return &core.ToolResult{Success: true}, nil
+				}))
+			registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: "send_money", RequiresUserConfirmation: true}, nil))
+			audit := NewMemoryAuditLogger()
+			eng := NewEngine(client, registry, WithAudit(audit), WithToolPolicy(NewBasicPolicy(BasicPolicyConfig{DeniedTools: tt.denied})))
+
+			agentCtx := core.NewContext("user-1", "sess-1", "conv-1", "req-1")
+			agentCtx.UserLimits = tt.limits
+			out, err := eng.Run(context.Background(), &Input{UserMessage: "go", Context: agentCtx})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantDenial == "" {
+				if tt.tool == "send_money" && (out.Type != OutputConfirmationNeeded || out.PendingAction == nil) {
+					t.Errorf("output = %+v, want a confirmation", out)
+				}
+				if len(audit.Entries()) != 0 {
+					t.Errorf("audited %d entries, want none", len(audit.Entries()))
+				}
+				return
+			}
+			if out.Type != OutputComplete || out.PendingAction != nil {
+				t.Errorf("output type = %v, pending action %v, want the denial returned to the model", out.Type, out.PendingAction)
+			}
+			if balanceRead.Load() {
+				t.Error("denied get_balance was executed")
+			}
+			if text, isError := toolResult(); text != tt.wantDenial || !isError {
+				t.Errorf("tool result = %q (error %v), want error %q", text, isError, tt.wantDenial)
+			}
+			entries := audit.Entries()
+			if len(entries) != 1 || entries[0].ToolName != tt.tool || entries[0].Error == nil || *entries[0].Error != tt.wantDenial {
+				t.Fatalf("audit entries = %+v, want the denial", entries)
+			}
+			if e := entries[0]; e.UserID != "user-1" || e.RequestID != "req-1" || e.IsWriteOp != (tt.tool == "send_money") {
+				t.Errorf("audit entry = %+v", e)
+			}
+		})
+	}
+}
+
+func TestRun_PolicyRequiresConfirmation(t *testing.T) {
+	client, _ := policyModel(t, "export_data", `{}`)
+	var exported atomic.Bool
+	registry := NewToolRegistry()
+	registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: "export_data"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			exported.Store(true)
+			return &core.ToolResult{Success: true}, nil
+		}))
+	policy := policyFunc(func(toolName string) PolicyDecision {
+		return PolicyDecision{Verdict: PolicyRequireConfirmation}
+	})
+	eng := NewEngine(client, registry, WithToolPolicy(policy))
+
+	out, err := eng.Run(context.Background(), &Input{UserMessage: "export", Context: core.NewContext("user-1", "sess-1", "conv-1", "req-1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Type != OutputConfirmationNeeded || out.PendingAction == nil || out.PendingAction.Tool != "export_data" {
+		t.Fatalf("output = %+v, want export_data offered for confirmation", out)
+	}
+	if exported.Load() {
+		t.Error("export_data ran before it was confirmed")
+	}
+}
+
+func TestExecuteAction_PolicyDenied(t *testing.T) {
+	var sent atomic.Bool
+	registry := NewToolRegistry()
+	registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: "send_money", RequiresUserConfirmation: true},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			sent.Store(true)
+			return &core.ToolResult{Success: true}, nil
+		}))
+	audit := NewMemoryAuditLogger()
+	eng := NewEngine(nil, registry, WithAudit(audit), WithToolPolicy(NewBasicPolicy(BasicPolicyConfig{})))
+
+	// The user raised the amount past their cap before confirming.
+	action := &core.PendingAction{ID: "action-1", UserID: "user-1", Tool: "send_money"}
+	if err := action.SetInput(json.RawMessage(`{"recipient":"@alice","amount":"900","currency":"USDC"}`)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := core.WithUserLimits(context.Background(), &core.UserLimits{SingleTransferMax: "500"})
+	_, err := eng.ExecuteAction(ctx, action)
+	var denied *PolicyDeniedError
+	if !errors.As(err, &denied) || denied.Tool != "send_money" {
+		t.Fatalf("ExecuteAction error = %v, want a *PolicyDeniedError", err)
+	}
+	if sent.Load() {
+		t.Error("send_money executed despite the denial")
+	}
+	if entries := audit.Entries(); len(entries) != 1 || entries[0].RequestID != "action-1" || !entries[0].IsWriteOp {
+		t.Errorf("audit entries = %+v, want the denial", entries)
+	}
+}
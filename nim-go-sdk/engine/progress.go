@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// toolListeners adapts the run's progress and message callbacks for one
+// tool call. Reports and messages are serialized, so a tool may send them
+// from its own goroutines, and stop ends them: anything sent after the tool
+// returns is dropped rather than arriving after its result. Returns nil
+// funcs for the callbacks run doesn't set.
+func toolListeners(run *Input, tool string) (core.ProgressFunc, core.MessageFunc, func()) {
+	if run.ProgressCallback == nil && run.ToolMessageCallback == nil {
+		return nil, nil, func() {}
+	}
+	var mu sync.Mutex
+	stopped := false
+	send := func(f func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !stopped {
+			f()
+		}
+	}
+
+	var progress core.ProgressFunc
+	if callback := run.ProgressCallback; callback != nil {
+		progress = func(stage string, percent float64) {
+			send(func() { callback(tool, stage, percent) })
+		}
+	}
+	var message core.MessageFunc
+	if callback := run.ToolMessageCallback; callback != nil {
+		message = func(text string) {
+			send(func() { callback(tool, text) })
+		}
+	}
+	stop := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+	}
+	return progress, message, stop
+}
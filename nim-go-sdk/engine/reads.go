@@ -0,0 +1,228 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/jsondiff"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+	"github.com/google/uuid"
+)
+
+// ReadResult is the full result of a read tool call, as sent to the model or
+// kept for recall.
+type ReadResult struct {
+	// ID identifies the result for recall_tool_result.
+	ID string `json:"id"`
+
+	// Key identifies the call: the tool name and canonicalized input.
+	Key string `json:"key"`
+
+	// Tool is the name of the read tool that ran.
+	Tool string `json:"tool"`
+
+	// Data is the tool's result data as JSON.
+	Data json.RawMessage `json:"data"`
+
+	// FetchedAt is when the tool ran.
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// ReadCache remembers the latest result of each differential read per
+// conversation, so a repeated call can be sent as the changes since the last.
+// This is an interface - implementations (e.g., Redis-backed) are provided
+// by the consuming application.
+type ReadCache interface {
+	// Store saves result as the latest for its key, replacing any earlier one.
+	Store(ctx context.Context, conversationID string, result *ReadResult) error
+
+	// Latest returns the most recent result stored under key.
+	// Returns nil, nil if there is none.
+	Latest(ctx context.Context, conversationID, key string) (*ReadResult, error)
+
+	// Get returns the result with the given ID.
+	// Returns nil, nil if there is none or it has been replaced.
+	Get(ctx context.Context, conversationID, id string) (*ReadResult, error)
+}
+
+// WithReadCache sends repeated calls to differential read tools (see
+// core.DifferentialTool) as the changes since the previous call, using cache
+// to remember results per conversation. Register RecallTool so the model can
+// fetch the full result when it needs it.
+func WithReadCache(cache ReadCache) Option {
+	return func(e *Engine) {
+		e.reads = cache
+	}
+}
+
+// differentialResult is sent to the model instead of a repeated read's result.
+type differentialResult struct {
+	Summary  string            `json:"summary"`
+	Changes  []jsondiff.Change `json:"changes"`
+	Since    time.Time         `json:"since"`
+	ResultID string            `json:"result_id"`
+	Note     string            `json:"note"`
+}
+
+// toolResultContent returns the content to send the model for a successful
+// read: the full result, or for a repeated differential read, the changes
+// since the previous call when that is smaller. enveloped says the result is
+// a core.Envelope, whose tool declares diff keys relative to its data.
+func (e *Engine) toolResultContent(ctx context.Context, tool core.Tool, input json.RawMessage, full []byte, enveloped bool) string {
+	if e.reads == nil || tool.RequiresConfirmation() {
+		return string(full)
+	}
+	d, ok := tool.(core.DifferentialTool)
+	if !ok || d.DiffKeys() == nil {
+		return string(full)
+	}
+	identity, ok := core.IdentityFromContext(ctx)
+	if !ok || identity.ConversationID == "" {
+		return string(full)
+	}
+
+	key := readKey(tool.Name(), input)
+	prev, err := e.reads.Latest(ctx, identity.ConversationID, key)
+	if err != nil {
+		prev = nil
+	}
+	current := &ReadResult{
+		ID:        uuid.New().String(),
+		Key:       key,
+		Tool:      tool.Name(),
+		Data:      full,
+		FetchedAt: time.Now(),
+	}
+	if err := e.reads.Store(ctx, identity.ConversationID, current); err != nil || prev == nil {
+		return string(full)
+	}
+
+	var before, after interface{}
+	if json.Unmarshal(prev.Data, &before) != nil || json.Unmarshal(full, &after) != nil {
+		return string(full)
+	}
+	keys := d.DiffKeys()
+	if enveloped {
+		keys = envelopeKeys(keys)
+	}
+	diff := jsondiff.Diff(before, after, keys)
+	compact, err := json.Marshal(differentialResult{
+		Summary:  fmt.Sprintf("%s since the last %s call", diff.Summary(), tool.Name()),
+		Changes:  diff.Changes,
+		Since:    prev.FetchedAt,
+		ResultID: current.ID,
+		Note:     "Only the changes since your previous identical call are shown; everything else is as it was. Call recall_tool_result with result_id if you need the full result.",
+	})
+	if err != nil || len(compact) >= len(full) {
+		return string(full)
+	}
+	return string(compact)
+}
+
+// envelopeKeys re-roots diff keys declared relative to an envelope's data.
+func envelopeKeys(keys map[string]string) jsondiff.Keys {
+	rooted := make(jsondiff.Keys, len(keys))
+	for path, key := range keys {
+		if path == "" {
+			rooted["data"] = key
+		} else {
+			rooted["data."+path] = key
+		}
+	}
+	return rooted
+}
+
+// readKey identifies a read by tool name and canonicalized input.
+func readKey(tool string, input json.RawMessage) string {
+	var parsed interface{}
+	if err := json.Unmarshal(input, &parsed); err != nil {
+		parsed = string(input)
+	}
+	canonical, _ := json.Marshal(parsed)
+
+	hash := sha256.Sum256([]byte(tool + ":" + string(canonical)))
+	return hex.EncodeToString(hash[:])
+}
+
+// RecallTool returns the recall_tool_result tool, which returns the full
+// result behind a differential read from cache.
+func RecallTool(cache ReadCache) core.Tool {
+	return tools.New("recall_tool_result").
+		Description("Get the full result of an earlier tool call that was shown only as changes. Pass the result_id from that result.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"result_id": tools.StringProperty("The result_id from a tool result that showed only changes"),
+		}, "result_id")).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				ResultID string `json:"result_id"`
+			}
+			if err := json.Unmarshal(params.Input, &input); err != nil || input.ResultID == "" {
+				return &core.ToolResult{Success: false, Error: "result_id is required"}, nil
+			}
+			identity, _ := core.IdentityFromContext(ctx)
+			if identity.ConversationID == "" {
+				return &core.ToolResult{Success: false, Error: "recall is only available within a conversation"}, nil
+			}
+
+			result, err := cache.Get(ctx, identity.ConversationID, input.ResultID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to load result: %v", err)}, nil
+			}
+			if result == nil {
+				return &core.ToolResult{Success: false, Error: "that result is no longer available; call the original tool again"}, nil
+			}
+			return &core.ToolResult{Success: true, Data: result.Data}, nil
+		}).
+		Build()
+}
+
+// MemoryReadCache is an in-memory implementation of ReadCache.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryReadCache struct {
+	mu      sync.RWMutex
+	results map[string]map[string]*ReadResult // conversationID -> key -> latest result
+}
+
+// NewMemoryReadCache creates an in-memory read cache.
+func NewMemoryReadCache() *MemoryReadCache {
+	return &MemoryReadCache{
+		results: make(map[string]map[string]*ReadResult),
+	}
+}
+
+func (m *MemoryReadCache) Store(ctx context.Context, conversationID string, result *ReadResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.results[conversationID] == nil {
+		m.results[conversationID] = make(map[string]*ReadResult)
+	}
+	m.results[conversationID][result.Key] = result
+	return nil
+}
+
+func (m *MemoryReadCache) Latest(ctx context.Context, conversationID, key string) (*ReadResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.results[conversationID][key], nil
+}
+
+func (m *MemoryReadCache) Get(ctx context.Context, conversationID, id string) (*ReadResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, result := range m.results[conversationID] {
+		if result.ID == id {
+			return result, nil
+		}
+	}
+	return nil, nil
+}
+
+// Verify MemoryReadCache implements ReadCache.
+var _ ReadCache = (*MemoryReadCache)(nil)
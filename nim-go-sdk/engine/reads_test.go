@@ -0,0 +1,296 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// readModel is a mock Claude API that calls one tool per Run, with the given
+// input, and records the tool result content it is sent back.
+type readModel struct {
+	mu      sync.Mutex
+	results []string
+}
+
+func (m *readModel) serve(t *testing.T, toolName, input string) *anthropic.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content []struct {
+					Type    string `json:"type"`
+					Content []struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		last := req.Messages[len(req.Messages)-1]
+		if len(last.Content) == 0 || last.Content[0].Type != "tool_result" {
+			fmt.Fprint(w, strings.Replace(fmt.Sprintf(toolUseResponse, toolName), `"input": {}`, `"input": `+input, 1))
+			return
+		}
+		m.mu.Lock()
+		m.results = append(m.results, last.Content[0].Content[0].Text)
+		m.mu.Unlock()
+		w.Write([]byte(textResponse))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := anthropic.NewClient(
+		option.WithAPIKey("test"),
+		option.WithBaseURL(srv.URL),
+		option.WithMaxRetries(0),
+	)
+	return &client
+}
+
+func (m *readModel) last() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.results[len(m.results)-1]
+}
+
+// transactionsPayload returns a realistic get_transactions response of n
+// transactions, newest first, starting at ID first.
+func transactionsPayload(first, n int) map[string]interface{} {
+	counterparties := []string{"@alice", "@bob", "Coffee Corner", "City Transit", "Grocer & Co"}
+	txs := make([]interface{}, 0, n)
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := first + n - 1; i >= first; i-- {
+		direction := "debit"
+		if i%4 == 0 {
+			direction = "credit"
+		}
+		txs = append(txs, map[string]interface{}{
+			"id":           fmt.Sprintf("tx_%04d", i),
+			"type":         "send",
+			"amount":       fmt.Sprintf("%d.%02d", 5+i%40, i%100),
+			"currency":     "USDC",
+			"usdValue":     fmt.Sprintf("%d.%02d", 5+i%40, i%100),
+			"counterparty": counterparties[i%len(counterparties)],
+			"note":         fmt.Sprintf("payment %d", i),
+			"status":       "completed",
+			"direction":    direction,
+			"createdAt":    start.Add(time.Duration(i) * time.Hour).Format(time.RFC3339),
+			"txHash":       fmt.Sprintf("0x%064x", i*7919),
+		})
+	}
+	return map[string]interface{}{"transactions": txs}
+}
+
+func TestRun_DifferentialRead(t *testing.T) {
+	var payload map[string]interface{}
+	getTransactions := tools.New("get_transactions").
+		Differential(map[string]string{"transactions": "id"}).
+		HandlerFunc(func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+			return payload, nil
+		}).
+		Build()
+
+	cache := NewMemoryReadCache()
+	model := &readModel{}
+	registry := NewToolRegistry()
+	registry.RegisterAll(getTransactions, RecallTool(cache))
+	eng := NewEngine(model.serve(t, "get_transactions", `{"limit":50}`), registry, WithReadCache(cache))
+
+	run := func() string {
+		t.Helper()
+		if _, err := eng.Run(context.Background(), &Input{
+			UserMessage: "show my transactions",
+			Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+		}); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		return model.last()
+	}
+
+	// The first read is sent in full.
+	payload = transactionsPayload(1, 50)
+	full := run()
+	if !strings.Contains(full, "tx_0001") {
+		t.Fatalf("first read = %.100s..., want the full payload", full)
+	}
+
+	// Two new transactions push the two oldest out of the window.
+	payload = transactionsPayload(3, 50)
+	second := run()
+	var diff differentialResult
+	if err := json.Unmarshal([]byte(second), &diff); err != nil {
+		t.Fatalf("second read is not a differential result: %v\n%s", err, second)
+	}
+	if want := "2 new transactions, 2 removed from transactions since the last get_transactions call"; diff.Summary != want {
+		t.Errorf("Summary = %q, want %q", diff.Summary, want)
+	}
+	if len(diff.Changes) != 4 {
+		t.Errorf("got %d changes, want 4", len(diff.Changes))
+	}
+	fullAgain, _ := json.Marshal(payload)
+	t.Logf("full result %d bytes, differential %d bytes", len(fullAgain), len(second))
+	if len(second)*5 > len(fullAgain) {
+		t.Errorf("differential result is %d bytes, want under a fifth of the %d byte full result", len(second), len(fullAgain))
+	}
+
+	// Reading again with nothing new says so.
+	if err := json.Unmarshal([]byte(run()), &diff); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(diff.Summary, "no changes") || len(diff.Changes) != 0 {
+		t.Errorf("unchanged read = %q with %d changes, want no changes", diff.Summary, len(diff.Changes))
+	}
+
+	// The full result stays available for recall.
+	ctx := core.WithIdentity(context.Background(), core.Identity{UserID: "user-1", ConversationID: "conv-1"})
+	recalled, err := RecallTool(cache).Execute(ctx, &core.ToolParams{
+		UserID: "user-1",
+		Input:  json.RawMessage(fmt.Sprintf(`{"result_id":%q}`, diff.ResultID)),
+	})
+	if err != nil || !recalled.Success {
+		t.Fatalf("recall = %+v, %v", recalled, err)
+	}
+	if got, _ := json.Marshal(recalled.Data); string(got) != string(fullAgain) {
+		t.Errorf("recalled %.100s..., want the full latest result", got)
+	}
+}
+
+func TestRun_DifferentialReadEnveloped(t *testing.T) {
+	var payload map[string]interface{}
+	getTransactions := tools.New("get_transactions").
+		Differential(map[string]string{"transactions": "id"}).
+		ReturnsEnvelope().
+		HandlerFunc(func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+			return payload, nil
+		}).
+		Build()
+
+	cache := NewMemoryReadCache()
+	model := &readModel{}
+	registry := NewToolRegistry()
+	registry.RegisterAll(getTransactions, RecallTool(cache))
+	eng := NewEngine(model.serve(t, "get_transactions", `{"limit":50}`), registry, WithReadCache(cache))
+
+	run := func() string {
+		t.Helper()
+		if _, err := eng.Run(context.Background(), &Input{
+			UserMessage: "show my transactions",
+			Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+		}); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		return model.last()
+	}
+
+	payload = transactionsPayload(1, 50)
+	var env core.Envelope
+	if err := json.Unmarshal([]byte(run()), &env); err != nil || env.Status != core.StatusOK {
+		t.Fatalf("first read = %+v, %v, want the envelope", env, err)
+	}
+
+	// Diff keys are relative to the envelope's data, so transactions are
+	// still matched by ID.
+	payload = transactionsPayload(3, 50)
+	var diff differentialResult
+	if err := json.Unmarshal([]byte(run()), &diff); err != nil {
+		t.Fatalf("second read is not a differential result: %v", err)
+	}
+	if len(diff.Changes) != 4 || !strings.HasPrefix(diff.Changes[0].Path, "data.transactions[tx_") {
+		t.Errorf("diff = %q with changes %+v, want 2 added and 2 removed by ID", diff.Summary, diff.Changes)
+	}
+}
+
+func TestRun_DifferentialReadSkipped(t *testing.T) {
+	payload := transactionsPayload(1, 20)
+	plain := tools.New("get_transactions").
+		HandlerFunc(func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+			return payload, nil
+		}).
+		Build()
+	differential := tools.New("get_transactions").
+		Differential(map[string]string{"transactions": "id"}).
+		HandlerFunc(func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+			return payload, nil
+		}).
+		Build()
+
+	tests := []struct {
+		name  string
+		tool  core.Tool
+		cache ReadCache
+	}{
+		{name: "tool not differential", tool: plain, cache: NewMemoryReadCache()},
+		{name: "no read cache", tool: differential},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &readModel{}
+			registry := NewToolRegistry()
+			registry.Register(tt.tool)
+			var opts []Option
+			if tt.cache != nil {
+				opts = append(opts, WithReadCache(tt.cache))
+			}
+			eng := NewEngine(model.serve(t, "get_transactions", `{}`), registry, opts...)
+
+			want, _ := json.Marshal(payload)
+			for i := 0; i < 2; i++ {
+				if _, err := eng.Run(context.Background(), &Input{
+					UserMessage: "show my transactions",
+					Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+				}); err != nil {
+					t.Fatal(err)
+				}
+				if got := model.last(); got != string(want) {
+					t.Errorf("read %d = %.100s..., want the full payload", i+1, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRecallTool_Refusals(t *testing.T) {
+	cache := NewMemoryReadCache()
+	cache.Store(context.Background(), "conv-1", &ReadResult{ID: "r-1", Key: "k", Data: json.RawMessage(`{}`)})
+	recall := RecallTool(cache)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		input   string
+		wantErr string
+	}{
+		{name: "missing id", ctx: conversationContext(), input: `{}`, wantErr: "result_id is required"},
+		{name: "no conversation", ctx: context.Background(), input: `{"result_id":"r-1"}`, wantErr: "only available within a conversation"},
+		{name: "other conversation", ctx: core.WithIdentity(context.Background(), core.Identity{ConversationID: "conv-2"}), input: `{"result_id":"r-1"}`, wantErr: "no longer available"},
+		{name: "unknown id", ctx: conversationContext(), input: `{"result_id":"r-2"}`, wantErr: "no longer available"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := recall.Execute(tt.ctx, &core.ToolParams{UserID: "user-1", Input: json.RawMessage(tt.input)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Success || !strings.Contains(result.Error, tt.wantErr) {
+				t.Errorf("result = %+v, want error containing %q", result, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,65 +2,162 @@
 package engine
 
 import (
+	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/becomeliminal/nim-go-sdk/core"
 )
 
+// ErrDuplicateTool is returned when registering a tool under a name that is
+// already taken. Use RegisterOverride to replace a tool on purpose.
+var ErrDuplicateTool = errors.New("tool already registered")
+
+// NamespaceSeparator joins a group's namespace and a tool's name in the
+// qualified names the model sees, e.g. "liminal__get_balance". The API
+// doesn't allow dots in tool names.
+const NamespaceSeparator = "__"
+
 // ToolRegistry manages available tools for an agent.
 type ToolRegistry struct {
-	mu    sync.RWMutex
-	tools map[string]core.Tool
+	mu         sync.RWMutex
+	tools      map[string]core.Tool
+	namespaces map[string]string // tool name -> namespace, for grouped tools
+	qualified  map[string]string // qualified name -> tool name
+	qualify    bool
 }
 
 // NewToolRegistry creates a new tool registry.
 func NewToolRegistry() *ToolRegistry {
 	return &ToolRegistry{
-		tools: make(map[string]core.Tool),
+		tools:      make(map[string]core.Tool),
+		namespaces: make(map[string]string),
+		qualified:  make(map[string]string),
 	}
 }
 
-// Register adds a tool to the registry.
-func (r *ToolRegistry) Register(tool core.Tool) {
+// Register adds a tool to the registry. Tools that fail validation (see
+// core.Validator), such as those with an invalid summary template, and
+// tools whose name is already registered (ErrDuplicateTool) are rejected
+// with an error.
+func (r *ToolRegistry) Register(tool core.Tool) error {
+	return r.register("", tool, false)
+}
+
+// RegisterOverride adds a tool to the registry, replacing any tool already
+// registered under its name.
+func (r *ToolRegistry) RegisterOverride(tool core.Tool) error {
+	return r.register("", tool, true)
+}
+
+// RegisterAll adds multiple tools to the registry. Tools that fail
+// validation or are duplicates are skipped; the errors are joined.
+func (r *ToolRegistry) RegisterAll(tools ...core.Tool) error {
+	return r.RegisterGroup("", tools...)
+}
+
+// RegisterGroup adds tools to the registry in a namespace, such as
+// "liminal". Tools are known by their own names unless QualifyNames is
+// on, so names must still be unique across namespaces. Tools that fail
+// validation or are duplicates are skipped; the errors are joined.
+func (r *ToolRegistry) RegisterGroup(namespace string, tools ...core.Tool) error {
+	var errs []error
+	for _, tool := range tools {
+		if err := r.register(namespace, tool, false); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// QualifyNames sets whether the model sees grouped tools by their
+// qualified names, e.g. "liminal__get_balance" rather than "get_balance".
+// Tools can be looked up by either name.
+func (r *ToolRegistry) QualifyNames(qualify bool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.tools[tool.Name()] = tool
+	r.qualify = qualify
 }
 
-// RegisterAll adds multiple tools to the registry.
-func (r *ToolRegistry) RegisterAll(tools ...core.Tool) {
-	for _, tool := range tools {
-		r.Register(tool)
+// QualifiedName returns the name a tool in a namespace is advertised
+// under when names are qualified.
+func QualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + NamespaceSeparator + name
+}
+
+func (r *ToolRegistry) register(namespace string, tool core.Tool, override bool) error {
+	if v, ok := tool.(core.Validator); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("tool %s: %w", tool.Name(), err)
+		}
+	}
+
+	name := tool.Name()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !override {
+		if _, ok := r.tools[name]; ok {
+			return fmt.Errorf("tool %s: %w", name, ErrDuplicateTool)
+		}
+		if _, ok := r.qualified[name]; ok {
+			return fmt.Errorf("tool %s: %w", name, ErrDuplicateTool)
+		}
+	}
+
+	if old, ok := r.namespaces[name]; ok {
+		delete(r.qualified, QualifiedName(old, name))
+		delete(r.namespaces, name)
 	}
+	r.tools[name] = tool
+	if namespace != "" {
+		r.namespaces[name] = namespace
+		r.qualified[QualifiedName(namespace, name)] = name
+	}
+	return nil
 }
 
-// Get retrieves a tool by name.
+// Get retrieves a tool by name, or by its qualified name if it is in a
+// namespace.
 func (r *ToolRegistry) Get(name string) (core.Tool, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	tool, ok := r.tools[name]
+	if tool, ok := r.tools[name]; ok {
+		return tool, true
+	}
+	tool, ok := r.tools[r.qualified[name]]
 	return tool, ok
 }
 
-// List returns all registered tool names.
+// List returns all registered tool names, as the model sees them.
 func (r *ToolRegistry) List() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	names := make([]string, 0, len(r.tools))
 	for name := range r.tools {
-		names = append(names, name)
+		names = append(names, r.advertisedName(name))
 	}
 	return names
 }
 
 // ToAPITools converts registered tools to Claude API format.
 func (r *ToolRegistry) ToAPITools() []anthropic.ToolUnionParam {
+	return r.ToAPIToolsFiltered(func(core.Tool) bool { return true })
+}
+
+// ToAPIToolsFiltered returns tools matching the filter.
+func (r *ToolRegistry) ToAPIToolsFiltered(filter func(core.Tool) bool) []anthropic.ToolUnionParam {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	tools := make([]anthropic.ToolUnionParam, 0, len(r.tools))
-	for _, tool := range r.tools {
+	var tools []anthropic.ToolUnionParam
+	for name, tool := range r.tools {
+		if !filter(tool) {
+			continue
+		}
 		schema := tool.Schema()
 		properties, _ := schema["properties"].(map[string]interface{})
 		required := []string{}
@@ -74,7 +171,7 @@ func (r *ToolRegistry) ToAPITools() []anthropic.ToolUnionParam {
 
 		tools = append(tools, anthropic.ToolUnionParam{
 			OfTool: &anthropic.ToolParam{
-				Name:        tool.Name(),
+				Name:        r.advertisedName(name),
 				Description: anthropic.String(tool.Description()),
 				InputSchema: anthropic.ToolInputSchemaParam{
 					Properties: properties,
@@ -86,38 +183,13 @@ func (r *ToolRegistry) ToAPITools() []anthropic.ToolUnionParam {
 	return tools
 }
 
-// ToAPIToolsFiltered returns tools matching the filter.
-func (r *ToolRegistry) ToAPIToolsFiltered(filter func(core.Tool) bool) []anthropic.ToolUnionParam {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	var tools []anthropic.ToolUnionParam
-	for _, tool := range r.tools {
-		if filter(tool) {
-			schema := tool.Schema()
-			properties, _ := schema["properties"].(map[string]interface{})
-			required := []string{}
-			if reqField, ok := schema["required"].([]interface{}); ok {
-				for _, r := range reqField {
-					if str, ok := r.(string); ok {
-						required = append(required, str)
-					}
-				}
-			}
-
-			tools = append(tools, anthropic.ToolUnionParam{
-				OfTool: &anthropic.ToolParam{
-					Name:        tool.Name(),
-					Description: anthropic.String(tool.Description()),
-					InputSchema: anthropic.ToolInputSchemaParam{
-						Properties: properties,
-						Required:   required,
-					},
-				},
-			})
-		}
+// advertisedName returns the name the model sees a tool by. The caller
+// holds r.mu.
+func (r *ToolRegistry) advertisedName(name string) string {
+	if !r.qualify {
+		return name
 	}
-	return tools
+	return QualifiedName(r.namespaces[name], name)
 }
 
 // FilterByNames returns a filter that matches tools by name.
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func TestToolRegistry_RejectsInvalidSummaryTemplate(t *testing.T) {
+	valid := tools.New("send_money").RequiresConfirmation().SummaryTemplate("Send {{.amount}}").Build()
+	invalid := tools.New("deposit_savings").RequiresConfirmation().SummaryTemplate("Deposit {{.amount}").Build()
+	unbounded := tools.New("withdraw_savings").RequiresConfirmation().SummaryTemplate("{{range 1000000000}}{{end}}").Build()
+
+	registry := NewToolRegistry()
+	err := registry.RegisterAll(valid, invalid, unbounded)
+	if err == nil || !strings.Contains(err.Error(), "deposit_savings") || !strings.Contains(err.Error(), "withdraw_savings") {
+		t.Fatalf("RegisterAll() error = %v, want errors for both invalid tools", err)
+	}
+	if _, ok := registry.Get("send_money"); !ok {
+		t.Error("valid tool was not registered")
+	}
+	for _, name := range []string{"deposit_savings", "withdraw_savings"} {
+		if _, ok := registry.Get(name); ok {
+			t.Errorf("%s was registered despite its invalid template", name)
+		}
+	}
+}
+
+func TestToolRegistry_Duplicates(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(r *ToolRegistry, tool core.Tool) error
+		wantErr  bool
+	}{
+		{name: "Register", register: (*ToolRegistry).Register, wantErr: true},
+		{name: "RegisterAll", register: func(r *ToolRegistry, tool core.Tool) error { return r.RegisterAll(tool) }, wantErr: true},
+		{name: "RegisterGroup", register: func(r *ToolRegistry, tool core.Tool) error { return r.RegisterGroup("custom", tool) }, wantErr: true},
+		{name: "RegisterOverride", register: (*ToolRegistry).RegisterOverride},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tools.New("get_transactions").Description("Liminal").Build()
+			custom := tools.New("get_transactions").Description("custom").Build()
+
+			registry := NewToolRegistry()
+			if err := registry.RegisterGroup("liminal", original); err != nil {
+				t.Fatal(err)
+			}
+			err := tt.register(registry, custom)
+			if gotErr := errors.Is(err, ErrDuplicateTool); gotErr != tt.wantErr {
+				t.Fatalf("registering a duplicate = %v, want ErrDuplicateTool: %v", err, tt.wantErr)
+			}
+
+			want := "custom"
+			if tt.wantErr {
+				want = "Liminal"
+			}
+			if tool, _ := registry.Get("get_transactions"); tool.Description() != want {
+				t.Errorf("registered tool = %q, want %q", tool.Description(), want)
+			}
+			if registry.Count() != 1 {
+				t.Errorf("Count() = %d, want 1", registry.Count())
+			}
+		})
+	}
+}
+
+func TestToolRegistry_Groups(t *testing.T) {
+	registry := NewToolRegistry()
+	if err := registry.RegisterGroup("liminal", tools.New("get_balance").Build()); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(tools.New("think").Build()); err != nil {
+		t.Fatal(err)
+	}
+
+	apiNames := func() []string {
+		var names []string
+		for _, tool := range registry.ToAPITools() {
+			names = append(names, tool.OfTool.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	// By default tools are known by their own names.
+	if got := apiNames(); strings.Join(got, ",") != "get_balance,think" {
+		t.Errorf("API tools = %v, want unqualified names", got)
+	}
+
+	registry.QualifyNames(true)
+	if got := apiNames(); strings.Join(got, ",") != "liminal__get_balance,think" {
+		t.Errorf("API tools = %v, want the grouped tool qualified", got)
+	}
+	for _, name := range []string{"get_balance", "liminal__get_balance"} {
+		if tool, ok := registry.Get(name); !ok || tool.Name() != "get_balance" {
+			t.Errorf("Get(%q) = %v, %v, want get_balance", name, tool, ok)
+		}
+	}
+
+	// Replacing a grouped tool outside its group drops its namespace.
+	if err := registry.RegisterOverride(tools.New("get_balance").Build()); err != nil {
+		t.Fatal(err)
+	}
+	if got := apiNames(); strings.Join(got, ",") != "get_balance,think" {
+		t.Errorf("API tools after override = %v", got)
+	}
+	if _, ok := registry.Get("liminal__get_balance"); ok {
+		t.Error("the replaced tool is still found by its qualified name")
+	}
+}
@@ -0,0 +1,350 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// Exchange is one model call: the request the engine sent and the response
+// it got back. WithRecorder writes one per line; Replay serves them back.
+type Exchange struct {
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response"`
+}
+
+// WithRecorder writes every model call the engine makes to w as JSON
+// lines of Exchange, for Replay to serve back later. Streamed responses are
+// recorded once complete. Writes are serialized, so w may be shared by
+// concurrent runs, though a recording is only replayable in call order.
+func WithRecorder(w io.Writer) Option {
+	return func(e *Engine) {
+		e.recorder = &recorder{w: w}
+	}
+}
+
+type recorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// record writes the exchange, logging rather than failing the run if it
+// can't: a recording is a debugging aid.
+func (r *recorder) record(params anthropic.MessageNewParams, resp *anthropic.Message) {
+	request, err := json.Marshal(params)
+	if err != nil {
+		log.Printf("Failed to record model request: %v", err)
+		return
+	}
+	response := json.RawMessage(resp.RawJSON())
+	if len(response) == 0 {
+		if response, err = json.Marshal(resp); err != nil {
+			log.Printf("Failed to record model response: %v", err)
+			return
+		}
+	}
+	line, err := json.Marshal(Exchange{Request: request, Response: response})
+	if err != nil {
+		log.Printf("Failed to record model call: %v", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		log.Printf("Failed to record model call: %v", err)
+	}
+}
+
+// Replay is a stand-in for the Anthropic API that answers with a recording
+// made by WithRecorder, so a run can be repeated without network access.
+// Requests must arrive in the recorded order and match the recorded ones
+// exactly; the first that doesn't is refused with a diff of the two, which
+// the run reports as its error, and is kept for Err.
+//
+// Replay is an http.Handler, to serve from an httptest.Server as a base
+// URL, or Client returns an Anthropic client that calls it in process.
+// Tools still run for real, so for an identical Output they must return
+// what they did when the recording was made.
+type Replay struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+	next      int
+	err       error
+}
+
+// NewReplay reads a recording written by WithRecorder.
+func NewReplay(r io.Reader) (*Replay, error) {
+	replay := &Replay{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var ex Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &ex); err != nil {
+			return nil, fmt.Errorf("failed to parse recording line %d: %w", line, err)
+		}
+		replay.exchanges = append(replay.exchanges, ex)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return replay, nil
+}
+
+// Client returns an Anthropic client that is answered by r, without
+// opening a connection. opts are applied after r's own.
+func (r *Replay) Client(opts ...option.RequestOption) *anthropic.Client {
+	client := anthropic.NewClient(append([]option.RequestOption{
+		option.WithAPIKey("replay"),
+		option.WithBaseURL("http://replay.invalid"),
+		option.WithHTTPClient(&http.Client{Transport: replayTransport{r}}),
+		option.WithMaxRetries(0),
+	}, opts...)...)
+	return &client
+}
+
+// Err returns the first request that didn't match the recording, or ran
+// past its end, as an error with the diff.
+func (r *Replay) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Remaining returns how many recorded calls haven't been replayed yet. A
+// replayed run that made every call it was recorded making leaves none.
+func (r *Replay) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.exchanges) - r.next
+}
+
+// ServeHTTP answers a Messages API request with the next recorded response,
+// streamed as events if the request asked for a stream.
+func (r *Replay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/v1/messages") {
+		replayError(w, http.StatusNotFound, "not_found_error", fmt.Sprintf("replay: %s %s isn't recorded", req.Method, req.URL.Path))
+		return
+	}
+	var got map[string]interface{}
+	if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+		replayError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("replay: invalid request: %v", err))
+		return
+	}
+	stream, _ := got["stream"].(bool)
+	delete(got, "stream")
+
+	ex, err := r.take(got)
+	if err != nil {
+		log.Print(err)
+		replayError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	if !stream {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(ex.Response)
+		return
+	}
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(ex.Response, &resp); err != nil {
+		replayError(w, http.StatusInternalServerError, "api_error", fmt.Sprintf("replay: invalid recorded response: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, event := range streamEvents(resp) {
+		data, _ := json.Marshal(event)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event["type"], data)
+	}
+}
+
+// take returns the next exchange if its request matches got, and advances.
+func (r *Replay) take(got map[string]interface{}) (Exchange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.next + 1
+	if r.next >= len(r.exchanges) {
+		return Exchange{}, r.fail(fmt.Errorf("replay: request %d is past the end of the recording, which has %d", n, len(r.exchanges)))
+	}
+	ex := r.exchanges[r.next]
+	var want map[string]interface{}
+	if err := json.Unmarshal(ex.Request, &want); err != nil {
+		return Exchange{}, r.fail(fmt.Errorf("replay: invalid recorded request %d: %w", n, err))
+	}
+	if diff := diffJSON(want, got); len(diff) > 0 {
+		return Exchange{}, r.fail(fmt.Errorf("replay: request %d doesn't match the recording:\n%s", n, strings.Join(diff, "\n")))
+	}
+	r.next++
+	return ex, nil
+}
+
+// fail keeps the first error for Err. Callers hold mu.
+func (r *Replay) fail(err error) error {
+	if r.err == nil {
+		r.err = err
+	}
+	return err
+}
+
+func replayError(w http.ResponseWriter, status int, kind, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":  "error",
+		"error": map[string]string{"type": kind, "message": message},
+	})
+}
+
+// replayTransport serves requests from a Replay in process.
+type replayTransport struct {
+	replay *Replay
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.replay.ServeHTTP(rec, req)
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// streamEvents returns the stream events that accumulate to resp: each
+// block is started empty and filled by a single delta. Values are passed
+// through as recorded, so tool inputs keep their bytes.
+func streamEvents(resp map[string]json.RawMessage) []map[string]interface{} {
+	var blocks []map[string]json.RawMessage
+	json.Unmarshal(resp["content"], &blocks)
+	start := make(map[string]json.RawMessage, len(resp))
+	for k, v := range resp {
+		start[k] = v
+	}
+	start["content"] = json.RawMessage(`[]`)
+	start["stop_reason"] = json.RawMessage(`null`)
+	start["stop_sequence"] = json.RawMessage(`null`)
+
+	events := []map[string]interface{}{{"type": "message_start", "message": start}}
+	for i, block := range blocks {
+		empty := make(map[string]json.RawMessage, len(block))
+		for k, v := range block {
+			empty[k] = v
+		}
+		var deltas []map[string]interface{}
+		switch string(block["type"]) {
+		case `"text"`:
+			empty["text"] = json.RawMessage(`""`)
+			deltas = append(deltas, map[string]interface{}{"type": "text_delta", "text": block["text"]})
+		case `"tool_use"`:
+			empty["input"] = json.RawMessage(`{}`)
+			deltas = append(deltas, map[string]interface{}{"type": "input_json_delta", "partial_json": string(block["input"])})
+		case `"thinking"`:
+			empty["thinking"], empty["signature"] = json.RawMessage(`""`), json.RawMessage(`""`)
+			deltas = append(deltas,
+				map[string]interface{}{"type": "thinking_delta", "thinking": block["thinking"]},
+				map[string]interface{}{"type": "signature_delta", "signature": block["signature"]})
+		}
+
+		events = append(events, map[string]interface{}{"type": "content_block_start", "index": i, "content_block": empty})
+		for _, delta := range deltas {
+			events = append(events, map[string]interface{}{"type": "content_block_delta", "index": i, "delta": delta})
+		}
+		events = append(events, map[string]interface{}{"type": "content_block_stop", "index": i})
+	}
+	var usage struct {
+		OutputTokens json.RawMessage `json:"output_tokens"`
+	}
+	json.Unmarshal(resp["usage"], &usage)
+	events = append(events,
+		map[string]interface{}{
+			"type":  "message_delta",
+			"delta": map[string]json.RawMessage{"stop_reason": resp["stop_reason"], "stop_sequence": resp["stop_sequence"]},
+			"usage": map[string]json.RawMessage{"output_tokens": usage.OutputTokens},
+		},
+		map[string]interface{}{"type": "message_stop"},
+	)
+	return events
+}
+
+// maxDiffLines caps how many differences a mismatch reports.
+const maxDiffLines = 20
+
+// diffJSON lists where got differs from want, one line per difference,
+// each naming its path.
+func diffJSON(want, got interface{}) []string {
+	var diff []string
+	var walk func(path string, want, got interface{})
+	walk = func(path string, want, got interface{}) {
+		if len(diff) >= maxDiffLines {
+			return
+		}
+		switch w := want.(type) {
+		case map[string]interface{}:
+			g, ok := got.(map[string]interface{})
+			if !ok {
+				break
+			}
+			keys := make([]string, 0, len(w)+len(g))
+			for k := range w {
+				keys = append(keys, k)
+			}
+			for k := range g {
+				if _, ok := w[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(path+"."+k, w[k], g[k])
+			}
+			return
+		case []interface{}:
+			g, ok := got.([]interface{})
+			if !ok {
+				break
+			}
+			for i := 0; i < max(len(w), len(g)); i++ {
+				var wi, gi interface{}
+				if i < len(w) {
+					wi = w[i]
+				}
+				if i < len(g) {
+					gi = g[i]
+				}
+				walk(fmt.Sprintf("%s[%d]", path, i), wi, gi)
+			}
+			return
+		}
+		if !reflect.DeepEqual(want, got) {
+			diff = append(diff, fmt.Sprintf("  %s: recorded %s, got %s", strings.TrimPrefix(path, "."), diffValue(want), diffValue(got)))
+		}
+	}
+	walk("", want, got)
+	return diff
+}
+
+// diffValue formats one side of a difference, shortened if it is long.
+func diffValue(v interface{}) string {
+	if v == nil {
+		return "nothing"
+	}
+	data, _ := json.Marshal(v)
+	const maxLen = 200
+	if len(data) > maxLen {
+		return cutString(string(data), maxLen) + "…"
+	}
+	return string(data)
+}
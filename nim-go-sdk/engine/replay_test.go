@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// recordRun runs the engine against a mock Claude API that calls
+// get_balance and then finishes, recording the model calls. Recordings are
+// compacted, so the tool input is compact, as the real API sends it.
+func recordRun(t *testing.T, input *Input) (*Output, []byte) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) == 1 {
+			fmt.Fprint(w, strings.Replace(fmt.Sprintf(toolUseResponse, "get_balance"), `"input": {}`, `"input": {"currency":"USDC"}`, 1))
+			return
+		}
+		w.Write([]byte(textResponse))
+	}))
+	t.Cleanup(srv.Close)
+	client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+
+	var recording bytes.Buffer
+	out, err := replayEngine(&client, WithRecorder(&recording)).Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("recorded Run() error = %v", err)
+	}
+	return out, recording.Bytes()
+}
+
+func replayEngine(client *anthropic.Client, opts ...Option) *Engine {
+	registry := NewToolRegistry()
+	registry.Register(tools.New("get_balance").
+		HandlerFunc(func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+			return map[string]interface{}{"balance": "42.00"}, nil
+		}).
+		Build())
+	return NewEngine(client, registry, opts...)
+}
+
+func replayInput(message string, stream func(string, bool)) *Input {
+	return &Input{
+		UserMessage:    message,
+		Context:        core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+		StreamCallback: stream,
+	}
+}
+
+// comparable clears what legitimately differs between runs.
+func comparable(out *Output) *Output {
+	o := *out
+	o.ToolsUsed = append([]core.ToolExecution(nil), out.ToolsUsed...)
+	for i := range o.ToolsUsed {
+		o.ToolsUsed[i].DurationMs = 0
+	}
+	return &o
+}
+
+func TestReplay(t *testing.T) {
+	want, recording := recordRun(t, replayInput("what's my balance?", nil))
+	if lines := bytes.Count(recording, []byte("\n")); lines != 2 {
+		t.Fatalf("recorded %d calls, want 2:\n%s", lines, recording)
+	}
+
+	tests := []struct {
+		name   string
+		stream bool
+		serve  bool // over HTTP rather than in process
+	}{
+		{name: "in process"},
+		{name: "streamed", stream: true},
+		{name: "over HTTP", serve: true},
+		{name: "streamed over HTTP", stream: true, serve: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay, err := NewReplay(bytes.NewReader(recording))
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := replay.Client()
+			if tt.serve {
+				srv := httptest.NewServer(replay)
+				t.Cleanup(srv.Close)
+				c := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+				client = &c
+			}
+			var streamed string
+			var stream func(string, bool)
+			if tt.stream {
+				stream = func(chunk string, done bool) { streamed += chunk }
+			}
+
+			got, err := replayEngine(client).Run(context.Background(), replayInput("what's my balance?", stream))
+			if err != nil {
+				t.Fatalf("replayed Run() error = %v", err)
+			}
+			if !reflect.DeepEqual(comparable(got), comparable(want)) {
+				t.Errorf("replayed Output = %+v, want %+v", comparable(got), comparable(want))
+			}
+			if tt.stream && streamed != want.Text {
+				t.Errorf("streamed %q, want %q", streamed, want.Text)
+			}
+			if replay.Err() != nil || replay.Remaining() != 0 {
+				t.Errorf("Err() = %v, Remaining() = %d, want nil, 0", replay.Err(), replay.Remaining())
+			}
+		})
+	}
+}
+
+func TestReplay_Mismatch(t *testing.T) {
+	_, recording := recordRun(t, replayInput("what's my balance?", nil))
+
+	tests := []struct {
+		name     string
+		message  string
+		calls    int    // recorded calls to replay, all if 0
+		wantErr  string // in Run's error, which quotes the API's
+		wantDiff string // in Err
+	}{
+		{
+			name:     "changed request",
+			message:  "what's my balance in EUR?",
+			wantErr:  "request 1 doesn't match the recording",
+			wantDiff: `messages[0].content[0].text: recorded "what's my balance?", got "what's my balance in EUR?"`,
+		},
+		{
+			name:     "past the end",
+			message:  "what's my balance?",
+			calls:    1,
+			wantErr:  "request 2 is past the end of the recording",
+			wantDiff: "request 2 is past the end of the recording, which has 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := recording
+			if tt.calls > 0 {
+				lines = bytes.SplitAfter(recording, []byte("\n"))[0]
+			}
+			replay, err := NewReplay(bytes.NewReader(lines))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = replayEngine(replay.Client()).Run(context.Background(), replayInput(tt.message, nil))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+			if replay.Err() == nil || !strings.Contains(replay.Err().Error(), tt.wantDiff) {
+				t.Errorf("Err() = %v, want the diff %q", replay.Err(), tt.wantDiff)
+			}
+		})
+	}
+}
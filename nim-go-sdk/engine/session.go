@@ -17,6 +17,11 @@ type Session struct {
 	messages       []anthropic.MessageParam
 	TurnCount      int
 	CreatedAt      time.Time
+
+	compacted bool   // messages open with a compaction summary
+	summary   string // the summary, for folding into the next one
+
+	agentCtx *core.Context // the run's context, passed to tools; may be nil
 }
 
 // NewSession creates a new session.
@@ -36,6 +41,18 @@ func (s *Session) AddUserMessage(content string) {
 	s.messages = append(s.messages, anthropic.NewUserMessage(anthropic.NewTextBlock(content)))
 }
 
+// AddUserContent adds a user message made of content blocks, such as
+// images, followed by text if it isn't empty.
+func (s *Session) AddUserContent(blocks []core.ContentBlock, text string) {
+	content := convertCoreBlocksToAPI(blocks)
+	if text != "" {
+		content = append(content, anthropic.NewTextBlock(text))
+	}
+	if len(content) > 0 {
+		s.messages = append(s.messages, anthropic.NewUserMessage(content...))
+	}
+}
+
 // AddAssistantMessage adds an assistant text message.
 func (s *Session) AddAssistantMessage(content string) {
 	s.messages = append(s.messages, anthropic.NewAssistantMessage(anthropic.NewTextBlock(content)))
@@ -124,6 +141,14 @@ func convertCoreBlocksToAPI(blocks []core.ContentBlock) []anthropic.ContentBlock
 				}
 				result = append(result, anthropic.NewToolResultBlock(block.ToolResult.ToolUseID, content, block.ToolResult.IsError))
 			}
+		case core.ImageBlockType:
+			switch {
+			case block.Image == nil:
+			case block.Image.Data != "":
+				result = append(result, anthropic.NewImageBlockBase64(block.Image.MediaType, block.Image.Data))
+			case block.Image.URL != "":
+				result = append(result, anthropic.NewImageBlock(anthropic.URLImageSourceParam{URL: block.Image.URL}))
+			}
 		}
 	}
 	return result
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// structuredToolName is the tool CompleteStructured makes the model call
+// with its answer.
+const structuredToolName = "structured_output"
+
+// structuredRequest holds CompleteStructured's settings.
+type structuredRequest struct {
+	model     string
+	maxTokens int64
+	system    string
+}
+
+// StructuredOption configures CompleteStructured.
+type StructuredOption func(*structuredRequest)
+
+// WithStructuredModel sets the model. Defaults to the engine's model (see
+// Engine.Model).
+func WithStructuredModel(model string) StructuredOption {
+	return func(r *structuredRequest) {
+		if model != "" {
+			r.model = model
+		}
+	}
+}
+
+// WithStructuredMaxTokens caps the response. Defaults to 1024.
+func WithStructuredMaxTokens(n int64) StructuredOption {
+	return func(r *structuredRequest) {
+		if n > 0 {
+			r.maxTokens = n
+		}
+	}
+}
+
+// WithStructuredSystem sets a system prompt.
+func WithStructuredSystem(prompt string) StructuredOption {
+	return func(r *structuredRequest) {
+		r.system = prompt
+	}
+}
+
+// CompleteStructured answers prompt with a JSON object matching schema, a
+// JSON Schema object such as tools.ObjectSchema builds. The model is made
+// to call a tool whose input schema is schema, and the tool's input is
+// returned. If the object lacks a required field, the model is told what
+// was wrong and asked once more.
+func (e *Engine) CompleteStructured(ctx context.Context, prompt string, schema map[string]interface{}, opts ...StructuredOption) (json.RawMessage, error) {
+	req := structuredRequest{model: e.Model(), maxTokens: 1024}
+	for _, opt := range opts {
+		opt(&req)
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := schemaRequired(schema)
+	params := anthropic.MessageNewParams{
+		Model:     anthropic.Model(req.model),
+		MaxTokens: req.maxTokens,
+		Messages:  []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(prompt))},
+		Tools: []anthropic.ToolUnionParam{{
+			OfTool: &anthropic.ToolParam{
+				Name:        structuredToolName,
+				Description: anthropic.String("Record your answer."),
+				InputSchema: anthropic.ToolInputSchemaParam{
+					Properties: properties,
+					Required:   required,
+				},
+			},
+		}},
+		ToolChoice: anthropic.ToolChoiceParamOfTool(structuredToolName),
+	}
+	if req.system != "" {
+		params.System = []anthropic.TextBlockParam{{Text: req.system}}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := e.newMessage(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to complete structured output: %w", err)
+		}
+		out, blockID, err := structuredOutput(resp, required)
+		if err == nil {
+			return out, nil
+		}
+		if attempt == 1 {
+			return nil, fmt.Errorf("invalid structured output: %w", err)
+		}
+
+		// Show the model its answer and what was wrong with it.
+		if blockID != "" {
+			params.Messages = append(params.Messages, resp.ToParam(), anthropic.NewUserMessage(
+				anthropic.NewToolResultBlock(blockID, fmt.Sprintf("Invalid input: %v. Call %s again with every required field.", err, structuredToolName), true),
+			))
+		}
+	}
+}
+
+// structuredOutput returns the input of the response's structured_output
+// call, and the call's ID, checking it has every required field.
+func structuredOutput(resp *anthropic.Message, required []string) (json.RawMessage, string, error) {
+	for _, block := range resp.Content {
+		if block.Type != "tool_use" || block.Name != structuredToolName {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(block.Input, &fields); err != nil {
+			return nil, block.ID, fmt.Errorf("not a JSON object: %w", err)
+		}
+		for _, name := range required {
+			if v, ok := fields[name]; !ok || string(v) == "null" {
+				return nil, block.ID, fmt.Errorf("missing required field %q", name)
+			}
+		}
+		return block.Input, block.ID, nil
+	}
+	return nil, "", fmt.Errorf("no %s call in response", structuredToolName)
+}
+
+// schemaRequired returns a JSON Schema object's required fields.
+func schemaRequired(schema map[string]interface{}) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []interface{}:
+		names := make([]string, 0, len(required))
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// structuredModel is a mock Claude API that answers the nth request with
+// the nth of inputs as a structured_output call, and records each request
+// body.
+func structuredModel(t *testing.T, bodies *[]string, inputs ...string) *Engine {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*bodies = append(*bodies, string(body))
+		i := len(*bodies) - 1
+		if i >= len(inputs) {
+			http.Error(w, `{"type":"error","error":{"type":"api_error","message":"unexpected request"}}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"msg_%d","type":"message","role":"assistant","model":"test-model","content":[{"type":"tool_use","id":"toolu_%d","name":"structured_output","input":%s}],"stop_reason":"tool_use","usage":{"input_tokens":1,"output_tokens":1}}`, i, i, inputs[i])
+	}))
+	t.Cleanup(srv.Close)
+
+	client := anthropic.NewClient(
+		option.WithAPIKey("test"),
+		option.WithBaseURL(srv.URL),
+		option.WithMaxRetries(0),
+	)
+	return NewEngine(&client, NewToolRegistry())
+}
+
+func TestCompleteStructured(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"category": map[string]interface{}{"type": "string"},
+			"note":     map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"category"},
+	}
+
+	tests := []struct {
+		name     string
+		inputs   []string
+		want     string
+		requests int
+	}{
+		{name: "valid", inputs: []string{`{"category":"food"}`}, want: `{"category":"food"}`, requests: 1},
+		{name: "retried", inputs: []string{`{"note":"lunch"}`, `{"category":"food","note":"lunch"}`}, want: `{"category":"food","note":"lunch"}`, requests: 2},
+		{name: "null field retried", inputs: []string{`{"category":null}`, `{"category":"food"}`}, want: `{"category":"food"}`, requests: 2},
+		{name: "invalid twice", inputs: []string{`{"note":"lunch"}`, `{"note":"lunch"}`}, requests: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			e := structuredModel(t, &bodies, tt.inputs...)
+
+			got, err := e.CompleteStructured(context.Background(), "Categorize: lunch", schema,
+				WithStructuredModel("test-model"), WithStructuredSystem("Be brief."))
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("CompleteStructured() = %s, want an error", got)
+				}
+			} else if err != nil || string(got) != tt.want {
+				t.Errorf("CompleteStructured() = %s, %v, want %s", got, err, tt.want)
+			}
+			if len(bodies) != tt.requests {
+				t.Fatalf("made %d requests, want %d", len(bodies), tt.requests)
+			}
+
+			var first struct {
+				Model      string `json:"model"`
+				ToolChoice struct {
+					Type string `json:"type"`
+					Name string `json:"name"`
+				} `json:"tool_choice"`
+				Tools []struct {
+					Name        string `json:"name"`
+					InputSchema struct {
+						Properties map[string]interface{} `json:"properties"`
+						Required   []string               `json:"required"`
+					} `json:"input_schema"`
+				} `json:"tools"`
+			}
+			if err := json.Unmarshal([]byte(bodies[0]), &first); err != nil {
+				t.Fatal(err)
+			}
+			if first.Model != "test-model" || first.ToolChoice.Type != "tool" || first.ToolChoice.Name != structuredToolName {
+				t.Errorf("request = %s, want the structured_output tool forced", bodies[0])
+			}
+			if len(first.Tools) != 1 || len(first.Tools[0].InputSchema.Properties) != 2 ||
+				len(first.Tools[0].InputSchema.Required) != 1 || first.Tools[0].InputSchema.Required[0] != "category" {
+				t.Errorf("tools = %+v, want the schema", first.Tools)
+			}
+
+			if tt.requests > 1 && !strings.Contains(bodies[1], `missing required field \"category\"`) {
+				t.Errorf("retry = %s, want the validation error", bodies[1])
+			}
+		})
+	}
+}
+
+func TestSchemaRequired(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema map[string]interface{}
+		want   []string
+	}{
+		{"strings", map[string]interface{}{"required": []string{"a", "b"}}, []string{"a", "b"}},
+		{"decoded JSON", map[string]interface{}{"required": []interface{}{"a", 1, "b"}}, []string{"a", "b"}},
+		{"none", map[string]interface{}{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := schemaRequired(tt.schema)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("schemaRequired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -64,7 +64,7 @@ func (e *Engine) GenerateTitle(ctx context.Context, history []core.Message) (str
 		},
 	}
 
-	resp, err := e.client.Messages.New(ctx, params)
+	resp, err := e.newMessage(ctx, params)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate title: %w", err)
 	}
@@ -93,3 +93,20 @@ func (e *Engine) GenerateTitleFromFirstMessage(ctx context.Context, message stri
 	}
 	return e.GenerateTitle(ctx, history)
 }
+
+// maxTitleWords is how many words of a message TitleFromMessage keeps.
+const maxTitleWords = 6
+
+// TitleFromMessage titles a conversation with the first few words of its
+// first message, without a model call.
+func TitleFromMessage(message string) string {
+	words := strings.Fields(message)
+	if len(words) > maxTitleWords {
+		words = words[:maxTitleWords]
+	}
+	title := strings.TrimRight(strings.Join(words, " "), ".,;:!?")
+	if title == "" {
+		return "New conversation"
+	}
+	return title
+}
@@ -0,0 +1,23 @@
+package engine
+
+import "testing"
+
+func TestTitleFromMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"What's my balance?", "What's my balance"},
+		{"  send\n20 USDC   to @alice please, right now", "send 20 USDC to @alice please"},
+		{"one two three four five six. seven", "one two three four five six"},
+		{" ?! ", "New conversation"},
+		{"", "New conversation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got := TitleFromMessage(tt.message); got != tt.want {
+				t.Errorf("TitleFromMessage(%q) = %q, want %q", tt.message, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// DefaultCacheInvalidations maps Liminal's write tools to the prefixes of
+// the read tools whose cached results they make stale. Writes not listed
+// here invalidate all of the user's cached results.
+var DefaultCacheInvalidations = map[string][]string{
+	"send_money":       {"get_balance", "get_transactions", "get_spending"},
+	"deposit_savings":  {"get_balance", "get_savings", "get_transactions", "get_spending"},
+	"withdraw_savings": {"get_balance", "get_savings", "get_transactions", "get_spending"},
+	"request_money":    {"get_payment_requests"},
+}
+
+// ToolCacheKey identifies a cached read: who ran which tool with what input.
+type ToolCacheKey struct {
+	UserID string
+	Tool   string
+
+	// Input is a hash of the tool's canonicalized input.
+	Input string
+}
+
+// ToolCache keeps read-only tool results across runs, for WithToolCache.
+// This is an interface - implementations (e.g., Redis-backed) are provided
+// by the consuming application.
+type ToolCache interface {
+	// Get returns the result cached under key.
+	// Returns nil, nil if there is none or it has expired.
+	Get(ctx context.Context, key ToolCacheKey) (*core.ToolResult, error)
+
+	// Set caches result under key until ttl has passed.
+	Set(ctx context.Context, key ToolCacheKey, result *core.ToolResult, ttl time.Duration) error
+
+	// Invalidate removes the user's results from tools whose names start
+	// with any of prefixes, or all of the user's results if prefixes is nil.
+	Invalidate(ctx context.Context, userID string, prefixes []string) error
+}
+
+// WithRunCache memoizes read-only tool results for the duration of each
+// Run: a second call to the same tool with the same input, by the model or
+// by another tool, returns the first call's result without executing it
+// again. Only successful results are kept. Tools requiring confirmation and
+// tools marked core.UncachedTool are never cached, and running them drops
+// the results they make stale (see WithCacheInvalidation).
+//
+// Tools that call a core.ToolExecutor directly, such as workflow nodes
+// fetching the balance, share the cache by wrapping it with CachedExecutor.
+func WithRunCache() Option {
+	return func(e *Engine) {
+		e.runCache = true
+	}
+}
+
+// WithToolCache is WithRunCache, also keeping results in cache for ttl, so
+// later runs reuse them. Confirmed writes executed with ExecuteTool or
+// ExecuteAction invalidate them as they would within a run.
+func WithToolCache(cache ToolCache, ttl time.Duration) Option {
+	return func(e *Engine) {
+		e.runCache = true
+		e.toolCache = cache
+		e.toolCacheTTL = ttl
+	}
+}
+
+// WithCacheInvalidation declares that running write makes the cached
+// results of read tools whose names start with any of prefixes stale,
+// replacing its entry in DefaultCacheInvalidations. With no prefixes,
+// write invalidates nothing.
+func WithCacheInvalidation(write string, prefixes ...string) Option {
+	return func(e *Engine) {
+		if e.invalidations == nil {
+			e.invalidations = make(map[string][]string, len(DefaultCacheInvalidations)+1)
+			for tool, p := range DefaultCacheInvalidations {
+				e.invalidations[tool] = p
+			}
+		}
+		if prefixes == nil {
+			prefixes = []string{}
+		}
+		e.invalidations[write] = prefixes
+	}
+}
+
+// cacheable reports whether the tool cache may reuse tool's results.
+func cacheable(tool core.Tool) bool {
+	if tool.RequiresConfirmation() {
+		return false
+	}
+	u, ok := tool.(core.UncachedTool)
+	return !ok || !u.NoCache()
+}
+
+// stale returns the prefixes of the read tools running write invalidates,
+// or nil for all of them.
+func (e *Engine) stale(write string) []string {
+	invalidations := e.invalidations
+	if invalidations == nil {
+		invalidations = DefaultCacheInvalidations
+	}
+	return invalidations[write]
+}
+
+// withRunCache attaches a new run cache to ctx, unless caching is off or
+// ctx already has one, as runs started by tools within a run do.
+func (e *Engine) withRunCache(ctx context.Context) context.Context {
+	if !e.runCache || runCacheFrom(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, runCacheKey{}, &runCache{
+		stale:   e.stale,
+		entries: make(map[runCacheKeyValue]*runCacheEntry),
+	})
+}
+
+// cachedTool runs exec for a tool call through the run cache and tool
+// cache, if the engine has them, invalidating stale results after tools
+// that can't be cached.
+func (e *Engine) cachedTool(ctx context.Context, tool core.Tool, params *core.ToolParams, exec ToolExecFunc) (*core.ToolResult, error) {
+	if !e.runCache {
+		return exec(ctx, tool.Name(), params)
+	}
+	if !cacheable(tool) {
+		result, err := exec(ctx, tool.Name(), params)
+		if err == nil && result != nil && result.Success {
+			e.invalidate(ctx, params.UserID, tool.Name())
+		}
+		return result, err
+	}
+
+	key := ToolCacheKey{UserID: params.UserID, Tool: tool.Name(), Input: readKey(tool.Name(), params.Input)}
+	run := func() (*core.ToolResult, error) {
+		if e.toolCache != nil {
+			if cached, err := e.toolCache.Get(ctx, key); err == nil && cached != nil {
+				return cached, nil
+			}
+		}
+		result, err := exec(ctx, tool.Name(), params)
+		if e.toolCache != nil && keepResult(result, err) {
+			e.toolCache.Set(ctx, key, result, e.toolCacheTTL)
+		}
+		return result, err
+	}
+	cache := runCacheFrom(ctx)
+	if cache == nil {
+		return run()
+	}
+	value, err := cache.do(runCacheKeyValue{kind: "tool", key: key}, func() (interface{}, bool, error) {
+		result, err := run()
+		return result, keepResult(result, err), err
+	})
+	result, _ := value.(*core.ToolResult)
+	return result, err
+}
+
+// keepResult reports whether a tool's result may be reused: it succeeded
+// and proposes no action, since proposing again would offer a new one.
+func keepResult(result *core.ToolResult, err error) bool {
+	return err == nil && result != nil && result.Success && result.Propose == nil
+}
+
+// invalidate drops the user's cached results that running write makes stale.
+func (e *Engine) invalidate(ctx context.Context, userID, write string) {
+	prefixes := e.stale(write)
+	if cache := runCacheFrom(ctx); cache != nil {
+		cache.invalidate(userID, prefixes)
+	}
+	if e.toolCache != nil && (prefixes == nil || len(prefixes) > 0) {
+		e.toolCache.Invalidate(ctx, userID, prefixes)
+	}
+}
+
+// CachedExecutor wraps exec so that its read-only calls share the run cache
+// of the engine run they are made in (see WithRunCache): the balance fetched
+// by one workflow node is reused by the next. Writes it executes invalidate
+// the results they make stale. Outside a run, or if the engine has no run
+// cache, calls go straight to exec.
+func CachedExecutor(exec core.ToolExecutor) core.ToolExecutor {
+	return &cachedExecutor{ToolExecutor: exec}
+}
+
+type cachedExecutor struct {
+	core.ToolExecutor
+}
+
+func (c *cachedExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	cache := runCacheFrom(ctx)
+	if cache == nil {
+		return c.ToolExecutor.Execute(ctx, req)
+	}
+	key := ToolCacheKey{UserID: req.UserID, Tool: req.Tool, Input: readKey(req.Tool, req.Input)}
+	value, err := cache.do(runCacheKeyValue{kind: "executor", key: key}, func() (interface{}, bool, error) {
+		resp, err := c.ToolExecutor.Execute(ctx, req)
+		return resp, err == nil && resp != nil && resp.Success, err
+	})
+	resp, _ := value.(*core.ExecuteResponse)
+	return resp, err
+}
+
+func (c *cachedExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	resp, err := c.ToolExecutor.ExecuteWrite(ctx, req)
+	if cache := runCacheFrom(ctx); cache != nil && err == nil && resp != nil && resp.Success && !resp.RequiresConfirmation {
+		cache.invalidate(req.UserID, cache.stale(req.Tool))
+	}
+	return resp, err
+}
+
+func (c *cachedExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	resp, err := c.ToolExecutor.Confirm(ctx, userID, confirmationID)
+	if cache := runCacheFrom(ctx); cache != nil && err == nil && resp != nil && resp.Success {
+		// The confirmed tool is unknown, so anything may be stale.
+		cache.invalidate(userID, nil)
+	}
+	return resp, err
+}
+
+// runCacheKey is the context key of a run's cache.
+type runCacheKey struct{}
+
+// runCacheFrom returns the run cache attached to ctx, or nil.
+func runCacheFrom(ctx context.Context) *runCache {
+	cache, _ := ctx.Value(runCacheKey{}).(*runCache)
+	return cache
+}
+
+// runCacheKeyValue identifies an entry in a run cache. Tool results and
+// executor responses are kept apart, since they are different values.
+type runCacheKeyValue struct {
+	kind string // "tool" or "executor"
+	key  ToolCacheKey
+}
+
+// runCache memoizes the reads made during one run. Concurrent calls with
+// the same key wait for the first to finish and share its result.
+type runCache struct {
+	stale   func(write string) []string
+	mu      sync.Mutex
+	entries map[runCacheKeyValue]*runCacheEntry
+}
+
+// runCacheEntry is a read that has run, or is running.
+type runCacheEntry struct {
+	done  chan struct{} // closed once value and err are set
+	value interface{}
+	err   error
+}
+
+// do returns the value cached under key, or calls fn to compute it. The
+// value is kept only if fn says so; failures are shared with calls already
+// waiting, but later calls try again.
+func (c *runCache) do(key runCacheKeyValue, fn func() (value interface{}, keep bool, err error)) (interface{}, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		<-entry.done
+		return entry.value, entry.err
+	}
+	entry := &runCacheEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	value, keep, err := fn()
+	entry.value, entry.err = value, err
+	close(entry.done)
+	if !keep {
+		c.mu.Lock()
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+	}
+	return value, err
+}
+
+// invalidate drops the user's entries from tools whose names start with
+// any of prefixes, or all of them if prefixes is nil.
+func (c *runCache) invalidate(userID string, prefixes []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key.key.UserID == userID && matchesPrefix(key.key.Tool, prefixes) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// matchesPrefix reports whether tool starts with any of prefixes, or
+// prefixes is nil.
+func matchesPrefix(tool string, prefixes []string) bool {
+	if prefixes == nil {
+		return true
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(tool, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryToolCache is an in-memory implementation of ToolCache.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryToolCache struct {
+	mu      sync.Mutex
+	results map[ToolCacheKey]memoryToolResult
+	now     func() time.Time
+}
+
+// memoryToolResult is a cached result and when it expires.
+type memoryToolResult struct {
+	result    *core.ToolResult
+	expiresAt time.Time
+}
+
+// NewMemoryToolCache creates an in-memory tool cache.
+func NewMemoryToolCache() *MemoryToolCache {
+	return &MemoryToolCache{
+		results: make(map[ToolCacheKey]memoryToolResult),
+		now:     time.Now,
+	}
+}
+
+func (m *MemoryToolCache) Get(ctx context.Context, key ToolCacheKey) (*core.ToolResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cached, ok := m.results[key]
+	if !ok {
+		return nil, nil
+	}
+	if !m.now().Before(cached.expiresAt) {
+		delete(m.results, key)
+		return nil, nil
+	}
+	return cached.result, nil
+}
+
+func (m *MemoryToolCache) Set(ctx context.Context, key ToolCacheKey, result *core.ToolResult, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results[key] = memoryToolResult{result: result, expiresAt: m.now().Add(ttl)}
+	return nil
+}
+
+func (m *MemoryToolCache) Invalidate(ctx context.Context, userID string, prefixes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.results {
+		if key.UserID == userID && matchesPrefix(key.Tool, prefixes) {
+			delete(m.results, key)
+		}
+	}
+	return nil
+}
+
+// Verify MemoryToolCache implements ToolCache.
+var _ ToolCache = (*MemoryToolCache)(nil)
@@ -0,0 +1,257 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// countingExecutor is a fake Liminal executor that counts its calls by tool.
+type countingExecutor struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingExecutor) count(tool string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[tool]++
+}
+
+func (c *countingExecutor) called(tool string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[tool]
+}
+
+func (c *countingExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	c.count(req.Tool)
+	return &core.ExecuteResponse{Success: true, Data: json.RawMessage(`{"amount":"100.00"}`)}, nil
+}
+
+func (c *countingExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	c.count(req.Tool)
+	return &core.ExecuteResponse{Success: true, RequiresConfirmation: true}, nil
+}
+
+func (c *countingExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	c.count("confirm")
+	return &core.ExecuteResponse{Success: true, Data: json.RawMessage(`{"status":"sent"}`)}, nil
+}
+
+func (c *countingExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	return nil
+}
+
+// toolUses is a response calling each of the named tools in one turn.
+func toolUses(turn int, names ...string) string {
+	blocks := make([]string, len(names))
+	for i, name := range names {
+		blocks[i] = fmt.Sprintf(`{"type":"tool_use","id":"toolu_%d_%d","name":%q,"input":{}}`, turn, i, name)
+	}
+	return fmt.Sprintf(`{"id":"msg_%d","type":"message","role":"assistant","model":"test-model","content":[%s],"stop_reason":"tool_use","usage":{"input_tokens":1,"output_tokens":1}}`,
+		turn, strings.Join(blocks, ","))
+}
+
+// scriptedClient is a mock Claude API that sends responses in order, then
+// ends every later turn.
+func scriptedClient(t *testing.T, responses ...string) *anthropic.Client {
+	t.Helper()
+	var turn atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if n := int(turn.Add(1)); n <= len(responses) {
+			w.Write([]byte(responses[n-1]))
+			return
+		}
+		w.Write([]byte(textResponse))
+	}))
+	t.Cleanup(srv.Close)
+	client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+	return &client
+}
+
+// cachingRegistry registers Liminal-style tools over exec: get_balance,
+// get_vault_rates and send_money; overview, which fetches the balance twice
+// itself, as workflow nodes do; and note, which saves something without
+// confirmation and so is never cached.
+func cachingRegistry(exec core.ToolExecutor, notes *atomic.Int32) *ToolRegistry {
+	registry := NewToolRegistry()
+	registry.RegisterAll(
+		core.NewExecutorTool(core.ToolDefinition{ToolName: "get_balance"}, exec),
+		core.NewExecutorTool(core.ToolDefinition{ToolName: "get_vault_rates"}, exec),
+		core.NewExecutorTool(core.ToolDefinition{ToolName: "send_money", RequiresUserConfirmation: true}, exec),
+		tools.New("overview").
+			Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+				for range 2 {
+					if _, err := exec.Execute(ctx, &core.ExecuteRequest{UserID: params.UserID, Tool: "get_balance", Input: json.RawMessage(`{ }`)}); err != nil {
+						return nil, err
+					}
+				}
+				return &core.ToolResult{Success: true, Data: "overview"}, nil
+			}).
+			Build(),
+		tools.New("note").
+			NoCache().
+			Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+				notes.Add(1)
+				return &core.ToolResult{Success: true, Data: "saved"}, nil
+			}).
+			Build(),
+	)
+	return registry
+}
+
+func TestRun_RunCache(t *testing.T) {
+	script := []string{
+		toolUses(1, "get_balance", "overview", "get_balance"),
+		toolUses(2, "get_balance"),
+		toolUses(3, "note"),
+		toolUses(4, "note"),
+		toolUses(5, "get_balance"),
+	}
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantBalances int
+	}{
+		// Turns 1 and 2 fetch the balance five times: twice by the model
+		// and twice by overview, all at once, then once more.
+		{name: "off", wantBalances: 6},
+		// Turn 1 fetches it once, and turn 5 again after note.
+		{name: "on", opts: []Option{WithRunCache()}, wantBalances: 2},
+		{name: "note invalidates nothing", opts: []Option{WithRunCache(), WithCacheInvalidation("note")}, wantBalances: 1},
+		{name: "note invalidates the balance", opts: []Option{WithRunCache(), WithCacheInvalidation("note", "get_bal")}, wantBalances: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &countingExecutor{}
+			var notes atomic.Int32
+			eng := NewEngine(scriptedClient(t, script...), cachingRegistry(CachedExecutor(exec), &notes), tt.opts...)
+
+			out, err := eng.Run(context.Background(), &Input{
+				UserMessage: "how am I doing?",
+				Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+			})
+			if err != nil || out.Type != OutputComplete {
+				t.Fatalf("Run() = %+v, %v", out, err)
+			}
+			if got := exec.called("get_balance"); got != tt.wantBalances {
+				t.Errorf("get_balance executed %d times, want %d", got, tt.wantBalances)
+			}
+			if notes.Load() != 2 {
+				t.Errorf("note executed %d times, want 2: it is never cached", notes.Load())
+			}
+			if len(out.ToolsUsed) != 7 {
+				t.Errorf("ToolsUsed has %d calls, want all 7, cached or not", len(out.ToolsUsed))
+			}
+		})
+	}
+}
+
+func TestRun_RunCacheEndsWithRun(t *testing.T) {
+	exec := &countingExecutor{}
+	var notes atomic.Int32
+	eng := NewEngine(scriptedClient(t, toolUses(1, "get_balance"), textResponse, toolUses(2, "get_balance")),
+		cachingRegistry(exec, &notes), WithRunCache())
+
+	for range 2 {
+		if _, err := eng.Run(context.Background(), &Input{UserMessage: "balance?", Context: core.NewContext("user-1", "sess-1", "conv-1", "req-1")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := exec.called("get_balance"); got != 2 {
+		t.Errorf("get_balance executed %d times over two runs, want 2", got)
+	}
+}
+
+func TestRun_ToolCacheInvalidatedByConfirmedWrite(t *testing.T) {
+	exec := &countingExecutor{}
+	var notes atomic.Int32
+	reads := toolUses(1, "get_balance", "get_vault_rates")
+	cache := NewMemoryToolCache()
+	eng := NewEngine(scriptedClient(t, reads, textResponse, reads, textResponse, reads, textResponse, reads),
+		cachingRegistry(exec, &notes), WithToolCache(cache, time.Minute))
+
+	run := func() {
+		t.Helper()
+		out, err := eng.Run(context.Background(), &Input{
+			UserMessage: "balance and rates?",
+			Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+		})
+		if err != nil || out.Type != OutputComplete {
+			t.Fatalf("Run() = %+v, %v", out, err)
+		}
+	}
+	check := func(when string, balances, rates int) {
+		t.Helper()
+		if got := exec.called("get_balance"); got != balances {
+			t.Errorf("%s: get_balance executed %d times, want %d", when, got, balances)
+		}
+		if got := exec.called("get_vault_rates"); got != rates {
+			t.Errorf("%s: get_vault_rates executed %d times, want %d", when, got, rates)
+		}
+	}
+
+	run()
+	run()
+	check("second run", 1, 1)
+
+	// Sending money changes the balance, but not vault rates.
+	result, err := eng.ExecuteTool(context.Background(), "user-1", "send_money", json.RawMessage(`{"recipient":"@alice","amount":"5"}`), "conf-1")
+	if err != nil || !result.Success || exec.called("confirm") != 1 {
+		t.Fatalf("ExecuteTool() = %+v, %v", result, err)
+	}
+	run()
+	check("after send_money", 2, 1)
+
+	// Results expire after the TTL.
+	cache.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	run()
+	check("after the TTL", 3, 2)
+}
+
+func TestMemoryToolCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryToolCache()
+	result := &core.ToolResult{Success: true, Data: "ok"}
+	keys := []ToolCacheKey{
+		{UserID: "user-1", Tool: "get_balance", Input: "a"},
+		{UserID: "user-1", Tool: "get_transactions", Input: "a"},
+		{UserID: "user-1", Tool: "get_vault_rates", Input: "a"},
+		{UserID: "user-2", Tool: "get_balance", Input: "a"},
+	}
+	for _, key := range keys {
+		cache.Set(ctx, key, result, time.Minute)
+	}
+
+	cache.Invalidate(ctx, "user-1", []string{"get_balance", "get_trans"})
+	for i, want := range []bool{false, false, true, true} {
+		if got, _ := cache.Get(ctx, keys[i]); (got != nil) != want {
+			t.Errorf("after invalidating balances, Get(%+v) = %v, want cached %v", keys[i], got, want)
+		}
+	}
+
+	cache.Invalidate(ctx, "user-1", nil)
+	if got, _ := cache.Get(ctx, keys[2]); got != nil {
+		t.Errorf("Invalidate(nil) left %+v cached", keys[2])
+	}
+	if got, _ := cache.Get(ctx, keys[3]); got == nil {
+		t.Error("Invalidate(user-1) removed user-2's result")
+	}
+}
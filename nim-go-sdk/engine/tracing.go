@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName names the tracer the engine's spans are recorded with.
+const TracerName = "github.com/becomeliminal/nim-go-sdk/engine"
+
+// Span attributes. Model and token attributes follow the OpenTelemetry
+// generative AI conventions.
+const (
+	attrUserID         = attribute.Key("nim.user_id")
+	attrConversationID = attribute.Key("nim.conversation_id")
+	attrRequestID      = attribute.Key("nim.request_id")
+	attrOutcome        = attribute.Key("nim.run.outcome")
+	attrTurn           = attribute.Key("nim.turn")
+	attrStopReason     = attribute.Key("nim.stop_reason")
+	attrToolName       = attribute.Key("nim.tool.name")
+	attrToolDuration   = attribute.Key("nim.tool.duration_ms")
+
+	attrModel               = attribute.Key("gen_ai.request.model")
+	attrInputTokens         = attribute.Key("gen_ai.usage.input_tokens")
+	attrOutputTokens        = attribute.Key("gen_ai.usage.output_tokens")
+	attrCacheReadTokens     = attribute.Key("gen_ai.usage.cache_read_input_tokens")
+	attrCacheCreationTokens = attribute.Key("gen_ai.usage.cache_creation_input_tokens")
+)
+
+// WithTracerProvider traces runs with OpenTelemetry: a span per Run, with a
+// child per model turn, and a child of the turn per tool call. Tools get
+// the tool call's span on their context, so an executor traced with the
+// same provider, such as executor.HTTPExecutor, continues the trace.
+// Without it, tracing costs a nil check.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(e *Engine) {
+		if tp != nil {
+			e.tracer = tp.Tracer(TracerName)
+		}
+	}
+}
+
+// startRun starts the span of a Run, if tracing.
+func (e *Engine) startRun(ctx context.Context, input *Input) (context.Context, trace.Span) {
+	if e.tracer == nil {
+		return ctx, nil
+	}
+	var attrs []attribute.KeyValue
+	if input.Context != nil {
+		attrs = append(attrs,
+			attrUserID.String(input.Context.UserID),
+			attrConversationID.String(input.Context.ConversationID),
+			attrRequestID.String(input.Context.RequestID),
+		)
+	}
+	return e.tracer.Start(ctx, "engine.Run", trace.WithAttributes(attrs...))
+}
+
+// endRun ends a Run's span with its outcome and token usage.
+func endRun(ctx context.Context, span trace.Span, out *Output, err error) {
+	if span == nil {
+		return
+	}
+	outcome := runOutcome(ctx, out, err)
+	span.SetAttributes(attrOutcome.String(string(outcome)))
+	if out != nil {
+		span.SetAttributes(tokenAttributes(out.TokensUsed)...)
+		if out.Model != "" {
+			span.SetAttributes(attrModel.String(out.Model))
+		}
+		if err == nil {
+			err = out.Error
+		}
+	}
+	if outcome == RunError {
+		setError(span, err)
+	}
+	span.End()
+}
+
+// startTurn starts the span of a model turn, if tracing.
+func (e *Engine) startTurn(ctx context.Context, turn int, model string) (context.Context, trace.Span) {
+	if e.tracer == nil {
+		return ctx, nil
+	}
+	return e.tracer.Start(ctx, "engine.turn", trace.WithAttributes(
+		attrTurn.Int(turn),
+		attrModel.String(model),
+	))
+}
+
+// turnResponse records the model's response on a turn's span.
+func turnResponse(span trace.Span, resp *anthropic.Message) {
+	if span == nil {
+		return
+	}
+	span.SetAttributes(attrStopReason.String(string(resp.StopReason)))
+	span.SetAttributes(tokenAttributes(core.TokenUsage{
+		InputTokens:              int(resp.Usage.InputTokens),
+		OutputTokens:             int(resp.Usage.OutputTokens),
+		CacheCreationInputTokens: int(resp.Usage.CacheCreationInputTokens),
+		CacheReadInputTokens:     int(resp.Usage.CacheReadInputTokens),
+	})...)
+}
+
+// endTurn ends a turn's span, if there is one. err is why the run ended
+// during the turn, if it did.
+func endTurn(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		setError(span, err)
+	}
+	span.End()
+}
+
+// startTool starts the span of a tool call, if tracing.
+func (e *Engine) startTool(ctx context.Context, tool string) (context.Context, trace.Span) {
+	if e.tracer == nil {
+		return ctx, nil
+	}
+	return e.tracer.Start(ctx, "engine.tool "+tool, trace.WithAttributes(attrToolName.String(tool)))
+}
+
+// endTool ends a tool call's span with its duration and outcome.
+func endTool(span trace.Span, start time.Time, result *core.ToolResult, err error) {
+	if span == nil {
+		return
+	}
+	span.SetAttributes(attrToolDuration.Int64(time.Since(start).Milliseconds()))
+	if err == nil && result != nil && !result.Success {
+		err = errors.New(result.Error)
+	}
+	if err != nil {
+		setError(span, err)
+	}
+	span.End()
+}
+
+// tokenAttributes are the attributes of token usage.
+func tokenAttributes(usage core.TokenUsage) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attrInputTokens.Int(usage.InputTokens),
+		attrOutputTokens.Int(usage.OutputTokens),
+		attrCacheReadTokens.Int(usage.CacheReadInputTokens),
+		attrCacheCreationTokens.Int(usage.CacheCreationInputTokens),
+	}
+}
+
+// setError marks span as failed with err.
+func setError(span trace.Span, err error) {
+	if err == nil {
+		span.SetStatus(codes.Error, "")
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRun_Tracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	// The gateway records the traceparent of each request, by path
+	var mu sync.Mutex
+	traceparents := make(map[string]string)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceparents[r.URL.Path] = r.Header.Get("traceparent")
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer gateway.Close()
+	exec := executor.NewHTTPExecutor(executor.HTTPExecutorConfig{BaseURL: gateway.URL, JWTToken: "jwt", TracerProvider: tp})
+
+	registry := NewToolRegistry()
+	registry.RegisterAll(
+		core.NewExecutorTool(core.ToolDefinition{ToolName: "get_balance"}, exec),
+		core.NewExecutorTool(core.ToolDefinition{ToolName: "get_vault_rates"}, exec),
+	)
+	eng := NewEngine(scriptedClient(t, toolUses(1, "get_balance", "get_vault_rates")), registry, WithTracerProvider(tp))
+
+	out, err := eng.Run(context.Background(), &Input{UserMessage: "balance and rates?", Context: core.NewContext("user-1", "sess-1", "conv-1", "req-1")})
+	if err != nil || out.Type != OutputComplete {
+		t.Fatalf("Run = %+v, %v", out, err)
+	}
+
+	spans := exporter.GetSpans()
+	byName := make(map[string][]tracetest.SpanStub)
+	for _, s := range spans {
+		byName[s.Name] = append(byName[s.Name], s)
+	}
+	attrs := func(s tracetest.SpanStub) map[attribute.Key]attribute.Value {
+		m := make(map[attribute.Key]attribute.Value)
+		for _, kv := range s.Attributes {
+			m[kv.Key] = kv.Value
+		}
+		return m
+	}
+
+	runs := byName["engine.Run"]
+	if len(runs) != 1 {
+		t.Fatalf("spans = %d engine.Run, want 1", len(runs))
+	}
+	run := runs[0]
+	if run.Parent.IsValid() {
+		t.Error("engine.Run has a parent, want a root span")
+	}
+	a := attrs(run)
+	if a[attrUserID].AsString() != "user-1" || a[attrConversationID].AsString() != "conv-1" || a[attrRequestID].AsString() != "req-1" {
+		t.Errorf("engine.Run attributes = %v, want the user, conversation and request IDs", run.Attributes)
+	}
+	if a[attrOutcome].AsString() != string(RunComplete) || a[attrInputTokens].AsInt64() != 2 {
+		t.Errorf("engine.Run attributes = %v, want a complete run of 2 input tokens", run.Attributes)
+	}
+
+	turns := byName["engine.turn"]
+	if len(turns) != 2 {
+		t.Fatalf("spans = %d engine.turn, want 2", len(turns))
+	}
+	turnIDs := make(map[int64]tracetest.SpanStub)
+	for _, turn := range turns {
+		if turn.Parent.SpanID() != run.SpanContext.SpanID() {
+			t.Errorf("turn %v isn't a child of the run", turn.Attributes)
+		}
+		a := attrs(turn)
+		if a[attrModel].AsString() != DefaultModel || a[attrOutputTokens].AsInt64() != 1 {
+			t.Errorf("turn attributes = %v, want the model and token counts", turn.Attributes)
+		}
+		turnIDs[a[attrTurn].AsInt64()] = turn
+	}
+	if stop := attrs(turnIDs[1])[attrStopReason].AsString(); stop != "tool_use" {
+		t.Errorf("turn 1 stop reason = %q, want tool_use", stop)
+	}
+
+	for tool, path := range map[string]string{
+		"get_balance":     "/nim/v1/agent/wallet/balance",
+		"get_vault_rates": "/nim/v1/agent/savings/vaults",
+	} {
+		calls := byName["engine.tool "+tool]
+		if len(calls) != 1 {
+			t.Fatalf("spans = %d for %s, want 1", len(calls), tool)
+		}
+		call := calls[0]
+		if call.Parent.SpanID() != turnIDs[1].SpanContext.SpanID() {
+			t.Errorf("%s isn't a child of turn 1", tool)
+		}
+		a := attrs(call)
+		if _, ok := a[attrToolDuration]; a[attrToolName].AsString() != tool || !ok || call.Status.Code == codes.Error {
+			t.Errorf("%s span = %v %v, want a successful call with its duration", tool, call.Attributes, call.Status)
+		}
+
+		var request *tracetest.SpanStub
+		for i, s := range byName["HTTPExecutor GET"] {
+			if s.Parent.SpanID() == call.SpanContext.SpanID() {
+				request = &byName["HTTPExecutor GET"][i]
+			}
+		}
+		if request == nil {
+			t.Fatalf("no HTTPExecutor GET span under %s", tool)
+		}
+		a = attrs(*request)
+		if a["url.path"].AsString() != path || a["http.response.status_code"].AsInt64() != http.StatusOK {
+			t.Errorf("%s request attributes = %v, want %s answered 200", tool, request.Attributes, path)
+		}
+		want := "00-" + request.SpanContext.TraceID().String() + "-" + request.SpanContext.SpanID().String() + "-01"
+		if got := traceparents[path]; got != want {
+			t.Errorf("%s traceparent = %q, want %q", path, got, want)
+		}
+	}
+
+	if len(spans) != 7 {
+		t.Errorf("recorded %d spans, want 7: a run, 2 turns, 2 tool calls and 2 requests", len(spans))
+	}
+}
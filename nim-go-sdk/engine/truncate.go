@@ -0,0 +1,156 @@
+package engine
+
+import (
+	"encoding/json"
+	"log"
+	"unicode/utf8"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// DefaultMaxToolResultBytes is the most tool result content sent to the
+// model per call, unless WithMaxToolResultBytes says otherwise.
+const DefaultMaxToolResultBytes = 16 << 10
+
+// WithMaxToolResultBytes caps the tool result content sent to the model per
+// call. Larger results are shortened (see truncateResult); the full result
+// is still recorded in Output.ToolsUsed and the audit log. A negative n
+// sends every result in full. Tools can opt out with core.FullResultTool.
+func WithMaxToolResultBytes(n int) Option {
+	return func(e *Engine) {
+		e.maxToolResult = n
+	}
+}
+
+// truncatedResult wraps a result shortened to fit the model's cap.
+type truncatedResult struct {
+	Truncated     bool            `json:"truncated"`
+	OriginalBytes int             `json:"original_bytes"`
+	Note          string          `json:"note"`
+	Result        json.RawMessage `json:"result,omitempty"`
+	Partial       string          `json:"partial,omitempty"`
+}
+
+const truncatedNote = "The result was too large to send in full. Long arrays keep only their first elements and long strings are cut short; don't assume anything missing here doesn't exist."
+
+// fitToolResult returns content, shortened if it is over the engine's cap
+// and the tool doesn't ask for full results.
+func (e *Engine) fitToolResult(tool core.Tool, content string) string {
+	limit := e.maxToolResult
+	if limit == 0 {
+		limit = DefaultMaxToolResultBytes
+	}
+	if limit < 0 || len(content) <= limit {
+		return content
+	}
+	if t, ok := tool.(core.FullResultTool); ok && t.FullResults() {
+		return content
+	}
+	truncated := truncateResult(content, limit)
+	log.Printf("Truncated %s result from %d to %d bytes", tool.Name(), len(content), len(truncated))
+	return truncated
+}
+
+// truncateResult shortens content to at most limit bytes, wrapped in a
+// truncatedResult. JSON keeps its structure: the largest array or string
+// is halved until the result fits. Anything else, or JSON that can't be
+// shortened enough that way, is cut as text.
+func truncateResult(content string, limit int) string {
+	wrapper := truncatedResult{Truncated: true, OriginalBytes: len(content), Note: truncatedNote}
+
+	var v interface{}
+	if json.Unmarshal([]byte(content), &v) == nil {
+		for {
+			data, err := json.Marshal(v)
+			if err != nil {
+				break
+			}
+			wrapper.Result = data
+			if out, _ := json.Marshal(wrapper); len(out) <= limit {
+				return string(out)
+			}
+			if !halveLargest(&v) {
+				break
+			}
+		}
+	}
+
+	// Cut as text, leaving room for the quotes and escaping.
+	wrapper.Result = nil
+	empty, _ := json.Marshal(wrapper)
+	partial := content
+	for budget := limit - len(empty) - len(`,"partial":""`); budget > 0; budget = budget * 3 / 4 {
+		partial = cutString(partial, budget)
+		wrapper.Partial = partial
+		out, _ := json.Marshal(wrapper)
+		if len(out) <= limit {
+			return string(out)
+		}
+	}
+	wrapper.Partial = ""
+	out, _ := json.Marshal(wrapper)
+	return string(out)
+}
+
+// halveLargest halves the array or string in v that marshals largest,
+// keeping arrays' first elements and strings' beginnings. It reports
+// whether there was anything left to halve.
+func halveLargest(v *interface{}) bool {
+	var largest interface{}
+	var replace func(interface{})
+	largestSize := 0
+	var visit func(value interface{}, set func(interface{}))
+	visit = func(value interface{}, set func(interface{})) {
+		size := 0
+		switch x := value.(type) {
+		case []interface{}:
+			for i := range x {
+				visit(x[i], func(halved interface{}) { x[i] = halved })
+			}
+			if len(x) > 1 {
+				size = marshaledSize(x)
+			}
+		case map[string]interface{}:
+			for k, child := range x {
+				visit(child, func(halved interface{}) { x[k] = halved })
+			}
+		case string:
+			if len(x) > minTruncatedString {
+				size = marshaledSize(x)
+			}
+		}
+		if size > largestSize {
+			largest, replace, largestSize = value, set, size
+		}
+	}
+	visit(*v, func(halved interface{}) { *v = halved })
+
+	switch x := largest.(type) {
+	case []interface{}:
+		replace(x[:len(x)/2])
+	case string:
+		replace(cutString(x, len(x)/2) + "…")
+	default:
+		return false
+	}
+	return true
+}
+
+// minTruncatedString is the shortest string halveLargest will cut.
+const minTruncatedString = 64
+
+func marshaledSize(v interface{}) int {
+	data, _ := json.Marshal(v)
+	return len(data)
+}
+
+// cutString returns the first at most n bytes of s, on a rune boundary.
+func cutString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func TestRun_TruncatesLargeResults(t *testing.T) {
+	payload := transactionsPayload(1, 200)
+	full, _ := json.Marshal(payload)
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		fullTool bool
+		wantMax  int // 0 if sent in full
+	}{
+		{name: "default cap", wantMax: DefaultMaxToolResultBytes},
+		{name: "configured cap", opts: []Option{WithMaxToolResultBytes(4 << 10)}, wantMax: 4 << 10},
+		{name: "no cap", opts: []Option{WithMaxToolResultBytes(-1)}},
+		{name: "tool opts out", fullTool: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := tools.New("get_transactions").
+				HandlerFunc(func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+					return payload, nil
+				})
+			if tt.fullTool {
+				builder = builder.FullResults()
+			}
+			audit := NewMemoryAuditLogger()
+			model := &readModel{}
+			registry := NewToolRegistry()
+			registry.Register(builder.Build())
+			eng := NewEngine(model.serve(t, "get_transactions", `{"limit":200}`), registry, append(tt.opts, WithAudit(audit))...)
+
+			out, err := eng.Run(context.Background(), &Input{
+				UserMessage: "show my transactions",
+				Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+			})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			sent := model.last()
+			if tt.wantMax == 0 {
+				if sent != string(full) {
+					t.Errorf("sent %d bytes, want the full %d", len(sent), len(full))
+				}
+			} else {
+				if len(sent) > tt.wantMax {
+					t.Errorf("sent %d bytes, want at most %d", len(sent), tt.wantMax)
+				}
+				var got struct {
+					Truncated     bool `json:"truncated"`
+					OriginalBytes int  `json:"original_bytes"`
+					Result        struct {
+						Transactions []map[string]interface{} `json:"transactions"`
+					} `json:"result"`
+				}
+				if err := json.Unmarshal([]byte(sent), &got); err != nil {
+					t.Fatalf("sent result is not JSON: %v\n%s", err, sent)
+				}
+				if !got.Truncated || got.OriginalBytes != len(full) {
+					t.Errorf("marker = truncated %v, original_bytes %d, want true, %d", got.Truncated, got.OriginalBytes, len(full))
+				}
+				txs := got.Result.Transactions
+				if len(txs) == 0 || len(txs) >= 200 || txs[0]["id"] != "tx_0200" {
+					t.Errorf("kept %d transactions starting %v, want the newest few", len(txs), txs)
+				}
+			}
+
+			// The full result is kept everywhere but the model's copy.
+			if len(out.ToolsUsed) != 1 {
+				t.Fatalf("ToolsUsed = %+v, want one call", out.ToolsUsed)
+			}
+			if used, _ := json.Marshal(out.ToolsUsed[0].Result); string(used) != string(full) {
+				t.Errorf("ToolsUsed result is %d bytes, want the full %d", len(used), len(full))
+			}
+			if entries := audit.Entries(); len(entries) != 1 || string(entries[0].ToolOutput) != string(full) {
+				t.Errorf("audit output isn't the full result")
+			}
+		})
+	}
+}
+
+func TestTruncateResult(t *testing.T) {
+	svg := "<svg>" + strings.Repeat(`<rect x="1" y="2"/>`, 2000) + "</svg>"
+	chart, _ := json.Marshal(map[string]interface{}{"chart_type": "line", "image": svg, "total_points": 30})
+
+	tests := []struct {
+		name    string
+		content string
+		limit   int
+		check   func(t *testing.T, got map[string]interface{})
+	}{
+		{
+			name:    "long string is cut",
+			content: string(chart),
+			limit:   2048,
+			check: func(t *testing.T, got map[string]interface{}) {
+				result, _ := got["result"].(map[string]interface{})
+				image, _ := result["image"].(string)
+				if result["chart_type"] != "line" || result["total_points"] != float64(30) || !strings.HasPrefix(svg, strings.TrimSuffix(image, "…")) || image == svg {
+					t.Errorf("result = %.200v, want the other fields whole and the image cut", result)
+				}
+			},
+		},
+		{
+			name:    "not JSON",
+			content: strings.Repeat("é plain text ", 1000),
+			limit:   1024,
+			check: func(t *testing.T, got map[string]interface{}) {
+				partial, _ := got["partial"].(string)
+				if partial == "" || !strings.HasPrefix(strings.Repeat("é plain text ", 1000), partial) {
+					t.Errorf("partial = %q, want the start of the text", partial)
+				}
+			},
+		},
+		{
+			name:    "many small fields",
+			content: manyFields(2000),
+			limit:   1024,
+			check: func(t *testing.T, got map[string]interface{}) {
+				if got["partial"] == nil {
+					t.Errorf("got %v, want the text cut when there is nothing to halve", got)
+				}
+			},
+		},
+		{
+			name:    "cap smaller than the marker",
+			content: string(chart),
+			limit:   10,
+			check: func(t *testing.T, got map[string]interface{}) {
+				if got["result"] != nil || got["partial"] != nil {
+					t.Errorf("got %v, want just the marker", got)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := truncateResult(tt.content, tt.limit)
+			if len(out) > tt.limit && tt.limit > 300 {
+				t.Errorf("truncated to %d bytes, want at most %d", len(out), tt.limit)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("truncated result is not JSON: %v\n%s", err, out)
+			}
+			if got["truncated"] != true || got["original_bytes"] != float64(len(tt.content)) {
+				t.Errorf("marker = %v, %v, want true, %d", got["truncated"], got["original_bytes"], len(tt.content))
+			}
+			tt.check(t, got)
+		})
+	}
+}
+
+func manyFields(n int) string {
+	fields := make(map[string]int, n)
+	for i := 0; i < n; i++ {
+		fields[fmt.Sprintf("field_%04d", i)] = i
+	}
+	data, _ := json.Marshal(fields)
+	return string(data)
+}
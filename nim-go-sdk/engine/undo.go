@@ -0,0 +1,190 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// DefaultUndoWindow is how long after execution an action can be undone.
+const DefaultUndoWindow = 30 * time.Minute
+
+// ExecutedAction is a confirmed write action that ran successfully.
+type ExecutedAction struct {
+	// UserID is the user who confirmed the action.
+	UserID string `json:"user_id"`
+
+	// Tool is the name of the write tool that ran.
+	Tool string `json:"tool"`
+
+	// Input is the tool parameters as JSON.
+	Input json.RawMessage `json:"input"`
+
+	// Result is the tool's result.
+	Result *core.ToolResult `json:"result"`
+
+	// ExecutedAt is when the action ran.
+	ExecutedAt time.Time `json:"executed_at"`
+}
+
+// ActionLog remembers the most recent executed write action per conversation,
+// so it can be undone.
+// This is an interface - implementations (e.g., Redis-backed) are provided
+// by the consuming application.
+type ActionLog interface {
+	// Record stores action as the conversation's most recent action.
+	Record(ctx context.Context, conversationID string, action *ExecutedAction) error
+
+	// Last returns the conversation's most recent action.
+	// Returns nil, nil if there is none.
+	Last(ctx context.Context, conversationID string) (*ExecutedAction, error)
+}
+
+// WithActionLog records confirmed write actions in log, per conversation.
+// Register UndoTool to let users undo the most recent one.
+func WithActionLog(log ActionLog) Option {
+	return func(e *Engine) {
+		e.actions = log
+	}
+}
+
+// recordAction stores a successful confirmed write in the action log.
+func (e *Engine) recordAction(ctx context.Context, userID string, tool core.Tool, input json.RawMessage, result *core.ToolResult) {
+	if e.actions == nil || result == nil || !result.Success || !tool.RequiresConfirmation() {
+		return
+	}
+	identity, ok := core.IdentityFromContext(ctx)
+	if !ok || identity.ConversationID == "" {
+		return
+	}
+	e.actions.Record(ctx, identity.ConversationID, &ExecutedAction{
+		UserID:     userID,
+		Tool:       tool.Name(),
+		Input:      input,
+		Result:     result,
+		ExecutedAt: time.Now(),
+	})
+}
+
+// UndoTool returns the undo_last_action tool. It looks up the conversation's
+// most recent action in log and proposes its inverse, as declared by the
+// tool (see core.ReversibleTool), for confirmation. The inverse is never
+// executed directly, and is subject to the same limits as any other write.
+// Actions older than window cannot be undone; zero means DefaultUndoWindow.
+func UndoTool(log ActionLog, registry *ToolRegistry, window time.Duration) core.Tool {
+	if window <= 0 {
+		window = DefaultUndoWindow
+	}
+	return tools.New("undo_last_action").
+		Description("Undo the most recent action in this conversation, e.g. when the user says 'oops, undo that'. Offers the reverse action for confirmation; some actions, like sending money, cannot be undone.").
+		Schema(tools.ObjectSchema(map[string]interface{}{})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			identity, _ := core.IdentityFromContext(ctx)
+			if identity.ConversationID == "" {
+				return &core.ToolResult{Success: false, Error: "undo is only available within a conversation"}, nil
+			}
+
+			last, err := log.Last(ctx, identity.ConversationID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to load last action: %v", err)}, nil
+			}
+			if last == nil || last.UserID != params.UserID {
+				return &core.ToolResult{Success: false, Error: "there is no recent action to undo"}, nil
+			}
+
+			tool, ok := registry.Get(last.Tool)
+			if !ok {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("%s is no longer available, so it cannot be undone", last.Tool)}, nil
+			}
+			summary := tool.GetSummary(last.Input)
+			if summary == "" {
+				summary = last.Tool
+			}
+
+			if age := time.Since(last.ExecutedAt); age > window {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("%q was %s ago, too long ago to undo", summary, age.Round(time.Minute))}, nil
+			}
+
+			var inverse core.InverseFunc
+			if r, ok := tool.(core.ReversibleTool); ok {
+				inverse = r.Inverse()
+			}
+			if inverse == nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("%q cannot be undone", summary)}, nil
+			}
+			inverseName, inverseInput, feasible := inverse(last.Input, last.Result)
+			if !feasible {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("%q cannot be undone", summary)}, nil
+			}
+
+			inverseTool, ok := registry.Get(inverseName)
+			if !ok {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("undo needs %s, which is not available", inverseName)}, nil
+			}
+			undoSummary := inverseTool.GetSummary(inverseInput)
+			if undoSummary == "" {
+				undoSummary = inverseName
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"undoing": summary,
+					"message": "The reverse action has been offered to the user for confirmation.",
+				},
+				Propose: &core.ProposedAction{
+					Tool:    inverseName,
+					Input:   inverseInput,
+					Summary: fmt.Sprintf("Undo: %s (reverses %q)", lowerFirst(undoSummary), summary),
+				},
+			}, nil
+		}).
+		Build()
+}
+
+// lowerFirst lowercases the first letter of s.
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+// MemoryActionLog is an in-memory implementation of ActionLog.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryActionLog struct {
+	mu      sync.RWMutex
+	actions map[string]*ExecutedAction // conversationID -> last action
+}
+
+// NewMemoryActionLog creates an in-memory action log.
+func NewMemoryActionLog() *MemoryActionLog {
+	return &MemoryActionLog{
+		actions: make(map[string]*ExecutedAction),
+	}
+}
+
+func (m *MemoryActionLog) Record(ctx context.Context, conversationID string, action *ExecutedAction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.actions[conversationID] = action
+	return nil
+}
+
+func (m *MemoryActionLog) Last(ctx context.Context, conversationID string) (*ExecutedAction, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.actions[conversationID], nil
+}
+
+// Verify MemoryActionLog implements ActionLog.
+var _ ActionLog = (*MemoryActionLog)(nil)
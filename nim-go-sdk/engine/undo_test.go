@@ -0,0 +1,200 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// savingsTools returns deposit, withdraw and send tools that succeed without
+// side effects, with the undo declarations used by the Liminal tools.
+func savingsTools() []core.Tool {
+	ok := func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true, Data: map[string]string{"status": "ok"}}, nil
+	}
+	swap := func(inverse string) core.InverseFunc {
+		return func(input json.RawMessage, result *core.ToolResult) (string, json.RawMessage, bool) {
+			return inverse, input, true
+		}
+	}
+	return []core.Tool{
+		tools.New("deposit_savings").
+			RequiresConfirmation().
+			SummaryTemplate("Deposit {{.amount}} {{.currency}} into savings").
+			Reversible(swap("withdraw_savings")).
+			Handler(ok).
+			Build(),
+		tools.New("withdraw_savings").
+			RequiresConfirmation().
+			SummaryTemplate("Withdraw {{.amount}} {{.currency}} from savings").
+			Reversible(swap("deposit_savings")).
+			Handler(ok).
+			Build(),
+		tools.New("send_money").
+			RequiresConfirmation().
+			SummaryTemplate("Send {{.amount}} {{.currency}} to {{.recipient}}").
+			Irreversible().
+			Handler(ok).
+			Build(),
+	}
+}
+
+// newUndoEngine returns an engine whose mock model calls undo_last_action.
+func newUndoEngine(t *testing.T, log ActionLog, opts ...Option) *Engine {
+	t.Helper()
+	registry := NewToolRegistry()
+	registry.RegisterAll(savingsTools()...)
+
+	eng := newTestEngine(t, "undo_last_action", UndoTool(log, registry, time.Hour), append(opts, WithActionLog(log))...)
+	eng.Registry().RegisterAll(savingsTools()...)
+	return eng
+}
+
+func conversationContext() context.Context {
+	return core.WithIdentity(context.Background(), core.Identity{UserID: "user-1", ConversationID: "conv-1"})
+}
+
+func runUndo(t *testing.T, eng *Engine) *Output {
+	t.Helper()
+	out, err := eng.Run(context.Background(), &Input{
+		UserMessage: "oops, undo that",
+		Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	return out
+}
+
+func TestUndo_DepositWithdrawRoundTrip(t *testing.T) {
+	log := NewMemoryActionLog()
+	eng := newUndoEngine(t, log)
+	ctx := conversationContext()
+	deposit := json.RawMessage(`{"amount":"50","currency":"USD"}`)
+
+	if _, err := eng.ExecuteTool(ctx, "user-1", "deposit_savings", deposit, "c-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	out := runUndo(t, eng)
+	if out.Type != OutputConfirmationNeeded {
+		t.Fatalf("Run() type = %v, want OutputConfirmationNeeded (error: %v)", out.Type, out.Error)
+	}
+	action := out.PendingAction
+	if action.Tool != "withdraw_savings" || string(action.Input) != string(deposit) {
+		t.Errorf("proposed %s %s, want withdraw_savings %s", action.Tool, action.Input, deposit)
+	}
+	if want := `Undo: withdraw 50 USD from savings (reverses "Deposit 50 USD into savings")`; action.Summary != want {
+		t.Errorf("Summary = %q, want %q", action.Summary, want)
+	}
+	if action.BlockID != "toolu_1" {
+		t.Errorf("BlockID = %q, want the undo_last_action tool_use block", action.BlockID)
+	}
+
+	// Proposing the undo does not execute it.
+	if last, _ := log.Last(ctx, "conv-1"); last.Tool != "deposit_savings" {
+		t.Errorf("last action = %s before confirmation, want deposit_savings", last.Tool)
+	}
+
+	// Confirming the undo records it, so undoing again re-deposits.
+	if _, err := eng.ExecuteTool(ctx, "user-1", action.Tool, action.Input, action.ID); err != nil {
+		t.Fatal(err)
+	}
+	out = runUndo(t, newUndoEngine(t, log))
+	if out.Type != OutputConfirmationNeeded || out.PendingAction.Tool != "deposit_savings" {
+		t.Errorf("undo of undo = %v %+v, want deposit_savings confirmation", out.Type, out.PendingAction)
+	}
+}
+
+func TestUndoTool_Refusals(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.RegisterAll(savingsTools()...)
+	registry.Register(tools.New("rename_account").RequiresConfirmation().Build())
+
+	tests := []struct {
+		name    string
+		last    *ExecutedAction
+		wantErr string
+	}{
+		{
+			name:    "nothing to undo",
+			wantErr: "no recent action",
+		},
+		{
+			name:    "irreversible",
+			last:    &ExecutedAction{UserID: "user-1", Tool: "send_money", Input: json.RawMessage(`{"amount":"5","currency":"USD","recipient":"@bob"}`), ExecutedAt: time.Now()},
+			wantErr: `"Send 5 USD to @bob" cannot be undone`,
+		},
+		{
+			name:    "undeclared",
+			last:    &ExecutedAction{UserID: "user-1", Tool: "rename_account", ExecutedAt: time.Now()},
+			wantErr: "cannot be undone",
+		},
+		{
+			name:    "stale",
+			last:    &ExecutedAction{UserID: "user-1", Tool: "deposit_savings", Input: json.RawMessage(`{"amount":"5","currency":"USD"}`), ExecutedAt: time.Now().Add(-2 * time.Hour)},
+			wantErr: "too long ago to undo",
+		},
+		{
+			name:    "another user",
+			last:    &ExecutedAction{UserID: "user-2", Tool: "deposit_savings", ExecutedAt: time.Now()},
+			wantErr: "no recent action",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := NewMemoryActionLog()
+			if tt.last != nil {
+				log.Record(context.Background(), "conv-1", tt.last)
+			}
+
+			result, err := UndoTool(log, registry, time.Hour).Execute(conversationContext(), &core.ToolParams{UserID: "user-1"})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result.Success || result.Propose != nil {
+				t.Fatalf("Execute() = %+v, want a refusal", result)
+			}
+			if !strings.Contains(result.Error, tt.wantErr) {
+				t.Errorf("Error = %q, want %q", result.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+// denyLimits rejects every action and remembers what it was asked about.
+type denyLimits struct {
+	seen []string
+}
+
+func (d *denyLimits) Authorize(ctx context.Context, action *core.PendingAction) error {
+	d.seen = append(d.seen, action.Tool)
+	return fmt.Errorf("over your daily limit")
+}
+
+func TestUndo_RespectsLimits(t *testing.T) {
+	log := NewMemoryActionLog()
+	log.Record(context.Background(), "conv-1", &ExecutedAction{
+		UserID:     "user-1",
+		Tool:       "deposit_savings",
+		Input:      json.RawMessage(`{"amount":"50","currency":"USD"}`),
+		ExecutedAt: time.Now(),
+	})
+
+	limits := &denyLimits{}
+	out := runUndo(t, newUndoEngine(t, log, WithTransferLimits(limits)))
+
+	if out.Type != OutputComplete || out.PendingAction != nil {
+		t.Fatalf("Run() = %v %+v, want no confirmation offered", out.Type, out.PendingAction)
+	}
+	if len(limits.seen) != 1 || limits.seen[0] != "withdraw_savings" {
+		t.Errorf("limits checked %v, want [withdraw_savings]", limits.seen)
+	}
+}
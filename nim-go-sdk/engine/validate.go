@@ -0,0 +1,27 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// checkInput validates a tool call's input against the tool's schema before
+// it executes or is offered for confirmation, so the model gets an error it
+// can correct, e.g. "invalid input: missing required field 'amount'
+// (expected string)", instead of a failure from the handler or the API.
+// Tools opt out with core.UncheckedInputTool.
+func checkInput(tool core.Tool, input json.RawMessage) error {
+	if t, ok := tool.(core.UncheckedInputTool); ok && t.SkipsValidation() {
+		return nil
+	}
+	schema := tool.Schema()
+	if len(schema) == 0 {
+		return nil
+	}
+	if err := core.ValidateInput(schema, input); err != nil {
+		return fmt.Errorf("invalid input: %w", err)
+	}
+	return nil
+}
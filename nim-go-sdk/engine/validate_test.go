@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// toolInputResponse asks for a tool, by name, with the given input.
+const toolInputResponse = `{
+	"id": "msg_1",
+	"type": "message",
+	"role": "assistant",
+	"model": "test-model",
+	"content": [{"type": "tool_use", "id": "toolu_1", "name": %q, "input": %s}],
+	"stop_reason": "tool_use",
+	"usage": {"input_tokens": 1, "output_tokens": 1}
+}`
+
+func TestRun_ValidatesToolInput(t *testing.T) {
+	schema := tools.ObjectSchema(map[string]interface{}{
+		"recipient": tools.StringProperty("Recipient"),
+		"amount":    tools.StringProperty("Amount"),
+		"currency":  tools.StringEnumProperty("Currency", "USD", "EUR"),
+	}, "recipient", "amount")
+
+	tests := []struct {
+		name         string
+		input        string
+		skip         bool
+		confirm      bool
+		wantExecuted bool
+		wantErr      string
+	}{
+		{name: "valid", input: `{"recipient":"@alice","amount":"45"}`, wantExecuted: true},
+		{name: "extra fields allowed", input: `{"recipient":"@alice","amount":"45","memo":"rent"}`, wantExecuted: true},
+		{name: "missing required", input: `{"recipient":"@alice"}`, wantErr: "invalid input: missing required field 'amount' (expected string)"},
+		{name: "wrong type", input: `{"recipient":"@alice","amount":45}`, wantErr: "invalid input: field 'amount' must be a string, got number"},
+		{name: "not in enum", input: `{"recipient":"@alice","amount":"45","currency":"GBP"}`, wantErr: `invalid input: field 'currency' must be one of USD, EUR, got "GBP"`},
+		{name: "skipped", input: `{"recipient":"@alice"}`, skip: true, wantExecuted: true},
+		{name: "write tool not offered", input: `{"amount":"45"}`, confirm: true, wantErr: "invalid input: missing required field 'recipient' (expected string)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			var followUp struct {
+				Messages []struct {
+					Content []struct {
+						Type    string `json:"type"`
+						IsError bool   `json:"is_error"`
+						Content []struct {
+							Text string `json:"text"`
+						} `json:"content"`
+					} `json:"content"`
+				} `json:"messages"`
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if calls.Add(1) == 1 {
+					fmt.Fprintf(w, toolInputResponse, "send_money", tt.input)
+					return
+				}
+				body, _ := io.ReadAll(r.Body)
+				json.Unmarshal(body, &followUp)
+				w.Write([]byte(textResponse))
+			}))
+			defer srv.Close()
+			client := anthropic.NewClient(option.WithAPIKey("test"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+
+			executed := false
+			builder := tools.New("send_money").
+				Schema(schema).
+				Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					executed = true
+					return &core.ToolResult{Success: true}, nil
+				})
+			if tt.skip {
+				builder.SkipValidation()
+			}
+			if tt.confirm {
+				builder.RequiresConfirmation()
+			}
+			registry := NewToolRegistry()
+			registry.Register(builder.Build())
+
+			out, err := NewEngine(&client, registry).Run(context.Background(), &Input{
+				UserMessage: "send 45 to alice",
+				Context:     core.NewContext("user-1", "sess-1", "conv-1", "req-1"),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if executed != tt.wantExecuted {
+				t.Errorf("executed = %v, want %v", executed, tt.wantExecuted)
+			}
+			if tt.wantErr == "" {
+				return
+			}
+			if out.Type != OutputComplete {
+				t.Errorf("output type = %v, want %v (invalid input is returned to the model)", out.Type, OutputComplete)
+			}
+			last := followUp.Messages[len(followUp.Messages)-1].Content
+			if len(last) != 1 || last[0].Type != "tool_result" || !last[0].IsError || len(last[0].Content) != 1 || last[0].Content[0].Text != tt.wantErr {
+				t.Errorf("tool result = %+v, want error %q", last, tt.wantErr)
+			}
+		})
+	}
+}
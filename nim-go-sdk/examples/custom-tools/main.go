@@ -163,6 +163,7 @@ func createCompleteTaskTool(store *TaskStore) core.Tool {
 		Schema(tools.ObjectSchema(map[string]interface{}{
 			"task_id": tools.StringProperty("ID of the task to complete"),
 		}, "task_id")).
+		NoCache().
 		HandlerFunc(func(ctx context.Context, input json.RawMessage) (interface{}, error) {
 			var params struct {
 				TaskID string `json:"task_id"`
@@ -6,60 +6,46 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
 	"strings"
 
+	"github.com/becomeliminal/nim-go-sdk/config"
 	"github.com/becomeliminal/nim-go-sdk/core"
-	"github.com/becomeliminal/nim-go-sdk/executor"
 	"github.com/becomeliminal/nim-go-sdk/server"
 	"github.com/becomeliminal/nim-go-sdk/tools"
 )
 
 func main() {
-	// Configuration from environment
-	anthropicKey := os.Getenv("ANTHROPIC_API_KEY")
-	if anthropicKey == "" {
-		log.Fatal("ANTHROPIC_API_KEY environment variable is required")
-	}
-
-	liminalBaseURL := os.Getenv("LIMINAL_BASE_URL")
-	if liminalBaseURL == "" {
-		liminalBaseURL = "https://api.liminal.cash"
+	// Configuration from environment (see the config package for all variables)
+	settings, err := config.FromEnv()
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	// Create HTTP executor for Liminal tools
-	// Authentication is automatic via JWT tokens from login flow
-	liminalExecutor := executor.NewHTTPExecutor(executor.HTTPExecutorConfig{
-		BaseURL: liminalBaseURL,
-	})
-	log.Println("Liminal API configured")
+	log.Printf("Configuration: %s", settings)
 
 	// Create server with authentication
-	srv, err := server.New(server.Config{
-		AnthropicKey:    anthropicKey,
-		SystemPrompt:    nimSystemPrompt,
-		Model:           "claude-sonnet-4-20250514",
-		MaxTokens:       4096,
-		LiminalExecutor: liminalExecutor, // SDK extracts JWT and forwards to Liminal
-		AuthFunc:        authenticateRequest,
+	// Authentication to Liminal is automatic via JWT tokens from login flow
+	srv, err := config.BuildServer(settings, func(cfg *server.Config) {
+		if cfg.SystemPrompt == "" {
+			cfg.SystemPrompt = nimSystemPrompt
+		}
+		cfg.AuthFunc = authenticateRequest
 	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// Add Liminal tools
-	srv.AddTools(tools.LiminalTools(liminalExecutor)...)
+	if liminalExecutor := srv.LiminalExecutor(); liminalExecutor != nil {
+		srv.AddTools(tools.LiminalTools(liminalExecutor)...)
+		log.Println("Liminal API configured")
+	}
 
 	// Add custom tools
 	srv.AddTool(createThinkTool())
 	srv.AddTool(createCalculateTool())
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
+	port := settings.Port
 	log.Printf("Starting Nim agent on :%s", port)
 	log.Printf("WebSocket endpoint: ws://localhost:%s/ws", port)
 	log.Printf("Health check: http://localhost:%s/health", port)
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/budget"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// ============================================================================
+// GOOGLE CALENDAR
+// ============================================================================
+// Adds the create_calendar_reminder tool's reminders to Google Calendar, and
+// deletes them when cancel_calendar_reminder cancels the series.
+// Set GOOGLE_CALENDAR_CREDENTIALS to a service account credentials file;
+// see CALENDAR_REMINDER_SETUP.md.
+
+const calendarTimeZone = "America/New_York"
+
+// googleCalendar implements budget.Calendar with the Google Calendar API.
+type googleCalendar struct {
+	credentialsFile string
+}
+
+func (g *googleCalendar) AddReminders(ctx context.Context, userID string, reminders []budget.Reminder) ([]string, error) {
+	srv, err := g.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(calendarTimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📝 Creating %d events...", len(reminders))
+	var ids []string
+	for i, r := range reminders {
+		// Reminders are planned in local time; keep the wall clock time in the calendar's zone
+		at := time.Date(r.At.Year(), r.At.Month(), r.At.Day(), r.At.Hour(), r.At.Minute(), 0, 0, loc).Format(time.RFC3339)
+		event := &calendar.Event{
+			Summary:     r.Title,
+			Description: r.Description,
+			Start:       &calendar.EventDateTime{DateTime: at, TimeZone: calendarTimeZone},
+			End:         &calendar.EventDateTime{DateTime: at, TimeZone: calendarTimeZone}, // 0-duration event (reminder)
+			Reminders: &calendar.EventReminders{
+				UseDefault: false,
+				Overrides: []*calendar.EventReminder{
+					{Method: "popup", Minutes: 0},
+					{Method: "email", Minutes: 60}, // 1 hour before
+				},
+			},
+		}
+
+		created, err := srv.Events.Insert("primary", event).Context(ctx).Do()
+		if err != nil {
+			return ids, fmt.Errorf("unable to create event %d: %v", i+1, err)
+		}
+		ids = append(ids, created.Id)
+		log.Printf("  ✓ Event %d/%d: %s on %s (ID: %s)", i+1, len(reminders), r.Title, r.At.Format("2006-01-02"), created.Id)
+	}
+	return ids, nil
+}
+
+func (g *googleCalendar) DeleteEvents(ctx context.Context, userID string, eventIDs []string) error {
+	srv, err := g.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Keep going past failures, so one missing event doesn't strand the rest
+	var failed int
+	var lastErr error
+	for _, id := range eventIDs {
+		if err := srv.Events.Delete("primary", id).Context(ctx).Do(); err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+		log.Printf("  ✓ Deleted event %s", id)
+	}
+	if failed > 0 {
+		return fmt.Errorf("unable to delete %d of %d events: %v", failed, len(eventIDs), lastErr)
+	}
+	return nil
+}
+
+func (g *googleCalendar) service(ctx context.Context) (*calendar.Service, error) {
+	srv, err := calendar.NewService(ctx, option.WithCredentialsFile(g.credentialsFile))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Calendar service: %v", err)
+	}
+	return srv, nil
+}
+
+// Verify googleCalendar implements budget.Calendar.
+var _ budget.Calendar = (*googleCalendar)(nil)
@@ -1,195 +1,89 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
+	"sync"
 	"time"
-
-	"github.com/joho/godotenv"
-)
-
-const (
-	processURL = "https://api.tabscanner.com/api/2/process"
-	resultBase = "https://api.tabscanner.com/api/result/"
 )
 
-type processResponse struct {
-	Token      string `json:"token"`
-	Status     string `json:"status"`
-	StatusCode int    `json:"status_code"`
-	Success    bool   `json:"success"`
-	Message    string `json:"message"`
-	Code       int    `json:"code"`
+// ============================================================================
+// RECEIPT UPLOADS
+// ============================================================================
+// Receipt images are uploaded here, then read and split by the
+// process_receipt_image and split_receipt tools (contrib/receipt)
+
+// receiptUploads stores receipt images uploaded through /upload-receipt
+// until the agent processes them (in production, use proper session storage).
+type receiptUploads struct {
+	mu     sync.Mutex
+	images map[string][]byte
+	latest string
 }
 
-type apiError struct {
-	Message    string `json:"message"`
-	Status     string `json:"status"`
-	StatusCode int    `json:"status_code"`
-	Success    bool   `json:"success"`
-	Code       int    `json:"code"`
+func newReceiptUploads() *receiptUploads {
+	return &receiptUploads{images: make(map[string][]byte)}
 }
 
-func main() {
-	// Load .env file
-	_ = godotenv.Load()
-
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s /path/to/receipt.jpg\n", os.Args[0])
-		os.Exit(2)
-	}
-
-	imagePath := os.Args[1]
-	apiKey := os.Getenv("TABSCANNER_APIKEY")
-	if apiKey == "" {
-		fmt.Fprintln(os.Stderr, "Missing env var TABSCANNER_APIKEY")
-		os.Exit(2)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
-	token, err := callProcess(ctx, apiKey, imagePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "process error: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Tabscanner recommends waiting ~5s before polling. :contentReference[oaicite:3]{index=3}
-	time.Sleep(5 * time.Second)
-
-	resultJSON, err := pollResult(ctx, apiKey, token, 1*time.Second, 30)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "result error: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Pretty print
-	var pretty bytes.Buffer
-	if err := json.Indent(&pretty, resultJSON, "", "  "); err != nil {
-		// If it's not valid JSON for some reason, just print raw.
-		fmt.Println(string(resultJSON))
-		return
-	}
-	fmt.Println(pretty.String())
+func (u *receiptUploads) add(image []byte) string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	id := fmt.Sprintf("img_%d", time.Now().UnixNano())
+	u.images[id] = image
+	u.latest = id
+	return id
 }
 
-// callProcess uploads the image to /api/2/process and returns the token.
-func callProcess(ctx context.Context, apiKey, imagePath string) (string, error) {
-	f, err := os.Open(imagePath)
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
-
-	var body bytes.Buffer
-	writer := multipart.NewWriter(&body)
-
-	// "file" is required. :contentReference[oaicite:4]{index=4}
-	part, err := writer.CreateFormFile("file", filepath.Base(imagePath))
-	if err != nil {
-		return "", err
-	}
-	if _, err := io.Copy(part, f); err != nil {
-		return "", err
-	}
-
-	// Optional form fields (examples):
-	// documentType: receipt|invoice|auto (default receipt) :contentReference[oaicite:5]{index=5}
-	_ = writer.WriteField("documentType", "receipt")
-	// region: ISO 2-alpha country code e.g. gb for United Kingdom :contentReference[oaicite:6]{index=6}
-	_ = writer.WriteField("region", "gb")
-	// defaultDateParsing: m/d or d/m (optional) :contentReference[oaicite:7]{index=7}
-	_ = writer.WriteField("defaultDateParsing", "d/m")
-
-	if err := writer.Close(); err != nil {
-		return "", err
+// get returns an uploaded image; id "latest" is the most recent upload.
+// It is a receipt.Images; uploads aren't kept per user.
+func (u *receiptUploads) get(ctx context.Context, userID, id string) ([]byte, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if id == "latest" {
+		id = u.latest
 	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, processURL, &body)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	// API key is passed via header named "apikey". :contentReference[oaicite:8]{index=8}
-	req.Header.Set("apikey", apiKey)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	respBytes, _ := io.ReadAll(resp.Body)
-
-	// Try parse as success response
-	var pr processResponse
-	if err := json.Unmarshal(respBytes, &pr); err == nil && pr.Token != "" {
-		return pr.Token, nil
-	}
-
-	// Otherwise parse error object if present
-	var ae apiError
-	if err := json.Unmarshal(respBytes, &ae); err == nil && ae.Message != "" {
-		return "", fmt.Errorf("tabscanner error (status_code=%d code=%d): %s", ae.StatusCode, ae.Code, ae.Message)
-	}
-
-	return "", fmt.Errorf("unexpected response (http=%d): %s", resp.StatusCode, string(respBytes))
+	image, ok := u.images[id]
+	return image, ok
 }
 
-// pollResult polls /api/result/{token} until result available or attempts exhausted.
-// Tabscanner returns status_code 202 when result is available, 301 when not yet. :contentReference[oaicite:9]{index=9}
-func pollResult(ctx context.Context, apiKey, token string, interval time.Duration, maxAttempts int) ([]byte, error) {
-	if token == "" {
-		return nil, errors.New("empty token")
-	}
-
-	url := resultBase + token
-
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-		if err != nil {
-			return nil, err
+// handler accepts a receipt image as the "image" field of a multipart form.
+func (u *receiptUploads) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
 		}
-		req.Header.Set("apikey", apiKey) // :contentReference[oaicite:10]{index=10}
-
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return nil, err
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
 		}
-		b, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
 
-		// Peek status_code if present
-		var meta struct {
-			StatusCode int    `json:"status_code"`
-			Status     string `json:"status"`
-			Code       int    `json:"code"`
-			Message    string `json:"message"`
+		if err := r.ParseMultipartForm(10 << 20); err != nil { // 10 MB max
+			http.Error(w, "Failed to parse form", http.StatusBadRequest)
+			return
 		}
-		_ = json.Unmarshal(b, &meta)
-
-		// TabScanner returns status_code=3 with status="done" when result is ready
-		if meta.StatusCode == 3 && meta.Status == "done" {
-			return b, nil
+		file, _, err := r.FormFile("image")
+		if err != nil {
+			http.Error(w, "Failed to get image", http.StatusBadRequest)
+			return
 		}
-		// status_code=2 means still processing
-		if meta.StatusCode == 2 {
-			time.Sleep(interval)
-			continue
+		defer file.Close()
+		image, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, "Failed to read image", http.StatusInternalServerError)
+			return
 		}
 
-		// Any other code: treat as error and surface payload
-		return nil, fmt.Errorf("unexpected result status_code=%d attempt=%d/%d payload=%s", meta.StatusCode, attempt, maxAttempts, string(b))
-	}
-
-	return nil, fmt.Errorf("result not available after %d attempts", maxAttempts)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"imageId": u.add(image),
+			"message": "Image uploaded successfully. Ask the assistant to process it!",
+		})
+	})
 }
@@ -0,0 +1,112 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the layout of get_transactions' start_date and end_date.
+const DateLayout = "2006-01-02"
+
+// DateRange is the window of transactions get_transactions returns for its
+// start_date and end_date: [Start, End). A zero bound is open.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsZero reports whether the range has no bounds, selecting everything.
+func (r DateRange) IsZero() bool {
+	return r.Start.IsZero() && r.End.IsZero()
+}
+
+// Contains reports whether t falls in the range. Unparseable (zero) times
+// fall outside any bounded range.
+func (r DateRange) Contains(t time.Time) bool {
+	if r.IsZero() {
+		return true
+	}
+	if t.IsZero() {
+		return false
+	}
+	return (r.Start.IsZero() || !t.Before(r.Start)) && (r.End.IsZero() || t.Before(r.End))
+}
+
+// ParseDateRange parses get_transactions' start_date and end_date. Both are
+// optional and inclusive: YYYY-MM-DD dates are whole days in UTC, so
+// end_date "2024-03-31" includes all of March 31st. RFC 3339 timestamps are
+// also accepted, as exact bounds.
+func ParseDateRange(start, end string) (DateRange, error) {
+	var r DateRange
+	var err error
+	if start != "" {
+		if r.Start, err = parseDateBound(start, false); err != nil {
+			return DateRange{}, fmt.Errorf("start_date must be a date such as 2024-03-01, got %q", start)
+		}
+	}
+	if end != "" {
+		if r.End, err = parseDateBound(end, true); err != nil {
+			return DateRange{}, fmt.Errorf("end_date must be a date such as 2024-03-31, got %q", end)
+		}
+	}
+	if !r.Start.IsZero() && !r.End.IsZero() && !r.Start.Before(r.End) {
+		return DateRange{}, fmt.Errorf("end_date must not be before start_date")
+	}
+	return r, nil
+}
+
+func parseDateBound(s string, end bool) (time.Time, error) {
+	if t, err := time.Parse(DateLayout, s); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil && end {
+		// An exact end bound is inclusive too.
+		t = t.Add(time.Nanosecond)
+	}
+	return t, err
+}
+
+// dateRangeInput parses the start_date and end_date of a tool input.
+func dateRangeInput(input json.RawMessage) (DateRange, error) {
+	var params struct {
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+	}
+	if len(input) > 0 {
+		json.Unmarshal(input, &params)
+	}
+	return ParseDateRange(params.StartDate, params.EndDate)
+}
+
+// FilterTransactions returns the transactions created in the range.
+func FilterTransactions(txs []Transaction, r DateRange) []Transaction {
+	if r.IsZero() {
+		return txs
+	}
+	filtered := make([]Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if r.Contains(tx.CreatedTime()) {
+			filtered = append(filtered, tx)
+		}
+	}
+	return filtered
+}
+
+// filterTransactionsData applies the range to a get_transactions response,
+// in case the backend ignored it.
+func filterTransactionsData(data json.RawMessage, r DateRange) (json.RawMessage, error) {
+	if r.IsZero() {
+		return data, nil
+	}
+	var resp GetTransactionsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse get_transactions response: %w", err)
+	}
+	resp.Transactions = FilterTransactions(resp.Transactions, r)
+	return json.Marshal(resp)
+}
@@ -0,0 +1,144 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestParseDateRange(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse(DateLayout, s)
+		return d
+	}
+	tests := []struct {
+		name       string
+		start, end string
+		want       DateRange
+		wantErr    string
+	}{
+		{name: "open", want: DateRange{}},
+		{name: "whole days", start: "2024-03-01", end: "2024-03-31", want: DateRange{Start: day("2024-03-01"), End: day("2024-04-01")}},
+		{name: "single day", start: "2024-03-05", end: "2024-03-05", want: DateRange{Start: day("2024-03-05"), End: day("2024-03-06")}},
+		{name: "start only", start: "2024-03-01", want: DateRange{Start: day("2024-03-01")}},
+		{
+			name: "timestamps", start: "2024-03-01T12:00:00Z", end: "2024-03-02T12:00:00Z",
+			want: DateRange{Start: day("2024-03-01").Add(12 * time.Hour), End: day("2024-03-02").Add(12*time.Hour + time.Nanosecond)},
+		},
+		{name: "invalid start", start: "03/01/2024", wantErr: "start_date must be a date"},
+		{name: "invalid end", end: "tomorrow", wantErr: "end_date must be a date"},
+		{name: "reversed", start: "2024-03-31", end: "2024-03-01", wantErr: "end_date must not be before start_date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDateRange(tt.start, tt.end)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Start.Equal(tt.want.Start) || !got.End.Equal(tt.want.End) {
+				t.Errorf("ParseDateRange(%q, %q) = %v, want %v", tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPExecutor_TransactionDates(t *testing.T) {
+	var queries []url.Values
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		// The gateway ignores the dates; the executor applies them.
+		json.NewEncoder(w).Encode(GetTransactionsResponse{Transactions: []Transaction{
+			{ID: "apr-1", CreatedAt: "2024-04-01T00:00:00Z"},
+			{ID: "mar-31", CreatedAt: "2024-03-31T23:59:59Z"},
+			{ID: "mar-1", CreatedAt: "2024-03-01T00:00:00Z"},
+			{ID: "feb-29", CreatedAt: "2024-02-29T23:59:59Z"},
+		}})
+	}))
+	defer gateway.Close()
+	exec := NewHTTPExecutor(HTTPExecutorConfig{BaseURL: gateway.URL})
+
+	tests := []struct {
+		name      string
+		input     string
+		wantIDs   string
+		wantQuery url.Values
+		wantErr   string
+	}{
+		{
+			name:      "no dates",
+			input:     `{"limit":10}`,
+			wantIDs:   "apr-1,mar-31,mar-1,feb-29",
+			wantQuery: url.Values{"limit": {"10"}},
+		},
+		{
+			name:      "date range",
+			input:     `{"limit":10,"start_date":"2024-03-01","end_date":"2024-03-31"}`,
+			wantIDs:   "mar-31,mar-1",
+			wantQuery: url.Values{"limit": {"10"}, "start_date": {"2024-03-01"}, "end_date": {"2024-03-31"}},
+		},
+		{
+			name:      "timestamp is escaped",
+			input:     `{"start_date":"2024-03-31T23:00:00+01:00"}`,
+			wantIDs:   "apr-1,mar-31",
+			wantQuery: url.Values{"start_date": {"2024-03-31T23:00:00+01:00"}},
+		},
+		{
+			name:    "invalid date",
+			input:   `{"start_date":"last week"}`,
+			wantErr: "start_date must be a date",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries = nil
+			resp, err := exec.Execute(context.Background(), &core.ExecuteRequest{
+				UserID: "user-1",
+				Tool:   "get_transactions",
+				Input:  json.RawMessage(tt.input),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" {
+				if resp.Success || !strings.Contains(resp.Error, tt.wantErr) {
+					t.Errorf("response = %+v, want error %q", resp, tt.wantErr)
+				}
+				if len(queries) != 0 {
+					t.Error("invalid dates should not reach the gateway")
+				}
+				return
+			}
+			if !resp.Success {
+				t.Fatalf("response error: %s", resp.Error)
+			}
+
+			var page GetTransactionsResponse
+			if err := json.Unmarshal(resp.Data, &page); err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, tx := range page.Transactions {
+				ids = append(ids, tx.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.wantIDs {
+				t.Errorf("transactions = %s, want %s", got, tt.wantIDs)
+			}
+			if len(queries) != 1 || queries[0].Encode() != tt.wantQuery.Encode() {
+				t.Errorf("query = %v, want %v", queries, tt.wantQuery)
+			}
+		})
+	}
+}
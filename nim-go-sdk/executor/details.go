@@ -0,0 +1,47 @@
+package executor
+
+import (
+	"encoding/json"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// writeDirections is the direction of each Liminal write tool's money.
+var writeDirections = map[string]string{
+	"send_money":       core.DirectionOutgoing,
+	"request_money":    core.DirectionIncoming,
+	"deposit_savings":  core.DirectionToSavings,
+	"withdraw_savings": core.DirectionFromSavings,
+}
+
+// WriteDetails returns the confirmation details of a Liminal write tool's
+// input, in core.Details' canonical keys: its amount, currency and
+// direction, and recipient and note if it has them. Other tools are
+// described by their input, as core.InputDetails does.
+func WriteDetails(tool string, input json.RawMessage) core.Details {
+	direction, ok := writeDirections[tool]
+	if !ok {
+		return core.InputDetails(input)
+	}
+
+	var params struct {
+		Recipient string `json:"recipient"`
+		Amount    string `json:"amount"`
+		Currency  string `json:"currency"`
+		Note      string `json:"note"`
+	}
+	json.Unmarshal(input, &params)
+
+	details := core.Details{
+		core.DetailAmount:    params.Amount,
+		core.DetailCurrency:  params.Currency,
+		core.DetailDirection: direction,
+	}
+	if params.Recipient != "" {
+		details[core.DetailRecipient] = params.Recipient
+	}
+	if params.Note != "" {
+		details[core.DetailNote] = params.Note
+	}
+	return details
+}
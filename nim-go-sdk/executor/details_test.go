@@ -0,0 +1,62 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+func TestGRPCExecutor_ConfirmationDetails(t *testing.T) {
+	tests := []struct {
+		tool  string
+		input string
+		want  core.Details
+	}{
+		{
+			tool:  "send_money",
+			input: `{"recipient":"@alice","amount":"50","currency":"USD","note":"rent"}`,
+			want:  core.Details{"amount": "50", "currency": "USD", "recipient": "@alice", "direction": "outgoing", "note": "rent"},
+		},
+		{
+			tool:  "deposit_savings",
+			input: `{"amount":"100","currency":"EUR"}`,
+			want:  core.Details{"amount": "100", "currency": "EUR", "direction": "to_savings"},
+		},
+		{
+			tool:  "withdraw_savings",
+			input: `{"amount":"20","currency":"USD"}`,
+			want:  core.Details{"amount": "20", "currency": "USD", "direction": "from_savings"},
+		},
+		{
+			tool:  "request_money",
+			input: requestMoneyInput,
+			want:  core.Details{"amount": "20", "currency": "USD", "recipient": "@bob", "direction": "incoming", "note": "pizza"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			confirmations := store.NewMemoryConfirmations()
+			exec := NewGRPCExecutor(GRPCExecutorConfig{Confirmations: confirmations})
+
+			resp, err := exec.ExecuteWrite(context.Background(), &core.ExecuteRequest{
+				UserID: "user-1",
+				Tool:   tt.tool,
+				Input:  json.RawMessage(tt.input),
+			})
+			if err != nil || !resp.RequiresConfirmation {
+				t.Fatalf("ExecuteWrite() = %+v, %v, want a confirmation", resp, err)
+			}
+			if !reflect.DeepEqual(resp.Confirmation.Details, tt.want) {
+				t.Errorf("confirmation details = %v, want %v", resp.Confirmation.Details, tt.want)
+			}
+			stored, err := confirmations.Get(context.Background(), "user-1", resp.Confirmation.ID)
+			if err != nil || !reflect.DeepEqual(stored.Details, tt.want) {
+				t.Errorf("stored details = %v, %v, want %v", stored.Details, err, tt.want)
+			}
+		})
+	}
+}
@@ -31,16 +31,28 @@ type WalletService interface {
 }
 
 // PaymentService defines the interface for payment operations.
+// With dryRun, Send validates the payment and estimates its fee and arrival
+// without sending it, returning a SendMoneyResponse with DryRun set, or an
+// error wrapping core.ErrPreviewUnavailable if it can't.
+// Request asks recipient to pay the user, returning a RequestMoneyResponse.
+// ListRequests returns the user's pending requests as a
+// GetPaymentRequestsResponse, only those in direction ("incoming" or
+// "outgoing") if it's set.
 type PaymentService interface {
-	Send(ctx context.Context, userID, recipient, amount, currency string, note *string) (json.RawMessage, error)
+	Send(ctx context.Context, userID, recipient, amount, currency string, note *string, dryRun bool) (json.RawMessage, error)
+	Request(ctx context.Context, userID, recipient, amount, currency string, note *string) (json.RawMessage, error)
+	ListRequests(ctx context.Context, userID string, direction *string) (json.RawMessage, error)
 }
 
 // SavingsService defines the interface for savings operations.
+// Deposit and Withdraw preview the operation with dryRun, as
+// PaymentService.Send does.
 type SavingsService interface {
 	GetBalance(ctx context.Context, userID string, vault *string) (json.RawMessage, error)
+	GetHistory(ctx context.Context, userID string, currency *string, days int) (json.RawMessage, error)
 	GetVaultRates(ctx context.Context) (json.RawMessage, error)
-	Deposit(ctx context.Context, userID, amount, currency string) (json.RawMessage, error)
-	Withdraw(ctx context.Context, userID, amount, currency string) (json.RawMessage, error)
+	Deposit(ctx context.Context, userID, amount, currency string, dryRun bool) (json.RawMessage, error)
+	Withdraw(ctx context.Context, userID, amount, currency string, dryRun bool) (json.RawMessage, error)
 }
 
 // UserService defines the interface for user operations.
@@ -78,6 +90,8 @@ func NewGRPCExecutor(cfg GRPCExecutorConfig) *GRPCExecutor {
 
 // Execute runs a read-only tool.
 func (e *GRPCExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	core.CheckContext(ctx, "GRPCExecutor.Execute "+req.Tool)
+
 	var data json.RawMessage
 	var err error
 
@@ -86,14 +100,20 @@ func (e *GRPCExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*
 		data, err = e.executeGetBalance(ctx, req)
 	case "get_savings_balance":
 		data, err = e.executeGetSavingsBalance(ctx, req)
+	case "get_savings_history":
+		data, err = e.executeGetSavingsHistory(ctx, req)
 	case "get_vault_rates":
 		data, err = e.executeGetVaultRates(ctx, req)
 	case "get_transactions":
 		data, err = e.executeGetTransactions(ctx, req)
+	case "get_spending_summary":
+		data, err = e.executeGetSpendingSummary(ctx, req)
 	case "get_profile":
 		data, err = e.executeGetProfile(ctx, req)
 	case "search_users":
 		data, err = e.executeSearchUsers(ctx, req)
+	case "get_payment_requests":
+		data, err = e.executeGetPaymentRequests(ctx, req)
 	default:
 		return &core.ExecuteResponse{
 			Success: false,
@@ -114,8 +134,14 @@ func (e *GRPCExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*
 	}, nil
 }
 
-// ExecuteWrite runs a write tool that may require confirmation.
+// ExecuteWrite runs a write tool that may require confirmation. Dry runs
+// go straight to the service as previews, without a confirmation.
 func (e *GRPCExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	core.CheckContext(ctx, "GRPCExecutor.ExecuteWrite "+req.Tool)
+	if req.DryRun {
+		return e.write(ctx, req.UserID, req.Tool, req.Input, true)
+	}
+
 	// Generate confirmation for write operations
 	confirmationID := uuid.New().String()
 
@@ -127,6 +153,8 @@ func (e *GRPCExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteReques
 		summary = e.generateDepositSummary(req.Input)
 	case "withdraw_savings":
 		summary = e.generateWithdrawSummary(req.Input)
+	case "request_money":
+		summary = e.generateRequestMoneySummary(req.Input)
 	default:
 		return &core.ExecuteResponse{
 			Success: false,
@@ -138,11 +166,17 @@ func (e *GRPCExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteReques
 		ID:        confirmationID,
 		UserID:    req.UserID,
 		Tool:      req.Tool,
-		Input:     req.Input,
 		Summary:   summary,
+		Details:   WriteDetails(req.Tool, req.Input),
 		CreatedAt: time.Now().Unix(),
 		ExpiresAt: time.Now().Add(10 * time.Minute).Unix(),
 	}
+	if err := action.SetInput(req.Input); err != nil {
+		return &core.ExecuteResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
 
 	if e.confirmations != nil {
 		if err := e.confirmations.Store(ctx, action); err != nil {
@@ -159,6 +193,7 @@ func (e *GRPCExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteReques
 		Confirmation: &core.ConfirmationDetails{
 			ID:        confirmationID,
 			Summary:   summary,
+			Details:   action.Details,
 			ExpiresAt: action.ExpiresAt,
 		},
 	}, nil
@@ -166,6 +201,8 @@ func (e *GRPCExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteReques
 
 // Confirm executes a previously confirmed write operation.
 func (e *GRPCExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	core.CheckContext(ctx, "GRPCExecutor.Confirm")
+
 	if e.confirmations == nil {
 		return &core.ExecuteResponse{
 			Success: false,
@@ -181,20 +218,34 @@ func (e *GRPCExecutor) Confirm(ctx context.Context, userID, confirmationID strin
 			Error:   err.Error(),
 		}, nil
 	}
+	if err := action.VerifyInput(); err != nil {
+		return &core.ExecuteResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
 
 	// Execute the confirmed operation
+	return e.write(ctx, action.UserID, action.Tool, action.Input, false)
+}
+
+// write executes a write tool, or previews it with dryRun.
+func (e *GRPCExecutor) write(ctx context.Context, userID, tool string, input json.RawMessage, dryRun bool) (*core.ExecuteResponse, error) {
 	var data json.RawMessage
-	switch action.Tool {
+	var err error
+	switch tool {
 	case "send_money":
-		data, err = e.executeSendMoney(ctx, action.UserID, action.Input)
+		data, err = e.executeSendMoney(ctx, userID, input, dryRun)
 	case "deposit_savings":
-		data, err = e.executeDepositSavings(ctx, action.UserID, action.Input)
+		data, err = e.executeDepositSavings(ctx, userID, input, dryRun)
 	case "withdraw_savings":
-		data, err = e.executeWithdrawSavings(ctx, action.UserID, action.Input)
+		data, err = e.executeWithdrawSavings(ctx, userID, input, dryRun)
+	case "request_money":
+		data, err = e.executeRequestMoney(ctx, userID, input, dryRun)
 	default:
 		return &core.ExecuteResponse{
 			Success: false,
-			Error:   fmt.Sprintf("unknown tool: %s", action.Tool),
+			Error:   fmt.Sprintf("unknown tool: %s", tool),
 		}, nil
 	}
 
@@ -248,6 +299,28 @@ func (e *GRPCExecutor) executeGetSavingsBalance(ctx context.Context, req *core.E
 	return e.savings.GetBalance(ctx, req.UserID, input.Vault)
 }
 
+func (e *GRPCExecutor) executeGetSavingsHistory(ctx context.Context, req *core.ExecuteRequest) (json.RawMessage, error) {
+	if e.savings == nil {
+		return nil, fmt.Errorf("savings service not configured")
+	}
+
+	var input struct {
+		Currency *string `json:"currency"`
+		Days     int     `json:"days"`
+	}
+	json.Unmarshal(req.Input, &input)
+
+	days := input.Days
+	if days == 0 {
+		days = 30
+	}
+	if days < 0 || days > MaxSavingsHistoryDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", MaxSavingsHistoryDays)
+	}
+
+	return e.savings.GetHistory(ctx, req.UserID, input.Currency, days)
+}
+
 func (e *GRPCExecutor) executeGetVaultRates(ctx context.Context, req *core.ExecuteRequest) (json.RawMessage, error) {
 	if e.savings == nil {
 		return nil, fmt.Errorf("savings service not configured")
@@ -271,8 +344,38 @@ func (e *GRPCExecutor) executeGetTransactions(ctx context.Context, req *core.Exe
 	if limit == 0 {
 		limit = 10
 	}
+	dates, err := dateRangeInput(req.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := e.ledger.GetTransactions(ctx, req.UserID, limit, input.Type)
+	if err != nil {
+		return nil, err
+	}
+	return filterTransactionsData(data, dates)
+}
 
-	return e.ledger.GetTransactions(ctx, req.UserID, limit, input.Type)
+// executeGetSpendingSummary aggregates the user's recent transactions, as
+// the gateway's summary endpoint does.
+func (e *GRPCExecutor) executeGetSpendingSummary(ctx context.Context, req *core.ExecuteRequest) (json.RawMessage, error) {
+	if e.ledger == nil {
+		return nil, fmt.Errorf("ledger service not configured")
+	}
+
+	days, groupBy, err := spendingSummaryInput(req.Input)
+	if err != nil {
+		return nil, err
+	}
+	data, err := e.ledger.GetTransactions(ctx, req.UserID, spendingSummaryFetchLimit, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := summarizeTransactionsData(data, days, groupBy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
 }
 
 func (e *GRPCExecutor) executeGetProfile(ctx context.Context, req *core.ExecuteRequest) (json.RawMessage, error) {
@@ -298,9 +401,45 @@ func (e *GRPCExecutor) executeSearchUsers(ctx context.Context, req *core.Execute
 	return e.users.Search(ctx, input.Query)
 }
 
+func (e *GRPCExecutor) executeGetPaymentRequests(ctx context.Context, req *core.ExecuteRequest) (json.RawMessage, error) {
+	if e.payments == nil {
+		return nil, fmt.Errorf("payment service not configured")
+	}
+
+	var input struct {
+		Direction *string `json:"direction"`
+	}
+	json.Unmarshal(req.Input, &input)
+
+	return e.payments.ListRequests(ctx, req.UserID, input.Direction)
+}
+
 // Write operation implementations
 
-func (e *GRPCExecutor) executeSendMoney(ctx context.Context, userID string, input json.RawMessage) (json.RawMessage, error) {
+func (e *GRPCExecutor) executeSendMoney(ctx context.Context, userID string, input json.RawMessage, dryRun bool) (json.RawMessage, error) {
+	if e.payments == nil {
+		return nil, fmt.Errorf("payment service not configured")
+	}
+
+	var params struct {
+		Recipient string  `json:"recipient"`
+		Amount    string  `json:"amount"`
+		Currency  string  `json:"currency"`
+		Note      *string `json:"note"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return nil, err
+	}
+
+	return e.payments.Send(ctx, userID, params.Recipient, params.Amount, params.Currency, params.Note, dryRun)
+}
+
+// executeRequestMoney asks the recipient to pay the user. There's nothing
+// to preview: a request moves no money until the recipient pays it.
+func (e *GRPCExecutor) executeRequestMoney(ctx context.Context, userID string, input json.RawMessage, dryRun bool) (json.RawMessage, error) {
+	if dryRun {
+		return nil, fmt.Errorf("%w: requests move no money", core.ErrPreviewUnavailable)
+	}
 	if e.payments == nil {
 		return nil, fmt.Errorf("payment service not configured")
 	}
@@ -315,10 +454,10 @@ func (e *GRPCExecutor) executeSendMoney(ctx context.Context, userID string, inpu
 		return nil, err
 	}
 
-	return e.payments.Send(ctx, userID, params.Recipient, params.Amount, params.Currency, params.Note)
+	return e.payments.Request(ctx, userID, params.Recipient, params.Amount, params.Currency, params.Note)
 }
 
-func (e *GRPCExecutor) executeDepositSavings(ctx context.Context, userID string, input json.RawMessage) (json.RawMessage, error) {
+func (e *GRPCExecutor) executeDepositSavings(ctx context.Context, userID string, input json.RawMessage, dryRun bool) (json.RawMessage, error) {
 	if e.savings == nil {
 		return nil, fmt.Errorf("savings service not configured")
 	}
@@ -331,10 +470,10 @@ func (e *GRPCExecutor) executeDepositSavings(ctx context.Context, userID string,
 		return nil, err
 	}
 
-	return e.savings.Deposit(ctx, userID, params.Amount, params.Currency)
+	return e.savings.Deposit(ctx, userID, params.Amount, params.Currency, dryRun)
 }
 
-func (e *GRPCExecutor) executeWithdrawSavings(ctx context.Context, userID string, input json.RawMessage) (json.RawMessage, error) {
+func (e *GRPCExecutor) executeWithdrawSavings(ctx context.Context, userID string, input json.RawMessage, dryRun bool) (json.RawMessage, error) {
 	if e.savings == nil {
 		return nil, fmt.Errorf("savings service not configured")
 	}
@@ -347,7 +486,7 @@ func (e *GRPCExecutor) executeWithdrawSavings(ctx context.Context, userID string
 		return nil, err
 	}
 
-	return e.savings.Withdraw(ctx, userID, params.Amount, params.Currency)
+	return e.savings.Withdraw(ctx, userID, params.Amount, params.Currency, dryRun)
 }
 
 // Summary generation helpers
@@ -382,3 +521,14 @@ func (e *GRPCExecutor) generateWithdrawSummary(input json.RawMessage) string {
 
 	return fmt.Sprintf("Withdraw %s %s from savings", params.Amount, params.Currency)
 }
+
+func (e *GRPCExecutor) generateRequestMoneySummary(input json.RawMessage) string {
+	var params struct {
+		Recipient string `json:"recipient"`
+		Amount    string `json:"amount"`
+		Currency  string `json:"currency"`
+	}
+	json.Unmarshal(input, &params)
+
+	return fmt.Sprintf("Request %s %s from %s", params.Amount, params.Currency, params.Recipient)
+}
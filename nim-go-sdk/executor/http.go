@@ -5,22 +5,36 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
+	"net/url"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/becomeliminal/nim-go-sdk/core"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // HTTPExecutor implements ToolExecutor by calling the agent_gateway over HTTP.
 // This is the public implementation used by external developers.
+// Requests use the credential on their context (core.WithCredential) if
+// there is one, so a single executor can serve many users at once.
 type HTTPExecutor struct {
-	baseURL    string
-	apiKey     string  // Deprecated: use jwtToken
-	jwtToken   string  // JWT for Bearer authentication
-	httpClient *http.Client
+	baseURL      string
+	apiKey       string // Deprecated: use jwtToken
+	mu           sync.RWMutex
+	jwtToken     string // JWT for requests without a credential on their context
+	httpClient   *http.Client
+	timeout      time.Duration
+	toolTimeouts map[string]time.Duration
+	maxRetries   int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	dryRun       bool
+	tracer       trace.Tracer // nil unless TracerProvider is configured
 }
 
 // HTTPExecutorConfig configures the HTTP executor.
@@ -35,8 +49,38 @@ type HTTPExecutorConfig struct {
 	// JWTToken is the JWT token for Bearer authentication.
 	JWTToken string
 
-	// Timeout is the HTTP request timeout.
+	// Timeout is the HTTP request timeout. Each retry gets a fresh timeout.
 	Timeout time.Duration
+
+	// ToolTimeouts overrides Timeout for individual tools, by tool name,
+	// e.g. a short timeout for get_balance and a longer one for send_money.
+	ToolTimeouts map[string]time.Duration
+
+	// MaxRetries is how many times a read is retried after a network error,
+	// timeout, 429 or 5xx response. Zero disables retries. Writes (POSTs such
+	// as send_money) are never retried, since they may have taken effect.
+	MaxRetries int
+
+	// RetryBackoff is the wait before the first retry, doubling for each
+	// retry after it. A Retry-After header from the gateway takes precedence.
+	// Defaults to 200ms.
+	RetryBackoff time.Duration
+
+	// MaxRetryBackoff caps the wait between retries, including one asked
+	// for by Retry-After. Defaults to 5s.
+	MaxRetryBackoff time.Duration
+
+	// DryRun is set if the gateway supports dry runs of writes. Without
+	// it, previews fail with core.ErrPreviewUnavailable instead of being
+	// sent to a gateway that might execute them.
+	DryRun bool
+
+	// TracerProvider, if set, traces each request to the gateway as a
+	// client span, a child of the span on the request's context, and sends
+	// the span to the gateway in a traceparent header so it can continue
+	// the trace. Give it the engine's provider to see requests under the
+	// tool calls that made them.
+	TracerProvider trace.TracerProvider
 }
 
 // NewHTTPExecutor creates a new HTTP-based tool executor.
@@ -46,28 +90,122 @@ func NewHTTPExecutor(cfg HTTPExecutorConfig) *HTTPExecutor {
 		timeout = 30 * time.Second
 	}
 
+	backoff := cfg.RetryBackoff
+	if backoff <= 0 {
+		backoff = 200 * time.Millisecond
+	}
+	maxBackoff := cfg.MaxRetryBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 5 * time.Second
+	}
+
 	return &HTTPExecutor{
-		baseURL:  cfg.BaseURL,
-		apiKey:   cfg.APIKey,   // Keep for backward compatibility
-		jwtToken: cfg.JWTToken, // New JWT field
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
+		baseURL:      cfg.BaseURL,
+		apiKey:       cfg.APIKey,   // Keep for backward compatibility
+		jwtToken:     cfg.JWTToken, // New JWT field
+		httpClient:   &http.Client{},
+		timeout:      timeout,
+		toolTimeouts: cfg.ToolTimeouts,
+		maxRetries:   cfg.MaxRetries,
+		backoff:      backoff,
+		maxBackoff:   maxBackoff,
+		dryRun:       cfg.DryRun,
+		tracer:       newTracer(cfg.TracerProvider),
 	}
 }
 
 // Execute runs a read-only tool via HTTP.
+// get_transactions' start_date and end_date are passed to the gateway and
+// also applied to its response, so only matching transactions are returned.
+// get_spending_summary is aggregated from get_transactions if the gateway
+// doesn't serve it.
 func (e *HTTPExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	if req.Tool == "get_spending_summary" {
+		return e.spendingSummary(ctx, req)
+	}
 	endpoint := e.endpointForTool(req.Tool)
-	return e.doRequest(ctx, "GET", endpoint, req, req.Tool)
+	if req.Tool != "get_transactions" {
+		return e.doRequest(ctx, "GET", endpoint, req, req.Tool)
+	}
+
+	dates, err := dateRangeInput(req.Input)
+	if err != nil {
+		return &core.ExecuteResponse{Success: false, Error: err.Error()}, nil
+	}
+	resp, err := e.doRequest(ctx, "GET", endpoint, req, req.Tool)
+	if err != nil || !resp.Success {
+		return resp, err
+	}
+	if resp.Data, err = filterTransactionsData(resp.Data, dates); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// spendingSummary runs get_spending_summary. Gateways without the summary
+// endpoint answer 404; the summary is then aggregated from the user's
+// transactions instead, in the same shape.
+func (e *HTTPExecutor) spendingSummary(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	days, groupBy, err := spendingSummaryInput(req.Input)
+	if err != nil {
+		return &core.ExecuteResponse{Success: false, Error: err.Error()}, nil
+	}
+	input, _ := json.Marshal(map[string]interface{}{"days": days, "group_by": groupBy})
+	summaryReq := &core.ExecuteRequest{UserID: req.UserID, Tool: req.Tool, Input: input, RequestID: req.RequestID}
+	resp, status, err := e.doRequestStatus(ctx, "GET", e.endpointForTool(req.Tool), summaryReq, req.Tool)
+	if err != nil || status != http.StatusNotFound {
+		return resp, err
+	}
+
+	now := time.Now()
+	input, _ = json.Marshal(map[string]interface{}{
+		"limit":      spendingSummaryFetchLimit,
+		"start_date": spendingWindow(days, now).Format(DateLayout),
+	})
+	txResp, err := e.Execute(ctx, &core.ExecuteRequest{UserID: req.UserID, Tool: "get_transactions", Input: input, RequestID: req.RequestID})
+	if err != nil || !txResp.Success {
+		return txResp, err
+	}
+	return summarizeTransactionsData(txResp.Data, days, groupBy, now)
 }
 
 // ExecuteWrite runs a write tool that may require confirmation.
 func (e *HTTPExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
 	endpoint := e.endpointForTool(req.Tool)
+	if req.DryRun {
+		return e.preview(ctx, endpoint, req)
+	}
 	return e.doRequest(ctx, "POST", endpoint, req, req.Tool)
 }
 
+// preview dry runs a write: it is sent with ?dry_run=true, and dry_run in
+// the body. A gateway that doesn't know the endpoint's dry runs, or
+// answers without marking its response as one, makes the preview
+// unavailable.
+func (e *HTTPExecutor) preview(ctx context.Context, endpoint string, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	if !e.dryRun {
+		return nil, fmt.Errorf("%w: the gateway isn't configured for dry runs", core.ErrPreviewUnavailable)
+	}
+	resp, status, err := e.doRequestStatus(ctx, "POST", endpoint+"?dry_run=true", req, req.Tool)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return nil, fmt.Errorf("%w: the gateway can't dry run %s (HTTP %d)", core.ErrPreviewUnavailable, req.Tool, status)
+	}
+	if !resp.Success {
+		return resp, nil
+	}
+	var ack struct {
+		DryRun bool `json:"dryRun"`
+	}
+	if json.Unmarshal(resp.Data, &ack) != nil || !ack.DryRun {
+		return nil, fmt.Errorf("%w: the gateway didn't answer %s as a dry run", core.ErrPreviewUnavailable, req.Tool)
+	}
+	return resp, nil
+}
+
 // Confirm executes a previously confirmed write operation.
 func (e *HTTPExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
 	endpoint := fmt.Sprintf("/nim/v1/agent/confirmations/%s/confirm", confirmationID)
@@ -81,19 +219,44 @@ func (e *HTTPExecutor) Cancel(ctx context.Context, userID, confirmationID string
 	return err
 }
 
+// Ping checks the gateway is reachable: it must answer a HEAD request for
+// BaseURL, with any status but a 5xx, within the executor's timeout. The
+// request is unauthenticated.
+func (e *HTTPExecutor) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, e.baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("gateway answered HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // endpointForTool maps tool names to HTTP endpoints.
 func (e *HTTPExecutor) endpointForTool(tool string) string {
 	// Map tool names to nim_gateway endpoints
 	endpoints := map[string]string{
-		"get_balance":         "/nim/v1/agent/wallet/balance",
-		"get_savings_balance": "/nim/v1/agent/savings/balance",
-		"get_vault_rates":     "/nim/v1/agent/savings/vaults",
-		"get_transactions":    "/nim/v1/agent/transactions",
-		"get_profile":         "/nim/v1/agent/profile",
-		"search_users":        "/nim/v1/agent/users/search",
-		"send_money":          "/nim/v1/agent/payments/send",
-		"deposit_savings":     "/nim/v1/agent/savings/deposit",
-		"withdraw_savings":    "/nim/v1/agent/savings/withdraw",
+		"get_balance":          "/nim/v1/agent/wallet/balance",
+		"get_savings_balance":  "/nim/v1/agent/savings/balance",
+		"get_savings_history":  "/nim/v1/agent/savings/history",
+		"get_vault_rates":      "/nim/v1/agent/savings/vaults",
+		"get_transactions":     "/nim/v1/agent/transactions",
+		"get_spending_summary": "/nim/v1/agent/transactions/summary",
+		"get_profile":          "/nim/v1/agent/profile",
+		"search_users":         "/nim/v1/agent/users/search",
+		"get_payment_requests": "/nim/v1/agent/payments/requests",
+		"send_money":           "/nim/v1/agent/payments/send",
+		"request_money":        "/nim/v1/agent/payments/request",
+		"deposit_savings":      "/nim/v1/agent/savings/deposit",
+		"withdraw_savings":     "/nim/v1/agent/savings/withdraw",
 	}
 
 	if endpoint, ok := endpoints[tool]; ok {
@@ -105,90 +268,243 @@ func (e *HTTPExecutor) endpointForTool(tool string) string {
 
 // doRequest performs an HTTP request to the agent_gateway.
 func (e *HTTPExecutor) doRequest(ctx context.Context, method, endpoint string, body interface{}, toolName string) (*core.ExecuteResponse, error) {
+	resp, _, err := e.doRequestStatus(ctx, method, endpoint, body, toolName)
+	return resp, err
+}
+
+// doRequestStatus is doRequest, also returning the gateway's HTTP status.
+func (e *HTTPExecutor) doRequestStatus(ctx context.Context, method, endpoint string, body interface{}, toolName string) (*core.ExecuteResponse, int, error) {
+	core.CheckContext(ctx, "HTTPExecutor "+method+" "+endpoint)
+
 	urlStr := e.baseURL + endpoint
 
-	var bodyReader io.Reader
+	var bodyBytes []byte
 
 	// For GET requests, encode parameters as query string instead of body
 	if method == "GET" && body != nil {
 		if execReq, ok := body.(*core.ExecuteRequest); ok && len(execReq.Input) > 0 {
-			// Parse Input JSON and add as query parameters
-			var params map[string]interface{}
-			if err := json.Unmarshal(execReq.Input, &params); err == nil {
-				query := make([]string, 0, len(params))
-				for k, v := range params {
-					query = append(query, fmt.Sprintf("%s=%v", k, v))
-				}
-				if len(query) > 0 {
-					urlStr += "?" + strings.Join(query, "&")
-				}
+			query, err := queryValues(execReq.Input)
+			if err != nil {
+				return &core.ExecuteResponse{Success: false, Error: err.Error()}, 0, nil
+			}
+			if len(query) > 0 {
+				urlStr += "?" + query.Encode()
 			}
 		}
-		bodyReader = nil
 	} else if body != nil {
-		bodyBytes, err := json.Marshal(body)
+		var err error
+		bodyBytes, err = json.Marshal(body)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request: %w", err)
+			return nil, 0, fmt.Errorf("failed to marshal request: %w", err)
 		}
-		bodyReader = bytes.NewReader(bodyBytes)
 	}
 
+	resp, respBody, err := e.send(ctx, method, urlStr, bodyBytes, toolName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return &core.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(respBody)),
+		}, resp.StatusCode, nil
+	}
+
+	// Gateway returns raw proto response (not wrapped in ExecuteResponse)
+	// Unmarshal into the proper type to validate the structure
+	responseType := toolResponseType(toolName)
+	if err := json.Unmarshal(respBody, responseType); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse %s response: %w", toolName, err)
+	}
+
+	// Marshal back to JSON bytes for ExecuteResponse.Data
+	dataBytes, err := json.Marshal(responseType)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to marshal %s response: %w", toolName, err)
+	}
+
+	return &core.ExecuteResponse{
+		Success: true,
+		Data:    json.RawMessage(dataBytes),
+	}, resp.StatusCode, nil
+}
+
+// queryValues encodes a read tool's JSON input as GET query parameters.
+// Numbers keep their JSON form, so large amounts aren't rendered in
+// exponent notation, and arrays become a repeated key per element, e.g.
+// {"currency":["USDC","EURC"]} is "currency=USDC&currency=EURC". Nulls
+// are left out. Objects can't be represented in a query and are an error.
+func queryValues(input json.RawMessage) (url.Values, error) {
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	var params map[string]interface{}
+	if err := dec.Decode(&params); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	query := make(url.Values, len(params))
+	for key, v := range params {
+		values, ok := v.([]interface{})
+		if !ok {
+			values = []interface{}{v}
+		}
+		for _, v := range values {
+			value, err := queryValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input: parameter %q %s", key, err)
+			}
+			if v != nil {
+				query.Add(key, value)
+			}
+		}
+	}
+	return query, nil
+}
+
+// queryValue formats a single JSON value for a query string.
+func queryValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		return "", errors.New("contains nested arrays, which can't be sent in a query string")
+	default:
+		return "", errors.New("is an object, which can't be sent in a query string")
+	}
+}
+
+// send performs the request, retrying reads that fail transiently, and
+// returns the final response with its body read.
+func (e *HTTPExecutor) send(ctx context.Context, method, urlStr string, body []byte, toolName string) (*http.Response, []byte, error) {
+	retries := 0
+	if method == http.MethodGet {
+		retries = e.maxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, respBody, err := e.attempt(ctx, method, urlStr, body, toolName)
+		if attempt == retries || ctx.Err() != nil || !retryable(resp, err) {
+			return resp, respBody, err
+		}
+
+		wait := e.backoff << attempt
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				wait = after
+			}
+		}
+		if wait > e.maxBackoff || wait < 0 {
+			wait = e.maxBackoff
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if err == nil {
+				err = ctx.Err()
+			}
+			return nil, nil, fmt.Errorf("request failed: %w", err)
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt performs the request once, within the tool's timeout.
+func (e *HTTPExecutor) attempt(ctx context.Context, method, urlStr string, body []byte, toolName string) (resp *http.Response, respBody []byte, err error) {
+	timeout := e.timeout
+	if t, ok := e.toolTimeouts[toolName]; ok && t > 0 {
+		timeout = t
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var span trace.Span
+	if e.tracer != nil {
+		ctx, span = e.startRequest(ctx, method, urlStr, toolName)
+		defer func() { endRequest(span, resp, err) }()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
 	req, err := http.NewRequestWithContext(ctx, method, urlStr, bodyReader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	if method != "GET" {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	// Prefer JWT over API key
-	if e.jwtToken != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", e.jwtToken))
+	// Prefer the caller's JWT, then the configured one, then the API key
+	jwt := core.CredentialFromContext(ctx)
+	if jwt == "" {
+		e.mu.RLock()
+		jwt = e.jwtToken
+		e.mu.RUnlock()
+	}
+	if jwt != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", jwt))
 	} else if e.apiKey != "" {
 		// Fallback to API key for backward compatibility
 		req.Header.Set("X-API-Key", e.apiKey)
 	}
+	if span != nil {
+		injectTraceContext(ctx, req)
+	}
 
-	resp, err := e.httpClient.Do(req)
+	resp, err = e.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err = io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
 	}
+	return resp, respBody, nil
+}
 
-	if resp.StatusCode >= 400 {
-		return &core.ExecuteResponse{
-			Success: false,
-			Error:   fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(respBody)),
-		}, nil
+// retryable reports whether a read that got resp or err is worth retrying:
+// network errors, timeouts, rate limiting and server errors.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
 	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
 
-	// Gateway returns raw proto response (not wrapped in ExecuteResponse)
-	// Unmarshal into the proper type to validate the structure
-	responseType := toolResponseType(toolName)
-	if err := json.Unmarshal(respBody, responseType); err != nil {
-		return nil, fmt.Errorf("failed to parse %s response: %w", toolName, err)
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date.
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
 	}
-
-	// Marshal back to JSON bytes for ExecuteResponse.Data
-	dataBytes, err := json.Marshal(responseType)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal %s response: %w", toolName, err)
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
 	}
-
-	return &core.ExecuteResponse{
-		Success: true,
-		Data:    json.RawMessage(dataBytes),
-	}, nil
+	if at, err := http.ParseTime(header); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
 }
 
-// UpdateJWT updates the JWT token used for authentication.
-// This should be called when the token is refreshed.
+// UpdateJWT updates the JWT token used for requests whose context carries
+// no credential. It is shared by every caller, so per-user tokens belong on
+// the request context instead (see core.WithCredential).
 func (e *HTTPExecutor) UpdateJWT(jwt string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.jwtToken = jwt
 }
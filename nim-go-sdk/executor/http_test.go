@@ -0,0 +1,213 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestHTTPExecutor_Retries(t *testing.T) {
+	tests := []struct {
+		name        string
+		tool        string
+		write       bool
+		maxRetries  int
+		statuses    []int // responses before the gateway recovers
+		retryAfter  string
+		wantCalls   int32
+		wantSuccess bool
+	}{
+		{name: "recovers after 503s", tool: "get_balance", maxRetries: 3, statuses: []int{503, 503}, wantCalls: 3, wantSuccess: true},
+		{name: "gives up after MaxRetries", tool: "get_balance", maxRetries: 2, statuses: []int{502, 502, 502, 502}, wantCalls: 3},
+		{name: "retries 429 after Retry-After", tool: "get_transactions", maxRetries: 1, statuses: []int{429}, retryAfter: "0", wantCalls: 2, wantSuccess: true},
+		{name: "client errors are not retried", tool: "get_balance", maxRetries: 3, statuses: []int{404}, wantCalls: 1},
+		{name: "retries off by default", tool: "get_balance", statuses: []int{503}, wantCalls: 1},
+		{name: "send_money is never retried", tool: "send_money", write: true, maxRetries: 3, statuses: []int{503}, wantCalls: 1},
+		{name: "deposit_savings is never retried", tool: "deposit_savings", write: true, maxRetries: 3, statuses: []int{502}, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				if int(n) <= len(tt.statuses) {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.statuses[n-1])
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer gateway.Close()
+
+			exec := NewHTTPExecutor(HTTPExecutorConfig{
+				BaseURL:      gateway.URL,
+				MaxRetries:   tt.maxRetries,
+				RetryBackoff: time.Millisecond,
+			})
+			req := &core.ExecuteRequest{UserID: "user-1", Tool: tt.tool, Input: json.RawMessage(`{}`)}
+			var resp *core.ExecuteResponse
+			var err error
+			if tt.write {
+				resp, err = exec.ExecuteWrite(context.Background(), req)
+			} else {
+				resp, err = exec.Execute(context.Background(), req)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Success != tt.wantSuccess {
+				t.Errorf("Success = %v (%s), want %v", resp.Success, resp.Error, tt.wantSuccess)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("gateway called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHTTPExecutor_RetryHonorsContext(t *testing.T) {
+	var calls atomic.Int32
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer gateway.Close()
+
+	exec := NewHTTPExecutor(HTTPExecutorConfig{BaseURL: gateway.URL, MaxRetries: 3, MaxRetryBackoff: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := exec.Execute(ctx, &core.ExecuteRequest{Tool: "get_balance"})
+	if err == nil || time.Since(start) > time.Second {
+		t.Errorf("Execute = %v after %v, want to give up when the context ends", err, time.Since(start))
+	}
+	if calls.Load() != 1 {
+		t.Errorf("gateway called %d times, want 1", calls.Load())
+	}
+}
+
+func TestHTTPExecutor_ToolTimeouts(t *testing.T) {
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer gateway.Close()
+
+	exec := NewHTTPExecutor(HTTPExecutorConfig{
+		BaseURL:      gateway.URL,
+		Timeout:      time.Second,
+		ToolTimeouts: map[string]time.Duration{"get_balance": 20 * time.Millisecond},
+	})
+	if _, err := exec.Execute(context.Background(), &core.ExecuteRequest{Tool: "get_balance"}); err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Errorf("get_balance error = %v, want its 20ms timeout", err)
+	}
+	if resp, err := exec.Execute(context.Background(), &core.ExecuteRequest{Tool: "get_profile"}); err != nil || !resp.Success {
+		t.Errorf("get_profile = %+v, %v, want success within the default timeout", resp, err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"Sun, 01 Mar 2026 12:00:10 GMT", 10 * time.Second, true},
+		{"Sun, 01 Mar 2026 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestQueryValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{name: "special characters", input: `{"query":"José & María?=+"}`, want: "query=Jos%C3%A9+%26+Mar%C3%ADa%3F%3D%2B"},
+		{name: "numbers", input: `{"limit":20,"amount":1000000,"rate":4.25}`, want: "amount=1000000&limit=20&rate=4.25"},
+		{name: "booleans", input: `{"include_pending":true,"archived":false}`, want: "archived=false&include_pending=true"},
+		{name: "arrays", input: `{"currency":["USDC","EURC"],"ids":[1,2]}`, want: "currency=USDC&currency=EURC&ids=1&ids=2"},
+		{name: "nulls left out", input: `{"cursor":null,"limit":5}`, want: "limit=5"},
+		{name: "empty", input: `{}`, want: ""},
+		{name: "object", input: `{"filter":{"min":5}}`, wantErr: `parameter "filter" is an object`},
+		{name: "object in array", input: `{"filters":[{"min":5}]}`, wantErr: `parameter "filters" is an object`},
+		{name: "nested array", input: `{"ids":[[1,2]]}`, wantErr: `parameter "ids" contains nested arrays`},
+		{name: "not an object", input: `["a"]`, wantErr: "invalid input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := queryValues(json.RawMessage(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("queryValues = %v, want an error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := query.Encode(); got != tt.want {
+				t.Errorf("query = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPExecutor_SearchUsersQuery(t *testing.T) {
+	var got string
+	var gotQuery string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.RawQuery
+		gotQuery = r.URL.Query().Get("query")
+		w.Write([]byte(`{"users":[]}`))
+	}))
+	defer gateway.Close()
+
+	exec := NewHTTPExecutor(HTTPExecutorConfig{BaseURL: gateway.URL})
+	resp, err := exec.Execute(context.Background(), &core.ExecuteRequest{
+		UserID: "user-1",
+		Tool:   "search_users",
+		Input:  json.RawMessage(`{"query":"José & María"}`),
+	})
+	if err != nil || !resp.Success {
+		t.Fatalf("Execute = %+v, %v", resp, err)
+	}
+	if gotQuery != "José & María" {
+		t.Errorf("gateway got query %q (raw %s), want %q", gotQuery, got, "José & María")
+	}
+
+	// Objects are refused before anything is sent.
+	got = ""
+	resp, err = exec.Execute(context.Background(), &core.ExecuteRequest{
+		UserID: "user-1",
+		Tool:   "search_users",
+		Input:  json.RawMessage(`{"query":{"name":"José"}}`),
+	})
+	if err != nil || resp.Success || !strings.Contains(resp.Error, "object") {
+		t.Errorf("Execute = %+v, %v, want an unsuccessful response about the object", resp, err)
+	}
+	if got != "" {
+		t.Errorf("gateway was called with %s", got)
+	}
+}
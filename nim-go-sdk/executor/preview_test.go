@@ -0,0 +1,167 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+const sendMoneyInput = `{"recipient":"@bob","amount":"500","currency":"USD"}`
+
+func TestHTTPExecutor_Preview(t *testing.T) {
+	tests := []struct {
+		name            string
+		dryRun          bool
+		status          int
+		body            string
+		wantCalls       int
+		wantFee         string
+		wantUnavailable bool
+	}{
+		{name: "preview", dryRun: true, body: `{"success":true,"dryRun":true,"fee":"0.50","feeCurrency":"USD","estimatedArrival":"2026-10-17T12:00:00Z"}`, wantCalls: 1, wantFee: "0.50"},
+		{name: "not configured", body: `{"success":true,"dryRun":true}`, wantUnavailable: true},
+		{name: "unknown to the gateway", dryRun: true, status: http.StatusNotFound, wantCalls: 1, wantUnavailable: true},
+		{name: "flag ignored", dryRun: true, body: `{"success":true,"transactionId":"tx-1"}`, wantCalls: 1, wantUnavailable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			var query string
+			var body struct {
+				Tool   string `json:"tool"`
+				DryRun bool   `json:"dry_run"`
+			}
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				query = r.URL.RawQuery
+				data, _ := io.ReadAll(r.Body)
+				json.Unmarshal(data, &body)
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer gateway.Close()
+
+			exec := NewHTTPExecutor(HTTPExecutorConfig{BaseURL: gateway.URL, DryRun: tt.dryRun})
+			resp, err := exec.ExecuteWrite(context.Background(), &core.ExecuteRequest{
+				UserID: "user-1",
+				Tool:   "send_money",
+				Input:  json.RawMessage(sendMoneyInput),
+				DryRun: true,
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("gateway called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantUnavailable {
+				if !errors.Is(err, core.ErrPreviewUnavailable) {
+					t.Errorf("ExecuteWrite() = %+v, %v, want ErrPreviewUnavailable", resp, err)
+				}
+				return
+			}
+			if err != nil || !resp.Success {
+				t.Fatalf("ExecuteWrite() = %+v, %v", resp, err)
+			}
+			if query != "dry_run=true" || !body.DryRun || body.Tool != "send_money" {
+				t.Errorf("sent ?%s with body %+v, want dry_run in both", query, body)
+			}
+			var preview SendMoneyResponse
+			json.Unmarshal(resp.Data, &preview)
+			if preview.Fee != tt.wantFee || preview.EstimatedArrival == "" || resp.RequiresConfirmation {
+				t.Errorf("preview = %+v, requires confirmation %v", preview, resp.RequiresConfirmation)
+			}
+		})
+	}
+}
+
+func TestExecuteRequest_DryRunJSON(t *testing.T) {
+	for _, tt := range []struct {
+		dryRun bool
+		want   string
+	}{
+		{false, `{"user_id":"user-1","tool":"send_money","input":{}}`},
+		{true, `{"user_id":"user-1","tool":"send_money","input":{},"dry_run":true}`},
+	} {
+		data, err := json.Marshal(&core.ExecuteRequest{UserID: "user-1", Tool: "send_money", Input: json.RawMessage(`{}`), DryRun: tt.dryRun})
+		if err != nil || string(data) != tt.want {
+			t.Errorf("Marshal(DryRun %v) = %s, %v, want %s", tt.dryRun, data, err, tt.want)
+		}
+	}
+}
+
+// fakePayments records payment calls and answers them.
+type fakePayments struct {
+	dryRuns, sends int
+	requested      []string // recipient, amount, currency of each request
+	direction      *string  // of the last ListRequests
+}
+
+func (f *fakePayments) Send(ctx context.Context, userID, recipient, amount, currency string, note *string, dryRun bool) (json.RawMessage, error) {
+	if dryRun {
+		f.dryRuns++
+		return json.RawMessage(`{"success":true,"dryRun":true,"fee":"0.50","feeCurrency":"USD"}`), nil
+	}
+	f.sends++
+	return json.RawMessage(`{"success":true,"transactionId":"tx-1"}`), nil
+}
+
+func (f *fakePayments) Request(ctx context.Context, userID, recipient, amount, currency string, note *string) (json.RawMessage, error) {
+	f.requested = append(f.requested, recipient, amount, currency)
+	return json.RawMessage(`{"success":true,"requestId":"req-1","status":"pending"}`), nil
+}
+
+func (f *fakePayments) ListRequests(ctx context.Context, userID string, direction *string) (json.RawMessage, error) {
+	f.direction = direction
+	return json.RawMessage(paymentRequestsJSON), nil
+}
+
+// countingConfirmations counts the confirmations created.
+type countingConfirmations struct {
+	store.Confirmations
+	stored int
+}
+
+func (c *countingConfirmations) Store(ctx context.Context, action *core.PendingAction) error {
+	c.stored++
+	return c.Confirmations.Store(ctx, action)
+}
+
+func TestGRPCExecutor_Preview(t *testing.T) {
+	payments := &fakePayments{}
+	confirmations := &countingConfirmations{Confirmations: store.NewMemoryConfirmations()}
+	exec := NewGRPCExecutor(GRPCExecutorConfig{Payments: payments, Confirmations: confirmations})
+
+	resp, err := exec.ExecuteWrite(context.Background(), &core.ExecuteRequest{
+		UserID: "user-1",
+		Tool:   "send_money",
+		Input:  json.RawMessage(sendMoneyInput),
+		DryRun: true,
+	})
+	if err != nil || !resp.Success || resp.RequiresConfirmation {
+		t.Fatalf("ExecuteWrite(dry run) = %+v, %v", resp, err)
+	}
+	if payments.dryRuns != 1 || payments.sends != 0 || confirmations.stored != 0 {
+		t.Errorf("dry run made %d previews, %d sends and %d confirmations, want only a preview", payments.dryRuns, payments.sends, confirmations.stored)
+	}
+
+	// Without the flag, the write still waits for confirmation.
+	resp, err = exec.ExecuteWrite(context.Background(), &core.ExecuteRequest{
+		UserID: "user-1",
+		Tool:   "send_money",
+		Input:  json.RawMessage(sendMoneyInput),
+	})
+	if err != nil || !resp.RequiresConfirmation || confirmations.stored != 1 {
+		t.Fatalf("ExecuteWrite() = %+v, %v, want a confirmation", resp, err)
+	}
+	if _, err := exec.Confirm(context.Background(), "user-1", resp.Confirmation.ID); err != nil || payments.sends != 1 {
+		t.Errorf("Confirm() sent %d payments, %v, want 1", payments.sends, err)
+	}
+}
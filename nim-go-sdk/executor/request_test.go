@@ -0,0 +1,104 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+const requestMoneyInput = `{"recipient":"@bob","amount":"20","currency":"USD","note":"pizza"}`
+
+// paymentRequestsJSON is a get_payment_requests response as the gateway
+// sends it.
+const paymentRequestsJSON = `{"requests":[{"id":"req-1","direction":"incoming","counterparty":"@alice","amount":"12.50","currency":"USD","status":"pending","createdAt":"2026-10-16T09:00:00Z"}]}`
+
+func TestHTTPExecutor_PaymentRequests(t *testing.T) {
+	var paths []string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/nim/v1/agent/payments/requests":
+			w.Write([]byte(paymentRequestsJSON))
+		default:
+			w.Write([]byte(`{"success":true,"requestId":"req-2","status":"pending"}`))
+		}
+	}))
+	defer gateway.Close()
+	exec := NewHTTPExecutor(HTTPExecutorConfig{BaseURL: gateway.URL})
+
+	resp, err := exec.Execute(context.Background(), &core.ExecuteRequest{
+		UserID: "user-1",
+		Tool:   "get_payment_requests",
+		Input:  json.RawMessage(`{"direction":"incoming"}`),
+	})
+	if err != nil || !resp.Success {
+		t.Fatalf("get_payment_requests = %+v, %v", resp, err)
+	}
+	var list GetPaymentRequestsResponse
+	if err := json.Unmarshal(resp.Data, &list); err != nil || len(list.Requests) != 1 || list.Requests[0].Counterparty != "@alice" {
+		t.Errorf("requests = %+v, %v", list, err)
+	}
+
+	resp, err = exec.ExecuteWrite(context.Background(), &core.ExecuteRequest{
+		UserID: "user-1",
+		Tool:   "request_money",
+		Input:  json.RawMessage(requestMoneyInput),
+	})
+	if err != nil || !resp.Success {
+		t.Fatalf("request_money = %+v, %v", resp, err)
+	}
+
+	want := []string{"GET /nim/v1/agent/payments/requests", "POST /nim/v1/agent/payments/request"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("gateway got %q, want %q", paths, want)
+	}
+}
+
+func TestGRPCExecutor_PaymentRequests(t *testing.T) {
+	payments := &fakePayments{}
+	exec := NewGRPCExecutor(GRPCExecutorConfig{Payments: payments, Confirmations: store.NewMemoryConfirmations()})
+
+	resp, err := exec.Execute(context.Background(), &core.ExecuteRequest{
+		UserID: "user-1",
+		Tool:   "get_payment_requests",
+		Input:  json.RawMessage(`{"direction":"outgoing"}`),
+	})
+	if err != nil || !resp.Success || string(resp.Data) != paymentRequestsJSON {
+		t.Fatalf("get_payment_requests = %+v, %v", resp, err)
+	}
+	if payments.direction == nil || *payments.direction != RequestOutgoing {
+		t.Errorf("listed direction %v, want outgoing", payments.direction)
+	}
+
+	resp, err = exec.ExecuteWrite(context.Background(), &core.ExecuteRequest{
+		UserID: "user-1",
+		Tool:   "request_money",
+		Input:  json.RawMessage(requestMoneyInput),
+	})
+	if err != nil || !resp.RequiresConfirmation {
+		t.Fatalf("ExecuteWrite() = %+v, %v, want a confirmation", resp, err)
+	}
+	if got, want := resp.Confirmation.Summary, "Request 20 USD from @bob"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+	if len(payments.requested) != 0 {
+		t.Fatalf("requested %q before confirmation", payments.requested)
+	}
+
+	resp, err = exec.Confirm(context.Background(), "user-1", resp.Confirmation.ID)
+	if err != nil || !resp.Success {
+		t.Fatalf("Confirm() = %+v, %v", resp, err)
+	}
+	if want := []string{"@bob", "20", "USD"}; !slices.Equal(payments.requested, want) {
+		t.Errorf("requested %q, want %q", payments.requested, want)
+	}
+	if payments.sends != 0 {
+		t.Errorf("request_money sent %d payments", payments.sends)
+	}
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// savingsHistoryJSON is a get_savings_history response as the gateway
+// sends it.
+const savingsHistoryJSON = `{
+	"points": [
+		{"date": "2026-03-01", "currency": "USD", "balance": "1000.00", "earnings": "0.11"},
+		{"date": "2026-03-02", "currency": "USD", "balance": "1000.11", "earnings": "0.11"}
+	],
+	"totalEarningsUsd": "0.22"
+}`
+
+var wantSavingsHistory = GetSavingsHistoryResponse{
+	Points: []SavingsHistoryPoint{
+		{Date: "2026-03-01", Currency: "USD", Balance: "1000.00", Earnings: "0.11"},
+		{Date: "2026-03-02", Currency: "USD", Balance: "1000.11", Earnings: "0.11"},
+	},
+	TotalEarningsUSD: "0.22",
+}
+
+func checkSavingsHistory(t *testing.T, resp *core.ExecuteResponse, err error) {
+	t.Helper()
+	if err != nil || !resp.Success {
+		t.Fatalf("get_savings_history = %+v, %v", resp, err)
+	}
+	var got GetSavingsHistoryResponse
+	if err := json.Unmarshal(resp.Data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Points) != 2 || got.Points[1] != wantSavingsHistory.Points[1] || got.TotalEarningsUSD != wantSavingsHistory.TotalEarningsUSD {
+		t.Errorf("history = %+v, want %+v", got, wantSavingsHistory)
+	}
+}
+
+func TestHTTPExecutor_SavingsHistory(t *testing.T) {
+	var path, query string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.RawQuery
+		w.Write([]byte(savingsHistoryJSON))
+	}))
+	defer gateway.Close()
+	exec := NewHTTPExecutor(HTTPExecutorConfig{BaseURL: gateway.URL})
+
+	resp, err := exec.Execute(context.Background(), &core.ExecuteRequest{
+		UserID: "user-1",
+		Tool:   "get_savings_history",
+		Input:  json.RawMessage(`{"currency":"USD","days":7}`),
+	})
+	checkSavingsHistory(t, resp, err)
+	if path != "/nim/v1/agent/savings/history" || query != "currency=USD&days=7" {
+		t.Errorf("request = %s?%s", path, query)
+	}
+}
+
+// fakeSavings records get_savings_history calls and answers them.
+type fakeSavings struct {
+	SavingsService // other methods are not called
+	currency       *string
+	days           int
+}
+
+func (f *fakeSavings) GetHistory(ctx context.Context, userID string, currency *string, days int) (json.RawMessage, error) {
+	f.currency, f.days = currency, days
+	return json.RawMessage(savingsHistoryJSON), nil
+}
+
+func TestGRPCExecutor_SavingsHistory(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantCurrency string
+		wantDays     int
+		wantErr      bool
+	}{
+		{name: "defaults", input: `{}`, wantDays: 30},
+		{name: "currency and days", input: `{"currency":"USD","days":7}`, wantCurrency: "USD", wantDays: 7},
+		{name: "too many days", input: `{"days":1000}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			savings := &fakeSavings{}
+			exec := NewGRPCExecutor(GRPCExecutorConfig{Savings: savings})
+
+			resp, err := exec.Execute(context.Background(), &core.ExecuteRequest{
+				UserID: "user-1",
+				Tool:   "get_savings_history",
+				Input:  json.RawMessage(tt.input),
+			})
+			if tt.wantErr {
+				if err != nil || resp.Success {
+					t.Errorf("response = %+v, %v, want a failure", resp, err)
+				}
+				return
+			}
+			checkSavingsHistory(t, resp, err)
+
+			var currency string
+			if savings.currency != nil {
+				currency = *savings.currency
+			}
+			if currency != tt.wantCurrency || savings.days != tt.wantDays {
+				t.Errorf("GetHistory(currency %q, days %d), want %q, %d", currency, savings.days, tt.wantCurrency, tt.wantDays)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// MaxSpendingSummaryDays is the longest window get_spending_summary covers.
+const MaxSpendingSummaryDays = 365
+
+// defaultSpendingSummaryDays is the window get_spending_summary covers when
+// no days are given.
+const defaultSpendingSummaryDays = 30
+
+// spendingSummaryFetchLimit is how many transactions are read to summarize
+// spending locally, where the gateway can't aggregate.
+const spendingSummaryFetchLimit = 1000
+
+// get_spending_summary groupings.
+const (
+	GroupByDay      = "day"      // buckets keyed by date, YYYY-MM-DD
+	GroupByWeek     = "week"     // buckets keyed by the date of the week's Monday
+	GroupByCategory = "category" // buckets keyed by transaction type, e.g. "send"
+)
+
+// spendingSummaryInput parses and validates get_spending_summary's input,
+// applying defaults.
+func spendingSummaryInput(input json.RawMessage) (days int, groupBy string, err error) {
+	var params struct {
+		Days    int    `json:"days"`
+		GroupBy string `json:"group_by"`
+	}
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &params); err != nil {
+			return 0, "", fmt.Errorf("invalid input: %w", err)
+		}
+	}
+
+	days = params.Days
+	if days == 0 {
+		days = defaultSpendingSummaryDays
+	}
+	if days < 1 || days > MaxSpendingSummaryDays {
+		return 0, "", fmt.Errorf("days must be between 1 and %d, got %d", MaxSpendingSummaryDays, params.Days)
+	}
+
+	groupBy = params.GroupBy
+	switch groupBy {
+	case "":
+		groupBy = GroupByDay
+	case GroupByDay, GroupByWeek, GroupByCategory:
+	default:
+		return 0, "", fmt.Errorf("group_by must be %q, %q or %q, got %q", GroupByDay, GroupByWeek, GroupByCategory, groupBy)
+	}
+	return days, groupBy, nil
+}
+
+// spendingWindow returns the start of the days-long window ending today,
+// in UTC.
+func spendingWindow(days int, now time.Time) time.Time {
+	today := now.UTC().Truncate(24 * time.Hour)
+	return today.AddDate(0, 0, 1-days)
+}
+
+// SummarizeSpending aggregates transactions into a get_spending_summary
+// response, as the gateway does: the money in and out over the last days
+// days, today included, grouped by day, week or category. Categories are
+// transaction types. Amounts are in USD, from each transaction's usdValue,
+// or its amount for USD and USDC; other transactions without a usdValue
+// are only counted. Buckets without transactions are left out.
+func SummarizeSpending(txs []Transaction, days int, groupBy string, now time.Time) *GetSpendingSummaryResponse {
+	window := DateRange{Start: spendingWindow(days, now)}
+
+	type totals struct {
+		in, out           float64
+		countIn, countOut int32
+	}
+	buckets := make(map[string]*totals)
+	var totalIn, totalOut float64
+	for _, tx := range txs {
+		created := tx.CreatedTime()
+		if !window.Contains(created) {
+			continue
+		}
+		amount := tx.AmountFloat()
+		credit := tx.Direction == "credit"
+		debit := tx.IsDebit()
+		if !credit && !debit {
+			continue
+		}
+
+		key := spendingBucketKey(tx, created, groupBy)
+		b := buckets[key]
+		if b == nil {
+			b = &totals{}
+			buckets[key] = b
+		}
+		usd := usdValue(tx, amount)
+		if credit {
+			b.in += usd
+			b.countIn++
+			totalIn += usd
+		} else {
+			b.out += usd
+			b.countOut++
+			totalOut += usd
+		}
+	}
+
+	resp := &GetSpendingSummaryResponse{
+		Days:        int32(days),
+		GroupBy:     groupBy,
+		Buckets:     make([]SpendingBucket, 0, len(buckets)),
+		TotalInUSD:  formatUSD(totalIn),
+		TotalOutUSD: formatUSD(totalOut),
+	}
+	for key, b := range buckets {
+		resp.Buckets = append(resp.Buckets, SpendingBucket{
+			Key:      key,
+			TotalIn:  formatUSD(b.in),
+			TotalOut: formatUSD(b.out),
+			CountIn:  b.countIn,
+			CountOut: b.countOut,
+			USDValue: formatUSD(b.in - b.out),
+		})
+	}
+	sort.Slice(resp.Buckets, func(i, j int) bool { return resp.Buckets[i].Key < resp.Buckets[j].Key })
+	return resp
+}
+
+// summarizeTransactionsData summarizes a get_transactions response.
+func summarizeTransactionsData(data json.RawMessage, days int, groupBy string, now time.Time) (*core.ExecuteResponse, error) {
+	var txs GetTransactionsResponse
+	if err := json.Unmarshal(data, &txs); err != nil {
+		return nil, fmt.Errorf("failed to parse get_transactions response: %w", err)
+	}
+	summary, err := json.Marshal(SummarizeSpending(txs.Transactions, days, groupBy, now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal get_spending_summary response: %w", err)
+	}
+	return &core.ExecuteResponse{Success: true, Data: summary}, nil
+}
+
+// spendingBucketKey returns the key of the bucket a transaction falls in.
+func spendingBucketKey(tx Transaction, created time.Time, groupBy string) string {
+	switch groupBy {
+	case GroupByWeek:
+		day := created.UTC().Truncate(24 * time.Hour)
+		offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+		return day.AddDate(0, 0, -offset).Format(DateLayout)
+	case GroupByCategory:
+		if tx.Type == "" {
+			return "other"
+		}
+		return tx.Type
+	default:
+		return created.UTC().Format(DateLayout)
+	}
+}
+
+// usdValue returns a transaction's absolute value in USD, or zero if it is
+// unknown.
+func usdValue(tx Transaction, amount float64) float64 {
+	if tx.USDValue != "" {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(tx.USDValue), 64); err == nil {
+			return math.Abs(v)
+		}
+	}
+	switch strings.ToUpper(tx.Currency) {
+	case "USD", "USDC":
+		return math.Abs(amount)
+	}
+	return 0
+}
+
+func formatUSD(v float64) string {
+	v = math.Round(v*100) / 100
+	if v == 0 {
+		v = 0 // not -0
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,227 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// recentTransactions are transactions over the past few days, as of now.
+func recentTransactions(now time.Time) []Transaction {
+	at := func(daysAgo int) string {
+		return now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -daysAgo).Add(12 * time.Hour).Format(time.RFC3339)
+	}
+	return []Transaction{
+		{ID: "1", Type: "send", Amount: "20", Currency: "USDC", Direction: "debit", CreatedAt: at(0)},
+		{ID: "2", Type: "send", Amount: "-10", Currency: "EURC", USDValue: "10.80", CreatedAt: at(0)},
+		{ID: "3", Type: "receive", Amount: "100", Currency: "USDC", Direction: "credit", CreatedAt: at(1)},
+		{ID: "4", Type: "send", Amount: "5", Currency: "LIL", Direction: "debit", CreatedAt: at(1)},
+		{ID: "5", Type: "deposit", Amount: "30", Currency: "USD", Direction: "debit", CreatedAt: at(8)},
+		{ID: "6", Type: "send", Amount: "99", Currency: "USD", Direction: "debit", CreatedAt: at(60)},
+	}
+}
+
+func TestSummarizeSpending(t *testing.T) {
+	// A Wednesday, so the last 8 days span three weeks.
+	now := time.Date(2026, 3, 4, 18, 0, 0, 0, time.UTC)
+	txs := recentTransactions(now)
+
+	tests := []struct {
+		name    string
+		days    int
+		groupBy string
+		want    []SpendingBucket
+		wantIn  string
+		wantOut string
+	}{
+		{
+			name: "day", days: 7, groupBy: GroupByDay,
+			want: []SpendingBucket{
+				{Key: "2026-03-03", TotalIn: "100.00", TotalOut: "0.00", CountIn: 1, CountOut: 1, USDValue: "100.00"},
+				{Key: "2026-03-04", TotalIn: "0.00", TotalOut: "30.80", CountOut: 2, USDValue: "-30.80"},
+			},
+			wantIn: "100.00", wantOut: "30.80",
+		},
+		{
+			name: "week", days: 30, groupBy: GroupByWeek,
+			want: []SpendingBucket{
+				{Key: "2026-02-23", TotalIn: "0.00", TotalOut: "30.00", CountOut: 1, USDValue: "-30.00"},
+				{Key: "2026-03-02", TotalIn: "100.00", TotalOut: "30.80", CountIn: 1, CountOut: 3, USDValue: "69.20"},
+			},
+			wantIn: "100.00", wantOut: "60.80",
+		},
+		{
+			name: "category", days: 30, groupBy: GroupByCategory,
+			want: []SpendingBucket{
+				{Key: "deposit", TotalIn: "0.00", TotalOut: "30.00", CountOut: 1, USDValue: "-30.00"},
+				{Key: "receive", TotalIn: "100.00", TotalOut: "0.00", CountIn: 1, USDValue: "100.00"},
+				{Key: "send", TotalIn: "0.00", TotalOut: "30.80", CountOut: 3, USDValue: "-30.80"},
+			},
+			wantIn: "100.00", wantOut: "60.80",
+		},
+		{
+			name: "today only", days: 1, groupBy: GroupByDay,
+			want:   []SpendingBucket{{Key: "2026-03-04", TotalIn: "0.00", TotalOut: "30.80", CountOut: 2, USDValue: "-30.80"}},
+			wantIn: "0.00", wantOut: "30.80",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SummarizeSpending(txs, tt.days, tt.groupBy, now)
+			if !reflect.DeepEqual(got.Buckets, tt.want) {
+				t.Errorf("buckets = %+v\nwant %+v", got.Buckets, tt.want)
+			}
+			if got.TotalInUSD != tt.wantIn || got.TotalOutUSD != tt.wantOut || got.Days != int32(tt.days) || got.GroupBy != tt.groupBy {
+				t.Errorf("summary = %+v", got)
+			}
+		})
+	}
+}
+
+func TestHTTPExecutor_SpendingSummary(t *testing.T) {
+	gatewaySummary := GetSpendingSummaryResponse{
+		Days:    7,
+		GroupBy: GroupByWeek,
+		Buckets: []SpendingBucket{
+			{Key: "2026-03-02", TotalIn: "100.00", TotalOut: "30.80", CountIn: 1, CountOut: 3, USDValue: "69.20"},
+		},
+		TotalInUSD:  "100.00",
+		TotalOutUSD: "30.80",
+	}
+
+	tests := []struct {
+		name         string
+		input        string
+		deployed     bool // whether the gateway serves the summary endpoint
+		wantRequests []string
+		want         func(now time.Time) *GetSpendingSummaryResponse
+	}{
+		{
+			name:         "gateway aggregates",
+			input:        `{"days":7,"group_by":"week"}`,
+			deployed:     true,
+			wantRequests: []string{"/nim/v1/agent/transactions/summary?days=7&group_by=week"},
+			want:         func(time.Time) *GetSpendingSummaryResponse { return &gatewaySummary },
+		},
+		{
+			name:  "endpoint not deployed",
+			input: `{"days":7,"group_by":"week"}`,
+			wantRequests: []string{
+				"/nim/v1/agent/transactions/summary?days=7&group_by=week",
+				"/nim/v1/agent/transactions?limit=1000&start_date=",
+			},
+			want: func(now time.Time) *GetSpendingSummaryResponse {
+				return SummarizeSpending(recentTransactions(now), 7, GroupByWeek, now)
+			},
+		},
+		{
+			name:         "defaults",
+			input:        `{}`,
+			deployed:     true,
+			wantRequests: []string{"/nim/v1/agent/transactions/summary?days=30&group_by=day"},
+			want:         func(time.Time) *GetSpendingSummaryResponse { return &gatewaySummary },
+		},
+		{
+			name:  "invalid grouping",
+			input: `{"group_by":"month"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			var requests []string
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.RequestURI())
+				switch r.URL.Path {
+				case "/nim/v1/agent/transactions/summary":
+					if !tt.deployed {
+						http.NotFound(w, r)
+						return
+					}
+					json.NewEncoder(w).Encode(gatewaySummary)
+				case "/nim/v1/agent/transactions":
+					json.NewEncoder(w).Encode(GetTransactionsResponse{Transactions: recentTransactions(now)})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer gateway.Close()
+			exec := NewHTTPExecutor(HTTPExecutorConfig{BaseURL: gateway.URL})
+
+			resp, err := exec.Execute(context.Background(), &core.ExecuteRequest{
+				UserID: "user-1",
+				Tool:   "get_spending_summary",
+				Input:  json.RawMessage(tt.input),
+			})
+			if tt.want == nil {
+				if err != nil || resp.Success || len(requests) != 0 {
+					t.Errorf("response = %+v, %v after %v, want a failure without requests", resp, err, requests)
+				}
+				return
+			}
+			if err != nil || !resp.Success {
+				t.Fatalf("get_spending_summary = %+v, %v", resp, err)
+			}
+
+			if len(requests) != len(tt.wantRequests) {
+				t.Fatalf("requests = %v, want %v", requests, tt.wantRequests)
+			}
+			for i, want := range tt.wantRequests {
+				if got := requests[i]; !strings.HasPrefix(got, want) {
+					t.Errorf("request %d = %s, want %s...", i, got, want)
+				}
+			}
+
+			var got GetSpendingSummaryResponse
+			if err := json.Unmarshal(resp.Data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.want(now); !reflect.DeepEqual(&got, want) {
+				t.Errorf("summary = %+v\nwant %+v", got, *want)
+			}
+		})
+	}
+}
+
+// fakeLedger answers get_transactions calls with transactions.
+type fakeLedger struct {
+	transactions []Transaction
+	limit        int
+}
+
+func (f *fakeLedger) GetTransactions(ctx context.Context, userID string, limit int, txType *string) (json.RawMessage, error) {
+	f.limit = limit
+	return json.Marshal(GetTransactionsResponse{Transactions: f.transactions})
+}
+
+func TestGRPCExecutor_SpendingSummary(t *testing.T) {
+	now := time.Now()
+	ledger := &fakeLedger{transactions: recentTransactions(now)}
+	exec := NewGRPCExecutor(GRPCExecutorConfig{Ledger: ledger})
+
+	resp, err := exec.Execute(context.Background(), &core.ExecuteRequest{
+		UserID: "user-1",
+		Tool:   "get_spending_summary",
+		Input:  json.RawMessage(`{"group_by":"category"}`),
+	})
+	if err != nil || !resp.Success {
+		t.Fatalf("get_spending_summary = %+v, %v", resp, err)
+	}
+	var got GetSpendingSummaryResponse
+	if err := json.Unmarshal(resp.Data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := SummarizeSpending(ledger.transactions, 30, GroupByCategory, now); !reflect.DeepEqual(&got, want) {
+		t.Errorf("summary = %+v\nwant %+v", got, *want)
+	}
+	if ledger.limit != spendingSummaryFetchLimit {
+		t.Errorf("read %d transactions, want %d", ledger.limit, spendingSummaryFetchLimit)
+	}
+}
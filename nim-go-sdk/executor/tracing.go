@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName names the tracer HTTPExecutor's spans are recorded with.
+const TracerName = "github.com/becomeliminal/nim-go-sdk/executor"
+
+// Span attributes of gateway requests, following the OpenTelemetry HTTP
+// client conventions where there is one.
+const (
+	attrMethod     = attribute.Key("http.request.method")
+	attrEndpoint   = attribute.Key("url.path")
+	attrStatusCode = attribute.Key("http.response.status_code")
+	attrToolName   = attribute.Key("nim.tool.name")
+)
+
+// newTracer returns tp's tracer for the executor, or nil without tp.
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		return nil
+	}
+	return tp.Tracer(TracerName)
+}
+
+// startRequest starts the client span of a request to urlStr.
+func (e *HTTPExecutor) startRequest(ctx context.Context, method, urlStr, toolName string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{attrMethod.String(method)}
+	if u, err := url.Parse(urlStr); err == nil {
+		attrs = append(attrs, attrEndpoint.String(u.Path))
+	}
+	if toolName != "" {
+		attrs = append(attrs, attrToolName.String(toolName))
+	}
+	return e.tracer.Start(ctx, "HTTPExecutor "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+// endRequest ends a request's span with the gateway's status. Requests
+// that failed or were answered with an error status are marked as errors.
+func endRequest(span trace.Span, resp *http.Response, err error) {
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case resp != nil:
+		span.SetAttributes(attrStatusCode.Int(resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+	}
+	span.End()
+}
+
+// injectTraceContext adds the traceparent header of the span on ctx to req.
+func injectTraceContext(ctx context.Context, req *http.Request) {
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
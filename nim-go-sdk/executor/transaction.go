@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseTransactions reads the transactions in a get_transactions result,
+// or in a bare JSON array of transactions. Amounts may be strings or
+// numbers; see Transaction.UnmarshalJSON.
+func ParseTransactions(data json.RawMessage) ([]Transaction, error) {
+	var txs []Transaction
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &txs); err != nil {
+			return nil, fmt.Errorf("failed to parse transactions: %w", err)
+		}
+		return txs, nil
+	}
+
+	var resp GetTransactionsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse transactions: %w", err)
+	}
+	return resp.Transactions, nil
+}
+
+// UnmarshalJSON decodes a transaction, accepting amount and usdValue as
+// either JSON strings or numbers. Numbers keep their exact text.
+func (tx *Transaction) UnmarshalJSON(data []byte) error {
+	type plain Transaction
+	var raw struct {
+		*plain
+		Amount   numberText `json:"amount"`
+		USDValue numberText `json:"usdValue"`
+	}
+	raw.plain = (*plain)(tx)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	tx.Amount, tx.USDValue = string(raw.Amount), string(raw.USDValue)
+	return nil
+}
+
+// AmountFloat returns the signed amount. It is zero if the amount is
+// missing or malformed.
+func (tx Transaction) AmountFloat() float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(tx.Amount), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// CreatedTime returns when the transaction was created. It is the zero
+// time if the timestamp is missing or malformed.
+func (tx Transaction) CreatedTime() time.Time {
+	t, _ := time.Parse(time.RFC3339, tx.CreatedAt)
+	return t
+}
+
+// IsDebit reports whether the transaction is money leaving the account:
+// its direction is "debit" or its amount is negative.
+func (tx Transaction) IsDebit() bool {
+	return tx.Direction == "debit" || tx.AmountFloat() < 0
+}
+
+// numberText decodes a JSON string or number as its text.
+type numberText string
+
+func (n *numberText) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*n = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*n = numberText(s)
+		return nil
+	}
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		return fmt.Errorf("invalid amount %s", data)
+	}
+	*n = numberText(num)
+	return nil
+}
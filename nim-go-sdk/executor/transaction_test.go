@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseTransactions(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Transaction
+		wantErr bool
+	}{
+		{
+			name: "string amounts",
+			data: `{"transactions":[{"id":"1","amount":"-12.50","usdValue":"12.50","currency":"USDC","createdAt":"2026-03-04T12:00:00Z"}]}`,
+			want: []Transaction{{ID: "1", Amount: "-12.50", USDValue: "12.50", Currency: "USDC", CreatedAt: "2026-03-04T12:00:00Z"}},
+		},
+		{
+			name: "number amounts",
+			data: `{"transactions":[{"id":"1","amount":-12.50,"usdValue":1e2,"currency":"USDC"}]}`,
+			want: []Transaction{{ID: "1", Amount: "-12.50", USDValue: "1e2", Currency: "USDC"}},
+		},
+		{
+			name: "missing and null fields",
+			data: `{"transactions":[{"id":"1","amount":null}]}`,
+			want: []Transaction{{ID: "1"}},
+		},
+		{
+			name: "bare array",
+			data: ` [{"id":"1","amount":5},{"id":"2","amount":"6"}]`,
+			want: []Transaction{{ID: "1", Amount: "5"}, {ID: "2", Amount: "6"}},
+		},
+		{
+			name: "no transactions",
+			data: `{}`,
+		},
+		{
+			name:    "amount of the wrong type",
+			data:    `{"transactions":[{"id":"1","amount":true}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "not JSON",
+			data:    `transactions`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTransactions(json.RawMessage(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTransactions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseTransactions() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("transaction %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTransactionHelpers(t *testing.T) {
+	tests := []struct {
+		name       string
+		tx         Transaction
+		wantAmount float64
+		wantDebit  bool
+		wantTime   time.Time
+	}{
+		{
+			name:       "debit",
+			tx:         Transaction{Amount: "12.50", Direction: "debit", CreatedAt: "2026-03-04T12:00:00Z"},
+			wantAmount: 12.5, wantDebit: true,
+			wantTime: time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "negative amount",
+			tx:         Transaction{Amount: " -3 ", CreatedAt: "2026-03-04T12:00:00.123+01:00"},
+			wantAmount: -3, wantDebit: true,
+			wantTime: time.Date(2026, 3, 4, 11, 0, 0, 123000000, time.UTC),
+		},
+		{
+			name:       "credit",
+			tx:         Transaction{Amount: "100", Direction: "credit"},
+			wantAmount: 100,
+		},
+		{
+			name: "malformed amount and time",
+			tx:   Transaction{Amount: "12,50 USD", Direction: "credit", CreatedAt: "yesterday"},
+		},
+		{
+			name: "missing fields",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tx.AmountFloat(); got != tt.wantAmount {
+				t.Errorf("AmountFloat() = %v, want %v", got, tt.wantAmount)
+			}
+			if got := tt.tx.IsDebit(); got != tt.wantDebit {
+				t.Errorf("IsDebit() = %v, want %v", got, tt.wantDebit)
+			}
+			if got := tt.tx.CreatedTime(); !got.Equal(tt.wantTime) {
+				t.Errorf("CreatedTime() = %v, want %v", got, tt.wantTime)
+			}
+		})
+	}
+}
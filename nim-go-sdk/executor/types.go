@@ -29,6 +29,23 @@ type SavingsPosition struct {
 	Earnings     string `json:"earnings"`
 }
 
+// MaxSavingsHistoryDays is the longest window get_savings_history covers.
+const MaxSavingsHistoryDays = 365
+
+type GetSavingsHistoryResponse struct {
+	Points           []SavingsHistoryPoint `json:"points"`
+	TotalEarningsUSD string                `json:"totalEarningsUsd"`
+}
+
+// SavingsHistoryPoint is one day of one currency's savings: the balance at
+// the end of the day and the interest accrued during it.
+type SavingsHistoryPoint struct {
+	Date     string `json:"date"` // YYYY-MM-DD
+	Currency string `json:"currency"`
+	Balance  string `json:"balance"`
+	Earnings string `json:"earnings"`
+}
+
 type GetVaultRatesResponse struct {
 	Vaults []VaultRate `json:"vaults"`
 }
@@ -44,6 +61,12 @@ type DepositResponse struct {
 	Error         string `json:"error,omitempty"`
 	TransactionID string `json:"transactionId,omitempty"`
 	TxHash        string `json:"txHash,omitempty"`
+
+	// Set for dry runs, which preview the write without executing it.
+	DryRun           bool   `json:"dryRun,omitempty"`
+	Fee              string `json:"fee,omitempty"`
+	FeeCurrency      string `json:"feeCurrency,omitempty"`
+	EstimatedArrival string `json:"estimatedArrival,omitempty"` // RFC 3339
 }
 
 type WithdrawResponse struct {
@@ -51,6 +74,12 @@ type WithdrawResponse struct {
 	Error         string `json:"error,omitempty"`
 	TransactionID string `json:"transactionId,omitempty"`
 	TxHash        string `json:"txHash,omitempty"`
+
+	// Set for dry runs, which preview the write without executing it.
+	DryRun           bool   `json:"dryRun,omitempty"`
+	Fee              string `json:"fee,omitempty"`
+	FeeCurrency      string `json:"feeCurrency,omitempty"`
+	EstimatedArrival string `json:"estimatedArrival,omitempty"` // RFC 3339
 }
 
 // Payments types
@@ -59,6 +88,44 @@ type SendMoneyResponse struct {
 	Error         string `json:"error,omitempty"`
 	TransactionID string `json:"transactionId,omitempty"`
 	TxHash        string `json:"txHash,omitempty"`
+
+	// Set for dry runs, which preview the write without executing it.
+	DryRun           bool   `json:"dryRun,omitempty"`
+	Fee              string `json:"fee,omitempty"`
+	FeeCurrency      string `json:"feeCurrency,omitempty"`
+	EstimatedArrival string `json:"estimatedArrival,omitempty"` // RFC 3339
+}
+
+// RequestMoneyResponse is the result of request_money: the request sent,
+// which stays pending until the recipient pays or declines it.
+type RequestMoneyResponse struct {
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+	Status    string `json:"status,omitempty"`
+}
+
+// Payment request directions, as get_payment_requests' direction input and
+// PaymentRequest.Direction.
+const (
+	RequestIncoming = "incoming" // someone asked the user to pay
+	RequestOutgoing = "outgoing" // the user asked someone to pay
+)
+
+type GetPaymentRequestsResponse struct {
+	Requests []PaymentRequest `json:"requests"`
+}
+
+// PaymentRequest is a pending request for money, to or from the user.
+type PaymentRequest struct {
+	ID           string `json:"id"`
+	Direction    string `json:"direction"`    // RequestIncoming or RequestOutgoing
+	Counterparty string `json:"counterparty"` // display tag of the other user
+	Amount       string `json:"amount"`
+	Currency     string `json:"currency"`
+	Note         string `json:"note,omitempty"`
+	Status       string `json:"status"`
+	CreatedAt    string `json:"createdAt"`
 }
 
 // Ledger types
@@ -81,6 +148,26 @@ type Transaction struct {
 	TxHash       string `json:"txHash"`
 }
 
+// GetSpendingSummaryResponse is the money in and out over the last Days
+// days, grouped by GroupBy ("day", "week" or "category"). Amounts are in USD.
+type GetSpendingSummaryResponse struct {
+	Days        int32            `json:"days"`
+	GroupBy     string           `json:"groupBy"`
+	Buckets     []SpendingBucket `json:"buckets"`
+	TotalInUSD  string           `json:"totalInUsd"`
+	TotalOutUSD string           `json:"totalOutUsd"`
+}
+
+// SpendingBucket totals the transactions in one day, week or category.
+type SpendingBucket struct {
+	Key      string `json:"key"` // YYYY-MM-DD, the week's Monday, or the category
+	TotalIn  string `json:"totalIn"`
+	TotalOut string `json:"totalOut"`
+	CountIn  int32  `json:"countIn"`
+	CountOut int32  `json:"countOut"`
+	USDValue string `json:"usdValue"` // net: TotalIn - TotalOut
+}
+
 // Users types
 type GetProfileResponse struct {
 	UserID     string `json:"userId"`
@@ -148,6 +235,8 @@ func toolResponseType(toolName string) interface{} {
 		return &GetBalanceResponse{}
 	case "get_savings_balance":
 		return &GetSavingsBalanceResponse{}
+	case "get_savings_history":
+		return &GetSavingsHistoryResponse{}
 	case "get_vault_rates":
 		return &GetVaultRatesResponse{}
 	case "deposit_savings":
@@ -156,8 +245,14 @@ func toolResponseType(toolName string) interface{} {
 		return &WithdrawResponse{}
 	case "send_money":
 		return &SendMoneyResponse{}
+	case "request_money":
+		return &RequestMoneyResponse{}
+	case "get_payment_requests":
+		return &GetPaymentRequestsResponse{}
 	case "get_transactions":
 		return &GetTransactionsResponse{}
+	case "get_spending_summary":
+		return &GetSpendingSummaryResponse{}
 	case "get_profile":
 		return &GetProfileResponse{}
 	case "search_users":
@@ -0,0 +1,159 @@
+// Package export renders stored conversations for users to download, as
+// JSON or markdown.
+//
+// Attachments, charts and other files appear as artifact references with
+// signed, expiring download URLs rather than inline data. Ephemeral
+// artifacts, which were not retained, keep their metadata but have no link.
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// Conversation is the exported form of a conversation.
+type Conversation struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	ExportedAt time.Time `json:"exported_at"`
+	Messages   []Message `json:"messages"`
+}
+
+// Message is an exported message.
+type Message struct {
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	CreatedAt time.Time      `json:"created_at"`
+	Artifacts []artifact.Ref `json:"artifacts,omitempty"`
+}
+
+// Build prepares conv for export at time now, resolving its artifacts with r.
+// A nil Resolver exports artifacts without download links.
+func Build(conv *store.ConversationWithMessages, r *artifact.Resolver, now time.Time) *Conversation {
+	out := &Conversation{
+		ID:         conv.ID,
+		Title:      conv.Title,
+		CreatedAt:  conv.CreatedAt.UTC(),
+		UpdatedAt:  conv.UpdatedAt.UTC(),
+		ExportedAt: now.UTC(),
+		Messages:   make([]Message, 0, len(conv.Messages)),
+	}
+	for _, m := range conv.Messages {
+		out.Messages = append(out.Messages, Message{
+			Role:      m.Role,
+			Content:   m.Content,
+			CreatedAt: m.CreatedAt.UTC(),
+			Artifacts: r.ResolveAll(m.Artifacts),
+		})
+	}
+	return out
+}
+
+// JSON renders the export as indented JSON.
+func (c *Conversation) JSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // keep & in signed URLs readable
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// timeFormat is how times appear in markdown exports.
+const timeFormat = "2006-01-02 15:04 UTC"
+
+// Markdown renders the export as markdown. Charts are shown as images and
+// other artifacts as labeled placeholders, linked when they can be downloaded.
+func (c *Conversation) Markdown() string {
+	var b strings.Builder
+
+	title := c.Title
+	if title == "" {
+		title = "Conversation"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "_Started %s · exported %s_\n", c.CreatedAt.Format(timeFormat), c.ExportedAt.Format(timeFormat))
+
+	for _, m := range c.Messages {
+		fmt.Fprintf(&b, "\n---\n\n**%s** · %s\n\n", speaker(m.Role), m.CreatedAt.Format(timeFormat))
+		if content := strings.TrimSpace(m.Content); content != "" {
+			b.WriteString(content)
+			b.WriteString("\n")
+		}
+		for _, ref := range m.Artifacts {
+			b.WriteString("\n")
+			b.WriteString(artifactMarkdown(ref))
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func speaker(role string) string {
+	switch role {
+	case "user":
+		return "You"
+	case "assistant":
+		return "Nim"
+	}
+	return role
+}
+
+// artifactMarkdown renders a resolved artifact reference.
+func artifactMarkdown(ref artifact.Ref) string {
+	name := ref.Name
+	if name == "" {
+		name = ref.ID
+	}
+
+	if ref.Kind == artifact.KindChart && ref.Status == artifact.StatusAvailable {
+		return fmt.Sprintf("![Chart: %s](%s)", name, ref.URL)
+	}
+
+	label := fmt.Sprintf("📎 %s: %s (%s, %s)", kindLabel(ref.Kind), name, ref.MediaType, formatSize(ref.Size))
+	switch ref.Status {
+	case artifact.StatusAvailable:
+		return fmt.Sprintf("> %s - [download](%s), link expires %s", label, ref.URL, ref.URLExpiresAt.UTC().Format(timeFormat))
+	case artifact.StatusNotRetained:
+		return fmt.Sprintf("> %s - not retained", label)
+	}
+	return fmt.Sprintf("> %s - unavailable", label)
+}
+
+func kindLabel(k artifact.Kind) string {
+	switch k {
+	case artifact.KindAttachment:
+		return "Attachment"
+	case artifact.KindChart:
+		return "Chart"
+	case artifact.KindExport:
+		return "Export"
+	}
+	return "File"
+}
+
+// formatSize formats a byte count, e.g. 512 B, 118.2 KB, 1.4 MB.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	size := float64(n) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if size < unit || suffix == "GB" {
+			return fmt.Sprintf("%.1f %s", size, suffix)
+		}
+		size /= unit
+	}
+	return ""
+}
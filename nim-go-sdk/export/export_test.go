@@ -0,0 +1,126 @@
+package export
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+var epoch = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+// fixture is a conversation with one chart, one retained attachment, and one
+// ephemeral receipt.
+func fixture() *store.ConversationWithMessages {
+	return &store.ConversationWithMessages{
+		Conversation: store.Conversation{
+			ID:        "conv-1",
+			UserID:    "user-1",
+			Title:     "Splitting dinner",
+			CreatedAt: epoch,
+			UpdatedAt: epoch.Add(5 * time.Minute),
+		},
+		Messages: []store.StoredMessage{
+			{
+				ID:        "m-1",
+				Role:      "user",
+				Content:   "Here's the lease and the dinner receipt",
+				CreatedAt: epoch,
+				Artifacts: []artifact.Ref{
+					{ID: "att-1", Kind: artifact.KindAttachment, Name: "lease.pdf", MediaType: "application/pdf", Size: 121037, CreatedAt: epoch, Location: "att-1.pdf"},
+					{ID: "att-2", Kind: artifact.KindAttachment, Name: "receipt.jpg", MediaType: "image/jpeg", Size: 48213, CreatedAt: epoch, Ephemeral: true},
+				},
+			},
+			{
+				ID:        "m-2",
+				Role:      "assistant",
+				Content:   "Dinner came to $84.20, so $42.10 each. Here's your spending this month:",
+				CreatedAt: epoch.Add(time.Minute),
+				Artifacts: []artifact.Ref{
+					{ID: "chart-1", Kind: artifact.KindChart, Name: "Spending by category", MediaType: "image/svg+xml", Size: 6120, CreatedAt: epoch.Add(time.Minute), Location: "chart-1.svg"},
+				},
+			},
+		},
+	}
+}
+
+func resolver() *artifact.Resolver {
+	signer := artifact.NewSigner([]byte("golden-secret"), 15*time.Minute)
+	signer.Now = func() time.Time { return epoch.Add(time.Hour) }
+	return &artifact.Resolver{BaseURL: "https://agent.example.com/artifacts/", Signer: signer}
+}
+
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s mismatch:\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func TestExport(t *testing.T) {
+	conv := Build(fixture(), resolver(), epoch.Add(time.Hour))
+
+	t.Run("json", func(t *testing.T) {
+		got, err := conv.JSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden(t, "conversation.json", got)
+	})
+	t.Run("markdown", func(t *testing.T) {
+		golden(t, "conversation.md", []byte(conv.Markdown()))
+	})
+}
+
+func TestExportWithoutResolver(t *testing.T) {
+	conv := Build(fixture(), nil, epoch.Add(time.Hour))
+	for _, m := range conv.Messages {
+		for _, ref := range m.Artifacts {
+			if ref.URL != "" || ref.Location != "" {
+				t.Errorf("%s exported with URL %q, location %q", ref.ID, ref.URL, ref.Location)
+			}
+			want := artifact.StatusUnavailable
+			if ref.Ephemeral {
+				want = artifact.StatusNotRetained
+			}
+			if ref.Status != want {
+				t.Errorf("%s status = %q, want %q", ref.ID, ref.Status, want)
+			}
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{121037, "118.2 KB"},
+		{3 << 20, "3.0 MB"},
+		{5 << 40, "5120.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.n); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Package features resolves feature flags: named switches for optional
+// behavior that operators turn on per deployment, or per user cohort with a
+// Provider such as Rollout.
+//
+// Flags must be registered before use, with a default, so a misspelled flag
+// in configuration fails at startup rather than silently doing nothing. The
+// server evaluates every flag for the user once per message and carries the
+// result on the context, where Enabled reads it from the server, engine and
+// tools alike.
+package features
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Built-in flags.
+const (
+	// NaturalConfirmations lets users answer a pending confirmation in
+	// plain text. See server.Config.NaturalConfirmations.
+	NaturalConfirmations = "natural_confirmations"
+
+	// ModerationBestEffort streams replies live and moderates them
+	// afterwards, instead of holding streamed text until it is checked.
+	ModerationBestEffort = "moderation_best_effort"
+
+	// DevMode lets authenticated connections request developer mode.
+	// See server.Config.AllowDevMode.
+	DevMode = "dev_mode"
+)
+
+// Flag is a registered feature flag.
+type Flag struct {
+	Name        string
+	Description string
+
+	// Default is the flag's value unless configuration or a Provider
+	// decides otherwise.
+	Default bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Flag{
+		NaturalConfirmations: {Name: NaturalConfirmations, Description: "Answer confirmations in plain text"},
+		ModerationBestEffort: {Name: ModerationBestEffort, Description: "Stream replies before moderating them"},
+		DevMode:              {Name: DevMode, Description: "Allow developer mode connections"},
+	}
+)
+
+// Register registers flags so they can be configured and evaluated.
+// Applications register their own flags before creating the server.
+// Registering a flag again replaces it.
+func Register(flags ...Flag) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, f := range flags {
+		registry[f.Name] = f
+	}
+}
+
+// Known returns every registered flag, sorted by name.
+func Known() []Flag {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	flags := make([]Flag, 0, len(registry))
+	for _, f := range registry {
+		flags = append(flags, f)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+func lookup(name string) (Flag, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	f, ok := registry[name]
+	return f, ok
+}
+
+// checkKnown returns an error naming every unregistered flag in names.
+func checkKnown(names []string) error {
+	var unknown []string
+	for _, name := range names {
+		if _, ok := lookup(name); !ok {
+			unknown = append(unknown, fmt.Sprintf("%q", name))
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("unknown feature flag %s; register it with features.Register", strings.Join(unknown, ", "))
+}
+
+// Provider decides flags per user, e.g. from a rollout percentage or a
+// remote flag service.
+type Provider interface {
+	// Evaluate decides the flag for the user. It returns ok false to leave
+	// the flag at its static value.
+	Evaluate(ctx context.Context, id core.Identity, flag string) (enabled, ok bool)
+}
+
+// Set resolves flags: a Provider's decision first, then the static value
+// configured for the deployment, then the flag's registered default.
+// A nil Set resolves every flag to its default.
+type Set struct {
+	static   map[string]bool
+	provider Provider
+}
+
+// New creates a set from static flag values and an optional provider.
+// Returns an error if static names an unregistered flag, or if the provider
+// lists one in a Flags method.
+func New(static map[string]bool, provider Provider) (*Set, error) {
+	names := make([]string, 0, len(static))
+	for name := range static {
+		names = append(names, name)
+	}
+	if p, ok := provider.(interface{ Flags() []string }); ok {
+		names = append(names, p.Flags()...)
+	}
+	if err := checkKnown(names); err != nil {
+		return nil, err
+	}
+
+	s := &Set{static: make(map[string]bool, len(static)), provider: provider}
+	for name, on := range static {
+		s.static[name] = on
+	}
+	return s, nil
+}
+
+// Enabled resolves the flag for the user. Unregistered flags are off.
+func (s *Set) Enabled(ctx context.Context, id core.Identity, flag string) bool {
+	f, ok := lookup(flag)
+	if !ok {
+		return false
+	}
+	if s == nil {
+		return f.Default
+	}
+	if s.provider != nil {
+		if on, ok := s.provider.Evaluate(ctx, id, flag); ok {
+			return on
+		}
+	}
+	if on, ok := s.static[flag]; ok {
+		return on
+	}
+	return f.Default
+}
+
+// Evaluate resolves every registered flag for the user.
+func (s *Set) Evaluate(ctx context.Context, id core.Identity) Flags {
+	flags := make(Flags)
+	for _, f := range Known() {
+		flags[f.Name] = s.Enabled(ctx, id, f.Name)
+	}
+	return flags
+}
+
+// Flags are evaluated flag values, keyed by name.
+type Flags map[string]bool
+
+type flagsKey struct{}
+
+// WithFlags returns a copy of ctx carrying evaluated flags.
+func WithFlags(ctx context.Context, flags Flags) context.Context {
+	return context.WithValue(ctx, flagsKey{}, flags)
+}
+
+// FromContext returns the flags carried by ctx, or nil.
+func FromContext(ctx context.Context) Flags {
+	flags, _ := ctx.Value(flagsKey{}).(Flags)
+	return flags
+}
+
+// Enabled reports whether the flag is on for the request ctx belongs to.
+// Flags not evaluated on ctx resolve to their registered default.
+func Enabled(ctx context.Context, flag string) bool {
+	if on, ok := FromContext(ctx)[flag]; ok {
+		return on
+	}
+	f, _ := lookup(flag)
+	return f.Default
+}
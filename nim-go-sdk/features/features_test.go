@@ -0,0 +1,234 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func init() {
+	Register(
+		Flag{Name: "test_on", Default: true},
+		Flag{Name: "test_off"},
+	)
+}
+
+// cohort turns flags on for the listed users and leaves everyone else alone.
+type cohort map[string]map[string]bool // flag -> userID -> enabled
+
+func (c cohort) Evaluate(ctx context.Context, id core.Identity, flag string) (bool, bool) {
+	on, ok := c[flag][id.UserID]
+	return on, ok
+}
+
+func TestSet_Enabled(t *testing.T) {
+	ctx := context.Background()
+	alice := core.Identity{UserID: "alice"}
+	bob := core.Identity{UserID: "bob"}
+
+	tests := []struct {
+		name     string
+		static   map[string]bool
+		provider Provider
+		id       core.Identity
+		flag     string
+		want     bool
+	}{
+		{name: "default on", flag: "test_on", id: alice, want: true},
+		{name: "default off", flag: "test_off", id: alice, want: false},
+		{name: "static overrides default", static: map[string]bool{"test_on": false}, flag: "test_on", id: alice, want: false},
+		{name: "unregistered flag is off", flag: "tset_on", id: alice, want: false},
+		{
+			name:     "provider overrides static",
+			static:   map[string]bool{"test_off": false},
+			provider: cohort{"test_off": {"alice": true}},
+			flag:     "test_off", id: alice, want: true,
+		},
+		{
+			name:     "provider can turn a flag off",
+			static:   map[string]bool{"test_off": true},
+			provider: cohort{"test_off": {"alice": false}},
+			flag:     "test_off", id: alice, want: false,
+		},
+		{
+			name:     "provider leaves other users to static",
+			static:   map[string]bool{"test_off": true},
+			provider: cohort{"test_off": {"alice": false}},
+			flag:     "test_off", id: bob, want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.static, tt.provider)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Enabled(ctx, tt.id, tt.flag); got != tt.want {
+				t.Errorf("Enabled(%s, %s) = %v, want %v", tt.id.UserID, tt.flag, got, tt.want)
+			}
+		})
+	}
+
+	var nilSet *Set
+	if !nilSet.Enabled(ctx, alice, "test_on") || nilSet.Enabled(ctx, alice, "test_off") {
+		t.Error("nil set should resolve flags to their defaults")
+	}
+}
+
+func TestNew_UnknownFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		static   map[string]bool
+		provider Provider
+		wantErr  string
+	}{
+		{name: "known", static: map[string]bool{"test_on": true, NaturalConfirmations: true}},
+		{name: "typo", static: map[string]bool{"natural_confirmation": true}, wantErr: `unknown feature flag "natural_confirmation"`},
+		{name: "all listed", static: map[string]bool{"b_flag": true, "a_flag": false}, wantErr: `"a_flag", "b_flag"`},
+		{name: "rollout typo", provider: mustRollout(t, map[string]int{"dev_mod": 10}), wantErr: `unknown feature flag "dev_mod"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.static, tt.provider)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnabled_Context(t *testing.T) {
+	ctx := context.Background()
+	if !Enabled(ctx, "test_on") || Enabled(ctx, "test_off") {
+		t.Error("flags not evaluated on the context should resolve to their defaults")
+	}
+
+	s, err := New(map[string]bool{"test_on": false, "test_off": true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags := s.Evaluate(ctx, core.Identity{UserID: "alice"})
+	if len(flags) != len(Known()) {
+		t.Errorf("evaluated %d flags, want every registered flag (%d)", len(flags), len(Known()))
+	}
+	ctx = WithFlags(ctx, flags)
+	if Enabled(ctx, "test_on") || !Enabled(ctx, "test_off") {
+		t.Errorf("Enabled read %v from the context, want test_on off and test_off on", FromContext(ctx))
+	}
+}
+
+func mustRollout(t *testing.T, percent map[string]int) *Rollout {
+	t.Helper()
+	r, err := NewRollout(percent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRollout(t *testing.T) {
+	ctx := context.Background()
+	users := make([]core.Identity, 1000)
+	for i := range users {
+		users[i] = core.Identity{UserID: fmt.Sprintf("user-%d", i)}
+	}
+	count := func(r *Rollout, flag string) (on map[string]bool) {
+		on = make(map[string]bool)
+		for _, id := range users {
+			enabled, ok := r.Evaluate(ctx, id, flag)
+			if !ok {
+				t.Fatalf("rollout left %s undecided for %s", flag, id.UserID)
+			}
+			if enabled {
+				on[id.UserID] = true
+			}
+		}
+		return on
+	}
+
+	t.Run("stable per user", func(t *testing.T) {
+		r := mustRollout(t, map[string]int{"test_off": 30})
+		first, second := count(r, "test_off"), count(r, "test_off")
+		if len(first) != len(second) {
+			t.Fatalf("decisions changed between evaluations: %d then %d users", len(first), len(second))
+		}
+		for user := range first {
+			if !second[user] {
+				t.Errorf("%s lost the flag on re-evaluation", user)
+			}
+		}
+	})
+
+	t.Run("roughly the percentage", func(t *testing.T) {
+		on := count(mustRollout(t, map[string]int{"test_off": 30}), "test_off")
+		if n := len(on); n < 250 || n > 350 {
+			t.Errorf("30%% rollout enabled %d of 1000 users", n)
+		}
+	})
+
+	t.Run("raising the percentage only adds users", func(t *testing.T) {
+		low := count(mustRollout(t, map[string]int{"test_off": 10}), "test_off")
+		high := count(mustRollout(t, map[string]int{"test_off": 50}), "test_off")
+		for user := range low {
+			if !high[user] {
+				t.Errorf("%s was in the 10%% rollout but not the 50%% one", user)
+			}
+		}
+	})
+
+	t.Run("flags pick different cohorts", func(t *testing.T) {
+		r := mustRollout(t, map[string]int{"test_on": 10, "test_off": 10})
+		a, b := count(r, "test_on"), count(r, "test_off")
+		same := 0
+		for user := range a {
+			if b[user] {
+				same++
+			}
+		}
+		if same == len(a) {
+			t.Error("two flags at the same percentage rolled out to exactly the same users")
+		}
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		if n := len(count(mustRollout(t, map[string]int{"test_off": 0}), "test_off")); n != 0 {
+			t.Errorf("0%% rollout enabled %d users", n)
+		}
+		if n := len(count(mustRollout(t, map[string]int{"test_off": 100}), "test_off")); n != len(users) {
+			t.Errorf("100%% rollout enabled %d of %d users", n, len(users))
+		}
+		if _, err := NewRollout(map[string]int{"test_off": 101}); err == nil {
+			t.Error("expected an error for a rollout over 100%")
+		}
+	})
+
+	t.Run("undecided flags and anonymous users", func(t *testing.T) {
+		r := mustRollout(t, map[string]int{"test_off": 100})
+		if _, ok := r.Evaluate(ctx, users[0], "test_on"); ok {
+			t.Error("rollout decided a flag it has no percentage for")
+		}
+		if _, ok := r.Evaluate(ctx, core.Identity{}, "test_off"); ok {
+			t.Error("rollout decided a flag for a request without a user")
+		}
+
+		s, err := New(map[string]bool{"test_on": false}, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Enabled(ctx, users[0], "test_on") {
+			t.Error("flag without a rollout should keep its static value")
+		}
+		if !s.Enabled(ctx, users[0], "test_off") {
+			t.Error("100% rollout should enable the flag")
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Rollout is a Provider that turns flags on for a percentage of users.
+//
+// Each user falls in a stable bucket per flag, so a user keeps the same
+// decision across messages and instances, and raising a percentage only
+// adds users. Buckets differ between flags, so rolling out two flags at 10%
+// does not pick the same users for both. Flags without a percentage, and
+// requests without a user, are left to the Set's static values.
+type Rollout struct {
+	percent map[string]int
+}
+
+// NewRollout creates a rollout from percentages of users, 0 to 100, keyed
+// by flag name.
+func NewRollout(percent map[string]int) (*Rollout, error) {
+	r := &Rollout{percent: make(map[string]int, len(percent))}
+	for flag, p := range percent {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("rollout of %q must be between 0 and 100 percent, got %d", flag, p)
+		}
+		r.percent[flag] = p
+	}
+	return r, nil
+}
+
+// Evaluate implements Provider.
+func (r *Rollout) Evaluate(ctx context.Context, id core.Identity, flag string) (bool, bool) {
+	p, ok := r.percent[flag]
+	if !ok || id.UserID == "" {
+		return false, false
+	}
+	return Bucket(id.UserID, flag) < p, true
+}
+
+// Flags returns the flags the rollout decides, so New can check they are
+// registered.
+func (r *Rollout) Flags() []string {
+	flags := make([]string, 0, len(r.percent))
+	for flag := range r.percent {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+	return flags
+}
+
+// Bucket returns the user's bucket for the flag, from 0 to 99.
+func Bucket(userID, flag string) int {
+	h := fnv.New32a()
+	h.Write([]byte(flag))
+	h.Write([]byte{0})
+	h.Write([]byte(userID))
+	return int(h.Sum32() % 100)
+}
+
+// Verify Rollout implements Provider.
+var _ Provider = (*Rollout)(nil)
@@ -0,0 +1,103 @@
+package i18n
+
+import (
+	"fmt"
+	"time"
+)
+
+// calendar is how a language writes dates. Languages without one are
+// written in English.
+type calendar struct {
+	months   [12]string // abbreviated, January first
+	weekdays [7]string  // Sunday first
+
+	// dayFirst writes the day before the month, e.g. "2 Jan".
+	dayFirst bool
+
+	// dayDot follows the day with a period, e.g. "2. Jan.".
+	dayDot bool
+
+	// weekdayComma separates the weekday from the date with a comma.
+	weekdayComma bool
+}
+
+var english = calendar{
+	months:       [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	weekdays:     [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	weekdayComma: true,
+}
+
+var calendars = map[string]calendar{
+	"de": {
+		months:       [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		weekdays:     [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		dayFirst:     true,
+		dayDot:       true,
+		weekdayComma: true,
+	},
+	"es": {
+		months:       [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		weekdays:     [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		dayFirst:     true,
+		weekdayComma: true,
+	},
+	"fr": {
+		months:   [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		weekdays: [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		dayFirst: true,
+	},
+}
+
+// japanese weekdays, Sunday first.
+var japaneseWeekdays = [7]string{"日", "月", "火", "水", "木", "金", "土"}
+
+// dates writes dates for a locale.
+type dates struct {
+	calendar
+	japanese bool
+}
+
+// names returns how locale writes dates. English outside the US, and
+// languages with a calendar, write the day first.
+func names(locale string) dates {
+	tag := Tag(locale)
+	base, _ := tag.Base()
+	switch base.String() {
+	case "ja":
+		return dates{japanese: true}
+	case "en":
+		c := english
+		region, _ := tag.Region()
+		c.dayFirst = region.String() != "US"
+		return dates{calendar: c}
+	}
+	if c, ok := calendars[base.String()]; ok {
+		return dates{calendar: c}
+	}
+	return dates{calendar: english}
+}
+
+func (d dates) date(t time.Time) string {
+	if d.japanese {
+		return fmt.Sprintf("%d月%d日", int(t.Month()), t.Day())
+	}
+	month := d.months[t.Month()-1]
+	switch {
+	case d.dayDot:
+		return fmt.Sprintf("%d. %s", t.Day(), month)
+	case d.dayFirst:
+		return fmt.Sprintf("%d %s", t.Day(), month)
+	}
+	return fmt.Sprintf("%s %d", month, t.Day())
+}
+
+func (d dates) weekdayDate(t time.Time) string {
+	if d.japanese {
+		return fmt.Sprintf("%s(%s)", d.date(t), japaneseWeekdays[t.Weekday()])
+	}
+	sep := " "
+	if d.weekdayComma {
+		sep = ", "
+	}
+	return d.weekdays[t.Weekday()] + sep + d.date(t)
+}
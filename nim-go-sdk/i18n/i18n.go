@@ -0,0 +1,152 @@
+// Package i18n formats money and dates for a user's locale and timezone,
+// and finds the start of their week. Locales are BCP 47 tags such as
+// "de-DE"; anything unparseable is formatted as American English.
+// Timezones are IANA names such as "America/New_York"; unknown ones are
+// UTC. Applications deployed without a zoneinfo database should import
+// time/tzdata.
+package i18n
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+
+	"github.com/becomeliminal/nim-go-sdk/money"
+)
+
+// maxFractionDigits caps the decimal places FormatMoney shows for amounts
+// finer than their currency's minor unit.
+const maxFractionDigits = 8
+
+// Tag parses locale, e.g. "de-DE" or "de_DE", or returns American English
+// if it doesn't parse.
+func Tag(locale string) language.Tag {
+	tag, err := language.Parse(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if err != nil || tag == language.Und {
+		return language.AmericanEnglish
+	}
+	return tag
+}
+
+// symbolAfter are the languages that write a currency symbol after the
+// amount, e.g. "1.234,50 €". Portuguese does, except in Brazil.
+var symbolAfter = map[string]bool{
+	"cs": true, "da": true, "de": true, "el": true, "es": true, "fi": true,
+	"fr": true, "hu": true, "it": true, "nb": true, "pl": true, "pt": true,
+	"ro": true, "ru": true, "sk": true, "sv": true, "uk": true,
+}
+
+// FormatMoney formats amount of currency for locale, with the locale's
+// separators and the currency's symbol where it has one: 1234.5 EUR is
+// "€1,234.50" in en-US and "1.234,50 €" in de-DE. Codes that aren't ISO
+// 4217 currencies, such as stablecoins, follow the amount: "1,234.50
+// USDC". Amounts show their currency's minor unit, or more decimal places
+// if they have them.
+func FormatMoney(amount float64, currencyCode, locale string) string {
+	tag := Tag(locale)
+	p := message.NewPrinter(tag)
+	code := strings.ToUpper(strings.TrimSpace(currencyCode))
+	digits := money.Digits(code)
+	num := p.Sprint(number.Decimal(math.Abs(amount),
+		number.MinFractionDigits(digits),
+		number.MaxFractionDigits(max(digits, maxFractionDigits))))
+
+	sign := ""
+	if amount < 0 && strings.ContainsAny(num, "123456789") {
+		sign = "-"
+	}
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return strings.TrimSpace(sign + num + " " + code)
+	}
+	symbol := p.Sprint(currency.Symbol(unit))
+	if after(tag) {
+		return sign + num + " " + symbol
+	}
+	if last, _ := lastRune(symbol); unicode.IsLetter(last) {
+		// Codes and lettered symbols, e.g. "CHF 50.00".
+		return sign + symbol + " " + num
+	}
+	return sign + symbol + num
+}
+
+// after reports whether tag's language writes currency symbols after the
+// amount.
+func after(tag language.Tag) bool {
+	base, _ := tag.Base()
+	if base.String() == "pt" {
+		region, _ := tag.Region()
+		return region.String() != "BR"
+	}
+	return symbolAfter[base.String()]
+}
+
+func lastRune(s string) (rune, bool) {
+	r := []rune(s)
+	if len(r) == 0 {
+		return 0, false
+	}
+	return r[len(r)-1], true
+}
+
+// locations caches loaded timezones by name.
+var locations sync.Map
+
+// Location loads the IANA timezone tz, or returns UTC if it's empty or
+// unknown.
+func Location(tz string) *time.Location {
+	if tz == "" {
+		return time.UTC
+	}
+	if loc, ok := locations.Load(tz); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = time.UTC
+	}
+	locations.Store(tz, loc)
+	return loc
+}
+
+// in returns t in timezone tz, or in its own location if tz is empty.
+func in(t time.Time, tz string) time.Time {
+	if tz == "" {
+		return t
+	}
+	return t.In(Location(tz))
+}
+
+// WeekStart returns midnight on the most recent weekStartDay, today
+// included, of t's week in timezone tz, or in t's location if tz is empty.
+// Days are counted on the calendar, so weeks spanning a daylight saving
+// change start at midnight all the same.
+func WeekStart(t time.Time, tz string, weekStartDay time.Weekday) time.Time {
+	t = in(t, tz)
+	back := (int(t.Weekday()) - int(weekStartDay) + 7) % 7
+	day := t.AddDate(0, 0, -back)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// FormatDate formats t's day and month for locale, in timezone tz, or in
+// t's location if tz is empty: "Jan 2" in en-US, "2 Jan" in en-GB, "2.
+// Jan." in de-DE, "1月2日" in ja-JP.
+func FormatDate(t time.Time, locale, tz string) string {
+	t = in(t, tz)
+	return names(locale).date(t)
+}
+
+// FormatWeekdayDate formats t's weekday, day and month for locale, in
+// timezone tz, or in t's location if tz is empty: "Monday, Jan 2" in
+// en-US, "Montag, 2. Jan." in de-DE.
+func FormatWeekdayDate(t time.Time, locale, tz string) string {
+	t = in(t, tz)
+	return names(locale).weekdayDate(t)
+}
@@ -0,0 +1,134 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		locale   string
+		want     string
+	}{
+		{1234.5, "EUR", "de-DE", "1.234,50 €"},
+		{1234.5, "EUR", "en-US", "€1,234.50"},
+		{1234.5, "EUR", "fr-FR", "1\u00a0234,50 €"},
+		{-5, "USD", "en-US", "-$5.00"},
+		{-5, "USD", "de-DE", "-5,00 $"},
+		{5, "USD", "en-GB", "US$5.00"},
+		{1000, "JPY", "ja-JP", "￥1,000"},
+		{50, "CHF", "en-US", "CHF 50.00"},
+		{0.125, "USDC", "en-US", "0.125 USDC"},
+		{20, "USDC", "de-DE", "20,00 USDC"},
+		{20, "usd", "", "$20.00"},
+		{20, "USD", "not a locale", "$20.00"},
+		{-0.001, "USD", "en-US", "-$0.001"},
+		{-1e-9, "USD", "en-US", "$0.00"},
+	}
+	for _, tt := range tests {
+		if got := FormatMoney(tt.amount, tt.currency, tt.locale); got != tt.want {
+			t.Errorf("FormatMoney(%v, %q, %q) = %q, want %q", tt.amount, tt.currency, tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	// 23:30 UTC on Monday 2 March is already Tuesday in Tokyo.
+	at := time.Date(2026, 3, 2, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		locale, tz  string
+		date, wdate string
+	}{
+		{"en-US", "", "Mar 2", "Monday, Mar 2"},
+		{"en-GB", "", "2 Mar", "Monday, 2 Mar"},
+		{"de-DE", "", "2. März", "Montag, 2. März"},
+		{"fr-FR", "", "2 mars", "lundi 2 mars"},
+		{"es-ES", "", "2 mar", "lunes, 2 mar"},
+		{"ja-JP", "Asia/Tokyo", "3月3日", "3月3日(火)"},
+		{"en-US", "Asia/Tokyo", "Mar 3", "Tuesday, Mar 3"},
+		{"", "Not/AZone", "Mar 2", "Monday, Mar 2"},
+	}
+	for _, tt := range tests {
+		if got := FormatDate(at, tt.locale, tt.tz); got != tt.date {
+			t.Errorf("FormatDate(%q, %q) = %q, want %q", tt.locale, tt.tz, got, tt.date)
+		}
+		if got := FormatWeekdayDate(at, tt.locale, tt.tz); got != tt.wdate {
+			t.Errorf("FormatWeekdayDate(%q, %q) = %q, want %q", tt.locale, tt.tz, got, tt.wdate)
+		}
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	newYork := Location("America/New_York")
+	tokyo := Location("Asia/Tokyo")
+	tests := []struct {
+		name  string
+		t     time.Time
+		tz    string
+		first time.Weekday
+		want  time.Time
+	}{
+		// Clocks went forward at 2am on Sunday 8 March 2026, so the week
+		// from Sunday is an hour short, and the one from Monday starts in
+		// daylight time.
+		{
+			name:  "new york sunday week across dst",
+			t:     time.Date(2026, 3, 11, 0, 30, 0, 0, newYork),
+			tz:    "America/New_York",
+			first: time.Sunday,
+			want:  time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "new york monday week after dst",
+			t:     time.Date(2026, 3, 11, 0, 30, 0, 0, newYork),
+			tz:    "America/New_York",
+			first: time.Monday,
+			want:  time.Date(2026, 3, 9, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "new york on the start day",
+			t:     time.Date(2026, 3, 8, 23, 0, 0, 0, newYork),
+			tz:    "America/New_York",
+			first: time.Sunday,
+			want:  time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC),
+		},
+		// 20:00 UTC on Sunday 17 May is 05:00 on Monday 18 May in Tokyo, so
+		// a Tokyo user's week has started while the server's hasn't.
+		{
+			name:  "tokyo",
+			t:     time.Date(2026, 5, 17, 20, 0, 0, 0, time.UTC),
+			tz:    "Asia/Tokyo",
+			first: time.Monday,
+			want:  time.Date(2026, 5, 18, 0, 0, 0, 0, tokyo),
+		},
+		{
+			name:  "utc",
+			t:     time.Date(2026, 5, 17, 20, 0, 0, 0, time.UTC),
+			tz:    "UTC",
+			first: time.Monday,
+			want:  time.Date(2026, 5, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "t's location",
+			t:     time.Date(2026, 5, 17, 20, 0, 0, 0, tokyo),
+			first: time.Sunday,
+			want:  time.Date(2026, 5, 17, 0, 0, 0, 0, tokyo),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WeekStart(tt.t, tt.tz, tt.first)
+			if !got.Equal(tt.want) || got.Hour() != 0 || got.Weekday() != tt.first {
+				t.Errorf("WeekStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	start := WeekStart(time.Date(2026, 3, 11, 0, 30, 0, 0, newYork), "America/New_York", time.Sunday)
+	if end := start.AddDate(0, 0, 7); end.Sub(start) != 167*time.Hour || end.Hour() != 0 {
+		t.Errorf("week across dst ends at %v, %v after it starts; want midnight, 167h", end, end.Sub(start))
+	}
+}
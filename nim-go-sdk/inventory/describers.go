@@ -0,0 +1,159 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+	"github.com/becomeliminal/nim-go-sdk/limits"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// listLimit caps how many conversations are read for an inventory.
+const listLimit = 10000
+
+// conversationItem lists a conversation without its messages.
+type conversationItem struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Messages  int       `json:"messages"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Conversations describes the user's conversation history: how many
+// conversations and messages, and when. Items list each conversation's
+// title and size, not its messages.
+func Conversations(convs store.Conversations) Describer {
+	return func(ctx context.Context, userID string, withItems bool) (*Category, error) {
+		list, err := convs.List(ctx, userID, listLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		c := &Category{Description: "Your conversations with the assistant."}
+		var items []conversationItem
+		messages := 0
+		for _, conv := range list {
+			full, err := convs.Get(ctx, conv.ID)
+			if err != nil {
+				return nil, err
+			}
+			c.Count++
+			c.Track(conv.CreatedAt)
+			c.Track(conv.UpdatedAt)
+			messages += len(full.Messages)
+			items = append(items, conversationItem{
+				ID:        conv.ID,
+				Title:     conv.Title,
+				Messages:  len(full.Messages),
+				CreatedAt: conv.CreatedAt,
+				UpdatedAt: conv.UpdatedAt,
+			})
+		}
+		c.Summary = map[string]interface{}{"messages": messages}
+		if withItems {
+			c.Items = items
+		}
+		return c, nil
+	}
+}
+
+// Artifacts describes the files attached to or generated in the user's
+// conversations, e.g. receipts and charts. Items are artifact references
+// without storage locations.
+func Artifacts(convs store.Conversations) Describer {
+	return func(ctx context.Context, userID string, withItems bool) (*Category, error) {
+		list, err := convs.List(ctx, userID, listLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		c := &Category{Description: "Files you attached and charts generated for you."}
+		var items []artifact.Ref
+		byKind := make(map[string]interface{})
+		retained := 0
+		for _, conv := range list {
+			full, err := convs.Get(ctx, conv.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range full.Messages {
+				for _, ref := range m.Artifacts {
+					c.Count++
+					if ref.CreatedAt.IsZero() {
+						ref.CreatedAt = m.CreatedAt
+					}
+					c.Track(ref.CreatedAt)
+					n, _ := byKind[string(ref.Kind)].(int)
+					byKind[string(ref.Kind)] = n + 1
+					if !ref.Ephemeral {
+						retained++
+					}
+					ref.Location = ""
+					items = append(items, ref)
+				}
+			}
+		}
+		byKind["retained"] = retained
+		c.Summary = byKind
+		if withItems {
+			c.Items = items
+		}
+		return c, nil
+	}
+}
+
+// Schedules describes the user's scheduled transfers.
+func Schedules(schedules store.Schedules) Describer {
+	return func(ctx context.Context, userID string, withItems bool) (*Category, error) {
+		list, err := schedules.List(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		c := &Category{
+			Description: "Recurring transfers you have scheduled.",
+			DeleteWith:  "cancel_scheduled_transfer",
+		}
+		active := 0
+		for _, s := range list {
+			c.Count++
+			c.Track(s.CreatedAt)
+			if s.Status == store.ScheduleActive {
+				active++
+			}
+		}
+		c.Summary = map[string]interface{}{"active": active}
+		if withItems {
+			c.Items = list
+		}
+		return c, nil
+	}
+}
+
+// Ledger describes the transfers recorded to enforce the user's transfer
+// limits, including reservations for pending confirmations.
+func Ledger(ledger limits.Ledger) Describer {
+	return func(ctx context.Context, userID string, withItems bool) (*Category, error) {
+		entries, err := ledger.Entries(ctx, userID, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+
+		c := &Category{Description: "Transfers recorded to enforce your transfer limits."}
+		reserved := 0
+		for _, e := range entries {
+			c.Count++
+			c.Track(e.At)
+			if e.Reserved {
+				reserved++
+			}
+		}
+		c.Summary = map[string]interface{}{"reserved": reserved}
+		if withItems {
+			c.Items = entries
+		}
+		return c, nil
+	}
+}
@@ -0,0 +1,165 @@
+// Package inventory answers "what do you know about me?" by compiling, for
+// one user, a summary of everything the agent has stored about them.
+//
+// Each store or feature registers a Describer with a Registry, so new
+// features appear in the inventory without changes here. Describers are only
+// ever given the authenticated user's ID, so an inventory cannot include
+// another user's data. By default they report counts and dates; the stored
+// values themselves are listed only when the user asks for one category.
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Category describes one kind of data stored about a user.
+type Category struct {
+	// Name identifies the category, e.g. "conversations".
+	Name string `json:"name"`
+
+	// Description says what the data is, for the user.
+	Description string `json:"description"`
+
+	// Count is how many records are stored.
+	Count int `json:"count"`
+
+	// Oldest and Newest are the dates of the oldest and newest records,
+	// if the data is dated and there is any.
+	Oldest *time.Time `json:"oldest,omitempty"`
+	Newest *time.Time `json:"newest,omitempty"`
+
+	// Summary holds non-sensitive facts about the data, e.g. how many
+	// schedules are active.
+	Summary map[string]interface{} `json:"summary,omitempty"`
+
+	// Items are the stored values. Set only when the category is described
+	// with items.
+	Items interface{} `json:"items,omitempty"`
+
+	// DeleteWith names the tool that deletes or cancels this data, if any.
+	DeleteWith string `json:"delete_with,omitempty"`
+
+	// Error is set if the data could not be described.
+	Error string `json:"error,omitempty"`
+}
+
+// Track records t in the category's date range.
+func (c *Category) Track(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if c.Oldest == nil || t.Before(*c.Oldest) {
+		c.Oldest = &t
+	}
+	if c.Newest == nil || t.After(*c.Newest) {
+		c.Newest = &t
+	}
+}
+
+// Describer describes the data one store holds for userID. Items should be
+// set only if withItems is true. The registry fills in the category name.
+type Describer func(ctx context.Context, userID string, withItems bool) (*Category, error)
+
+// Inventory is everything stored about a user.
+type Inventory struct {
+	Categories []*Category `json:"categories"`
+}
+
+type entry struct {
+	name     string
+	describe Describer
+}
+
+// Registry collects the describers for every store that holds user data.
+type Registry struct {
+	mu      sync.RWMutex
+	entries []entry
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a describer for the named category, replacing any existing
+// one with the same name.
+func (r *Registry) Register(name string, d Describer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, e := range r.entries {
+		if e.name == name {
+			r.entries[i].describe = d
+			return
+		}
+	}
+	r.entries = append(r.entries, entry{name: name, describe: d})
+}
+
+// Names returns the registered category names, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, len(r.entries))
+	for i, e := range r.entries {
+		names[i] = e.name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Describe summarizes every category for userID, without items. A category
+// that fails to describe is included with its Error set, so one unavailable
+// store doesn't hide the rest.
+func (r *Registry) Describe(ctx context.Context, userID string) *Inventory {
+	r.mu.RLock()
+	entries := append([]entry(nil), r.entries...)
+	r.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	inv := &Inventory{Categories: make([]*Category, 0, len(entries))}
+	for _, e := range entries {
+		inv.Categories = append(inv.Categories, describe(ctx, e, userID, false))
+	}
+	return inv
+}
+
+// DescribeCategory describes one category for userID, including its items.
+func (r *Registry) DescribeCategory(ctx context.Context, userID, name string) (*Category, error) {
+	r.mu.RLock()
+	var found *entry
+	for _, e := range r.entries {
+		if e.name == name {
+			e := e
+			found = &e
+			break
+		}
+	}
+	r.mu.RUnlock()
+
+	if found == nil {
+		return nil, fmt.Errorf("unknown category %q", name)
+	}
+	return describe(ctx, *found, userID, true), nil
+}
+
+func describe(ctx context.Context, e entry, userID string, withItems bool) *Category {
+	c, err := e.describe(ctx, userID, withItems)
+	if err != nil {
+		return &Category{Name: e.name, Error: fmt.Sprintf("could not be described: %v", err)}
+	}
+	if c == nil {
+		c = &Category{}
+	}
+	c.Name = e.name
+	if !withItems {
+		c.Items = nil
+	}
+	return c
+}
@@ -0,0 +1,167 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/limits"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// seed stores data for user in every store, with names containing marker
+// so the test can tell whose data appears where.
+func seed(t *testing.T, convs *store.MemoryConversations, schedules *store.MemorySchedules, ledger *limits.MemoryLedger, user, marker string) {
+	t.Helper()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		conv, err := convs.Create(ctx, user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		convs.SetTitle(ctx, conv.ID, marker+" budget chat")
+		convs.Append(ctx, &store.AppendMessage{ConversationID: conv.ID, Role: "user", Content: marker + " secret message"})
+		convs.Append(ctx, &store.AppendMessage{
+			ConversationID: conv.ID,
+			Role:           "assistant",
+			Content:        "Here's your chart",
+			Artifacts: []artifact.Ref{
+				{ID: marker + "-chart", Kind: artifact.KindChart, Name: marker + " spending.svg", Location: "chart.svg"},
+				{ID: marker + "-receipt", Kind: artifact.KindAttachment, Name: marker + " receipt.jpg", Ephemeral: true},
+			},
+		})
+	}
+
+	for _, status := range []string{store.ScheduleActive, store.ScheduleCancelled} {
+		schedules.Create(ctx, &store.ScheduledTransfer{UserID: user, Recipient: marker + "-landlord", Amount: "900", Currency: "USD", Cadence: "monthly", Status: status})
+	}
+
+	ledger.Record(ctx, limits.Entry{ID: marker + "-tx", UserID: user, Amount: "50", Currency: "USD", At: time.Now()})
+}
+
+func newRegistry(t *testing.T) *Registry {
+	t.Helper()
+	convs := store.NewMemoryConversations()
+	schedules := store.NewMemorySchedules()
+	ledger := limits.NewMemoryLedger()
+	seed(t, convs, schedules, ledger, "user-1", "alice")
+	seed(t, convs, schedules, ledger, "user-2", "bob")
+
+	r := NewRegistry()
+	r.Register("conversations", Conversations(convs))
+	r.Register("artifacts", Artifacts(convs))
+	r.Register("schedules", Schedules(schedules))
+	r.Register("transfer_ledger", Ledger(ledger))
+	return r
+}
+
+func run(t *testing.T, r *Registry, user, input string) (*core.ToolResult, string) {
+	t.Helper()
+	result, err := Tool(r).Execute(context.Background(), &core.ToolParams{UserID: user, Input: json.RawMessage(input)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(result.Data)
+	return result, string(data)
+}
+
+func TestDescribe(t *testing.T) {
+	r := newRegistry(t)
+	result, out := run(t, r, "user-1", `{}`)
+	if !result.Success {
+		t.Fatalf("describe failed: %s", result.Error)
+	}
+
+	inv := result.Data.(*Inventory)
+	want := map[string]int{"artifacts": 4, "conversations": 2, "schedules": 2, "transfer_ledger": 1}
+	if len(inv.Categories) != len(want) {
+		t.Fatalf("got %d categories, want %d", len(inv.Categories), len(want))
+	}
+	for _, c := range inv.Categories {
+		if c.Count != want[c.Name] {
+			t.Errorf("%s count = %d, want %d", c.Name, c.Count, want[c.Name])
+		}
+		if c.Oldest == nil || c.Newest == nil {
+			t.Errorf("%s has no date range", c.Name)
+		}
+		if c.Items != nil {
+			t.Errorf("%s lists items in the summary", c.Name)
+		}
+	}
+	if inv.Categories[2].DeleteWith != "cancel_scheduled_transfer" {
+		t.Errorf("schedules DeleteWith = %q", inv.Categories[2].DeleteWith)
+	}
+
+	for _, leaked := range []string{"alice", "bob", "secret"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("summary contains %q: %s", leaked, out)
+		}
+	}
+}
+
+func TestDescribeCategory(t *testing.T) {
+	r := newRegistry(t)
+
+	tests := []struct {
+		category string
+		want     []string
+	}{
+		{"conversations", []string{"alice budget chat"}},
+		{"artifacts", []string{"alice spending.svg", "alice receipt.jpg"}},
+		{"schedules", []string{"alice-landlord"}},
+		{"transfer_ledger", []string{"alice-tx"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.category, func(t *testing.T) {
+			result, out := run(t, r, "user-1", `{"category":"`+tt.category+`"}`)
+			if !result.Success {
+				t.Fatalf("describe failed: %s", result.Error)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("missing %q in %s", w, out)
+				}
+			}
+			for _, leaked := range []string{"bob", "secret message", "chart.svg\""} {
+				if strings.Contains(out, leaked) {
+					t.Errorf("contains %q: %s", leaked, out)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		result, _ := run(t, r, "user-1", `{"category":"passwords"}`)
+		if result.Success || !strings.Contains(result.Error, "conversations") {
+			t.Errorf("got %+v, want an error listing the categories", result)
+		}
+	})
+}
+
+func TestDescribeFailure(t *testing.T) {
+	r := NewRegistry()
+	r.Register("broken", func(ctx context.Context, userID string, withItems bool) (*Category, error) {
+		return nil, errors.New("store offline")
+	})
+	r.Register("empty", func(ctx context.Context, userID string, withItems bool) (*Category, error) {
+		return &Category{Description: "Nothing yet."}, nil
+	})
+
+	inv := r.Describe(context.Background(), "user-1")
+	if len(inv.Categories) != 2 {
+		t.Fatalf("got %d categories, want 2", len(inv.Categories))
+	}
+	if c := inv.Categories[0]; c.Name != "broken" || !strings.Contains(c.Error, "store offline") {
+		t.Errorf("broken = %+v, want its error", c)
+	}
+	if c := inv.Categories[1]; c.Name != "empty" || c.Error != "" || c.Count != 0 {
+		t.Errorf("empty = %+v", c)
+	}
+}
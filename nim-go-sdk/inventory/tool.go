@@ -0,0 +1,45 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// Tool returns the describe_my_data tool, which tells the user what is stored
+// about them. Without a category it summarizes every category registered
+// with r at call time; with one it also lists that category's stored values.
+func Tool(r *Registry) core.Tool {
+	return tools.New("describe_my_data").
+		Description("Describe what data is stored about the user: for each category, how many records, their date range, and how to delete them. Use when the user asks what you know or have stored about them. Pass a category only when the user asks to see the actual values in it.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"category": tools.StringProperty("Optional category to list in full, e.g. conversations or schedules"),
+		})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				Category string `json:"category"`
+			}
+			if len(params.Input) > 0 {
+				if err := json.Unmarshal(params.Input, &input); err != nil {
+					return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+				}
+			}
+
+			if input.Category == "" {
+				return &core.ToolResult{Success: true, Data: r.Describe(ctx, params.UserID)}, nil
+			}
+			c, err := r.DescribeCategory(ctx, params.UserID, input.Category)
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("%v; categories are %s", err, strings.Join(r.Names(), ", ")),
+				}, nil
+			}
+			return &core.ToolResult{Success: true, Data: c}, nil
+		}).
+		Build()
+}
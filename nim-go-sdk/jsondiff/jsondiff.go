@@ -0,0 +1,330 @@
+// Package jsondiff computes structural differences between decoded JSON
+// values, so a repeated read can be reported as what changed rather than the
+// whole payload again.
+//
+// Objects are compared field by field. Arrays whose path is declared in Keys
+// are matched element by element on an ID field, so reordering is never a
+// change; other arrays are compared as multisets of values. Values of
+// different JSON types are reported as a change from one to the other.
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Keys maps the path of an array to the field that identifies its elements,
+// e.g. {"transactions": "id", "balances": "currency"}. Paths join object
+// fields with dots and ignore array indexes, so "transactions.legs" is the
+// legs array inside every transaction. The root array's path is "".
+type Keys map[string]string
+
+// Kind is the kind of a Change.
+type Kind string
+
+// Change kinds.
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Change is a single difference between two JSON values.
+type Change struct {
+	// Path locates the value, e.g. "totalUsd", "transactions[tx_1]" for the
+	// element with ID tx_1, or "tags[]" for an element of an unkeyed array.
+	Path string `json:"path"`
+
+	// Kind is whether the value was added, removed or changed.
+	Kind Kind `json:"kind"`
+
+	// Old is the previous value. Unset for additions.
+	Old interface{} `json:"old,omitempty"`
+
+	// New is the current value. Unset for removals.
+	New interface{} `json:"new,omitempty"`
+}
+
+// Result is the difference between two JSON values.
+type Result struct {
+	// Changes lists the differences in a stable order.
+	Changes []Change `json:"changes"`
+
+	arrays []*arrayStats
+}
+
+// arrayStats counts element additions and removals in one array.
+type arrayStats struct {
+	name           string
+	added, removed int
+}
+
+// Empty reports whether the values were equal.
+func (r *Result) Empty() bool {
+	return len(r.Changes) == 0
+}
+
+// maxListedChanges is how many field changes Summary spells out.
+const maxListedChanges = 3
+
+// Summary describes the result in a short sentence for the model, e.g.
+// "2 new transactions, totalUsd changed 812.40 → 762.40".
+func (r *Result) Summary() string {
+	if r.Empty() {
+		return "no changes"
+	}
+
+	var parts []string
+	for _, a := range r.arrays {
+		if a.added > 0 {
+			parts = append(parts, fmt.Sprintf("%d new %s", a.added, a.name))
+		}
+		if a.removed > 0 {
+			parts = append(parts, fmt.Sprintf("%d removed from %s", a.removed, a.name))
+		}
+	}
+
+	listed, more := 0, 0
+	for _, c := range r.Changes {
+		if strings.HasSuffix(c.Path, "]") {
+			continue // whole elements, counted above
+		}
+		if listed == maxListedChanges {
+			more++
+			continue
+		}
+		listed++
+		path := c.Path
+		if path == "" {
+			path = "value"
+		}
+		switch c.Kind {
+		case Added:
+			parts = append(parts, fmt.Sprintf("%s added (%s)", path, format(c.New)))
+		case Removed:
+			parts = append(parts, fmt.Sprintf("%s removed", path))
+		default:
+			parts = append(parts, fmt.Sprintf("%s changed %s → %s", path, format(c.Old), format(c.New)))
+		}
+	}
+	if more > 0 {
+		parts = append(parts, fmt.Sprintf("%d more fields changed", more))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// format renders a value for Summary: strings unquoted, everything else as JSON.
+func format(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Diff returns the differences from old to new. Both must be values produced
+// by json.Unmarshal into an interface{}: maps, slices, strings, float64s,
+// bools and nil.
+func Diff(old, new interface{}, keys Keys) *Result {
+	d := &differ{keys: keys, arrays: make(map[string]*arrayStats)}
+	d.value("", "", old, new)
+	return &Result{Changes: d.changes, arrays: d.order}
+}
+
+type differ struct {
+	keys    Keys
+	changes []Change
+	arrays  map[string]*arrayStats
+	order   []*arrayStats
+}
+
+func (d *differ) add(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+// value diffs two values. path is where they appear; schema is path without
+// element selectors, as used in Keys.
+func (d *differ) value(path, schema string, old, new interface{}) {
+	switch o := old.(type) {
+	case map[string]interface{}:
+		if n, ok := new.(map[string]interface{}); ok {
+			d.object(path, schema, o, n)
+			return
+		}
+	case []interface{}:
+		if n, ok := new.([]interface{}); ok {
+			if key, ok := d.keys[schema]; ok {
+				d.keyedArray(path, schema, key, o, n)
+			} else {
+				d.unkeyedArray(path, schema, o, n)
+			}
+			return
+		}
+	default:
+		if equal(old, new) {
+			return
+		}
+	}
+	d.add(Change{Path: path, Kind: Changed, Old: old, New: new})
+}
+
+func (d *differ) object(path, schema string, old, new map[string]interface{}) {
+	names := make([]string, 0, len(old)+len(new))
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range new {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		o, inOld := old[name]
+		n, inNew := new[name]
+		p, s := join(path, name), join(schema, name)
+		switch {
+		case !inNew:
+			d.add(Change{Path: p, Kind: Removed, Old: o})
+		case !inOld:
+			d.add(Change{Path: p, Kind: Added, New: n})
+		default:
+			d.value(p, s, o, n)
+		}
+	}
+}
+
+// keyedArray matches elements by their key field. Elements without a usable
+// key, or with a key already seen, are matched by value instead.
+func (d *differ) keyedArray(path, schema, key string, old, new []interface{}) {
+	stats := d.stats(schema)
+	oldByID, oldRest := index(old, key)
+	_, newRest := index(new, key)
+
+	seen := make(map[string]bool)
+	for _, n := range new {
+		id, ok := elementID(n, key)
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		elem := fmt.Sprintf("%s[%s]", path, id)
+		o, ok := oldByID[id]
+		if !ok {
+			stats.added++
+			d.add(Change{Path: elem, Kind: Added, New: n})
+			continue
+		}
+		d.value(elem, schema, o, n)
+	}
+	for _, o := range old {
+		id, ok := elementID(o, key)
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		stats.removed++
+		d.add(Change{Path: fmt.Sprintf("%s[%s]", path, id), Kind: Removed, Old: o})
+	}
+
+	d.unkeyedArray(path, schema, oldRest, newRest)
+}
+
+// unkeyedArray compares arrays as multisets: an element is unchanged if an
+// equal element exists on the other side, wherever it is.
+func (d *differ) unkeyedArray(path, schema string, old, new []interface{}) {
+	if len(old) == 0 && len(new) == 0 {
+		return
+	}
+	stats := d.stats(schema)
+	remaining := make(map[string]int, len(old))
+	for _, o := range old {
+		remaining[canonical(o)]++
+	}
+	elem := path + "[]"
+	for _, n := range new {
+		c := canonical(n)
+		if remaining[c] > 0 {
+			remaining[c]--
+			continue
+		}
+		stats.added++
+		d.add(Change{Path: elem, Kind: Added, New: n})
+	}
+	for _, o := range old {
+		c := canonical(o)
+		if remaining[c] > 0 {
+			remaining[c]--
+			stats.removed++
+			d.add(Change{Path: elem, Kind: Removed, Old: o})
+		}
+	}
+}
+
+func (d *differ) stats(schema string) *arrayStats {
+	if s, ok := d.arrays[schema]; ok {
+		return s
+	}
+	name := schema
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		name = "items"
+	}
+	s := &arrayStats{name: name}
+	d.arrays[schema] = s
+	d.order = append(d.order, s)
+	return s
+}
+
+// index splits elements into those with a unique key, by key, and the rest.
+func index(elems []interface{}, key string) (map[string]interface{}, []interface{}) {
+	byID := make(map[string]interface{}, len(elems))
+	var rest []interface{}
+	for _, e := range elems {
+		id, ok := elementID(e, key)
+		if _, dup := byID[id]; !ok || dup {
+			rest = append(rest, e)
+			continue
+		}
+		byID[id] = e
+	}
+	return byID, rest
+}
+
+// elementID returns the element's key field as a string.
+func elementID(elem interface{}, key string) (string, bool) {
+	obj, ok := elem.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	switch v := obj[key].(type) {
+	case string:
+		return v, v != ""
+	case float64, bool:
+		return format(v), true
+	}
+	return "", false
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// canonical encodes v as JSON. Object fields are sorted, so equal values
+// always encode the same way.
+func canonical(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func equal(a, b interface{}) bool {
+	return canonical(a) == canonical(b)
+}
@@ -0,0 +1,188 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return v
+}
+
+func TestDiff(t *testing.T) {
+	keys := Keys{"transactions": "id", "balances": "currency", "": "id", "transactions.legs": "id"}
+
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []Change
+	}{
+		{
+			name: "equal",
+			old:  `{"a":1,"b":"x"}`,
+			new:  `{"b":"x","a":1}`,
+		},
+		{
+			name: "scalar changed",
+			old:  `{"totalUsd":"812.40","currency":"USD"}`,
+			new:  `{"totalUsd":"762.40","currency":"USD"}`,
+			want: []Change{{Path: "totalUsd", Kind: Changed, Old: "812.40", New: "762.40"}},
+		},
+		{
+			name: "field added and removed",
+			old:  `{"a":1}`,
+			new:  `{"b":2}`,
+			want: []Change{
+				{Path: "a", Kind: Removed, Old: 1.0},
+				{Path: "b", Kind: Added, New: 2.0},
+			},
+		},
+		{
+			name: "keyed insertion",
+			old:  `{"transactions":[{"id":"t1","amount":"5"}]}`,
+			new:  `{"transactions":[{"id":"t2","amount":"7"},{"id":"t1","amount":"5"}]}`,
+			want: []Change{{Path: "transactions[t2]", Kind: Added, New: map[string]interface{}{"id": "t2", "amount": "7"}}},
+		},
+		{
+			name: "keyed deletion",
+			old:  `{"transactions":[{"id":"t1"},{"id":"t2"}]}`,
+			new:  `{"transactions":[{"id":"t2"}]}`,
+			want: []Change{{Path: "transactions[t1]", Kind: Removed, Old: map[string]interface{}{"id": "t1"}}},
+		},
+		{
+			name: "keyed reorder is not a change",
+			old:  `{"transactions":[{"id":"t1","amount":"5"},{"id":"t2","amount":"7"},{"id":"t3","amount":"9"}]}`,
+			new:  `{"transactions":[{"id":"t3","amount":"9"},{"id":"t1","amount":"5"},{"id":"t2","amount":"7"}]}`,
+		},
+		{
+			name: "keyed element field changed",
+			old:  `{"transactions":[{"id":"t1","status":"pending"}]}`,
+			new:  `{"transactions":[{"id":"t1","status":"completed"}]}`,
+			want: []Change{{Path: "transactions[t1].status", Kind: Changed, Old: "pending", New: "completed"}},
+		},
+		{
+			name: "numeric keys",
+			old:  `[{"id":1,"v":"a"},{"id":2,"v":"b"}]`,
+			new:  `[{"id":2,"v":"c"},{"id":1,"v":"a"}]`,
+			want: []Change{{Path: "[2].v", Kind: Changed, Old: "b", New: "c"}},
+		},
+		{
+			name: "nested keyed array",
+			old:  `{"transactions":[{"id":"t1","legs":[{"id":"l1","amount":"1"},{"id":"l2","amount":"2"}]}]}`,
+			new:  `{"transactions":[{"id":"t1","legs":[{"id":"l2","amount":"3"},{"id":"l1","amount":"1"}]}]}`,
+			want: []Change{{Path: "transactions[t1].legs[l2].amount", Kind: Changed, Old: "2", New: "3"}},
+		},
+		{
+			name: "unkeyed reorder is not a change",
+			old:  `{"tags":["a","b","c"]}`,
+			new:  `{"tags":["c","a","b"]}`,
+		},
+		{
+			name: "unkeyed insertion and deletion",
+			old:  `{"tags":["a","b","b"]}`,
+			new:  `{"tags":["b","c","a"]}`,
+			want: []Change{
+				{Path: "tags[]", Kind: Added, New: "c"},
+				{Path: "tags[]", Kind: Removed, Old: "b"},
+			},
+		},
+		{
+			name: "elements without keys fall back to values",
+			old:  `{"transactions":[{"id":"t1"},{"note":"x"}]}`,
+			new:  `{"transactions":[{"note":"x"},{"id":"t1"},{"note":"y"}]}`,
+			want: []Change{{Path: "transactions[]", Kind: Added, New: map[string]interface{}{"note": "y"}}},
+		},
+		{
+			name: "scalar type change",
+			old:  `{"amount":"5"}`,
+			new:  `{"amount":5}`,
+			want: []Change{{Path: "amount", Kind: Changed, Old: "5", New: 5.0}},
+		},
+		{
+			name: "object to array",
+			old:  `{"balances":{"USD":"1"}}`,
+			new:  `{"balances":[{"currency":"USD"}]}`,
+			want: []Change{{
+				Path: "balances",
+				Kind: Changed,
+				Old:  map[string]interface{}{"USD": "1"},
+				New:  []interface{}{map[string]interface{}{"currency": "USD"}},
+			}},
+		},
+		{
+			name: "null to value",
+			old:  `{"note":null}`,
+			new:  `{"note":"rent"}`,
+			want: []Change{{Path: "note", Kind: Changed, Old: nil, New: "rent"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(decode(t, tt.old), decode(t, tt.new), keys)
+			if len(got.Changes) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got.Changes, tt.want) {
+				t.Errorf("Diff() =\n%+v\nwant\n%+v", got.Changes, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	keys := Keys{"transactions": "id", "balances": "currency"}
+
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "unchanged",
+			old:  `{"totalUsd":"1"}`,
+			new:  `{"totalUsd":"1"}`,
+			want: "no changes",
+		},
+		{
+			name: "new transactions and balance",
+			old:  `{"transactions":[{"id":"t1"}],"balances":[{"currency":"USD","amount":"812.40"}]}`,
+			new:  `{"transactions":[{"id":"t3"},{"id":"t2"},{"id":"t1"}],"balances":[{"currency":"USD","amount":"762.40"}]}`,
+			want: "2 new transactions, balances[USD].amount changed 812.40 → 762.40",
+		},
+		{
+			name: "removed",
+			old:  `{"transactions":[{"id":"t1"},{"id":"t2"}]}`,
+			new:  `{"transactions":[{"id":"t2"}]}`,
+			want: "1 removed from transactions",
+		},
+		{
+			name: "many fields",
+			old:  `{"a":1,"b":1,"c":1,"d":1,"e":1}`,
+			new:  `{"a":2,"b":2,"c":2,"d":2,"e":2}`,
+			want: "a changed 1 → 2, b changed 1 → 2, c changed 1 → 2, 2 more fields changed",
+		},
+		{
+			name: "root type change",
+			old:  `[]`,
+			new:  `{}`,
+			want: "value changed [] → {}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(decode(t, tt.old), decode(t, tt.new), keys).Summary(); got != tt.want {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package limits
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Entry is a transfer counted against a user's allowance.
+type Entry struct {
+	// ID identifies the transfer, typically the pending action ID.
+	ID       string
+	UserID   string
+	Amount   string
+	Currency string
+
+	// At is when the transfer executed, or when the reservation was made.
+	At time.Time
+
+	// Reserved marks a confirmation that has not executed yet.
+	Reserved bool
+
+	// ExpiresAt is when a reservation lapses. Ignored for executed transfers.
+	ExpiresAt time.Time
+}
+
+// Ledger records executed and reserved transfers.
+// Both enforcement and the allowance tool read usage from the same Ledger,
+// so the two can never disagree.
+// The SDK provides MemoryLedger for development; production deployments
+// should implement this with a shared store.
+type Ledger interface {
+	// Record stores an executed transfer, replacing any reservation with the same ID.
+	Record(ctx context.Context, entry Entry) error
+
+	// Reserve stores a reservation for a pending transfer.
+	Reserve(ctx context.Context, entry Entry) error
+
+	// Release removes a reservation. Executed transfers are not affected.
+	Release(ctx context.Context, userID, id string) error
+
+	// Entries returns the user's transfers executed at or after since,
+	// plus all unexpired reservations.
+	Entries(ctx context.Context, userID string, since time.Time) ([]Entry, error)
+}
+
+// MemoryLedger is an in-memory implementation of Ledger.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryLedger struct {
+	mu      sync.RWMutex
+	entries map[string]map[string]Entry // userID -> id -> entry
+	now     func() time.Time
+}
+
+// NewMemoryLedger creates an in-memory ledger.
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{
+		entries: make(map[string]map[string]Entry),
+		now:     time.Now,
+	}
+}
+
+func (m *MemoryLedger) Record(ctx context.Context, entry Entry) error {
+	entry.Reserved = false
+	m.put(entry)
+	return nil
+}
+
+func (m *MemoryLedger) Reserve(ctx context.Context, entry Entry) error {
+	entry.Reserved = true
+	m.put(entry)
+	return nil
+}
+
+func (m *MemoryLedger) put(entry Entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.entries[entry.UserID]
+	if !ok {
+		user = make(map[string]Entry)
+		m.entries[entry.UserID] = user
+	}
+	if existing, ok := user[entry.ID]; ok && !existing.Reserved && entry.Reserved {
+		// Never downgrade an executed transfer to a reservation.
+		return
+	}
+	user[entry.ID] = entry
+}
+
+func (m *MemoryLedger) Release(ctx context.Context, userID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[userID][id]; ok && entry.Reserved {
+		delete(m.entries[userID], id)
+	}
+	return nil
+}
+
+func (m *MemoryLedger) Entries(ctx context.Context, userID string, since time.Time) ([]Entry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.now()
+	var result []Entry
+	for _, entry := range m.entries[userID] {
+		if entry.Reserved {
+			if entry.ExpiresAt.IsZero() || entry.ExpiresAt.After(now) {
+				result = append(result, entry)
+			}
+			continue
+		}
+		if !entry.At.Before(since) {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+// Verify MemoryLedger implements Ledger.
+var _ Ledger = (*MemoryLedger)(nil)
@@ -0,0 +1,349 @@
+// Package limits enforces per-user daily transfer limits and reports the
+// remaining allowance. Enforcement and the get_transfer_allowance tool share a
+// single calculation (Limits.Allowance), so what the user is told they can send
+// is exactly what a subsequent confirmation will allow.
+package limits
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Config configures transfer limits.
+type Config struct {
+	// DailyLimit caps the total sent per day across all currencies.
+	// Empty means no combined limit.
+	DailyLimit string
+
+	// CurrencyLimits caps the amount sent per day in each currency,
+	// e.g. {"USDC": "500", "EURC": "450"}. Currencies not listed are only
+	// bound by DailyLimit.
+	CurrencyLimits map[string]string
+
+	// SingleTransferMax caps any individual transfer. Empty means no cap.
+	SingleTransferMax string
+
+	// CountReserved counts pending, not yet executed confirmations against the
+	// allowance, so a user cannot queue several confirmations that together
+	// exceed it.
+	CountReserved bool
+
+	// Tools lists the write tools that move money out of the user's account.
+	// Defaults to ["send_money"].
+	Tools []string
+
+	// Ledger tracks executed and reserved transfers. Defaults to a MemoryLedger.
+	Ledger Ledger
+
+	// Location returns the user's timezone, which determines when the daily
+	// allowance resets. Defaults to UTC.
+	Location func(ctx context.Context, userID string) *time.Location
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Limits enforces transfer limits for all users.
+type Limits struct {
+	cfg Config
+}
+
+// New creates transfer limits with the given configuration.
+func New(cfg Config) *Limits {
+	if len(cfg.Tools) == 0 {
+		cfg.Tools = []string{"send_money"}
+	}
+	if cfg.Ledger == nil {
+		cfg.Ledger = NewMemoryLedger()
+	}
+	if cfg.Location == nil {
+		cfg.Location = func(context.Context, string) *time.Location { return time.UTC }
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Limits{cfg: cfg}
+}
+
+// Allowance is a user's transfer allowance for the current day.
+// Amounts are decimal strings; limit fields are empty when not configured.
+type Allowance struct {
+	DailyLimit        string                        `json:"daily_limit,omitempty"`
+	Used              string                        `json:"used_today"`
+	Reserved          string                        `json:"reserved,omitempty"`
+	Remaining         string                        `json:"remaining,omitempty"`
+	SingleTransferMax string                        `json:"single_transfer_max,omitempty"`
+	ResetsAt          time.Time                     `json:"resets_at"`
+	Currencies        map[string]*CurrencyAllowance `json:"currencies,omitempty"`
+}
+
+// CurrencyAllowance is the allowance for a single currency.
+type CurrencyAllowance struct {
+	Limit     string `json:"limit,omitempty"`
+	Used      string `json:"used_today"`
+	Reserved  string `json:"reserved,omitempty"`
+	Remaining string `json:"remaining,omitempty"`
+}
+
+// Allowance calculates the user's allowance for the current day.
+// This is the single calculation used by both enforcement and the allowance tool.
+func (l *Limits) Allowance(ctx context.Context, userID string) (*Allowance, error) {
+	return l.allowance(ctx, userID, "")
+}
+
+// allowance calculates the allowance, ignoring the entry with ID exclude so a
+// pending action's own reservation is not counted against it.
+func (l *Limits) allowance(ctx context.Context, userID, exclude string) (*Allowance, error) {
+	loc := l.cfg.Location(ctx, userID)
+	if loc == nil {
+		loc = time.UTC
+	}
+	now := l.cfg.Now().In(loc)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	entries, err := l.cfg.Ledger.Entries(ctx, userID, dayStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transfer usage: %w", err)
+	}
+
+	used, reserved := new(big.Rat), new(big.Rat)
+	currencyUsed := make(map[string]*big.Rat)
+	currencyReserved := make(map[string]*big.Rat)
+	for _, e := range entries {
+		if e.ID == exclude {
+			continue
+		}
+		amount, ok := new(big.Rat).SetString(e.Amount)
+		if !ok {
+			continue
+		}
+		if e.Reserved {
+			if !l.cfg.CountReserved {
+				continue
+			}
+			reserved.Add(reserved, amount)
+			addTo(currencyReserved, e.Currency, amount)
+		} else {
+			used.Add(used, amount)
+			addTo(currencyUsed, e.Currency, amount)
+		}
+	}
+
+	a := &Allowance{
+		DailyLimit:        l.cfg.DailyLimit,
+		Used:              used.FloatString(2),
+		SingleTransferMax: l.cfg.SingleTransferMax,
+		ResetsAt:          dayStart.AddDate(0, 0, 1),
+	}
+	if l.cfg.CountReserved {
+		a.Reserved = reserved.FloatString(2)
+	}
+	if limit, ok := parseAmount(l.cfg.DailyLimit); ok {
+		a.Remaining = remaining(limit, used, reserved)
+	}
+
+	if len(l.cfg.CurrencyLimits) > 0 {
+		a.Currencies = make(map[string]*CurrencyAllowance)
+		for currency, limitStr := range l.cfg.CurrencyLimits {
+			cu, cr := valueOf(currencyUsed, currency), valueOf(currencyReserved, currency)
+			c := &CurrencyAllowance{Limit: limitStr, Used: cu.FloatString(2)}
+			if l.cfg.CountReserved {
+				c.Reserved = cr.FloatString(2)
+			}
+			if limit, ok := parseAmount(limitStr); ok {
+				c.Remaining = remaining(limit, cu, cr)
+			}
+			a.Currencies[currency] = c
+		}
+	}
+	return a, nil
+}
+
+// Check returns an error if a transfer of amount in currency is not allowed by a.
+func (a *Allowance) Check(amount, currency string) error {
+	value, ok := parseAmount(amount)
+	if !ok {
+		return fmt.Errorf("invalid amount: %q", amount)
+	}
+
+	if max, ok := parseAmount(a.SingleTransferMax); ok && value.Cmp(max) > 0 {
+		return fmt.Errorf("%s %s exceeds the single transfer maximum of %s", amount, currency, a.SingleTransferMax)
+	}
+	if rem, ok := parseAmount(a.Remaining); ok && value.Cmp(rem) > 0 {
+		return fmt.Errorf("%s %s exceeds your remaining daily allowance of %s", amount, currency, a.Remaining)
+	}
+	if c, ok := a.Currencies[currency]; ok {
+		if rem, ok := parseAmount(c.Remaining); ok && value.Cmp(rem) > 0 {
+			return fmt.Errorf("%s %s exceeds your remaining daily %s allowance of %s", amount, currency, currency, c.Remaining)
+		}
+	}
+	return nil
+}
+
+// CheckTransfer returns an error if the user may not send amount in currency now.
+func (l *Limits) CheckTransfer(ctx context.Context, userID, amount, currency string) error {
+	a, err := l.Allowance(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return a.Check(amount, currency)
+}
+
+// RecordTransfer records an executed transfer against the user's allowance.
+func (l *Limits) RecordTransfer(ctx context.Context, userID, id, amount, currency string) error {
+	return l.cfg.Ledger.Record(ctx, Entry{
+		ID:       id,
+		UserID:   userID,
+		Amount:   amount,
+		Currency: currency,
+		At:       l.cfg.Now(),
+	})
+}
+
+// Authorize checks a pending action against the user's limits and, when
+// CountReserved is set, reserves its amount until it executes or is released.
+// Actions for tools that don't move money out are always allowed; a transfer
+// whose amount can't be read is refused rather than waved through.
+// Calling Authorize again for the same action does not count it twice.
+func (l *Limits) Authorize(ctx context.Context, action *core.PendingAction) error {
+	amount, currency, ok, err := l.transfer(action)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	a, err := l.allowance(ctx, action.UserID, action.ID)
+	if err != nil {
+		return err
+	}
+	if err := a.Check(amount, currency); err != nil {
+		return err
+	}
+
+	if !l.cfg.CountReserved {
+		return nil
+	}
+	return l.cfg.Ledger.Reserve(ctx, Entry{
+		ID:        action.ID,
+		UserID:    action.UserID,
+		Amount:    amount,
+		Currency:  currency,
+		At:        l.cfg.Now(),
+		ExpiresAt: time.Unix(action.ExpiresAt, 0),
+	})
+}
+
+// Commit records that a confirmed action executed, converting any reservation to usage.
+func (l *Limits) Commit(ctx context.Context, action *core.PendingAction) error {
+	amount, currency, ok, err := l.transfer(action)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	return l.RecordTransfer(ctx, action.UserID, action.ID, amount, currency)
+}
+
+// Release drops the reservation for an action that was cancelled or failed.
+func (l *Limits) Release(ctx context.Context, action *core.PendingAction) error {
+	return l.cfg.Ledger.Release(ctx, action.UserID, action.ID)
+}
+
+// transfer extracts the amount and currency from an outgoing transfer action.
+// ok is false for tools that don't move money out. An error means the action
+// is a transfer but its amount can't be read, so it can't be checked.
+func (l *Limits) transfer(action *core.PendingAction) (amount, currency string, ok bool, err error) {
+	if !l.isTransferTool(action.Tool) {
+		return "", "", false, nil
+	}
+	var input struct {
+		Amount   json.Number `json:"amount"`
+		Currency string      `json:"currency"`
+	}
+	if err := json.Unmarshal(action.Input, &input); err != nil || input.Amount == "" {
+		return "", "", false, fmt.Errorf("the transfer amount couldn't be read, so it can't be checked against your limits")
+	}
+	if _, valid := parseAmount(input.Amount.String()); !valid {
+		return "", "", false, fmt.Errorf("invalid amount: %q", input.Amount.String())
+	}
+	return input.Amount.String(), input.Currency, true, nil
+}
+
+func (l *Limits) isTransferTool(tool string) bool {
+	for _, t := range l.cfg.Tools {
+		if t == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAmount parses a decimal string. Returns false for empty or invalid input.
+func parseAmount(s string) (*big.Rat, bool) {
+	if s == "" {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// remaining returns limit - used - reserved, floored at zero.
+func remaining(limit, used, reserved *big.Rat) string {
+	r := new(big.Rat).Sub(limit, used)
+	r.Sub(r, reserved)
+	if r.Sign() < 0 {
+		r.SetInt64(0)
+	}
+	return r.FloatString(2)
+}
+
+func addTo(m map[string]*big.Rat, key string, amount *big.Rat) {
+	if _, ok := m[key]; !ok {
+		m[key] = new(big.Rat)
+	}
+	m[key].Add(m[key], amount)
+}
+
+func valueOf(m map[string]*big.Rat, key string) *big.Rat {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	return new(big.Rat)
+}
+
+// UserLimitsFunc adapts a lookup of core.UserLimits, such as limits reported by
+// the Liminal API, into a transfer check. It uses the same Allowance.Check as
+// Limits. Empty limit fields are treated as unlimited.
+type UserLimitsFunc func(ctx context.Context, userID string) (*core.UserLimits, error)
+
+// CheckTransfer returns an error if the user may not send amount in currency.
+func (f UserLimitsFunc) CheckTransfer(ctx context.Context, userID, amount, currency string) error {
+	ul, err := f(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load limits: %w", err)
+	}
+	if ul == nil {
+		return nil
+	}
+
+	a := &Allowance{
+		DailyLimit:        ul.DailyTransferLimit,
+		Used:              ul.DailyTransferUsed,
+		SingleTransferMax: ul.SingleTransferMax,
+	}
+	if limit, ok := parseAmount(ul.DailyTransferLimit); ok {
+		used, ok := parseAmount(ul.DailyTransferUsed)
+		if !ok {
+			used = new(big.Rat)
+		}
+		a.Remaining = remaining(limit, used, new(big.Rat))
+	}
+	return a.Check(amount, currency)
+}
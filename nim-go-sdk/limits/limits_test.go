@@ -0,0 +1,218 @@
+package limits
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func sendAction(id, amount, currency string) *core.PendingAction {
+	input, _ := json.Marshal(map[string]string{"recipient": "@alice", "amount": amount, "currency": currency})
+	return &core.PendingAction{
+		ID:        id,
+		UserID:    "user-1",
+		Tool:      "send_money",
+		Input:     input,
+		ExpiresAt: time.Now().Add(10 * time.Minute).Unix(),
+	}
+}
+
+// toolAllowance calls get_transfer_allowance and decodes its result.
+func toolAllowance(t *testing.T, l *Limits) *Allowance {
+	t.Helper()
+	result, err := AllowanceTool(l).Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: []byte("{}")})
+	if err != nil || !result.Success {
+		t.Fatalf("get_transfer_allowance failed: %v %+v", err, result)
+	}
+	return result.Data.(*Allowance)
+}
+
+func TestToolAndEnforcementAgree(t *testing.T) {
+	ctx := context.Background()
+	l := New(Config{DailyLimit: "100", SingleTransferMax: "80"})
+
+	if err := l.RecordTransfer(ctx, "user-1", "tx-1", "30", "USDC"); err != nil {
+		t.Fatal(err)
+	}
+
+	a := toolAllowance(t, l)
+	if a.Used != "30.00" || a.Remaining != "70.00" {
+		t.Fatalf("allowance used=%s remaining=%s, want 30.00 and 70.00", a.Used, a.Remaining)
+	}
+
+	tests := []struct {
+		amount  string
+		wantErr bool
+	}{
+		{"70", false},
+		{"70.00", false},
+		{"70.01", true},
+		{"81", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			err := l.Authorize(ctx, sendAction("a-"+tt.amount, tt.amount, "USDC"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Authorize(%s) error = %v, wantErr %v", tt.amount, err, tt.wantErr)
+			}
+			// The tool's figures predict the outcome.
+			if got := a.Check(tt.amount, "USDC") != nil; got != tt.wantErr {
+				t.Errorf("Allowance.Check(%s) rejected = %v, want %v", tt.amount, got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReservationToggle(t *testing.T) {
+	tests := []struct {
+		name          string
+		countReserved bool
+		wantRemaining string
+		wantSecondErr bool
+	}{
+		{name: "reservations counted", countReserved: true, wantRemaining: "40.00", wantSecondErr: true},
+		{name: "reservations ignored", countReserved: false, wantRemaining: "100.00", wantSecondErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			l := New(Config{DailyLimit: "100", CountReserved: tt.countReserved})
+
+			first := sendAction("a-1", "60", "USDC")
+			if err := l.Authorize(ctx, first); err != nil {
+				t.Fatalf("first Authorize() error = %v", err)
+			}
+			if got := toolAllowance(t, l).Remaining; got != tt.wantRemaining {
+				t.Errorf("remaining = %s, want %s", got, tt.wantRemaining)
+			}
+
+			err := l.Authorize(ctx, sendAction("a-2", "50", "USDC"))
+			if (err != nil) != tt.wantSecondErr {
+				t.Errorf("second Authorize() error = %v, wantErr %v", err, tt.wantSecondErr)
+			}
+
+			// Re-authorizing the same action does not count its own reservation.
+			if err := l.Authorize(ctx, first); err != nil {
+				t.Errorf("re-Authorize() error = %v", err)
+			}
+
+			// Releasing frees the allowance; committing turns it into usage.
+			if err := l.Release(ctx, first); err != nil {
+				t.Fatal(err)
+			}
+			if got := toolAllowance(t, l).Remaining; got != "100.00" {
+				t.Errorf("after release remaining = %s, want 100.00", got)
+			}
+			if err := l.Commit(ctx, first); err != nil {
+				t.Fatal(err)
+			}
+			if got := toolAllowance(t, l); got.Used != "60.00" || got.Remaining != "40.00" {
+				t.Errorf("after commit used=%s remaining=%s, want 60.00 and 40.00", got.Used, got.Remaining)
+			}
+		})
+	}
+}
+
+func TestResetTimeAndRollover(t *testing.T) {
+	ctx := context.Background()
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	// 14:30 UTC on March 1 is 23:30 in Tokyo.
+	now := time.Date(2026, time.March, 1, 14, 30, 0, 0, time.UTC)
+	ledger := NewMemoryLedger()
+	l := New(Config{
+		DailyLimit: "100",
+		Ledger:     ledger,
+		Location:   func(context.Context, string) *time.Location { return tokyo },
+		Now:        func() time.Time { return now },
+	})
+
+	if err := l.RecordTransfer(ctx, "user-1", "tx-1", "90", "USDC"); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := l.Allowance(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantReset := time.Date(2026, time.March, 2, 0, 0, 0, 0, tokyo)
+	if !a.ResetsAt.Equal(wantReset) {
+		t.Errorf("ResetsAt = %s, want %s", a.ResetsAt, wantReset)
+	}
+	if a.ResetsAt.Location() != tokyo {
+		t.Errorf("ResetsAt location = %s, want Asia/Tokyo", a.ResetsAt.Location())
+	}
+	if err := l.CheckTransfer(ctx, "user-1", "20", "USDC"); err == nil {
+		t.Error("CheckTransfer(20) before reset should fail")
+	}
+
+	// One second before midnight Tokyo time the day has not rolled over.
+	now = wantReset.Add(-time.Second)
+	if a, _ := l.Allowance(ctx, "user-1"); a.Remaining != "10.00" {
+		t.Errorf("before rollover remaining = %s, want 10.00", a.Remaining)
+	}
+
+	// At midnight Tokyo time (still March 1 in UTC) the allowance resets.
+	now = wantReset
+	if a, _ := l.Allowance(ctx, "user-1"); a.Remaining != "100.00" || a.Used != "0.00" {
+		t.Errorf("after rollover used=%s remaining=%s, want 0.00 and 100.00", a.Used, a.Remaining)
+	}
+}
+
+func TestCurrencyLimits(t *testing.T) {
+	ctx := context.Background()
+	l := New(Config{CurrencyLimits: map[string]string{"USDC": "100", "EURC": "50"}})
+
+	if err := l.RecordTransfer(ctx, "user-1", "tx-1", "40", "EURC"); err != nil {
+		t.Fatal(err)
+	}
+
+	a := toolAllowance(t, l)
+	if got := a.Currencies["EURC"].Remaining; got != "10.00" {
+		t.Errorf("EURC remaining = %s, want 10.00", got)
+	}
+	if got := a.Currencies["USDC"].Remaining; got != "100.00" {
+		t.Errorf("USDC remaining = %s, want 100.00", got)
+	}
+
+	if err := l.CheckTransfer(ctx, "user-1", "20", "EURC"); err == nil {
+		t.Error("CheckTransfer(20 EURC) should exceed the EURC limit")
+	}
+	if err := l.CheckTransfer(ctx, "user-1", "20", "USDC"); err != nil {
+		t.Errorf("CheckTransfer(20 USDC) error = %v", err)
+	}
+}
+
+func TestAuthorizeRejectsUnreadableAmount(t *testing.T) {
+	ctx := context.Background()
+	l := New(Config{DailyLimit: "100", SingleTransferMax: "80"})
+
+	for _, amount := range []string{"1,000", "$1000", "1000 USDC", ""} {
+		t.Run(amount, func(t *testing.T) {
+			if err := l.Authorize(ctx, sendAction("a-"+amount, amount, "USDC")); err == nil {
+				t.Errorf("Authorize(%q) should fail", amount)
+			}
+		})
+	}
+
+	// Input that isn't an object at all is refused too.
+	action := sendAction("a-raw", "10", "USDC")
+	action.Input = []byte(`"send 1000"`)
+	if err := l.Authorize(ctx, action); err == nil {
+		t.Error("Authorize(non-object input) should fail")
+	}
+
+	// Tools that don't move money out are unaffected.
+	action = sendAction("a-other", "1,000", "USDC")
+	action.Tool = "get_balance"
+	if err := l.Authorize(ctx, action); err != nil {
+		t.Errorf("Authorize(get_balance) error = %v", err)
+	}
+}
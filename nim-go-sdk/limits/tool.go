@@ -0,0 +1,25 @@
+package limits
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// AllowanceTool returns the get_transfer_allowance tool, which reports how much
+// the user can still send today. It reads the same calculation that enforcement uses.
+func AllowanceTool(l *Limits) core.Tool {
+	return tools.New("get_transfer_allowance").
+		Description("Get how much the user can still send today: the daily limit, amount used, remaining allowance, single-transfer maximum, and when the allowance resets.").
+		Schema(tools.ObjectSchema(map[string]interface{}{})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			a, err := l.Allowance(ctx, params.UserID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to calculate allowance: %v", err)}, nil
+			}
+			return &core.ToolResult{Success: true, Data: a}, nil
+		}).
+		Build()
+}
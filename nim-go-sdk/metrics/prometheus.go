@@ -0,0 +1,243 @@
+// Package metrics exposes the engine's operational measurements (tool
+// calls, Claude API calls, runs, token spend and confirmations) in the
+// Prometheus text format.
+//
+//	m := metrics.NewPrometheus()
+//	srv, _ := server.New(server.Config{Metrics: m}) // serves GET /metrics
+//
+// Labels are tool names, model names and outcomes, which are bounded by the
+// registry and configuration. User and conversation IDs are never labels:
+// per-user series would grow without bound.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// Namespace prefixes every metric name.
+const Namespace = "nim"
+
+// Histogram buckets, in seconds or turns.
+var (
+	// ToolBuckets are the buckets of tool call durations.
+	ToolBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+	// ModelBuckets are the buckets of Claude API call durations.
+	ModelBuckets = []float64{0.25, 0.5, 1, 2, 4, 8, 15, 30, 60}
+
+	// TurnBuckets are the buckets of model turns per run.
+	TurnBuckets = []float64{1, 2, 3, 5, 8, 13, 20}
+)
+
+// Prometheus is an engine.Metrics that keeps counters and histograms in
+// memory and serves them for scraping as an http.Handler.
+type Prometheus struct {
+	mu       sync.Mutex
+	families []*family
+
+	toolCalls     *family
+	toolDuration  *family
+	modelCalls    *family
+	modelDuration *family
+	runs          *family
+	runTurns      *family
+	tokens        *family
+	confirmations *family
+}
+
+// Verify Prometheus implements engine.Metrics.
+var _ engine.Metrics = (*Prometheus)(nil)
+
+// NewPrometheus returns a Prometheus with no observations.
+func NewPrometheus() *Prometheus {
+	p := &Prometheus{}
+	p.toolCalls = p.add("tool_executions_total", "Tool calls, by tool and outcome.", nil, "tool", "outcome")
+	p.toolDuration = p.add("tool_execution_duration_seconds", "Tool call durations, by tool.", ToolBuckets, "tool")
+	p.modelCalls = p.add("model_calls_total", "Claude API calls, by model and outcome.", nil, "model", "outcome")
+	p.modelDuration = p.add("model_call_duration_seconds", "Claude API call durations, by model.", ModelBuckets, "model")
+	p.runs = p.add("runs_total", "Agent runs, by outcome.", nil, "outcome")
+	p.runTurns = p.add("run_turns", "Model turns per agent run.", TurnBuckets)
+	p.tokens = p.add("tokens_total", "Claude tokens used by agent runs, by type.", nil, "type")
+	p.confirmations = p.add("confirmations_total", "Write action confirmations, by tool and outcome.", nil, "tool", "outcome")
+	return p
+}
+
+// ObserveToolExecution implements engine.Metrics.
+func (p *Prometheus) ObserveToolExecution(tool string, duration time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.toolCalls.add(1, tool, outcome(err))
+	p.toolDuration.observe(duration.Seconds(), tool)
+}
+
+// ObserveModelCall implements engine.Metrics.
+func (p *Prometheus) ObserveModelCall(model string, duration time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.modelCalls.add(1, model, outcome(err))
+	p.modelDuration.observe(duration.Seconds(), model)
+}
+
+// ObserveRun implements engine.Metrics.
+func (p *Prometheus) ObserveRun(tokens core.TokenUsage, turns int, outcome engine.RunOutcome) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.runs.add(1, string(outcome))
+	p.runTurns.observe(float64(turns))
+	p.tokens.add(float64(tokens.InputTokens), "input")
+	p.tokens.add(float64(tokens.OutputTokens), "output")
+	p.tokens.add(float64(tokens.CacheCreationInputTokens), "cache_creation")
+	p.tokens.add(float64(tokens.CacheReadInputTokens), "cache_read")
+}
+
+// ObserveConfirmation implements engine.Metrics.
+func (p *Prometheus) ObserveConfirmation(tool string, outcome engine.ConfirmationOutcome) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.confirmations.add(1, tool, string(outcome))
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format.
+func (p *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	p.mu.Lock()
+	for _, f := range p.families {
+		f.write(&b)
+	}
+	p.mu.Unlock()
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+func (p *Prometheus) add(name, help string, buckets []float64, labels ...string) *family {
+	f := &family{
+		name:    Namespace + "_" + name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	p.families = append(p.families, f)
+	return f
+}
+
+// family is a metric and its series, one per combination of label values.
+// It is a histogram if it has buckets, and a counter otherwise. Callers
+// hold the Prometheus's mu.
+type family struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	values []string
+	sum    float64  // a counter's value
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+}
+
+func (f *family) get(values []string) *series {
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: values, counts: make([]uint64, len(f.buckets))}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) add(v float64, values ...string) {
+	f.get(values).sum += v
+}
+
+func (f *family) observe(v float64, values ...string) {
+	s := f.get(values)
+	s.sum += v
+	s.count++
+	if i := sort.SearchFloat64s(f.buckets, v); i < len(f.buckets) {
+		s.counts[i]++
+	}
+}
+
+func (f *family) write(b *strings.Builder) {
+	kind := "counter"
+	if f.buckets != nil {
+		kind = "histogram"
+	}
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, kind)
+
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := f.series[k]
+		if f.buckets == nil {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, f.labelSet(s.values, ""), formatValue(s.sum))
+			continue
+		}
+		var cumulative uint64
+		for i, le := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelSet(s.values, formatValue(le)), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelSet(s.values, "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, f.labelSet(s.values, ""), formatValue(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, f.labelSet(s.values, ""), s.count)
+	}
+}
+
+// labelSet formats label values as {name="value",...}, with an le label if
+// le is set.
+func (f *family) labelSet(values []string, le string) string {
+	pairs := make([]string, 0, len(values)+1)
+	for i, v := range values {
+		pairs = append(pairs, f.labels[i]+"="+quoteLabel(v))
+	}
+	if le != "" {
+		pairs = append(pairs, "le="+quoteLabel(le))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelEscaper escapes label values for the text format, which escapes
+// only backslashes, quotes and newlines.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+func TestPrometheus(t *testing.T) {
+	p := NewPrometheus()
+	p.ObserveToolExecution("get_balance", 20*time.Millisecond, nil)
+	p.ObserveToolExecution("get_balance", 3*time.Second, nil)
+	p.ObserveToolExecution("get_balance", 40*time.Millisecond, errors.New("timeout"))
+	p.ObserveModelCall("claude-test", 1500*time.Millisecond, nil)
+	p.ObserveRun(core.TokenUsage{InputTokens: 100, OutputTokens: 20}, 2, engine.RunComplete)
+	p.ObserveRun(core.TokenUsage{InputTokens: 50, CacheReadInputTokens: 30}, 1, engine.RunError)
+	p.ObserveConfirmation("send_money", engine.ConfirmationRequested)
+	p.ObserveConfirmation(`odd "tool"\`+"\n", engine.ConfirmationExpired)
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE nim_tool_executions_total counter",
+		`nim_tool_executions_total{tool="get_balance",outcome="success"} 2`,
+		`nim_tool_executions_total{tool="get_balance",outcome="error"} 1`,
+		"# TYPE nim_tool_execution_duration_seconds histogram",
+		`nim_tool_execution_duration_seconds_bucket{tool="get_balance",le="0.025"} 1`,
+		`nim_tool_execution_duration_seconds_bucket{tool="get_balance",le="0.05"} 2`,
+		`nim_tool_execution_duration_seconds_bucket{tool="get_balance",le="2.5"} 2`,
+		`nim_tool_execution_duration_seconds_bucket{tool="get_balance",le="5"} 3`,
+		`nim_tool_execution_duration_seconds_bucket{tool="get_balance",le="+Inf"} 3`,
+		`nim_tool_execution_duration_seconds_sum{tool="get_balance"} 3.06`,
+		`nim_tool_execution_duration_seconds_count{tool="get_balance"} 3`,
+		`nim_model_calls_total{model="claude-test",outcome="success"} 1`,
+		`nim_model_call_duration_seconds_bucket{model="claude-test",le="2"} 1`,
+		`nim_runs_total{outcome="complete"} 1`,
+		`nim_runs_total{outcome="error"} 1`,
+		`nim_run_turns_bucket{le="1"} 1`,
+		`nim_run_turns_bucket{le="2"} 2`,
+		"nim_run_turns_count 2",
+		`nim_tokens_total{type="input"} 150`,
+		`nim_tokens_total{type="output"} 20`,
+		`nim_tokens_total{type="cache_read"} 30`,
+		`nim_confirmations_total{tool="send_money",outcome="requested"} 1`,
+		`nim_confirmations_total{tool="odd \"tool\"\\\n",outcome="expired"} 1`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("missing %s in\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+// Package money represents amounts of money exactly, as whole minor units
+// (e.g. cents) of a currency, so totals don't drift the way float64 sums
+// do. ParseAmount reads the decimal strings Liminal returns, rejecting
+// anything that isn't a number rather than treating it as zero.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidAmount is returned when a string isn't a decimal amount.
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// ErrOutOfRange is returned when an amount doesn't fit in int64 minor units.
+var ErrOutOfRange = errors.New("amount out of range")
+
+// CurrencyMismatchError is returned when amounts in different currencies
+// are added, subtracted or compared.
+type CurrencyMismatchError struct {
+	A, B string
+}
+
+func (e *CurrencyMismatchError) Error() string {
+	return fmt.Sprintf("currency mismatch: %s and %s", e.A, e.B)
+}
+
+// minorDigits are the currencies whose minor unit isn't a hundredth.
+var minorDigits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+}
+
+// symbols are the currencies formatted with a symbol rather than a code.
+var symbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+}
+
+// Digits returns how many decimal digits currency's minor unit has: 2 for
+// most currencies, including stablecoins and an empty currency.
+func Digits(currency string) int {
+	if d, ok := minorDigits[strings.ToUpper(currency)]; ok {
+		return d
+	}
+	return 2
+}
+
+// Amount is a sum of money in whole minor units of Currency. An empty
+// Currency is unspecified, and takes on the currency of any amount it's
+// combined with; the zero Amount is zero in any currency.
+type Amount struct {
+	Minor    int64  `json:"minor"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// amountPattern matches a decimal amount with an optional sign and
+// exponent. Exponents are short, so parsing can't be made to allocate
+// huge numbers.
+var amountPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d{1,3})?$`)
+
+// ParseAmount parses a decimal amount of currency, such as "-12.50".
+// Digits beyond the currency's minor unit are rounded half away from zero.
+// Empty or malformed strings such as "12abc" return ErrInvalidAmount, and
+// amounts too large for int64 minor units return ErrOutOfRange.
+func ParseAmount(s, currency string) (Amount, error) {
+	text := strings.TrimSpace(s)
+	if !amountPattern.MatchString(text) {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(scale(currency)))
+
+	// Round half away from zero: truncate |r| + 1/2.
+	neg := r.Sign() < 0
+	r.Abs(r)
+	r.Add(r, big.NewRat(1, 2))
+	minor := new(big.Int).Quo(r.Num(), r.Denom())
+	if neg {
+		minor.Neg(minor)
+	}
+	if !minor.IsInt64() {
+		return Amount{}, fmt.Errorf("%w: %q", ErrOutOfRange, s)
+	}
+	return Amount{Minor: minor.Int64(), Currency: currency}, nil
+}
+
+// FromUSDValue parses a transaction's USD value, as Liminal reports it
+// alongside amounts in other currencies.
+func FromUSDValue(s string) (Amount, error) {
+	return ParseAmount(s, "USD")
+}
+
+// FromFloat rounds f, in units of currency, to the nearest minor unit.
+// Use it for amounts computed with floating point, such as currency
+// conversions, and for amounts stored as float64.
+func FromFloat(f float64, currency string) (Amount, error) {
+	minor := math.Round(f * math.Pow10(Digits(currency)))
+	if math.IsNaN(minor) || minor >= math.MaxInt64 || minor <= math.MinInt64 {
+		return Amount{}, fmt.Errorf("%w: %v", ErrOutOfRange, f)
+	}
+	return Amount{Minor: int64(minor), Currency: currency}, nil
+}
+
+// Add returns a + b. It fails if they're in different currencies or the
+// sum overflows.
+func (a Amount) Add(b Amount) (Amount, error) {
+	currency, err := common(a, b)
+	if err != nil {
+		return Amount{}, err
+	}
+	sum := a.Minor + b.Minor
+	if (b.Minor > 0 && sum < a.Minor) || (b.Minor < 0 && sum > a.Minor) {
+		return Amount{}, ErrOutOfRange
+	}
+	return Amount{Minor: sum, Currency: currency}, nil
+}
+
+// Sub returns a - b. It fails if they're in different currencies or the
+// difference overflows.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if b.Minor == math.MinInt64 {
+		return Amount{}, ErrOutOfRange
+	}
+	return a.Add(b.Neg())
+}
+
+// Compare returns -1, 0 or +1 as a is less than, equal to, or greater
+// than b. It fails if they're in different currencies.
+func (a Amount) Compare(b Amount) (int, error) {
+	if _, err := common(a, b); err != nil {
+		return 0, err
+	}
+	switch {
+	case a.Minor < b.Minor:
+		return -1, nil
+	case a.Minor > b.Minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Sum totals amounts, which must share a currency.
+func Sum(amounts ...Amount) (Amount, error) {
+	var total Amount
+	for _, a := range amounts {
+		var err error
+		if total, err = total.Add(a); err != nil {
+			return Amount{}, err
+		}
+	}
+	return total, nil
+}
+
+// common returns the currency a and b share.
+func common(a, b Amount) (string, error) {
+	switch {
+	case a.Currency == "":
+		return b.Currency, nil
+	case b.Currency == "" || strings.EqualFold(a.Currency, b.Currency):
+		return a.Currency, nil
+	}
+	return "", &CurrencyMismatchError{A: a.Currency, B: b.Currency}
+}
+
+// Neg returns -a.
+func (a Amount) Neg() Amount {
+	return Amount{Minor: -a.Minor, Currency: a.Currency}
+}
+
+// Abs returns a without its sign.
+func (a Amount) Abs() Amount {
+	if a.Minor < 0 {
+		return a.Neg()
+	}
+	return a
+}
+
+// IsZero reports whether a is zero.
+func (a Amount) IsZero() bool {
+	return a.Minor == 0
+}
+
+// Sign returns -1, 0 or +1 as a is negative, zero or positive.
+func (a Amount) Sign() int {
+	switch {
+	case a.Minor < 0:
+		return -1
+	case a.Minor > 0:
+		return 1
+	}
+	return 0
+}
+
+// Float64 returns a in units of its currency, for APIs and charts that
+// take float64. It is the float nearest the exact amount, so 0.3 is 0.3.
+func (a Amount) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(big.NewInt(a.Minor), scale(a.Currency)).Float64()
+	return f
+}
+
+// Decimal formats a as a plain decimal, e.g. "-1234.50".
+func (a Amount) Decimal() string {
+	digits := Digits(a.Currency)
+	sign, units, frac := a.parts()
+	if digits == 0 {
+		return sign + units
+	}
+	return sign + units + "." + frac
+}
+
+// String formats a for people: with the currency's symbol if it has one,
+// e.g. "-$1,234.50", otherwise with its code, e.g. "1,234.50 USDC".
+func (a Amount) String() string {
+	sign, units, frac := a.parts()
+	number := group(units)
+	if frac != "" {
+		number += "." + frac
+	}
+	if symbol, ok := symbols[strings.ToUpper(a.Currency)]; ok {
+		return sign + symbol + number
+	}
+	if a.Currency == "" {
+		return sign + number
+	}
+	return sign + number + " " + a.Currency
+}
+
+// parts splits a into its sign, whole units, and zero-padded minor digits.
+func (a Amount) parts() (sign, units, frac string) {
+	digits := Digits(a.Currency)
+	abs := new(big.Int).Abs(big.NewInt(a.Minor)).String()
+	if a.Minor < 0 {
+		sign = "-"
+	}
+	if digits == 0 {
+		return sign, abs, ""
+	}
+	if len(abs) <= digits {
+		abs = strings.Repeat("0", digits-len(abs)+1) + abs
+	}
+	return sign, abs[:len(abs)-digits], abs[len(abs)-digits:]
+}
+
+// group separates thousands in a string of digits with commas.
+func group(units string) string {
+	if len(units) <= 3 {
+		return units
+	}
+	var b strings.Builder
+	first := len(units) % 3
+	if first > 0 {
+		b.WriteString(units[:first])
+	}
+	for i := first; i < len(units); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(units[i : i+3])
+	}
+	return b.String()
+}
+
+// scale is 10 to the power of currency's minor digits.
+func scale(currency string) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(Digits(currency))), nil)
+}
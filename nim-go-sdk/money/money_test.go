@@ -0,0 +1,154 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		currency string
+		want     int64
+		wantErr  error
+	}{
+		{name: "whole", input: "12", currency: "USD", want: 1200},
+		{name: "cents", input: "12.34", currency: "USD", want: 1234},
+		{name: "padded", input: "  0.30 ", currency: "USD", want: 30},
+		{name: "leading point", input: ".5", currency: "USD", want: 50},
+		{name: "trailing point", input: "7.", currency: "USD", want: 700},
+		{name: "negative", input: "-45.67", currency: "USD", want: -4567},
+		{name: "explicit plus", input: "+1.01", currency: "USD", want: 101},
+		{name: "rounds half up", input: "0.005", currency: "USD", want: 1},
+		{name: "rounds negative half away", input: "-0.005", currency: "USD", want: -1},
+		{name: "truncates below half", input: "19.994999", currency: "USDC", want: 1999},
+		{name: "exponent", input: "1.5e3", currency: "USD", want: 150000},
+		{name: "no minor unit", input: "1234.5", currency: "JPY", want: 1235},
+		{name: "three digits", input: "1.2345", currency: "KWD", want: 1235},
+		{name: "largest", input: "92233720368547758.07", currency: "USD", want: math.MaxInt64},
+		{name: "most negative", input: "-92233720368547758.08", currency: "USD", want: math.MinInt64},
+		{name: "too large", input: "92233720368547758.08", currency: "USD", wantErr: ErrOutOfRange},
+		{name: "huge exponent", input: "1e300", currency: "USD", wantErr: ErrOutOfRange},
+		{name: "empty", input: "", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "trailing letters", input: "12abc", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "thousands separator", input: "1,000", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "currency symbol", input: "$5", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "double sign", input: "--5", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "fraction", input: "1/3", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "long exponent", input: "1e99999", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "not a number", input: "NaN", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "lone point", input: ".", currency: "USD", wantErr: ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAmount(tt.input, tt.currency)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseAmount(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAmount(%q) error = %v", tt.input, err)
+			}
+			if got.Minor != tt.want || got.Currency != tt.currency {
+				t.Errorf("ParseAmount(%q) = %+v, want %d %s", tt.input, got, tt.want, tt.currency)
+			}
+		})
+	}
+}
+
+func TestFromUSDValue(t *testing.T) {
+	got, err := FromUSDValue("-54.005")
+	if err != nil {
+		t.Fatalf("FromUSDValue() error = %v", err)
+	}
+	if got != (Amount{Minor: -5401, Currency: "USD"}) {
+		t.Errorf("FromUSDValue() = %+v, want -5401 USD", got)
+	}
+	if _, err := FromUSDValue("n/a"); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("FromUSDValue(n/a) error = %v, want ErrInvalidAmount", err)
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	got, err := FromFloat(0.1+0.2, "USD")
+	if err != nil || got.Minor != 30 {
+		t.Errorf("FromFloat(0.1+0.2) = %+v, %v, want 30", got, err)
+	}
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 1e18} {
+		if _, err := FromFloat(f, "USD"); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("FromFloat(%v) error = %v, want ErrOutOfRange", f, err)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	usd := func(minor int64) Amount { return Amount{Minor: minor, Currency: "USD"} }
+
+	// Ten dimes are exactly a dollar, unlike ten float64 0.1s.
+	var total Amount
+	for range 10 {
+		var err error
+		if total, err = total.Add(usd(10)); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if total != usd(100) || total.Float64() != 1 {
+		t.Errorf("ten dimes = %+v (%v), want 1 USD", total, total.Float64())
+	}
+
+	if got, err := usd(30).Sub(usd(45)); err != nil || got != usd(-15) {
+		t.Errorf("Sub() = %+v, %v, want -15", got, err)
+	}
+	if got, err := Sum(usd(1), Amount{Minor: 2}, usd(3)); err != nil || got != usd(6) {
+		t.Errorf("Sum() = %+v, %v, want 6 USD", got, err)
+	}
+	if c, err := usd(5).Compare(usd(7)); err != nil || c != -1 {
+		t.Errorf("Compare() = %d, %v, want -1", c, err)
+	}
+	if c, err := usd(7).Compare(Amount{Minor: 7, Currency: "usd"}); err != nil || c != 0 {
+		t.Errorf("Compare() ignoring case = %d, %v, want 0", c, err)
+	}
+
+	var mismatch *CurrencyMismatchError
+	if _, err := usd(1).Add(Amount{Minor: 1, Currency: "EUR"}); !errors.As(err, &mismatch) {
+		t.Errorf("Add(EUR) error = %v, want CurrencyMismatchError", err)
+	}
+	if _, err := usd(1).Compare(Amount{Minor: 1, Currency: "EUR"}); !errors.As(err, &mismatch) {
+		t.Errorf("Compare(EUR) error = %v, want CurrencyMismatchError", err)
+	}
+	if _, err := usd(math.MaxInt64).Add(usd(1)); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("overflowing Add() error = %v, want ErrOutOfRange", err)
+	}
+	if _, err := usd(0).Sub(usd(math.MinInt64)); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("overflowing Sub() error = %v, want ErrOutOfRange", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount      Amount
+		wantDecimal string
+		wantString  string
+	}{
+		{Amount{Minor: 30, Currency: "USD"}, "0.30", "$0.30"},
+		{Amount{Minor: -123456789, Currency: "USD"}, "-1234567.89", "-$1,234,567.89"},
+		{Amount{Minor: 5, Currency: "EUR"}, "0.05", "€0.05"},
+		{Amount{Minor: 100000, Currency: "USDC"}, "1000.00", "1,000.00 USDC"},
+		{Amount{Minor: 1234, Currency: "JPY"}, "1234", "¥1,234"},
+		{Amount{Minor: 1234, Currency: "KWD"}, "1.234", "1.234 KWD"},
+		{Amount{Minor: -7}, "-0.07", "-0.07"},
+		{Amount{Minor: math.MinInt64, Currency: "USD"}, "-92233720368547758.08", "-$92,233,720,368,547,758.08"},
+	}
+	for _, tt := range tests {
+		if got := tt.amount.Decimal(); got != tt.wantDecimal {
+			t.Errorf("%+v.Decimal() = %q, want %q", tt.amount, got, tt.wantDecimal)
+		}
+		if got := tt.amount.String(); got != tt.wantString {
+			t.Errorf("%+v.String() = %q, want %q", tt.amount, got, tt.wantString)
+		}
+	}
+}
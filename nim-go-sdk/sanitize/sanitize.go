@@ -0,0 +1,321 @@
+// Package sanitize reviews model-generated markdown before it reaches clients.
+//
+// A prompt-injected tool result can make the model emit an attacker-controlled
+// image (which the client fetches automatically) or a deceptive link. The
+// sanitizer allowlists image sources, applies a policy to external links,
+// neutralizes embedded HTML, and caps the number of images per message.
+// Fenced code blocks and inline code are left untouched.
+package sanitize
+
+import (
+	"expvar"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// LinkPolicy controls how links to non-allowlisted domains are rewritten.
+type LinkPolicy int
+
+const (
+	// LinkAnnotate keeps the link and appends its real domain, so
+	// "click here to verify" shows where it actually goes.
+	LinkAnnotate LinkPolicy = iota
+
+	// LinkStrip removes the link, keeping only its text.
+	LinkStrip
+
+	// LinkAllow passes links through unchanged.
+	LinkAllow
+)
+
+// Policy configures the sanitizer.
+type Policy struct {
+	// ImageBaseURLs are URL prefixes images may be loaded from,
+	// typically the deployment's chart BaseURL (e.g. "https://agent.example.com/charts/").
+	ImageBaseURLs []string
+
+	// AllowSVGDataURIs permits inline data:image/svg+xml images.
+	AllowSVGDataURIs bool
+
+	// Links is the policy for links to domains not in AllowedLinkDomains.
+	Links LinkPolicy
+
+	// AllowedLinkDomains are domains (and their subdomains) whose links pass untouched.
+	AllowedLinkDomains []string
+
+	// MaxImages caps images per message. Zero means no cap.
+	MaxImages int
+
+	// ImagePlaceholder replaces removed images. Defaults to "[image removed]".
+	ImagePlaceholder string
+}
+
+// DefaultPolicy returns a policy that allows images from chartBaseURL and
+// inline SVG, annotates external links, and allows up to 5 images.
+func DefaultPolicy(chartBaseURL string) Policy {
+	p := Policy{
+		AllowSVGDataURIs: true,
+		Links:            LinkAnnotate,
+		MaxImages:        5,
+	}
+	if chartBaseURL != "" {
+		p.ImageBaseURLs = []string{chartBaseURL}
+	}
+	return p
+}
+
+// Event kinds recorded in a Report.
+const (
+	EventImageStripped   = "image_stripped"
+	EventImageOverLimit  = "image_over_limit"
+	EventLinkStripped    = "link_stripped"
+	EventLinkAnnotated   = "link_annotated"
+	EventHTMLNeutralized = "html_neutralized"
+)
+
+// Event records a single change made by the sanitizer.
+type Event struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+// Report describes what the sanitizer changed.
+type Report struct {
+	Events []Event `json:"events,omitempty"`
+}
+
+// Changed reports whether any content was modified.
+func (r Report) Changed() bool {
+	return len(r.Events) > 0
+}
+
+// Count returns the number of events of the given kind.
+func (r Report) Count(kind string) int {
+	n := 0
+	for _, e := range r.Events {
+		if e.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// String summarizes the report, e.g. "image_stripped=1 link_annotated=2".
+func (r Report) String() string {
+	var parts []string
+	for _, kind := range []string{EventImageStripped, EventImageOverLimit, EventLinkStripped, EventLinkAnnotated, EventHTMLNeutralized} {
+		if n := r.Count(kind); n > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", kind, n))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// metrics counts sanitization events by kind, published at /debug/vars.
+var metrics = expvar.NewMap("nim_sanitize_events")
+
+var (
+	// imagePattern matches inline images: ![alt](src "title")
+	imagePattern = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^)\s>]*)>?(?:\s+"[^"]*")?\s*\)`)
+
+	// linkPattern matches inline links: [text](href "title")
+	linkPattern = regexp.MustCompile(`\[([^\]]*)\]\(\s*<?([^)\s>]*)>?(?:\s+"[^"]*")?\s*\)`)
+
+	// autolinkPattern matches autolinks: <https://example.com>
+	autolinkPattern = regexp.MustCompile(`<((?:https?|ftp|mailto|javascript|data):[^>\s]*)>`)
+
+	// definitionPattern matches reference definitions: [ref]: href
+	definitionPattern = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*<?(\S+?)>?(?:\s.*)?$`)
+
+	// htmlPattern matches the start of an HTML tag, comment, or declaration,
+	// but not an autolink such as <https://...>.
+	htmlPattern = regexp.MustCompile(`<(?:[A-Za-z][A-Za-z0-9-]*(?:[\s/>]|$)|[/!?])`)
+
+	// fencePattern matches a code fence line.
+	fencePattern = regexp.MustCompile("^ {0,3}(```|~~~)")
+)
+
+// Markdown sanitizes text according to the policy.
+// It is deterministic: the same input and policy always give the same
+// output. It records what it changed in the nim_sanitize_events expvar.
+func Markdown(text string, p Policy) (string, Report) {
+	s := newSanitizer(p)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = s.line(line)
+	}
+	s.publish()
+	return strings.Join(lines, "\n"), s.report
+}
+
+// sanitizer holds state carried across lines of one message.
+type sanitizer struct {
+	policy  Policy
+	report  Report
+	images  int
+	inFence string
+}
+
+func newSanitizer(p Policy) *sanitizer {
+	if p.ImagePlaceholder == "" {
+		p.ImagePlaceholder = "[image removed]"
+	}
+	return &sanitizer{policy: p}
+}
+
+// line sanitizes a single line, tracking fenced code blocks.
+func (s *sanitizer) line(line string) string {
+	if m := fencePattern.FindStringSubmatch(line); m != nil {
+		switch {
+		case s.inFence == "":
+			s.inFence = m[1]
+		case s.inFence == m[1]:
+			s.inFence = ""
+		}
+		return line
+	}
+	if s.inFence != "" {
+		return line
+	}
+
+	if m := definitionPattern.FindStringSubmatch(line); m != nil {
+		if s.imageAllowed(m[1]) || s.linkAllowed(m[1]) {
+			return line
+		}
+		s.record(EventLinkStripped, m[1])
+		return ""
+	}
+
+	// Process text outside inline code spans.
+	parts := strings.Split(line, "`")
+	for i := 0; i < len(parts); i += 2 {
+		// An unmatched trailing backtick leaves the last part as plain text.
+		parts[i] = s.text(parts[i])
+	}
+	return strings.Join(parts, "`")
+}
+
+// text sanitizes a run of markdown outside code.
+func (s *sanitizer) text(text string) string {
+	text = imagePattern.ReplaceAllStringFunc(text, func(match string) string {
+		src := imagePattern.FindStringSubmatch(match)[2]
+		if !s.imageAllowed(src) {
+			s.record(EventImageStripped, src)
+			return s.policy.ImagePlaceholder
+		}
+		if s.policy.MaxImages > 0 && s.images >= s.policy.MaxImages {
+			s.record(EventImageOverLimit, src)
+			return s.policy.ImagePlaceholder
+		}
+		s.images++
+		return match
+	})
+
+	text = s.links(text)
+
+	text = autolinkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		href := autolinkPattern.FindStringSubmatch(match)[1]
+		return s.link(match, href, href)
+	})
+
+	if htmlPattern.MatchString(text) {
+		text = htmlPattern.ReplaceAllStringFunc(text, func(match string) string {
+			s.record(EventHTMLNeutralized, match)
+			return "&lt;" + match[1:]
+		})
+	}
+	return text
+}
+
+// links applies the link policy to every inline link in text.
+// Matches directly after '!' are images that were already allowed, and are skipped.
+func (s *sanitizer) links(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range linkPattern.FindAllStringSubmatchIndex(text, -1) {
+		if m[0] > 0 && text[m[0]-1] == '!' {
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		b.WriteString(s.link(text[m[0]:m[1]], text[m[2]:m[3]], text[m[4]:m[5]]))
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// link applies the link policy to a single link.
+func (s *sanitizer) link(match, label, href string) string {
+	if s.linkAllowed(href) || s.imageAllowed(href) {
+		return match
+	}
+
+	u, err := url.Parse(href)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		// Non-web schemes (javascript:, data:, ...) are never passed through.
+		s.record(EventLinkStripped, href)
+		return label
+	}
+
+	switch s.policy.Links {
+	case LinkAllow:
+		return match
+	case LinkStrip:
+		s.record(EventLinkStripped, href)
+		return label
+	default:
+		s.record(EventLinkAnnotated, href)
+		return fmt.Sprintf("%s (%s)", match, u.Hostname())
+	}
+}
+
+// imageAllowed reports whether src is an allowlisted image source.
+func (s *sanitizer) imageAllowed(src string) bool {
+	if strings.HasPrefix(strings.ToLower(src), "data:image/svg+xml") {
+		return s.policy.AllowSVGDataURIs
+	}
+	u, err := url.Parse(src)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	for _, base := range s.policy.ImageBaseURLs {
+		b, err := url.Parse(base)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(u.Scheme, b.Scheme) && strings.EqualFold(u.Host, b.Host) && strings.HasPrefix(u.Path, b.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkAllowed reports whether href points at an allowlisted domain.
+func (s *sanitizer) linkAllowed(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range s.policy.AllowedLinkDomains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// record adds an event to the report.
+func (s *sanitizer) record(kind, detail string) {
+	s.report.Events = append(s.report.Events, Event{Kind: kind, Detail: detail})
+}
+
+// publish adds the report's events to the metrics counters.
+func (s *sanitizer) publish() {
+	for _, e := range s.report.Events {
+		metrics.Add(e.Kind, 1)
+	}
+}
@@ -0,0 +1,177 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+)
+
+const chartBase = "https://agent.example.com/charts/"
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     Policy
+		input      string
+		want       string
+		wantEvents map[string]int
+	}{
+		{
+			name:   "chart embed passes untouched",
+			policy: DefaultPolicy(chartBase),
+			input:  "Here is your trend:\n![Balance](https://agent.example.com/charts/balance-1.svg)",
+			want:   "Here is your trend:\n![Balance](https://agent.example.com/charts/balance-1.svg)",
+		},
+		{
+			name:   "inline svg data uri passes",
+			policy: DefaultPolicy(chartBase),
+			input:  "![chart](data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=)",
+			want:   "![chart](data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=)",
+		},
+		{
+			name:       "off-domain image stripped with placeholder",
+			policy:     DefaultPolicy(chartBase),
+			input:      "Look ![x](https://evil.example/track.png) here",
+			want:       "Look [image removed] here",
+			wantEvents: map[string]int{EventImageStripped: 1},
+		},
+		{
+			name:       "same host outside chart path stripped",
+			policy:     DefaultPolicy(chartBase),
+			input:      "![x](https://agent.example.com/private/a.png)",
+			want:       "[image removed]",
+			wantEvents: map[string]int{EventImageStripped: 1},
+		},
+		{
+			name:       "non-svg data uri stripped",
+			policy:     DefaultPolicy(chartBase),
+			input:      "![x](data:image/png;base64,AAAA)",
+			want:       "[image removed]",
+			wantEvents: map[string]int{EventImageStripped: 1},
+		},
+		{
+			name:       "external link annotated with domain",
+			policy:     DefaultPolicy(chartBase),
+			input:      "[click here to verify your account](https://liminal-secure.evil.example/login)",
+			want:       "[click here to verify your account](https://liminal-secure.evil.example/login) (liminal-secure.evil.example)",
+			wantEvents: map[string]int{EventLinkAnnotated: 1},
+		},
+		{
+			name:       "external link stripped",
+			policy:     Policy{Links: LinkStrip},
+			input:      "Go [here](https://evil.example) now",
+			want:       "Go here now",
+			wantEvents: map[string]int{EventLinkStripped: 1},
+		},
+		{
+			name:   "allowlisted domain and subdomain pass",
+			policy: Policy{Links: LinkStrip, AllowedLinkDomains: []string{"liminal.cash"}},
+			input:  "[docs](https://liminal.cash/docs) and [app](https://app.liminal.cash)",
+			want:   "[docs](https://liminal.cash/docs) and [app](https://app.liminal.cash)",
+		},
+		{
+			name:       "lookalike domain is not allowlisted",
+			policy:     Policy{Links: LinkStrip, AllowedLinkDomains: []string{"liminal.cash"}},
+			input:      "[docs](https://evilliminal.cash/docs)",
+			want:       "docs",
+			wantEvents: map[string]int{EventLinkStripped: 1},
+		},
+		{
+			name:       "javascript link always stripped",
+			policy:     Policy{Links: LinkAllow},
+			input:      "[ok](javascript:void)",
+			want:       "ok",
+			wantEvents: map[string]int{EventLinkStripped: 1},
+		},
+		{
+			name:       "html neutralized",
+			policy:     DefaultPolicy(chartBase),
+			input:      `<img src="https://evil.example/x.png"> and <script>alert(1)</script>`,
+			want:       `&lt;img src="https://evil.example/x.png"> and &lt;script>alert(1)&lt;/script>`,
+			wantEvents: map[string]int{EventHTMLNeutralized: 3},
+		},
+		{
+			name:   "comparisons are not html",
+			policy: DefaultPolicy(chartBase),
+			input:  "Spent < 50 USD and 3<4",
+			want:   "Spent < 50 USD and 3<4",
+		},
+		{
+			name:       "image cap",
+			policy:     Policy{ImageBaseURLs: []string{chartBase}, MaxImages: 1},
+			input:      "![a](https://agent.example.com/charts/a.svg) ![b](https://agent.example.com/charts/b.svg)",
+			want:       "![a](https://agent.example.com/charts/a.svg) [image removed]",
+			wantEvents: map[string]int{EventImageOverLimit: 1},
+		},
+		{
+			name:   "mixed content handled piecewise",
+			policy: DefaultPolicy(chartBase),
+			input: "Your chart: ![Trend](https://agent.example.com/charts/t.svg)\n" +
+				"![pixel](https://evil.example/p.gif) [verify](https://evil.example/v)",
+			want: "Your chart: ![Trend](https://agent.example.com/charts/t.svg)\n" +
+				"[image removed] [verify](https://evil.example/v) (evil.example)",
+			wantEvents: map[string]int{EventImageStripped: 1, EventLinkAnnotated: 1},
+		},
+		{
+			name:       "reference definitions are checked",
+			policy:     DefaultPolicy(chartBase),
+			input:      "![x][img]\n[img]: https://evil.example/x.png",
+			want:       "![x][img]\n",
+			wantEvents: map[string]int{EventLinkStripped: 1},
+		},
+		{
+			name:   "code is left untouched",
+			policy: Policy{Links: LinkStrip},
+			input:  "Use `<b>` or:\n```\n![x](https://evil.example/x.png)\n```",
+			want:   "Use `<b>` or:\n```\n![x](https://evil.example/x.png)\n```",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report := Markdown(tt.input, tt.policy)
+			if got != tt.want {
+				t.Errorf("Markdown() =\n%q\nwant\n%q", got, tt.want)
+			}
+			total := 0
+			for kind, n := range tt.wantEvents {
+				if c := report.Count(kind); c != n {
+					t.Errorf("report.Count(%s) = %d, want %d", kind, c, n)
+				}
+				total += n
+			}
+			if len(report.Events) != total {
+				t.Errorf("report has %d events, want %d: %s", len(report.Events), total, report)
+			}
+		})
+	}
+}
+
+func TestStreamMatchesMarkdown(t *testing.T) {
+	input := "Chart: ![t](https://agent.example.com/charts/t.svg)\n" +
+		"Bad: ![p](https://evil.example/p.gif)\n" +
+		"```\n<b>code</b>\n```\n" +
+		"Last [link](https://evil.example) line"
+	policy := DefaultPolicy(chartBase)
+
+	want, wantReport := Markdown(input, policy)
+
+	for _, size := range []int{1, 3, 7, 64} {
+		stream := NewStream(policy)
+		var b strings.Builder
+		for i := 0; i < len(input); i += size {
+			end := i + size
+			if end > len(input) {
+				end = len(input)
+			}
+			b.WriteString(stream.Write(input[i:end]))
+		}
+		b.WriteString(stream.Flush())
+
+		if got := b.String(); got != want {
+			t.Errorf("chunk size %d: stream output =\n%q\nwant\n%q", size, got, want)
+		}
+		if got := stream.Report().String(); got != wantReport.String() {
+			t.Errorf("chunk size %d: report = %q, want %q", size, got, wantReport.String())
+		}
+	}
+}
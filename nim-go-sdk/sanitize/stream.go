@@ -0,0 +1,55 @@
+package sanitize
+
+import "strings"
+
+// Stream sanitizes markdown that arrives in chunks, such as streamed model output.
+// Text is held back until a line is complete, so markdown split across chunks is
+// reviewed as a whole. The output is identical to calling Markdown on the full text.
+type Stream struct {
+	s       *sanitizer
+	pending strings.Builder
+}
+
+// NewStream creates a streaming sanitizer with the given policy.
+func NewStream(p Policy) *Stream {
+	return &Stream{s: newSanitizer(p)}
+}
+
+// Write adds a chunk and returns the sanitized text of any lines it completed.
+func (st *Stream) Write(chunk string) string {
+	st.pending.WriteString(chunk)
+	buffered := st.pending.String()
+
+	end := strings.LastIndexByte(buffered, '\n')
+	if end < 0 {
+		return ""
+	}
+
+	st.pending.Reset()
+	st.pending.WriteString(buffered[end+1:])
+
+	lines := strings.Split(buffered[:end], "\n")
+	for i, line := range lines {
+		lines[i] = st.s.line(line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Flush returns the sanitized remainder of a final, unterminated line.
+// Unlike Markdown, a Stream does not add to the metrics counters, since the
+// complete message is expected to be sanitized (and counted) once it is final.
+func (st *Stream) Flush() string {
+	rest := st.pending.String()
+	st.pending.Reset()
+
+	var out string
+	if rest != "" {
+		out = st.s.line(rest)
+	}
+	return out
+}
+
+// Report returns the changes made so far.
+func (st *Stream) Report() Report {
+	return st.s.report
+}
@@ -0,0 +1,131 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func minute(y int, m time.Month, d, hour, min int) time.Time {
+	return time.Date(y, m, d, hour, min, 0, 0, time.UTC)
+}
+
+func TestCron_Next(t *testing.T) {
+	// 2026-10-17 is a Saturday.
+	from := time.Date(2026, time.October, 17, 8, 30, 15, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want []time.Time
+	}{
+		{"*/15 * * * *", []time.Time{
+			minute(2026, time.October, 17, 8, 45),
+			minute(2026, time.October, 17, 9, 0),
+			minute(2026, time.October, 17, 9, 15),
+		}},
+		{"0 9 * * MON-FRI", []time.Time{
+			minute(2026, time.October, 19, 9, 0),
+			minute(2026, time.October, 20, 9, 0),
+		}},
+		{"30 8 * * *", []time.Time{
+			minute(2026, time.October, 18, 8, 30),
+		}},
+		{"0 9-17/4 * * *", []time.Time{
+			minute(2026, time.October, 17, 9, 0),
+			minute(2026, time.October, 17, 13, 0),
+			minute(2026, time.October, 17, 17, 0),
+			minute(2026, time.October, 18, 9, 0),
+		}},
+		{"0 0 1,15 * *", []time.Time{
+			minute(2026, time.November, 1, 0, 0),
+			minute(2026, time.November, 15, 0, 0),
+		}},
+		{"0 0 31 * *", []time.Time{
+			minute(2026, time.October, 31, 0, 0),
+			minute(2026, time.December, 31, 0, 0),
+		}},
+		{"0 0 29 feb *", []time.Time{
+			minute(2028, time.February, 29, 0, 0),
+		}},
+		// Both day fields restricted: the 1st, or any Sunday.
+		{"0 12 1 * 7", []time.Time{
+			minute(2026, time.October, 18, 12, 0),
+			minute(2026, time.October, 25, 12, 0),
+			minute(2026, time.November, 1, 12, 0),
+			minute(2026, time.November, 8, 12, 0),
+		}},
+		{"@weekly", []time.Time{
+			minute(2026, time.October, 18, 0, 0),
+			minute(2026, time.October, 25, 0, 0),
+		}},
+		{"@hourly", []time.Time{
+			minute(2026, time.October, 17, 9, 0),
+		}},
+		{"@yearly", []time.Time{
+			minute(2027, time.January, 1, 0, 0),
+		}},
+		{"@every 90m", []time.Time{
+			from.Add(90 * time.Minute),
+			from.Add(180 * time.Minute),
+		}},
+		{"0 0 30 2 *", []time.Time{{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			c, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron: %v", err)
+			}
+			at := from
+			for _, want := range tt.want {
+				at = c.Next(at)
+				if !at.Equal(want) {
+					t.Fatalf("Next = %s, want %s", at, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCron_NextInLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	c, err := ParseCron("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 23:00 UTC is already 9am the next day in UTC+10.
+	got := c.Next(minute(2026, time.October, 17, 22, 0).In(loc))
+	if want := minute(2026, time.October, 17, 23, 0); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got.UTC(), want)
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"", "want 5 fields"},
+		{"0 9 * *", "want 5 fields"},
+		{"60 * * * *", "minute"},
+		{"* 24 * * *", "hour"},
+		{"* * 0 * *", "day of month"},
+		{"* * * 13 *", "month"},
+		{"* * * * 8", "day of week"},
+		{"* * * * fun", "day of week"},
+		{"*/0 * * * *", "invalid step"},
+		{"17-9 * * * *", "runs backwards"},
+		{"@fortnightly", "unknown descriptor"},
+		{"@every 30s", "at least a minute"},
+		{"@every soon", "invalid duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseCron(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseCron(%q) = %v, want an error mentioning %q", tt.spec, err, tt.want)
+			}
+		})
+	}
+}
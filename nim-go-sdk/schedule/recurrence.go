@@ -0,0 +1,245 @@
+// Package schedule provides recurring transfer scheduling: recurrence math,
+// tools for creating and managing schedules, and a Scheduler that surfaces or
+// executes due occurrences.
+package schedule
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// Supported cadences.
+const (
+	CadenceDaily    = "daily"
+	CadenceWeekly   = "weekly"
+	CadenceBiweekly = "biweekly"
+	CadenceMonthly  = "monthly"
+)
+
+// Cadences lists the supported cadences, in schema order.
+var Cadences = []string{CadenceDaily, CadenceWeekly, CadenceBiweekly, CadenceMonthly}
+
+// maxOccurrences caps how far recurrence calculations will iterate.
+// Ten years of daily transfers is well past any sensible horizon.
+const maxOccurrences = 3660
+
+// Occurrence is a single due transfer within a schedule.
+type Occurrence struct {
+	// Index is the zero-based position in the series.
+	Index int
+
+	// DueAt is when the transfer is due.
+	DueAt time.Time
+}
+
+// OccurrenceAt returns the date of the n-th (zero-based) occurrence,
+// ignoring end conditions.
+//
+// Monthly schedules recur on the start date's day of month. When a month is
+// shorter than that day, the occurrence falls on the month's last day instead;
+// the following month returns to the original day (Jan 31, Feb 28, Mar 31, ...).
+func OccurrenceAt(s *store.ScheduledTransfer, n int) time.Time {
+	start := s.StartDate
+	switch s.Cadence {
+	case CadenceDaily:
+		return start.AddDate(0, 0, n)
+	case CadenceWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case CadenceBiweekly:
+		return start.AddDate(0, 0, 14*n)
+	default:
+		return addMonthsClamped(start, n)
+	}
+}
+
+// addMonthsClamped adds n months to t, clamping the day to the target month's
+// length. time.AddDate normalizes overflow instead (Jan 31 + 1 month = Mar 3),
+// which would skip February entirely.
+func addMonthsClamped(t time.Time, n int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := daysIn(first.Year(), first.Month(), t.Location()); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// daysIn returns the number of days in the given month.
+func daysIn(year int, month time.Month, loc *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+}
+
+// inSeries reports whether the n-th occurrence is within the schedule's end conditions.
+func inSeries(s *store.ScheduledTransfer, n int) bool {
+	if n < 0 || n >= maxOccurrences {
+		return false
+	}
+	if s.MaxOccurrences > 0 && n >= s.MaxOccurrences {
+		return false
+	}
+	if s.EndDate != nil && OccurrenceAt(s, n).After(*s.EndDate) {
+		return false
+	}
+	return true
+}
+
+// Due returns all occurrences due at or before now, in order.
+func Due(s *store.ScheduledTransfer, now time.Time) []Occurrence {
+	var due []Occurrence
+	for n := 0; inSeries(s, n); n++ {
+		at := OccurrenceAt(s, n)
+		if at.After(now) {
+			break
+		}
+		due = append(due, Occurrence{Index: n, DueAt: at})
+	}
+	return due
+}
+
+// Next returns the first occurrence after now, or false if the series has ended.
+func Next(s *store.ScheduledTransfer, now time.Time) (Occurrence, bool) {
+	for n := 0; inSeries(s, n); n++ {
+		if at := OccurrenceAt(s, n); at.After(now) {
+			return Occurrence{Index: n, DueAt: at}, true
+		}
+	}
+	return Occurrence{}, false
+}
+
+// Count returns the total number of occurrences, or -1 if the schedule has no end.
+func Count(s *store.ScheduledTransfer) int {
+	if s.MaxOccurrences <= 0 && s.EndDate == nil {
+		return -1
+	}
+	n := 0
+	for inSeries(s, n) {
+		n++
+	}
+	return n
+}
+
+// Finished reports whether every occurrence is due at or before now.
+func Finished(s *store.ScheduledTransfer, now time.Time) bool {
+	_, ok := Next(s, now)
+	return !ok && Count(s) >= 0
+}
+
+// Total returns amount multiplied by count, formatted with the amount's precision.
+func Total(amount string, count int) (string, error) {
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return "", fmt.Errorf("invalid amount: %q", amount)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(int64(count)))
+	return r.FloatString(decimals(amount)), nil
+}
+
+// decimals returns the number of digits after the decimal point in a decimal string.
+func decimals(amount string) int {
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		return len(amount) - i - 1
+	}
+	return 0
+}
+
+// Describe returns a human-readable description of the recurrence and the total
+// committed over the schedule's horizon, e.g.
+// "50 USDC to @mom every month on the 31st (the last day in shorter months),
+// starting 2026-01-31, 12 times — 600 USDC in total".
+func Describe(s *store.ScheduledTransfer) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s to %s %s", s.Amount, s.Currency, s.Recipient, cadencePhrase(s))
+	fmt.Fprintf(&b, ", starting %s", s.StartDate.Format("2006-01-02"))
+
+	count := Count(s)
+	if count < 0 {
+		b.WriteString(", with no end date")
+		if perYear := occurrencesPerYear(s.Cadence); perYear > 0 {
+			if total, err := Total(s.Amount, perYear); err == nil {
+				fmt.Fprintf(&b, " (%s %s per year)", total, s.Currency)
+			}
+		}
+		return b.String()
+	}
+
+	if s.EndDate != nil {
+		fmt.Fprintf(&b, " until %s", s.EndDate.Format("2006-01-02"))
+	}
+	times := "times"
+	if count == 1 {
+		times = "time"
+	}
+	fmt.Fprintf(&b, ", %d %s", count, times)
+	if total, err := Total(s.Amount, count); err == nil {
+		fmt.Fprintf(&b, " — %s %s in total", total, s.Currency)
+	}
+	return b.String()
+}
+
+// cadencePhrase describes the cadence in words.
+func cadencePhrase(s *store.ScheduledTransfer) string {
+	switch s.Cadence {
+	case CadenceDaily:
+		return "every day"
+	case CadenceWeekly:
+		return "every " + s.StartDate.Weekday().String()
+	case CadenceBiweekly:
+		return "every other " + s.StartDate.Weekday().String()
+	default:
+		day := s.StartDate.Day()
+		phrase := "every month on the " + ordinal(day)
+		if day > 28 {
+			phrase += " (the last day in shorter months)"
+		}
+		return phrase
+	}
+}
+
+// occurrencesPerYear returns how many times a cadence recurs in a year.
+func occurrencesPerYear(cadence string) int {
+	switch cadence {
+	case CadenceDaily:
+		return 365
+	case CadenceWeekly:
+		return 52
+	case CadenceBiweekly:
+		return 26
+	case CadenceMonthly:
+		return 12
+	}
+	return 0
+}
+
+// ordinal formats a day of month as "1st", "2nd", "3rd", "4th", ...
+func ordinal(n int) string {
+	suffix := "th"
+	switch n % 10 {
+	case 1:
+		if n%100 != 11 {
+			suffix = "st"
+		}
+	case 2:
+		if n%100 != 12 {
+			suffix = "nd"
+		}
+	case 3:
+		if n%100 != 13 {
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// ValidCadence reports whether cadence is supported.
+func ValidCadence(cadence string) bool {
+	for _, c := range Cadences {
+		if c == cadence {
+			return true
+		}
+	}
+	return false
+}
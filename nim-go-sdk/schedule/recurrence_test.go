@@ -0,0 +1,153 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestOccurrenceAt_MonthEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		start time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "31st clamps in short months and returns",
+			start: date(2026, time.January, 31),
+			want: []time.Time{
+				date(2026, time.January, 31),
+				date(2026, time.February, 28),
+				date(2026, time.March, 31),
+				date(2026, time.April, 30),
+				date(2026, time.May, 31),
+			},
+		},
+		{
+			name:  "29th in a leap year",
+			start: date(2028, time.January, 29),
+			want: []time.Time{
+				date(2028, time.January, 29),
+				date(2028, time.February, 29),
+				date(2028, time.March, 29),
+			},
+		},
+		{
+			name:  "30th across a non-leap February",
+			start: date(2026, time.January, 30),
+			want: []time.Time{
+				date(2026, time.January, 30),
+				date(2026, time.February, 28),
+				date(2026, time.March, 30),
+			},
+		},
+		{
+			name:  "crosses a year boundary",
+			start: date(2026, time.November, 30),
+			want: []time.Time{
+				date(2026, time.November, 30),
+				date(2026, time.December, 30),
+				date(2027, time.January, 30),
+				date(2027, time.February, 28),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &store.ScheduledTransfer{Cadence: CadenceMonthly, StartDate: tt.start}
+			for n, want := range tt.want {
+				if got := OccurrenceAt(s, n); !got.Equal(want) {
+					t.Errorf("OccurrenceAt(%d) = %s, want %s", n, got.Format("2006-01-02"), want.Format("2006-01-02"))
+				}
+			}
+		})
+	}
+}
+
+func TestOccurrenceAt_Cadences(t *testing.T) {
+	start := date(2026, time.January, 28)
+	tests := []struct {
+		cadence string
+		n       int
+		want    time.Time
+	}{
+		{CadenceDaily, 4, date(2026, time.February, 1)},
+		{CadenceWeekly, 1, date(2026, time.February, 4)},
+		{CadenceBiweekly, 2, date(2026, time.February, 25)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cadence, func(t *testing.T) {
+			s := &store.ScheduledTransfer{Cadence: tt.cadence, StartDate: start}
+			if got := OccurrenceAt(s, tt.n); !got.Equal(tt.want) {
+				t.Errorf("OccurrenceAt(%d) = %s, want %s", tt.n, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestCountAndDue(t *testing.T) {
+	end := date(2026, time.April, 30)
+	tests := []struct {
+		name      string
+		sched     *store.ScheduledTransfer
+		now       time.Time
+		wantCount int
+		wantDue   int
+	}{
+		{
+			name:      "end date includes clamped February",
+			sched:     &store.ScheduledTransfer{Cadence: CadenceMonthly, StartDate: date(2026, time.January, 31), EndDate: &end},
+			now:       date(2026, time.March, 1),
+			wantCount: 4,
+			wantDue:   2,
+		},
+		{
+			name:      "max occurrences",
+			sched:     &store.ScheduledTransfer{Cadence: CadenceWeekly, StartDate: date(2026, time.January, 1), MaxOccurrences: 3},
+			now:       date(2026, time.June, 1),
+			wantCount: 3,
+			wantDue:   3,
+		},
+		{
+			name:      "open ended",
+			sched:     &store.ScheduledTransfer{Cadence: CadenceMonthly, StartDate: date(2026, time.January, 1)},
+			now:       date(2025, time.December, 1),
+			wantCount: -1,
+			wantDue:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Count(tt.sched); got != tt.wantCount {
+				t.Errorf("Count() = %d, want %d", got, tt.wantCount)
+			}
+			if got := len(Due(tt.sched, tt.now)); got != tt.wantDue {
+				t.Errorf("len(Due()) = %d, want %d", got, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	s := &store.ScheduledTransfer{
+		Recipient:      "@mom",
+		Amount:         "50.00",
+		Currency:       "USDC",
+		Cadence:        CadenceMonthly,
+		StartDate:      date(2026, time.January, 31),
+		MaxOccurrences: 12,
+	}
+
+	want := "50.00 USDC to @mom every month on the 31st (the last day in shorter months), starting 2026-01-31, 12 times — 600.00 USDC in total"
+	if got := Describe(s); got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,407 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// Notifier delivers a scheduled transfer's confirmation request to the user.
+// This is an interface - implementations (push notifications, email, connected
+// WebSocket sessions) are provided by the consuming application.
+type Notifier interface {
+	// NotifyConfirmation tells the user a scheduled transfer awaits approval.
+	// Returns false if the user could not be reached right now; the occurrence
+	// is then presented again on their next connect (see Scheduler.CatchUp).
+	NotifyConfirmation(ctx context.Context, userID string, action *core.PendingAction) (bool, error)
+}
+
+// Limits checks a transfer against the user's limits before a pre-authorized
+// occurrence executes. A non-nil error blocks automatic execution; the
+// occurrence is surfaced as a confirmation instead.
+// This is an interface - implementations are provided by the consuming application.
+// limits.Limits and limits.UserLimitsFunc implement it.
+type Limits interface {
+	CheckTransfer(ctx context.Context, userID, amount, currency string) error
+}
+
+// transferRecorder is implemented by Limits that track usage themselves
+// (such as limits.Limits), so pre-authorized transfers count against the allowance.
+type transferRecorder interface {
+	RecordTransfer(ctx context.Context, userID, id, amount, currency string) error
+}
+
+// Config configures a Scheduler.
+type Config struct {
+	// Schedules persists schedules and occurrence markers. Required.
+	Schedules store.Schedules
+
+	// Confirmations stores confirmations for due occurrences. Required.
+	// Must be the same store the server confirms actions against.
+	Confirmations store.Confirmations
+
+	// Executor runs pre-authorized transfers.
+	// If nil, every occurrence is surfaced as a confirmation.
+	Executor core.ToolExecutor
+
+	// Notifier proactively delivers confirmations for due occurrences.
+	// If nil, confirmations are only presented when the user connects.
+	Notifier Notifier
+
+	// Limits bounds pre-authorized transfers.
+	// If nil, pre-authorized occurrences are surfaced as confirmations,
+	// since they cannot be checked against the user's limits.
+	Limits Limits
+
+	// Credentials returns the token a user's due transfers are made with,
+	// since ticks run without a connection. If nil, pre-authorized
+	// occurrences are surfaced as confirmations, so no transfer is ever
+	// made with the Executor's own token.
+	Credentials core.CredentialFunc
+
+	// Tool is the write tool used for each transfer. Defaults to "send_money".
+	Tool string
+
+	// ConfirmationTTL is how long a surfaced confirmation stays valid.
+	// Defaults to 24 hours.
+	ConfirmationTTL time.Duration
+
+	// CatchUpWindow is how late a pre-authorized occurrence may still execute
+	// automatically. Older missed occurrences are surfaced as confirmations.
+	// Defaults to 24 hours.
+	CatchUpWindow time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Scheduler surfaces or executes due occurrences of scheduled transfers.
+//
+// By default each due occurrence becomes a fresh confirmation, delivered via
+// the Notifier or presented on the user's next connect. Occurrences are marked
+// in the store once delivered or executed, so none is skipped or run twice.
+type Scheduler struct {
+	cfg Config
+}
+
+// New creates a scheduler with the given configuration.
+func New(cfg Config) *Scheduler {
+	if cfg.Tool == "" {
+		cfg.Tool = "send_money"
+	}
+	if cfg.ConfirmationTTL == 0 {
+		cfg.ConfirmationTTL = 24 * time.Hour
+	}
+	if cfg.CatchUpWindow == 0 {
+		cfg.CatchUpWindow = 24 * time.Hour
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Scheduler{cfg: cfg}
+}
+
+// Schedules returns the scheduler's schedule store.
+func (s *Scheduler) Schedules() store.Schedules {
+	return s.cfg.Schedules
+}
+
+// Run calls Tick every interval until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx); err != nil {
+			log.Printf("Scheduler tick failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick processes due occurrences for every active schedule.
+// Pre-authorized occurrences execute; the rest are delivered through the Notifier.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	schedules, err := s.cfg.Schedules.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	for _, sched := range schedules {
+		// Without the user's credential, due transfers are surfaced instead.
+		userCtx, err := s.cfg.Credentials.Attach(core.WithIdentity(ctx, core.Identity{UserID: sched.UserID}), sched.UserID)
+		if err != nil {
+			log.Printf("Scheduler failed to get credentials for schedule %s: %v", sched.ID, err)
+		}
+		_, err = s.process(userCtx, sched, func(action *core.PendingAction) (bool, error) {
+			if s.cfg.Notifier == nil {
+				return false, nil
+			}
+			return s.cfg.Notifier.NotifyConfirmation(userCtx, sched.UserID, action)
+		})
+		if err != nil {
+			log.Printf("Scheduler failed to process schedule %s: %v", sched.ID, err)
+		}
+	}
+	return nil
+}
+
+// CatchUp returns confirmations for a user's due occurrences that have not yet
+// been delivered, such as those missed while the user was offline.
+// Call it when the user connects and present every returned action.
+func (s *Scheduler) CatchUp(ctx context.Context, userID string) ([]*core.PendingAction, error) {
+	schedules, err := s.cfg.Schedules.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	var actions []*core.PendingAction
+	for _, sched := range schedules {
+		if sched.Status != store.ScheduleActive {
+			continue
+		}
+		_, err := s.process(ctx, sched, func(action *core.PendingAction) (bool, error) {
+			actions = append(actions, action)
+			return true, nil
+		})
+		if err != nil {
+			return actions, err
+		}
+	}
+	return actions, nil
+}
+
+// Cancel stops a schedule and withdraws confirmations for its undelivered occurrences.
+func (s *Scheduler) Cancel(ctx context.Context, userID, scheduleID string) error {
+	sched, err := s.cfg.Schedules.Get(ctx, userID, scheduleID)
+	if err != nil {
+		return err
+	}
+	if err := s.cfg.Schedules.SetStatus(ctx, userID, scheduleID, store.ScheduleCancelled); err != nil {
+		return err
+	}
+
+	for _, occ := range Due(sched, s.cfg.Now()) {
+		action, err := s.cfg.Confirmations.GetByIdempotency(ctx, userID, OccurrenceKey(sched.ID, occ.Index))
+		if err != nil || action == nil {
+			continue
+		}
+		if err := s.cfg.Confirmations.Cancel(ctx, userID, action.ID); err != nil {
+			log.Printf("Failed to cancel confirmation %s for schedule %s: %v", action.ID, scheduleID, err)
+		}
+	}
+	return nil
+}
+
+// OccurrenceKey returns the idempotency key for an occurrence.
+func OccurrenceKey(scheduleID string, index int) string {
+	return fmt.Sprintf("schedule:%s:%d", scheduleID, index)
+}
+
+// process handles each unmarked due occurrence of sched. Occurrences that need
+// approval are passed to deliver, and marked once it reports delivery.
+// Returns the number of occurrences handled.
+func (s *Scheduler) process(ctx context.Context, sched *store.ScheduledTransfer, deliver func(*core.PendingAction) (bool, error)) (int, error) {
+	now := s.cfg.Now()
+	handled := 0
+
+	for _, occ := range Due(sched, now) {
+		marked, err := s.cfg.Schedules.OccurrenceMarked(ctx, sched.ID, occ.Index)
+		if err != nil {
+			return handled, err
+		}
+		if marked {
+			continue
+		}
+
+		ok, err := s.handleOccurrence(ctx, sched, occ, now, deliver)
+		if err != nil {
+			return handled, err
+		}
+		if ok {
+			handled++
+		}
+	}
+
+	if Finished(sched, now) {
+		if err := s.markCompleted(ctx, sched); err != nil {
+			return handled, err
+		}
+	}
+	return handled, nil
+}
+
+// handleOccurrence executes or surfaces a single occurrence.
+// Returns true if the occurrence was marked as handled.
+func (s *Scheduler) handleOccurrence(ctx context.Context, sched *store.ScheduledTransfer, occ Occurrence, now time.Time, deliver func(*core.PendingAction) (bool, error)) (bool, error) {
+	var reason string
+	if sched.PreAuthorized {
+		existing, err := s.cfg.Confirmations.GetByIdempotency(ctx, sched.UserID, OccurrenceKey(sched.ID, occ.Index))
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case existing != nil:
+			// An earlier attempt failed and already asks the user; don't retry it.
+		case s.cfg.Executor == nil || s.cfg.Limits == nil || s.cfg.Credentials == nil:
+			reason = "automatic execution is not configured"
+		case core.CredentialFromContext(ctx) == "":
+			reason = "your credentials are unavailable"
+		case now.Sub(occ.DueAt) > s.cfg.CatchUpWindow:
+			reason = "this transfer was missed while you were away"
+		default:
+			if err := s.cfg.Limits.CheckTransfer(ctx, sched.UserID, sched.Amount, sched.Currency); err != nil {
+				reason = err.Error()
+			} else {
+				done, failure, err := s.execute(ctx, sched, occ, now)
+				if err != nil || done {
+					return done, err
+				}
+				reason = failure
+			}
+		}
+	}
+
+	action, err := s.confirmation(ctx, sched, occ, now, reason)
+	if err != nil {
+		return false, err
+	}
+
+	delivered, err := deliver(action)
+	if err != nil || !delivered {
+		// Leave the occurrence unmarked so it is presented on next connect.
+		return false, err
+	}
+	return s.cfg.Schedules.MarkOccurrence(ctx, sched.ID, occ.Index)
+}
+
+// execute runs a pre-authorized occurrence. The occurrence is claimed first,
+// so a concurrent tick cannot run it twice. Returns true once the transfer
+// has been sent, or if another tick claimed it. If the transfer fails, a
+// confirmation is stored for the occurrence and the claim released, and the
+// failure is returned so the user can be asked to send it themselves.
+func (s *Scheduler) execute(ctx context.Context, sched *store.ScheduledTransfer, occ Occurrence, now time.Time) (bool, string, error) {
+	claimed, err := s.cfg.Schedules.MarkOccurrence(ctx, sched.ID, occ.Index)
+	if err != nil {
+		return false, "", err
+	}
+	if !claimed {
+		return true, "", nil
+	}
+
+	key := OccurrenceKey(sched.ID, occ.Index)
+	ctx = core.WithRequestID(ctx, key)
+
+	resp, err := s.cfg.Executor.ExecuteWrite(ctx, &core.ExecuteRequest{
+		UserID:    sched.UserID,
+		Tool:      s.cfg.Tool,
+		Input:     transferInput(sched),
+		RequestID: key,
+	})
+	if err == nil && resp.Success && resp.RequiresConfirmation && resp.Confirmation != nil {
+		resp, err = s.cfg.Executor.Confirm(ctx, sched.UserID, resp.Confirmation.ID)
+	}
+	failure := ""
+	if err != nil {
+		failure = err.Error()
+	} else if !resp.Success {
+		failure = resp.Error
+	}
+	if err != nil || !resp.Success {
+		log.Printf("Scheduled transfer %s failed: %s", key, failure)
+		reason := "the automatic transfer failed: " + failure
+		if _, err := s.confirmation(ctx, sched, occ, now, reason); err != nil {
+			// Keep the claim rather than risk retrying on every tick.
+			return true, "", err
+		}
+		if err := s.cfg.Schedules.UnmarkOccurrence(ctx, sched.ID, occ.Index); err != nil {
+			return true, "", err
+		}
+		return false, reason, nil
+	}
+
+	if recorder, ok := s.cfg.Limits.(transferRecorder); ok {
+		if err := recorder.RecordTransfer(ctx, sched.UserID, key, sched.Amount, sched.Currency); err != nil {
+			log.Printf("Failed to record scheduled transfer %s against limits: %v", key, err)
+		}
+	}
+	return true, "", nil
+}
+
+// confirmation returns the stored confirmation for an occurrence, creating a
+// fresh one if none exists or the previous one expired.
+func (s *Scheduler) confirmation(ctx context.Context, sched *store.ScheduledTransfer, occ Occurrence, now time.Time, reason string) (*core.PendingAction, error) {
+	key := OccurrenceKey(sched.ID, occ.Index)
+
+	existing, err := s.cfg.Confirmations.GetByIdempotency(ctx, sched.UserID, key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	action := &core.PendingAction{
+		ID:             uuid.New().String(),
+		IdempotencyKey: key,
+		UserID:         sched.UserID,
+		Tool:           s.cfg.Tool,
+		Summary:        occurrenceSummary(sched, occ, reason),
+		CreatedAt:      now.Unix(),
+		ExpiresAt:      now.Add(s.cfg.ConfirmationTTL).Unix(),
+	}
+	if err := action.SetInput(transferInput(sched)); err != nil {
+		return nil, err
+	}
+	if err := s.cfg.Confirmations.Store(ctx, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// markCompleted marks a finished schedule as completed once every occurrence is handled.
+func (s *Scheduler) markCompleted(ctx context.Context, sched *store.ScheduledTransfer) error {
+	for n := 0; inSeries(sched, n); n++ {
+		marked, err := s.cfg.Schedules.OccurrenceMarked(ctx, sched.ID, n)
+		if err != nil || !marked {
+			return err
+		}
+	}
+	return s.cfg.Schedules.SetStatus(ctx, sched.UserID, sched.ID, store.ScheduleCompleted)
+}
+
+// transferInput builds the write tool input for one occurrence.
+func transferInput(sched *store.ScheduledTransfer) json.RawMessage {
+	input, _ := json.Marshal(map[string]string{
+		"recipient": sched.Recipient,
+		"amount":    sched.Amount,
+		"currency":  sched.Currency,
+		"note":      sched.Note,
+	})
+	return input
+}
+
+// occurrenceSummary describes an occurrence for its confirmation prompt.
+func occurrenceSummary(sched *store.ScheduledTransfer, occ Occurrence, reason string) string {
+	position := fmt.Sprintf("%d", occ.Index+1)
+	if count := Count(sched); count > 0 {
+		position = fmt.Sprintf("%d of %d", occ.Index+1, count)
+	}
+	summary := fmt.Sprintf("Scheduled transfer %s: send %s %s to %s (due %s)",
+		position, sched.Amount, sched.Currency, sched.Recipient, occ.DueAt.Format("2006-01-02"))
+	if reason != "" {
+		summary += " — needs your approval: " + reason
+	}
+	return summary
+}
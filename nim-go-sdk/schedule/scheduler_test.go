@@ -0,0 +1,406 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/limits"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// fakeExecutor records write calls and the credentials they carry,
+// failing them with fail if set.
+type fakeExecutor struct {
+	writes []*core.ExecuteRequest
+	tokens []string
+	fail   string
+}
+
+func (f *fakeExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	return &core.ExecuteResponse{Success: true}, nil
+}
+
+func (f *fakeExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	f.writes = append(f.writes, req)
+	f.tokens = append(f.tokens, core.CredentialFromContext(ctx))
+	if f.fail != "" {
+		return &core.ExecuteResponse{Success: false, Error: f.fail}, nil
+	}
+	return &core.ExecuteResponse{Success: true}, nil
+}
+
+func (f *fakeExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	return &core.ExecuteResponse{Success: true}, nil
+}
+
+func (f *fakeExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	return nil
+}
+
+// offlineNotifier never reaches the user.
+type offlineNotifier struct {
+	attempts int
+}
+
+func (n *offlineNotifier) NotifyConfirmation(ctx context.Context, userID string, action *core.PendingAction) (bool, error) {
+	n.attempts++
+	return false, nil
+}
+
+// testCredentials gives each user a token of their own.
+func testCredentials(ctx context.Context, userID string) (string, error) {
+	return "token-" + userID, nil
+}
+
+func newTestScheduler(now time.Time, exec core.ToolExecutor, limits Limits, notifier Notifier) (*Scheduler, *store.MemorySchedules, *store.MemoryConfirmations) {
+	schedules := store.NewMemorySchedules()
+	confirmations := store.NewMemoryConfirmations()
+	s := New(Config{
+		Schedules:     schedules,
+		Confirmations: confirmations,
+		Executor:      exec,
+		Limits:        limits,
+		Notifier:      notifier,
+		Credentials:   testCredentials,
+		Now:           func() time.Time { return now },
+	})
+	return s, schedules, confirmations
+}
+
+func TestScheduler_MissedOccurrencesCaughtUpOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	notifier := &offlineNotifier{}
+	s, schedules, _ := newTestScheduler(now, nil, nil, notifier)
+
+	sched := &store.ScheduledTransfer{
+		UserID:         "user-1",
+		Recipient:      "@mom",
+		Amount:         "50",
+		Currency:       "USDC",
+		Cadence:        CadenceDaily,
+		StartDate:      now.AddDate(0, 0, -2),
+		MaxOccurrences: 5,
+	}
+	if err := schedules.Create(ctx, sched); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user is offline: three occurrences are due but none can be delivered.
+	for i := 0; i < 2; i++ {
+		if err := s.Tick(ctx); err != nil {
+			t.Fatalf("Tick() error = %v", err)
+		}
+	}
+	if notifier.attempts != 6 {
+		t.Errorf("notifier attempts = %d, want 6", notifier.attempts)
+	}
+
+	actions, err := s.CatchUp(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("CatchUp() error = %v", err)
+	}
+	if len(actions) != 3 {
+		t.Fatalf("CatchUp() returned %d actions, want 3", len(actions))
+	}
+
+	// Repeated ticks reused the same confirmation for each occurrence.
+	seen := make(map[string]bool)
+	for i, action := range actions {
+		if want := OccurrenceKey(sched.ID, i); action.IdempotencyKey != want {
+			t.Errorf("action %d key = %q, want %q", i, action.IdempotencyKey, want)
+		}
+		seen[action.ID] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("got %d distinct actions, want 3", len(seen))
+	}
+
+	// Delivered occurrences are not presented again.
+	again, err := s.CatchUp(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("CatchUp() error = %v", err)
+	}
+	if len(again) != 0 {
+		t.Errorf("second CatchUp() returned %d actions, want 0", len(again))
+	}
+}
+
+func TestScheduler_CancelMidSeries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	s, schedules, confirmations := newTestScheduler(now, nil, nil, &offlineNotifier{})
+
+	sched := &store.ScheduledTransfer{
+		UserID:    "user-1",
+		Recipient: "@mom",
+		Amount:    "50",
+		Currency:  "USDC",
+		Cadence:   CadenceDaily,
+		StartDate: now.AddDate(0, 0, -1),
+	}
+	if err := schedules.Create(ctx, sched); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, _ := confirmations.GetByIdempotency(ctx, "user-1", OccurrenceKey(sched.ID, 1))
+	if pending == nil {
+		t.Fatal("expected a pending confirmation before cancelling")
+	}
+
+	result, err := CancelTool(s).Execute(ctx, &core.ToolParams{
+		UserID: "user-1",
+		Input:  []byte(`{"schedule_id": "` + sched.ID + `"}`),
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("cancel_scheduled_transfer failed: %v %+v", err, result)
+	}
+
+	if action, _ := confirmations.GetByIdempotency(ctx, "user-1", OccurrenceKey(sched.ID, 1)); action != nil {
+		t.Error("pending confirmation was not withdrawn")
+	}
+	actions, err := s.CatchUp(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 0 {
+		t.Errorf("CatchUp() after cancel returned %d actions, want 0", len(actions))
+	}
+
+	got, _ := schedules.Get(ctx, "user-1", sched.ID)
+	if got.Status != store.ScheduleCancelled {
+		t.Errorf("status = %q, want %q", got.Status, store.ScheduleCancelled)
+	}
+}
+
+func TestScheduler_PreAuthorizedLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		limits       *core.UserLimits
+		wantExecuted int
+		wantSurfaced int
+	}{
+		{
+			name:         "within limits executes",
+			limits:       &core.UserLimits{DailyTransferLimit: "100", DailyTransferUsed: "20", SingleTransferMax: "60"},
+			wantExecuted: 1,
+		},
+		{
+			name:         "over single transfer max is surfaced",
+			limits:       &core.UserLimits{SingleTransferMax: "40"},
+			wantSurfaced: 1,
+		},
+		{
+			name:         "over remaining daily allowance is surfaced",
+			limits:       &core.UserLimits{DailyTransferLimit: "100", DailyTransferUsed: "60"},
+			wantSurfaced: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().Truncate(time.Second)
+			exec := &fakeExecutor{}
+			checker := limits.UserLimitsFunc(func(ctx context.Context, userID string) (*core.UserLimits, error) {
+				return tt.limits, nil
+			})
+			s, schedules, _ := newTestScheduler(now, exec, checker, nil)
+
+			sched := &store.ScheduledTransfer{
+				UserID:         "user-1",
+				Recipient:      "@mom",
+				Amount:         "50",
+				Currency:       "USDC",
+				Cadence:        CadenceMonthly,
+				StartDate:      now.Add(-time.Hour),
+				MaxOccurrences: 3,
+				PreAuthorized:  true,
+			}
+			if err := schedules.Create(ctx, sched); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.Tick(ctx); err != nil {
+				t.Fatal(err)
+			}
+			actions, err := s.CatchUp(ctx, "user-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(exec.writes) != tt.wantExecuted {
+				t.Errorf("executed %d transfers, want %d", len(exec.writes), tt.wantExecuted)
+			}
+			if len(actions) != tt.wantSurfaced {
+				t.Errorf("surfaced %d confirmations, want %d", len(actions), tt.wantSurfaced)
+			}
+
+			// A second tick must not run the occurrence again.
+			if err := s.Tick(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if len(exec.writes) != tt.wantExecuted {
+				t.Errorf("after second tick executed %d transfers, want %d", len(exec.writes), tt.wantExecuted)
+			}
+		})
+	}
+}
+
+func TestScheduler_FailedTransferAsksUser(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	exec := &fakeExecutor{fail: "insufficient funds"}
+	checker := limits.UserLimitsFunc(func(ctx context.Context, userID string) (*core.UserLimits, error) {
+		return &core.UserLimits{}, nil
+	})
+	notifier := &offlineNotifier{}
+	s, schedules, _ := newTestScheduler(now, exec, checker, notifier)
+
+	sched := &store.ScheduledTransfer{
+		UserID:         "user-1",
+		Recipient:      "@mom",
+		Amount:         "50",
+		Currency:       "USDC",
+		Cadence:        CadenceMonthly,
+		StartDate:      now.Add(-time.Hour),
+		MaxOccurrences: 3,
+		PreAuthorized:  true,
+	}
+	if err := schedules.Create(ctx, sched); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(exec.writes) != 1 || notifier.attempts != 1 {
+		t.Fatalf("writes = %d, notifications = %d, want 1 and 1", len(exec.writes), notifier.attempts)
+	}
+	if marked, _ := schedules.OccurrenceMarked(ctx, sched.ID, 0); marked {
+		t.Error("failed occurrence was marked as handled")
+	}
+
+	// The user is asked on their next connect, and the transfer isn't retried.
+	actions, err := s.CatchUp(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || !strings.Contains(actions[0].Summary, "insufficient funds") {
+		t.Fatalf("actions = %+v, want one confirmation giving the failure", actions)
+	}
+	if err := s.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(exec.writes) != 1 {
+		t.Errorf("executed %d transfers, want 1", len(exec.writes))
+	}
+}
+
+func TestScheduler_Credentials(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	exec := &fakeExecutor{}
+	checker := limits.UserLimitsFunc(func(ctx context.Context, userID string) (*core.UserLimits, error) {
+		return &core.UserLimits{}, nil
+	})
+	s, schedules, _ := newTestScheduler(now, exec, checker, nil)
+
+	if err := schedules.Create(ctx, &store.ScheduledTransfer{
+		UserID:        "user-1",
+		Recipient:     "@mom",
+		Amount:        "50",
+		Currency:      "USDC",
+		Cadence:       CadenceMonthly,
+		StartDate:     now.Add(-time.Hour),
+		PreAuthorized: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(exec.tokens) != 1 || exec.tokens[0] != "token-user-1" {
+		t.Errorf("transfers made with credentials %q, want token-user-1", exec.tokens)
+	}
+}
+
+func TestScheduler_NoCredentials(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	exec := &fakeExecutor{}
+	checker := limits.UserLimitsFunc(func(ctx context.Context, userID string) (*core.UserLimits, error) {
+		return &core.UserLimits{}, nil
+	})
+	s, schedules, _ := newTestScheduler(now, exec, checker, nil)
+	s.cfg.Credentials = nil
+
+	if err := schedules.Create(ctx, &store.ScheduledTransfer{
+		UserID:        "user-1",
+		Recipient:     "@mom",
+		Amount:        "50",
+		Currency:      "USDC",
+		Cadence:       CadenceMonthly,
+		StartDate:     now.Add(-time.Hour),
+		PreAuthorized: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Even a connected user's credential isn't used without Credentials.
+	actions, err := s.CatchUp(core.WithCredential(ctx, "token-user-1"), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exec.writes) != 0 {
+		t.Errorf("executed %d transfers, want 0", len(exec.writes))
+	}
+	if len(actions) != 1 {
+		t.Errorf("surfaced %d confirmations, want 1", len(actions))
+	}
+}
+
+func TestScheduler_CredentialsUnavailable(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	exec := &fakeExecutor{}
+	checker := limits.UserLimitsFunc(func(ctx context.Context, userID string) (*core.UserLimits, error) {
+		return &core.UserLimits{}, nil
+	})
+	notifier := &offlineNotifier{}
+	s, schedules, _ := newTestScheduler(now, exec, checker, notifier)
+	s.cfg.Credentials = func(ctx context.Context, userID string) (string, error) {
+		return "", errors.New("token expired")
+	}
+
+	if err := schedules.Create(ctx, &store.ScheduledTransfer{
+		UserID:        "user-1",
+		Recipient:     "@mom",
+		Amount:        "50",
+		Currency:      "USDC",
+		Cadence:       CadenceMonthly,
+		StartDate:     now.Add(-time.Hour),
+		PreAuthorized: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(exec.writes) != 0 {
+		t.Errorf("executed %d transfers, want 0", len(exec.writes))
+	}
+	if notifier.attempts != 1 {
+		t.Errorf("notified %d times, want the occurrence surfaced once", notifier.attempts)
+	}
+}
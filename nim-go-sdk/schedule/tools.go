@@ -0,0 +1,244 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/i18n"
+	"github.com/becomeliminal/nim-go-sdk/store"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// Tools returns the scheduled transfer tools backed by the given scheduler:
+// create_scheduled_transfer, list_scheduled_transfers, and cancel_scheduled_transfer.
+func Tools(s *Scheduler) []core.Tool {
+	return []core.Tool{
+		CreateTool(s),
+		ListTool(s),
+		CancelTool(s),
+	}
+}
+
+// createInput is the input for create_scheduled_transfer.
+type createInput struct {
+	Recipient    string `json:"recipient"`
+	Amount       string `json:"amount"`
+	Currency     string `json:"currency"`
+	Note         string `json:"note"`
+	Cadence      string `json:"cadence"`
+	StartDate    string `json:"start_date"`
+	EndDate      string `json:"end_date"`
+	Occurrences  int    `json:"occurrences"`
+	PreAuthorize bool   `json:"pre_authorize"`
+}
+
+// parseCreateInput validates the input and builds the schedule it describes.
+// today is the user's current date; a start_date before it is rejected, since
+// every backdated occurrence would otherwise fall due at once.
+func parseCreateInput(userID string, raw json.RawMessage, today time.Time) (*store.ScheduledTransfer, error) {
+	var in createInput
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	if in.Recipient == "" || in.Currency == "" {
+		return nil, fmt.Errorf("recipient and currency are required")
+	}
+	amount, ok := new(big.Rat).SetString(in.Amount)
+	if !ok || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("amount must be a positive number")
+	}
+	if !ValidCadence(in.Cadence) {
+		return nil, fmt.Errorf("cadence must be one of %v", Cadences)
+	}
+	if in.Occurrences < 0 {
+		return nil, fmt.Errorf("occurrences must not be negative")
+	}
+
+	start, err := time.Parse("2006-01-02", in.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("start_date must be in YYYY-MM-DD format")
+	}
+	if start.Before(today) {
+		return nil, fmt.Errorf("start_date must not be before today (%s)", today.Format("2006-01-02"))
+	}
+
+	sched := &store.ScheduledTransfer{
+		UserID:         userID,
+		Recipient:      in.Recipient,
+		Amount:         in.Amount,
+		Currency:       in.Currency,
+		Note:           in.Note,
+		Cadence:        in.Cadence,
+		StartDate:      start,
+		MaxOccurrences: in.Occurrences,
+		PreAuthorized:  in.PreAuthorize,
+	}
+
+	if in.EndDate != "" {
+		end, err := time.Parse("2006-01-02", in.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("end_date must be in YYYY-MM-DD format")
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("end_date must not be before start_date")
+		}
+		sched.EndDate = &end
+	}
+	return sched, nil
+}
+
+// dateIn returns the calendar date of t in timezone tz, as midnight UTC to
+// match how start_date is parsed.
+func dateIn(t time.Time, tz string) time.Time {
+	y, m, d := t.In(i18n.Location(tz)).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// createSummary spells out the recurrence and total committed for the confirmation prompt.
+func createSummary(input json.RawMessage, today time.Time) string {
+	sched, err := parseCreateInput("", input, today)
+	if err != nil {
+		return fmt.Sprintf("Schedule a recurring transfer (invalid details: %v)", err)
+	}
+
+	summary := "Schedule a recurring transfer: " + Describe(sched) + "."
+	if sched.PreAuthorized {
+		summary += " Transfers will be sent automatically, within your transfer limits, without asking again."
+	} else {
+		summary += " You'll be asked to confirm each transfer when it's due."
+	}
+	return summary
+}
+
+// summaryTool overrides a tool's template summary with a computed one.
+type summaryTool struct {
+	core.Tool
+	summary func(json.RawMessage) string
+}
+
+// GetSummary implements core.Tool.
+func (t *summaryTool) GetSummary(input json.RawMessage) string {
+	return t.summary(input)
+}
+
+// CreateTool returns the create_scheduled_transfer tool.
+// Creating a schedule requires confirmation; the summary states the cadence,
+// horizon, and total amount committed.
+func CreateTool(s *Scheduler) core.Tool {
+	tool := tools.New("create_scheduled_transfer").
+		Description("Set up a recurring transfer (standing order), e.g. 'send mom $50 every month'. Requires confirmation. Each transfer asks for confirmation when due unless the user explicitly asks for it to be sent automatically.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"recipient":     tools.StringProperty("Recipient's display tag (e.g., @alice) or user ID"),
+			"amount":        tools.StringProperty("Amount per transfer (e.g., '50.00')"),
+			"currency":      tools.StringProperty("Currency to send (e.g., 'USD', 'EUR', 'LIL')"),
+			"note":          tools.StringProperty("Optional payment note"),
+			"cadence":       tools.StringEnumProperty("How often to send", Cadences...),
+			"start_date":    tools.StringProperty("Date of the first transfer (YYYY-MM-DD)"),
+			"end_date":      tools.StringProperty("Optional: last date a transfer may be sent (YYYY-MM-DD)"),
+			"occurrences":   tools.IntegerProperty("Optional: total number of transfers"),
+			"pre_authorize": tools.BooleanProperty("Send each transfer automatically without asking. Only set when the user explicitly requests it."),
+		}, "recipient", "amount", "currency", "cadence", "start_date")).
+		RequiresConfirmation().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			today := dateIn(s.cfg.Now(), core.PreferencesFor(ctx, params).Timezone)
+			sched, err := parseCreateInput(params.UserID, params.Input, today)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+			if err := s.cfg.Schedules.Create(ctx, sched); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to save schedule: %v", err)}, nil
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"schedule_id": sched.ID,
+					"message":     "Scheduled " + Describe(sched) + ".",
+				},
+			}, nil
+		}).
+		Build()
+
+	// The summary has no user context, so it accepts any date that is still
+	// today somewhere; the handler checks against the user's own timezone.
+	summary := func(input json.RawMessage) string {
+		return createSummary(input, dateIn(s.cfg.Now(), "Etc/GMT+12"))
+	}
+	return &summaryTool{Tool: tool, summary: summary}
+}
+
+// ListTool returns the list_scheduled_transfers tool.
+func ListTool(s *Scheduler) core.Tool {
+	return tools.New("list_scheduled_transfers").
+		Description("List the user's recurring transfers with their next due date.").
+		Schema(tools.ObjectSchema(map[string]interface{}{})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			schedules, err := s.cfg.Schedules.List(ctx, params.UserID)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("failed to list schedules: %v", err)}, nil
+			}
+
+			now := s.cfg.Now()
+			items := make([]map[string]interface{}, 0, len(schedules))
+			for _, sched := range schedules {
+				item := map[string]interface{}{
+					"schedule_id":    sched.ID,
+					"recipient":      sched.Recipient,
+					"amount":         sched.Amount,
+					"currency":       sched.Currency,
+					"cadence":        sched.Cadence,
+					"status":         sched.Status,
+					"pre_authorized": sched.PreAuthorized,
+					"description":    Describe(sched),
+				}
+				if next, ok := Next(sched, now); ok && sched.Status == store.ScheduleActive {
+					item["next_due"] = next.DueAt.Format("2006-01-02")
+				}
+				items = append(items, item)
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"schedules": items,
+					"count":     len(items),
+				},
+			}, nil
+		}).
+		Build()
+}
+
+// CancelTool returns the cancel_scheduled_transfer tool.
+// Cancelling stops future transfers and withdraws any awaiting confirmation.
+func CancelTool(s *Scheduler) core.Tool {
+	return tools.New("cancel_scheduled_transfer").
+		Description("Cancel a recurring transfer. Future transfers in the series will not be sent.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"schedule_id": tools.StringProperty("ID of the schedule to cancel (from list_scheduled_transfers)"),
+		}, "schedule_id")).
+		NoCache().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var in struct {
+				ScheduleID string `json:"schedule_id"`
+			}
+			if err := json.Unmarshal(params.Input, &in); err != nil || in.ScheduleID == "" {
+				return &core.ToolResult{Success: false, Error: "schedule_id is required"}, nil
+			}
+
+			if err := s.Cancel(ctx, params.UserID, in.ScheduleID); err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"schedule_id": in.ScheduleID,
+					"message":     "Scheduled transfer cancelled.",
+				},
+			}, nil
+		}).
+		Build()
+}
@@ -0,0 +1,46 @@
+package schedule
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseCreateInput_StartDate(t *testing.T) {
+	today := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		start   string
+		wantErr bool
+	}{
+		{"2026-03-15", false},
+		{"2026-04-01", false},
+		{"2026-03-14", true},
+		{"2025-03-15", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.start, func(t *testing.T) {
+			input, _ := json.Marshal(map[string]string{
+				"recipient":  "@mom",
+				"amount":     "50",
+				"currency":   "USDC",
+				"cadence":    CadenceMonthly,
+				"start_date": tt.start,
+			})
+			_, err := parseCreateInput("user-1", input, today)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseCreateInput(start_date %s) error = %v, wantErr %v", tt.start, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDateIn(t *testing.T) {
+	// 02:00 UTC on March 15 is still March 14 in New York.
+	now := time.Date(2026, 3, 15, 2, 0, 0, 0, time.UTC)
+	if got := dateIn(now, "America/New_York").Format("2006-01-02"); got != "2026-03-14" {
+		t.Errorf("dateIn(New York) = %s, want 2026-03-14", got)
+	}
+	if got := dateIn(now, "").Format("2006-01-02"); got != "2026-03-15" {
+		t.Errorf("dateIn(UTC) = %s, want 2026-03-15", got)
+	}
+}
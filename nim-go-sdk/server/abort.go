@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// abortedNote is recorded as the assistant's reply to a message whose run
+// the user aborted, and sent with "aborted".
+const abortedNote = "(Response interrupted: the user stopped this reply before it finished.)"
+
+// maxQueuedFrames caps how many frames are read ahead of the one being
+// handled while watching for an "abort".
+const maxQueuedFrames = 16
+
+// activeRun is the agent run a connection is handling, if any, so an
+// "abort" can stop it.
+type activeRun struct {
+	mu     sync.Mutex
+	cancel context.CancelCauseFunc
+}
+
+// start makes ctx's run the connection's active run until the returned
+// func is called.
+func (r *activeRun) start(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	r.mu.Lock()
+	r.cancel = cancel
+	r.mu.Unlock()
+	return ctx, func() {
+		r.mu.Lock()
+		r.cancel = nil
+		r.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// abort cancels the active run with engine.ErrAborted. It reports whether
+// there was one.
+func (r *activeRun) abort() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel == nil {
+		return false
+	}
+	r.cancel(engine.ErrAborted)
+	return true
+}
+
+// interceptAborts moves frames to out, in order, until frames is closed,
+// then closes out. Frames are handled one at a time, so an "abort" would
+// otherwise wait behind the run it's meant to stop: instead abort is
+// called for it as soon as it arrives, and it isn't passed on.
+func interceptAborts(frames <-chan inbound, out chan<- inbound, abort func(ClientMessage)) {
+	defer close(out)
+	var queue []inbound
+	in := frames
+	for in != nil || len(queue) > 0 {
+		// Stop reading while the queue is full, and only send what's queued.
+		recv, send := in, chan<- inbound(nil)
+		if len(queue) >= maxQueuedFrames {
+			recv = nil
+		}
+		var next inbound
+		if len(queue) > 0 {
+			send, next = out, queue[0]
+		}
+
+		select {
+		case frame, ok := <-recv:
+			if !ok {
+				in = nil
+				continue
+			}
+			if frame.err == nil && frame.msg.Type == "abort" {
+				abort(frame.msg)
+				continue
+			}
+			queue = append(queue, frame)
+		case send <- next:
+			queue = queue[1:]
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAbort(t *testing.T) {
+	s, addr, started, release := startSlowServer(t)
+	c := dialAddr(t, addr)
+
+	// With nothing running, an abort is only acknowledged.
+	c.send(ClientMessage{Type: "abort", ID: "a-1"})
+	checkReplies(t, []ServerMessage{c.read("message_ack")}, "a-1", "message_ack")
+
+	c.send(ClientMessage{Type: "new_conversation"})
+	conversationID := c.read("conversation_started").ConversationID
+	c.send(ClientMessage{Type: "message", Content: "what's my balance?", ID: "m-1"})
+	<-started
+
+	begin := time.Now()
+	c.send(ClientMessage{Type: "abort", ID: "a-2"})
+	var acked bool
+	var aborted ServerMessage
+	for aborted.Type == "" {
+		var msg ServerMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("waiting for aborted: %v", err)
+		}
+		switch msg.Type {
+		case "message_ack":
+			acked = acked || msg.ReplyTo == "a-2"
+		case "aborted":
+			aborted = msg
+		case "complete", "error":
+			t.Fatalf("aborted run ended with %+v", msg)
+		}
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("run ended %v after the abort, want promptly", elapsed)
+	}
+	if !acked || aborted.ReplyTo != "m-1" || aborted.Content != abortedNote || aborted.TokenUsage == nil || aborted.TokenUsage.TotalTokens == 0 {
+		t.Errorf("abort acked %v, then %+v, want an aborted reply to m-1 with the tokens used", acked, aborted)
+	}
+
+	// The conversation records the interruption, and carries on.
+	conv, err := s.conversations.Get(context.Background(), conversationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(conv.Messages); n != 2 || conv.Messages[0].Content != "what's my balance?" || conv.Messages[1].Content != abortedNote {
+		t.Errorf("conversation = %+v, want the message and the interruption", conv.Messages)
+	}
+	close(release)
+	c.send(ClientMessage{Type: "message", Content: "never mind", ID: "m-2"})
+	checkReplies(t, c.readUntil("complete"), "m-2", "message_ack", "text", "conversation_renamed", "complete")
+}
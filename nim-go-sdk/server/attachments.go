@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// defaultMaxAttachmentBytes is the default Config.MaxAttachmentBytes, the
+// largest image the model accepts.
+const defaultMaxAttachmentBytes = 5 << 20
+
+// maxAttachments is the most images a message can carry.
+const maxAttachments = 5
+
+// attachmentTypes are the image types the model accepts.
+var attachmentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// attachmentBlocks converts a message's attachments to the image blocks
+// sent to the model, and the refs the conversation stores in their place.
+// The refs are ephemeral: the image itself is never stored.
+func (s *Server) attachmentBlocks(attachments []Attachment) ([]core.ContentBlock, []artifact.Ref, error) {
+	if len(attachments) == 0 {
+		return nil, nil, nil
+	}
+	if len(attachments) > maxAttachments {
+		return nil, nil, fmt.Errorf("a message can have at most %d attachments", maxAttachments)
+	}
+	limit := s.config.MaxAttachmentBytes
+	if limit <= 0 {
+		limit = defaultMaxAttachmentBytes
+	}
+
+	blocks := make([]core.ContentBlock, 0, len(attachments))
+	refs := make([]artifact.Ref, 0, len(attachments))
+	for i, a := range attachments {
+		n := i + 1
+		if a.Type != "image" {
+			return nil, nil, fmt.Errorf("attachment %d: unsupported type %q; only images can be attached", n, a.Type)
+		}
+		ref := artifact.Ref{
+			ID:        uuid.New().String(),
+			Kind:      artifact.KindAttachment,
+			Name:      a.Name,
+			MediaType: a.MediaType,
+			CreatedAt: time.Now(),
+			Ephemeral: true,
+		}
+
+		switch {
+		case a.Data != "" && a.URL != "":
+			return nil, nil, fmt.Errorf("attachment %d: set data or url, not both", n)
+		case a.URL != "":
+			u, err := url.Parse(a.URL)
+			if err != nil || u.Scheme != "https" || u.Host == "" {
+				return nil, nil, fmt.Errorf("attachment %d: url must be an https URL", n)
+			}
+			blocks = append(blocks, core.NewImageURLBlock(a.URL))
+		case a.Data != "":
+			if !attachmentTypes[a.MediaType] {
+				return nil, nil, fmt.Errorf("attachment %d: unsupported media type %q; use image/png, image/jpeg, image/gif or image/webp", n, a.MediaType)
+			}
+			// Decoded size is known from the encoded length, so oversized
+			// images are refused before decoding them.
+			if base64.StdEncoding.DecodedLen(len(a.Data)) > limit+2 {
+				return nil, nil, fmt.Errorf("attachment %d is over the %d byte limit", n, limit)
+			}
+			data, err := base64.StdEncoding.DecodeString(a.Data)
+			if err != nil {
+				return nil, nil, fmt.Errorf("attachment %d: data is not valid base64", n)
+			}
+			if len(data) > limit {
+				return nil, nil, fmt.Errorf("attachment %d is over the %d byte limit", n, limit)
+			}
+			if detected := http.DetectContentType(data); detected != a.MediaType {
+				return nil, nil, fmt.Errorf("attachment %d: data is %s, not %s", n, detected, a.MediaType)
+			}
+			ref.Size = int64(len(data))
+			blocks = append(blocks, core.NewImageBlock(a.MediaType, a.Data))
+		default:
+			return nil, nil, fmt.Errorf("attachment %d: data or url is required", n)
+		}
+		refs = append(refs, ref)
+	}
+	return blocks, refs, nil
+}
+
+// userMessage is the history entry for a user's message and its images.
+func userMessage(text string, images []core.ContentBlock) core.Message {
+	if len(images) == 0 {
+		return core.NewUserMessage(text)
+	}
+	blocks := append([]core.ContentBlock(nil), images...)
+	if text != "" {
+		blocks = append(blocks, core.NewTextBlock(text))
+	}
+	return core.Message{Role: core.RoleUser, ContentBlocks: blocks}
+}
+
+// attachmentNote stands in for the images of a resumed conversation, which
+// weren't stored, so the model knows the user sent them.
+func attachmentNote(refs []artifact.Ref) string {
+	var notes []string
+	for _, ref := range refs {
+		if ref.Kind != artifact.KindAttachment {
+			continue
+		}
+		name := ref.Name
+		if name == "" {
+			name = "an image"
+		}
+		notes = append(notes, fmt.Sprintf("[The user attached %s here; it is no longer available.]", name))
+	}
+	return strings.Join(notes, "\n")
+}
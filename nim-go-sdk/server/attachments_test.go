@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+)
+
+// requestModel records the requests it is sent and always replies "OK.".
+type requestModel struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (m *requestModel) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		m.mu.Lock()
+		m.requests = append(m.requests, string(body))
+		m.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":[{"type":"text","text":"OK."}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// lastUserContent returns the content blocks of the last user message in
+// the latest request.
+func (m *requestModel) lastUserContent(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.requests) == 0 {
+		t.Fatal("the model was never called")
+	}
+	var req struct {
+		Messages []struct {
+			Role    string                   `json:"role"`
+			Content []map[string]interface{} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(m.requests[len(m.requests)-1]), &req); err != nil {
+		t.Fatal(err)
+	}
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role != "user" {
+		t.Fatalf("last message is from %s", last.Role)
+	}
+	return last.Content
+}
+
+// pngData returns a small PNG, base64-encoded.
+func pngData(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestAttachments(t *testing.T) {
+	model := &requestModel{}
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          model.serve(t).URL,
+		DisableStreaming: true,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := pngData(t)
+
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	conversationID := c.read("conversation_started").ConversationID
+	c.send(ClientMessage{Type: "message", Content: "split this receipt with alice", Attachments: []Attachment{
+		{Type: "image", MediaType: "image/png", Data: receipt, Name: "receipt.png"},
+	}})
+	c.read("complete")
+
+	content := model.lastUserContent(t)
+	if len(content) != 2 {
+		t.Fatalf("user content = %v, want an image then text", content)
+	}
+	source, _ := content[0]["source"].(map[string]interface{})
+	if content[0]["type"] != "image" || source["type"] != "base64" || source["media_type"] != "image/png" || source["data"] != receipt {
+		t.Errorf("first block = %v, want the PNG as a base64 image", content[0])
+	}
+	if content[1]["type"] != "text" || content[1]["text"] != "split this receipt with alice" {
+		t.Errorf("second block = %v, want the message text", content[1])
+	}
+
+	// The conversation stores a reference, not the image.
+	conv, err := s.conversations.Get(context.Background(), conversationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := json.Marshal(conv.Messages)
+	if strings.Contains(string(stored), receipt) {
+		t.Error("the conversation store holds the image data")
+	}
+	refs := conv.Messages[0].Artifacts
+	if len(refs) != 1 || refs[0].Kind != artifact.KindAttachment || refs[0].Name != "receipt.png" || refs[0].MediaType != "image/png" || !refs[0].Ephemeral || refs[0].Size == 0 {
+		t.Errorf("stored refs = %+v, want one ephemeral image/png attachment", refs)
+	}
+
+	// Resumed, the model is told an image was sent, without it.
+	c = dial(t, s)
+	c.send(ClientMessage{Type: "resume_conversation", ConversationID: conversationID})
+	c.read("conversation_resumed")
+	c.send(ClientMessage{Type: "message", Content: "thanks"})
+	c.read("complete")
+	model.mu.Lock()
+	resumed := model.requests[len(model.requests)-1]
+	model.mu.Unlock()
+	if strings.Contains(resumed, receipt) || !strings.Contains(resumed, "attached receipt.png here") {
+		t.Errorf("resumed request should note the image without resending it: %s", resumed)
+	}
+}
+
+func TestAttachments_Invalid(t *testing.T) {
+	receipt := pngData(t)
+	tests := []struct {
+		name        string
+		attachments []Attachment
+		want        string
+	}{
+		{"not an image", []Attachment{{Type: "file", MediaType: "application/pdf", Data: receipt}}, `unsupported type "file"`},
+		{"unsupported media type", []Attachment{{Type: "image", MediaType: "image/bmp", Data: receipt}}, `unsupported media type "image/bmp"`},
+		{"wrong media type", []Attachment{{Type: "image", MediaType: "image/jpeg", Data: receipt}}, "data is image/png, not image/jpeg"},
+		{"not base64", []Attachment{{Type: "image", MediaType: "image/png", Data: "not base64!"}}, "not valid base64"},
+		{"too large", []Attachment{{Type: "image", MediaType: "image/png", Data: base64.StdEncoding.EncodeToString(make([]byte, 2048))}}, "over the 1024 byte limit"},
+		{"plain http", []Attachment{{Type: "image", URL: "http://example.com/receipt.png"}}, "https URL"},
+		{"no image", []Attachment{{Type: "image"}}, "data or url is required"},
+		{"too many", make([]Attachment, maxAttachments+1), "at most 5 attachments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &requestModel{}
+			s, err := New(Config{
+				AnthropicKey:       "test",
+				BaseURL:            model.serve(t).URL,
+				DisableStreaming:   true,
+				MaxAttachmentBytes: 1024,
+				AuthFunc:           func(r *http.Request) (string, error) { return "user-1", nil },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := dial(t, s)
+			c.send(ClientMessage{Type: "new_conversation"})
+			c.read("conversation_started")
+			c.send(ClientMessage{Type: "message", Content: "split this", Attachments: tt.attachments})
+
+			msg := c.read("error")
+			if msg.Code != "invalid_attachment" || !strings.Contains(msg.Content, tt.want) {
+				t.Errorf("error = %s %q, want invalid_attachment mentioning %q", msg.Code, msg.Content, tt.want)
+			}
+			if len(model.requests) != 0 {
+				t.Error("the model was called with an invalid attachment")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import "sync"
+
+// busyContent is sent with a "busy" rejection.
+const busyContent = "Still working on your last message. Please wait for it to finish."
+
+// conversationRuns tracks the conversations with a run in flight, so a
+// conversation open on several connections, or over REST as well, is only
+// run one message at a time. Each connection already handles its own
+// messages in order; this guards against the others.
+type conversationRuns struct {
+	mu       sync.Mutex
+	inFlight map[string]string // conversationID -> ID of the message being handled
+}
+
+func newConversationRuns() *conversationRuns {
+	return &conversationRuns{inFlight: make(map[string]string)}
+}
+
+// begin claims conversationID for the message with ID replyTo, which may
+// be empty. If another run holds it, begin returns false and that run's
+// message ID.
+func (r *conversationRuns) begin(conversationID, replyTo string) (inFlight string, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id, busy := r.inFlight[conversationID]; busy {
+		return id, false
+	}
+	r.inFlight[conversationID] = replyTo
+	return "", true
+}
+
+func (r *conversationRuns) end(conversationID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.inFlight, conversationID)
+}
+
+// runsConversation reports whether a client message of type msgType runs
+// in its session's conversation, and so must not overlap another run.
+func runsConversation(msgType string) bool {
+	switch msgType {
+	case "message", "confirm", "confirm_with_edits", "cancel":
+		return true
+	}
+	return false
+}
+
+// claimConversation claims sess's conversation for a run replying to
+// replyTo, telling conn if it's busy. The returned func releases it.
+func (s *Server) claimConversation(conn peer, sess *session, replyTo string) (release func(), ok bool) {
+	if sess.ConversationID == "" {
+		return func() {}, true
+	}
+	inFlight, ok := s.running.begin(sess.ConversationID, replyTo)
+	if !ok {
+		s.send(conn, ServerMessage{Type: "busy", Code: "busy", Content: busyContent, ConversationID: sess.ConversationID, InFlight: inFlight, ReplyTo: replyTo})
+		return nil, false
+	}
+	return func() { s.running.end(sess.ConversationID) }, true
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// startSlowServer runs a server until the test ends, with a get_balance
+// tool that signals started on its first call and then waits for release,
+// or for its run to be cancelled.
+func startSlowServer(t *testing.T) (s *Server, addr string, started, release chan struct{}) {
+	t.Helper()
+	s = newShutdownServer(t, Config{})
+	started, release = make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	s.AddTool(core.NewBaseTool(core.ToolDefinition{ToolName: "get_balance"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			once.Do(func() { close(started) })
+			select {
+			case <-release:
+				return &core.ToolResult{Success: true, Data: "50.25 USDC"}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go s.serve(ctx, ln)
+	return s, ln.Addr().String(), started, release
+}
+
+func TestConversationBusy(t *testing.T) {
+	_, addr, started, release := startSlowServer(t)
+
+	a := dialAddr(t, addr)
+	a.send(ClientMessage{Type: "new_conversation"})
+	conversationID := a.read("conversation_started").ConversationID
+	a.send(ClientMessage{Type: "message", Content: "what's my balance?", ID: "m-1"})
+	<-started
+
+	// The conversation is open in another tab while the run is in flight.
+	b := dialAddr(t, addr)
+	b.send(ClientMessage{Type: "resume_conversation", ConversationID: conversationID})
+	b.read("conversation_resumed")
+	b.send(ClientMessage{Type: "message", Content: "and my savings?", ID: "m-2"})
+	busy := b.read("busy")
+	if busy.Code != "busy" || busy.InFlight != "m-1" || busy.ReplyTo != "m-2" || busy.ConversationID != conversationID {
+		t.Errorf("busy = %+v, want m-2 rejected while m-1 runs", busy)
+	}
+
+	body, _ := json.Marshal(ChatRequest{ConversationID: conversationID, Message: "and my savings?"})
+	resp, err := http.Post("http://"+addr+"/v1/chat", "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chat ChatResponse
+	json.NewDecoder(resp.Body).Decode(&chat)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict || chat.Code != "busy" {
+		t.Errorf("POST /v1/chat = %d %+v, want 409 busy", resp.StatusCode, chat)
+	}
+
+	// The run in flight finishes undisturbed, then the conversation is free.
+	close(release)
+	for _, msg := range a.readUntil("complete") {
+		if msg.Type == "busy" || msg.ReplyTo != "m-1" {
+			t.Errorf("first run sent %+v", msg)
+		}
+	}
+	b.send(ClientMessage{Type: "message", Content: "and my savings?", ID: "m-3"})
+	checkReplies(t, b.readUntil("complete"), "m-3", "message_ack", "text", "complete")
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// defaultMaxWaitWindows is the default cap on how long coalescing can delay
+// a run, in coalesce windows.
+const defaultMaxWaitWindows = 3
+
+// inbound is a frame read from the client.
+type inbound struct {
+	msg ClientMessage
+	err error // set if the frame was not a valid ClientMessage
+}
+
+// readFrames reads client frames into frames until the connection closes.
+// A client that sends nothing, not even a pong, for idle is disconnected.
+// The deadline is reset before each read, so time spent handling a frame
+// doesn't count.
+func readFrames(conn *websocket.Conn, frames chan<- inbound, idle time.Duration) {
+	defer close(frames)
+	for {
+		conn.SetReadDeadline(time.Now().Add(idle))
+		_, msgBytes, err := conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				log.Printf("WebSocket idle for %s, closing", idle)
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "idle timeout"),
+					time.Now().Add(closeWriteTimeout))
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("WebSocket error: %v", err)
+			}
+			return
+		}
+
+		var in inbound
+		in.err = json.Unmarshal(msgBytes, &in.msg)
+		frames <- in
+	}
+}
+
+// coalescer merges rapid-fire "message" frames ("can you", "send 20",
+// "to alice") into a single run, so the agent doesn't answer the first
+// fragment before the rest arrive.
+//
+// After a message frame, further message frames are absorbed while each
+// arrives within window of the previous one, up to maxWait after the first.
+// A fragment ending in a strong terminator (".", "?" or "!") or any other
+// frame type ends the wait early. Frames are only read between runs, so a
+// fragment arriving once a run has started is never merged into it; it
+// starts the next coalescing window when the run finishes.
+type coalescer struct {
+	window  time.Duration // zero disables coalescing
+	maxWait time.Duration
+
+	// ack is called for each absorbed fragment, so the client can show it
+	// as delivered before the run starts.
+	ack func(fragment ClientMessage)
+}
+
+// run dispatches frames to handle in order, merging message frames, until
+// frames is closed. handle is never called concurrently.
+func (c *coalescer) run(frames <-chan inbound, handle func(inbound)) {
+	var pending *inbound
+	for {
+		in := pending
+		pending = nil
+		if in == nil {
+			next, ok := <-frames
+			if !ok {
+				return
+			}
+			in = &next
+		}
+
+		if !isMessage(*in) || !c.coalesces(in.msg) {
+			handle(*in)
+			continue
+		}
+
+		merged, next, open := c.collect(in.msg, frames)
+		handle(merged)
+		if !open {
+			return
+		}
+		pending = next
+	}
+}
+
+// coalesces reports whether msg is merged with the messages after it, and
+// so was acknowledged on arrival.
+func (c *coalescer) coalesces(msg ClientMessage) bool {
+	return c.window > 0 && msg.Type == "message"
+}
+
+// collect absorbs message frames following first. It returns the merged
+// message, with the fragments' attachments in order, the frame that ended the wait if it must still be handled, and
+// whether frames is still open.
+func (c *coalescer) collect(first ClientMessage, frames <-chan inbound) (inbound, *inbound, bool) {
+	var fragments []string
+	var attachments []Attachment
+	absorb := func(msg ClientMessage) {
+		if msg.Content != "" {
+			fragments = append(fragments, msg.Content)
+		}
+		attachments = append(attachments, msg.Attachments...)
+		if c.ack != nil {
+			c.ack(msg)
+		}
+	}
+	merged := func() inbound {
+		msg := first
+		msg.Content = strings.Join(fragments, "\n")
+		msg.Attachments = attachments
+		return inbound{msg: msg}
+	}
+
+	last := first.Content
+	absorb(first)
+	maxWait := c.maxWait
+	if maxWait <= 0 {
+		maxWait = defaultMaxWaitWindows * c.window
+	}
+	deadline := time.Now().Add(maxWait)
+
+	for !terminated(last) {
+		wait := c.window
+		if remaining := time.Until(deadline); remaining < wait {
+			wait = remaining
+		}
+		if wait <= 0 {
+			break
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case in, ok := <-frames:
+			timer.Stop()
+			if !ok {
+				return merged(), nil, false
+			}
+			if !isMessage(in) {
+				return merged(), &in, true
+			}
+			absorb(in.msg)
+			last = in.msg.Content
+		case <-timer.C:
+			return merged(), nil, true
+		}
+	}
+	return merged(), nil, true
+}
+
+func isMessage(in inbound) bool {
+	return in.err == nil && in.msg.Type == "message"
+}
+
+// terminated reports whether a fragment ends in a strong terminator,
+// meaning the user has likely finished. A trailing ellipsis doesn't count.
+func terminated(fragment string) bool {
+	fragment = strings.TrimSpace(fragment)
+	if strings.HasSuffix(fragment, "..") || strings.HasSuffix(fragment, "…") {
+		return false
+	}
+	return strings.HasSuffix(fragment, ".") || strings.HasSuffix(fragment, "?") || strings.HasSuffix(fragment, "!")
+}
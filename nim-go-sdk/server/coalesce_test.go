@@ -0,0 +1,217 @@
+package server
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// step is a scripted client frame, sent at an offset from the start.
+type step struct {
+	at  time.Duration
+	msg ClientMessage
+}
+
+func message(at time.Duration, content string) step {
+	return step{at: at, msg: ClientMessage{Type: "message", Content: content}}
+}
+
+// script sends steps into a frames channel at their offsets, then closes it.
+func script(steps []step) <-chan inbound {
+	frames := make(chan inbound)
+	go func() {
+		defer close(frames)
+		start := time.Now()
+		for _, s := range steps {
+			time.Sleep(time.Until(start.Add(s.at)))
+			frames <- inbound{msg: s.msg}
+		}
+	}()
+	return frames
+}
+
+// handled is a frame passed to the handler and when it arrived.
+type handled struct {
+	msg ClientMessage
+	at  time.Duration
+}
+
+// runScript runs a coalescer over steps, handling each frame with turn, and
+// returns the handled frames and acknowledged fragments.
+func runScript(c *coalescer, steps []step, turn time.Duration) ([]handled, []string) {
+	var mu sync.Mutex
+	var acks []string
+	c.ack = func(fragment ClientMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		acks = append(acks, fragment.Content)
+	}
+
+	var got []handled
+	start := time.Now()
+	c.run(script(steps), func(in inbound) {
+		got = append(got, handled{msg: in.msg, at: time.Since(start)})
+		time.Sleep(turn)
+	})
+	return got, acks
+}
+
+func contents(got []handled) []string {
+	var out []string
+	for _, h := range got {
+		out = append(out, h.msg.Content)
+	}
+	return out
+}
+
+func TestCoalescer(t *testing.T) {
+	const window = 60 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		maxWait  time.Duration
+		window   time.Duration
+		steps    []step
+		turn     time.Duration
+		want     []string
+		wantAcks int
+	}{
+		{
+			name:   "fragments merged",
+			window: window,
+			steps: []step{
+				message(0, "can you"),
+				message(20*time.Millisecond, "send 20"),
+				message(40*time.Millisecond, "to alice"),
+			},
+			want:     []string{"can you\nsend 20\nto alice"},
+			wantAcks: 3,
+		},
+		{
+			name:   "pause longer than window",
+			window: window,
+			steps: []step{
+				message(0, "can you"),
+				message(20*time.Millisecond, "send 20"),
+				message(200*time.Millisecond, "to alice"),
+			},
+			want:     []string{"can you\nsend 20", "to alice"},
+			wantAcks: 3,
+		},
+		{
+			name:   "terminator fires early",
+			window: window,
+			steps: []step{
+				message(0, "send 20 to alice."),
+				message(30*time.Millisecond, "thanks!"),
+			},
+			want:     []string{"send 20 to alice.", "thanks!"},
+			wantAcks: 2,
+		},
+		{
+			name:   "ellipsis keeps waiting",
+			window: window,
+			steps: []step{
+				message(0, "so..."),
+				message(30*time.Millisecond, "what's my balance?"),
+			},
+			want:     []string{"so...\nwhat's my balance?"},
+			wantAcks: 2,
+		},
+		{
+			name:   "other frames flush and keep their order",
+			window: window,
+			steps: []step{
+				message(0, "yes"),
+				{at: 10 * time.Millisecond, msg: ClientMessage{Type: "confirm", ActionID: "a-1"}},
+				message(20*time.Millisecond, "and"),
+				message(30*time.Millisecond, "thanks"),
+			},
+			want:     []string{"yes", "", "and\nthanks"},
+			wantAcks: 3,
+		},
+		{
+			name:   "fragments during a running turn start the next run",
+			window: window,
+			turn:   150 * time.Millisecond,
+			steps: []step{
+				message(0, "send 20 to alice."),
+				message(40*time.Millisecond, "actually"),
+				message(80*time.Millisecond, "make it 30"),
+			},
+			want:     []string{"send 20 to alice.", "actually\nmake it 30"},
+			wantAcks: 3,
+		},
+		{
+			name:   "disabled",
+			window: 0,
+			steps: []step{
+				message(0, "can you"),
+				message(10*time.Millisecond, "send 20"),
+			},
+			want: []string{"can you", "send 20"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, acks := runScript(&coalescer{window: tt.window, maxWait: tt.maxWait}, tt.steps, tt.turn)
+			if !reflect.DeepEqual(contents(got), tt.want) {
+				t.Errorf("runs = %q, want %q", contents(got), tt.want)
+			}
+			if len(acks) != tt.wantAcks {
+				t.Errorf("acked %q, want %d acks", acks, tt.wantAcks)
+			}
+		})
+	}
+}
+
+func TestCoalescer_TerminatorDoesNotWait(t *testing.T) {
+	got, _ := runScript(&coalescer{window: time.Second}, []step{message(0, "what's my balance?")}, 0)
+	if len(got) != 1 || got[0].at > 200*time.Millisecond {
+		t.Errorf("handled %+v, want the terminated message immediately", got)
+	}
+}
+
+func TestCoalescer_MaxWait(t *testing.T) {
+	// A fragment every 30ms never leaves a 60ms gap, so only the cap
+	// starts the run.
+	var steps []step
+	for i := 0; i < 12; i++ {
+		steps = append(steps, message(time.Duration(i)*30*time.Millisecond, string(rune('a'+i))))
+	}
+
+	got, acks := runScript(&coalescer{window: 60 * time.Millisecond, maxWait: 150 * time.Millisecond}, steps, 0)
+
+	if len(got) < 2 {
+		t.Fatalf("runs = %q, want the cap to split a continuous typer", contents(got))
+	}
+	if got[0].at > 250*time.Millisecond {
+		t.Errorf("first run started after %v, want about the 150ms cap", got[0].at)
+	}
+	if joined := strings.ReplaceAll(strings.Join(contents(got), ""), "\n", ""); joined != "abcdefghijkl" {
+		t.Errorf("runs = %q, want every fragment exactly once, in order", contents(got))
+	}
+	if len(acks) != len(steps) {
+		t.Errorf("acked %d fragments, want %d", len(acks), len(steps))
+	}
+}
+
+func TestCoalescer_InvalidFrame(t *testing.T) {
+	frames := make(chan inbound, 3)
+	frames <- inbound{msg: ClientMessage{Type: "message", Content: "hi"}}
+	frames <- inbound{err: errors.New("bad json")}
+	frames <- inbound{msg: ClientMessage{Type: "message", Content: "there"}}
+	close(frames)
+
+	var got []inbound
+	c := &coalescer{window: time.Second}
+	c.run(frames, func(in inbound) { got = append(got, in) })
+
+	if len(got) != 3 || got[0].msg.Content != "hi" || got[1].err == nil || got[2].msg.Content != "there" {
+		t.Errorf("handled %+v, want hi, the invalid frame, then there", got)
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/features"
+)
+
+// addPending records an action offered to the user in this session.
+// Offering an action again doesn't add it twice.
+func (sess *session) addPending(actionID string) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if !slices.Contains(sess.Pending, actionID) {
+		sess.Pending = append(sess.Pending, actionID)
+	}
+}
+
+// removePending forgets an action once it is confirmed or cancelled.
+func (sess *session) removePending(actionID string) {
+	sess.expiries.stop(actionID)
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	for i, id := range sess.Pending {
+		if id == actionID {
+			sess.Pending = append(sess.Pending[:i], sess.Pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// outstandingAction returns the session's only pending action, dropping
+// actions that have expired or were settled elsewhere. It returns nil when
+// none or several are outstanding: a reply can't say which one it means.
+func (s *Server) outstandingAction(ctx context.Context, sess *session) *core.PendingAction {
+	sess.mu.Lock()
+	pending := slices.Clone(sess.Pending)
+	sess.mu.Unlock()
+
+	var outstanding []*core.PendingAction
+	for _, id := range pending {
+		action, err := s.confirmations.Get(ctx, sess.UserID, id)
+		if err != nil {
+			sess.removePending(id)
+			continue
+		}
+		outstanding = append(outstanding, action)
+	}
+
+	if len(outstanding) != 1 {
+		return nil
+	}
+	return outstanding[0]
+}
+
+// handleReply treats a message as the answer to the session's one pending
+// action, confirming or cancelling it as if the client had sent "confirm" or
+// "cancel". It reports whether it did; otherwise the message runs as usual,
+// with note set to remind the model the action is still waiting.
+func (s *Server) handleReply(ctx context.Context, conn peer, sess *session, content string) (handled bool, note string) {
+	if !features.Enabled(ctx, features.NaturalConfirmations) {
+		return false, ""
+	}
+	action := s.outstandingAction(ctx, sess)
+	if action == nil {
+		return false, ""
+	}
+
+	intent, err := s.engine.ClassifyConfirmationReply(ctx, action, content, sess.Locale, s.config.ConfirmationPhrases)
+	if err != nil {
+		log.Printf("[CONVERSATION %s] Failed to classify reply to action %s: %v", sess.ConversationID, action.ID, err)
+	}
+
+	switch intent {
+	case engine.ReplyConfirm, engine.ReplyCancel:
+		log.Printf("[CONVERSATION %s] USER: %s (%s action %s)", sess.ConversationID, truncate(content, 50), intent, action.ID)
+
+		// The reply is kept in the stored conversation, but not in the
+		// model's history: the action's tool_use block must be answered
+		// by its result first.
+		s.persistMessage(ctx, sess.ConversationID, "user", content)
+		if intent == engine.ReplyConfirm {
+			s.handleConfirm(ctx, conn, sess, sess.UserID, action.ID, nil)
+		} else {
+			s.handleCancel(ctx, conn, sess, sess.UserID, action.ID)
+		}
+		return true, ""
+	}
+
+	return false, fmt.Sprintf("The user has not yet confirmed or cancelled this pending action, and it has not been executed: %q. "+
+		"If their message is about it, don't treat it as approval; ask them to confirm or cancel it explicitly.", action.Summary)
+}
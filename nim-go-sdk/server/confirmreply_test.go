@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// sendModel is a mock Claude API that calls send_money when the user's
+// message mentions sending, and otherwise answers "OK.". It records the
+// request bodies it receives.
+type sendModel struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (m *sendModel) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Messages []struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+		json.Unmarshal(body, &req)
+
+		m.mu.Lock()
+		m.requests = append(m.requests, string(body))
+		n := len(m.requests)
+		m.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		content := `[{"type":"text","text":"OK."}]`
+		stop := "end_turn"
+		if last := string(req.Messages[len(req.Messages)-1].Content); strings.Contains(last, "send") && !strings.Contains(last, "tool_result") {
+			recipient := "@alice"
+			if strings.Contains(last, "bob") {
+				recipient = "@bob"
+			}
+			content = fmt.Sprintf(`[{"type":"tool_use","id":"toolu_%d","name":"send_money","input":{"recipient":%q,"amount":"50","currency":"USDC"}}]`, n, recipient)
+			stop = "tool_use"
+		}
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":%s,"stop_reason":%q,"usage":{"input_tokens":1,"output_tokens":1}}`, content, stop)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (m *sendModel) calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.requests)
+}
+
+func (m *sendModel) last() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.requests[len(m.requests)-1]
+}
+
+// wsClient is a websocket client for a server under test.
+type wsClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func dial(t *testing.T, s *Server) *wsClient {
+	t.Helper()
+	return dialQuery(t, s, "")
+}
+
+// dialQuery connects with a query string, e.g. "debug=1".
+func dialQuery(t *testing.T, s *Server, query string) *wsClient {
+	t.Helper()
+	ws := httptest.NewServer(s.Handler())
+	t.Cleanup(ws.Close)
+	url := "ws" + strings.TrimPrefix(ws.URL, "http")
+	if query != "" {
+		url += "/?" + query
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return &wsClient{t: t, conn: conn}
+}
+
+func (c *wsClient) send(msg ClientMessage) {
+	c.t.Helper()
+	if err := c.conn.WriteJSON(msg); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// read returns the next message of type want, skipping others.
+func (c *wsClient) read(want string) ServerMessage {
+	c.t.Helper()
+	for {
+		var msg ServerMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			c.t.Fatalf("waiting for %s: %v", want, err)
+		}
+		if msg.Type == want {
+			return msg
+		}
+		if msg.Type == "error" {
+			c.t.Fatalf("waiting for %s: error %q", want, msg.Content)
+		}
+	}
+}
+
+func TestNaturalConfirmations(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   string
+		offers   int // send_money confirmations offered before the reply
+		reply    string
+		wantText string // the server's answer to the reply
+		executed int
+		model    bool // whether the reply reached the model
+	}{
+		{name: "affirmative", offers: 1, reply: "Yes, do it!", wantText: "Sent", executed: 1},
+		{name: "negative", offers: 1, reply: "actually, cancel that", wantText: "Action cancelled.", executed: 0},
+		{name: "ambiguous", offers: 1, reply: "sure, but make it 40", wantText: "OK.", model: true},
+		{name: "multiple pending", offers: 2, reply: "yes", wantText: "OK.", model: true},
+		{name: "locale", locale: "es-ES", offers: 1, reply: "Sí, hazlo", wantText: "Sent", executed: 1},
+		{name: "other locale's phrase", locale: "de-DE", offers: 1, reply: "yes", wantText: "OK.", model: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &sendModel{}
+			srv := model.serve(t)
+
+			var mu sync.Mutex
+			executed := 0
+			s, err := New(Config{
+				AnthropicKey:         "test",
+				BaseURL:              srv.URL,
+				DisableStreaming:     true,
+				NaturalConfirmations: true,
+				AuthFunc:             func(r *http.Request) (string, error) { return "user-1", nil },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.AddTool(tools.New("send_money").
+				RequiresConfirmation().
+				SummaryTemplate("Send {{.amount}} {{.currency}} to {{.recipient}}").
+				Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					mu.Lock()
+					executed++
+					mu.Unlock()
+					return &core.ToolResult{Success: true, Data: map[string]interface{}{"message": "Sent"}}, nil
+				}).
+				Build())
+
+			c := dial(t, s)
+			c.send(ClientMessage{Type: "new_conversation", Locale: tt.locale})
+			c.read("conversation_started")
+			for _, recipient := range []string{"alice", "bob"}[:tt.offers] {
+				c.send(ClientMessage{Type: "message", Content: "send 50 to " + recipient})
+				c.read("confirm_request")
+			}
+
+			before := model.calls()
+			c.send(ClientMessage{Type: "message", Content: tt.reply})
+			if got := c.read("text").Content; got != tt.wantText {
+				t.Errorf("reply answered with %q, want %q", got, tt.wantText)
+			}
+			c.read("complete")
+
+			if reached := model.calls() > before; reached != tt.model {
+				t.Errorf("reply reached the model = %v, want %v", reached, tt.model)
+			}
+			if tt.model && tt.offers == 1 && !strings.Contains(model.last(), "has not been executed") {
+				t.Error("model was not told the action is still pending")
+			}
+			if tt.offers > 1 && strings.Contains(model.last(), "has not been executed") {
+				t.Error("model was told about one of several pending actions")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if executed != tt.executed {
+				t.Errorf("executed %d times, want %d", executed, tt.executed)
+			}
+		})
+	}
+}
+
+func TestNaturalConfirmations_Disabled(t *testing.T) {
+	model := &sendModel{}
+	srv := model.serve(t)
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          srv.URL,
+		DisableStreaming: true,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			t.Error("send_money executed without NaturalConfirmations")
+			return &core.ToolResult{Success: true}, nil
+		}).
+		Build())
+
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "send 50 to alice"})
+	c.read("confirm_request")
+	c.send(ClientMessage{Type: "message", Content: "yes"})
+	if got := c.read("text").Content; got != "OK." {
+		t.Errorf("reply answered with %q, want the model's answer", got)
+	}
+}
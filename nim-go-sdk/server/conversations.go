@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// Page sizes for "list_conversations".
+const (
+	defaultConversationPage = 20
+	maxConversationPage     = 100
+)
+
+// handleListConversations sends a page of the user's conversations. Stores
+// that can't page (see store.ConversationPager) list only the most recent.
+func (s *Server) handleListConversations(ctx context.Context, conn peer, userID, cursor string, limit int) {
+	if limit <= 0 {
+		limit = defaultConversationPage
+	}
+	limit = min(limit, maxConversationPage)
+
+	var convs []*store.Conversation
+	var next string
+	var err error
+	if pager, ok := s.conversations.(store.ConversationPager); ok {
+		convs, next, err = pager.ListAfter(ctx, userID, cursor, limit)
+	} else if cursor != "" {
+		err = store.ErrInvalidCursor
+	} else {
+		convs, err = s.conversations.List(ctx, userID, limit)
+	}
+	if errors.Is(err, store.ErrInvalidCursor) {
+		s.send(conn, ServerMessage{Type: "error", Code: "invalid_cursor", Content: "Invalid cursor. List conversations again from the start."})
+		return
+	}
+	if err != nil {
+		s.sendError(conn, fmt.Sprintf("Failed to list conversations: %v", err))
+		return
+	}
+
+	s.send(conn, ServerMessage{Type: "conversations_list", Conversations: convs, NextCursor: next})
+}
+
+// handleDeleteConversation deletes one of the user's conversations and
+// returns the active session, which ends if it was that conversation's.
+func (s *Server) handleDeleteConversation(ctx context.Context, conn peer, userID string, sess *session, conversationID string) *session {
+	if conversationID == "" {
+		s.sendError(conn, "delete_conversation requires a conversationId")
+		return sess
+	}
+	conv, err := s.conversations.Get(ctx, conversationID)
+	if err != nil || conv.UserID != userID {
+		s.sendError(conn, "Conversation not found")
+		return sess
+	}
+	if err := s.conversations.Delete(ctx, conversationID); err != nil {
+		s.sendError(conn, fmt.Sprintf("Failed to delete conversation: %v", err))
+		return sess
+	}
+
+	log.Printf("Deleted conversation %s for user %s", conversationID, userID)
+	s.send(conn, ServerMessage{Type: "conversation_deleted", ConversationID: conversationID})
+	if sess != nil && sess.ConversationID == conversationID {
+		s.endSession(conn)
+		return nil
+	}
+	return sess
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// usersServer is a server whose clients are the user named in the "user"
+// query parameter, user-1 by default.
+func usersServer(t *testing.T, conversations store.Conversations) *Server {
+	t.Helper()
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          replyModel(t, "Done.").URL,
+		DisableStreaming: true,
+		Conversations:    conversations,
+		AuthFunc: func(r *http.Request) (string, error) {
+			if user := r.URL.Query().Get("user"); user != "" {
+				return user, nil
+			}
+			return "user-1", nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestListConversations(t *testing.T) {
+	ctx := context.Background()
+	conversations := store.NewMemoryConversations()
+	var ids []string // newest first
+	for i := 0; i < 120; i++ {
+		conv, err := conversations.Create(ctx, "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append([]string{conv.ID}, ids...)
+	}
+	if _, err := conversations.Create(ctx, "user-2"); err != nil {
+		t.Fatal(err)
+	}
+	c := dial(t, usersServer(t, conversations))
+
+	tests := []struct {
+		name  string
+		limit int
+		pages []int
+	}{
+		{name: "default page size", limit: 0, pages: []int{20, 20, 20, 20, 20, 20}},
+		{name: "pages of 50", limit: 50, pages: []int{50, 50, 20}},
+		{name: "capped", limit: 500, pages: []int{100, 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var listed []string
+			var pages []int
+			cursor := ""
+			for {
+				c.send(ClientMessage{Type: "list_conversations", Cursor: cursor, Limit: tt.limit})
+				page := c.read("conversations_list")
+				pages = append(pages, len(page.Conversations))
+				for _, conv := range page.Conversations {
+					if conv.UserID != "user-1" {
+						t.Fatalf("listed %s's conversation %s", conv.UserID, conv.ID)
+					}
+					listed = append(listed, conv.ID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				cursor = page.NextCursor
+			}
+			if len(pages) != len(tt.pages) {
+				t.Fatalf("pages of %v, want %v", pages, tt.pages)
+			}
+			for i := range pages {
+				if pages[i] != tt.pages[i] {
+					t.Errorf("pages of %v, want %v", pages, tt.pages)
+					break
+				}
+			}
+			for i := range ids {
+				if listed[i] != ids[i] {
+					t.Fatalf("conversation %d = %s, want %s, newest first", i, listed[i], ids[i])
+				}
+			}
+		})
+	}
+
+	c.send(ClientMessage{Type: "list_conversations", Cursor: "bogus!"})
+	if got := c.read("error"); got.Code != "invalid_cursor" {
+		t.Errorf("bad cursor: error %+v, want invalid_cursor", got)
+	}
+}
+
+func TestDeleteConversation(t *testing.T) {
+	ctx := context.Background()
+	conversations := store.NewMemoryConversations()
+	s := usersServer(t, conversations)
+	c := dial(t, s)
+
+	c.send(ClientMessage{Type: "new_conversation"})
+	kept := c.read("conversation_started").ConversationID
+	c.send(ClientMessage{Type: "new_conversation"})
+	current := c.read("conversation_started").ConversationID
+
+	// Another user can neither delete nor tell it exists.
+	other := dialQuery(t, s, "user=user-2")
+	other.send(ClientMessage{Type: "delete_conversation", ConversationID: kept})
+	if got := other.read("error").Content; got != "Conversation not found" {
+		t.Errorf("delete by another user: error %q", got)
+	}
+	if _, err := conversations.Get(ctx, kept); err != nil {
+		t.Fatalf("conversation deleted by another user: %v", err)
+	}
+
+	// Deleting another conversation keeps the current one going.
+	c.send(ClientMessage{Type: "delete_conversation", ConversationID: kept})
+	if got := c.read("conversation_deleted").ConversationID; got != kept {
+		t.Errorf("conversation_deleted = %s, want %s", got, kept)
+	}
+	if _, err := conversations.Get(ctx, kept); err == nil {
+		t.Error("conversation still stored after delete")
+	}
+	c.send(ClientMessage{Type: "message", Content: "hi"})
+	c.read("complete")
+
+	// Deleting the current conversation ends it.
+	c.send(ClientMessage{Type: "delete_conversation", ConversationID: current})
+	c.read("conversation_deleted")
+	c.send(ClientMessage{Type: "message", Content: "hi"})
+	if got := c.read("error").Content; got != "No active conversation. Send 'new_conversation' first." {
+		t.Errorf("message after deleting the conversation: error %q", got)
+	}
+
+	c.send(ClientMessage{Type: "delete_conversation", ConversationID: current})
+	if got := c.read("error").Content; got != "Conversation not found" {
+		t.Errorf("deleting twice: error %q", got)
+	}
+	c.send(ClientMessage{Type: "list_conversations"})
+	if got := c.read("conversations_list").Conversations; len(got) != 0 {
+		t.Errorf("listed %d conversations after deleting them all", len(got))
+	}
+}
+
+func TestResumeConversation_OtherUser(t *testing.T) {
+	s := usersServer(t, store.NewMemoryConversations())
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	id := c.read("conversation_started").ConversationID
+	c.send(ClientMessage{Type: "message", Content: "hi"})
+	c.read("complete")
+
+	// Another user's conversation looks the same as one that doesn't exist.
+	other := dialQuery(t, s, "user=user-2")
+	for _, conversationID := range []string{id, "missing"} {
+		other.send(ClientMessage{Type: "resume_conversation", ConversationID: conversationID})
+		if got := other.read("error").Content; got != "Conversation not found" {
+			t.Errorf("resume %s by another user: error %q", conversationID, got)
+		}
+	}
+	other.send(ClientMessage{Type: "message", Content: "hi"})
+	if got := other.read("error").Content; got != "No active conversation. Send 'new_conversation' first." {
+		t.Errorf("message after a refused resume: error %q", got)
+	}
+}
+
+// TestConversations_LiminalTokens checks that under the default Liminal
+// auth, clients with different bearer tokens are different users.
+func TestConversations_LiminalTokens(t *testing.T) {
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          replyModel(t, "Done.").URL,
+		DisableStreaming: true,
+		LiminalExecutor:  executor.NewHTTPExecutor(executor.HTTPExecutorConfig{BaseURL: "http://gateway.invalid"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.routes())
+	t.Cleanup(srv.Close)
+
+	alice := dialQuery(t, s, "token=alice-jwt")
+	alice.send(ClientMessage{Type: "new_conversation"})
+	id := alice.read("conversation_started").ConversationID
+	alice.send(ClientMessage{Type: "message", Content: "hi"})
+	alice.read("complete")
+
+	bob := dialQuery(t, s, "token=bob-jwt")
+	bob.send(ClientMessage{Type: "list_conversations"})
+	if got := bob.read("conversations_list").Conversations; len(got) != 0 {
+		t.Errorf("bob listed %d of alice's conversations", len(got))
+	}
+	for _, typ := range []string{"resume_conversation", "delete_conversation"} {
+		bob.send(ClientMessage{Type: typ, ConversationID: id})
+		if got := bob.read("error").Content; got != "Conversation not found" {
+			t.Errorf("%s by bob: error %q", typ, got)
+		}
+	}
+
+	// Alice, reconnecting with the same token, is the same user.
+	again := dialQuery(t, s, "token=alice-jwt")
+	again.send(ClientMessage{Type: "resume_conversation", ConversationID: id})
+	again.read("conversation_resumed")
+	if resp, _ := export(t, srv, id, "", "bob-jwt"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("export by bob = %d, want 404", resp.StatusCode)
+	}
+	if resp, _ := export(t, srv, id, "", "alice-jwt"); resp.StatusCode != http.StatusOK {
+		t.Errorf("export by alice = %d, want 200", resp.StatusCode)
+	}
+
+	// A client without a token has no identity, so is refused.
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("tokenless connection: err %v, want 401", err)
+	}
+}
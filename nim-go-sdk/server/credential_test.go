@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// TestLiminalCredential_PerConnection checks that two users connected at
+// once, sharing the server's Liminal executor, each call the gateway with
+// their own JWT.
+func TestLiminalCredential_PerConnection(t *testing.T) {
+	// The gateway holds each balance request until both have arrived, so
+	// the two users' tool calls are in flight together, and reports the
+	// token it was called with as the balance.
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	var mu sync.Mutex
+	var tokens []string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		mu.Lock()
+		tokens = append(tokens, token)
+		mu.Unlock()
+
+		arrived.Done()
+		done := make(chan struct{})
+		go func() { arrived.Wait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+		}
+		json.NewEncoder(w).Encode(executor.GetBalanceResponse{TotalUSD: token})
+	}))
+	defer gateway.Close()
+
+	// The model calls get_balance, then answers with the conversation so
+	// far, which includes the user's message and the balance.
+	model := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		content := `[{"type":"tool_use","id":"toolu_1","name":"get_balance","input":{}}]`
+		stop := "tool_use"
+		if strings.Contains(string(body), `"tool_result"`) {
+			text, _ := json.Marshal(string(body))
+			content = fmt.Sprintf(`[{"type":"text","text":%s}]`, text)
+			stop = "end_turn"
+		}
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":%s,"stop_reason":%q,"usage":{"input_tokens":1,"output_tokens":1}}`, content, stop)
+	}))
+	defer model.Close()
+
+	exec := executor.NewHTTPExecutor(executor.HTTPExecutorConfig{BaseURL: gateway.URL})
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          model.URL,
+		DisableStreaming: true,
+		LiminalExecutor:  exec,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTools(tools.LiminalTools(exec)...)
+
+	// Both users connect before either sends a message.
+	alice := dialQuery(t, s, "token=alice-jwt")
+	bob := dialQuery(t, s, "token=bob-jwt")
+
+	var wg sync.WaitGroup
+	replies := make(map[string]string)
+	for user, c := range map[string]*wsClient{"alice": alice, "bob": bob} {
+		wg.Add(1)
+		go func(user string, c *wsClient) {
+			defer wg.Done()
+			c.send(ClientMessage{Type: "new_conversation"})
+			c.send(ClientMessage{Type: "message", Content: "balance for " + user})
+			reply := c.read("text").Content
+			mu.Lock()
+			replies[user] = reply
+			mu.Unlock()
+		}(user, c)
+	}
+	wg.Wait()
+
+	if len(tokens) != 2 {
+		t.Fatalf("gateway saw tokens %v, want one call per user", tokens)
+	}
+	for user, other := range map[string]string{"alice": "bob", "bob": "alice"} {
+		reply := replies[user]
+		if !strings.Contains(reply, "balance for "+user) {
+			t.Fatalf("%s's reply is not their conversation: %q", user, reply)
+		}
+		if !strings.Contains(reply, user+"-jwt") || strings.Contains(reply, other+"-jwt") {
+			t.Errorf("%s's balance call used the wrong token: %q", user, reply)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/features"
+)
+
+// DevModeParam is the query parameter a client sets, as "?debug=1", to
+// request developer mode for its connection. It is honored only when
+// the features.DevMode flag is on for the user, as Config.AllowDevMode
+// turns it on, and the connection was authenticated.
+const DevModeParam = "debug"
+
+type devModeKey struct{}
+
+// devModeRequested reports whether an authenticated request asked for
+// developer mode, and the server allows it for the user. Without an
+// AuthFunc, only the bearer token identifies the user, so it is required.
+func (s *Server) devModeRequested(r *http.Request, userID string, authenticated bool) bool {
+	if s.config.AuthFunc == nil && bearerToken(r) == "" {
+		return false
+	}
+	return authenticated && r.URL.Query().Get(DevModeParam) == "1" &&
+		s.features.Enabled(r.Context(), core.Identity{UserID: userID}, features.DevMode)
+}
+
+func withDevMode(ctx context.Context) context.Context {
+	return context.WithValue(ctx, devModeKey{}, true)
+}
+
+// devMode reports whether ctx belongs to a connection in developer mode.
+func devMode(ctx context.Context) bool {
+	on, _ := ctx.Value(devModeKey{}).(bool)
+	return on
+}
+
+// debugCallback returns the engine debug callback for a connection, or nil
+// outside developer mode. Debug frames are only sent, never persisted.
+func (s *Server) debugCallback(ctx context.Context, conn peer) func(engine.DebugEvent) {
+	if !devMode(ctx) {
+		return nil
+	}
+	return func(ev engine.DebugEvent) {
+		s.sendDebug(ctx, conn, ev)
+	}
+}
+
+// sendDebug sends a "debug" frame if the connection is in developer mode.
+func (s *Server) sendDebug(ctx context.Context, conn peer, ev engine.DebugEvent) {
+	if devMode(ctx) {
+		s.send(conn, ServerMessage{Type: "debug", Debug: &ev})
+	}
+}
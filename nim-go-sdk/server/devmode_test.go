@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/features"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// inspectModel is a mock Claude API that calls get_balance once, then
+// answers "OK.".
+func inspectModel(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		content := `[{"type":"tool_use","id":"toolu_1","name":"get_balance","input":{"currency":"USDC","pin":"4321"}}]`
+		stop := "tool_use"
+		if strings.Contains(string(body), "tool_result") {
+			content = `[{"type":"text","text":"OK."}]`
+			stop = "end_turn"
+		}
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":%s,"stop_reason":%q,"usage":{"input_tokens":10,"output_tokens":5}}`, content, stop)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func devModeServer(t *testing.T, allow, auth bool) *Server {
+	t.Helper()
+	cfg := Config{
+		AnthropicKey:     "test",
+		BaseURL:          inspectModel(t).URL,
+		SystemPrompt:     "You are a test assistant.",
+		DisableStreaming: true,
+		AllowDevMode:     allow,
+		Guardrails:       &engine.NoOpGuardrails{},
+		Moderator:        engine.NewRuleModerator(engine.DefaultModerationRules()...),
+	}
+	if auth {
+		cfg.AuthFunc = func(r *http.Request) (string, error) { return "user-1", nil }
+	}
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTool(tools.New("get_balance").
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{
+				"balance":        "12.50",
+				"account_number": "GB29NWBK60161331926819",
+				"access_token":   "secret-token",
+				"memo":           "paid from card 4111111111111111",
+			}}, nil
+		}).
+		Build())
+	return s
+}
+
+// runDebug sends one message and returns the debug events received until
+// the run completes.
+func runDebug(t *testing.T, c *wsClient) []engine.DebugEvent {
+	t.Helper()
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "what's my balance?"})
+
+	var events []engine.DebugEvent
+	for {
+		var msg ServerMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		switch msg.Type {
+		case "debug":
+			events = append(events, *msg.Debug)
+		case "error":
+			t.Fatalf("error %q", msg.Content)
+		case "complete":
+			return events
+		}
+	}
+}
+
+func TestDevMode_Off(t *testing.T) {
+	tests := []struct {
+		name  string
+		allow bool
+		auth  bool
+		query string
+	}{
+		{name: "not allowed", auth: true, query: "debug=1"},
+		{name: "not requested", allow: true, auth: true},
+		{name: "unauthenticated", allow: true, query: "debug=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := devModeServer(t, tt.allow, tt.auth)
+			if events := runDebug(t, dialQuery(t, s, tt.query)); len(events) != 0 {
+				t.Errorf("got %d debug frames, want none: %+v", len(events), events)
+			}
+		})
+	}
+}
+
+func TestDevMode_LiminalAuth(t *testing.T) {
+	s := devModeServer(t, true, false)
+	s.config.LiminalExecutor = executor.NewHTTPExecutor(executor.HTTPExecutorConfig{BaseURL: "http://gateway.invalid"})
+
+	req := httptest.NewRequest(http.MethodGet, "/ws?debug=1", nil)
+	userID, authenticated, _, err := s.authenticate(req)
+	if err == nil || s.devModeRequested(req, userID, authenticated) {
+		t.Errorf("tokenless request: err %v, dev mode %v; want refused", err, s.devModeRequested(req, userID, authenticated))
+	}
+
+	if events := runDebug(t, dialQuery(t, s, "debug=1&token=jwt")); len(events) == 0 {
+		t.Error("got no debug frames with a bearer token")
+	}
+}
+
+func TestDevMode_On(t *testing.T) {
+	s := devModeServer(t, true, true)
+	events := runDebug(t, dialQuery(t, s, "debug=1"))
+
+	var kinds []string
+	for _, ev := range events {
+		kinds = append(kinds, string(ev.Kind))
+	}
+	want := "features,moderation,guardrails,system_prompt,tools,turn,tool_call,turn,moderation"
+	if got := strings.Join(kinds, ","); got != want {
+		t.Fatalf("debug frames = %s, want %s", got, want)
+	}
+
+	byKind := func(kind engine.DebugKind, n int) engine.DebugEvent {
+		for _, ev := range events {
+			if ev.Kind == kind {
+				if n == 0 {
+					return ev
+				}
+				n--
+			}
+		}
+		t.Fatalf("no %s event", kind)
+		return engine.DebugEvent{}
+	}
+
+	if ev := byKind(engine.DebugFeatures, 0); !ev.Features[features.DevMode] || ev.Features[features.NaturalConfirmations] {
+		t.Errorf("features = %v, want dev_mode on and natural_confirmations off", ev.Features)
+	}
+	if ev := byKind(engine.DebugModeration, 0); ev.Stage != engine.ModerationInput || ev.Decision != "allow" {
+		t.Errorf("input moderation = %+v, want an allow", ev)
+	}
+	if ev := byKind(engine.DebugGuardrails, 0); ev.Decision != "allow" {
+		t.Errorf("guardrails = %+v, want an allow", ev)
+	}
+	if ev := byKind(engine.DebugSystemPrompt, 0); len(ev.System) != 1 || ev.System[0] != "You are a test assistant." {
+		t.Errorf("system prompt = %q", ev.System)
+	}
+	if ev := byKind(engine.DebugTools, 0); strings.Join(ev.Tools, ",") != "get_balance" {
+		t.Errorf("tools = %v, want [get_balance]", ev.Tools)
+	}
+	if ev := byKind(engine.DebugTurn, 1); ev.Turn != 2 || ev.StopReason != "end_turn" || ev.Tokens == nil || ev.Tokens.InputTokens != 10 {
+		t.Errorf("second turn = %+v", ev)
+	}
+
+	call := byKind(engine.DebugToolCall, 0)
+	if call.Tool != "get_balance" || call.Turn != 1 || call.Confirmation {
+		t.Errorf("tool call = %+v", call)
+	}
+	if !strings.Contains(string(call.Result), `"balance":"12.50"`) {
+		t.Errorf("tool call result = %s, want the balance", call.Result)
+	}
+}
+
+func TestDevMode_Redaction(t *testing.T) {
+	s := devModeServer(t, true, true)
+	events := runDebug(t, dialQuery(t, s, "debug=1"))
+
+	for _, ev := range events {
+		if ev.Kind != engine.DebugToolCall {
+			continue
+		}
+		payload := string(ev.Input) + string(ev.Result)
+		for _, secret := range []string{"4321", "GB29NWBK60161331926819", "secret-token", "4111111111111111"} {
+			if strings.Contains(payload, secret) {
+				t.Errorf("debug payload contains %q: %s", secret, payload)
+			}
+		}
+		if !strings.Contains(payload, "••••1111") {
+			t.Errorf("card number not masked in %s", payload)
+		}
+		return
+	}
+	t.Fatal("no tool_call debug frame")
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func TestConfirmWithEdits(t *testing.T) {
+	model := &sendModel{}
+	srv := model.serve(t)
+	audit := engine.NewMemoryAuditLogger()
+
+	var mu sync.Mutex
+	var executed []string
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          srv.URL,
+		DisableStreaming: true,
+		AuditLogger:      audit,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"recipient": tools.StringProperty("Recipient"),
+			"amount":    tools.StringProperty("Amount"),
+			"currency":  tools.StringProperty("Currency"),
+		}, "recipient", "amount", "currency")).
+		SummaryTemplate("Send {{.amount}} {{.currency}} to {{.recipient}}").
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			mu.Lock()
+			executed = append(executed, string(params.Input))
+			mu.Unlock()
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{"message": "Sent"}}, nil
+		}).
+		Build())
+
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "send 50 to alice"})
+	offer := c.read("confirm_request")
+	var offered map[string]string
+	if err := json.Unmarshal(offer.Input, &offered); err != nil || offered["amount"] != "50" {
+		t.Fatalf("confirm_request input = %s, want the offered fields", offer.Input)
+	}
+	if offer.Details[core.DetailAmount] != "50" {
+		t.Errorf("confirm_request details = %v, want the offered fields", offer.Details)
+	}
+
+	// Invalid edits are refused and leave the action pending.
+	c.send(ClientMessage{Type: "confirm_with_edits", ActionID: offer.ActionID, Input: json.RawMessage(`{"recipient":"@alice","currency":"USDC"}`)})
+	var refused ServerMessage
+	if err := c.conn.ReadJSON(&refused); err != nil {
+		t.Fatal(err)
+	}
+	if refused.Type != "error" || refused.Code != "invalid_edits" || !strings.Contains(refused.Content, "missing required field 'amount'") {
+		t.Errorf("invalid edits answered with %+v, want an invalid_edits error", refused)
+	}
+
+	const edited = `{"recipient":"@alice","amount":"45","currency":"USDC"}`
+	c.send(ClientMessage{Type: "confirm_with_edits", ActionID: offer.ActionID, Input: json.RawMessage(edited)})
+	if got := c.read("text").Content; got != "Sent" {
+		t.Errorf("edited confirmation answered with %q, want Sent", got)
+	}
+	c.read("complete")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(executed) != 1 || executed[0] != edited {
+		t.Errorf("executed %v, want [%s]", executed, edited)
+	}
+	var entry *engine.AuditEntry
+	for _, e := range audit.Entries() {
+		if e.ToolName == "send_money" && e.OriginalInput != nil {
+			entry = e
+		}
+	}
+	if entry == nil || string(entry.ToolInput) != edited {
+		t.Fatalf("audit entries = %+v, want the edited send_money", audit.Entries())
+	}
+	var original map[string]string
+	json.Unmarshal(entry.OriginalInput, &original)
+	if original["amount"] != "50" {
+		t.Errorf("audited original input = %s, want the offered amount", entry.OriginalInput)
+	}
+}
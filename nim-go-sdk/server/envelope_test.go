@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// balanceModel is a mock Claude API that calls get_balance, then answers.
+func balanceModel(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		content := `[{"type":"text","text":"You have 50.25 USDC."}]`
+		stop := "end_turn"
+		if strings.Contains(string(body), `"get_balance"`) && !strings.Contains(string(body), `"tool_result"`) {
+			content = `[{"type":"tool_use","id":"toolu_1","name":"get_balance","input":{}}]`
+			stop = "tool_use"
+		}
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":%s,"stop_reason":%q,"usage":{"input_tokens":1,"output_tokens":1}}`, content, stop)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestToolResultFigures(t *testing.T) {
+	model := balanceModel(t)
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          model.URL,
+		DisableStreaming: true,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTools(tools.LiminalTools(&txntest.Executor{Responses: map[string]interface{}{
+		"get_balance": executor.GetBalanceResponse{
+			Balances: []executor.WalletBalance{{Currency: "USDC", Amount: "50.25", USDValue: "50.25"}},
+			TotalUSD: "50.25",
+		},
+	}})...)
+
+	ws := httptest.NewServer(s.Handler())
+	t.Cleanup(ws.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ws.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	read := func(want string) ServerMessage {
+		t.Helper()
+		for {
+			var msg ServerMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("waiting for %s: %v", want, err)
+			}
+			if msg.Type == want {
+				return msg
+			}
+			if msg.Type == "error" {
+				t.Fatalf("waiting for %s: error %q", want, msg.Content)
+			}
+		}
+	}
+
+	conn.WriteJSON(ClientMessage{Type: "new_conversation"})
+	read("conversation_started")
+	conn.WriteJSON(ClientMessage{Type: "message", Content: "what's my balance?"})
+
+	result := read("tool_result")
+	if result.Tool != "get_balance" || result.Status != string(core.StatusOK) {
+		t.Errorf("tool_result = %+v, want get_balance ok", result)
+	}
+	want := []core.Figure{
+		{Name: "balance", Amount: "50.25", Currency: "USDC"},
+		{Name: "total", Amount: "50.25", Currency: "USD"},
+	}
+	if len(result.Figures) != len(want) {
+		t.Fatalf("Figures = %+v, want %+v", result.Figures, want)
+	}
+	for i := range want {
+		if result.Figures[i] != want[i] {
+			t.Errorf("Figures[%d] = %+v, want %+v", i, result.Figures[i], want[i])
+		}
+	}
+
+	if text := read("text"); text.Content != "You have 50.25 USDC." {
+		t.Errorf("text = %q", text.Content)
+	}
+	read("complete")
+}
+
+func TestFormatToolResult_Envelope(t *testing.T) {
+	data := core.NewEnvelope(map[string]interface{}{"message": "Goal saved"})
+	if got := formatToolResult("spend_weekly_goal", data); got != "Goal saved" {
+		t.Errorf("formatToolResult() = %q, want the envelope's message", got)
+	}
+}
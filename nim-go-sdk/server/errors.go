@@ -0,0 +1,53 @@
+package server
+
+import (
+	"errors"
+	"math"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// errorMessage is an "error" message showing content, coded by the kind of
+// engine error err is so clients can tell retrying from slowing down:
+//
+//   - "guardrails_blocked": the user is rate limited or their circuit is open
+//   - "turn_limit_exceeded", "tool_call_limit_exceeded": the run hit a limit
+//   - "timeout": the run ran out of time
+//   - "model_rate_limited", "model_overloaded", "model_error": the Claude
+//     API failed, with retryAfter set if it said when to try again
+//   - "unknown_tool": a tool that isn't registered was asked for
+//
+// Other errors have no code.
+func errorMessage(content string, err error) ServerMessage {
+	msg := ServerMessage{Type: "error", Content: content}
+	var (
+		blocked   *engine.GuardrailsBlockedError
+		turns     *engine.TurnLimitError
+		toolCalls *engine.ToolCallLimitError
+		apiErr    *engine.APIError
+		unknown   *engine.UnknownToolError
+	)
+	switch {
+	case errors.As(err, &blocked):
+		msg.Code = "guardrails_blocked"
+	case errors.As(err, &turns):
+		msg.Code = "turn_limit_exceeded"
+	case errors.As(err, &toolCalls):
+		msg.Code = "tool_call_limit_exceeded"
+	case errors.Is(err, engine.ErrTimeout):
+		msg.Code = "timeout"
+	case errors.As(err, &apiErr):
+		switch {
+		case apiErr.RateLimited():
+			msg.Code = "model_rate_limited"
+		case apiErr.Overloaded():
+			msg.Code = "model_overloaded"
+		default:
+			msg.Code = "model_error"
+		}
+		msg.RetryAfter = int(math.Ceil(apiErr.RetryAfter.Seconds()))
+	case errors.As(err, &unknown):
+		msg.Code = "unknown_tool"
+	}
+	return msg
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// rateLimitedModel is a mock Claude API that refuses every request with a
+// 429, asking callers to retry after 7 seconds.
+func rateLimitedModel(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		err            error
+		wantCode       string
+		wantRetryAfter int
+	}{
+		{err: &engine.GuardrailsBlockedError{Warning: "slow down"}, wantCode: "guardrails_blocked"},
+		{err: &engine.TurnLimitError{Max: 10}, wantCode: "turn_limit_exceeded"},
+		{err: &engine.ToolCallLimitError{Max: 20}, wantCode: "tool_call_limit_exceeded"},
+		{err: fmt.Errorf("%w: %w", engine.ErrTimeout, context.DeadlineExceeded), wantCode: "timeout"},
+		{err: &engine.APIError{Status: 429, RetryAfter: 1500 * time.Millisecond, Err: errors.New("429")}, wantCode: "model_rate_limited", wantRetryAfter: 2},
+		{err: &engine.APIError{Status: 529, Err: errors.New("529")}, wantCode: "model_overloaded"},
+		{err: &engine.APIError{Err: errors.New("connection refused")}, wantCode: "model_error"},
+		{err: fmt.Errorf("confirming: %w", &engine.UnknownToolError{Name: "send_money"}), wantCode: "unknown_tool"},
+		{err: errors.New("something else")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			msg := errorMessage("Sorry", tt.err)
+			if msg.Type != "error" || msg.Content != "Sorry" || msg.Code != tt.wantCode || msg.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("errorMessage() = %+v, want code %q, retry after %d", msg, tt.wantCode, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestErrorCodes_RateLimitedModel(t *testing.T) {
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          rateLimitedModel(t).URL,
+		DisableStreaming: true,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+		AnthropicOptions: []option.RequestOption{option.WithMaxRetries(0)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.routes())
+	defer srv.Close()
+
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "hi"})
+	msg := c.read("error")
+	if msg.Code != "model_rate_limited" || msg.RetryAfter != 7 || !strings.Contains(msg.Content, "429") {
+		t.Errorf("error = %+v, want model_rate_limited, retrying after 7s", msg)
+	}
+
+	resp, err := http.Post(srv.URL+"/v1/chat", "application/json", strings.NewReader(`{"message":"hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "7" {
+		t.Errorf("REST chat = %d, Retry-After %q, want 429 after 7s", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// defaultConfirmationCleanupInterval is how often expired actions are
+// removed from the Confirmations store by default.
+const defaultConfirmationCleanupInterval = time.Minute
+
+// expiryTimers are the timers pushing "confirm_expired" for a session's
+// pending actions.
+type expiryTimers struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer // actionID -> timer
+}
+
+// start calls expired once actionID has expired, replacing any timer
+// already running for it.
+func (t *expiryTimers) start(actionID string, expiresAt time.Time, expired func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timers == nil {
+		t.timers = make(map[string]*time.Timer)
+	}
+	if timer, ok := t.timers[actionID]; ok {
+		timer.Stop()
+	}
+	t.timers[actionID] = time.AfterFunc(time.Until(expiresAt), func() {
+		t.mu.Lock()
+		delete(t.timers, actionID)
+		t.mu.Unlock()
+		expired()
+	})
+}
+
+// stop cancels actionID's timer, if it has one.
+func (t *expiryTimers) stop(actionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if timer, ok := t.timers[actionID]; ok {
+		timer.Stop()
+		delete(t.timers, actionID)
+	}
+}
+
+// stopAll cancels every timer.
+func (t *expiryTimers) stopAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, timer := range t.timers {
+		timer.Stop()
+		delete(t.timers, id)
+	}
+}
+
+// watchExpiry tells the user when an action offered on conn expires
+// unanswered. Only WebSocket clients are told: a REST response can't be
+// added to later.
+func (s *Server) watchExpiry(conn peer, sess *session, action *core.PendingAction) {
+	if _, ok := conn.(*liveConn); !ok {
+		return
+	}
+	// Stores treat an action as expired once its expiry second has passed.
+	expiresAt := time.Unix(action.ExpiresAt, 0).Add(time.Second)
+	id, tool, summary, conversationID := action.ID, action.Tool, action.Summary, sess.ConversationID
+	sess.expiries.start(id, expiresAt, func() {
+		log.Printf("[CONVERSATION %s] Action %s expired unconfirmed", conversationID, id)
+		s.pins.removeAction(conversationID, id)
+		s.observeConfirmation(tool, engine.ConfirmationExpired)
+		s.send(conn, ServerMessage{
+			Type:     "confirm_expired",
+			ActionID: id,
+			Summary:  summary,
+			Content:  expiredNotice(summary),
+		})
+	})
+}
+
+// expiredNotice tells the user an action expired, suggesting they ask
+// again, e.g. "Ask me again if you still want to send 50 USDC to @alice."
+func expiredNotice(summary string) string {
+	if summary == "" {
+		return "That request expired before you confirmed it, so nothing was done. Ask me again if you still want to go ahead."
+	}
+	return fmt.Sprintf("That request expired before you confirmed it, so nothing was done. Ask me again if you still want to %s.", lowerFirst(summary))
+}
+
+// lowerFirst lowercases the first letter of a sentence, leaving acronyms
+// such as "USDC" alone.
+func lowerFirst(s string) string {
+	first, n := utf8.DecodeRuneInString(s)
+	if next, _ := utf8.DecodeRuneInString(s[n:]); unicode.IsUpper(next) {
+		return s
+	}
+	return string(unicode.ToLower(first)) + s[n:]
+}
+
+// cleanupConfirmations removes expired actions from the Confirmations store
+// every interval until ctx is cancelled or the server shuts down.
+func (s *Server) cleanupConfirmations(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			n, err := s.confirmations.Cleanup(ctx)
+			if err != nil {
+				log.Printf("Failed to clean up expired confirmations: %v", err)
+			} else if n > 0 {
+				log.Printf("Cleaned up %d expired confirmations", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func newExpiryServer(t *testing.T, ttl time.Duration) *Server {
+	t.Helper()
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          (&sendModel{}).serve(t).URL,
+		DisableStreaming: true,
+		ConfirmationTTL:  ttl,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		SummaryTemplate("Send {{.amount}} {{.currency}} to {{.recipient}}").
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{"message": "Sent"}}, nil
+		}).
+		Build())
+	return s
+}
+
+func TestConfirmExpired(t *testing.T) {
+	c := dial(t, newExpiryServer(t, time.Millisecond))
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "send 50 to alice"})
+	offer := c.read("confirm_request")
+
+	expired := c.read("confirm_expired")
+	if expired.ActionID != offer.ActionID {
+		t.Errorf("expired action %q, want %q", expired.ActionID, offer.ActionID)
+	}
+	if !strings.HasSuffix(expired.Content, "Ask me again if you still want to send 50 USDC to @alice.") {
+		t.Errorf("notice = %q", expired.Content)
+	}
+
+	c.send(ClientMessage{Type: "confirm", ActionID: offer.ActionID})
+	if got := c.read("text").Content; !strings.Contains(got, "expired") {
+		t.Errorf("confirming an expired action answered %q", got)
+	}
+}
+
+func TestConfirmExpired_NotSentOnceAnswered(t *testing.T) {
+	c := dial(t, newExpiryServer(t, time.Second))
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "send 50 to alice"})
+	offer := c.read("confirm_request")
+	c.send(ClientMessage{Type: "cancel", ActionID: offer.ActionID})
+	c.read("complete")
+
+	// Past the action's expiry, nothing more arrives.
+	c.conn.SetReadDeadline(expiry(t, offer).Add(1500 * time.Millisecond))
+	var msg ServerMessage
+	if err := c.conn.ReadJSON(&msg); err == nil {
+		t.Errorf("got %q after cancelling: %q", msg.Type, msg.Content)
+	}
+}
+
+// expiry returns when a confirm_request's action expires.
+func expiry(t *testing.T, offer ServerMessage) time.Time {
+	t.Helper()
+	at, err := time.Parse(time.RFC3339, offer.ExpiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return at
+}
+
+func TestExpiredNotice(t *testing.T) {
+	tests := []struct {
+		summary string
+		want    string
+	}{
+		{"Send $50 to @alice", "Ask me again if you still want to send $50 to @alice."},
+		{"USDC top-up from EURC", "Ask me again if you still want to USDC top-up from EURC."},
+		{"", "Ask me again if you still want to go ahead."},
+	}
+	for _, tt := range tests {
+		if got := expiredNotice(tt.summary); !strings.HasSuffix(got, tt.want) {
+			t.Errorf("expiredNotice(%q) = %q, want it to end %q", tt.summary, got, tt.want)
+		}
+	}
+}
+
+// cleanupCounter signals each Cleanup that removed actions.
+type cleanupCounter struct {
+	*store.MemoryConfirmations
+	removed chan int
+}
+
+func (c *cleanupCounter) Cleanup(ctx context.Context) (int, error) {
+	n, err := c.MemoryConfirmations.Cleanup(ctx)
+	if n > 0 {
+		c.removed <- n
+	}
+	return n, err
+}
+
+func TestConfirmationCleanup(t *testing.T) {
+	confirmations := &cleanupCounter{MemoryConfirmations: store.NewMemoryConfirmations(), removed: make(chan int, 1)}
+	confirmations.Store(context.Background(), &core.PendingAction{ID: "stale", UserID: "user-1", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+
+	s := newShutdownServer(t, Config{Confirmations: confirmations, ConfirmationCleanupInterval: 10 * time.Millisecond})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.serve(ctx, ln)
+
+	select {
+	case n := <-confirmations.removed:
+		if n != 1 {
+			t.Errorf("cleaned up %d actions, want 1", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expired actions were not cleaned up")
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// handleExport serves GET /conversations/{id}/export: one of the user's
+// conversations as JSON (the default) or, with ?format=markdown, as a
+// readable transcript. Messages pass through Config.RedactExport first.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	userID, _, _, err := s.authenticate(r)
+	if err != nil {
+		writeREST(w, http.StatusUnauthorized, ChatResponse{Error: "Unauthorized", Code: "unauthorized"})
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "markdown" {
+		writeREST(w, http.StatusBadRequest, ChatResponse{Error: "format must be json or markdown", Code: "invalid_request"})
+		return
+	}
+
+	conversationID := r.PathValue("id")
+	conv, err := s.conversations.Export(r.Context(), conversationID)
+	if errors.Is(err, store.ErrConversationEvicted) {
+		writeREST(w, http.StatusGone, ChatResponse{ConversationID: conversationID, Error: "This conversation is no longer available.", Code: "conversation_evicted"})
+		return
+	}
+	if err != nil || conv.UserID != userID {
+		writeREST(w, http.StatusNotFound, ChatResponse{ConversationID: conversationID, Error: "Conversation not found", Code: "not_found"})
+		return
+	}
+	for i, m := range conv.Messages {
+		if s.config.RedactExport != nil {
+			m = s.config.RedactExport(m)
+		}
+		m.Artifacts = s.config.Artifacts.ResolveAll(m.Artifacts)
+		conv.Messages[i] = m
+	}
+
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="conversation-%s.md"`, conv.ID))
+		w.Write([]byte(transcript(conv)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="conversation-%s.json"`, conv.ID))
+	if err := json.NewEncoder(w).Encode(conv); err != nil {
+		log.Printf("Failed to write export: %v", err)
+	}
+}
+
+// transcript renders a conversation as Markdown: a heading per message with
+// its role and time, and tool calls and results collapsed in <details>.
+func transcript(conv *store.ConversationWithMessages) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", conv.Title)
+	fmt.Fprintf(&b, "Conversation %s, started %s.\n", conv.ID, timestamp(conv.CreatedAt))
+	for _, m := range conv.Messages {
+		fmt.Fprintf(&b, "\n## %s, %s\n", roleName(m.Role), timestamp(m.CreatedAt))
+		if m.Content != "" {
+			fmt.Fprintf(&b, "\n%s\n", m.Content)
+		}
+		for _, block := range m.Blocks {
+			writeBlock(&b, block, m.Content == "")
+		}
+		for _, tool := range m.Tools {
+			var execution core.ToolExecution
+			if data, err := json.Marshal(tool); err != nil || json.Unmarshal(data, &execution) != nil || execution.Tool == "" {
+				writeDetails(&b, "Tool", tool)
+				continue
+			}
+			writeDetails(&b, "Tool: "+execution.Tool, execution)
+		}
+		for _, ref := range m.Artifacts {
+			name := ref.Name
+			if name == "" {
+				name = ref.ID
+			}
+			if ref.URL != "" {
+				name = fmt.Sprintf("[%s](%s)", name, ref.URL)
+			}
+			fmt.Fprintf(&b, "\n_Attachment: %s (%s)_\n", name, ref.MediaType)
+		}
+	}
+	return b.String()
+}
+
+// writeBlock renders a stored content block, a core.ContentBlock. Text
+// blocks are only written if the message has no content of its own, which
+// they would repeat.
+func writeBlock(b *strings.Builder, block interface{}, withText bool) {
+	var cb core.ContentBlock
+	if data, err := json.Marshal(block); err != nil || json.Unmarshal(data, &cb) != nil {
+		writeDetails(b, "Block", block)
+		return
+	}
+	switch {
+	case cb.Type == core.TextBlockType:
+		if withText {
+			fmt.Fprintf(b, "\n%s\n", cb.Text)
+		}
+	case cb.Type == core.ToolUseBlockType && cb.ToolUse != nil:
+		writeDetails(b, "Tool call: "+cb.ToolUse.Name, cb.ToolUse.Input)
+	case cb.Type == core.ToolResultBlockType && cb.ToolResult != nil:
+		summary := "Tool result"
+		if cb.ToolResult.IsError {
+			summary = "Tool error"
+		}
+		writeDetails(b, summary, cb.ToolResult.Content)
+	case cb.Type == core.ImageBlockType:
+		b.WriteString("\n_[image]_\n")
+	default:
+		writeDetails(b, "Block: "+string(cb.Type), block)
+	}
+}
+
+// writeDetails writes a payload collapsed under summary, as indented JSON
+// where it is JSON.
+func writeDetails(b *strings.Builder, summary string, payload interface{}) {
+	var text, lang string
+	switch p := payload.(type) {
+	case string:
+		text = p
+	case json.RawMessage:
+		text = string(p)
+	default:
+		data, _ := json.Marshal(p)
+		text = string(data)
+	}
+	var indented bytes.Buffer
+	if json.Indent(&indented, []byte(text), "", "  ") == nil {
+		text, lang = indented.String(), "json"
+	}
+
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "\n<details>\n<summary>%s</summary>\n\n%s%s\n%s\n%s\n\n</details>\n", html.EscapeString(summary), fence, lang, text, fence)
+}
+
+// roleName is a message role as a heading, e.g. "User".
+func roleName(role string) string {
+	if role == "" {
+		return "Unknown"
+	}
+	return strings.ToUpper(role[:1]) + role[1:]
+}
+
+// timestamp formats a message time for a transcript.
+func timestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
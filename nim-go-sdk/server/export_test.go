@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// export fetches a conversation export from srv as the given user.
+func export(t *testing.T, srv *httptest.Server, conversationID, query, user string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/conversations/"+conversationID+"/export"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user != "" {
+		req.Header.Set("Authorization", "Bearer "+user)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestExport(t *testing.T) {
+	var executed int
+	s, srv := newRESTServer(t, (&sendModel{}).serve(t).URL, &executed)
+	s.config.RedactExport = func(m store.StoredMessage) store.StoredMessage {
+		m.Content = strings.ReplaceAll(m.Content, "alice", "[redacted]")
+		return m
+	}
+
+	_, resp := post(t, srv, "/v1/chat", "user-1", `{"message":"send 50 to alice"}`)
+	if resp.PendingAction == nil {
+		t.Fatalf("chat = %+v, want a pending action", resp)
+	}
+	post(t, srv, "/v1/confirm/"+resp.PendingAction.ID, "user-1", "")
+	id := resp.ConversationID
+
+	httpResp, body := export(t, srv, id, "", "user-1")
+	var conv store.ConversationWithMessages
+	if err := json.Unmarshal([]byte(body), &conv); err != nil || httpResp.StatusCode != http.StatusOK {
+		t.Fatalf("JSON export = %d %s", httpResp.StatusCode, body)
+	}
+	if len(conv.Messages) != 2 || conv.Messages[0].Role != "user" || conv.Messages[0].Content != "send 50 to [redacted]" {
+		t.Errorf("exported messages = %+v, want the redacted request and its result", conv.Messages)
+	}
+	if got := httpResp.Header.Get("Content-Disposition"); !strings.Contains(got, "conversation-"+id+".json") {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	httpResp, body = export(t, srv, id, "?format=markdown", "user-1")
+	if httpResp.StatusCode != http.StatusOK || !strings.HasPrefix(httpResp.Header.Get("Content-Type"), "text/markdown") {
+		t.Fatalf("Markdown export = %d %s", httpResp.StatusCode, httpResp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(body, "## User, ") || !strings.Contains(body, "send 50 to [redacted]") || strings.Contains(body, "alice") {
+		t.Errorf("Markdown export = %s", body)
+	}
+
+	// The stored conversation is untouched by redaction.
+	stored, _ := s.conversations.Get(context.Background(), id)
+	if stored.Messages[0].Content != "send 50 to alice" {
+		t.Errorf("stored message = %q, want it unredacted", stored.Messages[0].Content)
+	}
+
+	tests := []struct {
+		name, id, query, user string
+		want                  int
+	}{
+		{name: "unauthenticated", id: id, want: http.StatusUnauthorized},
+		{name: "another user's", id: id, user: "user-2", want: http.StatusNotFound},
+		{name: "unknown", id: "missing", user: "user-1", want: http.StatusNotFound},
+		{name: "unknown format", id: id, query: "?format=pdf", user: "user-1", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp, body := export(t, srv, tt.id, tt.query, tt.user); resp.StatusCode != tt.want {
+				t.Errorf("status = %d (%s), want %d", resp.StatusCode, body, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	at := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	conv := &store.ConversationWithMessages{
+		Conversation: store.Conversation{ID: "conv-1", Title: "Paying Alice", CreatedAt: at},
+		Messages: []store.StoredMessage{
+			{Role: "user", Content: "Send 50 USDC to @alice", CreatedAt: at},
+			{
+				Role:    "assistant",
+				Content: "I'll send 50 USDC to @alice once you confirm.",
+				Blocks: []interface{}{
+					core.ContentBlock{Type: core.TextBlockType, Text: "I'll send 50 USDC to @alice once you confirm."},
+					core.ContentBlock{Type: core.ToolUseBlockType, ToolUse: &core.ToolUseContent{
+						ID: "toolu_1", Name: "send_money", Input: json.RawMessage(`{"recipient":"@alice","amount":"50","currency":"USDC"}`),
+					}},
+				},
+				CreatedAt: at.Add(time.Second),
+			},
+			{
+				Role: "user",
+				// As stored by the SQL store, which decodes blocks to maps.
+				Blocks: []interface{}{map[string]interface{}{
+					"type":        "tool_result",
+					"tool_result": map[string]interface{}{"tool_use_id": "toolu_1", "content": `{"message":"Sent"}`},
+				}},
+				CreatedAt: at.Add(30 * time.Second),
+			},
+			{Role: "assistant", Content: "Sent 50 USDC to @alice.", CreatedAt: at.Add(31 * time.Second)},
+		},
+	}
+
+	want := "# Paying Alice\n" +
+		"\n" +
+		"Conversation conv-1, started 2026-03-04 12:00:00 UTC.\n" +
+		"\n" +
+		"## User, 2026-03-04 12:00:00 UTC\n" +
+		"\n" +
+		"Send 50 USDC to @alice\n" +
+		"\n" +
+		"## Assistant, 2026-03-04 12:00:01 UTC\n" +
+		"\n" +
+		"I'll send 50 USDC to @alice once you confirm.\n" +
+		"\n" +
+		"<details>\n" +
+		"<summary>Tool call: send_money</summary>\n" +
+		"\n" +
+		"```json\n" +
+		"{\n" +
+		"  \"recipient\": \"@alice\",\n" +
+		"  \"amount\": \"50\",\n" +
+		"  \"currency\": \"USDC\"\n" +
+		"}\n" +
+		"```\n" +
+		"\n" +
+		"</details>\n" +
+		"\n" +
+		"## User, 2026-03-04 12:00:30 UTC\n" +
+		"\n" +
+		"<details>\n" +
+		"<summary>Tool result</summary>\n" +
+		"\n" +
+		"```json\n" +
+		"{\n" +
+		"  \"message\": \"Sent\"\n" +
+		"}\n" +
+		"```\n" +
+		"\n" +
+		"</details>\n" +
+		"\n" +
+		"## Assistant, 2026-03-04 12:00:31 UTC\n" +
+		"\n" +
+		"Sent 50 USDC to @alice.\n"
+	if got := transcript(conv); got != want {
+		t.Errorf("transcript() =\n%s\nwant\n%s", got, want)
+	}
+}
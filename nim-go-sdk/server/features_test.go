@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/features"
+)
+
+func TestFeatureFlags_UnknownFlag(t *testing.T) {
+	_, err := New(Config{
+		AnthropicKey: "test",
+		Features:     map[string]bool{"natural_confirmation": true},
+	})
+	if err == nil || !strings.Contains(err.Error(), `unknown feature flag "natural_confirmation"`) {
+		t.Errorf("New error = %v, want an unknown feature flag error", err)
+	}
+}
+
+func TestFeatureFlags(t *testing.T) {
+	noDevMode, err := features.NewRollout(map[string]int{features.DevMode: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want features.Flags
+	}{
+		{
+			name: "defaults",
+			want: features.Flags{features.NaturalConfirmations: false, features.ModerationBestEffort: false, features.DevMode: false},
+		},
+		{
+			name: "booleans turn flags on",
+			cfg:  Config{NaturalConfirmations: true, AllowDevMode: true},
+			want: features.Flags{features.NaturalConfirmations: true, features.ModerationBestEffort: false, features.DevMode: true},
+		},
+		{
+			name: "features override booleans",
+			cfg:  Config{NaturalConfirmations: true, Features: map[string]bool{features.NaturalConfirmations: false, features.ModerationBestEffort: true}},
+			want: features.Flags{features.NaturalConfirmations: false, features.ModerationBestEffort: true, features.DevMode: false},
+		},
+		{
+			name: "provider overrides both",
+			cfg:  Config{AllowDevMode: true, FlagProvider: noDevMode},
+			want: features.Flags{features.NaturalConfirmations: false, features.ModerationBestEffort: false, features.DevMode: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.AnthropicKey = "test"
+			cfg.BaseURL = inspectModel(t).URL
+			cfg.DisableStreaming = true
+			cfg.AuthFunc = func(r *http.Request) (string, error) { return "user-1", nil }
+			s, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := dialQuery(t, s, "debug=1")
+			c.send(ClientMessage{Type: "new_conversation"})
+			got := c.read("conversation_started").Features
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("conversation_started flag %s = %v, want %v (all: %v)", name, got[name], want, got)
+				}
+			}
+
+			// Developer mode follows the flag, not AllowDevMode alone.
+			c.send(ClientMessage{Type: "message", Content: "hello"})
+			debug := false
+			for {
+				var msg ServerMessage
+				if err := c.conn.ReadJSON(&msg); err != nil {
+					t.Fatal(err)
+				}
+				if msg.Type == "debug" {
+					debug = true
+				}
+				if msg.Type == "complete" {
+					break
+				}
+			}
+			if debug != tt.want[features.DevMode] {
+				t.Errorf("debug frames sent = %v, want %v", debug, tt.want[features.DevMode])
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// offerGroup stores the actions the model asked for together and asks the
+// user to confirm them in one confirm_request.
+func (s *Server) offerGroup(ctx context.Context, conn peer, sess *session, output *engine.Output) {
+	group := output.PendingGroup
+	keys := make(map[string]bool)
+	for i, action := range group.Actions {
+		// An earlier offer of the same action is reused, as for single
+		// actions, but not one offered in this group: the model asking
+		// twice must get two answers.
+		pending := action
+		if action.IdempotencyKey == "" || !keys[action.IdempotencyKey] {
+			pending = s.storePending(ctx, sess, action)
+		} else if err := s.confirmations.Store(ctx, action); err != nil {
+			log.Printf("Failed to store confirmation: %v", err)
+		}
+		keys[action.IdempotencyKey] = true
+		group.Actions[i] = pending
+		sess.addPending(pending.ID)
+		s.pins.addAction(sess.ConversationID, pending)
+	}
+	if err := s.confirmations.StoreGroup(ctx, group); err != nil {
+		log.Printf("Failed to store confirmation group: %v", err)
+	}
+	s.watchGroupExpiry(conn, sess, group)
+
+	sess.appendHistory(core.NewAssistantMessageWithBlocks(output.ResponseBlocks))
+
+	s.send(conn, ServerMessage{
+		Type:      "confirm_request",
+		ActionID:  group.ID,
+		Summary:   group.Summary(),
+		Actions:   confirmActions(group),
+		Content:   output.Text,
+		ExpiresAt: time.Unix(group.ExpiresAt, 0).Format(time.RFC3339),
+	})
+}
+
+// groupOf returns the ID of the group actionID names or belongs to, or ""
+// if it is a single action.
+func (s *Server) groupOf(ctx context.Context, userID, actionID string) string {
+	if _, err := s.confirmations.GetGroup(ctx, userID, actionID); err == nil {
+		return actionID
+	}
+	if action, err := s.confirmations.Get(ctx, userID, actionID); err == nil {
+		return action.GroupID
+	}
+	return ""
+}
+
+// handleConfirmGroup executes the actions of a group the user confirmed:
+// those in only, or all of them if only is empty. They run in order,
+// stopping at the first that fails; the rest are cancelled. The model is
+// given every action's outcome at once, and the user a summary of them.
+func (s *Server) handleConfirmGroup(ctx context.Context, conn peer, sess *session, userID, groupID string, only []string) {
+	log.Printf("Processing confirmation for group=%s, actions=%v, user=%s", groupID, only, userID)
+	ctx = core.WithPreferences(ctx, s.sessionPreferences(ctx, sess))
+
+	group, err := s.confirmations.GetGroup(ctx, userID, groupID)
+	if err == nil {
+		for _, id := range only {
+			if group.Action(id) == nil {
+				s.send(conn, ServerMessage{Type: "error", Code: "invalid_action_ids", ActionID: groupID, Content: fmt.Sprintf("Action %s isn't part of this request", id)})
+				return
+			}
+		}
+		group, err = s.confirmations.ConfirmGroup(ctx, userID, groupID)
+	}
+	sess.removePending(groupID)
+	if err != nil {
+		s.send(conn, ServerMessage{
+			Type:    "text",
+			Content: "Those actions expired. Would you like me to set them up again?",
+		})
+		s.complete(conn, sess, ServerMessage{})
+		return
+	}
+
+	type executed struct {
+		action *core.PendingAction
+		result *core.ToolResult
+	}
+	var (
+		results     []ActionResult
+		toolResults []core.ToolResultContent
+		succeeded   []executed
+		failed      bool
+	)
+	for _, pending := range group.Actions {
+		sess.removePending(pending.ID)
+		s.pins.removeAction(sess.ConversationID, pending.ID)
+
+		res := ActionResult{ActionID: pending.ID, Tool: pending.Tool, Summary: pending.Summary}
+		answer := core.ToolResultContent{ToolUseID: pending.BlockID, IsError: true}
+		switch {
+		case len(only) > 0 && !slices.Contains(only, pending.ID):
+			s.dropAction(ctx, userID, pending)
+			res.Status = ActionCancelled
+			answer.Content = "Cancelled by user"
+		case failed:
+			s.dropAction(ctx, userID, pending)
+			res.Status = ActionSkipped
+			answer.Content = "Not executed: an earlier action in the same request failed"
+		default:
+			action, err := s.confirmations.Confirm(ctx, userID, pending.ID)
+			if err != nil {
+				if s.config.Limits != nil {
+					s.config.Limits.Release(ctx, pending)
+				}
+				failed = true
+				res.Status = ActionFailed
+				res.Error = "it expired"
+				answer.Content = "Error: the confirmation expired before it was executed"
+				break
+			}
+			run := s.runConfirmed(ctx, conn, sess, action)
+			answer = run.toolResult(action)
+			if run.isError {
+				failed = true
+				res.Status = ActionFailed
+				switch {
+				case run.limitErr != nil:
+					res.Error = run.limitErr.Error()
+				case run.integrityErr != nil:
+					res.Error = "it no longer matches what you approved"
+				case run.duplicateErr != nil:
+					res.Error = "it had already been done"
+				default:
+					res.Error = strings.TrimPrefix(run.content, "Error: ")
+				}
+				break
+			}
+			res.Status = ActionExecuted
+			s.sendToolResult(conn, action.Tool, run.result.Data)
+			succeeded = append(succeeded, executed{action, run.result})
+		}
+		results = append(results, res)
+		// Actions raised outside the conversation have no tool_use block
+		// to answer.
+		if pending.BlockID != "" {
+			toolResults = append(toolResults, answer)
+		}
+	}
+	if len(toolResults) > 0 {
+		sess.appendHistory(core.NewToolResultMessage(toolResults))
+	}
+	if len(succeeded) > 0 {
+		// The actions may have changed the user's preferences.
+		sess.prefs = nil
+	}
+
+	text := groupResultText(results)
+	sess.appendHistory(core.NewAssistantMessage(text))
+	s.persistMessage(ctx, sess.ConversationID, "assistant", text)
+	s.send(conn, ServerMessage{Type: "text", Content: text, Results: results})
+	s.complete(conn, sess, ServerMessage{})
+	for _, e := range succeeded {
+		s.runActionHooks(ctx, e.action, e.result)
+	}
+}
+
+// handleCancelGroup cancels every action of a group.
+func (s *Server) handleCancelGroup(ctx context.Context, conn peer, sess *session, userID, groupID string) {
+	sess.removePending(groupID)
+	group, err := s.confirmations.GetGroup(ctx, userID, groupID)
+	if err != nil {
+		s.sendError(conn, "Action not found")
+		return
+	}
+	if err := s.confirmations.CancelGroup(ctx, userID, groupID); err != nil {
+		s.sendError(conn, "Failed to cancel action")
+		return
+	}
+
+	var toolResults []core.ToolResultContent
+	for _, action := range group.Actions {
+		sess.removePending(action.ID)
+		s.pins.removeAction(sess.ConversationID, action.ID)
+		if s.config.Limits != nil {
+			s.config.Limits.Release(ctx, action)
+		}
+		s.observeConfirmation(action.Tool, engine.ConfirmationCancelled)
+		if action.BlockID != "" {
+			toolResults = append(toolResults, core.ToolResultContent{ToolUseID: action.BlockID, Content: "Cancelled by user", IsError: true})
+		}
+	}
+	if len(toolResults) > 0 {
+		sess.appendHistory(core.NewToolResultMessage(toolResults))
+	}
+
+	s.send(conn, ServerMessage{Type: "text", Content: "Actions cancelled."})
+	s.complete(conn, sess, ServerMessage{})
+}
+
+// dropAction cancels one action of a confirmed group without executing it.
+func (s *Server) dropAction(ctx context.Context, userID string, action *core.PendingAction) {
+	if err := s.confirmations.Cancel(ctx, userID, action.ID); err != nil {
+		log.Printf("Failed to cancel action %s: %v", action.ID, err)
+	}
+	if s.config.Limits != nil {
+		s.config.Limits.Release(ctx, action)
+	}
+	s.observeConfirmation(action.Tool, engine.ConfirmationCancelled)
+}
+
+// groupResultText tells the user what happened to each action of a
+// confirmed group, e.g. "Done: Send 10 USDC to @alice".
+func groupResultText(results []ActionResult) string {
+	var done int
+	var b strings.Builder
+	for _, res := range results {
+		switch res.Status {
+		case ActionExecuted:
+			done++
+			fmt.Fprintf(&b, "\n- Done: %s", res.Summary)
+		case ActionFailed:
+			fmt.Fprintf(&b, "\n- Failed: %s (%s)", res.Summary, res.Error)
+		case ActionSkipped:
+			fmt.Fprintf(&b, "\n- Not done, because an earlier action failed: %s", res.Summary)
+		case ActionCancelled:
+			fmt.Fprintf(&b, "\n- Cancelled: %s", res.Summary)
+		}
+	}
+	header := fmt.Sprintf("Completed %d of %d actions:", done, len(results))
+	if done == len(results) {
+		header = "All done:"
+	}
+	return header + b.String()
+}
+
+// watchGroupExpiry tells the user when a group offered on conn expires
+// unanswered, like watchExpiry.
+func (s *Server) watchGroupExpiry(conn peer, sess *session, group *core.PendingActionGroup) {
+	if _, ok := conn.(*liveConn); !ok {
+		return
+	}
+	expiresAt := time.Unix(group.ExpiresAt, 0).Add(time.Second)
+	id, summary, conversationID := group.ID, group.Summary(), sess.ConversationID
+	actions := slices.Clone(group.Actions)
+	sess.expiries.start(id, expiresAt, func() {
+		log.Printf("[CONVERSATION %s] Group %s expired unconfirmed", conversationID, id)
+		for _, action := range actions {
+			s.pins.removeAction(conversationID, action.ID)
+			s.observeConfirmation(action.Tool, engine.ConfirmationExpired)
+		}
+		s.send(conn, ServerMessage{
+			Type:     "confirm_expired",
+			ActionID: id,
+			Summary:  summary,
+			Content:  "Those requests expired before you confirmed them, so nothing was done. Ask me again if you still want to go ahead.",
+		})
+	})
+}
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/limits"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// splitModel answers "split" by sending money to alice, bob and carol in
+// one turn, and anything else with "OK.". It keeps the messages of each
+// request.
+type splitModel struct {
+	mu       sync.Mutex
+	requests [][]json.RawMessage
+}
+
+func (m *splitModel) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Messages []json.RawMessage `json:"messages"`
+		}
+		json.Unmarshal(body, &req)
+		m.mu.Lock()
+		m.requests = append(m.requests, req.Messages)
+		m.mu.Unlock()
+
+		content := `[{"type":"text","text":"OK."}]`
+		stop := "end_turn"
+		if last := string(req.Messages[len(req.Messages)-1]); strings.Contains(last, "split") {
+			var blocks []string
+			for i, recipient := range []string{"@alice", "@bob", "@carol"} {
+				blocks = append(blocks, fmt.Sprintf(`{"type":"tool_use","id":"toolu_%d","name":"send_money","input":{"recipient":%q,"amount":"10","currency":"USDC"}}`, i+1, recipient))
+			}
+			content = "[" + strings.Join(blocks, ",") + "]"
+			stop = "tool_use"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":%s,"stop_reason":%q,"usage":{"input_tokens":1,"output_tokens":1}}`, content, stop)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// lastMessages returns the messages of the latest request.
+func (m *splitModel) lastMessages() []json.RawMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.requests[len(m.requests)-1]
+}
+
+func TestConfirmGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		confirm  func(offer ServerMessage) ClientMessage
+		want     []string // each action's status, in order
+		executed []string
+	}{
+		{
+			name: "all, second fails",
+			confirm: func(offer ServerMessage) ClientMessage {
+				return ClientMessage{Type: "confirm", ActionID: offer.ActionID}
+			},
+			want:     []string{ActionExecuted, ActionFailed, ActionSkipped},
+			executed: []string{"@alice", "@bob"},
+		},
+		{
+			name: "subset",
+			confirm: func(offer ServerMessage) ClientMessage {
+				return ClientMessage{Type: "confirm", ActionID: offer.ActionID, ActionIDs: []string{offer.Actions[0].ID, offer.Actions[2].ID}}
+			},
+			want:     []string{ActionExecuted, ActionCancelled, ActionExecuted},
+			executed: []string{"@alice", "@carol"},
+		},
+		{
+			name: "one action",
+			confirm: func(offer ServerMessage) ClientMessage {
+				return ClientMessage{Type: "confirm", ActionID: offer.Actions[2].ID}
+			},
+			want:     []string{ActionCancelled, ActionCancelled, ActionExecuted},
+			executed: []string{"@carol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &splitModel{}
+			s, executed := newSplitServer(t, model)
+			c := dial(t, s)
+			c.send(ClientMessage{Type: "new_conversation"})
+			c.read("conversation_started")
+			c.send(ClientMessage{Type: "message", Content: "split 30 with alice, bob and carol"})
+
+			offer := c.read("confirm_request")
+			if len(offer.Actions) != 3 {
+				t.Fatalf("offered %d actions, want 3", len(offer.Actions))
+			}
+			if offer.Summary != "Send 10 USDC to @alice; Send 10 USDC to @bob; Send 10 USDC to @carol" {
+				t.Errorf("summary = %q", offer.Summary)
+			}
+
+			c.send(tt.confirm(offer))
+			text := c.read("text")
+			c.read("complete")
+			var got []string
+			for i, res := range text.Results {
+				got = append(got, res.Status)
+				if res.ActionID != offer.Actions[i].ID {
+					t.Errorf("result %d is for %s, want %s", i, res.ActionID, offer.Actions[i].ID)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("statuses = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(executed(), tt.executed) {
+				t.Errorf("executed %v, want %v", executed(), tt.executed)
+			}
+
+			// The model is given all three outcomes in one message.
+			c.send(ClientMessage{Type: "message", Content: "thanks"})
+			c.read("complete")
+			answered := 0
+			for _, msg := range model.lastMessages() {
+				if n := strings.Count(string(msg), `"tool_result"`); n > 0 {
+					answered++
+					if n != 3 {
+						t.Errorf("tool_result message answers %d blocks, want 3: %s", n, msg)
+					}
+				}
+			}
+			if answered != 1 {
+				t.Errorf("%d tool_result messages, want 1", answered)
+			}
+		})
+	}
+}
+
+func TestConfirmGroup_FailureReported(t *testing.T) {
+	s, _ := newSplitServer(t, &splitModel{})
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "split 30 with alice, bob and carol"})
+	offer := c.read("confirm_request")
+	c.send(ClientMessage{Type: "confirm", ActionID: offer.ActionID})
+
+	text := c.read("text")
+	for _, want := range []string{"Completed 1 of 3", "Done: Send 10 USDC to @alice", "Failed: Send 10 USDC to @bob (recipient not found)", "earlier action failed: Send 10 USDC to @carol"} {
+		if !strings.Contains(text.Content, want) {
+			t.Errorf("text %q doesn't contain %q", text.Content, want)
+		}
+	}
+
+	// The group is settled: confirming it again finds nothing.
+	c.read("complete")
+	c.send(ClientMessage{Type: "confirm", ActionID: offer.ActionID})
+	if got := c.read("text").Content; !strings.Contains(got, "expired") {
+		t.Errorf("confirming again answered %q", got)
+	}
+}
+
+func TestConfirmGroup_ExpiredReleasesLimits(t *testing.T) {
+	ctx := context.Background()
+	ledger := limits.NewMemoryLedger()
+	s, _ := newSplitServerWith(t, &splitModel{}, Config{
+		Limits: limits.New(limits.Config{CountReserved: true, Ledger: ledger}),
+	})
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "split 30 with alice, bob and carol"})
+	offer := c.read("confirm_request")
+
+	// Bob's action expires before the group is confirmed.
+	if err := s.confirmations.Cancel(ctx, "user-1", offer.Actions[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	c.send(ClientMessage{Type: "confirm", ActionID: offer.ActionID})
+	if text := c.read("text").Content; !strings.Contains(text, "Failed: Send 10 USDC to @bob (it expired)") {
+		t.Errorf("text %q doesn't report the expired action", text)
+	}
+
+	entries, err := ledger.Entries(ctx, "user-1", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Reserved {
+			t.Errorf("reservation %s for %s %s left after the group settled", entry.ID, entry.Amount, entry.Currency)
+		}
+	}
+}
+
+func TestCancelGroup(t *testing.T) {
+	model := &splitModel{}
+	s, executed := newSplitServer(t, model)
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "split 30 with alice, bob and carol"})
+	offer := c.read("confirm_request")
+
+	c.send(ClientMessage{Type: "cancel", ActionID: offer.Actions[1].ID})
+	if got := c.read("text").Content; got != "Actions cancelled." {
+		t.Errorf("cancel answered %q", got)
+	}
+	c.read("complete")
+	if len(executed()) != 0 {
+		t.Errorf("executed %v after cancelling", executed())
+	}
+	for _, action := range offer.Actions {
+		if _, err := s.confirmations.Get(context.Background(), "user-1", action.ID); err == nil {
+			t.Errorf("action %s still pending", action.ID)
+		}
+	}
+}
+
+func TestConfirmGroup_REST(t *testing.T) {
+	s, executed := newSplitServer(t, &splitModel{})
+	srv := httptest.NewServer(s.routes())
+	t.Cleanup(srv.Close)
+
+	_, chat := post(t, srv, "/v1/chat", "user-1", `{"message":"split 30 with alice, bob and carol"}`)
+	if chat.PendingAction == nil || len(chat.PendingAction.Actions) != 3 {
+		t.Fatalf("pending action = %+v, want a group of 3", chat.PendingAction)
+	}
+	carol := chat.PendingAction.Actions[2].ID
+	_, chat = post(t, srv, "/v1/confirm/"+chat.PendingAction.ID, "user-1", fmt.Sprintf(`{"action_ids":[%q]}`, carol))
+	if len(chat.Results) != 3 || chat.Results[2].Status != ActionExecuted || chat.Results[0].Status != ActionCancelled {
+		t.Errorf("results = %+v", chat.Results)
+	}
+	if !slices.Equal(executed(), []string{"@carol"}) {
+		t.Errorf("executed %v, want [@carol]", executed())
+	}
+}
+
+// newSplitServer returns a server whose send_money fails for @bob, and a
+// func listing the recipients it was called for.
+func newSplitServer(t *testing.T, model *splitModel) (*Server, func() []string) {
+	t.Helper()
+	return newSplitServerWith(t, model, Config{})
+}
+
+// newSplitServerWith is newSplitServer with cfg's other settings.
+func newSplitServerWith(t *testing.T, model *splitModel, cfg Config) (*Server, func() []string) {
+	t.Helper()
+	cfg.AnthropicKey = "test"
+	cfg.BaseURL = model.serve(t).URL
+	cfg.DisableStreaming = true
+	cfg.AuthFunc = func(r *http.Request) (string, error) { return "user-1", nil }
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var recipients []string
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		SummaryTemplate("Send {{.amount}} {{.currency}} to {{.recipient}}").
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				Recipient string `json:"recipient"`
+			}
+			json.Unmarshal(params.Input, &input)
+			mu.Lock()
+			recipients = append(recipients, input.Recipient)
+			mu.Unlock()
+			if input.Recipient == "@bob" {
+				return &core.ToolResult{Success: false, Error: "recipient not found"}, nil
+			}
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{"message": "Sent"}}, nil
+		}).
+		Build())
+	return s, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(recipients)
+	}
+}
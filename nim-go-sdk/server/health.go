@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// defaultHealthCheckInterval is how often dependencies are checked unless
+// Config.HealthCheckInterval says otherwise.
+const defaultHealthCheckInterval = 30 * time.Second
+
+// healthCheckTimeout bounds each health check.
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheck is a dependency the server needs to serve traffic, checked
+// for /health/ready.
+type HealthCheck struct {
+	// Name identifies the dependency in /health/ready's response, e.g.
+	// "postgres".
+	Name string
+
+	// Check returns an error if the dependency is unusable. ctx expires
+	// after a few seconds.
+	Check func(ctx context.Context) error
+}
+
+// HealthStatus is the body of a /health/ready response.
+type HealthStatus struct {
+	Status string `json:"status"` // "ready", "unavailable" or "starting"
+
+	// Failing maps each failing check's name to its error.
+	Failing map[string]string `json:"failing,omitempty"`
+
+	// CheckedAt is when the checks last ran, in RFC 3339, unless they
+	// haven't yet.
+	CheckedAt string `json:"checkedAt,omitempty"`
+}
+
+// health runs the server's health checks in the background and remembers
+// their results, so readiness probes don't hit the dependencies.
+type health struct {
+	checks []HealthCheck
+
+	mu      sync.Mutex
+	checked time.Time
+	failing map[string]string
+}
+
+// healthChecks returns the checks for the server's dependencies: the
+// Anthropic API, the Liminal gateway if there's an executor, and stores
+// implementing store.Pinger, followed by Config.HealthChecks.
+func healthChecks(cfg Config, client *anthropic.Client, conversations store.Conversations, confirmations store.Confirmations) []HealthCheck {
+	checks := []HealthCheck{{
+		Name: "anthropic",
+		Check: func(ctx context.Context) error {
+			// The cheapest authenticated call there is.
+			_, err := client.Models.List(ctx, anthropic.ModelListParams{Limit: anthropic.Int(1)}, option.WithMaxRetries(0))
+			return err
+		},
+	}}
+	if cfg.LiminalExecutor != nil {
+		checks = append(checks, HealthCheck{Name: "liminal", Check: cfg.LiminalExecutor.Ping})
+	}
+	if pinger, ok := conversations.(store.Pinger); ok {
+		checks = append(checks, HealthCheck{Name: "conversations", Check: pinger.Ping})
+	}
+	if pinger, ok := confirmations.(store.Pinger); ok {
+		checks = append(checks, HealthCheck{Name: "confirmations", Check: pinger.Ping})
+	}
+	return append(checks, cfg.HealthChecks...)
+}
+
+// run checks now and then every interval until ctx is cancelled or done
+// is closed.
+func (h *health) run(ctx context.Context, interval time.Duration, done <-chan struct{}) {
+	h.check(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			h.check(ctx)
+		}
+	}
+}
+
+// check runs every check at once and records the failures.
+func (h *health) check(ctx context.Context) {
+	var mu sync.Mutex
+	failing := make(map[string]string)
+	var wg sync.WaitGroup
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			if err := c.Check(ctx); err != nil {
+				mu.Lock()
+				failing[c.Name] = err.Error()
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, err := range failing {
+		if _, known := h.failing[name]; !known {
+			log.Printf("Health check %s failing: %s", name, err)
+		}
+	}
+	for name := range h.failing {
+		if _, still := failing[name]; !still {
+			log.Printf("Health check %s recovered", name)
+		}
+	}
+	h.checked, h.failing = time.Now(), failing
+}
+
+// status reports the latest results.
+func (h *health) status() HealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case h.checked.IsZero():
+		return HealthStatus{Status: "starting"}
+	case len(h.failing) > 0:
+		return HealthStatus{Status: "unavailable", Failing: h.failing, CheckedAt: h.checked.Format(time.RFC3339)}
+	}
+	return HealthStatus{Status: "ready", CheckedAt: h.checked.Format(time.RFC3339)}
+}
+
+// handleReady answers readiness probes from the latest results: 200 if
+// every check passed, and 503 if any failed or none have run yet.
+func (h *health) handleReady(w http.ResponseWriter, r *http.Request) {
+	status := h.status()
+	w.Header().Set("Content-Type", "application/json")
+	if status.Status != "ready" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// handleLive answers liveness probes: the process is up.
+func handleLive(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// getReady fetches /health/ready.
+func getReady(t *testing.T, addr string) (int, HealthStatus) {
+	t.Helper()
+	resp, err := http.Get("http://" + addr + "/health/ready")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var status HealthStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, status
+}
+
+// awaitReady polls /health/ready until it answers with code, failing the
+// test after a few seconds.
+func awaitReady(t *testing.T, addr string, code int) HealthStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, status := getReady(t, addr)
+		if got == code {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("/health/ready = %d %+v, want %d", got, status, code)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHealthReady(t *testing.T) {
+	var gatewayDown atomic.Bool
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gatewayDown.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer gateway.Close()
+
+	var ledgerDown atomic.Bool
+	s := newShutdownServer(t, Config{
+		LiminalExecutor:     executor.NewHTTPExecutor(executor.HTTPExecutorConfig{BaseURL: gateway.URL}),
+		HealthCheckInterval: 10 * time.Millisecond,
+		HealthChecks: []HealthCheck{{
+			Name: "ledger",
+			Check: func(ctx context.Context) error {
+				if ledgerDown.Load() {
+					return errors.New("connection refused")
+				}
+				return nil
+			},
+		}},
+	})
+	addr, _ := startServer(t, s, make(chan struct{}), make(chan struct{}))
+
+	if status := awaitReady(t, addr, http.StatusOK); status.Status != "ready" || len(status.Failing) != 0 {
+		t.Errorf("ready status = %+v", status)
+	}
+
+	ledgerDown.Store(true)
+	gatewayDown.Store(true)
+	status := awaitReady(t, addr, http.StatusServiceUnavailable)
+	if status.Status != "unavailable" || status.Failing["ledger"] != "connection refused" || status.Failing["liminal"] == "" || status.Failing["anthropic"] != "" {
+		t.Errorf("unavailable status = %+v, want the ledger and liminal failing", status)
+	}
+
+	// Liveness doesn't depend on the checks.
+	resp, err := http.Get("http://" + addr + "/health/live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("/health/live = %d while dependencies are down, want 200", resp.StatusCode)
+	}
+
+	ledgerDown.Store(false)
+	gatewayDown.Store(false)
+	awaitReady(t, addr, http.StatusOK)
+}
+
+func TestHealthReady_Starting(t *testing.T) {
+	// Without Run, nothing has been checked yet.
+	s := newShutdownServer(t, Config{})
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	var status HealthStatus
+	json.NewDecoder(rec.Body).Decode(&status)
+	if rec.Code != http.StatusServiceUnavailable || status.Status != "starting" {
+		t.Errorf("/health/ready = %d %+v, want 503 starting", rec.Code, status)
+	}
+}
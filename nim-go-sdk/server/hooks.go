@@ -0,0 +1,32 @@
+package server
+
+import (
+	"context"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// ActionHook is called after a confirmed action executes successfully,
+// e.g. to check the user's budget after a payment and Deliver an alert.
+// It runs after the user has been sent the result, before their next
+// message is handled, so slow work belongs in a goroutine.
+type ActionHook func(ctx context.Context, action *core.PendingAction, result *core.ToolResult)
+
+// AddActionHook registers a hook to run after every confirmed action,
+// whether confirmed over WebSocket, SSE, REST or by a natural-language
+// reply. Hooks run in the order added. Add them before the server starts.
+func (s *Server) AddActionHook(hook ActionHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actionHooks = append(s.actionHooks, hook)
+}
+
+// runActionHooks runs the action hooks for an executed action.
+func (s *Server) runActionHooks(ctx context.Context, action *core.PendingAction, result *core.ToolResult) {
+	s.mu.Lock()
+	hooks := s.actionHooks
+	s.mu.Unlock()
+	for _, hook := range hooks {
+		hook(ctx, action, result)
+	}
+}
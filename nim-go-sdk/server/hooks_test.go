@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func TestActionHooks(t *testing.T) {
+	model := &sendModel{}
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          model.serve(t).URL,
+		DisableStreaming: true,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Payments to bob fail.
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			if strings.Contains(string(params.Input), "@bob") {
+				return nil, errors.New("insufficient funds")
+			}
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{"message": "Sent"}}, nil
+		}).
+		Build())
+
+	var mu sync.Mutex
+	var hooked []string
+	s.AddActionHook(func(ctx context.Context, action *core.PendingAction, result *core.ToolResult) {
+		mu.Lock()
+		hooked = append(hooked, action.Tool)
+		mu.Unlock()
+		s.Deliver(ctx, action.UserID, ServerMessage{Type: "budget_alert", Content: "You've used 80% of your weekly budget.", Threshold: 80})
+	})
+
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+
+	for _, recipient := range []string{"bob", "alice"} {
+		c.send(ClientMessage{Type: "message", Content: "send 50 to " + recipient})
+		actionID := c.read("confirm_request").ActionID
+		c.send(ClientMessage{Type: "confirm", ActionID: actionID})
+		for _, msg := range c.readUntil("complete") {
+			if msg.Type == "budget_alert" {
+				t.Errorf("budget_alert before the %s confirmation completed", recipient)
+			}
+		}
+	}
+	alert := c.read("budget_alert")
+	if alert.Threshold != 80 || alert.Content == "" {
+		t.Errorf("budget_alert = %+v", alert)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(hooked) != 1 || hooked[0] != "send_money" {
+		t.Errorf("hooks ran for %v, want only the successful send_money", hooked)
+	}
+}
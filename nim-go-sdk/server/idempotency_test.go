@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func TestIdempotentConfirmations(t *testing.T) {
+	tests := []struct {
+		name     string
+		steps    []string // "ask <recipient>" offers a transfer, "confirm <n>" confirms the nth offer
+		wantSame bool     // whether the offers share an action
+		wantText []string // the answers to each confirmation
+		executed int
+	}{
+		{
+			name:     "duplicate confirmation",
+			steps:    []string{"ask alice", "ask alice", "confirm 0", "confirm 1"},
+			wantSame: true,
+			wantText: []string{"Sent", "That action expired. Would you like me to set it up again?"},
+			executed: 1,
+		},
+		{
+			name:     "duplicate after success",
+			steps:    []string{"ask alice", "confirm 0", "ask alice", "confirm 1"},
+			wantText: []string{"Sent", "That's already been done, so I didn't do it again."},
+			executed: 1,
+		},
+		{
+			name:     "different input",
+			steps:    []string{"ask alice", "ask bob", "confirm 0", "confirm 1"},
+			wantText: []string{"Sent", "Sent"},
+			executed: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &sendModel{}
+			s, err := New(Config{
+				AnthropicKey:     "test",
+				BaseURL:          model.serve(t).URL,
+				DisableStreaming: true,
+				AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var mu sync.Mutex
+			executed := 0
+			s.AddTool(tools.New("send_money").
+				RequiresConfirmation().
+				Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					mu.Lock()
+					executed++
+					mu.Unlock()
+					return &core.ToolResult{Success: true, Data: map[string]interface{}{"message": "Sent"}}, nil
+				}).
+				Build())
+
+			c := dial(t, s)
+			c.send(ClientMessage{Type: "new_conversation"})
+			c.read("conversation_started")
+
+			var offers []string
+			var texts []string
+			for _, step := range tt.steps {
+				verb, arg, _ := strings.Cut(step, " ")
+				if verb == "ask" {
+					c.send(ClientMessage{Type: "message", Content: "send 50 to " + arg})
+					offers = append(offers, c.read("confirm_request").ActionID)
+					continue
+				}
+				n, _ := strconv.Atoi(arg)
+				c.send(ClientMessage{Type: "confirm", ActionID: offers[n]})
+				texts = append(texts, c.read("text").Content)
+				c.read("complete")
+			}
+
+			if same := offers[0] == offers[1]; same != tt.wantSame {
+				t.Errorf("offers %v share an action = %v, want %v", offers, same, tt.wantSame)
+			}
+			for i, want := range tt.wantText {
+				if texts[i] != want {
+					t.Errorf("confirmation %d answered with %q, want %q", i, texts[i], want)
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if executed != tt.executed {
+				t.Errorf("executed %d times, want %d", executed, tt.executed)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/schedule"
+)
+
+// jobTickInterval is how often Run checks for due jobs. Schedules match on
+// the minute, so jobs start up to this late.
+const jobTickInterval = 10 * time.Second
+
+// Job runs the agent for a set of users on a schedule, e.g. a weekly
+// spending summary or a low-balance check. Each user's reply is pushed to
+// their open connections as a "proactive" message, or passed to
+// Config.Notifier if they have none.
+//
+// Jobs run without a client, so they can't ask for confirmation: write
+// tools are refused, and the agent can only suggest the action for the user
+// to take later. Guardrails apply as for any other run. Replies aren't added
+// to a conversation.
+type Job struct {
+	// Name identifies the job in logs and "proactive" messages.
+	Name string
+
+	// Schedule is when the job runs, as a cron expression such as
+	// "0 9 * * MON", a descriptor such as "@daily", or an interval such as
+	// "@every 6h". See schedule.ParseCron.
+	Schedule string
+
+	// Location is the time zone Schedule is in. Defaults to UTC.
+	Location *time.Location
+
+	// Users lists the users to run the job for. It is asked afresh each time
+	// the job runs.
+	Users UserSource
+
+	// Input returns the agent input for a user, usually just a UserMessage
+	// such as "Summarize my spending this week". A nil input skips the user.
+	// The server sets the input's Context, which runs as userID and can't
+	// confirm, and its SystemPrompt if empty.
+	Input func(ctx context.Context, userID string) (*engine.Input, error)
+}
+
+// UserSource lists the users a Job runs for.
+type UserSource interface {
+	Users(ctx context.Context) ([]string, error)
+}
+
+// UserList is a fixed list of users.
+type UserList []string
+
+// Users implements UserSource.
+func (l UserList) Users(ctx context.Context) ([]string, error) {
+	return l, nil
+}
+
+// Notifier delivers job replies to users without an open connection, e.g.
+// by push notification or email.
+type Notifier interface {
+	Notify(ctx context.Context, userID string, msg ServerMessage) error
+}
+
+// jobRunner runs Config.Jobs on their schedules.
+type jobRunner struct {
+	s    *Server
+	jobs []*scheduledJob
+	now  func() time.Time
+	wg   sync.WaitGroup // job runs in flight
+}
+
+type scheduledJob struct {
+	Job
+	cron    *schedule.Cron
+	next    time.Time
+	running atomic.Bool // a run is in flight; overlapping runs are skipped
+}
+
+// newJobRunner parses the jobs' schedules, returning nil if there are none.
+func newJobRunner(s *Server, jobs []Job, now func() time.Time) (*jobRunner, error) {
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	r := &jobRunner{s: s, now: now}
+	for _, job := range jobs {
+		if job.Users == nil || job.Input == nil {
+			return nil, fmt.Errorf("job %q: Users and Input are required", job.Name)
+		}
+		cron, err := schedule.ParseCron(job.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("job %q: %w", job.Name, err)
+		}
+		if job.Location == nil {
+			job.Location = time.UTC
+		}
+		sj := &scheduledJob{Job: job, cron: cron}
+		sj.next = cron.Next(now().In(job.Location))
+		r.jobs = append(r.jobs, sj)
+	}
+	return r, nil
+}
+
+// Run starts due jobs every interval until ctx is cancelled.
+func (r *jobRunner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.tick(ctx)
+		}
+	}
+}
+
+// tick starts every job that has come due since the last tick. A job still
+// running from its previous time is skipped, and missed times are not
+// caught up.
+func (r *jobRunner) tick(ctx context.Context) {
+	now := r.now()
+	for _, job := range r.jobs {
+		if job.next.IsZero() || now.Before(job.next) {
+			continue
+		}
+		job.next = job.cron.Next(now.In(job.Location))
+		if !job.running.CompareAndSwap(false, true) {
+			log.Printf("Skipping job %s: its previous run is still in progress", job.Name)
+			continue
+		}
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			defer job.running.Store(false)
+			r.s.runJob(ctx, &job.Job)
+		}()
+	}
+}
+
+// runJob runs a job for each of its users in turn.
+func (s *Server) runJob(ctx context.Context, job *Job) {
+	users, err := job.Users.Users(ctx)
+	if err != nil {
+		log.Printf("Failed to list users for job %s: %v", job.Name, err)
+		return
+	}
+	log.Printf("Running job %s for %d users", job.Name, len(users))
+	for _, userID := range users {
+		if ctx.Err() != nil || !s.beginRun() {
+			return
+		}
+		s.runJobFor(ctx, job, userID)
+		s.endRun()
+	}
+}
+
+// runJobFor runs a job for one user and delivers the reply.
+func (s *Server) runJobFor(ctx context.Context, job *Job, userID string) {
+	ctx, cancel := s.messageContext(ctx, userID, nil)
+	defer cancel()
+
+	ctx, err := s.config.Credentials.Attach(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to get credentials for job %s, user %s: %v", job.Name, userID, err)
+		return
+	}
+	if s.config.LiminalExecutor != nil && core.CredentialFromContext(ctx) == "" {
+		// Never act for the user with LiminalExecutor's own token.
+		log.Printf("Skipping job %s for user %s: no credentials", job.Name, userID)
+		return
+	}
+
+	input, err := job.Input(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to build input for job %s, user %s: %v", job.Name, userID, err)
+		return
+	}
+	if input == nil {
+		return
+	}
+
+	// Copy the context and limits, which the job may share between users.
+	agentCtx := core.NewContext(userID, "", "", core.RequestIDFromContext(ctx))
+	if input.Context != nil {
+		*agentCtx = *input.Context
+		agentCtx.UserID = userID
+	}
+	limits := *core.DefaultLimits()
+	if agentCtx.Limits != nil {
+		limits = *agentCtx.Limits
+	}
+	limits.CanConfirm = false
+	agentCtx.Limits = &limits
+	input.Context = agentCtx
+	if input.SystemPrompt == "" {
+		input.SystemPrompt = s.config.SystemPrompt
+	}
+
+	output, err := s.engine.Run(ctx, input)
+	if err != nil {
+		log.Printf("Failed to run job %s for user %s: %v", job.Name, userID, err)
+		return
+	}
+	if output.Type == engine.OutputError {
+		log.Printf("Failed to run job %s for user %s: %v", job.Name, userID, output.Error)
+		return
+	}
+	if output.Type != engine.OutputComplete || output.Text == "" {
+		return
+	}
+	s.Deliver(ctx, userID, ServerMessage{Type: "proactive", Job: job.Name, Content: output.Text})
+}
+
+// Deliver sends msg to every open connection of the user's, or to
+// Config.Notifier if they have none. Applications can use it to push their
+// own messages, such as a "budget_alert" from an ActionHook.
+func (s *Server) Deliver(ctx context.Context, userID string, msg ServerMessage) {
+	delivered := false
+	for _, lc := range s.liveConns() {
+		if lc.userID == userID {
+			s.send(lc, msg)
+			delivered = true
+		}
+	}
+	for _, st := range s.liveStreams() {
+		if st.userID == userID {
+			s.send(st, msg)
+			delivered = true
+		}
+	}
+	if delivered {
+		return
+	}
+	if s.config.Notifier == nil {
+		log.Printf("Dropping %s message for offline user %s: no Notifier configured", msg.Type, userID)
+		return
+	}
+	if err := s.config.Notifier.Notify(ctx, userID, msg); err != nil {
+		log.Printf("Failed to notify user %s: %v", userID, err)
+	}
+}
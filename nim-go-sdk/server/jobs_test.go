@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// recordingNotifier records the messages for offline users.
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent map[string][]ServerMessage
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, userID string, msg ServerMessage) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.sent == nil {
+		n.sent = make(map[string][]ServerMessage)
+	}
+	n.sent[userID] = append(n.sent[userID], msg)
+	return nil
+}
+
+func (n *recordingNotifier) messages(userID string) []ServerMessage {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.sent[userID]
+}
+
+// blockUser denies one user and allows everyone else.
+type blockUser struct {
+	engine.NoOpGuardrails
+	userID string
+}
+
+func (g *blockUser) Check(ctx context.Context, userID string) (*engine.GuardrailResult, error) {
+	return &engine.GuardrailResult{Allowed: userID != g.userID, Warning: "blocked"}, nil
+}
+
+// fakeClock is a settable time source.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+func TestJobs(t *testing.T) {
+	model := &sendModel{}
+	notifier := &recordingNotifier{}
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          model.serve(t).URL,
+		DisableStreaming: true,
+		Guardrails:       &blockUser{userID: "blocked"},
+		Notifier:         notifier,
+		AuthFunc:         func(r *http.Request) (string, error) { return "online", nil },
+		Credentials: func(ctx context.Context, userID string) (string, error) {
+			return "token-" + userID, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	executed := 0
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			executed++
+			return &core.ToolResult{Success: true}, nil
+		}).
+		Build())
+
+	var inputs []string
+	clock := &fakeClock{now: time.Date(2026, time.October, 17, 8, 59, 30, 0, time.UTC)}
+	s.jobs, err = newJobRunner(s, []Job{{
+		Name:     "daily_nudge",
+		Schedule: "0 9 * * *",
+		Users:    UserList{"online", "offline", "blocked"},
+		Input: func(ctx context.Context, userID string) (*engine.Input, error) {
+			inputs = append(inputs, userID)
+			if got := core.CredentialFromContext(ctx); got != "token-"+userID {
+				t.Errorf("job for %s ran with credential %q", userID, got)
+			}
+			// The model asks to send money, which the job can't confirm.
+			return &engine.Input{UserMessage: "send 50 to alice"}, nil
+		},
+	}}, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+
+	ctx := context.Background()
+	s.jobs.tick(ctx)
+	s.jobs.wg.Wait()
+	if len(inputs) != 0 {
+		t.Fatalf("job ran before 9am for %v", inputs)
+	}
+
+	clock.set(time.Date(2026, time.October, 17, 9, 0, 5, 0, time.UTC))
+	s.jobs.tick(ctx)
+	s.jobs.wg.Wait()
+	if got := strings.Join(inputs, ","); got != "online,offline,blocked" {
+		t.Fatalf("job ran for %s", got)
+	}
+
+	if msg := c.read("proactive"); msg.Job != "daily_nudge" || msg.Content != "OK." {
+		t.Errorf("proactive message = %+v", msg)
+	}
+	if got := notifier.messages("offline"); len(got) != 1 || got[0].Type != "proactive" || got[0].Content != "OK." {
+		t.Errorf("notified offline user with %+v", got)
+	}
+	if got := notifier.messages("online"); len(got) != 0 {
+		t.Errorf("notified connected user with %+v", got)
+	}
+	if got := notifier.messages("blocked"); len(got) != 0 {
+		t.Errorf("guardrails let the job reach a blocked user: %+v", got)
+	}
+	if executed != 0 {
+		t.Errorf("send_money executed %d times, want 0", executed)
+	}
+	if !strings.Contains(model.last(), "requires user confirmation") {
+		t.Errorf("model wasn't told the write needs confirmation: %s", model.last())
+	}
+
+	// Not again until tomorrow.
+	s.jobs.tick(ctx)
+	s.jobs.wg.Wait()
+	clock.set(time.Date(2026, time.October, 18, 8, 0, 0, 0, time.UTC))
+	s.jobs.tick(ctx)
+	s.jobs.wg.Wait()
+	if len(inputs) != 3 {
+		t.Errorf("job ran %d times, want once per user", len(inputs))
+	}
+}
+
+func TestJobs_NoCredentials(t *testing.T) {
+	s, err := New(Config{
+		AnthropicKey:    "test",
+		LiminalExecutor: executor.NewHTTPExecutor(executor.HTTPExecutorConfig{BaseURL: "http://gateway.invalid", JWTToken: "shared"}),
+		Credentials: func(ctx context.Context, userID string) (string, error) {
+			if userID == "unknown" {
+				return "", errors.New("no token for user")
+			}
+			return "", nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ran []string
+	job := &Job{Name: "nudge", Input: func(ctx context.Context, userID string) (*engine.Input, error) {
+		ran = append(ran, userID)
+		return nil, nil
+	}}
+	s.runJobFor(context.Background(), job, "unknown")
+	s.runJobFor(context.Background(), job, "tokenless")
+	s.config.Credentials = nil
+	s.runJobFor(context.Background(), job, "unconfigured")
+	if len(ran) != 0 {
+		t.Errorf("jobs ran without the user's credentials for %v", ran)
+	}
+}
+
+func TestJobs_Invalid(t *testing.T) {
+	input := func(ctx context.Context, userID string) (*engine.Input, error) { return nil, nil }
+	tests := []struct {
+		name string
+		job  Job
+		want string
+	}{
+		{"bad schedule", Job{Name: "j", Schedule: "0 25 * * *", Users: UserList{"u"}, Input: input}, `job "j": invalid schedule`},
+		{"no users", Job{Name: "j", Schedule: "@daily", Input: input}, "Users and Input are required"},
+		{"no input", Job{Name: "j", Schedule: "@daily", Users: UserList{"u"}}, "Users and Input are required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(Config{AnthropicKey: "test", Jobs: []Job{tt.job}})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New = %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
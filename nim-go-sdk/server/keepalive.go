@@ -0,0 +1,56 @@
+package server
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Keepalive defaults. See Config.PingInterval and Config.IdleTimeout.
+const (
+	defaultPingInterval = 30 * time.Second
+	pingWriteTimeout    = 10 * time.Second
+)
+
+// keepaliveTimes returns the ping interval and idle timeout, applying
+// defaults.
+func (s *Server) keepaliveTimes() (ping, idle time.Duration) {
+	ping = s.config.PingInterval
+	if ping <= 0 {
+		ping = defaultPingInterval
+	}
+	idle = s.config.IdleTimeout
+	if idle <= 0 {
+		idle = 2 * ping
+	}
+	return ping, idle
+}
+
+// startKeepalive pings conn every interval until stop is called. The
+// client's pongs extend the read deadline by idle, so a client that stops
+// answering is disconnected by readFrames once the deadline passes.
+func startKeepalive(conn *websocket.Conn, interval, idle time.Duration) (stop func()) {
+	// Pong handlers run on the reading goroutine, inside ReadMessage.
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(idle))
+	})
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// WriteControl is safe to call alongside the connection's
+				// other writes.
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pingWriteTimeout)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func keepaliveServer(t *testing.T) *Server {
+	t.Helper()
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          replyModel(t, "OK.").URL,
+		DisableStreaming: true,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+		PingInterval:     20 * time.Millisecond,
+		IdleTimeout:      100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestKeepalive_UnresponsiveClient(t *testing.T) {
+	s := keepaliveServer(t)
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	if n := liveSessions(s); n != 1 {
+		t.Fatalf("%d live sessions, want 1", n)
+	}
+
+	// The client stops answering pings.
+	c.conn.SetPingHandler(func(string) error { return nil })
+	start := time.Now()
+	var err error
+	for err == nil {
+		_, _, err = c.conn.ReadMessage()
+	}
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("read %v, want a going away close frame", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("closed after %v, want about the 100ms idle timeout", elapsed)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for liveSessions(s) != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := liveSessions(s); n != 0 {
+		t.Errorf("%d live sessions after the idle timeout, want 0", n)
+	}
+}
+
+func TestKeepalive_ResponsiveClient(t *testing.T) {
+	s := keepaliveServer(t)
+	c := dial(t, s)
+
+	// Reading answers the server's pings.
+	messages := make(chan ServerMessage)
+	go func() {
+		defer close(messages)
+		for {
+			var msg ServerMessage
+			if err := c.conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			messages <- msg
+		}
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+	c.send(ClientMessage{Type: "new_conversation"})
+	msg, ok := <-messages
+	if !ok || msg.Type != "conversation_started" {
+		t.Errorf("after idling, got %+v (open %v), want conversation_started", msg, ok)
+	}
+}
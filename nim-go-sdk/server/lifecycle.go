@@ -0,0 +1,292 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// defaultShutdownTimeout is how long RunWithContext waits for in-flight
+// runs by default.
+const defaultShutdownTimeout = 30 * time.Second
+
+// closeWriteTimeout bounds writing the close frame to a client.
+const closeWriteTimeout = time.Second
+
+// peer is where server messages for a client go: its WebSocket, or the
+// response to a REST request.
+type peer interface {
+	writeMessage(msg ServerMessage) error
+}
+
+// connection is a connected client: a WebSocket, or an SSE stream. Server
+// messages sent while one of its messages is being handled reply to it.
+type connection interface {
+	peer
+
+	// replyingTo stamps messages with id until the returned func is called.
+	replyingTo(id string) (done func())
+}
+
+// liveConn is an open WebSocket connection.
+type liveConn struct {
+	conn   *websocket.Conn
+	userID string
+	mu     sync.Mutex // serializes writes
+	cancel context.CancelFunc
+
+	// replyTo is the ID of the client message being handled, stamped on
+	// every message sent until it is done.
+	replyTo string
+}
+
+func (c *liveConn) writeMessage(msg ServerMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if msg.ReplyTo == "" {
+		msg.ReplyTo = c.replyTo
+	}
+	return c.conn.WriteJSON(msg)
+}
+
+// replyingTo stamps messages with id until the returned func is called.
+func (c *liveConn) replyingTo(id string) (done func()) {
+	c.mu.Lock()
+	c.replyTo = id
+	c.mu.Unlock()
+	return func() {
+		c.mu.Lock()
+		c.replyTo = ""
+		c.mu.Unlock()
+	}
+}
+
+// Run starts the server on the given address. It returns once the server
+// fails, or after Shutdown.
+func (s *Server) Run(addr string) error {
+	return s.RunWithContext(context.Background(), addr)
+}
+
+// RunWithContext starts the server on the given address and shuts it down
+// gracefully when ctx is cancelled, e.g. on SIGTERM with
+// signal.NotifyContext, waiting up to Config.ShutdownTimeout for in-flight
+// runs. It returns nil after a graceful shutdown.
+func (s *Server) RunWithContext(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("Starting Nim agent server on %s", addr)
+	return s.serve(ctx, ln)
+}
+
+// serve serves HTTP on ln until ctx is cancelled or Shutdown is called.
+func (s *Server) serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{Handler: s.routes()}
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		ln.Close()
+		return http.ErrServerClosed
+	}
+	s.httpServer = srv
+	s.mu.Unlock()
+
+	if s.config.Scheduler != nil {
+		go s.config.Scheduler.Run(ctx, time.Minute)
+	}
+	if s.jobs != nil {
+		go s.jobs.Run(ctx, jobTickInterval)
+	}
+	cleanup := s.config.ConfirmationCleanupInterval
+	if cleanup <= 0 {
+		cleanup = defaultConfirmationCleanupInterval
+	}
+	go s.cleanupConfirmations(ctx, cleanup)
+	interval := s.config.HealthCheckInterval
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	go s.health.run(ctx, interval, s.closed)
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		// Shutdown was called directly; wait for it to drain.
+		<-s.closed
+		return nil
+	case <-ctx.Done():
+		timeout := s.config.ShutdownTimeout
+		if timeout <= 0 {
+			timeout = defaultShutdownTimeout
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+		return s.Shutdown(shutdownCtx)
+	}
+}
+
+// routes returns the server's HTTP handler: /ws, the SSE endpoints if
+// enabled, /health (an alias of /health/live), /health/ready, /debug/vars, the REST endpoints under /v1, conversation
+// exports, and Config.Mux for everything else, wrapped in Config.Middleware.
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	if !s.config.DisableWebSocket {
+		mux.Handle("/ws", s.Handler())
+	}
+	if s.config.ServerSentEvents {
+		mux.HandleFunc("GET /events", s.handleEvents)
+		mux.HandleFunc("POST /messages", s.handlePostMessage)
+	}
+	mux.HandleFunc("/health", handleLive)
+	mux.HandleFunc("/health/live", handleLive)
+	mux.HandleFunc("/health/ready", s.health.handleReady)
+	mux.Handle("/debug/vars", expvar.Handler())
+	if h, ok := s.config.Metrics.(http.Handler); ok {
+		mux.Handle("GET /metrics", h)
+	}
+	mux.HandleFunc("POST /v1/chat", s.handleChat)
+	mux.HandleFunc("POST /v1/confirm/{id}", s.handleConfirmREST)
+	mux.HandleFunc("POST /v1/cancel/{id}", s.handleCancelREST)
+	mux.HandleFunc("POST /v1/messages", s.handleChat)
+	mux.HandleFunc("POST /v1/confirmations/{id}/confirm", s.handleConfirmREST)
+	mux.HandleFunc("POST /v1/confirmations/{id}/cancel", s.handleCancelREST)
+	mux.HandleFunc("GET /conversations/{id}/export", s.handleExport)
+	if s.config.Mux != nil {
+		mux.Handle("/", s.config.Mux)
+	}
+
+	var h http.Handler = mux
+	if s.config.Middleware != nil {
+		h = s.config.Middleware(h)
+	}
+	return h
+}
+
+// Shutdown gracefully shuts down the server. It stops accepting
+// connections, sends "server_closing" on open WebSockets and SSE streams,
+// and waits for in-flight messages to be handled, refusing new ones,
+// before closing them. If ctx expires first, the remaining runs are
+// cancelled, the connections closed, and ctx's error returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		select {
+		case <-s.closed:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	s.closing = true
+	srv := s.httpServer
+	s.mu.Unlock()
+	defer close(s.closed)
+
+	// http.Server waits for SSE streams, which only end below, so it shuts
+	// down alongside. WebSockets are hijacked connections, which it leaves
+	// to us.
+	httpDone := make(chan error, 1)
+	if srv != nil {
+		go func() { httpDone <- srv.Shutdown(ctx) }()
+	} else {
+		httpDone <- nil
+	}
+	for _, lc := range s.liveConns() {
+		s.send(lc, ServerMessage{Type: "server_closing"})
+	}
+	for _, st := range s.liveStreams() {
+		s.send(st, ServerMessage{Type: "server_closing"})
+	}
+
+	var err error
+	idle := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s.mu.Lock()
+	conns := s.conns
+	s.conns = make(map[*websocket.Conn]*liveConn)
+	streams := s.streams
+	s.streams = make(map[string]*sseStream)
+	s.mu.Unlock()
+	closeFrame := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for conn, lc := range conns {
+		lc.cancel()
+		conn.WriteControl(websocket.CloseMessage, closeFrame, time.Now().Add(closeWriteTimeout))
+		conn.Close()
+	}
+	for _, st := range streams {
+		st.cancel()
+	}
+	if httpErr := <-httpDone; err == nil {
+		err = httpErr
+	}
+	return err
+}
+
+// track registers an open connection, returning false if the server is
+// shutting down.
+func (s *Server) track(conn *websocket.Conn, userID string, cancel context.CancelFunc) (*liveConn, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return nil, false
+	}
+	lc := &liveConn{conn: conn, userID: userID, cancel: cancel}
+	s.conns[conn] = lc
+	return lc, true
+}
+
+func (s *Server) untrack(conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+}
+
+func (s *Server) liveConns() []*liveConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conns := make([]*liveConn, 0, len(s.conns))
+	for _, lc := range s.conns {
+		conns = append(conns, lc)
+	}
+	return conns
+}
+
+// beginRun registers a client message being handled, returning false if
+// the server is shutting down. Each successful call must be paired with
+// endRun.
+func (s *Server) beginRun() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return false
+	}
+	s.runs.Add(1)
+	return true
+}
+
+func (s *Server) endRun() {
+	s.runs.Done()
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// startServer runs s on a random local port until the test ends, with a
+// slow get_balance tool that signals started and waits for release.
+func startServer(t *testing.T, s *Server, started chan<- struct{}, release <-chan struct{}) (addr string, done <-chan error) {
+	t.Helper()
+	s.AddTool(core.NewBaseTool(core.ToolDefinition{ToolName: "get_balance"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			close(started)
+			select {
+			case <-release:
+				return &core.ToolResult{Success: true, Data: "50.25 USDC"}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	errc := make(chan error, 1)
+	go func() { errc <- s.serve(ctx, ln) }()
+	return ln.Addr().String(), errc
+}
+
+func dialAddr(t *testing.T, addr string) *wsClient {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return &wsClient{t: t, conn: conn}
+}
+
+func newShutdownServer(t *testing.T, cfg Config) *Server {
+	t.Helper()
+	cfg.AnthropicKey = "test"
+	cfg.BaseURL = balanceModel(t).URL
+	cfg.DisableStreaming = true
+	cfg.AuthFunc = func(r *http.Request) (string, error) { return "user-1", nil }
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestShutdown_WaitsForRuns(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("uploaded"))
+	})
+	s := newShutdownServer(t, Config{Mux: mux})
+	started, release := make(chan struct{}), make(chan struct{})
+	addr, served := startServer(t, s, started, release)
+
+	for path, want := range map[string]string{"/health": "ok", "/upload": "uploaded"} {
+		resp, err := http.Get("http://" + addr + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != want {
+			t.Errorf("GET %s = %q, want %q", path, body, want)
+		}
+	}
+
+	c := dialAddr(t, addr)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "what's my balance?"})
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- s.Shutdown(ctx)
+	}()
+
+	c.read("server_closing")
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown() = %v before the run finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/ws", nil); err == nil {
+		t.Error("new connection accepted while shutting down")
+	}
+
+	// The run in flight completes before the socket is closed.
+	close(release)
+	c.read("text")
+	c.read("complete")
+	var msg ServerMessage
+	err := c.conn.ReadJSON(&msg)
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("after the run, read %+v, %v, want a going away close frame", msg, err)
+	}
+
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown() = %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("serve() = %v after Shutdown", err)
+	}
+}
+
+func TestShutdown_Deadline(t *testing.T) {
+	s := newShutdownServer(t, Config{})
+	started := make(chan struct{})
+	addr, _ := startServer(t, s, started, make(chan struct{}))
+
+	c := dialAddr(t, addr)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "what's my balance?"})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() = %v, want the deadline exceeded", err)
+	}
+
+	c.read("server_closing")
+	var msg ServerMessage
+	for {
+		err := c.conn.ReadJSON(&msg)
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+			t.Errorf("read %v, want a going away close frame", err)
+		}
+		break
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/metrics"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func TestMetrics(t *testing.T) {
+	model := &sendModel{}
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          model.serve(t).URL,
+		DisableStreaming: true,
+		Metrics:          metrics.NewPrometheus(),
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{"message": "Sent"}}, nil
+		}).
+		Build())
+
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+
+	// One transfer confirmed, one cancelled, and a plain reply.
+	c.send(ClientMessage{Type: "message", Content: "send 50 to alice"})
+	c.send(ClientMessage{Type: "confirm", ActionID: c.read("confirm_request").ActionID})
+	c.read("complete")
+	c.send(ClientMessage{Type: "message", Content: "send 50 to bob"})
+	c.send(ClientMessage{Type: "cancel", ActionID: c.read("confirm_request").ActionID})
+	c.read("complete")
+	c.send(ClientMessage{Type: "message", Content: "thanks"})
+	c.read("complete")
+
+	srv := httptest.NewServer(s.routes())
+	t.Cleanup(srv.Close)
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /metrics: %d", resp.StatusCode)
+	}
+
+	scrape := string(body)
+	for _, want := range []string{
+		`nim_tool_executions_total{tool="send_money",outcome="success"} 1`,
+		`nim_tool_execution_duration_seconds_count{tool="send_money"} 1`,
+		`nim_confirmations_total{tool="send_money",outcome="requested"} 2`,
+		`nim_confirmations_total{tool="send_money",outcome="confirmed"} 1`,
+		`nim_confirmations_total{tool="send_money",outcome="cancelled"} 1`,
+		`nim_runs_total{outcome="confirmation_needed"} 2`,
+		`nim_runs_total{outcome="complete"} 1`,
+		`nim_model_calls_total{model="claude-sonnet-4-20250514",outcome="success"} 3`,
+		`nim_tokens_total{type="input"} 3`,
+	} {
+		if !strings.Contains(scrape, want+"\n") {
+			t.Errorf("missing %s in\n%s", want, scrape)
+		}
+	}
+	if strings.Contains(scrape, "user-1") {
+		t.Errorf("scrape mentions the user:\n%s", scrape)
+	}
+}
+
+func TestMetrics_NotConfigured(t *testing.T) {
+	s, err := New(Config{AnthropicKey: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.routes())
+	t.Cleanup(srv.Close)
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /metrics without Config.Metrics: %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// modelsModel is a mock Claude API that records the model of each request.
+func modelsModel(t *testing.T, models *[]string) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		*models = append(*models, req.Model)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":%q,"content":[{"type":"text","text":"Done."}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`, req.Model)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMessageModel(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     string // Config.Model
+		requested string
+		want      string // "" if refused
+	}{
+		{name: "configured default", model: "claude-opus-test", want: "claude-opus-test"},
+		{name: "engine default", want: "claude-sonnet-4-20250514"},
+		{name: "allowed override", model: "claude-opus-test", requested: "claude-haiku-test", want: "claude-haiku-test"},
+		{name: "default named explicitly", model: "claude-opus-test", requested: "claude-opus-test", want: "claude-opus-test"},
+		{name: "not allowed", model: "claude-opus-test", requested: "claude-expensive-test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var models []string
+			s, err := New(Config{
+				AnthropicKey:     "test",
+				BaseURL:          modelsModel(t, &models).URL,
+				Model:            tt.model,
+				AllowedModels:    []string{"claude-haiku-test"},
+				DisableStreaming: true,
+				AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := dial(t, s)
+			c.send(ClientMessage{Type: "new_conversation"})
+			c.read("conversation_started")
+
+			c.send(ClientMessage{Type: "message", Content: "quick question", Model: tt.requested})
+			if tt.want == "" {
+				if got := c.read("error"); got.Code != "model_not_allowed" || len(models) != 0 {
+					t.Errorf("got %+v after %d model calls, want model_not_allowed and none", got, len(models))
+				}
+				return
+			}
+			if got := c.read("complete").Model; got != tt.want {
+				t.Errorf("complete model = %q, want %q", got, tt.want)
+			}
+			if len(models) == 0 || models[0] != tt.want {
+				t.Errorf("model calls %v, want %s", models, tt.want)
+			}
+
+			// An override lasts one message.
+			c.send(ClientMessage{Type: "message", Content: "and again"})
+			want := tt.model
+			if want == "" {
+				want = "claude-sonnet-4-20250514"
+			}
+			if got := c.read("complete").Model; got != want {
+				t.Errorf("next message model = %q, want the default %q", got, want)
+			}
+		})
+	}
+}
+
+func TestChatModel(t *testing.T) {
+	var models []string
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          modelsModel(t, &models).URL,
+		AllowedModels:    []string{"claude-haiku-test"},
+		DisableStreaming: true,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.routes())
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		model      string
+		wantStatus int
+		wantModel  string
+	}{
+		{"claude-haiku-test", http.StatusOK, "claude-haiku-test"},
+		{"", http.StatusOK, "claude-sonnet-4-20250514"},
+		{"claude-expensive-test", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		status, chat := post(t, srv, "/v1/chat", "user-1", fmt.Sprintf(`{"message":"hi","model":%q}`, tt.model))
+		if status != tt.wantStatus || chat.Model != tt.wantModel {
+			t.Errorf("model %q: %d %+v, want %d served by %q", tt.model, status, chat, tt.wantStatus, tt.wantModel)
+		}
+		if tt.wantStatus != http.StatusOK && chat.Code != "model_not_allowed" {
+			t.Errorf("model %q: code %q, want model_not_allowed", tt.model, chat.Code)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// loadPreferences returns the user's saved preferences, or the defaults if
+// they have none or Config.Preferences isn't set.
+func (s *Server) loadPreferences(ctx context.Context, userID string) *core.UserPreferences {
+	if s.config.Preferences == nil {
+		return core.DefaultPreferences()
+	}
+	prefs, err := s.config.Preferences.Get(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load preferences for user %s: %v", userID, err)
+	}
+	if prefs == nil {
+		return core.DefaultPreferences()
+	}
+	return prefs
+}
+
+// sessionPreferences returns the preferences of the session's user,
+// loading them if the session hasn't yet.
+func (s *Server) sessionPreferences(ctx context.Context, sess *session) *core.UserPreferences {
+	if sess.prefs == nil {
+		sess.prefs = s.loadPreferences(ctx, sess.UserID)
+	}
+	return sess.prefs
+}
+
+// resolveShortcuts replaces a send_money recipient that is one of the
+// user's shortcuts, such as "mom", with the user ID it stands for when the
+// payment is offered for confirmation, so the user confirms the account it
+// will go to even if the shortcut changes before they answer. Display tags
+// are never replaced.
+func resolveShortcuts(prefs store.Preferences) engine.ActionInputFunc {
+	return func(ctx context.Context, userID, tool string, input json.RawMessage) json.RawMessage {
+		if tool != "send_money" {
+			return input
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(input, &fields); err != nil {
+			return input
+		}
+		recipient, _ := fields["recipient"].(string)
+		p, err := prefs.Get(ctx, userID)
+		if err != nil {
+			log.Printf("Failed to load shortcuts for user %s: %v", userID, err)
+		}
+		resolved, ok := p.ResolveRecipient(recipient)
+		if !ok {
+			return input
+		}
+
+		fields["recipient"] = resolved
+		rewritten, err := json.Marshal(fields)
+		if err != nil {
+			return input
+		}
+		return rewritten
+	}
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/store"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// shortcutModel saves "mom" for @jane when asked to remember, and sends
+// money to whoever follows "to" in the user's message, recording the
+// system prompts it is given.
+type shortcutModel struct {
+	mu      sync.Mutex
+	systems []string
+}
+
+func (m *shortcutModel) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			System   json.RawMessage `json:"system"`
+			Messages []struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+		json.Unmarshal(body, &req)
+		m.mu.Lock()
+		m.systems = append(m.systems, string(req.System))
+		m.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		content := `[{"type":"text","text":"OK."}]`
+		stop := "end_turn"
+		var last string
+		if err := json.Unmarshal(req.Messages[len(req.Messages)-1].Content, &last); err != nil {
+			var blocks []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			}
+			json.Unmarshal(req.Messages[len(req.Messages)-1].Content, &blocks)
+			if len(blocks) > 0 && blocks[0].Type == "text" {
+				last = blocks[0].Text
+			}
+		}
+		switch {
+		case strings.HasPrefix(last, "remember"):
+			content = `[{"type":"tool_use","id":"toolu_1","name":"remember_shortcut","input":{"nickname":"Mom","recipient":"@jane"}}]`
+			stop = "tool_use"
+		case strings.HasPrefix(last, "send"):
+			_, recipient, _ := strings.Cut(last, " to ")
+			content = fmt.Sprintf(`[{"type":"tool_use","id":"toolu_2","name":"send_money","input":{"recipient":%q,"amount":"50","currency":"USDC"}}]`, recipient)
+			stop = "tool_use"
+		}
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":%s,"stop_reason":%q,"usage":{"input_tokens":1,"output_tokens":1}}`, content, stop)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (m *shortcutModel) lastSystem() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.systems[len(m.systems)-1]
+}
+
+func TestShortcuts(t *testing.T) {
+	model := &shortcutModel{}
+	prefs := store.NewMemoryPreferences()
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          model.serve(t).URL,
+		DisableStreaming: true,
+		Preferences:      prefs,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := &txntest.Executor{Responses: map[string]interface{}{
+		"search_users": executor.SearchUsersResponse{Users: []executor.UserResult{
+			{UserID: "user_jane_doe", DisplayTag: "@janedoe"},
+			{UserID: "user_abc123", DisplayTag: "@jane", Name: "Jane"},
+		}},
+	}}
+	s.AddTools(tools.ShortcutTools(prefs, exec)...)
+	var recipients []string
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				Recipient string `json:"recipient"`
+			}
+			json.Unmarshal(params.Input, &input)
+			recipients = append(recipients, input.Recipient)
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{"message": "Sent"}}, nil
+		}).
+		Build())
+
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+
+	c.send(ClientMessage{Type: "message", Content: "remember mom is @jane"})
+	offer := c.read("confirm_request")
+	if offer.Tool != "remember_shortcut" || offer.Summary != "Remember Mom as @jane" {
+		t.Errorf("confirm_request = %+v", offer)
+	}
+	c.send(ClientMessage{Type: "confirm", ActionID: offer.ActionID})
+	c.read("complete")
+	saved, _ := prefs.Get(context.Background(), "user-1")
+	if saved == nil || saved.Shortcuts["mom"] != "user_abc123" {
+		t.Fatalf("saved preferences = %+v, want mom for user_abc123", saved)
+	}
+
+	// The offer shows who will be paid, and that's who is paid, even if the
+	// shortcut changes before the user confirms.
+	c.send(ClientMessage{Type: "message", Content: "send 50 to mom"})
+	offer = c.read("confirm_request")
+	if !strings.Contains(string(offer.Input), `"recipient":"user_abc123"`) {
+		t.Errorf("offered input = %s, want the resolved recipient", offer.Input)
+	}
+	prefs.Set(context.Background(), "user-1", &core.UserPreferences{Shortcuts: map[string]string{"mom": "user_jane_doe"}})
+	c.send(ClientMessage{Type: "confirm", ActionID: offer.ActionID})
+	c.read("complete")
+	if got := recipients[len(recipients)-1]; got != "user_abc123" {
+		t.Errorf("paid %s after the shortcut changed, want user_abc123 as confirmed", got)
+	}
+
+	// A display tag is never a shortcut.
+	c.send(ClientMessage{Type: "message", Content: "send 50 to @mom"})
+	c.send(ClientMessage{Type: "confirm", ActionID: c.read("confirm_request").ActionID})
+	c.read("complete")
+	if got := recipients[len(recipients)-1]; got != "@mom" {
+		t.Errorf("sending to @mom paid %s", got)
+	}
+	if !strings.Contains(model.lastSystem(), "mom: user_jane_doe") {
+		t.Errorf("system prompt doesn't list the shortcut: %s", model.lastSystem())
+	}
+}
+
+// TestPreferences_LiminalTokens checks that under the default Liminal auth
+// each bearer token has preferences of its own.
+func TestPreferences_LiminalTokens(t *testing.T) {
+	ctx := context.Background()
+	model := &shortcutModel{}
+	prefs := store.NewMemoryPreferences()
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          model.serve(t).URL,
+		DisableStreaming: true,
+		Preferences:      prefs,
+		LiminalExecutor:  executor.NewHTTPExecutor(executor.HTTPExecutorConfig{BaseURL: "http://gateway.invalid"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefs.Set(ctx, tokenUserID("alice-jwt"), &core.UserPreferences{Shortcuts: map[string]string{"mom": "user_alice_mom"}})
+
+	for token, want := range map[string]bool{"alice-jwt": true, "bob-jwt": false} {
+		c := dialQuery(t, s, "token="+token)
+		c.send(ClientMessage{Type: "new_conversation"})
+		c.read("conversation_started")
+		c.send(ClientMessage{Type: "message", Content: "hi"})
+		c.read("complete")
+		if got := strings.Contains(model.lastSystem(), "mom: user_alice_mom"); got != want {
+			t.Errorf("%s's system prompt lists alice's shortcut: %v, want %v", token, got, want)
+		}
+	}
+	if got, _ := prefs.Get(ctx, "user"); got != nil {
+		t.Errorf("preferences stored under the shared placeholder user: %+v", got)
+	}
+}
+
+// TestShortcuts_LiminalTokens checks that under the default Liminal auth one
+// user's shortcut never picks another user's recipient.
+func TestShortcuts_LiminalTokens(t *testing.T) {
+	prefs := store.NewMemoryPreferences()
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          (&shortcutModel{}).serve(t).URL,
+		DisableStreaming: true,
+		Preferences:      prefs,
+		LiminalExecutor:  executor.NewHTTPExecutor(executor.HTTPExecutorConfig{BaseURL: "http://gateway.invalid"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			return &core.ToolResult{Success: true}, nil
+		}).
+		Build())
+	prefs.Set(context.Background(), tokenUserID("alice-jwt"), &core.UserPreferences{Shortcuts: map[string]string{"mom": "user_alice_mom"}})
+
+	for token, want := range map[string]string{"alice-jwt": "user_alice_mom", "bob-jwt": "mom"} {
+		c := dialQuery(t, s, "token="+token)
+		c.send(ClientMessage{Type: "new_conversation"})
+		c.read("conversation_started")
+		c.send(ClientMessage{Type: "message", Content: "send 50 to mom"})
+		offer := c.read("confirm_request")
+		if !strings.Contains(string(offer.Input), fmt.Sprintf(`"recipient":%q`, want)) {
+			t.Errorf("%s was offered %s, want recipient %s", token, offer.Input, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/contrib/charts"
+	"github.com/becomeliminal/nim-go-sdk/contrib/flows"
+	"github.com/becomeliminal/nim-go-sdk/contrib/txn/txntest"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+func TestToolProgress(t *testing.T) {
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          balanceModel(t).URL,
+		DisableStreaming: true,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTool(core.NewBaseTool(core.ToolDefinition{ToolName: "get_balance"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			params.ReportProgress("fetching balances", 0)
+			params.ReportProgress("converting currencies", 50)
+			return &core.ToolResult{Success: true, Data: "50.25 USDC"}, nil
+		}))
+
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "what's my balance?"})
+
+	for _, want := range []struct {
+		stage   string
+		percent float64
+	}{{"fetching balances", 0}, {"converting currencies", 50}} {
+		msg := c.read("tool_progress")
+		if msg.Tool != "get_balance" || msg.Stage != want.stage || msg.Percent == nil || *msg.Percent != want.percent {
+			t.Errorf("tool_progress = %+v (percent %v), want get_balance at %q %v%%", msg, msg.Percent, want.stage, want.percent)
+		}
+	}
+	if text := c.read("text"); text.Content == "" {
+		t.Error("expected the answer after the progress reports")
+	}
+}
+
+// routeModel calls route_request once, then answers.
+func routeModel(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		content := `[{"type":"text","text":"Here's your savings plan."}]`
+		stop := "end_turn"
+		if !strings.Contains(string(body), `"tool_result"`) {
+			content = `[{"type":"tool_use","id":"toolu_1","name":"route_request","input":{"user_message":"help me save"}}]`
+			stop = "tool_use"
+		}
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":%s,"stop_reason":%q,"usage":{"input_tokens":1,"output_tokens":1}}`, content, stop)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestToolMessages(t *testing.T) {
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          routeModel(t).URL,
+		DisableStreaming: true,
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTool(flows.RouteTool(flows.Deps{
+		Exec: &txntest.Executor{Responses: map[string]interface{}{
+			"get_balance": executor.GetBalanceResponse{Balances: []executor.WalletBalance{
+				{Currency: "USDC", Amount: "100"}, {Currency: "EURC", Amount: "50"}, {Currency: "LIL", Amount: "10"},
+			}},
+			"get_vault_rates": executor.GetVaultRatesResponse{Vaults: []executor.VaultRate{
+				{Currency: "USDC", APY: "4"}, {Currency: "EURC", APY: "5"},
+			}},
+		}},
+		Classifier: flows.ClassifierFunc(func(ctx context.Context, input string) (string, float64, error) {
+			return flows.RouteFinancialHelp, 1, nil
+		}),
+		Charts: charts.Dir{Path: t.TempDir(), BaseURL: "/charts/"},
+	}))
+
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "help me save"})
+
+	var steps []string
+	for _, msg := range c.readUntil("tool_result") {
+		if msg.Type != "tool_message" {
+			continue
+		}
+		if msg.Tool != "route_request" || strings.HasPrefix(msg.Content, "Great news") {
+			t.Errorf("unexpected tool_message %+v; the final response belongs in the result", msg)
+		}
+		if strings.Contains(msg.Content, "Step ") {
+			steps = append(steps, msg.Content)
+		}
+	}
+	if len(steps) != 3 {
+		t.Fatalf("got %d steps before the tool result, want 3: %q", len(steps), steps)
+	}
+	for i, step := range steps {
+		if want := fmt.Sprintf("Step %d/3", i+1); !strings.Contains(step, want) {
+			t.Errorf("step %d = %q, want %q", i+1, step, want)
+		}
+	}
+	if text := c.read("text"); text.Content != "Here's your savings plan." {
+		t.Errorf("text = %q", text.Content)
+	}
+}
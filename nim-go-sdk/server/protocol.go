@@ -1,25 +1,136 @@
 // Package server provides a ready-to-run WebSocket server for the Nim agent.
 package server
 
+import (
+	"encoding/json"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/features"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
 // ClientMessage is a message from the client.
 type ClientMessage struct {
-	Type           string `json:"type"` // "new_conversation", "resume_conversation", "message", "confirm", "cancel"
-	Content        string `json:"content,omitempty"`
-	ActionID       string `json:"actionId,omitempty"`
-	ConversationID string `json:"conversationId,omitempty"`
+	Type           string          `json:"type"` // "new_conversation", "resume_conversation", "message", "confirm", "confirm_with_edits", "cancel", "rename_conversation", "list_conversations", "delete_conversation", "abort"
+	Content        string          `json:"content,omitempty"`
+	ActionID       string          `json:"actionId,omitempty"`
+	ConversationID string          `json:"conversationId,omitempty"`
+	Locale         string          `json:"locale,omitempty"` // new_conversation, resume_conversation: e.g. "en-US"
+	Input          json.RawMessage `json:"input,omitempty"`  // confirm_with_edits: the input to execute instead
+	Title          string          `json:"title,omitempty"`  // rename_conversation: the new title
+	Cursor         string          `json:"cursor,omitempty"` // list_conversations: nextCursor of the previous page
+	Model          string          `json:"model,omitempty"`  // message: one of Config.AllowedModels, instead of the default
+	Limit          int             `json:"limit,omitempty"`  // list_conversations: page size
+
+	// ActionIDs, with "confirm", confirms only these actions of the group
+	// in ActionID; the group's other actions are cancelled.
+	ActionIDs []string `json:"actionIds,omitempty"`
+
+	// Attachments are images sent with a "message", e.g. a photo of a
+	// receipt to split. See Attachment.
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// ID, if set, is echoed as ReplyTo on every message sent in response,
+	// and the message is acknowledged with a "message_ack" as soon as it is
+	// accepted. The server doesn't interpret it; reused IDs are echoed as is.
+	ID string `json:"id,omitempty"`
+}
+
+// Attachment is an image sent with a message, given inline as base64 Data
+// or by an https URL. Inline images must be PNG, JPEG, GIF or WebP and at
+// most Config.MaxAttachmentBytes once decoded. The model sees the image,
+// but the conversation stores only a reference to it, so it isn't resent
+// when the conversation is resumed.
+type Attachment struct {
+	Type      string `json:"type"`                 // "image"
+	MediaType string `json:"media_type,omitempty"` // e.g. "image/png"; required with Data
+	Data      string `json:"data,omitempty"`       // base64-encoded image
+	URL       string `json:"url,omitempty"`        // instead of Data
+	Name      string `json:"name,omitempty"`       // e.g. a file name
 }
 
 // ServerMessage is a message to the client.
 type ServerMessage struct {
-	Type           string      `json:"type"` // "conversation_started", "conversation_resumed", "text", "text_chunk", "confirm_request", "complete", "error"
-	Content        string      `json:"content,omitempty"`
-	ActionID       string      `json:"actionId,omitempty"`
-	Tool           string      `json:"tool,omitempty"`
-	Summary        string      `json:"summary,omitempty"`
-	ExpiresAt      string      `json:"expiresAt,omitempty"`
-	ConversationID string      `json:"conversationId,omitempty"`
-	Messages       interface{} `json:"messages,omitempty"`
-	TokenUsage     *TokenUsage `json:"tokenUsage,omitempty"`
+	Type           string          `json:"type"` // "conversation_started", "conversation_resumed", "conversation_renamed", "conversations_list", "conversation_deleted", "text", "text_chunk", "text_part", "text_end", "confirm_request", "tool_result", "tool_progress", "tool_message", "message_ack", "confirm_expired", "complete", "error", "debug", "server_closing", "stream_opened", "proactive", "busy", "aborted"
+	Content        string          `json:"content,omitempty"`
+	Code           string          `json:"code,omitempty"`       // error: machine-readable reason, e.g. "conversation_evicted"
+	RetryAfter     int             `json:"retryAfter,omitempty"` // error: seconds to wait before trying again, if known
+	ActionID       string          `json:"actionId,omitempty"`
+	Tool           string          `json:"tool,omitempty"`
+	Summary        string          `json:"summary,omitempty"`
+	Input          json.RawMessage `json:"input,omitempty"`     // confirm_request: exactly what will execute
+	InputHash      string          `json:"inputHash,omitempty"` // confirm_request: abbreviated SHA-256 of the canonical input
+	Details        core.Details    `json:"details,omitempty"`   // confirm_request: the action's fields, e.g. amount and recipient
+	ExpiresAt      string          `json:"expiresAt,omitempty"`
+	ConversationID string          `json:"conversationId,omitempty"`
+	Messages       interface{}     `json:"messages,omitempty"`
+	TokenUsage     *TokenUsage     `json:"tokenUsage,omitempty"`
+	Model          string          `json:"model,omitempty"` // complete, aborted: the model that served the turn
+
+	// complete, aborted: the conversation's token usage so far, this turn
+	// included. See Config.MaxConversationTokens.
+	ConversationTokenUsage *TokenUsage `json:"conversationTokenUsage,omitempty"`
+
+	// conversation_started, conversation_resumed: the user's feature flags
+	Features features.Flags `json:"features,omitempty"`
+
+	// conversation_started, conversation_resumed, conversation_renamed: the
+	// conversation's title
+	Title string `json:"title,omitempty"`
+
+	// conversations_list: a page of the user's conversations, newest first,
+	// and the cursor for the next page, empty after the last
+	Conversations []*store.Conversation `json:"conversations,omitempty"`
+	NextCursor    string                `json:"nextCursor,omitempty"`
+
+	// text_part, text_end: final text split across frames. See Config.TextPartSize.
+	Part     int    `json:"part,omitempty"`     // text_part: 1-based index
+	Parts    int    `json:"parts,omitempty"`    // text_part, text_end: total parts
+	Checksum string `json:"checksum,omitempty"` // text_end: hex SHA-256 of the full text
+
+	// tool_result: the structured parts of an enveloped tool result
+	Status    string         `json:"status,omitempty"`
+	Figures   []core.Figure  `json:"figures,omitempty"`
+	Warnings  []string       `json:"warnings,omitempty"`
+	Artifacts []artifact.Ref `json:"artifacts,omitempty"`
+
+	// tool_progress: a long-running tool's progress, sent while it runs.
+	// tool_message frames, also sent while a tool runs, carry a message for
+	// the user in Content instead; they aren't saved to the conversation.
+	Stage   string   `json:"stage,omitempty"`
+	Percent *float64 `json:"percent,omitempty"` // 0-100
+
+	// stream_opened: the SSE stream's ID, to post client messages to
+	StreamID string `json:"streamId,omitempty"`
+
+	// proactive: the name of the Config.Jobs entry that sent it
+	Job string `json:"job,omitempty"`
+
+	// budget_alert: the percentage of the user's weekly goal their spending
+	// crossed, e.g. 80, with the goal and spending in Figures. Sent by
+	// applications with Deliver, e.g. from a budget.Alerter in an ActionHook.
+	Threshold float64 `json:"threshold,omitempty"`
+
+	// confirm_request: the actions of a group offered together, in the
+	// order they execute. ActionID is then the group's, Summary describes
+	// all of them, and the other action fields are empty.
+	Actions []ConfirmAction `json:"actions,omitempty"`
+
+	// text: what happened to each action of a confirmed group
+	Results []ActionResult `json:"results,omitempty"`
+
+	// debug: developer mode only, never persisted. See Config.AllowDevMode.
+	Debug *engine.DebugEvent `json:"debug,omitempty"`
+
+	// busy: the ID of the client message still being handled in the
+	// conversation, if it had one. The rejected message is in ReplyTo.
+	InFlight string `json:"inFlight,omitempty"`
+
+	// ReplyTo is the ID of the client message this responds to, if it had
+	// one. Merged message fragments are answered with the first one's ID.
+	ReplyTo string `json:"replyTo,omitempty"`
 }
 
 // TokenUsage tracks Claude API token consumption.
@@ -31,6 +142,60 @@ type TokenUsage struct {
 	TotalTokens              int `json:"totalTokens"`
 }
 
+// tokenUsage converts an engine run's token usage for the client.
+func tokenUsage(u core.TokenUsage) *TokenUsage {
+	return &TokenUsage{
+		InputTokens:              u.InputTokens,
+		OutputTokens:             u.OutputTokens,
+		CacheCreationInputTokens: u.CacheCreationInputTokens,
+		CacheReadInputTokens:     u.CacheReadInputTokens,
+		TotalTokens:              u.TotalTokens(),
+	}
+}
+
+// ConfirmAction is one action of a group offered for confirmation.
+type ConfirmAction struct {
+	ID        string          `json:"id"`
+	Tool      string          `json:"tool"`
+	Summary   string          `json:"summary"`
+	Input     json.RawMessage `json:"input"`
+	InputHash string          `json:"inputHash,omitempty"`
+	Details   core.Details    `json:"details,omitempty"`
+}
+
+// confirmActions describes group's actions for the client.
+func confirmActions(group *core.PendingActionGroup) []ConfirmAction {
+	actions := make([]ConfirmAction, len(group.Actions))
+	for i, action := range group.Actions {
+		actions[i] = ConfirmAction{
+			ID:        action.ID,
+			Tool:      action.Tool,
+			Summary:   action.Summary,
+			Input:     action.Input,
+			InputHash: core.ShortHash(action.InputHash),
+			Details:   action.Details,
+		}
+	}
+	return actions
+}
+
+// Action result statuses.
+const (
+	ActionExecuted  = "executed"  // the action ran and succeeded
+	ActionFailed    = "failed"    // the action ran, or was refused, and failed
+	ActionSkipped   = "skipped"   // not run, because an earlier action failed
+	ActionCancelled = "cancelled" // not among the actions the user confirmed
+)
+
+// ActionResult is what happened to one action of a confirmed group.
+type ActionResult struct {
+	ActionID string `json:"actionId"`
+	Tool     string `json:"tool"`
+	Summary  string `json:"summary"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"` // failed: why
+}
+
 // Confirmation contains details about a pending action.
 type Confirmation struct {
 	ID        string `json:"id"`
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// streamModel is a mock Claude API that streams "Hello there." in two
+// chunks.
+func streamModel(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		event := func(name, data string) { fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data) }
+		event("message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":[],"stop_reason":null,"usage":{"input_tokens":1,"output_tokens":0}}}`)
+		event("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`)
+		for _, chunk := range []string{"Hello ", "there."} {
+			event("content_block_delta", fmt.Sprintf(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":%q}}`, chunk))
+		}
+		event("content_block_stop", `{"type":"content_block_stop","index":0}`)
+		event("message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":1}}`)
+		event("message_stop", `{"type":"message_stop"}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// readUntil returns every message up to and including the first of type
+// last.
+func (c *wsClient) readUntil(last string) []ServerMessage {
+	c.t.Helper()
+	var msgs []ServerMessage
+	for {
+		var msg ServerMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			c.t.Fatalf("waiting for %s: %v", last, err)
+		}
+		msgs = append(msgs, msg)
+		if msg.Type == last {
+			return msgs
+		}
+	}
+}
+
+func TestReplyTo(t *testing.T) {
+	s, err := New(Config{
+		AnthropicKey: "test",
+		BaseURL:      streamModel(t).URL,
+		AuthFunc:     func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := dial(t, s)
+
+	c.send(ClientMessage{Type: "new_conversation", ID: "c-1"})
+	checkReplies(t, c.readUntil("conversation_started"), "c-1", "message_ack", "conversation_started")
+
+	// The first reply names the conversation.
+	c.send(ClientMessage{Type: "message", Content: "hi", ID: "m-1"})
+	checkReplies(t, c.readUntil("complete"), "m-1", "message_ack", "text_chunk", "text_chunk", "text", "conversation_renamed", "complete")
+
+	// A reused ID is echoed again, and a message without one gets no ack.
+	for _, id := range []string{"m-1", ""} {
+		c.send(ClientMessage{Type: "message", Content: "hi", ID: id})
+		msgs := c.readUntil("complete")
+		if id == "" {
+			checkReplies(t, msgs, "", "text_chunk", "text_chunk", "text", "complete")
+			continue
+		}
+		checkReplies(t, msgs, id, "message_ack", "text_chunk", "text_chunk", "text", "complete")
+	}
+}
+
+// checkReplies fails unless msgs have the given types, in order, and all
+// reply to id.
+func checkReplies(t *testing.T, msgs []ServerMessage, id string, types ...string) {
+	t.Helper()
+	var got []string
+	for _, msg := range msgs {
+		got = append(got, msg.Type)
+		if msg.ReplyTo != id {
+			t.Errorf("%s replies to %q, want %q", msg.Type, msg.ReplyTo, id)
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(types) {
+		t.Errorf("got %v, want %v", got, types)
+	}
+}
@@ -0,0 +1,382 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// restTimeout bounds a REST request, the agent run included.
+const restTimeout = 60 * time.Second
+
+// maxRESTBody bounds the size of a REST request body.
+const maxRESTBody = 1 << 20
+
+// ChatRequest is the body of POST /v1/chat, or POST /v1/messages.
+type ChatRequest struct {
+	// ConversationID continues a conversation, started over either REST or
+	// WebSocket. Empty starts a new one.
+	ConversationID string `json:"conversation_id,omitempty"`
+	Message        string `json:"message"`
+
+	// Content is the message, if Message is empty, as the WebSocket
+	// protocol's message type names it.
+	Content string `json:"content,omitempty"`
+
+	// Model, if set, runs the message on one of Config.AllowedModels
+	// instead of the default.
+	Model string `json:"model,omitempty"`
+}
+
+// ConfirmRequest is the optional body of POST /v1/confirm/{id}, or
+// POST /v1/confirmations/{id}/confirm.
+type ConfirmRequest struct {
+	// Input, if set, replaces the action's input, as confirm_with_edits does.
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// ActionIDs, if set, confirms only these actions of the group being
+	// confirmed, cancelling the rest.
+	ActionIDs []string `json:"action_ids,omitempty"`
+}
+
+// ChatResponse is the reply to a REST request: the outcome of one agent
+// run, or of confirming or cancelling an action. ToolsUsed and TokenUsage
+// are only reported for agent runs.
+type ChatResponse struct {
+	ConversationID string           `json:"conversation_id,omitempty"`
+	Text           string           `json:"text,omitempty"`
+	ToolsUsed      []string         `json:"tools_used,omitempty"`
+	TokenUsage     *core.TokenUsage `json:"token_usage,omitempty"`
+	Model          string           `json:"model,omitempty"` // the model that served the run
+
+	// ConversationTokenUsage is the conversation's token usage so far.
+	// See Config.MaxConversationTokens.
+	ConversationTokenUsage *core.TokenUsage `json:"conversation_token_usage,omitempty"`
+
+	// PendingAction is set when the agent needs the user to confirm an
+	// action: POST /v1/confirm/{id} or /v1/cancel/{id} to answer.
+	PendingAction *RESTPendingAction `json:"pending_action,omitempty"`
+
+	// Results is what happened to each action of a confirmed group.
+	Results []RESTActionResult `json:"results,omitempty"`
+
+	Error      string `json:"error,omitempty"`
+	Code       string `json:"code,omitempty"`        // machine-readable reason, e.g. "unauthorized"
+	RetryAfter int    `json:"retry_after,omitempty"` // seconds to wait before trying again, if known
+}
+
+// RESTPendingAction is an action awaiting confirmation.
+type RESTPendingAction struct {
+	ID        string          `json:"id"`
+	Tool      string          `json:"tool"`
+	Summary   string          `json:"summary"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	InputHash string          `json:"input_hash,omitempty"`
+	Details   core.Details    `json:"details,omitempty"`
+	ExpiresAt string          `json:"expires_at,omitempty"`
+
+	// Actions are the actions of a group offered together, confirmed all
+	// at once with the group's ID, or some of them with ConfirmRequest's
+	// ActionIDs.
+	Actions []RESTPendingAction `json:"actions,omitempty"`
+}
+
+// RESTActionResult is what happened to one action of a confirmed group.
+// Status is one of ActionExecuted, ActionFailed, ActionSkipped and
+// ActionCancelled.
+type RESTActionResult struct {
+	ActionID string `json:"action_id"`
+	Tool     string `json:"tool"`
+	Summary  string `json:"summary"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// recorder is the peer of a REST request: it collects the messages a
+// WebSocket client would have been sent, to answer with once the run ends.
+type recorder struct {
+	mu       sync.Mutex
+	messages []ServerMessage
+}
+
+func (r *recorder) writeMessage(msg ServerMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+// response summarises the recorded messages, and the status to send them
+// with.
+func (r *recorder) response(conversationID string) (int, ChatResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resp := ChatResponse{ConversationID: conversationID}
+	var text strings.Builder
+	for _, msg := range r.messages {
+		switch msg.Type {
+		case "text", "text_part":
+			text.WriteString(msg.Content)
+			for _, res := range msg.Results {
+				resp.Results = append(resp.Results, RESTActionResult(res))
+			}
+		case "confirm_request":
+			text.WriteString(msg.Content)
+			resp.PendingAction = &RESTPendingAction{
+				ID:        msg.ActionID,
+				Tool:      msg.Tool,
+				Summary:   msg.Summary,
+				Input:     msg.Input,
+				InputHash: msg.InputHash,
+				Details:   msg.Details,
+				ExpiresAt: msg.ExpiresAt,
+			}
+			for _, action := range msg.Actions {
+				resp.PendingAction.Actions = append(resp.PendingAction.Actions, RESTPendingAction{
+					ID:        action.ID,
+					Tool:      action.Tool,
+					Summary:   action.Summary,
+					Input:     action.Input,
+					InputHash: action.InputHash,
+					Details:   action.Details,
+				})
+			}
+		case "error", "busy":
+			resp.Error, resp.Code, resp.RetryAfter = msg.Content, msg.Code, msg.RetryAfter
+		}
+	}
+	resp.Text = text.String()
+
+	switch {
+	case resp.Code == "busy":
+		return http.StatusConflict, resp
+	case resp.Code == "invalid_edits", resp.Code == "invalid_action_ids":
+		return http.StatusBadRequest, resp
+	case resp.Code == "conversation_token_limit":
+		return http.StatusForbidden, resp
+	case resp.Code == "guardrails_blocked", resp.Code == "model_rate_limited":
+		return http.StatusTooManyRequests, resp
+	case resp.Code == "model_overloaded":
+		return http.StatusServiceUnavailable, resp
+	case resp.Code == "timeout":
+		return http.StatusGatewayTimeout, resp
+	case resp.Error != "":
+		return http.StatusInternalServerError, resp
+	}
+	return http.StatusOK, resp
+}
+
+// restRequest is an authenticated REST request.
+type restRequest struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	userID string
+}
+
+// beginREST authenticates a REST request and registers it as a run,
+// answering it itself if either fails. The caller must call end once it
+// has answered.
+func (s *Server) beginREST(w http.ResponseWriter, r *http.Request) (*restRequest, bool) {
+	userID, _, liminalAuth, err := s.authenticate(r)
+	if err != nil {
+		writeREST(w, http.StatusUnauthorized, ChatResponse{Error: "Unauthorized", Code: "unauthorized"})
+		return nil, false
+	}
+	if !s.beginRun() {
+		writeREST(w, http.StatusServiceUnavailable, ChatResponse{Error: "The server is shutting down", Code: "server_closing"})
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(r, liminalAuth), restTimeout)
+	return &restRequest{ctx: ctx, cancel: cancel, userID: userID}, true
+}
+
+func (s *Server) endREST(req *restRequest) {
+	req.cancel()
+	s.endRun()
+}
+
+// loadSession rebuilds one of the user's conversations, answering the
+// request itself if it can't.
+func (s *Server) loadSession(w http.ResponseWriter, req *restRequest, conversationID string) (*session, bool) {
+	conv, err := s.conversations.Get(req.ctx, conversationID)
+	if errors.Is(err, store.ErrConversationEvicted) {
+		writeREST(w, http.StatusGone, ChatResponse{
+			ConversationID: conversationID,
+			Error:          "This conversation is no longer available. Please start a new one.",
+			Code:           "conversation_evicted",
+		})
+		return nil, false
+	}
+	if err != nil || conv.UserID != req.userID {
+		writeREST(w, http.StatusNotFound, ChatResponse{ConversationID: conversationID, Error: "Conversation not found", Code: "not_found"})
+		return nil, false
+	}
+	return resumedSession(conv, req.userID), true
+}
+
+// handleChat serves POST /v1/chat and POST /v1/messages: it runs the agent
+// once on a message, in a new conversation or one started earlier over
+// either transport.
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.beginREST(w, r)
+	if !ok {
+		return
+	}
+	defer s.endREST(req)
+
+	var body ChatRequest
+	err := json.NewDecoder(io.LimitReader(r.Body, maxRESTBody)).Decode(&body)
+	if body.Message == "" {
+		body.Message = body.Content
+	}
+	if err != nil || body.Message == "" {
+		writeREST(w, http.StatusBadRequest, ChatResponse{Error: "Expected a JSON body with a message", Code: "invalid_request"})
+		return
+	}
+	if !s.modelAllowed(body.Model) {
+		writeREST(w, http.StatusBadRequest, ChatResponse{Error: modelNotAllowed(body.Model), Code: "model_not_allowed"})
+		return
+	}
+
+	var sess *session
+	if body.ConversationID != "" {
+		if sess, ok = s.loadSession(w, req, body.ConversationID); !ok {
+			return
+		}
+	} else {
+		conv, err := s.conversations.Create(req.ctx, req.userID)
+		if err != nil {
+			writeREST(w, http.StatusInternalServerError, ChatResponse{Error: "Failed to create conversation"})
+			return
+		}
+		sess = &session{ID: conv.ID, UserID: req.userID, ConversationID: conv.ID, History: []core.Message{}}
+		log.Printf("Started conversation %s for user %s over REST", conv.ID, req.userID)
+	}
+
+	rec := &recorder{}
+	release, ok := s.claimConversation(rec, sess, "")
+	if !ok {
+		s.reply(w, rec, sess)
+		return
+	}
+	defer release()
+	s.startSession(rec, sess)
+	defer s.endSession(rec)
+
+	ctx, cancel := s.messageContext(req.ctx, req.userID, sess)
+	defer cancel()
+	output := s.handleMessage(ctx, rec, sess, body.Message, body.Model, nil)
+	status, resp := rec.response(sess.ConversationID)
+	if output != nil {
+		for _, execution := range output.ToolsUsed {
+			resp.ToolsUsed = append(resp.ToolsUsed, execution.Tool)
+		}
+		usage := output.TokensUsed
+		resp.TokenUsage = &usage
+		resp.Model = output.Model
+	}
+	resp.ConversationTokenUsage = sess.usage()
+	writeREST(w, status, resp)
+}
+
+// handleConfirmREST serves POST /v1/confirm/{id} and
+// POST /v1/confirmations/{id}/confirm.
+func (s *Server) handleConfirmREST(w http.ResponseWriter, r *http.Request) {
+	s.handleAction(w, r, func(ctx context.Context, rec *recorder, sess *session, userID, actionID string) bool {
+		var body ConfirmRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxRESTBody)).Decode(&body); err != nil && err != io.EOF {
+			writeREST(w, http.StatusBadRequest, ChatResponse{ConversationID: sess.ConversationID, Error: "Invalid JSON body", Code: "invalid_request"})
+			return false
+		}
+		if len(body.ActionIDs) > 0 {
+			s.handleConfirmGroup(ctx, rec, sess, userID, actionID, body.ActionIDs)
+			return true
+		}
+		s.handleConfirm(ctx, rec, sess, userID, actionID, body.Input)
+		return true
+	})
+}
+
+// handleCancelREST serves POST /v1/cancel/{id} and
+// POST /v1/confirmations/{id}/cancel.
+func (s *Server) handleCancelREST(w http.ResponseWriter, r *http.Request) {
+	s.handleAction(w, r, func(ctx context.Context, rec *recorder, sess *session, userID, actionID string) bool {
+		s.handleCancel(ctx, rec, sess, userID, actionID)
+		return true
+	})
+}
+
+// handleAction answers a pending action in the conversation it was
+// offered in. handle returns false if it answered the request itself.
+func (s *Server) handleAction(w http.ResponseWriter, r *http.Request, handle func(ctx context.Context, rec *recorder, sess *session, userID, actionID string) bool) {
+	req, ok := s.beginREST(w, r)
+	if !ok {
+		return
+	}
+	defer s.endREST(req)
+
+	actionID := r.PathValue("id")
+	var conversationID string
+	if action, err := s.confirmations.Get(req.ctx, req.userID, actionID); err == nil {
+		conversationID = action.ConversationID
+	} else if group, err := s.confirmations.GetGroup(req.ctx, req.userID, actionID); err == nil {
+		conversationID = group.ConversationID
+	} else {
+		writeREST(w, http.StatusNotFound, ChatResponse{Error: "Action not found", Code: "not_found"})
+		return
+	}
+
+	// Actions raised outside a conversation, like scheduled transfers,
+	// are answered in a session of their own.
+	sess := &session{UserID: req.userID}
+	if conversationID != "" {
+		if sess, ok = s.loadSession(w, req, conversationID); !ok {
+			return
+		}
+	}
+
+	rec := &recorder{}
+	release, ok := s.claimConversation(rec, sess, "")
+	if !ok {
+		s.reply(w, rec, sess)
+		return
+	}
+	defer release()
+	s.startSession(rec, sess)
+	defer s.endSession(rec)
+
+	ctx, cancel := s.messageContext(req.ctx, req.userID, sess)
+	defer cancel()
+	if handle(ctx, rec, sess, req.userID, actionID) {
+		s.reply(w, rec, sess)
+	}
+}
+
+func (s *Server) reply(w http.ResponseWriter, rec *recorder, sess *session) {
+	status, resp := rec.response(sess.ConversationID)
+	resp.ConversationTokenUsage = sess.usage()
+	writeREST(w, status, resp)
+}
+
+func writeREST(w http.ResponseWriter, status int, resp ChatResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	if resp.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(resp.RetryAfter))
+	}
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// post sends a REST request to srv as the given user and decodes the reply.
+func post(t *testing.T, srv *httptest.Server, path, user, body string) (int, ChatResponse) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.Header.Set("Authorization", "Bearer "+user)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("POST %s: decoding the response: %v", path, err)
+	}
+	return resp.StatusCode, out
+}
+
+// newRESTServer serves a server whose users authenticate with their name
+// as a bearer token, and whose send_money tool counts its executions.
+func newRESTServer(t *testing.T, modelURL string, executed *int) (*Server, *httptest.Server) {
+	t.Helper()
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          modelURL,
+		DisableStreaming: true,
+		AuthFunc: func(r *http.Request) (string, error) {
+			if user := bearerToken(r); user != "" {
+				return user, nil
+			}
+			return "", errors.New("no token")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTool(core.NewBaseTool(core.ToolDefinition{ToolName: "get_balance"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			return &core.ToolResult{Success: true, Data: "50.25 USDC"}, nil
+		}))
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		SummaryTemplate("Send {{.amount}} {{.currency}} to {{.recipient}}").
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			*executed++
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{"message": "Sent"}}, nil
+		}).
+		Build())
+
+	srv := httptest.NewServer(s.routes())
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func TestREST_Chat(t *testing.T) {
+	var executed int
+	_, srv := newRESTServer(t, balanceModel(t).URL, &executed)
+
+	status, resp := post(t, srv, "/v1/chat", "user-1", `{"message":"what's my balance?"}`)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d (%s), want 200", status, resp.Error)
+	}
+	if resp.ConversationID == "" || resp.Text != "You have 50.25 USDC." {
+		t.Errorf("response = %+v, want the answer in a new conversation", resp)
+	}
+	if len(resp.ToolsUsed) != 1 || resp.ToolsUsed[0] != "get_balance" {
+		t.Errorf("tools_used = %v, want [get_balance]", resp.ToolsUsed)
+	}
+	if resp.TokenUsage == nil || resp.TokenUsage.TotalTokens() != 4 {
+		t.Errorf("token_usage = %+v, want both calls' tokens", resp.TokenUsage)
+	}
+
+	// Only its owner can continue the conversation.
+	body := `{"conversation_id":"` + resp.ConversationID + `","message":"and now?"}`
+	if status, _ := post(t, srv, "/v1/chat", "user-2", body); status != http.StatusNotFound {
+		t.Errorf("another user's conversation: status = %d, want 404", status)
+	}
+	if status, resp := post(t, srv, "/v1/chat", "user-1", body); status != http.StatusOK || resp.Text == "" {
+		t.Errorf("continuing: status = %d, response = %+v", status, resp)
+	}
+}
+
+func TestREST_Errors(t *testing.T) {
+	var executed int
+	_, srv := newRESTServer(t, balanceModel(t).URL, &executed)
+
+	tests := []struct {
+		name string
+		path string
+		user string
+		body string
+		want int
+	}{
+		{name: "no credentials", path: "/v1/chat", body: `{"message":"hi"}`, want: http.StatusUnauthorized},
+		{name: "no message", path: "/v1/chat", user: "user-1", body: `{}`, want: http.StatusBadRequest},
+		{name: "not JSON", path: "/v1/chat", user: "user-1", body: `hi`, want: http.StatusBadRequest},
+		{name: "unknown conversation", path: "/v1/chat", user: "user-1", body: `{"conversation_id":"nope","message":"hi"}`, want: http.StatusNotFound},
+		{name: "unknown action", path: "/v1/confirm/nope", user: "user-1", want: http.StatusNotFound},
+		{name: "cancel without credentials", path: "/v1/cancel/nope", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := post(t, srv, tt.path, tt.user, tt.body)
+			if status != tt.want || resp.Error == "" {
+				t.Errorf("status = %d, response = %+v, want %d with an error", status, resp, tt.want)
+			}
+		})
+	}
+}
+
+func TestREST_Confirmation(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		wantText string
+		executed int
+	}{
+		{name: "confirm", path: "/v1/confirm/", wantText: "Sent", executed: 1},
+		{name: "confirm with edits", path: "/v1/confirm/", body: `{"input":{"recipient":"@bob","amount":"5","currency":"USDC"}}`, wantText: "Sent", executed: 1},
+		{name: "cancel", path: "/v1/cancel/", wantText: "Action cancelled.", executed: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executed int
+			_, srv := newRESTServer(t, (&sendModel{}).serve(t).URL, &executed)
+
+			status, resp := post(t, srv, "/v1/chat", "user-1", `{"message":"send 50 to alice"}`)
+			if status != http.StatusOK || resp.PendingAction == nil {
+				t.Fatalf("status = %d, response = %+v, want a pending action", status, resp)
+			}
+			if resp.PendingAction.Summary != "Send 50 USDC to @alice" {
+				t.Errorf("summary = %q", resp.PendingAction.Summary)
+			}
+
+			// Another user can't answer it.
+			if status, _ := post(t, srv, tt.path+resp.PendingAction.ID, "user-2", tt.body); status != http.StatusNotFound {
+				t.Errorf("another user's action: status = %d, want 404", status)
+			}
+
+			status, answer := post(t, srv, tt.path+resp.PendingAction.ID, "user-1", tt.body)
+			if status != http.StatusOK || answer.Text != tt.wantText {
+				t.Errorf("status = %d, response = %+v, want %q", status, answer, tt.wantText)
+			}
+			if answer.ConversationID != resp.ConversationID {
+				t.Errorf("answered in conversation %q, want %q", answer.ConversationID, resp.ConversationID)
+			}
+			if executed != tt.executed {
+				t.Errorf("executed %d times, want %d", executed, tt.executed)
+			}
+		})
+	}
+}
+
+func TestREST_MessagesAndConfirmations(t *testing.T) {
+	for _, answer := range []struct {
+		verb     string
+		wantText string
+		executed int
+	}{
+		{verb: "confirm", wantText: "Sent", executed: 1},
+		{verb: "cancel", wantText: "Action cancelled.", executed: 0},
+	} {
+		t.Run(answer.verb, func(t *testing.T) {
+			var executed int
+			_, srv := newRESTServer(t, (&sendModel{}).serve(t).URL, &executed)
+
+			status, resp := post(t, srv, "/v1/messages", "user-1", `{"content":"send 50 to alice"}`)
+			if status != http.StatusOK || resp.PendingAction == nil || resp.TokenUsage == nil {
+				t.Fatalf("status = %d, response = %+v, want a pending action and token usage", status, resp)
+			}
+
+			path := "/v1/confirmations/" + resp.PendingAction.ID + "/" + answer.verb
+			if status, _ := post(t, srv, path, "user-2", ""); status != http.StatusNotFound {
+				t.Errorf("another user's action: status = %d, want 404", status)
+			}
+			status, reply := post(t, srv, path, "user-1", "")
+			if status != http.StatusOK || reply.Text != answer.wantText || reply.ConversationID != resp.ConversationID {
+				t.Errorf("status = %d, response = %+v, want %q in conversation %s", status, reply, answer.wantText, resp.ConversationID)
+			}
+			if executed != answer.executed {
+				t.Errorf("executed %d times, want %d", executed, answer.executed)
+			}
+		})
+	}
+}
+
+func TestREST_SharesConversationsWithWebSocket(t *testing.T) {
+	var executed int
+	s, srv := newRESTServer(t, replyModel(t, "Hello!").URL, &executed)
+
+	_, resp := post(t, srv, "/v1/chat", "user-1", `{"message":"hi over REST"}`)
+
+	header := http.Header{"Authorization": {"Bearer user-1"}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	c := &wsClient{t: t, conn: conn}
+	c.send(ClientMessage{Type: "resume_conversation", ConversationID: resp.ConversationID})
+	resumed := c.read("conversation_resumed")
+	data, _ := json.Marshal(resumed.Messages)
+	if !strings.Contains(string(data), "hi over REST") || !strings.Contains(string(data), "Hello!") {
+		t.Errorf("resumed messages = %s, want the REST exchange", data)
+	}
+	if liveSessions(s) != 1 {
+		t.Errorf("%d live sessions, want only the WebSocket's", liveSessions(s))
+	}
+}
@@ -2,20 +2,34 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/becomeliminal/nim-go-sdk/artifact"
 	"github.com/becomeliminal/nim-go-sdk/core"
 	"github.com/becomeliminal/nim-go-sdk/engine"
 	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/features"
+	"github.com/becomeliminal/nim-go-sdk/inventory"
+	"github.com/becomeliminal/nim-go-sdk/limits"
+	"github.com/becomeliminal/nim-go-sdk/sanitize"
+	"github.com/becomeliminal/nim-go-sdk/schedule"
 	"github.com/becomeliminal/nim-go-sdk/store"
 )
 
@@ -32,12 +46,26 @@ type Config struct {
 	// SystemPrompt is the system prompt for the agent.
 	SystemPrompt string
 
-	// Model is the Claude model to use.
+	// Model is the Claude model to use. Defaults to engine.DefaultModel.
 	Model string
 
-	// MaxTokens is the maximum response tokens.
+	// AllowedModels are the models a client may ask for instead of Model,
+	// per message, e.g. a smaller model for a quick question. Requests for
+	// any other model are refused with a "model_not_allowed" error. If
+	// empty, clients can't choose.
+	AllowedModels []string
+
+	// MaxTokens is the maximum response tokens. Defaults to
+	// engine.DefaultMaxTokens.
 	MaxTokens int64
 
+	// MaxConversationTokens caps the model tokens, input and output, that
+	// a conversation's agent runs may use. Once a conversation has used
+	// more, further messages in it are refused with a
+	// "conversation_token_limit" error; it can still be resumed and
+	// exported. Zero means no cap.
+	MaxConversationTokens int
+
 	// LiminalExecutor is the executor for Liminal API calls.
 	// If provided, the server will automatically extract JWT tokens from requests
 	// and forward them to the executor for authenticated API calls.
@@ -45,17 +73,43 @@ type Config struct {
 
 	// AuthFunc validates requests and returns a user ID.
 	// If nil, a default handler is used that extracts JWT tokens for Liminal authentication.
-	// Most users should leave this nil.
+	// It requires a bearer token and identifies each client by its token,
+	// since the server can't verify the JWT itself: conversations and
+	// preferences don't follow a user to a refreshed token. Set AuthFunc
+	// to identify users across tokens.
 	AuthFunc func(r *http.Request) (userID string, err error)
 
 	// Conversations persists conversations.
 	// If nil, an in-memory store is used.
 	Conversations store.Conversations
 
+	// RedactExport, if set, is applied to each message of a conversation
+	// exported from GET /conversations/{id}/export, e.g. to strip account
+	// numbers from tool payloads before the transcript leaves the server.
+	RedactExport func(store.StoredMessage) store.StoredMessage
+
 	// Confirmations stores pending actions awaiting user approval.
 	// If nil, an in-memory store is used.
 	Confirmations store.Confirmations
 
+	// Preferences keeps each user's preferences across conversations. If
+	// set, they are loaded into each run's core.Context, the user's
+	// recipient shortcuts are listed in the system prompt, and a send_money
+	// to a nickname is offered for confirmation as a payment to the user it
+	// stands for. Register tools.ShortcutTools with the same store to let
+	// users save shortcuts. They are keyed by the user ID from AuthFunc, so
+	// under the default Liminal auth by bearer token.
+	Preferences store.Preferences
+
+	// CompletedActions remembers the write actions that executed, so
+	// confirming a duplicate of one, such as the same transfer asked for
+	// twice, doesn't execute it again. If nil, an in-memory store is used.
+	CompletedActions store.CompletedActions
+
+	// CompletedActionWindow is how long executed actions are remembered.
+	// Defaults to engine.DefaultCompletedActionWindow.
+	CompletedActionWindow time.Duration
+
 	// Guardrails provides rate limiting and circuit breaker functionality.
 	// If nil, no guardrails are applied.
 	Guardrails engine.Guardrails
@@ -68,6 +122,272 @@ type Config struct {
 	// This can be used to customize the HTTP client for testing.
 	AnthropicOptions []option.RequestOption
 
+	// Mux serves the application's own HTTP routes, e.g. an *http.ServeMux
+	// with file uploads. The server handles /ws, /health/... and /debug/vars
+	// itself and passes every other request to Mux. If nil, they are not
+	// found.
+	Mux http.Handler
+
+	// Middleware wraps the server's HTTP handler, including Mux, e.g. for
+	// logging or CORS.
+	Middleware func(http.Handler) http.Handler
+
+	// ServerSentEvents serves the protocol over Server-Sent Events as well,
+	// for clients behind proxies that block WebSocket upgrades: GET /events
+	// streams server messages and POST /messages sends client messages.
+	// Off by default.
+	ServerSentEvents bool
+
+	// DisableWebSocket stops serving /ws, e.g. when clients only use SSE or
+	// the REST endpoints.
+	DisableWebSocket bool
+
+	// HealthChecks are the application's own dependencies, checked with the
+	// server's for /health/ready: the Anthropic API, LiminalExecutor's
+	// gateway, and Conversations and Confirmations if they implement
+	// store.Pinger.
+	HealthChecks []HealthCheck
+
+	// HealthCheckInterval is how often Run checks dependencies, in the
+	// background; /health/ready reports the latest results. Defaults to 30
+	// seconds.
+	HealthCheckInterval time.Duration
+
+	// ShutdownTimeout is how long RunWithContext waits for in-flight agent
+	// runs once its context is cancelled. Defaults to 30 seconds.
+	ShutdownTimeout time.Duration
+
+	// Scheduler runs scheduled transfers. If set, Run starts it, and due
+	// transfers missed while a user was offline are presented as confirmations
+	// when they start or resume a conversation.
+	// The scheduler must share this server's Confirmations store.
+	Scheduler *schedule.Scheduler
+
+	// Jobs run the agent for users on a schedule, e.g. a daily check-in,
+	// pushing each reply to the user's open connections as a "proactive"
+	// message. Jobs can't request confirmation. New fails on an invalid
+	// schedule. See Job.
+	Jobs []Job
+
+	// Notifier delivers job replies to users with no open connection. If
+	// nil, those replies are dropped.
+	Notifier Notifier
+
+	// Credentials returns the token a job acts for a user with, since a
+	// job has no connection to take one from. With a LiminalExecutor, jobs
+	// are skipped for users it returns no token for, rather than run with
+	// the executor's own. Give the Scheduler and any roundup.Sweeper the
+	// same function.
+	Credentials core.CredentialFunc
+
+	// Sanitizer reviews assistant markdown before it is sent or persisted,
+	// allowlisting image sources and rewriting external links.
+	// If nil, assistant text is passed through unchanged.
+	// See sanitize.DefaultPolicy for a starting point.
+	Sanitizer *sanitize.Policy
+
+	// Moderator checks each user message and assistant reply, refusing or
+	// rewriting content the application must not handle. If nil, content is
+	// not moderated. See engine.RuleModerator for a reference implementation.
+	Moderator engine.Moderator
+
+	// ModerationBestEffort streams replies live and moderates them afterwards;
+	// the final "text" message carries the moderated reply. By default,
+	// streamed text is held until each turn has been checked.
+	// Equivalent to turning on features.ModerationBestEffort in Features.
+	ModerationBestEffort bool
+
+	// Limits enforces per-user transfer limits. Write actions over the limit
+	// are refused before a confirmation is offered, and checked again when
+	// confirmed. If nil, no limits are enforced.
+	// Register limits.AllowanceTool so users can ask what they have left.
+	Limits *limits.Limits
+
+	// ToolPolicy is consulted before every tool call, e.g.
+	// engine.NewBasicPolicy to deny tools outright. Denied calls are
+	// returned to the model with the reason, and audited. If nil, every
+	// call is allowed.
+	ToolPolicy engine.ToolPolicy
+
+	// Actions remembers the last confirmed write action in each conversation.
+	// If set, the undo_last_action tool is registered, letting users reverse
+	// it with a new confirmation. If nil, undo is not available.
+	Actions engine.ActionLog
+
+	// ConfirmationTTL is how long write actions await confirmation. When an
+	// action offered over a WebSocket expires unanswered, the client is sent
+	// "confirm_expired". Defaults to engine.DefaultConfirmationTTL.
+	ConfirmationTTL time.Duration
+
+	// ConfirmationCleanupInterval is how often Run removes expired actions
+	// from the Confirmations store. Defaults to a minute.
+	ConfirmationCleanupInterval time.Duration
+
+	// UndoWindow is how long after execution an action can be undone.
+	// Defaults to engine.DefaultUndoWindow.
+	UndoWindow time.Duration
+
+	// Reads remembers the latest result of differential read tools in each
+	// conversation. If set, repeated reads are sent to the model as the
+	// changes since the last call, and the recall_tool_result tool is
+	// registered for fetching the full result. If nil, reads are always sent
+	// in full.
+	Reads engine.ReadCache
+
+	// Artifacts mints signed download URLs for the attachments and charts in
+	// resumed conversations. If nil, artifacts are sent without links.
+	Artifacts *artifact.Resolver
+
+	// Inventory compiles what is stored about each user. If set, the
+	// describe_my_data tool is registered, and the server's conversation
+	// store is added as the "conversations" and "artifacts" categories.
+	// Register describers for the application's other stores with it, e.g.
+	// inventory.Schedules and budget.DescribeGoals.
+	Inventory *inventory.Registry
+
+	// CoalesceWindow merges rapid-fire "message" frames into one run: after
+	// a message, further messages arriving within this window of the previous
+	// one are joined with newlines, and each is acknowledged with a
+	// "message_ack" frame. A fragment ending in ".", "?" or "!" starts the run
+	// straight away. If zero, every message starts a run immediately.
+	// Around 1.5s suits mobile clients.
+	CoalesceWindow time.Duration
+
+	// CoalesceMaxWait caps how long coalescing can delay a run, so a user who
+	// keeps typing is still answered. Defaults to three times CoalesceWindow.
+	CoalesceMaxWait time.Duration
+
+	// PingInterval is how often the server pings each WebSocket, so proxies
+	// keep idle connections open and dead clients are noticed. Defaults to
+	// 30 seconds.
+	PingInterval time.Duration
+
+	// IdleTimeout closes a WebSocket that has sent nothing, not even a pong,
+	// for this long while the server waits for its next message. Defaults
+	// to twice PingInterval.
+	IdleTimeout time.Duration
+
+	// NaturalConfirmations lets users answer a confirmation in plain text.
+	// While exactly one action is awaiting approval, a message such as "yes,
+	// do it" or "cancel that" confirms or cancels it, exactly as if the client
+	// had sent "confirm" or "cancel"; any other message runs as usual, with
+	// the model reminded the action is still pending. Replies are matched
+	// against phrase lists for the conversation's locale first, and otherwise
+	// classified by the model. Anything hedged, such as "sure, but make it
+	// 40", confirms nothing.
+	// Equivalent to turning on features.NaturalConfirmations in Features.
+	NaturalConfirmations bool
+
+	// ConfirmationPhrases overrides the phrases that confirm or cancel an
+	// action, keyed by language code ("en", "es", ...).
+	// Defaults to engine.DefaultConfirmationPhrases.
+	ConfirmationPhrases map[string]engine.ConfirmationPhrases
+
+	// AllowDevMode lets authenticated clients connect with "?debug=1" to
+	// inspect each run: such connections also receive "debug" frames with
+	// the system prompt, the tools advertised, each tool call's redacted
+	// input and result, model call timings and tokens, and guardrails and
+	// moderation decisions. Debug frames are never persisted. Connections
+	// are never in developer mode unless they ask, and never when the
+	// server has no AuthFunc or LiminalExecutor to authenticate them, or
+	// under the default Liminal auth without a bearer token.
+	// Leave this off in production.
+	// Equivalent to turning on features.DevMode in Features.
+	AllowDevMode bool
+
+	// Features sets feature flags for the deployment, keyed by name, e.g.
+	// features.NaturalConfirmations. Flags must be registered (see
+	// features.Register); New fails on unknown names. The booleans above
+	// that a flag replaces still work, and turn the flag on unless Features
+	// sets it.
+	Features map[string]bool
+
+	// FlagProvider decides flags per user, overriding Features, e.g.
+	// features.Rollout for a percentage rollout. If nil, every user gets
+	// the deployment's flags. Flags are evaluated once per client message,
+	// sent with "conversation_started" and "conversation_resumed", and
+	// readable from the engine and tools with features.Enabled.
+	FlagProvider features.Provider
+
+	// TextPartSize splits final text longer than this many bytes into
+	// "text_part" frames, numbered from 1, followed by a "text_end" frame
+	// with the part count and the hex SHA-256 of the full text, so clients
+	// can render long replies such as reports incrementally and detect
+	// truncation. Text at or under the size is sent as a single "text"
+	// frame, as is all text if zero. Streamed "text_chunk" frames are not
+	// affected, and the conversation always stores the full text once.
+	TextPartSize int
+
+	// MaxAttachmentBytes is the largest inline image a client can attach to
+	// a message, once decoded. Defaults to 5MB, the most the model accepts.
+	MaxAttachmentBytes int
+
+	// ToolParallelism is how many read-only tool calls from one model
+	// response run concurrently. Defaults to engine.DefaultToolParallelism;
+	// 1 runs them serially.
+	ToolParallelism int
+
+	// MaxToolResultBytes caps the tool result content sent to the model per
+	// call; larger results are shortened. Defaults to
+	// engine.DefaultMaxToolResultBytes; negative sends results in full.
+	MaxToolResultBytes int
+
+	// QualifyToolNames shows the model tools added with AddToolGroup by
+	// their namespaced names, e.g. "liminal__get_balance". Off by default,
+	// so tools are known by their own names.
+	QualifyToolNames bool
+
+	// PromptCaching caches the system prompt and tool definitions with
+	// Anthropic prompt caching, cutting the input cost of every turn after
+	// the first. Cache reads and writes are reported in "complete" token
+	// usage.
+	PromptCaching bool
+
+	// CacheReads memoizes read-only tool results for the rest of an agent
+	// turn, so a balance fetched by one tool isn't fetched again by the
+	// next (see engine.WithRunCache). Tools calling an executor directly
+	// share the cache if it is wrapped with engine.CachedExecutor.
+	CacheReads bool
+
+	// ToolCache also keeps read-only tool results across turns for
+	// ToolCacheTTL, 30 seconds by default. Confirmed writes invalidate the
+	// results they change. Implies CacheReads.
+	ToolCache    engine.ToolCache
+	ToolCacheTTL time.Duration
+
+	// Compaction summarizes the oldest messages of conversations that grow
+	// too long for the model's context window. Stored history is kept in
+	// full; each run compacts its own copy. If nil, history is sent whole.
+	Compaction *engine.CompactionConfig
+
+	// ToolMiddleware wraps every tool execution, outermost first, e.g.
+	// engine.LogToolCalls.
+	ToolMiddleware []engine.ToolMiddleware
+
+	// Recorder, if set, receives every model call as a line of JSON, for
+	// engine.NewReplay to serve back. Point BaseURL at a server running the
+	// replay to repeat the recorded conversations offline.
+	Recorder io.Writer
+
+	// Metrics receives tool call, model call, run and confirmation
+	// measurements, labelled by tool, model and outcome but never by user.
+	// If it is also an http.Handler, as metrics.Prometheus is, it is
+	// served at GET /metrics, unauthenticated like /debug/vars.
+	Metrics engine.Metrics
+
+	// TracerProvider, if set, traces each run with OpenTelemetry: a span
+	// per run carrying its user, conversation and request IDs, with spans
+	// for each model turn and tool call beneath it. Set the same provider
+	// in LiminalExecutor's HTTPExecutorConfig to trace its requests to the
+	// gateway too, which continues the trace from their traceparent header.
+	TracerProvider trace.TracerProvider
+
+	// GenerateTitles names each conversation with a model call summarizing
+	// its first message, made once its first reply is complete. Otherwise,
+	// and if the call fails, the first few words of the message are used.
+	// Either way, the client is sent "conversation_renamed" with the title.
+	GenerateTitles bool
+
 	// DisableStreaming disables streaming mode for the Anthropic API.
 	// When true, uses the non-streaming Messages.New() API instead of NewStreaming().
 	// Useful for testing with mock servers that don't support SSE.
@@ -83,7 +403,21 @@ type Server struct {
 
 	conversations store.Conversations
 	confirmations store.Confirmations
-	sessions      sync.Map // *websocket.Conn -> *session
+	features      *features.Set
+	sessions      sync.Map // peer -> *session
+	pins          *conversationPins
+	running       *conversationRuns
+	health        *health
+	jobs          *jobRunner // nil without Config.Jobs
+	actionHooks   []ActionHook
+
+	mu         sync.Mutex
+	httpServer *http.Server
+	conns      map[*websocket.Conn]*liveConn
+	streams    map[string]*sseStream // open SSE streams by ID
+	closing    bool
+	runs       sync.WaitGroup // client messages being handled
+	closed     chan struct{}  // closed when Shutdown finishes
 }
 
 type session struct {
@@ -92,8 +426,40 @@ type session struct {
 	ConversationID string
 	History        []core.Message
 	TurnCount      int
+	Locale         string   // client's locale, e.g. "en-US"
+	Pending        []string // IDs of actions offered in this session
+
+	// Usage totals the model tokens the conversation has used, in this
+	// session and before.
+	Usage core.TokenUsage
+
+	// mu guards History, TurnCount, Pending and Usage, which a confirm or
+	// cancel may touch while a run is in flight.
+	mu sync.Mutex
+
+	titled   bool                  // whether the conversation has been named
+	expiries expiryTimers          // push "confirm_expired" for Pending actions
+	prefs    *core.UserPreferences // loaded with the first message; nil to reload
+}
+
+// history returns a copy of the session's history.
+func (sess *session) history() []core.Message {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return slices.Clone(sess.History)
+}
+
+// appendHistory adds msgs to the end of the session's history.
+func (sess *session) appendHistory(msgs ...core.Message) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.History = append(sess.History, msgs...)
 }
 
+// defaultToolCacheTTL is how long Config.ToolCache keeps read results
+// unless ToolCacheTTL says otherwise.
+const defaultToolCacheTTL = 30 * time.Second
+
 // New creates a new server with the given configuration.
 // Returns an error if AnthropicKey is not provided.
 func New(cfg Config) (*Server, error) {
@@ -114,17 +480,98 @@ func New(cfg Config) (*Server, error) {
 	// Create Anthropic client
 	client := anthropic.NewClient(opts...)
 
+	flags, err := features.New(featureFlags(cfg), cfg.FlagProvider)
+	if err != nil {
+		return nil, err
+	}
+	for _, check := range cfg.HealthChecks {
+		if check.Name == "" || check.Check == nil {
+			return nil, fmt.Errorf("health check %q needs a Name and a Check", check.Name)
+		}
+	}
+
 	// Create registry
 	registry := engine.NewToolRegistry()
+	registry.QualifyNames(cfg.QualifyToolNames)
 
 	// Build engine options
-	var engineOpts []engine.Option
+	engineOpts := []engine.Option{
+		engine.WithDefaultModel(cfg.Model),
+		engine.WithDefaultMaxTokens(cfg.MaxTokens),
+	}
 	if cfg.Guardrails != nil {
 		engineOpts = append(engineOpts, engine.WithGuardrails(cfg.Guardrails))
 	}
 	if cfg.AuditLogger != nil {
 		engineOpts = append(engineOpts, engine.WithAudit(cfg.AuditLogger))
 	}
+	if cfg.Limits != nil {
+		engineOpts = append(engineOpts, engine.WithTransferLimits(cfg.Limits))
+	}
+	if cfg.ToolPolicy != nil {
+		engineOpts = append(engineOpts, engine.WithToolPolicy(cfg.ToolPolicy))
+	}
+	if cfg.Sanitizer != nil {
+		engineOpts = append(engineOpts, engine.WithSanitizer(*cfg.Sanitizer))
+	}
+	if cfg.Moderator != nil {
+		// Runs stream live when features.ModerationBestEffort is on for the user.
+		engineOpts = append(engineOpts, engine.WithModerator(cfg.Moderator, engine.ModerationBuffered))
+	}
+	completed := cfg.CompletedActions
+	if completed == nil {
+		completed = store.NewMemoryCompletedActions()
+	}
+	engineOpts = append(engineOpts, engine.WithCompletedActions(completed, cfg.CompletedActionWindow))
+	if cfg.Actions != nil {
+		engineOpts = append(engineOpts, engine.WithActionLog(cfg.Actions))
+		registry.Register(engine.UndoTool(cfg.Actions, registry, cfg.UndoWindow))
+	}
+	if cfg.Reads != nil {
+		engineOpts = append(engineOpts, engine.WithReadCache(cfg.Reads))
+		registry.Register(engine.RecallTool(cfg.Reads))
+	}
+
+	if cfg.PromptCaching {
+		engineOpts = append(engineOpts, engine.WithPromptCaching())
+	}
+	switch {
+	case cfg.ToolCache != nil:
+		ttl := cfg.ToolCacheTTL
+		if ttl <= 0 {
+			ttl = defaultToolCacheTTL
+		}
+		engineOpts = append(engineOpts, engine.WithToolCache(cfg.ToolCache, ttl))
+	case cfg.CacheReads:
+		engineOpts = append(engineOpts, engine.WithRunCache())
+	}
+	if cfg.ToolParallelism != 0 {
+		engineOpts = append(engineOpts, engine.WithToolParallelism(cfg.ToolParallelism))
+	}
+	if cfg.MaxToolResultBytes != 0 {
+		engineOpts = append(engineOpts, engine.WithMaxToolResultBytes(cfg.MaxToolResultBytes))
+	}
+	if cfg.Compaction != nil {
+		engineOpts = append(engineOpts, engine.WithCompaction(*cfg.Compaction))
+	}
+	if cfg.ConfirmationTTL > 0 {
+		engineOpts = append(engineOpts, engine.WithConfirmationTTL(cfg.ConfirmationTTL))
+	}
+	if len(cfg.ToolMiddleware) > 0 {
+		engineOpts = append(engineOpts, engine.WithToolMiddleware(cfg.ToolMiddleware...))
+	}
+	if cfg.Preferences != nil {
+		engineOpts = append(engineOpts, engine.WithActionInput(resolveShortcuts(cfg.Preferences)))
+	}
+	if cfg.Recorder != nil {
+		engineOpts = append(engineOpts, engine.WithRecorder(cfg.Recorder))
+	}
+	if cfg.Metrics != nil {
+		engineOpts = append(engineOpts, engine.WithMetrics(cfg.Metrics))
+	}
+	if cfg.TracerProvider != nil {
+		engineOpts = append(engineOpts, engine.WithTracerProvider(cfg.TracerProvider))
+	}
 
 	// Create engine
 	eng := engine.NewEngine(&client, registry, engineOpts...)
@@ -140,28 +587,120 @@ func New(cfg Config) (*Server, error) {
 		confirmations = store.NewMemoryConfirmations()
 	}
 
-	return &Server{
+	if cfg.Inventory != nil {
+		cfg.Inventory.Register("conversations", inventory.Conversations(conversations))
+		cfg.Inventory.Register("artifacts", inventory.Artifacts(conversations))
+		registry.Register(inventory.Tool(cfg.Inventory))
+	}
+
+	// Keep conversations that are open or awaiting confirmation if the
+	// store evicts idle ones.
+	pins := newConversationPins()
+	if pinner, ok := conversations.(store.Pinner); ok {
+		pinner.AddPin(pins.pinned)
+	}
+
+	s := &Server{
 		config:        cfg,
 		engine:        eng,
 		registry:      registry,
 		conversations: conversations,
 		confirmations: confirmations,
+		features:      flags,
+		pins:          pins,
+		running:       newConversationRuns(),
+		health:        &health{checks: healthChecks(cfg, &client, conversations, confirmations)},
+		conns:         make(map[*websocket.Conn]*liveConn),
+		streams:       make(map[string]*sseStream),
+		closed:        make(chan struct{}),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true // Allow all origins in development
 			},
 		},
-	}, nil
+	}
+	if s.jobs, err = newJobRunner(s, cfg.Jobs, time.Now); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
-// AddTool registers a custom tool with the server.
-func (s *Server) AddTool(tool core.Tool) {
-	s.registry.Register(tool)
+// featureFlags returns the deployment's static flags: Features, plus the
+// flags turned on by the Config booleans they replace.
+func featureFlags(cfg Config) map[string]bool {
+	flags := make(map[string]bool, len(cfg.Features)+3)
+	for name, on := range cfg.Features {
+		flags[name] = on
+	}
+	for name, on := range map[string]bool{
+		features.NaturalConfirmations: cfg.NaturalConfirmations,
+		features.ModerationBestEffort: cfg.ModerationBestEffort,
+		features.DevMode:              cfg.AllowDevMode,
+	} {
+		if _, set := cfg.Features[name]; on && !set {
+			flags[name] = true
+		}
+	}
+	return flags
 }
 
-// AddTools registers multiple tools with the server.
-func (s *Server) AddTools(tools ...core.Tool) {
-	s.registry.RegisterAll(tools...)
+// AddTool registers a custom tool with the server. A tool with an invalid
+// definition, such as a summary template that doesn't parse, or whose name
+// is already registered (engine.ErrDuplicateTool), is not registered; the
+// error is returned and logged. Use ReplaceTool to replace a tool on purpose.
+func (s *Server) AddTool(tool core.Tool) error {
+	err := s.registry.Register(tool)
+	if err != nil {
+		log.Printf("Failed to register tool: %v", err)
+	}
+	return err
+}
+
+// AddTools registers multiple tools with the server. Tools with invalid
+// definitions or duplicate names are skipped, and their errors returned
+// and logged.
+func (s *Server) AddTools(tools ...core.Tool) error {
+	return s.AddToolGroup("", tools...)
+}
+
+// AddToolGroup registers tools in a namespace, e.g. "liminal", as AddTools
+// does. With Config.QualifyToolNames the model sees them by their
+// namespaced names.
+func (s *Server) AddToolGroup(namespace string, tools ...core.Tool) error {
+	err := s.registry.RegisterGroup(namespace, tools...)
+	if err != nil {
+		log.Printf("Failed to register tools: %v", err)
+	}
+	return err
+}
+
+// ReplaceTool registers a tool with the server, replacing any tool already
+// registered under its name.
+func (s *Server) ReplaceTool(tool core.Tool) error {
+	err := s.registry.RegisterOverride(tool)
+	if err != nil {
+		log.Printf("Failed to register tool: %v", err)
+	}
+	return err
+}
+
+// CompleteStructured answers prompt with a JSON object matching schema,
+// using the server's Anthropic client and, unless an option overrides it,
+// Config.Model. Tool handlers can use it for classification or extraction
+// (see engine.CompleteStructured).
+func (s *Server) CompleteStructured(ctx context.Context, prompt string, schema map[string]interface{}, opts ...engine.StructuredOption) (json.RawMessage, error) {
+	return s.engine.CompleteStructured(ctx, prompt, schema, opts...)
+}
+
+// modelAllowed reports whether a client may run a message on model: the
+// default, or one of Config.AllowedModels. Empty means the default.
+func (s *Server) modelAllowed(model string) bool {
+	return model == "" || model == s.engine.Model() || slices.Contains(s.config.AllowedModels, model)
+}
+
+// modelNotAllowed is the error for a model the client may not use.
+func modelNotAllowed(model string) string {
+	return fmt.Sprintf("Model %q is not available. Leave model unset to use the default.", model)
 }
 
 // ToolCount returns the number of registered tools.
@@ -169,129 +708,279 @@ func (s *Server) ToolCount() int {
 	return s.registry.Count()
 }
 
+// LiminalExecutor returns the configured Liminal executor, or nil if none.
+func (s *Server) LiminalExecutor() *executor.HTTPExecutor {
+	return s.config.LiminalExecutor
+}
+
 // Handler returns an HTTP handler for WebSocket connections.
 func (s *Server) Handler() http.Handler {
 	return http.HandlerFunc(s.handleWebSocket)
 }
 
-// Run starts the server on the given address.
-func (s *Server) Run(addr string) error {
-	http.Handle("/ws", s.Handler())
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("ok"))
-	})
-
-	log.Printf("Starting Nim agent server on %s", addr)
-	return http.ListenAndServe(addr, nil)
-}
-
 // defaultLiminalAuthFunc returns a default authentication function for Liminal.
-// It extracts JWT tokens from requests and forwards them to the HTTPExecutor.
+// It accepts any request with a bearer token; the gateway authenticates the
+// JWT, which the connection forwards on each of its own executor calls.
 func (s *Server) defaultLiminalAuthFunc() func(r *http.Request) (string, error) {
 	return func(r *http.Request) (string, error) {
-		// Extract JWT from query param (WebSocket) or Authorization header
-		jwt := r.URL.Query().Get("token")
+		jwt := bearerToken(r)
 		if jwt == "" {
-			auth := r.Header.Get("Authorization")
-			if len(auth) > 7 && auth[:7] == "Bearer " {
-				jwt = auth[7:]
-			}
+			return "", errors.New("missing bearer token")
 		}
+		return tokenUserID(jwt), nil
+	}
+}
 
-		// Forward JWT to HTTPExecutor for API calls
-		if jwt != "" && s.config.LiminalExecutor != nil {
-			s.config.LiminalExecutor.UpdateJWT(jwt)
-		}
+// tokenUserID identifies the holder of a JWT. The gateway extracts the real
+// user from the JWT, but its claims are unverified here, so the ID is
+// derived from the whole token: only its holder can act as that user.
+func tokenUserID(jwt string) string {
+	sum := sha256.Sum256([]byte(jwt))
+	return "liminal-" + hex.EncodeToString(sum[:16])
+}
 
-		// Return placeholder user ID (gateway extracts real user from JWT)
-		return "user", nil
+// bearerToken extracts the JWT from the token query param (WebSocket) or
+// the Authorization header.
+func bearerToken(r *http.Request) string {
+	if jwt := r.URL.Query().Get("token"); jwt != "" {
+		return jwt
 	}
+	auth := r.Header.Get("Authorization")
+	if len(auth) > 7 && auth[:7] == "Bearer " {
+		return auth[7:]
+	}
+	return ""
 }
 
-func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	// Authenticate
-	userID := "default-user"
+// authenticate identifies the user making a request. authenticated reports
+// whether an AuthFunc, or the default Liminal one, vouched for them;
+// liminal whether the default Liminal one did.
+func (s *Server) authenticate(r *http.Request) (userID string, authenticated, liminal bool, err error) {
 	authFunc := s.config.AuthFunc
 
 	// Use default Liminal JWT handler if no custom auth provided
-	if authFunc == nil && s.config.LiminalExecutor != nil {
+	liminal = authFunc == nil && s.config.LiminalExecutor != nil
+	if liminal {
 		authFunc = s.defaultLiminalAuthFunc()
 	}
+	if authFunc == nil {
+		return "default-user", false, false, nil
+	}
+	userID, err = authFunc(r)
+	return userID, true, liminal, err
+}
 
-	if authFunc != nil {
-		var err error
-		userID, err = authFunc(r)
-		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
+// requestContext derives the context a client's messages are handled in
+// from its HTTP request.
+func requestContext(r *http.Request, liminalAuth bool) context.Context {
+	ctx := r.Context()
+	if liminalAuth {
+		// Each client's executor calls carry its own JWT, never another
+		// user's; the shared executor's token is only a fallback.
+		if jwt := bearerToken(r); jwt != "" {
+			ctx = core.WithCredential(ctx, jwt)
 		}
 	}
+	return ctx
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	userID, authenticated, liminalAuth, err := s.authenticate(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	dev := s.devModeRequested(r, userID, authenticated)
 
 	// Upgrade connection
-	conn, err := s.upgrader.Upgrade(w, r, nil)
+	ws, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade failed: %v", err)
 		return
 	}
-	defer conn.Close()
+	defer ws.Close()
+
+	// The connection outlives the upgrade request, so its context is only
+	// cancelled when the connection closes, or when shutdown gives up
+	// waiting for it.
+	connCtx, cancelConn := context.WithCancel(context.WithoutCancel(requestContext(r, liminalAuth)))
+	defer cancelConn()
+	conn, ok := s.track(ws, userID, cancelConn)
+	if !ok {
+		ws.WriteJSON(ServerMessage{Type: "server_closing"})
+		return
+	}
+	defer s.untrack(ws)
 
 	log.Printf("WebSocket connected for user %s", userID)
+	if dev {
+		log.Printf("Developer mode enabled for user %s", userID)
+		connCtx = withDevMode(connCtx)
+	}
 
-	var currentSession *session
+	// Frames are read on their own goroutine so message fragments can be
+	// coalesced; they are still handled one at a time, in order.
+	ping, idle := s.keepaliveTimes()
+	defer startKeepalive(ws, ping, idle)()
+	frames := make(chan inbound)
+	go readFrames(ws, frames, idle)
+	s.serveFrames(connCtx, conn, userID, frames)
+}
 
-	for {
-		_, msgBytes, err := conn.ReadMessage()
-		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket error: %v", err)
-			}
-			break
+// serveFrames handles a connected client's messages from frames, one at a
+// time and in order, until frames is closed. It is shared by the WebSocket
+// and SSE transports.
+func (s *Server) serveFrames(connCtx context.Context, conn connection, userID string, frames <-chan inbound) {
+	// The session, if any, is forgotten however the connection ends.
+	defer s.endSession(conn)
+
+	c := &coalescer{
+		window:  s.config.CoalesceWindow,
+		maxWait: s.config.CoalesceMaxWait,
+		ack: func(fragment ClientMessage) {
+			s.send(conn, ServerMessage{Type: "message_ack", Content: fragment.Content, ReplyTo: fragment.ID})
+		},
+	}
+
+	// An "abort" stops the message being handled, so it can't wait its
+	// turn with the other frames.
+	var run activeRun
+	queued := make(chan inbound)
+	go interceptAborts(frames, queued, func(msg ClientMessage) {
+		if run.abort() {
+			log.Printf("Aborting the run in flight for user=%s", userID)
 		}
+		if msg.ID != "" {
+			s.send(conn, ServerMessage{Type: "message_ack", ReplyTo: msg.ID})
+		}
+	})
 
-		var msg ClientMessage
-		if err := json.Unmarshal(msgBytes, &msg); err != nil {
+	var currentSession *session
+	c.run(queued, func(in inbound) {
+		if in.err != nil {
 			s.sendError(conn, "Invalid message format")
-			continue
+			return
 		}
+		msg := in.msg
 
 		log.Printf("Received message type=%s from user=%s", msg.Type, userID)
 
-		switch msg.Type {
-		case "new_conversation":
-			currentSession = s.handleNewConversation(r.Context(), conn, userID)
+		if !s.beginRun() {
+			s.send(conn, ServerMessage{Type: "error", Code: "server_closing", Content: "The server is shutting down", ReplyTo: msg.ID})
+			return
+		}
+		defer s.endRun()
 
-		case "resume_conversation":
-			currentSession = s.handleResumeConversation(r.Context(), conn, userID, msg.ConversationID)
+		// Coalesced fragments were acknowledged as they arrived.
+		if msg.ID != "" && !c.coalesces(msg) {
+			s.send(conn, ServerMessage{Type: "message_ack", Content: msg.Content, ReplyTo: msg.ID})
+		}
 
-		case "message":
-			if currentSession == nil {
-				s.sendError(conn, "No active conversation. Send 'new_conversation' first.")
-				continue
+		// This connection's messages are handled in order, but the
+		// conversation may be running elsewhere, e.g. in another tab.
+		if currentSession != nil && runsConversation(msg.Type) {
+			release, ok := s.claimConversation(conn, currentSession, msg.ID)
+			if !ok {
+				return
 			}
-			s.handleMessage(r.Context(), conn, currentSession, msg.Content)
+			defer release()
+		}
+		defer conn.replyingTo(msg.ID)()
+
+		ctx, cancel := s.messageContext(connCtx, userID, currentSession)
+		defer cancel()
+		// Confirmations can't be aborted: the write may already be under way.
+		if msg.Type == "message" {
+			var done func()
+			ctx, done = run.start(ctx)
+			defer done()
+		}
+		currentSession = s.handleClientMessage(ctx, conn, userID, currentSession, msg)
+	})
+}
 
-		case "confirm":
-			if currentSession == nil {
-				s.sendError(conn, "No active conversation")
-				continue
-			}
-			s.handleConfirm(r.Context(), conn, currentSession, userID, msg.ActionID)
+// messageContext derives the context for handling a single client message.
+// It carries the caller's identity, their feature flags, a fresh request ID,
+// and the per-turn deadline, and is passed unchanged to the engine and every
+// tool it runs.
+func (s *Server) messageContext(connCtx context.Context, userID string, sess *session) (context.Context, context.CancelFunc) {
+	id := core.Identity{UserID: userID}
+	if sess != nil {
+		id.SessionID = sess.ID
+		id.ConversationID = sess.ConversationID
+	}
 
-		case "cancel":
-			if currentSession == nil {
-				s.sendError(conn, "No active conversation")
-				continue
-			}
-			s.handleCancel(r.Context(), conn, currentSession, userID, msg.ActionID)
+	ctx := core.WithIdentity(connCtx, id)
+	ctx = features.WithFlags(ctx, s.features.Evaluate(ctx, id))
+	ctx = core.WithRequestID(ctx, uuid.New().String())
+	return core.WithTurnDeadline(ctx, time.Now().Add(core.DefaultLimits().Timeout))
+}
+
+// handleClientMessage dispatches a client message and returns the active session.
+func (s *Server) handleClientMessage(ctx context.Context, conn peer, userID string, currentSession *session, msg ClientMessage) *session {
+	switch msg.Type {
+	case "new_conversation":
+		return s.handleNewConversation(ctx, conn, userID, msg.Locale)
 
-		default:
-			s.sendError(conn, fmt.Sprintf("Unknown message type: %s", msg.Type))
+	case "resume_conversation":
+		return s.handleResumeConversation(ctx, conn, userID, msg.ConversationID, msg.Locale)
+
+	case "message":
+		if currentSession == nil {
+			s.sendError(conn, "No active conversation. Send 'new_conversation' first.")
+			return nil
+		}
+		if !s.modelAllowed(msg.Model) {
+			s.send(conn, ServerMessage{Type: "error", Code: "model_not_allowed", Content: modelNotAllowed(msg.Model)})
+			return currentSession
 		}
+		s.handleMessage(ctx, conn, currentSession, msg.Content, msg.Model, msg.Attachments)
+
+	case "confirm":
+		if currentSession == nil {
+			s.sendError(conn, "No active conversation")
+			return nil
+		}
+		if len(msg.ActionIDs) > 0 {
+			s.handleConfirmGroup(ctx, conn, currentSession, userID, msg.ActionID, msg.ActionIDs)
+			return currentSession
+		}
+		s.handleConfirm(ctx, conn, currentSession, userID, msg.ActionID, nil)
+
+	case "confirm_with_edits":
+		if currentSession == nil {
+			s.sendError(conn, "No active conversation")
+			return nil
+		}
+		if len(msg.Input) == 0 {
+			s.sendError(conn, "confirm_with_edits requires an input")
+			return currentSession
+		}
+		s.handleConfirm(ctx, conn, currentSession, userID, msg.ActionID, msg.Input)
+
+	case "cancel":
+		if currentSession == nil {
+			s.sendError(conn, "No active conversation")
+			return nil
+		}
+		s.handleCancel(ctx, conn, currentSession, userID, msg.ActionID)
+
+	case "rename_conversation":
+		s.handleRename(ctx, conn, userID, currentSession, msg.ConversationID, msg.Title)
+
+	case "list_conversations":
+		s.handleListConversations(ctx, conn, userID, msg.Cursor, msg.Limit)
+
+	case "delete_conversation":
+		return s.handleDeleteConversation(ctx, conn, userID, currentSession, msg.ConversationID)
+
+	default:
+		s.sendError(conn, fmt.Sprintf("Unknown message type: %s", msg.Type))
 	}
+	return currentSession
 }
 
-func (s *Server) handleNewConversation(ctx context.Context, conn *websocket.Conn, userID string) *session {
+func (s *Server) handleNewConversation(ctx context.Context, conn peer, userID, locale string) *session {
 	conv, err := s.conversations.Create(ctx, userID)
 	if err != nil {
 		s.sendError(conn, fmt.Sprintf("Failed to create conversation: %v", err))
@@ -303,76 +992,174 @@ func (s *Server) handleNewConversation(ctx context.Context, conn *websocket.Conn
 		UserID:         userID,
 		ConversationID: conv.ID,
 		History:        []core.Message{},
+		Locale:         locale,
 	}
-	s.sessions.Store(conn, sess)
+	s.startSession(conn, sess)
 
 	s.send(conn, ServerMessage{
 		Type:           "conversation_started",
 		ConversationID: conv.ID,
+		Title:          conv.Title,
+		Features:       features.FromContext(ctx),
 	})
 
 	log.Printf("Started conversation %s for user %s", conv.ID, userID)
+	s.presentScheduledTransfers(ctx, conn, sess)
 	return sess
 }
 
-func (s *Server) handleResumeConversation(ctx context.Context, conn *websocket.Conn, userID, conversationID string) *session {
+func (s *Server) handleResumeConversation(ctx context.Context, conn peer, userID, conversationID, locale string) *session {
 	conv, err := s.conversations.Get(ctx, conversationID)
-	if err != nil {
+	if errors.Is(err, store.ErrConversationEvicted) {
+		s.send(conn, ServerMessage{
+			Type:    "error",
+			Code:    "conversation_evicted",
+			Content: "This conversation is no longer available. Please start a new one.",
+		})
+		return nil
+	}
+	// Another user's conversation is reported as missing, not forbidden.
+	if err != nil || conv.UserID != userID {
 		s.sendError(conn, "Conversation not found")
 		return nil
 	}
 
+	sess := resumedSession(conv, userID)
+	sess.Locale = locale
+	s.startSession(conn, sess)
+
+	// Stored artifact refs carry no links; mint fresh ones for the client.
+	messages := make([]store.StoredMessage, len(conv.Messages))
+	for i, m := range conv.Messages {
+		m.Artifacts = s.config.Artifacts.ResolveAll(m.Artifacts)
+		messages[i] = m
+	}
+
+	s.send(conn, ServerMessage{
+		Type:           "conversation_resumed",
+		ConversationID: conversationID,
+		Title:          conv.Title,
+		Messages:       messages,
+		Features:       features.FromContext(ctx),
+	})
+
+	log.Printf("Resumed conversation %s for user %s", conversationID, userID)
+	s.presentScheduledTransfers(ctx, conn, sess)
+	return sess
+}
+
+// resumedSession rebuilds a session from a stored conversation.
+func resumedSession(conv *store.ConversationWithMessages, userID string) *session {
 	// Convert stored messages to core.Message
 	history := make([]core.Message, 0, len(conv.Messages))
 	for _, m := range conv.Messages {
+		content := m.Content
+		if note := attachmentNote(m.Artifacts); note != "" && m.Role == "user" {
+			content = strings.TrimSpace(note + "\n\n" + content)
+		}
 		history = append(history, core.Message{
 			Role:    core.Role(m.Role),
-			Content: m.Content,
+			Content: content,
 		})
 	}
 
-	sess := &session{
-		ID:             conversationID,
+	return &session{
+		ID:             conv.ID,
 		UserID:         userID,
-		ConversationID: conversationID,
+		ConversationID: conv.ID,
 		History:        history,
+		Usage:          conv.Usage,
+		titled:         !untitled(conv.Title),
 	}
-	s.sessions.Store(conn, sess)
+}
 
-	s.send(conn, ServerMessage{
-		Type:           "conversation_resumed",
-		ConversationID: conversationID,
-		Messages:       conv.Messages,
-	})
+// presentScheduledTransfers sends confirmation requests for scheduled transfers
+// that came due while the user was offline.
+func (s *Server) presentScheduledTransfers(ctx context.Context, conn peer, sess *session) {
+	if s.config.Scheduler == nil {
+		return
+	}
 
-	log.Printf("Resumed conversation %s for user %s", conversationID, userID)
-	return sess
+	actions, err := s.config.Scheduler.CatchUp(ctx, sess.UserID)
+	if err != nil {
+		log.Printf("Failed to load scheduled transfers for user %s: %v", sess.UserID, err)
+	}
+	for _, action := range actions {
+		sess.addPending(action.ID)
+		s.pins.addAction(sess.ConversationID, action)
+		s.watchExpiry(conn, sess, action)
+		s.send(conn, ServerMessage{
+			Type:      "confirm_request",
+			ActionID:  action.ID,
+			Tool:      action.Tool,
+			Summary:   action.Summary,
+			Input:     action.Input,
+			InputHash: core.ShortHash(action.InputHash),
+			Details:   action.Details,
+			ExpiresAt: time.Unix(action.ExpiresAt, 0).Format(time.RFC3339),
+		})
+	}
 }
 
-func (s *Server) handleMessage(ctx context.Context, conn *websocket.Conn, sess *session, content string) {
-	if content == "" {
-		return
+// handleMessage runs the agent on a user message and its attachments, with
+// model if set or else the default, returning its output if it ran.
+func (s *Server) handleMessage(ctx context.Context, conn peer, sess *session, content, model string, attachments []Attachment) *engine.Output {
+	if content == "" && len(attachments) == 0 {
+		return nil
+	}
+	images, refs, err := s.attachmentBlocks(attachments)
+	if err != nil {
+		s.send(conn, ServerMessage{Type: "error", Code: "invalid_attachment", Content: err.Error()})
+		return nil
+	}
+
+	// A message with images is never a reply to a confirmation.
+	var note string
+	if len(images) == 0 {
+		var handled bool
+		handled, note = s.handleReply(ctx, conn, sess, content)
+		if handled {
+			return nil
+		}
+	}
+
+	if s.overTokenLimit(sess) {
+		log.Printf("[CONVERSATION %s] Refused message over the token limit", sess.ConversationID)
+		s.send(conn, ServerMessage{Type: "error", Code: "conversation_token_limit", Content: tokenLimitMessage})
+		return nil
 	}
 
 	log.Printf("[CONVERSATION %s] USER: %s", sess.ConversationID, truncate(content, 50))
 
-	// Add to history
-	sess.History = append(sess.History, core.NewUserMessage(content))
+	sess.mu.Lock()
 	sess.TurnCount++
-
-	// Persist user message
-	s.persistMessage(ctx, sess.ConversationID, "user", content)
+	sess.mu.Unlock()
 
 	// Build input
-	agentCtx := core.NewContext(sess.UserID, sess.ID, sess.ConversationID, sess.ID)
+	agentCtx := core.NewContext(sess.UserID, sess.ID, sess.ConversationID, core.RequestIDFromContext(ctx))
+	agentCtx.Credential = core.CredentialFromContext(ctx)
+	agentCtx.Preferences = s.sessionPreferences(ctx, sess)
 
 	input := &engine.Input{
-		UserMessage:  content,
-		Context:      agentCtx,
-		History:      sess.History[:len(sess.History)-1],
-		SystemPrompt: s.config.SystemPrompt,
-		Model:        s.config.Model,
-		MaxTokens:    s.config.MaxTokens,
+		UserMessage:   content,
+		UserContent:   images,
+		Context:       agentCtx,
+		History:       sess.history(),
+		SystemPrompt:  s.config.SystemPrompt,
+		Model:         model,
+		DebugCallback: s.debugCallback(ctx, conn),
+		ProgressCallback: func(tool, stage string, percent float64) {
+			s.send(conn, ServerMessage{Type: "tool_progress", Tool: tool, Stage: stage, Percent: &percent})
+		},
+		ToolMessageCallback: func(tool, text string) {
+			s.send(conn, ServerMessage{Type: "tool_message", Tool: tool, Content: text})
+		},
+	}
+	if shortcuts := sess.prefs.ShortcutsPrompt(); shortcuts != "" {
+		input.SystemPrompt += "\n\n" + shortcuts
+	}
+	if note != "" {
+		input.SystemPrompt += "\n\n" + note
 	}
 
 	// Only enable streaming if not disabled (streaming requires SSE-compatible server)
@@ -386,61 +1173,172 @@ func (s *Server) handleMessage(ctx context.Context, conn *websocket.Conn, sess *
 
 	// Run agent
 	output, err := s.engine.Run(ctx, input)
+
+	// An aborted run's context is cancelled, but what happened is still saved.
+	if output != nil && output.Type == engine.OutputAborted {
+		ctx = context.WithoutCancel(ctx)
+	}
+	if output != nil {
+		// Counted whatever the outcome, including runs that end in a
+		// confirmation request, which is answered without one.
+		s.addUsage(ctx, sess, output.TokensUsed)
+	}
+
+	// Record the message as the model saw it: moderation may have rewritten
+	// or refused it, and a refused message is never stored. Images are
+	// stored as references only.
+	if output != nil && (output.UserMessage != "" || len(images) > 0 && !output.InputRefused()) {
+		sess.appendHistory(userMessage(output.UserMessage, images))
+		s.persistMessage(ctx, sess.ConversationID, "user", output.UserMessage, refs...)
+	}
+
 	if err != nil {
 		log.Printf("Agent error: %v", err)
-		s.sendError(conn, fmt.Sprintf("Agent error: %v", err))
-		return
+		s.send(conn, errorMessage(fmt.Sprintf("Agent error: %v", err), err))
+		return nil
 	}
 
 	s.handleOutput(ctx, conn, sess, output)
+	return output
 }
 
-func (s *Server) handleOutput(ctx context.Context, conn *websocket.Conn, sess *session, output *engine.Output) {
+func (s *Server) handleOutput(ctx context.Context, conn peer, sess *session, output *engine.Output) {
+	if output.Sanitization.Changed() {
+		log.Printf("[CONVERSATION %s] Sanitized assistant output: %s", sess.ConversationID, output.Sanitization)
+	}
+
+	for _, execution := range output.ToolsUsed {
+		s.sendToolResult(conn, execution.Tool, execution.Result)
+	}
+
 	switch output.Type {
 	case engine.OutputComplete:
 		log.Printf("[CONVERSATION %s] ASSISTANT: %s", sess.ConversationID, truncate(output.Text, 200))
 
-		sess.History = append(sess.History, core.NewAssistantMessage(output.Text))
-
-		s.persistMessage(ctx, sess.ConversationID, "assistant", output.Text)
+		// A refusal of the user's message is shown but kept out of the
+		// conversation, like the message itself.
+		if !output.InputRefused() {
+			sess.appendHistory(core.NewAssistantMessage(output.Text))
+			s.persistMessage(ctx, sess.ConversationID, "assistant", output.Text)
+		}
 
-		s.send(conn, ServerMessage{Type: "text", Content: output.Text})
-		s.send(conn, ServerMessage{
-			Type: "complete",
-			TokenUsage: &TokenUsage{
-				InputTokens:  output.TokensUsed.InputTokens,
-				OutputTokens: output.TokensUsed.OutputTokens,
-				TotalTokens:  output.TokensUsed.TotalTokens(),
-			},
-		})
+		s.sendText(conn, output.Text)
+		if !output.InputRefused() {
+			s.titleConversation(ctx, conn, sess)
+		}
+		s.complete(conn, sess, ServerMessage{Model: output.Model, TokenUsage: tokenUsage(output.TokensUsed)})
 
 	case engine.OutputConfirmationNeeded:
-		pending := output.PendingAction
-
-		// Store confirmation
-		if err := s.confirmations.Store(ctx, pending); err != nil {
-			log.Printf("Failed to store confirmation: %v", err)
+		if output.PendingGroup != nil {
+			s.offerGroup(ctx, conn, sess, output)
+			return
 		}
+		pending := s.storePending(ctx, sess, output.PendingAction)
+		sess.addPending(pending.ID)
+		s.pins.addAction(sess.ConversationID, pending)
+		s.watchExpiry(conn, sess, pending)
 
-		sess.History = append(sess.History, core.NewAssistantMessageWithBlocks(output.ResponseBlocks))
+		sess.appendHistory(core.NewAssistantMessageWithBlocks(output.ResponseBlocks))
 
 		s.send(conn, ServerMessage{
 			Type:      "confirm_request",
 			ActionID:  pending.ID,
 			Tool:      pending.Tool,
 			Summary:   pending.Summary,
+			Input:     pending.Input,
+			InputHash: core.ShortHash(pending.InputHash),
+			Details:   pending.Details,
 			Content:   output.Text,
 			ExpiresAt: time.Unix(pending.ExpiresAt, 0).Format(time.RFC3339),
 		})
 
 	case engine.OutputError:
 		log.Printf("Agent error: %v", output.Error)
-		s.sendError(conn, output.Error.Error())
+		s.send(conn, errorMessage(output.Error.Error(), output.Error))
+
+	case engine.OutputAborted:
+		// Note the interruption, so the next turn doesn't read the user's
+		// message as unanswered.
+		log.Printf("[CONVERSATION %s] Run aborted by the user", sess.ConversationID)
+		sess.appendHistory(core.NewAssistantMessage(abortedNote))
+		s.persistMessage(ctx, sess.ConversationID, "assistant", abortedNote)
+		s.send(conn, ServerMessage{Type: "aborted", Content: abortedNote, Model: output.Model, TokenUsage: tokenUsage(output.TokensUsed), ConversationTokenUsage: sess.conversationUsage()})
+	}
+}
+
+// storePending stores a new pending action, unless an identical one (by
+// idempotency key) is still awaiting confirmation: then that one is offered
+// again, answering the new tool call, so that confirming both copies can't
+// execute the action twice.
+func (s *Server) storePending(ctx context.Context, sess *session, pending *core.PendingAction) *core.PendingAction {
+	if pending.IdempotencyKey != "" {
+		existing, err := s.confirmations.GetByIdempotency(ctx, pending.UserID, pending.IdempotencyKey)
+		if err != nil {
+			log.Printf("Failed to look up duplicate confirmations: %v", err)
+		}
+		if existing != nil {
+			log.Printf("[CONVERSATION %s] Reusing pending action %s for duplicate %s", sess.ConversationID, existing.ID, pending.ID)
+			if s.config.Limits != nil {
+				s.config.Limits.Release(ctx, pending)
+			}
+			if existing.ConversationID != sess.ConversationID {
+				s.pins.removeAction(existing.ConversationID, existing.ID)
+			}
+			reused := *existing
+			reused.SessionID = pending.SessionID
+			reused.ConversationID = pending.ConversationID
+			reused.BlockID = pending.BlockID
+			reused.GroupID = pending.GroupID
+			pending = &reused
+		}
 	}
+
+	if err := s.confirmations.Store(ctx, pending); err != nil {
+		log.Printf("Failed to store confirmation: %v", err)
+	}
+	return pending
 }
 
-func (s *Server) handleConfirm(ctx context.Context, conn *websocket.Conn, sess *session, userID, actionID string) {
+// handleConfirm executes a confirmed action. edits, if set, replace the
+// action's input; they are checked before the action is consumed, so
+// invalid edits leave it pending for the user to correct or confirm as is.
+// actionID may name a group, confirming all its actions, or one action of
+// a group, confirming only that one.
+func (s *Server) handleConfirm(ctx context.Context, conn peer, sess *session, userID, actionID string, edits json.RawMessage) {
 	log.Printf("Processing confirmation for action=%s, user=%s", actionID, userID)
+	if groupID := s.groupOf(ctx, userID, actionID); groupID != "" {
+		if edits != nil {
+			s.send(conn, ServerMessage{Type: "error", Code: "invalid_edits", ActionID: actionID, Content: "Invalid edits: actions offered together can't be edited"})
+			return
+		}
+		var only []string
+		if groupID != actionID {
+			only = []string{actionID}
+		}
+		s.handleConfirmGroup(ctx, conn, sess, userID, groupID, only)
+		return
+	}
+	// Confirmed tools run outside an agent run, so they find the user's
+	// preferences on ctx.
+	ctx = core.WithPreferences(ctx, s.sessionPreferences(ctx, sess))
+	var edited *core.PendingAction
+	if edits != nil {
+		pending, err := s.confirmations.Get(ctx, userID, actionID)
+		if err == nil {
+			if s.config.Preferences != nil {
+				edits = resolveShortcuts(s.config.Preferences)(ctx, userID, pending.Tool, edits)
+			}
+			copied := *pending
+			edited = &copied
+			err = s.engine.EditAction(edited, edits)
+		}
+		if err != nil {
+			s.send(conn, ServerMessage{Type: "error", Code: "invalid_edits", ActionID: actionID, Content: fmt.Sprintf("Invalid edits: %v", err)})
+			return
+		}
+	}
+	sess.removePending(actionID)
+	s.pins.removeAction(sess.ConversationID, actionID)
 
 	// Get and remove confirmation
 	action, err := s.confirmations.Confirm(ctx, userID, actionID)
@@ -449,51 +1347,174 @@ func (s *Server) handleConfirm(ctx context.Context, conn *websocket.Conn, sess *
 			Type:    "text",
 			Content: "That action expired. Would you like me to set it up again?",
 		})
-		s.send(conn, ServerMessage{Type: "complete"})
+		s.complete(conn, sess, ServerMessage{})
 		return
 	}
+	if edited != nil {
+		// Run the copy the edits were checked on, so they apply exactly
+		// as validated and can't fail now the action is consumed.
+		action = edited
+		log.Printf("[CONVERSATION %s] Action %s edited before confirmation", sess.ConversationID, action.ID)
+	}
 
-	// Execute the confirmed tool
-	result, err := s.engine.ExecuteTool(ctx, userID, action.Tool, action.Input, action.ID)
-
-	var resultContent string
-	var isError bool
-	if err != nil {
-		resultContent = fmt.Sprintf("Error: %v", err)
-		isError = true
-	} else if !result.Success {
-		resultContent = result.Error
-		isError = true
-	} else {
-		resultBytes, _ := json.Marshal(result.Data)
-		resultContent = string(resultBytes)
+	run := s.runConfirmed(ctx, conn, sess, action)
+	if run.limitErr != nil {
+		s.send(conn, ServerMessage{Type: "text", Content: run.notice()})
+		s.complete(conn, sess, ServerMessage{})
+		return
 	}
 
-	// Add tool result to history
-	sess.History = append(sess.History, core.NewToolResultMessage([]core.ToolResultContent{
-		{ToolUseID: action.BlockID, Content: resultContent, IsError: isError},
-	}))
+	// Add tool result to history. Actions raised outside the conversation
+	// (e.g. scheduled transfers) have no tool_use block to answer.
+	if action.BlockID != "" {
+		sess.appendHistory(core.NewToolResultMessage([]core.ToolResultContent{run.toolResult(action)}))
+	}
 
-	if isError {
-		s.send(conn, ServerMessage{
-			Type:    "text",
-			Content: fmt.Sprintf("Sorry, that action failed: %s", resultContent),
-		})
-		s.send(conn, ServerMessage{Type: "complete"})
+	if run.isError {
+		s.send(conn, ServerMessage{Type: "text", Content: run.notice()})
+		s.complete(conn, sess, ServerMessage{})
 		return
 	}
+	result := run.result
+
+	s.sendToolResult(conn, action.Tool, result.Data)
+	// The action may have changed the user's preferences, e.g. a new shortcut.
+	sess.prefs = nil
 
-	// Format success message
+	// Format success message. Tool results are untrusted, so review them
+	// like model output.
 	resultMsg := formatToolResult(action.Tool, result.Data)
-	sess.History = append(sess.History, core.NewAssistantMessage(resultMsg))
+	if s.config.Sanitizer != nil {
+		var report sanitize.Report
+		resultMsg, report = sanitize.Markdown(resultMsg, *s.config.Sanitizer)
+		if report.Changed() {
+			log.Printf("[CONVERSATION %s] Sanitized tool result: %s", sess.ConversationID, report)
+		}
+	}
+	sess.appendHistory(core.NewAssistantMessage(resultMsg))
 
 	s.persistMessage(ctx, sess.ConversationID, "assistant", resultMsg)
 
-	s.send(conn, ServerMessage{Type: "text", Content: resultMsg})
-	s.send(conn, ServerMessage{Type: "complete"})
+	s.sendText(conn, resultMsg)
+	s.complete(conn, sess, ServerMessage{})
+	s.runActionHooks(ctx, action, result)
+}
+
+// confirmedRun is the outcome of executing a confirmed action.
+type confirmedRun struct {
+	result  *core.ToolResult
+	content string // the tool_result content for the model
+	isError bool
+
+	limitErr     error // Limits or the tool policy refused the action, so it didn't execute
+	integrityErr *core.InputIntegrityError
+	duplicateErr *engine.DuplicateActionError
+}
+
+// toolResult answers action's tool_use block with the run's outcome.
+func (r confirmedRun) toolResult(action *core.PendingAction) core.ToolResultContent {
+	content := r.content
+	if action.OriginalInput != nil {
+		content = fmt.Sprintf("The user changed the input to %s before confirming.\n%s", action.Input, r.content)
+	}
+	return core.ToolResultContent{ToolUseID: action.BlockID, Content: content, IsError: r.isError}
+}
+
+// notice tells the user why a failed run failed.
+func (r confirmedRun) notice() string {
+	switch {
+	case r.limitErr != nil:
+		return fmt.Sprintf("Sorry, I can't do that right now: %v", r.limitErr)
+	case r.integrityErr != nil:
+		return "Sorry, I didn't run that action: it no longer matches what you approved. Nothing was executed - would you like me to set it up again?"
+	case r.duplicateErr != nil:
+		return "That's already been done, so I didn't do it again."
+	}
+	return fmt.Sprintf("Sorry, that action failed: %s", r.content)
 }
 
-func (s *Server) handleCancel(ctx context.Context, conn *websocket.Conn, sess *session, userID, actionID string) {
+// runConfirmed executes a confirmed action, taken from the Confirmations
+// store, after checking it against Limits again, and records it against
+// them if it succeeds.
+func (s *Server) runConfirmed(ctx context.Context, conn peer, sess *session, action *core.PendingAction) confirmedRun {
+	var run confirmedRun
+
+	// Re-check limits: other transfers may have executed since this was offered
+	if s.config.Limits != nil {
+		if err := s.config.Limits.Authorize(ctx, action); err != nil {
+			s.config.Limits.Release(ctx, action)
+			run.limitErr = err
+			run.content = fmt.Sprintf("Error: %v", err)
+			run.isError = true
+			return run
+		}
+	}
+
+	// Execute the confirmed tool, provided its input is what was approved
+	start := time.Now()
+	result, err := s.engine.ExecuteAction(ctx, action)
+	durationMs := time.Since(start).Milliseconds()
+
+	run.result = result
+	var denied *engine.PolicyDeniedError
+	if errors.As(err, &denied) {
+		log.Printf("[CONVERSATION %s] Refused action %s: %v", sess.ConversationID, action.ID, err)
+		run.limitErr = denied
+		run.content = fmt.Sprintf("Error: %v", err)
+		run.isError = true
+	} else if errors.As(err, &run.integrityErr) {
+		log.Printf("[CONVERSATION %s] Refused action %s: %v", sess.ConversationID, action.ID, err)
+		run.content = fmt.Sprintf("Error: %v", err)
+		run.isError = true
+	} else if errors.As(err, &run.duplicateErr) {
+		log.Printf("[CONVERSATION %s] Refused action %s: %v", sess.ConversationID, action.ID, err)
+		previous, _ := json.Marshal(run.duplicateErr.Result.Data)
+		run.content = fmt.Sprintf("Error: %v. Its result was: %s", err, previous)
+		run.isError = true
+	} else if err != nil {
+		run.content = fmt.Sprintf("Error: %v", err)
+		run.isError = true
+	} else if !result.Success {
+		run.content = result.Error
+		run.isError = true
+	} else {
+		resultBytes, _ := json.Marshal(result.Data)
+		run.content = string(resultBytes)
+	}
+
+	debug := engine.DebugEvent{
+		Kind:       engine.DebugToolCall,
+		Tool:       action.Tool,
+		Input:      engine.RedactDebug(action.Input),
+		DurationMs: durationMs,
+	}
+	if run.isError {
+		debug.Error = run.content
+	} else {
+		debug.Result = engine.RedactDebug(json.RawMessage(run.content))
+	}
+	s.sendDebug(ctx, conn, debug)
+
+	if s.config.Limits != nil {
+		if run.isError {
+			s.config.Limits.Release(ctx, action)
+		} else if err := s.config.Limits.Commit(ctx, action); err != nil {
+			log.Printf("Failed to record transfer %s against limits: %v", action.ID, err)
+		}
+	}
+	return run
+}
+
+// handleCancel cancels a pending action. Cancelling a group, or any
+// action of one, cancels the whole group.
+func (s *Server) handleCancel(ctx context.Context, conn peer, sess *session, userID, actionID string) {
+	if groupID := s.groupOf(ctx, userID, actionID); groupID != "" {
+		s.handleCancelGroup(ctx, conn, sess, userID, groupID)
+		return
+	}
+	sess.removePending(actionID)
+	s.pins.removeAction(sess.ConversationID, actionID)
+
 	// Get action first to have the BlockID for history
 	action, err := s.confirmations.Get(ctx, userID, actionID)
 	if err != nil {
@@ -507,33 +1528,49 @@ func (s *Server) handleCancel(ctx context.Context, conn *websocket.Conn, sess *s
 		return
 	}
 
+	if s.config.Limits != nil {
+		s.config.Limits.Release(ctx, action)
+	}
+	s.observeConfirmation(action.Tool, engine.ConfirmationCancelled)
+
 	// Add cancelled tool result to history
-	sess.History = append(sess.History, core.NewToolResultMessage([]core.ToolResultContent{
-		{ToolUseID: action.BlockID, Content: "Cancelled by user", IsError: true},
-	}))
+	if action.BlockID != "" {
+		sess.appendHistory(core.NewToolResultMessage([]core.ToolResultContent{
+			{ToolUseID: action.BlockID, Content: "Cancelled by user", IsError: true},
+		}))
+	}
 
 	s.send(conn, ServerMessage{Type: "text", Content: "Action cancelled."})
-	s.send(conn, ServerMessage{Type: "complete"})
+	s.complete(conn, sess, ServerMessage{})
 }
 
-func (s *Server) persistMessage(ctx context.Context, conversationID string, role, content string) {
+// observeConfirmation reports a confirmation step the engine doesn't see
+// to the configured metrics, if any.
+func (s *Server) observeConfirmation(tool string, outcome engine.ConfirmationOutcome) {
+	if s.config.Metrics != nil {
+		s.config.Metrics.ObserveConfirmation(tool, outcome)
+	}
+}
+
+func (s *Server) persistMessage(ctx context.Context, conversationID string, role, content string, artifacts ...artifact.Ref) {
 	err := s.conversations.Append(ctx, &store.AppendMessage{
 		ConversationID: conversationID,
 		Role:           role,
 		Content:        content,
+		Artifacts:      artifacts,
 	})
 	if err != nil {
 		log.Printf("Failed to persist message: %v", err)
 	}
 }
 
-func (s *Server) send(conn *websocket.Conn, msg ServerMessage) {
-	if err := conn.WriteJSON(msg); err != nil {
+func (s *Server) send(conn peer, msg ServerMessage) {
+	if err := conn.writeMessage(msg); err != nil {
 		log.Printf("Failed to send message: %v", err)
 	}
 }
 
-func (s *Server) sendError(conn *websocket.Conn, content string) {
+func (s *Server) sendError(conn peer, content string) {
 	log.Printf("Sending error: %s", content)
 	s.send(conn, ServerMessage{Type: "error", Content: content})
 }
@@ -545,7 +1582,27 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
+// sendToolResult sends the client the status, figures, warnings and
+// artifacts of an enveloped tool result. Other results aren't sent.
+func (s *Server) sendToolResult(conn peer, tool string, data interface{}) {
+	env, ok := core.EnvelopeOf(data)
+	if !ok {
+		return
+	}
+	s.send(conn, ServerMessage{
+		Type:      "tool_result",
+		Tool:      tool,
+		Status:    string(env.Status),
+		Figures:   env.Figures,
+		Warnings:  env.Warnings,
+		Artifacts: s.config.Artifacts.ResolveAll(env.Artifacts),
+	})
+}
+
 func formatToolResult(tool string, result interface{}) string {
+	if env, ok := core.EnvelopeOf(result); ok {
+		result = env.Data
+	}
 	switch r := result.(type) {
 	case map[string]interface{}:
 		if msg, ok := r["message"].(string); ok {
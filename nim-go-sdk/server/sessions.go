@@ -0,0 +1,99 @@
+package server
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// sessionMetrics tracks live sessions ("sessions"), published at /debug/vars.
+var sessionMetrics = expvar.NewMap("nim_server_sessions")
+
+// conversationPins tracks the conversations the server still needs, so a
+// store that evicts conversations (see store.Pinner) keeps them: those open
+// in a live session, and those with actions awaiting confirmation.
+type conversationPins struct {
+	mu       sync.Mutex
+	sessions map[string]int              // conversationID -> live sessions
+	actions  map[string]map[string]int64 // conversationID -> actionID -> expiry (unix)
+}
+
+func newConversationPins() *conversationPins {
+	return &conversationPins{
+		sessions: make(map[string]int),
+		actions:  make(map[string]map[string]int64),
+	}
+}
+
+func (p *conversationPins) openSession(conversationID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sessions[conversationID]++
+}
+
+func (p *conversationPins) closeSession(conversationID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sessions[conversationID]--; p.sessions[conversationID] <= 0 {
+		delete(p.sessions, conversationID)
+	}
+}
+
+func (p *conversationPins) addAction(conversationID string, action *core.PendingAction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.actions[conversationID] == nil {
+		p.actions[conversationID] = make(map[string]int64)
+	}
+	p.actions[conversationID][action.ID] = action.ExpiresAt
+}
+
+func (p *conversationPins) removeAction(conversationID, actionID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.actions[conversationID], actionID)
+	if len(p.actions[conversationID]) == 0 {
+		delete(p.actions, conversationID)
+	}
+}
+
+// pinned reports whether a conversation must be kept, forgetting expired
+// actions as it goes.
+func (p *conversationPins) pinned(conversationID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sessions[conversationID] > 0 {
+		return true
+	}
+
+	now := time.Now().Unix()
+	for id, expiresAt := range p.actions[conversationID] {
+		if expiresAt >= now {
+			return true
+		}
+		delete(p.actions[conversationID], id)
+	}
+	delete(p.actions, conversationID)
+	return false
+}
+
+// startSession makes sess the connection's session, ending any previous one.
+func (s *Server) startSession(conn peer, sess *session) {
+	s.endSession(conn)
+	s.sessions.Store(conn, sess)
+	s.pins.openSession(sess.ConversationID)
+	sessionMetrics.Add("sessions", 1)
+}
+
+// endSession forgets the connection's session, if it has one, and stops
+// watching its actions' expiry. It is called when the connection closes.
+func (s *Server) endSession(conn peer) {
+	if v, ok := s.sessions.LoadAndDelete(conn); ok {
+		sess := v.(*session)
+		sess.expiries.stopAll()
+		s.pins.closeSession(sess.ConversationID)
+		sessionMetrics.Add("sessions", -1)
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func liveSessions(s *Server) int {
+	n := 0
+	s.sessions.Range(func(any, any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+func TestConversationEviction(t *testing.T) {
+	model := &sendModel{}
+	srv := model.serve(t)
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          srv.URL,
+		DisableStreaming: true,
+		Conversations:    store.NewBoundedMemoryConversations(store.MemoryLimits{MaxConversations: 1}),
+		AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			return &core.ToolResult{Success: true}, nil
+		}).
+		Build())
+
+	// A conversation left with a pending confirmation.
+	first := dial(t, s)
+	first.send(ClientMessage{Type: "new_conversation"})
+	pending := first.read("conversation_started").ConversationID
+	first.send(ClientMessage{Type: "message", Content: "send 50 to alice"})
+	first.read("confirm_request")
+	first.conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for liveSessions(s) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("session not removed when its connection closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Conversations started since push the idle one out, but not the one
+	// awaiting confirmation or the one in use.
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "new_conversation"})
+	idle := c.read("conversation_started").ConversationID
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "new_conversation"})
+	current := c.read("conversation_started").ConversationID
+	if n := liveSessions(s); n != 1 {
+		t.Errorf("live sessions = %d, want 1", n)
+	}
+
+	c.send(ClientMessage{Type: "resume_conversation", ConversationID: idle})
+	var msg ServerMessage
+	if err := c.conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != "error" || msg.Code != "conversation_evicted" {
+		t.Errorf("resuming an evicted conversation = %+v, want a conversation_evicted error", msg)
+	}
+
+	for _, id := range []string{pending, current} {
+		c.send(ClientMessage{Type: "resume_conversation", ConversationID: id})
+		if got := c.read("conversation_resumed").ConversationID; got != id {
+			t.Errorf("resumed %s, want %s", got, id)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sseInboxSize is how many posted messages a stream holds while an earlier
+// one is being handled, before POST /messages waits for room.
+const sseInboxSize = 32
+
+// sseStream is an open Server-Sent Events stream: the server-to-client half
+// of the SSE transport. The client's messages arrive in its inbox from
+// POST /messages.
+type sseStream struct {
+	id         string
+	userID     string
+	credential string // the Liminal JWT the stream was opened with, if any
+	inbox      chan inbound
+	done       <-chan struct{} // closed when the stream ends
+	cancel     context.CancelFunc
+
+	mu      sync.Mutex // serializes writes
+	w       io.Writer
+	flusher http.Flusher
+	closed  bool // set once the GET handler returns and w is no longer usable
+
+	// replyTo is the ID of the client message being handled, stamped on
+	// every message sent until it is done.
+	replyTo string
+}
+
+func (st *sseStream) writeMessage(msg ServerMessage) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if msg.ReplyTo == "" {
+		msg.ReplyTo = st.replyTo
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return st.write(fmt.Sprintf("event: %s\ndata: %s\n\n", msg.Type, data))
+}
+
+// ping writes an SSE comment, so proxies don't close an idle stream.
+func (st *sseStream) ping() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.write(": ping\n\n")
+}
+
+// write sends an event and flushes it. Callers hold mu.
+func (st *sseStream) write(event string) error {
+	if st.closed {
+		return errors.New("stream closed")
+	}
+	if _, err := io.WriteString(st.w, event); err != nil {
+		return err
+	}
+	st.flusher.Flush()
+	return nil
+}
+
+// replyingTo stamps messages with id until the returned func is called.
+func (st *sseStream) replyingTo(id string) (done func()) {
+	st.mu.Lock()
+	st.replyTo = id
+	st.mu.Unlock()
+	return func() {
+		st.mu.Lock()
+		st.replyTo = ""
+		st.mu.Unlock()
+	}
+}
+
+// forward moves posted messages from the inbox to frames, in order, until
+// the stream ends, then closes frames.
+func (st *sseStream) forward(frames chan<- inbound) {
+	defer close(frames)
+	for {
+		select {
+		case in := <-st.inbox:
+			select {
+			case frames <- in:
+			case <-st.done:
+				return
+			}
+		case <-st.done:
+			return
+		}
+	}
+}
+
+// handleEvents opens an SSE stream, for clients that can't use WebSockets.
+// The first event, "stream_opened", carries the stream ID to post messages
+// to; every later event is a ServerMessage named by its type. With a
+// conversation_id query parameter the stream resumes that conversation,
+// as if it had been sent resume_conversation.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	userID, authenticated, liminalAuth, err := s.authenticate(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	dev := s.devModeRequested(r, userID, authenticated)
+
+	// The stream lasts as long as its request, unless shutdown ends it.
+	connCtx, cancelConn := context.WithCancel(requestContext(r, liminalAuth))
+	defer cancelConn()
+	stream := &sseStream{
+		id:      uuid.New().String(),
+		userID:  userID,
+		inbox:   make(chan inbound, sseInboxSize),
+		done:    connCtx.Done(),
+		cancel:  cancelConn,
+		w:       w,
+		flusher: flusher,
+	}
+	if liminalAuth {
+		stream.credential = bearerToken(r)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // don't let nginx buffer events
+	w.WriteHeader(http.StatusOK)
+
+	if !s.trackStream(stream) {
+		s.send(stream, ServerMessage{Type: "server_closing"})
+		return
+	}
+	defer s.untrackStream(stream)
+	defer func() {
+		stream.mu.Lock()
+		stream.closed = true
+		stream.mu.Unlock()
+	}()
+
+	log.Printf("SSE stream opened for user %s", userID)
+	if dev {
+		log.Printf("Developer mode enabled for user %s", userID)
+		connCtx = withDevMode(connCtx)
+	}
+
+	s.send(stream, ServerMessage{Type: "stream_opened", StreamID: stream.id})
+	if id := r.URL.Query().Get("conversation_id"); id != "" {
+		stream.inbox <- inbound{msg: ClientMessage{Type: "resume_conversation", ConversationID: id}}
+	}
+
+	ping, _ := s.keepaliveTimes()
+	go func() {
+		ticker := time.NewTicker(ping)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stream.done:
+				return
+			case <-ticker.C:
+				if stream.ping() != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	frames := make(chan inbound)
+	go stream.forward(frames)
+	s.serveFrames(connCtx, stream, userID, frames)
+}
+
+// handlePostMessage delivers a client message to the SSE stream named by
+// the stream query parameter. Replies arrive on the stream, so it answers
+// 202 Accepted as soon as the message is queued.
+func (s *Server) handlePostMessage(w http.ResponseWriter, r *http.Request) {
+	userID, _, liminalAuth, err := s.authenticate(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	stream := s.stream(r.URL.Query().Get("stream"))
+	// A stream only takes messages from the user, and the token, that opened it.
+	if stream == nil || stream.userID != userID || (liminalAuth && stream.credential != bearerToken(r)) {
+		http.Error(w, "Stream not found", http.StatusNotFound)
+		return
+	}
+
+	var msg ClientMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRESTBody)).Decode(&msg); err != nil {
+		http.Error(w, "Invalid message format", http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case stream.inbox <- inbound{msg: msg}:
+		w.WriteHeader(http.StatusAccepted)
+	case <-stream.done:
+		http.Error(w, "Stream not found", http.StatusNotFound)
+	case <-r.Context().Done():
+	}
+}
+
+// trackStream registers an open stream, returning false if the server is
+// shutting down.
+func (s *Server) trackStream(stream *sseStream) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return false
+	}
+	s.streams[stream.id] = stream
+	return true
+}
+
+func (s *Server) untrackStream(stream *sseStream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.streams, stream.id)
+}
+
+func (s *Server) stream(id string) *sseStream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streams[id]
+}
+
+func (s *Server) liveStreams() []*sseStream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	streams := make([]*sseStream, 0, len(s.streams))
+	for _, st := range s.streams {
+		streams = append(streams, st)
+	}
+	return streams
+}
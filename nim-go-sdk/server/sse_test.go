@@ -0,0 +1,285 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// sseClient is a Server-Sent Events client for a server under test.
+type sseClient struct {
+	t      *testing.T
+	url    string // the server's base URL
+	user   string
+	events *bufio.Reader
+	stream string
+}
+
+// openStream opens an SSE stream as user, with an optional query string,
+// and reads its stream_opened event.
+func openStream(t *testing.T, url, user, query string) *sseClient {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url+"/events?user="+user+"&"+query, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET /events: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	c := &sseClient{t: t, url: url, user: user, events: bufio.NewReader(resp.Body)}
+	c.stream = c.read("stream_opened").StreamID
+	if c.stream == "" {
+		t.Fatal("stream_opened without a stream ID")
+	}
+	return c
+}
+
+// post sends msg to the client's stream as user, returning the status.
+func (c *sseClient) post(user string, msg ClientMessage) int {
+	c.t.Helper()
+	body, _ := json.Marshal(msg)
+	resp, err := http.Post(c.url+"/messages?stream="+c.stream+"&user="+user, "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func (c *sseClient) send(msg ClientMessage) {
+	c.t.Helper()
+	if status := c.post(c.user, msg); status != http.StatusAccepted {
+		c.t.Fatalf("POST /messages: %d, want 202", status)
+	}
+}
+
+// next returns the next event, checking it is named by its type.
+func (c *sseClient) next() ServerMessage {
+	c.t.Helper()
+	var name string
+	var msg ServerMessage
+	for {
+		line, err := c.events.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("reading events: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &msg); err != nil {
+				c.t.Fatalf("event data: %v", err)
+			}
+		case line == "" && name != "":
+			if name != msg.Type {
+				c.t.Fatalf("event %q carries a %q message", name, msg.Type)
+			}
+			return msg
+		}
+	}
+}
+
+// read returns the next message of type want, skipping others.
+func (c *sseClient) read(want string) ServerMessage {
+	c.t.Helper()
+	for {
+		msg := c.next()
+		if msg.Type == want {
+			return msg
+		}
+		if msg.Type == "error" {
+			c.t.Fatalf("waiting for %s: error %q", want, msg.Content)
+		}
+	}
+}
+
+// readUntil returns every message up to and including the next of type last.
+func (c *sseClient) readUntil(last string) []ServerMessage {
+	c.t.Helper()
+	var msgs []ServerMessage
+	for {
+		msg := c.next()
+		msgs = append(msgs, msg)
+		if msg.Type == last {
+			return msgs
+		}
+	}
+}
+
+// sseServer serves s with SSE enabled. Clients are the user named in the
+// "user" query parameter.
+func sseServer(t *testing.T, cfg Config) (*Server, *httptest.Server) {
+	t.Helper()
+	cfg.AnthropicKey = "test"
+	cfg.ServerSentEvents = true
+	cfg.AuthFunc = func(r *http.Request) (string, error) { return r.URL.Query().Get("user"), nil }
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.routes())
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func TestSSE_Message(t *testing.T) {
+	_, srv := sseServer(t, Config{BaseURL: streamModel(t).URL})
+	c := openStream(t, srv.URL, "user-1", "")
+
+	c.send(ClientMessage{Type: "new_conversation"})
+	conversationID := c.read("conversation_started").ConversationID
+
+	c.send(ClientMessage{Type: "message", Content: "hi", ID: "m-1"})
+	msgs := c.readUntil("complete")
+	checkReplies(t, msgs, "m-1", "message_ack", "text_chunk", "text_chunk", "text", "conversation_renamed", "complete")
+	var streamed string
+	for _, msg := range msgs {
+		if msg.Type == "text_chunk" {
+			streamed += msg.Content
+		}
+	}
+	if streamed != "Hello there." {
+		t.Errorf("streamed %q, want %q", streamed, "Hello there.")
+	}
+
+	// A new stream can pick the conversation up.
+	resumed := openStream(t, srv.URL, "user-1", "conversation_id="+conversationID)
+	if got := resumed.read("conversation_resumed").ConversationID; got != conversationID {
+		t.Errorf("resumed %s, want %s", got, conversationID)
+	}
+}
+
+func TestSSE_Confirm(t *testing.T) {
+	model := &sendModel{}
+	s, srv := sseServer(t, Config{BaseURL: model.serve(t).URL, DisableStreaming: true})
+	var executed atomic.Int32
+	s.AddTool(tools.New("send_money").
+		RequiresConfirmation().
+		SummaryTemplate("Send {{.amount}} {{.currency}} to {{.recipient}}").
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			executed.Add(1)
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{"message": "Sent"}}, nil
+		}).
+		Build())
+
+	c := openStream(t, srv.URL, "user-1", "")
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "send 50 to alice"})
+	offer := c.read("confirm_request")
+	if offer.Summary != "Send 50 USDC to @alice" {
+		t.Errorf("summary = %q", offer.Summary)
+	}
+
+	// Only the stream's own user can post to it.
+	if status := c.post("user-2", ClientMessage{Type: "confirm", ActionID: offer.ActionID}); status != http.StatusNotFound {
+		t.Errorf("another user's post: %d, want 404", status)
+	}
+
+	c.send(ClientMessage{Type: "confirm", ActionID: offer.ActionID})
+	c.read("complete")
+	if got := executed.Load(); got != 1 {
+		t.Errorf("executed %d times, want 1", got)
+	}
+}
+
+func TestTransports(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		wantWS     bool
+		wantEvents bool
+	}{
+		{name: "default", wantWS: true},
+		{name: "both", cfg: Config{ServerSentEvents: true}, wantWS: true, wantEvents: true},
+		{name: "SSE only", cfg: Config{ServerSentEvents: true, DisableWebSocket: true}, wantEvents: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.AnthropicKey = "test"
+			s, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv := httptest.NewServer(s.routes())
+			t.Cleanup(srv.Close)
+
+			// A plain GET of /ws fails the upgrade, but is routed.
+			resp, err := http.Get(srv.URL + "/ws")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if served := resp.StatusCode != http.StatusNotFound; served != tt.wantWS {
+				t.Errorf("/ws served = %v, want %v", served, tt.wantWS)
+			}
+
+			resp, err = http.Post(srv.URL+"/messages?stream=none", "application/json", strings.NewReader("{}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if served := strings.Contains(string(body), "Stream not found"); served != tt.wantEvents {
+				t.Errorf("/messages served = %v (%d %s), want %v", served, resp.StatusCode, body, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestShutdown_SSE(t *testing.T) {
+	s := newShutdownServer(t, Config{ServerSentEvents: true})
+	started, release := make(chan struct{}), make(chan struct{})
+	addr, served := startServer(t, s, started, release)
+
+	c := openStream(t, "http://"+addr, "user-1", "")
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "message", Content: "what's my balance?"})
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- s.Shutdown(ctx)
+	}()
+
+	c.read("server_closing")
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown() = %v before the run finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The run in flight completes before the stream ends.
+	close(release)
+	c.read("text")
+	c.read("complete")
+	if rest, err := io.ReadAll(c.events); err != nil || len(rest) != 0 {
+		t.Errorf("after the run, read %q, %v, want the stream to end", rest, err)
+	}
+
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown() = %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("serve() = %v after Shutdown", err)
+	}
+}
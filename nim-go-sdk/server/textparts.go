@@ -0,0 +1,99 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// sendText sends final assistant text. Text longer than
+// Config.TextPartSize bytes is sent as numbered "text_part" frames followed
+// by a "text_end" frame carrying its checksum; shorter text, or any text
+// when TextPartSize is zero, is sent as a single "text" frame.
+func (s *Server) sendText(conn peer, text string) {
+	size := s.config.TextPartSize
+	if size <= 0 || len(text) <= size {
+		s.send(conn, ServerMessage{Type: "text", Content: text})
+		return
+	}
+	parts := SplitText(text, size)
+	for i, part := range parts {
+		s.send(conn, ServerMessage{Type: "text_part", Content: part, Part: i + 1, Parts: len(parts)})
+	}
+	s.send(conn, ServerMessage{Type: "text_end", Parts: len(parts), Checksum: TextChecksum(text)})
+}
+
+// SplitText splits text into parts of at most size bytes, never splitting
+// a UTF-8 character. A size smaller than a character still makes progress:
+// that part holds the one character.
+func SplitText(text string, size int) []string {
+	var parts []string
+	for len(text) > size {
+		cut := size
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			_, cut = utf8.DecodeRuneInString(text)
+		}
+		parts = append(parts, text[:cut])
+		text = text[cut:]
+	}
+	if text != "" || len(parts) == 0 {
+		parts = append(parts, text)
+	}
+	return parts
+}
+
+// TextChecksum returns the checksum a "text_end" frame carries: the hex
+// SHA-256 of the full text.
+func TextChecksum(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// TextAssembler reassembles final text for a client, whether it arrives as
+// a single "text" frame or as "text_part" frames and a "text_end". Frames of
+// other types may arrive between the parts and are ignored.
+type TextAssembler struct {
+	parts []string
+}
+
+// Add feeds a server frame to the assembler. It returns the complete text
+// and true once a "text" frame or a "text_end" arrives. A "text_end" whose
+// parts are missing or out of order, or whose checksum does not match the
+// reassembled text, returns an error; the partial text is discarded either
+// way, ready for the next response.
+func (a *TextAssembler) Add(msg ServerMessage) (string, bool, error) {
+	switch msg.Type {
+	case "text":
+		a.parts = nil
+		return msg.Content, true, nil
+
+	case "text_part":
+		if msg.Part == 1 {
+			a.parts = nil
+		}
+		if msg.Part != len(a.parts)+1 {
+			a.parts = nil
+			return "", false, fmt.Errorf("text part %d of %d arrived out of order", msg.Part, msg.Parts)
+		}
+		a.parts = append(a.parts, msg.Content)
+		return "", false, nil
+
+	case "text_end":
+		parts := a.parts
+		a.parts = nil
+		if len(parts) != msg.Parts {
+			return "", false, fmt.Errorf("received %d of %d text parts", len(parts), msg.Parts)
+		}
+		text := strings.Join(parts, "")
+		if TextChecksum(text) != msg.Checksum {
+			return "", false, fmt.Errorf("text checksum mismatch")
+		}
+		return text, true, nil
+	}
+	return "", false, nil
+}
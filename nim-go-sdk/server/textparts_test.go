@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// replyModel is a mock Claude API that always answers with text.
+func replyModel(t *testing.T, text string) *httptest.Server {
+	t.Helper()
+	content, _ := json.Marshal([]map[string]string{{"type": "text", "text": text}})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":%s,"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`, content)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		size int
+		want []string
+	}{
+		{name: "under size", text: "abc", size: 4, want: []string{"abc"}},
+		{name: "exact size", text: "abcd", size: 4, want: []string{"abcd"}},
+		{name: "one over", text: "abcde", size: 4, want: []string{"abcd", "e"}},
+		{name: "exact multiple", text: "abcdefgh", size: 4, want: []string{"abcd", "efgh"}},
+		{name: "never splits a character", text: "ab€cd", size: 4, want: []string{"ab", "€c", "d"}},
+		{name: "size smaller than a character", text: "€€", size: 2, want: []string{"€", "€"}},
+		{name: "empty", text: "", size: 4, want: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitText(tt.text, tt.size)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Errorf("SplitText(%q, %d) = %q, want %q", tt.text, tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextAssembler(t *testing.T) {
+	text := "line one\nline two\nline three"
+	sum := TextChecksum(text)
+	part := func(n, of int, content string) ServerMessage {
+		return ServerMessage{Type: "text_part", Part: n, Parts: of, Content: content}
+	}
+	end := func(of int, checksum string) ServerMessage {
+		return ServerMessage{Type: "text_end", Parts: of, Checksum: checksum}
+	}
+
+	tests := []struct {
+		name    string
+		frames  []ServerMessage
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "single text",
+			frames: []ServerMessage{{Type: "text", Content: text}},
+			want:   text,
+		},
+		{
+			name:   "parts",
+			frames: []ServerMessage{part(1, 3, "line one\n"), part(2, 3, "line two\n"), part(3, 3, "line three"), end(3, sum)},
+			want:   text,
+		},
+		{
+			name: "interleaved with other messages",
+			frames: []ServerMessage{
+				part(1, 2, "line one\nline two\n"),
+				{Type: "message_ack", Content: "and another thing"},
+				{Type: "tool_result", Tool: "get_balance"},
+				part(2, 2, "line three"),
+				{Type: "debug"},
+				end(2, sum),
+			},
+			want: text,
+		},
+		{
+			name:    "checksum mismatch",
+			frames:  []ServerMessage{part(1, 2, "line one\n"), part(2, 2, "line 2\nline three"), end(2, sum)},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name:    "missing part",
+			frames:  []ServerMessage{part(1, 3, "line one\n"), part(2, 3, "line two\n"), end(3, sum)},
+			wantErr: "received 2 of 3",
+		},
+		{
+			name:    "out of order",
+			frames:  []ServerMessage{part(2, 2, "line three"), part(1, 2, "line one\nline two\n"), end(2, sum)},
+			wantErr: "out of order",
+		},
+		{
+			name:   "restarts after a broken response",
+			frames: []ServerMessage{part(1, 3, "stale"), part(1, 1, text), end(1, sum)},
+			want:   text,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a TextAssembler
+			var got string
+			var done bool
+			var firstErr error
+			for _, frame := range tt.frames {
+				text, ok, err := a.Add(frame)
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				if ok {
+					got, done = text, true
+				}
+			}
+			if tt.wantErr != "" {
+				if firstErr == nil || !strings.Contains(firstErr.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", firstErr, tt.wantErr)
+				}
+				if done {
+					t.Errorf("assembled %q despite the error", got)
+				}
+				return
+			}
+			if firstErr != nil {
+				t.Fatal(firstErr)
+			}
+			if !done || got != tt.want {
+				t.Errorf("assembled %q (done %v), want %q", got, done, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendText_Threshold(t *testing.T) {
+	reply := strings.Repeat("Groceries: 42.10 USDC\n", 20)
+
+	tests := []struct {
+		name      string
+		size      int
+		wantParts int
+	}{
+		{name: "disabled", size: 0, wantParts: 0},
+		{name: "exactly the threshold", size: len(reply), wantParts: 0},
+		{name: "one byte over", size: len(reply) - 1, wantParts: 2},
+		{name: "many parts", size: 100, wantParts: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conversations := store.NewMemoryConversations()
+			s, err := New(Config{
+				AnthropicKey:     "test",
+				BaseURL:          replyModel(t, reply).URL,
+				DisableStreaming: true,
+				TextPartSize:     tt.size,
+				Conversations:    conversations,
+				AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := dial(t, s)
+			c.send(ClientMessage{Type: "new_conversation"})
+			id := c.read("conversation_started").ConversationID
+			c.send(ClientMessage{Type: "message", Content: "weekly summary please"})
+
+			var a TextAssembler
+			var got string
+			var types []string
+			for {
+				var msg ServerMessage
+				if err := c.conn.ReadJSON(&msg); err != nil {
+					t.Fatal(err)
+				}
+				if msg.Type == "complete" {
+					break
+				}
+				if msg.Type == "conversation_renamed" {
+					continue
+				}
+				types = append(types, msg.Type)
+				if msg.Type == "text_part" && len(msg.Content) > tt.size {
+					t.Errorf("part %d is %d bytes, over %d", msg.Part, len(msg.Content), tt.size)
+				}
+				text, done, err := a.Add(msg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if done {
+					got = text
+				}
+			}
+			if got != reply {
+				t.Errorf("reassembled %q, want %q", got, reply)
+			}
+
+			parts := strings.Count(strings.Join(types, ","), "text_part")
+			if parts != tt.wantParts {
+				t.Errorf("frames %v: %d text parts, want %d", types, parts, tt.wantParts)
+			}
+			if tt.wantParts == 0 && types[len(types)-1] != "text" {
+				t.Errorf("frames %v, want a single text frame", types)
+			}
+			if tt.wantParts > 0 && types[len(types)-1] != "text_end" {
+				t.Errorf("frames %v, want text parts ending with text_end", types)
+			}
+
+			// The conversation stores the full reply once.
+			conv, err := conversations.Get(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var stored []string
+			for _, m := range conv.Messages {
+				if m.Role == "assistant" {
+					stored = append(stored, m.Content)
+				}
+			}
+			if len(stored) != 1 || stored[0] != reply {
+				t.Errorf("stored assistant messages %q, want the full reply once", stored)
+			}
+		})
+	}
+}
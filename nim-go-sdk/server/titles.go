@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// maxTitleLength caps conversation titles set by users, in runes.
+const maxTitleLength = 100
+
+// untitled reports whether a conversation still has no title of its own.
+func untitled(title string) bool {
+	return title == "" || title == store.DefaultTitle
+}
+
+// titleConversation names the session's conversation after its first
+// message, once its first reply is complete. A conversation already named,
+// e.g. renamed by the user, keeps its title.
+func (s *Server) titleConversation(ctx context.Context, conn peer, sess *session) {
+	if sess.titled {
+		return
+	}
+	sess.titled = true
+
+	first := firstUserMessage(sess.history())
+	if first == "" {
+		return
+	}
+	// It may have been renamed from another connection.
+	if conv, err := s.conversations.Get(ctx, sess.ConversationID); err != nil || !untitled(conv.Title) {
+		return
+	}
+
+	title := engine.TitleFromMessage(first)
+	if s.config.GenerateTitles {
+		generated, err := s.engine.GenerateTitleFromFirstMessage(ctx, first)
+		if err != nil {
+			log.Printf("Failed to generate title for conversation %s: %v", sess.ConversationID, err)
+		} else {
+			title = generated
+		}
+	}
+
+	if err := s.conversations.SetTitle(ctx, sess.ConversationID, title); err != nil {
+		log.Printf("Failed to set title for conversation %s: %v", sess.ConversationID, err)
+		return
+	}
+	s.send(conn, ServerMessage{Type: "conversation_renamed", ConversationID: sess.ConversationID, Title: title})
+}
+
+// firstUserMessage returns the text of the first user message in history.
+func firstUserMessage(history []core.Message) string {
+	for _, msg := range history {
+		if msg.Role == core.RoleUser && msg.Content != "" {
+			return msg.Content
+		}
+	}
+	return ""
+}
+
+// handleRename sets the title of one of the user's conversations, the
+// current one if conversationID is empty.
+func (s *Server) handleRename(ctx context.Context, conn peer, userID string, sess *session, conversationID, title string) {
+	if conversationID == "" && sess != nil {
+		conversationID = sess.ConversationID
+	}
+	if conversationID == "" {
+		s.sendError(conn, "No active conversation")
+		return
+	}
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		s.sendError(conn, "rename_conversation requires a title")
+		return
+	}
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = strings.TrimSpace(string(runes[:maxTitleLength]))
+	}
+
+	conv, err := s.conversations.Get(ctx, conversationID)
+	if err != nil || conv.UserID != userID {
+		s.sendError(conn, "Conversation not found")
+		return
+	}
+	if err := s.conversations.SetTitle(ctx, conversationID, title); err != nil {
+		s.sendError(conn, fmt.Sprintf("Failed to rename conversation: %v", err))
+		return
+	}
+	if sess != nil && sess.ConversationID == conversationID {
+		sess.titled = true
+	}
+
+	log.Printf("Renamed conversation %s for user %s", conversationID, userID)
+	s.send(conn, ServerMessage{Type: "conversation_renamed", ConversationID: conversationID, Title: title})
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// titleModel is a mock Claude API that answers title requests with
+// "Checking my balance", counting them, and everything else with "Done.".
+func titleModel(t *testing.T, titleCalls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		text := "Done."
+		if strings.Contains(string(body), "generate a short title") {
+			titleCalls.Add(1)
+			text = "Checking my balance"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":[{"type":"text","text":%q}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`, text)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestConversationTitle(t *testing.T) {
+	tests := []struct {
+		name      string
+		generate  bool
+		want      string
+		wantCalls int32
+	}{
+		{name: "generated", generate: true, want: "Checking my balance", wantCalls: 1},
+		{name: "first words", generate: false, want: "hey, what's my balance looking like"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var titleCalls atomic.Int32
+			conversations := store.NewMemoryConversations()
+			s, err := New(Config{
+				AnthropicKey:     "test",
+				BaseURL:          titleModel(t, &titleCalls).URL,
+				DisableStreaming: true,
+				GenerateTitles:   tt.generate,
+				Conversations:    conversations,
+				AuthFunc:         func(r *http.Request) (string, error) { return "user-1", nil },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := dial(t, s)
+			c.send(ClientMessage{Type: "new_conversation"})
+			started := c.read("conversation_started")
+			if started.Title != store.DefaultTitle {
+				t.Errorf("conversation_started title = %q, want %q", started.Title, store.DefaultTitle)
+			}
+
+			c.send(ClientMessage{Type: "message", Content: "hey, what's my balance looking like today?"})
+			msgs := c.readUntil("complete")
+			if renamed := msgs[len(msgs)-2]; renamed.Type != "conversation_renamed" || renamed.Title != tt.want || renamed.ConversationID != started.ConversationID {
+				t.Errorf("before complete, got %+v, want conversation_renamed to %q", renamed, tt.want)
+			}
+
+			// Later replies leave the title alone.
+			for i := 0; i < 2; i++ {
+				c.send(ClientMessage{Type: "message", Content: "and now?"})
+				for _, msg := range c.readUntil("complete") {
+					if msg.Type == "conversation_renamed" {
+						t.Errorf("renamed again to %q", msg.Title)
+					}
+				}
+			}
+			if got := titleCalls.Load(); got != tt.wantCalls {
+				t.Errorf("made %d title calls, want %d", got, tt.wantCalls)
+			}
+
+			conv, err := conversations.Get(context.Background(), started.ConversationID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if conv.Title != tt.want {
+				t.Errorf("stored title = %q, want %q", conv.Title, tt.want)
+			}
+
+			resumer := dial(t, s)
+			resumer.send(ClientMessage{Type: "resume_conversation", ConversationID: started.ConversationID})
+			if got := resumer.read("conversation_resumed").Title; got != tt.want {
+				t.Errorf("conversation_resumed title = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenameConversation(t *testing.T) {
+	var titleCalls atomic.Int32
+	conversations := store.NewMemoryConversations()
+	s, err := New(Config{
+		AnthropicKey:     "test",
+		BaseURL:          titleModel(t, &titleCalls).URL,
+		DisableStreaming: true,
+		GenerateTitles:   true,
+		Conversations:    conversations,
+		AuthFunc: func(r *http.Request) (string, error) {
+			if user := r.URL.Query().Get("user"); user != "" {
+				return user, nil
+			}
+			return "user-1", nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := dial(t, s)
+	c.send(ClientMessage{Type: "rename_conversation", Title: "Budget"})
+	if got := c.read("error").Content; got != "No active conversation" {
+		t.Errorf("rename without a conversation: error %q", got)
+	}
+
+	c.send(ClientMessage{Type: "new_conversation"})
+	id := c.read("conversation_started").ConversationID
+
+	c.send(ClientMessage{Type: "rename_conversation", Title: "  "})
+	if got := c.read("error").Content; got != "rename_conversation requires a title" {
+		t.Errorf("blank rename: error %q", got)
+	}
+
+	c.send(ClientMessage{Type: "rename_conversation", Title: "  Trip \n budget "})
+	renamed := c.read("conversation_renamed")
+	if renamed.ConversationID != id || renamed.Title != "Trip budget" {
+		t.Errorf("conversation_renamed = %+v, want %q for %s", renamed, "Trip budget", id)
+	}
+
+	// A user's title is never replaced by a generated one.
+	c.send(ClientMessage{Type: "message", Content: "what's my balance?"})
+	for _, msg := range c.readUntil("complete") {
+		if msg.Type == "conversation_renamed" {
+			t.Errorf("renamed to %q after the user named it", msg.Title)
+		}
+	}
+	if got := titleCalls.Load(); got != 0 {
+		t.Errorf("made %d title calls, want none", got)
+	}
+
+	// Other users can't rename it.
+	other := dialQuery(t, s, "user=user-2")
+	other.send(ClientMessage{Type: "rename_conversation", ConversationID: id, Title: "Mine now"})
+	if got := other.read("error").Content; got != "Conversation not found" {
+		t.Errorf("rename by another user: error %q", got)
+	}
+
+	// Conversations can be renamed by ID from any session.
+	c.send(ClientMessage{Type: "new_conversation"})
+	c.read("conversation_started")
+	c.send(ClientMessage{Type: "rename_conversation", ConversationID: id, Title: "Trip budget 2026"})
+	c.read("conversation_renamed")
+
+	conv, err := conversations.Get(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conv.Title != "Trip budget 2026" {
+		t.Errorf("stored title = %q, want %q", conv.Title, "Trip budget 2026")
+	}
+	c.send(ClientMessage{Type: "resume_conversation", ConversationID: id})
+	if got := c.read("conversation_resumed").Title; got != "Trip budget 2026" {
+		t.Errorf("conversation_resumed title = %q", got)
+	}
+}
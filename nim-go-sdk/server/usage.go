@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// tokenLimitMessage refuses a message in a conversation over
+// Config.MaxConversationTokens.
+const tokenLimitMessage = "This conversation has reached its usage limit. Please start a new one."
+
+// addUsage adds an agent run's token usage to the session's conversation.
+func (s *Server) addUsage(ctx context.Context, sess *session, usage core.TokenUsage) {
+	if usage == (core.TokenUsage{}) {
+		return
+	}
+	sess.mu.Lock()
+	sess.Usage = sess.Usage.Add(usage)
+	sess.mu.Unlock()
+
+	if sess.ConversationID == "" {
+		return
+	}
+	if err := s.conversations.AddUsage(ctx, sess.ConversationID, usage); err != nil {
+		log.Printf("Failed to record token usage for conversation %s: %v", sess.ConversationID, err)
+	}
+}
+
+// usage returns the session's conversation's token usage, or nil if it has
+// no conversation.
+func (sess *session) usage() *core.TokenUsage {
+	if sess.ConversationID == "" {
+		return nil
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	usage := sess.Usage
+	return &usage
+}
+
+// conversationUsage is usage for a WebSocket or SSE client.
+func (sess *session) conversationUsage() *TokenUsage {
+	if usage := sess.usage(); usage != nil {
+		return tokenUsage(*usage)
+	}
+	return nil
+}
+
+// overTokenLimit reports whether the session's conversation has used more
+// than Config.MaxConversationTokens.
+func (s *Server) overTokenLimit(sess *session) bool {
+	if s.config.MaxConversationTokens <= 0 {
+		return false
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.Usage.TotalTokens() > s.config.MaxConversationTokens
+}
+
+// complete sends msg, a "complete" frame, with the conversation's token
+// usage so far.
+func (s *Server) complete(conn peer, sess *session, msg ServerMessage) {
+	msg.Type = "complete"
+	msg.ConversationTokenUsage = sess.conversationUsage()
+	s.send(conn, msg)
+}
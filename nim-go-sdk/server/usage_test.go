@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// dialAs connects to srv's WebSocket as user.
+func dialAs(t *testing.T, srv string, user string) *wsClient {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv, "http") + "/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer " + user}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return &wsClient{t: t, conn: conn}
+}
+
+func TestConversationTokenLimit(t *testing.T) {
+	// Each call to sendModel uses 2 tokens, so a message and a confirmed
+	// send take a conversation over 3.
+	model := &sendModel{}
+	var executed int
+	s, srv := newRESTServer(t, model.serve(t).URL, &executed)
+	s.config.MaxConversationTokens = 3
+
+	c := dialAs(t, srv.URL, "user-1")
+	c.send(ClientMessage{Type: "new_conversation"})
+	id := c.read("conversation_started").ConversationID
+	c.send(ClientMessage{Type: "message", Content: "hello"})
+	done := c.read("complete")
+	if done.TokenUsage == nil || done.TokenUsage.TotalTokens != 2 || done.ConversationTokenUsage == nil || done.ConversationTokenUsage.TotalTokens != 2 {
+		t.Fatalf("complete usage = %+v, conversation %+v, want 2 and 2", done.TokenUsage, done.ConversationTokenUsage)
+	}
+
+	// The run offering the send is counted, though it ends without a
+	// complete frame and the confirmation makes no model call.
+	c.send(ClientMessage{Type: "message", Content: "send 50 to alice"})
+	offer := c.read("confirm_request")
+	c.send(ClientMessage{Type: "confirm", ActionID: offer.ActionID})
+	done = c.read("complete")
+	if done.ConversationTokenUsage == nil || done.ConversationTokenUsage.TotalTokens != 4 {
+		t.Fatalf("confirmation's conversation usage = %+v, want 4", done.ConversationTokenUsage)
+	}
+	conv, err := s.conversations.Get(context.Background(), id)
+	if want := (core.TokenUsage{InputTokens: 2, OutputTokens: 2}); err != nil || conv.Usage != want {
+		t.Fatalf("stored usage = %+v, %v; want %+v", conv.Usage, err, want)
+	}
+
+	calls := model.calls()
+	c.send(ClientMessage{Type: "message", Content: "hello again"})
+	if refused := c.read("error"); refused.Code != "conversation_token_limit" {
+		t.Errorf("over the limit, got %+v, want a conversation_token_limit error", refused)
+	}
+
+	// Resuming loads the conversation's usage, so it stays over the limit,
+	// but it can still be resumed and exported.
+	resumed := dialAs(t, srv.URL, "user-1")
+	resumed.send(ClientMessage{Type: "resume_conversation", ConversationID: id})
+	resumed.read("conversation_resumed")
+	resumed.send(ClientMessage{Type: "message", Content: "hello again"})
+	if refused := resumed.read("error"); refused.Code != "conversation_token_limit" {
+		t.Errorf("over the limit after resuming, got %+v, want a conversation_token_limit error", refused)
+	}
+	status, resp := post(t, srv, "/v1/chat", "user-1", `{"conversation_id":"`+id+`","message":"hello again"}`)
+	if status != http.StatusForbidden || resp.Code != "conversation_token_limit" || resp.ConversationTokenUsage == nil || resp.ConversationTokenUsage.TotalTokens() != 4 {
+		t.Errorf("REST over the limit = %d %+v, want 403 conversation_token_limit with the usage", status, resp)
+	}
+	if model.calls() != calls {
+		t.Errorf("model called %d times over the limit, want none", model.calls()-calls)
+	}
+	if httpResp, body := export(t, srv, id, "", "user-1"); httpResp.StatusCode != http.StatusOK {
+		t.Errorf("export over the limit = %d %s, want 200", httpResp.StatusCode, body)
+	}
+
+	// Other conversations are unaffected.
+	status, resp = post(t, srv, "/v1/chat", "user-1", `{"message":"hello"}`)
+	if status != http.StatusOK || resp.ConversationTokenUsage == nil || resp.ConversationTokenUsage.TotalTokens() != 2 {
+		t.Errorf("new conversation = %d %+v, want 200 with 2 tokens used", status, resp)
+	}
+}
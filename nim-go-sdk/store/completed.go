@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// MemoryCompletedActions is an in-memory implementation of CompletedActions.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryCompletedActions struct {
+	mu        sync.Mutex
+	completed map[string]completedAction // userID + key -> result
+}
+
+type completedAction struct {
+	result    *core.ToolResult
+	expiresAt time.Time
+}
+
+// NewMemoryCompletedActions creates an in-memory completed action store.
+func NewMemoryCompletedActions() *MemoryCompletedActions {
+	return &MemoryCompletedActions{completed: make(map[string]completedAction)}
+}
+
+func (m *MemoryCompletedActions) Complete(ctx context.Context, userID, key string, result *core.ToolResult, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for k, c := range m.completed {
+		if !now.Before(c.expiresAt) {
+			delete(m.completed, k)
+		}
+	}
+	m.completed[userID+":"+key] = completedAction{result: result, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (m *MemoryCompletedActions) Completed(ctx context.Context, userID, key string) (*core.ToolResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.completed[userID+":"+key]
+	if !ok || !time.Now().Before(c.expiresAt) {
+		return nil, nil
+	}
+	return c.result, nil
+}
+
+// Verify MemoryCompletedActions implements CompletedActions.
+var _ CompletedActions = (*MemoryCompletedActions)(nil)
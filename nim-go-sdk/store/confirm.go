@@ -14,8 +14,9 @@ import (
 // as data is lost on restart and doesn't work across multiple instances.
 type MemoryConfirmations struct {
 	mu            sync.RWMutex
-	actions       map[string]*core.PendingAction // actionID -> action
-	byIdempotency map[string]string              // idempotencyKey -> actionID
+	actions       map[string]*core.PendingAction      // actionID -> action
+	byIdempotency map[string]string                   // idempotencyKey -> actionID
+	groups        map[string]*core.PendingActionGroup // groupID -> group
 }
 
 // NewMemoryConfirmations creates an in-memory confirmation store.
@@ -23,6 +24,7 @@ func NewMemoryConfirmations() *MemoryConfirmations {
 	return &MemoryConfirmations{
 		actions:       make(map[string]*core.PendingAction),
 		byIdempotency: make(map[string]string),
+		groups:        make(map[string]*core.PendingActionGroup),
 	}
 }
 
@@ -112,6 +114,53 @@ func (m *MemoryConfirmations) Cancel(ctx context.Context, userID, actionID strin
 	return nil
 }
 
+func (m *MemoryConfirmations) StoreGroup(ctx context.Context, group *core.PendingActionGroup) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.groups[group.ID] = group
+	return nil
+}
+
+func (m *MemoryConfirmations) GetGroup(ctx context.Context, userID, groupID string) (*core.PendingActionGroup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.groupUnlocked(userID, groupID)
+}
+
+func (m *MemoryConfirmations) ConfirmGroup(ctx context.Context, userID, groupID string) (*core.PendingActionGroup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	group, ok := m.groups[groupID]
+	if !ok || group.UserID != userID {
+		return nil, fmt.Errorf("group not found: %s", groupID)
+	}
+	delete(m.groups, groupID)
+	if group.ExpiresAt < time.Now().Unix() {
+		return nil, fmt.Errorf("group expired: %s", groupID)
+	}
+	return group, nil
+}
+
+func (m *MemoryConfirmations) CancelGroup(ctx context.Context, userID, groupID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	group, ok := m.groups[groupID]
+	if !ok || group.UserID != userID {
+		return fmt.Errorf("group not found: %s", groupID)
+	}
+	delete(m.groups, groupID)
+	for _, action := range group.Actions {
+		if pending, ok := m.actions[action.ID]; ok && pending.UserID == userID {
+			m.deleteUnlocked(pending)
+		}
+	}
+	return nil
+}
+
 func (m *MemoryConfirmations) Cleanup(ctx context.Context) (int, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -124,9 +173,25 @@ func (m *MemoryConfirmations) Cleanup(ctx context.Context) (int, error) {
 			count++
 		}
 	}
+	for id, group := range m.groups {
+		if group.ExpiresAt < now {
+			delete(m.groups, id)
+		}
+	}
 	return count, nil
 }
 
+func (m *MemoryConfirmations) groupUnlocked(userID, groupID string) (*core.PendingActionGroup, error) {
+	group, ok := m.groups[groupID]
+	if !ok || group.UserID != userID {
+		return nil, fmt.Errorf("group not found: %s", groupID)
+	}
+	if group.ExpiresAt < time.Now().Unix() {
+		return nil, fmt.Errorf("group expired: %s", groupID)
+	}
+	return group, nil
+}
+
 func (m *MemoryConfirmations) deleteUnlocked(action *core.PendingAction) {
 	delete(m.actions, action.ID)
 	if action.IdempotencyKey != "" {
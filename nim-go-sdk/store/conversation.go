@@ -1,14 +1,57 @@
 package store
 
 import (
+	"container/list"
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/becomeliminal/nim-go-sdk/core"
 	"github.com/google/uuid"
 )
 
+// ErrConversationEvicted is returned for a conversation that a
+// MemoryConversations evicted to stay within its limits. Clients should
+// start a new conversation.
+var ErrConversationEvicted = errors.New("conversation evicted")
+
+// maxEvicted is how many evicted conversation IDs are remembered, so
+// ErrConversationEvicted can be told apart from an unknown conversation.
+// Older evictions are reported as not found.
+const maxEvicted = 10000
+
+// memoryMetrics tracks the occupancy of in-memory conversation stores
+// ("conversations", "messages") and what was dropped to bound it
+// ("evictions", "trimmed_messages"), published at /debug/vars.
+var memoryMetrics = expvar.NewMap("nim_memory_conversations")
+
+// MemoryLimits bounds what a MemoryConversations keeps in memory.
+// Zero values mean no limit.
+type MemoryLimits struct {
+	// MaxConversations is the most conversations kept. Beyond it, the least
+	// recently used conversation is evicted.
+	MaxConversations int
+
+	// MaxMessages is the most messages kept across all conversations.
+	// Beyond it, least recently used conversations are evicted.
+	MaxMessages int
+
+	// MaxConversationMessages is the most messages kept in one conversation.
+	// Beyond it, the conversation is passed to Trim.
+	MaxConversationMessages int
+
+	// Trim shortens a conversation that has grown past
+	// MaxConversationMessages, e.g. by replacing older messages with a
+	// summary. It is called with the store locked and must not use it.
+	// Whatever it leaves over the limit is dropped oldest first.
+	// If nil, the oldest messages are dropped.
+	Trim func(conv *ConversationWithMessages) []StoredMessage
+}
+
 // MemoryConversations is an in-memory implementation of Conversations.
 // Suitable for development and testing. Not suitable for production
 // as data is lost on restart and doesn't work across multiple instances.
@@ -16,16 +59,43 @@ type MemoryConversations struct {
 	mu            sync.RWMutex
 	conversations map[string]*ConversationWithMessages
 	byUser        map[string][]string // userID -> []conversationID
+
+	limits   MemoryLimits
+	recent   *list.List               // conversation IDs, most recently used first
+	elements map[string]*list.Element // conversationID -> element in recent
+	messages int
+	evicted  map[string]bool
+	evictLog []string // evicted IDs, oldest first
+	pins     []func(conversationID string) bool
 }
 
 // NewMemoryConversations creates a new in-memory conversation store.
 func NewMemoryConversations() *MemoryConversations {
+	return NewBoundedMemoryConversations(MemoryLimits{})
+}
+
+// NewBoundedMemoryConversations creates an in-memory conversation store that
+// evicts idle conversations and trims long ones to stay within limits.
+// Conversations held by a pin (see AddPin) are never evicted.
+func NewBoundedMemoryConversations(limits MemoryLimits) *MemoryConversations {
 	return &MemoryConversations{
 		conversations: make(map[string]*ConversationWithMessages),
 		byUser:        make(map[string][]string),
+		limits:        limits,
+		recent:        list.New(),
+		elements:      make(map[string]*list.Element),
+		evicted:       make(map[string]bool),
 	}
 }
 
+// AddPin registers a check for conversations that must not be evicted, such
+// as those with live sessions or pending confirmations.
+func (m *MemoryConversations) AddPin(pinned func(conversationID string) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pins = append(m.pins, pinned)
+}
+
 func (m *MemoryConversations) Create(ctx context.Context, userID string) (*Conversation, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -35,7 +105,7 @@ func (m *MemoryConversations) Create(ctx context.Context, userID string) (*Conve
 		Conversation: Conversation{
 			ID:        uuid.New().String(),
 			UserID:    userID,
-			Title:     "New conversation",
+			Title:     DefaultTitle,
 			CreatedAt: now,
 			UpdatedAt: now,
 		},
@@ -44,28 +114,52 @@ func (m *MemoryConversations) Create(ctx context.Context, userID string) (*Conve
 
 	m.conversations[conv.ID] = conv
 	m.byUser[userID] = append(m.byUser[userID], conv.ID)
+	m.elements[conv.ID] = m.recent.PushFront(conv.ID)
+	memoryMetrics.Add("conversations", 1)
+	m.evict(conv.ID)
 
 	return &conv.Conversation, nil
 }
 
 func (m *MemoryConversations) Get(ctx context.Context, conversationID string) (*ConversationWithMessages, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	conv, ok := m.conversations[conversationID]
-	if !ok {
-		return nil, fmt.Errorf("conversation not found: %s", conversationID)
+	conv, err := m.lookup(conversationID)
+	if err != nil {
+		return nil, err
 	}
 	return conv, nil
 }
 
+func (m *MemoryConversations) Export(ctx context.Context, conversationID string) (*ConversationWithMessages, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conv, err := m.lookup(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	copied := &ConversationWithMessages{
+		Conversation: conv.Conversation,
+		Messages:     make([]StoredMessage, len(conv.Messages)),
+	}
+	for i, msg := range conv.Messages {
+		msg.Blocks = slices.Clone(msg.Blocks)
+		msg.Tools = slices.Clone(msg.Tools)
+		msg.Artifacts = slices.Clone(msg.Artifacts)
+		copied.Messages[i] = msg
+	}
+	return copied, nil
+}
+
 func (m *MemoryConversations) Append(ctx context.Context, msg *AppendMessage) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	conv, ok := m.conversations[msg.ConversationID]
-	if !ok {
-		return fmt.Errorf("conversation not found: %s", msg.ConversationID)
+	conv, err := m.lookup(msg.ConversationID)
+	if err != nil {
+		return err
 	}
 
 	stored := StoredMessage{
@@ -74,12 +168,17 @@ func (m *MemoryConversations) Append(ctx context.Context, msg *AppendMessage) er
 		Content:   msg.Content,
 		Blocks:    msg.Blocks,
 		Tools:     msg.Tools,
+		Artifacts: msg.Artifacts,
 		CreatedAt: time.Now(),
 	}
 
 	conv.Messages = append(conv.Messages, stored)
 	conv.UpdatedAt = time.Now()
+	m.messages++
+	memoryMetrics.Add("messages", 1)
 
+	m.trim(conv)
+	m.evict(conv.ID)
 	return nil
 }
 
@@ -87,9 +186,9 @@ func (m *MemoryConversations) SetTitle(ctx context.Context, conversationID, titl
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	conv, ok := m.conversations[conversationID]
-	if !ok {
-		return fmt.Errorf("conversation not found: %s", conversationID)
+	conv, err := m.lookup(conversationID)
+	if err != nil {
+		return err
 	}
 
 	conv.Title = title
@@ -97,6 +196,19 @@ func (m *MemoryConversations) SetTitle(ctx context.Context, conversationID, titl
 	return nil
 }
 
+func (m *MemoryConversations) AddUsage(ctx context.Context, conversationID string, usage core.TokenUsage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conv, err := m.lookup(conversationID)
+	if err != nil {
+		return err
+	}
+
+	conv.Usage = conv.Usage.Add(usage)
+	return nil
+}
+
 func (m *MemoryConversations) List(ctx context.Context, userID string, limit int) ([]*Conversation, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -117,14 +229,60 @@ func (m *MemoryConversations) List(ctx context.Context, userID string, limit int
 	return result, nil
 }
 
+func (m *MemoryConversations) ListAfter(ctx context.Context, userID, cursor string, limit int) ([]*Conversation, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("invalid limit: %d", limit)
+	}
+	from, err := parseCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	m.mu.RLock()
+	convs := make([]*Conversation, 0, len(m.byUser[userID]))
+	for _, id := range m.byUser[userID] {
+		conv := m.conversations[id].Conversation
+		if from == nil || from.after(&conv) {
+			convs = append(convs, &conv)
+		}
+	}
+	m.mu.RUnlock()
+
+	slices.SortFunc(convs, newestFirst)
+	if len(convs) <= limit {
+		return convs, "", nil
+	}
+	convs = convs[:limit]
+	return convs, cursorAt(convs[len(convs)-1]), nil
+}
+
 func (m *MemoryConversations) Delete(ctx context.Context, conversationID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if _, ok := m.conversations[conversationID]; !ok {
+		return fmt.Errorf("conversation not found: %s", conversationID)
+	}
+	m.remove(conversationID)
+	return nil
+}
+
+// lookup returns a conversation and marks it as recently used.
+func (m *MemoryConversations) lookup(conversationID string) (*ConversationWithMessages, error) {
 	conv, ok := m.conversations[conversationID]
 	if !ok {
-		return fmt.Errorf("conversation not found: %s", conversationID)
+		if m.evicted[conversationID] {
+			return nil, fmt.Errorf("%w: %s", ErrConversationEvicted, conversationID)
+		}
+		return nil, fmt.Errorf("conversation not found: %s", conversationID)
 	}
+	m.recent.MoveToFront(m.elements[conversationID])
+	return conv, nil
+}
+
+// remove deletes a conversation and its index entries.
+func (m *MemoryConversations) remove(conversationID string) {
+	conv := m.conversations[conversationID]
 
 	// Remove from byUser index
 	userConvs := m.byUser[conv.UserID]
@@ -134,10 +292,88 @@ func (m *MemoryConversations) Delete(ctx context.Context, conversationID string)
 			break
 		}
 	}
+	if len(m.byUser[conv.UserID]) == 0 {
+		delete(m.byUser, conv.UserID)
+	}
 
+	m.recent.Remove(m.elements[conversationID])
+	delete(m.elements, conversationID)
 	delete(m.conversations, conversationID)
-	return nil
+	m.messages -= len(conv.Messages)
+	memoryMetrics.Add("conversations", -1)
+	memoryMetrics.Add("messages", -int64(len(conv.Messages)))
+}
+
+// trim applies MaxConversationMessages to a conversation.
+func (m *MemoryConversations) trim(conv *ConversationWithMessages) {
+	limit := m.limits.MaxConversationMessages
+	if limit <= 0 || len(conv.Messages) <= limit {
+		return
+	}
+
+	before := len(conv.Messages)
+	messages := conv.Messages
+	if m.limits.Trim != nil {
+		messages = m.limits.Trim(conv)
+	}
+	if len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	// Copy so the dropped messages aren't kept alive by the backing array.
+	conv.Messages = append(make([]StoredMessage, 0, limit), messages...)
+
+	dropped := before - len(conv.Messages)
+	m.messages -= dropped
+	memoryMetrics.Add("messages", -int64(dropped))
+	memoryMetrics.Add("trimmed_messages", int64(dropped))
+}
+
+// evict removes least recently used conversations until the store is within
+// its limits, skipping keep and pinned conversations. If every candidate is
+// pinned, the store stays over its limits until they are released.
+func (m *MemoryConversations) evict(keep string) {
+	over := func() bool {
+		return (m.limits.MaxConversations > 0 && len(m.conversations) > m.limits.MaxConversations) ||
+			(m.limits.MaxMessages > 0 && m.messages > m.limits.MaxMessages)
+	}
+
+	for e := m.recent.Back(); e != nil && over(); {
+		id := e.Value.(string)
+		e = e.Prev()
+		if id == keep || m.pinned(id) {
+			continue
+		}
+		m.remove(id)
+		m.markEvicted(id)
+		memoryMetrics.Add("evictions", 1)
+	}
+}
+
+func (m *MemoryConversations) pinned(conversationID string) bool {
+	for _, pinned := range m.pins {
+		if pinned(conversationID) {
+			return true
+		}
+	}
+	return false
+}
+
+// markEvicted remembers an evicted conversation, forgetting the oldest
+// beyond maxEvicted.
+func (m *MemoryConversations) markEvicted(conversationID string) {
+	m.evicted[conversationID] = true
+	m.evictLog = append(m.evictLog, conversationID)
+	if len(m.evictLog) > maxEvicted {
+		delete(m.evicted, m.evictLog[0])
+		m.evictLog = m.evictLog[1:]
+	}
 }
 
 // Verify MemoryConversations implements Conversations.
 var _ Conversations = (*MemoryConversations)(nil)
+
+// Verify MemoryConversations implements Pinner.
+var _ Pinner = (*MemoryConversations)(nil)
+
+// Verify MemoryConversations implements ConversationPager.
+var _ ConversationPager = (*MemoryConversations)(nil)
@@ -0,0 +1,363 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func create(t *testing.T, m *MemoryConversations, user string) string {
+	t.Helper()
+	conv, err := m.Create(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conv.ID
+}
+
+func appendN(t *testing.T, m *MemoryConversations, id string, n int, content string) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := m.Append(context.Background(), &AppendMessage{ConversationID: id, Role: "user", Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func gauge(name string) int64 {
+	if v, ok := memoryMetrics.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestMemoryConversations_Eviction(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		limits      MemoryLimits
+		run         func(t *testing.T, m *MemoryConversations) []string
+		wantEvicted []int // indexes into the IDs run returns
+		wantKept    []int
+	}{
+		{
+			name:   "least recently used first",
+			limits: MemoryLimits{MaxConversations: 3},
+			run: func(t *testing.T, m *MemoryConversations) []string {
+				a, b, c := create(t, m, "u1"), create(t, m, "u2"), create(t, m, "u1")
+				m.Get(ctx, a) // a is now more recent than b
+				d := create(t, m, "u3")
+				return []string{a, b, c, d}
+			},
+			wantEvicted: []int{1},
+			wantKept:    []int{0, 2, 3},
+		},
+		{
+			name:   "appending counts as use",
+			limits: MemoryLimits{MaxConversations: 2},
+			run: func(t *testing.T, m *MemoryConversations) []string {
+				a, b := create(t, m, "u1"), create(t, m, "u1")
+				appendN(t, m, a, 1, "hi")
+				c := create(t, m, "u1")
+				return []string{a, b, c}
+			},
+			wantEvicted: []int{1},
+			wantKept:    []int{0, 2},
+		},
+		{
+			name:   "total messages",
+			limits: MemoryLimits{MaxMessages: 5},
+			run: func(t *testing.T, m *MemoryConversations) []string {
+				a, b := create(t, m, "u1"), create(t, m, "u2")
+				appendN(t, m, a, 3, "hi")
+				appendN(t, m, b, 3, "hi")
+				return []string{a, b}
+			},
+			wantEvicted: []int{0},
+			wantKept:    []int{1},
+		},
+		{
+			name:   "pinned conversations are kept",
+			limits: MemoryLimits{MaxConversations: 2},
+			run: func(t *testing.T, m *MemoryConversations) []string {
+				a := create(t, m, "u1")
+				m.AddPin(func(id string) bool { return id == a })
+				b, c, d := create(t, m, "u1"), create(t, m, "u1"), create(t, m, "u1")
+				return []string{a, b, c, d}
+			},
+			wantEvicted: []int{1, 2},
+			wantKept:    []int{0, 3},
+		},
+		{
+			name:   "over the limit while everything is pinned",
+			limits: MemoryLimits{MaxConversations: 1},
+			run: func(t *testing.T, m *MemoryConversations) []string {
+				m.AddPin(func(string) bool { return true })
+				return []string{create(t, m, "u1"), create(t, m, "u1")}
+			},
+			wantKept: []int{0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewBoundedMemoryConversations(tt.limits)
+			ids := tt.run(t, m)
+
+			for _, i := range tt.wantEvicted {
+				if _, err := m.Get(ctx, ids[i]); !errors.Is(err, ErrConversationEvicted) {
+					t.Errorf("Get(conversation %d) error = %v, want ErrConversationEvicted", i, err)
+				}
+				if err := m.Append(ctx, &AppendMessage{ConversationID: ids[i]}); !errors.Is(err, ErrConversationEvicted) {
+					t.Errorf("Append(conversation %d) error = %v, want ErrConversationEvicted", i, err)
+				}
+			}
+			for _, i := range tt.wantKept {
+				if _, err := m.Get(ctx, ids[i]); err != nil {
+					t.Errorf("Get(conversation %d) error = %v, want it kept", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryConversations_NotFoundIsNotEvicted(t *testing.T) {
+	m := NewBoundedMemoryConversations(MemoryLimits{MaxConversations: 1})
+	_, err := m.Get(context.Background(), "missing")
+	if err == nil || errors.Is(err, ErrConversationEvicted) {
+		t.Errorf("Get(missing) error = %v, want a plain not-found error", err)
+	}
+
+	// Deleted conversations are not reported as evicted either.
+	id := create(t, m, "u1")
+	m.Delete(context.Background(), id)
+	if _, err := m.Get(context.Background(), id); err == nil || errors.Is(err, ErrConversationEvicted) {
+		t.Errorf("Get(deleted) error = %v, want a plain not-found error", err)
+	}
+}
+
+func TestMemoryConversations_Export(t *testing.T) {
+	m := NewMemoryConversations()
+	id := create(t, m, "u1")
+	m.Append(context.Background(), &AppendMessage{ConversationID: id, Role: "assistant", Content: "sending", Blocks: []interface{}{"tool_use"}})
+	appendN(t, m, id, 1, "thanks")
+
+	exported, err := m.Export(context.Background(), id)
+	if err != nil || len(exported.Messages) != 2 || exported.Messages[0].Content != "sending" || exported.Messages[1].Content != "thanks" {
+		t.Fatalf("Export() = %+v, %v, want both messages in order", exported, err)
+	}
+
+	// Changing the export leaves the stored conversation alone.
+	exported.Messages[0].Content = "[redacted]"
+	exported.Messages[0].Blocks[0] = "[redacted]"
+	stored, _ := m.Get(context.Background(), id)
+	if stored.Messages[0].Content != "sending" || stored.Messages[0].Blocks[0] != "tool_use" {
+		t.Errorf("stored message = %+v after changing the export", stored.Messages[0])
+	}
+}
+
+func TestMemoryConversations_Trim(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		trim func(conv *ConversationWithMessages) []StoredMessage
+		want []string
+	}{
+		{
+			name: "drops oldest by default",
+			want: []string{"m3", "m4", "m5"},
+		},
+		{
+			name: "summarization hook",
+			trim: func(conv *ConversationWithMessages) []StoredMessage {
+				n := len(conv.Messages) - 1
+				summary := StoredMessage{Role: "assistant", Content: fmt.Sprintf("summary of %d messages", n)}
+				return []StoredMessage{summary, conv.Messages[n]}
+			},
+			want: []string{"summary of 3 messages", "m4", "m5"},
+		},
+		{
+			name: "hook output over the limit is cut",
+			trim: func(conv *ConversationWithMessages) []StoredMessage {
+				return conv.Messages
+			},
+			want: []string{"m3", "m4", "m5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewBoundedMemoryConversations(MemoryLimits{MaxConversationMessages: 3, Trim: tt.trim})
+			id := create(t, m, "u1")
+			for i := 1; i <= 5; i++ {
+				appendN(t, m, id, 1, fmt.Sprintf("m%d", i))
+			}
+
+			conv, _ := m.Get(ctx, id)
+			var got []string
+			for _, msg := range conv.Messages {
+				got = append(got, msg.Content)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Messages = %v, want %v", got, tt.want)
+			}
+			if m.messages != len(tt.want) {
+				t.Errorf("message count = %d, want %d", m.messages, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMemoryConversations_BoundedUnderLoad(t *testing.T) {
+	const (
+		conversations = 5000
+		messages      = 4
+		keep          = 100
+	)
+	content := strings.Repeat("x", 1024)
+	beforeConvs, beforeMsgs := gauge("conversations"), gauge("messages")
+
+	heap := func() uint64 {
+		runtime.GC()
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return stats.HeapAlloc
+	}
+
+	// Every hundredth conversation has a pending confirmation.
+	pinned := make(map[string]bool)
+	m := NewBoundedMemoryConversations(MemoryLimits{MaxConversations: keep})
+	m.AddPin(func(id string) bool { return pinned[id] })
+
+	start := heap()
+	ids := make([]string, conversations)
+	for i := range ids {
+		ids[i] = create(t, m, fmt.Sprintf("user-%d", i%500))
+		if i%100 == 0 {
+			pinned[ids[i]] = true
+		}
+		appendN(t, m, ids[i], messages, content)
+	}
+	grown := int64(heap()) - int64(start)
+
+	// Unbounded, the messages alone would take 20MB.
+	if limit := int64(4 << 20); grown > limit {
+		t.Errorf("heap grew %d bytes, want under %d", grown, limit)
+	}
+	if len(m.conversations) != keep {
+		t.Errorf("kept %d conversations, want %d", len(m.conversations), keep)
+	}
+	if got := gauge("conversations") - beforeConvs; got != int64(len(m.conversations)) {
+		t.Errorf("conversations gauge = %d, want %d", got, len(m.conversations))
+	}
+	if got := gauge("messages") - beforeMsgs; got != int64(m.messages) {
+		t.Errorf("messages gauge = %d, want %d", got, m.messages)
+	}
+
+	ctx := context.Background()
+	for i, id := range ids {
+		_, err := m.Get(ctx, id)
+		recent := i >= conversations-keep/2 // among the newest, which survive
+		switch {
+		case pinned[id] || recent:
+			if err != nil {
+				t.Fatalf("conversation %d: %v, want it kept", i, err)
+			}
+		case i < conversations-keep:
+			if !errors.Is(err, ErrConversationEvicted) {
+				t.Fatalf("conversation %d: error = %v, want ErrConversationEvicted", i, err)
+			}
+		}
+	}
+
+	// Evicted conversations no longer appear in their users' lists.
+	for user, convIDs := range m.byUser {
+		for _, id := range convIDs {
+			if _, ok := m.conversations[id]; !ok {
+				t.Fatalf("user %s lists evicted conversation %s", user, id)
+			}
+		}
+	}
+}
+
+func TestListAfter(t *testing.T) {
+	type pagedStore interface {
+		Conversations
+		ConversationPager
+	}
+	stores := []struct {
+		name string
+		open func(t *testing.T) pagedStore
+	}{
+		{"memory", func(t *testing.T) pagedStore { return NewMemoryConversations() }},
+		{"sqlite", func(t *testing.T) pagedStore { return openSQLite(t, filepath.Join(t.TempDir(), "nim.db")) }},
+	}
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := st.open(t)
+
+			var ids []string // oldest first
+			for i := 0; i < 120; i++ {
+				conv, err := s.Create(ctx, "user-1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, conv.ID)
+			}
+			if _, err := s.Create(ctx, "user-2"); err != nil {
+				t.Fatal(err)
+			}
+
+			var listed []string
+			var pages int
+			cursor := ""
+			for {
+				page, next, err := s.ListAfter(ctx, "user-1", cursor, 50)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pages++
+				for _, conv := range page {
+					listed = append(listed, conv.ID)
+				}
+				if pages == 1 {
+					// Deleting the last conversation listed, and creating
+					// one, doesn't disturb the following pages.
+					if err := s.Delete(ctx, page[len(page)-1].ID); err != nil {
+						t.Fatal(err)
+					}
+					if _, err := s.Create(ctx, "user-1"); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+
+			if pages != 3 {
+				t.Errorf("listed %d pages, want 3", pages)
+			}
+			want := make([]string, 0, len(ids))
+			for i := len(ids) - 1; i >= 0; i-- {
+				want = append(want, ids[i])
+			}
+			if strings.Join(listed, ",") != strings.Join(want, ",") {
+				t.Errorf("listed %d conversations, want all 120 newest first, each once", len(listed))
+			}
+
+			if _, _, err := s.ListAfter(ctx, "user-1", "not a cursor", 10); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("ListAfter(bad cursor) = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"cmp"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCursor is returned by ListAfter for a cursor it didn't issue.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is a position in a newest-first listing of conversations: the
+// last conversation of the previous page.
+type pageCursor struct {
+	created int64 // unix nanoseconds
+	id      string
+}
+
+// cursorAt returns the cursor following conv.
+func cursorAt(conv *Conversation) string {
+	raw := strconv.FormatInt(conv.CreatedAt.UnixNano(), 10) + ":" + conv.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// parseCursor decodes a cursor from cursorAt. The empty cursor is the
+// start of the listing.
+func parseCursor(cursor string) (*pageCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, cursor)
+	}
+	created, id, ok := strings.Cut(string(raw), ":")
+	nanos, err := strconv.ParseInt(created, 10, 64)
+	if !ok || err != nil || id == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, cursor)
+	}
+	return &pageCursor{created: nanos, id: id}, nil
+}
+
+// after reports whether conv comes after the cursor, newest first.
+func (c *pageCursor) after(conv *Conversation) bool {
+	created := conv.CreatedAt.UnixNano()
+	return created < c.created || (created == c.created && conv.ID < c.id)
+}
+
+// newestFirst orders conversations by creation, newest first, breaking
+// ties by ID so pages never overlap.
+func newestFirst(a, b *Conversation) int {
+	if c := cmp.Compare(b.CreatedAt.UnixNano(), a.CreatedAt.UnixNano()); c != 0 {
+		return c
+	}
+	return strings.Compare(b.ID, a.ID)
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// MemoryKeyValue is an in-memory implementation of KeyValue.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryKeyValue struct {
+	mu     sync.RWMutex
+	values map[string][]byte
+}
+
+// NewMemoryKeyValue creates an in-memory key-value store.
+func NewMemoryKeyValue() *MemoryKeyValue {
+	return &MemoryKeyValue{
+		values: make(map[string][]byte),
+	}
+}
+
+func (m *MemoryKeyValue) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok := m.values[key]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (m *MemoryKeyValue) Set(ctx context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (m *MemoryKeyValue) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+// FileKeyValue is a KeyValue kept in memory and saved to a JSON file on
+// every change, so values survive restarts. The file is replaced
+// atomically, so a crash mid-write leaves the previous contents.
+// Suitable for development and single-instance demos. Not suitable for
+// production: every write rewrites the whole file, and it doesn't work
+// across multiple instances.
+type FileKeyValue struct {
+	mu     sync.Mutex
+	path   string
+	values map[string][]byte
+}
+
+// NewFileKeyValue opens the store saved at path, creating it (and its
+// directory) on the first write if it doesn't exist.
+func NewFileKeyValue(path string) (*FileKeyValue, error) {
+	f := &FileKeyValue{path: path, values: make(map[string][]byte)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &f.values); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	return f, nil
+}
+
+func (f *FileKeyValue) Get(ctx context.Context, key string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.values[key]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (f *FileKeyValue) Set(ctx context.Context, key string, value []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	previous, existed := f.values[key]
+	f.values[key] = append([]byte(nil), value...)
+	if err := f.save(); err != nil {
+		if existed {
+			f.values[key] = previous
+		} else {
+			delete(f.values, key)
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *FileKeyValue) Delete(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	previous, existed := f.values[key]
+	if !existed {
+		return nil
+	}
+	delete(f.values, key)
+	if err := f.save(); err != nil {
+		f.values[key] = previous
+		return err
+	}
+	return nil
+}
+
+// save writes the values to a temporary file and renames it over the
+// store's file. Callers hold f.mu.
+func (f *FileKeyValue) save() error {
+	data, err := json.Marshal(f.values)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", f.path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save %s: %w", f.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save %s: %w", f.path, err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to save %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// Verify MemoryKeyValue and FileKeyValue implement KeyValue.
+var (
+	_ KeyValue = (*MemoryKeyValue)(nil)
+	_ KeyValue = (*FileKeyValue)(nil)
+)
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestKeyValue(t *testing.T) {
+	ctx := context.Background()
+	stores := map[string]func(t *testing.T) KeyValue{
+		"memory": func(t *testing.T) KeyValue { return NewMemoryKeyValue() },
+		"file": func(t *testing.T) KeyValue {
+			kv, err := NewFileKeyValue(filepath.Join(t.TempDir(), "data", "kv.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return kv
+		},
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			kv := open(t)
+			if v, err := kv.Get(ctx, "missing"); v != nil || err != nil {
+				t.Errorf("Get(missing) = %q, %v, want nil, nil", v, err)
+			}
+
+			value := []byte(`{"amount":150}`)
+			if err := kv.Set(ctx, "goal:user-1", value); err != nil {
+				t.Fatal(err)
+			}
+			value[0] = 'x' // the store keeps its own copy
+			if v, _ := kv.Get(ctx, "goal:user-1"); string(v) != `{"amount":150}` {
+				t.Errorf("Get = %q, want the value set", v)
+			}
+
+			if err := kv.Delete(ctx, "goal:user-1"); err != nil {
+				t.Fatal(err)
+			}
+			if v, _ := kv.Get(ctx, "goal:user-1"); v != nil {
+				t.Errorf("Get after Delete = %q, want nil", v)
+			}
+			if err := kv.Delete(ctx, "goal:user-1"); err != nil {
+				t.Errorf("Delete of a missing key = %v, want nil", err)
+			}
+
+			// Concurrent writes to one key leave one of the written values.
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					if err := kv.Set(ctx, "goal:user-2", []byte(fmt.Sprintf(`{"amount":%d}`, i))); err != nil {
+						t.Error(err)
+					}
+				}(i)
+			}
+			wg.Wait()
+			v, _ := kv.Get(ctx, "goal:user-2")
+			var amount int
+			if _, err := fmt.Sscanf(string(v), `{"amount":%d}`, &amount); err != nil || amount < 0 || amount >= 20 {
+				t.Errorf("after concurrent writes Get = %q, want one of the written values", v)
+			}
+		})
+	}
+}
+
+func TestFileKeyValue_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "kv.json")
+
+	kv, err := NewFileKeyValue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv.Set(ctx, "a", []byte("1"))
+	kv.Set(ctx, "b", []byte("2"))
+	kv.Delete(ctx, "b")
+
+	reopened, err := NewFileKeyValue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := reopened.Get(ctx, "a"); string(v) != "1" {
+		t.Errorf("a = %q after reopening, want 1", v)
+	}
+	if v, _ := reopened.Get(ctx, "b"); v != nil {
+		t.Errorf("b = %q after reopening, want it deleted", v)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the store (no temporary files)", len(entries))
+	}
+
+	os.WriteFile(path, []byte("not json"), 0o644)
+	if _, err := NewFileKeyValue(path); err == nil {
+		t.Error("NewFileKeyValue should reject a corrupt file")
+	}
+}
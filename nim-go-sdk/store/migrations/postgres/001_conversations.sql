@@ -0,0 +1,23 @@
+CREATE TABLE conversations (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    title      TEXT NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE INDEX conversations_user_updated ON conversations (user_id, updated_at);
+
+CREATE TABLE messages (
+    seq             BIGSERIAL PRIMARY KEY,
+    id              TEXT NOT NULL UNIQUE,
+    conversation_id TEXT NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    role            TEXT NOT NULL,
+    content         TEXT NOT NULL,
+    blocks          TEXT,
+    tools           TEXT,
+    artifacts       TEXT,
+    created_at      BIGINT NOT NULL
+);
+
+CREATE INDEX messages_conversation_seq ON messages (conversation_id, seq);
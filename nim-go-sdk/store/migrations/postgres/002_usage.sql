@@ -0,0 +1,4 @@
+ALTER TABLE conversations ADD COLUMN input_tokens BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE conversations ADD COLUMN output_tokens BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE conversations ADD COLUMN cache_creation_input_tokens BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE conversations ADD COLUMN cache_read_input_tokens BIGINT NOT NULL DEFAULT 0;
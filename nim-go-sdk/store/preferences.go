@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// MemoryPreferences is an in-memory implementation of Preferences.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemoryPreferences struct {
+	mu    sync.RWMutex
+	prefs map[string]*core.UserPreferences // userID -> preferences
+}
+
+// NewMemoryPreferences creates an in-memory preference store.
+func NewMemoryPreferences() *MemoryPreferences {
+	return &MemoryPreferences{
+		prefs: make(map[string]*core.UserPreferences),
+	}
+}
+
+func (m *MemoryPreferences) Get(ctx context.Context, userID string) (*core.UserPreferences, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.prefs[userID].Clone(), nil
+}
+
+func (m *MemoryPreferences) Set(ctx context.Context, userID string, prefs *core.UserPreferences) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prefs[userID] = prefs.Clone()
+	return nil
+}
+
+// KeyValuePreferences stores preferences as JSON in a KeyValue, one key
+// per user, so they persist wherever the key-value store does.
+type KeyValuePreferences struct {
+	kv KeyValue
+}
+
+// NewKeyValuePreferences creates a preference store backed by kv.
+func NewKeyValuePreferences(kv KeyValue) *KeyValuePreferences {
+	return &KeyValuePreferences{kv: kv}
+}
+
+// NewFilePreferences opens a preference store saved to the JSON file at
+// path, creating it if needed. See FileKeyValue.
+func NewFilePreferences(path string) (*KeyValuePreferences, error) {
+	kv, err := NewFileKeyValue(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyValuePreferences(kv), nil
+}
+
+func (k *KeyValuePreferences) Get(ctx context.Context, userID string) (*core.UserPreferences, error) {
+	data, err := k.kv.Get(ctx, preferencesKey(userID))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var prefs core.UserPreferences
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+func (k *KeyValuePreferences) Set(ctx context.Context, userID string, prefs *core.UserPreferences) error {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	return k.kv.Set(ctx, preferencesKey(userID), data)
+}
+
+// preferencesKey is the key a user's preferences are stored under.
+func preferencesKey(userID string) string {
+	return "preferences:" + userID
+}
+
+// Verify MemoryPreferences and KeyValuePreferences implement Preferences.
+var (
+	_ Preferences = (*MemoryPreferences)(nil)
+	_ Preferences = (*KeyValuePreferences)(nil)
+)
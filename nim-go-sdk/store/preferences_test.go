@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestPreferences(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "prefs.json")
+	stores := map[string]func(t *testing.T) Preferences{
+		"memory": func(t *testing.T) Preferences { return NewMemoryPreferences() },
+		"file": func(t *testing.T) Preferences {
+			prefs, err := NewFilePreferences(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return prefs
+		},
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			prefs := open(t)
+			if p, err := prefs.Get(ctx, "user-1"); p != nil || err != nil {
+				t.Errorf("Get = %+v, %v, want nil, nil", p, err)
+			}
+
+			p := core.DefaultPreferences()
+			p.Shortcuts = map[string]string{"mom": "user_abc123"}
+			if err := prefs.Set(ctx, "user-1", p); err != nil {
+				t.Fatal(err)
+			}
+			p.Shortcuts["mom"] = "someone_else" // the store keeps its own copy
+
+			got, err := prefs.Get(ctx, "user-1")
+			if err != nil || got == nil || got.Shortcuts["mom"] != "user_abc123" || got.Timezone != "UTC" {
+				t.Fatalf("Get = %+v, %v, want the preferences set", got, err)
+			}
+			if other, _ := prefs.Get(ctx, "user-2"); other != nil {
+				t.Errorf("user-2 sees %+v", other)
+			}
+		})
+	}
+
+	// The file store keeps preferences across restarts.
+	reopened, err := NewFilePreferences(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := reopened.Get(ctx, "user-1"); got == nil || got.Shortcuts["mom"] != "user_abc123" {
+		t.Errorf("after reopening, Get = %+v", got)
+	}
+}
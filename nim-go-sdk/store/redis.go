@@ -0,0 +1,259 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisConfirmations is an implementation of Confirmations backed by Redis.
+// Recommended for production deployments with several instances: an action
+// offered by one instance can be confirmed on any other. Actions expire with
+// their ExpiresAt through Redis TTLs.
+type RedisConfirmations struct {
+	client     redis.UniversalClient
+	prefix     string
+	defaultTTL time.Duration
+}
+
+// RedisConfig configures the Redis confirmations store.
+type RedisConfig struct {
+	// Client is the Redis connection to use. Required.
+	Client redis.UniversalClient
+	// Prefix namespaces the store's keys. Defaults to "nim:confirmations:".
+	Prefix string
+	// DefaultTTL is the expiration time for actions without an ExpiresAt.
+	// Defaults to 15 minutes.
+	DefaultTTL time.Duration
+}
+
+// NewRedisConfirmations creates a confirmation store on an existing Redis
+// client.
+func NewRedisConfirmations(cfg RedisConfig) (*RedisConfirmations, error) {
+	if cfg.Client == nil {
+		return nil, fmt.Errorf("redis client is required")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "nim:confirmations:"
+	}
+	if cfg.DefaultTTL <= 0 {
+		cfg.DefaultTTL = 15 * time.Minute
+	}
+	return &RedisConfirmations{
+		client:     cfg.Client,
+		prefix:     cfg.Prefix,
+		defaultTTL: cfg.DefaultTTL,
+	}, nil
+}
+
+// Ping checks the Redis connection.
+func (r *RedisConfirmations) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// takeScript gets and deletes an action in one step, so only one of several
+// concurrent confirmations or cancellations receives it.
+var takeScript = redis.NewScript(`
+local value = redis.call("GET", KEYS[1])
+if value then
+	redis.call("DEL", KEYS[1])
+end
+return value
+`)
+
+// releaseScript deletes an idempotency mapping if it still points at the
+// given action, leaving mappings a newer action has taken over.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (r *RedisConfirmations) Store(ctx context.Context, action *core.PendingAction) error {
+	data, err := json.Marshal(action)
+	if err != nil {
+		return fmt.Errorf("failed to encode action: %w", err)
+	}
+	ttl := r.ttlFor(action)
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, r.actionKey(action.UserID, action.ID), data, ttl)
+		if action.IdempotencyKey != "" {
+			pipe.Set(ctx, r.idempotencyKey(action.UserID, action.IdempotencyKey), action.ID, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store action: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisConfirmations) Get(ctx context.Context, userID, actionID string) (*core.PendingAction, error) {
+	data, err := r.client.Get(ctx, r.actionKey(userID, actionID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("action not found: %s", actionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get action: %w", err)
+	}
+	return r.decode(data, actionID)
+}
+
+func (r *RedisConfirmations) GetByIdempotency(ctx context.Context, userID, key string) (*core.PendingAction, error) {
+	idempKey := r.idempotencyKey(userID, key)
+	actionID, err := r.client.Get(ctx, idempKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	action, err := r.Get(ctx, userID, actionID)
+	if err != nil {
+		// Action expired, confirmed or cancelled; clean up the mapping
+		releaseScript.Run(ctx, r.client, []string{idempKey}, actionID)
+		return nil, nil
+	}
+	return action, nil
+}
+
+func (r *RedisConfirmations) Confirm(ctx context.Context, userID, actionID string) (*core.PendingAction, error) {
+	return r.take(ctx, userID, actionID)
+}
+
+func (r *RedisConfirmations) Cancel(ctx context.Context, userID, actionID string) error {
+	_, err := r.take(ctx, userID, actionID)
+	return err
+}
+
+func (r *RedisConfirmations) StoreGroup(ctx context.Context, group *core.PendingActionGroup) error {
+	data, err := json.Marshal(group)
+	if err != nil {
+		return fmt.Errorf("failed to encode group: %w", err)
+	}
+	ttl := r.defaultTTL
+	if group.ExpiresAt > 0 {
+		if until := time.Until(time.Unix(group.ExpiresAt, 0)); until > 0 {
+			ttl = until
+		}
+	}
+	if err := r.client.Set(ctx, r.groupKey(group.UserID, group.ID), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store group: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisConfirmations) GetGroup(ctx context.Context, userID, groupID string) (*core.PendingActionGroup, error) {
+	data, err := r.client.Get(ctx, r.groupKey(userID, groupID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("group not found: %s", groupID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	return r.decodeGroup(data, groupID)
+}
+
+func (r *RedisConfirmations) ConfirmGroup(ctx context.Context, userID, groupID string) (*core.PendingActionGroup, error) {
+	data, err := takeScript.Run(ctx, r.client, []string{r.groupKey(userID, groupID)}).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("group not found: %s", groupID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take group: %w", err)
+	}
+	return r.decodeGroup([]byte(data), groupID)
+}
+
+func (r *RedisConfirmations) CancelGroup(ctx context.Context, userID, groupID string) error {
+	group, err := r.ConfirmGroup(ctx, userID, groupID)
+	if err != nil {
+		return err
+	}
+	for _, action := range group.Actions {
+		// Actions already confirmed or expired are gone; that's fine
+		r.take(ctx, userID, action.ID)
+	}
+	return nil
+}
+
+// Cleanup has nothing to do: Redis expires actions and their idempotency
+// keys itself.
+func (r *RedisConfirmations) Cleanup(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+// take removes a pending action and returns it.
+func (r *RedisConfirmations) take(ctx context.Context, userID, actionID string) (*core.PendingAction, error) {
+	data, err := takeScript.Run(ctx, r.client, []string{r.actionKey(userID, actionID)}).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("action not found: %s", actionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take action: %w", err)
+	}
+	action, err := r.decode([]byte(data), actionID)
+	if err != nil {
+		return nil, err
+	}
+	if action.IdempotencyKey != "" {
+		releaseScript.Run(ctx, r.client, []string{r.idempotencyKey(userID, action.IdempotencyKey)}, actionID)
+	}
+	return action, nil
+}
+
+func (r *RedisConfirmations) decode(data []byte, actionID string) (*core.PendingAction, error) {
+	var action core.PendingAction
+	if err := json.Unmarshal(data, &action); err != nil {
+		return nil, fmt.Errorf("failed to decode action %s: %w", actionID, err)
+	}
+	if action.ExpiresAt < time.Now().Unix() {
+		return nil, fmt.Errorf("action expired: %s", actionID)
+	}
+	return &action, nil
+}
+
+// Keys share a {userID} hash tag so a user's keys live on one Redis Cluster
+// slot.
+func (r *RedisConfirmations) actionKey(userID, actionID string) string {
+	return r.prefix + "{" + userID + "}:action:" + actionID
+}
+
+func (r *RedisConfirmations) decodeGroup(data []byte, groupID string) (*core.PendingActionGroup, error) {
+	var group core.PendingActionGroup
+	if err := json.Unmarshal(data, &group); err != nil {
+		return nil, fmt.Errorf("failed to decode group %s: %w", groupID, err)
+	}
+	if group.ExpiresAt < time.Now().Unix() {
+		return nil, fmt.Errorf("group expired: %s", groupID)
+	}
+	return &group, nil
+}
+
+func (r *RedisConfirmations) groupKey(userID, groupID string) string {
+	return r.prefix + "{" + userID + "}:group:" + groupID
+}
+
+func (r *RedisConfirmations) idempotencyKey(userID, key string) string {
+	return r.prefix + "{" + userID + "}:idemp:" + key
+}
+
+func (r *RedisConfirmations) ttlFor(action *core.PendingAction) time.Duration {
+	if action.ExpiresAt > 0 {
+		ttl := time.Until(time.Unix(action.ExpiresAt, 0))
+		if ttl > 0 {
+			return ttl
+		}
+	}
+	return r.defaultTTL
+}
+
+// Verify RedisConfirmations implements Confirmations.
+var _ Confirmations = (*RedisConfirmations)(nil)
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// newRedisConfirmations returns n stores sharing one miniredis server, like
+// replicas behind a load balancer.
+func newRedisConfirmations(t *testing.T, n int) (*miniredis.Miniredis, []*RedisConfirmations) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	stores := make([]*RedisConfirmations, n)
+	for i := range stores {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		s, err := NewRedisConfirmations(RedisConfig{Client: client})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores[i] = s
+	}
+	return mr, stores
+}
+
+func pendingAction(id, idempotencyKey string) *core.PendingAction {
+	return &core.PendingAction{
+		ID:             id,
+		IdempotencyKey: idempotencyKey,
+		UserID:         "user-1",
+		Tool:           "send_money",
+		Input:          []byte(`{"amount":"50"}`),
+		Summary:        "Send 50",
+		ExpiresAt:      time.Now().Add(10 * time.Minute).Unix(),
+	}
+}
+
+func TestRedisConfirmations(t *testing.T) {
+	ctx := context.Background()
+	mr, stores := newRedisConfirmations(t, 2)
+	a, b := stores[0], stores[1]
+
+	if err := a.Store(ctx, pendingAction("action-1", "idem-1")); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL(a.actionKey("user-1", "action-1")); ttl <= 9*time.Minute || ttl > 10*time.Minute {
+		t.Errorf("action TTL = %v, want about 10m from ExpiresAt", ttl)
+	}
+
+	got, err := b.Get(ctx, "user-1", "action-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Tool != "send_money" || string(got.Input) != `{"amount":"50"}` {
+		t.Errorf("Get() = %+v, want the stored action", got)
+	}
+	if _, err := b.Get(ctx, "user-2", "action-1"); err == nil {
+		t.Error("Get() returned another user's action")
+	}
+	if got, err := b.GetByIdempotency(ctx, "user-1", "idem-1"); err != nil || got == nil || got.ID != "action-1" {
+		t.Errorf("GetByIdempotency() = %v, %v, want action-1", got, err)
+	}
+	if got, err := b.GetByIdempotency(ctx, "user-1", "idem-2"); got != nil || err != nil {
+		t.Errorf("GetByIdempotency() of an unknown key = %v, %v, want nil, nil", got, err)
+	}
+
+	// Confirmed on the other replica, exactly once.
+	confirmed, err := b.Confirm(ctx, "user-1", "action-1")
+	if err != nil || confirmed.ID != "action-1" {
+		t.Fatalf("Confirm() = %v, %v", confirmed, err)
+	}
+	if _, err := a.Confirm(ctx, "user-1", "action-1"); err == nil {
+		t.Error("action confirmed twice")
+	}
+	if mr.Exists(a.idempotencyKey("user-1", "idem-1")) {
+		t.Error("idempotency key outlived the confirmed action")
+	}
+
+	if err := a.Store(ctx, pendingAction("action-2", "")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Cancel(ctx, "user-1", "action-2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Get(ctx, "user-1", "action-2"); err == nil {
+		t.Error("cancelled action still pending")
+	}
+}
+
+func TestRedisConfirmations_Group(t *testing.T) {
+	ctx := context.Background()
+	_, stores := newRedisConfirmations(t, 2)
+	a, b := stores[0], stores[1]
+
+	actions := []*core.PendingAction{pendingAction("action-1", "idem-1"), pendingAction("action-2", "idem-2")}
+	group := core.NewPendingActionGroup("group-1", actions)
+	for _, action := range actions {
+		if err := a.Store(ctx, action); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.StoreGroup(ctx, group); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := b.GetGroup(ctx, "user-1", "group-1")
+	if err != nil || len(got.Actions) != 2 || got.Action("action-2") == nil {
+		t.Fatalf("GetGroup() = %+v, %v, want the stored group", got, err)
+	}
+	if action, err := b.Get(ctx, "user-1", "action-1"); err != nil || action.GroupID != "group-1" {
+		t.Errorf("Get() = %+v, %v, want an action of group-1", action, err)
+	}
+	if _, err := b.GetGroup(ctx, "user-2", "group-1"); err == nil {
+		t.Error("GetGroup() returned another user's group")
+	}
+
+	// Confirmed once; its actions stay pending until taken.
+	if _, err := b.ConfirmGroup(ctx, "user-1", "group-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ConfirmGroup(ctx, "user-1", "group-1"); err == nil {
+		t.Error("group confirmed twice")
+	}
+	if _, err := a.Get(ctx, "user-1", "action-2"); err != nil {
+		t.Errorf("action of a confirmed group: %v", err)
+	}
+
+	// Cancelling a group cancels its actions.
+	if err := a.StoreGroup(ctx, core.NewPendingActionGroup("group-2", actions)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.CancelGroup(ctx, "user-1", "group-2"); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range actions {
+		if _, err := a.Get(ctx, "user-1", action.ID); err == nil {
+			t.Errorf("%s of a cancelled group still pending", action.ID)
+		}
+	}
+}
+
+func TestRedisConfirmations_Expiry(t *testing.T) {
+	ctx := context.Background()
+	mr, stores := newRedisConfirmations(t, 1)
+	s := stores[0]
+
+	if err := s.Store(ctx, pendingAction("action-1", "idem-1")); err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(11 * time.Minute)
+	if _, err := s.Get(ctx, "user-1", "action-1"); err == nil {
+		t.Error("Get() returned an expired action")
+	}
+	if got, err := s.GetByIdempotency(ctx, "user-1", "idem-1"); got != nil || err != nil {
+		t.Errorf("GetByIdempotency() of an expired action = %v, %v, want nil, nil", got, err)
+	}
+	if n, err := s.Cleanup(ctx); n != 0 || err != nil {
+		t.Errorf("Cleanup() = %d, %v, want 0, nil", n, err)
+	}
+}
+
+func TestRedisConfirmations_ConcurrentConfirm(t *testing.T) {
+	ctx := context.Background()
+	_, stores := newRedisConfirmations(t, 4)
+	if err := stores[0].Store(ctx, pendingAction("action-1", "idem-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	confirmed := 0
+	for _, s := range stores {
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(s *RedisConfirmations) {
+				defer wg.Done()
+				if _, err := s.Confirm(ctx, "user-1", "action-1"); err == nil {
+					mu.Lock()
+					confirmed++
+					mu.Unlock()
+				}
+			}(s)
+		}
+	}
+	wg.Wait()
+	if confirmed != 1 {
+		t.Errorf("action confirmed %d times, want 1", confirmed)
+	}
+}
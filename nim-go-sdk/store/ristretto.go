@@ -157,6 +157,56 @@ func (r *RistrettoConfirmations) Cancel(ctx context.Context, userID, actionID st
 	return nil
 }
 
+func (r *RistrettoConfirmations) StoreGroup(ctx context.Context, group *core.PendingActionGroup) error {
+	ttl := r.defaultTTL
+	if group.ExpiresAt > 0 {
+		if until := time.Until(time.Unix(group.ExpiresAt, 0)); until > 0 {
+			ttl = until
+		}
+	}
+	r.cache.SetWithTTL(r.groupKey(group.UserID, group.ID), group, 1, ttl)
+	r.cache.Wait()
+	return nil
+}
+
+func (r *RistrettoConfirmations) GetGroup(ctx context.Context, userID, groupID string) (*core.PendingActionGroup, error) {
+	key := r.groupKey(userID, groupID)
+	val, found := r.cache.Get(key)
+	if !found {
+		return nil, fmt.Errorf("group not found: %s", groupID)
+	}
+
+	group := val.(*core.PendingActionGroup)
+	if group.ExpiresAt < time.Now().Unix() {
+		r.cache.Del(key)
+		return nil, fmt.Errorf("group expired: %s", groupID)
+	}
+
+	return group, nil
+}
+
+func (r *RistrettoConfirmations) ConfirmGroup(ctx context.Context, userID, groupID string) (*core.PendingActionGroup, error) {
+	group, err := r.GetGroup(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.Del(r.groupKey(userID, groupID))
+	return group, nil
+}
+
+func (r *RistrettoConfirmations) CancelGroup(ctx context.Context, userID, groupID string) error {
+	group, err := r.ConfirmGroup(ctx, userID, groupID)
+	if err != nil {
+		return err
+	}
+
+	for _, action := range group.Actions {
+		r.delete(action)
+	}
+	return nil
+}
+
 func (r *RistrettoConfirmations) Cleanup(ctx context.Context) (int, error) {
 	// Ristretto handles TTL-based eviction automatically.
 	// This method cleans up expired entries from our tracking map.
@@ -223,6 +273,10 @@ func (r *RistrettoConfirmations) actionKey(userID, actionID string) string {
 	return userID + ":" + actionID
 }
 
+func (r *RistrettoConfirmations) groupKey(userID, groupID string) string {
+	return userID + ":group:" + groupID
+}
+
 func (r *RistrettoConfirmations) idempotencyKey(userID, key string) string {
 	return userID + ":idemp:" + key
 }
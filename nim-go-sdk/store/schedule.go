@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemorySchedules is an in-memory implementation of Schedules.
+// Suitable for development and testing. Not suitable for production
+// as data is lost on restart and doesn't work across multiple instances.
+type MemorySchedules struct {
+	mu          sync.RWMutex
+	schedules   map[string]*ScheduledTransfer
+	byUser      map[string][]string     // userID -> []scheduleID
+	occurrences map[string]map[int]bool // scheduleID -> handled occurrence indexes
+}
+
+// NewMemorySchedules creates a new in-memory schedule store.
+func NewMemorySchedules() *MemorySchedules {
+	return &MemorySchedules{
+		schedules:   make(map[string]*ScheduledTransfer),
+		byUser:      make(map[string][]string),
+		occurrences: make(map[string]map[int]bool),
+	}
+}
+
+func (m *MemorySchedules) Create(ctx context.Context, schedule *ScheduledTransfer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if schedule.ID == "" {
+		schedule.ID = uuid.New().String()
+	}
+	if schedule.Status == "" {
+		schedule.Status = ScheduleActive
+	}
+	now := time.Now()
+	if schedule.CreatedAt.IsZero() {
+		schedule.CreatedAt = now
+	}
+	schedule.UpdatedAt = now
+
+	stored := *schedule
+	m.schedules[schedule.ID] = &stored
+	m.byUser[schedule.UserID] = append(m.byUser[schedule.UserID], schedule.ID)
+	return nil
+}
+
+func (m *MemorySchedules) Get(ctx context.Context, userID, scheduleID string) (*ScheduledTransfer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	schedule, ok := m.schedules[scheduleID]
+	if !ok || schedule.UserID != userID {
+		return nil, fmt.Errorf("schedule not found: %s", scheduleID)
+	}
+	result := *schedule
+	return &result, nil
+}
+
+func (m *MemorySchedules) List(ctx context.Context, userID string) ([]*ScheduledTransfer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := m.byUser[userID]
+	result := make([]*ScheduledTransfer, 0, len(ids))
+	for _, id := range ids {
+		if schedule, ok := m.schedules[id]; ok {
+			s := *schedule
+			result = append(result, &s)
+		}
+	}
+	return result, nil
+}
+
+func (m *MemorySchedules) ListActive(ctx context.Context) ([]*ScheduledTransfer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []*ScheduledTransfer
+	for _, schedule := range m.schedules {
+		if schedule.Status == ScheduleActive {
+			s := *schedule
+			result = append(result, &s)
+		}
+	}
+	return result, nil
+}
+
+func (m *MemorySchedules) SetStatus(ctx context.Context, userID, scheduleID, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schedule, ok := m.schedules[scheduleID]
+	if !ok || schedule.UserID != userID {
+		return fmt.Errorf("schedule not found: %s", scheduleID)
+	}
+	schedule.Status = status
+	schedule.UpdatedAt = time.Now()
+	return nil
+}
+
+func (m *MemorySchedules) MarkOccurrence(ctx context.Context, scheduleID string, index int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	marked, ok := m.occurrences[scheduleID]
+	if !ok {
+		marked = make(map[int]bool)
+		m.occurrences[scheduleID] = marked
+	}
+	if marked[index] {
+		return false, nil
+	}
+	marked[index] = true
+	return true, nil
+}
+
+func (m *MemorySchedules) UnmarkOccurrence(ctx context.Context, scheduleID string, index int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.occurrences[scheduleID], index)
+	return nil
+}
+
+func (m *MemorySchedules) OccurrenceMarked(ctx context.Context, scheduleID string, index int) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.occurrences[scheduleID][index], nil
+}
+
+// Verify MemorySchedules implements Schedules.
+var _ Schedules = (*MemorySchedules)(nil)
@@ -0,0 +1,382 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/google/uuid"
+)
+
+// Dialect is a SQL database SQLConversations can run on. Queries are shared;
+// only the migrations differ.
+type Dialect string
+
+// Supported dialects.
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+//go:embed migrations
+var migrations embed.FS
+
+// SQLConversations is an implementation of Conversations on database/sql,
+// for SQLite in development and Postgres in production. History survives
+// restarts, so conversations can be resumed after a deploy. Messages are
+// returned in the order they were appended.
+type SQLConversations struct {
+	db *sql.DB
+}
+
+// NewSQLConversations creates a conversation store on db, a connection to a
+// database of the given dialect, applying any migrations it hasn't run yet.
+// The caller registers the driver and owns db.
+func NewSQLConversations(ctx context.Context, db *sql.DB, dialect Dialect) (*SQLConversations, error) {
+	if err := migrate(ctx, db, dialect); err != nil {
+		return nil, err
+	}
+	return &SQLConversations{db: db}, nil
+}
+
+// Ping checks the database connection.
+func (s *SQLConversations) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// migrate applies the dialect's migrations in name order, each once.
+func migrate(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	dir := "migrations/" + string(dialect)
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return fmt.Errorf("unsupported dialect: %s", dialect)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS nim_schema_migrations (
+    version    TEXT PRIMARY KEY,
+    applied_at BIGINT NOT NULL
+)`); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	for _, name := range names {
+		var applied int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM nim_schema_migrations WHERE version = $1`, name).Scan(&applied); err != nil {
+			return fmt.Errorf("failed to check migration %s: %w", name, err)
+		}
+		if applied > 0 {
+			continue
+		}
+		script, err := migrations.ReadFile(dir + "/" + name)
+		if err != nil {
+			return err
+		}
+		err = inTx(ctx, db, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO nim_schema_migrations (version, applied_at) VALUES ($1, $2)`, name, time.Now().Unix())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLConversations) Create(ctx context.Context, userID string) (*Conversation, error) {
+	now := time.Now()
+	conv := &Conversation{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Title:     DefaultTitle,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO conversations (id, user_id, title, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`,
+		conv.ID, conv.UserID, conv.Title, now.UnixNano(), now.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+	return conv, nil
+}
+
+func (s *SQLConversations) Get(ctx context.Context, conversationID string) (*ConversationWithMessages, error) {
+	var conv ConversationWithMessages
+	err := scanConversation(s.db.QueryRowContext(ctx,
+		`SELECT `+conversationColumns+` FROM conversations WHERE id = $1`,
+		conversationID), &conv.Conversation)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("conversation not found: %s", conversationID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, role, content, blocks, tools, artifacts, created_at FROM messages WHERE conversation_id = $1 ORDER BY seq`,
+		conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	defer rows.Close()
+
+	conv.Messages = []StoredMessage{}
+	for rows.Next() {
+		var msg StoredMessage
+		var blocks, tools, artifacts sql.NullString
+		var created int64
+		if err := rows.Scan(&msg.ID, &msg.Role, &msg.Content, &blocks, &tools, &artifacts, &created); err != nil {
+			return nil, fmt.Errorf("failed to read message: %w", err)
+		}
+		msg.CreatedAt = time.Unix(0, created)
+		if err := unmarshalColumn(blocks, &msg.Blocks); err != nil {
+			return nil, err
+		}
+		if err := unmarshalColumn(tools, &msg.Tools); err != nil {
+			return nil, err
+		}
+		if err := unmarshalColumn(artifacts, &msg.Artifacts); err != nil {
+			return nil, err
+		}
+		conv.Messages = append(conv.Messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+	return &conv, nil
+}
+
+// Export returns the conversation as Get does; each call reads a fresh copy.
+func (s *SQLConversations) Export(ctx context.Context, conversationID string) (*ConversationWithMessages, error) {
+	return s.Get(ctx, conversationID)
+}
+
+func (s *SQLConversations) Append(ctx context.Context, msg *AppendMessage) error {
+	blocks, err := marshalColumn(msg.Blocks, len(msg.Blocks) == 0)
+	if err != nil {
+		return err
+	}
+	tools, err := marshalColumn(msg.Tools, len(msg.Tools) == 0)
+	if err != nil {
+		return err
+	}
+	artifacts, err := marshalColumn(msg.Artifacts, len(msg.Artifacts) == 0)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixNano()
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := touch(ctx, tx, msg.ConversationID, now); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO messages (id, conversation_id, role, content, blocks, tools, artifacts, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			uuid.New().String(), msg.ConversationID, msg.Role, msg.Content, blocks, tools, artifacts, now)
+		if err != nil {
+			return fmt.Errorf("failed to append message: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *SQLConversations) SetTitle(ctx context.Context, conversationID, title string) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE conversations SET title = $1, updated_at = $2 WHERE id = $3`,
+		title, time.Now().UnixNano(), conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to set title: %w", err)
+	}
+	return notFoundIfNone(res, conversationID)
+}
+
+func (s *SQLConversations) AddUsage(ctx context.Context, conversationID string, usage core.TokenUsage) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE conversations SET input_tokens = input_tokens + $1, output_tokens = output_tokens + $2, cache_creation_input_tokens = cache_creation_input_tokens + $3, cache_read_input_tokens = cache_read_input_tokens + $4 WHERE id = $5`,
+		usage.InputTokens, usage.OutputTokens, usage.CacheCreationInputTokens, usage.CacheReadInputTokens, conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to add usage: %w", err)
+	}
+	return notFoundIfNone(res, conversationID)
+}
+
+func (s *SQLConversations) List(ctx context.Context, userID string, limit int) ([]*Conversation, error) {
+	return s.ListPage(ctx, userID, limit, 0)
+}
+
+// ListPage returns a page of a user's conversations, most recently active
+// first, skipping the first offset.
+func (s *SQLConversations) ListPage(ctx context.Context, userID string, limit, offset int) ([]*Conversation, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+conversationColumns+` FROM conversations WHERE user_id = $1 ORDER BY updated_at DESC, id DESC LIMIT $2 OFFSET $3`,
+		userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	defer rows.Close()
+
+	result := []*Conversation{}
+	for rows.Next() {
+		var conv Conversation
+		if err := scanConversation(rows, &conv); err != nil {
+			return nil, fmt.Errorf("failed to read conversation: %w", err)
+		}
+		result = append(result, &conv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	return result, nil
+}
+
+func (s *SQLConversations) ListAfter(ctx context.Context, userID, cursor string, limit int) ([]*Conversation, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("invalid limit: %d", limit)
+	}
+	from, err := parseCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Fetch one extra to learn whether there is a next page.
+	query := `SELECT ` + conversationColumns + ` FROM conversations WHERE user_id = $1`
+	args := []interface{}{userID}
+	if from != nil {
+		query += ` AND (created_at < $2 OR (created_at = $2 AND id < $3))`
+		args = append(args, from.created, from.id)
+	}
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args)+1)
+	args = append(args, limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list conversations: %w", err)
+	}
+	defer rows.Close()
+
+	result := []*Conversation{}
+	for rows.Next() {
+		var conv Conversation
+		if err := scanConversation(rows, &conv); err != nil {
+			return nil, "", fmt.Errorf("failed to read conversation: %w", err)
+		}
+		result = append(result, &conv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to list conversations: %w", err)
+	}
+	if len(result) <= limit {
+		return result, "", nil
+	}
+	result = result[:limit]
+	return result, cursorAt(result[len(result)-1]), nil
+}
+
+func (s *SQLConversations) Delete(ctx context.Context, conversationID string) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE conversation_id = $1`, conversationID); err != nil {
+			return fmt.Errorf("failed to delete messages: %w", err)
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM conversations WHERE id = $1`, conversationID)
+		if err != nil {
+			return fmt.Errorf("failed to delete conversation: %w", err)
+		}
+		return notFoundIfNone(res, conversationID)
+	})
+}
+
+// conversationColumns are the columns scanConversation reads, in order.
+const conversationColumns = `id, user_id, title, created_at, updated_at, input_tokens, output_tokens, cache_creation_input_tokens, cache_read_input_tokens`
+
+// scanner is a *sql.Row or *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanConversation reads a row of conversationColumns into conv.
+func scanConversation(row scanner, conv *Conversation) error {
+	var created, updated int64
+	err := row.Scan(&conv.ID, &conv.UserID, &conv.Title, &created, &updated,
+		&conv.Usage.InputTokens, &conv.Usage.OutputTokens, &conv.Usage.CacheCreationInputTokens, &conv.Usage.CacheReadInputTokens)
+	if err != nil {
+		return err
+	}
+	conv.CreatedAt, conv.UpdatedAt = time.Unix(0, created), time.Unix(0, updated)
+	return nil
+}
+
+// touch marks a conversation as updated, failing if it doesn't exist.
+func touch(ctx context.Context, tx *sql.Tx, conversationID string, now int64) error {
+	res, err := tx.ExecContext(ctx, `UPDATE conversations SET updated_at = $1 WHERE id = $2`, now, conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation: %w", err)
+	}
+	return notFoundIfNone(res, conversationID)
+}
+
+func notFoundIfNone(res sql.Result, conversationID string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("conversation not found: %s", conversationID)
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction, committing if it succeeds.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// marshalColumn encodes a message's list field as JSON, or NULL if empty.
+func marshalColumn(v interface{}, empty bool) (sql.NullString, error) {
+	if empty {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode message: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func unmarshalColumn(col sql.NullString, v interface{}) error {
+	if !col.Valid {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(col.String), v); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+	return nil
+}
+
+// Verify SQLConversations implements Conversations and ConversationPager.
+var (
+	_ Conversations     = (*SQLConversations)(nil)
+	_ ConversationPager = (*SQLConversations)(nil)
+)
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func openSQLite(t *testing.T, path string) *SQLConversations {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s, err := NewSQLConversations(context.Background(), db, DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSQLConversations_Resume(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nim.db")
+	s := openSQLite(t, path)
+
+	conv, err := s.Create(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roles := []string{"user", "assistant"}
+	for i := 0; i < 100; i++ {
+		msg := &AppendMessage{ConversationID: conv.ID, Role: roles[i%2], Content: fmt.Sprintf("message %d", i)}
+		if i == 1 {
+			msg.Blocks = []interface{}{map[string]interface{}{"type": "tool_use", "name": "get_balance"}}
+			msg.Artifacts = []artifact.Ref{{ID: "chart-1", Kind: artifact.KindChart, MediaType: "image/svg+xml", Size: 42}}
+		}
+		if err := s.Append(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SetTitle(ctx, conv.ID, "Balance questions"); err != nil {
+		t.Fatal(err)
+	}
+	for _, usage := range []core.TokenUsage{{InputTokens: 100, OutputTokens: 20}, {InputTokens: 50, OutputTokens: 10, CacheReadInputTokens: 30}} {
+		if err := s.AddUsage(ctx, conv.ID, usage); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.AddUsage(ctx, "missing", core.TokenUsage{InputTokens: 1}); err == nil {
+		t.Error("AddUsage(missing) succeeded, want not found")
+	}
+
+	// Reopening the database, as after a restart, keeps the history.
+	resumed, err := openSQLite(t, path).Get(ctx, conv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.UserID != "user-1" || resumed.Title != "Balance questions" {
+		t.Errorf("conversation = %+v, want user-1's titled conversation", resumed.Conversation)
+	}
+	if want := (core.TokenUsage{InputTokens: 150, OutputTokens: 30, CacheReadInputTokens: 30}); resumed.Usage != want {
+		t.Errorf("usage = %+v, want %+v", resumed.Usage, want)
+	}
+	if len(resumed.Messages) != 100 {
+		t.Fatalf("resumed %d messages, want 100", len(resumed.Messages))
+	}
+	for i, msg := range resumed.Messages {
+		if msg.Content != fmt.Sprintf("message %d", i) || msg.Role != roles[i%2] {
+			t.Fatalf("message %d = %s %q, want %s %q", i, msg.Role, msg.Content, roles[i%2], fmt.Sprintf("message %d", i))
+		}
+	}
+	withBlocks := resumed.Messages[1]
+	if len(withBlocks.Blocks) != 1 || withBlocks.Blocks[0].(map[string]interface{})["name"] != "get_balance" {
+		t.Errorf("blocks = %v, want the tool_use block", withBlocks.Blocks)
+	}
+	if len(withBlocks.Artifacts) != 1 || withBlocks.Artifacts[0].ID != "chart-1" || withBlocks.Artifacts[0].Size != 42 {
+		t.Errorf("artifacts = %v, want chart-1", withBlocks.Artifacts)
+	}
+	if resumed.Messages[0].Blocks != nil || resumed.Messages[0].Artifacts != nil {
+		t.Errorf("message without blocks = %+v, want none", resumed.Messages[0])
+	}
+}
+
+func TestSQLConversations_List(t *testing.T) {
+	ctx := context.Background()
+	s := openSQLite(t, filepath.Join(t.TempDir(), "nim.db"))
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		conv, err := s.Create(ctx, "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, conv.ID)
+	}
+	if _, err := s.Create(ctx, "user-2"); err != nil {
+		t.Fatal(err)
+	}
+	// Activity moves a conversation to the front.
+	if err := s.Append(ctx, &AppendMessage{ConversationID: ids[0], Role: "user", Content: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var listed []string
+	for offset := 0; ; offset += 2 {
+		page, err := s.ListPage(ctx, "user-1", 2, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, conv := range page {
+			listed = append(listed, conv.ID)
+		}
+	}
+	want := []string{ids[0], ids[4], ids[3], ids[2], ids[1]}
+	if strings.Join(listed, ",") != strings.Join(want, ",") {
+		t.Errorf("listed %v, want %v", listed, want)
+	}
+	if recent, _ := s.List(ctx, "user-1", 1); len(recent) != 1 || recent[0].ID != ids[0] {
+		t.Errorf("List() = %v, want the most recently active conversation", recent)
+	}
+}
+
+func TestSQLConversations_NotFound(t *testing.T) {
+	ctx := context.Background()
+	s := openSQLite(t, filepath.Join(t.TempDir(), "nim.db"))
+	conv, err := s.Create(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(ctx, &AppendMessage{ConversationID: conv.ID, Role: "user", Content: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, conv.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := map[string]error{
+		"Get":      func() error { _, err := s.Get(ctx, conv.ID); return err }(),
+		"Append":   s.Append(ctx, &AppendMessage{ConversationID: conv.ID, Role: "user", Content: "hi"}),
+		"SetTitle": s.SetTitle(ctx, conv.ID, "title"),
+		"Delete":   s.Delete(ctx, conv.ID),
+	}
+	for name, err := range checks {
+		if err == nil || !strings.Contains(err.Error(), "conversation not found") {
+			t.Errorf("%s() after Delete = %v, want not found", name, err)
+		}
+	}
+
+	var messages int
+	s.db.QueryRow(`SELECT COUNT(*) FROM messages`).Scan(&messages)
+	if messages != 0 {
+		t.Errorf("%d messages outlived their conversation", messages)
+	}
+}
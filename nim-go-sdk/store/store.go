@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/becomeliminal/nim-go-sdk/core"
 )
@@ -29,12 +30,43 @@ type Confirmations interface {
 	// Cancel removes a pending action without executing it.
 	Cancel(ctx context.Context, userID, actionID string) error
 
+	// StoreGroup saves a group of pending actions offered together. Its
+	// actions are stored separately, with Store.
+	StoreGroup(ctx context.Context, group *core.PendingActionGroup) error
+
+	// GetGroup retrieves a pending action group by ID for the given user.
+	// Returns error if not found or expired.
+	GetGroup(ctx context.Context, userID, groupID string) (*core.PendingActionGroup, error)
+
+	// ConfirmGroup removes a group from pending and returns it, so only one
+	// confirmation of it proceeds. Its actions stay pending: the caller
+	// confirms or cancels each of them.
+	ConfirmGroup(ctx context.Context, userID, groupID string) (*core.PendingActionGroup, error)
+
+	// CancelGroup removes a group and its pending actions without executing
+	// them.
+	CancelGroup(ctx context.Context, userID, groupID string) error
+
 	// Cleanup removes all expired actions. Returns count of removed actions.
 	Cleanup(ctx context.Context) (int, error)
 }
 
+// CompletedActions remembers the write actions that executed, by
+// idempotency key, so a duplicate confirmation of one isn't executed again.
+// The SDK provides MemoryCompletedActions for development.
+type CompletedActions interface {
+	// Complete records the result of the action with the given key,
+	// keeping it for ttl.
+	Complete(ctx context.Context, userID, key string, result *core.ToolResult, ttl time.Duration) error
+
+	// Completed returns the result recorded for key.
+	// Returns nil, nil if there is none or it has expired.
+	Completed(ctx context.Context, userID, key string) (*core.ToolResult, error)
+}
+
 // Conversations stores conversation history.
 // The SDK provides MemoryConversations for development.
+// Stores that evict conversations return ErrConversationEvicted for them.
 // Production deployments should implement with PostgreSQL or similar.
 type Conversations interface {
 	// Create starts a new conversation for the user.
@@ -43,15 +75,114 @@ type Conversations interface {
 	// Get retrieves a conversation with all messages.
 	Get(ctx context.Context, conversationID string) (*ConversationWithMessages, error)
 
+	// Export returns a copy of a conversation with every stored message in
+	// order, including any tool_use and tool_result blocks, for the caller
+	// to redact or render as a transcript.
+	Export(ctx context.Context, conversationID string) (*ConversationWithMessages, error)
+
 	// Append adds a message to a conversation.
 	Append(ctx context.Context, msg *AppendMessage) error
 
 	// SetTitle updates the conversation title.
 	SetTitle(ctx context.Context, conversationID, title string) error
 
+	// AddUsage adds an agent run's token usage to the conversation's
+	// totals.
+	AddUsage(ctx context.Context, conversationID string, usage core.TokenUsage) error
+
 	// List returns recent conversations for a user.
 	List(ctx context.Context, userID string, limit int) ([]*Conversation, error)
 
 	// Delete removes a conversation.
 	Delete(ctx context.Context, conversationID string) error
 }
+
+// Pinner is implemented by conversation stores that evict conversations to
+// bound memory, such as MemoryConversations with limits. The server pins the
+// conversations it still needs: those with live sessions or pending
+// confirmations.
+type Pinner interface {
+	// AddPin registers a check for conversations that must not be evicted.
+	AddPin(pinned func(conversationID string) bool)
+}
+
+// Pinger is implemented by stores whose backend can be unreachable, such as
+// SQLConversations and RedisConfirmations. The server pings them to decide
+// whether it is ready for traffic.
+type Pinger interface {
+	// Ping checks the store can reach its backend.
+	Ping(ctx context.Context) error
+}
+
+// ConversationPager is implemented by conversation stores that can list a
+// user's conversations a page at a time, such as MemoryConversations and
+// SQLConversations. Conversations are listed newest first, by creation.
+type ConversationPager interface {
+	// ListAfter returns up to limit of the user's conversations following
+	// cursor, and the cursor for the next page, or "" after the last one.
+	// An empty cursor starts from the newest conversation. Cursors stay
+	// valid when conversations are created or deleted; a malformed one
+	// fails with ErrInvalidCursor.
+	ListAfter(ctx context.Context, userID, cursor string, limit int) ([]*Conversation, string, error)
+}
+
+// Schedules stores recurring transfer schedules and tracks which occurrences
+// have been handled, so each occurrence is surfaced or executed at most once.
+// The SDK provides MemorySchedules for development.
+type Schedules interface {
+	// Create saves a new schedule. An ID is assigned if empty.
+	Create(ctx context.Context, schedule *ScheduledTransfer) error
+
+	// Get retrieves a schedule by ID for the given user.
+	Get(ctx context.Context, userID, scheduleID string) (*ScheduledTransfer, error)
+
+	// List returns all schedules for a user, oldest first.
+	List(ctx context.Context, userID string) ([]*ScheduledTransfer, error)
+
+	// ListActive returns active schedules across all users.
+	ListActive(ctx context.Context) ([]*ScheduledTransfer, error)
+
+	// SetStatus updates a schedule's status.
+	SetStatus(ctx context.Context, userID, scheduleID, status string) error
+
+	// MarkOccurrence records that an occurrence has been handled.
+	// Returns false if it was already marked, so concurrent callers can
+	// use it as a claim.
+	MarkOccurrence(ctx context.Context, scheduleID string, index int) (bool, error)
+
+	// UnmarkOccurrence releases a mark, such as the claim on an occurrence
+	// whose transfer failed, so it is surfaced again.
+	UnmarkOccurrence(ctx context.Context, scheduleID string, index int) error
+
+	// OccurrenceMarked reports whether an occurrence has been handled.
+	OccurrenceMarked(ctx context.Context, scheduleID string, index int) (bool, error)
+}
+
+// Preferences stores each user's preferences, such as the recipient
+// shortcuts they've asked the agent to remember, across conversations.
+// The SDK provides MemoryPreferences and KeyValuePreferences.
+type Preferences interface {
+	// Get returns the user's saved preferences.
+	// Returns nil, nil if they have none.
+	Get(ctx context.Context, userID string) (*core.UserPreferences, error)
+
+	// Set saves the user's preferences, replacing any saved before.
+	Set(ctx context.Context, userID string, prefs *core.UserPreferences) error
+}
+
+// KeyValue stores small values by key, such as per-user settings kept by
+// tools. Values are opaque bytes, typically JSON; Set replaces a value
+// atomically, so of concurrent writes to a key the last one wins.
+// The SDK provides MemoryKeyValue and FileKeyValue for development.
+// Production deployments should implement with Redis or similar.
+type KeyValue interface {
+	// Get returns the value stored under key.
+	// Returns nil, nil if there is none.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores value under key, replacing any previous value.
+	Set(ctx context.Context, key string, value []byte) error
+
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
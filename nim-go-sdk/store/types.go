@@ -1,6 +1,14 @@
 package store
 
-import "time"
+import (
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/artifact"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// DefaultTitle is the title of a conversation that hasn't been named yet.
+const DefaultTitle = "New conversation"
 
 // Conversation represents conversation metadata.
 type Conversation struct {
@@ -9,6 +17,9 @@ type Conversation struct {
 	Title     string    `json:"title"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Usage totals the model tokens the conversation's agent runs used.
+	Usage core.TokenUsage `json:"usage"`
 }
 
 // ConversationWithMessages includes the full message history.
@@ -25,6 +36,11 @@ type StoredMessage struct {
 	Blocks    []interface{} `json:"blocks,omitempty"`
 	Tools     []interface{} `json:"tools,omitempty"`
 	CreatedAt time.Time     `json:"created_at"`
+
+	// Artifacts are the files attached to or generated in this message.
+	// Stored refs carry no URL; resolve them with an artifact.Resolver
+	// before presenting them.
+	Artifacts []artifact.Ref `json:"artifacts,omitempty"`
 }
 
 // AppendMessage contains data for adding a message to a conversation.
@@ -34,4 +50,42 @@ type AppendMessage struct {
 	Content        string
 	Blocks         []interface{}
 	Tools          []interface{}
+	Artifacts      []artifact.Ref
+}
+
+// Schedule statuses.
+const (
+	ScheduleActive    = "active"
+	ScheduleCancelled = "cancelled"
+	ScheduleCompleted = "completed"
+)
+
+// ScheduledTransfer is a standing order to send money on a recurring cadence.
+type ScheduledTransfer struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"`
+	Currency  string `json:"currency"`
+	Note      string `json:"note,omitempty"`
+
+	// Cadence is one of "daily", "weekly", "biweekly", or "monthly".
+	Cadence string `json:"cadence"`
+
+	// StartDate is the first occurrence. Monthly schedules recur on its day of month.
+	StartDate time.Time `json:"start_date"`
+
+	// EndDate, if set, is the last date an occurrence may fall on (inclusive).
+	EndDate *time.Time `json:"end_date,omitempty"`
+
+	// MaxOccurrences, if positive, limits the number of transfers.
+	MaxOccurrences int `json:"max_occurrences,omitempty"`
+
+	// PreAuthorized transfers execute without a per-occurrence confirmation.
+	// The user must opt in explicitly when creating the schedule.
+	PreAuthorized bool `json:"pre_authorized"`
+
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -111,20 +111,19 @@ func (d *DelegationTool) Execute(ctx context.Context, params *core.ToolParams) (
 		task = d.taskFormatter(input.Query)
 	}
 
-	// Create a minimal context for the sub-agent
-	// The sub-agent context should be created from the parent context
-	// but we only have userID here, so we create a basic one
-	subCtx := &core.Context{
-		UserID:    params.UserID,
-		RequestID: params.RequestID,
-		Limits:    core.SubAgentLimits(),
+	// The sub-agent inherits the parent run's context, with restricted
+	// limits and its audit entries linked to the parent request. Called
+	// outside a run, only the caller's identity is known.
+	parentCtx := params.Context
+	if parentCtx == nil {
+		parentCtx = &core.Context{
+			UserID:    params.UserID,
+			RequestID: params.RequestID,
+		}
 	}
 
 	// Run sub-agent
-	output, err := d.subagent.Run(ctx, &core.Input{
-		UserMessage: task,
-		Context:     subCtx,
-	})
+	output, err := d.subagent.RunWithTask(ctx, parentCtx, task)
 	if err != nil {
 		return &core.ToolResult{
 			Success: false,
@@ -0,0 +1,120 @@
+package subagent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// MaxParallel is how many sub-agents RunAll runs at once.
+const MaxParallel = 4
+
+// RunAll runs each agent on the same task concurrently, at most MaxParallel
+// at a time, and returns their results in the order given. Each agent runs
+// as RunWithTask would, with restricted limits and its audit entries
+// linked to the parent request.
+//
+// An agent's failure is recorded in its result rather than stopping the
+// others, so the results are partial when some fail. The error is non-nil
+// only if every agent failed.
+func RunAll(ctx context.Context, parentCtx *core.Context, task string, agents ...*SubAgent) ([]*SubAgentResult, error) {
+	results := make([]*SubAgentResult, len(agents))
+	var g errgroup.Group
+	g.SetLimit(MaxParallel)
+	for i, agent := range agents {
+		g.Go(func() error {
+			// Failures are results for the caller, not group errors.
+			output, err := agent.RunWithTask(ctx, parentCtx, task)
+			if err != nil {
+				results[i] = &SubAgentResult{
+					AgentName: agent.Name(),
+					Error:     fmt.Sprintf("sub-agent error: %v", err),
+				}
+				return nil
+			}
+			results[i] = ToResult(agent.Name(), output)
+			return nil
+		})
+	}
+	g.Wait()
+
+	if len(results) == 0 {
+		return results, nil
+	}
+	for _, result := range results {
+		if result.Success {
+			return results, nil
+		}
+	}
+	return results, fmt.Errorf("all %d sub-agents failed: %s", len(results), failures(results))
+}
+
+// TotalUsage returns the tokens used by all the results together.
+func TotalUsage(results []*SubAgentResult) core.TokenUsage {
+	var total core.TokenUsage
+	for _, result := range results {
+		total.InputTokens += result.TokensUsed.InputTokens
+		total.OutputTokens += result.TokensUsed.OutputTokens
+		total.CacheCreationInputTokens += result.TokensUsed.CacheCreationInputTokens
+		total.CacheReadInputTokens += result.TokensUsed.CacheReadInputTokens
+	}
+	return total
+}
+
+// CombineResults merges the results of RunAll into a single tool result for
+// the parent agent, listing each agent's response or error so the parent
+// can weigh the findings together. It succeeds if any agent did.
+func CombineResults(results []*SubAgentResult) *core.ToolResult {
+	findings := make([]map[string]interface{}, 0, len(results))
+	succeeded := 0
+	for _, result := range results {
+		finding := map[string]interface{}{
+			"agent":   result.AgentName,
+			"success": result.Success,
+		}
+		if result.Success {
+			finding["response"] = result.Response
+			succeeded++
+		} else {
+			finding["error"] = result.Error
+		}
+		findings = append(findings, finding)
+	}
+
+	metadata := map[string]interface{}{
+		"agents":      len(results),
+		"succeeded":   succeeded,
+		"tokens_used": TotalUsage(results).TotalTokens(),
+	}
+	if succeeded == 0 {
+		return &core.ToolResult{
+			Success:  false,
+			Error:    "every sub-agent failed: " + failures(results),
+			Metadata: metadata,
+		}
+	}
+	return &core.ToolResult{
+		Success: true,
+		Data: map[string]interface{}{
+			"results":   findings,
+			"succeeded": succeeded,
+			"failed":    len(results) - succeeded,
+		},
+		Metadata: metadata,
+	}
+}
+
+// failures describes the failed results, e.g. "analyst: rate limited".
+func failures(results []*SubAgentResult) string {
+	var parts []string
+	for _, result := range results {
+		if !result.Success {
+			parts = append(parts, result.AgentName+": "+result.Error)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,127 @@
+package subagent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// specialistModel is a mock Claude API that answers each sub-agent with
+// its system prompt, using 10 input and 5 output tokens, and fails those
+// whose prompt mentions failing. It records the most requests in flight.
+type specialistModel struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (m *specialistModel) serve(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.inFlight++
+		m.peak = max(m.peak, m.inFlight)
+		m.mu.Unlock()
+		defer func() {
+			m.mu.Lock()
+			m.inFlight--
+			m.mu.Unlock()
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "always fail") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"invalid_request_error","message":"bad request"}}`)
+			return
+		}
+		prompt := "unknown"
+		if _, rest, ok := strings.Cut(string(body), `"system":[{"text":"`); ok {
+			prompt, _, _ = strings.Cut(rest, `"`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model","content":[{"type":"text","text":%q}],"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":5}}`, prompt)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestRunAll(t *testing.T) {
+	model := &specialistModel{}
+	client := anthropic.NewClient(
+		option.WithAPIKey("test"),
+		option.WithBaseURL(model.serve(t)),
+		option.WithMaxRetries(0),
+	)
+	eng := engine.NewEngine(&client, engine.NewToolRegistry())
+	agent := func(name, prompt string) *SubAgent {
+		return NewSubAgent(eng, SubAgentConfig{Name: name, SystemPrompt: prompt})
+	}
+	agents := []*SubAgent{
+		agent("spending", "spending analyst"),
+		agent("savings", "always fail"),
+		agent("subscriptions", "subscription detective"),
+		agent("cashflow", "cash flow forecaster"),
+		agent("fees", "always fail"),
+		agent("goals", "goal coach"),
+	}
+
+	parentCtx := core.NewContext("user-1", "sess-1", "conv-1", "req-1")
+	results, err := RunAll(context.Background(), parentCtx, "review my finances", agents...)
+	if err != nil {
+		t.Fatalf("RunAll() error = %v, want partial results", err)
+	}
+	if len(results) != len(agents) {
+		t.Fatalf("got %d results, want %d", len(results), len(agents))
+	}
+	for i, result := range results {
+		if result.AgentName != agents[i].Name() {
+			t.Errorf("result %d is from %s, want %s", i, result.AgentName, agents[i].Name())
+		}
+		fail := agents[i].systemPrompt == "always fail"
+		if result.Success == fail || fail && result.Error == "" {
+			t.Errorf("%s: result = %+v, want failed = %v", result.AgentName, result, fail)
+		}
+		if !fail && result.Response != agents[i].systemPrompt {
+			t.Errorf("%s answered %q, want %q", result.AgentName, result.Response, agents[i].systemPrompt)
+		}
+	}
+	if model.peak < 2 || model.peak > MaxParallel {
+		t.Errorf("%d sub-agents ran at once, want 2 to %d", model.peak, MaxParallel)
+	}
+
+	if usage := TotalUsage(results); usage.InputTokens != 40 || usage.OutputTokens != 20 {
+		t.Errorf("TotalUsage() = %+v, want the 4 successful runs' 40 input and 20 output tokens", usage)
+	}
+
+	combined := CombineResults(results)
+	data, _ := combined.Data.(map[string]interface{})
+	if !combined.Success || data["succeeded"] != 4 || data["failed"] != 2 {
+		t.Errorf("CombineResults() = %+v", combined)
+	}
+	if combined.Metadata["tokens_used"] != 60 {
+		t.Errorf("tokens_used = %v, want 60", combined.Metadata["tokens_used"])
+	}
+
+	// With every agent failing, RunAll reports an error alongside the
+	// results and the combined result fails.
+	results, err = RunAll(context.Background(), parentCtx, "review my finances", agents[1], agents[4])
+	if err == nil || len(results) != 2 {
+		t.Fatalf("RunAll() = %d results, %v, want 2 and an error", len(results), err)
+	}
+	if combined := CombineResults(results); combined.Success || !strings.Contains(combined.Error, "savings: ") || !strings.Contains(combined.Error, "fees: ") {
+		t.Errorf("CombineResults() = %+v, want each agent's error", combined)
+	}
+}
@@ -34,7 +34,7 @@ type SubAgentConfig struct {
 	// AvailableTools lists the tool names this sub-agent can use.
 	AvailableTools []string
 
-	// Model is the Claude model to use. Defaults to claude-sonnet-4-20250514.
+	// Model is the Claude model to use. Defaults to the engine's model.
 	Model string
 
 	// MaxTokens is the maximum response tokens per turn. Defaults to 2048.
@@ -47,10 +47,6 @@ type SubAgentConfig struct {
 // NewSubAgent creates a new sub-agent with the given configuration.
 func NewSubAgent(eng *engine.Engine, cfg SubAgentConfig) *SubAgent {
 	// Apply defaults
-	model := cfg.Model
-	if model == "" {
-		model = "claude-sonnet-4-20250514"
-	}
 	maxTokens := cfg.MaxTokens
 	if maxTokens == 0 {
 		maxTokens = 2048
@@ -64,7 +60,7 @@ func NewSubAgent(eng *engine.Engine, cfg SubAgentConfig) *SubAgent {
 		name:           cfg.Name,
 		systemPrompt:   cfg.SystemPrompt,
 		availableTools: cfg.AvailableTools,
-		model:          model,
+		model:          cfg.Model,
 		maxTokens:      maxTokens,
 		maxTurns:       maxTurns,
 		engine:         eng,
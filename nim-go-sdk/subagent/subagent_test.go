@@ -0,0 +1,98 @@
+package subagent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// capturingAudit records audit entries.
+type capturingAudit struct {
+	mu      sync.Mutex
+	entries []*engine.AuditEntry
+}
+
+func (a *capturingAudit) Log(ctx context.Context, entry *engine.AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+// scriptedModel is a mock Claude API answering requests with responses in
+// turn: each is a tool to call, or "" to finish.
+func scriptedModel(t *testing.T, tools ...string) string {
+	t.Helper()
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		i := int(n.Add(1)) - 1
+		if i < len(tools) && tools[i] != "" {
+			fmt.Fprintf(w, `{"id":"msg_%d","type":"message","role":"assistant","model":"test-model","content":[{"type":"tool_use","id":"toolu_%d","name":%q,"input":{"query":"how am I doing?"}}],"stop_reason":"tool_use","usage":{"input_tokens":1,"output_tokens":1}}`, i, i, tools[i])
+			return
+		}
+		fmt.Fprintf(w, `{"id":"msg_%d","type":"message","role":"assistant","model":"test-model","content":[{"type":"text","text":"done"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`, i)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestDelegationTool_InheritsParentContext(t *testing.T) {
+	// The parent delegates, the sub-agent checks the balance and answers,
+	// then the parent answers.
+	client := anthropic.NewClient(
+		option.WithAPIKey("test"),
+		option.WithBaseURL(scriptedModel(t, "delegate_to_analyst", "get_balance", "", "")),
+		option.WithMaxRetries(0),
+	)
+	audit := &capturingAudit{}
+	registry := engine.NewToolRegistry()
+	eng := engine.NewEngine(&client, registry, engine.WithAudit(audit))
+
+	var toolCtx *core.Context
+	registry.Register(core.NewBaseTool(core.ToolDefinition{ToolName: "get_balance"},
+		func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			toolCtx = params.Context
+			return &core.ToolResult{Success: true, Data: "100 USDC"}, nil
+		}))
+	registry.Register(DelegationToolFromAgent(NewSubAgent(eng, SubAgentConfig{
+		Name:           "analyst",
+		AvailableTools: []string{"get_balance"},
+	})))
+
+	parentCtx := core.NewContext("user-1", "sess-1", "conv-1", "req-1")
+	out, err := eng.Run(context.Background(), &engine.Input{UserMessage: "how am I doing?", Context: parentCtx})
+	if err != nil || out.Type != engine.OutputComplete {
+		t.Fatalf("Run() = %+v, %v", out, err)
+	}
+
+	if toolCtx == nil {
+		t.Fatal("sub-agent tool got no context")
+	}
+	if toolCtx.SessionID != "sess-1" || toolCtx.ConversationID != "conv-1" || toolCtx.Preferences != parentCtx.Preferences {
+		t.Errorf("sub-agent context = %+v, want the parent's session, conversation and preferences", toolCtx)
+	}
+	if toolCtx.Limits == nil || toolCtx.Limits.CanConfirm {
+		t.Errorf("sub-agent limits = %+v, want restricted", toolCtx.Limits)
+	}
+
+	if len(audit.entries) != 2 {
+		t.Fatalf("got %d audit entries, want 2", len(audit.entries))
+	}
+	sub, parent := audit.entries[0], audit.entries[1]
+	if parent.ToolName != "delegate_to_analyst" || parent.RequestID != "req-1" || parent.ParentID != nil {
+		t.Errorf("parent entry = %+v", parent)
+	}
+	if sub.ToolName != "get_balance" || sub.AgentName != "analyst" || sub.ParentID == nil || *sub.ParentID != parent.RequestID {
+		t.Errorf("sub-agent entry = %+v (parent %v), want parent %s", sub, sub.ParentID, parent.RequestID)
+	}
+}
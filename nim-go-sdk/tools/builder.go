@@ -15,6 +15,14 @@ type Builder struct {
 	schema               map[string]interface{}
 	requiresConfirmation bool
 	summaryTemplate      string
+	summaryDetails       core.DetailsFunc
+	inverse              core.InverseFunc
+	diffKeys             map[string]string
+	envelope             bool
+	fullResults          bool
+	skipValidation       bool
+	noCache              bool
+	figures              core.FigureFunc
 	handler              core.ToolHandler
 }
 
@@ -50,7 +58,95 @@ func (b *Builder) SummaryTemplate(template string) *Builder {
 	return b
 }
 
+// SummaryDetails sets how to describe the tool's pending actions in
+// machine-readable fields, e.g. an amount and recipient, which clients get
+// with each confirm_request to render. By default the action's input is
+// sent as is; fn returning nil falls back to that too.
+func (b *Builder) SummaryDetails(fn core.DetailsFunc) *Builder {
+	b.summaryDetails = fn
+	return b
+}
+
+// Reversible declares how to undo this write tool. After the tool executes,
+// undo_last_action calls fn with the original input and result to get the
+// inverse action, which is offered to the user as a normal confirmation.
+// fn returns feasible=false when the action can no longer be undone.
+func (b *Builder) Reversible(fn core.InverseFunc) *Builder {
+	b.inverse = fn
+	return b
+}
+
+// Irreversible declares that this write tool can never be undone.
+func (b *Builder) Irreversible() *Builder {
+	b.inverse = core.Irreversible
+	return b
+}
+
+// Differential opts this read tool into differential results. When the engine
+// has a read cache and the tool is called again with the same input in a
+// conversation, the model is sent the changes since the previous call instead
+// of the whole result. keys names the field that identifies the elements of
+// each array, by the array's path, e.g. {"transactions": "id"}, so that
+// reordered elements aren't reported as changes.
+func (b *Builder) Differential(keys map[string]string) *Builder {
+	if keys == nil {
+		keys = map[string]string{}
+	}
+	b.diffKeys = keys
+	return b
+}
+
+// FullResults sends the tool's results to the model in full, however large.
+// By default the engine shortens results over its cap (see
+// engine.WithMaxToolResultBytes); use this only where the model needs every
+// byte, since large results cost input tokens on every later turn.
+func (b *Builder) FullResults() *Builder {
+	b.fullResults = true
+	return b
+}
+
+// SkipValidation stops the engine checking the tool's input against its
+// schema before executing it. By default input missing a required field, of
+// the wrong type or outside an enum is returned to the model as an error
+// without calling the handler; use this for tools whose schema is only a
+// hint, or that validate their input themselves.
+func (b *Builder) SkipValidation() *Builder {
+	b.skipValidation = true
+	return b
+}
+
+// NoCache stops the engine's tool cache reusing this read tool's results
+// within a run or across runs (see engine.WithRunCache). Use it for tools
+// that change state without asking for confirmation, such as saving a
+// preference, or whose results must always be fresh.
+func (b *Builder) NoCache() *Builder {
+	b.noCache = true
+	return b
+}
+
+// ReturnsEnvelope wraps the tool's successful results in a core.Envelope,
+// so servers and clients get a machine-readable status, warnings and
+// figures. Handlers can also return a core.Envelope themselves to add
+// warnings or figures; it is passed through as is.
+func (b *Builder) ReturnsEnvelope() *Builder {
+	b.envelope = true
+	return b
+}
+
+// Figures sets how to extract the monetary figures from the tool's results
+// into their envelope. Implies ReturnsEnvelope.
+func (b *Builder) Figures(fn core.FigureFunc) *Builder {
+	b.envelope = true
+	b.figures = fn
+	return b
+}
+
 // Handler sets the execution handler for the tool.
+//
+// The ctx passed to a handler carries the caller's identity, request ID, and
+// deadline (see core.IdentityFromContext). Pass it unchanged to any executor or
+// outbound call so that work is cancelled with the originating request; never
+// replace it with context.Background().
 func (b *Builder) Handler(h core.ToolHandler) *Builder {
 	b.handler = h
 	return b
@@ -75,7 +171,15 @@ func (b *Builder) Build() core.Tool {
 		ToolDescription:          b.description,
 		RequiresUserConfirmation: b.requiresConfirmation,
 		SummaryTemplate:          b.summaryTemplate,
+		SummaryDetails:           b.summaryDetails,
 		InputSchema:              b.schema,
+		Inverse:                  b.inverse,
+		DiffKeys:                 b.diffKeys,
+		FullResults:              b.fullResults,
+		SkipValidation:           b.skipValidation,
+		NoCache:                  b.noCache,
+		Envelope:                 b.envelope,
+		Figures:                  b.figures,
 	}, b.handler)
 }
 
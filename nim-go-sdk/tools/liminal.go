@@ -1,17 +1,26 @@
 package tools
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
 )
 
 // LiminalToolDefinitions returns the definitions for all Liminal tools.
-// These are the standard tools available through the Liminal API.
+// These are the standard tools available through the Liminal API. Their
+// results are wrapped in a core.Envelope. Each write tool that moves money
+// has a read-only preview, e.g. preview_send_money, which dry runs it.
 func LiminalToolDefinitions() []core.ToolDefinition {
-	return []core.ToolDefinition{
+	definitions := []core.ToolDefinition{
 		// Read operations
 		{
 			ToolName:        "get_balance",
 			ToolDescription: "Get the user's wallet balance.",
+			DiffKeys:        map[string]string{"balances": "currency"},
+			Figures:         balanceFigures,
+			Envelope:        true,
 			InputSchema: ObjectSchema(map[string]interface{}{
 				"currency": StringProperty("Optional: filter by currency (e.g., 'USD', 'EUR', 'LIL')"),
 			}),
@@ -19,42 +28,81 @@ func LiminalToolDefinitions() []core.ToolDefinition {
 		{
 			ToolName:        "get_savings_balance",
 			ToolDescription: "Get the user's savings positions and current APY.",
+			Figures:         savingsFigures,
+			Envelope:        true,
 			InputSchema: ObjectSchema(map[string]interface{}{
 				"vault": StringProperty("Optional: filter by vault name"),
 			}),
 		},
+		{
+			ToolName:        "get_savings_history",
+			ToolDescription: "Get the user's daily savings balance and interest earned over recent days. Use this to answer how much interest the user earned in a period.",
+			Figures:         savingsHistoryFigures,
+			Envelope:        true,
+			InputSchema: ObjectSchema(map[string]interface{}{
+				"currency": StringProperty("Optional: filter by currency (e.g., 'USD', 'EUR', 'LIL')"),
+				"days":     IntegerProperty(fmt.Sprintf("Number of days of history, ending today (default: 30, max: %d)", executor.MaxSavingsHistoryDays)),
+			}),
+		},
 		{
 			ToolName:        "get_vault_rates",
 			ToolDescription: "Get current APY rates for available savings vaults.",
+			Envelope:        true,
 			InputSchema:     ObjectSchema(map[string]interface{}{}),
 		},
 		{
 			ToolName:        "get_transactions",
-			ToolDescription: "Get the user's recent transaction history.",
-			InputSchema: ObjectSchema(map[string]interface{}{
+			ToolDescription: "Get the user's recent transaction history, optionally only between two dates.",
+			DiffKeys:        map[string]string{"transactions": "id"},
+			Envelope:        true,
+			InputSchema: ObjectSchema(DateRangeProperties(map[string]interface{}{
 				"limit": IntegerProperty("Number of transactions to return (default: 10)"),
 				"type":  StringEnumProperty("Filter by transaction type", "send", "receive", "deposit", "withdraw"),
+			})),
+		},
+		{
+			ToolName:        "get_spending_summary",
+			ToolDescription: "Get the user's money in and out over recent days, totalled in USD by day, week, or category (transaction type). Prefer this to get_transactions for questions about spending totals or trends.",
+			Figures:         spendingSummaryFigures,
+			Envelope:        true,
+			InputSchema: ObjectSchema(map[string]interface{}{
+				"days":     IntegerProperty(fmt.Sprintf("Number of days to cover, ending today (default: 30, max: %d)", executor.MaxSpendingSummaryDays)),
+				"group_by": StringEnumProperty("How to group the totals (default: day)", executor.GroupByDay, executor.GroupByWeek, executor.GroupByCategory),
 			}),
 		},
 		{
 			ToolName:        "get_profile",
 			ToolDescription: "Get the user's profile information.",
+			Envelope:        true,
 			InputSchema:     ObjectSchema(map[string]interface{}{}),
 		},
 		{
 			ToolName:        "search_users",
 			ToolDescription: "Search for users by display tag or name.",
+			Envelope:        true,
 			InputSchema: ObjectSchema(map[string]interface{}{
 				"query": StringProperty("Search query (display tag like @alice or name)"),
 			}, "query"),
 		},
+		{
+			ToolName:        "get_payment_requests",
+			ToolDescription: "List the user's pending payment requests: incoming ones other users asked them to pay, and outgoing ones they sent with request_money.",
+			DiffKeys:        map[string]string{"requests": "id"},
+			Envelope:        true,
+			InputSchema: ObjectSchema(map[string]interface{}{
+				"direction": StringEnumProperty("Optional: only incoming or only outgoing requests (default: both)", executor.RequestIncoming, executor.RequestOutgoing),
+			}),
+		},
 
 		// Write operations (require confirmation)
 		{
 			ToolName:                 "send_money",
 			ToolDescription:          "Send money to another user. Requires confirmation.",
 			RequiresUserConfirmation: true,
-			SummaryTemplate:          "Send {{.amount}} {{.currency}} to {{.recipient}}",
+			SummaryTemplate:          "Send {{currency .amount .currency}} to {{.recipient}}",
+			SummaryDetails:           writeDetails("send_money"),
+			Inverse:                  core.Irreversible,
+			Envelope:                 true,
 			InputSchema: ObjectSchema(map[string]interface{}{
 				"recipient": StringProperty("Recipient's display tag (e.g., @alice) or user ID"),
 				"amount":    StringProperty("Amount to send (e.g., '50.00')"),
@@ -62,11 +110,28 @@ func LiminalToolDefinitions() []core.ToolDefinition {
 				"note":      StringProperty("Optional payment note"),
 			}, "recipient", "amount", "currency"),
 		},
+		{
+			ToolName:                 "request_money",
+			ToolDescription:          "Ask another user to pay the user. The recipient is sent a request they can pay or decline; no money moves until they pay. Requires confirmation.",
+			RequiresUserConfirmation: true,
+			SummaryTemplate:          "Request {{currency .amount .currency}} from {{.recipient}}",
+			SummaryDetails:           writeDetails("request_money"),
+			Envelope:                 true,
+			InputSchema: ObjectSchema(map[string]interface{}{
+				"recipient": StringProperty("Display tag of the user to request from (e.g., @bob)"),
+				"amount":    StringProperty("Amount to request (e.g., '20.00')"),
+				"currency":  StringProperty("Currency to request (e.g., 'USD', 'EUR', 'LIL')"),
+				"note":      StringProperty("Optional note explaining the request"),
+			}, "recipient", "amount", "currency"),
+		},
 		{
 			ToolName:                 "deposit_savings",
 			ToolDescription:          "Deposit funds into savings. Requires confirmation.",
 			RequiresUserConfirmation: true,
-			SummaryTemplate:          "Deposit {{.amount}} {{.currency}} into savings",
+			SummaryTemplate:          "Deposit {{currency .amount .currency}} into savings",
+			SummaryDetails:           writeDetails("deposit_savings"),
+			Inverse:                  sameAmount("withdraw_savings"),
+			Envelope:                 true,
 			InputSchema: ObjectSchema(map[string]interface{}{
 				"amount":   StringProperty("Amount to deposit"),
 				"currency": StringProperty("Currency to deposit (e.g., 'USD', 'EUR', 'LIL')"),
@@ -76,13 +141,38 @@ func LiminalToolDefinitions() []core.ToolDefinition {
 			ToolName:                 "withdraw_savings",
 			ToolDescription:          "Withdraw funds from savings. Requires confirmation.",
 			RequiresUserConfirmation: true,
-			SummaryTemplate:          "Withdraw {{.amount}} {{.currency}} from savings",
+			SummaryTemplate:          "Withdraw {{currency .amount .currency}} from savings",
+			SummaryDetails:           writeDetails("withdraw_savings"),
+			Inverse:                  sameAmount("deposit_savings"),
+			Envelope:                 true,
 			InputSchema: ObjectSchema(map[string]interface{}{
 				"amount":   StringProperty("Amount to withdraw"),
 				"currency": StringProperty("Currency to withdraw (e.g., 'USD', 'EUR', 'LIL')"),
 			}, "amount", "currency"),
 		},
 	}
+
+	// Previews of the write operations (no confirmation). Requests move no
+	// money, so there's no fee or arrival to preview.
+	for _, def := range definitions {
+		if def.RequiresUserConfirmation && def.ToolName != "request_money" {
+			definitions = append(definitions, preview(def))
+		}
+	}
+	return definitions
+}
+
+// preview defines the read-only tool that dry runs a write tool, taking the
+// same input.
+func preview(write core.ToolDefinition) core.ToolDefinition {
+	return core.ToolDefinition{
+		ToolName:        "preview_" + write.ToolName,
+		ToolDescription: fmt.Sprintf("Preview %s without doing it: checks the same input would go through and reports the fee and estimated arrival. Nothing is moved and no confirmation is needed. Use it when the user asks what it would cost.", write.ToolName),
+		Previews:        write.ToolName,
+		Figures:         previewFigures,
+		Envelope:        true,
+		InputSchema:     write.InputSchema,
+	}
 }
 
 // LiminalTools creates Tool instances for all Liminal tools using the given executor.
@@ -94,3 +184,119 @@ func LiminalTools(executor core.ToolExecutor) []core.Tool {
 	}
 	return tools
 }
+
+// balanceFigures extracts each wallet balance, and the USD total, from a
+// get_balance result.
+func balanceFigures(data json.RawMessage) []core.Figure {
+	var resp executor.GetBalanceResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil
+	}
+	var figures []core.Figure
+	for _, b := range resp.Balances {
+		figures = append(figures, core.Figure{Name: "balance", Amount: b.Amount, Currency: b.Currency})
+	}
+	if resp.TotalUSD != "" {
+		figures = append(figures, core.Figure{Name: "total", Amount: resp.TotalUSD, Currency: "USD"})
+	}
+	return figures
+}
+
+// savingsFigures extracts each position's value and earnings, and the USD
+// total, from a get_savings_balance result.
+func savingsFigures(data json.RawMessage) []core.Figure {
+	var resp executor.GetSavingsBalanceResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil
+	}
+	var figures []core.Figure
+	for _, p := range resp.Positions {
+		figures = append(figures, core.Figure{Name: "savings", Amount: p.CurrentValue, Currency: p.Currency})
+		if p.Earnings != "" {
+			figures = append(figures, core.Figure{Name: "earnings", Amount: p.Earnings, Currency: p.Currency})
+		}
+	}
+	if resp.TotalUSD != "" {
+		figures = append(figures, core.Figure{Name: "total", Amount: resp.TotalUSD, Currency: "USD"})
+	}
+	return figures
+}
+
+// savingsHistoryFigures extracts each currency's latest balance, and the
+// USD earnings over the period, from a get_savings_history result.
+func savingsHistoryFigures(data json.RawMessage) []core.Figure {
+	var resp executor.GetSavingsHistoryResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil
+	}
+	latest := make(map[string]executor.SavingsHistoryPoint)
+	var currencies []string
+	for _, p := range resp.Points {
+		prev, seen := latest[p.Currency]
+		if !seen {
+			currencies = append(currencies, p.Currency)
+		}
+		if !seen || p.Date >= prev.Date {
+			latest[p.Currency] = p
+		}
+	}
+	var figures []core.Figure
+	for _, currency := range currencies {
+		figures = append(figures, core.Figure{Name: "savings", Amount: latest[currency].Balance, Currency: currency})
+	}
+	if resp.TotalEarningsUSD != "" {
+		figures = append(figures, core.Figure{Name: "earnings", Amount: resp.TotalEarningsUSD, Currency: "USD"})
+	}
+	return figures
+}
+
+// spendingSummaryFigures extracts the USD totals in and out from a
+// get_spending_summary result.
+func spendingSummaryFigures(data json.RawMessage) []core.Figure {
+	var resp executor.GetSpendingSummaryResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil
+	}
+	var figures []core.Figure
+	if resp.TotalOutUSD != "" {
+		figures = append(figures, core.Figure{Name: "spent", Amount: resp.TotalOutUSD, Currency: "USD"})
+	}
+	if resp.TotalInUSD != "" {
+		figures = append(figures, core.Figure{Name: "received", Amount: resp.TotalInUSD, Currency: "USD"})
+	}
+	return figures
+}
+
+// previewFigures extracts the fee from a preview's dry run result. Every
+// write's response has the same dry run fields.
+func previewFigures(data json.RawMessage) []core.Figure {
+	var resp executor.SendMoneyResponse
+	if err := json.Unmarshal(data, &resp); err != nil || resp.Fee == "" {
+		return nil
+	}
+	return []core.Figure{{Name: "fee", Amount: resp.Fee, Currency: resp.FeeCurrency}}
+}
+
+// writeDetails returns the SummaryDetails of a write tool, the same details
+// GRPCExecutor gives its confirmations.
+func writeDetails(tool string) core.DetailsFunc {
+	return func(input json.RawMessage) core.Details {
+		return executor.WriteDetails(tool, input)
+	}
+}
+
+// sameAmount returns an InverseFunc that moves the same amount and currency
+// with the given tool, e.g. a deposit is undone by withdrawing it again.
+func sameAmount(inverseTool string) core.InverseFunc {
+	return func(input json.RawMessage, result *core.ToolResult) (string, json.RawMessage, bool) {
+		var in struct {
+			Amount   string `json:"amount"`
+			Currency string `json:"currency"`
+		}
+		if err := json.Unmarshal(input, &in); err != nil || in.Amount == "" || in.Currency == "" {
+			return "", nil, false
+		}
+		inverse, _ := json.Marshal(map[string]string{"amount": in.Amount, "currency": in.Currency})
+		return inverseTool, inverse, true
+	}
+}
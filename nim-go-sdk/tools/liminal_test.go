@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// writeRecorder is a Liminal executor that records the writes it's asked
+// for.
+type writeRecorder struct {
+	writes []core.ExecuteRequest
+	reads  int
+}
+
+func (w *writeRecorder) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	w.reads++
+	return &core.ExecuteResponse{Success: true, Data: json.RawMessage(`{}`)}, nil
+}
+
+func (w *writeRecorder) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	w.writes = append(w.writes, *req)
+	if req.DryRun {
+		return &core.ExecuteResponse{Success: true, Data: json.RawMessage(`{"success":true,"dryRun":true,"fee":"0.50","feeCurrency":"USD"}`)}, nil
+	}
+	return &core.ExecuteResponse{Success: true, RequiresConfirmation: true}, nil
+}
+
+func (w *writeRecorder) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	return &core.ExecuteResponse{Success: true}, nil
+}
+
+func (w *writeRecorder) Cancel(ctx context.Context, userID, confirmationID string) error {
+	return nil
+}
+
+func TestLiminalTools_Previews(t *testing.T) {
+	exec := &writeRecorder{}
+	byName := make(map[string]core.Tool)
+	for _, tool := range LiminalTools(exec) {
+		byName[tool.Name()] = tool
+	}
+
+	for write, previewName := range map[string]string{
+		"send_money":       "preview_send_money",
+		"deposit_savings":  "preview_deposit_savings",
+		"withdraw_savings": "preview_withdraw_savings",
+	} {
+		t.Run(previewName, func(t *testing.T) {
+			preview, ok := byName[previewName]
+			if !ok {
+				t.Fatalf("LiminalTools() has no %s", previewName)
+			}
+			if preview.RequiresConfirmation() {
+				t.Errorf("%s requires confirmation", previewName)
+			}
+			exec.writes = nil
+
+			result, err := preview.Execute(context.Background(), &core.ToolParams{
+				UserID: "user-1",
+				Input:  json.RawMessage(`{"amount":"500","currency":"USD","recipient":"@bob"}`),
+			})
+			if err != nil || !result.Success {
+				t.Fatalf("Execute() = %+v, %v", result, err)
+			}
+			if len(exec.writes) != 1 || exec.writes[0].Tool != write || !exec.writes[0].DryRun {
+				t.Errorf("%s sent writes %+v, want one dry run of %s", previewName, exec.writes, write)
+			}
+			envelope := result.Data.(*core.Envelope)
+			if len(envelope.Figures) != 1 || envelope.Figures[0].Name != "fee" || envelope.Figures[0].Amount != "0.50" {
+				t.Errorf("figures = %+v, want the fee", envelope.Figures)
+			}
+		})
+	}
+	if exec.reads != 0 {
+		t.Errorf("previews made %d reads, want none", exec.reads)
+	}
+	if _, ok := byName["preview_request_money"]; ok {
+		t.Error("request_money moves no money but has a preview")
+	}
+}
+
+func TestLiminalTools_SummaryDetails(t *testing.T) {
+	byName := make(map[string]core.Tool)
+	for _, tool := range LiminalTools(&writeRecorder{}) {
+		byName[tool.Name()] = tool
+	}
+
+	tests := []struct {
+		tool        string
+		input       string
+		wantSummary string
+		wantLocal   string // in de-DE
+		want        core.Details
+	}{
+		{
+			tool:        "send_money",
+			input:       `{"recipient":"@alice","amount":"50","currency":"USD"}`,
+			wantSummary: "Send 50.00 USD to @alice",
+			wantLocal:   "Send 50,00 $ to @alice",
+			want:        core.Details{"amount": "50", "currency": "USD", "recipient": "@alice", "direction": "outgoing"},
+		},
+		{
+			tool:        "deposit_savings",
+			input:       `{"amount":"1234.5","currency":"EUR"}`,
+			wantSummary: "Deposit 1234.50 EUR into savings",
+			wantLocal:   "Deposit 1.234,50 € into savings",
+			want:        core.Details{"amount": "1234.5", "currency": "EUR", "direction": "to_savings"},
+		},
+		{
+			tool:        "withdraw_savings",
+			input:       `{"amount":"20","currency":"USDC"}`,
+			wantSummary: "Withdraw 20.00 USDC from savings",
+			wantLocal:   "Withdraw 20,00 USDC from savings",
+			want:        core.Details{"amount": "20", "currency": "USDC", "direction": "from_savings"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			tool := byName[tt.tool]
+			if got := tool.GetSummary(json.RawMessage(tt.input)); got != tt.wantSummary {
+				t.Errorf("summary = %q, want %q", got, tt.wantSummary)
+			}
+			if got := core.SummaryOf(tool, json.RawMessage(tt.input), "de-DE"); got != tt.wantLocal {
+				t.Errorf("de-DE summary = %q, want %q", got, tt.wantLocal)
+			}
+			if got := core.SummaryDetailsOf(tool, json.RawMessage(tt.input)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("details = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
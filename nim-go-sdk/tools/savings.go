@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/money"
+)
+
+// ProjectSavingsToolName is the name of the savings projection tool.
+const ProjectSavingsToolName = "project_savings"
+
+// Compounding frequencies for a SavingsPlan.
+const (
+	CompoundMonthly = "monthly"
+	CompoundDaily   = "daily"
+)
+
+// Bounds on a SavingsPlan, so a projection stays small and exact.
+const (
+	maxProjectionMonths = 600          // 50 years
+	maxProjectionCents  = int64(1e15)  // balances stay exact in float64
+	maxProjectionAPY    = float64(100) // percent
+)
+
+// SavingsPlan describes savings to project with ProjectSavings. Amounts
+// are in cents.
+type SavingsPlan struct {
+	Principal           int64   // deposited before the first month
+	MonthlyContribution int64   // deposited at the start of every month
+	APY                 float64 // annual rate in percent, e.g. 4.5
+	Months              int
+	Compounding         string // CompoundMonthly (the default) or CompoundDaily
+}
+
+// SavingsMonth is one month of a projection. Amounts are in cents.
+type SavingsMonth struct {
+	Month        int
+	Contribution int64
+	Interest     int64
+	Balance      int64 // at the end of the month
+}
+
+// SavingsProjection is the month-by-month growth of a SavingsPlan.
+type SavingsProjection struct {
+	Plan               SavingsPlan
+	Schedule           []SavingsMonth
+	TotalContributions int64 // the principal and every monthly contribution
+	TotalInterest      int64
+	EndingBalance      int64
+}
+
+// ProjectSavings projects plan month by month. Balances are kept in whole
+// cents: each month's contribution is added, then its interest is
+// calculated on the balance and rounded to the cent. APY is treated as a
+// nominal annual rate, compounded monthly (APY/12 a month) or daily
+// (APY/365 a day, for 365/12 days a month).
+func ProjectSavings(plan SavingsPlan) (*SavingsProjection, error) {
+	if plan.Compounding == "" {
+		plan.Compounding = CompoundMonthly
+	}
+	switch {
+	case plan.Principal < 0 || plan.MonthlyContribution < 0:
+		return nil, fmt.Errorf("amounts must not be negative")
+	case plan.APY < 0 || plan.APY > maxProjectionAPY || math.IsNaN(plan.APY):
+		return nil, fmt.Errorf("apy must be between 0 and %g", maxProjectionAPY)
+	case plan.Months < 1 || plan.Months > maxProjectionMonths:
+		return nil, fmt.Errorf("months must be between 1 and %d", maxProjectionMonths)
+	case plan.Compounding != CompoundMonthly && plan.Compounding != CompoundDaily:
+		return nil, fmt.Errorf("compounding must be %s or %s", CompoundMonthly, CompoundDaily)
+	}
+
+	// growth is the interest a cent earns in a month.
+	rate := plan.APY / 100
+	growth := rate / 12
+	if plan.Compounding == CompoundDaily {
+		growth = math.Expm1(365.0 / 12 * math.Log1p(rate/365))
+	}
+
+	p := &SavingsProjection{
+		Plan:               plan,
+		Schedule:           make([]SavingsMonth, 0, plan.Months),
+		TotalContributions: plan.Principal,
+	}
+	balance := plan.Principal
+	for month := 1; month <= plan.Months; month++ {
+		balance += plan.MonthlyContribution
+		interest := int64(math.Round(float64(balance) * growth))
+		balance += interest
+		if balance > maxProjectionCents {
+			return nil, fmt.Errorf("the balance grows too large to project")
+		}
+		p.TotalContributions += plan.MonthlyContribution
+		p.TotalInterest += interest
+		p.Schedule = append(p.Schedule, SavingsMonth{
+			Month:        month,
+			Contribution: plan.MonthlyContribution,
+			Interest:     interest,
+			Balance:      balance,
+		})
+	}
+	p.EndingBalance = balance
+	return p, nil
+}
+
+// Balances returns the balance at the start, then at the end of each month,
+// in currency units, for charting.
+func (p *SavingsProjection) Balances() []float64 {
+	balances := make([]float64, 0, len(p.Schedule)+1)
+	balances = append(balances, float64(p.Plan.Principal)/100)
+	for _, m := range p.Schedule {
+		balances = append(balances, float64(m.Balance)/100)
+	}
+	return balances
+}
+
+// Cents converts an amount in currency units to whole cents.
+func Cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// FormatCents formats cents as a decimal amount such as "12.50".
+func FormatCents(c int64) string {
+	return money.Amount{Minor: c}.Decimal()
+}
+
+// parseCents parses a decimal amount such as "12.5" into whole cents,
+// rounding fractions of a cent half away from zero.
+func parseCents(s string) (int64, error) {
+	amount, err := money.ParseAmount(s, "")
+	if err != nil {
+		return 0, err
+	}
+	if amount.Minor > maxProjectionCents || amount.Minor < -maxProjectionCents {
+		return 0, fmt.Errorf("%q is too large", s)
+	}
+	return amount.Minor, nil
+}
+
+// projectSavingsInput is the input of project_savings.
+type projectSavingsInput struct {
+	Principal           string  `json:"principal" description:"Starting amount, e.g. \"1000.00\"" required:"true"`
+	MonthlyContribution string  `json:"monthly_contribution" description:"Amount added at the start of each month, e.g. \"50.00\"" default:"0"`
+	APY                 float64 `json:"apy" description:"Annual interest rate in percent, e.g. 4.5 (see get_vault_rates)" required:"true" minimum:"0"`
+	Months              int     `json:"months" description:"Number of months to project, up to 600" required:"true" minimum:"1"`
+	Compounding         string  `json:"compounding" description:"How often interest compounds" default:"monthly" enum:"monthly,daily"`
+}
+
+// ProjectSavingsTool returns the project_savings tool, which projects the
+// growth of savings with compound interest month by month. It only
+// calculates, so it needs no confirmation or executor.
+func ProjectSavingsTool() core.Tool {
+	return New(ProjectSavingsToolName).
+		Description("Project how savings grow with compound interest: a starting amount plus optional monthly contributions at an annual rate. Returns a month-by-month schedule of contributions, interest and balance, with totals. Use it for any 'how much will I have' or 'how much interest would I earn' question instead of calculating yourself.").
+		Schema(SchemaFor[projectSavingsInput]()).
+		Handler(TypedHandler(func(ctx context.Context, params *core.ToolParams, input projectSavingsInput) (*core.ToolResult, error) {
+			principal, err := parseCents(input.Principal)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid principal: %v", err)}, nil
+			}
+			contribution, err := parseCents(input.MonthlyContribution)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid monthly_contribution: %v", err)}, nil
+			}
+			p, err := ProjectSavings(SavingsPlan{
+				Principal:           principal,
+				MonthlyContribution: contribution,
+				APY:                 input.APY,
+				Months:              input.Months,
+				Compounding:         input.Compounding,
+			})
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			schedule := make([]map[string]interface{}, len(p.Schedule))
+			for i, m := range p.Schedule {
+				schedule[i] = map[string]interface{}{
+					"month":          m.Month,
+					"contribution":   FormatCents(m.Contribution),
+					"interest":       FormatCents(m.Interest),
+					"ending_balance": FormatCents(m.Balance),
+				}
+			}
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{
+				"principal":           FormatCents(principal),
+				"apy":                 input.APY,
+				"compounding":         p.Plan.Compounding,
+				"months":              input.Months,
+				"schedule":            schedule,
+				"total_contributions": FormatCents(p.TotalContributions),
+				"total_interest":      FormatCents(p.TotalInterest),
+				"ending_balance":      FormatCents(p.EndingBalance),
+			}}, nil
+		})).
+		Build()
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// randomPlans returns n plans with random amounts, rates and terms, the
+// same ones every run.
+func randomPlans(n int) []SavingsPlan {
+	r := rand.New(rand.NewSource(1))
+	plans := make([]SavingsPlan, n)
+	for i := range plans {
+		plans[i] = SavingsPlan{
+			Principal:           r.Int63n(10_000_000), // up to 100,000.00
+			MonthlyContribution: r.Int63n(500_000),
+			APY:                 float64(r.Intn(2000)) / 100, // up to 20%
+			Months:              1 + r.Intn(360),
+		}
+	}
+	return plans
+}
+
+func TestProjectSavings_ZeroAPY(t *testing.T) {
+	for _, plan := range randomPlans(200) {
+		plan.APY = 0
+		for _, compounding := range []string{CompoundMonthly, CompoundDaily} {
+			plan.Compounding = compounding
+			p, err := ProjectSavings(plan)
+			if err != nil {
+				t.Fatalf("ProjectSavings(%+v) error = %v", plan, err)
+			}
+			want := plan.Principal + plan.MonthlyContribution*int64(plan.Months)
+			if p.EndingBalance != want || p.TotalContributions != want || p.TotalInterest != 0 {
+				t.Errorf("ProjectSavings(%+v) = balance %d, contributions %d, interest %d, want %d with no interest",
+					plan, p.EndingBalance, p.TotalContributions, p.TotalInterest, want)
+			}
+		}
+	}
+}
+
+func TestProjectSavings_DailyEarnsAtLeastMonthly(t *testing.T) {
+	for _, plan := range randomPlans(200) {
+		plan.Compounding = CompoundMonthly
+		monthly, err := ProjectSavings(plan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan.Compounding = CompoundDaily
+		daily, err := ProjectSavings(plan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range daily.Schedule {
+			if daily.Schedule[i].Balance < monthly.Schedule[i].Balance {
+				t.Fatalf("%+v: month %d daily balance %d < monthly %d", plan, i+1, daily.Schedule[i].Balance, monthly.Schedule[i].Balance)
+			}
+		}
+	}
+}
+
+func TestProjectSavings_NeverNegative(t *testing.T) {
+	for _, plan := range randomPlans(200) {
+		p, err := ProjectSavings(plan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		previous := plan.Principal
+		var interest int64
+		for _, m := range p.Schedule {
+			if m.Contribution < 0 || m.Interest < 0 || m.Balance < previous {
+				t.Fatalf("%+v: month %+v is negative or shrinks the balance from %d", plan, m, previous)
+			}
+			if m.Balance != previous+m.Contribution+m.Interest {
+				t.Fatalf("%+v: month %+v doesn't add up from %d", plan, m, previous)
+			}
+			previous = m.Balance
+			interest += m.Interest
+		}
+		if p.EndingBalance != previous || p.TotalInterest != interest || p.EndingBalance != p.TotalContributions+p.TotalInterest {
+			t.Errorf("%+v: totals %+v don't match the schedule", plan, p)
+		}
+	}
+
+	invalid := []SavingsPlan{
+		{Principal: -1, APY: 5, Months: 12},
+		{MonthlyContribution: -1, APY: 5, Months: 12},
+		{Principal: 100, APY: -1, Months: 12},
+		{Principal: 100, APY: 5, Months: 0},
+		{Principal: 100, APY: 5, Months: 12, Compounding: "hourly"},
+	}
+	for _, plan := range invalid {
+		if _, err := ProjectSavings(plan); err == nil {
+			t.Errorf("ProjectSavings(%+v) succeeded, want an error", plan)
+		}
+	}
+}
+
+func TestProjectSavingsTool(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			// 1000 * 1.01^12 is 1126.825; interest is credited to the
+			// cent each month.
+			name:  "a year at 12% compounded monthly",
+			input: `{"principal":"1000","apy":12,"months":12}`,
+			want: map[string]interface{}{
+				"compounding":         "monthly",
+				"total_contributions": "1000.00",
+				"total_interest":      "126.84",
+				"ending_balance":      "1126.84",
+			},
+		},
+		{
+			name:  "contributions compounded daily",
+			input: `{"principal":"0.10","monthly_contribution":"100","apy":0,"months":3,"compounding":"daily"}`,
+			want: map[string]interface{}{
+				"compounding":         "daily",
+				"total_contributions": "300.10",
+				"total_interest":      "0.00",
+				"ending_balance":      "300.10",
+			},
+		},
+		{name: "bad amount", input: `{"principal":"lots","apy":5,"months":12}`, wantErr: `invalid principal: invalid amount: "lots"`},
+		{name: "too long", input: `{"principal":"100","apy":5,"months":601}`, wantErr: "months must be between 1 and 600"},
+		{name: "missing rate", input: `{"principal":"100","months":12}`, wantErr: "invalid input: missing required field 'apy' (expected number)"},
+	}
+	tool := ProjectSavingsTool()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tool.Execute(context.Background(), &core.ToolParams{Input: json.RawMessage(tt.input)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" {
+				if result.Success || result.Error != tt.wantErr {
+					t.Errorf("result = %+v, want error %q", result, tt.wantErr)
+				}
+				return
+			}
+			data, _ := result.Data.(map[string]interface{})
+			if !result.Success {
+				t.Fatalf("result = %+v", result)
+			}
+			for key, want := range tt.want {
+				if data[key] != want {
+					t.Errorf("%s = %v, want %v", key, data[key], want)
+				}
+			}
+		})
+	}
+}
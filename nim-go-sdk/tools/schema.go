@@ -31,6 +31,24 @@ func StringEnumProperty(description string, values ...string) map[string]interfa
 	}
 }
 
+// DateProperty creates a string property holding a YYYY-MM-DD date.
+func DateProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"format":      "date",
+		"description": description,
+	}
+}
+
+// DateRangeProperties adds optional start_date and end_date properties,
+// both inclusive, to properties and returns it. get_transactions uses them;
+// custom tools taking a date range should too.
+func DateRangeProperties(properties map[string]interface{}) map[string]interface{} {
+	properties["start_date"] = DateProperty("Optional: earliest date to include (YYYY-MM-DD)")
+	properties["end_date"] = DateProperty("Optional: latest date to include (YYYY-MM-DD)")
+	return properties
+}
+
 // NumberProperty creates a number property with optional description.
 func NumberProperty(description string) map[string]interface{} {
 	return map[string]interface{}{
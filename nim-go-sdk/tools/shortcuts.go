@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// ShortcutTools returns the tools for recipient shortcuts, kept in prefs:
+// remember_shortcut and list_shortcuts. exec looks up the users shortcuts
+// point to.
+func ShortcutTools(prefs store.Preferences, exec core.ToolExecutor) []core.Tool {
+	return []core.Tool{RememberShortcutTool(prefs, exec), ListShortcutsTool(prefs)}
+}
+
+// Shortcut is a nickname for a recipient.
+type Shortcut struct {
+	Nickname string `json:"nickname"`
+	UserID   string `json:"user_id"`
+}
+
+// RememberShortcutTool returns the remember_shortcut tool, which saves a
+// nickname such as "mom" for a user found with search_users, so the user
+// can send money by nickname in later conversations. It requires
+// confirmation.
+func RememberShortcutTool(prefs store.Preferences, exec core.ToolExecutor) core.Tool {
+	return New("remember_shortcut").
+		Description("Remember a nickname for a recipient, such as 'mom' for @jane, so the user can send money to them by nickname from now on. Find the recipient with search_users first. Requires confirmation.").
+		Schema(ObjectSchema(map[string]interface{}{
+			"nickname":  StringProperty("The nickname, e.g. 'mom' or 'landlord'"),
+			"recipient": StringProperty("The recipient's display tag (e.g., @jane) or user ID, as returned by search_users"),
+		}, "nickname", "recipient")).
+		RequiresConfirmation().
+		SummaryTemplate("Remember {{.nickname}} as {{.recipient}}").
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				Nickname  string `json:"nickname"`
+				Recipient string `json:"recipient"`
+			}
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+			}
+			nickname := core.ShortcutKey(input.Nickname)
+			if nickname == "" || strings.HasPrefix(nickname, "@") {
+				return &core.ToolResult{Success: false, Error: "the nickname must not be empty or start with @: display tags always mean the user with that tag"}, nil
+			}
+
+			user, err := findUser(ctx, exec, params, input.Recipient)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			p, err := prefs.Get(ctx, params.UserID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load preferences: %w", err)
+			}
+			if p == nil {
+				p = core.DefaultPreferences()
+			}
+			if p.Shortcuts == nil {
+				p.Shortcuts = make(map[string]string)
+			}
+			previous := p.Shortcuts[nickname]
+			p.Shortcuts[nickname] = user.UserID
+			if err := prefs.Set(ctx, params.UserID, p); err != nil {
+				return nil, fmt.Errorf("failed to save preferences: %w", err)
+			}
+
+			data := map[string]interface{}{
+				"nickname":    nickname,
+				"user_id":     user.UserID,
+				"display_tag": user.DisplayTag,
+			}
+			if previous != "" && previous != user.UserID {
+				data["replaced_user_id"] = previous
+			}
+			return &core.ToolResult{Success: true, Data: data}, nil
+		}).
+		Build()
+}
+
+// ListShortcutsTool returns the list_shortcuts tool, which lists the
+// nicknames the user has saved with remember_shortcut.
+func ListShortcutsTool(prefs store.Preferences) core.Tool {
+	return New("list_shortcuts").
+		Description("List the recipient nicknames the user has saved, such as 'mom', and the users they stand for.").
+		Schema(ObjectSchema(map[string]interface{}{})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			p, err := prefs.Get(ctx, params.UserID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load preferences: %w", err)
+			}
+			shortcuts := []Shortcut{}
+			if p != nil {
+				for nickname, userID := range p.Shortcuts {
+					shortcuts = append(shortcuts, Shortcut{Nickname: nickname, UserID: userID})
+				}
+			}
+			sort.Slice(shortcuts, func(i, j int) bool { return shortcuts[i].Nickname < shortcuts[j].Nickname })
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{"shortcuts": shortcuts}}, nil
+		}).
+		Build()
+}
+
+// findUser looks up the user a display tag or user ID names with
+// search_users, requiring an exact match.
+func findUser(ctx context.Context, exec core.ToolExecutor, params *core.ToolParams, recipient string) (*executor.UserResult, error) {
+	recipient = strings.TrimSpace(recipient)
+	if recipient == "" {
+		return nil, fmt.Errorf("recipient is required")
+	}
+	input, _ := json.Marshal(map[string]string{"query": recipient})
+	resp, err := exec.Execute(ctx, &core.ExecuteRequest{
+		UserID:    params.UserID,
+		Tool:      "search_users",
+		Input:     input,
+		RequestID: params.RequestID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search_users failed: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("search_users failed: %s", resp.Error)
+	}
+	var found executor.SearchUsersResponse
+	if err := json.Unmarshal(resp.Data, &found); err != nil {
+		return nil, fmt.Errorf("failed to parse search_users response: %w", err)
+	}
+
+	tag := strings.TrimPrefix(recipient, "@")
+	for _, u := range found.Users {
+		if u.UserID == recipient || strings.EqualFold(strings.TrimPrefix(u.DisplayTag, "@"), tag) {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("no user has the display tag or ID %q; use search_users to find theirs", recipient)
+}
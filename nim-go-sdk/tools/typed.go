@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// SchemaFor derives the JSON Schema of a typed tool input, a struct whose
+// fields are the tool's parameters, so the schema the model sees and the
+// struct TypedHandler decodes can't drift apart. The json tag names each
+// parameter, and these tags describe it:
+//
+//	description:"Number of days to analyze"  the property description
+//	default:"30"                             the value when it's left out
+//	required:"true"                          the model must always pass it
+//	enum:"set,get"                           the allowed string values
+//	minimum:"1"                              the smallest allowed number
+//
+// For example:
+//
+//	type spendingInput struct {
+//		Days int `json:"days" description:"Number of days to analyze" default:"30" minimum:"1"`
+//	}
+//
+//	tools.New("analyze_spending").
+//		Schema(tools.SchemaFor[spendingInput]()).
+//		Handler(tools.TypedHandler(func(ctx context.Context, params *core.ToolParams, input spendingInput) (*core.ToolResult, error) {
+//			// input.Days is 30 if the model left it out
+//		}))
+func SchemaFor[T any]() map[string]interface{} {
+	return schemaOf(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// TypedHandler adapts fn to a core.ToolHandler that decodes the input into
+// T, a struct described as for SchemaFor. Input is first checked against
+// SchemaFor[T], so the model gets a consistent "invalid input" error naming
+// the field that failed, and parameters it left out take their default tag
+// values.
+func TypedHandler[T any](fn func(ctx context.Context, params *core.ToolParams, input T) (*core.ToolResult, error)) core.ToolHandler {
+	schema := SchemaFor[T]()
+	return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		raw := params.Input
+		if len(raw) == 0 {
+			raw = json.RawMessage(`{}`)
+		}
+		if err := core.ValidateInput(schema, raw); err != nil {
+			return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+		}
+
+		var input T
+		if err := applyDefaults(reflect.ValueOf(&input).Elem()); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &input); err != nil {
+			return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+		}
+		return fn(ctx, params, input)
+	}
+}
+
+// schemaOf maps a Go type to the JSON Schema encoding/json gives it.
+func schemaOf(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte, including json.RawMessage, holds any JSON.
+			return map[string]interface{}{}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object"}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		var required []string
+		addFields(t, properties, &required)
+		return ObjectSchema(properties, required...)
+	}
+	return map[string]interface{}{}
+}
+
+// addFields adds the properties of a struct's fields, flattening embedded
+// structs the way encoding/json does.
+func addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := jsonName(f)
+		if !ok {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addFields(f.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		property := schemaOf(f.Type)
+		if desc := f.Tag.Get("description"); desc != "" {
+			property["description"] = desc
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			property["enum"] = strings.Split(enum, ",")
+		}
+		if min := f.Tag.Get("minimum"); min != "" {
+			if n, err := strconv.ParseFloat(min, 64); err == nil {
+				property["minimum"] = n
+			}
+		}
+		if def, ok := f.Tag.Lookup("default"); ok {
+			v := reflect.New(f.Type).Elem()
+			if err := setDefault(v, def); err == nil {
+				property["default"] = v.Interface()
+			}
+		}
+		properties[name] = property
+		if f.Tag.Get("required") == "true" {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// jsonName returns a field's name in its json tag, if any, and whether it is
+// encoded at all.
+func jsonName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() && !f.Anonymous {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, true
+}
+
+// applyDefaults sets the fields of a struct that have a default tag.
+func applyDefaults(v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, ok := jsonName(f); !ok {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if err := applyDefaults(v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if def, ok := f.Tag.Lookup("default"); ok {
+			if err := setDefault(v.Field(i), def); err != nil {
+				return fmt.Errorf("invalid default for %s.%s: %w", t.Name(), f.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setDefault sets v from a default tag: strings as written, anything else
+// as JSON.
+func setDefault(v reflect.Value, def string) error {
+	if v.Kind() == reflect.String {
+		v.SetString(def)
+		return nil
+	}
+	return json.Unmarshal([]byte(def), v.Addr().Interface())
+}
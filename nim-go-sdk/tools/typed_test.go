@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+type period struct {
+	Days int `json:"days" description:"Number of days" default:"30" minimum:"1"`
+}
+
+type reportInput struct {
+	period
+	Account  string   `json:"account" description:"Account to report on" required:"true"`
+	Currency string   `json:"currency,omitempty" default:"USD" enum:"USD,EUR"`
+	Tags     []string `json:"tags"`
+	Internal string   `json:"-"`
+	note     string
+}
+
+func TestSchemaFor(t *testing.T) {
+	want := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"days":     map[string]interface{}{"type": "integer", "description": "Number of days", "default": 30, "minimum": 1.0},
+			"account":  map[string]interface{}{"type": "string", "description": "Account to report on"},
+			"currency": map[string]interface{}{"type": "string", "default": "USD", "enum": []string{"USD", "EUR"}},
+			"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		"required": []string{"account"},
+	}
+	if got := SchemaFor[reportInput](); !reflect.DeepEqual(got, want) {
+		t.Errorf("SchemaFor() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestTypedHandler(t *testing.T) {
+	var got reportInput
+	handler := TypedHandler(func(ctx context.Context, params *core.ToolParams, input reportInput) (*core.ToolResult, error) {
+		got = input
+		return &core.ToolResult{Success: true}, nil
+	})
+
+	tests := []struct {
+		name    string
+		input   string
+		want    reportInput
+		wantErr string
+	}{
+		{name: "defaults", input: `{"account":"main"}`, want: reportInput{period: period{Days: 30}, Account: "main", Currency: "USD"}},
+		{name: "all fields", input: `{"account":"main","days":7,"currency":"EUR","tags":["food"]}`, want: reportInput{period: period{Days: 7}, Account: "main", Currency: "EUR", Tags: []string{"food"}}},
+		{name: "missing required", input: `{"days":7}`, wantErr: "invalid input: missing required field 'account' (expected string)"},
+		{name: "wrong type", input: `{"account":"main","days":"7"}`, wantErr: "invalid input: field 'days' must be an integer, got string"},
+		{name: "below minimum", input: `{"account":"main","days":0}`, wantErr: "invalid input: field 'days' must be at least 1, got 0"},
+		{name: "not in enum", input: `{"account":"main","currency":"GBP"}`, wantErr: `invalid input: field 'currency' must be one of USD, EUR, got "GBP"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = reportInput{}
+			result, err := handler(context.Background(), &core.ToolParams{Input: json.RawMessage(tt.input)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" {
+				if result.Success || !strings.Contains(result.Error, tt.wantErr) {
+					t.Errorf("result = %+v, want error %q", result, tt.wantErr)
+				}
+				return
+			}
+			if !result.Success || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("handler got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// DefaultWebhookTimeout bounds a webhook tool's request unless its config
+// says otherwise.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// maxWebhookResponse bounds how much of a response a webhook tool reads.
+const maxWebhookResponse = 1 << 20
+
+// maxWebhookErrorBody is how much of a failed response's body is reported.
+const maxWebhookErrorBody = 500
+
+// redactedSecret replaces a webhook's secret wherever a response echoes it.
+const redactedSecret = "[redacted]"
+
+// WebhookConfig describes an external HTTP API to call as a tool, e.g. a
+// weather service or a CRM. See NewWebhookTool.
+type WebhookConfig struct {
+	Name        string
+	Description string
+	Schema      map[string]interface{}
+
+	// URL is a text/template of the request URL, executed with the input's
+	// top-level fields, e.g.
+	// "https://api.example.com/v1/forecast/{{.city}}?days={{.days}}".
+	// Values are URL-escaped before they're substituted, so input can't
+	// change the URL's structure; missing fields are empty.
+	URL string
+
+	// Method is the HTTP method. Defaults to GET.
+	Method string
+
+	// Headers are sent with every request.
+	Headers map[string]string
+
+	// SecretHeader, if set, is sent with the value of the environment
+	// variable SecretEnv, after SecretPrefix, e.g. "Authorization",
+	// "API_TOKEN" and "Bearer ". The secret is read when the tool is
+	// created and never appears in its results or errors, so it stays out
+	// of audit logs and the model's context.
+	SecretHeader string
+	SecretEnv    string
+	SecretPrefix string
+
+	// Body maps the fields of the JSON request body to the input fields
+	// they're copied from, e.g. {"email": "customer_email"}. Missing input
+	// fields are left out. If nil, methods other than GET, HEAD and DELETE
+	// send the whole input.
+	Body map[string]string
+
+	// Timeout bounds each request. Defaults to DefaultWebhookTimeout.
+	Timeout time.Duration
+
+	// Select projects a JSON response before it is returned, with a path
+	// like ".current.temp_c", ".items[0]" or ".items[].name", where "[]"
+	// maps the rest of the path over an array. Empty returns the whole
+	// response.
+	Select string
+
+	// Transform, if set, shapes the response body into the tool's result
+	// instead of Select.
+	Transform func(body []byte) (interface{}, error)
+
+	// Write marks the tool as changing state, so it requires confirmation,
+	// summarized with SummaryTemplate.
+	Write           bool
+	SummaryTemplate string
+
+	// NoCache stops the engine reusing a read's results, for APIs whose
+	// answers must always be fresh.
+	NoCache bool
+
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// WebhookError is a webhook tool's non-2xx response.
+type WebhookError struct {
+	StatusCode int    `json:"status"`
+	Body       string `json:"body,omitempty"` // truncated
+}
+
+func (e *WebhookError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("HTTP %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("HTTP %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// webhook is a tool calling an external HTTP API.
+type webhook struct {
+	cfg    WebhookConfig
+	url    *template.Template
+	path   []selector
+	secret string
+}
+
+// NewWebhookTool returns a tool that calls the HTTP API cfg describes and
+// returns its response, projected with cfg.Select or cfg.Transform. JSON
+// responses are returned as JSON, others as text. Non-2xx responses fail
+// the tool with a WebhookError, as do timeouts and network errors. It
+// fails if cfg's URL template or Select path doesn't parse, or its secret
+// isn't set.
+func NewWebhookTool(cfg WebhookConfig) (core.Tool, error) {
+	if cfg.Name == "" || cfg.URL == "" {
+		return nil, fmt.Errorf("webhook tool needs a name and URL")
+	}
+	tmpl, err := template.New(cfg.Name).Option("missingkey=zero").Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("webhook tool %s: invalid URL template: %w", cfg.Name, err)
+	}
+	path, err := parseSelect(cfg.Select)
+	if err != nil {
+		return nil, fmt.Errorf("webhook tool %s: invalid select %q: %w", cfg.Name, cfg.Select, err)
+	}
+	w := &webhook{cfg: cfg, url: tmpl, path: path}
+	if cfg.SecretHeader != "" {
+		w.secret = os.Getenv(cfg.SecretEnv)
+		if w.secret == "" {
+			return nil, fmt.Errorf("webhook tool %s: environment variable %q is not set", cfg.Name, cfg.SecretEnv)
+		}
+	}
+	if w.cfg.Method == "" {
+		w.cfg.Method = http.MethodGet
+	}
+	if w.cfg.Timeout <= 0 {
+		w.cfg.Timeout = DefaultWebhookTimeout
+	}
+	if w.cfg.Client == nil {
+		w.cfg.Client = http.DefaultClient
+	}
+	schema := cfg.Schema
+	if schema == nil {
+		schema = ObjectSchema(map[string]interface{}{})
+	}
+
+	return core.NewBaseTool(core.ToolDefinition{
+		ToolName:                 cfg.Name,
+		ToolDescription:          cfg.Description,
+		InputSchema:              schema,
+		RequiresUserConfirmation: cfg.Write,
+		SummaryTemplate:          cfg.SummaryTemplate,
+		NoCache:                  cfg.NoCache,
+	}, w.handle), nil
+}
+
+func (w *webhook) handle(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+	data, err := w.call(ctx, params.Input)
+	if err != nil {
+		result := &core.ToolResult{Success: false, Error: w.redact(err.Error())}
+		if webhookErr, ok := err.(*WebhookError); ok {
+			webhookErr.Body = w.redact(webhookErr.Body)
+			result.Data = webhookErr
+			result.Metadata = map[string]interface{}{"status": webhookErr.StatusCode}
+		}
+		return result, nil
+	}
+	return &core.ToolResult{Success: true, Data: data}, nil
+}
+
+// call sends the request for input and shapes its response.
+func (w *webhook) call(ctx context.Context, input json.RawMessage) (interface{}, error) {
+	var fields map[string]json.RawMessage
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &fields); err != nil {
+			return nil, fmt.Errorf("invalid input: %w", err)
+		}
+	}
+
+	var target strings.Builder
+	if err := w.url.Execute(&target, urlValues(fields)); err != nil {
+		return nil, fmt.Errorf("failed to build URL: %w", err)
+	}
+	body, err := w.body(input, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, w.cfg.Method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for name, value := range w.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	if w.secret != "" {
+		req.Header.Set(w.cfg.SecretHeader, w.cfg.SecretPrefix+w.secret)
+	}
+
+	resp, err := w.cfg.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &WebhookError{StatusCode: resp.StatusCode, Body: truncateBody(respBody)}
+	}
+
+	if w.cfg.Transform != nil {
+		data, err := w.cfg.Transform(respBody)
+		if err != nil {
+			return nil, err
+		}
+		return w.redactResult(data)
+	}
+	var v interface{}
+	if err := json.Unmarshal(respBody, &v); err != nil {
+		if len(w.path) > 0 {
+			return nil, fmt.Errorf("response isn't JSON, so can't be selected from")
+		}
+		return w.redact(string(respBody)), nil
+	}
+	selected, err := selectPath(v, w.path)
+	if err != nil {
+		return nil, err
+	}
+	return w.redactValue(selected), nil
+}
+
+// body returns the JSON request body for input, or nil if there is none.
+func (w *webhook) body(input json.RawMessage, fields map[string]json.RawMessage) (io.Reader, error) {
+	if w.cfg.Body == nil {
+		switch w.cfg.Method {
+		case http.MethodGet, http.MethodHead, http.MethodDelete:
+			return nil, nil
+		}
+		if len(input) == 0 {
+			input = json.RawMessage("{}")
+		}
+		return bytes.NewReader(input), nil
+	}
+	mapped := make(map[string]json.RawMessage, len(w.cfg.Body))
+	for key, field := range w.cfg.Body {
+		if value, ok := fields[field]; ok {
+			mapped[key] = value
+		}
+	}
+	data, err := json.Marshal(mapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode body: %w", err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+// redact hides the secret in s.
+func (w *webhook) redact(s string) string {
+	if w.secret == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, w.secret, redactedSecret)
+}
+
+// redactValue hides the secret in the strings, and object keys, of a
+// decoded JSON value.
+func (w *webhook) redactValue(v interface{}) interface{} {
+	if w.secret == "" {
+		return v
+	}
+	switch v := v.(type) {
+	case string:
+		return w.redact(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = w.redactValue(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[w.redact(key)] = w.redactValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// redactResult hides the secret in a Transform result. A result that
+// contains it is returned as its redacted JSON values.
+func (w *webhook) redactResult(data interface{}) (interface{}, error) {
+	if w.secret == "" {
+		return data, nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	if redacted := w.redactValue(v); !reflect.DeepEqual(redacted, v) {
+		return redacted, nil
+	}
+	return data, nil
+}
+
+// urlValues returns input's top-level fields URL-escaped: strings as they
+// are, other values as JSON.
+func urlValues(fields map[string]json.RawMessage) map[string]string {
+	values := make(map[string]string, len(fields))
+	for name, raw := range fields {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			s = string(raw)
+		}
+		// QueryEscape escapes everything reserved in paths and queries;
+		// %20 is a space in both, where + is only one in queries.
+		values[name] = strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+	}
+	return values
+}
+
+// truncateBody shortens a failed response's body for its error.
+func truncateBody(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) > maxWebhookErrorBody {
+		s = s[:maxWebhookErrorBody] + "..."
+	}
+	return s
+}
+
+// selector is a step of a Select path: a key, an index, or "[]" to map
+// the rest of the path over an array.
+type selector struct {
+	key   string
+	index int
+	kind  byte // 'k', 'i' or 'm'
+}
+
+// parseSelect parses a Select path, e.g. ".items[].name".
+func parseSelect(path string) ([]selector, error) {
+	if path == "" || path == "." {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "[") {
+		return nil, fmt.Errorf("must start with . or [")
+	}
+	var steps []selector
+	for rest := path; rest != ""; {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key")
+			}
+			steps = append(steps, selector{key: rest[:end], kind: 'k'})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [")
+			}
+			if end == 1 {
+				steps = append(steps, selector{kind: 'm'})
+			} else {
+				i, err := strconv.Atoi(rest[1:end])
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q", rest[1:end])
+				}
+				steps = append(steps, selector{index: i, kind: 'i'})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest[0])
+		}
+	}
+	return steps, nil
+}
+
+// selectPath follows path through v, a decoded JSON value. Missing keys
+// and indexes select null.
+func selectPath(v interface{}, path []selector) (interface{}, error) {
+	for i, step := range path {
+		switch step.kind {
+		case 'k':
+			obj, ok := v.(map[string]interface{})
+			if !ok && v != nil {
+				return nil, fmt.Errorf("can't select %q from a non-object", step.key)
+			}
+			v = obj[step.key]
+		case 'i':
+			arr, ok := v.([]interface{})
+			if !ok && v != nil {
+				return nil, fmt.Errorf("can't index a non-array")
+			}
+			if step.index < 0 {
+				step.index += len(arr)
+			}
+			if step.index < 0 || step.index >= len(arr) {
+				v = nil
+				continue
+			}
+			v = arr[step.index]
+		case 'm':
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("can't map over a non-array")
+			}
+			mapped := make([]interface{}, len(arr))
+			for j, e := range arr {
+				selected, err := selectPath(e, path[i+1:])
+				if err != nil {
+					return nil, err
+				}
+				mapped[j] = selected
+			}
+			return mapped, nil
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,261 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestWebhookTool_GetWithTemplatedQuery(t *testing.T) {
+	var gotPath, gotQuery, gotHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotHeader = r.URL.EscapedPath(), r.URL.RawQuery, r.Header.Get("X-Client")
+		w.Write([]byte(`{"city":"New York","days":[{"temp_c":21},{"temp_c":18}]}`))
+	}))
+	defer srv.Close()
+
+	tool, err := NewWebhookTool(WebhookConfig{
+		Name:        "get_forecast",
+		Description: "Get the weather forecast for a city",
+		Schema: ObjectSchema(map[string]interface{}{
+			"city": StringProperty("City name"),
+			"days": IntegerProperty("Days to forecast"),
+		}, "city"),
+		URL:     srv.URL + "/forecast/{{.city}}?days={{.days}}&q={{.city}}",
+		Headers: map[string]string{"X-Client": "nim"},
+		Select:  ".days[].temp_c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tool.RequiresConfirmation() {
+		t.Error("read webhook requires confirmation")
+	}
+
+	result, err := tool.Execute(context.Background(), &core.ToolParams{Input: json.RawMessage(`{"city":"New York/?x","days":2}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("Execute() = %+v, %v", result, err)
+	}
+	if want := []interface{}{21.0, 18.0}; !reflect.DeepEqual(result.Data, want) {
+		t.Errorf("data = %v, want %v", result.Data, want)
+	}
+	if gotPath != "/forecast/New%20York%2F%3Fx" || gotQuery != "days=2&q=New%20York%2F%3Fx" {
+		t.Errorf("requested %s?%s, want the city escaped", gotPath, gotQuery)
+	}
+	if gotHeader != "nim" {
+		t.Errorf("X-Client = %q, want nim", gotHeader)
+	}
+}
+
+func TestWebhookTool_PostWithBodyMapping(t *testing.T) {
+	t.Setenv("CRM_TOKEN", "s3cret")
+	var gotMethod, gotAuth string
+	var gotBody map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotAuth = r.Method, r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"lead":{"id":"lead_1","status":"new"}}`))
+	}))
+	defer srv.Close()
+
+	tool, err := NewWebhookTool(WebhookConfig{
+		Name:         "create_lead",
+		Description:  "Create a CRM lead",
+		URL:          srv.URL + "/leads",
+		Method:       http.MethodPost,
+		SecretHeader: "Authorization",
+		SecretEnv:    "CRM_TOKEN",
+		SecretPrefix: "Bearer ",
+		Body:         map[string]string{"email": "customer_email", "name": "customer_name", "source": "source"},
+		Select:       ".lead.id",
+		Write:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tool.RequiresConfirmation() {
+		t.Error("write webhook doesn't require confirmation")
+	}
+
+	result, err := tool.Execute(context.Background(), &core.ToolParams{Input: json.RawMessage(`{"customer_email":"a@example.com","customer_name":"Ada","note":"ignored"}`)})
+	if err != nil || !result.Success || result.Data != "lead_1" {
+		t.Fatalf("Execute() = %+v, %v; want lead_1", result, err)
+	}
+	if gotMethod != http.MethodPost || gotAuth != "Bearer s3cret" {
+		t.Errorf("request = %s with Authorization %q", gotMethod, gotAuth)
+	}
+	if want := map[string]interface{}{"email": "a@example.com", "name": "Ada"}; !reflect.DeepEqual(gotBody, want) {
+		t.Errorf("body = %v, want %v", gotBody, want)
+	}
+
+	if _, err := NewWebhookTool(WebhookConfig{Name: "x", URL: srv.URL, SecretHeader: "Authorization", SecretEnv: "UNSET_WEBHOOK_TOKEN"}); err == nil {
+		t.Error("NewWebhookTool() with an unset secret succeeded")
+	}
+}
+
+func TestWebhookTool_Errors(t *testing.T) {
+	t.Setenv("CRM_TOKEN", "s3cret")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/text" {
+			w.Write([]byte("pong"))
+			return
+		}
+		// A misbehaving API echoing the credential it rejected.
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"error":"bad token `+strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")+`"}`+strings.Repeat(" ", 1000)+"tail")
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		path      string
+		selector  string
+		wantData  interface{}
+		wantErr   string
+		wantState int
+	}{
+		{name: "text response", path: "/text", wantData: "pong"},
+		{name: "non-2xx", path: "/leads", wantErr: `HTTP 401 Unauthorized: {"error":"bad token [redacted]"}`, wantState: http.StatusUnauthorized},
+		{name: "select from text", path: "/text", selector: ".x", wantErr: "response isn't JSON, so can't be selected from"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool, err := NewWebhookTool(WebhookConfig{
+				Name:         "crm",
+				URL:          srv.URL + tt.path,
+				SecretHeader: "Authorization",
+				SecretEnv:    "CRM_TOKEN",
+				SecretPrefix: "Bearer ",
+				Select:       tt.selector,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := tool.Execute(context.Background(), &core.ToolParams{Input: json.RawMessage(`{}`)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr == "" {
+				if !result.Success || result.Data != tt.wantData {
+					t.Errorf("Execute() = %+v, want %v", result, tt.wantData)
+				}
+				return
+			}
+			if result.Success || !strings.HasPrefix(result.Error, tt.wantErr) {
+				t.Errorf("error = %q, want prefix %q", result.Error, tt.wantErr)
+			}
+			if strings.Contains(result.Error, "s3cret") || strings.Contains(result.Error, "tail") {
+				t.Errorf("error %q leaks the secret or isn't truncated", result.Error)
+			}
+			if tt.wantState == 0 {
+				return
+			}
+			webhookErr, ok := result.Data.(*WebhookError)
+			if !ok || webhookErr.StatusCode != tt.wantState || strings.Contains(webhookErr.Body, "s3cret") {
+				t.Errorf("data = %+v, want a redacted WebhookError with status %d", result.Data, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestWebhookTool_RedactsJSON(t *testing.T) {
+	t.Setenv("CRM_TOKEN", "s3cret")
+	// An auth-debug endpoint echoing the credential it was sent.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth":    map[string]string{"token": token, "scheme": "bearer"},
+			"seen":    []string{"Bearer " + token},
+			token:     true,
+			"expires": 3600,
+		})
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		selector  string
+		transform func(body []byte) (interface{}, error)
+	}{
+		{name: "whole response"},
+		{name: "selected", selector: ".seen[0]"},
+		{name: "transformed", transform: func(body []byte) (interface{}, error) {
+			var v struct {
+				Auth struct {
+					Token string `json:"token"`
+				} `json:"auth"`
+			}
+			err := json.Unmarshal(body, &v)
+			return v, err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool, err := NewWebhookTool(WebhookConfig{
+				Name:         "whoami",
+				URL:          srv.URL,
+				SecretHeader: "Authorization",
+				SecretEnv:    "CRM_TOKEN",
+				SecretPrefix: "Bearer ",
+				Select:       tt.selector,
+				Transform:    tt.transform,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := tool.Execute(context.Background(), &core.ToolParams{Input: json.RawMessage(`{}`)})
+			if err != nil || !result.Success {
+				t.Fatalf("Execute() = %+v, %v", result, err)
+			}
+			data, _ := json.Marshal(result.Data)
+			if strings.Contains(string(data), "s3cret") || !strings.Contains(string(data), redactedSecret) {
+				t.Errorf("data = %s, want the secret redacted", data)
+			}
+		})
+	}
+}
+
+func TestParseSelect(t *testing.T) {
+	v := map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"name": "a"},
+		map[string]interface{}{"name": "b"},
+	}}
+	tests := []struct {
+		path    string
+		want    interface{}
+		wantErr bool
+	}{
+		{path: ".", want: v},
+		{path: ".items[1].name", want: "b"},
+		{path: ".items[-1].name", want: "b"},
+		{path: ".items[].name", want: []interface{}{"a", "b"}},
+		{path: ".missing.name", want: nil},
+		{path: ".items[5]", want: nil},
+		{path: "items", wantErr: true},
+		{path: ".items[x]", wantErr: true},
+		{path: ".items[", wantErr: true},
+	}
+	for _, tt := range tests {
+		path, err := parseSelect(tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseSelect(%q) succeeded, want an error", tt.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("parseSelect(%q): %v", tt.path, err)
+		}
+		if got, err := selectPath(v, path); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("select %q = %v, %v; want %v", tt.path, got, err, tt.want)
+		}
+	}
+}